@@ -17,6 +17,7 @@ import (
 	lauthor "github.com/drausin/libri/libri/author"
 	"github.com/drausin/libri/libri/author/io/common"
 	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/drausin/libri/libri/common/id"
 	clogging "github.com/drausin/libri/libri/common/logging"
@@ -331,7 +332,7 @@ func setUp(rng *rand.Rand, nSeeds, nPeers int, logLevel zapcore.Level) (
 
 	// create keychains for author
 	err := lauthor.CreateKeychains(logger, authorConfig.KeychainDir, authorKeychainAuth,
-		veryLightScryptN, veryLightScryptP)
+		authorConfig.Keychain)
 	if err != nil {
 		panic(err)
 	}
@@ -436,6 +437,11 @@ func newConfigs(nSeeds, nPeers int, maxBucketPeers uint, logLevel zapcore.Level)
 		WithDataDir(dataDir).
 		WithDefaultDBDir().
 		WithDefaultKeychainDir().
+		WithKeychain(&keychain.Parameters{
+			NKeys:   keychain.DefaultNKeys,
+			ScryptN: veryLightScryptN,
+			ScryptP: veryLightScryptP,
+		}).
 		WithLogLevel(logLevel)
 	authorConfig.Publish.PutTimeout = 10 * time.Second
 	page.MinSize = 128 // just for testing
@@ -1,8 +1,12 @@
 package author
 
 import (
-	"io"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"time"
+
 	"github.com/drausin/libri/libri/author/io/enc"
 	"github.com/drausin/libri/libri/author/io/pack"
 	"github.com/drausin/libri/libri/author/io/page"
@@ -15,11 +19,10 @@ import (
 	"github.com/drausin/libri/libri/common/storage"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/drausin/libri/libri/librarian/client"
-	healthpb "google.golang.org/grpc/health/grpc_health_v1"
-	"go.uber.org/zap"
-	"time"
-	"golang.org/x/net/context"
 	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 const (
@@ -39,9 +42,9 @@ const (
 	LoggerNPages = "n_pages"
 )
 
-var (
-	healthcheckTimeout = 2 * time.Second
-)
+// ErrKeySignerWithSharedSecret indicates when the author is configured to sign requests with both
+// a key signer and a shared secret.
+var ErrKeySignerWithSharedSecret = errors.New("cannot use both a key signer and a shared secret")
 
 // Author is the main client of the libri network. It can upload, download, and share documents with
 // other author clients.
@@ -69,14 +72,35 @@ type Author struct {
 	// SL for client data
 	clientSL storage.NamespaceStorerLoader
 
+	// SLI for the local index of uploaded documents
+	uploadSL storage.NamespaceStorerLoaderIterator
+
+	// SLI for the checkpoints of incomplete uploads
+	checkpointSL storage.NamespaceStorerLoaderIteratorDeleter
+
+	// records the keys used by uploaded and shared envelopes
+	keyLedger KeyLedger
+
 	// SL for locally stored documents
 	documentSL storage.DocumentStorerLoader
 
+	// iterates over the keys of locally stored documents
+	documentIter storage.DocumentIterator
+
 	// load balancer for librarian clients
 	librarians api.ClientBalancer
 
-	// librarian address -> health check client for all librarians
-	librarianHealths map[string]healthpb.HealthClient
+	// periodically checks the health of all librarians
+	health *healthMonitor
+
+	// shares connections to librarians and the peers they return among individual queries
+	conns client.ConnManager
+
+	// connectors to each of the librarians, for querying them individually
+	librarianConns []api.Connector
+
+	// issues Find queries to individual librarians
+	finder client.FindQuerier
 
 	// creates entry documents from raw content
 	entryPacker pack.EntryPacker
@@ -86,8 +110,17 @@ type Author struct {
 	// publishes documents to libri
 	shipper ship.Shipper
 
+	// publishes individual documents, like shared envelopes, to libri
+	publisher publish.Publisher
+
+	// acquires individual documents, like existing envelopes, from libri
+	acquirer publish.Acquirer
+
 	receiver ship.Receiver
 
+	// acquires and stores individual pages while streaming downloads
+	ssAcquirer publish.SingleStoreAcquirer
+
 	// stores Pages in chan to local storage
 	pageSL page.StorerLoader
 
@@ -109,16 +142,38 @@ func NewAuthor(
 	selfReaderKeys keychain.Keychain,
 	logger *zap.Logger) (*Author, error) {
 
+	if config.KeySigner != nil && config.SharedSecret != nil {
+		return nil, ErrKeySignerWithSharedSecret
+	}
 	rdb, err := db.NewRocksDB(config.DbDir)
 	if err != nil {
 		logger.Error("unable to init RocksDB", zap.Error(err))
 		return nil, err
 	}
 	clientSL := storage.NewClientKVDBStorerLoader(rdb)
-	documentSL := storage.NewDocumentKVDBStorerLoader(rdb)
+	documentSLI := storage.NewDocumentKVDBStorerLoader(rdb)
+	documentSL, err := storage.NewPageCacheDocumentStorerLoader(documentSLI, config.PageCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientID ecid.ID
+	if config.KeySigner != nil {
+		clientID, err = loadKeySignerClientID(logger, config.KeySigner)
+	} else {
+		// get client ID and immediately save it so subsequent restarts have it
+		clientID, err = loadOrCreateClientID(logger, clientSL, config.ClientIDCurve)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	// get client ID and immediately save it so subsequent restarts have it
-	clientID, err := loadOrCreateClientID(logger, clientSL)
+	ledgerKey, err := loadOrCreateLedgerKey(clientSL, authorKeys, selfReaderKeys)
+	if err != nil {
+		logger.Error("unable to load key ledger key", zap.Error(err))
+		return nil, err
+	}
+	keyLedger, err := NewKeyLedger(storage.NewKeyLedgerKVDBStorerLoader(rdb), ledgerKey)
 	if err != nil {
 		return nil, err
 	}
@@ -127,23 +182,56 @@ func NewAuthor(
 		authorKeys:     authorKeys,
 		selfReaderKeys: selfReaderKeys,
 	}
-	librarians, err := api.NewUniformRandomClientBalancer(config.LibrarianAddrs)
+	librarianTLS, err := config.librarianTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	breaker := client.NewDefaultCircuitBreaker()
+	librarians, err := api.NewWeightedClientBalancer(config.LibrarianAddrs, librarianTLS,
+		config.ClientInterceptors, func(addr *net.TCPAddr) grpc.UnaryClientInterceptor {
+			return client.NewBreakerUnaryInterceptor(breaker, addr)
+		})
 	if err != nil {
 		return nil, err
 	}
-	librarianHealths, err := getLibrarianHealthClients(config.LibrarianAddrs)
+	librarianHealths, err := getLibrarianHealthClients(config.LibrarianAddrs, librarianTLS)
 	if err != nil {
 		return nil, err
 	}
-	signer := client.NewSigner(clientID.Key())
+	health := newHealthMonitor(librarianHealths, librarians, config.HealthcheckInterval,
+		config.Timeouts.Ping, config.UploadQuorum, logger)
+	conns := client.NewSecureConnManager(client.DefaultConnIdleTimeout, librarianTLS,
+		config.ClientInterceptors)
+	finder := client.NewSharedFindQuerier(client.NewQueriers(config.Timeouts).Find, conns)
+	finder = client.NewBreakerFindQuerier(finder, breaker)
+	if config.QueryObserver != nil {
+		finder = client.NewObservedFindQuerier(finder, config.QueryObserver)
+	}
+	librarianConns := make([]api.Connector, len(config.LibrarianAddrs))
+	for i, librarianAddr := range config.LibrarianAddrs {
+		librarianConns[i] = conns.Connector(librarianAddr)
+	}
+	signer := client.NewIDSigner(clientID)
+	if config.KeySigner != nil {
+		if signer, err = client.NewKeySigner(config.KeySigner); err != nil {
+			return nil, err
+		}
+	}
+	if config.SharedSecret != nil {
+		if signer, err = client.NewHMACSigner(config.SharedSecret); err != nil {
+			return nil, err
+		}
+	}
 
-	publisher := publish.NewPublisher(clientID, signer, config.Publish)
-	acquirer := publish.NewAcquirer(clientID, signer, config.Publish)
+	publisher := publish.NewRetryPublisher(publish.NewPublisher(clientID, signer, config.Publish),
+		librarians, config.RetryPolicy)
+	acquirer := publish.NewRetryAcquirer(publish.NewAcquirer(clientID, signer, config.Publish),
+		librarians, config.RetryPolicy)
 	slPublisher := publish.NewSingleLoadPublisher(publisher, documentSL)
-	ssAcquirer := publish.NewSingleStoreAcquirer(acquirer, documentSL)
-	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, config.Publish)
+	ssAcquirer := publish.NewCheckpointSingleStoreAcquirer(
+		publish.NewSingleStoreAcquirer(acquirer, documentSL), documentSL)
 	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, config.Publish)
-	shipper := ship.NewShipper(librarians, publisher, mlPublisher)
+	shipper := newCheckpointShipper(librarians, publisher, slPublisher, config.Publish, rdb)
 	receiver := ship.NewReceiver(librarians, selfReaderKeys, acquirer, msAcquirer, documentSL)
 
 	mdEncDec := enc.NewMetadataEncrypterDecrypter()
@@ -151,28 +239,39 @@ func NewAuthor(
 	entryUnpacker := pack.NewEntryUnpacker(config.Print, mdEncDec, documentSL)
 
 	author := &Author{
-		clientID:         clientID,
-		config:           config,
-		authorKeys:       authorKeys,
-		selfReaderKeys:   selfReaderKeys,
-		envelopeKeys:     envelopeKeys,
-		db:               rdb,
-		clientSL:         clientSL,
-		documentSL:       documentSL,
-		librarians:       librarians,
-		librarianHealths: librarianHealths,
-		entryPacker:      entryPacker,
-		entryUnpacker:    entryUnpacker,
-		shipper:          shipper,
-		receiver:         receiver,
-		pageSL:           page.NewStorerLoader(documentSL),
-		signer:           signer,
-		logger:           logger,
-		stop:             make(chan struct{}),
+		clientID:       clientID,
+		config:         config,
+		authorKeys:     authorKeys,
+		selfReaderKeys: selfReaderKeys,
+		envelopeKeys:   envelopeKeys,
+		db:             rdb,
+		clientSL:       clientSL,
+		uploadSL:       storage.NewUploadsKVDBStorerLoader(rdb),
+		checkpointSL:   storage.NewCheckpointKVDBStorerLoader(rdb),
+		keyLedger:      keyLedger,
+		documentSL:     documentSL,
+		documentIter:   documentSLI,
+		librarians:     librarians,
+		health:         health,
+		conns:          conns,
+		librarianConns: librarianConns,
+		finder:         finder,
+		entryPacker:    entryPacker,
+		entryUnpacker:  entryUnpacker,
+		shipper:        shipper,
+		publisher:      publisher,
+		acquirer:       acquirer,
+		receiver:       receiver,
+		ssAcquirer:     ssAcquirer,
+		pageSL:         page.NewStorerLoader(documentSL),
+		signer:         signer,
+		logger:         logger,
+		stop:           make(chan struct{}),
 	}
 
 	// for now, this doesn't really do anything
 	go func() { <-author.stop }()
+	health.start()
 
 	return author, nil
 }
@@ -181,16 +280,13 @@ func NewAuthor(
 // - Share()
 // - Subscribe()
 
-// Healthcheck executes and reports healthcheck status for all connected librarians.
+// Healthcheck executes and reports healthcheck status for all connected librarians, also updating
+// the statuses tracked by the author's periodic healthchecks.
 func (a *Author) Healthcheck() (bool, map[string]healthpb.HealthCheckResponse_ServingStatus) {
-	healthStatus := make(map[string]healthpb.HealthCheckResponse_ServingStatus)
+	healthStatus := a.health.check()
 	allHealthy := true
-	for addrStr, healthClient := range a.librarianHealths {
-		ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
-		rp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
-		cancel()
-		if err != nil {
-			healthStatus[addrStr] = healthpb.HealthCheckResponse_UNKNOWN
+	for addrStr, status := range healthStatus {
+		if status == healthpb.HealthCheckResponse_UNKNOWN {
 			allHealthy = false
 			a.logger.Info("librarian peer is not reachable",
 				zap.String("peer_address", addrStr),
@@ -198,8 +294,7 @@ func (a *Author) Healthcheck() (bool, map[string]healthpb.HealthCheckResponse_Se
 			continue
 		}
 
-		healthStatus[addrStr] = rp.Status
-		if rp.Status == healthpb.HealthCheckResponse_SERVING {
+		if status == healthpb.HealthCheckResponse_SERVING {
 			a.logger.Info("librarian peer is healthy",
 				zap.String("peer_address", addrStr),
 			)
@@ -216,14 +311,55 @@ func (a *Author) Healthcheck() (bool, map[string]healthpb.HealthCheckResponse_Se
 }
 
 // Upload compresses, encrypts, and splits the content into pages and then stores them in the
-// libri network. It returns the uploaded envelope for self-storage and its key.
+// libri network. It returns the uploaded envelope for self-storage and its key. If shipping fails,
+// the upload can be resumed with ResumeUpload. If the media type is empty, it is detected from the
+// content.
 func (a *Author) Upload(content io.Reader, mediaType string) (*api.Document, id.ID, error) {
-	startTime := time.Now()
 	authorPub, readerPub, keys, err := a.envelopeKeys.sample()
 	if err != nil {
 		return nil, nil, err
 	}
+	return a.upload(content, mediaType, authorPub, readerPub, keys)
+}
 
+// UploadRevision uploads the content like Upload but with the same author and self-reader keys as
+// the previously uploaded envelope with the given key, e.g., for a new version of a document.
+// Since the entry keys are the same, when the Print parameters use page.ContentDefinedChunking,
+// the pages of the content's unmodified parts are the same as the previous version's and aren't
+// published again.
+func (a *Author) UploadRevision(content io.Reader, mediaType string, prevEnvelopeKey id.ID) (
+	*api.Document, id.ID, error) {
+	lc, err := a.librarians.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	prevEnvelope, err := a.acquirer.Acquire(prevEnvelopeKey, nil, lc)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorPub, readerPub, _, err := pack.SeparateEnvelopeDoc(prevEnvelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return nil, nil, ErrNotEnvelopeAuthor
+	}
+	selfReaderID, in := a.selfReaderKeys.Get(readerPub)
+	if !in {
+		return nil, nil, keychain.ErrUnexpectedMissingKey
+	}
+	keys, err := enc.NewKeys(authorID.Key(), &selfReaderID.Key().PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.upload(content, mediaType, authorPub, readerPub, keys)
+}
+
+func (a *Author) upload(
+	content io.Reader, mediaType string, authorPub, readerPub []byte, keys *enc.Keys,
+) (*api.Document, id.ID, error) {
+	startTime := time.Now()
 	a.logger.Debug("packing content",
 		zap.String(LoggerAuthorPub, fmt.Sprintf("%065x", authorPub)),
 	)
@@ -231,12 +367,28 @@ func (a *Author) Upload(content io.Reader, mediaType string) (*api.Document, id.
 	if err != nil {
 		return nil, nil, err
 	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return nil, nil, keychain.ErrUnexpectedMissingKey
+	}
+	if err := client.SignEntry(authorID, entry.GetEntry()); err != nil {
+		return nil, nil, err
+	}
 
 	a.logger.Debug("shipping entry",
 		zap.String(LoggerAuthorPub, fmt.Sprintf("%065x", authorPub)),
 		zap.String(LoggerReaderPub, fmt.Sprintf("%065x", readerPub)),
 	)
-	envelope, envelopeKey, err := a.shipper.Ship(entry, authorPub, readerPub)
+	var attrs *api.EntryAttributes
+	if a.config.DiscloseEntryAttributes {
+		attrs = metadata.GetEntryAttributes()
+	}
+	cp, err := newUploadCheckpoint(a.checkpointSL, a.documentSL, entry, authorPub, readerPub,
+		attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	envelope, envelopeKey, err := a.shipCheckpointed(entry, metadata, cp)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -248,7 +400,7 @@ func (a *Author) Upload(content io.Reader, mediaType string) (*api.Document, id.
 	entryKeyBytes := envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey
 	uncompressedSize, _ := metadata.GetUncompressedSize()
 	ciphertextSize, _ := metadata.GetCiphertextSize()
-	speedMbps := float32(uncompressedSize) / float32(elapsedTime.Seconds()) / float32(2 << 20)
+	speedMbps := float32(uncompressedSize) / float32(elapsedTime.Seconds()) / float32(2<<20)
 	a.logger.Info("successfully uploaded document",
 		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
 		zap.String(LoggerEntryKey, id.FromBytes(entryKeyBytes).String()),
@@ -297,6 +449,83 @@ func (a *Author) Download(content io.Writer, envelopeKey id.ID) error {
 	return nil
 }
 
+// DownloadRange downloads the content like Download but only writes the length bytes starting at
+// the offset to the content writer, or all bytes after the offset if length is zero. Only the
+// pages containing those bytes are acquired unless the content was compressed as a whole or
+// split into content-defined chunks, in which case all pages are. Since pages already in local
+// storage aren't downloaded again, an interrupted download can be resumed from the number of
+// bytes already written.
+func (a *Author) DownloadRange(content io.Writer, envelopeKey id.ID, offset, length uint64) error {
+	a.logger.Debug("receiving entry", zap.String(LoggerEnvelopeKey, envelopeKey.String()))
+	entry, keys, err := a.receiver.ReceiveEntry(envelopeKey)
+	if err != nil {
+		return err
+	}
+	entryKey, nPages, err := getEntryInfo(entry)
+	if err != nil {
+		return err
+	}
+
+	// acquire each page as it's scanned, so pages outside the range are never acquired
+	docSL := publish.NewAcquiringDocumentStorerLoader(a.documentSL, a.ssAcquirer,
+		api.GetAuthorPub(entry), a.librarians)
+	unpacker := pack.NewEntryUnpacker(a.config.Print, enc.NewMetadataEncrypterDecrypter(),
+		docSL)
+	a.logger.Debug("unpacking content range",
+		zap.String(LoggerEntryKey, entryKey.String()),
+		zap.Int(LoggerNPages, nPages),
+		zap.Uint64("offset", offset),
+		zap.Uint64("length", length),
+	)
+	if _, err := unpacker.UnpackRange(content, entry, keys, offset, length); err != nil {
+		return err
+	}
+	a.logger.Info("successfully downloaded document range",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+		zap.String(LoggerEntryKey, entryKey.String()),
+		zap.Uint64("offset", offset),
+		zap.Uint64("length", length),
+	)
+	return nil
+}
+
+// DownloadStream returns an io.ReadCloser that streams the decrypted and decompressed content as
+// each page is downloaded rather than after all have been. Since the content's MACs can only be
+// checked once all of it has been read, the content should not be trusted until a Read returns
+// io.EOF. Closing the reader before then stops the download.
+func (a *Author) DownloadStream(envelopeKey id.ID) (io.ReadCloser, error) {
+	a.logger.Debug("receiving entry", zap.String(LoggerEnvelopeKey, envelopeKey.String()))
+	entry, keys, err := a.receiver.ReceiveEntry(envelopeKey)
+	if err != nil {
+		return nil, err
+	}
+	entryKey, nPages, err := getEntryInfo(entry)
+	if err != nil {
+		return nil, err
+	}
+	docSL := publish.NewAcquiringDocumentStorerLoader(a.documentSL, a.ssAcquirer,
+		api.GetAuthorPub(entry), a.librarians)
+	unpacker := pack.NewEntryUnpacker(a.config.Print, enc.NewMetadataEncrypterDecrypter(),
+		docSL)
+
+	a.logger.Debug("streaming content",
+		zap.String(LoggerEntryKey, entryKey.String()),
+		zap.Int(LoggerNPages, nPages),
+	)
+	content, contentW := io.Pipe()
+	go func() {
+		_, err := unpacker.Unpack(contentW, entry, keys)
+		if err != nil {
+			a.logger.Error("unable to stream document",
+				zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+				zap.Error(err),
+			)
+		}
+		contentW.CloseWithError(err)
+	}()
+	return content, nil
+}
+
 func getEntryInfo(entry *api.Document) (id.ID, int, error) {
 	entryKey, err := api.GetKey(entry)
 	if err != nil {
@@ -2,6 +2,7 @@ package author
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,12 +12,15 @@ import (
 	"errors"
 	"github.com/drausin/libri/libri/author/io/common"
 	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/io/pack"
 	"github.com/drausin/libri/libri/author/io/page"
 	"github.com/drausin/libri/libri/author/io/publish"
 	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/drausin/libri/libri/common/id"
 	clogging "github.com/drausin/libri/libri/common/logging"
 	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
@@ -33,7 +37,7 @@ const (
 func TestNewAuthor(t *testing.T) {
 	// return empty map of health clients
 	orig := getLibrarianHealthClients
-	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr) (
+	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config) (
 		map[string]healthpb.HealthClient, error) {
 		return make(map[string]healthpb.HealthClient), nil
 	}
@@ -58,10 +62,69 @@ func TestNewAuthor(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestNewAuthor_keySigner(t *testing.T) {
+	orig := getLibrarianHealthClients
+	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config) (
+		map[string]healthpb.HealthClient, error) {
+		return make(map[string]healthpb.HealthClient), nil
+	}
+	defer func() { getLibrarianHealthClients = orig }()
+
+	rng := rand.New(rand.NewSource(0))
+	clientID := ecid.NewPseudoRandom(rng)
+	nKeys := keychain.DefaultNKeys
+	authorKeys, selfReaderKeys := keychain.New(nKeys), keychain.New(nKeys)
+	config := newTestConfig().WithKeySigner(clientID.Key())
+	a, err := NewAuthor(config, authorKeys, selfReaderKeys, clogging.NewDevInfoLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, clientID.ID(), a.clientID.ID())
+	assert.Nil(t, a.clientID.Key().D)
+
+	// check requests are signed by the key signer
+	rq := client.NewFindRequest(a.clientID, id.NewPseudoRandom(rng), 20)
+	encToken, err := a.signer.Sign(rq)
+	assert.Nil(t, err)
+	err = client.NewVerifier().Verify(encToken, &clientID.Key().PublicKey, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, a.CloseAndRemove())
+
+	// check using a key signer with a shared secret errors
+	config = newTestConfig().WithKeySigner(clientID.Key()).WithSharedSecret([]byte("secret"))
+	a, err = NewAuthor(config, authorKeys, selfReaderKeys, clogging.NewDevInfoLogger())
+	assert.Equal(t, ErrKeySignerWithSharedSecret, err)
+	assert.Nil(t, a)
+}
+
+func TestNewAuthor_lockedKeychains(t *testing.T) {
+	orig := getLibrarianHealthClients
+	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config) (
+		map[string]healthpb.HealthClient, error) {
+		return make(map[string]healthpb.HealthClient), nil
+	}
+	defer func() { getLibrarianHealthClients = orig }()
+
+	config := newTestConfig()
+	logger := clogging.NewDevInfoLogger()
+	err := CreateKeychains(logger, config.KeychainDir, testKeychainAuth, veryLightKeychainParams)
+	assert.Nil(t, err)
+	authorKeys, selfReaderKeys, err := LoadLockedKeychains(config.KeychainDir,
+		testKeychainAuth)
+	assert.Nil(t, err)
+	a, err := NewAuthor(config, authorKeys, selfReaderKeys, logger)
+	assert.Nil(t, err)
+
+	// check closing author closes its locked keychains
+	assert.Nil(t, a.CloseAndRemove())
+	_, err = authorKeys.Sample()
+	assert.Equal(t, keychain.ErrKeychainClosed, err)
+	_, err = selfReaderKeys.Sample()
+	assert.Equal(t, keychain.ErrKeychainClosed, err)
+}
+
 func TestAuthor_Healthcheck_ok(t *testing.T) {
 	// return fixed map of health clients
 	orig := getLibrarianHealthClients
-	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr) (
+	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config) (
 		map[string]healthpb.HealthClient, error) {
 		return map[string]healthpb.HealthClient{
 			"peerAddr1" : &fixedHealthClient{
@@ -90,7 +153,7 @@ func TestAuthor_Healthcheck_ok(t *testing.T) {
 func TestAuthor_Healthcheck_err(t *testing.T) {
 	// return fixed map of health clients
 	orig := getLibrarianHealthClients
-	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr) (
+	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config) (
 		map[string]healthpb.HealthClient, error) {
 		return map[string]healthpb.HealthClient{
 			"peerAddr1" : &fixedHealthClient{
@@ -119,11 +182,13 @@ func TestAuthor_Upload_ok(t *testing.T) {
 		api.RandBytes(rng, 32),
 	)
 	assert.Nil(t, err)
+	entry, _ := api.NewTestDocument(rng)
 	a.entryPacker = &fixedEntryPacker{
+		entry:    entry,
 		metadata: metadata,
 	}
 	expectedEntryKey := id.NewPseudoRandom(rng)
-	a.shipper = &fixedShipper{
+	shipper := &fixedShipper{
 		envelope: &api.Document{
 			Contents: &api.Document_Envelope{
 				Envelope: api.NewTestEnvelope(rng),
@@ -131,18 +196,28 @@ func TestAuthor_Upload_ok(t *testing.T) {
 		},
 		envelopeKey: expectedEntryKey,
 	}
+	a.shipper = shipper
 
 	// since everything is mocked, inputs don't really matter
 	actualEnvelope, actualEnvelopeKey, err := a.Upload(nil, "")
 	assert.Nil(t, err)
 	assert.NotNil(t, actualEnvelope)
 	assert.Equal(t, expectedEntryKey, actualEnvelopeKey)
+	assert.Nil(t, shipper.attrs)
+	assert.Nil(t, client.VerifyEntrySignature(shipper.entry.GetEntry()))
+
+	// check entry attributes are shipped when disclosed
+	a.config.WithDiscloseEntryAttributes(true)
+	_, _, err = a.Upload(nil, "")
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.GetEntryAttributes(), shipper.attrs)
 
 	err = a.CloseAndRemove()
 	assert.Nil(t, err)
 }
 
 func TestAuthor_Upload_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
 	a := newTestAuthor()
 	a.entryPacker = &fixedEntryPacker{err: errors.New("some Pack error")}
 	a.shipper = &fixedShipper{}
@@ -153,19 +228,147 @@ func TestAuthor_Upload_err(t *testing.T) {
 	assert.Nil(t, actualEnvelope)
 	assert.Nil(t, actualEnvelopeKey)
 
-	a.entryPacker = &fixedEntryPacker{}
+	entry, _ := api.NewTestDocument(rng)
+	a.entryPacker = &fixedEntryPacker{entry: entry}
 	a.shipper = &fixedShipper{err: errors.New("some Ship error")}
 
-	// check pack error bubbles up
+	// check ship error bubbles up
 	actualEnvelope, actualEnvelopeKey, err = a.Upload(nil, "")
 	assert.NotNil(t, err)
 	assert.Nil(t, actualEnvelope)
 	assert.Nil(t, actualEnvelopeKey)
 
+	// check missing author key to sign entry with bubbles up
+	a.authorKeys = keychain.New(0)
+	actualEnvelope, actualEnvelopeKey, err = a.Upload(nil, "")
+	assert.Equal(t, keychain.ErrUnexpectedMissingKey, err)
+	assert.Nil(t, actualEnvelope)
+	assert.Nil(t, actualEnvelopeKey)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_UploadRevision_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.receiver = ship.NewReceiver(a.librarians, a.selfReaderKeys, pubAcq, msAcquirer,
+		a.documentSL)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 1024
+	a.config.Print.Chunking = page.ContentDefinedChunking
+	content1 := api.RandBytes(rng, 32*1024)
+	content2 := append(api.RandBytes(rng, 10), content1...)
+	envelope1, envelopeKey1, err := a.Upload(bytes.NewReader(content1), "application/x-pdf")
+	assert.Nil(t, err)
+	envelope2, envelopeKey2, err := a.UploadRevision(bytes.NewReader(content2),
+		"application/x-pdf", envelopeKey1)
+	assert.Nil(t, err)
+
+	// check revision has same author and reader keys
+	authorPub1, readerPub1, _, err := pack.SeparateEnvelopeDoc(envelope1)
+	assert.Nil(t, err)
+	authorPub2, readerPub2, _, err := pack.SeparateEnvelopeDoc(envelope2)
+	assert.Nil(t, err)
+	assert.Equal(t, authorPub1, authorPub2)
+	assert.Equal(t, readerPub1, readerPub2)
+
+	// check that all but the first few pages are shared with the previous revision
+	pageKeys1 := getTestEntryPageKeys(t, pubAcq, envelope1)
+	pageKeys2 := getTestEntryPageKeys(t, pubAcq, envelope2)
+	nShared := 0
+	for pageKey := range pageKeys2 {
+		if _, in := pageKeys1[pageKey]; in {
+			nShared++
+		}
+	}
+	assert.True(t, nShared >= len(pageKeys1)-2, "%d of %d shared", nShared, len(pageKeys1))
+
+	// check revision downloads
+	downloaded := new(bytes.Buffer)
+	err = a.Download(downloaded, envelopeKey2)
+	assert.Nil(t, err)
+	assert.Equal(t, content2, downloaded.Bytes())
+
 	err = a.CloseAndRemove()
 	assert.Nil(t, err)
 }
 
+func TestAuthor_UploadRevision_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	readerPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	entryKey := id.NewPseudoRandom(rng)
+
+	// check librarian balancer error bubbles up
+	a.librarians = &fixedClientBalancer{err: errors.New("some Next error")}
+	envelope, envelopeKey, err := a.UploadRevision(nil, "", entryKey)
+	assert.NotNil(t, err)
+	assert.Nil(t, envelope)
+	assert.Nil(t, envelopeKey)
+	a.librarians = &fixedClientBalancer{}
+
+	// check non-envelope error bubbles up
+	entry, entryDocKey := api.NewTestDocument(rng)
+	_, err = pubAcq.Publish(entry, nil, nil)
+	assert.Nil(t, err)
+	_, _, err = a.UploadRevision(nil, "", entryDocKey)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+
+	// check missing author key
+	prevEnvelope := pack.NewEnvelopeDoc(otherPub, readerPub, entryKey, nil)
+	prevEnvelopeKey, err := pubAcq.Publish(prevEnvelope, nil, nil)
+	assert.Nil(t, err)
+	_, _, err = a.UploadRevision(nil, "", prevEnvelopeKey)
+	assert.Equal(t, ErrNotEnvelopeAuthor, err)
+
+	// check missing self reader key
+	prevEnvelope = pack.NewEnvelopeDoc(authorPub, readerPub, entryKey, nil)
+	prevEnvelopeKey, err = pubAcq.Publish(prevEnvelope, nil, nil)
+	assert.Nil(t, err)
+	_, _, err = a.UploadRevision(nil, "", prevEnvelopeKey)
+	assert.Equal(t, keychain.ErrUnexpectedMissingKey, err)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func getTestEntryPageKeys(t *testing.T, pubAcq *memPublisherAcquirer, envelope *api.Document) (
+	map[string]struct{}) {
+	_, _, entryKey, err := pack.SeparateEnvelopeDoc(envelope)
+	assert.Nil(t, err)
+	entry, err := pubAcq.Acquire(entryKey, nil, nil)
+	assert.Nil(t, err)
+	pageKeys, err := api.GetEntryPageKeys(entry)
+	assert.Nil(t, err)
+	pageKeySet := make(map[string]struct{})
+	for _, pageKey := range pageKeys {
+		pageKeySet[pageKey.String()] = struct{}{}
+	}
+	return pageKeySet
+}
+
 func TestAuthor_Download_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	doc, docKey := api.NewTestDocument(rng)
@@ -209,6 +412,117 @@ func TestAuthor_Download_err(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestAuthor_DownloadStream_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	a.librarians, b.librarians = &fixedClientBalancer{}, &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+
+	// b streams with a's reader keys, so all pages are acquired from libri
+	b.ssAcquirer = publish.NewSingleStoreAcquirer(pubAcq, b.documentSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(b.ssAcquirer, b.config.Publish)
+	b.receiver = ship.NewReceiver(b.librarians, a.selfReaderKeys, pubAcq, msAcquirer,
+		b.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 128
+	for _, uncompressedSize := range []int{128, 512, 2048} {
+		content1 := common.NewCompressableBytes(rng, uncompressedSize)
+		content1Bytes := content1.Bytes()
+		_, envelopeKey, err := a.Upload(content1, "application/x-gzip")
+		assert.Nil(t, err)
+
+		content2, err := b.DownloadStream(envelopeKey)
+		assert.Nil(t, err)
+		content2Bytes, err := ioutil.ReadAll(content2)
+		assert.Nil(t, err)
+		assert.Nil(t, content2.Close())
+		assert.Equal(t, content1Bytes, content2Bytes)
+	}
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
+
+func TestAuthor_DownloadStream_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	_, docKey := api.NewTestDocument(rng)
+	a := &Author{
+		logger:   clogging.NewDevInfoLogger(),
+		receiver: &fixedReceiver{err: errors.New("some Receive error")},
+	}
+	content, err := a.DownloadStream(docKey)
+	assert.NotNil(t, err)
+	assert.Nil(t, content)
+}
+
+func TestAuthor_DownloadRange_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	a.librarians, b.librarians = &fixedClientBalancer{}, &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+
+	// b downloads with a's reader keys, so pages are acquired from libri
+	b.ssAcquirer = publish.NewSingleStoreAcquirer(pubAcq, b.documentSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(b.ssAcquirer, b.config.Publish)
+	b.receiver = ship.NewReceiver(b.librarians, a.selfReaderKeys, pubAcq, msAcquirer,
+		b.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 128
+	content1 := common.NewCompressableBytes(rng, 2048)
+	content1Bytes := content1.Bytes()
+	envelope, envelopeKey, err := a.Upload(content1, "application/x-gzip")
+	assert.Nil(t, err)
+	entryKey := id.FromBytes(envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey)
+	pageKeys, err := api.GetEntryPageKeys(pubAcq.docs[entryKey.String()])
+	assert.Nil(t, err)
+
+	// bytes [300, 500) are in the pages with indices 2 and 3
+	content2 := new(bytes.Buffer)
+	err = b.DownloadRange(content2, envelopeKey, 300, 200)
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes[300:500], content2.Bytes())
+
+	// check pages outside the range were never acquired
+	acquired := make(map[string]struct{})
+	for _, docKey := range pubAcq.acquired {
+		acquired[docKey.String()] = struct{}{}
+	}
+	for i, pageKey := range pageKeys {
+		_, in := acquired[pageKey.String()]
+		assert.Equal(t, i == 2 || i == 3, in, "page %d", i)
+	}
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
+
+func TestAuthor_DownloadRange_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	_, docKey := api.NewTestDocument(rng)
+	a := &Author{
+		logger:   clogging.NewDevInfoLogger(),
+		receiver: &fixedReceiver{err: errors.New("some Receive error")},
+	}
+	err := a.DownloadRange(new(bytes.Buffer), docKey, 1, 2)
+	assert.NotNil(t, err)
+}
+
 func TestAuthor_UploadDownload(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	a := newTestAuthor()
@@ -257,6 +571,42 @@ func TestAuthor_UploadDownload(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestAuthor_UploadDownload_erasureCoding(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, pubAcq := newTestVerifyAuthor()
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	a.config.Print.DataShards, a.config.Print.ParityShards = 4, 2
+
+	content1 := common.NewCompressableBytes(rng, 2048)
+	content1Bytes := content1.Bytes()
+	envelope, envelopeKey, err := a.Upload(content1, "application/x-pdf")
+	assert.Nil(t, err)
+	entryKey := id.FromBytes(envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey)
+	shardKeys, err := api.GetEntryPageKeys(pubAcq.docs[entryKey.String()])
+	assert.Nil(t, err)
+	assert.Zero(t, len(shardKeys)%6)
+
+	// download into empty local storage after two shards of each page are lost from libri
+	for i := 0; i < len(shardKeys); i += 6 {
+		delete(pubAcq.docs, shardKeys[i+(i/6)%4].String())
+		delete(pubAcq.docs, shardKeys[i+4].String())
+	}
+	docSL := &memDocumentStorerLoader{stored: make(map[string]*api.Document)}
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, docSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, a.config.Publish)
+	a.receiver = ship.NewReceiver(a.librarians, a.selfReaderKeys, pubAcq, msAcquirer, docSL)
+	a.entryUnpacker = pack.NewEntryUnpacker(a.config.Print, enc.NewMetadataEncrypterDecrypter(),
+		docSL)
+
+	content2 := new(bytes.Buffer)
+	err = a.Download(content2, envelopeKey)
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes, content2.Bytes())
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
 type fixedPublisher struct {
 	doc        *api.Document
 	lc         api.Putter
@@ -264,7 +614,8 @@ type fixedPublisher struct {
 	publishErr error
 }
 
-func (f *fixedPublisher) Publish(doc *api.Document, lc api.Putter) (id.ID, error) {
+func (f *fixedPublisher) Publish(doc *api.Document, authorPub []byte, lc api.Putter) (
+	id.ID, error) {
 	f.doc, f.lc = doc, lc
 	return f.publishID, f.publishErr
 }
@@ -289,17 +640,28 @@ type fixedEntryPacker struct {
 func (f *fixedEntryPacker) Pack(
 	content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte,
 ) (*api.Document, *api.Metadata, error) {
+	if f.entry != nil {
+		// mimic packing with the sampled author key
+		f.entry.GetEntry().AuthorPublicKey = authorPub
+		if page := f.entry.GetEntry().GetPage(); page != nil {
+			page.AuthorPublicKey = authorPub
+		}
+	}
 	return f.entry, f.metadata, f.err
 }
 
 type fixedShipper struct {
+	entry       *api.Document
 	envelope    *api.Document
 	envelopeKey id.ID
+	attrs       *api.EntryAttributes
 	err         error
 }
 
-func (f *fixedShipper) Ship(entry *api.Document, authorPub []byte, readerPub []byte) (
-	*api.Document, id.ID, error) {
+func (f *fixedShipper) Ship(
+	entry *api.Document, authorPub []byte, readerPub []byte, attrs *api.EntryAttributes,
+) (*api.Document, id.ID, error) {
+	f.entry, f.attrs = entry, attrs
 	return f.envelope, f.envelopeKey, f.err
 }
 
@@ -313,6 +675,10 @@ func (f *fixedReceiver) Receive(envelopeKey id.ID) (*api.Document, *enc.Keys, er
 	return f.entry, f.keys, f.err
 }
 
+func (f *fixedReceiver) ReceiveEntry(envelopeKey id.ID) (*api.Document, *enc.Keys, error) {
+	return f.entry, f.keys, f.err
+}
+
 type fixedUnpacker struct {
 	metadata *api.Metadata
 	err error
@@ -323,9 +689,16 @@ func (f *fixedUnpacker) Unpack(content io.Writer, entry *api.Document, keys *enc
 	return f.metadata, f.err
 }
 
+func (f *fixedUnpacker) UnpackRange(
+	content io.Writer, entry *api.Document, keys *enc.Keys, offset, length uint64,
+) (*api.Metadata, error) {
+	return f.metadata, f.err
+}
+
 type memPublisherAcquirer struct {
-	docs map[string]*api.Document
-	mu   sync.Mutex
+	docs     map[string]*api.Document
+	acquired []id.ID
+	mu       sync.Mutex
 }
 
 func (p *memPublisherAcquirer) Publish(doc *api.Document, authorPub []byte, lc api.Putter) (
@@ -344,7 +717,30 @@ func (p *memPublisherAcquirer) Acquire(docKey id.ID, authorPub []byte, lc api.Ge
 	*api.Document, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return p.docs[docKey.String()], nil
+	p.acquired = append(p.acquired, docKey)
+	doc, in := p.docs[docKey.String()]
+	if !in {
+		return nil, errors.New("missing")
+	}
+	return doc, nil
+}
+
+type memDocumentStorerLoader struct {
+	stored map[string]*api.Document
+	mu     sync.Mutex
+}
+
+func (m *memDocumentStorerLoader) Store(key id.ID, value *api.Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stored[key.String()] = value
+	return nil
+}
+
+func (m *memDocumentStorerLoader) Load(key id.ID) (*api.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stored[key.String()], nil
 }
 
 type fixedClientBalancer struct {
@@ -415,12 +811,13 @@ func newTestAuthor() *Author {
 
 	// create keychains
 	err := CreateKeychains(logger, config.KeychainDir, testKeychainAuth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	if err != nil {
 		panic(err)
 	}
 
-	authorKeys, selfReaderKeys := keychain.New(nInitialKeys), keychain.New(nInitialKeys)
+	nKeys := keychain.DefaultNKeys
+	authorKeys, selfReaderKeys := keychain.New(nKeys), keychain.New(nKeys)
 	author, err := NewAuthor(config, authorKeys, selfReaderKeys, logger)
 	if err != nil {
 		panic(err)
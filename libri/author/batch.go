@@ -0,0 +1,83 @@
+package author
+
+import (
+	"io"
+	"sync"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"go.uber.org/zap"
+)
+
+// UploadContent is a document to upload with UploadAll.
+type UploadContent struct {
+	// Content is the document's content.
+	Content io.Reader
+
+	// MediaType is the media type of the content, which is detected from it if empty.
+	MediaType string
+}
+
+// UploadResult is the outcome of uploading a document with UploadAll.
+type UploadResult struct {
+	// Envelope is the uploaded envelope for self-storage.
+	Envelope *api.Document
+
+	// EnvelopeKey is the key of the uploaded envelope.
+	EnvelopeKey id.ID
+
+	// Err is the error uploading the document, if any.
+	Err error
+}
+
+// UploadAll uploads each of the contents like Upload, with up to the configured
+// UploadParallelism packed and shipped at once over the shared librarian connections. It returns
+// a result for each content, in the same order. Each upload has its own checkpoint, so failed
+// uploads can be resumed by calling ResumeUpload until it returns ErrNoUploadCheckpoint.
+func (a *Author) UploadAll(contents []*UploadContent) []*UploadResult {
+	results := make([]*UploadResult, len(contents))
+	toUpload := make(chan int, len(contents))
+	for i := range contents {
+		toUpload <- i
+	}
+	close(toUpload)
+
+	// serializes sampling envelope keys, since keychains aren't safe for concurrent use
+	var mu sync.Mutex
+	wg := new(sync.WaitGroup)
+	for c := uint32(0); c < a.config.UploadParallelism && c < uint32(len(contents)); c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range toUpload {
+				mu.Lock()
+				authorPub, readerPub, keys, err := a.envelopeKeys.sample()
+				mu.Unlock()
+				if err != nil {
+					results[i] = &UploadResult{Err: err}
+					continue
+				}
+				envelope, envelopeKey, err := a.upload(contents[i].Content,
+					contents[i].MediaType, authorPub, readerPub, keys)
+				results[i] = &UploadResult{
+					Envelope:    envelope,
+					EnvelopeKey: envelopeKey,
+					Err:         err,
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	nErrs := 0
+	for _, result := range results {
+		if result.Err != nil {
+			nErrs++
+		}
+	}
+	a.logger.Info("finished uploading documents",
+		zap.Int("n_documents", len(contents)),
+		zap.Int("n_errors", nErrs),
+	)
+	return results
+}
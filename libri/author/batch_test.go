@@ -0,0 +1,106 @@
+package author
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_UploadAll(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.receiver = ship.NewReceiver(a.librarians, a.selfReaderKeys, pubAcq, msAcquirer,
+		a.documentSL)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 1024
+	nContents := 8
+	contents := make([]*UploadContent, nContents)
+	expected := make([][]byte, nContents)
+	for i := range contents {
+		expected[i] = api.RandBytes(rng, 4*1024)
+		contents[i] = &UploadContent{
+			Content:   bytes.NewReader(expected[i]),
+			MediaType: "application/x-pdf",
+		}
+	}
+
+	// check content that can't be read fails only its own upload
+	contents[3] = &UploadContent{Content: errReader{}, MediaType: "application/x-pdf"}
+
+	results := a.UploadAll(contents)
+	assert.Len(t, results, nContents)
+	for i, result := range results {
+		if i == 3 {
+			assert.NotNil(t, result.Err)
+			assert.Nil(t, result.Envelope)
+			assert.Nil(t, result.EnvelopeKey)
+			continue
+		}
+		assert.Nil(t, result.Err)
+		assert.NotNil(t, result.Envelope)
+
+		// check each uploaded content downloads in the same order
+		downloaded := new(bytes.Buffer)
+		err := a.Download(downloaded, result.EnvelopeKey)
+		assert.Nil(t, err)
+		assert.Equal(t, expected[i], downloaded.Bytes())
+	}
+
+	// check all successful uploads are in the index of uploads
+	records, err := a.ListUploads(nil)
+	assert.Nil(t, err)
+	assert.Len(t, records, nContents-1)
+
+	// check no contents has no results
+	results = a.UploadAll([]*UploadContent{})
+	assert.Empty(t, results)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_UploadAll_err(t *testing.T) {
+	a := newTestAuthor()
+	a.authorKeys = keychain.New(0)
+	a.envelopeKeys = &envelopeKeySamplerImpl{
+		authorKeys:     a.authorKeys,
+		selfReaderKeys: a.selfReaderKeys,
+	}
+
+	// check key sampling error is in each result
+	results := a.UploadAll([]*UploadContent{{}, {}})
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.Equal(t, keychain.ErrEmptyKeychain, result.Err)
+	}
+
+	err := a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("some Read error")
+}
@@ -0,0 +1,217 @@
+package author
+
+import (
+	"errors"
+	"time"
+
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoUploadCheckpoint indicates when an upload is resumed without an incomplete upload to
+	// resume.
+	ErrNoUploadCheckpoint = errors.New("no incomplete upload to resume")
+)
+
+// ResumeUpload resumes an incomplete upload that failed while shipping its pages, entry, or
+// envelope. Each upload has its own checkpoint, so each call resumes one of them until it returns
+// ErrNoUploadCheckpoint. Pages already published are skipped, so shipping resumes from the first
+// incomplete page. It returns the uploaded envelope for self-storage and its key.
+func (a *Author) ResumeUpload() (*api.Document, id.ID, error) {
+	for {
+		cp, err := loadUploadCheckpoint(a.checkpointSL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cp == nil {
+			return nil, nil, ErrNoUploadCheckpoint
+		}
+		entryKey := id.FromBytes(cp.EntryKey)
+		entry, err := a.documentSL.Load(entryKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if entry == nil {
+			// without its entry the upload can't be resumed, so try the next one
+			a.logger.Warn("discarding upload checkpoint with missing entry",
+				zap.String(LoggerEntryKey, entryKey.String()),
+			)
+			if err := a.deleteUploadCheckpoint(entryKey); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		metadata, err := a.getEntryMetadata(entry, cp.AuthorPublicKey, cp.ReaderPublicKey)
+		if err != nil {
+			// still ship the entry, but its index record won't have any metadata
+			a.logger.Warn("unable to decrypt entry metadata",
+				zap.String(LoggerEntryKey, entryKey.String()),
+				zap.Error(err),
+			)
+		}
+		a.logger.Info("resuming upload", zap.String(LoggerEntryKey, entryKey.String()))
+		return a.shipCheckpointed(entry, metadata, cp)
+	}
+}
+
+// shipCheckpointed ships the entry with the envelope keys and attributes in the checkpoint, deletes
+// the completed checkpoint, and then adds the upload to the local index of uploads.
+func (a *Author) shipCheckpointed(entry *api.Document, metadata *api.Metadata,
+	cp *UploadCheckpoint) (*api.Document, id.ID, error) {
+	var attrs *api.EntryAttributes
+	if cp.EntryAttributes != nil {
+		attrs = &api.EntryAttributes{}
+		if err := proto.Unmarshal(cp.EntryAttributes, attrs); err != nil {
+			return nil, nil, err
+		}
+	}
+	envelope, envelopeKey, err := a.shipper.Ship(entry, cp.AuthorPublicKey,
+		cp.ReaderPublicKey, attrs)
+	if err != nil {
+		a.logger.Error("unable to ship entry, resume upload to retry",
+			zap.String(LoggerEntryKey, id.FromBytes(cp.EntryKey).String()),
+			zap.Error(err),
+		)
+		return nil, nil, err
+	}
+	if err := a.deleteUploadCheckpoint(id.FromBytes(cp.EntryKey)); err != nil {
+		return nil, nil, err
+	}
+	if err := indexUpload(a.uploadSL, cp, envelopeKey, entry, metadata); err != nil {
+		return nil, nil, err
+	}
+	err = a.keyLedger.Record(&KeyUsageRecord{
+		EnvelopeKey:     envelopeKey.Bytes(),
+		EntryKey:        cp.EntryKey,
+		AuthorPublicKey: cp.AuthorPublicKey,
+		ReaderPublicKey: cp.ReaderPublicKey,
+		PublishedTime:   time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return envelope, envelopeKey, nil
+}
+
+// newUploadCheckpoint stores the entry in the local document storage and then saves a new
+// checkpoint for it, so its upload can be resumed if shipping fails.
+func newUploadCheckpoint(
+	nsl storage.NamespaceStorer,
+	docSL storage.DocumentStorer,
+	entry *api.Document,
+	authorPub, readerPub []byte,
+	attrs *api.EntryAttributes,
+) (*UploadCheckpoint, error) {
+	entryKey, err := api.GetKey(entry)
+	if err != nil {
+		return nil, err
+	}
+	cp := &UploadCheckpoint{
+		EntryKey:        entryKey.Bytes(),
+		AuthorPublicKey: authorPub,
+		ReaderPublicKey: readerPub,
+	}
+	if attrs != nil {
+		if cp.EntryAttributes, err = proto.Marshal(attrs); err != nil {
+			return nil, err
+		}
+	}
+	if err := docSL.Store(entryKey, entry); err != nil {
+		return nil, err
+	}
+	return cp, saveUploadCheckpoint(nsl, cp)
+}
+
+// deleteUploadCheckpoint deletes the checkpoint of the upload with the given entry key along with
+// its record of published pages.
+func (a *Author) deleteUploadCheckpoint(entryKey id.ID) error {
+	pagesSL := storage.NewCheckpointPagesKVDBStorerLoader(a.db, entryKey)
+	pageKeys := make([][]byte, 0)
+	err := pagesSL.Iterate(make(chan struct{}), func(key, value []byte) {
+		pageKeys = append(pageKeys, key)
+	})
+	if err != nil {
+		return err
+	}
+	for _, pageKey := range pageKeys {
+		if err := pagesSL.Delete(pageKey); err != nil {
+			return err
+		}
+	}
+	return a.checkpointSL.Delete(entryKey.Bytes())
+}
+
+func saveUploadCheckpoint(nsl storage.NamespaceStorer, cp *UploadCheckpoint) error {
+	bytes, err := proto.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return nsl.Store(cp.EntryKey, bytes)
+}
+
+// loadUploadCheckpoint loads the first of the checkpoints, or nil if there are none.
+func loadUploadCheckpoint(nsl storage.NamespaceIterator) (*UploadCheckpoint, error) {
+	var bytes []byte
+	done := make(chan struct{})
+	err := nsl.Iterate(done, func(key, value []byte) {
+		if bytes == nil {
+			bytes = value
+			close(done)
+		}
+	})
+	if err != nil || bytes == nil {
+		return nil, err
+	}
+	cp := &UploadCheckpoint{}
+	if err := proto.Unmarshal(bytes, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// checkpointShipper ships each entry with its own upload checkpoint of published pages, so
+// resuming its upload skips the pages already published.
+type checkpointShipper struct {
+	librarians  api.ClientBalancer
+	publisher   publish.Publisher
+	slPublisher publish.SingleLoadPublisher
+	params      *publish.Parameters
+	db          db.KVDB
+}
+
+func newCheckpointShipper(
+	librarians api.ClientBalancer,
+	publisher publish.Publisher,
+	slPublisher publish.SingleLoadPublisher,
+	params *publish.Parameters,
+	kvdb db.KVDB,
+) ship.Shipper {
+	return &checkpointShipper{
+		librarians:  librarians,
+		publisher:   publisher,
+		slPublisher: slPublisher,
+		params:      params,
+		db:          kvdb,
+	}
+}
+
+func (s *checkpointShipper) Ship(
+	entry *api.Document, authorPub []byte, readerPub []byte, attrs *api.EntryAttributes,
+) (*api.Document, id.ID, error) {
+	entryKey, err := api.GetKey(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	pagesSL := storage.NewCheckpointPagesKVDBStorerLoader(s.db, entryKey)
+	mlPublisher := publish.NewMultiLoadPublisher(
+		publish.NewCheckpointSingleLoadPublisher(s.slPublisher, pagesSL), s.params)
+	shipper := ship.NewShipper(s.librarians, s.publisher, mlPublisher)
+	return shipper.Ship(entry, authorPub, readerPub, attrs)
+}
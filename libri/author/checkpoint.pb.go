@@ -0,0 +1,119 @@
+// Code generated by protoc-gen-go.
+// source: libri/author/checkpoint.proto
+// DO NOT EDIT!
+
+/*
+Package author is a generated protocol buffer package.
+
+It is generated from these files:
+	libri/author/checkpoint.proto
+	libri/author/contacts.proto
+	libri/author/ledger.proto
+	libri/author/manifest.proto
+	libri/author/uploads.proto
+
+It has these top-level messages:
+	UploadCheckpoint
+	Contacts
+	Contact
+	KeyUsageRecord
+	WrappedLedgerKey
+	WrappedLedgerKeys
+	Manifest
+	ManifestFile
+	UploadRecord
+	UploadManifest
+	EncryptedUploadManifest
+*/
+package author
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// UploadCheckpoint records the progress of the last upload, so a failed upload can be resumed.
+type UploadCheckpoint struct {
+	// key of the packed and signed entry in local document storage
+	EntryKey []byte `protobuf:"bytes,1,opt,name=entry_key,json=entryKey,proto3" json:"entry_key,omitempty"`
+	// public key of the envelope author
+	AuthorPublicKey []byte `protobuf:"bytes,2,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
+	// public key of the envelope reader
+	ReaderPublicKey []byte `protobuf:"bytes,3,opt,name=reader_public_key,json=readerPublicKey,proto3" json:"reader_public_key,omitempty"`
+	// marshaled api.EntryAttributes disclosed in the envelope, if any
+	EntryAttributes []byte `protobuf:"bytes,4,opt,name=entry_attributes,json=entryAttributes,proto3" json:"entry_attributes,omitempty"`
+	// whether the upload has completed
+	Completed bool `protobuf:"varint,5,opt,name=completed" json:"completed,omitempty"`
+}
+
+func (m *UploadCheckpoint) Reset()                    { *m = UploadCheckpoint{} }
+func (m *UploadCheckpoint) String() string            { return proto.CompactTextString(m) }
+func (*UploadCheckpoint) ProtoMessage()               {}
+func (*UploadCheckpoint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *UploadCheckpoint) GetEntryKey() []byte {
+	if m != nil {
+		return m.EntryKey
+	}
+	return nil
+}
+
+func (m *UploadCheckpoint) GetAuthorPublicKey() []byte {
+	if m != nil {
+		return m.AuthorPublicKey
+	}
+	return nil
+}
+
+func (m *UploadCheckpoint) GetReaderPublicKey() []byte {
+	if m != nil {
+		return m.ReaderPublicKey
+	}
+	return nil
+}
+
+func (m *UploadCheckpoint) GetEntryAttributes() []byte {
+	if m != nil {
+		return m.EntryAttributes
+	}
+	return nil
+}
+
+func (m *UploadCheckpoint) GetCompleted() bool {
+	if m != nil {
+		return m.Completed
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*UploadCheckpoint)(nil), "author.UploadCheckpoint")
+}
+
+func init() { proto.RegisterFile("libri/author/checkpoint.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 187 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x92, 0xcd, 0xc9, 0x4c, 0x2a,
+	0xca, 0xd4, 0x4f, 0x2c, 0x2d, 0xc9, 0xc8, 0x2f, 0xd2, 0x4f, 0xce, 0x48, 0x4d, 0xce, 0x2e, 0xc8,
+	0xcf, 0xcc, 0x2b, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x83, 0x48, 0x28, 0x5d, 0x60,
+	0xe4, 0x12, 0x08, 0x2d, 0xc8, 0xc9, 0x4f, 0x4c, 0x71, 0x86, 0x2b, 0x11, 0x92, 0xe6, 0xe2, 0x4c,
+	0xcd, 0x2b, 0x29, 0xaa, 0x8c, 0xcf, 0x4e, 0xad, 0x94, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x09, 0xe2,
+	0x00, 0x0b, 0x78, 0xa7, 0x56, 0x0a, 0x69, 0x71, 0x09, 0x42, 0xf4, 0xc6, 0x17, 0x94, 0x26, 0xe5,
+	0x64, 0x26, 0x83, 0x15, 0x31, 0x81, 0x15, 0xf1, 0x43, 0x24, 0x02, 0xc0, 0xe2, 0x50, 0xb5, 0x45,
+	0xa9, 0x89, 0x29, 0xa9, 0x28, 0x6a, 0x99, 0x21, 0x6a, 0x21, 0x12, 0x08, 0xb5, 0x9a, 0x5c, 0x02,
+	0x10, 0x4b, 0x13, 0x4b, 0x4a, 0x8a, 0x32, 0x93, 0x4a, 0x4b, 0x52, 0x8b, 0x25, 0x58, 0x20, 0x4a,
+	0xc1, 0xe2, 0x8e, 0x70, 0x61, 0x21, 0x19, 0x2e, 0xce, 0xe4, 0xfc, 0xdc, 0x82, 0x9c, 0xd4, 0x92,
+	0xd4, 0x14, 0x09, 0x56, 0x05, 0x46, 0x0d, 0x8e, 0x20, 0x84, 0x40, 0x12, 0x1b, 0xd8, 0x87, 0xc6,
+	0x80, 0x01, 0x00, 0x2f, 0xe0, 0x64, 0x6c, 0x02, 0x01, 0x00, 0x00,
+}
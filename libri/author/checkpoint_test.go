@@ -0,0 +1,201 @@
+package author
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_ResumeUpload_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	metadata, err := api.NewEntryMetadata(
+		"application/x-pdf",
+		1,
+		api.RandBytes(rng, 32),
+		2,
+		api.RandBytes(rng, 32),
+	)
+	assert.Nil(t, err)
+	entry, _ := api.NewTestDocument(rng)
+	a.entryPacker = &fixedEntryPacker{entry: entry, metadata: metadata}
+	a.config.WithDiscloseEntryAttributes(true)
+	a.shipper = &fixedShipper{err: errors.New("some Ship error")}
+
+	// check failed upload leaves incomplete checkpoint
+	_, _, err = a.Upload(nil, "")
+	assert.NotNil(t, err)
+	cp, err := loadUploadCheckpoint(a.checkpointSL)
+	assert.Nil(t, err)
+	assert.NotNil(t, cp)
+
+	expectedEnvelopeKey := id.NewPseudoRandom(rng)
+	shipper := &fixedShipper{
+		envelope: &api.Document{
+			Contents: &api.Document_Envelope{
+				Envelope: api.NewTestEnvelope(rng),
+			},
+		},
+		envelopeKey: expectedEnvelopeKey,
+	}
+	a.shipper = shipper
+
+	// check resumed upload ships the same entry and deletes the completed checkpoint
+	envelope, envelopeKey, err := a.ResumeUpload()
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+	assert.Equal(t, expectedEnvelopeKey, envelopeKey)
+	assert.Equal(t, entry, shipper.entry)
+	assert.Equal(t, metadata.GetEntryAttributes(), shipper.attrs)
+	cp, err = loadUploadCheckpoint(a.checkpointSL)
+	assert.Nil(t, err)
+	assert.Nil(t, cp)
+
+	// check resumed upload is in the index of uploads
+	records, err := a.ListUploads(nil)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, expectedEnvelopeKey.Bytes(), records[0].EnvelopeKey)
+
+	// check completed upload can't be resumed
+	envelope, envelopeKey, err = a.ResumeUpload()
+	assert.Equal(t, ErrNoUploadCheckpoint, err)
+	assert.Nil(t, envelope)
+	assert.Nil(t, envelopeKey)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_ResumeUpload_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+
+	// check no checkpoint
+	envelope, envelopeKey, err := a.ResumeUpload()
+	assert.Equal(t, ErrNoUploadCheckpoint, err)
+	assert.Nil(t, envelope)
+	assert.Nil(t, envelopeKey)
+
+	// check missing entry discards its checkpoint
+	cp := &UploadCheckpoint{EntryKey: id.NewPseudoRandom(rng).Bytes()}
+	assert.Nil(t, saveUploadCheckpoint(a.checkpointSL, cp))
+	envelope, envelopeKey, err = a.ResumeUpload()
+	assert.Equal(t, ErrNoUploadCheckpoint, err)
+	assert.Nil(t, envelope)
+	assert.Nil(t, envelopeKey)
+	cp, err = loadUploadCheckpoint(a.checkpointSL)
+	assert.Nil(t, err)
+	assert.Nil(t, cp)
+
+	// check bad entry attributes
+	entry, _ := api.NewTestDocument(rng)
+	cp, err = newUploadCheckpoint(a.checkpointSL, a.documentSL, entry, nil, nil, nil)
+	assert.Nil(t, err)
+	cp.EntryAttributes = []byte("the wrong bytes")
+	assert.Nil(t, saveUploadCheckpoint(a.checkpointSL, cp))
+	a.shipper = &fixedShipper{}
+	envelope, envelopeKey, err = a.ResumeUpload()
+	assert.NotNil(t, err)
+	assert.Nil(t, envelope)
+	assert.Nil(t, envelopeKey)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_ResumeUpload_pages(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+
+	// check failed uploads each keep their own checkpoint of published pages
+	a.shipper = newCheckpointShipper(a.librarians,
+		&fixedPublisher{publishErr: errors.New("some Publish error")}, slPublisher,
+		a.config.Publish, a.db)
+	nUploads := 2
+	for c := 0; c < nUploads; c++ {
+		_, _, err := a.Upload(common.NewCompressableBytes(rng, 1024), "application/x-gzip")
+		assert.NotNil(t, err)
+	}
+	entryKeys := make([]id.ID, 0, nUploads)
+	err := a.checkpointSL.Iterate(make(chan struct{}), func(key, value []byte) {
+		entryKeys = append(entryKeys, id.FromBytes(key))
+	})
+	assert.Nil(t, err)
+	assert.Len(t, entryKeys, nUploads)
+	for _, entryKey := range entryKeys {
+		entry, err := a.documentSL.Load(entryKey)
+		assert.Nil(t, err)
+		pageKeys, err := api.GetEntryPageKeys(entry)
+		assert.Nil(t, err)
+		assert.True(t, len(pageKeys) > 1)
+		assert.Len(t, checkpointPageKeys(t, a, entryKey), len(pageKeys))
+	}
+
+	// check resumed uploads skip their published pages
+	a.shipper = newCheckpointShipper(a.librarians, pubAcq,
+		&fixedSingleLoadPublisher{err: errors.New("some Publish error")}, a.config.Publish,
+		a.db)
+	for range entryKeys {
+		envelope, envelopeKey, err := a.ResumeUpload()
+		assert.Nil(t, err)
+		assert.NotNil(t, envelope)
+		assert.NotNil(t, envelopeKey)
+	}
+	_, _, err = a.ResumeUpload()
+	assert.Equal(t, ErrNoUploadCheckpoint, err)
+
+	// check completed uploads delete their published pages
+	for _, entryKey := range entryKeys {
+		assert.Len(t, checkpointPageKeys(t, a, entryKey), 0)
+	}
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestLoadUploadCheckpoint_err(t *testing.T) {
+	cp, err := loadUploadCheckpoint(&fixedStorerLoaderIterator{
+		iterateErr: errors.New("some iterate error"),
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, cp)
+
+	cp, err = loadUploadCheckpoint(&fixedStorerLoaderIterator{
+		values: [][]byte{[]byte("the wrong bytes")},
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, cp)
+}
+
+func checkpointPageKeys(t *testing.T, a *Author, entryKey id.ID) [][]byte {
+	pageKeys := make([][]byte, 0)
+	pagesSL := storage.NewCheckpointPagesKVDBStorerLoader(a.db, entryKey)
+	err := pagesSL.Iterate(make(chan struct{}), func(key, value []byte) {
+		pageKeys = append(pageKeys, key)
+	})
+	assert.Nil(t, err)
+	return pageKeys
+}
+
+type fixedSingleLoadPublisher struct {
+	err error
+}
+
+func (f *fixedSingleLoadPublisher) Publish(docKey id.ID, authorPub []byte, lc api.Putter) error {
+	return f.err
+}
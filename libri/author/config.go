@@ -1,12 +1,19 @@
 package author
 
 import (
+	"crypto"
+	"crypto/tls"
 	"net"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/drausin/libri/libri/author/io/print"
 	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -30,6 +37,13 @@ const (
 
 	// KeychainSubDir is the default DB subdirectory within the data dir.
 	KeychainSubDir = "keychain"
+
+	// DefaultPageCacheSize is the default number of recently stored or loaded pages kept in
+	// memory.
+	DefaultPageCacheSize = 32
+
+	// DefaultUploadParallelism is the default number of documents UploadAll uploads at once.
+	DefaultUploadParallelism = 3
 )
 
 // Config is used to configure an Author.
@@ -44,6 +58,9 @@ type Config struct {
 	// KeychainDir is the local directory where the author keys are stored.
 	KeychainDir string
 
+	// Keychain defines parameters for creating the author and self reader keychains.
+	Keychain *keychain.Parameters
+
 	// LibrarianAddrs is a list of public addresses of Librarian servers to issue request to.
 	LibrarianAddrs []*net.TCPAddr
 
@@ -53,8 +70,65 @@ type Config struct {
 	// Publish defines parameters for publishing pages to libri.
 	Publish *publish.Parameters
 
+	// RetryPolicy defines how Put and Get requests that fail or time out are retried against
+	// other librarians.
+	RetryPolicy *publish.RetryPolicy
+
+	// PageCacheSize is the number of recently stored or loaded pages kept in memory in front of
+	// the local DB.
+	PageCacheSize uint32
+
+	// UploadParallelism is the number of documents UploadAll packs and ships at once.
+	UploadParallelism uint32
+
+	// HealthcheckInterval is the time between checks of the librarians' health.
+	HealthcheckInterval time.Duration
+
+	// Timeouts define the timeouts of Find queries to individual librarians and of their health
+	// checks.
+	Timeouts *client.Timeouts
+
+	// UploadQuorum is the fraction of librarians that must be healthy for CanUpload to be true.
+	UploadQuorum float64
+
 	// LogLevel is the log level
 	LogLevel zapcore.Level
+
+	// DiscloseEntryAttributes indicates whether to disclose (unencrypted) the media type and size
+	// of uploaded entries in their envelopes, so subscribers can filter publications on them.
+	DiscloseEntryAttributes bool
+
+	// SharedSecret is the optional secret every peer and client in a fully trusted private
+	// cluster signs requests with instead of its own key.
+	SharedSecret []byte
+
+	// KeySigner is the optional signer, usually backed by a PKCS #11 module, HSM, or OS
+	// keystore, the author signs requests with instead of its stored client key. The author uses
+	// the signer's public key as its client ID.
+	KeySigner crypto.Signer
+
+	// QueryObserver is the optional observer notified of each Find query the author makes to
+	// individual librarians.
+	QueryObserver client.QueryObserver
+
+	// ClientInterceptors are the optional client interceptors, e.g., for tracing or debug
+	// logging, installed on the author's connections to librarians.
+	ClientInterceptors *api.Interceptors
+
+	// LibrarianTLS indicates whether the author dials librarians over TLS.
+	LibrarianTLS bool
+
+	// LibrarianTLSCAFile is the optional PEM file of CA certificates that librarians' TLS
+	// certificates must be signed by; empty uses the system's CA certificates.
+	LibrarianTLSCAFile string
+
+	// LibrarianTLSServerName is the optional name librarians' TLS certificates must have,
+	// instead of their dialed hosts.
+	LibrarianTLSServerName string
+
+	// ClientIDCurve is the curve name (see ecid.CurveName and ecid.Ed25519CurveName) of the
+	// client ID the author creates for itself. It doesn't affect an existing stored client ID.
+	ClientIDCurve string
 }
 
 // NewDefaultConfig returns a reasonable default author configuration.
@@ -66,9 +140,17 @@ func NewDefaultConfig() *Config {
 	config.WithDefaultDataDir()
 	config.WithDefaultDBDir()
 	config.WithDefaultKeychainDir()
+	config.WithDefaultKeychain()
 	config.WithDefaultLibrarianAddrs()
 	config.WithDefaultPrint()
 	config.WithDefaultPublish()
+	config.WithDefaultRetryPolicy()
+	config.WithDefaultPageCacheSize()
+	config.WithDefaultUploadParallelism()
+	config.WithDefaultHealthcheckInterval()
+	config.WithDefaultTimeouts()
+	config.WithDefaultUploadQuorum()
+	config.WithDefaultClientIDCurve()
 	config.WithDefaultLogLevel()
 
 	return config
@@ -124,6 +206,22 @@ func (c *Config) WithDefaultKeychainDir() *Config {
 	return c
 }
 
+// WithKeychain sets the Keychain parameters to the given value or the default if it is nil.
+func (c *Config) WithKeychain(params *keychain.Parameters) *Config {
+	if params == nil {
+		return c.WithDefaultKeychain()
+	}
+	c.Keychain = params
+	return c
+}
+
+// WithDefaultKeychain sets the Keychain parameters to the default values specified in the
+// keychain package.
+func (c *Config) WithDefaultKeychain() *Config {
+	c.Keychain = keychain.NewDefaultParameters()
+	return c
+}
+
 // WithLibrarianAddrs sets the librarian addresses to the given value or the default if the given
 // value is empty.
 func (c *Config) WithLibrarianAddrs(librarianAddrs []*net.TCPAddr) *Config {
@@ -178,6 +276,114 @@ func (c *Config) WithDefaultPublish() *Config {
 	return c
 }
 
+// WithPutParallelism sets the maximum number of pages published to librarians at once to the given
+// value or the default if it is zero. Higher values improve upload throughput when librarians are
+// far away.
+func (c *Config) WithPutParallelism(putParallelism uint32) *Config {
+	if c.Publish == nil {
+		c.WithDefaultPublish()
+	}
+	if putParallelism == 0 {
+		putParallelism = publish.DefaultPutParallelism
+	}
+	c.Publish.PutParallelism = putParallelism
+	return c
+}
+
+// WithRetryPolicy sets the retry policy to the given value or the default if it is nil.
+func (c *Config) WithRetryPolicy(policy *publish.RetryPolicy) *Config {
+	if policy == nil {
+		return c.WithDefaultRetryPolicy()
+	}
+	c.RetryPolicy = policy
+	return c
+}
+
+// WithDefaultRetryPolicy sets the retry policy to the default values specified in the publish
+// package.
+func (c *Config) WithDefaultRetryPolicy() *Config {
+	c.RetryPolicy = publish.NewDefaultRetryPolicy()
+	return c
+}
+
+// WithPageCacheSize sets the page cache size to the given value or the default if it is zero.
+func (c *Config) WithPageCacheSize(size uint32) *Config {
+	if size == 0 {
+		return c.WithDefaultPageCacheSize()
+	}
+	c.PageCacheSize = size
+	return c
+}
+
+// WithDefaultPageCacheSize sets the page cache size to the default value.
+func (c *Config) WithDefaultPageCacheSize() *Config {
+	c.PageCacheSize = DefaultPageCacheSize
+	return c
+}
+
+// WithUploadParallelism sets the upload parallelism to the given value or the default if it is
+// zero.
+func (c *Config) WithUploadParallelism(uploadParallelism uint32) *Config {
+	if uploadParallelism == 0 {
+		return c.WithDefaultUploadParallelism()
+	}
+	c.UploadParallelism = uploadParallelism
+	return c
+}
+
+// WithDefaultUploadParallelism sets the upload parallelism to the default value.
+func (c *Config) WithDefaultUploadParallelism() *Config {
+	c.UploadParallelism = DefaultUploadParallelism
+	return c
+}
+
+// WithHealthcheckInterval sets the healthcheck interval to the given value or the default if it
+// is zero.
+func (c *Config) WithHealthcheckInterval(interval time.Duration) *Config {
+	if interval == 0 {
+		return c.WithDefaultHealthcheckInterval()
+	}
+	c.HealthcheckInterval = interval
+	return c
+}
+
+// WithDefaultHealthcheckInterval sets the healthcheck interval to the default value.
+func (c *Config) WithDefaultHealthcheckInterval() *Config {
+	c.HealthcheckInterval = DefaultHealthcheckInterval
+	return c
+}
+
+// WithTimeouts sets the request timeouts to the given value or the default if it is nil.
+func (c *Config) WithTimeouts(timeouts *client.Timeouts) *Config {
+	if timeouts == nil {
+		return c.WithDefaultTimeouts()
+	}
+	c.Timeouts = timeouts
+	return c
+}
+
+// WithDefaultTimeouts sets the request timeouts to the default values specified in the client
+// package.
+func (c *Config) WithDefaultTimeouts() *Config {
+	c.Timeouts = client.NewDefaultTimeouts()
+	return c
+}
+
+// WithUploadQuorum sets the upload quorum to the given value or the default if it is zero.
+func (c *Config) WithUploadQuorum(quorum float64) *Config {
+	if quorum == 0 {
+		return c.WithDefaultUploadQuorum()
+	}
+	c.UploadQuorum = quorum
+	return c
+}
+
+// WithDefaultUploadQuorum sets the upload quorum to the default value.
+func (c *Config) WithDefaultUploadQuorum() *Config {
+	c.UploadQuorum = DefaultUploadQuorum
+	return c
+}
+
 // WithLogLevel sets the log level to the given value, though this doesn't have any direct effect
 // on the creation of the logger instance.
 func (c *Config) WithLogLevel(logLevel zapcore.Level) *Config {
@@ -188,6 +394,71 @@ func (c *Config) WithLogLevel(logLevel zapcore.Level) *Config {
 	return c
 }
 
+// WithDiscloseEntryAttributes sets whether to disclose the attributes of uploaded entries.
+func (c *Config) WithDiscloseEntryAttributes(disclose bool) *Config {
+	c.DiscloseEntryAttributes = disclose
+	return c
+}
+
+// WithSharedSecret sets the secret the author signs requests with instead of its client key.
+func (c *Config) WithSharedSecret(secret []byte) *Config {
+	c.SharedSecret = secret
+	return c
+}
+
+// WithKeySigner sets the signer the author signs requests with instead of its client key.
+func (c *Config) WithKeySigner(key crypto.Signer) *Config {
+	c.KeySigner = key
+	return c
+}
+
+// WithQueryObserver sets the observer notified of each query the author makes to individual
+// librarians.
+func (c *Config) WithQueryObserver(obs client.QueryObserver) *Config {
+	c.QueryObserver = obs
+	return c
+}
+
+// WithClientInterceptors sets the client interceptors installed on the author's connections to
+// librarians.
+func (c *Config) WithClientInterceptors(interceptors *api.Interceptors) *Config {
+	c.ClientInterceptors = interceptors
+	return c
+}
+
+// WithLibrarianTLS sets the author to dial librarians over TLS with the given CA file and server
+// name, either of which may be empty.
+func (c *Config) WithLibrarianTLS(caFile, serverName string) *Config {
+	c.LibrarianTLS = true
+	c.LibrarianTLSCAFile, c.LibrarianTLSServerName = caFile, serverName
+	return c
+}
+
+// librarianTLSConfig returns the TLS config for dialing librarians, or nil when the author dials
+// them insecurely.
+func (c *Config) librarianTLSConfig() (*tls.Config, error) {
+	if !c.LibrarianTLS {
+		return nil, nil
+	}
+	return api.NewClientTLSConfig(c.LibrarianTLSCAFile, c.LibrarianTLSServerName)
+}
+
+// WithClientIDCurve sets the curve name of the created client ID to the given value or to the
+// default if the given value is empty.
+func (c *Config) WithClientIDCurve(curveName string) *Config {
+	if curveName == "" {
+		return c.WithDefaultClientIDCurve()
+	}
+	c.ClientIDCurve = curveName
+	return c
+}
+
+// WithDefaultClientIDCurve sets the curve name of the created client ID to the default value.
+func (c *Config) WithDefaultClientIDCurve() *Config {
+	c.ClientIDCurve = ecid.CurveName
+	return c
+}
+
 // WithDefaultLogLevel sets the log level to INFO.
 func (c *Config) WithDefaultLogLevel() *Config {
 	c.LogLevel = DefaultLogLevel
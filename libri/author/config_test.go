@@ -1,11 +1,17 @@
 package author
 
 import (
+	"math/rand"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/drausin/libri/libri/author/io/print"
 	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
@@ -16,9 +22,16 @@ func TestNewDefaultConfig(t *testing.T) {
 	assert.NotEmpty(t, c.DataDir)
 	assert.NotEmpty(t, c.DbDir)
 	assert.NotEmpty(t, c.KeychainDir)
+	assert.NotEmpty(t, c.Keychain)
 	assert.NotEmpty(t, c.LibrarianAddrs)
 	assert.NotEmpty(t, c.Print)
 	assert.NotEmpty(t, c.Publish)
+	assert.NotEmpty(t, c.RetryPolicy)
+	assert.NotEmpty(t, c.PageCacheSize)
+	assert.NotEmpty(t, c.UploadParallelism)
+	assert.NotEmpty(t, c.HealthcheckInterval)
+	assert.NotEmpty(t, c.UploadQuorum)
+	assert.NotEmpty(t, c.ClientIDCurve)
 	assert.NotEmpty(t, c.LogLevel)
 }
 
@@ -55,6 +68,16 @@ func TestConfig_WithBootstrapAddrs(t *testing.T) {
 	)
 }
 
+func TestConfig_WithKeychain(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultKeychain()
+	assert.Equal(t, c1.Keychain, c2.WithKeychain(nil).Keychain)
+	assert.NotEqual(t,
+		c1.Keychain,
+		c3.WithKeychain(&keychain.Parameters{NKeys: 1}).Keychain,
+	)
+}
+
 func TestConfig_WithPrint(t *testing.T) {
 	c1, c2, c3 := &Config{}, &Config{}, &Config{}
 	c1.WithDefaultPrint()
@@ -75,6 +98,38 @@ func TestConfig_WithPublish(t *testing.T) {
 	)
 }
 
+func TestConfig_WithPutParallelism(t *testing.T) {
+	c1, c2 := &Config{}, &Config{}
+	assert.Equal(t, uint32(publish.DefaultPutParallelism),
+		c1.WithPutParallelism(0).Publish.PutParallelism)
+	assert.Equal(t, uint32(8), c2.WithPutParallelism(8).Publish.PutParallelism)
+	assert.Equal(t, uint32(publish.DefaultGetParallelism), c2.Publish.GetParallelism)
+}
+
+func TestConfig_WithRetryPolicy(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultRetryPolicy()
+	assert.Equal(t, c1.RetryPolicy, c2.WithRetryPolicy(nil).RetryPolicy)
+	assert.NotEqual(t,
+		c1.RetryPolicy,
+		c3.WithRetryPolicy(&publish.RetryPolicy{MaxAttempts: 1}).RetryPolicy,
+	)
+}
+
+func TestConfig_WithPageCacheSize(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultPageCacheSize()
+	assert.Equal(t, c1.PageCacheSize, c2.WithPageCacheSize(0).PageCacheSize)
+	assert.NotEqual(t, c1.PageCacheSize, c3.WithPageCacheSize(64).PageCacheSize)
+}
+
+func TestConfig_WithUploadParallelism(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultUploadParallelism()
+	assert.Equal(t, c1.UploadParallelism, c2.WithUploadParallelism(0).UploadParallelism)
+	assert.NotEqual(t, c1.UploadParallelism, c3.WithUploadParallelism(8).UploadParallelism)
+}
+
 func TestConfig_WithLogLevel(t *testing.T) {
 	c1, c2, c3 := &Config{}, &Config{}, &Config{}
 	c1.WithDefaultLogLevel()
@@ -84,3 +139,77 @@ func TestConfig_WithLogLevel(t *testing.T) {
 		c3.WithLogLevel(zapcore.DebugLevel).LogLevel,
 	)
 }
+
+func TestConfig_WithSharedSecret(t *testing.T) {
+	secret := []byte("some shared secret")
+	assert.Equal(t, secret, (&Config{}).WithSharedSecret(secret).SharedSecret)
+}
+
+func TestConfig_WithKeySigner(t *testing.T) {
+	key := ecid.NewPseudoRandom(rand.New(rand.NewSource(0))).Key()
+	assert.Equal(t, key, (&Config{}).WithKeySigner(key).KeySigner)
+}
+
+func TestConfig_WithQueryObserver(t *testing.T) {
+	obs := client.NewQueryMetrics()
+	assert.Equal(t, obs, (&Config{}).WithQueryObserver(obs).QueryObserver)
+}
+
+func TestConfig_WithClientInterceptors(t *testing.T) {
+	interceptors := &api.Interceptors{}
+	c := (&Config{}).WithClientInterceptors(interceptors)
+	assert.Equal(t, interceptors, c.ClientInterceptors)
+}
+
+func TestConfig_WithLibrarianTLS(t *testing.T) {
+	// check insecure by default
+	c := &Config{}
+	tlsConfig, err := c.librarianTLSConfig()
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+
+	c.WithLibrarianTLS("", "librarians.example.com")
+	assert.True(t, c.LibrarianTLS)
+	assert.Empty(t, c.LibrarianTLSCAFile)
+	tlsConfig, err = c.librarianTLSConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, "librarians.example.com", tlsConfig.ServerName)
+
+	// check CA file error bubbles up
+	c.WithLibrarianTLS("missing-ca.pem", "")
+	tlsConfig, err = c.librarianTLSConfig()
+	assert.NotNil(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func TestConfig_WithClientIDCurve(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultClientIDCurve()
+	assert.Equal(t, ecid.CurveName, c1.ClientIDCurve)
+	assert.Equal(t, c1.ClientIDCurve, c2.WithClientIDCurve("").ClientIDCurve)
+	assert.Equal(t, ecid.Ed25519CurveName,
+		c3.WithClientIDCurve(ecid.Ed25519CurveName).ClientIDCurve)
+}
+
+func TestConfig_WithHealthcheckInterval(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultHealthcheckInterval()
+	assert.Equal(t, c1.HealthcheckInterval, c2.WithHealthcheckInterval(0).HealthcheckInterval)
+	assert.NotEqual(t, c1.HealthcheckInterval,
+		c3.WithHealthcheckInterval(time.Minute).HealthcheckInterval)
+}
+
+func TestConfig_WithTimeouts(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultTimeouts()
+	assert.Equal(t, c1.Timeouts, c2.WithTimeouts(nil).Timeouts)
+	assert.NotEqual(t, c1.Timeouts,
+		c3.WithTimeouts(&client.Timeouts{Find: time.Minute}).Timeouts)
+}
+
+func TestConfig_WithUploadQuorum(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultUploadQuorum()
+	assert.Equal(t, c1.UploadQuorum, c2.WithUploadQuorum(0).UploadQuorum)
+	assert.NotEqual(t, c1.UploadQuorum, c3.WithUploadQuorum(1.0).UploadQuorum)
+}
@@ -0,0 +1,186 @@
+package author
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+// LoggerContactName is the logger key used for the name of a contact.
+const LoggerContactName = "contact_name"
+
+var (
+	// ErrEmptyContactName indicates when a contact is added without a name.
+	ErrEmptyContactName = errors.New("empty contact name")
+
+	// ErrNoReaderPublicKeys indicates when a contact is added without any reader public keys.
+	ErrNoReaderPublicKeys = errors.New("no reader public keys")
+
+	// ErrMissingContact indicates when a contact isn't in the address book.
+	ErrMissingContact = errors.New("missing contact")
+
+	contactsKey = []byte("Contacts")
+)
+
+// AddContact adds the reader public keys to the contact with the given name in the address book,
+// creating the contact if it doesn't already exist.
+func (a *Author) AddContact(name string, readerPubs ...[]byte) error {
+	if name == "" {
+		return ErrEmptyContactName
+	}
+	if len(readerPubs) == 0 {
+		return ErrNoReaderPublicKeys
+	}
+	for _, readerPub := range readerPubs {
+		if err := api.ValidatePublicKey(readerPub); err != nil {
+			return err
+		}
+	}
+	contacts, err := loadContacts(a.clientSL)
+	if err != nil {
+		return err
+	}
+	contact := getContact(contacts, name)
+	if contact == nil {
+		contact = &Contact{Name: name}
+		contacts.Contacts = append(contacts.Contacts, contact)
+		sort.Slice(contacts.Contacts, func(i, j int) bool {
+			return contacts.Contacts[i].Name < contacts.Contacts[j].Name
+		})
+	}
+	for _, readerPub := range readerPubs {
+		if !hasReaderPub(contact, readerPub) {
+			contact.ReaderPublicKeys = append(contact.ReaderPublicKeys, readerPub)
+		}
+	}
+	if err := saveContacts(a.clientSL, contacts); err != nil {
+		return err
+	}
+	a.logger.Info("added contact",
+		zap.String(LoggerContactName, name),
+		zap.Int("n_reader_keys", len(contact.ReaderPublicKeys)),
+	)
+	return nil
+}
+
+// ListContacts returns the contacts in the address book, sorted by name.
+func (a *Author) ListContacts() ([]*Contact, error) {
+	contacts, err := loadContacts(a.clientSL)
+	if err != nil {
+		return nil, err
+	}
+	return contacts.Contacts, nil
+}
+
+// RemoveContact removes the contact with the given name from the address book.
+func (a *Author) RemoveContact(name string) error {
+	contacts, err := loadContacts(a.clientSL)
+	if err != nil {
+		return err
+	}
+	for i, contact := range contacts.Contacts {
+		if contact.Name == name {
+			contacts.Contacts = append(contacts.Contacts[:i], contacts.Contacts[i+1:]...)
+			if err := saveContacts(a.clientSL, contacts); err != nil {
+				return err
+			}
+			a.logger.Info("removed contact", zap.String(LoggerContactName, name))
+			return nil
+		}
+	}
+	return ErrMissingContact
+}
+
+// ShareWithContacts shares the envelope returned by Upload with each reader public key of the
+// named contacts. It returns the new envelopes and their keys, in the order of the contacts and
+// their reader public keys.
+func (a *Author) ShareWithContacts(envelope *api.Document, names []string) (
+	[]*api.Document, []id.ID, error) {
+	readerPubs, err := a.getContactReaderPubs(names)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.Share(envelope, readerPubs)
+}
+
+// ShareExistingWithContact shares the existing envelope with the given key with each reader
+// public key of the named contact, like ShareExisting. It returns the new envelopes and their
+// keys, in the order of the contact's reader public keys.
+func (a *Author) ShareExistingWithContact(envelopeKey id.ID, name string) (
+	[]*api.Document, []id.ID, error) {
+	readerPubs, err := a.getContactReaderPubs([]string{name})
+	if err != nil {
+		return nil, nil, err
+	}
+	envelopes := make([]*api.Document, len(readerPubs))
+	envelopeKeys := make([]id.ID, len(readerPubs))
+	for i, readerPub := range readerPubs {
+		envelopes[i], envelopeKeys[i], err = a.ShareExisting(envelopeKey, readerPub)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return envelopes, envelopeKeys, nil
+}
+
+func (a *Author) getContactReaderPubs(names []string) ([][]byte, error) {
+	contacts, err := loadContacts(a.clientSL)
+	if err != nil {
+		return nil, err
+	}
+	readerPubs := make([][]byte, 0, len(names))
+	for _, name := range names {
+		contact := getContact(contacts, name)
+		if contact == nil {
+			a.logger.Error("contact not in address book", zap.String(LoggerContactName, name))
+			return nil, ErrMissingContact
+		}
+		readerPubs = append(readerPubs, contact.ReaderPublicKeys...)
+	}
+	return readerPubs, nil
+}
+
+func getContact(contacts *Contacts, name string) *Contact {
+	for _, contact := range contacts.Contacts {
+		if contact.Name == name {
+			return contact
+		}
+	}
+	return nil
+}
+
+func hasReaderPub(contact *Contact, readerPub []byte) bool {
+	for _, existing := range contact.ReaderPublicKeys {
+		if bytes.Equal(existing, readerPub) {
+			return true
+		}
+	}
+	return false
+}
+
+func saveContacts(nsl storage.NamespaceStorer, contacts *Contacts) error {
+	marshaled, err := proto.Marshal(contacts)
+	if err != nil {
+		return err
+	}
+	return nsl.Store(contactsKey, marshaled)
+}
+
+// loadContacts loads the address book, which is empty if it hasn't been saved yet.
+func loadContacts(nsl storage.NamespaceLoader) (*Contacts, error) {
+	marshaled, err := nsl.Load(contactsKey)
+	if err != nil {
+		return nil, err
+	}
+	contacts := &Contacts{}
+	if err := proto.Unmarshal(marshaled, contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
@@ -0,0 +1,79 @@
+// Code generated by protoc-gen-go.
+// source: libri/author/contacts.proto
+// DO NOT EDIT!
+
+package author
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// Contacts lists the contacts in an author's address book.
+type Contacts struct {
+	Contacts []*Contact `protobuf:"bytes,1,rep,name=contacts" json:"contacts,omitempty"`
+}
+
+func (m *Contacts) Reset()                    { *m = Contacts{} }
+func (m *Contacts) String() string            { return proto.CompactTextString(m) }
+func (*Contacts) ProtoMessage()               {}
+func (*Contacts) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+
+func (m *Contacts) GetContacts() []*Contact {
+	if m != nil {
+		return m.Contacts
+	}
+	return nil
+}
+
+// Contact names the reader public keys of someone documents are shared with.
+type Contact struct {
+	// unique name of the contact
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// reader public keys of the contact
+	ReaderPublicKeys [][]byte `protobuf:"bytes,2,rep,name=reader_public_keys,json=readerPublicKeys,proto3" json:"reader_public_keys,omitempty"`
+}
+
+func (m *Contact) Reset()                    { *m = Contact{} }
+func (m *Contact) String() string            { return proto.CompactTextString(m) }
+func (*Contact) ProtoMessage()               {}
+func (*Contact) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+
+func (m *Contact) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Contact) GetReaderPublicKeys() [][]byte {
+	if m != nil {
+		return m.ReaderPublicKeys
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Contacts)(nil), "author.Contacts")
+	proto.RegisterType((*Contact)(nil), "author.Contact")
+}
+
+func init() { proto.RegisterFile("libri/author/contacts.proto", fileDescriptor1) }
+
+var fileDescriptor1 = []byte{
+	// 150 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x92, 0xce, 0xc9, 0x4c, 0x2a,
+	0xca, 0xd4, 0x4f, 0x2c, 0x2d, 0xc9, 0xc8, 0x2f, 0xd2, 0x4f, 0xce, 0xcf, 0x2b, 0x49, 0x4c, 0x2e,
+	0x29, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x83, 0x08, 0x2b, 0x99, 0x73, 0x71, 0x38,
+	0x43, 0x65, 0x84, 0xb4, 0xb9, 0x38, 0x60, 0xaa, 0x24, 0x18, 0x15, 0x98, 0x35, 0xb8, 0x8d, 0xf8,
+	0xf5, 0x20, 0xca, 0xf4, 0xa0, 0x6a, 0x82, 0xe0, 0x0a, 0x94, 0xbc, 0xb9, 0xd8, 0xa1, 0x82, 0x42,
+	0x42, 0x5c, 0x2c, 0x79, 0x89, 0xb9, 0xa9, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x60, 0xb6,
+	0x90, 0x0e, 0x97, 0x50, 0x51, 0x6a, 0x62, 0x4a, 0x6a, 0x51, 0x7c, 0x41, 0x69, 0x52, 0x4e, 0x66,
+	0x72, 0x7c, 0x76, 0x6a, 0x65, 0xb1, 0x04, 0x93, 0x02, 0xb3, 0x06, 0x4f, 0x90, 0x00, 0x44, 0x26,
+	0x00, 0x2c, 0xe1, 0x9d, 0x5a, 0x59, 0x9c, 0xc4, 0x06, 0x76, 0x94, 0x31, 0x60, 0x00, 0x5a, 0x4c,
+	0x44, 0x7c, 0xb3, 0x00, 0x00, 0x00,
+}
@@ -0,0 +1,218 @@
+package author
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_AddListRemoveContact(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	pub1 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	pub2 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	pub3 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+
+	// check empty address book has no contacts
+	contacts, err := a.ListContacts()
+	assert.Nil(t, err)
+	assert.Empty(t, contacts)
+
+	err = a.AddContact("bob", pub1)
+	assert.Nil(t, err)
+	err = a.AddContact("alice", pub2, pub3)
+	assert.Nil(t, err)
+
+	// check adding keys to existing contact skips ones it already has
+	err = a.AddContact("bob", pub1, pub3)
+	assert.Nil(t, err)
+
+	contacts, err = a.ListContacts()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Contact{
+		{Name: "alice", ReaderPublicKeys: [][]byte{pub2, pub3}},
+		{Name: "bob", ReaderPublicKeys: [][]byte{pub1, pub3}},
+	}, contacts)
+
+	err = a.RemoveContact("alice")
+	assert.Nil(t, err)
+	contacts, err = a.ListContacts()
+	assert.Nil(t, err)
+	assert.Len(t, contacts, 1)
+	assert.Equal(t, "bob", contacts[0].Name)
+
+	// check contacts persist after the author is restarted
+	assert.Nil(t, a.Close())
+	a2, err := NewAuthor(a.config, a.authorKeys, a.selfReaderKeys, a.logger)
+	assert.Nil(t, err)
+	contacts, err = a2.ListContacts()
+	assert.Nil(t, err)
+	assert.Len(t, contacts, 1)
+	assert.Equal(t, "bob", contacts[0].Name)
+
+	assert.Nil(t, a2.CloseAndRemove())
+}
+
+func TestAuthor_AddContact_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	readerPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+
+	// check empty name creates error
+	err := a.AddContact("", readerPub)
+	assert.Equal(t, ErrEmptyContactName, err)
+
+	// check no reader keys creates error
+	err = a.AddContact("bob")
+	assert.Equal(t, ErrNoReaderPublicKeys, err)
+
+	// check invalid reader key creates error
+	err = a.AddContact("bob", readerPub, []byte{1, 2, 3})
+	assert.NotNil(t, err)
+
+	assert.Nil(t, a.CloseAndRemove())
+
+	// check load error bubbles up
+	a.clientSL = &fixedStorerLoader{loadErr: errors.New("some Load error")}
+	err = a.AddContact("bob", readerPub)
+	assert.NotNil(t, err)
+
+	// check store error bubbles up
+	a.clientSL = &fixedStorerLoader{storeErr: errors.New("some Store error")}
+	err = a.AddContact("bob", readerPub)
+	assert.NotNil(t, err)
+}
+
+func TestAuthor_ListContacts_err(t *testing.T) {
+	a := &Author{}
+
+	// check load error bubbles up
+	a.clientSL = &fixedStorerLoader{loadErr: errors.New("some Load error")}
+	contacts, err := a.ListContacts()
+	assert.NotNil(t, err)
+	assert.Nil(t, contacts)
+
+	// check unmarshal error bubbles up
+	a.clientSL = &fixedStorerLoader{loadBytes: []byte{1, 2, 3}}
+	contacts, err = a.ListContacts()
+	assert.NotNil(t, err)
+	assert.Nil(t, contacts)
+}
+
+func TestAuthor_RemoveContact_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+
+	// check missing contact creates error
+	err := a.RemoveContact("bob")
+	assert.Equal(t, ErrMissingContact, err)
+
+	err = a.AddContact("bob", ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng)))
+	assert.Nil(t, err)
+	marshaled, err := a.clientSL.Load(contactsKey)
+	assert.Nil(t, err)
+	assert.Nil(t, a.CloseAndRemove())
+
+	// check load error bubbles up
+	a.clientSL = &fixedStorerLoader{loadErr: errors.New("some Load error")}
+	err = a.RemoveContact("bob")
+	assert.NotNil(t, err)
+
+	// check store error bubbles up
+	a.clientSL = &fixedStorerLoader{
+		loadBytes: marshaled,
+		storeErr:  errors.New("some Store error"),
+	}
+	err = a.RemoveContact("bob")
+	assert.NotNil(t, err)
+}
+
+func TestAuthor_ShareWithContacts(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	a.librarians, b.librarians = &fixedClientBalancer{}, &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, b.documentSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, b.config.Publish)
+	b.receiver = ship.NewReceiver(b.librarians, b.selfReaderKeys, pubAcq, msAcquirer,
+		b.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	content1 := common.NewCompressableBytes(rng, 1024)
+	content1Bytes := content1.Bytes()
+	envelope, envelopeKey, err := a.Upload(content1, "application/x-gzip")
+	assert.Nil(t, err)
+
+	readerID, err := b.selfReaderKeys.Sample()
+	assert.Nil(t, err)
+	readerPub := ecid.ToPublicKeyBytes(readerID)
+	otherPub1 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	otherPub2 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	assert.Nil(t, a.AddContact("bob", readerPub))
+	assert.Nil(t, a.AddContact("carol", otherPub1, otherPub2))
+
+	shared, sharedKeys, err := a.ShareWithContacts(envelope, []string{"carol", "bob"})
+	assert.Nil(t, err)
+	assert.Len(t, shared, 3)
+	assert.Len(t, sharedKeys, 3)
+	for i, expected := range [][]byte{otherPub1, otherPub2, readerPub} {
+		_, sharedReaderPub, _, err := pack.SeparateEnvelopeDoc(shared[i])
+		assert.Nil(t, err)
+		assert.Equal(t, expected, sharedReaderPub)
+	}
+
+	// check b can download with their reader key
+	content2 := new(bytes.Buffer)
+	err = b.Download(content2, sharedKeys[2])
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes, content2.Bytes())
+
+	shared, sharedKeys, err = a.ShareExistingWithContact(envelopeKey, "carol")
+	assert.Nil(t, err)
+	assert.Len(t, shared, 2)
+	assert.Len(t, sharedKeys, 2)
+	for i, expected := range [][]byte{otherPub1, otherPub2} {
+		_, sharedReaderPub, _, err := pack.SeparateEnvelopeDoc(shared[i])
+		assert.Nil(t, err)
+		assert.Equal(t, expected, sharedReaderPub)
+	}
+
+	// check missing contact creates error
+	shared, sharedKeys, err = a.ShareWithContacts(envelope, []string{"bob", "dave"})
+	assert.Equal(t, ErrMissingContact, err)
+	assert.Nil(t, shared)
+	assert.Nil(t, sharedKeys)
+	shared, sharedKeys, err = a.ShareExistingWithContact(envelopeKey, "dave")
+	assert.Equal(t, ErrMissingContact, err)
+	assert.Nil(t, shared)
+	assert.Nil(t, sharedKeys)
+
+	// check ShareExisting error bubbles up
+	shared, sharedKeys, err = a.ShareExistingWithContact(id.NewPseudoRandom(rng), "carol")
+	assert.NotNil(t, err)
+	assert.Nil(t, shared)
+	assert.Nil(t, sharedKeys)
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
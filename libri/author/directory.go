@@ -0,0 +1,205 @@
+package author
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+const (
+	// ManifestMediaType is the media type of uploaded directory manifest entries.
+	ManifestMediaType = "application/x-libri-manifest"
+
+	// LoggerNFiles is the logger key used for the number of files in a directory.
+	LoggerNFiles = "n_files"
+)
+
+var (
+	// ErrInvalidManifestPath indicates when a manifest file path is absolute or outside of the
+	// directory root.
+	ErrInvalidManifestPath = errors.New("manifest path must be relative and within the root")
+
+	// ErrDirectoryNotEmpty indicates when a directory is downloaded into an existing directory
+	// that isn't empty.
+	ErrDirectoryNotEmpty = errors.New("download directory is not empty")
+)
+
+// UploadDirectory uploads each regular file in the directory tree as its own entry and then a
+// manifest entry linking their relative paths and modes to their envelopes. It returns the
+// manifest's envelope for self-storage and its key. Symlinks and other non-regular files are
+// skipped.
+func (a *Author) UploadDirectory(dir string) (*api.Document, id.ID, error) {
+	manifest, err := newManifest(dir, func(filePath string) (id.ID, error) {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return nil, err
+		}
+		content := &pack.File{File: file, Filepath: relPath}
+		_, envelopeKey, err := a.Upload(content, fileMediaType(filePath))
+		return envelopeKey, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	manifestBytes, err := proto.Marshal(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	envelope, envelopeKey, err := a.Upload(bytes.NewReader(manifestBytes), ManifestMediaType)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.logger.Info("successfully uploaded directory",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+		zap.Int(LoggerNFiles, len(manifest.Files)),
+	)
+	return envelope, envelopeKey, nil
+}
+
+// DownloadDirectory downloads the manifest entry with the given envelope key and then each file it
+// links to, restoring them with their relative paths and modes under the directory, which must
+// not exist or be empty.
+func (a *Author) DownloadDirectory(dir string, manifestEnvelopeKey id.ID) error {
+	manifestBytes := new(bytes.Buffer)
+	if err := a.Download(manifestBytes, manifestEnvelopeKey); err != nil {
+		return err
+	}
+	manifest := &Manifest{}
+	if err := proto.Unmarshal(manifestBytes.Bytes(), manifest); err != nil {
+		return err
+	}
+	err := restoreManifest(dir, manifest, func(filePath string, envelopeKey id.ID) error {
+		return a.DownloadToFile(envelopeKey, filePath)
+	})
+	if err != nil {
+		return err
+	}
+	a.logger.Info("successfully downloaded directory",
+		zap.String(LoggerEnvelopeKey, manifestEnvelopeKey.String()),
+		zap.Int(LoggerNFiles, len(manifest.Files)),
+	)
+	return nil
+}
+
+// newManifest walks the directory tree, uploading each regular file and adding it and each
+// subdirectory to the returned manifest.
+func newManifest(dir string, upload func(filePath string) (id.ID, error)) (*Manifest, error) {
+	manifest := &Manifest{Files: make([]*ManifestFile, 0)}
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == dir || !(info.IsDir() || info.Mode().IsRegular()) {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		mf := &ManifestFile{
+			Path: filepath.ToSlash(relPath),
+			Mode: uint32(info.Mode()),
+		}
+		if info.Mode().IsRegular() {
+			envelopeKey, err := upload(filePath)
+			if err != nil {
+				return err
+			}
+			mf.EnvelopeKey = envelopeKey.Bytes()
+		}
+		manifest.Files = append(manifest.Files, mf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// restoreManifest creates each subdirectory in the manifest and downloads each file under the
+// directory, after checking that every path stays within it.
+func restoreManifest(dir string, manifest *Manifest,
+	download func(filePath string, envelopeKey id.ID) error) error {
+	for _, mf := range manifest.Files {
+		if err := validateManifestFile(mf); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return ErrDirectoryNotEmpty
+	}
+
+	// create subdirectories with user write permission, so their files can be created, and set
+	// their modes once all files are restored
+	dirs := make([]*ManifestFile, 0)
+	for _, mf := range manifest.Files {
+		filePath := filepath.Join(dir, filepath.FromSlash(mf.Path))
+		mode := os.FileMode(mf.Mode)
+		if mode.IsDir() {
+			if err := os.MkdirAll(filePath, 0700); err != nil {
+				return err
+			}
+			dirs = append(dirs, mf)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			return err
+		}
+		if err := download(filePath, id.FromBytes(mf.EnvelopeKey)); err != nil {
+			return err
+		}
+		if err := os.Chmod(filePath, mode.Perm()); err != nil {
+			return err
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		filePath := filepath.Join(dir, filepath.FromSlash(dirs[i].Path))
+		if err := os.Chmod(filePath, os.FileMode(dirs[i].Mode).Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateManifestFile(mf *ManifestFile) error {
+	p := mf.Path
+	if p == "" || path.IsAbs(p) || strings.Contains(p, `\`) {
+		return ErrInvalidManifestPath
+	}
+	cleaned := path.Clean(p)
+	if cleaned != p || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return ErrInvalidManifestPath
+	}
+	if os.FileMode(mf.Mode).IsDir() {
+		return nil
+	}
+	return api.ValidateBytes(mf.EnvelopeKey, id.Length, "EnvelopeKey")
+}
+
+// fileMediaType returns the media type of the file from its extension, or "" if the extension
+// is unknown, so it is detected from the content when packed.
+func fileMediaType(filePath string) string {
+	return mime.TypeByExtension(filepath.Ext(filePath))
+}
@@ -0,0 +1,135 @@
+package author
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRestoreManifest_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	upDir, err := ioutil.TempDir("", "test-upload-dir")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(upDir)) }()
+	contents := map[string][]byte{
+		"a.txt":         []byte("some a contents"),
+		"sub/b.bin":     []byte("some b contents"),
+		"sub/sub2/c.md": []byte("some c contents"),
+	}
+	for relPath, content := range contents {
+		filePath := filepath.Join(upDir, filepath.FromSlash(relPath))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filePath), 0750))
+		assert.Nil(t, ioutil.WriteFile(filePath, content, 0640))
+	}
+	assert.Nil(t, os.Mkdir(filepath.Join(upDir, "empty"), 0700))
+	assert.Nil(t, os.Symlink("a.txt", filepath.Join(upDir, "link.txt")))
+
+	// fake upload keeping the contents by envelope key
+	uploaded := make(map[string][]byte)
+	manifest, err := newManifest(upDir, func(filePath string) (id.ID, error) {
+		key := id.NewPseudoRandom(rng)
+		content, err := ioutil.ReadFile(filePath)
+		uploaded[key.String()] = content
+		return key, err
+	})
+	assert.Nil(t, err)
+	paths := make([]string, len(manifest.Files))
+	for i, mf := range manifest.Files {
+		paths[i] = mf.Path
+	}
+	assert.Equal(t, []string{"a.txt", "empty", "sub", "sub/b.bin", "sub/sub2",
+		"sub/sub2/c.md"}, paths)
+	assert.Len(t, uploaded, len(contents))
+
+	downDir, err := ioutil.TempDir("", "test-download-dir")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(downDir)) }()
+	err = restoreManifest(downDir, manifest, func(filePath string, envelopeKey id.ID) error {
+		return ioutil.WriteFile(filePath, uploaded[envelopeKey.String()], 0600)
+	})
+	assert.Nil(t, err)
+
+	for relPath, content := range contents {
+		filePath := filepath.Join(downDir, filepath.FromSlash(relPath))
+		downloaded, err := ioutil.ReadFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, content, downloaded)
+		info, err := os.Stat(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode())
+	}
+	info, err := os.Stat(filepath.Join(downDir, "sub"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeDir|0750, info.Mode())
+	info, err = os.Stat(filepath.Join(downDir, "empty"))
+	assert.Nil(t, err)
+	assert.True(t, info.IsDir())
+	_, err = os.Lstat(filepath.Join(downDir, "link.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewManifest_err(t *testing.T) {
+	upDir, err := ioutil.TempDir("", "test-upload-dir")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(upDir)) }()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(upDir, "a.txt"), []byte("some a"), 0600))
+
+	// check upload error
+	manifest, err := newManifest(upDir, func(filePath string) (id.ID, error) {
+		return nil, errors.New("some upload error")
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, manifest)
+
+	// check missing directory
+	manifest, err = newManifest(filepath.Join(upDir, "missing"), nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, manifest)
+}
+
+func TestRestoreManifest_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := id.NewPseudoRandom(rng).Bytes()
+	noopDownload := func(filePath string, envelopeKey id.ID) error { return nil }
+	downDir, err := ioutil.TempDir("", "test-download-dir")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(downDir)) }()
+
+	// check invalid manifest files
+	cases := []*ManifestFile{
+		{Path: "", EnvelopeKey: key},
+		{Path: "/etc/passwd", EnvelopeKey: key},
+		{Path: "../outside.txt", EnvelopeKey: key},
+		{Path: "sub/../../outside.txt", EnvelopeKey: key},
+		{Path: "sub/./a.txt", EnvelopeKey: key},
+		{Path: `sub\a.txt`, EnvelopeKey: key},
+		{Path: "a.txt"},
+	}
+	for i, c := range cases {
+		err := restoreManifest(downDir, &Manifest{Files: []*ManifestFile{c}}, noopDownload)
+		assert.NotNil(t, err, "case %d", i)
+	}
+
+	// check download error
+	manifest := &Manifest{Files: []*ManifestFile{{Path: "a.txt", Mode: 0600, EnvelopeKey: key}}}
+	err = restoreManifest(downDir, manifest, func(filePath string, envelopeKey id.ID) error {
+		return errors.New("some download error")
+	})
+	assert.NotNil(t, err)
+
+	// check non-empty directory
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(downDir, "a.txt"), []byte("some a"), 0600))
+	err = restoreManifest(downDir, manifest, noopDownload)
+	assert.Equal(t, ErrDirectoryNotEmpty, err)
+}
+
+func TestFileMediaType(t *testing.T) {
+	assert.Equal(t, "image/png", fileMediaType("some/image.png"))
+	assert.Equal(t, "", fileMediaType("some/file"))
+}
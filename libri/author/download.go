@@ -0,0 +1,99 @@
+package author
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"go.uber.org/zap"
+)
+
+// DownloadToFile downloads the content like Download but writes it to a temporary file in the
+// same directory as the file path, which is only renamed to the file path once it has been
+// synced to disk and its size and MAC match the entry metadata. An interrupted or failed
+// download thus never leaves a partial or corrupt file at the file path.
+func (a *Author) DownloadToFile(envelopeKey id.ID, filePath string) error {
+	a.logger.Debug("receiving entry", zap.String(LoggerEnvelopeKey, envelopeKey.String()))
+	entry, keys, err := a.receiver.Receive(envelopeKey)
+	if err != nil {
+		return err
+	}
+	entryKey, nPages, err := getEntryInfo(entry)
+	if err != nil {
+		return err
+	}
+
+	dir, name := filepath.Split(filePath)
+	tmpFile, err := ioutil.TempFile(dir, "."+name+".download-")
+	if err != nil {
+		return err
+	}
+	if err := a.downloadToTempFile(tmpFile, entry, keys, nPages); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), filePath); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	a.logger.Info("successfully downloaded document to file",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+		zap.String(LoggerEntryKey, entryKey.String()),
+		zap.String("filepath", filePath),
+	)
+	return nil
+}
+
+// downloadToTempFile unpacks the entry content to the temporary file, syncs it to disk, and then
+// checks the written content against the entry metadata.
+func (a *Author) downloadToTempFile(
+	tmpFile *os.File, entry *api.Document, keys *enc.Keys, nPages int,
+) error {
+	a.logger.Debug("unpacking content",
+		zap.String("tmp_filepath", tmpFile.Name()),
+		zap.Int(LoggerNPages, nPages),
+	)
+	metadata, err := a.entryUnpacker.Unpack(tmpFile, entry, keys)
+	if err != nil {
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		return err
+	}
+	return checkFileMAC(tmpFile, keys, metadata)
+}
+
+// checkFileMAC re-reads the file from the beginning and checks that its size and MAC match the
+// uncompressed size and MAC in the metadata.
+func checkFileMAC(file *os.File, keys *enc.Keys, metadata *api.Metadata) error {
+	if err := api.ValidateMetadata(metadata); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	mac := enc.NewHMAC(keys.HMACKey)
+	if _, err := io.Copy(mac, file); err != nil {
+		return err
+	}
+
+	// ignore second boolean argument in Get... calls below b/c we've already validated that
+	// they are present via api.ValidateMetadata call
+	if size, _ := metadata.GetUncompressedSize(); size != mac.MessageSize() {
+		return enc.ErrUnexpectedUncompressedSize
+	}
+	if expected, _ := metadata.GetUncompressedMAC(); !bytes.Equal(expected, mac.Sum(nil)) {
+		return enc.ErrUnexpectedUncompressedMAC
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package author
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_DownloadToFile_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+	dir, err := ioutil.TempDir("", "download-to-file-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.receiver = ship.NewReceiver(a.librarians, a.selfReaderKeys, pubAcq, msAcquirer,
+		a.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	content1 := common.NewCompressableBytes(rng, 1024)
+	content1Bytes := content1.Bytes()
+	_, envelopeKey, err := a.Upload(content1, "application/x-gzip")
+	assert.Nil(t, err)
+
+	// check content1 == content1 --> Upload --> DownloadToFile
+	filePath := path.Join(dir, "content.gz")
+	err = a.DownloadToFile(envelopeKey, filePath)
+	assert.Nil(t, err)
+	content2Bytes, err := ioutil.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes, content2Bytes)
+
+	// check existing file is replaced
+	err = ioutil.WriteFile(filePath, []byte("some existing content"), 0600)
+	assert.Nil(t, err)
+	err = a.DownloadToFile(envelopeKey, filePath)
+	assert.Nil(t, err)
+	content2Bytes, err = ioutil.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes, content2Bytes)
+
+	// check no temporary files are left behind
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_DownloadToFile_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	metadata, err := api.NewEntryMetadata("application/x-pdf", 1, api.RandBytes(rng, 32), 2,
+		api.RandBytes(rng, 32))
+	assert.Nil(t, err)
+	dir, err := ioutil.TempDir("", "download-to-file-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filePath := path.Join(dir, "content.pdf")
+
+	// check Receive error bubbles up
+	a1 := &Author{
+		logger:        clogging.NewDevInfoLogger(),
+		receiver:      &fixedReceiver{err: errors.New("some Receive error")},
+		entryUnpacker: &fixedUnpacker{},
+	}
+	err = a1.DownloadToFile(docKey, filePath)
+	assert.NotNil(t, err)
+
+	// check temp file create error bubbles up
+	a2 := &Author{
+		logger:        clogging.NewDevInfoLogger(),
+		receiver:      &fixedReceiver{entry: doc, keys: keys},
+		entryUnpacker: &fixedUnpacker{metadata: metadata},
+	}
+	err = a2.DownloadToFile(docKey, path.Join(dir, "missing", "content.pdf"))
+	assert.NotNil(t, err)
+
+	// check Unpack error bubbles up
+	a3 := &Author{
+		logger:        clogging.NewDevInfoLogger(),
+		receiver:      &fixedReceiver{entry: doc, keys: keys},
+		entryUnpacker: &fixedUnpacker{err: errors.New("some Unpack error")},
+	}
+	err = a3.DownloadToFile(docKey, filePath)
+	assert.NotNil(t, err)
+
+	// check content inconsistent with metadata creates error
+	a4 := &Author{
+		logger:        clogging.NewDevInfoLogger(),
+		receiver:      &fixedReceiver{entry: doc, keys: keys},
+		entryUnpacker: &fixedUnpacker{metadata: metadata},
+	}
+	err = a4.DownloadToFile(docKey, filePath)
+	assert.Equal(t, enc.ErrUnexpectedUncompressedSize, err)
+
+	// check rename error bubbles up
+	a5 := &Author{
+		logger:        clogging.NewDevInfoLogger(),
+		receiver:      &fixedReceiver{entry: doc, keys: keys},
+		entryUnpacker: &fixedContentUnpacker{content: []byte{1}, keys: keys},
+	}
+	dirPath := path.Join(dir, "existing-dir")
+	assert.Nil(t, os.MkdirAll(path.Join(dirPath, "sub"), 0700))
+	err = a5.DownloadToFile(docKey, dirPath)
+	assert.NotNil(t, err)
+
+	// check failed downloads leave no files behind
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "existing-dir", files[0].Name())
+}
+
+func TestCheckFileMAC(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	content := api.RandBytes(rng, 64)
+	file, err := ioutil.TempFile("", "check-file-mac-test")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	_, err = file.Write(content)
+	assert.Nil(t, err)
+
+	// check consistent file has no error
+	metadata, err := api.NewEntryMetadata("application/x-pdf", 1, api.RandBytes(rng, 32),
+		uint64(len(content)), enc.HMAC(content, keys.HMACKey))
+	assert.Nil(t, err)
+	err = checkFileMAC(file, keys, metadata)
+	assert.Nil(t, err)
+
+	// check invalid metadata creates error
+	err = checkFileMAC(file, keys, &api.Metadata{})
+	assert.NotNil(t, err)
+
+	// check inconsistent size creates error
+	metadata, err = api.NewEntryMetadata("application/x-pdf", 1, api.RandBytes(rng, 32),
+		uint64(len(content)+1), enc.HMAC(content, keys.HMACKey))
+	assert.Nil(t, err)
+	err = checkFileMAC(file, keys, metadata)
+	assert.Equal(t, enc.ErrUnexpectedUncompressedSize, err)
+
+	// check inconsistent MAC creates error
+	metadata, err = api.NewEntryMetadata("application/x-pdf", 1, api.RandBytes(rng, 32),
+		uint64(len(content)), api.RandBytes(rng, 32))
+	assert.Nil(t, err)
+	err = checkFileMAC(file, keys, metadata)
+	assert.Equal(t, enc.ErrUnexpectedUncompressedMAC, err)
+}
+
+type fixedContentUnpacker struct {
+	content []byte
+	keys    *enc.Keys
+}
+
+func (f *fixedContentUnpacker) Unpack(content io.Writer, entry *api.Document, keys *enc.Keys) (
+	*api.Metadata, error) {
+	if _, err := content.Write(f.content); err != nil {
+		return nil, err
+	}
+	return api.NewEntryMetadata("application/x-pdf", 1, enc.HMAC(f.content, f.keys.HMACKey),
+		uint64(len(f.content)), enc.HMAC(f.content, f.keys.HMACKey))
+}
+
+func (f *fixedContentUnpacker) UnpackRange(
+	content io.Writer, entry *api.Document, keys *enc.Keys, offset, length uint64,
+) (*api.Metadata, error) {
+	return f.Unpack(content, entry, keys)
+}
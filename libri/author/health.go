@@ -0,0 +1,195 @@
+package author
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// DefaultHealthcheckInterval is the default time between checks of the librarians' health.
+	DefaultHealthcheckInterval = 10 * time.Second
+
+	// DefaultUploadQuorum is the default fraction of librarians that must be healthy to upload.
+	DefaultUploadQuorum = 0.5
+)
+
+// healthMonitor periodically checks the health of each librarian, tracking the latest status of
+// each and excluding those that aren't serving from the balancer.
+type healthMonitor struct {
+	clients      map[string]healthpb.HealthClient
+	balancer     api.ExclusionClientBalancer
+	interval     time.Duration
+	timeout      time.Duration
+	uploadQuorum float64
+	logger       *zap.Logger
+
+	mu       sync.Mutex
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newHealthMonitor creates a new *healthMonitor of the librarians with the given health clients,
+// keyed by address. Until they are first checked, their statuses are unknown.
+func newHealthMonitor(
+	clients map[string]healthpb.HealthClient,
+	balancer api.ExclusionClientBalancer,
+	interval time.Duration,
+	timeout time.Duration,
+	uploadQuorum float64,
+	logger *zap.Logger,
+) *healthMonitor {
+	statuses := make(map[string]healthpb.HealthCheckResponse_ServingStatus)
+	for addrStr := range clients {
+		statuses[addrStr] = healthpb.HealthCheckResponse_UNKNOWN
+	}
+	if interval == 0 {
+		interval = DefaultHealthcheckInterval
+	}
+	if timeout == 0 {
+		timeout = client.DefaultPingTimeout
+	}
+	return &healthMonitor{
+		clients:      clients,
+		balancer:     balancer,
+		interval:     interval,
+		timeout:      timeout,
+		uploadQuorum: uploadQuorum,
+		logger:       logger,
+		statuses:     statuses,
+		stop:         make(chan struct{}),
+	}
+}
+
+// start checks the librarians' health now and then after every interval until stopped.
+func (m *healthMonitor) start() {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			m.check()
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopChecking stops the periodic checks; it is safe to call more than once.
+func (m *healthMonitor) stopChecking() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// check checks the health of all librarians in parallel, updates their statuses and exclusion
+// from the balancer, and returns the new statuses.
+func (m *healthMonitor) check() map[string]healthpb.HealthCheckResponse_ServingStatus {
+	statuses := make(map[string]healthpb.HealthCheckResponse_ServingStatus)
+	statusesMu := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+	for addrStr, healthClient := range m.clients {
+		wg.Add(1)
+		go func(addrStr string, healthClient healthpb.HealthClient) {
+			defer wg.Done()
+			status := healthpb.HealthCheckResponse_UNKNOWN
+			ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+			rp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
+			cancel()
+			if err == nil {
+				status = rp.Status
+			}
+			statusesMu.Lock()
+			statuses[addrStr] = status
+			statusesMu.Unlock()
+		}(addrStr, healthClient)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for addrStr, status := range statuses {
+		if status != m.statuses[addrStr] {
+			m.logger.Info("librarian peer health changed",
+				zap.String("peer_address", addrStr),
+				zap.Stringer("prev_status", m.statuses[addrStr]),
+				zap.Stringer("status", status),
+			)
+		}
+		m.statuses[addrStr] = status
+		if m.balancer == nil {
+			continue
+		}
+		if status == healthpb.HealthCheckResponse_SERVING {
+			m.balancer.Include(addrStr)
+		} else {
+			m.balancer.Exclude(addrStr)
+		}
+	}
+	return statuses
+}
+
+// getStatuses returns the latest status of each librarian.
+func (m *healthMonitor) getStatuses() map[string]healthpb.HealthCheckResponse_ServingStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make(map[string]healthpb.HealthCheckResponse_ServingStatus)
+	for addrStr, status := range m.statuses {
+		statuses[addrStr] = status
+	}
+	return statuses
+}
+
+// nHealthy returns the number of librarians whose latest status is serving.
+func (m *healthMonitor) nHealthy() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, status := range m.statuses {
+		if status == healthpb.HealthCheckResponse_SERVING {
+			n++
+		}
+	}
+	return n
+}
+
+// canUpload returns whether at least the upload quorum fraction of librarians, and at least one,
+// are healthy.
+func (m *healthMonitor) canUpload() bool {
+	quorum := int(math.Ceil(m.uploadQuorum * float64(len(m.clients))))
+	if quorum < 1 {
+		quorum = 1
+	}
+	return m.nHealthy() >= quorum
+}
+
+// canDownload returns whether at least one librarian is healthy, since any of them can find and
+// get the documents of an entry.
+func (m *healthMonitor) canDownload() bool {
+	return m.nHealthy() >= 1
+}
+
+// LibrarianHealth returns the latest health status of each librarian, keyed by address, as
+// tracked by the author's periodic healthchecks.
+func (a *Author) LibrarianHealth() map[string]healthpb.HealthCheckResponse_ServingStatus {
+	return a.health.getStatuses()
+}
+
+// CanUpload returns whether enough librarians are currently healthy to upload, i.e., at least the
+// UploadQuorum fraction of them.
+func (a *Author) CanUpload() bool {
+	return a.health.canUpload()
+}
+
+// CanDownload returns whether enough librarians are currently healthy to download, i.e., at
+// least one of them.
+func (a *Author) CanDownload() bool {
+	return a.health.canDownload()
+}
@@ -0,0 +1,158 @@
+package author
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthMonitor_check(t *testing.T) {
+	clients := map[string]healthpb.HealthClient{
+		"peerAddr1": &fixedHealthClient{
+			response: &healthpb.HealthCheckResponse{
+				Status: healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		"peerAddr2": &fixedHealthClient{
+			response: &healthpb.HealthCheckResponse{
+				Status: healthpb.HealthCheckResponse_NOT_SERVING,
+			},
+		},
+		"peerAddr3": &fixedHealthClient{
+			err: errors.New("some Check error"),
+		},
+	}
+	balancer := &memExclusionClientBalancer{excluded: make(map[string]struct{})}
+	m := newHealthMonitor(clients, balancer, time.Second, time.Second, DefaultUploadQuorum,
+		clogging.NewDevInfoLogger())
+
+	// check statuses are unknown before first check
+	for _, status := range m.getStatuses() {
+		assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, status)
+	}
+	assert.False(t, m.canUpload())
+	assert.False(t, m.canDownload())
+
+	statuses := m.check()
+	assert.Equal(t, statuses, m.getStatuses())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, statuses["peerAddr1"])
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statuses["peerAddr2"])
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, statuses["peerAddr3"])
+
+	// check unhealthy librarians are excluded from balancer
+	assert.Equal(t, map[string]struct{}{"peerAddr2": {}, "peerAddr3": {}}, balancer.excluded)
+
+	// check 1 of 3 healthy librarians isn't enough to upload but is enough to download
+	assert.False(t, m.canUpload())
+	assert.True(t, m.canDownload())
+
+	// check recovered librarian is included again in balancer
+	clients["peerAddr2"] = &fixedHealthClient{
+		response: &healthpb.HealthCheckResponse{
+			Status: healthpb.HealthCheckResponse_SERVING,
+		},
+	}
+	m.check()
+	assert.Equal(t, map[string]struct{}{"peerAddr3": {}}, balancer.excluded)
+	assert.True(t, m.canUpload())
+	assert.True(t, m.canDownload())
+}
+
+func TestHealthMonitor_canUpload(t *testing.T) {
+	serving := &fixedHealthClient{
+		response: &healthpb.HealthCheckResponse{
+			Status: healthpb.HealthCheckResponse_SERVING,
+		},
+	}
+	notServing := &fixedHealthClient{
+		response: &healthpb.HealthCheckResponse{
+			Status: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+	}
+	cases := []struct {
+		clients  map[string]healthpb.HealthClient
+		quorum   float64
+		expected bool
+	}{
+		{map[string]healthpb.HealthClient{"1": serving}, 0.5, true},
+		{map[string]healthpb.HealthClient{"1": notServing}, 0.5, false},
+		{map[string]healthpb.HealthClient{"1": serving, "2": notServing}, 0.5, true},
+		{map[string]healthpb.HealthClient{"1": serving, "2": notServing}, 1.0, false},
+		{map[string]healthpb.HealthClient{"1": notServing, "2": notServing}, 0.1, false},
+		{map[string]healthpb.HealthClient{"1": serving, "2": serving}, 1.0, true},
+	}
+	for i, c := range cases {
+		m := newHealthMonitor(c.clients, nil, time.Second, time.Second, c.quorum,
+			clogging.NewDevInfoLogger())
+		m.check()
+		assert.Equal(t, c.expected, m.canUpload(), "case %d", i)
+	}
+}
+
+func TestHealthMonitor_start(t *testing.T) {
+	clients := map[string]healthpb.HealthClient{
+		"peerAddr1": &fixedHealthClient{
+			response: &healthpb.HealthCheckResponse{
+				Status: healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+	}
+	m := newHealthMonitor(clients, nil, time.Millisecond, time.Second, DefaultUploadQuorum,
+		clogging.NewDevInfoLogger())
+
+	// check librarians are checked once started
+	m.start()
+	for c := 0; c < 100 && !m.canUpload(); c++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, m.canUpload())
+
+	// check stopping more than once is fine
+	m.stopChecking()
+	m.stopChecking()
+}
+
+func TestAuthor_CanUploadDownload(t *testing.T) {
+	orig := getLibrarianHealthClients
+	getLibrarianHealthClients = func(librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config) (
+		map[string]healthpb.HealthClient, error) {
+		return map[string]healthpb.HealthClient{
+			"peerAddr1": &fixedHealthClient{
+				response: &healthpb.HealthCheckResponse{
+					Status: healthpb.HealthCheckResponse_SERVING,
+				},
+			},
+		}, nil
+	}
+	defer func() { getLibrarianHealthClients = orig }()
+	a := newTestAuthor()
+
+	a.Healthcheck()
+	assert.True(t, a.CanUpload())
+	assert.True(t, a.CanDownload())
+	assert.Equal(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"peerAddr1": healthpb.HealthCheckResponse_SERVING,
+	}, a.LibrarianHealth())
+
+	err := a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+type memExclusionClientBalancer struct {
+	fixedClientBalancer
+	excluded map[string]struct{}
+}
+
+func (m *memExclusionClientBalancer) Exclude(addr string) {
+	m.excluded[addr] = struct{}{}
+}
+
+func (m *memExclusionClientBalancer) Include(addr string) {
+	delete(m.excluded, addr)
+}
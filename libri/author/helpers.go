@@ -1,11 +1,13 @@
 package author
 
 import (
+	"crypto/tls"
+
 	"github.com/drausin/libri/libri/author/io/enc"
 	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc"
 	"net"
 )
 
@@ -39,13 +41,13 @@ func (s *envelopeKeySamplerImpl) sample() ([]byte, []byte, *enc.Keys, error) {
 
 // use var so it's easy to replace for tests w/o a single-method interface
 var getLibrarianHealthClients = func(
-	librarianAddrs []*net.TCPAddr,
+	librarianAddrs []*net.TCPAddr, tlsConfig *tls.Config,
 ) (map[string]healthpb.HealthClient, error) {
 
 	healthClients := make(map[string]healthpb.HealthClient)
 	for _, librarianAddr := range librarianAddrs {
 		addrStr := librarianAddr.String()
-		conn, err := grpc.Dial(addrStr, grpc.WithInsecure())
+		conn, err := api.Dial(librarianAddr, tlsConfig, nil)
 		if err != nil {
 			return nil, err
 		}
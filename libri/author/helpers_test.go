@@ -81,7 +81,7 @@ func TestGetLibrarianHealthClients(t *testing.T) {
 		{IP: net.ParseIP("127.0.0.1"), Port: 20100},
 		{IP: net.ParseIP("127.0.0.1"), Port: 20101},
 	}
-	healthClients, err := getLibrarianHealthClients(librarianAddrs)
+	healthClients, err := getLibrarianHealthClients(librarianAddrs, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(healthClients))
 	_, in := healthClients["127.0.0.1:20100"]
@@ -106,3 +106,23 @@ func (f *fixedKeychain) Get(publicKey []byte) (ecid.ID, bool) {
 func (f *fixedKeychain) Len() int {
 	return 0
 }
+
+func (f *fixedKeychain) Merge(other keychain.Keychain) keychain.Keychain {
+	return nil
+}
+
+func (f *fixedKeychain) Subset(publicKeys [][]byte) (keychain.Keychain, error) {
+	return nil, nil
+}
+
+func (f *fixedKeychain) PublicKeys() [][]byte {
+	return nil
+}
+
+func (f *fixedKeychain) Metadata(publicKey []byte) (keychain.Metadata, bool) {
+	return keychain.Metadata{}, false
+}
+
+func (f *fixedKeychain) SetMetadata(publicKey []byte, md keychain.Metadata) error {
+	return nil
+}
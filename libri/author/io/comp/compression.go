@@ -5,11 +5,14 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 
 	"errors"
 
 	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
 )
 
 // Codec is a comp.codec.
@@ -22,6 +25,12 @@ const (
 	// GZIPCodec indicates gzip comp.
 	GZIPCodec Codec = "gzip"
 
+	// ZstdCodec indicates zstd comp.
+	ZstdCodec Codec = "zstd"
+
+	// LZ4Codec indicates lz4 comp.
+	LZ4Codec Codec = "lz4"
+
 	// DefaultCodec defines the default comp.scheme.
 	DefaultCodec = GZIPCodec
 
@@ -34,8 +43,32 @@ const (
 	DefaultBufferSize = uint32(1024)
 )
 
-// ErrBufferSizeTooSmall indicates when the max page size is too small (often because it is zero).
-var ErrBufferSizeTooSmall = fmt.Errorf("buffer size is below %d byte minimum", MinBufferSize)
+const (
+	// lz4BestCompressionLevel is the lz4 high compression level with the best comp.
+	lz4BestCompressionLevel = 9
+
+	// lz4BlockMaxSize is the smallest lz4 block size, which limits the lz4.Writer's buffers.
+	lz4BlockMaxSize = 64 * 1024
+)
+
+var (
+	// ErrBufferSizeTooSmall indicates when the max page size is too small (often because it is
+	// zero).
+	ErrBufferSizeTooSmall = fmt.Errorf("buffer size is below %d byte minimum", MinBufferSize)
+
+	// ErrUnsupportedCodec indicates when a comp.codec isn't one of the supported codecs.
+	ErrUnsupportedCodec = errors.New("unsupported compression codec")
+)
+
+// ValidateCodec checks that the comp.codec is one of the supported codecs.
+func ValidateCodec(codec Codec) error {
+	switch codec {
+	case NoneCodec, GZIPCodec, ZstdCodec, LZ4Codec:
+		return nil
+	default:
+		return ErrUnsupportedCodec
+	}
+}
 
 // MediaToCompressionCodec maps MIME media types to what comp.codec should be used with
 // them.
@@ -113,6 +146,7 @@ type compressor struct {
 	buf                    *bytes.Buffer
 	uncompressedMAC        enc.MAC
 	uncompressedBufferSize uint32
+	closed                 bool
 }
 
 // NewCompressor creates a new Compressor for the compressed contents using the given compression
@@ -121,44 +155,86 @@ type compressor struct {
 func NewCompressor(
 	uncompressed io.Reader, codec Codec, keys *enc.Keys, uncompressedBufferSize uint32,
 ) (Compressor, error) {
-	var err error
-	var inner FlushCloseWriter
 	buf := new(bytes.Buffer)
+	inner, err := newInnerCompressor(buf, codec)
+	if err != nil {
+		return nil, err
+	}
+	if uncompressedBufferSize < MinBufferSize {
+		return nil, ErrBufferSizeTooSmall
+	}
+	return &compressor{
+		uncompressed:           uncompressed,
+		inner:                  inner,
+		buf:                    buf,
+		uncompressedMAC:        enc.NewHMAC(keys.HMACKey),
+		uncompressedBufferSize: uncompressedBufferSize,
+	}, nil
+}
 
+// newInnerCompressor creates a new FlushCloseWriter given the codec.
+func newInnerCompressor(buf io.Writer, codec Codec) (FlushCloseWriter, error) {
 	switch codec {
 	case GZIPCodec:
 		// optimize for best comp.to reduce network transfer volume and time (at
 		// expense of more client CPU)
-		inner, err = gzip.NewWriterLevel(buf, gzip.BestCompression)
+		return gzip.NewWriterLevel(buf, gzip.BestCompression)
+	case ZstdCodec:
+		return zstd.NewWriter(buf, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	case LZ4Codec:
+		inner := lz4.NewWriter(buf)
+		inner.Header.CompressionLevel = lz4BestCompressionLevel
+		inner.Header.BlockMaxSize = lz4BlockMaxSize
+		return inner, nil
 	case NoneCodec:
 		// inner, err = gzip.NewWriterLevel(buf, gzip.NoCompression)
-		inner = &noOpFlushCloseWriter{buf}
+		return &noOpFlushCloseWriter{buf}, nil
 	default:
 		panic(fmt.Errorf("unexpected codec: %s", codec))
 	}
+}
 
+// Compress compresses all of the uncompressed bytes at once using the given codec, e.g., for a
+// single page of content-defined chunks.
+func Compress(uncompressed []byte, codec Codec) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	inner, err := newInnerCompressor(buf, codec)
 	if err != nil {
 		return nil, err
 	}
-	if uncompressedBufferSize < MinBufferSize {
-		return nil, ErrBufferSizeTooSmall
+	if _, err := inner.Write(uncompressed); err != nil {
+		return nil, err
 	}
-	return &compressor{
-		uncompressed:           uncompressed,
-		inner:                  inner,
-		buf:                    buf,
-		uncompressedMAC:        enc.NewHMAC(keys.HMACKey),
-		uncompressedBufferSize: uncompressedBufferSize,
-	}, nil
+	if err := inner.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses all of the compressed bytes at once using the given codec.
+func Decompress(compressed []byte, codec Codec) ([]byte, error) {
+	inner, err := newInnerDecompressor(bytes.NewReader(compressed), codec)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := inner.(io.Closer); ok {
+		defer closer.Close()
+	}
+	uncompressed, err := ioutil.ReadAll(inner)
+	if err != nil {
+		return nil, err
+	}
+	return uncompressed, nil
 }
 
 // Read reads compressed contents into p from the underling uncompressed io.Reader.
 func (c *compressor) Read(p []byte) (int, error) {
-	// write compressed contents into buffer until we have enough for p
-	for c.buf.Len() < len(p) {
+	// write compressed contents into buffer until we have enough for p or the inner writer has
+	// been closed
+	for !c.closed && c.buf.Len() < len(p) {
 		more := make([]byte, int(c.uncompressedBufferSize))
-		nMore, err := c.uncompressed.Read(more)
-		if err != nil && err != io.EOF {
+		nMore, err := io.ReadFull(c.uncompressed, more)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 			return 0, err
 		}
 		if _, err = c.inner.Write(more[:nMore]); err != nil {
@@ -176,6 +252,7 @@ func (c *compressor) Read(p []byte) (int, error) {
 			if err = c.inner.Close(); err != nil {
 				return 0, err
 			}
+			c.closed = true
 			break
 		}
 	}
@@ -236,6 +313,9 @@ func NewDecompressor(
 	if uncompressedBufferSize < MinBufferSize {
 		return nil, ErrBufferSizeTooSmall
 	}
+	if codec == ZstdCodec || codec == LZ4Codec {
+		return newBlockDecompressor(uncompressed, codec, keys, uncompressedBufferSize), nil
+	}
 	return &decompressor{
 		uncompressed:           uncompressed,
 		inner:                  nil,
@@ -252,6 +332,14 @@ func newInnerDecompressor(buf io.Reader, codec Codec) (io.Reader, error) {
 	switch codec {
 	case GZIPCodec:
 		return gzip.NewReader(buf)
+	case ZstdCodec:
+		dec, err := zstd.NewReader(buf, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case LZ4Codec:
+		return lz4.NewReader(buf), nil
 	case NoneCodec:
 		return buf, nil
 	default:
@@ -321,3 +409,71 @@ func (d *decompressor) Close() error {
 	d.closed = true
 	return nil
 }
+
+// blockDecompressor implements Decompressor for codecs whose readers read whole blocks of
+// compressed contents at a time, which may span more than a single Write. Compressed contents are
+// written to an io.Pipe, from which a separate goroutine reads and decompresses them to the
+// underlying uncompressed io.Writer.
+type blockDecompressor struct {
+	compressed      *io.PipeWriter
+	uncompressedMAC enc.MAC
+	done            chan error
+	closed          bool
+}
+
+func newBlockDecompressor(
+	uncompressed io.Writer, codec Codec, keys *enc.Keys, uncompressedBufferSize uint32,
+) *blockDecompressor {
+	compressedR, compressedW := io.Pipe()
+	d := &blockDecompressor{
+		compressed:      compressedW,
+		uncompressedMAC: enc.NewHMAC(keys.HMACKey),
+		done:            make(chan error, 1),
+	}
+	go func() {
+		err := d.decompress(compressedR, uncompressed, codec, uncompressedBufferSize)
+		compressedR.CloseWithError(err)
+		d.done <- err
+	}()
+	return d
+}
+
+func (d *blockDecompressor) decompress(
+	compressed io.Reader, uncompressed io.Writer, codec Codec, uncompressedBufferSize uint32,
+) error {
+	inner, err := newInnerDecompressor(compressed, codec)
+	if err != nil {
+		return err
+	}
+	if closer, ok := inner.(io.Closer); ok {
+		defer closer.Close()
+	}
+	_, err = io.CopyBuffer(io.MultiWriter(d.uncompressedMAC, uncompressed), inner,
+		make([]byte, uncompressedBufferSize))
+	return err
+}
+
+// Write writes compressed p to the pipe, returning once all of it has been read for
+// decompression.
+func (d *blockDecompressor) Write(p []byte) (int, error) {
+	if d.closed {
+		return 0, errors.New("decompressor is closed")
+	}
+	return d.compressed.Write(p)
+}
+
+func (d *blockDecompressor) UncompressedMAC() enc.MAC {
+	return d.uncompressedMAC
+}
+
+// Close waits for the remaining contents to be written to the underlying uncompressed io.Writer.
+func (d *blockDecompressor) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	if err := d.compressed.Close(); err != nil {
+		return err
+	}
+	return <-d.done
+}
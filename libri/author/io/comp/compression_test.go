@@ -5,13 +5,16 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"testing"
+	"testing/iotest"
 
 	"errors"
 
 	"github.com/drausin/libri/libri/author/io/common"
 	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,6 +41,14 @@ func TestGetCompressionCodec(t *testing.T) {
 
 }
 
+func TestValidateCodec(t *testing.T) {
+	for _, codec := range []Codec{NoneCodec, GZIPCodec, ZstdCodec, LZ4Codec} {
+		assert.Nil(t, ValidateCodec(codec))
+	}
+	assert.Equal(t, ErrUnsupportedCodec, ValidateCodec(Codec("unexpected")))
+	assert.Equal(t, ErrUnsupportedCodec, ValidateCodec(Codec("")))
+}
+
 func TestNewCompressor_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	keys, _, _ := enc.NewPseudoRandomKeys(rng)
@@ -83,6 +94,18 @@ func TestNewDecompressor_ok(t *testing.T) {
 	assert.Equal(t, minUncompressedBufferSize, comp.(*decompressor).uncompressedBufferSize)
 }
 
+func TestNewDecompressor_block(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	for _, codec := range []Codec{ZstdCodec, LZ4Codec} {
+		decomp, err := NewDecompressor(new(bytes.Buffer), codec, keys, MinBufferSize)
+		assert.Nil(t, err)
+		assert.NotNil(t, decomp.(*blockDecompressor).compressed)
+		assert.NotNil(t, decomp.UncompressedMAC())
+		assert.Nil(t, decomp.Close())
+	}
+}
+
 func TestNewDecompressor_err(t *testing.T) {
 	// too small uncompressed buffer
 	comp, err := NewDecompressor(new(bytes.Buffer), GZIPCodec, nil, 0)
@@ -146,6 +169,24 @@ func TestCompressor_Read_ok(t *testing.T) {
 	// TODO check uncompressedMAC
 }
 
+func TestCompressor_Read_shortReads(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	uncompressedBytes := common.NewCompressableBytes(rng, int(4*MinBufferSize)).Bytes()
+
+	// check reader returning fewer bytes than requested doesn't end the content early
+	comp, err := NewCompressor(
+		iotest.OneByteReader(bytes.NewReader(uncompressedBytes)),
+		NoneCodec,
+		keys,
+		MinBufferSize,
+	)
+	assert.Nil(t, err)
+	compressed, err := ioutil.ReadAll(comp)
+	assert.Nil(t, err)
+	assert.Equal(t, uncompressedBytes, compressed)
+}
+
 func TestCompressor_Read_err(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	keys, _, _ := enc.NewPseudoRandomKeys(rng)
@@ -268,9 +309,35 @@ func TestDecompressor_Write_err(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestBlockDecompressor_Write_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	decomp, err := NewDecompressor(new(bytes.Buffer), ZstdCodec, keys, MinBufferSize)
+	assert.Nil(t, err)
+	assert.Nil(t, decomp.Close())
+
+	// check that Write when decomp is closed produces errors
+	n, err := decomp.Write(api.RandBytes(rng, int(MinBufferSize)))
+	assert.NotNil(t, err)
+	assert.Zero(t, n)
+
+	// check that bad compressed contents produce errors
+	for _, codec := range []Codec{ZstdCodec, LZ4Codec} {
+		decomp, err = NewDecompressor(new(bytes.Buffer), codec, keys, MinBufferSize)
+		assert.Nil(t, err)
+		_, err = decomp.Write(api.RandBytes(rng, int(MinBufferSize)))
+		if err == nil {
+			err = decomp.Close()
+		}
+		assert.NotNil(t, err, string(codec))
+	}
+}
+
 func TestCompressDecompress(t *testing.T) {
 	mediaCases := []mediaTestCase{
 		{GZIPCodec, false},
+		{ZstdCodec, false},
+		{LZ4Codec, false},
 		{NoneCodec, true}, // equalSize since we're not compressing twice
 	}
 	uncompressedSizes := []int{128, 192, 256, 384, 512, 1024}
@@ -331,6 +398,33 @@ func TestCompressDecompress(t *testing.T) {
 	}
 }
 
+func TestCompressDecompressBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for _, codec := range []Codec{NoneCodec, GZIPCodec, ZstdCodec, LZ4Codec} {
+		for _, size := range []int{0, 1, 128, 1024, 8192} {
+			uncompressed1 := common.NewCompressableBytes(rng, size).Bytes()
+			compressed, err := Compress(uncompressed1, codec)
+			assert.Nil(t, err, string(codec))
+			if codec != NoneCodec && size >= 1024 {
+				assert.True(t, len(compressed) < size, string(codec))
+			}
+			uncompressed2, err := Decompress(compressed, codec)
+			assert.Nil(t, err, string(codec))
+			assert.Equal(t, len(uncompressed1), len(uncompressed2), string(codec))
+			assert.True(t, bytes.Equal(uncompressed1, uncompressed2), string(codec))
+		}
+	}
+}
+
+func TestDecompressBytes_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for _, codec := range []Codec{GZIPCodec, ZstdCodec, LZ4Codec} {
+		uncompressed, err := Decompress(api.RandBytes(rng, 128), codec)
+		assert.NotNil(t, err, string(codec))
+		assert.Nil(t, uncompressed, string(codec))
+	}
+}
+
 func TestTrimBuffer(t *testing.T) {
 	buf := new(bytes.Buffer)
 
@@ -0,0 +1,72 @@
+package enc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+// ErrCiphertextTooShort indicates when a ciphertext is too short to contain its nonce.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// EncryptedKeys contains both the ciphertext and ciphertext MAC involved in encrypting the entry
+// encryption keys (EEK) with the key encryption keys (KEK) derived from an author and reader's
+// ECDH shared secret.
+type EncryptedKeys struct {
+	Ciphertext    []byte
+	CiphertextMAC []byte
+}
+
+// NewEncryptedKeys creates a new *EncryptedKeys instance if the ciphertext and ciphertextMAC are
+// valid.
+func NewEncryptedKeys(ciphertext, ciphertextMAC []byte) (*EncryptedKeys, error) {
+	if err := api.ValidateNotEmpty(ciphertext, "EekCiphertext"); err != nil {
+		return nil, err
+	}
+	if err := api.ValidateHMAC256(ciphertextMAC); err != nil {
+		return nil, err
+	}
+	return &EncryptedKeys{
+		Ciphertext:    ciphertext,
+		CiphertextMAC: ciphertextMAC,
+	}, nil
+}
+
+// EncryptKeys encrypts the entry encryption keys with the KEK AES key and a random nonce, which
+// is prepended to the ciphertext since the same KEK may encrypt many entries' keys.
+func EncryptKeys(eek *Keys, kek *Keys) (*EncryptedKeys, error) {
+	cipher, err := newGCMCipher(kek.AESKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, cipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := cipher.Seal(nonce, nonce, Marshal(eek), nil)
+	return NewEncryptedKeys(ciphertext, HMAC(ciphertext, kek.HMACKey))
+}
+
+// DecryptKeys decrypts the entry encryption keys with the KEK. It returns ErrUnexpectedMAC if the
+// calculated ciphertext MAC does not match the expected ciphertext MAC.
+func DecryptKeys(ek *EncryptedKeys, kek *Keys) (*Keys, error) {
+	mac := HMAC(ek.Ciphertext, kek.HMACKey)
+	if !bytes.Equal(ek.CiphertextMAC, mac) {
+		return nil, ErrUnexpectedMAC
+	}
+	cipher, err := newGCMCipher(kek.AESKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(ek.Ciphertext) < cipher.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce, ciphertext := ek.Ciphertext[:cipher.NonceSize()], ek.Ciphertext[cipher.NonceSize():]
+	plaintext, err := cipher.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(plaintext)
+}
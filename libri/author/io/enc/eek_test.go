@@ -0,0 +1,68 @@
+package enc
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEncryptedKeys_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	// check errors on invalid ciphertext
+	ek1, err := NewEncryptedKeys([]byte{}, api.RandBytes(rng, 32))
+	assert.NotNil(t, err)
+	assert.Nil(t, ek1)
+
+	// check errors on invalid ciphertextMAC
+	ek2, err := NewEncryptedKeys(api.RandBytes(rng, 64), api.RandBytes(rng, 8))
+	assert.NotNil(t, err)
+	assert.Nil(t, ek2)
+}
+
+func TestEncryptDecryptKeys(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	eek, _, _ := NewPseudoRandomKeys(rng)
+	kek, _, _ := NewPseudoRandomKeys(rng)
+
+	ek1, err := EncryptKeys(eek, kek)
+	assert.Nil(t, err)
+	decrypted, err := DecryptKeys(ek1, kek)
+	assert.Nil(t, err)
+	assert.Equal(t, eek, decrypted)
+
+	// check random nonce gives different ciphertext for same keys
+	ek2, err := EncryptKeys(eek, kek)
+	assert.Nil(t, err)
+	assert.NotEqual(t, ek1.Ciphertext, ek2.Ciphertext)
+}
+
+func TestDecryptKeys_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	eek, _, _ := NewPseudoRandomKeys(rng)
+	kek, _, _ := NewPseudoRandomKeys(rng)
+	otherKEK, _, _ := NewPseudoRandomKeys(rng)
+	ek, err := EncryptKeys(eek, kek)
+	assert.Nil(t, err)
+
+	// check different KEK gives unexpected MAC
+	decrypted, err := DecryptKeys(ek, otherKEK)
+	assert.Equal(t, ErrUnexpectedMAC, err)
+	assert.Nil(t, decrypted)
+
+	// check too short ciphertext
+	short := []byte{1, 2, 3}
+	decrypted, err = DecryptKeys(&EncryptedKeys{short, HMAC(short, kek.HMACKey)}, kek)
+	assert.Equal(t, ErrCiphertextTooShort, err)
+	assert.Nil(t, decrypted)
+
+	// check bad AES key
+	badKEK := &Keys{AESKey: api.RandBytes(rng, 8), HMACKey: kek.HMACKey}
+	decrypted, err = DecryptKeys(ek, badKEK)
+	assert.NotNil(t, err)
+	assert.Nil(t, decrypted)
+	_, err = EncryptKeys(eek, badKEK)
+	assert.NotNil(t, err)
+}
@@ -1,7 +1,6 @@
 package enc
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -16,33 +15,25 @@ type Encrypter interface {
 }
 
 type encrypter struct {
-	gcmCipher cipher.AEAD
+	aead      cipher.AEAD
 	pageIVMAC hash.Hash
 }
 
-// NewEncrypter creates a new Encrypter using the encryption keys.
-func NewEncrypter(keys *Keys) (Encrypter, error) {
-	gcmCipher, err := newGCMCipher(keys.AESKey)
+// NewEncrypter creates a new Encrypter using the encryption keys and AEAD suite.
+func NewEncrypter(keys *Keys, suite AEADSuite) (Encrypter, error) {
+	aead, err := newAEAD(suite, keys.AESKey)
 	if err != nil {
 		return nil, err
 	}
 	return &encrypter{
-		gcmCipher: gcmCipher,
+		aead:      aead,
 		pageIVMAC: hmac.New(sha256.New, keys.PageIVSeed),
 	}, nil
 }
 
-func newGCMCipher(aesKey []byte) (cipher.AEAD, error) {
-	block, err := aes.NewCipher(aesKey)
-	if err != nil {
-		return nil, err
-	}
-	return cipher.NewGCM(block)
-}
-
 func (e *encrypter) Encrypt(plaintext []byte, pageIndex uint32) ([]byte, error) {
-	pageIV := generatePageIV(pageIndex, e.pageIVMAC, e.gcmCipher.NonceSize())
-	ciphertext := e.gcmCipher.Seal(nil, pageIV, plaintext, nil)
+	pageIV := generatePageIV(pageIndex, e.pageIVMAC, e.aead.NonceSize())
+	ciphertext := e.aead.Seal(nil, pageIV, plaintext, nil)
 	return ciphertext, nil
 }
 
@@ -53,25 +44,76 @@ type Decrypter interface {
 }
 
 type decrypter struct {
-	gcmCipher cipher.AEAD
+	aead      cipher.AEAD
 	pageIVMAC hash.Hash
 }
 
-// NewDecrypter creates a new Decrypter instance using the encryption keys.
-func NewDecrypter(keys *Keys) (Decrypter, error) {
-	gcmCipher, err := newGCMCipher(keys.AESKey)
+// NewDecrypter creates a new Decrypter instance using the encryption keys and AEAD suite.
+func NewDecrypter(keys *Keys, suite AEADSuite) (Decrypter, error) {
+	aead, err := newAEAD(suite, keys.AESKey)
 	if err != nil {
 		return nil, err
 	}
 	return &decrypter{
-		gcmCipher: gcmCipher,
+		aead:      aead,
 		pageIVMAC: hmac.New(sha256.New, keys.PageIVSeed),
 	}, nil
 }
 
 func (d *decrypter) Decrypt(ciphertext []byte, pageIndex uint32) ([]byte, error) {
-	pageIV := generatePageIV(pageIndex, d.pageIVMAC, d.gcmCipher.NonceSize())
-	return d.gcmCipher.Open(nil, pageIV, ciphertext, nil)
+	pageIV := generatePageIV(pageIndex, d.pageIVMAC, d.aead.NonceSize())
+	return d.aead.Open(nil, pageIV, ciphertext, nil)
+}
+
+type contentEncrypter struct {
+	aead      cipher.AEAD
+	pageIVMAC hash.Hash
+}
+
+// NewContentEncrypter creates a new Encrypter using the encryption keys and AEAD suite that
+// derives each page's IV from its plaintext rather than its index, so identical plaintexts
+// encrypted with the same keys have identical ciphertexts regardless of their page index. The IV
+// is prepended to the ciphertext.
+func NewContentEncrypter(keys *Keys, suite AEADSuite) (Encrypter, error) {
+	aead, err := newAEAD(suite, keys.AESKey)
+	if err != nil {
+		return nil, err
+	}
+	return &contentEncrypter{
+		aead:      aead,
+		pageIVMAC: hmac.New(sha256.New, keys.PageIVSeed),
+	}, nil
+}
+
+func (e *contentEncrypter) Encrypt(plaintext []byte, pageIndex uint32) ([]byte, error) {
+	e.pageIVMAC.Reset()
+	if _, err := e.pageIVMAC.Write(plaintext); err != nil {
+		return nil, err
+	}
+	pageIV := e.pageIVMAC.Sum(nil)[:e.aead.NonceSize()]
+	return e.aead.Seal(pageIV, pageIV, plaintext, nil), nil
+}
+
+type contentDecrypter struct {
+	aead cipher.AEAD
+}
+
+// NewContentDecrypter creates a new Decrypter instance using the encryption keys and AEAD suite for
+// ciphertexts from a content Encrypter.
+func NewContentDecrypter(keys *Keys, suite AEADSuite) (Decrypter, error) {
+	aead, err := newAEAD(suite, keys.AESKey)
+	if err != nil {
+		return nil, err
+	}
+	return &contentDecrypter{aead: aead}, nil
+}
+
+func (d *contentDecrypter) Decrypt(ciphertext []byte, pageIndex uint32) ([]byte, error) {
+	if len(ciphertext) < d.aead.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+	pageIV, ciphertext := ciphertext[:d.aead.NonceSize()], ciphertext[d.aead.NonceSize():]
+	return d.aead.Open(nil, pageIV, ciphertext, nil)
 }
 
 func generatePageIV(pageIndex uint32, pageIVMac hash.Hash, size int) []byte {
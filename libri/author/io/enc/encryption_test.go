@@ -10,65 +10,143 @@ import (
 func TestNewEncrypter_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	keys, _, _ := NewPseudoRandomKeys(rng)
-	enc, err := NewEncrypter(keys)
+	enc, err := NewEncrypter(keys, DefaultAEADSuite)
 	assert.Nil(t, err)
-	assert.NotNil(t, enc.(*encrypter).gcmCipher)
+	assert.NotNil(t, enc.(*encrypter).aead)
 	assert.NotNil(t, enc.(*encrypter).pageIVMAC)
 }
 
 func TestNewEncrypter_err(t *testing.T) {
-	enc, err := NewEncrypter(&Keys{})
+	enc, err := NewEncrypter(&Keys{}, DefaultAEADSuite)
 	assert.NotNil(t, err)
 	assert.Nil(t, enc)
+
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := NewPseudoRandomKeys(rng)
+	enc, err = NewEncrypter(keys, AEADSuite("unsupported"))
+	assert.Equal(t, ErrUnsupportedAEADSuite, err)
+	assert.Nil(t, enc)
 }
 
 func TestNewDecrypter_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	keys, _, _ := NewPseudoRandomKeys(rng)
-	enc, err := NewDecrypter(keys)
+	enc, err := NewDecrypter(keys, DefaultAEADSuite)
 	assert.Nil(t, err)
-	assert.NotNil(t, enc.(*decrypter).gcmCipher)
+	assert.NotNil(t, enc.(*decrypter).aead)
 	assert.NotNil(t, enc.(*decrypter).pageIVMAC)
 }
 
 func TestNewDecrypter_err(t *testing.T) {
-	enc, err := NewDecrypter(&Keys{})
+	enc, err := NewDecrypter(&Keys{}, DefaultAEADSuite)
 	assert.NotNil(t, err)
 	assert.Nil(t, enc)
 }
 
 func TestEncryptDecrypt(t *testing.T) {
-	rng := rand.New(rand.NewSource(0))
-	keys, _, _ := NewPseudoRandomKeys(rng)
-	nPlaintextBytesPerPage, nPages := 32, uint32(3)
+	for _, suite := range []AEADSuite{AESGCMSuite, XChaCha20Poly1305Suite} {
+		rng := rand.New(rand.NewSource(0))
+		keys, _, _ := NewPseudoRandomKeys(rng)
+		nPlaintextBytesPerPage, nPages := 32, uint32(3)
 
-	encrypter, err := NewEncrypter(keys)
-	assert.Nil(t, err)
+		encrypter, err := NewEncrypter(keys, suite)
+		assert.Nil(t, err)
 
-	decrypter, err := NewDecrypter(keys)
-	assert.Nil(t, err)
+		decrypter, err := NewDecrypter(keys, suite)
+		assert.Nil(t, err)
+
+		otherSuite := XChaCha20Poly1305Suite
+		if suite == XChaCha20Poly1305Suite {
+			otherSuite = AESGCMSuite
+		}
+		otherDecrypter, err := NewDecrypter(keys, otherSuite)
+		assert.Nil(t, err)
+
+		for p := uint32(0); p < nPages; p++ {
+
+			plaintext1 := make([]byte, nPlaintextBytesPerPage)
+			n0, err := rng.Read(plaintext1)
+			assert.Equal(t, n0, nPlaintextBytesPerPage)
+			assert.Nil(t, err)
 
-	for p := uint32(0); p < nPages; p++ {
+			ciphertext, err := encrypter.Encrypt(plaintext1, p)
+			assert.Nil(t, err)
 
-		plaintext1 := make([]byte, nPlaintextBytesPerPage)
-		n0, err := rng.Read(plaintext1)
-		assert.Equal(t, n0, nPlaintextBytesPerPage)
+			// ciphertext can be longer b/c of AEAD auth overhead
+			assert.True(t, len(ciphertext) >= len(plaintext1))
+
+			// check that page number matters
+			diffCiphertext, err := encrypter.Encrypt(plaintext1, p+1)
+			assert.Nil(t, err)
+			assert.NotEqual(t, ciphertext, diffCiphertext)
+
+			// check that decrypted plaintext matches original
+			plaintext2, err := decrypter.Decrypt(ciphertext, p)
+			assert.Nil(t, err)
+			assert.Equal(t, plaintext1, plaintext2)
+
+			// check that decrypting with the other suite errors
+			plaintext3, err := otherDecrypter.Decrypt(ciphertext, p)
+			assert.NotNil(t, err)
+			assert.Nil(t, plaintext3)
+		}
+	}
+}
+
+func TestNewContentEncrypterDecrypter_err(t *testing.T) {
+	enc, err := NewContentEncrypter(&Keys{}, DefaultAEADSuite)
+	assert.NotNil(t, err)
+	assert.Nil(t, enc)
+
+	dec, err := NewContentDecrypter(&Keys{}, DefaultAEADSuite)
+	assert.NotNil(t, err)
+	assert.Nil(t, dec)
+}
+
+func TestContentEncryptDecrypt(t *testing.T) {
+	for _, suite := range []AEADSuite{AESGCMSuite, XChaCha20Poly1305Suite} {
+		rng := rand.New(rand.NewSource(0))
+		keys, _, _ := NewPseudoRandomKeys(rng)
+		encrypter, err := NewContentEncrypter(keys, suite)
+		assert.Nil(t, err)
+		decrypter, err := NewContentDecrypter(keys, suite)
 		assert.Nil(t, err)
 
-		ciphertext, err := encrypter.Encrypt(plaintext1, p)
+		plaintext1 := make([]byte, 32)
+		_, err = rng.Read(plaintext1)
 		assert.Nil(t, err)
 
-		// ciphertext can be longer b/c of GCM auth overhead
-		assert.True(t, len(ciphertext) >= len(plaintext1))
+		ciphertext1, err := encrypter.Encrypt(plaintext1, 0)
+		assert.Nil(t, err)
+		plaintext2, err := decrypter.Decrypt(ciphertext1, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, plaintext1, plaintext2)
 
-		// check that page number matters
-		diffCiphertext, err := encrypter.Encrypt(plaintext1, p+1)
+		// check same plaintext has same ciphertext regardless of page index
+		ciphertext2, err := encrypter.Encrypt(plaintext1, 1)
 		assert.Nil(t, err)
-		assert.NotEqual(t, ciphertext, diffCiphertext)
+		assert.Equal(t, ciphertext1, ciphertext2)
 
-		// check that decrypted plaintext matches original
-		plaintext2, err := decrypter.Decrypt(ciphertext, p)
+		// check different plaintext has different IV
+		ciphertext3, err := encrypter.Encrypt(plaintext1[1:], 0)
 		assert.Nil(t, err)
-		assert.Equal(t, plaintext1, plaintext2)
+		assert.NotEqual(t, ciphertext1[:12], ciphertext3[:12])
+
+		// check different keys give different ciphertext
+		keys2, _, _ := NewPseudoRandomKeys(rng)
+		encrypter2, err := NewContentEncrypter(keys2, suite)
+		assert.Nil(t, err)
+		ciphertext4, err := encrypter2.Encrypt(plaintext1, 0)
+		assert.Nil(t, err)
+		assert.NotEqual(t, ciphertext1, ciphertext4)
+
+		// check too short and modified ciphertexts error
+		plaintext3, err := decrypter.Decrypt(ciphertext1[:8], 0)
+		assert.Equal(t, ErrCiphertextTooShort, err)
+		assert.Nil(t, plaintext3)
+		ciphertext1[len(ciphertext1)-1]++
+		plaintext3, err = decrypter.Decrypt(ciphertext1, 0)
+		assert.NotNil(t, err)
+		assert.Nil(t, plaintext3)
 	}
 }
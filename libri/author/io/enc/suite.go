@@ -0,0 +1,57 @@
+package enc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AEADSuite defines the authenticated encryption construction used to encrypt pages.
+type AEADSuite string
+
+const (
+	// AESGCMSuite encrypts pages with AES-256 in Galois/Counter Mode.
+	AESGCMSuite AEADSuite = "aes-256-gcm"
+
+	// XChaCha20Poly1305Suite encrypts pages with XChaCha20-Poly1305, which is faster than
+	// AES-GCM on CPUs without AES hardware support.
+	XChaCha20Poly1305Suite AEADSuite = "xchacha20-poly1305"
+
+	// DefaultAEADSuite is the default AEAD suite.
+	DefaultAEADSuite = AESGCMSuite
+)
+
+// ErrUnsupportedAEADSuite indicates when an AEAD suite isn't one of the supported suites.
+var ErrUnsupportedAEADSuite = errors.New("unsupported AEAD suite")
+
+// ValidateAEADSuite checks that the AEAD suite is one of the supported suites.
+func ValidateAEADSuite(suite AEADSuite) error {
+	switch suite {
+	case AESGCMSuite, XChaCha20Poly1305Suite:
+		return nil
+	default:
+		return ErrUnsupportedAEADSuite
+	}
+}
+
+// newAEAD creates a new cipher.AEAD for the suite with the given 32-byte key.
+func newAEAD(suite AEADSuite, key []byte) (cipher.AEAD, error) {
+	switch suite {
+	case AESGCMSuite:
+		return newGCMCipher(key)
+	case XChaCha20Poly1305Suite:
+		return chacha20poly1305.NewX(key)
+	default:
+		return nil, ErrUnsupportedAEADSuite
+	}
+}
+
+func newGCMCipher(aesKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
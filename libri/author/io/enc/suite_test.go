@@ -0,0 +1,45 @@
+package enc
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestValidateAEADSuite(t *testing.T) {
+	assert.Nil(t, ValidateAEADSuite(AESGCMSuite))
+	assert.Nil(t, ValidateAEADSuite(XChaCha20Poly1305Suite))
+	assert.Equal(t, ErrUnsupportedAEADSuite, ValidateAEADSuite(AEADSuite("unsupported")))
+	assert.Equal(t, ErrUnsupportedAEADSuite, ValidateAEADSuite(AEADSuite("")))
+}
+
+func TestNewAEAD_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := api.RandBytes(rng, 32)
+
+	aead, err := newAEAD(AESGCMSuite, key)
+	assert.Nil(t, err)
+	assert.Equal(t, 12, aead.NonceSize())
+
+	aead, err = newAEAD(XChaCha20Poly1305Suite, key)
+	assert.Nil(t, err)
+	assert.Equal(t, chacha20poly1305.NonceSizeX, aead.NonceSize())
+}
+
+func TestNewAEAD_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := api.RandBytes(rng, 32)
+
+	aead, err := newAEAD(AEADSuite("unsupported"), key)
+	assert.Equal(t, ErrUnsupportedAEADSuite, err)
+	assert.Nil(t, aead)
+
+	for _, suite := range []AEADSuite{AESGCMSuite, XChaCha20Poly1305Suite} {
+		aead, err = newAEAD(suite, key[:8])
+		assert.NotNil(t, err)
+		assert.Nil(t, aead)
+	}
+}
@@ -1,8 +1,13 @@
 package pack
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/drausin/libri/libri/author/io/enc"
@@ -13,10 +18,28 @@ import (
 	"github.com/drausin/libri/libri/librarian/api"
 )
 
+const (
+	// unknownMediaType is the media type of content whose type can't be detected.
+	unknownMediaType = "application/octet-stream"
+
+	// sniffLen is the number of bytes at the start of content used to detect its media type.
+	sniffLen = 512
+)
+
+// File is file content with its filepath relative to some root directory, e.g., an uploaded
+// directory.
+type File struct {
+	*os.File
+	Filepath string
+}
+
 // EntryPacker creates entry documents from raw content.
 type EntryPacker interface {
 	// Pack prints pages from the content, encrypts their metadata, and binds them together
-	// into an entry *api.Document.
+	// into an entry *api.Document. If the content is a regular *File or *os.File, its relative
+	// filepath, file mode, and modified time are also added to the metadata. If the media type
+	// is empty, it is detected from the start of the content or, failing that, its filepath
+	// extension.
 	Pack(content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte) (
 		*api.Document, *api.Metadata, error)
 }
@@ -48,13 +71,20 @@ type entryPacker struct {
 func (p *entryPacker) Pack(content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte) (
 	*api.Document, *api.Metadata, error) {
 
-	pageKeys, metadata, err := p.printer.Print(content, mediaType, keys, authorPub)
+	printContent := content
+	if mediaType == "" {
+		var err error
+		if mediaType, printContent, err = detectMediaType(content); err != nil {
+			return nil, nil, err
+		}
+	}
+	pageKeys, metadata, err := p.printer.Print(printContent, mediaType, keys, authorPub)
 	if err != nil {
 		return nil, nil, err
 	}
-	// TODO (drausin) add additional metadata K/V here
-	// - relative filepath
-	// - file mode permissions
+	if err := setFileMetadata(metadata, content); err != nil {
+		return nil, nil, err
+	}
 	encMetadata, err := p.metadataEnc.Encrypt(metadata, keys)
 	if err != nil {
 		return nil, nil, err
@@ -66,8 +96,17 @@ func (p *entryPacker) Pack(content io.Reader, mediaType string, keys *enc.Keys,
 // EntryUnpacker writes individual pages to the content io.Writer.
 type EntryUnpacker interface {
 	// Unpack extracts the individual pages from a document and stitches them together to write
-	// to the content io.Writer.
+	// to the content io.Writer. If the content is a regular *os.File, its file mode and modified
+	// time are restored from the metadata.
 	Unpack(content io.Writer, entry *api.Document, keys *enc.Keys) (*api.Metadata, error)
+
+	// UnpackRange is like Unpack but only writes the length bytes starting at the offset to the
+	// content io.Writer, or all bytes after the offset if length is zero. If the entry's pages
+	// can be decoded independently (see print.PageRange), it only loads the pages containing
+	// those bytes and so doesn't check the MACs of the entire content. File metadata isn't
+	// restored.
+	UnpackRange(content io.Writer, entry *api.Document, keys *enc.Keys, offset, length uint64) (
+		*api.Metadata, error)
 }
 
 type entryUnpacker struct {
@@ -93,16 +132,53 @@ func NewEntryUnpacker(
 
 func (u *entryUnpacker) Unpack(content io.Writer, entry *api.Document, keys *enc.Keys) (
 	*api.Metadata, error) {
+	metadata, pageKeys, err := u.getMetadataPageKeys(entry, keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.scanner.Scan(content, pageKeys, keys, metadata); err != nil {
+		return metadata, err
+	}
+	return metadata, restoreFileMetadata(content, metadata)
+}
+
+func (u *entryUnpacker) UnpackRange(
+	content io.Writer, entry *api.Document, keys *enc.Keys, offset, length uint64,
+) (*api.Metadata, error) {
+	metadata, pageKeys, err := u.getMetadataPageKeys(entry, keys)
+	if err != nil {
+		return nil, err
+	}
+	keysPerPage := 1
+	if dataShards, parityShards, in := metadata.GetErasureShards(); in {
+		keysPerPage = int(dataShards + parityShards)
+	}
+	first, end, ok := print.PageRange(metadata, len(pageKeys)/keysPerPage, offset, length)
+	if !ok {
+		// each page depends on those before it, so all of them are scanned
+		rangeContent := newRangeWriter(content, offset, length)
+		return metadata, u.scanner.Scan(rangeContent, pageKeys, keys, metadata)
+	}
+	pageSize, _ := metadata.GetPageSize()
+	rangeContent := newRangeWriter(content, offset-uint64(first)*pageSize, length)
+	rangeKeys := pageKeys[first*keysPerPage : end*keysPerPage]
+	return metadata, u.scanner.ScanRange(rangeContent, rangeKeys, uint32(first), keys, metadata)
+}
+
+// getMetadataPageKeys decrypts the entry's metadata and returns it along with the keys of the
+// entry's pages.
+func (u *entryUnpacker) getMetadataPageKeys(entry *api.Document, keys *enc.Keys) (
+	*api.Metadata, []id.ID, error) {
 	encMetadata, err := enc.NewEncryptedMetadata(
 		entry.Contents.(*api.Document_Entry).Entry.MetadataCiphertext,
 		entry.Contents.(*api.Document_Entry).Entry.MetadataCiphertextMac,
 	)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	metadata, err := u.metadataDec.Decrypt(encMetadata, keys)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var pageKeys []id.ID
@@ -110,16 +186,138 @@ func (u *entryUnpacker) Unpack(content io.Writer, entry *api.Document, keys *enc
 	case *api.Entry_PageKeys:
 		pageKeys, err = api.GetEntryPageKeys(entry)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	case *api.Entry_Page:
 		_, docKey, err := api.GetPageDocument(ec.Page)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		pageKeys = []id.ID{docKey}
 	}
-	return metadata, u.scanner.Scan(content, pageKeys, keys, metadata)
+	return metadata, pageKeys, nil
+}
+
+// rangeWriter writes only the bytes within [offset, offset + length) to the inner io.Writer and
+// discards the rest. A zero length includes all bytes after the offset.
+type rangeWriter struct {
+	inner  io.Writer
+	offset uint64
+	length uint64
+	n      uint64
+}
+
+func newRangeWriter(inner io.Writer, offset, length uint64) io.Writer {
+	if offset == 0 && length == 0 {
+		return inner
+	}
+	return &rangeWriter{
+		inner:  inner,
+		offset: offset,
+		length: length,
+	}
+}
+
+func (w *rangeWriter) Write(p []byte) (int, error) {
+	pStart := w.n
+	w.n += uint64(len(p))
+	start, end := pStart, w.n
+	if start < w.offset {
+		start = w.offset
+	}
+	if w.length > 0 && end > w.offset+w.length {
+		end = w.offset + w.length
+	}
+	if start < end {
+		if _, err := w.inner.Write(p[start-pStart : end-pStart]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// detectMediaType sniffs the media type from the start of the content or, if it can't, from the
+// extension of a file's name. It returns the media type and a reader of the whole content.
+func detectMediaType(content io.Reader) (string, io.Reader, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+	var whole io.Reader = io.MultiReader(bytes.NewReader(head), content)
+	if seeker, ok := content.(io.Seeker); ok {
+		// rewind instead if possible, so the printer can still read the content at any offset
+		if _, err := seeker.Seek(int64(-n), io.SeekCurrent); err == nil {
+			whole = content
+		}
+	}
+	if mediaType := http.DetectContentType(head); mediaType != unknownMediaType {
+		return mediaType, whole, nil
+	}
+	var name string
+	switch f := content.(type) {
+	case *File:
+		name = f.Filepath
+	case *os.File:
+		name = f.Name()
+	}
+	if mediaType := mime.TypeByExtension(filepath.Ext(name)); mediaType != "" {
+		return mediaType, whole, nil
+	}
+	return unknownMediaType, whole, nil
+}
+
+// setFileMetadata adds the relative filepath, file mode, and modified time to the metadata when
+// the content is a regular file. An *os.File's filepath is just its base name. Other files, like
+// a piped os.Stdin, have no meaningful filepath, mode, or modified time to record.
+func setFileMetadata(metadata *api.Metadata, content io.Reader) error {
+	var file *os.File
+	var relPath string
+	switch f := content.(type) {
+	case *File:
+		file, relPath = f.File, f.Filepath
+	case *os.File:
+		file, relPath = f, filepath.Base(f.Name())
+	default:
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	metadata.SetFilepath(filepath.ToSlash(relPath))
+	metadata.SetFileMode(info.Mode())
+	metadata.SetModifiedTime(info.ModTime())
+	return nil
+}
+
+// restoreFileMetadata sets the file mode and modified time from the metadata when the content is
+// a regular file, so they aren't applied to, e.g., a piped os.Stdout.
+func restoreFileMetadata(content io.Writer, metadata *api.Metadata) error {
+	file, ok := content.(*os.File)
+	if !ok {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	if mode, in := metadata.GetFileMode(); in {
+		if err := file.Chmod(mode); err != nil {
+			return err
+		}
+	}
+	if modTime, in := metadata.GetModifiedTime(); in {
+		return os.Chtimes(file.Name(), modTime, modTime)
+	}
+	return nil
 }
 
 func newEntryDoc(
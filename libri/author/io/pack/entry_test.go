@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/drausin/libri/libri/author/io/common"
 	"github.com/drausin/libri/libri/author/io/comp"
@@ -93,6 +98,89 @@ func TestEntryPacker_Pack_err(t *testing.T) {
 
 }
 
+func TestEntryPacker_Pack_detectMediaType(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := print.NewDefaultParameters()
+	page.MinSize = 64 // just for testing
+	params.PageSize = 128
+	docSL := &fixedDocStorerLoader{
+		stored: make(map[string]*api.Document),
+	}
+	metadataEncDec := enc.NewMetadataEncrypterDecrypter()
+	p := NewEntryPacker(params, metadataEncDec, docSL)
+	u := NewEntryUnpacker(params, metadataEncDec, docSL)
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	content1 := append([]byte("%PDF-1.4\n"), api.RandBytes(rng, 1024)...)
+
+	// check empty media type is detected and whole content is packed
+	doc, metadata, err := p.Pack(bytes.NewReader(content1), "", keys, authorPub)
+	assert.Nil(t, err)
+	mediaType, in := metadata.GetMediaType()
+	assert.True(t, in)
+	assert.Equal(t, "application/pdf", mediaType)
+
+	content2 := new(bytes.Buffer)
+	_, err = u.Unpack(content2, doc, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, content1, content2.Bytes())
+}
+
+func TestDetectMediaType(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	dir, err := ioutil.TempDir("", "test-detect-media-type")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	binary := append([]byte{0, 1, 2, 3}, api.RandBytes(rng, 1024)...)
+	path := filepath.Join(dir, "some.json")
+	assert.Nil(t, ioutil.WriteFile(path, binary, 0600))
+	file1, err := os.Open(path)
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, file1.Close()) }()
+	file2, err := os.Open(path)
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, file2.Close()) }()
+
+	cases := []struct {
+		content  io.Reader
+		expected string
+		whole    []byte
+	}{
+		// sniffed from content
+		{bytes.NewReader([]byte("%PDF-1.4")), "application/pdf", []byte("%PDF-1.4")},
+		{bytes.NewReader([]byte{}), "text/plain; charset=utf-8", []byte{}},
+
+		// unknown content
+		{bytes.NewReader(binary), unknownMediaType, binary},
+
+		// from filepath extension
+		{file1, "application/json", binary},
+		{&File{File: file2, Filepath: "other.json"}, "application/json", binary},
+	}
+	for i, c := range cases {
+		mediaType, whole, err := detectMediaType(c.content)
+		assert.Nil(t, err, "case %d", i)
+		assert.Equal(t, c.expected, mediaType, "case %d", i)
+		wholeBytes, err := ioutil.ReadAll(whole)
+		assert.Nil(t, err)
+		assert.Equal(t, c.whole, wholeBytes, "case %d", i)
+	}
+
+	// check seekable content is rewound rather than wrapped, and other content is wrapped
+	seekable := bytes.NewReader(binary)
+	_, whole, err := detectMediaType(seekable)
+	assert.Nil(t, err)
+	assert.True(t, whole == io.Reader(seekable))
+	_, whole, err = detectMediaType(iotest.OneByteReader(bytes.NewReader(binary)))
+	assert.Nil(t, err)
+	wholeBytes, err := ioutil.ReadAll(whole)
+	assert.Nil(t, err)
+	assert.Equal(t, binary, wholeBytes)
+
+	// check read error bubbles up
+	_, _, err = detectMediaType(iotest.ErrReader(errors.New("some Read error")))
+	assert.NotNil(t, err)
+}
+
 func TestEntryUnpacker_Unpack_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	params := print.NewDefaultParameters()
@@ -159,6 +247,131 @@ func TestEntryUnpacker_Unpack_err(t *testing.T) {
 	assert.Nil(t, metadata)
 }
 
+func TestEntryUnpacker_UnpackRange_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := print.NewDefaultParameters()
+	docSL := &fixedDocStorerLoader{
+		stored: make(map[string]*api.Document),
+	}
+	doc, _ := api.NewTestDocument(rng)
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+
+	// check decryption error bubbles up
+	u1 := NewEntryUnpacker(
+		params,
+		&fixedMetadataDecrypter{err: errors.New("some Decrypt error")},
+		docSL,
+	)
+	metadata, err := u1.UnpackRange(new(bytes.Buffer), doc, keys, 1, 2)
+	assert.NotNil(t, err)
+	assert.Nil(t, metadata)
+
+	// check scanner error bubbles up
+	metadata1, err := api.NewEntryMetadata("application/x-gzip", 1, api.RandBytes(rng, 32), 2,
+		api.RandBytes(rng, 32))
+	assert.Nil(t, err)
+	u2 := NewEntryUnpacker(params, &fixedMetadataDecrypter{metadata: metadata1}, docSL)
+	u2.(*entryUnpacker).scanner = &fixedScanner{err: errors.New("some Scan error")}
+	_, err = u2.UnpackRange(new(bytes.Buffer), doc, keys, 1, 2)
+	assert.NotNil(t, err)
+	metadata1.SetPageSize(uint64(params.PageSize))
+	_, err = u2.UnpackRange(new(bytes.Buffer), doc, keys, 1, 2)
+	assert.NotNil(t, err)
+}
+
+func TestEntryPackUnpackRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	page.MinSize = 64 // just for testing
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	metadataEncDec := enc.NewMetadataEncrypterDecrypter()
+	params, err := print.NewParameters(comp.MinBufferSize, 128, 1)
+	assert.Nil(t, err)
+	content1Bytes := common.NewCompressableBytes(rng, 1024).Bytes()
+	ranges := [][2]uint64{{0, 0}, {200, 0}, {200, 100}, {1000, 100}, {2000, 0}}
+
+	for _, mediaType := range []string{"application/x-pdf", "application/x-gzip"} {
+		for _, r := range ranges {
+			docSL := &fixedDocStorerLoader{
+				stored: make(map[string]*api.Document),
+			}
+			p := NewEntryPacker(params, metadataEncDec, docSL)
+			u := NewEntryUnpacker(params, metadataEncDec, docSL)
+			content1 := bytes.NewBuffer(content1Bytes)
+			doc, _, err := p.Pack(content1, mediaType, keys, authorPub)
+			assert.Nil(t, err)
+
+			// pages of uncompressed content outside the range can't be loaded
+			pageKeys, err := api.GetEntryPageKeys(doc)
+			assert.Nil(t, err)
+			offset, end := r[0], r[0]+r[1]
+			if r[1] == 0 || end > uint64(len(content1Bytes)) {
+				end = uint64(len(content1Bytes))
+			}
+			if offset > end {
+				offset = end
+			}
+			if mediaType == "application/x-gzip" {
+				for i, pageKey := range pageKeys {
+					pageStart, pageEnd := uint64(i)*128, uint64(i+1)*128
+					if pageEnd <= r[0] || (r[1] > 0 && pageStart >= r[0]+r[1]) {
+						delete(docSL.stored, pageKey.String())
+					}
+				}
+			}
+
+			content2 := new(bytes.Buffer)
+			_, err = u.UnpackRange(content2, doc, keys, r[0], r[1])
+			assert.Nil(t, err, mediaType)
+			assert.True(t, bytes.Equal(content1Bytes[offset:end], content2.Bytes()),
+				mediaType)
+		}
+	}
+}
+
+func TestRangeWriter_Write(t *testing.T) {
+	content := []byte("some content to write in chunks")
+	cases := []struct {
+		offset, length uint64
+		expected       string
+	}{
+		{0, 0, "some content to write in chunks"},
+		{5, 0, "content to write in chunks"},
+		{5, 7, "content"},
+		{0, 4, "some"},
+		{22, 100, "in chunks"},
+		{100, 0, ""},
+		{31, 0, ""},
+	}
+	for _, c := range cases {
+		inner := new(bytes.Buffer)
+		w := newRangeWriter(inner, c.offset, c.length)
+		for i := 0; i < len(content); i += 3 {
+			j := i + 3
+			if j > len(content) {
+				j = len(content)
+			}
+			n, err := w.Write(content[i:j])
+			assert.Nil(t, err)
+			assert.Equal(t, j-i, n)
+		}
+		assert.Equal(t, c.expected, inner.String(), "offset: %d, length: %d", c.offset,
+			c.length)
+	}
+}
+
+func TestRangeWriter_Write_err(t *testing.T) {
+	w := newRangeWriter(&errWriter{}, 1, 0)
+	n, err := w.Write([]byte("some content"))
+	assert.NotNil(t, err)
+	assert.Zero(t, n)
+}
+
+type errWriter struct{}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("some Write error")
+}
+
 func TestEntryPackUnpack(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	page.MinSize = 64 // just for testing
@@ -210,6 +423,133 @@ func TestEntryPackUnpack(t *testing.T) {
 	}
 }
 
+func TestEntryPackUnpack_file(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	page.MinSize = 64 // just for testing
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	metadataEncDec := enc.NewMetadataEncrypterDecrypter()
+	params, err := print.NewParameters(comp.MinBufferSize, 128, 1)
+	assert.Nil(t, err)
+	docSL := &fixedDocStorerLoader{
+		stored: make(map[string]*api.Document),
+	}
+	p := NewEntryPacker(params, metadataEncDec, docSL)
+	u := NewEntryUnpacker(params, metadataEncDec, docSL)
+	dir, err := ioutil.TempDir("", "test-pack-file")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+
+	contentBytes := common.NewCompressableBytes(rng, 512).Bytes()
+	upPath := filepath.Join(dir, "up.txt")
+	assert.Nil(t, ioutil.WriteFile(upPath, contentBytes, 0640))
+	modTime := time.Unix(1500000000, 0)
+	assert.Nil(t, os.Chtimes(upPath, modTime, modTime))
+
+	// check *os.File packs its base name, mode, and modified time
+	upFile, err := os.Open(upPath)
+	assert.Nil(t, err)
+	_, metadata, err := p.Pack(upFile, "application/x-gzip", keys, authorPub)
+	assert.Nil(t, err)
+	assert.Nil(t, upFile.Close())
+	relPath, in := metadata.GetFilepath()
+	assert.True(t, in)
+	assert.Equal(t, "up.txt", relPath)
+	mode, in := metadata.GetFileMode()
+	assert.True(t, in)
+	assert.Equal(t, os.FileMode(0640), mode)
+	packedModTime, in := metadata.GetModifiedTime()
+	assert.True(t, in)
+	assert.True(t, modTime.Equal(packedModTime))
+
+	// check *File packs its relative filepath
+	upFile, err = os.Open(upPath)
+	assert.Nil(t, err)
+	doc, metadata, err := p.Pack(&File{File: upFile, Filepath: "some/dir/up.txt"},
+		"application/x-gzip", keys, authorPub)
+	assert.Nil(t, err)
+	assert.Nil(t, upFile.Close())
+	relPath, in = metadata.GetFilepath()
+	assert.True(t, in)
+	assert.Equal(t, "some/dir/up.txt", relPath)
+
+	// check unpacking to *os.File restores mode and modified time
+	downPath := filepath.Join(dir, "down.txt")
+	downFile, err := os.OpenFile(downPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	assert.Nil(t, err)
+	metadata, err = u.Unpack(downFile, doc, keys)
+	assert.Nil(t, err)
+	assert.NotNil(t, metadata)
+	assert.Nil(t, downFile.Close())
+	downBytes, err := ioutil.ReadFile(downPath)
+	assert.Nil(t, err)
+	assert.Equal(t, contentBytes, downBytes)
+	info, err := os.Stat(downPath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode())
+	assert.True(t, modTime.Equal(info.ModTime()))
+
+	// check Stat error bubbles up
+	doc, metadata, err = p.Pack(upFile, "application/x-gzip", keys, authorPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, doc)
+	assert.Nil(t, metadata)
+}
+
+func TestEntryPackUnpack_pipe(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	page.MinSize = 64 // just for testing
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	metadataEncDec := enc.NewMetadataEncrypterDecrypter()
+	params, err := print.NewParameters(comp.MinBufferSize, 128, 3)
+	assert.Nil(t, err)
+	docSL := &fixedDocStorerLoader{
+		stored: make(map[string]*api.Document),
+	}
+	p := NewEntryPacker(params, metadataEncDec, docSL)
+	u := NewEntryUnpacker(params, metadataEncDec, docSL)
+	contentBytes := common.NewCompressableBytes(rng, 2048).Bytes()
+
+	// check unseekable content of unknown size, like a piped os.Stdin, packs without file
+	// metadata
+	upR, upW, err := os.Pipe()
+	assert.Nil(t, err)
+	go func() {
+		_, wErr := upW.Write(contentBytes)
+		assert.Nil(t, wErr)
+		assert.Nil(t, upW.Close())
+	}()
+	doc, metadata, err := p.Pack(upR, "", keys, authorPub)
+	assert.Nil(t, err)
+	assert.Nil(t, upR.Close())
+	uncompressedSize, in := metadata.GetUncompressedSize()
+	assert.True(t, in)
+	assert.Equal(t, uint64(len(contentBytes)), uncompressedSize)
+	mediaType, in := metadata.GetMediaType()
+	assert.True(t, in)
+	assert.Equal(t, "text/plain; charset=utf-8", mediaType)
+	_, in = metadata.GetFilepath()
+	assert.False(t, in)
+	_, in = metadata.GetFileMode()
+	assert.False(t, in)
+	_, in = metadata.GetModifiedTime()
+	assert.False(t, in)
+
+	// check unpacking to unseekable content, like a piped os.Stdout, writes the whole content
+	downR, downW, err := os.Pipe()
+	assert.Nil(t, err)
+	downBytes := make(chan []byte)
+	go func() {
+		buf, rErr := ioutil.ReadAll(downR)
+		assert.Nil(t, rErr)
+		downBytes <- buf
+	}()
+	_, err = u.Unpack(downW, doc, keys)
+	assert.Nil(t, err)
+	assert.Nil(t, downW.Close())
+	assert.Equal(t, contentBytes, <-downBytes)
+	assert.Nil(t, downR.Close())
+}
+
 type fixedDocStorerLoader struct {
 	storeErr error
 	stored   map[string]*api.Document
@@ -246,6 +586,13 @@ func (f *fixedScanner) Scan(
 	return f.err
 }
 
+func (f *fixedScanner) ScanRange(
+	content io.Writer, pageKeys []id.ID, firstIndex uint32, keys *enc.Keys,
+	metadata *api.Metadata,
+) error {
+	return f.err
+}
+
 type packTestCase struct {
 	pageSize          uint32
 	uncompressedSize  int
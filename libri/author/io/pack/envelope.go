@@ -1,17 +1,22 @@
 package pack
 
 import (
+	"github.com/drausin/libri/libri/author/io/enc"
 	"github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/librarian/api"
 )
 
 // NewEnvelopeDoc returns a new envelope document for the given entry key and author and reader
-// public keys.
-func NewEnvelopeDoc(authorPub, readerPub []byte, entryKey id.ID) *api.Document {
+// public keys. The entry attributes are optional and disclosed unencrypted, so they should be nil
+// unless the author chooses to disclose them.
+func NewEnvelopeDoc(
+	authorPub, readerPub []byte, entryKey id.ID, attrs *api.EntryAttributes,
+) *api.Document {
 	envelope := &api.Envelope{
 		AuthorPublicKey: authorPub,
 		ReaderPublicKey: readerPub,
 		EntryKey:        entryKey.Bytes(),
+		EntryAttributes: attrs,
 	}
 	return &api.Document{
 		Contents: &api.Document_Envelope{
@@ -20,6 +25,39 @@ func NewEnvelopeDoc(authorPub, readerPub []byte, entryKey id.ID) *api.Document {
 	}
 }
 
+// NewSharedEnvelopeDoc returns a new envelope document like NewEnvelopeDoc that also contains the
+// entry encryption keys encrypted for the reader, so a reader other than the one the entry was
+// encrypted for can decrypt it.
+func NewSharedEnvelopeDoc(
+	authorPub, readerPub []byte,
+	entryKey id.ID,
+	eek *enc.EncryptedKeys,
+	attrs *api.EntryAttributes,
+) *api.Document {
+	envelope := NewEnvelopeDoc(authorPub, readerPub, entryKey, attrs)
+	envelope.Contents.(*api.Document_Envelope).Envelope.EekCiphertext = eek.Ciphertext
+	envelope.Contents.(*api.Document_Envelope).Envelope.EekCiphertextMac = eek.CiphertextMAC
+	return envelope
+}
+
+// GetEntryKeys returns the entry encryption keys of an envelope given the key encryption keys
+// (KEK) derived from its author and reader keys. These are the KEK themselves unless the envelope
+// contains encrypted entry keys.
+func GetEntryKeys(envelope *api.Document, kek *enc.Keys) (*enc.Keys, error) {
+	c, ok := envelope.Contents.(*api.Document_Envelope)
+	if !ok {
+		return nil, api.ErrUnexpectedDocumentType
+	}
+	if c.Envelope.EekCiphertext == nil {
+		return kek, nil
+	}
+	eek, err := enc.NewEncryptedKeys(c.Envelope.EekCiphertext, c.Envelope.EekCiphertextMac)
+	if err != nil {
+		return nil, err
+	}
+	return enc.DecryptKeys(eek, kek)
+}
+
 // SeparateEnvelopeDoc returns the author and reader public keys along with the entry ID in an
 // envelope.
 func SeparateEnvelopeDoc(envelope *api.Document) ([]byte, []byte, id.ID, error) {
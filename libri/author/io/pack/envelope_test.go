@@ -14,18 +14,20 @@ func TestNewEnvelopeDoc(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	_, authorPub, readerPub := enc.NewPseudoRandomKeys(rng)
 	entryKey := id.NewPseudoRandom(rng)
-	docEnvelope := NewEnvelopeDoc(authorPub, readerPub, entryKey)
+	attrs := &api.EntryAttributes{MediaType: "application/x-pdf", UncompressedSize: 2}
+	docEnvelope := NewEnvelopeDoc(authorPub, readerPub, entryKey, attrs)
 	envelope := docEnvelope.Contents.(*api.Document_Envelope).Envelope
 	assert.Equal(t, authorPub, envelope.AuthorPublicKey)
 	assert.Equal(t, readerPub, envelope.ReaderPublicKey)
 	assert.Equal(t, entryKey.Bytes(), envelope.EntryKey)
+	assert.Equal(t, attrs, envelope.EntryAttributes)
 }
 
 func TestSeparateEnvelopeDoc(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	_, authorPub1, readerPub1 := enc.NewPseudoRandomKeys(rng)
 	entryKey1 := id.NewPseudoRandom(rng)
-	docEnvelope := NewEnvelopeDoc(authorPub1, readerPub1, entryKey1)
+	docEnvelope := NewEnvelopeDoc(authorPub1, readerPub1, entryKey1, nil)
 
 	authorPub2, readerPub2, entryKey2, err := SeparateEnvelopeDoc(docEnvelope)
 	assert.Nil(t, err)
@@ -41,3 +43,55 @@ func TestSeparateEnvelopeDoc(t *testing.T) {
 	assert.Nil(t, readerPub3)
 	assert.Nil(t, entryKey3)
 }
+
+func TestNewSharedEnvelopeDoc_GetEntryKeys(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	eek, _, _ := enc.NewPseudoRandomKeys(rng)
+	kek, authorPub, readerPub := enc.NewPseudoRandomKeys(rng)
+	entryKey := id.NewPseudoRandom(rng)
+	encKeys, err := enc.EncryptKeys(eek, kek)
+	assert.Nil(t, err)
+
+	docEnvelope := NewSharedEnvelopeDoc(authorPub, readerPub, entryKey, encKeys, nil)
+	envelope := docEnvelope.Contents.(*api.Document_Envelope).Envelope
+	assert.Nil(t, api.ValidateEnvelope(envelope))
+	assert.Equal(t, encKeys.Ciphertext, envelope.EekCiphertext)
+	assert.Equal(t, encKeys.CiphertextMAC, envelope.EekCiphertextMac)
+
+	entryKeys, err := GetEntryKeys(docEnvelope, kek)
+	assert.Nil(t, err)
+	assert.Equal(t, eek, entryKeys)
+
+	// check KEK are entry keys without encrypted keys
+	entryKeys, err = GetEntryKeys(NewEnvelopeDoc(authorPub, readerPub, entryKey, nil), kek)
+	assert.Nil(t, err)
+	assert.Equal(t, kek, entryKeys)
+}
+
+func TestGetEntryKeys_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kek, authorPub, readerPub := enc.NewPseudoRandomKeys(rng)
+	entryKey := id.NewPseudoRandom(rng)
+
+	// check it errors on non-envelope doc
+	entryDoc, _ := api.NewTestDocument(rng)
+	entryKeys, err := GetEntryKeys(entryDoc, kek)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+	assert.Nil(t, entryKeys)
+
+	// check it errors on invalid encrypted keys
+	docEnvelope := NewEnvelopeDoc(authorPub, readerPub, entryKey, nil)
+	docEnvelope.Contents.(*api.Document_Envelope).Envelope.EekCiphertext = []byte{1, 2, 3}
+	entryKeys, err = GetEntryKeys(docEnvelope, kek)
+	assert.NotNil(t, err)
+	assert.Nil(t, entryKeys)
+
+	// check it errors on encrypted keys for a different KEK
+	otherKEK, _, _ := enc.NewPseudoRandomKeys(rng)
+	encKeys, err := enc.EncryptKeys(kek, otherKEK)
+	assert.Nil(t, err)
+	docEnvelope = NewSharedEnvelopeDoc(authorPub, readerPub, entryKey, encKeys, nil)
+	entryKeys, err = GetEntryKeys(docEnvelope, kek)
+	assert.Equal(t, enc.ErrUnexpectedMAC, err)
+	assert.Nil(t, entryKeys)
+}
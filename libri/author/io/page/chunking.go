@@ -0,0 +1,278 @@
+package page
+
+import (
+	"errors"
+	"io"
+
+	"github.com/drausin/libri/libri/author/io/comp"
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+// Chunking defines how content is split into pages.
+type Chunking string
+
+const (
+	// FixedChunking splits compressed content into pages of the maximum page size.
+	FixedChunking Chunking = "fixed"
+
+	// ContentDefinedChunking splits uncompressed content into variable-size chunks whose
+	// boundaries are defined by the content (via FastCDC) and compresses and encrypts each chunk
+	// into its own page, so an edit to part of the content only changes the pages around it.
+	ContentDefinedChunking Chunking = "fastcdc"
+
+	// DefaultChunking is the default chunking.
+	DefaultChunking = FixedChunking
+)
+
+const (
+	// gearSeed is the seed for generating the gear hash table, which must never change since
+	// chunk boundaries depend on it.
+	gearSeed = uint64(0x6c69627269636463)
+
+	// cdcAvgSizeRatio is the ratio of the max to the average chunk size.
+	cdcAvgSizeRatio = 4
+
+	// cdcMinSizeRatio is the ratio of the max to the min chunk size.
+	cdcMinSizeRatio = 16
+)
+
+// ErrUnsupportedChunking indicates when a chunking isn't one of the supported chunkings.
+var ErrUnsupportedChunking = errors.New("unsupported chunking")
+
+// gear is the random table of 64-bit values, one for each byte, used by the FastCDC rolling hash.
+var gear = newGearTable(gearSeed)
+
+// ValidateChunking checks that the chunking is one of the supported chunkings.
+func ValidateChunking(chunking Chunking) error {
+	switch chunking {
+	case FixedChunking, ContentDefinedChunking:
+		return nil
+	default:
+		return ErrUnsupportedChunking
+	}
+}
+
+// fastCDC finds content-defined chunk boundaries using FastCDC's normalized chunking, which uses
+// a harder-to-match mask before the average chunk size and an easier one after it to keep chunk
+// sizes close to the average.
+type fastCDC struct {
+	minSize int
+	avgSize int
+	maxSize int
+	maskS   uint64
+	maskL   uint64
+}
+
+func newFastCDC(maxSize int) *fastCDC {
+	avgSize := maxSize / cdcAvgSizeRatio
+	bits := uint(0)
+	for 1<<(bits+1) <= avgSize {
+		bits++
+	}
+	return &fastCDC{
+		minSize: maxSize / cdcMinSizeRatio,
+		avgSize: avgSize,
+		maxSize: maxSize,
+		maskS:   highBitsMask(bits + 1),
+		maskL:   highBitsMask(bits - 1),
+	}
+}
+
+// cut returns the length of the next chunk at the start of data, which must contain at least
+// maxSize bytes unless it is the end of the content.
+func (c *fastCDC) cut(data []byte) int {
+	n := len(data)
+	if n <= c.minSize {
+		return n
+	}
+	if n > c.maxSize {
+		n = c.maxSize
+	}
+	normal := c.avgSize
+	if n < normal {
+		normal = n
+	}
+	var fp uint64
+	i := c.minSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// highBitsMask returns a mask of the n most significant bits, which are the bits the rolling hash
+// has mixed the most bytes into.
+func highBitsMask(n uint) uint64 {
+	return ^uint64(0) << (64 - n)
+}
+
+// newGearTable generates the gear table from the seed using SplitMix64, so it's the same for all
+// builds.
+func newGearTable(seed uint64) [256]uint64 {
+	var table [256]uint64
+	x := seed
+	for i := range table {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}
+
+// chunkingPaginator is a Paginator that reads uncompressed bytes and emits them in
+// content-defined chunks, each compressed and encrypted into its own page. Since a chunk's page
+// shouldn't depend on its position in the content, all pages have index 0 and are ordered only by
+// the entry's page keys.
+type chunkingPaginator struct {
+	*paginator
+	chunker *fastCDC
+	codec   comp.Codec
+}
+
+// NewChunkingPaginator creates a new Paginator that emits content-defined chunks of at most the
+// page size of uncompressed content from an uncompressed io.Reader, compressing each with the
+// given codec. The encrypter should be a content encrypter, so identical chunks have identical
+// pages.
+func NewChunkingPaginator(
+	pages chan *api.Page,
+	encrypter enc.Encrypter,
+	keys *enc.Keys,
+	authorPub []byte,
+	pageSize uint32,
+	codec comp.Codec,
+) (Paginator, error) {
+	inner, err := NewPaginator(pages, encrypter, keys, authorPub, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := comp.ValidateCodec(codec); err != nil {
+		return nil, err
+	}
+	return &chunkingPaginator{
+		paginator: inner.(*paginator),
+		chunker:   newFastCDC(int(pageSize)),
+		codec:     codec,
+	}, nil
+}
+
+// ReadFrom reads uncompressed content from the io.Reader and emits a page for each chunk to the
+// underlying channel.
+func (p *chunkingPaginator) ReadFrom(uncompressed io.Reader) (int64, error) {
+	var n int64
+	buf := make([]byte, p.chunker.maxSize)
+	end, eof := 0, false
+	for i := 0; ; i++ {
+
+		// fill the buffer, so the chunker sees as much content as a chunk may contain
+		for !eof && end < len(buf) {
+			ni, err := uncompressed.Read(buf[end:])
+			end += ni
+			n += int64(ni)
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return n, err
+			}
+		}
+		if end == 0 && i > 0 {
+			// no more content, though we always emit the first chunk so empty content has a page
+			return n, nil
+		}
+
+		cut := p.chunker.cut(buf[:end])
+		if err := p.emitChunk(buf[:cut]); err != nil {
+			return n, err
+		}
+		end = copy(buf, buf[cut:end])
+	}
+}
+
+func (p *chunkingPaginator) emitChunk(chunk []byte) error {
+	compressedChunk, err := comp.Compress(chunk, p.codec)
+	if err != nil {
+		return err
+	}
+	pageCiphertext, err := p.encrypter.Encrypt(compressedChunk, 0)
+	if err != nil {
+		return err
+	}
+	if _, err = p.ciphertextMAC.Write(pageCiphertext); err != nil {
+		return err
+	}
+	page, err := p.getPage(pageCiphertext, 0)
+	if err != nil {
+		return err
+	}
+	p.pages <- page
+	return nil
+}
+
+// chunkUnpaginator is an Unpaginator that decrypts and decompresses each page of content-defined
+// chunks and writes the uncompressed chunk.
+type chunkUnpaginator struct {
+	*unpaginator
+	codec comp.Codec
+}
+
+// NewChunkUnpaginator creates a new Unpaginator for pages from a chunking Paginator, which
+// writes their uncompressed content to a pass-through (i.e., comp.NoneCodec) decompressor. The
+// decrypter should be a content decrypter.
+func NewChunkUnpaginator(
+	pages chan *api.Page,
+	decrypter enc.Decrypter,
+	keys *enc.Keys,
+	codec comp.Codec,
+) (Unpaginator, error) {
+	inner, err := NewUnpaginator(pages, decrypter, keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := comp.ValidateCodec(codec); err != nil {
+		return nil, err
+	}
+	return &chunkUnpaginator{
+		unpaginator: inner.(*unpaginator),
+		codec:       codec,
+	}, nil
+}
+
+func (u *chunkUnpaginator) WriteTo(decompressor comp.CloseWriter) (int64, error) {
+	var n int64
+	for page := range u.pages {
+		if err := api.ValidatePage(page); err != nil {
+			return n, err
+		}
+		if err := u.checkCiphertextMAC(page); err != nil {
+			return n, err
+		}
+		if _, err := u.ciphertextMAC.Write(page.Ciphertext); err != nil {
+			return n, err
+		}
+		compressedChunk, err := u.decrypter.Decrypt(page.Ciphertext, page.Index)
+		if err != nil {
+			return n, err
+		}
+		chunk, err := comp.Decompress(compressedChunk, u.codec)
+		if err != nil {
+			return n, err
+		}
+		np, err := decompressor.Write(chunk)
+		if err != nil {
+			return n, err
+		}
+		n += int64(np)
+	}
+	return n, decompressor.Close()
+}
@@ -0,0 +1,255 @@
+package page
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/comp"
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateChunking(t *testing.T) {
+	assert.Nil(t, ValidateChunking(FixedChunking))
+	assert.Nil(t, ValidateChunking(ContentDefinedChunking))
+	assert.Equal(t, ErrUnsupportedChunking, ValidateChunking(Chunking("")))
+	assert.Equal(t, ErrUnsupportedChunking, ValidateChunking(Chunking("unexpected")))
+}
+
+func TestNewGearTable(t *testing.T) {
+	// check table is deterministic and has distinct values
+	assert.Equal(t, gear, newGearTable(gearSeed))
+	distinct := make(map[uint64]struct{})
+	for _, v := range gear {
+		distinct[v] = struct{}{}
+	}
+	assert.Len(t, distinct, len(gear))
+}
+
+func TestFastCDC_cut(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	c := newFastCDC(64 * 1024)
+	assert.Equal(t, 4*1024, c.minSize)
+	assert.Equal(t, 16*1024, c.avgSize)
+
+	// check short content is a single chunk
+	assert.Equal(t, 0, c.cut([]byte{}))
+	assert.Equal(t, c.minSize, c.cut(api.RandBytes(rng, c.minSize)))
+
+	// check chunk sizes are within bounds and close to the average
+	content := api.RandBytes(rng, 64*c.maxSize)
+	nChunks := 0
+	for len(content) > 0 {
+		cut := c.cut(content)
+		if len(content) > c.minSize {
+			assert.True(t, cut > c.minSize)
+		}
+		assert.True(t, cut <= c.maxSize)
+		content = content[cut:]
+		nChunks++
+	}
+	meanSize := 64 * c.maxSize / nChunks
+	assert.True(t, meanSize > c.avgSize/2, "mean size %d", meanSize)
+	assert.True(t, meanSize < c.avgSize*2, "mean size %d", meanSize)
+
+	// check chunks of constant content are max size
+	assert.Equal(t, c.maxSize, c.cut(make([]byte, 2*c.maxSize)))
+}
+
+func TestFastCDC_cut_shifted(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	c := newFastCDC(4 * 1024)
+	content1 := api.RandBytes(rng, 64*c.maxSize)
+	content2 := append(api.RandBytes(rng, 10), content1...)
+
+	chunks1 := cutAll(c, content1)
+	chunks2 := cutAll(c, content2)
+
+	// check that all but the first few chunks are shared after inserting bytes at the front
+	nShared := 0
+	for chunk := range chunks2 {
+		if _, in := chunks1[chunk]; in {
+			nShared++
+		}
+	}
+	assert.True(t, nShared >= len(chunks1)-2, "%d of %d shared", nShared, len(chunks1))
+}
+
+func cutAll(c *fastCDC, content []byte) map[string]struct{} {
+	chunks := make(map[string]struct{})
+	for len(content) > 0 {
+		cut := c.cut(content)
+		chunks[string(content[:cut])] = struct{}{}
+		content = content[cut:]
+	}
+	return chunks
+}
+
+func TestNewChunkingPaginator_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+	encrypter, err := enc.NewContentEncrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+	MinSize = 64 // just for testing
+
+	// check inner paginator error bubbles up
+	p, err := NewChunkingPaginator(nil, encrypter, keys, authorPub, 0, comp.GZIPCodec)
+	assert.Equal(t, ErrPageSizeTooSmall, err)
+	assert.Nil(t, p)
+
+	// check unsupported codec triggers error
+	p, err = NewChunkingPaginator(nil, encrypter, keys, authorPub, 128, comp.Codec("x"))
+	assert.Equal(t, comp.ErrUnsupportedCodec, err)
+	assert.Nil(t, p)
+}
+
+func TestChunkingPaginator_ReadFrom_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+	MinSize = 64 // just for testing
+	pages := make(chan *api.Page, 1)
+
+	// check read error bubbles up
+	encrypter, err := enc.NewContentEncrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+	p1, err := NewChunkingPaginator(pages, encrypter, keys, authorPub, 128, comp.GZIPCodec)
+	assert.Nil(t, err)
+	_, err = p1.ReadFrom(errReader{})
+	assert.NotNil(t, err)
+
+	// check encrypt error bubbles up
+	errEncrypter := &fixedEncrypter{encryptErr: errors.New("some Encrypt error")}
+	p2, err := NewChunkingPaginator(pages, errEncrypter, keys, authorPub, 128, comp.GZIPCodec)
+	assert.Nil(t, err)
+	_, err = p2.ReadFrom(bytes.NewReader(api.RandBytes(rng, 256)))
+	assert.NotNil(t, err)
+
+	// check getPage error bubbles up
+	p3, err := NewChunkingPaginator(pages, encrypter, keys, authorPub, 128, comp.GZIPCodec)
+	assert.Nil(t, err)
+	p3.(*chunkingPaginator).authorPub = nil
+	_, err = p3.ReadFrom(bytes.NewReader(api.RandBytes(rng, 256)))
+	assert.NotNil(t, err)
+}
+
+func TestNewChunkUnpaginator_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	decrypter, err := enc.NewContentDecrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+
+	// check inner unpaginator error bubbles up
+	u, err := NewChunkUnpaginator(nil, decrypter, &enc.Keys{}, comp.GZIPCodec)
+	assert.NotNil(t, err)
+	assert.Nil(t, u)
+
+	// check unsupported codec triggers error
+	u, err = NewChunkUnpaginator(nil, decrypter, keys, comp.Codec("x"))
+	assert.Equal(t, comp.ErrUnsupportedCodec, err)
+	assert.Nil(t, u)
+}
+
+func TestChunkUnpaginator_WriteTo_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+	decrypter, err := enc.NewContentDecrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+	newPage := func(ciphertext []byte) *api.Page {
+		return &api.Page{
+			AuthorPublicKey: authorPub,
+			Ciphertext:      ciphertext,
+			CiphertextMac:   enc.HMAC(ciphertext, keys.HMACKey),
+		}
+	}
+	cases := []struct {
+		page      *api.Page
+		decrypter enc.Decrypter
+		codec     comp.Codec
+		w         comp.CloseWriter
+	}{
+		// invalid page
+		{&api.Page{}, decrypter, comp.NoneCodec, new(errCloseWriter)},
+
+		// unexpected ciphertext MAC
+		{&api.Page{
+			AuthorPublicKey: authorPub,
+			Ciphertext:      api.RandBytes(rng, 64),
+			CiphertextMac:   api.RandBytes(rng, api.HMAC256Length),
+		}, decrypter, comp.NoneCodec, new(errCloseWriter)},
+
+		// decrypt error
+		{newPage(api.RandBytes(rng, 64)), decrypter, comp.NoneCodec, new(errCloseWriter)},
+
+		// decompress error
+		{newPage(api.RandBytes(rng, 64)),
+			&fixedDecrypter{compressedPage: api.RandBytes(rng, 64)}, comp.GZIPCodec,
+			new(errCloseWriter)},
+
+		// write error
+		{newPage(api.RandBytes(rng, 64)), &fixedDecrypter{compressedPage: []byte{}},
+			comp.NoneCodec, &errCloseWriter{writeErr: errors.New("some Write error")}},
+	}
+	for i, c := range cases {
+		pages := make(chan *api.Page, 1)
+		pages <- c.page
+		close(pages)
+		u, err := NewChunkUnpaginator(pages, c.decrypter, keys, c.codec)
+		assert.Nil(t, err)
+		_, err = u.WriteTo(c.w)
+		assert.NotNil(t, err, "case %d", i)
+	}
+}
+
+func TestChunkPaginateUnpaginate(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+
+	encrypter, err := enc.NewContentEncrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+	decrypter, err := enc.NewContentDecrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+
+	MinSize = 64 // just for testing
+	uncompressedSizes := []int{0, 128, 192, 256, 384, 512, 768, 1024, 2048, 4096, 8192}
+	pageSizes := []uint32{128, 256, 512, 1024}
+	codecs := []comp.Codec{comp.GZIPCodec, comp.NoneCodec}
+
+	for _, c := range caseCrossProduct(pageSizes, uncompressedSizes, codecs) {
+		pages := make(chan *api.Page, 3)
+		paginator, err := NewChunkingPaginator(pages, encrypter, keys, authorPub,
+			c.pageSize, c.codec)
+		assert.Nil(t, err)
+		unpaginator, err := NewChunkUnpaginator(pages, decrypter, keys, c.codec)
+		assert.Nil(t, err)
+
+		uncompressed1 := common.NewCompressableBytes(rng, c.uncompressedSize)
+		uncompressed1Bytes := uncompressed1.Bytes()
+		uncompressed2 := new(bytes.Buffer)
+		decompressor, err := comp.NewDecompressor(uncompressed2, comp.NoneCodec, keys,
+			comp.MinBufferSize)
+		assert.Nil(t, err)
+
+		// test writing and reading in parallel
+		go func() {
+			n, err := paginator.ReadFrom(uncompressed1)
+			assert.Nil(t, err, c.String())
+			assert.Equal(t, c.uncompressedSize, int(n), c.String())
+			close(pages)
+		}()
+
+		_, err = unpaginator.WriteTo(decompressor)
+		assert.Nil(t, err)
+		assert.Equal(t, c.uncompressedSize, uncompressed2.Len(), c.String())
+		assert.Equal(t, uncompressed1Bytes, uncompressed2.Bytes(), c.String())
+		assert.Equal(t, paginator.CiphertextMAC().Sum(nil),
+			unpaginator.CiphertextMAC().Sum(nil))
+	}
+}
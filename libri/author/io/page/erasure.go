@@ -0,0 +1,279 @@
+package page
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/klauspost/reedsolomon"
+)
+
+// shardHeaderLength is the length of the header before a page's ciphertext in the data split
+// into shards, which contains the page index, ciphertext length, and ciphertext MAC.
+const shardHeaderLength = 4 + 4 + api.HMAC256Length
+
+var (
+	// ErrZeroParityShards indicates when an ErasureCoder's number of parity shards is improperly
+	// set to zero.
+	ErrZeroParityShards = errors.New("zero value parity shards")
+
+	// ErrUnexpectedNShards indicates when the number of shards doesn't match (a multiple of)
+	// the number of data and parity shards.
+	ErrUnexpectedNShards = errors.New("unexpected number of shards")
+
+	// ErrTooFewShards indicates when fewer than the number of data shards of a page are
+	// available, so it can't be reconstructed.
+	ErrTooFewShards = errors.New("too few shards to reconstruct page")
+
+	// ErrInvalidShardData indicates when the data reconstructed from a page's shards doesn't
+	// describe a page.
+	ErrInvalidShardData = errors.New("invalid shard data")
+)
+
+// ErasureCoder splits pages into Reed-Solomon data and parity shards, each itself a page, and
+// reconstructs pages from any sufficient subset of them. Since each shard has a different index,
+// each is stored under a distinct key and so by a different set of librarians.
+type ErasureCoder interface {
+	// Encode splits the page into its data shards followed by its parity shards.
+	Encode(page *api.Page) ([]*api.Page, error)
+
+	// Decode reconstructs the page from its shards, where missing shards are nil. Shards with
+	// an invalid MAC are treated as missing.
+	Decode(shards []*api.Page) (*api.Page, error)
+
+	// DataShards returns the number of data shards per page, which is the minimum needed to
+	// reconstruct it.
+	DataShards() uint32
+
+	// NShards returns the total number of data and parity shards per page.
+	NShards() uint32
+}
+
+type erasureCoder struct {
+	encoder      reedsolomon.Encoder
+	dataShards   uint32
+	parityShards uint32
+	keys         *enc.Keys
+}
+
+// NewErasureCoder creates a new ErasureCoder with the given number of data and parity shards per
+// page, using the keys to create and check the shard MACs.
+func NewErasureCoder(dataShards, parityShards uint32, keys *enc.Keys) (ErasureCoder, error) {
+	if err := api.ValidateHMACKey(keys.HMACKey); err != nil {
+		return nil, err
+	}
+	if parityShards == 0 {
+		return nil, ErrZeroParityShards
+	}
+	encoder, err := reedsolomon.New(int(dataShards), int(parityShards))
+	if err != nil {
+		return nil, err
+	}
+	return &erasureCoder{
+		encoder:      encoder,
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		keys:         keys,
+	}, nil
+}
+
+func (c *erasureCoder) Encode(page *api.Page) ([]*api.Page, error) {
+	data := make([]byte, shardHeaderLength, shardHeaderLength+len(page.Ciphertext))
+	binary.BigEndian.PutUint32(data[0:4], page.Index)
+	binary.BigEndian.PutUint32(data[4:8], uint32(len(page.Ciphertext)))
+	copy(data[8:shardHeaderLength], page.CiphertextMac)
+	data = append(data, page.Ciphertext...)
+
+	shardsData, err := c.encoder.Split(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.encoder.Encode(shardsData); err != nil {
+		return nil, err
+	}
+	shardMAC := enc.NewHMAC(c.keys.HMACKey)
+	shards := make([]*api.Page, len(shardsData))
+	for i, shardData := range shardsData {
+		shards[i], err = newPage(shardData, uint32(i), page.AuthorPublicKey, shardMAC)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shards, nil
+}
+
+func (c *erasureCoder) Decode(shards []*api.Page) (*api.Page, error) {
+	if len(shards) != int(c.NShards()) {
+		return nil, ErrUnexpectedNShards
+	}
+	shardsData := make([][]byte, len(shards))
+	var authorPub []byte
+	nShards := uint32(0)
+	for i, shard := range shards {
+		if !c.validShard(shard, uint32(i)) {
+			continue
+		}
+		shardsData[i] = shard.Ciphertext
+		authorPub = shard.AuthorPublicKey
+		nShards++
+	}
+	if nShards < c.dataShards {
+		return nil, ErrTooFewShards
+	}
+	if err := c.encoder.ReconstructData(shardsData); err != nil {
+		return nil, err
+	}
+	data := new(bytes.Buffer)
+	dataSize := len(shardsData[0]) * int(c.dataShards)
+	if err := c.encoder.Join(data, shardsData, dataSize); err != nil {
+		return nil, err
+	}
+	return parseShardData(data.Bytes(), authorPub)
+}
+
+func (c *erasureCoder) DataShards() uint32 {
+	return c.dataShards
+}
+
+func (c *erasureCoder) NShards() uint32 {
+	return c.dataShards + c.parityShards
+}
+
+func (c *erasureCoder) validShard(shard *api.Page, index uint32) bool {
+	if shard == nil || shard.Index != index {
+		return false
+	}
+	if err := api.ValidatePage(shard); err != nil {
+		return false
+	}
+	return hmac.Equal(enc.HMAC(shard.Ciphertext, c.keys.HMACKey), shard.CiphertextMac)
+}
+
+// parseShardData parses the header and ciphertext of the (possibly zero-padded) data joined from
+// a page's data shards.
+func parseShardData(data []byte, authorPub []byte) (*api.Page, error) {
+	if len(data) < shardHeaderLength {
+		return nil, ErrInvalidShardData
+	}
+	ciphertextLen := int(binary.BigEndian.Uint32(data[4:8]))
+	if ciphertextLen > len(data)-shardHeaderLength {
+		return nil, ErrInvalidShardData
+	}
+	page := &api.Page{
+		AuthorPublicKey: authorPub,
+		Index:           binary.BigEndian.Uint32(data[0:4]),
+		Ciphertext:      data[shardHeaderLength : shardHeaderLength+ciphertextLen],
+		CiphertextMac:   data[8:shardHeaderLength],
+	}
+	if err := api.ValidatePage(page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+type erasureStorer struct {
+	inner Storer
+	coder ErasureCoder
+}
+
+// NewErasureStorer creates a new Storer that stores the shards of each page to the inner Storer
+// and returns their keys, in page order, instead of the pages'.
+func NewErasureStorer(inner Storer, coder ErasureCoder) Storer {
+	return &erasureStorer{
+		inner: inner,
+		coder: coder,
+	}
+}
+
+func (s *erasureStorer) Store(pages chan *api.Page) ([]cid.ID, error) {
+	shards := make(chan *api.Page, int(s.coder.NShards()))
+	errs := make(chan error, 1)
+	go func() {
+		defer close(shards)
+		for page := range pages {
+			pageShards, err := s.coder.Encode(page)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, shard := range pageShards {
+				shards <- shard
+			}
+		}
+	}()
+
+	keys, err := s.inner.Store(shards)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case err = <-errs:
+		return nil, err
+	default:
+		return keys, nil
+	}
+}
+
+type erasureLoader struct {
+	inner Loader
+	coder ErasureCoder
+}
+
+// NewErasureLoader creates a new Loader that loads the shards with the given keys from the inner
+// Loader and sends the pages reconstructed from them. Shards that can't be loaded are treated as
+// missing, and once a page's data shards are loaded its parity shards are skipped.
+func NewErasureLoader(inner Loader, coder ErasureCoder) Loader {
+	return &erasureLoader{
+		inner: inner,
+		coder: coder,
+	}
+}
+
+func (l *erasureLoader) Load(keys []cid.ID, pages chan *api.Page, abort chan struct{}) error {
+	nShards := int(l.coder.NShards())
+	if len(keys)%nShards != 0 {
+		return ErrUnexpectedNShards
+	}
+	for i := 0; i < len(keys); i += nShards {
+		shards := make([]*api.Page, nShards)
+		nLoaded := uint32(0)
+		for j, key := range keys[i : i+nShards] {
+			if nLoaded == l.coder.DataShards() {
+				break
+			}
+			if shards[j] = l.loadShard(key); shards[j] != nil {
+				nLoaded++
+			}
+		}
+		page, err := l.coder.Decode(shards)
+		if err != nil {
+			return err
+		}
+		select {
+		case <-abort:
+			return nil
+		default:
+			pages <- page
+		}
+	}
+	return nil
+}
+
+// loadShard loads the shard with the given key from the inner Loader, returning nil if it can't
+// be loaded.
+func (l *erasureLoader) loadShard(key cid.ID) *api.Page {
+	shard := make(chan *api.Page, 1)
+	if err := l.inner.Load([]cid.ID{key}, shard, make(chan struct{})); err != nil {
+		return nil
+	}
+	select {
+	case page := <-shard:
+		return page
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,233 @@
+package page
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewErasureCoder_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+
+	// invalid HMACKey should bubble up
+	c1, err := NewErasureCoder(4, 2, &enc.Keys{})
+	assert.NotNil(t, err)
+	assert.Nil(t, c1)
+
+	// zero parity shards should create error
+	c2, err := NewErasureCoder(4, 0, keys)
+	assert.Equal(t, ErrZeroParityShards, err)
+	assert.Nil(t, c2)
+
+	// zero data shards should create error
+	c3, err := NewErasureCoder(0, 2, keys)
+	assert.NotNil(t, err)
+	assert.Nil(t, c3)
+}
+
+func TestErasureCoder_EncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	dataShards, parityShards := uint32(4), uint32(2)
+	c, err := NewErasureCoder(dataShards, parityShards, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, dataShards, c.DataShards())
+	assert.Equal(t, dataShards+parityShards, c.NShards())
+
+	for _, ciphertextSize := range []int{1, 17, 64, 1024} {
+		p := api.NewTestPage(rng)
+		p.Index = 3
+		p.Ciphertext = api.RandBytes(rng, ciphertextSize)
+
+		shards, err := c.Encode(p)
+		assert.Nil(t, err)
+		assert.Len(t, shards, int(c.NShards()))
+		shardKeys := make(map[string]struct{})
+		for i, shard := range shards {
+			assert.Equal(t, uint32(i), shard.Index)
+			assert.Equal(t, p.AuthorPublicKey, shard.AuthorPublicKey)
+			assert.Equal(t, enc.HMAC(shard.Ciphertext, keys.HMACKey), shard.CiphertextMac)
+			shardKey, err := api.GetKey(shard)
+			assert.Nil(t, err)
+			shardKeys[shardKey.String()] = struct{}{}
+		}
+		assert.Len(t, shardKeys, len(shards))
+
+		// check page is reconstructed from all shards
+		decoded, err := c.Decode(shards)
+		assert.Nil(t, err)
+		assert.Equal(t, p, decoded)
+
+		// check page is reconstructed with up to parityShards missing or invalid shards
+		missing := append([]*api.Page{}, shards...)
+		missing[0] = nil
+		missing[2] = &api.Page{
+			AuthorPublicKey: shards[2].AuthorPublicKey,
+			Index:           shards[2].Index,
+			Ciphertext:      shards[2].Ciphertext,
+			CiphertextMac:   api.RandBytes(rng, api.HMAC256Length),
+		}
+		decoded, err = c.Decode(missing)
+		assert.Nil(t, err)
+		assert.Equal(t, p, decoded)
+
+		// check too many missing shards creates error
+		missing[5] = nil
+		decoded, err = c.Decode(missing)
+		assert.Equal(t, ErrTooFewShards, err)
+		assert.Nil(t, decoded)
+	}
+}
+
+func TestErasureCoder_Decode_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	c, err := NewErasureCoder(2, 1, keys)
+	assert.Nil(t, err)
+	shards, err := c.Encode(api.NewTestPage(rng))
+	assert.Nil(t, err)
+
+	// check wrong number of shards creates error
+	decoded, err := c.Decode(shards[:2])
+	assert.Equal(t, ErrUnexpectedNShards, err)
+	assert.Nil(t, decoded)
+
+	// check shards out of order are treated as missing
+	decoded, err = c.Decode([]*api.Page{shards[1], shards[0], shards[2]})
+	assert.Equal(t, ErrTooFewShards, err)
+	assert.Nil(t, decoded)
+}
+
+func TestParseShardData(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+
+	// check too-short header creates error
+	p, err := parseShardData(make([]byte, shardHeaderLength-1), authorPub)
+	assert.Equal(t, ErrInvalidShardData, err)
+	assert.Nil(t, p)
+
+	// check too-long ciphertext length creates error
+	data := make([]byte, shardHeaderLength+8)
+	data[7] = 9
+	p, err = parseShardData(data, authorPub)
+	assert.Equal(t, ErrInvalidShardData, err)
+	assert.Nil(t, p)
+
+	// check invalid page creates error
+	data[7] = 0
+	p, err = parseShardData(data, authorPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, p)
+}
+
+func TestErasureStorerLoader_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	c, err := NewErasureCoder(3, 2, keys)
+	assert.Nil(t, err)
+	docSL := &memDocumentStorerLoader{stored: make(map[string]*api.Document)}
+	s := NewErasureStorer(NewStorerLoader(docSL), c)
+	l := NewErasureLoader(NewStorerLoader(docSL), c)
+
+	nPages := 4
+	expected := make([]*api.Page, nPages)
+	pages := make(chan *api.Page, nPages)
+	for i := range expected {
+		expected[i] = api.NewTestPage(rng)
+		expected[i].Index = uint32(i)
+		pages <- expected[i]
+	}
+	close(pages)
+
+	shardKeys, err := s.Store(pages)
+	assert.Nil(t, err)
+	assert.Len(t, shardKeys, nPages*int(c.NShards()))
+	assert.Len(t, docSL.stored, len(shardKeys))
+
+	// remove up to 2 shards from each page
+	for i := 0; i < nPages; i++ {
+		delete(docSL.stored, shardKeys[i*int(c.NShards())+i%3].String())
+		delete(docSL.stored, shardKeys[i*int(c.NShards())+3].String())
+	}
+
+	loaded := make(chan *api.Page, nPages)
+	err = l.Load(shardKeys, loaded, make(chan struct{}))
+	assert.Nil(t, err)
+	close(loaded)
+	i := 0
+	for p := range loaded {
+		assert.Equal(t, expected[i], p)
+		i++
+	}
+	assert.Equal(t, nPages, i)
+
+	// check aborted load sends no pages
+	abort := make(chan struct{})
+	close(abort)
+	loaded = make(chan *api.Page, nPages)
+	err = l.Load(shardKeys, loaded, abort)
+	assert.Nil(t, err)
+	assert.Len(t, loaded, 0)
+}
+
+func TestErasureStorer_Store_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	c, err := NewErasureCoder(3, 2, keys)
+	assert.Nil(t, err)
+
+	// check inner store error bubbles up
+	s1 := NewErasureStorer(NewStorerLoader(&errDocumentStorerLoader{}), c)
+	pages := make(chan *api.Page, 1)
+	pages <- api.NewTestPage(rng)
+	close(pages)
+	shardKeys, err := s1.Store(pages)
+	assert.NotNil(t, err)
+	assert.Nil(t, shardKeys)
+
+	// check encode error bubbles up
+	docSL := &memDocumentStorerLoader{stored: make(map[string]*api.Document)}
+	s2 := NewErasureStorer(NewStorerLoader(docSL), &errErasureCoder{ErasureCoder: c})
+	pages = make(chan *api.Page, 1)
+	pages <- api.NewTestPage(rng)
+	close(pages)
+	shardKeys, err = s2.Store(pages)
+	assert.NotNil(t, err)
+	assert.Nil(t, shardKeys)
+}
+
+func TestErasureLoader_Load_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	c, err := NewErasureCoder(3, 2, keys)
+	assert.Nil(t, err)
+	l := NewErasureLoader(NewStorerLoader(&errDocumentStorerLoader{}), c)
+	pages := make(chan *api.Page, 1)
+
+	// check wrong number of keys creates error
+	err = l.Load([]cid.ID{cid.NewPseudoRandom(rng)}, pages, make(chan struct{}))
+	assert.Equal(t, ErrUnexpectedNShards, err)
+
+	// check unloadable shards create error
+	shardKeys := make([]cid.ID, c.NShards())
+	for i := range shardKeys {
+		shardKeys[i] = cid.NewPseudoRandom(rng)
+	}
+	err = l.Load(shardKeys, pages, make(chan struct{}))
+	assert.Equal(t, ErrTooFewShards, err)
+}
+
+type errErasureCoder struct {
+	ErasureCoder
+}
+
+func (e *errErasureCoder) Encode(page *api.Page) ([]*api.Page, error) {
+	return nil, errors.New("some Encode error")
+}
@@ -107,15 +107,21 @@ func (p *paginator) ReadFrom(compressor io.Reader) (int64, error) {
 
 // getPage constructs a page from a given ciphertext.
 func (p *paginator) getPage(ciphertext []byte, index uint32) (*api.Page, error) {
-	p.pageMAC.Reset()
-	if _, err := p.pageMAC.Write(ciphertext); err != nil {
+	return newPage(ciphertext, index, p.authorPub, p.pageMAC)
+}
+
+// newPage constructs a page from a given ciphertext, using the pageMAC to compute its MAC.
+func newPage(ciphertext []byte, index uint32, authorPub []byte, pageMAC enc.MAC) (
+	*api.Page, error) {
+	pageMAC.Reset()
+	if _, err := pageMAC.Write(ciphertext); err != nil {
 		return nil, err
 	}
 	page := &api.Page{
-		AuthorPublicKey: p.authorPub,
+		AuthorPublicKey: authorPub,
 		Index:           index,
 		Ciphertext:      ciphertext,
-		CiphertextMac:   p.pageMAC.Sum(nil),
+		CiphertextMac:   pageMAC.Sum(nil),
 	}
 	if err := api.ValidatePage(page); err != nil {
 		// extra safeguard
@@ -143,6 +149,7 @@ type unpaginator struct {
 	compressedBuf *bytes.Buffer
 	pageMAC       enc.MAC
 	ciphertextMAC enc.MAC
+	firstIndex    uint32
 }
 
 // NewUnpaginator creates a new Unpaginator from the channel of pages and decrypter.
@@ -150,6 +157,17 @@ func NewUnpaginator(
 	pages chan *api.Page,
 	decrypter enc.Decrypter,
 	keys *enc.Keys,
+) (Unpaginator, error) {
+	return NewRangeUnpaginator(pages, decrypter, keys, 0)
+}
+
+// NewRangeUnpaginator creates a new Unpaginator like NewUnpaginator for a range of an entry's
+// pages, the first of which has the given index. Its CiphertextMAC then only covers the range.
+func NewRangeUnpaginator(
+	pages chan *api.Page,
+	decrypter enc.Decrypter,
+	keys *enc.Keys,
+	firstIndex uint32,
 ) (Unpaginator, error) {
 	if err := api.ValidateHMACKey(keys.HMACKey); err != nil {
 		return nil, err
@@ -159,12 +177,13 @@ func NewUnpaginator(
 		decrypter:     decrypter,
 		pageMAC:       enc.NewHMAC(keys.HMACKey),
 		ciphertextMAC: enc.NewHMAC(keys.HMACKey),
+		firstIndex:    firstIndex,
 	}, nil
 }
 
 func (u *unpaginator) WriteTo(decompressor comp.CloseWriter) (int64, error) {
 	var n int64
-	var pageIndex uint32
+	pageIndex := u.firstIndex
 	for page := range u.pages {
 		if err := api.ValidatePage(page); err != nil {
 			return n, err
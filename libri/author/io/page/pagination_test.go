@@ -61,7 +61,7 @@ func TestPaginator_ReadFrom_err(t *testing.T) {
 	pages := make(chan *api.Page, 3)
 	compressedBytes := []byte("some fake compressed bytes")
 
-	encrypter, err := enc.NewEncrypter(keys)
+	encrypter, err := enc.NewEncrypter(keys, enc.DefaultAEADSuite)
 	assert.Nil(t, err)
 
 	// check that compressed read error bubbles up
@@ -148,7 +148,7 @@ func (f *fixedDecrypter) Decrypt(ciphertext []byte, pageIndex uint32) ([]byte, e
 func TestUnpaginator_WriteTo_err(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	keys, _, _ := enc.NewPseudoRandomKeys(rng)
-	decrypter, err := enc.NewDecrypter(keys)
+	decrypter, err := enc.NewDecrypter(keys, enc.DefaultAEADSuite)
 	assert.Nil(t, err)
 	pages := make(chan *api.Page, 1)
 
@@ -258,9 +258,9 @@ func TestPaginateUnpaginate(t *testing.T) {
 	keys, _, _ := enc.NewPseudoRandomKeys(rng)
 	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
 
-	encrypter, err := enc.NewEncrypter(keys)
+	encrypter, err := enc.NewEncrypter(keys, enc.DefaultAEADSuite)
 	assert.Nil(t, err)
-	decrypter, err := enc.NewDecrypter(keys)
+	decrypter, err := enc.NewDecrypter(keys, enc.DefaultAEADSuite)
 	assert.Nil(t, err)
 
 	MinSize = 64 // just for testing
@@ -308,6 +308,45 @@ func TestPaginateUnpaginate(t *testing.T) {
 	}
 }
 
+func TestRangeUnpaginator_WriteTo(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+	encrypter, err := enc.NewEncrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+	decrypter, err := enc.NewDecrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+
+	MinSize = 64 // just for testing
+	pageSize, firstIndex := uint32(128), uint32(2)
+	uncompressed1 := common.NewCompressableBytes(rng, 1024)
+	uncompressed1Bytes := uncompressed1.Bytes()
+	allPages := make(chan *api.Page, 1024/pageSize+1)
+	paginator, err := NewPaginator(allPages, encrypter, keys, authorPub, pageSize)
+	assert.Nil(t, err)
+	_, err = paginator.ReadFrom(uncompressed1)
+	assert.Nil(t, err)
+	close(allPages)
+
+	// skip the pages before the first index
+	pages := make(chan *api.Page, 1024/pageSize+1)
+	for page := range allPages {
+		if page.Index >= firstIndex {
+			pages <- page
+		}
+	}
+	close(pages)
+
+	uncompressed2 := new(bytes.Buffer)
+	decompressor, err := comp.NewDecompressor(uncompressed2, comp.NoneCodec, keys, pageSize)
+	assert.Nil(t, err)
+	unpaginator, err := NewRangeUnpaginator(pages, decrypter, keys, firstIndex)
+	assert.Nil(t, err)
+	_, err = unpaginator.WriteTo(decompressor)
+	assert.Nil(t, err)
+	assert.Equal(t, uncompressed1Bytes[firstIndex*pageSize:], uncompressed2.Bytes())
+}
+
 type pageTestCase struct {
 	pageSize         uint32
 	uncompressedSize int
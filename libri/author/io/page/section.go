@@ -0,0 +1,188 @@
+package page
+
+import (
+	"errors"
+	"io"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+// ErrZeroParallelism indicates when a SectionPaginator's parallelism is improperly set to zero.
+var ErrZeroParallelism = errors.New("zero value parallelism")
+
+// SectionPaginator reads uncompressed content from an io.SectionReader and emits it in discrete
+// pages. Since the content's size is known, so are the number of pages and the offset of each,
+// which lets it read and encrypt them in parallel.
+type SectionPaginator interface {
+	// ReadSection reads all of the content from the io.SectionReader and emits its encrypted
+	// pages to the underlying channel in order.
+	ReadSection(content *io.SectionReader) (int64, error)
+
+	// CiphertextMAC is the MAC for the entire ciphertext across all pages.
+	CiphertextMAC() enc.MAC
+
+	// UncompressedMAC is the MAC for the entire uncompressed content.
+	UncompressedMAC() enc.MAC
+}
+
+type sectionPaginator struct {
+	pages           chan *api.Page
+	keys            *enc.Keys
+	suite           enc.AEADSuite
+	authorPub       []byte
+	pageSize        uint32
+	parallelism     uint32
+	ciphertextMAC   enc.MAC
+	uncompressedMAC enc.MAC
+}
+
+// sectionPage is a page (or the error creating it) along with the uncompressed content it
+// contains.
+type sectionPage struct {
+	uncompressed []byte
+	page         *api.Page
+	err          error
+}
+
+// NewSectionPaginator creates a new SectionPaginator that emits pages to the given channel,
+// creating up to parallelism pages at a time and encrypting them with the AEAD suite. Its pages are
+// the same as those of a Paginator reading from a comp.NoneCodec compressor.
+func NewSectionPaginator(
+	pages chan *api.Page,
+	keys *enc.Keys,
+	suite enc.AEADSuite,
+	authorPub []byte,
+	pageSize uint32,
+	parallelism uint32,
+) (SectionPaginator, error) {
+	if err := api.ValidateHMACKey(keys.HMACKey); err != nil {
+		return nil, err
+	}
+	if err := enc.ValidateAEADSuite(suite); err != nil {
+		return nil, err
+	}
+	if err := api.ValidatePublicKey(authorPub); err != nil {
+		return nil, err
+	}
+	if pageSize < MinSize {
+		return nil, ErrPageSizeTooSmall
+	}
+	if parallelism == 0 {
+		return nil, ErrZeroParallelism
+	}
+	return &sectionPaginator{
+		pages:           pages,
+		keys:            keys,
+		suite:           suite,
+		authorPub:       authorPub,
+		pageSize:        pageSize,
+		parallelism:     parallelism,
+		ciphertextMAC:   enc.NewHMAC(keys.HMACKey),
+		uncompressedMAC: enc.NewHMAC(keys.HMACKey),
+	}, nil
+}
+
+// NumPages returns the number of pages a Paginator or SectionPaginator emits for size bytes of
+// compressed content, which always includes a last partial (possibly empty) page.
+func NumPages(size int64, pageSize uint32) uint32 {
+	return uint32(size/int64(pageSize)) + 1
+}
+
+func (p *sectionPaginator) ReadSection(content *io.SectionReader) (int64, error) {
+	nPages := NumPages(content.Size(), p.pageSize)
+	results := make([]chan *sectionPage, nPages)
+	for i := range results {
+		results[i] = make(chan *sectionPage, 1)
+	}
+
+	// window limits how many pages are created before they're emitted, and done stops creating
+	// them after an error
+	window := make(chan struct{}, p.parallelism)
+	done := make(chan struct{})
+	defer close(done)
+	indices := make(chan uint32)
+	go func() {
+		defer close(indices)
+		for i := uint32(0); i < nPages; i++ {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+			indices <- i
+		}
+	}()
+	for w := uint32(0); w < p.parallelism; w++ {
+		go p.createPages(content, indices, results)
+	}
+
+	var n int64
+	for i := range results {
+		result := <-results[i]
+		if result.err != nil {
+			return n, result.err
+		}
+		if _, err := p.uncompressedMAC.Write(result.uncompressed); err != nil {
+			return n, err
+		}
+		if _, err := p.ciphertextMAC.Write(result.page.Ciphertext); err != nil {
+			return n, err
+		}
+		n += int64(len(result.uncompressed))
+		p.pages <- result.page
+		<-window
+	}
+	return n, nil
+}
+
+// createPages reads the content of and encrypts each page index it receives, sending the page to
+// the index's result channel.
+func (p *sectionPaginator) createPages(
+	content *io.SectionReader, indices chan uint32, results []chan *sectionPage,
+) {
+	encrypter, err := enc.NewEncrypter(p.keys, p.suite)
+	pageMAC := enc.NewHMAC(p.keys.HMACKey)
+	for i := range indices {
+		if err != nil {
+			results[i] <- &sectionPage{err: err}
+			continue
+		}
+		results[i] <- p.createPage(content, i, encrypter, pageMAC)
+	}
+}
+
+func (p *sectionPaginator) createPage(
+	content *io.SectionReader, index uint32, encrypter enc.Encrypter, pageMAC enc.MAC,
+) *sectionPage {
+	offset := int64(index) * int64(p.pageSize)
+	length := content.Size() - offset
+	if length > int64(p.pageSize) {
+		length = int64(p.pageSize)
+	}
+	uncompressed := make([]byte, int(length))
+	if nr, err := content.ReadAt(uncompressed, offset); nr < len(uncompressed) {
+		if err == io.EOF {
+			// content is shorter than its size
+			err = io.ErrUnexpectedEOF
+		}
+		return &sectionPage{err: err}
+	}
+	pageCiphertext, err := encrypter.Encrypt(uncompressed, index)
+	if err != nil {
+		return &sectionPage{err: err}
+	}
+	page, err := newPage(pageCiphertext, index, p.authorPub, pageMAC)
+	if err != nil {
+		return &sectionPage{err: err}
+	}
+	return &sectionPage{uncompressed: uncompressed, page: page}
+}
+
+func (p *sectionPaginator) CiphertextMAC() enc.MAC {
+	return p.ciphertextMAC
+}
+
+func (p *sectionPaginator) UncompressedMAC() enc.MAC {
+	return p.uncompressedMAC
+}
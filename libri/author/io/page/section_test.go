@@ -0,0 +1,171 @@
+package page
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/comp"
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSectionPaginator_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys1, authorPub1, _ := enc.NewPseudoRandomKeys(rng)
+	keys1.HMACKey = nil
+
+	// invalid HMACKey should bubble up
+	p1, err := NewSectionPaginator(nil, keys1, enc.DefaultAEADSuite, authorPub1, MinSize, 2)
+	assert.NotNil(t, err)
+	assert.Nil(t, p1)
+
+	keys2, authorPub2, _ := enc.NewPseudoRandomKeys(rng)
+
+	// unsupported AEAD suite should create error
+	p5, err := NewSectionPaginator(nil, keys2, enc.AEADSuite("unsupported"), authorPub2, MinSize,
+		2)
+	assert.Equal(t, enc.ErrUnsupportedAEADSuite, err)
+	assert.Nil(t, p5)
+
+	// invalid author public key should bubble up
+	p2, err := NewSectionPaginator(nil, keys2, enc.DefaultAEADSuite, nil, MinSize, 2)
+	assert.NotNil(t, err)
+	assert.Nil(t, p2)
+
+	// too small page size should create error
+	p3, err := NewSectionPaginator(nil, keys2, enc.DefaultAEADSuite, authorPub2, 0, 2)
+	assert.Equal(t, ErrPageSizeTooSmall, err)
+	assert.Nil(t, p3)
+
+	// zero parallelism should create error
+	p4, err := NewSectionPaginator(nil, keys2, enc.DefaultAEADSuite, authorPub2, MinSize, 0)
+	assert.Equal(t, ErrZeroParallelism, err)
+	assert.Nil(t, p4)
+}
+
+func TestNumPages(t *testing.T) {
+	assert.Equal(t, uint32(1), NumPages(0, 64))
+	assert.Equal(t, uint32(1), NumPages(63, 64))
+	assert.Equal(t, uint32(2), NumPages(64, 64))
+	assert.Equal(t, uint32(3), NumPages(129, 64))
+}
+
+func TestSectionPaginator_ReadSection(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+	encrypter, err := enc.NewEncrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+
+	MinSize = 64 // just for testing
+	uncompressedSizes := []int{128, 192, 256, 384, 512, 768, 1024, 2048, 4096, 8192}
+	pageSizes := []uint32{128, 256, 512, 1024}
+	parallelisms := []uint32{1, 2, 3}
+
+	for _, c := range caseCrossProduct(pageSizes, uncompressedSizes, []comp.Codec{comp.NoneCodec}) {
+		for _, parallelism := range parallelisms {
+			uncompressed := api.RandBytes(rng, c.uncompressedSize)
+
+			// get pages from Paginator reading from a comp.NoneCodec compressor
+			pages1 := make(chan *api.Page, NumPages(int64(c.uncompressedSize), c.pageSize))
+			paginator1, err := NewPaginator(pages1, encrypter, keys, authorPub, c.pageSize)
+			assert.Nil(t, err)
+			compressor, err := comp.NewCompressor(bytes.NewReader(uncompressed),
+				comp.NoneCodec, keys, comp.MinBufferSize)
+			assert.Nil(t, err)
+			_, err = paginator1.ReadFrom(compressor)
+			assert.Nil(t, err)
+			close(pages1)
+
+			pages2 := make(chan *api.Page, 1)
+			paginator2, err := NewSectionPaginator(pages2, keys, enc.DefaultAEADSuite, authorPub,
+				c.pageSize, parallelism)
+			assert.Nil(t, err)
+			section := io.NewSectionReader(bytes.NewReader(uncompressed), 0,
+				int64(c.uncompressedSize))
+			go func() {
+				n, err := paginator2.ReadSection(section)
+				assert.Nil(t, err, c.String())
+				assert.Equal(t, int64(c.uncompressedSize), n, c.String())
+				close(pages2)
+			}()
+
+			// check pages are the same and in the same order
+			for page1 := range pages1 {
+				assert.Equal(t, page1, <-pages2, c.String())
+			}
+			_, open := <-pages2
+			assert.False(t, open)
+
+			assert.Equal(t, paginator1.CiphertextMAC().MessageSize(),
+				paginator2.CiphertextMAC().MessageSize())
+			assert.Equal(t, paginator1.CiphertextMAC().Sum(nil),
+				paginator2.CiphertextMAC().Sum(nil))
+			assert.Equal(t, compressor.UncompressedMAC().MessageSize(),
+				paginator2.UncompressedMAC().MessageSize())
+			assert.Equal(t, compressor.UncompressedMAC().Sum(nil),
+				paginator2.UncompressedMAC().Sum(nil))
+		}
+	}
+
+	// check empty content has a single empty page
+	pages := make(chan *api.Page, 2)
+	p, err := NewSectionPaginator(pages, keys, enc.DefaultAEADSuite, authorPub, 128, 2)
+	assert.Nil(t, err)
+	n, err := p.ReadSection(io.NewSectionReader(bytes.NewReader(nil), 0, 0))
+	assert.Nil(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, pages, 1)
+	decrypter, err := enc.NewDecrypter(keys, enc.DefaultAEADSuite)
+	assert.Nil(t, err)
+	plaintext, err := decrypter.Decrypt((<-pages).Ciphertext, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, plaintext)
+}
+
+func TestSectionPaginator_ReadSection_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	authorPub := api.RandBytes(rng, api.ECPubKeyLength)
+	MinSize = 64 // just for testing
+
+	// check content shorter than its size triggers error
+	pages := make(chan *api.Page, 16)
+	p1, err := NewSectionPaginator(pages, keys, enc.DefaultAEADSuite, authorPub, 128, 2)
+	assert.Nil(t, err)
+	_, err = p1.ReadSection(io.NewSectionReader(bytes.NewReader(api.RandBytes(rng, 256)), 0,
+		1024))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// check read error bubbles up
+	p2, err := NewSectionPaginator(pages, keys, enc.DefaultAEADSuite, authorPub, 128, 2)
+	assert.Nil(t, err)
+	_, err = p2.ReadSection(io.NewSectionReader(errReaderAt{}, 0, 1024))
+	assert.NotNil(t, err)
+
+	// check encrypter error bubbles up
+	p3, err := NewSectionPaginator(pages, &enc.Keys{HMACKey: keys.HMACKey}, enc.DefaultAEADSuite,
+		authorPub, 128, 2)
+	assert.Nil(t, err)
+	_, err = p3.ReadSection(io.NewSectionReader(bytes.NewReader(api.RandBytes(rng, 256)), 0,
+		256))
+	assert.NotNil(t, err)
+
+	// check page error bubbles up
+	p4, err := NewSectionPaginator(pages, keys, enc.DefaultAEADSuite, authorPub, 128, 2)
+	assert.Nil(t, err)
+	p4.(*sectionPaginator).authorPub = nil
+	_, err = p4.ReadSection(io.NewSectionReader(bytes.NewReader(api.RandBytes(rng, 256)), 0,
+		256))
+	assert.NotNil(t, err)
+}
+
+type errReaderAt struct{}
+
+func (errReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("some ReadAt error")
+}
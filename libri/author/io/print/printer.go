@@ -3,6 +3,7 @@ package print
 import (
 	"errors"
 	"io"
+	"os"
 
 	"github.com/drausin/libri/libri/author/io/comp"
 	"github.com/drausin/libri/libri/author/io/enc"
@@ -28,9 +29,30 @@ type Parameters struct {
 	// PageSize is the maximum size (in bytes) of an api.Page ciphertext.
 	PageSize uint32
 
+	// CompressionCodec is the comp.Codec used by Printers for content that isn't already
+	// compressed. Scanners use the comp.Codec recorded in the entry metadata.
+	CompressionCodec comp.Codec
+
+	// Chunking is the page.Chunking used by Printers to split content into pages, where
+	// page.ContentDefinedChunking lets re-printing modified content with the same keys reuse the
+	// pages of its unmodified parts. Scanners use the page.Chunking recorded in the entry
+	// metadata.
+	Chunking page.Chunking
+
+	// AEADSuite is the enc.AEADSuite used by Printers to encrypt pages. Scanners use the
+	// enc.AEADSuite recorded in the entry metadata.
+	AEADSuite enc.AEADSuite
+
 	// Parallelism is the parallelism used by Printers and Scanners when storing and loading
 	// pages.
 	Parallelism uint32
+
+	// DataShards and ParityShards are the number of Reed-Solomon data and parity shards
+	// Printers split each page into, so an entry remains recoverable when shards of a page are
+	// unavailable. Zero ParityShards disables erasure coding. Scanners use the number of shards
+	// recorded in the entry metadata.
+	DataShards   uint32
+	ParityShards uint32
 }
 
 // NewParameters creates a new *Parameters instance.
@@ -43,6 +65,9 @@ func NewParameters(
 	return &Parameters{
 		CompressionBufferSize: compressionBufferSize,
 		PageSize:              pageSize,
+		CompressionCodec:      comp.DefaultCodec,
+		Chunking:              page.DefaultChunking,
+		AEADSuite:             enc.DefaultAEADSuite,
 		Parallelism:           parallelism,
 	}, nil
 }
@@ -57,9 +82,48 @@ func NewDefaultParameters() *Parameters {
 	return params
 }
 
+// getCompressionCodec returns the comp.Codec to print content with the given media type, which is
+// the CompressionCodec (or the default if it is empty) unless the content is already compressed.
+func (p *Parameters) getCompressionCodec(mediaType string) (comp.Codec, error) {
+	codec, err := comp.GetCompressionCodec(mediaType)
+	if err != nil || codec == comp.NoneCodec || p.CompressionCodec == "" {
+		return codec, err
+	}
+	return p.CompressionCodec, comp.ValidateCodec(p.CompressionCodec)
+}
+
+// getChunking returns the page.Chunking to print content with, which is the default if Chunking
+// is empty.
+func (p *Parameters) getChunking() (page.Chunking, error) {
+	if p.Chunking == "" {
+		return page.DefaultChunking, nil
+	}
+	return p.Chunking, page.ValidateChunking(p.Chunking)
+}
+
+// getAEADSuite returns the enc.AEADSuite to encrypt pages with, which is the default if AEADSuite
+// is empty.
+func (p *Parameters) getAEADSuite() (enc.AEADSuite, error) {
+	if p.AEADSuite == "" {
+		return enc.DefaultAEADSuite, nil
+	}
+	return p.AEADSuite, enc.ValidateAEADSuite(p.AEADSuite)
+}
+
+// getErasureCoder returns the page.ErasureCoder to print pages with, or nil if ParityShards is
+// zero.
+func (p *Parameters) getErasureCoder(keys *enc.Keys) (page.ErasureCoder, error) {
+	if p.ParityShards == 0 {
+		return nil, nil
+	}
+	return page.NewErasureCoder(p.DataShards, p.ParityShards, keys)
+}
+
 // Printer stores pages created from (uncompressed) content.
 type Printer interface {
 	// Print creates pages from the given content and stores them via an internal page.Storer.
+	// If the content's size is known and it can be read at any offset (e.g., a regular
+	// *os.File), uncompressed content is read and encrypted in parallel.
 	Print(content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte) (
 		[]id.ID, *api.Metadata, error)
 }
@@ -87,8 +151,34 @@ func NewPrinter(
 func (p *printer) Print(content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte) (
 	[]id.ID, *api.Metadata, error) {
 
+	codec, err := p.params.getCompressionCodec(mediaType)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunking, err := p.params.getChunking()
+	if err != nil {
+		return nil, nil, err
+	}
+	suite, err := p.params.getAEADSuite()
+	if err != nil {
+		return nil, nil, err
+	}
+	coder, err := p.params.getErasureCoder(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	pageS := p.pageS
+	if coder != nil {
+		pageS = page.NewErasureStorer(p.pageS, coder)
+	}
+	if chunking == page.FixedChunking && codec == comp.NoneCodec && p.params.Parallelism > 1 {
+		if section, ok := getSection(content); ok {
+			return p.printSection(section, mediaType, suite, keys, authorPub, pageS, coder)
+		}
+	}
 	pages := make(chan *api.Page, int(p.params.Parallelism))
-	compressor, paginator, err := p.init.Initialize(content, mediaType, keys, authorPub, pages)
+	compressor, paginator, err := p.init.Initialize(content, codec, chunking, suite, keys,
+		authorPub, pages)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -101,7 +191,7 @@ func (p *printer) Print(content io.Reader, mediaType string, keys *enc.Keys, aut
 		close(pages)
 	}()
 
-	pageKeys, err := p.pageS.Store(pages)
+	pageKeys, err := pageS.Store(pages)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -122,13 +212,117 @@ func (p *printer) Print(content io.Reader, mediaType string, keys *enc.Keys, aut
 	if err != nil {
 		return nil, nil, err
 	}
+	metadata.SetCompressionCodec(string(codec))
+	metadata.SetChunking(string(chunking))
+	metadata.SetAEADSuite(string(suite))
+	if chunking == page.FixedChunking {
+		metadata.SetPageSize(uint64(p.params.PageSize))
+	}
+	setErasureShards(metadata, coder)
+
+	return pageKeys, metadata, nil
+}
+
+// printSection prints the uncompressed content from the section, which is read and encrypted in
+// parallel since its pages are known up front.
+func (p *printer) printSection(
+	section *io.SectionReader,
+	mediaType string,
+	suite enc.AEADSuite,
+	keys *enc.Keys,
+	authorPub []byte,
+	pageS page.Storer,
+	coder page.ErasureCoder,
+) ([]id.ID, *api.Metadata, error) {
+
+	pages := make(chan *api.Page, int(p.params.Parallelism))
+	paginator, err := page.NewSectionPaginator(pages, keys, suite, authorPub, p.params.PageSize,
+		p.params.Parallelism)
+	if err != nil {
+		return nil, nil, err
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, rsErr := paginator.ReadSection(section)
+		if rsErr != nil {
+			errs <- rsErr
+		}
+		close(pages)
+	}()
+
+	pageKeys, err := pageS.Store(pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	select {
+	case err = <-errs:
+		return nil, nil, err
+	default:
+	}
+
+	metadata, err := api.NewEntryMetadata(
+		mediaType,
+		paginator.CiphertextMAC().MessageSize(),
+		paginator.CiphertextMAC().Sum(nil),
+		paginator.UncompressedMAC().MessageSize(),
+		paginator.UncompressedMAC().Sum(nil),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata.SetCompressionCodec(string(comp.NoneCodec))
+	metadata.SetChunking(string(page.FixedChunking))
+	metadata.SetAEADSuite(string(suite))
+	metadata.SetPageSize(uint64(p.params.PageSize))
+	setErasureShards(metadata, coder)
 
 	return pageKeys, metadata, nil
 }
 
+// setErasureShards records the number of data and parity shards per page in the metadata if the
+// pages were erasure coded.
+func setErasureShards(metadata *api.Metadata, coder page.ErasureCoder) {
+	if coder != nil {
+		metadata.SetErasureShards(uint64(coder.DataShards()),
+			uint64(coder.NShards()-coder.DataShards()))
+	}
+}
+
+// getSection returns an io.SectionReader of the rest of the content if its size is known and it
+// can be read at any offset, like a regular *os.File or *bytes.Reader. The content is then
+// consumed as if it had been read. Other *os.Files, like a piped os.Stdin or a device, have no
+// section even if they can be seeked.
+func getSection(content io.Reader) (*io.SectionReader, bool) {
+	if file, ok := content.(*os.File); ok {
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, false
+		}
+	}
+	readerAt, ok := content.(io.ReaderAt)
+	if !ok {
+		return nil, false
+	}
+	seeker, ok := content.(io.Seeker)
+	if !ok {
+		return nil, false
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, false
+	}
+	return io.NewSectionReader(readerAt, offset, end-offset), true
+}
+
 type printInitializer interface {
-	Initialize(content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte,
-		pages chan *api.Page) (comp.Compressor, page.Paginator, error)
+	Initialize(content io.Reader, codec comp.Codec, chunking page.Chunking, suite enc.AEADSuite,
+		keys *enc.Keys, authorPub []byte, pages chan *api.Page) (
+		comp.Compressor, page.Paginator, error)
 }
 
 type printInitializerImpl struct {
@@ -136,19 +330,24 @@ type printInitializerImpl struct {
 }
 
 func (pi *printInitializerImpl) Initialize(
-	content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte, pages chan *api.Page,
+	content io.Reader,
+	codec comp.Codec,
+	chunking page.Chunking,
+	suite enc.AEADSuite,
+	keys *enc.Keys,
+	authorPub []byte,
+	pages chan *api.Page,
 ) (comp.Compressor, page.Paginator, error) {
 
-	codec, err := comp.GetCompressionCodec(mediaType)
-	if err != nil {
-		return nil, nil, err
+	if chunking == page.ContentDefinedChunking {
+		return pi.initializeChunking(content, codec, suite, keys, authorPub, pages)
 	}
 	compressor, err := comp.NewCompressor(content, codec, keys,
 		pi.params.CompressionBufferSize)
 	if err != nil {
 		return nil, nil, err
 	}
-	encrypter, err := enc.NewEncrypter(keys)
+	encrypter, err := enc.NewEncrypter(keys, suite)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -159,3 +358,31 @@ func (pi *printInitializerImpl) Initialize(
 	}
 	return compressor, paginator, nil
 }
+
+// initializeChunking initializes a chunking page.Paginator, which compresses each chunk itself, so
+// the comp.Compressor just passes the uncompressed content through.
+func (pi *printInitializerImpl) initializeChunking(
+	content io.Reader,
+	codec comp.Codec,
+	suite enc.AEADSuite,
+	keys *enc.Keys,
+	authorPub []byte,
+	pages chan *api.Page,
+) (comp.Compressor, page.Paginator, error) {
+
+	compressor, err := comp.NewCompressor(content, comp.NoneCodec, keys,
+		pi.params.CompressionBufferSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	encrypter, err := enc.NewContentEncrypter(keys, suite)
+	if err != nil {
+		return nil, nil, err
+	}
+	paginator, err := page.NewChunkingPaginator(pages, encrypter, keys, authorPub,
+		pi.params.PageSize, codec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return compressor, paginator, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"math/rand"
+	"os"
 	"testing"
 
 	"fmt"
@@ -36,6 +37,99 @@ func TestNewParameters_err(t *testing.T) {
 	assert.Nil(t, params)
 }
 
+func TestParameters_getCompressionCodec(t *testing.T) {
+	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
+	assert.Nil(t, err)
+
+	// check uses default codec
+	codec, err := params.getCompressionCodec("application/x-pdf")
+	assert.Nil(t, err)
+	assert.Equal(t, comp.DefaultCodec, codec)
+
+	// check uses parameters codec
+	for _, c := range []comp.Codec{comp.ZstdCodec, comp.LZ4Codec, comp.NoneCodec} {
+		params.CompressionCodec = c
+		codec, err = params.getCompressionCodec("application/x-pdf")
+		assert.Nil(t, err)
+		assert.Equal(t, c, codec)
+	}
+
+	// check doesn't compress something already compressed
+	params.CompressionCodec = comp.ZstdCodec
+	codec, err = params.getCompressionCodec("application/x-gzip")
+	assert.Nil(t, err)
+	assert.Equal(t, comp.NoneCodec, codec)
+
+	// check empty codec uses default
+	params.CompressionCodec = ""
+	codec, err = params.getCompressionCodec("application/x-pdf")
+	assert.Nil(t, err)
+	assert.Equal(t, comp.DefaultCodec, codec)
+
+	// check bad media type triggers error
+	_, err = params.getCompressionCodec("application/")
+	assert.NotNil(t, err)
+
+	// check unsupported codec triggers error
+	params.CompressionCodec = comp.Codec("unsupported")
+	_, err = params.getCompressionCodec("application/x-pdf")
+	assert.Equal(t, comp.ErrUnsupportedCodec, err)
+}
+
+func TestParameters_getChunking(t *testing.T) {
+	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
+	assert.Nil(t, err)
+
+	// check uses default chunking
+	chunking, err := params.getChunking()
+	assert.Nil(t, err)
+	assert.Equal(t, page.DefaultChunking, chunking)
+
+	// check uses parameters chunking
+	params.Chunking = page.ContentDefinedChunking
+	chunking, err = params.getChunking()
+	assert.Nil(t, err)
+	assert.Equal(t, page.ContentDefinedChunking, chunking)
+
+	// check empty chunking uses default
+	params.Chunking = ""
+	chunking, err = params.getChunking()
+	assert.Nil(t, err)
+	assert.Equal(t, page.DefaultChunking, chunking)
+
+	// check unsupported chunking triggers error
+	params.Chunking = page.Chunking("unsupported")
+	_, err = params.getChunking()
+	assert.Equal(t, page.ErrUnsupportedChunking, err)
+}
+
+func TestParameters_getAEADSuite(t *testing.T) {
+	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
+	assert.Nil(t, err)
+
+	// check uses default AEAD suite
+	suite, err := params.getAEADSuite()
+	assert.Nil(t, err)
+	assert.Equal(t, enc.DefaultAEADSuite, suite)
+
+	// check uses parameters AEAD suite
+	params.AEADSuite = enc.XChaCha20Poly1305Suite
+	suite, err = params.getAEADSuite()
+	assert.Nil(t, err)
+	assert.Equal(t, enc.XChaCha20Poly1305Suite, suite)
+
+	// check empty AEAD suite uses default
+	params.AEADSuite = ""
+	suite, err = params.getAEADSuite()
+	assert.Nil(t, err)
+	assert.Equal(t, enc.DefaultAEADSuite, suite)
+
+	// check unsupported AEAD suite triggers error
+	params.AEADSuite = enc.AEADSuite("unsupported")
+	_, err = params.getAEADSuite()
+	assert.Equal(t, enc.ErrUnsupportedAEADSuite, err)
+}
+
 func TestPrinter_Print_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
@@ -80,6 +174,15 @@ func TestPrinter_Print_ok(t *testing.T) {
 	assert.Equal(t, uint64(readCiphertextN), actualCiphertextSize)
 	actualCiphertextSum, _ := entryMetadata.GetCiphertextMAC()
 	assert.Equal(t, ciphertextSum, actualCiphertextSum)
+	actualCodec, in := entryMetadata.GetCompressionCodec()
+	assert.True(t, in)
+	assert.Equal(t, string(comp.DefaultCodec), actualCodec)
+	actualChunking, in := entryMetadata.GetChunking()
+	assert.True(t, in)
+	assert.Equal(t, string(page.DefaultChunking), actualChunking)
+	actualSuite, in := entryMetadata.GetAEADSuite()
+	assert.True(t, in)
+	assert.Equal(t, string(enc.DefaultAEADSuite), actualSuite)
 }
 
 func TestPrinter_Print_err(t *testing.T) {
@@ -89,6 +192,33 @@ func TestPrinter_Print_err(t *testing.T) {
 	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
 	content, mediaType := bytes.NewReader(api.RandBytes(rng, 64)), "application/x-pdf"
 
+	// check that bad media type triggers error
+	printer0 := NewPrinter(params, &fixedStorer{})
+	pageKeys, entryMetadata, err := printer0.Print(content, "application/", keys, authorPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, pageKeys)
+	assert.Nil(t, entryMetadata)
+
+	// check that unsupported chunking triggers error
+	params0, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
+	assert.Nil(t, err)
+	params0.Chunking = page.Chunking("unsupported")
+	printer0 = NewPrinter(params0, &fixedStorer{})
+	pageKeys, entryMetadata, err = printer0.Print(content, mediaType, keys, authorPub)
+	assert.Equal(t, page.ErrUnsupportedChunking, err)
+	assert.Nil(t, pageKeys)
+	assert.Nil(t, entryMetadata)
+
+	// check that unsupported AEAD suite triggers error
+	params0, err = NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
+	assert.Nil(t, err)
+	params0.AEADSuite = enc.AEADSuite("unsupported")
+	printer0 = NewPrinter(params0, &fixedStorer{})
+	pageKeys, entryMetadata, err = printer0.Print(content, mediaType, keys, authorPub)
+	assert.Equal(t, enc.ErrUnsupportedAEADSuite, err)
+	assert.Nil(t, pageKeys)
+	assert.Nil(t, entryMetadata)
+
 	printer1 := NewPrinter(params, &fixedStorer{})
 	printer1.(*printer).init = &fixedPrintInitializer{
 		initCompressor: nil,
@@ -97,7 +227,7 @@ func TestPrinter_Print_err(t *testing.T) {
 	}
 
 	// check that init error bubbles up
-	pageKeys, entryMetadata, err := printer1.Print(content, mediaType, keys, authorPub)
+	pageKeys, entryMetadata, err = printer1.Print(content, mediaType, keys, authorPub)
 	assert.NotNil(t, err)
 	assert.Nil(t, pageKeys)
 	assert.Nil(t, entryMetadata)
@@ -194,6 +324,330 @@ func TestPrintScan(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, content1Bytes, content2.Bytes())
 	}
+
+	// check scanner uses codec printed with
+	for _, codec := range []comp.Codec{comp.ZstdCodec, comp.LZ4Codec} {
+		printParams, err := NewParameters(comp.MinBufferSize, 256, DefaultParallelism)
+		assert.Nil(t, err)
+		printParams.CompressionCodec = codec
+		scanParams, err := NewParameters(comp.MinBufferSize, 256, DefaultParallelism)
+		assert.Nil(t, err)
+		p := NewPrinter(printParams, pageSL)
+		s := NewScanner(scanParams, pageSL)
+
+		content1 := common.NewCompressableBytes(rng, 4096)
+		content1Bytes := content1.Bytes()
+
+		pageKey, metadata, err := p.Print(content1, "application/x-pdf", keys, authorPub)
+		assert.Nil(t, err)
+		printedCodec, in := metadata.GetCompressionCodec()
+		assert.True(t, in)
+		assert.Equal(t, string(codec), printedCodec)
+
+		content2 := new(bytes.Buffer)
+		err = s.Scan(content2, pageKey, keys, metadata)
+		assert.Nil(t, err)
+		assert.Equal(t, content1Bytes, content2.Bytes())
+	}
+
+	// check scanner uses chunking printed with
+	for _, c := range caseCrossProduct(pageSizes, uncompressedSizes, mediaTypes, []uint32{2}) {
+		printParams, err := NewParameters(comp.MinBufferSize, c.pageSize, c.parallelism)
+		assert.Nil(t, err)
+		printParams.Chunking = page.ContentDefinedChunking
+		scanParams, err := NewParameters(comp.MinBufferSize, c.pageSize, c.parallelism)
+		assert.Nil(t, err)
+		p := NewPrinter(printParams, pageSL)
+		s := NewScanner(scanParams, pageSL)
+
+		content1 := common.NewCompressableBytes(rng, c.uncompressedSize)
+		content1Bytes := content1.Bytes()
+
+		pageKey, metadata, err := p.Print(content1, c.mediaType, keys, authorPub)
+		assert.Nil(t, err, c.String())
+		printedChunking, in := metadata.GetChunking()
+		assert.True(t, in)
+		assert.Equal(t, string(page.ContentDefinedChunking), printedChunking)
+
+		content2 := new(bytes.Buffer)
+		err = s.Scan(content2, pageKey, keys, metadata)
+		assert.Nil(t, err, c.String())
+		assert.Equal(t, content1Bytes, content2.Bytes(), c.String())
+	}
+}
+
+func TestPrint_contentDefinedChunking(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	pageSL := page.NewStorerLoader(
+		&memDocumentStorerLoader{
+			stored: make(map[string]*api.Document),
+		},
+	)
+	page.MinSize = 64 // just for testing
+	params, err := NewParameters(comp.MinBufferSize, 1024, DefaultParallelism)
+	assert.Nil(t, err)
+	params.Chunking = page.ContentDefinedChunking
+	p := NewPrinter(params, pageSL)
+
+	content1 := api.RandBytes(rng, 64*1024)
+	content2 := append(api.RandBytes(rng, 10), content1...)
+	pageKeys1, _, err := p.Print(bytes.NewReader(content1), "application/x-pdf", keys,
+		authorPub)
+	assert.Nil(t, err)
+	pageKeys2, _, err := p.Print(bytes.NewReader(content2), "application/x-pdf", keys,
+		authorPub)
+	assert.Nil(t, err)
+
+	// check that all but the first few pages are shared after inserting bytes at the front
+	printed1 := make(map[string]struct{})
+	for _, pageKey := range pageKeys1 {
+		printed1[pageKey.String()] = struct{}{}
+	}
+	nShared := 0
+	for _, pageKey := range pageKeys2 {
+		if _, in := printed1[pageKey.String()]; in {
+			nShared++
+		}
+	}
+	assert.True(t, nShared >= len(pageKeys1)-2, "%d of %d shared", nShared, len(pageKeys1))
+}
+
+func TestPrinter_Print_section(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	pageSL := page.NewStorerLoader(
+		&memDocumentStorerLoader{
+			stored: make(map[string]*api.Document),
+		},
+	)
+	page.MinSize = 64 // just for testing
+	params, err := NewParameters(comp.MinBufferSize, 256, DefaultParallelism)
+	assert.Nil(t, err)
+	p := NewPrinter(params, pageSL)
+	s := NewScanner(params, pageSL)
+	content := api.RandBytes(rng, 4096)
+
+	// check printing from a section gives the same pages and metadata as printing sequentially
+	pageKeys1, metadata1, err := p.Print(bytes.NewBuffer(content), "application/x-gzip", keys,
+		authorPub)
+	assert.Nil(t, err)
+	section := bytes.NewReader(content)
+	pageKeys2, metadata2, err := p.Print(section, "application/x-gzip", keys, authorPub)
+	assert.Nil(t, err)
+	assert.Equal(t, pageKeys1, pageKeys2)
+	assert.Equal(t, metadata1, metadata2)
+	assert.Zero(t, section.Len())
+
+	scanned := new(bytes.Buffer)
+	err = s.Scan(scanned, pageKeys2, keys, metadata2)
+	assert.Nil(t, err)
+	assert.Equal(t, content, scanned.Bytes())
+
+	// check section paginator error bubbles up
+	p.(*printer).params.PageSize = 0
+	_, _, err = p.Print(bytes.NewReader(content), "application/x-gzip", keys, authorPub)
+	assert.Equal(t, page.ErrPageSizeTooSmall, err)
+	p.(*printer).params.PageSize = 256
+
+	// check storer error bubbles up
+	p.(*printer).pageS = &fixedStorer{storeErr: errors.New("some Store error")}
+	_, _, err = p.Print(bytes.NewReader(content), "application/x-gzip", keys, authorPub)
+	assert.NotNil(t, err)
+
+	// check ReadSection error bubbles up
+	p.(*printer).pageS = pageSL
+	_, _, err = p.Print(io.NewSectionReader(errReaderAt{}, 0, 1024), "application/x-gzip", keys,
+		authorPub)
+	assert.NotNil(t, err)
+}
+
+func TestPrintScan_aeadSuite(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	pageSL := page.NewStorerLoader(
+		&memDocumentStorerLoader{
+			stored: make(map[string]*api.Document),
+		},
+	)
+	page.MinSize = 64 // just for testing
+	printParams, err := NewParameters(comp.MinBufferSize, 256, DefaultParallelism)
+	assert.Nil(t, err)
+	printParams.AEADSuite = enc.XChaCha20Poly1305Suite
+	scanParams, err := NewParameters(comp.MinBufferSize, 256, DefaultParallelism)
+	assert.Nil(t, err)
+	p := NewPrinter(printParams, pageSL)
+	s := NewScanner(scanParams, pageSL)
+	content := common.NewCompressableBytes(rng, 4096).Bytes()
+
+	for _, chunking := range []page.Chunking{page.FixedChunking, page.ContentDefinedChunking} {
+		printParams.Chunking = chunking
+		for _, mediaType := range []string{"application/x-pdf", "application/x-gzip"} {
+			// check scanner uses AEAD suite printed with
+			pageKeys, metadata, err := p.Print(bytes.NewReader(content), mediaType, keys,
+				authorPub)
+			assert.Nil(t, err)
+			printedSuite, in := metadata.GetAEADSuite()
+			assert.True(t, in)
+			assert.Equal(t, string(enc.XChaCha20Poly1305Suite), printedSuite)
+
+			scanned := new(bytes.Buffer)
+			err = s.Scan(scanned, pageKeys, keys, metadata)
+			assert.Nil(t, err, mediaType)
+			assert.Equal(t, content, scanned.Bytes(), mediaType)
+
+			// check scanning with a different suite fails
+			metadata.SetAEADSuite(string(enc.AESGCMSuite))
+			err = s.Scan(new(bytes.Buffer), pageKeys, keys, metadata)
+			assert.NotNil(t, err, mediaType)
+		}
+	}
+}
+
+func TestPrintScan_erasureCoding(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	docSL := &memDocumentStorerLoader{stored: make(map[string]*api.Document)}
+	pageSL := page.NewStorerLoader(docSL)
+	page.MinSize = 64 // just for testing
+	params, err := NewParameters(comp.MinBufferSize, 256, DefaultParallelism)
+	assert.Nil(t, err)
+	params.DataShards, params.ParityShards = 4, 2
+	p := NewPrinter(params, pageSL)
+	s := NewScanner(params, pageSL)
+	content := common.NewCompressableBytes(rng, 4096).Bytes()
+
+	for _, mediaType := range []string{"application/x-pdf", "application/x-gzip"} {
+		// printing gzip content from a bytes.Reader pages it in parallel via a section
+		shardKeys, metadata, err := p.Print(bytes.NewReader(content), mediaType, keys,
+			authorPub)
+		assert.Nil(t, err)
+		dataShards, parityShards, in := metadata.GetErasureShards()
+		assert.True(t, in)
+		assert.Equal(t, uint64(4), dataShards)
+		assert.Equal(t, uint64(2), parityShards)
+		assert.Zero(t, len(shardKeys)%6)
+
+		// check content is scanned with two shards of each page missing
+		for i := 0; i < len(shardKeys); i += 6 {
+			delete(docSL.stored, shardKeys[i+(i/6)%4].String())
+			delete(docSL.stored, shardKeys[i+5].String())
+		}
+		scanned := new(bytes.Buffer)
+		err = s.Scan(scanned, shardKeys, keys, metadata)
+		assert.Nil(t, err, mediaType)
+		assert.Equal(t, content, scanned.Bytes(), mediaType)
+
+		// check a third missing shard makes scanning fail
+		delete(docSL.stored, shardKeys[4].String())
+		err = s.Scan(new(bytes.Buffer), shardKeys, keys, metadata)
+		assert.Equal(t, page.ErrTooFewShards, err, mediaType)
+	}
+
+	// check invalid number of shards bubbles up
+	p.(*printer).params.DataShards = 0
+	_, _, err = p.Print(bytes.NewReader(content), "application/x-pdf", keys, authorPub)
+	assert.NotNil(t, err)
+
+	metadata, err := api.NewEntryMetadata("application/x-pdf", 1, api.RandBytes(rng, 32), 2,
+		api.RandBytes(rng, 32))
+	assert.Nil(t, err)
+	metadata.SetErasureShards(4, 0)
+	err = s.Scan(new(bytes.Buffer), nil, keys, metadata)
+	assert.Equal(t, page.ErrZeroParityShards, err)
+}
+
+func TestPrintScanRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
+	pageSL := page.NewStorerLoader(
+		&memDocumentStorerLoader{
+			stored: make(map[string]*api.Document),
+		},
+	)
+	page.MinSize = 64 // just for testing
+	pageSize := 256
+	params, err := NewParameters(comp.MinBufferSize, uint32(pageSize), DefaultParallelism)
+	assert.Nil(t, err)
+	p := NewPrinter(params, pageSL)
+	s := NewScanner(params, pageSL)
+	content := common.NewCompressableBytes(rng, 2048).Bytes()
+	ranges := [][2]uint64{{0, 0}, {300, 0}, {300, 100}, {512, 256}, {2000, 1000}, {4096, 0}}
+
+	// printing from a bytes.Reader pages in parallel via a section
+	for _, reader := range []io.Reader{bytes.NewBuffer(content), bytes.NewReader(content)} {
+		pageKeys, metadata, err := p.Print(reader, "application/x-gzip", keys, authorPub)
+		assert.Nil(t, err)
+		for _, r := range ranges {
+			first, end, ok := PageRange(metadata, len(pageKeys), r[0], r[1])
+			assert.True(t, ok)
+			scanned := new(bytes.Buffer)
+			rangeKeys := pageKeys[first:end]
+			err = s.ScanRange(scanned, rangeKeys, uint32(first), keys, metadata)
+			assert.Nil(t, err)
+
+			// check scanned pages are the content's and hold the whole range
+			start, stop := first*pageSize, end*pageSize
+			if start > len(content) {
+				start = len(content)
+			}
+			if stop > len(content) {
+				stop = len(content)
+			}
+			assert.True(t, bytes.Equal(content[start:stop], scanned.Bytes()))
+			lo, hi := int(r[0]), int(r[0]+r[1])
+			if r[1] == 0 || hi > len(content) {
+				hi = len(content)
+			}
+			if lo > hi {
+				lo = hi
+			}
+			assert.True(t, start <= lo && hi <= stop)
+		}
+	}
+
+	// check compressed content can't be scanned as a range
+	pageKeys, metadata, err := p.Print(bytes.NewReader(content), "application/x-pdf", keys,
+		authorPub)
+	assert.Nil(t, err)
+	_, _, ok := PageRange(metadata, len(pageKeys), 300, 100)
+	assert.False(t, ok)
+	err = s.ScanRange(new(bytes.Buffer), pageKeys[1:2], 1, keys, metadata)
+	assert.Equal(t, ErrUnrangedEntry, err)
+}
+
+func TestGetSection(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	content := api.RandBytes(rng, 1024)
+
+	// check section contains the rest of the content, which is then consumed
+	r := bytes.NewReader(content)
+	_, err := r.Read(make([]byte, 24))
+	assert.Nil(t, err)
+	section, ok := getSection(r)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1000), section.Size())
+	rest := make([]byte, 1000)
+	_, err = section.ReadAt(rest, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, content[24:], rest)
+	assert.Zero(t, r.Len())
+
+	// check content without known size has no section
+	_, ok = getSection(bytes.NewBuffer(content))
+	assert.False(t, ok)
+	pr, pw, err := os.Pipe()
+	assert.Nil(t, err)
+	_, ok = getSection(pr)
+	assert.False(t, ok)
+	assert.Nil(t, pr.Close())
+	assert.Nil(t, pw.Close())
+}
+
+type errReaderAt struct{}
+
+func (errReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("some ReadAt error")
 }
 
 func TestPrintInitializerImpl_Initialize_ok(t *testing.T) {
@@ -201,17 +655,19 @@ func TestPrintInitializerImpl_Initialize_ok(t *testing.T) {
 	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
 	assert.Nil(t, err)
 	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
-	content, mediaType := bytes.NewReader(api.RandBytes(rng, 64)), "application/x-pdf"
+	content, codec := bytes.NewReader(api.RandBytes(rng, 64)), comp.GZIPCodec
 	pages := make(chan *api.Page)
 
 	printInit := &printInitializerImpl{
 		params: params,
 	}
-	compressor, paginator, err := printInit.Initialize(content, mediaType, keys, authorPub,
-		pages)
-	assert.Nil(t, err)
-	assert.NotNil(t, compressor)
-	assert.NotNil(t, paginator)
+	for _, chunking := range []page.Chunking{page.FixedChunking, page.ContentDefinedChunking} {
+		compressor, paginator, err := printInit.Initialize(content, codec, chunking,
+			enc.XChaCha20Poly1305Suite, keys, authorPub, pages)
+		assert.Nil(t, err)
+		assert.NotNil(t, compressor)
+		assert.NotNil(t, paginator)
+	}
 }
 
 func TestPrintInitializerImpl_Initialize_err(t *testing.T) {
@@ -219,20 +675,25 @@ func TestPrintInitializerImpl_Initialize_err(t *testing.T) {
 	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
 	assert.Nil(t, err)
 	keys, authorPub, _ := enc.NewPseudoRandomKeys(rng)
-	content, mediaType := bytes.NewReader(api.RandBytes(rng, 64)), "application/x-pdf"
+	content, codec := bytes.NewReader(api.RandBytes(rng, 64)), comp.GZIPCodec
 	pages := make(chan *api.Page)
-
-	printInit1 := &printInitializerImpl{
-		params: params,
+	for _, chunking := range []page.Chunking{page.FixedChunking, page.ContentDefinedChunking} {
+		testPrintInitializerImplInitializeErr(t, rng, params, content, codec, chunking, keys,
+			authorPub, pages)
 	}
+}
 
-	// check that bad media type triggers error
-	compressor, paginator, err := printInit1.Initialize(content, "application/", keys,
-		authorPub, pages)
-	assert.NotNil(t, err)
-	assert.Nil(t, compressor)
-	assert.Nil(t, paginator)
-
+func testPrintInitializerImplInitializeErr(
+	t *testing.T,
+	rng *rand.Rand,
+	params *Parameters,
+	content io.Reader,
+	codec comp.Codec,
+	chunking page.Chunking,
+	keys *enc.Keys,
+	authorPub []byte,
+	pages chan *api.Page,
+) {
 	printInit2 := &printInitializerImpl{
 		params: &Parameters{
 			CompressionBufferSize: 0, // will trigger error when creating compressor
@@ -242,8 +703,8 @@ func TestPrintInitializerImpl_Initialize_err(t *testing.T) {
 	}
 
 	// check that error creating new compressor bubbles up
-	compressor, paginator, err = printInit2.Initialize(content, mediaType, keys, authorPub,
-		pages)
+	compressor, paginator, err := printInit2.Initialize(content, codec, chunking,
+		enc.DefaultAEADSuite, keys, authorPub, pages)
 	assert.NotNil(t, err)
 	assert.Nil(t, compressor)
 	assert.Nil(t, paginator)
@@ -253,8 +714,8 @@ func TestPrintInitializerImpl_Initialize_err(t *testing.T) {
 	printInit3 := &printInitializerImpl{params}
 
 	// check that error creating new encrypter triggers error
-	compressor, paginator, err = printInit3.Initialize(content, mediaType, keys3, authorPub,
-		pages)
+	compressor, paginator, err = printInit3.Initialize(content, codec, chunking,
+		enc.DefaultAEADSuite, keys3, authorPub, pages)
 	assert.NotNil(t, err)
 	assert.Nil(t, compressor)
 	assert.Nil(t, paginator)
@@ -264,8 +725,8 @@ func TestPrintInitializerImpl_Initialize_err(t *testing.T) {
 	printInit4 := &printInitializerImpl{params}
 
 	// check that error creating new encrypter triggers error
-	compressor, paginator, err = printInit4.Initialize(content, mediaType, keys4, authorPub,
-		pages)
+	compressor, paginator, err = printInit4.Initialize(content, codec, chunking,
+		enc.DefaultAEADSuite, keys4, authorPub, pages)
 	assert.NotNil(t, err)
 	assert.Nil(t, compressor)
 	assert.Nil(t, paginator)
@@ -331,7 +792,13 @@ type fixedPrintInitializer struct {
 }
 
 func (f *fixedPrintInitializer) Initialize(
-	content io.Reader, mediaType string, keys *enc.Keys, authorPub []byte, pages chan *api.Page,
+	content io.Reader,
+	codec comp.Codec,
+	chunking page.Chunking,
+	suite enc.AEADSuite,
+	keys *enc.Keys,
+	authorPub []byte,
+	pages chan *api.Page,
 ) (comp.Compressor, page.Paginator, error) {
 
 	f.initPaginator.pages = pages
@@ -1,6 +1,7 @@
 package print
 
 import (
+	"errors"
 	"io"
 	"sync"
 
@@ -11,11 +12,21 @@ import (
 	"github.com/drausin/libri/libri/librarian/api"
 )
 
+// ErrUnrangedEntry indicates when a range of pages is scanned from an entry whose pages can't be
+// decoded independently of the others.
+var ErrUnrangedEntry = errors.New("entry pages can't be scanned as a range")
+
 // Scanner writes locally-stored pages to a unified content stream.
 type Scanner interface {
 	// Scan loads pages with the given keys and metadata from an internal page.Loader and
 	// writes their concatenated output to the content io.Writer.
 	Scan(content io.Writer, pageKeys []id.ID, keys *enc.Keys, metatdata *api.Metadata) error
+
+	// ScanRange is like Scan but for a range of the entry's pages (see PageRange), the first of
+	// which has the given index. Since the rest of the pages aren't loaded, it only checks
+	// the MACs of each page and not those of the entire content.
+	ScanRange(content io.Writer, pageKeys []id.ID, firstIndex uint32, keys *enc.Keys,
+		metadata *api.Metadata) error
 }
 
 type scanner struct {
@@ -45,11 +56,81 @@ func (s *scanner) Scan(
 	if err := api.ValidateMetadata(md); err != nil {
 		return err
 	}
-	mediaType, _ := md.GetMediaType()
-	decompressor, unpaginator, err := s.init.Initialize(content, mediaType, keys, pages)
+	codec, err := getCompressionCodec(md)
+	if err != nil {
+		return err
+	}
+	chunking, err := getChunking(md)
+	if err != nil {
+		return err
+	}
+	suite, err := getAEADSuite(md)
+	if err != nil {
+		return err
+	}
+	pageL, err := s.getLoader(md, keys)
 	if err != nil {
 		return err
 	}
+	decompressor, unpaginator, err := s.init.Initialize(content, codec, chunking, suite, keys,
+		pages)
+	if err != nil {
+		return err
+	}
+	if err := scanPages(pageL, pageKeys, pages, decompressor, unpaginator); err != nil {
+		return err
+	}
+	if err := enc.CheckMACs(unpaginator.CiphertextMAC(), decompressor.UncompressedMAC(),
+		md); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *scanner) ScanRange(
+	content io.Writer, pageKeys []id.ID, firstIndex uint32, keys *enc.Keys, md *api.Metadata,
+) error {
+
+	pages := make(chan *api.Page, int(s.params.Parallelism))
+	if err := api.ValidateMetadata(md); err != nil {
+		return err
+	}
+	if _, ok := getPageSize(md); !ok {
+		return ErrUnrangedEntry
+	}
+	suite, err := getAEADSuite(md)
+	if err != nil {
+		return err
+	}
+	pageL, err := s.getLoader(md, keys)
+	if err != nil {
+		return err
+	}
+	decompressor, err := comp.NewDecompressor(content, comp.NoneCodec, keys,
+		s.params.CompressionBufferSize)
+	if err != nil {
+		return err
+	}
+	decrypter, err := enc.NewDecrypter(keys, suite)
+	if err != nil {
+		return err
+	}
+	unpaginator, err := page.NewRangeUnpaginator(pages, decrypter, keys, firstIndex)
+	if err != nil {
+		return err
+	}
+	return scanPages(pageL, pageKeys, pages, decompressor, unpaginator)
+}
+
+// scanPages loads the pages with the given keys into the pages channel while the unpaginator
+// writes them to the decompressor.
+func scanPages(
+	pageL page.Loader,
+	pageKeys []id.ID,
+	pages chan *api.Page,
+	decompressor comp.Decompressor,
+	unpaginator page.Unpaginator,
+) error {
 	errs := make(chan error, 1)
 	abortLoad := make(chan struct{})
 	wg := new(sync.WaitGroup)
@@ -63,7 +144,7 @@ func (s *scanner) Scan(
 		wg.Done()
 	}()
 
-	err = s.pageL.Load(pageKeys, pages, abortLoad)
+	err := pageL.Load(pageKeys, pages, abortLoad)
 	close(pages)
 	if err != nil {
 		return err
@@ -74,18 +155,91 @@ func (s *scanner) Scan(
 	case err = <-errs:
 		return err
 	default:
+		return nil
 	}
+}
 
-	if err := enc.CheckMACs(unpaginator.CiphertextMAC(), decompressor.UncompressedMAC(),
-		md); err != nil {
-		return err
+// PageRange returns the index of the first of an entry's nPages containing the length bytes of
+// uncompressed content starting at the offset, or all bytes after the offset if length is zero,
+// and the index after the last of them. It returns false if the entry's pages can't be decoded
+// independently of each other, since its content was compressed as a whole or split into
+// content-defined chunks.
+func PageRange(md *api.Metadata, nPages int, offset, length uint64) (int, int, bool) {
+	pageSize, ok := getPageSize(md)
+	if !ok {
+		return 0, 0, false
 	}
-	return nil
+	first, end := uint64(nPages), uint64(nPages)
+	if offset/pageSize < first {
+		first = offset / pageSize
+	}
+	if length > 0 && (offset+length-1)/pageSize+1 < end {
+		end = (offset+length-1)/pageSize + 1
+	}
+	return int(first), int(end), true
+}
+
+// getPageSize returns the size of each page's content if the metadata's pages can be decoded
+// independently of the others.
+func getPageSize(md *api.Metadata) (uint64, bool) {
+	pageSize, in := md.GetPageSize()
+	if !in || pageSize == 0 {
+		return 0, false
+	}
+	if codec, err := getCompressionCodec(md); err != nil || codec != comp.NoneCodec {
+		return 0, false
+	}
+	if chunking, err := getChunking(md); err != nil || chunking != page.FixedChunking {
+		return 0, false
+	}
+	return pageSize, true
+}
+
+// getLoader returns the page.Loader for the pages of an entry with the given metadata, which
+// reconstructs them from their shards if they were erasure coded.
+func (s *scanner) getLoader(md *api.Metadata, keys *enc.Keys) (page.Loader, error) {
+	dataShards, parityShards, in := md.GetErasureShards()
+	if !in {
+		return s.pageL, nil
+	}
+	coder, err := page.NewErasureCoder(uint32(dataShards), uint32(parityShards), keys)
+	if err != nil {
+		return nil, err
+	}
+	return page.NewErasureLoader(s.pageL, coder), nil
+}
+
+// getCompressionCodec returns the comp.Codec recorded in the metadata or, for entries printed
+// before it was recorded, the comp.Codec for the metadata's media type.
+func getCompressionCodec(md *api.Metadata) (comp.Codec, error) {
+	if codec, in := md.GetCompressionCodec(); in {
+		return comp.Codec(codec), comp.ValidateCodec(comp.Codec(codec))
+	}
+	mediaType, _ := md.GetMediaType()
+	return comp.GetCompressionCodec(mediaType)
+}
+
+// getChunking returns the page.Chunking recorded in the metadata or, for entries printed before
+// it was recorded, page.FixedChunking.
+func getChunking(md *api.Metadata) (page.Chunking, error) {
+	if chunking, in := md.GetChunking(); in {
+		return page.Chunking(chunking), page.ValidateChunking(page.Chunking(chunking))
+	}
+	return page.FixedChunking, nil
+}
+
+// getAEADSuite returns the enc.AEADSuite recorded in the metadata or, for entries printed before
+// it was recorded, enc.AESGCMSuite.
+func getAEADSuite(md *api.Metadata) (enc.AEADSuite, error) {
+	if suite, in := md.GetAEADSuite(); in {
+		return enc.AEADSuite(suite), enc.ValidateAEADSuite(enc.AEADSuite(suite))
+	}
+	return enc.AESGCMSuite, nil
 }
 
 type scanInitializer interface {
-	Initialize(content io.Writer, mediaType string, keys *enc.Keys, pages chan *api.Page) (
-		comp.Decompressor, page.Unpaginator, error)
+	Initialize(content io.Writer, codec comp.Codec, chunking page.Chunking, suite enc.AEADSuite,
+		keys *enc.Keys, pages chan *api.Page) (comp.Decompressor, page.Unpaginator, error)
 }
 
 type scanInitializerImpl struct {
@@ -93,19 +247,23 @@ type scanInitializerImpl struct {
 }
 
 func (si *scanInitializerImpl) Initialize(
-	content io.Writer, mediaType string, keys *enc.Keys, pages chan *api.Page,
+	content io.Writer,
+	codec comp.Codec,
+	chunking page.Chunking,
+	suite enc.AEADSuite,
+	keys *enc.Keys,
+	pages chan *api.Page,
 ) (comp.Decompressor, page.Unpaginator, error) {
 
-	codec, err := comp.GetCompressionCodec(mediaType)
-	if err != nil {
-		return nil, nil, err
+	if chunking == page.ContentDefinedChunking {
+		return si.initializeChunking(content, codec, suite, keys, pages)
 	}
 	decompressor, err := comp.NewDecompressor(content, codec, keys,
 		si.params.CompressionBufferSize)
 	if err != nil {
 		return nil, nil, err
 	}
-	decrypter, err := enc.NewDecrypter(keys)
+	decrypter, err := enc.NewDecrypter(keys, suite)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -115,3 +273,25 @@ func (si *scanInitializerImpl) Initialize(
 	}
 	return decompressor, unpaginator, nil
 }
+
+// initializeChunking initializes a chunk page.Unpaginator, which decompresses each chunk itself,
+// so the comp.Decompressor just passes the uncompressed content through.
+func (si *scanInitializerImpl) initializeChunking(
+	content io.Writer, codec comp.Codec, suite enc.AEADSuite, keys *enc.Keys, pages chan *api.Page,
+) (comp.Decompressor, page.Unpaginator, error) {
+
+	decompressor, err := comp.NewDecompressor(content, comp.NoneCodec, keys,
+		si.params.CompressionBufferSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	decrypter, err := enc.NewContentDecrypter(keys, suite)
+	if err != nil {
+		return nil, nil, err
+	}
+	unpaginator, err := page.NewChunkUnpaginator(pages, decrypter, keys, codec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decompressor, unpaginator, nil
+}
@@ -91,6 +91,34 @@ func TestScanner_Scan_err(t *testing.T) {
 	err = scanner1.Scan(content, pageKeys, keys, md1)
 	assert.NotNil(t, err)
 
+	// check that bad media type triggers error
+	md2, err := api.NewEntryMetadata("application/", 1, api.RandBytes(rng, api.HMAC256Length),
+		3, api.RandBytes(rng, api.HMAC256Length))
+	assert.Nil(t, err)
+	err = scanner1.Scan(content, pageKeys, keys, md2)
+	assert.NotNil(t, err)
+
+	// check that unsupported codec triggers error
+	md2.SetCompressionCodec("unsupported")
+	err = scanner1.Scan(content, pageKeys, keys, md2)
+	assert.Equal(t, comp.ErrUnsupportedCodec, err)
+
+	// check that unsupported chunking triggers error
+	md3, err := api.NewEntryMetadata(mediaType, 1, api.RandBytes(rng, api.HMAC256Length),
+		3, api.RandBytes(rng, api.HMAC256Length))
+	assert.Nil(t, err)
+	md3.SetChunking("unsupported")
+	err = scanner1.Scan(content, pageKeys, keys, md3)
+	assert.Equal(t, page.ErrUnsupportedChunking, err)
+
+	// check that unsupported AEAD suite triggers error
+	md4, err := api.NewEntryMetadata(mediaType, 1, api.RandBytes(rng, api.HMAC256Length),
+		3, api.RandBytes(rng, api.HMAC256Length))
+	assert.Nil(t, err)
+	md4.SetAEADSuite("unsupported")
+	err = scanner1.Scan(content, pageKeys, keys, md4)
+	assert.Equal(t, enc.ErrUnsupportedAEADSuite, err)
+
 	// check that init error bubbles up
 	scanner2 := NewScanner(params, &fixedLoader{})
 	scanner2.(*scanner).init = &fixedScanInitializer{
@@ -151,19 +179,81 @@ func TestScanner_Scan_err(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestGetAEADSuite(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	md, err := api.NewEntryMetadata("application/x-pdf", 1,
+		api.RandBytes(rng, api.HMAC256Length), 3, api.RandBytes(rng, api.HMAC256Length))
+	assert.Nil(t, err)
+
+	// check entries printed before the suite was recorded use AES-GCM
+	suite, err := getAEADSuite(md)
+	assert.Nil(t, err)
+	assert.Equal(t, enc.AESGCMSuite, suite)
+
+	// check uses recorded suite
+	md.SetAEADSuite(string(enc.XChaCha20Poly1305Suite))
+	suite, err = getAEADSuite(md)
+	assert.Nil(t, err)
+	assert.Equal(t, enc.XChaCha20Poly1305Suite, suite)
+}
+
+func TestPageRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	md, err := api.NewEntryMetadata("application/x-gzip", 1,
+		api.RandBytes(rng, api.HMAC256Length), 3, api.RandBytes(rng, api.HMAC256Length))
+	assert.Nil(t, err)
+
+	// check entries printed before the page size was recorded have no range
+	_, _, ok := PageRange(md, 8, 0, 0)
+	assert.False(t, ok)
+
+	md.SetPageSize(100)
+	md.SetCompressionCodec(string(comp.NoneCodec))
+	cases := []struct {
+		offset, length uint64
+		first, end     int
+	}{
+		{0, 0, 0, 8},
+		{0, 100, 0, 1},
+		{99, 2, 0, 2},
+		{250, 0, 2, 8},
+		{250, 100, 2, 4},
+		{750, 1000, 7, 8},
+		{1000, 10, 8, 8},
+	}
+	for _, c := range cases {
+		first, end, ok := PageRange(md, 8, c.offset, c.length)
+		assert.True(t, ok)
+		assert.Equal(t, c.first, first)
+		assert.Equal(t, c.end, end)
+	}
+
+	// check compressed and content-defined chunked entries have no range
+	md.SetCompressionCodec(string(comp.GZIPCodec))
+	_, _, ok = PageRange(md, 8, 0, 0)
+	assert.False(t, ok)
+	md.SetCompressionCodec(string(comp.NoneCodec))
+	md.SetChunking(string(page.ContentDefinedChunking))
+	_, _, ok = PageRange(md, 8, 0, 0)
+	assert.False(t, ok)
+}
+
 func TestScanInitializerImpl_Initialize_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
 	assert.Nil(t, err)
 	keys, _, _ := enc.NewPseudoRandomKeys(rng)
-	content, mediaType := new(bytes.Buffer), "application/x-pdf"
+	content, codec := new(bytes.Buffer), comp.GZIPCodec
 	pages := make(chan *api.Page)
 
 	scanInit := &scanInitializerImpl{params: params}
-	decompressor, unpaginator, err := scanInit.Initialize(content, mediaType, keys, pages)
-	assert.Nil(t, err)
-	assert.NotNil(t, decompressor)
-	assert.NotNil(t, unpaginator)
+	for _, chunking := range []page.Chunking{page.FixedChunking, page.ContentDefinedChunking} {
+		decompressor, unpaginator, err := scanInit.Initialize(content, codec, chunking,
+			enc.XChaCha20Poly1305Suite, keys, pages)
+		assert.Nil(t, err)
+		assert.NotNil(t, decompressor)
+		assert.NotNil(t, unpaginator)
+	}
 }
 
 func TestScanInitializerImpl_Initialize_err(t *testing.T) {
@@ -171,19 +261,24 @@ func TestScanInitializerImpl_Initialize_err(t *testing.T) {
 	params, err := NewParameters(comp.MinBufferSize, page.MinSize, DefaultParallelism)
 	assert.Nil(t, err)
 	keys, _, _ := enc.NewPseudoRandomKeys(rng)
-	content, mediaType := new(bytes.Buffer), "application/x-pdf"
+	content, codec := new(bytes.Buffer), comp.GZIPCodec
 	pages := make(chan *api.Page)
-
-	scanInit1 := &scanInitializerImpl{
-		params: params,
+	for _, chunking := range []page.Chunking{page.FixedChunking, page.ContentDefinedChunking} {
+		testScanInitializerImplInitializeErr(t, rng, params, content, codec, chunking, keys,
+			pages)
 	}
+}
 
-	// check that bad media type triggers error
-	decompressor, unpaginator, err := scanInit1.Initialize(content, "application/", keys, pages)
-	assert.NotNil(t, err)
-	assert.Nil(t, decompressor)
-	assert.Nil(t, unpaginator)
-
+func testScanInitializerImplInitializeErr(
+	t *testing.T,
+	rng *rand.Rand,
+	params *Parameters,
+	content io.Writer,
+	codec comp.Codec,
+	chunking page.Chunking,
+	keys *enc.Keys,
+	pages chan *api.Page,
+) {
 	scanInit2 := &scanInitializerImpl{
 		params: &Parameters{
 			CompressionBufferSize: 0, // will trigger error when creating decompressor
@@ -193,7 +288,8 @@ func TestScanInitializerImpl_Initialize_err(t *testing.T) {
 	}
 
 	// check that error creating new decompressor bubbles up
-	decompressor, unpaginator, err = scanInit2.Initialize(content, mediaType, keys, pages)
+	decompressor, unpaginator, err := scanInit2.Initialize(content, codec, chunking,
+		enc.DefaultAEADSuite, keys, pages)
 	assert.NotNil(t, err)
 	assert.Nil(t, decompressor)
 	assert.Nil(t, unpaginator)
@@ -205,7 +301,8 @@ func TestScanInitializerImpl_Initialize_err(t *testing.T) {
 	}
 
 	// check that error creating new decrypter triggers error
-	decompressor, unpaginator, err = scanInit3.Initialize(content, mediaType, keys3, pages)
+	decompressor, unpaginator, err = scanInit3.Initialize(content, codec, chunking,
+		enc.DefaultAEADSuite, keys3, pages)
 	assert.NotNil(t, err)
 	assert.Nil(t, decompressor)
 	assert.Nil(t, unpaginator)
@@ -217,7 +314,8 @@ func TestScanInitializerImpl_Initialize_err(t *testing.T) {
 	}
 
 	// check that error creating new decrypter triggers error
-	decompressor, unpaginator, err = scanInit4.Initialize(content, mediaType, keys4, pages)
+	decompressor, unpaginator, err = scanInit4.Initialize(content, codec, chunking,
+		enc.DefaultAEADSuite, keys4, pages)
 	assert.NotNil(t, err)
 	assert.Nil(t, decompressor)
 	assert.Nil(t, unpaginator)
@@ -290,7 +388,12 @@ type fixedScanInitializer struct {
 }
 
 func (f *fixedScanInitializer) Initialize(
-	content io.Writer, mediaType string, keys *enc.Keys, pages chan *api.Page,
+	content io.Writer,
+	codec comp.Codec,
+	chunking page.Chunking,
+	suite enc.AEADSuite,
+	keys *enc.Keys,
+	pages chan *api.Page,
 ) (comp.Decompressor, page.Unpaginator, error) {
 
 	f.initUnpaginator.pages = pages
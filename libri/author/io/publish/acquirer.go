@@ -87,6 +87,77 @@ func (a *singleStoreAcquirer) Acquire(docKey id.ID, authorPub []byte, lc api.Get
 	return nil
 }
 
+type checkpointSingleStoreAcquirer struct {
+	inner SingleStoreAcquirer
+	docL  storage.DocumentLoader
+}
+
+// NewCheckpointSingleStoreAcquirer creates a new SingleStoreAcquirer that skips documents already
+// in the docL internal storage, so a retried acquire of many documents resumes with those not yet
+// acquired.
+func NewCheckpointSingleStoreAcquirer(
+	inner SingleStoreAcquirer, docL storage.DocumentLoader,
+) SingleStoreAcquirer {
+	return &checkpointSingleStoreAcquirer{
+		inner: inner,
+		docL:  docL,
+	}
+}
+
+func (a *checkpointSingleStoreAcquirer) Acquire(docKey id.ID, authorPub []byte,
+	lc api.Getter) error {
+	doc, err := a.docL.Load(docKey)
+	if err != nil {
+		return err
+	}
+	if doc != nil {
+		return nil
+	}
+	return a.inner.Acquire(docKey, authorPub, lc)
+}
+
+type acquiringDocumentStorerLoader struct {
+	storage.DocumentStorer
+	docL       storage.DocumentLoader
+	acq        SingleStoreAcquirer
+	authorPub  []byte
+	librarians api.ClientBalancer
+}
+
+// NewAcquiringDocumentStorerLoader creates a new storage.DocumentStorerLoader that acquires a
+// document missing from the inner docSL storage from the libri network, balancing between the
+// librarians, before loading it. This lets documents be loaded as soon as each is acquired rather
+// than after all have been acquired.
+func NewAcquiringDocumentStorerLoader(
+	docSL storage.DocumentStorerLoader,
+	acq SingleStoreAcquirer,
+	authorPub []byte,
+	librarians api.ClientBalancer,
+) storage.DocumentStorerLoader {
+	return &acquiringDocumentStorerLoader{
+		DocumentStorer: docSL,
+		docL:           docSL,
+		acq:            acq,
+		authorPub:      authorPub,
+		librarians:     librarians,
+	}
+}
+
+func (l *acquiringDocumentStorerLoader) Load(key id.ID) (*api.Document, error) {
+	doc, err := l.docL.Load(key)
+	if err != nil || doc != nil {
+		return doc, err
+	}
+	lc, err := l.librarians.Next()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.acq.Acquire(key, l.authorPub, lc); err != nil {
+		return nil, err
+	}
+	return l.docL.Load(key)
+}
+
 // MultiStoreAcquirer Gets and stores multiple documents.
 type MultiStoreAcquirer interface {
 	// Acquire in parallel Gets and stores the documents with the given keys. It balances
@@ -112,25 +183,29 @@ func (a *multiStoreAcquirer) Acquire(
 	docKeys []id.ID, authorPub []byte, cb api.ClientBalancer,
 ) error {
 
-	docKeysChan := make(chan id.ID, a.params.PutParallelism)
-	go loadChan(docKeys, docKeysChan)
+	docKeysChan := make(chan id.ID, a.params.GetParallelism)
+	abort, abortOnce := make(chan struct{}), new(sync.Once)
+	go loadChan(docKeys, docKeysChan, abort)
 	wg := new(sync.WaitGroup)
 	getErrs := make(chan error, a.params.GetParallelism)
 	for c := uint32(0); c < a.params.GetParallelism; c++ {
 		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			for docKey := range docKeysChan {
+				if aborted(abort) {
+					return
+				}
 				lc, err := cb.Next()
+				if err == nil {
+					err = a.inner.Acquire(docKey, authorPub, lc)
+				}
 				if err != nil {
 					getErrs <- err
+					abortOnce.Do(func() { close(abort) })
 					return
 				}
-				if err := a.inner.Acquire(docKey, authorPub, lc); err != nil {
-					getErrs <- err
-					break
-				}
 			}
-			wg.Done()
 		}()
 	}
 	wg.Wait()
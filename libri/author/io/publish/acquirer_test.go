@@ -120,6 +120,89 @@ func TestSingleStoreAcquirer_Acquire_err(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestCheckpointSingleStoreAcquirer_Acquire_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	authorPub := api.GetAuthorPub(doc)
+	inner := &fixedSingleStoreAcquirer{acquiredKeys: make(map[string]struct{})}
+	docL := &memDocStorerLoader{docs: make(map[string]*api.Document)}
+	acq := NewCheckpointSingleStoreAcquirer(inner, docL)
+
+	err := acq.Acquire(docKey, authorPub, &fixedGetter{})
+	assert.Nil(t, err)
+	assert.Len(t, inner.acquiredKeys, 1)
+
+	// check already stored document is skipped
+	inner.acquiredKeys = make(map[string]struct{})
+	docL.docs[docKey.String()] = doc
+	err = acq.Acquire(docKey, authorPub, &fixedGetter{})
+	assert.Nil(t, err)
+	assert.Len(t, inner.acquiredKeys, 0)
+}
+
+func TestCheckpointSingleStoreAcquirer_Acquire_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	authorPub := api.GetAuthorPub(doc)
+
+	// check load error bubbles up
+	inner := &fixedSingleStoreAcquirer{acquiredKeys: make(map[string]struct{})}
+	err := NewCheckpointSingleStoreAcquirer(inner, &errDocLoader{}).
+		Acquire(docKey, authorPub, &fixedGetter{})
+	assert.NotNil(t, err)
+
+	// check inner acquire error bubbles up
+	inner = &fixedSingleStoreAcquirer{err: errors.New("some Acquire error")}
+	docL := &memDocStorerLoader{docs: make(map[string]*api.Document)}
+	err = NewCheckpointSingleStoreAcquirer(inner, docL).Acquire(docKey, authorPub,
+		&fixedGetter{})
+	assert.NotNil(t, err)
+}
+
+func TestAcquiringDocumentStorerLoader_Load_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	authorPub := api.GetAuthorPub(doc)
+	docSL := &memDocStorerLoader{docs: make(map[string]*api.Document)}
+	acq := &fixedSingleStoreAcquirer{acquiredKeys: make(map[string]struct{})}
+	sl := NewAcquiringDocumentStorerLoader(docSL, acq, authorPub, &fixedClientBalancer{})
+
+	// check missing document is acquired before loading
+	loaded, err := sl.Load(docKey)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded) // since fixedSingleStoreAcquirer doesn't store anything
+	assert.Len(t, acq.acquiredKeys, 1)
+
+	// check stored document is loaded without acquiring
+	acq.acquiredKeys = make(map[string]struct{})
+	assert.Nil(t, sl.Store(docKey, doc))
+	loaded, err = sl.Load(docKey)
+	assert.Nil(t, err)
+	assert.Equal(t, doc, loaded)
+	assert.Len(t, acq.acquiredKeys, 0)
+}
+
+func TestAcquiringDocumentStorerLoader_Load_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	authorPub := api.GetAuthorPub(doc)
+	docSL := &memDocStorerLoader{docs: make(map[string]*api.Document)}
+	acq := &fixedSingleStoreAcquirer{acquiredKeys: make(map[string]struct{})}
+
+	// check Next error bubbles up
+	cb := &fixedClientBalancer{err: errors.New("some Next error")}
+	loaded, err := NewAcquiringDocumentStorerLoader(docSL, acq, authorPub, cb).Load(docKey)
+	assert.NotNil(t, err)
+	assert.Nil(t, loaded)
+
+	// check Acquire error bubbles up
+	acq = &fixedSingleStoreAcquirer{err: errors.New("some Acquire error")}
+	sl := NewAcquiringDocumentStorerLoader(docSL, acq, authorPub, &fixedClientBalancer{})
+	loaded, err = sl.Load(docKey)
+	assert.NotNil(t, err)
+	assert.Nil(t, loaded)
+}
+
 func TestMultiStoreAcquirer_Acquire_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	cb := &fixedClientBalancer{}
@@ -172,6 +255,12 @@ func TestMultiStoreAcquirer_Acquire_err(t *testing.T) {
 			assert.NotNil(t, err)
 		}
 	}
+
+	// check balancer error bubbles up
+	msAcq := NewMultiStoreAcquirer(&fixedSingleStoreAcquirer{}, NewDefaultParameters())
+	docKeys := []id.ID{id.NewPseudoRandom(rng), id.NewPseudoRandom(rng)}
+	err := msAcq.Acquire(docKeys, nil, &fixedClientBalancer{err: errors.New("some Next error")})
+	assert.NotNil(t, err)
 }
 
 type fixedGetter struct {
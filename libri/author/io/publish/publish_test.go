@@ -164,6 +164,53 @@ func TestSingleLoadPublisher_Publish_err(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestCheckpointSingleLoadPublisher_Publish_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	inner := &fixedSingleLoadPublisher{publishedKeys: make(map[string]struct{})}
+	nsl := &memNamespaceStorerLoader{values: make(map[string][]byte)}
+	cpPub := NewCheckpointSingleLoadPublisher(inner, nsl)
+	doc, docKey := api.NewTestDocument(rng)
+
+	err := cpPub.Publish(docKey, api.GetAuthorPub(doc), &fixedPutter{})
+	assert.Nil(t, err)
+	assert.Len(t, inner.publishedKeys, 1)
+	assert.NotNil(t, nsl.values[string(docKey.Bytes())])
+
+	// check already published document is skipped
+	inner.publishedKeys = make(map[string]struct{})
+	err = cpPub.Publish(docKey, api.GetAuthorPub(doc), &fixedPutter{})
+	assert.Nil(t, err)
+	assert.Len(t, inner.publishedKeys, 0)
+}
+
+func TestCheckpointSingleLoadPublisher_Publish_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	authorPub := api.GetAuthorPub(doc)
+
+	// check load error bubbles up
+	inner := &fixedSingleLoadPublisher{publishedKeys: make(map[string]struct{})}
+	nsl := &memNamespaceStorerLoader{loadErr: errors.New("some Load error")}
+	err := NewCheckpointSingleLoadPublisher(inner, nsl).Publish(docKey, authorPub, nil)
+	assert.NotNil(t, err)
+
+	// check inner publish error bubbles up without recording document
+	inner = &fixedSingleLoadPublisher{err: errors.New("some Publish error")}
+	nsl = &memNamespaceStorerLoader{values: make(map[string][]byte)}
+	err = NewCheckpointSingleLoadPublisher(inner, nsl).Publish(docKey, authorPub, nil)
+	assert.NotNil(t, err)
+	assert.Len(t, nsl.values, 0)
+
+	// check store error bubbles up
+	inner = &fixedSingleLoadPublisher{publishedKeys: make(map[string]struct{})}
+	nsl = &memNamespaceStorerLoader{
+		values:   make(map[string][]byte),
+		storeErr: errors.New("some Store error"),
+	}
+	err = NewCheckpointSingleLoadPublisher(inner, nsl).Publish(docKey, authorPub, nil)
+	assert.NotNil(t, err)
+}
+
 func TestMultiLoadPublisher_Publish_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	cb := &fixedClientBalancer{}
@@ -214,6 +261,49 @@ func TestMultiLoadPublisher_Publish_err(t *testing.T) {
 
 			err = mlPub.Publish(docKeys, authorKey, cb)
 			assert.NotNil(t, err)
+
+			// check no more Puts are started after one fails
+			assert.True(t, slPub.nCalls <= int(2*putParallelism))
+		}
+	}
+
+	// check balancer error bubbles up
+	slPub := &fixedSingleLoadPublisher{
+		publishedKeys: make(map[string]struct{}),
+	}
+	mlPub := NewMultiLoadPublisher(slPub, NewDefaultParameters())
+	docKeys := []id.ID{id.NewPseudoRandom(rng), id.NewPseudoRandom(rng)}
+	err := mlPub.Publish(docKeys, nil, &fixedClientBalancer{err: errors.New("some Next error")})
+	assert.NotNil(t, err)
+	assert.Zero(t, slPub.nCalls)
+}
+
+func TestMultiLoadPublisher_Publish_parallel(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	cb := &fixedClientBalancer{}
+	nDocs := 16
+	docKeys := make([]id.ID, nDocs)
+	for i := 0; i < nDocs; i++ {
+		docKeys[i] = id.NewPseudoRandom(rng)
+	}
+	for _, putParallelism := range []uint32{1, 2, 4, 8} {
+		slPub := &fixedSingleLoadPublisher{
+			publishedKeys: make(map[string]struct{}),
+			delay:         10 * time.Millisecond,
+		}
+		params, err := NewParameters(DefaultPutTimeout, DefaultGetTimeout,
+			putParallelism, DefaultGetParallelism)
+		assert.Nil(t, err)
+		mlPub := NewMultiLoadPublisher(slPub, params)
+
+		err = mlPub.Publish(docKeys, nil, cb)
+		assert.Nil(t, err)
+
+		// check Puts happened in parallel but no more than PutParallelism at once
+		assert.Len(t, slPub.publishedKeys, nDocs)
+		assert.True(t, slPub.maxInFlight <= int(putParallelism))
+		if putParallelism > 1 {
+			assert.True(t, slPub.maxInFlight > 1)
 		}
 	}
 }
@@ -409,17 +499,50 @@ type fixedSingleLoadPublisher struct {
 	mu            sync.Mutex
 	publishedKeys map[string]struct{}
 	err           error
+	delay         time.Duration
+	nCalls        int
+	nInFlight     int
+	maxInFlight   int
 }
 
 func (f *fixedSingleLoadPublisher) Publish(docKey id.ID, authorPub []byte, lc api.Putter) error {
+	f.mu.Lock()
+	f.nCalls++
+	f.nInFlight++
+	if f.nInFlight > f.maxInFlight {
+		f.maxInFlight = f.nInFlight
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.nInFlight--
 	if f.err == nil {
 		f.publishedKeys[docKey.String()] = struct{}{}
 	}
 	return f.err
 }
 
+type memNamespaceStorerLoader struct {
+	values   map[string][]byte
+	loadErr  error
+	storeErr error
+}
+
+func (m *memNamespaceStorerLoader) Store(key []byte, value []byte) error {
+	if m.storeErr != nil {
+		return m.storeErr
+	}
+	m.values[string(key)] = value
+	return nil
+}
+
+func (m *memNamespaceStorerLoader) Load(key []byte) ([]byte, error) {
+	return m.values[string(key)], m.loadErr
+}
+
 type fixedClientBalancer struct {
 	client api.LibrarianClient
 	err    error
@@ -16,11 +16,11 @@ import (
 const (
 	// DefaultPutTimeout is the default timeout duration for a Publisher's Put() call to a
 	// librarian.
-	DefaultPutTimeout = 5 * time.Second
+	DefaultPutTimeout = client.DefaultPutTimeout
 
 	// DefaultGetTimeout is the default timeout duration for an Acquirer's Get() call to a
 	// librarian.
-	DefaultGetTimeout = 5 * time.Second
+	DefaultGetTimeout = client.DefaultGetTimeout
 
 	// DefaultPutParallelism is the default parallelism a MultiLoadPublisher uses when
 	// making multiple Put calls to librarians.
@@ -191,6 +191,42 @@ func (p *singleLoadPublisher) Publish(docKey cid.ID, authorPub []byte, lc api.Pu
 	return nil
 }
 
+// publishedValue is the value a checkpoint SingleLoadPublisher stores for each published document.
+var publishedValue = []byte{1}
+
+type checkpointSingleLoadPublisher struct {
+	inner SingleLoadPublisher
+	nsl   storage.NamespaceStorerLoader
+}
+
+// NewCheckpointSingleLoadPublisher creates a new SingleLoadPublisher that records each document
+// the inner SingleLoadPublisher publishes in the storage.NamespaceStorerLoader and skips
+// documents already recorded there, so a retried publish of many documents resumes with those
+// not yet published.
+func NewCheckpointSingleLoadPublisher(
+	inner SingleLoadPublisher, nsl storage.NamespaceStorerLoader,
+) SingleLoadPublisher {
+	return &checkpointSingleLoadPublisher{
+		inner: inner,
+		nsl:   nsl,
+	}
+}
+
+func (p *checkpointSingleLoadPublisher) Publish(docKey cid.ID, authorPub []byte,
+	lc api.Putter) error {
+	published, err := p.nsl.Load(docKey.Bytes())
+	if err != nil {
+		return err
+	}
+	if published != nil {
+		return nil
+	}
+	if err := p.inner.Publish(docKey, authorPub, lc); err != nil {
+		return err
+	}
+	return p.nsl.Store(docKey.Bytes(), publishedValue)
+}
+
 // MultiLoadPublisher loads and publishes a collection of documents from internal storage.
 type MultiLoadPublisher interface {
 	// Publish in parallel loads and publishes the documents with the given keys. It balances
@@ -215,25 +251,31 @@ func (p *multiLoadPublisher) Publish(
 	docKeys []cid.ID, authorPub []byte, cb api.ClientBalancer,
 ) error {
 
+	// at most PutParallelism workers Put documents at once, and the first to fail stops the
+	// rest from starting any more
 	docKeysChan := make(chan cid.ID, p.params.PutParallelism)
-	go loadChan(docKeys, docKeysChan)
+	abort, abortOnce := make(chan struct{}), new(sync.Once)
+	go loadChan(docKeys, docKeysChan, abort)
 	wg := new(sync.WaitGroup)
 	putErrs := make(chan error, p.params.PutParallelism)
 	for c := uint32(0); c < p.params.PutParallelism; c++ {
 		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			for docKey := range docKeysChan {
+				if aborted(abort) {
+					return
+				}
 				lc, err := cb.Next()
+				if err == nil {
+					err = p.inner.Publish(docKey, authorPub, lc)
+				}
 				if err != nil {
 					putErrs <- err
+					abortOnce.Do(func() { close(abort) })
 					return
 				}
-				if err := p.inner.Publish(docKey, authorPub, lc); err != nil {
-					putErrs <- err
-					break
-				}
 			}
-			wg.Done()
 		}()
 	}
 	wg.Wait()
@@ -247,9 +289,24 @@ func (p *multiLoadPublisher) Publish(
 	}
 }
 
-func loadChan(idSlice []cid.ID, idChan chan cid.ID) {
+// loadChan sends each ID to the channel, which it closes once all have been sent or the abort
+// channel is closed.
+func loadChan(idSlice []cid.ID, idChan chan cid.ID, abort chan struct{}) {
+	defer close(idChan)
 	for _, id := range idSlice {
-		idChan <- id
+		select {
+		case <-abort:
+			return
+		case idChan <- id:
+		}
+	}
+}
+
+func aborted(abort chan struct{}) bool {
+	select {
+	case <-abort:
+		return true
+	default:
+		return false
 	}
-	close(idChan)
 }
@@ -0,0 +1,185 @@
+package publish
+
+import (
+	"errors"
+	"time"
+
+	cbackoff "github.com/cenkalti/backoff"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the default maximum number of Put or Get attempts for a
+	// document.
+	DefaultRetryMaxAttempts = 3
+
+	// DefaultRetryInitialBackoff is the default time to wait after the first failed attempt.
+	DefaultRetryInitialBackoff = 250 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the default maximum time to wait between attempts.
+	DefaultRetryMaxBackoff = 2 * time.Second
+)
+
+var (
+	// ErrRetryMaxAttemptsZeroValue indicates when the MaxAttempts parameter has the zero value.
+	ErrRetryMaxAttemptsZeroValue = errors.New("MaxAttempts must be greater than zero")
+
+	// ErrRetryInitialBackoffZeroValue indicates when the InitialBackoff parameter has the zero
+	// value.
+	ErrRetryInitialBackoffZeroValue = errors.New("InitialBackoff must be greater than zero")
+
+	// ErrRetryMaxBackoffTooSmall indicates when the MaxBackoff parameter is smaller than the
+	// InitialBackoff.
+	ErrRetryMaxBackoffTooSmall = errors.New("MaxBackoff must be at least InitialBackoff")
+)
+
+// RetryPolicy defines how a failed Put or Get request is retried against other librarians.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for each request, where every attempt after
+	// the first is against the next librarian from the balancer. One disables retries.
+	MaxAttempts uint32
+
+	// InitialBackoff is the time to wait after the first failed attempt, which grows
+	// exponentially with each subsequent failed attempt.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between attempts.
+	MaxBackoff time.Duration
+}
+
+// NewRetryPolicy validates the parameters and returns a new *RetryPolicy instance.
+func NewRetryPolicy(
+	maxAttempts uint32, initialBackoff time.Duration, maxBackoff time.Duration,
+) (*RetryPolicy, error) {
+	if maxAttempts == 0 {
+		return nil, ErrRetryMaxAttemptsZeroValue
+	}
+	if initialBackoff == 0 {
+		return nil, ErrRetryInitialBackoffZeroValue
+	}
+	if maxBackoff < initialBackoff {
+		return nil, ErrRetryMaxBackoffTooSmall
+	}
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}, nil
+}
+
+// NewDefaultRetryPolicy creates a default *RetryPolicy instance.
+func NewDefaultRetryPolicy() *RetryPolicy {
+	policy, err := NewRetryPolicy(DefaultRetryMaxAttempts, DefaultRetryInitialBackoff,
+		DefaultRetryMaxBackoff)
+	if err != nil {
+		// should never happen; if does, it's programmer error
+		panic(err)
+	}
+	return policy
+}
+
+// retry calls the operation with the attempt number until it succeeds, returns a
+// *cbackoff.PermanentError, or has been attempted MaxAttempts times, waiting with exponential
+// backoff between attempts. It returns the last error.
+func (p *RetryPolicy) retry(operation func(attempt uint32) error) error {
+	attempt := uint32(0)
+	return cbackoff.Retry(func() error {
+		err := operation(attempt)
+		attempt++
+		return err
+	}, p.newBackOff())
+}
+
+func (p *RetryPolicy) newBackOff() cbackoff.BackOff {
+	if p.MaxAttempts <= 1 {
+		return &cbackoff.StopBackOff{}
+	}
+	backoff := cbackoff.NewExponentialBackOff()
+	backoff.InitialInterval = p.InitialBackoff
+	backoff.MaxInterval = p.MaxBackoff
+	backoff.MaxElapsedTime = 0 // only stop after MaxAttempts
+	return cbackoff.WithMaxTries(backoff, uint64(p.MaxAttempts-1))
+}
+
+type retryPublisher struct {
+	inner      Publisher
+	librarians api.ClientBalancer
+	policy     *RetryPolicy
+}
+
+// NewRetryPublisher creates a new Publisher that, when the inner Publisher errors, retries the
+// Put against the next librarian from the balancer according to the RetryPolicy.
+func NewRetryPublisher(
+	inner Publisher, librarians api.ClientBalancer, policy *RetryPolicy,
+) Publisher {
+	return &retryPublisher{
+		inner:      inner,
+		librarians: librarians,
+		policy:     policy,
+	}
+}
+
+func (p *retryPublisher) Publish(doc *api.Document, authorPub []byte, lc api.Putter) (
+	cid.ID, error) {
+	var docKey cid.ID
+	err := p.policy.retry(func(attempt uint32) error {
+		if attempt > 0 {
+			next, err := p.librarians.Next()
+			if err != nil {
+				return err
+			}
+			lc = next
+		}
+		var err error
+		docKey, err = p.inner.Publish(doc, authorPub, lc)
+		if err == ErrInconsistentAuthorPubKey {
+			// no librarian will accept the document, so no point in trying another
+			return cbackoff.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docKey, nil
+}
+
+type retryAcquirer struct {
+	inner      Acquirer
+	librarians api.ClientBalancer
+	policy     *RetryPolicy
+}
+
+// NewRetryAcquirer creates a new Acquirer that, when the inner Acquirer errors, retries the Get
+// against the next librarian from the balancer according to the RetryPolicy.
+func NewRetryAcquirer(
+	inner Acquirer, librarians api.ClientBalancer, policy *RetryPolicy,
+) Acquirer {
+	return &retryAcquirer{
+		inner:      inner,
+		librarians: librarians,
+		policy:     policy,
+	}
+}
+
+func (a *retryAcquirer) Acquire(docKey cid.ID, authorPub []byte, lc api.Getter) (
+	*api.Document, error) {
+	var doc *api.Document
+	err := a.policy.retry(func(attempt uint32) error {
+		if attempt > 0 {
+			next, err := a.librarians.Next()
+			if err != nil {
+				return err
+			}
+			lc = next
+		}
+		var err error
+		doc, err = a.inner.Acquire(docKey, authorPub, lc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
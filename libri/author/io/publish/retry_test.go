@@ -0,0 +1,204 @@
+package publish
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRetryPolicy_ok(t *testing.T) {
+	policy, err := NewRetryPolicy(DefaultRetryMaxAttempts, DefaultRetryInitialBackoff,
+		DefaultRetryMaxBackoff)
+	assert.Nil(t, err)
+	assert.Equal(t, NewDefaultRetryPolicy(), policy)
+}
+
+func TestNewRetryPolicy_err(t *testing.T) {
+	policy, err := NewRetryPolicy(0, DefaultRetryInitialBackoff, DefaultRetryMaxBackoff)
+	assert.Equal(t, ErrRetryMaxAttemptsZeroValue, err)
+	assert.Nil(t, policy)
+
+	policy, err = NewRetryPolicy(DefaultRetryMaxAttempts, 0, DefaultRetryMaxBackoff)
+	assert.Equal(t, ErrRetryInitialBackoffZeroValue, err)
+	assert.Nil(t, policy)
+
+	policy, err = NewRetryPolicy(DefaultRetryMaxAttempts, time.Second, time.Millisecond)
+	assert.Equal(t, ErrRetryMaxBackoffTooSmall, err)
+	assert.Nil(t, policy)
+}
+
+func TestRetryPolicy_retry(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	}
+	cases := []struct {
+		nFailures        uint32
+		expectedAttempts uint32
+		expectErr        bool
+	}{
+		{nFailures: 0, expectedAttempts: 1},
+		{nFailures: 2, expectedAttempts: 3},
+		{nFailures: 3, expectedAttempts: 3, expectErr: true},
+	}
+	for i, c := range cases {
+		attempts := make([]uint32, 0)
+		err := policy.retry(func(attempt uint32) error {
+			attempts = append(attempts, attempt)
+			if attempt < c.nFailures {
+				return errors.New("some operation error")
+			}
+			return nil
+		})
+		assert.Equal(t, c.expectErr, err != nil, "case %d", i)
+		assert.Len(t, attempts, int(c.expectedAttempts), "case %d", i)
+		for j, attempt := range attempts {
+			assert.Equal(t, uint32(j), attempt, "case %d", i)
+		}
+	}
+
+	// check single attempt disables retries
+	policy.MaxAttempts = 1
+	nAttempts := 0
+	err := policy.retry(func(attempt uint32) error {
+		nAttempts++
+		return errors.New("some operation error")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, nAttempts)
+}
+
+func TestRetryPublisher_Publish_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	first, next := &fixedLibrarianClient{name: "first"}, &fixedLibrarianClient{name: "next"}
+	inner := &flakyPublisherAcquirer{nFailures: 2}
+	p := NewRetryPublisher(inner, &fixedClientBalancer{client: next}, newTestRetryPolicy())
+
+	// check failed Puts are retried against the next librarians
+	publishedKey, err := p.Publish(doc, api.GetAuthorPub(doc), first)
+	assert.Nil(t, err)
+	assert.Equal(t, docKey, publishedKey)
+	assert.Equal(t, []interface{}{first, next, next}, inner.clients)
+}
+
+func TestRetryPublisher_Publish_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, _ := api.NewTestDocument(rng)
+	lc := &fixedLibrarianClient{}
+
+	// check error after max attempts bubbles up
+	inner := &flakyPublisherAcquirer{nFailures: 3}
+	p := NewRetryPublisher(inner, &fixedClientBalancer{client: lc}, newTestRetryPolicy())
+	docKey, err := p.Publish(doc, api.GetAuthorPub(doc), lc)
+	assert.NotNil(t, err)
+	assert.Nil(t, docKey)
+	assert.Len(t, inner.clients, 3)
+
+	// check Next error bubbles up
+	inner = &flakyPublisherAcquirer{nFailures: 1}
+	p = NewRetryPublisher(inner, &fixedClientBalancer{err: errors.New("some Next error")},
+		newTestRetryPolicy())
+	docKey, err = p.Publish(doc, api.GetAuthorPub(doc), lc)
+	assert.NotNil(t, err)
+	assert.Nil(t, docKey)
+
+	// check inconsistent author pub key isn't retried
+	inner = &flakyPublisherAcquirer{nFailures: 3, err: ErrInconsistentAuthorPubKey}
+	p = NewRetryPublisher(inner, &fixedClientBalancer{client: lc}, newTestRetryPolicy())
+	docKey, err = p.Publish(doc, api.GetAuthorPub(doc), lc)
+	assert.Equal(t, ErrInconsistentAuthorPubKey, err)
+	assert.Nil(t, docKey)
+	assert.Len(t, inner.clients, 1)
+}
+
+func TestRetryAcquirer_Acquire_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	first, next := &fixedLibrarianClient{name: "first"}, &fixedLibrarianClient{name: "next"}
+	inner := &flakyPublisherAcquirer{nFailures: 2, doc: doc}
+	a := NewRetryAcquirer(inner, &fixedClientBalancer{client: next}, newTestRetryPolicy())
+
+	// check failed Gets are retried against the next librarians
+	acquired, err := a.Acquire(docKey, api.GetAuthorPub(doc), first)
+	assert.Nil(t, err)
+	assert.Equal(t, doc, acquired)
+	assert.Equal(t, []interface{}{first, next, next}, inner.clients)
+}
+
+func TestRetryAcquirer_Acquire_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	doc, docKey := api.NewTestDocument(rng)
+	lc := &fixedLibrarianClient{}
+
+	// check error after max attempts bubbles up
+	inner := &flakyPublisherAcquirer{nFailures: 3, doc: doc}
+	a := NewRetryAcquirer(inner, &fixedClientBalancer{client: lc}, newTestRetryPolicy())
+	acquired, err := a.Acquire(docKey, api.GetAuthorPub(doc), lc)
+	assert.NotNil(t, err)
+	assert.Nil(t, acquired)
+	assert.Len(t, inner.clients, 3)
+
+	// check Next error bubbles up
+	inner = &flakyPublisherAcquirer{nFailures: 1, doc: doc}
+	a = NewRetryAcquirer(inner, &fixedClientBalancer{err: errors.New("some Next error")},
+		newTestRetryPolicy())
+	acquired, err = a.Acquire(docKey, api.GetAuthorPub(doc), lc)
+	assert.NotNil(t, err)
+	assert.Nil(t, acquired)
+}
+
+func newTestRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+}
+
+type fixedLibrarianClient struct {
+	api.LibrarianClient
+	name string
+}
+
+// flakyPublisherAcquirer fails the first nFailures Publish or Acquire calls and records the
+// librarian client of each call.
+type flakyPublisherAcquirer struct {
+	nFailures int
+	err       error
+	doc       *api.Document
+	clients   []interface{}
+}
+
+func (f *flakyPublisherAcquirer) Publish(doc *api.Document, authorPub []byte, lc api.Putter) (
+	id.ID, error) {
+	if err := f.call(lc); err != nil {
+		return nil, err
+	}
+	return api.GetKey(doc)
+}
+
+func (f *flakyPublisherAcquirer) Acquire(docKey id.ID, authorPub []byte, lc api.Getter) (
+	*api.Document, error) {
+	if err := f.call(lc); err != nil {
+		return nil, err
+	}
+	return f.doc, nil
+}
+
+func (f *flakyPublisherAcquirer) call(lc interface{}) error {
+	f.clients = append(f.clients, lc)
+	if len(f.clients) <= f.nFailures {
+		if f.err != nil {
+			return f.err
+		}
+		return errors.New("some librarian error")
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package ship
 
 import (
+	"errors"
+
 	"github.com/drausin/libri/libri/author/io/enc"
 	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/author/io/page"
 	"github.com/drausin/libri/libri/author/io/publish"
 	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/drausin/libri/libri/common/ecid"
@@ -11,12 +14,20 @@ import (
 	"github.com/drausin/libri/libri/librarian/api"
 )
 
+// ErrEnvelopeRevoked indicates when a received envelope has been revoked by its author.
+var ErrEnvelopeRevoked = errors.New("envelope has been revoked")
+
 // Receiver downloads the envelope, entry, and pages from the libri network.
 type Receiver interface {
 	// Receive gets (from libri) the envelope, entry, and pages implied by the envelope key. It
 	// stores these documents in a storage.DocumentStorer and returns the entry and encryption
 	// keys.
 	Receive(envelopeKey id.ID) (*api.Document, *enc.Keys, error)
+
+	// ReceiveEntry gets (from libri) the envelope and entry implied by the envelope key. It
+	// stores the entry's page in a storage.DocumentStorer if the entry contains it but leaves
+	// separate pages to be acquired by the caller. It returns the entry and encryption keys.
+	ReceiveEntry(envelopeKey id.ID) (*api.Document, *enc.Keys, error)
 }
 
 type receiver struct {
@@ -46,6 +57,17 @@ func NewReceiver(
 }
 
 func (r *receiver) Receive(envelopeKey id.ID) (*api.Document, *enc.Keys, error) {
+	entryDoc, encKeys, err := r.ReceiveEntry(envelopeKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.getPages(entryDoc, encKeys); err != nil {
+		return nil, nil, err
+	}
+	return entryDoc, encKeys, nil
+}
+
+func (r *receiver) ReceiveEntry(envelopeKey id.ID) (*api.Document, *enc.Keys, error) {
 	lc, err := r.librarians.Next()
 	if err != nil {
 		return nil, nil, err
@@ -56,21 +78,28 @@ func (r *receiver) Receive(envelopeKey id.ID) (*api.Document, *enc.Keys, error)
 	if err != nil {
 		return nil, nil, err
 	}
+	if envelopeDoc.GetTombstone() != nil {
+		return nil, nil, ErrEnvelopeRevoked
+	}
 	authorPubBytes, readerPubBytes, entryKey, err := pack.SeparateEnvelopeDoc(envelopeDoc)
 	if err != nil {
 		return nil, nil, err
 	}
-	encKeys, err := r.createEncryptionKeys(authorPubBytes, readerPubBytes)
+	kek, err := r.createEncryptionKeys(authorPubBytes, readerPubBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	encKeys, err := pack.GetEntryKeys(envelopeDoc, kek)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// get the entry and pages
+	// get the entry and its page, if it contains one
 	entryDoc, err := r.acquirer.Acquire(entryKey, authorPubBytes, lc)
 	if err != nil {
 		return nil, nil, err
 	}
-	if err := r.getPages(entryDoc, authorPubBytes); err != nil {
+	if err := r.storeEntryPage(entryDoc); err != nil {
 		return nil, nil, err
 	}
 
@@ -93,18 +122,14 @@ func (r *receiver) createEncryptionKeys(authorPubBytes, readerPubBytes []byte) (
 	return encKeys, nil
 }
 
-func (r *receiver) getPages(entry *api.Document, authorPubBytes []byte) error {
+func (r *receiver) storeEntryPage(entry *api.Document) error {
 	if _, ok := entry.Contents.(*api.Document_Entry); !ok {
 		return api.ErrUnexpectedDocumentType
 	}
 	switch ec := entry.Contents.(*api.Document_Entry).Entry.Contents.(type) {
 	case *api.Entry_PageKeys:
-		pageKeys, err := api.GetEntryPageKeys(entry)
-		if err != nil {
-			// should never get here
-			return err
-		}
-		return r.msAcquirer.Acquire(pageKeys, authorPubBytes, r.librarians)
+		// separate pages acquired in getPages
+		return nil
 	case *api.Entry_Page:
 		pageDoc, docKey, err := api.GetPageDocument(ec.Page)
 		if err != nil {
@@ -117,3 +142,63 @@ func (r *receiver) getPages(entry *api.Document, authorPubBytes []byte) error {
 	// should never get here
 	return api.ErrUnknownDocumentType
 }
+
+func (r *receiver) getPages(entry *api.Document, keys *enc.Keys) error {
+	pageKeys, err := api.GetEntryPageKeys(entry)
+	if err != nil || pageKeys == nil {
+		return err
+	}
+	// invalid metadata is left for unpacking the entry to report
+	if metadata, err := getMetadata(entry, keys); err == nil {
+		if dataShards, parityShards, in := metadata.GetErasureShards(); in {
+			return r.getShards(pageKeys, api.GetAuthorPub(entry), dataShards, parityShards)
+		}
+	}
+	return r.msAcquirer.Acquire(pageKeys, api.GetAuthorPub(entry), r.librarians)
+}
+
+// getShards acquires enough of the shards of each erasure-coded page to reconstruct it. It first
+// acquires all of the data shards and then, if some can't be acquired, acquires parity shards in
+// place of the missing ones.
+func (r *receiver) getShards(
+	shardKeys []id.ID, authorPub []byte, dataShards, parityShards uint64,
+) error {
+	nShards := int(dataShards + parityShards)
+	if dataShards == 0 || len(shardKeys)%nShards != 0 {
+		return page.ErrUnexpectedNShards
+	}
+	dataKeys := make([]id.ID, 0, len(shardKeys)/nShards*int(dataShards))
+	for i := 0; i < len(shardKeys); i += nShards {
+		dataKeys = append(dataKeys, shardKeys[i:i+int(dataShards)]...)
+	}
+	if err := r.msAcquirer.Acquire(dataKeys, authorPub, r.librarians); err == nil {
+		return nil
+	}
+	for i := 0; i < len(shardKeys); i += nShards {
+		nAcquired := uint64(0)
+		for _, shardKey := range shardKeys[i : i+nShards] {
+			if nAcquired == dataShards {
+				break
+			}
+			err := r.msAcquirer.Acquire([]id.ID{shardKey}, authorPub, r.librarians)
+			if err == nil {
+				nAcquired++
+			}
+		}
+		if nAcquired < dataShards {
+			return page.ErrTooFewShards
+		}
+	}
+	return nil
+}
+
+func getMetadata(entry *api.Document, keys *enc.Keys) (*api.Metadata, error) {
+	encMetadata, err := enc.NewEncryptedMetadata(
+		entry.GetEntry().MetadataCiphertext,
+		entry.GetEntry().MetadataCiphertextMac,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewMetadataEncrypterDecrypter().Decrypt(encMetadata, keys)
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 
 	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/author/io/page"
 	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/drausin/libri/libri/common/id"
@@ -46,6 +47,7 @@ func TestReceiver_Receive_ok(t *testing.T) {
 			ecid.ToPublicKeyBytes(authorKey),
 			ecid.ToPublicKeyBytes(readerKey),
 			entryKey,
+			nil,
 		)
 		envelopeKey, err := api.GetKey(envelope)
 		assert.Nil(t, err)
@@ -96,6 +98,7 @@ func TestReceiver_Receive_err(t *testing.T) {
 		ecid.ToPublicKeyBytes(authorKey),
 		ecid.ToPublicKeyBytes(readerKey),
 		entryKey,
+		nil,
 	)
 	envelopeKey, err := api.GetKey(envelope)
 	assert.Nil(t, err)
@@ -157,6 +160,60 @@ func TestReceiver_Receive_err(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, receivedDoc)
 	assert.Nil(t, receivedKeys)
+
+	// check revoked envelope errors
+	acq7 := &fixedAcquirer{docs: make(map[string]*api.Document)}
+	acq7.docs[envelopeKey.String()] = &api.Document{
+		Contents: &api.Document_Tombstone{Tombstone: api.NewTestTombstone(rng)},
+	}
+	r7 := NewReceiver(cb, readerKeys, acq7, msAcq, docS)
+	receivedDoc, receivedKeys, err = r7.Receive(envelopeKey)
+	assert.Equal(t, ErrEnvelopeRevoked, err)
+	assert.Nil(t, receivedDoc)
+	assert.Nil(t, receivedKeys)
+}
+
+func TestReceiver_ReceiveEntry_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorKeys, readerKeys := keychain.New(3), keychain.New(3)
+	authorKey, err := authorKeys.Sample()
+	assert.Nil(t, err)
+	readerKey, err := readerKeys.Sample()
+	assert.Nil(t, err)
+	cb := &fixedClientBalancer{}
+
+	entry := &api.Document{
+		Contents: &api.Document_Entry{
+			Entry: api.NewTestMultiPageEntry(rng),
+		},
+	}
+	entryKey, err := api.GetKey(entry)
+	assert.Nil(t, err)
+	envelope := pack.NewEnvelopeDoc(
+		ecid.ToPublicKeyBytes(authorKey),
+		ecid.ToPublicKeyBytes(readerKey),
+		entryKey,
+		nil,
+	)
+	envelopeKey, err := api.GetKey(envelope)
+	assert.Nil(t, err)
+	acq := &fixedAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	acq.docs[entryKey.String()] = entry
+	acq.docs[envelopeKey.String()] = envelope
+	msAcq := &fixedMultiStoreAcquirer{}
+	docS := &fixedStorer{}
+	r := NewReceiver(cb, readerKeys, acq, msAcq, docS)
+
+	receivedEntry, receivedKeys, err := r.ReceiveEntry(envelopeKey)
+	assert.Nil(t, err)
+	assert.Equal(t, entry, receivedEntry)
+	assert.NotNil(t, receivedKeys)
+
+	// check that separate pages haven't been acquired
+	assert.Nil(t, msAcq.docKeys)
+	assert.Nil(t, docS.storedKey)
 }
 
 func TestReceiver_createEncryptionKeys_err(t *testing.T) {
@@ -204,6 +261,45 @@ func (f *fixedAcquirer) Acquire(docKey id.ID, authorPub []byte, lc api.Getter) (
 	return value, f.err
 }
 
+func TestReceiver_getShards(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	shardKeys := make([]id.ID, 12)
+	for i := range shardKeys {
+		shardKeys[i] = id.NewPseudoRandom(rng)
+	}
+	msAcq := &missingMultiStoreAcquirer{missing: make(map[string]struct{})}
+	r := &receiver{librarians: &fixedClientBalancer{}, msAcquirer: msAcq}
+
+	// check only data shards are acquired when all are available
+	err := r.getShards(shardKeys, nil, 4, 2)
+	assert.Nil(t, err)
+	assert.Len(t, msAcq.acquired, 8)
+
+	// check parity shards are acquired in place of missing data shards
+	msAcq.acquired = nil
+	msAcq.missing[shardKeys[1].String()] = struct{}{}
+	msAcq.missing[shardKeys[3].String()] = struct{}{}
+	msAcq.missing[shardKeys[8].String()] = struct{}{}
+	err = r.getShards(shardKeys, nil, 4, 2)
+	assert.Nil(t, err)
+	assert.Len(t, msAcq.acquired, 8)
+	for _, acquired := range msAcq.acquired {
+		_, in := msAcq.missing[acquired.String()]
+		assert.False(t, in)
+	}
+
+	// check too many missing shards creates error
+	msAcq.missing[shardKeys[4].String()] = struct{}{}
+	err = r.getShards(shardKeys, nil, 4, 2)
+	assert.Equal(t, page.ErrTooFewShards, err)
+
+	// check wrong number of shards creates error
+	err = r.getShards(shardKeys[:5], nil, 4, 2)
+	assert.Equal(t, page.ErrUnexpectedNShards, err)
+	err = r.getShards(shardKeys, nil, 0, 2)
+	assert.Equal(t, page.ErrUnexpectedNShards, err)
+}
+
 type fixedMultiStoreAcquirer struct {
 	err       error
 	docKeys   []id.ID
@@ -244,3 +340,42 @@ func (f *fixedKeychain) Get(publicKey []byte) (ecid.ID, bool) {
 func (f *fixedKeychain) Len() int {
 	return 0
 }
+
+func (f *fixedKeychain) Merge(other keychain.Keychain) keychain.Keychain {
+	return nil
+}
+
+func (f *fixedKeychain) Subset(publicKeys [][]byte) (keychain.Keychain, error) {
+	return nil, nil
+}
+
+func (f *fixedKeychain) PublicKeys() [][]byte {
+	return nil
+}
+
+func (f *fixedKeychain) Metadata(publicKey []byte) (keychain.Metadata, bool) {
+	return keychain.Metadata{}, false
+}
+
+func (f *fixedKeychain) SetMetadata(publicKey []byte, md keychain.Metadata) error {
+	return nil
+}
+
+// missingMultiStoreAcquirer fails to acquire all documents if any is missing and otherwise
+// records them as acquired.
+type missingMultiStoreAcquirer struct {
+	missing  map[string]struct{}
+	acquired []id.ID
+}
+
+func (m *missingMultiStoreAcquirer) Acquire(
+	docKeys []id.ID, authorPub []byte, cb api.ClientBalancer,
+) error {
+	for _, docKey := range docKeys {
+		if _, in := m.missing[docKey.String()]; in {
+			return errors.New("missing")
+		}
+	}
+	m.acquired = append(m.acquired, docKeys...)
+	return nil
+}
@@ -10,9 +10,10 @@ import (
 // Shipper publishes documents to libri.
 type Shipper interface {
 	// Ship publishes (to libri) the entry document, its page document keys (if more than one),
-	// and the envelope document with the author and reader public keys. It returns the
-	// published envelope document and its key.
-	Ship(entry *api.Document, authorPub []byte, readerPub []byte) (*api.Document, id.ID, error)
+	// and the envelope document with the author and reader public keys and (optional) disclosed
+	// entry attributes. It returns the published envelope document and its key.
+	Ship(entry *api.Document, authorPub []byte, readerPub []byte, attrs *api.EntryAttributes) (
+		*api.Document, id.ID, error)
 }
 
 type shipper struct {
@@ -33,8 +34,9 @@ func NewShipper(
 	}
 }
 
-func (s *shipper) Ship(entry *api.Document, authorPub []byte, readerPub []byte) (
-	*api.Document, id.ID, error) {
+func (s *shipper) Ship(
+	entry *api.Document, authorPub []byte, readerPub []byte, attrs *api.EntryAttributes,
+) (*api.Document, id.ID, error) {
 
 	// publish separate pages, if necessary
 	pageKeys, err := api.GetEntryPageKeys(entry)
@@ -56,7 +58,7 @@ func (s *shipper) Ship(entry *api.Document, authorPub []byte, readerPub []byte)
 	if err != nil {
 		return nil, nil, err
 	}
-	envelope := pack.NewEnvelopeDoc(authorPub, readerPub, entryKey)
+	envelope := pack.NewEnvelopeDoc(authorPub, readerPub, entryKey, attrs)
 	envelopeKey, err := s.publisher.Publish(envelope, authorPub, lc)
 	if err != nil {
 		return nil, nil, err
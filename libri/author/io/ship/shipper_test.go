@@ -33,7 +33,7 @@ func TestShipper_Ship_ok(t *testing.T) {
 	assert.Nil(t, err)
 
 	// test multi-page ship
-	envelope, envelopeKey, err := s.Ship(entry, authorPub, readerPub)
+	envelope, envelopeKey, err := s.Ship(entry, authorPub, readerPub, nil)
 	assert.Nil(t, err)
 	assert.NotNil(t, envelope)
 	assert.NotNil(t, envelopeKey)
@@ -48,7 +48,7 @@ func TestShipper_Ship_ok(t *testing.T) {
 	}
 	origEntryKey, err = api.GetKey(entry)
 	assert.Nil(t, err)
-	envelope, envelopeKey, err = s.Ship(entry, authorPub, readerPub)
+	envelope, envelopeKey, err = s.Ship(entry, authorPub, readerPub, nil)
 	assert.Nil(t, err)
 	assert.NotNil(t, envelope)
 	assert.NotNil(t, envelopeKey)
@@ -77,13 +77,13 @@ func TestShipper_Ship_err(t *testing.T) {
 			Envelope: api.NewTestEnvelope(rng),
 		},
 	}
-	envelope, entryKey, err := s.Ship(envelope, authorPub, readerPub)
+	envelope, entryKey, err := s.Ship(envelope, authorPub, readerPub, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, envelope)
 	assert.Nil(t, entryKey)
 
 	// check page publish error bubbles up
-	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub)
+	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, envelope)
 	assert.Nil(t, entryKey)
@@ -95,7 +95,7 @@ func TestShipper_Ship_err(t *testing.T) {
 	)
 
 	// check getting next librarian error bubbles up
-	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub)
+	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, envelope)
 	assert.Nil(t, entryKey)
@@ -107,7 +107,7 @@ func TestShipper_Ship_err(t *testing.T) {
 	)
 
 	// check entry publish error bubbles up
-	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub)
+	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, envelope)
 	assert.Nil(t, entryKey)
@@ -119,7 +119,7 @@ func TestShipper_Ship_err(t *testing.T) {
 	)
 
 	// check envelope publish error bubbles up
-	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub)
+	envelope, entryKey, err = s.Ship(entry, authorPub, readerPub, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, envelope)
 	assert.Nil(t, entryKey)
@@ -186,7 +186,7 @@ func TestShipReceive(t *testing.T) {
 		s := NewShipper(cb, pubAcq, mlP)
 		envelopeKeys := make([]id.ID, nDocs)
 		for i := uint32(0); i < nDocs; i++ {
-			envelope, _, err := s.Ship(docs[i], authorPub, readerPub)
+			envelope, _, err := s.Ship(docs[i], authorPub, readerPub, nil)
 			assert.Nil(t, err)
 			envelopeKeys[i], err = api.GetKey(envelope)
 			assert.Nil(t, err)
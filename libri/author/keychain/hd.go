@@ -0,0 +1,145 @@
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// AuthorAccount is the HD derivation account of the author keychain.
+	AuthorAccount = uint32(0)
+
+	// SelfReaderAccount is the HD derivation account of the self-reader keychain.
+	SelfReaderAccount = uint32(1)
+
+	// SeedLength is the length of seeds created by NewSeed.
+	SeedLength = 32
+
+	// MinSeedLength is the minimum length of a seed.
+	MinSeedLength = 16
+
+	// MaxSeedLength is the maximum length of a seed.
+	MaxSeedLength = 64
+
+	// hardenedOffset is added to child indices for hardened derivation, which only private
+	// extended keys can do.
+	hardenedOffset = uint32(1) << 31
+
+	privateKeyLength = 32
+)
+
+var (
+	// ErrInvalidSeedLength indicates when a seed is shorter than MinSeedLength or longer than
+	// MaxSeedLength.
+	ErrInvalidSeedLength = errors.New("invalid seed length")
+
+	// ErrInvalidDerivedKey indicates when a derived key is zero or not less than the curve order,
+	// which happens with probability lower than 1 in 2^127.
+	ErrInvalidDerivedKey = errors.New("invalid derived key")
+)
+
+// masterKeyHMACKey is the HMAC key for deriving the master extended key from a seed.
+var masterKeyHMACKey = []byte("Bitcoin seed")
+
+// NewSeed creates a new random seed for FromSeed.
+func NewSeed() ([]byte, error) {
+	seed := make([]byte, SeedLength)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// FromSeed creates a Keychain with n keys deterministically derived from the seed, so the same
+// seed and account always give the same keys. Each key i is derived along the BIP32 path
+// m/account'/i', where all levels use hardened derivation.
+func FromSeed(seed []byte, account uint32, n int) (Keychain, error) {
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	accountKey, err := master.child(account)
+	if err != nil {
+		return nil, err
+	}
+	ecids := make([]ecid.ID, n)
+	for i := 0; i < n; i++ {
+		childKey, err := accountKey.child(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		priv, err := childKey.privateKey()
+		if err != nil {
+			return nil, err
+		}
+		ecids[i] = ecid.FromPrivateKey(priv)
+	}
+	return newCreated(ecids), nil
+}
+
+// extendedKey is a BIP32 private extended key.
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// newMasterKey derives the master extended key from the seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	if len(seed) < MinSeedLength || len(seed) > MaxSeedLength {
+		return nil, ErrInvalidSeedLength
+	}
+	return newExtendedKey(masterKeyHMACKey, seed, big.NewInt(0))
+}
+
+// child derives the hardened child extended key with the given index, which must be less than
+// 2^31.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 1+privateKeyLength+4)
+	k.keyBytes(data[1 : 1+privateKeyLength])
+	binary.BigEndian.PutUint32(data[1+privateKeyLength:], index+hardenedOffset)
+	return newExtendedKey(k.chainCode, data, k.key)
+}
+
+// newExtendedKey splits the HMAC-SHA512 of the data into a tweak added to the parent key and a
+// new chain code.
+func newExtendedKey(hmacKey, data []byte, parentKey *big.Int) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, hmacKey)
+	if _, err := mac.Write(data); err != nil {
+		return nil, err
+	}
+	sum := mac.Sum(nil)
+	n := ecid.Curve.Params().N
+	tweak := new(big.Int).SetBytes(sum[:privateKeyLength])
+	if tweak.Cmp(n) >= 0 {
+		return nil, ErrInvalidDerivedKey
+	}
+	key := tweak.Add(tweak, parentKey)
+	key.Mod(key, n)
+	if key.Sign() == 0 {
+		return nil, ErrInvalidDerivedKey
+	}
+	return &extendedKey{
+		key:       key,
+		chainCode: sum[privateKeyLength:],
+	}, nil
+}
+
+// keyBytes writes the 32-byte big-endian private key to buf.
+func (k *extendedKey) keyBytes(buf []byte) {
+	keyBytes := k.key.Bytes()
+	copy(buf[privateKeyLength-len(keyBytes):], keyBytes)
+}
+
+func (k *extendedKey) privateKey() (*ecdsa.PrivateKey, error) {
+	buf := make([]byte, privateKeyLength)
+	k.keyBytes(buf)
+	return crypto.ToECDSA(buf)
+}
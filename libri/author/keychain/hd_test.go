@@ -0,0 +1,83 @@
+package keychain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSeed(t *testing.T) {
+	seed1, err := NewSeed()
+	assert.Nil(t, err)
+	assert.Len(t, seed1, SeedLength)
+
+	seed2, err := NewSeed()
+	assert.Nil(t, err)
+	assert.NotEqual(t, seed1, seed2)
+}
+
+func TestFromSeed_ok(t *testing.T) {
+	seed, err := NewSeed()
+	assert.Nil(t, err)
+
+	// check same seed and account give same keys
+	kc1, err := FromSeed(seed, AuthorAccount, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, kc1.Len())
+	kc2, err := FromSeed(seed, AuthorAccount, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1, kc2)
+
+	// check more keys from the same seed includes the previous keys
+	kc3, err := FromSeed(seed, AuthorAccount, 4)
+	assert.Nil(t, err)
+	for _, pub := range kc1.(*keychain).pubs {
+		_, in := kc3.(*keychain).privs[pub]
+		assert.True(t, in)
+	}
+
+	// check different account gives different keys
+	kc4, err := FromSeed(seed, SelfReaderAccount, 3)
+	assert.Nil(t, err)
+	for _, pub := range kc1.(*keychain).pubs {
+		_, in := kc4.(*keychain).privs[pub]
+		assert.False(t, in)
+	}
+
+	// check derived keys can sign
+	key, err := kc1.Sample()
+	assert.Nil(t, err)
+	pub, err := ecid.FromPublicKeyBytes(ecid.ToPublicKeyBytes(key))
+	assert.Nil(t, err)
+	assert.Equal(t, key.Key().PublicKey, *pub)
+}
+
+func TestFromSeed_err(t *testing.T) {
+	for _, seedLen := range []int{0, MinSeedLength - 1, MaxSeedLength + 1} {
+		kc, err := FromSeed(make([]byte, seedLen), AuthorAccount, 3)
+		assert.Equal(t, ErrInvalidSeedLength, err)
+		assert.Nil(t, kc)
+	}
+}
+
+func TestExtendedKey_child(t *testing.T) {
+	// BIP32 test vector 1
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	assert.Nil(t, err)
+	master, err := newMasterKey(seed)
+	assert.Nil(t, err)
+	assert.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		hex.EncodeToString(master.key.Bytes()))
+	assert.Equal(t, "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508",
+		hex.EncodeToString(master.chainCode))
+
+	// m/0'
+	child, err := master.child(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		hex.EncodeToString(child.key.Bytes()))
+	assert.Equal(t, "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141",
+		hex.EncodeToString(child.chainCode))
+}
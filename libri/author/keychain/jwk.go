@@ -0,0 +1,314 @@
+package keychain
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// JWKKeyType is the JWK key type of exported keys.
+	JWKKeyType = "EC"
+
+	// JWKCurve is the JWK curve name (RFC 8812) of exported keys.
+	JWKCurve = "secp256k1"
+
+	// JWEAlgorithm is the JWE key management algorithm of exported JWKs.
+	JWEAlgorithm = "PBES2-HS256+A128KW"
+
+	// JWEEncryption is the JWE content encryption algorithm of exported JWKs.
+	JWEEncryption = "A256GCM"
+
+	jwkContentType    = "jwk+json"
+	jwkSetContentType = "jwk-set+json"
+	a128KWKeyLength   = 16
+	gcmIVLength       = 12
+	gcmTagLength      = 16
+	nJWEParts         = 5
+)
+
+var (
+	// ErrMalformedJWE indicates when an imported JWE isn't in the compact serialization.
+	ErrMalformedJWE = errors.New("malformed JWE")
+
+	// ErrUnsupportedJWE indicates when an imported JWE uses algorithms other than JWEAlgorithm
+	// and JWEEncryption or has unexpected content.
+	ErrUnsupportedJWE = errors.New("unsupported JWE algorithm or content type")
+
+	// ErrKeyUnwrapFailed indicates when the JWE content encryption key fails to unwrap, usually
+	// because of a wrong passphrase.
+	ErrKeyUnwrapFailed = errors.New("key unwrap failed, possibly from wrong passphrase")
+)
+
+// keyWrapIV is the RFC 3394 AES key wrap default initial value.
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// jwk is the JSON Web Key (RFC 7517) of a elliptic curve private key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d"`
+}
+
+// jwkSet is a JSON Web Key Set (RFC 7517).
+type jwkSet struct {
+	Keys []*jwk `json:"keys"`
+}
+
+// jweHeader is the protected header of a JSON Web Encryption (RFC 7516) with PBES2 key
+// management (RFC 7518).
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty"`
+	P2s string `json:"p2s"`
+	P2c int    `json:"p2c"`
+}
+
+// ExportKeyJWK exports the key as a JWK encrypted with the passphrase in a compact JWE, whose key
+// is derived via PBKDF2 with the given number of iterations.
+func ExportKeyJWK(key ecid.ID, auth string, iterations int) ([]byte, error) {
+	plaintext, err := json.Marshal(toJWK(key))
+	if err != nil {
+		return nil, err
+	}
+	return encryptJWE(plaintext, jwkContentType, auth, iterations)
+}
+
+// ImportKeyJWK imports a key exported by ExportKeyJWK or by another tool in the same format.
+func ImportKeyJWK(jwe []byte, auth string) (ecid.ID, error) {
+	plaintext, err := decryptJWE(jwe, jwkContentType, auth)
+	if err != nil {
+		return nil, err
+	}
+	key := &jwk{}
+	if err := json.Unmarshal(plaintext, key); err != nil {
+		return nil, err
+	}
+	return fromJWK(key)
+}
+
+// ExportJWK exports all keys in the keychain as a JWK Set encrypted like ExportKeyJWK.
+func ExportJWK(kc Keychain, auth string, iterations int) ([]byte, error) {
+	keys := sortedKeys(kc)
+	set := &jwkSet{Keys: make([]*jwk, len(keys))}
+	for i, key := range keys {
+		set.Keys[i] = toJWK(key)
+	}
+	plaintext, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	return encryptJWE(plaintext, jwkSetContentType, auth, iterations)
+}
+
+// ImportJWK imports a keychain from a JWK Set exported by ExportJWK.
+func ImportJWK(jwe []byte, auth string) (Keychain, error) {
+	plaintext, err := decryptJWE(jwe, jwkSetContentType, auth)
+	if err != nil {
+		return nil, err
+	}
+	set := &jwkSet{}
+	if err := json.Unmarshal(plaintext, set); err != nil {
+		return nil, err
+	}
+	ecids := make([]ecid.ID, len(set.Keys))
+	for i, key := range set.Keys {
+		if ecids[i], err = fromJWK(key); err != nil {
+			return nil, err
+		}
+	}
+	return FromECIDs(ecids), nil
+}
+
+func toJWK(key ecid.ID) *jwk {
+	return &jwk{
+		Kty: JWKKeyType,
+		Crv: JWKCurve,
+		X:   encodeJWKCoordinate(key.Key().X),
+		Y:   encodeJWKCoordinate(key.Key().Y),
+		D:   base64.RawURLEncoding.EncodeToString(crypto.FromECDSA(key.Key())),
+	}
+}
+
+func fromJWK(key *jwk) (ecid.ID, error) {
+	if key.Kty != JWKKeyType || key.Crv != JWKCurve {
+		return nil, ErrUnsupportedKeyCurve
+	}
+	d, err := base64.RawURLEncoding.DecodeString(key.D)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := crypto.ToECDSA(d)
+	if err != nil {
+		return nil, err
+	}
+	if key.X != encodeJWKCoordinate(priv.X) || key.Y != encodeJWKCoordinate(priv.Y) {
+		return nil, ErrKeyMismatch
+	}
+	return ecid.FromPrivateKey(priv), nil
+}
+
+// encodeJWKCoordinate encodes the 32-byte big-endian curve point coordinate.
+func encodeJWKCoordinate(x *big.Int) string {
+	buf := make([]byte, privateKeyLength)
+	xBytes := x.Bytes()
+	copy(buf[privateKeyLength-len(xBytes):], xBytes)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func encryptJWE(plaintext []byte, cty, auth string, iterations int) ([]byte, error) {
+	if iterations <= 0 {
+		return nil, ErrInvalidPBKDF2Iterations
+	}
+	salt, cek, iv := make([]byte, pbkdf2SaltLength), make([]byte, aes256KeyLength),
+		make([]byte, gcmIVLength)
+	for _, b := range [][]byte{salt, cek, iv} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	headerJSON, err := json.Marshal(&jweHeader{
+		Alg: JWEAlgorithm,
+		Enc: JWEEncryption,
+		Cty: cty,
+		P2s: base64.RawURLEncoding.EncodeToString(salt),
+		P2c: iterations,
+	})
+	if err != nil {
+		return nil, err
+	}
+	wrappedCEK, err := wrapKey(deriveKEK(auth, salt, iterations), cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	header := base64.RawURLEncoding.EncodeToString(headerJSON)
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(header))
+	ciphertext, tag := sealed[:len(sealed)-gcmTagLength], sealed[len(sealed)-gcmTagLength:]
+	parts := []string{
+		header,
+		base64.RawURLEncoding.EncodeToString(wrappedCEK),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}
+	return []byte(strings.Join(parts, ".")), nil
+}
+
+func decryptJWE(jwe []byte, cty, auth string) ([]byte, error) {
+	parts := bytes.Split(bytes.TrimSpace(jwe), []byte{'.'})
+	if len(parts) != nJWEParts {
+		return nil, ErrMalformedJWE
+	}
+	decoded := make([][]byte, nJWEParts)
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(string(part)); err != nil {
+			return nil, ErrMalformedJWE
+		}
+	}
+	header := &jweHeader{}
+	if err := json.Unmarshal(decoded[0], header); err != nil {
+		return nil, ErrMalformedJWE
+	}
+	if header.Alg != JWEAlgorithm || header.Enc != JWEEncryption || header.Cty != cty {
+		return nil, ErrUnsupportedJWE
+	}
+	if header.P2c <= 0 {
+		return nil, ErrInvalidPBKDF2Iterations
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(header.P2s)
+	if err != nil {
+		return nil, ErrMalformedJWE
+	}
+	cek, err := unwrapKey(deriveKEK(auth, salt, header.P2c), decoded[1])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded[2]) != gcmIVLength {
+		return nil, ErrMalformedJWE
+	}
+	sealed := append(decoded[3], decoded[4]...)
+	return gcm.Open(nil, decoded[2], sealed, parts[0])
+}
+
+// deriveKEK derives the PBES2-HS256+A128KW key encryption key, whose PBKDF2 salt is prefixed with
+// the algorithm name.
+func deriveKEK(auth string, p2s []byte, iterations int) []byte {
+	salt := append(append([]byte(JWEAlgorithm), 0), p2s...)
+	return pbkdf2.Key([]byte(auth), salt, iterations, a128KWKeyLength, sha256.New)
+}
+
+// wrapKey wraps the key with the key encryption key via RFC 3394 AES key wrap.
+func wrapKey(kek, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	a := append([]byte{}, keyWrapIV...)
+	r := append([]byte{}, key...)
+	b := make([]byte, aes.BlockSize)
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(b, a)
+			copy(b[8:], r[i*8:(i+1)*8])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+			copy(r[i*8:], b[8:])
+		}
+	}
+	return append(a, r...), nil
+}
+
+// unwrapKey unwraps the key with the key encryption key via RFC 3394 AES key wrap.
+func unwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, ErrMalformedJWE
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	a := append([]byte{}, wrapped[:8]...)
+	r := append([]byte{}, wrapped[8:]...)
+	b := make([]byte, aes.BlockSize)
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[i*8:(i+1)*8])
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(r[i*8:], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, ErrKeyUnwrapFailed
+	}
+	return r, nil
+}
@@ -0,0 +1,122 @@
+package keychain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportKeyJWK(t *testing.T) {
+	key1, auth := ecid.NewRandom(), "test passphrase"
+	jwe, err := ExportKeyJWK(key1, auth, veryLightPBKDF2Iterations)
+	assert.Nil(t, err)
+	parts := bytes.Split(jwe, []byte{'.'})
+	assert.Len(t, parts, nJWEParts)
+	headerJSON, err := base64.RawURLEncoding.DecodeString(string(parts[0]))
+	assert.Nil(t, err)
+	header := &jweHeader{}
+	assert.Nil(t, json.Unmarshal(headerJSON, header))
+	assert.Equal(t, JWEAlgorithm, header.Alg)
+	assert.Equal(t, JWEEncryption, header.Enc)
+	assert.Equal(t, veryLightPBKDF2Iterations, header.P2c)
+
+	key2, err := ImportKeyJWK(jwe, auth)
+	assert.Nil(t, err)
+	assert.Equal(t, key1, key2)
+
+	// check wrong passphrase errors
+	key3, err := ImportKeyJWK(jwe, "wrong passphrase")
+	assert.Equal(t, ErrKeyUnwrapFailed, err)
+	assert.Nil(t, key3)
+
+	// check modified ciphertext errors
+	jwe[len(jwe)-1] ^= 1
+	key4, err := ImportKeyJWK(jwe, auth)
+	assert.NotNil(t, err)
+	assert.Nil(t, key4)
+}
+
+func TestImportKeyJWK_err(t *testing.T) {
+	auth := "test passphrase"
+
+	// check malformed JWEs error
+	for _, jwe := range []string{"", "a.b.c", "a.b.c.d.!"} {
+		key, err := ImportKeyJWK([]byte(jwe), auth)
+		assert.Equal(t, ErrMalformedJWE, err)
+		assert.Nil(t, key)
+	}
+
+	// check keychain JWK Set doesn't import as a single key
+	jwe, err := ExportJWK(New(1), auth, veryLightPBKDF2Iterations)
+	assert.Nil(t, err)
+	key, err := ImportKeyJWK(jwe, auth)
+	assert.Equal(t, ErrUnsupportedJWE, err)
+	assert.Nil(t, key)
+
+	// check invalid iterations errors
+	jwe, err = ExportKeyJWK(ecid.NewRandom(), auth, 0)
+	assert.Equal(t, ErrInvalidPBKDF2Iterations, err)
+	assert.Nil(t, jwe)
+}
+
+func TestFromJWK_err(t *testing.T) {
+	key := toJWK(ecid.NewRandom())
+	key.Crv = "P-256"
+	id, err := fromJWK(key)
+	assert.Equal(t, ErrUnsupportedKeyCurve, err)
+	assert.Nil(t, id)
+
+	key = toJWK(ecid.NewRandom())
+	key.X = toJWK(ecid.NewRandom()).X
+	id, err = fromJWK(key)
+	assert.Equal(t, ErrKeyMismatch, err)
+	assert.Nil(t, id)
+}
+
+func TestExportImportJWK(t *testing.T) {
+	kc1, auth := New(3), "test passphrase"
+	jwe, err := ExportJWK(kc1, auth, veryLightPBKDF2Iterations)
+	assert.Nil(t, err)
+
+	kc2, err := ImportJWK(jwe, auth)
+	assert.Nil(t, err)
+	assert.Equal(t, sortedKeys(kc1), sortedKeys(kc2)) // key metadata isn't exported
+
+	// check wrong passphrase errors
+	kc3, err := ImportJWK(jwe, "wrong passphrase")
+	assert.Equal(t, ErrKeyUnwrapFailed, err)
+	assert.Nil(t, kc3)
+}
+
+func TestWrapUnwrapKey(t *testing.T) {
+	// RFC 3394 section 4.1 test vector
+	kek, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	assert.Nil(t, err)
+	key, err := hex.DecodeString("00112233445566778899aabbccddeeff")
+	assert.Nil(t, err)
+
+	wrapped, err := wrapKey(kek, key)
+	assert.Nil(t, err)
+	assert.Equal(t, "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+		hex.EncodeToString(wrapped))
+
+	unwrapped, err := unwrapKey(kek, wrapped)
+	assert.Nil(t, err)
+	assert.Equal(t, key, unwrapped)
+
+	// check modified wrapped key errors
+	wrapped[0] ^= 1
+	unwrapped, err = unwrapKey(kek, wrapped)
+	assert.Equal(t, ErrKeyUnwrapFailed, err)
+	assert.Nil(t, unwrapped)
+
+	// check wrapped key with a bad length errors
+	unwrapped, err = unwrapKey(kek, wrapped[:20])
+	assert.Equal(t, ErrMalformedJWE, err)
+	assert.Nil(t, unwrapped)
+}
@@ -0,0 +1,245 @@
+package keychain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// ScryptAlgorithm is the name of the scrypt KDF, which keychain files without a KDF header
+	// use.
+	ScryptAlgorithm = "scrypt"
+
+	// Argon2idAlgorithm is the name of the Argon2id KDF.
+	Argon2idAlgorithm = "argon2id"
+
+	// DefaultArgon2idTime is the default number of Argon2id passes over the memory.
+	DefaultArgon2idTime = 1
+
+	// DefaultArgon2idMemory is the default Argon2id memory in KiB, which uses 64MB.
+	DefaultArgon2idMemory = 64 * 1024
+
+	// DefaultArgon2idThreads is the default number of Argon2id threads.
+	DefaultArgon2idThreads = 4
+
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+var (
+	// ErrUnknownKDF indicates when a keychain file header has an unknown KDF algorithm.
+	ErrUnknownKDF = errors.New("unknown keychain KDF algorithm")
+
+	// ErrInvalidKDFParameters indicates when KDF parameters are zero or out of range.
+	ErrInvalidKDFParameters = errors.New("invalid keychain KDF parameters")
+
+	// ErrCiphertextTooShort indicates when encrypted private key bytes are too short to contain
+	// its nonce.
+	ErrCiphertextTooShort = errors.New("encrypted private key too short")
+)
+
+// KDF encrypts and decrypts private keys with a key derived from an authentication passphrase.
+type KDF interface {
+	// Header returns the header stored in the keychain file to recreate the KDF when loading,
+	// or nil for the scrypt KDF.
+	Header() *KDFHeader
+
+	// Encrypt encrypts the private key with the passphrase.
+	Encrypt(key *ecdsa.PrivateKey, auth string) ([]byte, error)
+
+	// Decrypt decrypts the private key with the passphrase.
+	Decrypt(encrypted []byte, auth string) (*ecdsa.PrivateKey, error)
+}
+
+// FromHeader creates the KDF described by a keychain file header, which is scrypt when nil.
+func FromHeader(header *KDFHeader) (KDF, error) {
+	if header == nil || header.Algorithm == "" || header.Algorithm == ScryptAlgorithm {
+		// scrypt parameters are stored with each encrypted key
+		return &scryptKDF{}, nil
+	}
+	if header.Algorithm != Argon2idAlgorithm {
+		return nil, ErrUnknownKDF
+	}
+	if err := validateArgon2id(header); err != nil {
+		return nil, err
+	}
+	return &argon2idKDF{header: header}, nil
+}
+
+// renewKDF creates a KDF with the same algorithm and difficulty as the one that encrypted the
+// stored keychain but with a fresh salt.
+func renewKDF(stored *StoredKeychain) (KDF, error) {
+	if _, err := FromHeader(stored.Kdf); err != nil {
+		return nil, err
+	}
+	if header := stored.Kdf; header != nil && header.Algorithm == Argon2idAlgorithm {
+		return NewArgon2idKDF(&Argon2idParameters{
+			Time:    header.Time,
+			Memory:  header.Memory,
+			Threads: uint8(header.Threads),
+		})
+	}
+	if len(stored.PrivateKeys) == 0 {
+		return nil, ErrEmptyKeychain
+	}
+	scryptN, scryptP, err := scryptParams(stored.PrivateKeys[0])
+	if err != nil {
+		return nil, err
+	}
+	return NewScryptKDF(scryptN, scryptP), nil
+}
+
+type scryptKDF struct {
+	n int
+	p int
+}
+
+// NewScryptKDF creates a new KDF using scrypt with the given difficulty parameters.
+func NewScryptKDF(scryptN, scryptP int) KDF {
+	return &scryptKDF{n: scryptN, p: scryptP}
+}
+
+func (k *scryptKDF) Header() *KDFHeader {
+	return nil
+}
+
+func (k *scryptKDF) Encrypt(key *ecdsa.PrivateKey, auth string) ([]byte, error) {
+	return encryptKey(key, auth, k.n, k.p)
+}
+
+func (k *scryptKDF) Decrypt(encrypted []byte, auth string) (*ecdsa.PrivateKey, error) {
+	return decryptKey(encrypted, auth)
+}
+
+// Argon2idParameters define the cost of deriving a key with Argon2id.
+type Argon2idParameters struct {
+	// Time is the number of passes over the memory.
+	Time uint32
+
+	// Memory is the memory used, in KiB.
+	Memory uint32
+
+	// Threads is the number of threads used.
+	Threads uint8
+}
+
+// NewDefaultArgon2idParameters returns a *Argon2idParameters object with default values.
+func NewDefaultArgon2idParameters() *Argon2idParameters {
+	return &Argon2idParameters{
+		Time:    DefaultArgon2idTime,
+		Memory:  DefaultArgon2idMemory,
+		Threads: DefaultArgon2idThreads,
+	}
+}
+
+type argon2idKDF struct {
+	header *KDFHeader
+
+	// derived key cache, since each key in a keychain uses the same passphrase
+	auth    string
+	derived []byte
+	mu      sync.Mutex
+}
+
+// NewArgon2idKDF creates a new KDF using Argon2id with the given parameters and a random salt.
+func NewArgon2idKDF(params *Argon2idParameters) (KDF, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	header := &KDFHeader{
+		Algorithm: Argon2idAlgorithm,
+		Salt:      salt,
+		Time:      params.Time,
+		Memory:    params.Memory,
+		Threads:   uint32(params.Threads),
+	}
+	if err := validateArgon2id(header); err != nil {
+		return nil, err
+	}
+	return &argon2idKDF{header: header}, nil
+}
+
+func (k *argon2idKDF) Header() *KDFHeader {
+	return k.header
+}
+
+func (k *argon2idKDF) Encrypt(key *ecdsa.PrivateKey, auth string) ([]byte, error) {
+	aead, err := k.newAEAD(auth)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, crypto.FromECDSA(key), nil), nil
+}
+
+func (k *argon2idKDF) Decrypt(encrypted []byte, auth string) (*ecdsa.PrivateKey, error) {
+	aead, err := k.newAEAD(auth)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < aead.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce, ciphertext := encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.ToECDSA(plaintext)
+}
+
+// newAEAD returns an AES-256 GCM cipher with the key derived from the passphrase.
+func (k *argon2idKDF) newAEAD(auth string) (cipher.AEAD, error) {
+	k.mu.Lock()
+	if k.derived == nil || k.auth != auth {
+		k.derived = deriveArgon2idKey(k.header, auth)
+		k.auth = auth
+	}
+	derived := k.derived
+	k.mu.Unlock()
+	return newAESGCM(derived)
+}
+
+// NewAEAD returns an AES-256 GCM cipher with the key derived from the passphrase by the Argon2id
+// KDF described by the header, e.g., to encrypt data other than private keys with a passphrase.
+func NewAEAD(header *KDFHeader, auth string) (cipher.AEAD, error) {
+	if header.Algorithm != Argon2idAlgorithm {
+		return nil, ErrUnknownKDF
+	}
+	if err := validateArgon2id(header); err != nil {
+		return nil, err
+	}
+	return newAESGCM(deriveArgon2idKey(header, auth))
+}
+
+func deriveArgon2idKey(header *KDFHeader, auth string) []byte {
+	return argon2.IDKey([]byte(auth), header.Salt, header.Time, header.Memory,
+		uint8(header.Threads), argon2idKeyLength)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func validateArgon2id(header *KDFHeader) error {
+	if len(header.Salt) == 0 || header.Time == 0 || header.Memory == 0 ||
+		header.Threads == 0 || header.Threads > 255 {
+		return ErrInvalidKDFParameters
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package keychain
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/stretchr/testify/assert"
+)
+
+var veryLightArgon2idParams = &Argon2idParameters{Time: 1, Memory: 8, Threads: 1}
+
+func TestFromHeader_ok(t *testing.T) {
+	// check missing & scrypt headers give scrypt KDF
+	for _, header := range []*KDFHeader{nil, {}, {Algorithm: ScryptAlgorithm}} {
+		kdf, err := FromHeader(header)
+		assert.Nil(t, err)
+		assert.IsType(t, &scryptKDF{}, kdf)
+	}
+
+	kdf1, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+	kdf2, err := FromHeader(kdf1.Header())
+	assert.Nil(t, err)
+	assert.Equal(t, kdf1.Header(), kdf2.Header())
+}
+
+func TestFromHeader_err(t *testing.T) {
+	kdf, err := FromHeader(&KDFHeader{Algorithm: "bcrypt"})
+	assert.Equal(t, ErrUnknownKDF, err)
+	assert.Nil(t, kdf)
+
+	kdf, err = FromHeader(&KDFHeader{Algorithm: Argon2idAlgorithm})
+	assert.Equal(t, ErrInvalidKDFParameters, err)
+	assert.Nil(t, kdf)
+}
+
+func TestRenewKDF_err(t *testing.T) {
+	// check unknown KDF errors
+	kdf, err := renewKDF(&StoredKeychain{Kdf: &KDFHeader{Algorithm: "other"}})
+	assert.Equal(t, ErrUnknownKDF, err)
+	assert.Nil(t, kdf)
+
+	// check bad encrypted scrypt key errors
+	kdf, err = renewKDF(&StoredKeychain{PrivateKeys: [][]byte{[]byte("not JSON")}})
+	assert.NotNil(t, err)
+	assert.Nil(t, kdf)
+}
+
+func TestNewArgon2idKDF(t *testing.T) {
+	kdf1, err := NewArgon2idKDF(NewDefaultArgon2idParameters())
+	assert.Nil(t, err)
+	header := kdf1.Header()
+	assert.Equal(t, Argon2idAlgorithm, header.Algorithm)
+	assert.Len(t, header.Salt, argon2idSaltLength)
+	assert.Equal(t, uint32(DefaultArgon2idTime), header.Time)
+	assert.Equal(t, uint32(DefaultArgon2idMemory), header.Memory)
+	assert.Equal(t, uint32(DefaultArgon2idThreads), header.Threads)
+
+	// check salt is random
+	kdf2, err := NewArgon2idKDF(NewDefaultArgon2idParameters())
+	assert.Nil(t, err)
+	assert.NotEqual(t, header.Salt, kdf2.Header().Salt)
+
+	// check zero parameters error
+	kdf3, err := NewArgon2idKDF(&Argon2idParameters{})
+	assert.Equal(t, ErrInvalidKDFParameters, err)
+	assert.Nil(t, kdf3)
+}
+
+func TestArgon2idKDF_EncryptDecrypt_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := ecid.NewPseudoRandom(rng).Key()
+	kdf, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+
+	encrypted, err := kdf.Encrypt(key, "test passphrase")
+	assert.Nil(t, err)
+	decrypted, err := kdf.Decrypt(encrypted, "test passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, key.D, decrypted.D)
+	assert.Equal(t, key.PublicKey.X, decrypted.PublicKey.X)
+	assert.Equal(t, key.PublicKey.Y, decrypted.PublicKey.Y)
+
+	// check recreated KDF decrypts too
+	kdf2, err := FromHeader(kdf.Header())
+	assert.Nil(t, err)
+	decrypted, err = kdf2.Decrypt(encrypted, "test passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, key.D, decrypted.D)
+}
+
+func TestArgon2idKDF_Decrypt_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := ecid.NewPseudoRandom(rng).Key()
+	kdf, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+	encrypted, err := kdf.Encrypt(key, "test passphrase")
+	assert.Nil(t, err)
+
+	// check wrong passphrase errors
+	decrypted, err := kdf.Decrypt(encrypted, "wrong passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, decrypted)
+
+	// check different salt errors
+	other, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+	decrypted, err = other.Decrypt(encrypted, "test passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, decrypted)
+
+	// check truncated ciphertext errors
+	decrypted, err = kdf.Decrypt(encrypted[:4], "test passphrase")
+	assert.Equal(t, ErrCiphertextTooShort, err)
+	assert.Nil(t, decrypted)
+}
+
+func TestNewAEAD(t *testing.T) {
+	kdf, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+	plaintext := []byte("some plaintext")
+	nonce := make([]byte, 12)
+
+	aead1, err := NewAEAD(kdf.Header(), "some auth")
+	assert.Nil(t, err)
+	ciphertext := aead1.Seal(nil, nonce, plaintext, nil)
+
+	// check same header and passphrase decrypts
+	aead2, err := NewAEAD(kdf.Header(), "some auth")
+	assert.Nil(t, err)
+	decrypted, err := aead2.Open(nil, nonce, ciphertext, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	// check different passphrase doesn't decrypt
+	aead3, err := NewAEAD(kdf.Header(), "other auth")
+	assert.Nil(t, err)
+	decrypted, err = aead3.Open(nil, nonce, ciphertext, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, decrypted)
+
+	// check non-Argon2id header creates error
+	aead4, err := NewAEAD(&KDFHeader{Algorithm: ScryptAlgorithm}, "some auth")
+	assert.Equal(t, ErrUnknownKDF, err)
+	assert.Nil(t, aead4)
+
+	// check invalid parameters create error
+	aead5, err := NewAEAD(&KDFHeader{Algorithm: Argon2idAlgorithm}, "some auth")
+	assert.Equal(t, ErrInvalidKDFParameters, err)
+	assert.Nil(t, aead5)
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"os"
+	"path"
 	"sort"
+	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/golang/protobuf/proto"
@@ -37,6 +40,35 @@ var (
 	ErrUnexpectedMissingKey = errors.New("missing key")
 )
 
+// KeyUsage defines bit flags of how a key may be used.
+type KeyUsage uint32
+
+const (
+	// AuthorUsage indicates that a key may be used as an envelope author key.
+	AuthorUsage KeyUsage = 1 << iota
+
+	// ReaderUsage indicates that a key may be used as an envelope reader key.
+	ReaderUsage
+)
+
+// Has returns whether the usage includes all of the given flags.
+func (u KeyUsage) Has(flags KeyUsage) bool {
+	return u&flags == flags
+}
+
+// Metadata describes a key in a keychain.
+type Metadata struct {
+	// Created is when the key was created, which is zero when unknown, e.g., for keys saved
+	// before key metadata existed.
+	Created time.Time
+
+	// Label is an optional human-readable label.
+	Label string
+
+	// Usage indicates how the key may be used, which is zero when unspecified.
+	Usage KeyUsage
+}
+
 // Keychain is a collection of ECDSA keys.
 type Keychain interface {
 	// Sample randomly selects a key.
@@ -48,6 +80,24 @@ type Keychain interface {
 
 	// Len returns the number of keys in the keychain.
 	Len() int
+
+	// Merge returns a new keychain with the keys in either this or the other keychain.
+	Merge(other Keychain) Keychain
+
+	// Subset returns a new keychain with only the keys with the given public keys. It returns
+	// ErrUnexpectedMissingKey if any of the keys isn't in the keychain.
+	Subset(publicKeys [][]byte) (Keychain, error)
+
+	// PublicKeys returns the sorted public keys of the keys in the keychain.
+	PublicKeys() [][]byte
+
+	// Metadata returns the metadata of the key with the given public key, if it exists. The
+	// second return value indicates whether the key is present in the keychain or not.
+	Metadata(publicKey []byte) (Metadata, bool)
+
+	// SetMetadata sets the metadata of the key with the given public key. It returns
+	// ErrUnexpectedMissingKey if the key isn't in the keychain.
+	SetMetadata(publicKey []byte, md Metadata) error
 }
 
 // Keychain represents a collection of ECDSA private keys.
@@ -58,6 +108,9 @@ type keychain struct {
 	// hex 65-byte public key representations
 	pubs []string
 
+	// key metadata indexed by the hex of the 65-byte public key representation
+	metadata map[string]Metadata
+
 	// random number generator for sampling keys
 	rng *rand.Rand
 }
@@ -68,10 +121,22 @@ func New(n int) Keychain {
 	for i := 0; i < n; i++ {
 		ecids[i] = ecid.NewRandom()
 	}
-	return FromECIDs(ecids)
+	return newCreated(ecids)
 }
 
-// FromECIDs creates a Keychain instance from a map of ECDSA private keys.
+// newCreated creates a Keychain instance from newly created ECDSA private keys, whose creation
+// time is now.
+func newCreated(ecids []ecid.ID) Keychain {
+	kc := FromECIDs(ecids).(*keychain)
+	created := time.Unix(time.Now().Unix(), 0) // matches precision when stored
+	for _, pub := range kc.pubs {
+		kc.metadata[pub] = Metadata{Created: created}
+	}
+	return kc
+}
+
+// FromECIDs creates a Keychain instance from a map of ECDSA private keys, whose metadata is
+// empty.
 func FromECIDs(ecids []ecid.ID) Keychain {
 	pubs := make([]string, len(ecids))
 	privs := make(map[string]ecid.ID)
@@ -81,9 +146,10 @@ func FromECIDs(ecids []ecid.ID) Keychain {
 	}
 	sort.Strings(pubs)
 	return &keychain{
-		privs: privs,
-		pubs:  pubs,
-		rng:   rand.New(rand.NewSource(int64(len(privs)))),
+		privs:    privs,
+		pubs:     pubs,
+		metadata: make(map[string]Metadata),
+		rng:      rand.New(rand.NewSource(int64(len(privs)))),
 	}
 }
 
@@ -105,9 +171,81 @@ func (kc *keychain) Len() int {
 	return len(kc.pubs)
 }
 
-// Save saves and encrypts a keychain to a file.
+func (kc *keychain) Merge(other Keychain) Keychain {
+	ecids := sortedKeys(kc)
+	for _, key := range sortedKeys(other) {
+		if _, in := kc.Get(ecid.ToPublicKeyBytes(key)); !in {
+			ecids = append(ecids, key)
+		}
+	}
+	merged := FromECIDs(ecids).(*keychain)
+	for _, kc1 := range []Keychain{other, kc} {
+		// keys in both keychains get this keychain's metadata
+		for _, pub := range kc1.PublicKeys() {
+			if md, _ := kc1.Metadata(pub); md != (Metadata{}) {
+				merged.metadata[pubKeyString(pub)] = md
+			}
+		}
+	}
+	return merged
+}
+
+func (kc *keychain) Subset(publicKeys [][]byte) (Keychain, error) {
+	ecids := make([]ecid.ID, 0, len(publicKeys))
+	added := make(map[string]struct{})
+	for _, publicKey := range publicKeys {
+		key, in := kc.Get(publicKey)
+		if !in {
+			return nil, ErrUnexpectedMissingKey
+		}
+		if _, in := added[pubKeyString(publicKey)]; !in {
+			ecids = append(ecids, key)
+			added[pubKeyString(publicKey)] = struct{}{}
+		}
+	}
+	subset := FromECIDs(ecids).(*keychain)
+	for pub := range added {
+		if md, in := kc.metadata[pub]; in {
+			subset.metadata[pub] = md
+		}
+	}
+	return subset, nil
+}
+
+func (kc *keychain) PublicKeys() [][]byte {
+	pubs := make([][]byte, len(kc.pubs))
+	for i, pub := range kc.pubs {
+		pubs[i] = ecid.ToPublicKeyBytes(kc.privs[pub])
+	}
+	return pubs
+}
+
+func (kc *keychain) Metadata(publicKey []byte) (Metadata, bool) {
+	pub := pubKeyString(publicKey)
+	if _, in := kc.privs[pub]; !in {
+		return Metadata{}, false
+	}
+	return kc.metadata[pub], true
+}
+
+func (kc *keychain) SetMetadata(publicKey []byte, md Metadata) error {
+	pub := pubKeyString(publicKey)
+	if _, in := kc.privs[pub]; !in {
+		return ErrUnexpectedMissingKey
+	}
+	kc.metadata[pub] = md
+	return nil
+}
+
+// Save saves and encrypts a keychain to a file using scrypt.
 func Save(filepath, auth string, kc Keychain, scryptN, scryptP int) error {
-	stored, err := encryptToStored(kc, auth, scryptN, scryptP)
+	return SaveWithKDF(filepath, auth, kc, NewScryptKDF(scryptN, scryptP))
+}
+
+// SaveWithKDF saves and encrypts a keychain to a file using the given KDF, whose header is saved
+// with the keychain so Load can decrypt it.
+func SaveWithKDF(filepath, auth string, kc Keychain, kdf KDF) error {
+	stored, err := encryptToStored(kc, auth, kdf)
 	if err != nil {
 		return err
 	}
@@ -119,8 +257,57 @@ func Save(filepath, auth string, kc Keychain, scryptN, scryptP int) error {
 	return ioutil.WriteFile(filepath, buf, filePerm)
 }
 
-// Load loads and decrypts a keychain from a file.
+// Load loads and decrypts a keychain from a file, using the KDF described by its header. Files
+// with older format versions are migrated in memory, but the file itself is left unchanged (see
+// Migrate).
 func Load(filepath, auth string) (Keychain, error) {
+	stored, err := loadStored(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return decryptFromStored(stored, auth)
+}
+
+// ChangeAuth changes the passphrase of a keychain file from oldAuth to newAuth. The keys are
+// re-encrypted with the file's KDF difficulty but a fresh salt, and the new file atomically
+// replaces the old one, so the file is never left partially written.
+func ChangeAuth(filepath, oldAuth, newAuth string) error {
+	stored, err := loadStored(filepath)
+	if err != nil {
+		return err
+	}
+	kc, err := decryptFromStored(stored, oldAuth)
+	if err != nil {
+		return err
+	}
+	kdf, err := renewKDF(stored)
+	if err != nil {
+		return err
+	}
+	renewed, err := encryptToStored(kc, newAuth, kdf)
+	if err != nil {
+		return err
+	}
+	buf, err := proto.Marshal(renewed)
+	if err != nil {
+		return err
+	}
+	return replaceFile(filepath, buf)
+}
+
+// loadStored reads the stored keychain from the file and migrates it to the current version.
+func loadStored(filepath string) (*StoredKeychain, error) {
+	stored, err := readStored(filepath)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func readStored(filepath string) (*StoredKeychain, error) {
 	buf, err := ioutil.ReadFile(filepath)
 	if err != nil {
 		return nil, err
@@ -129,7 +316,31 @@ func Load(filepath, auth string) (Keychain, error) {
 	if err := proto.Unmarshal(buf, stored); err != nil {
 		return nil, err
 	}
-	return decryptFromStored(stored, auth)
+	return stored, nil
+}
+
+// replaceFile writes buf to a temporary file in the same directory and then renames it to
+// filepath, which is atomic on POSIX filesystems.
+func replaceFile(filepath string, buf []byte) error {
+	tmp, err := ioutil.TempFile(path.Dir(filepath), path.Base(filepath)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op after successful rename
+
+	// temp file already has only user read & write permissions
+	if _, err := tmp.Write(buf); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath)
 }
 
 func pubKeyString(pubKey []byte) string {
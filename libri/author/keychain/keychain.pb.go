@@ -10,6 +10,8 @@ It is generated from these files:
 
 It has these top-level messages:
 	StoredKeychain
+	KDFHeader
+	StoredKeyMetadata
 */
 package keychain
 
@@ -30,6 +32,15 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type StoredKeychain struct {
 	PrivateKeys [][]byte `protobuf:"bytes,1,rep,name=privateKeys,proto3" json:"privateKeys,omitempty"`
+	// KDF used to encrypt the private keys, which is scrypt when missing
+	Kdf *KDFHeader `protobuf:"bytes,2,opt,name=kdf" json:"kdf,omitempty"`
+	// metadata of each key, which is empty for keychains saved before key metadata existed
+	Metadata []*StoredKeyMetadata `protobuf:"bytes,3,rep,name=metadata" json:"metadata,omitempty"`
+	// version of the keychain file format, which is 1 when missing
+	Version uint32 `protobuf:"varint,4,opt,name=version" json:"version,omitempty"`
+	// 65-byte public keys of the private keys, in the same order, which may be missing for files
+	// saved before version 3
+	PublicKeys [][]byte `protobuf:"bytes,5,rep,name=publicKeys,proto3" json:"publicKeys,omitempty"`
 }
 
 func (m *StoredKeychain) Reset()                    { *m = StoredKeychain{} }
@@ -44,19 +55,162 @@ func (m *StoredKeychain) GetPrivateKeys() [][]byte {
 	return nil
 }
 
+func (m *StoredKeychain) GetKdf() *KDFHeader {
+	if m != nil {
+		return m.Kdf
+	}
+	return nil
+}
+
+func (m *StoredKeychain) GetMetadata() []*StoredKeyMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *StoredKeychain) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *StoredKeychain) GetPublicKeys() [][]byte {
+	if m != nil {
+		return m.PublicKeys
+	}
+	return nil
+}
+
+// KDFHeader describes the key derivation function (KDF) and parameters used to derive the key
+// encrypting a keychain's private keys from its passphrase.
+type KDFHeader struct {
+	// name of the KDF algorithm
+	Algorithm string `protobuf:"bytes,1,opt,name=algorithm" json:"algorithm,omitempty"`
+	// random salt for the derived key
+	Salt []byte `protobuf:"bytes,2,opt,name=salt,proto3" json:"salt,omitempty"`
+	// number of passes over the memory
+	Time uint32 `protobuf:"varint,3,opt,name=time" json:"time,omitempty"`
+	// memory used, in KiB
+	Memory uint32 `protobuf:"varint,4,opt,name=memory" json:"memory,omitempty"`
+	// number of threads used
+	Threads uint32 `protobuf:"varint,5,opt,name=threads" json:"threads,omitempty"`
+}
+
+func (m *KDFHeader) Reset()                    { *m = KDFHeader{} }
+func (m *KDFHeader) String() string            { return proto.CompactTextString(m) }
+func (*KDFHeader) ProtoMessage()               {}
+func (*KDFHeader) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *KDFHeader) GetAlgorithm() string {
+	if m != nil {
+		return m.Algorithm
+	}
+	return ""
+}
+
+func (m *KDFHeader) GetSalt() []byte {
+	if m != nil {
+		return m.Salt
+	}
+	return nil
+}
+
+func (m *KDFHeader) GetTime() uint32 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *KDFHeader) GetMemory() uint32 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *KDFHeader) GetThreads() uint32 {
+	if m != nil {
+		return m.Threads
+	}
+	return 0
+}
+
+// StoredKeyMetadata is the (unencrypted) metadata of a key in a keychain.
+type StoredKeyMetadata struct {
+	// 65-byte public key of the key the metadata describes
+	PublicKey []byte `protobuf:"bytes,1,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+	// creation time of the key, in seconds since the epoch
+	Created int64 `protobuf:"varint,2,opt,name=created" json:"created,omitempty"`
+	// human-readable label of the key
+	Label string `protobuf:"bytes,3,opt,name=label" json:"label,omitempty"`
+	// bit flags of how the key may be used
+	Usage uint32 `protobuf:"varint,4,opt,name=usage" json:"usage,omitempty"`
+}
+
+func (m *StoredKeyMetadata) Reset()                    { *m = StoredKeyMetadata{} }
+func (m *StoredKeyMetadata) String() string            { return proto.CompactTextString(m) }
+func (*StoredKeyMetadata) ProtoMessage()               {}
+func (*StoredKeyMetadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *StoredKeyMetadata) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *StoredKeyMetadata) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+func (m *StoredKeyMetadata) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *StoredKeyMetadata) GetUsage() uint32 {
+	if m != nil {
+		return m.Usage
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*StoredKeychain)(nil), "keychain.StoredKeychain")
+	proto.RegisterType((*KDFHeader)(nil), "keychain.KDFHeader")
+	proto.RegisterType((*StoredKeyMetadata)(nil), "keychain.StoredKeyMetadata")
 }
 
 func init() { proto.RegisterFile("libri/author/keychain/keychain.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 101 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x52, 0xc9, 0xc9, 0x4c, 0x2a,
-	0xca, 0xd4, 0x4f, 0x2c, 0x2d, 0xc9, 0xc8, 0x2f, 0xd2, 0xcf, 0x4e, 0xad, 0x4c, 0xce, 0x48, 0xcc,
-	0xcc, 0x83, 0x33, 0xf4, 0x0a, 0x8a, 0xf2, 0x4b, 0xf2, 0x85, 0x38, 0x60, 0x7c, 0x25, 0x23, 0x2e,
-	0xbe, 0xe0, 0x92, 0xfc, 0xa2, 0xd4, 0x14, 0x6f, 0xa8, 0x88, 0x90, 0x02, 0x17, 0x77, 0x41, 0x51,
-	0x66, 0x59, 0x62, 0x49, 0xaa, 0x77, 0x6a, 0x65, 0xb1, 0x04, 0xa3, 0x02, 0xb3, 0x06, 0x4f, 0x10,
-	0xb2, 0x50, 0x12, 0x1b, 0xd8, 0x10, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0x04, 0xa8, 0x22,
-	0x2a, 0x6c, 0x00, 0x00, 0x00,
+	// 310 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x91, 0xc1, 0x4a, 0x03, 0x31,
+	0x10, 0x86, 0x89, 0xdb, 0xd6, 0xee, 0xb4, 0x0a, 0x46, 0x91, 0x80, 0x22, 0x4b, 0x51, 0xd8, 0x53,
+	0x0b, 0xf5, 0xe0, 0x0b, 0x88, 0x08, 0xc5, 0x4b, 0x7c, 0x82, 0xd9, 0xee, 0xd8, 0x0d, 0xdd, 0x6d,
+	0x4a, 0x36, 0xad, 0xf4, 0xec, 0xeb, 0xf9, 0x50, 0x92, 0x34, 0x4d, 0x0b, 0xde, 0xe6, 0xff, 0x32,
+	0xcc, 0x7c, 0x43, 0xe0, 0xb1, 0x56, 0x85, 0x51, 0x13, 0xdc, 0xd8, 0x4a, 0x9b, 0xc9, 0x92, 0x76,
+	0xf3, 0x0a, 0xd5, 0x2a, 0x16, 0xe3, 0xb5, 0xd1, 0x56, 0xf3, 0xfe, 0x21, 0x8f, 0x7e, 0x19, 0x5c,
+	0x7e, 0x5a, 0x6d, 0xa8, 0x9c, 0x05, 0xc4, 0x33, 0x18, 0xac, 0x8d, 0xda, 0xa2, 0xa5, 0x19, 0xed,
+	0x5a, 0xc1, 0xb2, 0x24, 0x1f, 0xca, 0x53, 0xc4, 0x9f, 0x20, 0x59, 0x96, 0x5f, 0xe2, 0x2c, 0x63,
+	0xf9, 0x60, 0x7a, 0x3d, 0x8e, 0xc3, 0x67, 0xaf, 0x6f, 0xef, 0x84, 0x25, 0x19, 0xe9, 0xde, 0xf9,
+	0x0b, 0xf4, 0x1b, 0xb2, 0x58, 0xa2, 0x45, 0x91, 0x64, 0x49, 0x3e, 0x98, 0xde, 0x1d, 0x7b, 0xe3,
+	0xd2, 0x8f, 0xd0, 0x22, 0x63, 0x33, 0x17, 0x70, 0xbe, 0x25, 0xd3, 0x2a, 0xbd, 0x12, 0x9d, 0x8c,
+	0xe5, 0x17, 0xf2, 0x10, 0xf9, 0x03, 0xc0, 0x7a, 0x53, 0xd4, 0x6a, 0xee, 0xd5, 0xba, 0x5e, 0xed,
+	0x84, 0x8c, 0x7e, 0x18, 0xa4, 0xd1, 0x82, 0xdf, 0x43, 0x8a, 0xf5, 0x42, 0x1b, 0x65, 0xab, 0x46,
+	0xb0, 0x8c, 0xe5, 0xa9, 0x3c, 0x02, 0xce, 0xa1, 0xd3, 0x62, 0x6d, 0xfd, 0x19, 0x43, 0xe9, 0x6b,
+	0xc7, 0xac, 0x6a, 0x48, 0x24, 0x7e, 0xad, 0xaf, 0xf9, 0x2d, 0xf4, 0x1a, 0x6a, 0xb4, 0xd9, 0x05,
+	0x99, 0x90, 0x9c, 0xa5, 0xad, 0x0c, 0x61, 0xe9, 0x44, 0xbc, 0x65, 0x88, 0xa3, 0x6f, 0xb8, 0xfa,
+	0x77, 0x9e, 0x93, 0x89, 0xa2, 0x5e, 0x66, 0x28, 0x8f, 0xc0, 0x0d, 0x9b, 0x1b, 0x42, 0x4b, 0xa5,
+	0xf7, 0x49, 0xe4, 0x21, 0xf2, 0x1b, 0xe8, 0xd6, 0x58, 0x50, 0xed, 0x9d, 0x52, 0xb9, 0x0f, 0x8e,
+	0x6e, 0x5a, 0x5c, 0x50, 0x70, 0xda, 0x87, 0xa2, 0xe7, 0xbf, 0xf7, 0xf9, 0x6f, 0x00, 0xc0, 0x7c,
+	0x96, 0x50, 0x06, 0x02, 0x00, 0x00,
 }
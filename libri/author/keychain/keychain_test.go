@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,124 @@ func TestKeychain_Len(t *testing.T) {
 	assert.Equal(t, 3, kc.Len())
 }
 
+func TestKeychain_Merge(t *testing.T) {
+	kc1, kc2 := New(3), New(2)
+	key1, err := kc1.Sample()
+	assert.Nil(t, err)
+
+	merged := kc1.Merge(kc2)
+	assert.Equal(t, 5, merged.Len())
+	for _, key := range append(sortedKeys(kc1), sortedKeys(kc2)...) {
+		_, in := merged.Get(ecid.ToPublicKeyBytes(key))
+		assert.True(t, in)
+	}
+
+	// check merging keychains with shared keys doesn't duplicate them
+	assert.Equal(t, 3, kc1.Merge(kc1).Len())
+	assert.Equal(t, 3, kc1.Merge(FromECIDs([]ecid.ID{key1})).Len())
+	assert.Equal(t, 3, kc1.Merge(New(0)).Len())
+
+	// check merging keeps metadata, preferring this keychain's
+	pub1 := ecid.ToPublicKeyBytes(key1)
+	assert.Nil(t, kc1.SetMetadata(pub1, Metadata{Label: "key 1"}))
+	other := FromECIDs([]ecid.ID{key1})
+	assert.Nil(t, other.SetMetadata(pub1, Metadata{Label: "other key 1"}))
+	md, in := kc1.Merge(other).Metadata(pub1)
+	assert.True(t, in)
+	assert.Equal(t, "key 1", md.Label)
+	md, in = other.Merge(kc2).Metadata(pub1)
+	assert.True(t, in)
+	assert.Equal(t, "other key 1", md.Label)
+
+	// check merging doesn't modify either keychain
+	assert.Equal(t, 3, kc1.Len())
+	assert.Equal(t, 2, kc2.Len())
+}
+
+func TestKeychain_Subset_ok(t *testing.T) {
+	kc := New(5)
+	keys := sortedKeys(kc)
+	pubKeys := [][]byte{
+		ecid.ToPublicKeyBytes(keys[0]),
+		ecid.ToPublicKeyBytes(keys[2]),
+		ecid.ToPublicKeyBytes(keys[2]),
+	}
+	subset, err := kc.Subset(pubKeys)
+	assert.Nil(t, err)
+	assert.Equal(t, []ecid.ID{keys[0], keys[2]}, sortedKeys(subset))
+
+	// check subset keeps metadata
+	md, in := kc.Metadata(pubKeys[0])
+	assert.True(t, in)
+	subsetMD, in := subset.Metadata(pubKeys[0])
+	assert.True(t, in)
+	assert.Equal(t, md, subsetMD)
+
+	subset, err = kc.Subset(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, subset.Len())
+}
+
+func TestKeychain_Subset_err(t *testing.T) {
+	kc := New(3)
+	missing, err := New(1).Sample()
+	assert.Nil(t, err)
+	subset, err := kc.Subset([][]byte{ecid.ToPublicKeyBytes(missing)})
+	assert.Equal(t, ErrUnexpectedMissingKey, err)
+	assert.Nil(t, subset)
+}
+
+func TestKeychain_PublicKeys(t *testing.T) {
+	kc := New(3)
+	pubKeys := kc.PublicKeys()
+	assert.Len(t, pubKeys, 3)
+	for i, key := range sortedKeys(kc) {
+		assert.Equal(t, ecid.ToPublicKeyBytes(key), pubKeys[i])
+	}
+	assert.Empty(t, New(0).PublicKeys())
+}
+
+func TestKeychain_Metadata(t *testing.T) {
+	kc := New(3)
+	pub := kc.PublicKeys()[0]
+
+	// check new keys have creation time
+	md, in := kc.Metadata(pub)
+	assert.True(t, in)
+	assert.False(t, md.Created.IsZero())
+	assert.Zero(t, md.Label)
+	assert.Zero(t, md.Usage)
+
+	// check keys from ECIDs have empty metadata
+	md, in = FromECIDs(sortedKeys(kc)).Metadata(pub)
+	assert.True(t, in)
+	assert.Equal(t, Metadata{}, md)
+
+	// check set metadata is returned
+	md = Metadata{Created: md.Created, Label: "some label", Usage: AuthorUsage | ReaderUsage}
+	assert.Nil(t, kc.SetMetadata(pub, md))
+	md2, in := kc.Metadata(pub)
+	assert.True(t, in)
+	assert.Equal(t, md, md2)
+
+	// check missing key
+	missing := New(1).PublicKeys()[0]
+	md, in = kc.Metadata(missing)
+	assert.False(t, in)
+	assert.Equal(t, Metadata{}, md)
+	assert.Equal(t, ErrUnexpectedMissingKey, kc.SetMetadata(missing, md))
+}
+
+func TestKeyUsage_Has(t *testing.T) {
+	usage := AuthorUsage | ReaderUsage
+	assert.True(t, usage.Has(AuthorUsage))
+	assert.True(t, usage.Has(ReaderUsage))
+	assert.True(t, usage.Has(AuthorUsage|ReaderUsage))
+	assert.False(t, ReaderUsage.Has(AuthorUsage))
+	assert.False(t, ReaderUsage.Has(AuthorUsage|ReaderUsage))
+	assert.True(t, ReaderUsage.Has(0))
+}
+
 func TestSave_err(t *testing.T) {
 	file, err := ioutil.TempFile("", "kechain-test")
 	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
@@ -82,4 +201,120 @@ func TestSaveLoad(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, kc3)
 
+	// check keychain saved with Argon2id loads with its header
+	kdf, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+	err = SaveWithKDF(file.Name(), auth, kc1, kdf)
+	assert.Nil(t, err)
+
+	kc4, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1, kc4)
+
+	kc5, err := Load(file.Name(), "wrong passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, kc5)
+
+	// check key metadata saves and loads
+	pub := kc1.PublicKeys()[0]
+	assert.Nil(t, kc1.SetMetadata(pub, Metadata{
+		Created: time.Unix(1500000000, 0),
+		Label:   "some label",
+		Usage:   ReaderUsage,
+	}))
+	err = Save(file.Name(), auth, kc1, veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	kc8, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1, kc8)
+
+	// check merged and subset keychains save and load
+	merged := kc1.Merge(New(2))
+	err = Save(file.Name(), auth, merged, veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	kc6, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, merged, kc6)
+
+	subset, err := merged.Subset([][]byte{ecid.ToPublicKeyBytes(sortedKeys(merged)[1])})
+	assert.Nil(t, err)
+	err = Save(file.Name(), auth, subset, veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	kc7, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, subset, kc7)
+}
+
+func TestChangeAuth_ok(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	scryptKDF := NewScryptKDF(veryLightScryptN, veryLightScryptP)
+	argon2idKDF, err := NewArgon2idKDF(veryLightArgon2idParams)
+	assert.Nil(t, err)
+	for _, kdf := range []KDF{scryptKDF, argon2idKDF} {
+		kc1, oldAuth, newAuth := New(3), "old passphrase", "new passphrase"
+		err = SaveWithKDF(file.Name(), oldAuth, kc1, kdf)
+		assert.Nil(t, err)
+
+		err = ChangeAuth(file.Name(), oldAuth, newAuth)
+		assert.Nil(t, err)
+
+		// check keychain only loads with new passphrase
+		kc2, err := Load(file.Name(), newAuth)
+		assert.Nil(t, err)
+		assert.Equal(t, kc1, kc2)
+		kc3, err := Load(file.Name(), oldAuth)
+		assert.NotNil(t, err)
+		assert.Nil(t, kc3)
+
+		// check KDF difficulty kept but salt changed
+		stored, err := loadStored(file.Name())
+		assert.Nil(t, err)
+		if kdf.Header() != nil {
+			assert.NotEqual(t, kdf.Header().Salt, stored.Kdf.Salt)
+			assert.Equal(t, kdf.Header().Memory, stored.Kdf.Memory)
+		} else {
+			scryptN, scryptP, err := scryptParams(stored.PrivateKeys[0])
+			assert.Nil(t, err)
+			assert.Equal(t, veryLightScryptN, scryptN)
+			assert.Equal(t, veryLightScryptP, scryptP)
+		}
+
+		// check permissions preserved
+		info, err := os.Stat(file.Name())
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestChangeAuth_err(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	// check missing file errors
+	err = ChangeAuth(file.Name()+"-missing", "old passphrase", "new passphrase")
+	assert.NotNil(t, err)
+
+	// check wrong old passphrase errors and leaves file unchanged
+	kc1, auth := New(3), "test passphrase"
+	err = Save(file.Name(), auth, kc1, veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	buf1, err := ioutil.ReadFile(file.Name())
+	assert.Nil(t, err)
+	err = ChangeAuth(file.Name(), "wrong passphrase", "new passphrase")
+	assert.NotNil(t, err)
+	buf2, err := ioutil.ReadFile(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, buf1, buf2)
+
+	// check empty scrypt keychain errors
+	err = Save(file.Name(), auth, New(0), veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	err = ChangeAuth(file.Name(), auth, "new passphrase")
+	assert.Equal(t, ErrEmptyKeychain, err)
 }
@@ -0,0 +1,336 @@
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/drausin/libri/libri/common/ecid"
+)
+
+// scalarWords is the number of words in a private key scalar.
+const scalarWords = (256 + wordBytes*8 - 1) / (wordBytes * 8)
+
+var (
+	// ErrKeychainClosed indicates when a locked keychain is used after it is closed.
+	ErrKeychainClosed = errors.New("keychain closed")
+
+	// ErrMismatchedPublicKey indicates when a decrypted private key doesn't have the public key
+	// stored with it.
+	ErrMismatchedPublicKey = errors.New("decrypted private key doesn't match its public key")
+
+	errScalarTooLarge = errors.New("private key scalar too large")
+)
+
+// LockedKeychain is a Keychain whose private keys are decrypted individually when first used and
+// then held in memory locked against being swapped to disk.
+type LockedKeychain interface {
+	Keychain
+
+	// Close zeroes the decrypted private keys and unlocks their memory. Afterwards, the keychain
+	// has no keys, and keys previously returned from it can no longer sign or decrypt.
+	Close() error
+}
+
+type lockedKeychain struct {
+	// hex 65-byte public key representations
+	pubs []string
+
+	// encrypted private keys not yet decrypted, indexed by hex public key
+	encrypted map[string][]byte
+
+	// decrypted private keys, indexed by hex public key
+	privs map[string]ecid.ID
+
+	// key metadata, indexed by hex public key
+	metadata map[string]Metadata
+
+	// decrypts the encrypted private keys
+	kdf KDF
+
+	// passphrase decrypting the private keys
+	auth *lockedBuffer
+
+	// scalars of the decrypted private keys
+	scalars *lockedBuffer
+
+	// number of private key scalars in scalars
+	nScalars int
+
+	// random number generator for sampling keys
+	rng *rand.Rand
+
+	closed bool
+	mu     sync.Mutex
+}
+
+// LoadLocked loads a keychain from a file like Load, except each private key is only decrypted
+// when first used, after which its scalar is kept in locked memory until Close zeroes it. Since the
+// KDFs and signing copy some key material to the Go heap while using it, this limits but doesn't
+// eliminate private key material that could be swapped to disk. Files saved before version 3 don't
+// store their public keys, so all their private keys are decrypted when loading.
+func LoadLocked(filepath, auth string) (LockedKeychain, error) {
+	stored, err := loadStored(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return decryptLockedFromStored(stored, auth)
+}
+
+func decryptLockedFromStored(stored *StoredKeychain, auth string) (LockedKeychain, error) {
+	kdf, err := FromHeader(stored.Kdf)
+	if err != nil {
+		return nil, err
+	}
+	authBuf, err := newLockedBuffer(len(auth))
+	if err != nil {
+		return nil, err
+	}
+	copy(authBuf.buf, auth)
+	scalars, err := newLockedBuffer(len(stored.PrivateKeys) * scalarWords * wordBytes)
+	if err != nil {
+		_ = authBuf.destroy()
+		return nil, err
+	}
+	kc := &lockedKeychain{
+		pubs:      make([]string, 0, len(stored.PrivateKeys)),
+		encrypted: make(map[string][]byte),
+		privs:     make(map[string]ecid.ID),
+		metadata:  make(map[string]Metadata),
+		kdf:       kdf,
+		auth:      authBuf,
+		scalars:   scalars,
+		rng:       rand.New(rand.NewSource(int64(len(stored.PrivateKeys)))),
+	}
+	if err := kc.addStored(stored, auth); err != nil {
+		_ = kc.Close()
+		return nil, err
+	}
+	sort.Strings(kc.pubs)
+	fromStoredMetadata(kc, stored.Metadata)
+	return kc, nil
+}
+
+// addStored adds the stored private keys to the keychain, leaving them encrypted when their public
+// keys are stored. It decrypts the first key to check the passphrase.
+func (kc *lockedKeychain) addStored(stored *StoredKeychain, auth string) error {
+	if len(stored.PublicKeys) != len(stored.PrivateKeys) {
+		// older versions without public keys must be decrypted to get them
+		plain, err := decryptFromStored(stored, auth)
+		if err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(plain) {
+			if err := kc.lock(key.Key()); err != nil {
+				return err
+			}
+			pub := pubKeyString(ecid.ToPublicKeyBytes(key))
+			kc.pubs = append(kc.pubs, pub)
+			kc.privs[pub] = key
+		}
+		return nil
+	}
+	for i, publicKey := range stored.PublicKeys {
+		pub := pubKeyString(publicKey)
+		kc.pubs = append(kc.pubs, pub)
+		kc.encrypted[pub] = stored.PrivateKeys[i]
+	}
+	if len(kc.pubs) > 0 {
+		if _, err := kc.get(kc.pubs[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (kc *lockedKeychain) Sample() (ecid.ID, error) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if kc.closed {
+		return nil, ErrKeychainClosed
+	}
+	if len(kc.pubs) == 0 {
+		return nil, ErrEmptyKeychain
+	}
+	i := kc.rng.Int31n(int32(len(kc.pubs)))
+	return kc.get(kc.pubs[i])
+}
+
+func (kc *lockedKeychain) Get(publicKey []byte) (ecid.ID, bool) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	key, err := kc.get(pubKeyString(publicKey))
+	return key, err == nil
+}
+
+func (kc *lockedKeychain) Len() int {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	return len(kc.pubs)
+}
+
+// Merge returns a new (unlocked) keychain with the keys in either this or the other keychain. Its
+// private keys from this keychain still use its locked memory, so they are also zeroed by Close.
+func (kc *lockedKeychain) Merge(other Keychain) Keychain {
+	kc.mu.Lock()
+	pubs := make([]string, 0, len(kc.pubs))
+	for _, pub := range kc.pubs {
+		if _, err := kc.get(pub); err == nil {
+			pubs = append(pubs, pub) // keys that fail to decrypt are omitted
+		}
+	}
+	plain, _ := kc.plain(pubs)
+	kc.mu.Unlock()
+	return plain.Merge(other)
+}
+
+// Subset returns a new (unlocked) keychain with only the keys with the given public keys. Like
+// with Merge, its private keys are zeroed when this keychain is closed.
+func (kc *lockedKeychain) Subset(publicKeys [][]byte) (Keychain, error) {
+	pubs := make([]string, len(publicKeys))
+	for i, publicKey := range publicKeys {
+		pubs[i] = pubKeyString(publicKey)
+	}
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	return kc.plain(pubs)
+}
+
+func (kc *lockedKeychain) PublicKeys() [][]byte {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	pubs := make([][]byte, len(kc.pubs))
+	for i, pub := range kc.pubs {
+		pubs[i], _ = hex.DecodeString(pub)
+	}
+	return pubs
+}
+
+func (kc *lockedKeychain) Metadata(publicKey []byte) (Metadata, bool) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	pub := pubKeyString(publicKey)
+	if !kc.has(pub) {
+		return Metadata{}, false
+	}
+	return kc.metadata[pub], true
+}
+
+func (kc *lockedKeychain) SetMetadata(publicKey []byte, md Metadata) error {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	pub := pubKeyString(publicKey)
+	if !kc.has(pub) {
+		return ErrUnexpectedMissingKey
+	}
+	kc.metadata[pub] = md
+	return nil
+}
+
+func (kc *lockedKeychain) Close() error {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if kc.closed {
+		return nil
+	}
+	for _, key := range kc.privs {
+		// detach scalar from locked memory before it's freed
+		key.Key().D.SetBits(nil)
+	}
+	kc.pubs, kc.encrypted, kc.privs = nil, nil, nil
+	kc.closed = true
+	authErr, scalarsErr := kc.auth.destroy(), kc.scalars.destroy()
+	if authErr != nil {
+		return authErr
+	}
+	return scalarsErr
+}
+
+func (kc *lockedKeychain) has(pub string) bool {
+	if _, in := kc.privs[pub]; in {
+		return true
+	}
+	_, in := kc.encrypted[pub]
+	return in
+}
+
+// get returns the key with the given hex public key, decrypting it if it hasn't been already.
+func (kc *lockedKeychain) get(pub string) (ecid.ID, error) {
+	if kc.closed {
+		return nil, ErrKeychainClosed
+	}
+	if key, in := kc.privs[pub]; in {
+		return key, nil
+	}
+	encrypted, in := kc.encrypted[pub]
+	if !in {
+		return nil, ErrUnexpectedMissingKey
+	}
+	priv, err := kc.kdf.Decrypt(encrypted, string(kc.auth.buf))
+	if err != nil {
+		return nil, err
+	}
+	key := ecid.FromPrivateKey(priv)
+	if pubKeyString(ecid.ToPublicKeyBytes(key)) != pub {
+		// check before locking, so repeatedly getting a bad key doesn't use up locked memory
+		zeroWords(priv.D.Bits())
+		return nil, ErrMismatchedPublicKey
+	}
+	if err := kc.lock(priv); err != nil {
+		return nil, err
+	}
+	kc.privs[pub] = key
+	delete(kc.encrypted, pub)
+	return key, nil
+}
+
+// lock moves the private key's scalar into the locked memory and zeroes its previous memory.
+func (kc *lockedKeychain) lock(priv *ecdsa.PrivateKey) error {
+	unlocked := priv.D.Bits()
+	if len(unlocked) > scalarWords {
+		return errScalarTooLarge
+	}
+	locked, err := kc.scalars.words(kc.nScalars*scalarWords, len(unlocked))
+	if err != nil {
+		return err
+	}
+	copy(locked, unlocked)
+	priv.D.SetBits(locked)
+	zeroWords(unlocked)
+	kc.nScalars++
+	return nil
+}
+
+func zeroWords(words []big.Word) {
+	for i := range words {
+		words[i] = 0
+	}
+}
+
+// plain returns a plain keychain with the keys with the given hex public keys.
+func (kc *lockedKeychain) plain(pubs []string) (Keychain, error) {
+	ecids := make([]ecid.ID, 0, len(pubs))
+	added := make(map[string]struct{})
+	for _, pub := range pubs {
+		if _, in := added[pub]; in {
+			continue
+		}
+		key, err := kc.get(pub)
+		if err != nil {
+			return nil, err
+		}
+		ecids = append(ecids, key)
+		added[pub] = struct{}{}
+	}
+	plain := FromECIDs(ecids).(*keychain)
+	for pub := range added {
+		if md, in := kc.metadata[pub]; in {
+			plain.metadata[pub] = md
+		}
+	}
+	return plain, nil
+}
@@ -0,0 +1,189 @@
+package keychain
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLocked_ok(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	kc1, auth := New(3), "test passphrase"
+	pub := kc1.PublicKeys()[0]
+	md := Metadata{Created: time.Unix(1500000000, 0), Label: "some label", Usage: ReaderUsage}
+	assert.Nil(t, kc1.SetMetadata(pub, md))
+	err = Save(file.Name(), auth, kc1, veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+
+	kc2, err := LoadLocked(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1.Len(), kc2.Len())
+	assert.Equal(t, kc1.PublicKeys(), kc2.PublicKeys())
+
+	// check only first key is decrypted when loading
+	lkc2 := kc2.(*lockedKeychain)
+	assert.Len(t, lkc2.privs, 1)
+	assert.Len(t, lkc2.encrypted, kc1.Len()-1)
+
+	// check keys are decrypted when first used
+	for _, pub := range kc1.PublicKeys() {
+		key1, _ := kc1.Get(pub)
+		key2, in := kc2.Get(pub)
+		assert.True(t, in)
+		assert.Equal(t, key1.Key().D, key2.Key().D)
+	}
+	assert.Len(t, lkc2.privs, kc1.Len())
+	assert.Len(t, lkc2.encrypted, 0)
+
+	key, err := kc2.Sample()
+	assert.Nil(t, err)
+	_, in := kc1.Get(ecid.ToPublicKeyBytes(key))
+	assert.True(t, in)
+
+	key, in = kc2.Get(New(1).PublicKeys()[0])
+	assert.False(t, in)
+	assert.Nil(t, key)
+
+	// check metadata is loaded and can be set
+	md2, in := kc2.Metadata(pub)
+	assert.True(t, in)
+	assert.Equal(t, md, md2)
+	md.Label = "another label"
+	assert.Nil(t, kc2.SetMetadata(pub, md))
+	md2, in = kc2.Metadata(pub)
+	assert.True(t, in)
+	assert.Equal(t, md, md2)
+	assert.Equal(t, ErrUnexpectedMissingKey, kc2.SetMetadata(New(1).PublicKeys()[0], md))
+
+	// check merged and subset keychains have the locked keys
+	merged := kc2.Merge(New(2))
+	assert.Equal(t, kc1.Len()+2, merged.Len())
+	md2, in = merged.Metadata(pub)
+	assert.True(t, in)
+	assert.Equal(t, md, md2)
+
+	subset, err := kc2.Subset([][]byte{pub, pub})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, subset.Len())
+	key, in = subset.Get(pub)
+	assert.True(t, in)
+
+	subset, err = kc2.Subset([][]byte{New(1).PublicKeys()[0]})
+	assert.Equal(t, ErrUnexpectedMissingKey, err)
+	assert.Nil(t, subset)
+
+	// check locked keychain saves and loads
+	err = Save(file.Name(), auth, kc2, veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	kc3, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, sortedKeys(kc1), sortedKeys(kc3))
+
+	// check closing zeroes keys and empties keychain
+	assert.Nil(t, kc2.Close())
+	assert.Zero(t, key.Key().D.Sign())
+	assert.Zero(t, kc2.Len())
+	key, err = kc2.Sample()
+	assert.Equal(t, ErrKeychainClosed, err)
+	assert.Nil(t, key)
+	key, in = kc2.Get(pub)
+	assert.False(t, in)
+	assert.Nil(t, key)
+	assert.Nil(t, kc2.Close())
+}
+
+func TestLoadLocked_noPublicKeys(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	// mimic keychain saved before public keys were stored
+	kc1, auth := New(3), "test passphrase"
+	stored, err := encryptToStored(kc1, auth, NewScryptKDF(veryLightScryptN, veryLightScryptP))
+	assert.Nil(t, err)
+	stored.PublicKeys, stored.Version = nil, KeychainVersion2
+	writeStored(t, file.Name(), stored)
+
+	// check all keys are decrypted when loading
+	kc2, err := LoadLocked(file.Name(), auth)
+	assert.Nil(t, err)
+	lkc2 := kc2.(*lockedKeychain)
+	assert.Len(t, lkc2.privs, kc1.Len())
+	assert.Len(t, lkc2.encrypted, 0)
+	assert.Equal(t, kc1.PublicKeys(), kc2.PublicKeys())
+	for _, pub := range kc1.PublicKeys() {
+		key1, _ := kc1.Get(pub)
+		key2, in := kc2.Get(pub)
+		assert.True(t, in)
+		assert.Equal(t, key1.Key().D, key2.Key().D)
+	}
+	assert.Nil(t, kc2.Close())
+}
+
+func TestLoadLocked_err(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	// check missing file errors
+	kc, err := LoadLocked(file.Name()+"-missing", "test passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, kc)
+
+	kc1, auth := New(3), "test passphrase"
+	stored, err := encryptToStored(kc1, auth, NewScryptKDF(veryLightScryptN, veryLightScryptP))
+	assert.Nil(t, err)
+	writeStored(t, file.Name(), stored)
+
+	// check wrong passphrase errors
+	kc, err = LoadLocked(file.Name(), "wrong passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, kc)
+
+	// check private key not matching its public key errors
+	stored.PublicKeys = append(stored.PublicKeys[1:], stored.PublicKeys[0])
+	writeStored(t, file.Name(), stored)
+	kc, err = LoadLocked(file.Name(), auth)
+	assert.Equal(t, ErrMismatchedPublicKey, err)
+	assert.Nil(t, kc)
+
+	// check wrong passphrase errors without public keys
+	stored.PublicKeys = nil
+	writeStored(t, file.Name(), stored)
+	kc, err = LoadLocked(file.Name(), "wrong passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, kc)
+}
+
+func TestLockedKeychain_Get_mismatchedPublicKey(t *testing.T) {
+	kc1, auth := New(3), "test passphrase"
+	stored, err := encryptToStored(kc1, auth, NewScryptKDF(veryLightScryptN, veryLightScryptP))
+	assert.Nil(t, err)
+
+	// swap public keys of last two private keys, leaving first one to check the passphrase
+	pks := stored.PublicKeys
+	pks[1], pks[2] = pks[2], pks[1]
+	kc2, err := decryptLockedFromStored(stored, auth)
+	assert.Nil(t, err)
+	lkc2 := kc2.(*lockedKeychain)
+	assert.Equal(t, 1, lkc2.nScalars)
+
+	// check repeatedly getting mismatched key neither returns it nor uses up locked memory
+	for i := 0; i < 4; i++ {
+		key, in := kc2.Get(pks[1])
+		assert.False(t, in)
+		assert.Nil(t, key)
+	}
+	assert.Equal(t, 1, lkc2.nScalars)
+	assert.Nil(t, kc2.Close())
+}
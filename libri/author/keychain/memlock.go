@@ -0,0 +1,63 @@
+package keychain
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+	"unsafe"
+)
+
+const (
+	wordBytes = bits.UintSize / 8
+
+	// maxLockedWords bounds the words of a locked buffer that can back big.Int values
+	maxLockedWords = 1 << 24
+)
+
+var (
+	// ErrMemoryLockUnsupported indicates when memory can't be locked on the current platform.
+	ErrMemoryLockUnsupported = errors.New("memory locking unsupported on this platform")
+
+	errLockedWordsOutOfRange = errors.New("words out of range of locked buffer")
+)
+
+// lockedBuffer is memory allocated outside of the Go heap and locked so that it is never swapped
+// to disk.
+type lockedBuffer struct {
+	// full locked memory, which has at least one byte
+	mem []byte
+
+	// requested bytes of the locked memory
+	buf []byte
+}
+
+func newLockedBuffer(size int) (*lockedBuffer, error) {
+	n := size
+	if n == 0 {
+		n = 1 // can't map empty memory
+	}
+	mem, err := allocLocked(n)
+	if err != nil {
+		return nil, err
+	}
+	return &lockedBuffer{mem: mem, buf: mem[:size]}, nil
+}
+
+// words returns the n words starting at word offset i of the buffer, which use its memory so
+// big.Int values set with them are never copied to the Go heap. The words must be within the
+// requested bytes of the buffer.
+func (b *lockedBuffer) words(i, n int) ([]big.Word, error) {
+	if i < 0 || n < 0 || i+n > len(b.buf)/wordBytes || i+n > maxLockedWords {
+		return nil, errLockedWordsOutOfRange
+	}
+	all := (*[maxLockedWords]big.Word)(unsafe.Pointer(&b.mem[0]))
+	return all[i : i+n : i+n], nil
+}
+
+// destroy zeroes, unlocks, and frees the buffer's memory, which must no longer be used.
+func (b *lockedBuffer) destroy() error {
+	for i := range b.mem {
+		b.mem[i] = 0
+	}
+	return freeLocked(b.mem)
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package keychain
+
+func allocLocked(n int) ([]byte, error) {
+	return nil, ErrMemoryLockUnsupported
+}
+
+func freeLocked(mem []byte) error {
+	return ErrMemoryLockUnsupported
+}
@@ -0,0 +1,45 @@
+package keychain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockedBuffer(t *testing.T) {
+	b, err := newLockedBuffer(4 * wordBytes)
+	if err == ErrMemoryLockUnsupported {
+		t.Skip(err)
+	}
+	assert.Nil(t, err)
+	assert.Len(t, b.buf, 4*wordBytes)
+
+	// check big.Int set with words uses the locked memory
+	words, err := b.words(1, 2)
+	assert.Nil(t, err)
+	words[0], words[1] = 1, 2
+	x := new(big.Int).SetBits(words)
+	zero := make([]byte, wordBytes)
+	assert.Equal(t, zero, b.buf[:wordBytes])
+	assert.NotEqual(t, zero, b.buf[wordBytes:2*wordBytes])
+	x.Bits()[0] = 0
+	assert.Equal(t, zero, b.buf[wordBytes:2*wordBytes])
+	assert.Len(t, x.Bits(), 2)
+	assert.Equal(t, 2, cap(x.Bits()))
+
+	// check words beyond the requested bytes can't be used
+	for _, in := range [][2]int{{3, 2}, {4, 1}, {-1, 1}, {0, -1}} {
+		words, err = b.words(in[0], in[1])
+		assert.Equal(t, errLockedWordsOutOfRange, err)
+		assert.Nil(t, words)
+	}
+
+	assert.Nil(t, b.destroy())
+
+	// check empty buffer can be allocated
+	b, err = newLockedBuffer(0)
+	assert.Nil(t, err)
+	assert.Len(t, b.buf, 0)
+	assert.Nil(t, b.destroy())
+}
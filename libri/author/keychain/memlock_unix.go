@@ -0,0 +1,28 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package keychain
+
+import "syscall"
+
+// allocLocked maps n bytes of anonymous memory and locks it into RAM.
+func allocLocked(n int) ([]byte, error) {
+	mem, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mlock(mem); err != nil {
+		_ = syscall.Munmap(mem)
+		return nil, err
+	}
+	return mem, nil
+}
+
+// freeLocked unlocks and unmaps memory from allocLocked.
+func freeLocked(mem []byte) error {
+	if err := syscall.Munlock(mem); err != nil {
+		return err
+	}
+	return syscall.Munmap(mem)
+}
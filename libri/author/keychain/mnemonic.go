@@ -0,0 +1,155 @@
+package keychain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MnemonicEntropyLength is the length of the entropy encoded by mnemonics created by
+	// NewMnemonic, which gives 24 words.
+	MnemonicEntropyLength = 32
+
+	// MinMnemonicEntropyLength is the minimum length of mnemonic entropy, which gives 12 words.
+	MinMnemonicEntropyLength = 16
+
+	// MaxMnemonicEntropyLength is the maximum length of mnemonic entropy, which gives 24 words.
+	MaxMnemonicEntropyLength = 32
+
+	// mnemonicEntropyStep is the length multiple of mnemonic entropy, where each additional step
+	// adds 3 words.
+	mnemonicEntropyStep = 4
+
+	// mnemonicWordBits is the number of bits each mnemonic word encodes.
+	mnemonicWordBits = 11
+
+	// mnemonicPBKDF2Iterations is the number of PBKDF2 iterations deriving a seed from a mnemonic.
+	mnemonicPBKDF2Iterations = 2048
+
+	// mnemonicSaltPrefix prefixes the passphrase in the PBKDF2 salt deriving a seed from a
+	// mnemonic.
+	mnemonicSaltPrefix = "mnemonic"
+)
+
+var (
+	// ErrInvalidEntropyLength indicates when mnemonic entropy isn't a multiple of 4 bytes within
+	// [MinMnemonicEntropyLength, MaxMnemonicEntropyLength].
+	ErrInvalidEntropyLength = errors.New("invalid mnemonic entropy length")
+
+	// ErrInvalidMnemonic indicates when a mnemonic has an invalid number of words or a word not
+	// in the wordlist.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrInvalidMnemonicChecksum indicates when a mnemonic's checksum doesn't match its entropy,
+	// usually because of a mistyped or misordered word.
+	ErrInvalidMnemonicChecksum = errors.New("invalid mnemonic checksum")
+)
+
+// englishWordIndices maps each englishWords word to its index.
+var englishWordIndices = func() map[string]int {
+	indices := make(map[string]int, len(englishWords))
+	for i, word := range englishWords {
+		indices[word] = i
+	}
+	return indices
+}()
+
+// NewMnemonic creates a new random BIP39 mnemonic for Restore, which users can write down as a
+// paper backup of their keychains.
+func NewMnemonic() (string, error) {
+	entropy := make([]byte, MnemonicEntropyLength)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic encodes the entropy as a BIP39 English mnemonic, whose final word includes a
+// checksum of the entropy.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy) < MinMnemonicEntropyLength || len(entropy) > MaxMnemonicEntropyLength ||
+		len(entropy)%mnemonicEntropyStep != 0 {
+		return "", ErrInvalidEntropyLength
+	}
+	nWords := (len(entropy)*8 + mnemonicChecksumBits(len(entropy))) / mnemonicWordBits
+	bits := withChecksum(entropy)
+	words := make([]string, nWords)
+	mask := big.NewInt(1<<mnemonicWordBits - 1)
+	index := new(big.Int)
+	for i := nWords - 1; i >= 0; i-- {
+		words[i] = englishWords[index.And(bits, mask).Int64()]
+		bits.Rsh(bits, mnemonicWordBits)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes the entropy from the BIP39 English mnemonic, checking its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	nBits := len(words) * mnemonicWordBits
+	entropyLen := nBits * 32 / 33 / 8 // each 32 bits of entropy have 1 checksum bit
+	if len(words)%3 != 0 || entropyLen < MinMnemonicEntropyLength ||
+		entropyLen > MaxMnemonicEntropyLength {
+		return nil, ErrInvalidMnemonic
+	}
+	bits := new(big.Int)
+	for _, word := range words {
+		index, in := englishWordIndices[strings.ToLower(word)]
+		if !in {
+			return nil, ErrInvalidMnemonic
+		}
+		bits.Lsh(bits, mnemonicWordBits)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+	entropy := make([]byte, entropyLen)
+	entropyBytes := new(big.Int).Rsh(bits, uint(mnemonicChecksumBits(entropyLen))).Bytes()
+	copy(entropy[entropyLen-len(entropyBytes):], entropyBytes)
+	if withChecksum(entropy).Cmp(bits) != 0 {
+		return nil, ErrInvalidMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+// MnemonicToSeed derives the 64-byte BIP39 seed from the mnemonic and (optional) passphrase, after
+// checking the mnemonic's checksum. Different passphrases give different seeds.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if _, err := MnemonicToEntropy(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+	salt := norm.NFKD.String(mnemonicSaltPrefix + passphrase)
+	return pbkdf2.Key([]byte(normalized), []byte(salt), mnemonicPBKDF2Iterations,
+		MaxSeedLength, sha512.New), nil
+}
+
+// Restore recreates the Keychain with n keys derived for the account from the seed of the
+// mnemonic and passphrase, so the keys can be recovered from a paper backup of the mnemonic.
+func Restore(mnemonic, passphrase string, account uint32, n int) (Keychain, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return FromSeed(seed, account, n)
+}
+
+// mnemonicChecksumBits returns the number of SHA256 checksum bits appended to the entropy, one
+// for each 32 bits.
+func mnemonicChecksumBits(entropyLen int) int {
+	return entropyLen * 8 / 32
+}
+
+// withChecksum returns the entropy bits followed by its checksum bits.
+func withChecksum(entropy []byte) *big.Int {
+	checksumBits := uint(mnemonicChecksumBits(len(entropy)))
+	checksum := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, checksumBits)
+	return bits.Or(bits, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+}
@@ -0,0 +1,166 @@
+package keychain
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// BIP39 test vectors, all of whose seeds use the passphrase "TREZOR"
+var mnemonicVectors = []struct {
+	entropy, mnemonic, seed string
+}{
+	{
+		entropy:  "00000000000000000000000000000000",
+		mnemonic: strings.Repeat("abandon ", 11) + "about",
+		seed: "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e5349553" +
+			"1f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		seed: "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6f" +
+			"a457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		entropy:  "80808080808080808080808080808080",
+		mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		seed: "d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30" +
+			"fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		seed: "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13" +
+			"332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+}
+
+func TestNewMnemonic(t *testing.T) {
+	mnemonic1, err := NewMnemonic()
+	assert.Nil(t, err)
+	assert.Len(t, strings.Fields(mnemonic1), 24)
+	entropy, err := MnemonicToEntropy(mnemonic1)
+	assert.Nil(t, err)
+	assert.Len(t, entropy, MnemonicEntropyLength)
+
+	mnemonic2, err := NewMnemonic()
+	assert.Nil(t, err)
+	assert.NotEqual(t, mnemonic1, mnemonic2)
+}
+
+func TestEntropyToMnemonic_ok(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		entropy, err := hex.DecodeString(v.entropy)
+		assert.Nil(t, err)
+		mnemonic, err := EntropyToMnemonic(entropy)
+		assert.Nil(t, err)
+		assert.Equal(t, v.mnemonic, mnemonic)
+	}
+	for n := MinMnemonicEntropyLength; n <= MaxMnemonicEntropyLength; n += mnemonicEntropyStep {
+		mnemonic, err := EntropyToMnemonic(make([]byte, n))
+		assert.Nil(t, err)
+		assert.Len(t, strings.Fields(mnemonic), n*3/4)
+	}
+}
+
+func TestEntropyToMnemonic_err(t *testing.T) {
+	for _, entropyLen := range []int{0, MinMnemonicEntropyLength - 4, MinMnemonicEntropyLength + 1,
+		MaxMnemonicEntropyLength + 4} {
+		mnemonic, err := EntropyToMnemonic(make([]byte, entropyLen))
+		assert.Equal(t, ErrInvalidEntropyLength, err)
+		assert.Empty(t, mnemonic)
+	}
+}
+
+func TestMnemonicToEntropy_ok(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		entropy, err := MnemonicToEntropy(v.mnemonic)
+		assert.Nil(t, err)
+		assert.Equal(t, v.entropy, hex.EncodeToString(entropy))
+	}
+
+	// check case and extra whitespace are ignored
+	entropy, err := MnemonicToEntropy(" Zoo zoo ZOO zoo zoo zoo zoo zoo zoo zoo zoo\nwrong ")
+	assert.Nil(t, err)
+	assert.Equal(t, mnemonicVectors[3].entropy, hex.EncodeToString(entropy))
+}
+
+func TestMnemonicToEntropy_err(t *testing.T) {
+	cases := map[string]error{
+		"":                                    ErrInvalidMnemonic,
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo": ErrInvalidMnemonic, // too few words
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong": ErrInvalidMnemonic, // 13 words
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo libri":     ErrInvalidMnemonic, // not word
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo":       ErrInvalidMnemonicChecksum,
+		strings.Repeat("abandon ", 10) + "about abandon":        ErrInvalidMnemonicChecksum,
+	}
+	for mnemonic, expected := range cases {
+		entropy, err := MnemonicToEntropy(mnemonic)
+		assert.Equal(t, expected, err, mnemonic)
+		assert.Nil(t, entropy)
+	}
+}
+
+func TestMnemonicToSeed_ok(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		seed, err := MnemonicToSeed(v.mnemonic, "TREZOR")
+		assert.Nil(t, err)
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+
+	// check different passphrase gives different seed
+	seed, err := MnemonicToSeed(mnemonicVectors[0].mnemonic, "")
+	assert.Nil(t, err)
+	assert.NotEqual(t, mnemonicVectors[0].seed, hex.EncodeToString(seed))
+}
+
+func TestMnemonicToSeed_err(t *testing.T) {
+	seed, err := MnemonicToSeed("zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo", "TREZOR")
+	assert.Equal(t, ErrInvalidMnemonicChecksum, err)
+	assert.Nil(t, seed)
+}
+
+func TestRestore_ok(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	assert.Nil(t, err)
+	seed, err := MnemonicToSeed(mnemonic, "some passphrase")
+	assert.Nil(t, err)
+	kc1, err := FromSeed(seed, AuthorAccount, 3)
+	assert.Nil(t, err)
+
+	kc2, err := Restore(mnemonic, "some passphrase", AuthorAccount, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1.PublicKeys(), kc2.PublicKeys())
+
+	kc3, err := Restore(mnemonic, "other passphrase", AuthorAccount, 3)
+	assert.Nil(t, err)
+	assert.NotEqual(t, kc1.PublicKeys(), kc3.PublicKeys())
+}
+
+func TestRestore_err(t *testing.T) {
+	kc, err := Restore("not a mnemonic", "some passphrase", AuthorAccount, 3)
+	assert.Equal(t, ErrInvalidMnemonic, err)
+	assert.Nil(t, kc)
+}
+
+func TestEnglishWords(t *testing.T) {
+	prefixes := make(map[string]struct{})
+	for i, word := range englishWords {
+		if i > 0 {
+			assert.True(t, englishWords[i-1] < word)
+		}
+		assert.Equal(t, i, englishWordIndices[word])
+
+		// BIP39 English words are uniquely identified by their first 4 letters
+		prefix := word
+		if len(prefix) > 4 {
+			prefix = prefix[:4]
+		}
+		_, in := prefixes[prefix]
+		assert.False(t, in)
+		prefixes[prefix] = struct{}{}
+	}
+}
@@ -0,0 +1,77 @@
+package keychain
+
+import "errors"
+
+const (
+	// DefaultNKeys is the default number of keys in a created keychain.
+	DefaultNKeys = 64
+
+	// MinNKeys is the minimum number of keys in a created keychain.
+	MinNKeys = 1
+
+	// MaxNKeys is the maximum number of keys in a created keychain.
+	MaxNKeys = 1024
+
+	// MinScryptN is the minimum Scrypt N parameter.
+	MinScryptN = LightScryptN
+
+	// MaxScryptN is the maximum Scrypt N parameter, using 1GB memory.
+	MaxScryptN = 1 << 20
+
+	// MinScryptP is the minimum Scrypt P parameter.
+	MinScryptP = 1
+
+	// MaxScryptP is the maximum Scrypt P parameter.
+	MaxScryptP = 16
+)
+
+var (
+	// ErrInvalidNKeys indicates when the number of keys is outside [MinNKeys, MaxNKeys].
+	ErrInvalidNKeys = errors.New("number of keys out of bounds")
+
+	// ErrInvalidScryptN indicates when the Scrypt N parameter isn't a power of 2 within
+	// [MinScryptN, MaxScryptN].
+	ErrInvalidScryptN = errors.New("scrypt N not a power of 2 within bounds")
+
+	// ErrInvalidScryptP indicates when the Scrypt P parameter is outside [MinScryptP, MaxScryptP].
+	ErrInvalidScryptP = errors.New("scrypt P out of bounds")
+)
+
+// Parameters define how keychains are created.
+type Parameters struct {
+	// NKeys is the number of keys generated in each keychain.
+	NKeys int
+
+	// ScryptN and ScryptP are the Scrypt parameters of the key deriving the keychain encryption
+	// key from the passphrase, where larger values make brute-forcing the passphrase harder.
+	ScryptN int
+	ScryptP int
+}
+
+// NewParameters creates a new *Parameters instance, checking each value is within its bounds.
+func NewParameters(nKeys, scryptN, scryptP int) (*Parameters, error) {
+	if nKeys < MinNKeys || nKeys > MaxNKeys {
+		return nil, ErrInvalidNKeys
+	}
+	if scryptN < MinScryptN || scryptN > MaxScryptN || scryptN&(scryptN-1) != 0 {
+		return nil, ErrInvalidScryptN
+	}
+	if scryptP < MinScryptP || scryptP > MaxScryptP {
+		return nil, ErrInvalidScryptP
+	}
+	return &Parameters{
+		NKeys:   nKeys,
+		ScryptN: scryptN,
+		ScryptP: scryptP,
+	}, nil
+}
+
+// NewDefaultParameters creates a default *Parameters instance.
+func NewDefaultParameters() *Parameters {
+	params, err := NewParameters(DefaultNKeys, LightScryptN, LightScryptP)
+	if err != nil {
+		// should never happen; if does, it's programmer error
+		panic(err)
+	}
+	return params
+}
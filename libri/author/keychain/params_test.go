@@ -0,0 +1,47 @@
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewParameters_ok(t *testing.T) {
+	cases := [][]int{
+		{MinNKeys, MinScryptN, MinScryptP},
+		{MaxNKeys, MaxScryptN, MaxScryptP},
+		{DefaultNKeys, StandardScryptN, StandardScryptP},
+	}
+	for _, c := range cases {
+		params, err := NewParameters(c[0], c[1], c[2])
+		assert.Nil(t, err)
+		assert.Equal(t, &Parameters{NKeys: c[0], ScryptN: c[1], ScryptP: c[2]}, params)
+	}
+}
+
+func TestNewParameters_err(t *testing.T) {
+	cases := []struct {
+		nKeys, scryptN, scryptP int
+		expected                error
+	}{
+		{MinNKeys - 1, LightScryptN, LightScryptP, ErrInvalidNKeys},
+		{MaxNKeys + 1, LightScryptN, LightScryptP, ErrInvalidNKeys},
+		{DefaultNKeys, MinScryptN / 2, LightScryptP, ErrInvalidScryptN},
+		{DefaultNKeys, MaxScryptN * 2, LightScryptP, ErrInvalidScryptN},
+		{DefaultNKeys, LightScryptN + 1, LightScryptP, ErrInvalidScryptN},
+		{DefaultNKeys, LightScryptN, MinScryptP - 1, ErrInvalidScryptP},
+		{DefaultNKeys, LightScryptN, MaxScryptP + 1, ErrInvalidScryptP},
+	}
+	for _, c := range cases {
+		params, err := NewParameters(c.nKeys, c.scryptN, c.scryptP)
+		assert.Equal(t, c.expected, err)
+		assert.Nil(t, params)
+	}
+}
+
+func TestNewDefaultParameters(t *testing.T) {
+	params := NewDefaultParameters()
+	assert.Equal(t, DefaultNKeys, params.NKeys)
+	assert.Equal(t, LightScryptN, params.ScryptN)
+	assert.Equal(t, LightScryptP, params.ScryptP)
+}
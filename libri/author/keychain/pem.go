@@ -0,0 +1,395 @@
+package keychain
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// StandardPBKDF2Iterations is the number of PBKDF2 iterations used when exporting keys,
+	// taking approximately 250ms CPU time on a modern processor.
+	StandardPBKDF2Iterations = 600000
+
+	// LightPBKDF2Iterations is the number of PBKDF2 iterations used when exporting keys,
+	// taking approximately 5ms CPU time on a modern processor.
+	LightPBKDF2Iterations = 10000
+
+	// EncryptedPEMType is the PEM block type of exported keys, which are PKCS #8 encrypted
+	// private keys.
+	EncryptedPEMType = "ENCRYPTED PRIVATE KEY"
+
+	pbkdf2SaltLength = 16
+	aes256KeyLength  = 32
+)
+
+var (
+	// ErrNoPEMBlocks indicates when imported PEM bytes have no PEM blocks.
+	ErrNoPEMBlocks = errors.New("no PEM blocks")
+
+	// ErrUnexpectedPEMType indicates when an imported PEM block isn't an encrypted private key.
+	ErrUnexpectedPEMType = errors.New("unexpected PEM block type")
+
+	// ErrUnsupportedKeyEncryption indicates when an imported key is encrypted with something
+	// other than PBES2 with PBKDF2 and AES-CBC.
+	ErrUnsupportedKeyEncryption = errors.New("unsupported key encryption")
+
+	// ErrUnsupportedKeyCurve indicates when an imported key isn't an elliptic curve key on the
+	// keychain curve.
+	ErrUnsupportedKeyCurve = errors.New("unsupported key curve")
+
+	// ErrKeyMismatch indicates when an imported private key doesn't match its public key.
+	ErrKeyMismatch = errors.New("private key does not match public key")
+
+	// ErrInvalidPadding indicates when decrypted key bytes have invalid padding, usually because
+	// of a wrong passphrase.
+	ErrInvalidPadding = errors.New("invalid padding, possibly from wrong passphrase")
+
+	// ErrInvalidPBKDF2Iterations indicates when the number of PBKDF2 iterations isn't positive.
+	ErrInvalidPBKDF2Iterations = errors.New("invalid PBKDF2 iterations")
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidECPublicKey    = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1      = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// encryptedPrivateKeyInfo is the PKCS #8 (RFC 5208) encrypted private key.
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the PKCS #5 (RFC 8018) PBES2 parameters.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the PKCS #5 (RFC 8018) PBKDF2 parameters, whose PRF is hmacWithSHA1 when
+// missing.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// privateKeyInfo is the PKCS #8 (RFC 5208) private key.
+type privateKeyInfo struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// ecPrivateKey is the SEC 1 (RFC 5915) elliptic curve private key.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// ExportKeyPEM exports the key as a PEM-encoded PKCS #8 private key encrypted with the passphrase
+// via PBKDF2 with the given number of iterations and AES-256-CBC, which tools like OpenSSL can
+// import.
+func ExportKeyPEM(key ecid.ID, auth string, iterations int) ([]byte, error) {
+	block, err := encryptToPEMBlock(key, auth, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// ImportKeyPEM imports a key exported by ExportKeyPEM or by another tool in the same format.
+func ImportKeyPEM(pemBytes []byte, auth string) (ecid.ID, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, ErrNoPEMBlocks
+	}
+	return decryptFromPEMBlock(block, auth)
+}
+
+// ExportPEM exports all keys in the keychain as concatenated PEM blocks, each like those from
+// ExportKeyPEM.
+func ExportPEM(kc Keychain, auth string, iterations int) ([]byte, error) {
+	out := new(bytes.Buffer)
+	for _, key := range sortedKeys(kc) {
+		block, err := encryptToPEMBlock(key, auth, iterations)
+		if err != nil {
+			return nil, err
+		}
+		if err := pem.Encode(out, block); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// ImportPEM imports a keychain from concatenated PEM blocks, each like those from ExportKeyPEM.
+func ImportPEM(pemBytes []byte, auth string) (Keychain, error) {
+	ecids := make([]ecid.ID, 0)
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		key, err := decryptFromPEMBlock(block, auth)
+		if err != nil {
+			return nil, err
+		}
+		ecids = append(ecids, key)
+	}
+	if len(ecids) == 0 {
+		return nil, ErrNoPEMBlocks
+	}
+	return FromECIDs(ecids), nil
+}
+
+func encryptToPEMBlock(key ecid.ID, auth string, iterations int) (*pem.Block, error) {
+	if iterations <= 0 {
+		return nil, ErrInvalidPBKDF2Iterations
+	}
+	plaintext, err := marshalPKCS8(key)
+	if err != nil {
+		return nil, err
+	}
+	salt, iv := make([]byte, pbkdf2SaltLength), make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: iterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.NullRawValue,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdfParams},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivParam},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	aesKey := pbkdf2.Key([]byte(auth), salt, iterations, aes256KeyLength, sha256.New)
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := pad(plaintext, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBES2,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedData: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: EncryptedPEMType, Bytes: der}, nil
+}
+
+func decryptFromPEMBlock(block *pem.Block, auth string) (ecid.ID, error) {
+	if block.Type != EncryptedPEMType {
+		return nil, ErrUnexpectedPEMType
+	}
+	info := &encryptedPrivateKeyInfo{}
+	if _, err := asn1.Unmarshal(block.Bytes, info); err != nil {
+		return nil, err
+	}
+	if !info.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedKeyEncryption
+	}
+	params := &pbes2Params{}
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, params); err != nil {
+		return nil, err
+	}
+	aesKey, err := deriveAESKey(params, auth)
+	if err != nil {
+		return nil, err
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	aesBlock, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := info.EncryptedData
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrUnsupportedKeyEncryption
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(aesBlock, iv).CryptBlocks(plaintext, ciphertext)
+	plaintext, err = unpad(plaintext, aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPKCS8(plaintext)
+}
+
+// deriveAESKey derives the AES key from the passphrase with the PBES2 PBKDF2 parameters.
+func deriveAESKey(params *pbes2Params, auth string) ([]byte, error) {
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, ErrUnsupportedKeyEncryption
+	}
+	kdfParams := &pbkdf2Params{}
+	_, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, kdfParams)
+	if err != nil {
+		return nil, err
+	}
+	if kdfParams.IterationCount <= 0 {
+		return nil, ErrInvalidPBKDF2Iterations
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, ErrUnsupportedKeyEncryption
+	}
+	var keyLength int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength = aes256KeyLength
+	default:
+		return nil, ErrUnsupportedKeyEncryption
+	}
+	return pbkdf2.Key([]byte(auth), kdfParams.Salt, kdfParams.IterationCount, keyLength, prf), nil
+}
+
+func marshalPKCS8(key ecid.ID) ([]byte, error) {
+	ecKey, err := asn1.Marshal(ecPrivateKey{
+		Version:    1,
+		PrivateKey: crypto.FromECDSA(key.Key()),
+		PublicKey: asn1.BitString{
+			Bytes:     ecid.ToPublicKeyBytes(key),
+			BitLength: 8 * len(ecid.ToPublicKeyBytes(key)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	curveParam, err := asn1.Marshal(oidSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(privateKeyInfo{
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  oidECPublicKey,
+			Parameters: asn1.RawValue{FullBytes: curveParam},
+		},
+		PrivateKey: ecKey,
+	})
+}
+
+func unmarshalPKCS8(der []byte) (ecid.ID, error) {
+	info := &privateKeyInfo{}
+	if _, err := asn1.Unmarshal(der, info); err != nil {
+		return nil, err
+	}
+	if !info.Algo.Algorithm.Equal(oidECPublicKey) {
+		return nil, ErrUnsupportedKeyCurve
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &curve); err != nil {
+		return nil, err
+	}
+	if !curve.Equal(oidSecp256k1) {
+		return nil, ErrUnsupportedKeyCurve
+	}
+	ecKey := &ecPrivateKey{}
+	if _, err := asn1.Unmarshal(info.PrivateKey, ecKey); err != nil {
+		return nil, err
+	}
+	if ecKey.NamedCurveOID != nil && !ecKey.NamedCurveOID.Equal(oidSecp256k1) {
+		return nil, ErrUnsupportedKeyCurve
+	}
+	priv, err := crypto.ToECDSA(ecKey.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if ecKey.PublicKey.BitLength > 0 &&
+		!bytes.Equal(ecKey.PublicKey.Bytes, elliptic.Marshal(ecid.Curve, priv.X, priv.Y)) {
+		return nil, ErrKeyMismatch
+	}
+	return ecid.FromPrivateKey(priv), nil
+}
+
+// sortedKeys returns the keychain's keys ordered by their public keys.
+func sortedKeys(kc Keychain) []ecid.ID {
+	pubs := kc.PublicKeys()
+	keys := make([]ecid.ID, 0, len(pubs))
+	for _, pub := range pubs {
+		if key, in := kc.Get(pub); in {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// pad adds PKCS #7 padding to a copy of the bytes.
+func pad(b []byte, blockSize int) []byte {
+	n := blockSize - len(b)%blockSize
+	return append(append([]byte{}, b...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+// unpad removes PKCS #7 padding from the bytes.
+func unpad(b []byte, blockSize int) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidPadding
+	}
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize || n > len(b) {
+		return nil, ErrInvalidPadding
+	}
+	if !bytes.Equal(b[len(b)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, ErrInvalidPadding
+	}
+	return b[:len(b)-n], nil
+}
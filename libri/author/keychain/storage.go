@@ -2,35 +2,38 @@ package keychain
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
 	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// encryptToStored encrypts the contents of Keychain using the authentication passphrase and scrypt
-// difficulty parameters.
-func encryptToStored(kc Keychain, auth string, scryptN, scryptP int) (*StoredKeychain, error) {
-	storedPrivateKeys := make([][]byte, 0)
-	var mu sync.Mutex
+// encryptToStored encrypts the contents of Keychain using the authentication passphrase and KDF.
+func encryptToStored(kc Keychain, auth string, kdf KDF) (*StoredKeychain, error) {
+	pubs := kc.PublicKeys()
+	storedPrivateKeys := make([][]byte, len(pubs))
 	var wg sync.WaitGroup
 	errs, done := make(chan error, 1), make(chan struct{}, 1)
 
-	// encrypt all keys in parallel b/c each can be intensive, thanks to scrypt
-	for _, key1 := range kc.(*keychain).privs {
+	// encrypt all keys in parallel b/c each can be intensive, thanks to the KDF
+	for i, pub := range pubs {
+		key1, in := kc.Get(pub)
+		if !in {
+			return nil, ErrUnexpectedMissingKey
+		}
 		wg.Add(1)
-		go func(key2 ecid.ID) {
-			var err error
+		go func(i int, key2 ecid.ID) {
 			defer wg.Done()
-			encryptedKeyBytes, err := encryptKey(key2.Key(), auth, scryptN, scryptP)
+			encryptedKeyBytes, err := kdf.Encrypt(key2.Key(), auth)
 			if err != nil {
 				errs <- err
+				return
 			}
-			mu.Lock()
-			storedPrivateKeys = append(storedPrivateKeys, encryptedKeyBytes)
-			mu.Unlock()
-		}(key1)
+			storedPrivateKeys[i] = encryptedKeyBytes // each goroutine sets a different index
+		}(i, key1)
 	}
 
 	go func() {
@@ -40,8 +43,16 @@ func encryptToStored(kc Keychain, auth string, scryptN, scryptP int) (*StoredKey
 
 	select {
 	case <-done:
+		header := kdf.Header()
+		if header == nil {
+			header = &KDFHeader{Algorithm: ScryptAlgorithm}
+		}
 		return &StoredKeychain{
 			PrivateKeys: storedPrivateKeys,
+			PublicKeys:  pubs,
+			Kdf:         header,
+			Metadata:    toStoredMetadata(kc),
+			Version:     CurrentKeychainVersion,
 		}, nil
 	case err := <-errs:
 		return nil, err
@@ -50,17 +61,21 @@ func encryptToStored(kc Keychain, auth string, scryptN, scryptP int) (*StoredKey
 
 // decryptFromStored decrypts the contents of a StoredKeychain using the authentication passphrase.
 func decryptFromStored(stored *StoredKeychain, auth string) (Keychain, error) {
+	kdf, err := FromHeader(stored.Kdf)
+	if err != nil {
+		return nil, err
+	}
 	ecids := make([]ecid.ID, 0)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	errs, done := make(chan error, 1), make(chan struct{}, 1)
 
-	// decrypt all keys in parallel b/c each can be intensive, thanks to scrypt
+	// decrypt all keys in parallel b/c each can be intensive, thanks to the KDF
 	for _, keyJSON1 := range stored.PrivateKeys {
 		wg.Add(1)
 		go func(keyJSON2 []byte) {
 			defer wg.Done()
-			priv, err := decryptKey(keyJSON2, auth)
+			priv, err := kdf.Decrypt(keyJSON2, auth)
 			if err != nil {
 				errs <- err
 				return
@@ -78,12 +93,52 @@ func decryptFromStored(stored *StoredKeychain, auth string) (Keychain, error) {
 
 	select {
 	case <-done:
-		return FromECIDs(ecids), nil
+		kc := FromECIDs(ecids)
+		fromStoredMetadata(kc, stored.Metadata)
+		return kc, nil
 	case err := <-errs:
 		return nil, err
 	}
 }
 
+// toStoredMetadata returns the stored metadata of the keys in the keychain with non-empty
+// metadata.
+func toStoredMetadata(kc Keychain) []*StoredKeyMetadata {
+	stored := make([]*StoredKeyMetadata, 0, kc.Len())
+	for _, pub := range kc.PublicKeys() {
+		md, _ := kc.Metadata(pub)
+		if md == (Metadata{}) {
+			continue
+		}
+		var created int64
+		if !md.Created.IsZero() {
+			created = md.Created.Unix()
+		}
+		stored = append(stored, &StoredKeyMetadata{
+			PublicKey: pub,
+			Created:   created,
+			Label:     md.Label,
+			Usage:     uint32(md.Usage),
+		})
+	}
+	return stored
+}
+
+// fromStoredMetadata sets the metadata of the keys in the keychain, ignoring metadata of keys
+// not in it.
+func fromStoredMetadata(kc Keychain, stored []*StoredKeyMetadata) {
+	for _, storedMD := range stored {
+		md := Metadata{
+			Label: storedMD.Label,
+			Usage: KeyUsage(storedMD.Usage),
+		}
+		if storedMD.Created != 0 {
+			md.Created = time.Unix(storedMD.Created, 0)
+		}
+		_ = kc.SetMetadata(storedMD.PublicKey, md) // missing key is ignored
+	}
+}
+
 func encryptKey(key *ecdsa.PrivateKey, auth string, scryptN, scryptP int) ([]byte, error) {
 	ethKey := &ethkeystore.Key{
 		// Address is not not used by libri, but required for encryption & decryption
@@ -100,3 +155,23 @@ func decryptKey(keyJSON []byte, auth string) (*ecdsa.PrivateKey, error) {
 	}
 	return ethKey.PrivateKey, nil
 }
+
+// scryptParams returns the scrypt N and P parameters of an encrypted key.
+func scryptParams(keyJSON []byte) (int, int, error) {
+	encrypted := &struct {
+		Crypto struct {
+			KDFParams struct {
+				N int `json:"n"`
+				P int `json:"p"`
+			} `json:"kdfparams"`
+		} `json:"crypto"`
+	}{}
+	if err := json.Unmarshal(keyJSON, encrypted); err != nil {
+		return 0, 0, err
+	}
+	params := encrypted.Crypto.KDFParams
+	if params.N == 0 || params.P == 0 {
+		return 0, 0, ErrInvalidKDFParameters
+	}
+	return params.N, params.P, nil
+}
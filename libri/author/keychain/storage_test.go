@@ -1,8 +1,10 @@
 package keychain
 
 import (
+	"math/rand"
 	"testing"
 
+	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,7 +18,7 @@ func TestToFromStored_ok(t *testing.T) {
 	kc1 := New(nKeys)
 
 	auth2 := "test passphrase"
-	stored1, err := encryptToStored(kc1, auth2, veryLightScryptN, veryLightScryptP)
+	stored1, err := encryptToStored(kc1, auth2, NewScryptKDF(veryLightScryptN, veryLightScryptP))
 	assert.Nil(t, err)
 	assert.Equal(t, nKeys, len(stored1.PrivateKeys))
 
@@ -25,7 +27,7 @@ func TestToFromStored_ok(t *testing.T) {
 	assert.Equal(t, kc1, kc2)
 
 	auth3 := "a different test passphrase"
-	stored2, err := encryptToStored(kc1, auth3, veryLightScryptN, veryLightScryptP)
+	stored2, err := encryptToStored(kc1, auth3, NewScryptKDF(veryLightScryptN, veryLightScryptP))
 	assert.Nil(t, err)
 	assert.Equal(t, nKeys, len(stored2.PrivateKeys))
 	assert.NotEqual(t, stored1, stored2)
@@ -35,11 +37,38 @@ func TestToFromStored_ok(t *testing.T) {
 	assert.Equal(t, kc1, kc3)
 }
 
+func TestToFromStored_metadata(t *testing.T) {
+	kc1, auth := New(3), "test passphrase"
+	stored, err := encryptToStored(kc1, auth, NewScryptKDF(veryLightScryptN, veryLightScryptP))
+	assert.Nil(t, err)
+	assert.Len(t, stored.Metadata, kc1.Len())
+
+	// check keychains saved before key metadata existed load with empty metadata
+	stored.Metadata = nil
+	kc2, err := decryptFromStored(stored, auth)
+	assert.Nil(t, err)
+	assert.Equal(t, sortedKeys(kc1), sortedKeys(kc2))
+	for _, pub := range kc2.PublicKeys() {
+		md, in := kc2.Metadata(pub)
+		assert.True(t, in)
+		assert.Equal(t, Metadata{}, md)
+	}
+
+	// check metadata of keys not in the keychain is ignored
+	stored.Metadata = []*StoredKeyMetadata{
+		{PublicKey: New(1).PublicKeys()[0], Label: "missing key"},
+	}
+	kc3, err := decryptFromStored(stored, auth)
+	assert.Nil(t, err)
+	assert.Equal(t, kc2, kc3)
+}
+
 func TestToFromStored_err(t *testing.T) {
 	nKeys := 3
 	kc1 := New(nKeys)
 
-	stored1, err := encryptToStored(kc1, "test passphrase", veryLightScryptN, veryLightScryptP)
+	stored1, err := encryptToStored(kc1, "test passphrase",
+		NewScryptKDF(veryLightScryptN, veryLightScryptP))
 	assert.Nil(t, err)
 	assert.Equal(t, kc1.Len(), len(stored1.PrivateKeys))
 
@@ -47,3 +76,19 @@ func TestToFromStored_err(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, kc2)
 }
+
+func TestScryptParams(t *testing.T) {
+	keyJSON, err := encryptKey(ecid.NewPseudoRandom(rand.New(rand.NewSource(0))).Key(),
+		"test passphrase", veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+	scryptN, scryptP, err := scryptParams(keyJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, veryLightScryptN, scryptN)
+	assert.Equal(t, veryLightScryptP, scryptP)
+
+	// check bad JSON and missing params error
+	_, _, err = scryptParams([]byte("not JSON"))
+	assert.NotNil(t, err)
+	_, _, err = scryptParams([]byte("{}"))
+	assert.Equal(t, ErrInvalidKDFParameters, err)
+}
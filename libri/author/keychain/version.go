@@ -0,0 +1,101 @@
+package keychain
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	// KeychainVersion1 is the version of keychain files saved before they had versions, which
+	// omit the KDF header when encrypted with scrypt and may omit key metadata.
+	KeychainVersion1 uint32 = 1
+
+	// KeychainVersion2 is the version of keychain files that always name their KDF in the header.
+	KeychainVersion2 uint32 = 2
+
+	// KeychainVersion3 is the version of keychain files that store the public key of each
+	// encrypted private key, so keys can be decrypted individually (see LoadLocked).
+	KeychainVersion3 uint32 = 3
+
+	// CurrentKeychainVersion is the version of newly saved keychain files.
+	CurrentKeychainVersion = KeychainVersion3
+)
+
+// ErrUnsupportedKeychainVersion indicates when a keychain file has a newer version than this
+// library can read.
+var ErrUnsupportedKeychainVersion = errors.New("unsupported keychain file version")
+
+// migrations[i] migrates a stored keychain from version i+1 to version i+2, so a new version only
+// needs a migration from the previous one.
+var migrations = []func(stored *StoredKeychain) error{
+	migrateV1ToV2,
+	migrateV2ToV3,
+}
+
+// Version returns the format version of the keychain file.
+func Version(filepath string) (uint32, error) {
+	stored, err := readStored(filepath)
+	if err != nil {
+		return 0, err
+	}
+	return storedVersion(stored), nil
+}
+
+// Migrate rewrites the keychain file in the current format version, if it has an older one. Since
+// only the unencrypted parts of the format change between versions, it doesn't need the keychain's
+// passphrase. It returns the file's version before migrating.
+func Migrate(filepath string) (uint32, error) {
+	stored, err := readStored(filepath)
+	if err != nil {
+		return 0, err
+	}
+	version := storedVersion(stored)
+	if version == CurrentKeychainVersion {
+		return version, nil
+	}
+	if err := migrate(stored); err != nil {
+		return 0, err
+	}
+	buf, err := proto.Marshal(stored)
+	if err != nil {
+		return 0, err
+	}
+	return version, replaceFile(filepath, buf)
+}
+
+// migrate successively migrates the stored keychain from its version to the current version.
+func migrate(stored *StoredKeychain) error {
+	version := storedVersion(stored)
+	if version > CurrentKeychainVersion {
+		return ErrUnsupportedKeychainVersion
+	}
+	for ; version < CurrentKeychainVersion; version++ {
+		if err := migrations[version-1](stored); err != nil {
+			return err
+		}
+		stored.Version = version + 1
+	}
+	return nil
+}
+
+func storedVersion(stored *StoredKeychain) uint32 {
+	if stored.Version == 0 {
+		return KeychainVersion1
+	}
+	return stored.Version
+}
+
+// migrateV1ToV2 names the scrypt KDF in the header of keychains without one.
+func migrateV1ToV2(stored *StoredKeychain) error {
+	if stored.Kdf == nil || stored.Kdf.Algorithm == "" {
+		stored.Kdf = &KDFHeader{Algorithm: ScryptAlgorithm}
+	}
+	return nil
+}
+
+// migrateV2ToV3 leaves the public keys missing, since they can't be derived without decrypting the
+// private keys. They are added the next time the keychain is saved, e.g., by ChangeAuth.
+func migrateV2ToV3(stored *StoredKeychain) error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package keychain
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSave_version(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	err = Save(file.Name(), "test passphrase", New(3), veryLightScryptN, veryLightScryptP)
+	assert.Nil(t, err)
+
+	// check new files have the current version and name their KDF
+	version, err := Version(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, CurrentKeychainVersion, version)
+	stored, err := readStored(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, ScryptAlgorithm, stored.Kdf.Algorithm)
+}
+
+func TestMigrate_ok(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	// mimic keychain saved before versions
+	kc1, auth := New(3), "test passphrase"
+	stored, err := encryptToStored(kc1, auth, NewScryptKDF(veryLightScryptN, veryLightScryptP))
+	assert.Nil(t, err)
+	stored.Kdf, stored.Version = nil, 0
+	writeStored(t, file.Name(), stored)
+
+	version, err := Version(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, KeychainVersion1, version)
+
+	// check old version loads without changing file
+	kc2, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1, kc2)
+	version, err = Version(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, KeychainVersion1, version)
+
+	// check migrating rewrites file with current version
+	version, err = Migrate(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, KeychainVersion1, version)
+	version, err = Version(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, CurrentKeychainVersion, version)
+	migrated, err := readStored(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, ScryptAlgorithm, migrated.Kdf.Algorithm)
+	assert.Equal(t, stored.PrivateKeys, migrated.PrivateKeys)
+
+	kc3, err := Load(file.Name(), auth)
+	assert.Nil(t, err)
+	assert.Equal(t, kc1, kc3)
+
+	// check migrating current version is a no-op
+	version, err = Migrate(file.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, CurrentKeychainVersion, version)
+}
+
+func TestMigrate_err(t *testing.T) {
+	file, err := ioutil.TempFile("", "kechain-test")
+	defer func() { assert.Nil(t, os.Remove(file.Name())) }()
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	// check missing file errors
+	version, err := Migrate(file.Name() + "-missing")
+	assert.NotNil(t, err)
+	assert.Zero(t, version)
+	version, err = Version(file.Name() + "-missing")
+	assert.NotNil(t, err)
+	assert.Zero(t, version)
+
+	// check newer version can't be migrated or loaded
+	auth := "test passphrase"
+	stored, err := encryptToStored(New(3), auth,
+		NewScryptKDF(veryLightScryptN, veryLightScryptP))
+	assert.Nil(t, err)
+	stored.Version = CurrentKeychainVersion + 1
+	writeStored(t, file.Name(), stored)
+
+	version, err = Migrate(file.Name())
+	assert.Equal(t, ErrUnsupportedKeychainVersion, err)
+	assert.Zero(t, version)
+
+	kc, err := Load(file.Name(), auth)
+	assert.Equal(t, ErrUnsupportedKeychainVersion, err)
+	assert.Nil(t, kc)
+
+	err = ChangeAuth(file.Name(), auth, "new passphrase")
+	assert.Equal(t, ErrUnsupportedKeychainVersion, err)
+}
+
+func TestMigrateV1ToV2(t *testing.T) {
+	stored := &StoredKeychain{}
+	assert.Nil(t, migrateV1ToV2(stored))
+	assert.Equal(t, &KDFHeader{Algorithm: ScryptAlgorithm}, stored.Kdf)
+
+	// check existing header is kept
+	header := &KDFHeader{Algorithm: Argon2idAlgorithm, Time: 1}
+	stored = &StoredKeychain{Kdf: header}
+	assert.Nil(t, migrateV1ToV2(stored))
+	assert.Equal(t, header, stored.Kdf)
+}
+
+func writeStored(t *testing.T, filepath string, stored *StoredKeychain) {
+	buf, err := proto.Marshal(stored)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath, buf, 0600))
+}
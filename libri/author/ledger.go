@@ -0,0 +1,289 @@
+package author
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"sort"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	// ErrLedgerKeyUnavailable indicates when none of the keychain keys that wrapped the key
+	// ledger's encryption key remain in the keychains, so the ledger cannot be opened.
+	ErrLedgerKeyUnavailable = errors.New("no keychain key can unwrap the key ledger key")
+
+	// ErrLedgerCiphertextTooShort indicates when a key ledger ciphertext is too short to contain
+	// its nonce.
+	ErrLedgerCiphertextTooShort = errors.New("key ledger ciphertext too short")
+)
+
+const ledgerKeyLength = 32
+
+var (
+	ledgerKeysKey = []byte("KeyLedgerKeys")
+)
+
+// KeyLedger records which author and reader keys were used for each envelope the author uploaded
+// or shared, so the envelopes orphaned by rotating or pruning keychain keys can be found.
+type KeyLedger interface {
+	// Record saves the record of an envelope's keys to the ledger.
+	Record(record *KeyUsageRecord) error
+
+	// Get returns the record of the envelope with the given key, or nil if the ledger has none.
+	Get(envelopeKey id.ID) (*KeyUsageRecord, error)
+
+	// List returns the records matching the filter, ordered by published time. A nil filter
+	// matches all records.
+	List(filter func(record *KeyUsageRecord) bool) ([]*KeyUsageRecord, error)
+}
+
+type keyLedger struct {
+	sl   storage.NamespaceStorerLoaderIterator
+	aead cipher.AEAD
+}
+
+// NewKeyLedger returns a new KeyLedger storing its records in the storer, encrypted with the
+// 32-byte AES-256 GCM ledger key.
+func NewKeyLedger(sl storage.NamespaceStorerLoaderIterator, ledgerKey []byte) (KeyLedger, error) {
+	aead, err := newLedgerAEAD(ledgerKey)
+	if err != nil {
+		return nil, err
+	}
+	return &keyLedger{sl: sl, aead: aead}, nil
+}
+
+func (l *keyLedger) Record(record *KeyUsageRecord) error {
+	plaintext, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := sealLedger(l.aead, plaintext)
+	if err != nil {
+		return err
+	}
+	return l.sl.Store(record.EnvelopeKey, ciphertext)
+}
+
+func (l *keyLedger) Get(envelopeKey id.ID) (*KeyUsageRecord, error) {
+	ciphertext, err := l.sl.Load(envelopeKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if ciphertext == nil {
+		return nil, nil
+	}
+	return l.openRecord(ciphertext)
+}
+
+func (l *keyLedger) List(filter func(record *KeyUsageRecord) bool) ([]*KeyUsageRecord, error) {
+	records := make([]*KeyUsageRecord, 0)
+	var openErr error
+	err := l.sl.Iterate(make(chan struct{}), func(key, value []byte) {
+		record, err := l.openRecord(value)
+		if err != nil {
+			openErr = err
+			return
+		}
+		if filter == nil || filter(record) {
+			records = append(records, record)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if openErr != nil {
+		return nil, openErr
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].PublishedTime < records[j].PublishedTime
+	})
+	return records, nil
+}
+
+func (l *keyLedger) openRecord(ciphertext []byte) (*KeyUsageRecord, error) {
+	plaintext, err := openLedger(l.aead, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	record := &KeyUsageRecord{}
+	if err := proto.Unmarshal(plaintext, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// KeyUsage returns the ledger's record of the keys used by the envelope with the given key, or nil
+// if the author didn't upload or share it.
+func (a *Author) KeyUsage(envelopeKey id.ID) (*KeyUsageRecord, error) {
+	return a.keyLedger.Get(envelopeKey)
+}
+
+// EnvelopesUsingKey returns the ledger's records of the envelopes using the public key as either
+// their author or reader key, ordered by published time.
+func (a *Author) EnvelopesUsingKey(publicKey []byte) ([]*KeyUsageRecord, error) {
+	return a.keyLedger.List(func(record *KeyUsageRecord) bool {
+		return bytes.Equal(record.AuthorPublicKey, publicKey) ||
+			bytes.Equal(record.ReaderPublicKey, publicKey)
+	})
+}
+
+// OrphanedEnvelopes returns the ledger's records of the envelopes the author could no longer
+// manage with the given author and self-reader keychains, e.g., after rotating or pruning them.
+// Shared envelopes are orphaned without their author key, since it is needed to share or revoke
+// them, and uploaded envelopes are also orphaned without their self-reader key, since it is needed
+// to decrypt them.
+func (a *Author) OrphanedEnvelopes(authorKeys, selfReaderKeys keychain.Keychain) (
+	[]*KeyUsageRecord, error) {
+	return a.keyLedger.List(func(record *KeyUsageRecord) bool {
+		if _, in := authorKeys.Get(record.AuthorPublicKey); !in {
+			return true
+		}
+		if record.Shared {
+			return false
+		}
+		_, in := selfReaderKeys.Get(record.ReaderPublicKey)
+		return !in
+	})
+}
+
+// loadOrCreateLedgerKey unwraps the saved key ledger key with any of the keychains' keys or creates
+// a new one if none is saved. It then saves the key wrapped by each keychain key that hasn't yet
+// wrapped it, so the ledger remains open as keys are rotated into the keychains.
+func loadOrCreateLedgerKey(nsl storage.NamespaceStorerLoader, kcs ...keychain.Keychain) (
+	[]byte, error) {
+	wrapped := &WrappedLedgerKeys{}
+	buf, err := nsl.Load(ledgerKeysKey)
+	if err != nil {
+		return nil, err
+	}
+	if buf != nil {
+		if err := proto.Unmarshal(buf, wrapped); err != nil {
+			return nil, err
+		}
+	}
+	ledgerKey, err := unwrapLedgerKey(wrapped, kcs)
+	if err != nil {
+		return nil, err
+	}
+	if ledgerKey == nil {
+		if len(wrapped.Keys) > 0 {
+			return nil, ErrLedgerKeyUnavailable
+		}
+		ledgerKey = make([]byte, ledgerKeyLength)
+		if _, err := rand.Read(ledgerKey); err != nil {
+			return nil, err
+		}
+	}
+
+	nWrapped := len(wrapped.Keys)
+	if err := wrapLedgerKey(ledgerKey, wrapped, kcs); err != nil {
+		return nil, err
+	}
+	if len(wrapped.Keys) == nWrapped {
+		return ledgerKey, nil
+	}
+	buf, err = proto.Marshal(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return ledgerKey, nsl.Store(ledgerKeysKey, buf)
+}
+
+// unwrapLedgerKey returns the ledger key unwrapped by the first of the keychains' keys that wrapped
+// it, or nil if none did.
+func unwrapLedgerKey(wrapped *WrappedLedgerKeys, kcs []keychain.Keychain) ([]byte, error) {
+	for _, wk := range wrapped.Keys {
+		for _, kc := range kcs {
+			keyID, in := kc.Get(wk.PublicKey)
+			if !in {
+				continue
+			}
+			aead, err := newWrappingAEAD(keyID)
+			if err != nil {
+				return nil, err
+			}
+			return openLedger(aead, wk.Ciphertext)
+		}
+	}
+	return nil, nil
+}
+
+// wrapLedgerKey adds the ledger key wrapped by each of the keychains' keys that hasn't yet wrapped
+// it.
+func wrapLedgerKey(ledgerKey []byte, wrapped *WrappedLedgerKeys, kcs []keychain.Keychain) error {
+	existing := make(map[string]struct{})
+	for _, wk := range wrapped.Keys {
+		existing[string(wk.PublicKey)] = struct{}{}
+	}
+	for _, kc := range kcs {
+		for _, pub := range kc.PublicKeys() {
+			if _, in := existing[string(pub)]; in {
+				continue
+			}
+			keyID, in := kc.Get(pub)
+			if !in || keyID.Key() == nil {
+				// only available ECDSA keys can derive wrapping keys
+				continue
+			}
+			aead, err := newWrappingAEAD(keyID)
+			if err != nil {
+				return err
+			}
+			ciphertext, err := sealLedger(aead, ledgerKey)
+			if err != nil {
+				return err
+			}
+			wrapped.Keys = append(wrapped.Keys, &WrappedLedgerKey{
+				PublicKey:  pub,
+				Ciphertext: ciphertext,
+			})
+			existing[string(pub)] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// newWrappingAEAD returns an AES-256 GCM cipher with the key derived from the ECDH shared secret of
+// the keychain key with itself.
+func newWrappingAEAD(keyID ecid.ID) (cipher.AEAD, error) {
+	keys, err := enc.NewKeys(keyID.Key(), &keyID.Key().PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return newLedgerAEAD(keys.AESKey)
+}
+
+func newLedgerAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealLedger encrypts the plaintext to a random nonce followed by its ciphertext.
+func sealLedger(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openLedger decrypts the nonce and ciphertext from sealLedger.
+func openLedger(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrLedgerCiphertextTooShort
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	return aead.Open(nil, nonce, ciphertext[aead.NonceSize():], nil)
+}
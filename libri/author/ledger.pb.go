@@ -0,0 +1,153 @@
+// Code generated by protoc-gen-go.
+// source: libri/author/ledger.proto
+// DO NOT EDIT!
+
+package author
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// KeyUsageRecord describes the author and reader keys of an uploaded or shared envelope in the
+// author's local ledger of key usage.
+type KeyUsageRecord struct {
+	// key of the envelope
+	EnvelopeKey []byte `protobuf:"bytes,1,opt,name=envelope_key,json=envelopeKey,proto3" json:"envelope_key,omitempty"`
+	// key of the entry the envelope gives access to
+	EntryKey []byte `protobuf:"bytes,2,opt,name=entry_key,json=entryKey,proto3" json:"entry_key,omitempty"`
+	// public key of the envelope author
+	AuthorPublicKey []byte `protobuf:"bytes,3,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
+	// public key of the envelope reader
+	ReaderPublicKey []byte `protobuf:"bytes,4,opt,name=reader_public_key,json=readerPublicKey,proto3" json:"reader_public_key,omitempty"`
+	// whether the envelope was shared with another reader instead of uploaded for the author
+	Shared bool `protobuf:"varint,5,opt,name=shared" json:"shared,omitempty"`
+	// epoch time (seconds since 1970-01-01) when the envelope was published
+	PublishedTime int64 `protobuf:"varint,6,opt,name=published_time,json=publishedTime" json:"published_time,omitempty"`
+}
+
+func (m *KeyUsageRecord) Reset()                    { *m = KeyUsageRecord{} }
+func (m *KeyUsageRecord) String() string            { return proto.CompactTextString(m) }
+func (*KeyUsageRecord) ProtoMessage()               {}
+func (*KeyUsageRecord) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{0} }
+
+func (m *KeyUsageRecord) GetEnvelopeKey() []byte {
+	if m != nil {
+		return m.EnvelopeKey
+	}
+	return nil
+}
+
+func (m *KeyUsageRecord) GetEntryKey() []byte {
+	if m != nil {
+		return m.EntryKey
+	}
+	return nil
+}
+
+func (m *KeyUsageRecord) GetAuthorPublicKey() []byte {
+	if m != nil {
+		return m.AuthorPublicKey
+	}
+	return nil
+}
+
+func (m *KeyUsageRecord) GetReaderPublicKey() []byte {
+	if m != nil {
+		return m.ReaderPublicKey
+	}
+	return nil
+}
+
+func (m *KeyUsageRecord) GetShared() bool {
+	if m != nil {
+		return m.Shared
+	}
+	return false
+}
+
+func (m *KeyUsageRecord) GetPublishedTime() int64 {
+	if m != nil {
+		return m.PublishedTime
+	}
+	return 0
+}
+
+// WrappedLedgerKey is the key encrypting the ledger's records, itself encrypted with a key derived
+// from a keychain key.
+type WrappedLedgerKey struct {
+	// public key of the keychain key
+	PublicKey []byte `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// nonce followed by the AES-256 GCM ciphertext of the ledger key
+	Ciphertext []byte `protobuf:"bytes,2,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+}
+
+func (m *WrappedLedgerKey) Reset()                    { *m = WrappedLedgerKey{} }
+func (m *WrappedLedgerKey) String() string            { return proto.CompactTextString(m) }
+func (*WrappedLedgerKey) ProtoMessage()               {}
+func (*WrappedLedgerKey) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{1} }
+
+func (m *WrappedLedgerKey) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *WrappedLedgerKey) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+// WrappedLedgerKeys lists the ledger key wrapped by each of the author's keychain keys, so the
+// ledger can be opened as long as any one of them remains in the keychains.
+type WrappedLedgerKeys struct {
+	Keys []*WrappedLedgerKey `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *WrappedLedgerKeys) Reset()                    { *m = WrappedLedgerKeys{} }
+func (m *WrappedLedgerKeys) String() string            { return proto.CompactTextString(m) }
+func (*WrappedLedgerKeys) ProtoMessage()               {}
+func (*WrappedLedgerKeys) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
+
+func (m *WrappedLedgerKeys) GetKeys() []*WrappedLedgerKey {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*KeyUsageRecord)(nil), "author.KeyUsageRecord")
+	proto.RegisterType((*WrappedLedgerKey)(nil), "author.WrappedLedgerKey")
+	proto.RegisterType((*WrappedLedgerKeys)(nil), "author.WrappedLedgerKeys")
+}
+
+func init() { proto.RegisterFile("libri/author/ledger.proto", fileDescriptor2) }
+
+var fileDescriptor2 = []byte{
+	// 272 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x91, 0xd1, 0x4a, 0xc3, 0x30,
+	0x14, 0x86, 0x89, 0x9b, 0x65, 0x3b, 0x9b, 0xd3, 0xf5, 0x42, 0x2a, 0xa2, 0xd4, 0x82, 0x50, 0x44,
+	0x3a, 0xd0, 0x27, 0xf0, 0x7a, 0x5e, 0x68, 0x51, 0xbc, 0x2c, 0x6d, 0xf3, 0xb3, 0x86, 0x75, 0x6b,
+	0x48, 0x33, 0x31, 0x4f, 0xec, 0x6b, 0xc8, 0x92, 0x3a, 0xe7, 0x2e, 0xf3, 0xfd, 0x5f, 0xce, 0xe1,
+	0x4f, 0xe8, 0xa2, 0x16, 0x85, 0x12, 0xb3, 0x7c, 0xa3, 0xab, 0x46, 0xcd, 0x6a, 0xf0, 0x05, 0x54,
+	0x22, 0x55, 0xa3, 0x1b, 0xdf, 0x73, 0x30, 0xfa, 0x66, 0x34, 0x99, 0xc3, 0xbc, 0xb7, 0xf9, 0x02,
+	0x29, 0xca, 0x46, 0x71, 0xff, 0x86, 0xc6, 0x58, 0x7f, 0xa2, 0x6e, 0x24, 0xb2, 0x25, 0x4c, 0xc0,
+	0x42, 0x16, 0x8f, 0xd3, 0xd1, 0x2f, 0x9b, 0xc3, 0xf8, 0x97, 0x34, 0xc4, 0x5a, 0x2b, 0x63, 0xf3,
+	0x23, 0x9b, 0x0f, 0x2c, 0xd8, 0x86, 0x77, 0x34, 0x75, 0xc3, 0x33, 0xb9, 0x29, 0x6a, 0x51, 0x5a,
+	0xa9, 0x67, 0xa5, 0x53, 0x17, 0xbc, 0x58, 0xde, 0xb9, 0x0a, 0x39, 0xc7, 0x3f, 0xb7, 0xef, 0x5c,
+	0x17, 0xfc, 0xb9, 0xe7, 0xe4, 0xb5, 0x55, 0xae, 0xc0, 0x83, 0xe3, 0x90, 0xc5, 0x83, 0xb4, 0x3b,
+	0xf9, 0xb7, 0x34, 0xb1, 0x97, 0xdb, 0x0a, 0x3c, 0xd3, 0x62, 0x85, 0xc0, 0x0b, 0x59, 0xdc, 0x4b,
+	0x4f, 0x76, 0xf4, 0x4d, 0xac, 0x10, 0xbd, 0xd2, 0xd9, 0x87, 0xca, 0xa5, 0x04, 0x7f, 0xb6, 0x0f,
+	0xb1, 0x1d, 0x79, 0x45, 0xb4, 0xb7, 0xd7, 0x15, 0x1d, 0xca, 0xdd, 0xc6, 0x6b, 0xa2, 0x52, 0xc8,
+	0x0a, 0x4a, 0xe3, 0x4b, 0x77, 0x3d, 0xf7, 0x48, 0xf4, 0x44, 0xd3, 0xc3, 0x91, 0xad, 0x7f, 0x4f,
+	0xfd, 0x25, 0x4c, 0x1b, 0xb0, 0xb0, 0x17, 0x8f, 0x1e, 0x82, 0xc4, 0x55, 0x4e, 0x0e, 0xc5, 0xd4,
+	0x5a, 0x85, 0x67, 0xbf, 0xe3, 0xf1, 0x67, 0x00, 0xc8, 0x17, 0xd0, 0xa8, 0xab, 0x01, 0x00, 0x00,
+}
@@ -0,0 +1,269 @@
+package author
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyLedger_RecordGetList_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	sl := storage.NewKeyLedgerKVDBStorerLoader(kvdb)
+	kl, err := NewKeyLedger(sl, api.RandBytes(rng, ledgerKeyLength))
+	assert.Nil(t, err)
+
+	// check missing record is nil
+	record, err := kl.Get(id.NewPseudoRandom(rng))
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+
+	records := make([]*KeyUsageRecord, 3)
+	for i, publishedTime := range []int64{3, 1, 2} {
+		records[i] = &KeyUsageRecord{
+			EnvelopeKey:     id.NewPseudoRandom(rng).Bytes(),
+			EntryKey:        id.NewPseudoRandom(rng).Bytes(),
+			AuthorPublicKey: api.RandBytes(rng, api.ECPubKeyLength),
+			ReaderPublicKey: api.RandBytes(rng, api.ECPubKeyLength),
+			Shared:          i == 0,
+			PublishedTime:   publishedTime,
+		}
+		assert.Nil(t, kl.Record(records[i]))
+
+		// check record isn't stored in plaintext
+		stored, err := sl.Load(records[i].EnvelopeKey)
+		assert.Nil(t, err)
+		plaintext, err := proto.Marshal(records[i])
+		assert.Nil(t, err)
+		assert.NotContains(t, string(stored), string(plaintext))
+	}
+
+	for _, expected := range records {
+		record, err = kl.Get(id.FromBytes(expected.EnvelopeKey))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, record)
+	}
+
+	// check all records are listed by published time
+	listed, err := kl.List(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []*KeyUsageRecord{records[1], records[2], records[0]}, listed)
+
+	// check filter selects matching records
+	listed, err = kl.List(func(record *KeyUsageRecord) bool { return record.Shared })
+	assert.Nil(t, err)
+	assert.Equal(t, []*KeyUsageRecord{records[0]}, listed)
+
+	// check ledger with a different key can't open records
+	kl2, err := NewKeyLedger(sl, api.RandBytes(rng, ledgerKeyLength))
+	assert.Nil(t, err)
+	listed, err = kl2.List(nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, listed)
+}
+
+func TestKeyLedger_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	ledgerKey := api.RandBytes(rng, ledgerKeyLength)
+
+	// check bad key length creates error
+	kl, err := NewKeyLedger(&fixedStorerLoaderIterator{}, []byte{1, 2, 3})
+	assert.NotNil(t, err)
+	assert.Nil(t, kl)
+
+	// check store error bubbles up
+	kl, err = NewKeyLedger(&fixedStorerLoaderIterator{
+		fixedStorerLoader: fixedStorerLoader{storeErr: errors.New("some Store error")},
+	}, ledgerKey)
+	assert.Nil(t, err)
+	assert.NotNil(t, kl.Record(&KeyUsageRecord{}))
+
+	// check load error bubbles up
+	kl, err = NewKeyLedger(&fixedStorerLoaderIterator{
+		fixedStorerLoader: fixedStorerLoader{loadErr: errors.New("some Load error")},
+	}, ledgerKey)
+	assert.Nil(t, err)
+	record, err := kl.Get(id.NewPseudoRandom(rng))
+	assert.NotNil(t, err)
+	assert.Nil(t, record)
+
+	// check ciphertext too short creates error
+	kl, err = NewKeyLedger(&fixedStorerLoaderIterator{
+		fixedStorerLoader: fixedStorerLoader{loadBytes: []byte{1, 2, 3}},
+	}, ledgerKey)
+	assert.Nil(t, err)
+	record, err = kl.Get(id.NewPseudoRandom(rng))
+	assert.Equal(t, ErrLedgerCiphertextTooShort, err)
+	assert.Nil(t, record)
+
+	// check iterate error bubbles up
+	kl, err = NewKeyLedger(&fixedStorerLoaderIterator{
+		iterateErr: errors.New("some Iterate error"),
+	}, ledgerKey)
+	assert.Nil(t, err)
+	records, err := kl.List(nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+}
+
+func TestLoadOrCreateLedgerKey_ok(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	nsl := storage.NewClientKVDBStorerLoader(kvdb)
+	authorKeys, selfReaderKeys := keychain.New(3), keychain.New(3)
+
+	// check new key is wrapped by every keychain key
+	ledgerKey1, err := loadOrCreateLedgerKey(nsl, authorKeys, selfReaderKeys)
+	assert.Nil(t, err)
+	assert.Len(t, ledgerKey1, ledgerKeyLength)
+	assert.Len(t, loadWrappedLedgerKeys(t, nsl).Keys, 6)
+
+	// check same key is loaded with the same keychains
+	ledgerKey2, err := loadOrCreateLedgerKey(nsl, authorKeys, selfReaderKeys)
+	assert.Nil(t, err)
+	assert.Equal(t, ledgerKey1, ledgerKey2)
+	assert.Len(t, loadWrappedLedgerKeys(t, nsl).Keys, 6)
+
+	// check same key is loaded after rotating in a new key and pruning the rest
+	rotated := authorKeys.Merge(keychain.New(1))
+	ledgerKey3, err := loadOrCreateLedgerKey(nsl, rotated)
+	assert.Nil(t, err)
+	assert.Equal(t, ledgerKey1, ledgerKey3)
+	assert.Len(t, loadWrappedLedgerKeys(t, nsl).Keys, 7)
+
+	pruned, err := rotated.Subset(rotated.PublicKeys()[3:])
+	assert.Nil(t, err)
+	ledgerKey4, err := loadOrCreateLedgerKey(nsl, pruned)
+	assert.Nil(t, err)
+	assert.Equal(t, ledgerKey1, ledgerKey4)
+}
+
+func TestLoadOrCreateLedgerKey_err(t *testing.T) {
+	authorKeys := keychain.New(3)
+
+	// check load error bubbles up
+	nsl1 := &fixedStorerLoader{loadErr: errors.New("some Load error")}
+	ledgerKey, err := loadOrCreateLedgerKey(nsl1, authorKeys)
+	assert.NotNil(t, err)
+	assert.Nil(t, ledgerKey)
+
+	// check unmarshal error bubbles up
+	nsl2 := &fixedStorerLoader{loadBytes: []byte{1, 2, 3}}
+	ledgerKey, err = loadOrCreateLedgerKey(nsl2, authorKeys)
+	assert.NotNil(t, err)
+	assert.Nil(t, ledgerKey)
+
+	// check store error bubbles up
+	nsl3 := &fixedStorerLoader{storeErr: errors.New("some Store error")}
+	ledgerKey, err = loadOrCreateLedgerKey(nsl3, authorKeys)
+	assert.NotNil(t, err)
+
+	// check key wrapped only by other keys is unavailable
+	wrapped := &WrappedLedgerKeys{}
+	assert.Nil(t, wrapLedgerKey(make([]byte, ledgerKeyLength), wrapped,
+		[]keychain.Keychain{keychain.New(1)}))
+	buf, err := proto.Marshal(wrapped)
+	assert.Nil(t, err)
+	nsl4 := &fixedStorerLoader{loadBytes: buf}
+	ledgerKey, err = loadOrCreateLedgerKey(nsl4, authorKeys)
+	assert.Equal(t, ErrLedgerKeyUnavailable, err)
+	assert.Nil(t, ledgerKey)
+}
+
+func TestAuthor_KeyUsage(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+	a.publisher = &memPublisherAcquirer{docs: make(map[string]*api.Document)}
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	otherAuthorID := ecid.NewPseudoRandom(rng)
+	otherReaderPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+
+	metadata, err := api.NewEntryMetadata("text/plain", 1, api.RandBytes(rng, 32), 1,
+		api.RandBytes(rng, 32))
+	assert.Nil(t, err)
+	entry, _ := api.NewTestDocument(rng)
+	a.entryPacker = &fixedEntryPacker{entry: entry, metadata: metadata}
+	uploadedKey := id.NewPseudoRandom(rng)
+	a.shipper = &fixedShipper{
+		envelope: &api.Document{
+			Contents: &api.Document_Envelope{Envelope: api.NewTestEnvelope(rng)},
+		},
+		envelopeKey: uploadedKey,
+	}
+	_, _, err = a.Upload(nil, "")
+	assert.Nil(t, err)
+
+	// check upload is recorded with its author and self-reader keys
+	uploaded, err := a.KeyUsage(uploadedKey)
+	assert.Nil(t, err)
+	assert.NotNil(t, uploaded)
+	assert.False(t, uploaded.Shared)
+	_, in := a.authorKeys.Get(uploaded.AuthorPublicKey)
+	assert.True(t, in)
+	_, in = a.selfReaderKeys.Get(uploaded.ReaderPublicKey)
+	assert.True(t, in)
+
+	entryKey := id.NewPseudoRandom(rng)
+	eek, _, _ := enc.NewPseudoRandomKeys(rng)
+	_, sharedKey, err := a.shareEntryKeys(authorID, eek, otherReaderPub, entryKey, nil)
+	assert.Nil(t, err)
+
+	// check share is recorded with its author and other reader keys
+	shared, err := a.KeyUsage(sharedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, authorPub, shared.AuthorPublicKey)
+	assert.Equal(t, otherReaderPub, shared.ReaderPublicKey)
+	assert.Equal(t, entryKey.Bytes(), shared.EntryKey)
+	assert.True(t, shared.Shared)
+
+	records, err := a.EnvelopesUsingKey(otherReaderPub)
+	assert.Nil(t, err)
+	assert.Equal(t, []*KeyUsageRecord{shared}, records)
+
+	records, err = a.EnvelopesUsingKey(ecid.ToPublicKeyBytes(otherAuthorID))
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	// check nothing is orphaned with the current keychains
+	records, err = a.OrphanedEnvelopes(a.authorKeys, a.selfReaderKeys)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	// check upload is orphaned without its self-reader key, though share isn't
+	records, err = a.OrphanedEnvelopes(a.authorKeys, keychain.New(1))
+	assert.Nil(t, err)
+	assert.Equal(t, []*KeyUsageRecord{uploaded}, records)
+
+	// check both are orphaned without their author keys
+	records, err = a.OrphanedEnvelopes(keychain.New(1), a.selfReaderKeys)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func loadWrappedLedgerKeys(t *testing.T, nsl storage.NamespaceStorerLoader) *WrappedLedgerKeys {
+	buf, err := nsl.Load(ledgerKeysKey)
+	assert.Nil(t, err)
+	wrapped := &WrappedLedgerKeys{}
+	assert.Nil(t, proto.Unmarshal(buf, wrapped))
+	return wrapped
+}
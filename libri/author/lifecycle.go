@@ -1,20 +1,42 @@
 package author
 
-import "os"
+import (
+	"io"
+	"os"
+)
 
-// Close disconnects the author from its librarians and closes the DB.
+// Close disconnects the author from its librarians, closes the DB, and closes its keychains if
+// they are locked keychains (see keychain.LoadLocked).
 func (a *Author) Close() error {
 	// send stop signal to listener
 	a.stop <- struct{}{}
+	a.health.stopChecking()
 
 	// disconnect from librarians
 	if err := a.librarians.CloseAll(); err != nil {
 		return err
 	}
+	for _, lc := range a.librarianConns {
+		if err := lc.Disconnect(); err != nil {
+			return err
+		}
+	}
+	if err := a.conns.Close(); err != nil {
+		return err
+	}
 
 	// close the DB
 	a.db.Close()
 
+	// zero locked keys
+	for _, kc := range []interface{}{a.authorKeys, a.selfReaderKeys} {
+		if closer, ok := kc.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
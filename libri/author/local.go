@@ -0,0 +1,127 @@
+package author
+
+import (
+	"errors"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+)
+
+// types of locally stored documents
+const (
+	// EnvelopeDocumentType is the type of Envelope documents.
+	EnvelopeDocumentType = "envelope"
+
+	// EntryDocumentType is the type of Entry documents.
+	EntryDocumentType = "entry"
+
+	// PageDocumentType is the type of Page documents.
+	PageDocumentType = "page"
+
+	// TombstoneDocumentType is the type of Tombstone documents.
+	TombstoneDocumentType = "tombstone"
+)
+
+var (
+	// ErrMissingLocalDocument indicates when a document isn't in local storage.
+	ErrMissingLocalDocument = errors.New("document not in local storage")
+)
+
+// LocalDocument describes a document in the author's local storage.
+type LocalDocument struct {
+	// Key is the key of the document.
+	Key id.ID
+
+	// Type is the type of the document, e.g., EnvelopeDocumentType.
+	Type string
+
+	// Size is the size (in bytes) of the marshaled document.
+	Size int
+}
+
+// ListLocalDocuments returns the envelopes, entries, pages, and tombstones in local storage, in
+// key order.
+func (a *Author) ListLocalDocuments() ([]*LocalDocument, error) {
+	keys := make([]id.ID, 0)
+	err := a.documentIter.Iterate(make(chan struct{}), func(key id.ID) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*LocalDocument, 0, len(keys))
+	for _, key := range keys {
+		doc, err := a.documentSL.Load(key)
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			// should never happen b/c we just iterated over the key, but being defensive
+			return nil, ErrMissingLocalDocument
+		}
+		docs = append(docs, &LocalDocument{
+			Key:  key,
+			Type: getDocumentType(doc),
+			Size: proto.Size(doc),
+		})
+	}
+	return docs, nil
+}
+
+// LoadLocalEntry loads the entry of the envelope, e.g., as returned by Upload, from local storage
+// and decrypts its metadata. The author must have the envelope's author key.
+func (a *Author) LoadLocalEntry(envelope *api.Document) (*api.Document, *api.Metadata, error) {
+	authorPub, readerPub, entryKey, err := pack.SeparateEnvelopeDoc(envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return nil, nil, ErrNotEnvelopeAuthor
+	}
+	readerPubKey, err := ecid.FromPublicKeyBytes(readerPub)
+	if err != nil {
+		return nil, nil, err
+	}
+	kek, err := enc.NewKeys(authorID.Key(), readerPubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	eek, err := pack.GetEntryKeys(envelope, kek)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := a.documentSL.Load(entryKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry == nil {
+		return nil, nil, ErrMissingLocalDocument
+	}
+	if entry.GetEntry() == nil {
+		return nil, nil, api.ErrUnexpectedDocumentType
+	}
+	metadata, err := decryptEntryMetadata(entry, eek)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, metadata, nil
+}
+
+func getDocumentType(doc *api.Document) string {
+	switch doc.Contents.(type) {
+	case *api.Document_Envelope:
+		return EnvelopeDocumentType
+	case *api.Document_Entry:
+		return EntryDocumentType
+	case *api.Document_Page:
+		return PageDocumentType
+	case *api.Document_Tombstone:
+		return TombstoneDocumentType
+	}
+	return ""
+}
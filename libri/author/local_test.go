@@ -0,0 +1,232 @@
+package author
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_ListLocalDocuments(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+
+	// check empty local storage has no documents
+	docs, err := a.ListLocalDocuments()
+	assert.Nil(t, err)
+	assert.Empty(t, docs)
+
+	entry, _ := api.NewTestDocument(rng)
+	envelope := &api.Document{
+		Contents: &api.Document_Envelope{
+			Envelope: api.NewTestEnvelope(rng),
+		},
+	}
+	pageDoc := &api.Document{
+		Contents: &api.Document_Page{
+			Page: api.NewTestPage(rng),
+		},
+	}
+	expected := make(map[string]*LocalDocument)
+	for docType, doc := range map[string]*api.Document{
+		EntryDocumentType:    entry,
+		EnvelopeDocumentType: envelope,
+		PageDocumentType:     pageDoc,
+	} {
+		key, err := api.GetKey(doc)
+		assert.Nil(t, err)
+		assert.Nil(t, a.documentSL.Store(key, doc))
+		expected[key.String()] = &LocalDocument{
+			Key:  key,
+			Type: docType,
+			Size: proto.Size(doc),
+		}
+	}
+
+	docs, err = a.ListLocalDocuments()
+	assert.Nil(t, err)
+	assert.Len(t, docs, len(expected))
+	for i, doc := range docs {
+		assert.Equal(t, expected[doc.Key.String()], doc)
+		if i > 0 {
+			// check documents are in key order
+			assert.True(t, docs[i-1].Key.Cmp(doc.Key) < 0)
+		}
+	}
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_ListLocalDocuments_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := &Author{}
+	key := id.NewPseudoRandom(rng)
+
+	// check iterate error bubbles up
+	a.documentIter = &fixedDocumentIterator{err: errors.New("some Iterate error")}
+	docs, err := a.ListLocalDocuments()
+	assert.NotNil(t, err)
+	assert.Nil(t, docs)
+
+	// check load error bubbles up
+	a.documentIter = &fixedDocumentIterator{keys: []id.ID{key}}
+	a.documentSL = &fixedDocumentStorerLoader{loadErr: errors.New("some Load error")}
+	docs, err = a.ListLocalDocuments()
+	assert.NotNil(t, err)
+	assert.Nil(t, docs)
+
+	// check missing document creates error
+	a.documentSL = &fixedDocumentStorerLoader{}
+	docs, err = a.ListLocalDocuments()
+	assert.Equal(t, ErrMissingLocalDocument, err)
+	assert.Nil(t, docs)
+}
+
+func TestAuthor_LoadLocalEntry(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	content := common.NewCompressableBytes(rng, 1024)
+	envelope, _, err := a.Upload(content, "application/x-gzip")
+	assert.Nil(t, err)
+	_, _, entryKey, err := pack.SeparateEnvelopeDoc(envelope)
+	assert.Nil(t, err)
+
+	// check uploaded entry loads and decrypts
+	entry, metadata, err := a.LoadLocalEntry(envelope)
+	assert.Nil(t, err)
+	loadedEntryKey, err := api.GetKey(entry)
+	assert.Nil(t, err)
+	assert.Equal(t, entryKey, loadedEntryKey)
+	mediaType, _ := metadata.GetMediaType()
+	assert.Equal(t, "application/x-gzip", mediaType)
+	uncompressedSize, _ := metadata.GetUncompressedSize()
+	assert.Equal(t, uint64(1024), uncompressedSize)
+
+	// check entry of envelope shared with someone else also loads and decrypts
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	shared, _, err := a.Share(envelope, [][]byte{otherPub})
+	assert.Nil(t, err)
+	_, sharedMetadata, err := a.LoadLocalEntry(shared[0])
+	assert.Nil(t, err)
+	assert.Equal(t, metadata, sharedMetadata)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_LoadLocalEntry_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	readerPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+
+	// check non-envelope creates error
+	entryDoc, entryKey := api.NewTestDocument(rng)
+	entry, metadata, err := a.LoadLocalEntry(entryDoc)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+	assert.Nil(t, entry)
+	assert.Nil(t, metadata)
+
+	// check envelope from another author creates error
+	envelope := pack.NewEnvelopeDoc(readerPub, readerPub, entryKey, nil)
+	entry, metadata, err = a.LoadLocalEntry(envelope)
+	assert.Equal(t, ErrNotEnvelopeAuthor, err)
+	assert.Nil(t, entry)
+	assert.Nil(t, metadata)
+
+	// check missing entry creates error
+	envelope = pack.NewEnvelopeDoc(authorPub, readerPub, entryKey, nil)
+	entry, metadata, err = a.LoadLocalEntry(envelope)
+	assert.Equal(t, ErrMissingLocalDocument, err)
+	assert.Nil(t, entry)
+	assert.Nil(t, metadata)
+
+	// check non-entry creates error
+	pageDoc := &api.Document{
+		Contents: &api.Document_Page{
+			Page: api.NewTestPage(rng),
+		},
+	}
+	pageKey, err := api.GetKey(pageDoc)
+	assert.Nil(t, err)
+	assert.Nil(t, a.documentSL.Store(pageKey, pageDoc))
+	envelope = pack.NewEnvelopeDoc(authorPub, readerPub, pageKey, nil)
+	entry, metadata, err = a.LoadLocalEntry(envelope)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+	assert.Nil(t, entry)
+	assert.Nil(t, metadata)
+
+	// check metadata decryption error bubbles up
+	assert.Nil(t, a.documentSL.Store(entryKey, entryDoc))
+	envelope = pack.NewEnvelopeDoc(authorPub, readerPub, entryKey, nil)
+	entry, metadata, err = a.LoadLocalEntry(envelope)
+	assert.NotNil(t, err)
+	assert.Nil(t, entry)
+	assert.Nil(t, metadata)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+type fixedDocumentIterator struct {
+	keys []id.ID
+	err  error
+}
+
+func (f *fixedDocumentIterator) Iterate(done chan struct{}, callback func(key id.ID)) error {
+	for _, key := range f.keys {
+		callback(key)
+	}
+	return f.err
+}
+
+func (f *fixedDocumentIterator) IterateFrom(
+	start id.ID, done chan struct{}, callback func(key id.ID),
+) error {
+	for _, key := range f.keys {
+		if key.Cmp(start) >= 0 {
+			callback(key)
+		}
+	}
+	return f.err
+}
+
+type fixedDocumentStorerLoader struct {
+	loadDoc  *api.Document
+	loadErr  error
+	storeErr error
+}
+
+func (f *fixedDocumentStorerLoader) Store(key id.ID, value *api.Document) error {
+	return f.storeErr
+}
+
+func (f *fixedDocumentStorerLoader) Load(key id.ID) (*api.Document, error) {
+	return f.loadDoc, f.loadErr
+}
@@ -0,0 +1,89 @@
+// Code generated by protoc-gen-go.
+// source: libri/author/manifest.proto
+// DO NOT EDIT!
+
+package author
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// Manifest lists the files and subdirectories of an uploaded directory.
+type Manifest struct {
+	Files []*ManifestFile `protobuf:"bytes,1,rep,name=files" json:"files,omitempty"`
+}
+
+func (m *Manifest) Reset()                    { *m = Manifest{} }
+func (m *Manifest) String() string            { return proto.CompactTextString(m) }
+func (*Manifest) ProtoMessage()               {}
+func (*Manifest) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{0} }
+
+func (m *Manifest) GetFiles() []*ManifestFile {
+	if m != nil {
+		return m.Files
+	}
+	return nil
+}
+
+// ManifestFile links a file's path within an uploaded directory to its uploaded envelope.
+type ManifestFile struct {
+	// slash-separated path relative to the directory root
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	// file mode, including the directory bit for subdirectories
+	Mode uint32 `protobuf:"varint,2,opt,name=mode" json:"mode,omitempty"`
+	// key of the file's envelope, which is empty for subdirectories
+	EnvelopeKey []byte `protobuf:"bytes,3,opt,name=envelope_key,json=envelopeKey,proto3" json:"envelope_key,omitempty"`
+}
+
+func (m *ManifestFile) Reset()                    { *m = ManifestFile{} }
+func (m *ManifestFile) String() string            { return proto.CompactTextString(m) }
+func (*ManifestFile) ProtoMessage()               {}
+func (*ManifestFile) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{1} }
+
+func (m *ManifestFile) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ManifestFile) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *ManifestFile) GetEnvelopeKey() []byte {
+	if m != nil {
+		return m.EnvelopeKey
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Manifest)(nil), "author.Manifest")
+	proto.RegisterType((*ManifestFile)(nil), "author.ManifestFile")
+}
+
+func init() { proto.RegisterFile("libri/author/manifest.proto", fileDescriptor3) }
+
+var fileDescriptor3 = []byte{
+	// 162 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x92, 0xce, 0xc9, 0x4c, 0x2a,
+	0xca, 0xd4, 0x4f, 0x2c, 0x2d, 0xc9, 0xc8, 0x2f, 0xd2, 0xcf, 0x4d, 0xcc, 0xcb, 0x4c, 0x4b, 0x2d,
+	0x2e, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x83, 0x08, 0x2b, 0x99, 0x71, 0x71, 0xf8,
+	0x42, 0x65, 0x84, 0xb4, 0xb8, 0x58, 0xd3, 0x32, 0x73, 0x52, 0x8b, 0x25, 0x18, 0x15, 0x98, 0x35,
+	0xb8, 0x8d, 0x44, 0xf4, 0x20, 0x6a, 0xf4, 0x60, 0x0a, 0xdc, 0x32, 0x73, 0x52, 0x83, 0x20, 0x4a,
+	0x94, 0x22, 0xb9, 0x78, 0x90, 0x85, 0x85, 0x84, 0xb8, 0x58, 0x0a, 0x12, 0x4b, 0x32, 0x24, 0x18,
+	0x15, 0x18, 0x35, 0x38, 0x83, 0xc0, 0x6c, 0x90, 0x58, 0x6e, 0x7e, 0x4a, 0xaa, 0x04, 0x93, 0x02,
+	0xa3, 0x06, 0x6f, 0x10, 0x98, 0x2d, 0xa4, 0xc8, 0xc5, 0x93, 0x9a, 0x57, 0x96, 0x9a, 0x93, 0x5f,
+	0x90, 0x1a, 0x9f, 0x9d, 0x5a, 0x29, 0xc1, 0xac, 0xc0, 0xa8, 0xc1, 0x13, 0xc4, 0x0d, 0x13, 0xf3,
+	0x4e, 0xad, 0x4c, 0x62, 0x03, 0xbb, 0xd0, 0x18, 0x30, 0x00, 0xa6, 0xb0, 0x83, 0xcd, 0xc0, 0x00,
+	0x00, 0x00,
+}
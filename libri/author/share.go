@@ -0,0 +1,263 @@
+package author
+
+import (
+	"errors"
+	"time"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"go.uber.org/zap"
+)
+
+// LoggerNReaders is the logger key used for the number of readers a document is shared with.
+const LoggerNReaders = "n_readers"
+
+// ErrNotEnvelopeAuthor indicates when an existing envelope is shared or revoked by an author
+// without its author key.
+var ErrNotEnvelopeAuthor = errors.New("missing author key of envelope to share")
+
+// Share publishes an additional envelope for an uploaded entry to each of the reader public keys,
+// so they can download it without its content being uploaded again. It takes the envelope
+// returned by Upload and returns the new envelopes and their keys, in the order of the reader
+// public keys.
+func (a *Author) Share(envelope *api.Document, readerPubs [][]byte) (
+	[]*api.Document, []id.ID, error) {
+	authorPub, selfReaderPub, entryKey, err := pack.SeparateEnvelopeDoc(envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return nil, nil, keychain.ErrUnexpectedMissingKey
+	}
+	selfReaderID, in := a.selfReaderKeys.Get(selfReaderPub)
+	if !in {
+		return nil, nil, keychain.ErrUnexpectedMissingKey
+	}
+	kek, err := enc.NewKeys(authorID.Key(), &selfReaderID.Key().PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	eek, err := pack.GetEntryKeys(envelope, kek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attrs := envelope.Contents.(*api.Document_Envelope).Envelope.EntryAttributes
+	envelopes := make([]*api.Document, len(readerPubs))
+	envelopeKeys := make([]id.ID, len(readerPubs))
+	for i, readerPub := range readerPubs {
+		envelopes[i], envelopeKeys[i], err = a.shareEntryKeys(authorID, eek, readerPub,
+			entryKey, attrs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	a.logger.Info("successfully shared document",
+		zap.String(LoggerEntryKey, entryKey.String()),
+		zap.Int(LoggerNReaders, len(readerPubs)),
+	)
+	return envelopes, envelopeKeys, nil
+}
+
+// ShareExisting gets the existing envelope with the given key from libri and publishes a new
+// envelope for its entry to the reader public key, e.g., to forward an uploaded document. The
+// author must have the existing envelope's author key, though its reader may be anyone. It
+// returns the new envelope and its key.
+func (a *Author) ShareExisting(envelopeKey id.ID, readerPub []byte) (*api.Document, id.ID,
+	error) {
+	lc, err := a.librarians.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	envelope, err := a.acquirer.Acquire(envelopeKey, nil, lc)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorPub, prevReaderPub, entryKey, err := pack.SeparateEnvelopeDoc(envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return nil, nil, ErrNotEnvelopeAuthor
+	}
+	prevReaderPubKey, err := ecid.FromPublicKeyBytes(prevReaderPub)
+	if err != nil {
+		return nil, nil, err
+	}
+	kek, err := enc.NewKeys(authorID.Key(), prevReaderPubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	eek, err := pack.GetEntryKeys(envelope, kek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attrs := envelope.Contents.(*api.Document_Envelope).Envelope.EntryAttributes
+	shared, sharedKey, err := a.shareEntryKeys(authorID, eek, readerPub, entryKey, attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.logger.Info("successfully shared existing document",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+		zap.String(LoggerEntryKey, entryKey.String()),
+	)
+	return shared, sharedKey, nil
+}
+
+// Revoke publishes a tombstone for the envelope with the given key, which replaces the envelope
+// in libri, so readers who haven't already downloaded it can no longer open it. Since readers may
+// have already downloaded the envelope and entry, revocation is only best-effort. The author must
+// have the envelope's author key.
+func (a *Author) Revoke(envelopeKey id.ID) error {
+	lc, err := a.librarians.Next()
+	if err != nil {
+		return err
+	}
+	envelope, err := a.acquirer.Acquire(envelopeKey, nil, lc)
+	if err != nil {
+		return err
+	}
+	if envelope.GetTombstone() != nil {
+		// already revoked
+		return nil
+	}
+	authorPub, _, _, err := pack.SeparateEnvelopeDoc(envelope)
+	if err != nil {
+		return err
+	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return ErrNotEnvelopeAuthor
+	}
+	if err := a.publishTombstone(authorID, envelopeKey, lc); err != nil {
+		return err
+	}
+	a.logger.Info("successfully revoked envelope",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+	)
+	return nil
+}
+
+// Delete publishes tombstones for the pages, entry, and envelope with the given key, which replace
+// them in libri. Like Revoke, it is only best-effort, and other envelopes of the entry (e.g., from
+// Share) can no longer be downloaded either. Since pages from content-defined chunking may be
+// shared by revisions uploaded with the same keys, those revisions may no longer be downloaded
+// either. The envelope is deleted last, so a failed Delete can be retried. The author must have the
+// envelope's author key. It also deletes the entry's local upload checkpoint, if any.
+func (a *Author) Delete(envelopeKey id.ID) error {
+	lc, err := a.librarians.Next()
+	if err != nil {
+		return err
+	}
+	envelope, err := a.acquirer.Acquire(envelopeKey, nil, lc)
+	if err != nil {
+		return err
+	}
+	if envelope.GetTombstone() != nil {
+		return ship.ErrEnvelopeRevoked
+	}
+	authorPub, _, entryKey, err := pack.SeparateEnvelopeDoc(envelope)
+	if err != nil {
+		return err
+	}
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return ErrNotEnvelopeAuthor
+	}
+	entry, err := a.acquirer.Acquire(entryKey, authorPub, lc)
+	if err != nil {
+		return err
+	}
+	if entry.GetTombstone() == nil {
+		// entry tombstone means a previous Delete already deleted its pages
+		pageKeys, err := api.GetEntryPageKeys(entry)
+		if err != nil {
+			return err
+		}
+		for _, pageKey := range pageKeys {
+			if err := a.publishTombstone(authorID, pageKey, lc); err != nil {
+				return err
+			}
+		}
+		if err := a.publishTombstone(authorID, entryKey, lc); err != nil {
+			return err
+		}
+	}
+	if err := a.publishTombstone(authorID, envelopeKey, lc); err != nil {
+		return err
+	}
+	// a later upload of a tombstoned page must publish it again rather than resume past it
+	if err := a.deleteUploadCheckpoint(entryKey); err != nil {
+		return err
+	}
+	a.logger.Info("successfully deleted document",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+		zap.String(LoggerEntryKey, entryKey.String()),
+	)
+	return nil
+}
+
+// publishTombstone publishes a tombstone by the author for the document with the given key.
+func (a *Author) publishTombstone(authorID ecid.ID, docKey id.ID, lc api.Putter) error {
+	tombstone, err := client.NewTombstone(authorID, docKey)
+	if err != nil {
+		return err
+	}
+	doc := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	_, err = a.publisher.Publish(doc, ecid.ToPublicKeyBytes(authorID), lc)
+	return err
+}
+
+// shareEntryKeys encrypts the entry keys for the reader and publishes them in a new envelope,
+// which it records in the ledger of key usage.
+func (a *Author) shareEntryKeys(
+	authorID ecid.ID,
+	eek *enc.Keys,
+	readerPub []byte,
+	entryKey id.ID,
+	attrs *api.EntryAttributes,
+) (*api.Document, id.ID, error) {
+	readerPubKey, err := ecid.FromPublicKeyBytes(readerPub)
+	if err != nil {
+		return nil, nil, err
+	}
+	kek, err := enc.NewKeys(authorID.Key(), readerPubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	encKeys, err := enc.EncryptKeys(eek, kek)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	envelope := pack.NewSharedEnvelopeDoc(authorPub, readerPub, entryKey, encKeys, attrs)
+	lc, err := a.librarians.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	envelopeKey, err := a.publisher.Publish(envelope, authorPub, lc)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = a.keyLedger.Record(&KeyUsageRecord{
+		EnvelopeKey:     envelopeKey.Bytes(),
+		EntryKey:        entryKey.Bytes(),
+		AuthorPublicKey: authorPub,
+		ReaderPublicKey: readerPub,
+		Shared:          true,
+		PublishedTime:   time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return envelope, envelopeKey, nil
+}
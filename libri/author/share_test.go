@@ -0,0 +1,386 @@
+package author
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/pack"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_Share_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	a.librarians, b.librarians = &fixedClientBalancer{}, &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.publisher = pubAcq
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, b.documentSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, b.config.Publish)
+	b.receiver = ship.NewReceiver(b.librarians, b.selfReaderKeys, pubAcq, msAcquirer,
+		b.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	content1 := common.NewCompressableBytes(rng, 1024)
+	content1Bytes := content1.Bytes()
+	envelope, _, err := a.Upload(content1, "application/x-gzip")
+	assert.Nil(t, err)
+
+	readerID1, err := b.selfReaderKeys.Sample()
+	assert.Nil(t, err)
+	readerID2 := ecid.NewPseudoRandom(rng)
+	readerPubs := [][]byte{ecid.ToPublicKeyBytes(readerID1), ecid.ToPublicKeyBytes(readerID2)}
+	shared, sharedKeys, err := a.Share(envelope, readerPubs)
+	assert.Nil(t, err)
+	assert.Len(t, shared, 2)
+	assert.Len(t, sharedKeys, 2)
+	for i, s := range shared {
+		_, readerPub, _, err := pack.SeparateEnvelopeDoc(s)
+		assert.Nil(t, err)
+		assert.Equal(t, readerPubs[i], readerPub)
+	}
+
+	// check other author can download with their reader key
+	content2 := new(bytes.Buffer)
+	err = b.Download(content2, sharedKeys[0])
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes, content2.Bytes())
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
+
+func TestAuthor_Share_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	selfReaderID, err := a.selfReaderKeys.Sample()
+	assert.Nil(t, err)
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	readerPubs := [][]byte{otherPub}
+	entryKey := api.RandBytes(rng, 32)
+	newTestEnvelope := func(authorPub, readerPub []byte) *api.Document {
+		return &api.Document{
+			Contents: &api.Document_Envelope{
+				Envelope: &api.Envelope{
+					AuthorPublicKey: authorPub,
+					ReaderPublicKey: readerPub,
+					EntryKey:        entryKey,
+				},
+			},
+		}
+	}
+	authorPub, selfReaderPub := ecid.ToPublicKeyBytes(authorID),
+		ecid.ToPublicKeyBytes(selfReaderID)
+
+	// check non-envelope error bubbles up
+	entry, _ := api.NewTestDocument(rng)
+	_, _, err = a.Share(entry, readerPubs)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+
+	// check missing author and self reader keys
+	_, _, err = a.Share(newTestEnvelope(otherPub, selfReaderPub), readerPubs)
+	assert.Equal(t, keychain.ErrUnexpectedMissingKey, err)
+	_, _, err = a.Share(newTestEnvelope(authorPub, otherPub), readerPubs)
+	assert.Equal(t, keychain.ErrUnexpectedMissingKey, err)
+
+	// check invalid reader public key
+	envelope := newTestEnvelope(authorPub, selfReaderPub)
+	_, _, err = a.Share(envelope, [][]byte{{1, 2, 3}})
+	assert.Equal(t, ecid.ErrKeyPointOffCurve, err)
+
+	// check librarian balancer error bubbles up
+	a.librarians = &fixedClientBalancer{err: errors.New("some Next error")}
+	shared, sharedKeys, err := a.Share(envelope, readerPubs)
+	assert.NotNil(t, err)
+	assert.Nil(t, shared)
+	assert.Nil(t, sharedKeys)
+
+	a.librarians = &fixedClientBalancer{}
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_ShareExisting_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	a.librarians, b.librarians = &fixedClientBalancer{}, &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, b.documentSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, b.config.Publish)
+	b.receiver = ship.NewReceiver(b.librarians, b.selfReaderKeys, pubAcq, msAcquirer,
+		b.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	content1 := common.NewCompressableBytes(rng, 1024)
+	content1Bytes := content1.Bytes()
+	_, envelopeKey, err := a.Upload(content1, "application/x-gzip")
+	assert.Nil(t, err)
+
+	// share self envelope with some other reader and then forward that envelope to b
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	_, otherEnvelopeKey, err := a.ShareExisting(envelopeKey, otherPub)
+	assert.Nil(t, err)
+	readerID, err := b.selfReaderKeys.Sample()
+	assert.Nil(t, err)
+	shared, sharedKey, err := a.ShareExisting(otherEnvelopeKey, ecid.ToPublicKeyBytes(readerID))
+	assert.Nil(t, err)
+	assert.NotNil(t, shared)
+
+	// check b can download with their reader key
+	content2 := new(bytes.Buffer)
+	err = b.Download(content2, sharedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, content1Bytes, content2.Bytes())
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
+
+func TestAuthor_ShareExisting_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	readerPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	entryKey := id.NewPseudoRandom(rng)
+
+	// check librarian balancer error bubbles up
+	a.librarians = &fixedClientBalancer{err: errors.New("some Next error")}
+	shared, sharedKey, err := a.ShareExisting(entryKey, readerPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, shared)
+	assert.Nil(t, sharedKey)
+	a.librarians = &fixedClientBalancer{}
+
+	// check non-envelope error bubbles up
+	entry, entryDocKey := api.NewTestDocument(rng)
+	_, err = pubAcq.Publish(entry, nil, nil)
+	assert.Nil(t, err)
+	_, _, err = a.ShareExisting(entryDocKey, readerPub)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+
+	// check missing author key
+	envelope := pack.NewEnvelopeDoc(otherPub, readerPub, entryKey, nil)
+	otherEnvelopeKey, err := pubAcq.Publish(envelope, nil, nil)
+	assert.Nil(t, err)
+	_, _, err = a.ShareExisting(otherEnvelopeKey, readerPub)
+	assert.Equal(t, ErrNotEnvelopeAuthor, err)
+
+	// check invalid new reader key
+	envelope = pack.NewEnvelopeDoc(authorPub, readerPub, entryKey, nil)
+	envelopeKey, err := pubAcq.Publish(envelope, nil, nil)
+	assert.Nil(t, err)
+	_, _, err = a.ShareExisting(envelopeKey, []byte{1, 2, 3})
+	assert.Equal(t, ecid.ErrKeyPointOffCurve, err)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_Revoke_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	a.librarians, b.librarians = &fixedClientBalancer{}, &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, b.documentSL)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, b.config.Publish)
+	b.receiver = ship.NewReceiver(b.librarians, b.selfReaderKeys, pubAcq, msAcquirer,
+		b.documentSL)
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	_, envelopeKey, err := a.Upload(common.NewCompressableBytes(rng, 1024), "application/x-gzip")
+	assert.Nil(t, err)
+	readerID, err := b.selfReaderKeys.Sample()
+	assert.Nil(t, err)
+	_, sharedKey, err := a.ShareExisting(envelopeKey, ecid.ToPublicKeyBytes(readerID))
+	assert.Nil(t, err)
+	assert.Nil(t, b.Download(new(bytes.Buffer), sharedKey))
+
+	// check b can't download once revoked
+	err = a.Revoke(sharedKey)
+	assert.Nil(t, err)
+	err = b.Download(new(bytes.Buffer), sharedKey)
+	assert.Equal(t, ship.ErrEnvelopeRevoked, err)
+
+	// check revoking again is a no-op
+	err = a.Revoke(sharedKey)
+	assert.Nil(t, err)
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
+
+func TestAuthor_Revoke_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	readerPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	entryKey := id.NewPseudoRandom(rng)
+
+	// check librarian balancer error bubbles up
+	a.librarians = &fixedClientBalancer{err: errors.New("some Next error")}
+	err := a.Revoke(entryKey)
+	assert.NotNil(t, err)
+	a.librarians = &fixedClientBalancer{}
+
+	// check non-envelope error bubbles up
+	entry, entryDocKey := api.NewTestDocument(rng)
+	_, err = pubAcq.Publish(entry, nil, nil)
+	assert.Nil(t, err)
+	err = a.Revoke(entryDocKey)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+
+	// check missing author key
+	envelope := pack.NewEnvelopeDoc(otherPub, readerPub, entryKey, nil)
+	otherEnvelopeKey, err := pubAcq.Publish(envelope, nil, nil)
+	assert.Nil(t, err)
+	err = a.Revoke(otherEnvelopeKey)
+	assert.Equal(t, ErrNotEnvelopeAuthor, err)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_Delete_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+
+	// just mock interaction with libri network
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	envelope, envelopeKey, err := a.Upload(common.NewCompressableBytes(rng, 1024),
+		"application/x-gzip")
+	assert.Nil(t, err)
+	entryKey := id.FromBytes(envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey)
+	pageKeys, err := api.GetEntryPageKeys(pubAcq.docs[entryKey.String()])
+	assert.Nil(t, err)
+	assert.True(t, len(pageKeys) > 1)
+
+	// leave a checkpoint like a failed upload of the same entry would
+	cp := &UploadCheckpoint{EntryKey: entryKey.Bytes()}
+	assert.Nil(t, saveUploadCheckpoint(a.checkpointSL, cp))
+	pagesSL := storage.NewCheckpointPagesKVDBStorerLoader(a.db, entryKey)
+	assert.Nil(t, pagesSL.Store(pageKeys[0].Bytes(), []byte{1}))
+
+	err = a.Delete(envelopeKey)
+	assert.Nil(t, err)
+
+	// check upload checkpoint and its published pages have been deleted
+	cp, err = loadUploadCheckpoint(a.checkpointSL)
+	assert.Nil(t, err)
+	assert.Nil(t, cp)
+	published, err := pagesSL.Load(pageKeys[0].Bytes())
+	assert.Nil(t, err)
+	assert.Nil(t, published)
+
+	// check envelope, entry, and pages have all been replaced by tombstones
+	for _, docKey := range append(pageKeys, entryKey, envelopeKey) {
+		tombstone := pubAcq.docs[docKey.String()].GetTombstone()
+		assert.NotNil(t, tombstone)
+		assert.Equal(t, docKey.Bytes(), tombstone.DocumentKey)
+	}
+
+	// check deleting again triggers error
+	err = a.Delete(envelopeKey)
+	assert.Equal(t, ship.ErrEnvelopeRevoked, err)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_Delete_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	readerPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	entryKey := id.NewPseudoRandom(rng)
+
+	// check librarian balancer error bubbles up
+	a.librarians = &fixedClientBalancer{err: errors.New("some Next error")}
+	err = a.Delete(entryKey)
+	assert.NotNil(t, err)
+	a.librarians = &fixedClientBalancer{}
+
+	// check non-envelope error bubbles up
+	entry, entryDocKey := api.NewTestDocument(rng)
+	_, err = pubAcq.Publish(entry, nil, nil)
+	assert.Nil(t, err)
+	err = a.Delete(entryDocKey)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+
+	// check missing author key
+	envelope := pack.NewEnvelopeDoc(otherPub, readerPub, entryDocKey, nil)
+	otherEnvelopeKey, err := pubAcq.Publish(envelope, nil, nil)
+	assert.Nil(t, err)
+	err = a.Delete(otherEnvelopeKey)
+	assert.Equal(t, ErrNotEnvelopeAuthor, err)
+
+	// check non-entry error bubbles up
+	envelope = pack.NewEnvelopeDoc(authorPub, readerPub, otherEnvelopeKey, nil)
+	envelopeKey, err := pubAcq.Publish(envelope, nil, nil)
+	assert.Nil(t, err)
+	err = a.Delete(envelopeKey)
+	assert.Equal(t, api.ErrUnexpectedDocumentType, err)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
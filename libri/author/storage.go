@@ -1,6 +1,7 @@
 package author
 
 import (
+	"crypto"
 	"os"
 	"path"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/golang/protobuf/proto"
 	"go.uber.org/zap"
 	"fmt"
@@ -43,16 +45,16 @@ const (
 
 	// SelfReaderKeychainFilename defines the self reader keychain filename
 	SelfReaderKeychainFilename = "self-reader.keys"
-
-	// nInitialKeys is the number of keys to generate on a keychain
-	nInitialKeys = 64
 )
 
 var (
 	clientIDKey = []byte("ClientID")
 )
 
-func loadOrCreateClientID(logger *zap.Logger, nsl storage.NamespaceStorerLoader) (ecid.ID, error) {
+// loadOrCreateClientID loads the saved client ID or creates and saves a new one on the named curve.
+func loadOrCreateClientID(
+	logger *zap.Logger, nsl storage.NamespaceStorerLoader, curveName string,
+) (ecid.ID, error) {
 	bytes, err := nsl.Load(clientIDKey)
 	if err != nil {
 		logger.Error("error loading client ID", zap.Error(err))
@@ -76,12 +78,30 @@ func loadOrCreateClientID(logger *zap.Logger, nsl storage.NamespaceStorerLoader)
 	}
 
 	// return new client ID
-	clientID := ecid.NewRandom()
-	logger.Info("created new client ID", zap.String(LoggerClientID, clientID.String()))
+	clientID, err := ecid.NewRandomOnCurve(curveName, 0)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("created new client ID",
+		zap.String(LoggerClientID, clientID.String()),
+		zap.String("curve", clientID.CurveName()),
+	)
 
 	return clientID, saveClientID(nsl, clientID)
 }
 
+// loadKeySignerClientID returns the key signer's public key as the client ID, which has no
+// private key.
+func loadKeySignerClientID(logger *zap.Logger, key crypto.Signer) (ecid.ID, error) {
+	clientID, err := client.KeySignerID(key)
+	if err != nil {
+		logger.Error("error loading key signer public key", zap.Error(err))
+		return nil, err
+	}
+	logger.Info("using key signer for client ID", zap.String(LoggerClientID, clientID.String()))
+	return clientID, nil
+}
+
 func saveClientID(ns storage.NamespaceStorer, clientID ecid.ID) error {
 	bytes, err := proto.Marshal(ecid.ToStored(clientID))
 	if err != nil {
@@ -108,41 +128,157 @@ func LoadKeychains(keychainDir, auth string) (keychain.Keychain, keychain.Keycha
 	return authorKeys, selfReaderKeys, nil
 }
 
+// LoadLockedKeychains loads the author and self-reader keychains from a directory on the local
+// filesystem, decrypting each private key only when first used and keeping it in locked memory
+// until the keychain is closed.
+func LoadLockedKeychains(keychainDir, auth string) (
+	keychain.LockedKeychain, keychain.LockedKeychain, error) {
+	authorKeychainFilepath := path.Join(keychainDir, AuthorKeychainFilename)
+	authorKeys, err := keychain.LoadLocked(authorKeychainFilepath, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selfReaderKeychainPath := path.Join(keychainDir, SelfReaderKeychainFilename)
+	selfReaderKeys, err := keychain.LoadLocked(selfReaderKeychainPath, auth)
+	if err != nil {
+		_ = authorKeys.Close()
+		return nil, nil, err
+	}
+
+	return authorKeys, selfReaderKeys, nil
+}
+
+// MigrateKeychains migrates the author and self-reader keychain files in the given keychain
+// directory to the current keychain format version.
+func MigrateKeychains(logger *zap.Logger, keychainDir string) error {
+	for _, filename := range []string{AuthorKeychainFilename, SelfReaderKeychainFilename} {
+		filepath := path.Join(keychainDir, filename)
+		version, err := keychain.Migrate(filepath)
+		if err != nil {
+			return err
+		}
+		if version != keychain.CurrentKeychainVersion {
+			logger.Info("migrated keychain",
+				zap.String(LoggerKeychainFilepath, filepath),
+				zap.Uint32("from_version", version),
+				zap.Uint32("to_version", keychain.CurrentKeychainVersion),
+			)
+		}
+	}
+	return nil
+}
+
 // CreateKeychains creates the author and self reader keychains in the given keychain directory with
-// the given authentication passphrase and Scrypt parameters.
-func CreateKeychains(logger *zap.Logger, keychainDir, auth string, scryptN, scryptP int) error {
+// the given authentication passphrase and keychain parameters.
+func CreateKeychains(
+	logger *zap.Logger, keychainDir, auth string, params *keychain.Parameters,
+) error {
+	newKeys := func(account uint32) (keychain.Keychain, error) {
+		return keychain.New(params.NKeys), nil
+	}
+	return createKeychains(logger, keychainDir, auth, newKeys, params)
+}
+
+// CreateKeychainsFromSeed creates the author and self reader keychains in the given keychain
+// directory like CreateKeychains, except their keys are derived from the seed, so backing up the
+// seed is enough to recreate both keychains.
+func CreateKeychainsFromSeed(
+	logger *zap.Logger, keychainDir, auth string, seed []byte, params *keychain.Parameters,
+) error {
+	newKeys := func(account uint32) (keychain.Keychain, error) {
+		return keychain.FromSeed(seed, account, params.NKeys)
+	}
+	return createKeychains(logger, keychainDir, auth, newKeys, params)
+}
+
+// CreateKeychainsFromMnemonic creates the author and self reader keychains in the given keychain
+// directory like CreateKeychainsFromSeed, using the seed of the mnemonic and its (optional)
+// passphrase, which may differ from the keychains' authentication passphrase.
+func CreateKeychainsFromMnemonic(
+	logger *zap.Logger,
+	keychainDir, auth, mnemonic, mnemonicPassphrase string,
+	params *keychain.Parameters,
+) error {
+	seed, err := keychain.MnemonicToSeed(mnemonic, mnemonicPassphrase)
+	if err != nil {
+		return err
+	}
+	return CreateKeychainsFromSeed(logger, keychainDir, auth, seed, params)
+}
+
+func createKeychains(
+	logger *zap.Logger,
+	keychainDir, auth string,
+	newKeys func(account uint32) (keychain.Keychain, error),
+	params *keychain.Parameters,
+) error {
 	if _, err := os.Stat(keychainDir); os.IsNotExist(err) {
 		err := os.MkdirAll(keychainDir, os.ModePerm)
 		if err != nil {
 			return err
 		}
 	}
+	authorKeys, err := newKeys(keychain.AuthorAccount)
+	if err != nil {
+		return err
+	}
+	if err = setUsage(authorKeys, keychain.AuthorUsage|keychain.ReaderUsage); err != nil {
+		return err
+	}
 	authorKeychainFP := path.Join(keychainDir, AuthorKeychainFilename)
-	if err := CreateKeychain(logger, authorKeychainFP, auth, scryptN, scryptP); err != nil {
+	err = createKeychain(logger, authorKeychainFP, auth, authorKeys, params)
+	if err != nil {
+		return err
+	}
+	selfReaderKeys, err := newKeys(keychain.SelfReaderAccount)
+	if err != nil {
+		return err
+	}
+	if err = setUsage(selfReaderKeys, keychain.ReaderUsage); err != nil {
 		return err
 	}
 	selfReaderKeysFP := path.Join(keychainDir, SelfReaderKeychainFilename)
-	if err := CreateKeychain(logger, selfReaderKeysFP, auth, scryptN, scryptP); err != nil {
+	err = createKeychain(logger, selfReaderKeysFP, auth, selfReaderKeys, params)
+	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// CreateKeychain creates a keychain in the given filepath with the given auth and Scrypt params.
-func CreateKeychain(logger *zap.Logger, filepath, auth string, scryptN, scryptP int) error {
+// setUsage sets the usage of every key in the keychain, since author keys may be used as either
+// author or reader keys but self reader keys are never used as author keys.
+func setUsage(kc keychain.Keychain, usage keychain.KeyUsage) error {
+	for _, pub := range kc.PublicKeys() {
+		md, _ := kc.Metadata(pub)
+		md.Usage = usage
+		if err := kc.SetMetadata(pub, md); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateKeychain creates a keychain in the given filepath with the given auth and keychain params.
+func CreateKeychain(logger *zap.Logger, filepath, auth string, params *keychain.Parameters) error {
+	return createKeychain(logger, filepath, auth, keychain.New(params.NKeys), params)
+}
+
+func createKeychain(
+	logger *zap.Logger, filepath, auth string, keys keychain.Keychain, params *keychain.Parameters,
+) error {
 	if info, _ := os.Stat(filepath); info != nil {
 		logger.Error("keychain already exists",
 			zap.String(LoggerKeychainFilepath, filepath))
 		return ErrKeychainExists
 	}
 
-	keys := keychain.New(nInitialKeys)
-	err := keychain.Save(filepath, auth, keys, scryptN, scryptP)
+	err := keychain.Save(filepath, auth, keys, params.ScryptN, params.ScryptP)
 	if err != nil {
 		return err
 	}
 	logger.Info("saved new keychain", zap.String(LoggerKeychainFilepath, filepath),
-		zap.Int(LoggerKeychainNKeys, nInitialKeys))
+		zap.Int(LoggerKeychainNKeys, keys.Len()))
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/drausin/libri/libri/common/ecid"
 	clogging "github.com/drausin/libri/libri/common/logging"
 	"github.com/golang/protobuf/proto"
@@ -19,20 +20,34 @@ const (
 	veryLightScryptP = 1
 )
 
+var veryLightKeychainParams = &keychain.Parameters{
+	NKeys:   keychain.DefaultNKeys,
+	ScryptN: veryLightScryptN,
+	ScryptP: veryLightScryptP,
+}
+
 func TestLoadOrCreateClientID_ok(t *testing.T) {
 
 	// create new client ID
-	id1, err := loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{})
+	id1, err := loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		ecid.CurveName)
 	assert.NotNil(t, id1)
 	assert.Nil(t, err)
 
+	// create new Ed25519 client ID
+	id1, err = loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		ecid.Ed25519CurveName)
+	assert.Nil(t, err)
+	assert.Equal(t, ecid.Ed25519CurveName, id1.CurveName())
+
 	// load existing
 	rng := rand.New(rand.NewSource(0))
 	peerID2 := ecid.NewPseudoRandom(rng)
 	bytes, err := proto.Marshal(ecid.ToStored(peerID2))
 	assert.Nil(t, err)
 
-	id2, err := loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{loadBytes: bytes})
+	id2, err := loadOrCreateClientID(clogging.NewDevInfoLogger(),
+		&fixedStorerLoader{loadBytes: bytes}, ecid.CurveName)
 
 	assert.Equal(t, peerID2, id2)
 	assert.Nil(t, err)
@@ -41,15 +56,20 @@ func TestLoadOrCreateClientID_ok(t *testing.T) {
 func TestLoadOrCreatePeerID_err(t *testing.T) {
 	id1, err := loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{
 		loadErr: errors.New("some load error"),
-	})
+	}, ecid.CurveName)
 	assert.Nil(t, id1)
 	assert.NotNil(t, err)
 
 	id2, err := loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{
 		loadBytes: []byte("the wrong bytes"),
-	})
+	}, ecid.CurveName)
 	assert.Nil(t, id2)
 	assert.NotNil(t, err)
+
+	id3, err := loadOrCreateClientID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		"other curve")
+	assert.Nil(t, id3)
+	assert.Equal(t, ecid.ErrUnknownCurve, err)
 }
 
 func TestSaveClientID(t *testing.T) {
@@ -64,14 +84,24 @@ func TestLoadKeychains(t *testing.T) {
 	auth := "some secret passphrase"
 
 	err = CreateKeychains(clogging.NewDevInfoLogger(), testKeychainDir, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 
 	// check our keychains load properly and have the expected length
 	authorKeys, selfReaderKeys, err := LoadKeychains(testKeychainDir, auth)
 	assert.Nil(t, err)
-	assert.Equal(t, nInitialKeys, authorKeys.Len())
-	assert.Equal(t, nInitialKeys, selfReaderKeys.Len())
+	assert.Equal(t, keychain.DefaultNKeys, authorKeys.Len())
+	assert.Equal(t, keychain.DefaultNKeys, selfReaderKeys.Len())
+
+	// check keys have the usage of their keychain
+	for _, pub := range authorKeys.PublicKeys() {
+		md, _ := authorKeys.Metadata(pub)
+		assert.Equal(t, keychain.AuthorUsage|keychain.ReaderUsage, md.Usage)
+	}
+	for _, pub := range selfReaderKeys.PublicKeys() {
+		md, _ := selfReaderKeys.Metadata(pub)
+		assert.Equal(t, keychain.ReaderUsage, md.Usage)
+	}
 
 	// delete self reader keychain to trigger error
 	err = os.Remove(path.Join(testKeychainDir, SelfReaderKeychainFilename))
@@ -94,6 +124,65 @@ func TestLoadKeychains(t *testing.T) {
 	assert.Nil(t, selfReaderKeys)
 }
 
+func TestLoadLockedKeychains(t *testing.T) {
+	testKeychainDir, err := ioutil.TempDir("", "author-test-keychains")
+	defer rmDir(testKeychainDir)
+	assert.Nil(t, err)
+	auth := "some secret passphrase"
+
+	err = CreateKeychains(clogging.NewDevInfoLogger(), testKeychainDir, auth,
+		veryLightKeychainParams)
+	assert.Nil(t, err)
+
+	// check locked keychains have the same keys as unlocked ones
+	authorKeys1, selfReaderKeys1, err := LoadKeychains(testKeychainDir, auth)
+	assert.Nil(t, err)
+	authorKeys2, selfReaderKeys2, err := LoadLockedKeychains(testKeychainDir, auth)
+	assert.Nil(t, err)
+	assert.Equal(t, authorKeys1.PublicKeys(), authorKeys2.PublicKeys())
+	assert.Equal(t, selfReaderKeys1.PublicKeys(), selfReaderKeys2.PublicKeys())
+	assert.Nil(t, authorKeys2.Close())
+	assert.Nil(t, selfReaderKeys2.Close())
+
+	// check wrong passphrase triggers error
+	authorKeys2, selfReaderKeys2, err = LoadLockedKeychains(testKeychainDir, "wrong passphrase")
+	assert.NotNil(t, err)
+	assert.Nil(t, authorKeys2)
+	assert.Nil(t, selfReaderKeys2)
+
+	// check missing self reader keychain file triggers error
+	err = os.Remove(path.Join(testKeychainDir, SelfReaderKeychainFilename))
+	assert.Nil(t, err)
+	authorKeys2, selfReaderKeys2, err = LoadLockedKeychains(testKeychainDir, auth)
+	assert.NotNil(t, err)
+	assert.Nil(t, authorKeys2)
+	assert.Nil(t, selfReaderKeys2)
+}
+
+func TestMigrateKeychains(t *testing.T) {
+	testKeychainDir, err := ioutil.TempDir("", "author-test-keychains")
+	defer rmDir(testKeychainDir)
+	assert.Nil(t, err)
+	logger := clogging.NewDevInfoLogger()
+
+	// check missing keychains error
+	err = MigrateKeychains(logger, testKeychainDir)
+	assert.NotNil(t, err)
+
+	err = CreateKeychains(logger, testKeychainDir, "some secret passphrase",
+		veryLightKeychainParams)
+	assert.Nil(t, err)
+
+	// check keychains have the current version after migrating
+	err = MigrateKeychains(logger, testKeychainDir)
+	assert.Nil(t, err)
+	for _, filename := range []string{AuthorKeychainFilename, SelfReaderKeychainFilename} {
+		version, err := keychain.Version(path.Join(testKeychainDir, filename))
+		assert.Nil(t, err)
+		assert.Equal(t, keychain.CurrentKeychainVersion, version)
+	}
+}
+
 func TestCreateKeychains_ok(t *testing.T) {
 	testKeychainDir, err := ioutil.TempDir("", "author-test-keychains")
 	defer rmDir(testKeychainDir)
@@ -102,13 +191,13 @@ func TestCreateKeychains_ok(t *testing.T) {
 
 	// check creating in existing dir is fine
 	err = CreateKeychains(clogging.NewDevInfoLogger(), testKeychainDir, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 
 	// check creating in new dir is fine
 	testKeychainSubDir := path.Join(testKeychainDir, "sub")
 	err = CreateKeychains(clogging.NewDevInfoLogger(), testKeychainSubDir, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 }
 
@@ -124,15 +213,79 @@ func TestCreateKeychains_err(t *testing.T) {
 
 	// check create self reader keychain error bubbles up
 	err = CreateKeychains(clogging.NewDevInfoLogger(), testKeychainDir, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.NotNil(t, err)
 
 	// check create author keychain error bubbles up
 	err = CreateKeychains(clogging.NewDevInfoLogger(), testKeychainDir, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.NotNil(t, err)
 }
 
+func TestCreateKeychainsFromSeed(t *testing.T) {
+	testKeychainDir1, err := ioutil.TempDir("", "author-test-keychains")
+	defer rmDir(testKeychainDir1)
+	assert.Nil(t, err)
+	testKeychainDir2, err := ioutil.TempDir("", "author-test-keychains")
+	defer rmDir(testKeychainDir2)
+	assert.Nil(t, err)
+	auth := "some secret passphrase"
+	seed, err := keychain.NewSeed()
+	assert.Nil(t, err)
+
+	// check keychains created from the same seed have the same keys
+	err = CreateKeychainsFromSeed(clogging.NewDevInfoLogger(), testKeychainDir1, auth, seed,
+		veryLightKeychainParams)
+	assert.Nil(t, err)
+	err = CreateKeychainsFromSeed(clogging.NewDevInfoLogger(), testKeychainDir2, auth, seed,
+		veryLightKeychainParams)
+	assert.Nil(t, err)
+	authorKeys1, selfReaderKeys1, err := LoadKeychains(testKeychainDir1, auth)
+	assert.Nil(t, err)
+	authorKeys2, selfReaderKeys2, err := LoadKeychains(testKeychainDir2, auth)
+	assert.Nil(t, err)
+	assert.Equal(t, authorKeys1, authorKeys2)
+	assert.Equal(t, selfReaderKeys1, selfReaderKeys2)
+	assert.NotEqual(t, authorKeys1, selfReaderKeys1)
+
+	// check invalid seed error bubbles up
+	testKeychainSubDir := path.Join(testKeychainDir1, "sub")
+	err = CreateKeychainsFromSeed(clogging.NewDevInfoLogger(), testKeychainSubDir, auth,
+		[]byte{}, veryLightKeychainParams)
+	assert.Equal(t, keychain.ErrInvalidSeedLength, err)
+}
+
+func TestCreateKeychainsFromMnemonic(t *testing.T) {
+	testKeychainDir, err := ioutil.TempDir("", "author-test-keychains")
+	defer rmDir(testKeychainDir)
+	assert.Nil(t, err)
+	auth, mnemonicPassphrase := "some secret passphrase", "some mnemonic passphrase"
+	mnemonic, err := keychain.NewMnemonic()
+	assert.Nil(t, err)
+
+	// check keychains have the keys restored from the mnemonic
+	err = CreateKeychainsFromMnemonic(clogging.NewDevInfoLogger(), testKeychainDir, auth,
+		mnemonic, mnemonicPassphrase, veryLightKeychainParams)
+	assert.Nil(t, err)
+	authorKeys, selfReaderKeys, err := LoadKeychains(testKeychainDir, auth)
+	assert.Nil(t, err)
+	nKeys := veryLightKeychainParams.NKeys
+	restoredAuthorKeys, err := keychain.Restore(mnemonic, mnemonicPassphrase,
+		keychain.AuthorAccount, nKeys)
+	assert.Nil(t, err)
+	restoredSelfReaderKeys, err := keychain.Restore(mnemonic, mnemonicPassphrase,
+		keychain.SelfReaderAccount, nKeys)
+	assert.Nil(t, err)
+	assert.Equal(t, restoredAuthorKeys.PublicKeys(), authorKeys.PublicKeys())
+	assert.Equal(t, restoredSelfReaderKeys.PublicKeys(), selfReaderKeys.PublicKeys())
+
+	// check invalid mnemonic error bubbles up
+	testKeychainSubDir := path.Join(testKeychainDir, "sub")
+	err = CreateKeychainsFromMnemonic(clogging.NewDevInfoLogger(), testKeychainSubDir, auth,
+		"not a mnemonic", mnemonicPassphrase, veryLightKeychainParams)
+	assert.Equal(t, keychain.ErrInvalidMnemonic, err)
+}
+
 func TestCreateKeychain(t *testing.T) {
 	testKeychainDir, err := ioutil.TempDir("", "author-test-keychains")
 	defer rmDir(testKeychainDir)
@@ -141,7 +294,7 @@ func TestCreateKeychain(t *testing.T) {
 	auth := "some secret passphrase"
 
 	err = CreateKeychain(clogging.NewDevInfoLogger(), authorKeychainFP, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 
 	// check keychain file exists
@@ -151,12 +304,13 @@ func TestCreateKeychain(t *testing.T) {
 
 	// check attempt to create keychain in same file returns error
 	err = CreateKeychain(clogging.NewDevInfoLogger(), authorKeychainFP, auth,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Equal(t, ErrKeychainExists, err)
 
 	// check save error bubbles up
 	otherAuthorKeychainFP := path.Join(testKeychainDir, "other-author.keys")
-	err = CreateKeychain(clogging.NewDevInfoLogger(), otherAuthorKeychainFP, auth, -1, -1)
+	err = CreateKeychain(clogging.NewDevInfoLogger(), otherAuthorKeychainFP, auth,
+		&keychain.Parameters{NKeys: 1, ScryptN: -1, ScryptP: -1})
 	assert.NotNil(t, err)
 }
 
@@ -0,0 +1,132 @@
+package author
+
+import (
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUploadManifestTooShort indicates when an encrypted upload manifest's ciphertext is too
+	// short to contain its nonce.
+	ErrUploadManifestTooShort = errors.New("encrypted upload manifest too short")
+)
+
+// SaveUploadManifest saves the records of the uploads in the local index of uploads, including
+// their envelope, entry, author, and reader keys, to a manifest file encrypted with a key derived
+// from the passphrase by Argon2id with the given parameters. With the manifest and the author's
+// keychains, access to the uploads can be recovered after reinstalling with LoadUploadManifest.
+func (a *Author) SaveUploadManifest(
+	filepath, auth string, params *keychain.Argon2idParameters,
+) error {
+	records, err := a.ListUploads(nil)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encryptUploadManifest(&UploadManifest{Uploads: records}, auth, params)
+	if err != nil {
+		return err
+	}
+	buf, err := proto.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+	const filePerm = 0600 // only user can read
+	if err := ioutil.WriteFile(filepath, buf, filePerm); err != nil {
+		return err
+	}
+	a.logger.Info("saved upload manifest",
+		zap.String("filepath", filepath),
+		zap.Int("n_uploads", len(records)),
+	)
+	return nil
+}
+
+// LoadUploadManifest decrypts the manifest file saved by SaveUploadManifest with the passphrase
+// and restores its records to the local index of uploads. It returns the restored records.
+func (a *Author) LoadUploadManifest(filepath, auth string) ([]*UploadRecord, error) {
+	buf, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := &EncryptedUploadManifest{}
+	if err := proto.Unmarshal(buf, encrypted); err != nil {
+		return nil, err
+	}
+	manifest, err := decryptUploadManifest(encrypted, auth)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range manifest.Uploads {
+		value, err := proto.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.uploadSL.Store(record.EnvelopeKey, value); err != nil {
+			return nil, err
+		}
+	}
+	a.logger.Info("loaded upload manifest",
+		zap.String("filepath", filepath),
+		zap.Int("n_uploads", len(manifest.Uploads)),
+	)
+	return manifest.Uploads, nil
+}
+
+func encryptUploadManifest(
+	manifest *UploadManifest, auth string, params *keychain.Argon2idParameters,
+) (*EncryptedUploadManifest, error) {
+	kdf, err := keychain.NewArgon2idKDF(params)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := keychain.NewAEAD(kdf.Header(), auth)
+	if err != nil {
+		return nil, err
+	}
+	kdfBytes, err := proto.Marshal(kdf.Header())
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := proto.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &EncryptedUploadManifest{
+		Kdf:        kdfBytes,
+		Ciphertext: aead.Seal(nonce, nonce, plaintext, nil),
+	}, nil
+}
+
+func decryptUploadManifest(encrypted *EncryptedUploadManifest, auth string) (
+	*UploadManifest, error) {
+	header := &keychain.KDFHeader{}
+	if err := proto.Unmarshal(encrypted.Kdf, header); err != nil {
+		return nil, err
+	}
+	aead, err := keychain.NewAEAD(header, auth)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Ciphertext) < aead.NonceSize() {
+		return nil, ErrUploadManifestTooShort
+	}
+	nonce := encrypted.Ciphertext[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, encrypted.Ciphertext[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &UploadManifest{}
+	if err := proto.Unmarshal(plaintext, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
@@ -0,0 +1,175 @@
+package author
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+var veryLightArgon2idParams = &keychain.Argon2idParameters{Time: 1, Memory: 8, Threads: 1}
+
+func TestAuthor_SaveLoadUploadManifest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, b := newTestAuthor(), newTestAuthor()
+	dir, err := ioutil.TempDir("", "upload-manifest-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filepath := path.Join(dir, "uploads.manifest")
+
+	nUploads := 3
+	for i := 0; i < nUploads; i++ {
+		metadata, err := api.NewEntryMetadata("application/x-pdf", 1, api.RandBytes(rng, 32),
+			2, api.RandBytes(rng, 32))
+		assert.Nil(t, err)
+		entry, _ := api.NewTestDocument(rng)
+		entry.GetEntry().CreatedTime = int64(i + 1)
+		a.entryPacker = &fixedEntryPacker{entry: entry, metadata: metadata}
+		a.shipper = &fixedShipper{
+			envelope: &api.Document{
+				Contents: &api.Document_Envelope{
+					Envelope: api.NewTestEnvelope(rng),
+				},
+			},
+			envelopeKey: id.NewPseudoRandom(rng),
+		}
+		_, _, err = a.Upload(nil, "")
+		assert.Nil(t, err)
+	}
+	expected, err := a.ListUploads(nil)
+	assert.Nil(t, err)
+	assert.Len(t, expected, nUploads)
+
+	err = a.SaveUploadManifest(filepath, testKeychainAuth, veryLightArgon2idParams)
+	assert.Nil(t, err)
+	info, err := os.Stat(filepath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// check manifest file doesn't contain plaintext keys
+	buf, err := ioutil.ReadFile(filepath)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(buf), string(expected[0].EnvelopeKey))
+
+	// check wrong passphrase can't load manifest
+	records, err := b.LoadUploadManifest(filepath, "wrong auth")
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+
+	// check other author restores records in its index of uploads
+	records, err = b.LoadUploadManifest(filepath, testKeychainAuth)
+	assert.Nil(t, err)
+	assert.Len(t, records, nUploads)
+	for i := range expected {
+		assert.True(t, proto.Equal(expected[i], records[i]))
+	}
+	restored, err := b.ListUploads(nil)
+	assert.Nil(t, err)
+	assert.Len(t, restored, nUploads)
+	for i := range expected {
+		assert.True(t, proto.Equal(expected[i], restored[i]))
+	}
+
+	assert.Nil(t, a.CloseAndRemove())
+	assert.Nil(t, b.CloseAndRemove())
+}
+
+func TestAuthor_SaveUploadManifest_err(t *testing.T) {
+	a := &Author{}
+	dir, err := ioutil.TempDir("", "upload-manifest-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filepath := path.Join(dir, "uploads.manifest")
+
+	// check list error bubbles up
+	a.uploadSL = &fixedStorerLoaderIterator{iterateErr: errors.New("some Iterate error")}
+	err = a.SaveUploadManifest(filepath, testKeychainAuth, veryLightArgon2idParams)
+	assert.NotNil(t, err)
+
+	// check invalid KDF parameters create error
+	a.uploadSL = &fixedStorerLoaderIterator{}
+	err = a.SaveUploadManifest(filepath, testKeychainAuth, &keychain.Argon2idParameters{})
+	assert.Equal(t, keychain.ErrInvalidKDFParameters, err)
+
+	// check write error bubbles up
+	err = a.SaveUploadManifest(path.Join(dir, "missing", "uploads.manifest"), testKeychainAuth,
+		veryLightArgon2idParams)
+	assert.NotNil(t, err)
+}
+
+func TestAuthor_LoadUploadManifest_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := &Author{}
+	dir, err := ioutil.TempDir("", "upload-manifest-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filepath := path.Join(dir, "uploads.manifest")
+
+	// check missing file creates error
+	records, err := a.LoadUploadManifest(filepath, testKeychainAuth)
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+
+	// check unmarshal error bubbles up
+	err = ioutil.WriteFile(filepath, []byte{1, 2, 3}, 0600)
+	assert.Nil(t, err)
+	records, err = a.LoadUploadManifest(filepath, testKeychainAuth)
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+
+	// check store error bubbles up
+	manifest := &UploadManifest{
+		Uploads: []*UploadRecord{{EnvelopeKey: id.NewPseudoRandom(rng).Bytes()}},
+	}
+	encrypted, err := encryptUploadManifest(manifest, testKeychainAuth, veryLightArgon2idParams)
+	assert.Nil(t, err)
+	buf, err := proto.Marshal(encrypted)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath, buf, 0600)
+	assert.Nil(t, err)
+	a.uploadSL = &fixedStorerLoaderIterator{
+		fixedStorerLoader: fixedStorerLoader{storeErr: errors.New("some Store error")},
+	}
+	records, err = a.LoadUploadManifest(filepath, testKeychainAuth)
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+}
+
+func TestDecryptUploadManifest_err(t *testing.T) {
+	manifest := &UploadManifest{}
+	encrypted, err := encryptUploadManifest(manifest, testKeychainAuth, veryLightArgon2idParams)
+	assert.Nil(t, err)
+
+	// check KDF header unmarshal error bubbles up
+	decrypted, err := decryptUploadManifest(&EncryptedUploadManifest{Kdf: []byte{1, 2, 3}},
+		testKeychainAuth)
+	assert.NotNil(t, err)
+	assert.Nil(t, decrypted)
+
+	// check unknown KDF creates error
+	decrypted, err = decryptUploadManifest(&EncryptedUploadManifest{}, testKeychainAuth)
+	assert.Equal(t, keychain.ErrUnknownKDF, err)
+	assert.Nil(t, decrypted)
+
+	// check too-short ciphertext creates error
+	decrypted, err = decryptUploadManifest(&EncryptedUploadManifest{
+		Kdf:        encrypted.Kdf,
+		Ciphertext: encrypted.Ciphertext[:4],
+	}, testKeychainAuth)
+	assert.Equal(t, ErrUploadManifestTooShort, err)
+	assert.Nil(t, decrypted)
+
+	// check modified ciphertext creates error
+	encrypted.Ciphertext[len(encrypted.Ciphertext)-1] ^= 1
+	decrypted, err = decryptUploadManifest(encrypted, testKeychainAuth)
+	assert.NotNil(t, err)
+	assert.Nil(t, decrypted)
+}
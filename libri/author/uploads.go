@@ -0,0 +1,137 @@
+package author
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/keychain"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+)
+
+// UploadFilter selects uploads from the local index of uploads. Zero-valued fields match all
+// uploads.
+type UploadFilter struct {
+	// NamePrefix matches uploads whose name starts with it.
+	NamePrefix string
+
+	// MediaType matches uploads with exactly this media type.
+	MediaType string
+
+	// CreatedAfter matches uploads created at or after it.
+	CreatedAfter time.Time
+
+	// CreatedBefore matches uploads created before it.
+	CreatedBefore time.Time
+}
+
+// Matches returns whether the upload record satisfies the filter.
+func (f *UploadFilter) Matches(record *UploadRecord) bool {
+	if !strings.HasPrefix(record.Name, f.NamePrefix) {
+		return false
+	}
+	if f.MediaType != "" && record.MediaType != f.MediaType {
+		return false
+	}
+	createdTime := time.Unix(record.CreatedTime, 0)
+	if !f.CreatedAfter.IsZero() && createdTime.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !createdTime.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// ListUploads returns the records of the uploaded documents in the local index that match the
+// filter, ordered by created time. A nil filter matches all uploads.
+func (a *Author) ListUploads(filter *UploadFilter) ([]*UploadRecord, error) {
+	if filter == nil {
+		filter = &UploadFilter{}
+	}
+	records := make([]*UploadRecord, 0)
+	var unmarshalErr error
+	err := a.uploadSL.Iterate(make(chan struct{}), func(key, value []byte) {
+		record := &UploadRecord{}
+		if err := proto.Unmarshal(value, record); err != nil {
+			unmarshalErr = err
+			return
+		}
+		if filter.Matches(record) {
+			records = append(records, record)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedTime < records[j].CreatedTime
+	})
+	return records, nil
+}
+
+// getEntryMetadata decrypts the metadata of the entry uploaded with the given author and
+// self-reader public keys.
+func (a *Author) getEntryMetadata(entry *api.Document, authorPub, readerPub []byte) (
+	*api.Metadata, error) {
+	authorID, in := a.authorKeys.Get(authorPub)
+	if !in {
+		return nil, keychain.ErrUnexpectedMissingKey
+	}
+	selfReaderID, in := a.selfReaderKeys.Get(readerPub)
+	if !in {
+		return nil, keychain.ErrUnexpectedMissingKey
+	}
+	keys, err := enc.NewKeys(authorID.Key(), &selfReaderID.Key().PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return decryptEntryMetadata(entry, keys)
+}
+
+// decryptEntryMetadata decrypts the metadata of the entry with the entry keys.
+func decryptEntryMetadata(entry *api.Document, keys *enc.Keys) (*api.Metadata, error) {
+	encMetadata, err := enc.NewEncryptedMetadata(
+		entry.GetEntry().MetadataCiphertext,
+		entry.GetEntry().MetadataCiphertextMac,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewMetadataEncrypterDecrypter().Decrypt(encMetadata, keys)
+}
+
+// indexUpload saves the record of the envelope and entry uploaded with the checkpoint to the local
+// index of uploads. The record only has the entry's metadata fields when the metadata is non-nil.
+func indexUpload(
+	usl storage.NamespaceStorer,
+	cp *UploadCheckpoint,
+	envelopeKey id.ID,
+	entry *api.Document,
+	metadata *api.Metadata,
+) error {
+	record := &UploadRecord{
+		EnvelopeKey:     envelopeKey.Bytes(),
+		EntryKey:        cp.EntryKey,
+		CreatedTime:     entry.GetEntry().CreatedTime,
+		AuthorPublicKey: cp.AuthorPublicKey,
+		ReaderPublicKey: cp.ReaderPublicKey,
+	}
+	if metadata != nil {
+		record.Name, _ = metadata.GetFilepath()
+		record.MediaType, _ = metadata.GetMediaType()
+		record.UncompressedSize, _ = metadata.GetUncompressedSize()
+	}
+	value, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return usl.Store(envelopeKey.Bytes(), value)
+}
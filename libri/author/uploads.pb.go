@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go.
+// source: libri/author/uploads.proto
+// DO NOT EDIT!
+
+package author
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// UploadRecord describes an uploaded document in the author's local index of uploads.
+type UploadRecord struct {
+	// key of the uploaded envelope
+	EnvelopeKey []byte `protobuf:"bytes,1,opt,name=envelope_key,json=envelopeKey,proto3" json:"envelope_key,omitempty"`
+	// key of the uploaded entry
+	EntryKey []byte `protobuf:"bytes,2,opt,name=entry_key,json=entryKey,proto3" json:"entry_key,omitempty"`
+	// (relative) filepath of the uploaded content, if any
+	Name string `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	// media type of the uploaded content
+	MediaType string `protobuf:"bytes,4,opt,name=media_type,json=mediaType" json:"media_type,omitempty"`
+	// size of the uploaded content before compression
+	UncompressedSize uint64 `protobuf:"varint,5,opt,name=uncompressed_size,json=uncompressedSize" json:"uncompressed_size,omitempty"`
+	// created epoch time (seconds since 1970-01-01) of the entry
+	CreatedTime int64 `protobuf:"varint,6,opt,name=created_time,json=createdTime" json:"created_time,omitempty"`
+	// public key of the envelope author
+	AuthorPublicKey []byte `protobuf:"bytes,7,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
+	// public key of the envelope (self) reader
+	ReaderPublicKey []byte `protobuf:"bytes,8,opt,name=reader_public_key,json=readerPublicKey,proto3" json:"reader_public_key,omitempty"`
+}
+
+func (m *UploadRecord) Reset()                    { *m = UploadRecord{} }
+func (m *UploadRecord) String() string            { return proto.CompactTextString(m) }
+func (*UploadRecord) ProtoMessage()               {}
+func (*UploadRecord) Descriptor() ([]byte, []int) { return fileDescriptor4, []int{0} }
+
+func (m *UploadRecord) GetEnvelopeKey() []byte {
+	if m != nil {
+		return m.EnvelopeKey
+	}
+	return nil
+}
+
+func (m *UploadRecord) GetEntryKey() []byte {
+	if m != nil {
+		return m.EntryKey
+	}
+	return nil
+}
+
+func (m *UploadRecord) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *UploadRecord) GetMediaType() string {
+	if m != nil {
+		return m.MediaType
+	}
+	return ""
+}
+
+func (m *UploadRecord) GetUncompressedSize() uint64 {
+	if m != nil {
+		return m.UncompressedSize
+	}
+	return 0
+}
+
+func (m *UploadRecord) GetCreatedTime() int64 {
+	if m != nil {
+		return m.CreatedTime
+	}
+	return 0
+}
+
+func (m *UploadRecord) GetAuthorPublicKey() []byte {
+	if m != nil {
+		return m.AuthorPublicKey
+	}
+	return nil
+}
+
+func (m *UploadRecord) GetReaderPublicKey() []byte {
+	if m != nil {
+		return m.ReaderPublicKey
+	}
+	return nil
+}
+
+// UploadManifest lists the records of an author's uploads, so access to them can be recovered.
+type UploadManifest struct {
+	Uploads []*UploadRecord `protobuf:"bytes,1,rep,name=uploads" json:"uploads,omitempty"`
+}
+
+func (m *UploadManifest) Reset()                    { *m = UploadManifest{} }
+func (m *UploadManifest) String() string            { return proto.CompactTextString(m) }
+func (*UploadManifest) ProtoMessage()               {}
+func (*UploadManifest) Descriptor() ([]byte, []int) { return fileDescriptor4, []int{1} }
+
+func (m *UploadManifest) GetUploads() []*UploadRecord {
+	if m != nil {
+		return m.Uploads
+	}
+	return nil
+}
+
+// EncryptedUploadManifest is an UploadManifest encrypted with a key derived from a passphrase.
+type EncryptedUploadManifest struct {
+	// marshaled keychain.KDFHeader describing how the key is derived from the passphrase
+	Kdf []byte `protobuf:"bytes,1,opt,name=kdf,proto3" json:"kdf,omitempty"`
+	// nonce followed by the AES-256 GCM ciphertext of the marshaled UploadManifest
+	Ciphertext []byte `protobuf:"bytes,2,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+}
+
+func (m *EncryptedUploadManifest) Reset()                    { *m = EncryptedUploadManifest{} }
+func (m *EncryptedUploadManifest) String() string            { return proto.CompactTextString(m) }
+func (*EncryptedUploadManifest) ProtoMessage()               {}
+func (*EncryptedUploadManifest) Descriptor() ([]byte, []int) { return fileDescriptor4, []int{2} }
+
+func (m *EncryptedUploadManifest) GetKdf() []byte {
+	if m != nil {
+		return m.Kdf
+	}
+	return nil
+}
+
+func (m *EncryptedUploadManifest) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*UploadRecord)(nil), "author.UploadRecord")
+	proto.RegisterType((*UploadManifest)(nil), "author.UploadManifest")
+	proto.RegisterType((*EncryptedUploadManifest)(nil), "author.EncryptedUploadManifest")
+}
+
+func init() { proto.RegisterFile("libri/author/uploads.proto", fileDescriptor4) }
+
+var fileDescriptor4 = []byte{
+	// 318 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x91, 0xdf, 0x4a, 0xc3, 0x30,
+	0x14, 0xc6, 0xe9, 0x3a, 0xf7, 0xe7, 0x6c, 0xe8, 0x16, 0x04, 0x8b, 0xa2, 0xd4, 0x5d, 0x95, 0x09,
+	0x1d, 0xe8, 0x0b, 0x78, 0xe3, 0xd5, 0x10, 0xa4, 0xce, 0xeb, 0x92, 0x35, 0x67, 0x2c, 0xac, 0x6d,
+	0x42, 0x9a, 0x8a, 0xd9, 0x1b, 0xf9, 0x96, 0xd2, 0xa4, 0x93, 0xea, 0xdd, 0xe1, 0xf7, 0xfb, 0x68,
+	0xbf, 0x7e, 0x85, 0xeb, 0x9c, 0x6f, 0x15, 0x5f, 0xd1, 0x5a, 0xef, 0x85, 0x5a, 0xd5, 0x32, 0x17,
+	0x94, 0x55, 0xb1, 0x54, 0x42, 0x0b, 0x32, 0x70, 0x74, 0xf1, 0xdd, 0x83, 0xe9, 0x87, 0x35, 0x09,
+	0x66, 0x42, 0x31, 0x72, 0x0f, 0x53, 0x2c, 0x3f, 0x31, 0x17, 0x12, 0xd3, 0x03, 0x9a, 0xc0, 0x0b,
+	0xbd, 0x68, 0x9a, 0x4c, 0x4e, 0x6c, 0x8d, 0x86, 0xdc, 0xc0, 0x18, 0x4b, 0xad, 0x8c, 0xf5, 0x3d,
+	0xeb, 0x47, 0x16, 0x34, 0x92, 0x40, 0xbf, 0xa4, 0x05, 0x06, 0x7e, 0xe8, 0x45, 0xe3, 0xc4, 0xde,
+	0xe4, 0x16, 0xa0, 0x40, 0xc6, 0x69, 0xaa, 0x8d, 0xc4, 0xa0, 0x6f, 0xcd, 0xd8, 0x92, 0x8d, 0x91,
+	0x48, 0x1e, 0x60, 0x5e, 0x97, 0x99, 0x28, 0xa4, 0xc2, 0xaa, 0x42, 0x96, 0x56, 0xfc, 0x88, 0xc1,
+	0x59, 0xe8, 0x45, 0xfd, 0x64, 0xd6, 0x15, 0xef, 0xfc, 0x88, 0x4d, 0xbf, 0x4c, 0x21, 0xd5, 0xc8,
+	0x52, 0xcd, 0x0b, 0x0c, 0x06, 0xa1, 0x17, 0xf9, 0xc9, 0xa4, 0x65, 0x1b, 0x5e, 0x20, 0x59, 0xc2,
+	0xdc, 0x7d, 0x5d, 0x2a, 0xeb, 0x6d, 0xce, 0x33, 0xdb, 0x73, 0x68, 0x7b, 0x5e, 0x38, 0xf1, 0x66,
+	0x79, 0x53, 0x77, 0x09, 0x73, 0x85, 0x94, 0xe1, 0x9f, 0xec, 0xc8, 0x65, 0x9d, 0xf8, 0xcd, 0x2e,
+	0x9e, 0xe1, 0xdc, 0x4d, 0xf5, 0x4a, 0x4b, 0xbe, 0xc3, 0x4a, 0x93, 0x18, 0x86, 0xed, 0xac, 0x81,
+	0x17, 0xfa, 0xd1, 0xe4, 0xf1, 0x32, 0x76, 0x2f, 0x88, 0xbb, 0x9b, 0x26, 0xa7, 0xd0, 0x62, 0x0d,
+	0x57, 0x2f, 0x65, 0xa6, 0x8c, 0xd4, 0xc8, 0xfe, 0x3d, 0x6a, 0x06, 0xfe, 0x81, 0xed, 0xda, 0xb9,
+	0x9b, 0x93, 0xdc, 0x01, 0x64, 0x5c, 0xee, 0x51, 0x69, 0xfc, 0xd2, 0xed, 0xce, 0x1d, 0xb2, 0x1d,
+	0xd8, 0x3f, 0xf9, 0xf4, 0x33, 0x00, 0x91, 0x85, 0x1c, 0x45, 0xe7, 0x01, 0x00, 0x00,
+}
@@ -0,0 +1,178 @@
+package author
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthor_ListUploads(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+
+	// check empty index has no uploads
+	records, err := a.ListUploads(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	uploads := []struct {
+		name        string
+		mediaType   string
+		createdTime int64
+	}{
+		{name: "docs/b.pdf", mediaType: "application/pdf", createdTime: 3},
+		{name: "", mediaType: "text/plain", createdTime: 1},
+		{name: "docs/a.txt", mediaType: "text/plain", createdTime: 2},
+	}
+	envelopeKeys := make([]id.ID, len(uploads))
+	for i, u := range uploads {
+		metadata, err := api.NewEntryMetadata(u.mediaType, 1, api.RandBytes(rng, 32),
+			uint64(i+1), api.RandBytes(rng, 32))
+		assert.Nil(t, err)
+		if u.name != "" {
+			metadata.SetFilepath(u.name)
+		}
+		entry, _ := api.NewTestDocument(rng)
+		entry.GetEntry().CreatedTime = u.createdTime
+		a.entryPacker = &fixedEntryPacker{entry: entry, metadata: metadata}
+		envelopeKeys[i] = id.NewPseudoRandom(rng)
+		a.shipper = &fixedShipper{
+			envelope: &api.Document{
+				Contents: &api.Document_Envelope{
+					Envelope: api.NewTestEnvelope(rng),
+				},
+			},
+			envelopeKey: envelopeKeys[i],
+		}
+		_, _, err = a.Upload(nil, "")
+		assert.Nil(t, err)
+	}
+
+	// check all uploads are listed by created time
+	records, err = a.ListUploads(nil)
+	assert.Nil(t, err)
+	assert.Len(t, records, len(uploads))
+	for i, j := range []int{1, 2, 0} {
+		assert.Equal(t, envelopeKeys[j].Bytes(), records[i].EnvelopeKey)
+		assert.Equal(t, uploads[j].name, records[i].Name)
+		assert.Equal(t, uploads[j].mediaType, records[i].MediaType)
+		assert.Equal(t, uint64(j+1), records[i].UncompressedSize)
+		assert.Equal(t, uploads[j].createdTime, records[i].CreatedTime)
+		assert.NotEmpty(t, records[i].EntryKey)
+		assert.NotEmpty(t, records[i].AuthorPublicKey)
+		assert.NotEmpty(t, records[i].ReaderPublicKey)
+	}
+
+	// check filter selects matching uploads
+	records, err = a.ListUploads(&UploadFilter{NamePrefix: "docs/"})
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "docs/a.txt", records[0].Name)
+	assert.Equal(t, "docs/b.pdf", records[1].Name)
+
+	records, err = a.ListUploads(&UploadFilter{
+		MediaType:    "text/plain",
+		CreatedAfter: time.Unix(2, 0),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, envelopeKeys[2].Bytes(), records[0].EnvelopeKey)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestAuthor_ListUploads_err(t *testing.T) {
+	a := &Author{}
+
+	// check iterate error bubbles up
+	a.uploadSL = &fixedStorerLoaderIterator{iterateErr: errors.New("some Iterate error")}
+	records, err := a.ListUploads(nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+
+	// check unmarshal error bubbles up
+	a.uploadSL = &fixedStorerLoaderIterator{values: [][]byte{{1, 2, 3}}}
+	records, err = a.ListUploads(nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+}
+
+func TestUploadFilter_Matches(t *testing.T) {
+	record := &UploadRecord{
+		Name:        "docs/a.txt",
+		MediaType:   "text/plain",
+		CreatedTime: 10,
+	}
+	cases := []struct {
+		filter   *UploadFilter
+		expected bool
+	}{
+		{filter: &UploadFilter{}, expected: true},
+		{filter: &UploadFilter{NamePrefix: "docs/"}, expected: true},
+		{filter: &UploadFilter{NamePrefix: "other/"}, expected: false},
+		{filter: &UploadFilter{MediaType: "text/plain"}, expected: true},
+		{filter: &UploadFilter{MediaType: "application/pdf"}, expected: false},
+		{filter: &UploadFilter{CreatedAfter: time.Unix(10, 0)}, expected: true},
+		{filter: &UploadFilter{CreatedAfter: time.Unix(11, 0)}, expected: false},
+		{filter: &UploadFilter{CreatedBefore: time.Unix(11, 0)}, expected: true},
+		{filter: &UploadFilter{CreatedBefore: time.Unix(10, 0)}, expected: false},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, c.filter.Matches(record), i)
+	}
+}
+
+func TestAuthor_getEntryMetadata_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a := newTestAuthor()
+	authorID, err := a.authorKeys.Sample()
+	assert.Nil(t, err)
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	otherPub := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	entry, _ := api.NewTestDocument(rng)
+
+	// check missing author key creates error
+	metadata, err := a.getEntryMetadata(entry, otherPub, otherPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, metadata)
+
+	// check missing self-reader key creates error
+	metadata, err = a.getEntryMetadata(entry, authorPub, otherPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, metadata)
+
+	err = a.CloseAndRemove()
+	assert.Nil(t, err)
+}
+
+func TestIndexUpload_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	entry, _ := api.NewTestDocument(rng)
+	usl := &fixedStorerLoader{storeErr: errors.New("some Store error")}
+
+	// check store error bubbles up
+	err := indexUpload(usl, &UploadCheckpoint{}, id.NewPseudoRandom(rng), entry, nil)
+	assert.NotNil(t, err)
+}
+
+type fixedStorerLoaderIterator struct {
+	fixedStorerLoader
+	values     [][]byte
+	iterateErr error
+}
+
+func (f *fixedStorerLoaderIterator) Iterate(
+	done chan struct{}, callback func(key, value []byte),
+) error {
+	for _, value := range f.values {
+		callback(nil, value)
+	}
+	return f.iterateErr
+}
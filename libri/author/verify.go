@@ -0,0 +1,290 @@
+package author
+
+import (
+	"bytes"
+	"crypto/hmac"
+
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"go.uber.org/zap"
+)
+
+// findPageQueriesPerReplica is the number of peers beyond the configured librarians that may be
+// queried for each replica of a page being verified.
+const findPageQueriesPerReplica = 3
+
+// PageHealth describes how well a page of an entry is stored in libri.
+type PageHealth struct {
+	// Key is the key of the page document, or of the entry for a single-page entry, since its
+	// page is stored in the entry.
+	Key id.ID
+
+	// NReplicas is the number of librarians found storing the page.
+	NReplicas uint
+
+	// ValidMAC is whether a stored page was found and its ciphertext MAC was valid.
+	ValidMAC bool
+}
+
+// Healthy returns whether the page has at least minReplicas replicas and a valid MAC.
+func (h *PageHealth) Healthy(minReplicas uint) bool {
+	return h.NReplicas >= minReplicas && h.ValidMAC
+}
+
+// EntryHealth summarizes how well the pages of an entry are stored in libri.
+type EntryHealth struct {
+	// EntryKey is the key of the entry document.
+	EntryKey id.ID
+
+	// MinReplicas is the minimum number of replicas for a page to be healthy.
+	MinReplicas uint
+
+	// Pages contains the health of each page (or page shard, for erasure-coded pages), in the
+	// entry's page order.
+	Pages []*PageHealth
+
+	// ValidCiphertextMAC is whether all pages (or, for erasure-coded pages, enough of their
+	// shards to reconstruct them) were found and the MAC of their ciphertexts matches the one in
+	// the entry metadata.
+	ValidCiphertextMAC bool
+}
+
+// NHealthyPages returns the number of healthy pages.
+func (h *EntryHealth) NHealthyPages() int {
+	nHealthy := 0
+	for _, ph := range h.Pages {
+		if ph.Healthy(h.MinReplicas) {
+			nHealthy++
+		}
+	}
+	return nHealthy
+}
+
+// Healthy returns whether all pages are healthy and the entry ciphertext MAC is valid.
+func (h *EntryHealth) Healthy() bool {
+	return h.ValidCiphertextMAC && h.NHealthyPages() == len(h.Pages)
+}
+
+// Verify audits the entry of the envelope with the given key, checking that each of its pages is
+// stored by at least minReplicas librarians and that their ciphertext MACs are valid. It sends
+// presence-only Find requests to the configured librarians and the peers they return as closest
+// to each page, counting the ones that store it. The envelope is needed for the entry keys to
+// check the MACs.
+func (a *Author) Verify(envelopeKey id.ID, minReplicas uint) (*EntryHealth, error) {
+	a.logger.Debug("receiving entry", zap.String(LoggerEnvelopeKey, envelopeKey.String()))
+	entry, keys, err := a.receiver.ReceiveEntry(envelopeKey)
+	if err != nil {
+		return nil, err
+	}
+	entryKey, err := api.GetKey(entry)
+	if err != nil {
+		return nil, err
+	}
+	encMetadata, err := enc.NewEncryptedMetadata(
+		entry.GetEntry().MetadataCiphertext,
+		entry.GetEntry().MetadataCiphertextMac,
+	)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := enc.NewMetadataEncrypterDecrypter().Decrypt(encMetadata, keys)
+	if err != nil {
+		return nil, err
+	}
+	pageKeys, err := api.GetEntryPageKeys(entry)
+	if err != nil {
+		return nil, err
+	}
+	if len(pageKeys) == 0 {
+		// zero extra pages implies a single page entry
+		pageKeys = []id.ID{entryKey}
+	}
+
+	health := &EntryHealth{
+		EntryKey:    entryKey,
+		MinReplicas: minReplicas,
+		Pages:       make([]*PageHealth, len(pageKeys)),
+	}
+	pages := make([]*api.Page, len(pageKeys))
+	for i, pageKey := range pageKeys {
+		var nReplicas uint
+		pages[i], nReplicas, err = a.findPage(pageKey, minReplicas)
+		if err != nil {
+			return nil, err
+		}
+		health.Pages[i] = &PageHealth{
+			Key:       pageKey,
+			NReplicas: nReplicas,
+			ValidMAC:  pages[i] != nil && validPageMAC(pages[i], keys),
+		}
+	}
+	health.ValidCiphertextMAC, err = validCiphertextMAC(pages, metadata, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("verified document",
+		zap.String(LoggerEnvelopeKey, envelopeKey.String()),
+		zap.String(LoggerEntryKey, entryKey.String()),
+		zap.Int(LoggerNPages, len(pageKeys)),
+		zap.Int("n_healthy_pages", health.NHealthyPages()),
+		zap.Bool("valid_ciphertext_mac", health.ValidCiphertextMAC),
+	)
+	return health, nil
+}
+
+// findPage sends presence-only Find requests for the page with the given key to the configured
+// librarians and then to the closest peers they return, until minReplicas of them are found
+// storing it or findPageQueriesPerReplica peers per replica beyond the configured librarians have
+// been queried. The page itself is only requested from the first librarian storing it. It returns
+// the page and the number of librarians found storing it.
+func (a *Author) findPage(key id.ID, minReplicas uint) (*api.Page, uint, error) {
+	queried := make(map[string]struct{})
+	toQuery := make([]api.Connector, 0, len(a.librarianConns))
+	for _, lc := range a.librarianConns {
+		queried[lc.Address().String()] = struct{}{}
+		toQuery = append(toQuery, lc)
+	}
+	maxQueries := len(a.librarianConns) + int(findPageQueriesPerReplica*minReplicas)
+	defer func() {
+		// only the configured librarians' connections are kept open
+		for _, pConn := range toQuery[len(a.librarianConns):] {
+			if err := pConn.Disconnect(); err != nil {
+				a.logger.Error("unable to disconnect from peer",
+					zap.String("peer_address", pConn.Address().String()),
+					zap.Error(err),
+				)
+			}
+		}
+	}()
+
+	var found *api.Page
+	nReplicas := uint(0)
+	for i := 0; i < len(toQuery) && nReplicas < minReplicas; i++ {
+		pConn := toQuery[i]
+		rq := client.NewFindPresenceRequest(a.clientID, key, minReplicas)
+		rp, err := a.queryPage(pConn, key, rq)
+		if err != nil {
+			return nil, 0, err
+		}
+		if rp == nil {
+			// an unreachable librarian just doesn't count as a replica
+			continue
+		}
+		page := getStoredPage(rp.Value, key)
+		if rp.Stored || page != nil {
+			nReplicas++
+			if found == nil && page == nil {
+				// only get the page from the first replica to check its MAC
+				rq = client.NewFindRequest(a.clientID, key, minReplicas)
+				if rp, err = a.queryPage(pConn, key, rq); err != nil {
+					return nil, 0, err
+				}
+				if rp != nil {
+					page = getStoredPage(rp.Value, key)
+				}
+			}
+			if found == nil {
+				found = page
+			}
+			continue
+		}
+		for _, pa := range rp.Peers {
+			addr := api.ToAddress(pa)
+			if _, in := queried[addr.String()]; !in && len(toQuery) < maxQueries {
+				queried[addr.String()] = struct{}{}
+				toQuery = append(toQuery, a.conns.Connector(addr))
+			}
+		}
+	}
+	return found, nReplicas, nil
+}
+
+// queryPage sends the Find request for the page with the given key to the peer. It returns an
+// error only if the request can't be signed and a nil response if the peer can't be queried.
+func (a *Author) queryPage(pConn api.Connector, key id.ID, rq *api.FindRequest) (
+	*api.FindResponse, error) {
+	ctx, cancel, err := client.NewSignedTimeoutContext(a.signer, rq, a.config.Timeouts.Find)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := a.finder.Query(ctx, pConn, rq)
+	cancel()
+	if err == nil && !bytes.Equal(rp.Metadata.RequestId, rq.Metadata.RequestId) {
+		err = client.ErrUnexpectedRequestID
+	}
+	if err != nil {
+		a.logger.Info("unable to find page from librarian",
+			zap.String("page_key", key.String()),
+			zap.String("peer_address", pConn.Address().String()),
+			zap.Error(err),
+		)
+		return nil, nil
+	}
+	return rp, nil
+}
+
+// validCiphertextMAC returns whether all pages (or, for erasure-coded pages, enough of their
+// shards) were found and the MAC of their ciphertexts matches the one in the metadata.
+func validCiphertextMAC(pages []*api.Page, metadata *api.Metadata, keys *enc.Keys) (bool, error) {
+	if dataShards, parityShards, in := metadata.GetErasureShards(); in {
+		coder, err := page.NewErasureCoder(uint32(dataShards), uint32(parityShards), keys)
+		if err != nil {
+			return false, err
+		}
+		if pages = decodePages(pages, coder); pages == nil {
+			return false, nil
+		}
+	}
+	ciphertextMAC := enc.NewHMAC(keys.HMACKey)
+	for _, p := range pages {
+		if p == nil {
+			return false, nil
+		}
+		if _, err := ciphertextMAC.Write(p.Ciphertext); err != nil {
+			return false, err
+		}
+	}
+	expectedMAC, _ := metadata.GetCiphertextMAC()
+	return hmac.Equal(expectedMAC, ciphertextMAC.Sum(nil)), nil
+}
+
+// decodePages reconstructs pages from their found shards, where a page with too few shards is
+// nil. It returns nil if the shards don't divide into pages.
+func decodePages(shards []*api.Page, coder page.ErasureCoder) []*api.Page {
+	nShards := int(coder.NShards())
+	if len(shards)%nShards != 0 {
+		return nil
+	}
+	pages := make([]*api.Page, len(shards)/nShards)
+	for i := range pages {
+		pages[i], _ = coder.Decode(shards[i*nShards : (i+1)*nShards])
+	}
+	return pages
+}
+
+// getStoredPage returns the page in the document if the document has the given key, or nil
+// otherwise (e.g., for tombstones).
+func getStoredPage(doc *api.Document, key id.ID) *api.Page {
+	if doc == nil {
+		return nil
+	}
+	docKey, err := api.GetKey(doc)
+	if err != nil || !bytes.Equal(docKey.Bytes(), key.Bytes()) {
+		return nil
+	}
+	if page := doc.GetPage(); page != nil {
+		return page
+	}
+	return doc.GetEntry().GetPage()
+}
+
+func validPageMAC(page *api.Page, keys *enc.Keys) bool {
+	if err := api.ValidatePage(page); err != nil {
+		return false
+	}
+	return hmac.Equal(enc.HMAC(page.Ciphertext, keys.HMACKey), page.CiphertextMac)
+}
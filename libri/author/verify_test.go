@@ -0,0 +1,355 @@
+package author
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/drausin/libri/libri/author/io/common"
+	"github.com/drausin/libri/libri/author/io/enc"
+	"github.com/drausin/libri/libri/author/io/page"
+	"github.com/drausin/libri/libri/author/io/publish"
+	"github.com/drausin/libri/libri/author/io/ship"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestEntryHealth_Healthy(t *testing.T) {
+	h := &EntryHealth{
+		MinReplicas: 2,
+		Pages: []*PageHealth{
+			{NReplicas: 3, ValidMAC: true},
+			{NReplicas: 2, ValidMAC: true},
+		},
+		ValidCiphertextMAC: true,
+	}
+	assert.Equal(t, 2, h.NHealthyPages())
+	assert.True(t, h.Healthy())
+
+	h.ValidCiphertextMAC = false
+	assert.False(t, h.Healthy())
+	h.ValidCiphertextMAC = true
+
+	h.Pages[0].ValidMAC = false
+	assert.Equal(t, 1, h.NHealthyPages())
+	assert.False(t, h.Healthy())
+	h.Pages[0].ValidMAC = true
+
+	h.Pages[1].NReplicas = 1
+	assert.Equal(t, 1, h.NHealthyPages())
+	assert.False(t, h.Healthy())
+}
+
+func TestAuthor_Verify_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, pubAcq := newTestVerifyAuthor()
+	addrs := []*net.TCPAddr{
+		{IP: net.ParseIP("127.0.0.1"), Port: 20100},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20101},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20102},
+	}
+	a.librarianConns = []api.Connector{api.NewConnector(addrs[0]), api.NewConnector(addrs[1])}
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	envelope, envelopeKey, err := a.Upload(common.NewCompressableBytes(rng, 1024),
+		"application/x-gzip")
+	assert.Nil(t, err)
+	entryKey := id.FromBytes(envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey)
+	pageKeys, err := api.GetEntryPageKeys(pubAcq.docs[entryKey.String()])
+	assert.Nil(t, err)
+	assert.True(t, len(pageKeys) > 1)
+
+	// first librarian stores all pages, second stores none but knows the third, which stores all
+	// but the first page
+	finder := &fixedFindQuerier{
+		values: map[string]map[string]*api.Document{
+			addrs[0].String(): pubAcq.docs,
+			addrs[1].String(): {},
+			addrs[2].String(): make(map[string]*api.Document),
+		},
+		peers: map[string][]*api.PeerAddress{
+			addrs[1].String(): {api.FromAddress(id.NewPseudoRandom(rng), "peer-2", addrs[2])},
+		},
+	}
+	for _, pageKey := range pageKeys[1:] {
+		finder.values[addrs[2].String()][pageKey.String()] = pubAcq.docs[pageKey.String()]
+	}
+	a.finder = finder
+
+	health, err := a.Verify(envelopeKey, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, entryKey, health.EntryKey)
+	assert.Len(t, health.Pages, len(pageKeys))
+	assert.True(t, health.ValidCiphertextMAC)
+	assert.Equal(t, len(pageKeys)-1, health.NHealthyPages())
+	assert.False(t, health.Healthy())
+	assert.Equal(t, pageKeys[0], health.Pages[0].Key)
+	assert.Equal(t, uint(1), health.Pages[0].NReplicas)
+	for i, ph := range health.Pages {
+		assert.Equal(t, pageKeys[i], ph.Key)
+		assert.True(t, ph.ValidMAC)
+	}
+
+	health, err = a.Verify(envelopeKey, 1)
+	assert.Nil(t, err)
+	assert.True(t, health.Healthy())
+
+	// check unreachable librarian and missing page make entry unhealthy
+	finder.errs = map[string]error{addrs[0].String(): errors.New("some Find error")}
+	health, err = a.Verify(envelopeKey, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(0), health.Pages[0].NReplicas)
+	assert.False(t, health.Pages[0].ValidMAC)
+	assert.False(t, health.ValidCiphertextMAC)
+	assert.False(t, health.Healthy())
+
+	// check single-page entry health uses the entry
+	envelope, envelopeKey, err = a.Upload(common.NewCompressableBytes(rng, 64),
+		"application/x-gzip")
+	assert.Nil(t, err)
+	entryKey = id.FromBytes(envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey)
+	finder.errs = nil
+	health, err = a.Verify(envelopeKey, 1)
+	assert.Nil(t, err)
+	assert.Len(t, health.Pages, 1)
+	assert.Equal(t, entryKey, health.Pages[0].Key)
+	assert.True(t, health.Healthy())
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_Verify_erasureCoding(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, pubAcq := newTestVerifyAuthor()
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 20100}
+	a.librarianConns = []api.Connector{api.NewConnector(addr)}
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	a.config.Print.DataShards, a.config.Print.ParityShards = 4, 2
+	envelope, envelopeKey, err := a.Upload(common.NewCompressableBytes(rng, 1024),
+		"application/x-gzip")
+	assert.Nil(t, err)
+	entryKey := id.FromBytes(envelope.Contents.(*api.Document_Envelope).Envelope.EntryKey)
+	shardKeys, err := api.GetEntryPageKeys(pubAcq.docs[entryKey.String()])
+	assert.Nil(t, err)
+	assert.Zero(t, len(shardKeys)%6)
+
+	finder := &fixedFindQuerier{
+		values: map[string]map[string]*api.Document{addr.String(): {}},
+	}
+	for _, shardKey := range shardKeys {
+		finder.values[addr.String()][shardKey.String()] = pubAcq.docs[shardKey.String()]
+	}
+	a.finder = finder
+
+	health, err := a.Verify(envelopeKey, 1)
+	assert.Nil(t, err)
+	assert.Len(t, health.Pages, len(shardKeys))
+	assert.True(t, health.Healthy())
+
+	// check ciphertext MAC is still valid with two missing shards of the first page
+	delete(finder.values[addr.String()], shardKeys[0].String())
+	delete(finder.values[addr.String()], shardKeys[5].String())
+	health, err = a.Verify(envelopeKey, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(shardKeys)-2, health.NHealthyPages())
+	assert.True(t, health.ValidCiphertextMAC)
+	assert.False(t, health.Healthy())
+
+	// check ciphertext MAC is invalid with a third missing shard
+	delete(finder.values[addr.String()], shardKeys[1].String())
+	health, err = a.Verify(envelopeKey, 1)
+	assert.Nil(t, err)
+	assert.False(t, health.ValidCiphertextMAC)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_Verify_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, _ := newTestVerifyAuthor()
+	a.librarianConns = []api.Connector{
+		api.NewConnector(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 20100}),
+	}
+	a.finder = &fixedFindQuerier{}
+
+	page.MinSize = 64 // just for testing
+	a.config.Print.PageSize = 256
+	_, envelopeKey, err := a.Upload(common.NewCompressableBytes(rng, 1024),
+		"application/x-gzip")
+	assert.Nil(t, err)
+
+	// check signer error bubbles up
+	a.signer = &client.TestErrSigner{}
+	health, err := a.Verify(envelopeKey, 1)
+	assert.NotNil(t, err)
+	assert.Nil(t, health)
+
+	// check Receive error bubbles up
+	a.receiver = &fixedReceiver{err: errors.New("some ReceiveEntry error")}
+	health, err = a.Verify(envelopeKey, 1)
+	assert.NotNil(t, err)
+	assert.Nil(t, health)
+
+	// check metadata decrypt error bubbles up
+	entry, _ := api.NewTestDocument(rng)
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	a.receiver = &fixedReceiver{entry: entry, keys: keys}
+	health, err = a.Verify(envelopeKey, 1)
+	assert.NotNil(t, err)
+	assert.Nil(t, health)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestAuthor_findPage(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	a, _ := newTestVerifyAuthor()
+	addrs := make([]*net.TCPAddr, 10)
+	for i := range addrs {
+		addrs[i] = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 20100 + i}
+	}
+	a.librarianConns = []api.Connector{api.NewConnector(addrs[0]), api.NewConnector(addrs[1])}
+	p := api.NewTestPage(rng)
+	pageDoc, pageKey, err := api.GetPageDocument(p)
+	assert.Nil(t, err)
+
+	// check querying stops once enough replicas are found and the page is only got once
+	finder := &fixedFindQuerier{
+		values: map[string]map[string]*api.Document{
+			addrs[0].String(): {pageKey.String(): pageDoc},
+		},
+	}
+	a.finder = finder
+	found, nReplicas, err := a.findPage(pageKey, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, p, found)
+	assert.Equal(t, uint(1), nReplicas)
+	assert.Equal(t, []string{addrs[0].String(), addrs[0].String()}, finder.queried)
+
+	// check peers beyond the configured librarians queried are capped
+	finder = &fixedFindQuerier{peers: make(map[string][]*api.PeerAddress)}
+	for i := 1; i < len(addrs)-1; i++ {
+		finder.peers[addrs[i].String()] = []*api.PeerAddress{
+			api.FromAddress(id.NewPseudoRandom(rng), "peer", addrs[i+1]),
+		}
+	}
+	a.finder = finder
+	found, nReplicas, err = a.findPage(pageKey, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, found)
+	assert.Zero(t, nReplicas)
+	assert.Len(t, finder.queried, len(a.librarianConns)+findPageQueriesPerReplica)
+
+	// check signer error bubbles up
+	a.signer = &client.TestErrSigner{}
+	found, nReplicas, err = a.findPage(pageKey, 1)
+	assert.NotNil(t, err)
+	assert.Nil(t, found)
+	assert.Zero(t, nReplicas)
+
+	assert.Nil(t, a.CloseAndRemove())
+}
+
+func TestDecodePages(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	coder, err := page.NewErasureCoder(2, 1, keys)
+	assert.Nil(t, err)
+	p := api.NewTestPage(rng)
+	shards, err := coder.Encode(p)
+	assert.Nil(t, err)
+
+	pages := decodePages(append(shards, nil, nil, nil), coder)
+	assert.Equal(t, []*api.Page{p, nil}, pages)
+
+	// check shards that don't divide into pages have no pages
+	assert.Nil(t, decodePages(shards[:2], coder))
+}
+
+func TestGetStoredPage(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	p := api.NewTestPage(rng)
+	pageDoc, pageKey, err := api.GetPageDocument(p)
+	assert.Nil(t, err)
+	assert.Equal(t, p, getStoredPage(pageDoc, pageKey))
+
+	// check missing, mismatched, and tombstone documents have no stored page
+	assert.Nil(t, getStoredPage(nil, pageKey))
+	assert.Nil(t, getStoredPage(pageDoc, id.NewPseudoRandom(rng)))
+	tombstone := &api.Document{Contents: &api.Document_Tombstone{
+		Tombstone: &api.Tombstone{DocumentKey: pageKey.Bytes()},
+	}}
+	tombstoneKey, err := api.GetKey(tombstone)
+	assert.Nil(t, err)
+	assert.Nil(t, getStoredPage(tombstone, tombstoneKey))
+}
+
+func TestValidPageMAC(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	keys, _, _ := enc.NewPseudoRandomKeys(rng)
+	p := api.NewTestPage(rng)
+	p.CiphertextMac = enc.HMAC(p.Ciphertext, keys.HMACKey)
+	assert.True(t, validPageMAC(p, keys))
+
+	otherKeys, _, _ := enc.NewPseudoRandomKeys(rng)
+	assert.False(t, validPageMAC(p, otherKeys))
+	assert.False(t, validPageMAC(&api.Page{}, keys))
+}
+
+// newTestVerifyAuthor creates a new test author that just mocks interaction with the libri
+// network.
+func newTestVerifyAuthor() (*Author, *memPublisherAcquirer) {
+	a := newTestAuthor()
+	a.librarians = &fixedClientBalancer{}
+	pubAcq := &memPublisherAcquirer{
+		docs: make(map[string]*api.Document),
+	}
+	slPublisher := publish.NewSingleLoadPublisher(pubAcq, a.documentSL)
+	ssAcquirer := publish.NewSingleStoreAcquirer(pubAcq, a.documentSL)
+	mlPublisher := publish.NewMultiLoadPublisher(slPublisher, a.config.Publish)
+	msAcquirer := publish.NewMultiStoreAcquirer(ssAcquirer, a.config.Publish)
+	a.shipper = ship.NewShipper(a.librarians, pubAcq, mlPublisher)
+	a.receiver = ship.NewReceiver(a.librarians, a.selfReaderKeys, pubAcq, msAcquirer,
+		a.documentSL)
+	a.publisher, a.acquirer = pubAcq, pubAcq
+	return a, pubAcq
+}
+
+// fixedFindQuerier returns the values stored by or the peers known to the librarian at each
+// address and records the addresses queried.
+type fixedFindQuerier struct {
+	values  map[string]map[string]*api.Document
+	peers   map[string][]*api.PeerAddress
+	errs    map[string]error
+	queried []string
+}
+
+func (f *fixedFindQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.FindRequest, opts ...grpc.CallOption,
+) (*api.FindResponse, error) {
+	addr := pConn.Address().String()
+	f.queried = append(f.queried, addr)
+	if err := f.errs[addr]; err != nil {
+		return nil, err
+	}
+	value := f.values[addr][id.FromBytes(rq.Key).String()]
+	if value != nil && rq.PresenceOnly {
+		return &api.FindResponse{
+			Metadata: &api.ResponseMetadata{RequestId: rq.Metadata.RequestId},
+			Stored:   true,
+		}, nil
+	}
+	return &api.FindResponse{
+		Metadata: &api.ResponseMetadata{RequestId: rq.Metadata.RequestId},
+		Value:    value,
+		Peers:    f.peers[addr],
+	}, nil
+}
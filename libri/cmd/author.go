@@ -12,6 +12,7 @@ import (
 	"github.com/drausin/libri/libri/author/keychain"
 	"golang.org/x/crypto/ssh/terminal"
 	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/ecid"
 	"io"
 )
 
@@ -20,6 +21,12 @@ const (
 	keychainDirFlag = "keychainsDir"
 	passphraseVar = "passphrase"
 	authorLibrariansFlag = "authorLibrarians"
+	discloseAttributesFlag = "discloseAttributes"
+	clientIDCurveFlag = "clientIDCurve"
+	lockKeysFlag = "lockKeys"
+	librarianTLSFlag = "librarianTLS"
+	librarianTLSCAFlag = "librarianTLSCA"
+	librarianTLSServerNameFlag = "librarianTLSServerName"
 )
 
 // authorCmd represents the author command
@@ -35,6 +42,16 @@ func init() {
 	authorCmd.PersistentFlags().StringP(keychainDirFlag, "k", "", "local keychains directory")
 	authorCmd.PersistentFlags().StringSliceP(authorLibrariansFlag, "a", nil,
 		"comma-separated addresses (IPv4:Port) of librarian(s)")
+	authorCmd.PersistentFlags().String(clientIDCurveFlag, ecid.CurveName,
+		"curve (secp256k1 or ed25519) of a created client ID")
+	authorCmd.PersistentFlags().Bool(lockKeysFlag, false,
+		"decrypt keychain keys only when used and keep them in locked memory")
+	authorCmd.PersistentFlags().Bool(librarianTLSFlag, false,
+		"dial librarians over TLS")
+	authorCmd.PersistentFlags().String(librarianTLSCAFlag, "",
+		"PEM CA certificates file that librarians' TLS certificates must be signed by")
+	authorCmd.PersistentFlags().String(librarianTLSServerNameFlag, "",
+		"name librarians' TLS certificates must have instead of their hosts")
 
 	// bind viper flags
 	viper.SetEnvPrefix(envVarPrefix) // look for env vars with "LIBRI_" prefix
@@ -80,7 +97,10 @@ type authorConfigGetterImpl struct {}
 func (*authorConfigGetterImpl) get(librariansFlag string) (*author.Config, *zap.Logger, error) {
 	config := author.NewDefaultConfig().
 		WithDataDir(viper.GetString(dataDirFlag)).
-		WithLogLevel(getLogLevel())
+		WithLogLevel(getLogLevel()).
+		WithDiscloseEntryAttributes(viper.GetBool(discloseAttributesFlag)).
+		WithPutParallelism(uint32(viper.GetInt(parallelismFlag))).
+		WithClientIDCurve(viper.GetString(clientIDCurveFlag))
 
 	logger := clogging.NewDevLogger(config.LogLevel)
 	librarianNetAddrs, err := server.ParseAddrs(viper.GetStringSlice(librariansFlag))
@@ -89,11 +109,19 @@ func (*authorConfigGetterImpl) get(librariansFlag string) (*author.Config, *zap.
 		return nil, logger, err
 	}
 	config.WithLibrarianAddrs(librarianNetAddrs)
+	if viper.GetBool(librarianTLSFlag) {
+		config.WithLibrarianTLS(viper.GetString(librarianTLSCAFlag),
+			viper.GetString(librarianTLSServerNameFlag))
+	}
 
 	logger.Info("author configuration",
 		zap.String(librariansFlag, fmt.Sprintf("%v", config.LibrarianAddrs)),
 		zap.String(dataDirFlag, config.DataDir),
 		zap.Stringer(logLevelFlag, config.LogLevel),
+		zap.Bool(discloseAttributesFlag, config.DiscloseEntryAttributes),
+		zap.Uint32(parallelismFlag, config.Publish.PutParallelism),
+		zap.String(clientIDCurveFlag, config.ClientIDCurve),
+		zap.Bool(librarianTLSFlag, config.LibrarianTLS),
 	)
 	return config, logger, nil
 }
@@ -135,3 +163,16 @@ func (*authorDownloaderImpl) download(
 ) error {
 	return author.Download(content, envelopeKey)
 }
+
+// authorVerifier just wraps an *author.Author Verify call for the same reason as authorUploader
+type authorVerifier interface {
+	verify(author *lauthor.Author, envelopeKey id.ID, minReplicas uint) (
+		*lauthor.EntryHealth, error)
+}
+
+type authorVerifierImpl struct {}
+
+func (*authorVerifierImpl) verify(author *lauthor.Author, envelopeKey id.ID, minReplicas uint) (
+	*lauthor.EntryHealth, error) {
+	return author.Verify(envelopeKey, minReplicas)
+}
@@ -63,17 +63,24 @@ func TestAuthorConfigGetter_get_ok(t *testing.T) {
 	viper.Set(dataDirFlag, dataDir)
 	viper.Set(logLevelFlag, logLevel)
 	viper.Set(authorLibrariansFlag, libAddrsArg)
+	viper.Set(parallelismFlag, 8)
+	viper.Set(librarianTLSFlag, true)
+	viper.Set(librarianTLSServerNameFlag, "librarians.example.com")
 	acg := &authorConfigGetterImpl{}
 
 	config, logger, err := acg.get(authorLibrariansFlag)
 
 	assert.Nil(t, err)
 	assert.Equal(t, logLevel, config.LogLevel)
+	assert.Equal(t, uint32(8), config.Publish.PutParallelism)
 	assert.Equal(t, len(libAddrs), len(config.LibrarianAddrs))
 	for i, la := range config.LibrarianAddrs {
 		assert.Equal(t, libAddrs[i], la.String())
 	}
+	assert.True(t, config.LibrarianTLS)
+	assert.Equal(t, "librarians.example.com", config.LibrarianTLSServerName)
 	assert.NotNil(t, logger)
+	viper.Set(librarianTLSFlag, false)
 }
 
 func TestAuthorConfigGetter_get_err(t *testing.T) {
@@ -38,7 +38,7 @@ func init() {
 	downloadCmd.Flags().Uint32P(parallelismFlag, "n", 3,
 		"number of parallel processes")
 	downloadCmd.Flags().StringP(downFilepathFlag, "f", "",
-		"path of local file to write downloaded contents to")
+		"path of local file to write downloaded contents to, or - to write to stdout")
 	downloadCmd.Flags().StringP(envelopeKeyFlag, "e", "",
 		"key of envelope to download")
 
@@ -91,9 +91,11 @@ func (d *fileDownloaderImpl) download() error {
 	if err != nil {
 		return err
 	}
-	file, err := os.Create(downFilepath)
-	if err != nil {
-		return err
+	file := os.Stdout
+	if downFilepath != stdioFilepath {
+		if file, err = os.Create(downFilepath); err != nil {
+			return err
+		}
 	}
 	logger.Info("downloading document",
 		zap.Stringer("envelope_key", envelopeKey),
@@ -103,5 +105,8 @@ func (d *fileDownloaderImpl) download() error {
 	if err != nil {
 		return err
 	}
+	if file == os.Stdout {
+		return nil
+	}
 	return file.Close()
 }
\ No newline at end of file
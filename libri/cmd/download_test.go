@@ -41,6 +41,34 @@ func TestFileDownloader_download_ok(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestFileDownloader_download_stdout(t *testing.T) {
+	ad := &fixedAuthorDownloader{}
+	d := &fileDownloaderImpl{
+		ag: &fixedAuthorGetter{
+			author: nil, // ok since we're passing it into a mocked method anyway
+			logger: server.NewDevInfoLogger(),
+		},
+		ad: ad,
+		kc: &fixedKeychainsGetter{}, // ok that KCs are null since passing to mock
+	}
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	pr, pw, err := os.Pipe()
+	assert.Nil(t, err)
+	os.Stdout = pw
+	viper.Set(downFilepathFlag, stdioFilepath)
+	viper.Set(envelopeKeyFlag, id.LowerBound.String())
+
+	// check content is downloaded to stdout
+	err = d.download()
+	assert.Nil(t, err)
+	assert.Equal(t, pw, ad.content)
+
+	// check stdout isn't closed
+	assert.Nil(t, pw.Close())
+	assert.Nil(t, pr.Close())
+}
+
 func TestFileDownloader_download_err(t *testing.T) {
 	// should error on missing envelopeKey
 	d1 := &fileDownloaderImpl{}
@@ -99,11 +127,13 @@ func TestFileDownloader_download_err(t *testing.T) {
 }
 
 type fixedAuthorDownloader struct {
-	err error
+	err     error
+	content io.Writer
 }
 
 func (f *fixedAuthorDownloader) download(
 	author *lauthor.Author, content io.Writer, envelopeKey id.ID,
 ) error {
+	f.content = content
 	return f.err
 }
@@ -20,13 +20,24 @@ var (
 	recordedInput              = "RECORDED"
 )
 
+const (
+	nKeysFlag   = "nKeys"
+	scryptNFlag = "scryptN"
+	scryptPFlag = "scryptP"
+)
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "initialize author keychains",
 	Long:  `TODO (drausin) add longer description and examples here`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := newKeychainCreator().create(); err != nil {
+		params, err := getKeychainParameters()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := newKeychainCreator(params).create(); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -35,28 +46,48 @@ var initCmd = &cobra.Command{
 
 func init() {
 	authorCmd.AddCommand(initCmd)
+
+	initCmd.Flags().Int(nKeysFlag, keychain.DefaultNKeys,
+		"number of keys to generate in each keychain")
+	initCmd.Flags().Int(scryptNFlag, keychain.LightScryptN,
+		"Scrypt N parameter (power of 2) for encrypting the keychains")
+	initCmd.Flags().Int(scryptPFlag, keychain.LightScryptP,
+		"Scrypt P parameter for encrypting the keychains")
+
+	// bind viper flags
+	viper.SetEnvPrefix(envVarPrefix) // look for env vars with "LIBRI_" prefix
+	viper.AutomaticEnv()             // read in environment variables that match
+	if err := viper.BindPFlags(initCmd.Flags()); err != nil {
+		panic(err)
+	}
+}
+
+func getKeychainParameters() (*keychain.Parameters, error) {
+	return keychain.NewParameters(
+		viper.GetInt(nKeysFlag),
+		viper.GetInt(scryptNFlag),
+		viper.GetInt(scryptPFlag),
+	)
 }
 
 type keychainCreator interface {
 	create() error
 }
 
-func newKeychainCreator() keychainCreator {
+func newKeychainCreator(params *keychain.Parameters) keychainCreator {
 	return &keychainCreatorImpl{
 		ps: &passphraseSetterImpl{
 			pg1: &terminalPassphraseGetter{},
 			pg2: &terminalPassphraseGetter{},
 			reader: bufio.NewReader(os.Stdin),
 		},
-		scryptN: keychain.LightScryptN,
-		scryptP: keychain.LightScryptP,
+		params: params,
 	}
 }
 
 type keychainCreatorImpl struct {
 	ps passphraseSetter
-	scryptN int
-	scryptP int
+	params *keychain.Parameters
 }
 
 func (c *keychainCreatorImpl) create() error {
@@ -78,7 +109,7 @@ func (c *keychainCreatorImpl) create() error {
 
 	logger := clogging.NewDevLogger(getLogLevel())
 	logger.Info("creating keychains")
-	return author.CreateKeychains(logger, keychainDir, passphrase, c.scryptN, c.scryptP)
+	return author.CreateKeychains(logger, keychainDir, passphrase, c.params)
 }
 
 type passphraseSetter interface {
@@ -10,15 +10,33 @@ import (
 	"io/ioutil"
 	"os"
 	"github.com/drausin/libri/libri/author"
+	"github.com/drausin/libri/libri/author/keychain"
 	"path"
 	"github.com/drausin/libri/libri/common/logging"
 )
 
 func TestNewKeychainCreator(t *testing.T) {
-	kc := newKeychainCreator()
+	kc := newKeychainCreator(keychain.NewDefaultParameters())
 	assert.NotNil(t, kc)
 }
 
+func TestGetKeychainParameters(t *testing.T) {
+	viper.Set(nKeysFlag, keychain.DefaultNKeys)
+	viper.Set(scryptNFlag, keychain.StandardScryptN)
+	viper.Set(scryptPFlag, keychain.StandardScryptP)
+	params, err := getKeychainParameters()
+	assert.Nil(t, err)
+	assert.Equal(t, keychain.StandardScryptN, params.ScryptN)
+	assert.Equal(t, keychain.StandardScryptP, params.ScryptP)
+
+	// check out of bounds value errors
+	viper.Set(nKeysFlag, 0)
+	params, err = getKeychainParameters()
+	assert.Equal(t, keychain.ErrInvalidNKeys, err)
+	assert.Nil(t, params)
+	viper.Set(nKeysFlag, keychain.DefaultNKeys)
+}
+
 func TestKeychainCreator_create_ok(t *testing.T) {
 	keychainDir, err := ioutil.TempDir("", "test-keychains")
 	assert.Nil(t, err)
@@ -27,8 +45,7 @@ func TestKeychainCreator_create_ok(t *testing.T) {
 
 	kc := keychainCreatorImpl{
 		ps: &fixedPassphraseSetter{passphrase: passphrase},
-		scryptN: veryLightScryptN,
-		scryptP: veryLightScryptP,
+		params: veryLightKeychainParams,
 	}
 	err = kc.create()
 	assert.Nil(t, err)
@@ -53,7 +70,7 @@ func TestKeychainCreator_create_err(t *testing.T) {
 	viper.Set(keychainDirFlag, keychainDir)
 	keychainFilepath := path.Join(keychainDir, author.AuthorKeychainFilename)
 	err = author.CreateKeychain(logger, keychainFilepath, setPassphrase,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 	kc2 := &keychainCreatorImpl{}
 	err = kc2.create()
@@ -62,7 +79,7 @@ func TestKeychainCreator_create_err(t *testing.T) {
 	// should throw error when keychains exist
 	keychainFilepath = path.Join(keychainDir, author.SelfReaderKeychainFilename)
 	err = author.CreateKeychain(logger, keychainFilepath, setPassphrase,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 	kc3 := &keychainCreatorImpl{}
 	err = kc3.create()
@@ -80,8 +97,7 @@ func TestKeychainCreator_create_err(t *testing.T) {
 
 	kc5 := keychainCreatorImpl{
 		ps: &fixedPassphraseSetter{passphrase: setPassphrase},
-		scryptN: -1,  // will cause error
-		scryptP: -1,
+		params: &keychain.Parameters{NKeys: 1, ScryptN: -1, ScryptP: -1}, // will cause error
 	}
 	err = kc5.create()
 	assert.NotNil(t, err)
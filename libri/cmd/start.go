@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/hex"
 	"os"
 
 	"fmt"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
 	clogging "github.com/drausin/libri/libri/common/logging"
 	"github.com/drausin/libri/libri/common/subscribe"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,14 +18,34 @@ import (
 )
 
 const (
-	bootstrapsFlag     = "bootstraps"
-	localHostFlag      = "localHost"
-	localPortFlag      = "localPort"
-	publicHostFlag     = "publicHost"
-	publicNameFlag     = "publicName"
-	publicPortFlag     = "publicPort"
-	nSubscriptionsFlag = "nSubscriptions"
-	fpRateFlag         = "fpRate"
+	bootstrapsFlag         = "bootstraps"
+	localHostFlag          = "localHost"
+	localPortFlag          = "localPort"
+	publicHostFlag         = "publicHost"
+	publicNameFlag         = "publicName"
+	publicPortFlag         = "publicPort"
+	nSubscriptionsFlag     = "nSubscriptions"
+	fpRateFlag             = "fpRate"
+	allowedSubscribersFlag = "allowedSubscribers"
+	webhookEndpointsFlag   = "webhookEndpoints"
+	tlsCertFlag            = "tlsCert"
+	tlsKeyFlag             = "tlsKey"
+	tlsClientCAFlag        = "tlsClientCA"
+	peerTLSCAFlag          = "peerTLSCA"
+	peerTLSServerNameFlag  = "peerTLSServerName"
+	storePoWBitsFlag       = "storePoWBits"
+	rotatePeerIDFlag       = "rotatePeerID"
+	peerIDDifficultyFlag   = "peerIDDifficulty"
+	peerIDCurveFlag        = "peerIDCurve"
+	verifyClockSkewFlag    = "verifyClockSkew"
+	verifyMaxAgeFlag       = "verifyMaxAge"
+	verifyIssuedAtFlag     = "verifyRequireIssuedAt"
+	auditLogFlag           = "auditLog"
+	publicationLogFlag     = "publicationLog"
+	denylistFlag           = "denylist"
+	requireEntrySigsFlag   = "requireEntrySignatures"
+	accessControlFlag      = "accessControl"
+	adminPublicKeysFlag    = "adminPublicKeys"
 )
 
 // startLibrarianCmd represents the librarian start command
@@ -62,6 +86,46 @@ func init() {
 		"number of active subscriptions to other peers to maintain")
 	startLibrarianCmd.Flags().Float32P(fpRateFlag, "f", subscribe.DefaultFPRate,
 		"false positive rate for subscriptions to other peers")
+	startLibrarianCmd.Flags().StringSlice(allowedSubscribersFlag, nil,
+		"comma-separated hex public keys of the only clients allowed to subscribe")
+	startLibrarianCmd.Flags().StringSlice(webhookEndpointsFlag, nil,
+		"comma-separated URLs to POST all publications to as JSON")
+	startLibrarianCmd.Flags().String(tlsCertFlag, "",
+		"PEM certificate file to serve TLS with")
+	startLibrarianCmd.Flags().String(tlsKeyFlag, "",
+		"PEM private key file of the TLS certificate")
+	startLibrarianCmd.Flags().String(tlsClientCAFlag, "",
+		"PEM CA certificates file that TLS client certificates must be signed by")
+	startLibrarianCmd.Flags().String(peerTLSCAFlag, "",
+		"PEM CA certificates file that peers' TLS certificates must be signed by")
+	startLibrarianCmd.Flags().String(peerTLSServerNameFlag, "",
+		"name peers' TLS certificates must have instead of their hosts")
+	startLibrarianCmd.Flags().Uint(storePoWBitsFlag, 0,
+		"leading zero bits of proof of work required on stores, the same for the whole network")
+	startLibrarianCmd.Flags().Bool(rotatePeerIDFlag, false,
+		"replace the stored peer ID with a new one linked to it by a signed rotation")
+	startLibrarianCmd.Flags().Uint(peerIDDifficultyFlag, 0,
+		"leading zero bits required of peer ID hashes, the same for the whole network")
+	startLibrarianCmd.Flags().String(peerIDCurveFlag, ecid.CurveName,
+		"curve (secp256k1 or ed25519) of created peer IDs, including rotated ones")
+	startLibrarianCmd.Flags().Duration(verifyClockSkewFlag, client.DefaultClockSkew,
+		"allowed clock skew when verifying request signature times")
+	startLibrarianCmd.Flags().Duration(verifyMaxAgeFlag, client.DefaultMaxSignatureAge,
+		"maximum age of request signatures, or zero for no expiry")
+	startLibrarianCmd.Flags().Bool(verifyIssuedAtFlag, client.DefaultRequireIssuedAt,
+		"reject request signatures without an issued time")
+	startLibrarianCmd.Flags().Bool(auditLogFlag, false,
+		"record the requester, key, and outcome of each Store and Put request")
+	startLibrarianCmd.Flags().Bool(publicationLogFlag, false,
+		"record received publications, so subscribers can replay them")
+	startLibrarianCmd.Flags().StringSlice(denylistFlag, nil,
+		"hex document keys to refuse to store or serve")
+	startLibrarianCmd.Flags().Bool(requireEntrySigsFlag, false,
+		"refuse to store entries without an author signature")
+	startLibrarianCmd.Flags().Bool(accessControlFlag, false,
+		"only serve private documents to requesters with an access proof")
+	startLibrarianCmd.Flags().StringSlice(adminPublicKeysFlag, nil,
+		"comma-separated hex public keys of the only clients allowed to make admin requests")
 
 	// bind viper flags
 	viper.SetEnvPrefix("LIBRI") // look for env vars with "LIBRI_" prefix
@@ -94,9 +158,27 @@ func getLibrarianConfig() (*server.Config, *zap.Logger, error) {
 		WithPublicName(viper.GetString(publicNameFlag)).
 		WithDataDir(viper.GetString(dataDirFlag)).
 		WithDefaultDBDir().  // depends on DataDir
+		WithTLS(
+			viper.GetString(tlsCertFlag),
+			viper.GetString(tlsKeyFlag),
+			viper.GetString(tlsClientCAFlag),
+		).
+		WithPeerTLS(viper.GetString(peerTLSCAFlag), viper.GetString(peerTLSServerNameFlag)).
+		WithRotatePeerID(viper.GetBool(rotatePeerIDFlag)).
+		WithPeerIDDifficulty(uint(viper.GetInt(peerIDDifficultyFlag))).
+		WithPeerIDCurve(viper.GetString(peerIDCurveFlag)).
+		WithAuditLog(viper.GetBool(auditLogFlag)).
+		WithPublicationLog(viper.GetBool(publicationLogFlag)).
+		WithRequireEntrySignatures(viper.GetBool(requireEntrySigsFlag)).
+		WithAccessControl(viper.GetBool(accessControlFlag)).
 		WithLogLevel(getLogLevel())
 	config.SubscribeTo.NSubscriptions = uint32(viper.GetInt(nSubscriptionsFlag))
 	config.SubscribeTo.FPRate = float32(viper.GetFloat64(fpRateFlag))
+	config.Store.ProofOfWorkBits = uint(viper.GetInt(storePoWBitsFlag))
+	config.Replicate.ProofOfWorkBits = config.Store.ProofOfWorkBits
+	config.Verify.ClockSkew = viper.GetDuration(verifyClockSkewFlag)
+	config.Verify.MaxAge = viper.GetDuration(verifyMaxAgeFlag)
+	config.Verify.RequireIssuedAt = viper.GetBool(verifyIssuedAtFlag)
 
 	logger := clogging.NewDevLogger(config.LogLevel)
 	bootstrapNetAddrs, err := server.ParseAddrs(viper.GetStringSlice(bootstrapsFlag))
@@ -106,6 +188,31 @@ func getLibrarianConfig() (*server.Config, *zap.Logger, error) {
 
 	}
 	config.WithBootstrapAddrs(bootstrapNetAddrs)
+	allowedSubscribers, err := decodePublicKeys(viper.GetStringSlice(allowedSubscribersFlag))
+	if err != nil {
+		logger.Error("unable to decode allowed subscriber public key", zap.Error(err))
+		return nil, nil, err
+	}
+	config.SubscribeFrom.AllowedPublicKeys = allowedSubscribers
+	config.Webhook.Endpoints = viper.GetStringSlice(webhookEndpointsFlag)
+	denylist, err := decodeIDs(viper.GetStringSlice(denylistFlag))
+	if err != nil {
+		logger.Error("unable to decode denylist key", zap.Error(err))
+		return nil, nil, err
+	}
+	config.WithDenylist(denylist)
+	adminPublicKeys, err := decodePublicKeys(viper.GetStringSlice(adminPublicKeysFlag))
+	if err != nil {
+		logger.Error("unable to decode admin public key", zap.Error(err))
+		return nil, nil, err
+	}
+	if len(adminPublicKeys) > 0 {
+		roles := server.NewDefaultRoles()
+		for _, pubKey := range adminPublicKeys {
+			roles.AssignPublicKey(pubKey, server.AdminRole)
+		}
+		config.WithRoles(roles)
+	}
 
 	logger.Info("librarian configuration",
 		zap.Stringer("localAddress", config.LocalAddr),
@@ -116,6 +223,48 @@ func getLibrarianConfig() (*server.Config, *zap.Logger, error) {
 		zap.Stringer(logLevelFlag, config.LogLevel),
 		zap.Uint32(nSubscriptionsFlag, config.SubscribeTo.NSubscriptions),
 		zap.Float32(fpRateFlag, config.SubscribeTo.FPRate),
+		zap.Int("nAllowedSubscribers", len(config.SubscribeFrom.AllowedPublicKeys)),
+		zap.Int("nAdminPublicKeys", len(adminPublicKeys)),
+		zap.Strings(webhookEndpointsFlag, config.Webhook.Endpoints),
+		zap.String(tlsCertFlag, config.TLSCertFile),
+		zap.String(tlsClientCAFlag, config.TLSClientCAFile),
+		zap.String(peerTLSCAFlag, config.PeerTLSCAFile),
+		zap.Uint(storePoWBitsFlag, config.Store.ProofOfWorkBits),
+		zap.Bool(rotatePeerIDFlag, config.RotatePeerID),
+		zap.Uint(peerIDDifficultyFlag, config.PeerIDDifficulty),
+		zap.String(peerIDCurveFlag, config.PeerIDCurve),
+		zap.Duration(verifyClockSkewFlag, config.Verify.ClockSkew),
+		zap.Duration(verifyMaxAgeFlag, config.Verify.MaxAge),
+		zap.Bool(verifyIssuedAtFlag, config.Verify.RequireIssuedAt),
+		zap.Bool(auditLogFlag, config.AuditLog),
+		zap.Bool(publicationLogFlag, config.PublicationLog),
+		zap.Int("nDenylistKeys", len(config.Denylist)),
+		zap.Bool(requireEntrySigsFlag, config.RequireEntrySignatures),
+		zap.Bool(accessControlFlag, config.AccessControl),
 	)
 	return config, logger, nil
 }
+
+func decodePublicKeys(encoded []string) ([][]byte, error) {
+	pubKeys := make([][]byte, len(encoded))
+	for i, e := range encoded {
+		pubKey, err := hex.DecodeString(e)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys[i] = pubKey
+	}
+	return pubKeys, nil
+}
+
+func decodeIDs(encoded []string) ([]id.ID, error) {
+	ids := make([]id.ID, len(encoded))
+	for i, e := range encoded {
+		decoded, err := id.FromString(e)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = decoded
+	}
+	return ids, nil
+}
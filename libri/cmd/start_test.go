@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/id"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/drausin/libri/libri/librarian/server"
@@ -26,6 +30,21 @@ func TestGetLibrarianConfig_ok(t *testing.T) {
 	viper.Set(nSubscriptionsFlag, nSubscriptions)
 	viper.Set(fpRateFlag, fpRate)
 	viper.Set(bootstrapsFlag, bootstraps)
+	viper.Set(allowedSubscribersFlag, "0a0b 0c0d")
+	viper.Set(webhookEndpointsFlag, "http://localhost:8080/pubs")
+	viper.Set(tlsCertFlag, "cert.pem")
+	viper.Set(tlsKeyFlag, "key.pem")
+	viper.Set(peerTLSCAFlag, "ca.pem")
+	viper.Set(storePoWBitsFlag, 12)
+	viper.Set(rotatePeerIDFlag, true)
+	viper.Set(verifyClockSkewFlag, "30s")
+	viper.Set(verifyMaxAgeFlag, "5m")
+	viper.Set(verifyIssuedAtFlag, true)
+	viper.Set(auditLogFlag, true)
+	viper.Set(publicationLogFlag, true)
+	viper.Set(denylistFlag, strings.Repeat("0a", id.Length))
+	viper.Set(requireEntrySigsFlag, true)
+	viper.Set(accessControlFlag, true)
 
 	config, logger, err := getLibrarianConfig()
 	assert.Nil(t, err)
@@ -39,6 +58,24 @@ func TestGetLibrarianConfig_ok(t *testing.T) {
 	assert.Equal(t, uint32(nSubscriptions), config.SubscribeTo.NSubscriptions)
 	assert.Equal(t, float32(fpRate), config.SubscribeTo.FPRate)
 	assert.Equal(t, 2, len(config.BootstrapAddrs))
+	assert.Equal(t, [][]byte{{10, 11}, {12, 13}}, config.SubscribeFrom.AllowedPublicKeys)
+	assert.Equal(t, []string{"http://localhost:8080/pubs"}, config.Webhook.Endpoints)
+	assert.Equal(t, "cert.pem", config.TLSCertFile)
+	assert.Equal(t, "key.pem", config.TLSKeyFile)
+	assert.Empty(t, config.TLSClientCAFile)
+	assert.Equal(t, "ca.pem", config.PeerTLSCAFile)
+	assert.Empty(t, config.PeerTLSServerName)
+	assert.Equal(t, uint(12), config.Store.ProofOfWorkBits)
+	assert.Equal(t, uint(12), config.Replicate.ProofOfWorkBits)
+	assert.True(t, config.RotatePeerID)
+	assert.Equal(t, 30*time.Second, config.Verify.ClockSkew)
+	assert.Equal(t, 5*time.Minute, config.Verify.MaxAge)
+	assert.True(t, config.Verify.RequireIssuedAt)
+	assert.True(t, config.AuditLog)
+	assert.True(t, config.PublicationLog)
+	assert.Len(t, config.Denylist, 1)
+	assert.True(t, config.RequireEntrySignatures)
+	assert.True(t, config.AccessControl)
 }
 
 func TestGetLibrarianConfig_err(t *testing.T) {
@@ -61,4 +98,19 @@ func TestGetLibrarianConfig_err(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, config)
 	assert.Nil(t, logger)
+
+	viper.Set(bootstrapsFlag, "1.2.3.5:1000")
+	viper.Set(allowedSubscribersFlag, "not hex")
+	config, logger, err = getLibrarianConfig()
+	assert.NotNil(t, err)
+	assert.Nil(t, config)
+	assert.Nil(t, logger)
+	viper.Set(allowedSubscribersFlag, nil)
+
+	viper.Set(denylistFlag, "not hex")
+	config, logger, err = getLibrarianConfig()
+	assert.NotNil(t, err)
+	assert.Nil(t, config)
+	assert.Nil(t, logger)
+	viper.Set(denylistFlag, nil)
 }
\ No newline at end of file
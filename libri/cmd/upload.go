@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	lauthor "github.com/drausin/libri/libri/author"
+	"github.com/drausin/libri/libri/author/io/publish"
 	"github.com/drausin/libri/libri/author/keychain"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -18,6 +19,9 @@ import (
 const (
 	upFilepathFlag = "upFilepath"
 	octetMediaType = "application/octet-stream"
+
+	// stdioFilepath is the filepath for uploading from stdin or downloading to stdout.
+	stdioFilepath = "-"
 )
 
 var (
@@ -41,10 +45,12 @@ var uploadCmd = &cobra.Command{
 func init() {
 	authorCmd.AddCommand(uploadCmd)
 
-	uploadCmd.Flags().Uint32P(parallelismFlag, "n", 3,
-		"number of parallel processes")
+	uploadCmd.Flags().Uint32P(parallelismFlag, "n", publish.DefaultPutParallelism,
+		"number of pages to upload to librarians in parallel")
 	uploadCmd.Flags().StringP(upFilepathFlag, "f", "",
-		"path of local file to upload")
+		"path of local file to upload, or - to upload from stdin")
+	uploadCmd.Flags().Bool(discloseAttributesFlag, false,
+		"disclose (unencrypted) the media type and size of the file to subscribers")
 
 	// bind viper flags
 	viper.SetEnvPrefix(envVarPrefix) // look for env vars with "LIBRI_" prefix
@@ -81,14 +87,7 @@ func (u *fileUploaderImpl) upload() error {
 	if upFilepath == "" {
 		return errMissingFilepath
 	}
-	mediaType, err := u.mtg.get(upFilepath)
-	if err != nil {
-		return err
-	}
-	if _, err = os.Stat(upFilepath); err != nil {
-		return err
-	}
-	file, err := os.Open(upFilepath)
+	file, mediaType, err := u.openContent(upFilepath)
 	if err != nil {
 		return err
 	}
@@ -108,9 +107,32 @@ func (u *fileUploaderImpl) upload() error {
 	if _, err = u.au.upload(author, file, mediaType); err != nil {
 		return err
 	}
+	if file == os.Stdin {
+		return nil
+	}
 	return file.Close()
 }
 
+// openContent opens the file to upload and gets its media type, or returns os.Stdin with an empty
+// media type, so it is detected from the start of the content, if the filepath is stdioFilepath.
+func (u *fileUploaderImpl) openContent(upFilepath string) (*os.File, string, error) {
+	if upFilepath == stdioFilepath {
+		return os.Stdin, "", nil
+	}
+	mediaType, err := u.mtg.get(upFilepath)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = os.Stat(upFilepath); err != nil {
+		return nil, "", err
+	}
+	file, err := os.Open(upFilepath)
+	if err != nil {
+		return nil, "", err
+	}
+	return file, mediaType, nil
+}
+
 func maybePanic(err error) {
 	if err != nil {
 		panic(err)
@@ -174,11 +196,19 @@ func (g *keychainsGetterImpl) get() (keychain.Keychain, keychain.Keychain, error
 	passphrase := viper.GetString(passphraseVar) // intentionally not bound to flag
 	if passphrase == "" {
 		// get passphrase from terminal
-		fmt.Print("Enter keychains passphrase: ")
+		// prompt on stderr, so it doesn't mix with content downloaded to stdout
+		fmt.Fprint(os.Stderr, "Enter keychains passphrase: ")
 		passphrase, err = g.pg.get()
 		if err != nil {
 			return nil, nil, err
 		}
 	}
+	if viper.GetBool(lockKeysFlag) {
+		authorKeys, selfReaderKeys, err := lauthor.LoadLockedKeychains(keychainDir, passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		return authorKeys, selfReaderKeys, nil
+	}
 	return lauthor.LoadKeychains(keychainDir, passphrase)
 }
@@ -24,6 +24,12 @@ const (
 	veryLightScryptP = 1
 )
 
+var veryLightKeychainParams = &keychain.Parameters{
+	NKeys:   keychain.DefaultNKeys,
+	ScryptN: veryLightScryptN,
+	ScryptP: veryLightScryptP,
+}
+
 func TestNewFileUploader(t *testing.T) {
 	u := newFileUploader()
 	assert.NotNil(t, u)
@@ -52,6 +58,35 @@ func TestFileUploader_upload_ok(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestFileUploader_upload_stdin(t *testing.T) {
+	au := &fixedAuthorUploader{}
+	u := &fileUploaderImpl{
+		ag: &fixedAuthorGetter{
+			author: nil, // ok since we're passing it into a mocked method anyway
+			logger: server.NewDevInfoLogger(),
+		},
+		au:  au,
+		mtg: &fixedMediaTypeGetter{err: errors.New("some get error")}, // shouldn't be called
+		kc:  &fixedKeychainsGetter{}, // ok that KCs are null since passing to mock
+	}
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	pr, pw, err := os.Pipe()
+	assert.Nil(t, err)
+	os.Stdin = pr
+	viper.Set(upFilepathFlag, stdioFilepath)
+
+	// check stdin is uploaded with media type left to be detected
+	err = u.upload()
+	assert.Nil(t, err)
+	assert.Equal(t, pr, au.content)
+	assert.Equal(t, "", au.mediaType)
+
+	// check stdin isn't closed
+	assert.Nil(t, pr.Close())
+	assert.Nil(t, pw.Close())
+}
+
 func TestFileUploader_upload_err(t *testing.T) {
 
 	// should error on missing filepath
@@ -178,7 +213,7 @@ func TestKeychainsGetter_get_ok(t *testing.T) {
 	logger := server.NewDevInfoLogger()
 	passphrase := "some test passphrase"
 	err = author.CreateKeychains(logger, keychainDir, passphrase,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 	viper.Set(keychainDirFlag, keychainDir)
 
@@ -199,6 +234,14 @@ func TestKeychainsGetter_get_ok(t *testing.T) {
 	assert.NotNil(t, authorKeys)
 	assert.NotNil(t, selfReaderKeys)
 
+	// check getting locked keychains
+	viper.Set(lockKeysFlag, true)
+	defer viper.Set(lockKeysFlag, false)
+	authorKeys, selfReaderKeys, err = kg2.get()
+	assert.Nil(t, err)
+	assert.Implements(t, (*keychain.LockedKeychain)(nil), authorKeys)
+	assert.Implements(t, (*keychain.LockedKeychain)(nil), selfReaderKeys)
+
 	err = os.RemoveAll(keychainDir)
 	assert.Nil(t, err)
 }
@@ -220,7 +263,7 @@ func TestKeychainsGetter_get_err(t *testing.T) {
 	// should error on one missing keychain
 	keychainFilepath := path.Join(keychainDir, author.AuthorKeychainFilename)
 	err = author.CreateKeychain(logger, keychainFilepath, passphrase,
-		veryLightScryptN, veryLightScryptP)
+		veryLightKeychainParams)
 	assert.Nil(t, err)
 	kg2 := &keychainsGetterImpl{}
 	authorKeys, selfReaderKeys, err = kg2.get()
@@ -255,10 +298,13 @@ func TestKeychainsGetter_get_err(t *testing.T) {
 type fixedAuthorUploader struct {
 	envelopeKey id.ID
 	err error
+	content io.Reader
+	mediaType string
 }
 
 func (f *fixedAuthorUploader) upload(author *lauthor.Author, content io.Reader, mediaType string) (
 	id.ID, error) {
+	f.content, f.mediaType = content, mediaType
 	return f.envelopeKey, f.err
 }
 
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/server/replicate"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	verifyEnvelopeKeyFlag = "verifyEnvelopeKey"
+	minReplicasFlag       = "minReplicas"
+)
+
+var (
+	errUnhealthyEntry = errors.New("entry is not healthy")
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "verify that an uploaded entry's pages are replicated and valid",
+	Long:  `TODO (drausin) add long description and examples`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := newEntryVerifier().verify(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	authorCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringP(verifyEnvelopeKeyFlag, "e", "",
+		"key of envelope whose entry to verify")
+	verifyCmd.Flags().UintP(minReplicasFlag, "r", replicate.DefaultNReplicas,
+		"minimum number of librarians that should store each page")
+
+	// bind viper flags
+	viper.SetEnvPrefix(envVarPrefix) // look for env vars with "LIBRI_" prefix
+	viper.AutomaticEnv()             // read in environment variables that match
+	if err := viper.BindPFlags(verifyCmd.Flags()); err != nil {
+		panic(err)
+	}
+}
+
+type entryVerifier interface {
+	verify() error
+}
+
+func newEntryVerifier() entryVerifier {
+	return &entryVerifierImpl{
+		ag: newAuthorGetter(),
+		av: &authorVerifierImpl{},
+		kc: &keychainsGetterImpl{
+			pg: &terminalPassphraseGetter{},
+		},
+	}
+}
+
+type entryVerifierImpl struct {
+	ag authorGetter
+	av authorVerifier
+	kc keychainsGetter
+}
+
+func (v *entryVerifierImpl) verify() error {
+	envelopeKeyStr := viper.GetString(verifyEnvelopeKeyFlag)
+	if envelopeKeyStr == "" {
+		return errMissingEnvelopeKey
+	}
+	envelopeKey, err := id.FromString(envelopeKeyStr)
+	if err != nil {
+		return err
+	}
+	authorKeys, selfReaderKeys, err := v.kc.get()
+	if err != nil {
+		return err
+	}
+	author, logger, err := v.ag.get(authorKeys, selfReaderKeys)
+	if err != nil {
+		return err
+	}
+	minReplicas := uint(viper.GetInt(minReplicasFlag))
+	logger.Info("verifying document",
+		zap.Stringer("envelope_key", envelopeKey),
+		zap.Uint(minReplicasFlag, minReplicas),
+	)
+	health, err := v.av.verify(author, envelopeKey, minReplicas)
+	if err != nil {
+		return err
+	}
+	for i, ph := range health.Pages {
+		logger.Info("page health",
+			zap.Int("page", i),
+			zap.Stringer("page_key", ph.Key),
+			zap.Uint("n_replicas", ph.NReplicas),
+			zap.Bool("valid_mac", ph.ValidMAC),
+			zap.Bool("healthy", ph.Healthy(health.MinReplicas)),
+		)
+	}
+	logger.Info("entry health",
+		zap.Stringer("entry_key", health.EntryKey),
+		zap.Int("n_pages", len(health.Pages)),
+		zap.Int("n_healthy_pages", health.NHealthyPages()),
+		zap.Bool("valid_ciphertext_mac", health.ValidCiphertextMAC),
+	)
+	if !health.Healthy() {
+		return errUnhealthyEntry
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	lauthor "github.com/drausin/libri/libri/author"
+	"github.com/drausin/libri/libri/common/id"
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEntryVerifier(t *testing.T) {
+	v := newEntryVerifier()
+	assert.NotNil(t, v)
+}
+
+func TestEntryVerifier_verify_ok(t *testing.T) {
+	v := &entryVerifierImpl{
+		ag: &fixedAuthorGetter{
+			author: nil, // ok since we're passing it into a mocked method anyway
+			logger: clogging.NewDevInfoLogger(),
+		},
+		av: &fixedAuthorVerifier{
+			health: &lauthor.EntryHealth{
+				EntryKey:    id.LowerBound,
+				MinReplicas: 1,
+				Pages: []*lauthor.PageHealth{
+					{Key: id.LowerBound, NReplicas: 1, ValidMAC: true},
+				},
+				ValidCiphertextMAC: true,
+			},
+		},
+		kc: &fixedKeychainsGetter{}, // ok that KCs are null since passing to mock
+	}
+	viper.Set(verifyEnvelopeKeyFlag, id.LowerBound.String())
+	viper.Set(minReplicasFlag, 1)
+
+	err := v.verify()
+	assert.Nil(t, err)
+}
+
+func TestEntryVerifier_verify_err(t *testing.T) {
+	// should error on missing envelopeKey
+	v1 := &entryVerifierImpl{}
+	viper.Set(verifyEnvelopeKeyFlag, "")
+	err := v1.verify()
+	assert.Equal(t, errMissingEnvelopeKey, err)
+
+	// should error on bad envelope key
+	v2 := &entryVerifierImpl{}
+	viper.Set(verifyEnvelopeKeyFlag, "0")
+	err = v2.verify()
+	assert.NotNil(t, err)
+
+	// error getting author keys should bubble up
+	viper.Set(verifyEnvelopeKeyFlag, id.LowerBound.String())
+	v3 := &entryVerifierImpl{
+		kc: &fixedKeychainsGetter{err: errors.New("some get error")},
+	}
+	err = v3.verify()
+	assert.NotNil(t, err)
+
+	// error getting author should bubble up
+	v4 := &entryVerifierImpl{
+		ag: &fixedAuthorGetter{err: errors.New("some get error")},
+		kc: &fixedKeychainsGetter{}, // ok that KCs are null since passing to mock
+	}
+	err = v4.verify()
+	assert.NotNil(t, err)
+
+	// verify error should bubble up
+	v5 := &entryVerifierImpl{
+		ag: &fixedAuthorGetter{
+			author: nil, // ok since we're passing it into a mocked method anyway
+			logger: clogging.NewDevInfoLogger(),
+		},
+		av: &fixedAuthorVerifier{err: errors.New("some verify error")},
+		kc: &fixedKeychainsGetter{}, // ok that KCs are null since passing to mock
+	}
+	err = v5.verify()
+	assert.NotNil(t, err)
+
+	// unhealthy entry should error
+	v6 := &entryVerifierImpl{
+		ag: &fixedAuthorGetter{
+			author: nil, // ok since we're passing it into a mocked method anyway
+			logger: clogging.NewDevInfoLogger(),
+		},
+		av: &fixedAuthorVerifier{
+			health: &lauthor.EntryHealth{
+				EntryKey:    id.LowerBound,
+				MinReplicas: 2,
+				Pages: []*lauthor.PageHealth{
+					{Key: id.LowerBound, NReplicas: 1, ValidMAC: true},
+				},
+				ValidCiphertextMAC: true,
+			},
+		},
+		kc: &fixedKeychainsGetter{}, // ok that KCs are null since passing to mock
+	}
+	err = v6.verify()
+	assert.Equal(t, errUnhealthyEntry, err)
+}
+
+type fixedAuthorVerifier struct {
+	health *lauthor.EntryHealth
+	err    error
+}
+
+func (f *fixedAuthorVerifier) verify(
+	author *lauthor.Author, envelopeKey id.ID, minReplicas uint,
+) (*lauthor.EntryHealth, error) {
+	return f.health, f.err
+}
@@ -20,6 +20,14 @@ type KVDB interface {
 	// Delete removes the value for a key.
 	Delete(key []byte) error
 
+	// Iterate calls the callback for each key-value pair whose key has the given prefix until
+	// either all pairs have been visited or the done channel is closed.
+	Iterate(prefix []byte, done chan struct{}, callback func(key, value []byte)) error
+
+	// IterateFrom is like Iterate but starts at the first key with the prefix at or after the
+	// start key instead of at the first key with the prefix.
+	IterateFrom(prefix, start []byte, done chan struct{}, callback func(key, value []byte)) error
+
 	// Close gracefully shuts down the database.
 	Close()
 }
@@ -94,6 +102,39 @@ func (db *RocksDB) Delete(key []byte) error {
 	return db.rdb.Delete(db.wo, key)
 }
 
+// Iterate calls the callback for each key-value pair whose key has the given prefix until
+// either all pairs have been visited or the done channel is closed.
+func (db *RocksDB) Iterate(
+	prefix []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	return db.IterateFrom(prefix, prefix, done, callback)
+}
+
+// IterateFrom calls the callback for each key-value pair whose key has the given prefix and is at
+// or after the start key until either all pairs have been visited or the done channel is closed.
+func (db *RocksDB) IterateFrom(
+	prefix, start []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	it := db.rdb.NewIterator(db.ro)
+	defer it.Close()
+	for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		keySlice, valueSlice := it.Key(), it.Value()
+
+		// copy bytes since the slices are only valid until the iterator moves
+		key := append([]byte(nil), keySlice.Data()...)
+		value := append([]byte(nil), valueSlice.Data()...)
+		keySlice.Free()
+		valueSlice.Free()
+		callback(key, value)
+	}
+	return it.Err()
+}
+
 // Close gracefully shuts down the database.
 func (db *RocksDB) Close() {
 	db.rdb.Close()
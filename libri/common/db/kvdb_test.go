@@ -76,3 +76,60 @@ func TestRocksDB_PutGetDeleteGet(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, getValue2)
 }
+
+func TestRocksDB_Iterate(t *testing.T) {
+	db, cleanup, err := NewTempDirRocksDB()
+	defer cleanup()
+	defer db.Close()
+	assert.Nil(t, err)
+
+	assert.Nil(t, db.Put([]byte("a1"), []byte("value1")))
+	assert.Nil(t, db.Put([]byte("a2"), []byte("value2")))
+	assert.Nil(t, db.Put([]byte("b1"), []byte("value3")))
+
+	// check iterates over only keys with prefix
+	visited := make(map[string]string)
+	err = db.Iterate([]byte("a"), make(chan struct{}), func(key, value []byte) {
+		visited[string(key)] = string(value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a1": "value1", "a2": "value2"}, visited)
+
+	// check closed done channel stops iteration
+	done := make(chan struct{})
+	close(done)
+	nVisited := 0
+	err = db.Iterate([]byte("a"), done, func(key, value []byte) {
+		nVisited++
+	})
+	assert.Nil(t, err)
+	assert.Zero(t, nVisited)
+}
+
+func TestRocksDB_IterateFrom(t *testing.T) {
+	db, cleanup, err := NewTempDirRocksDB()
+	defer cleanup()
+	defer db.Close()
+	assert.Nil(t, err)
+
+	assert.Nil(t, db.Put([]byte("a1"), []byte("value1")))
+	assert.Nil(t, db.Put([]byte("a2"), []byte("value2")))
+	assert.Nil(t, db.Put([]byte("a3"), []byte("value3")))
+	assert.Nil(t, db.Put([]byte("b1"), []byte("value4")))
+
+	// check iterates over only keys with prefix at or after start
+	visited := make([]string, 0)
+	err = db.IterateFrom([]byte("a"), []byte("a2"), make(chan struct{}),
+		func(key, value []byte) {
+			visited = append(visited, string(key))
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a2", "a3"}, visited)
+
+	// check start after all keys with prefix visits nothing
+	err = db.IterateFrom([]byte("a"), []byte("a4"), make(chan struct{}),
+		func(key, value []byte) {
+			assert.Fail(t, "unexpected visited key")
+		})
+	assert.Nil(t, err)
+}
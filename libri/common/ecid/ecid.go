@@ -1,9 +1,12 @@
 package ecid
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	crand "crypto/rand"
+	"crypto/sha256"
 	"io"
 	"math/big"
 	mrand "math/rand"
@@ -21,20 +24,41 @@ var Curve = secp256k1.S256()
 // CurveName gives the name of the elliptic curve used for the private key.
 const CurveName = "secp256k1"
 
-// ErrKeyPointOffCurve indicates when a public key does not lay on the expected elliptic curve.
-var ErrKeyPointOffCurve = errors.New("key point is off the expected curve")
+var (
+	// ErrKeyPointOffCurve indicates when a public key does not lay on the expected elliptic
+	// curve.
+	ErrKeyPointOffCurve = errors.New("key point is off the expected curve")
+
+	// ErrUnknownCurve indicates when a curve name is neither CurveName nor Ed25519CurveName.
+	ErrUnknownCurve = errors.New("unknown curve")
+
+	// ErrUnsupportedPublicKey indicates when a public key is neither an *ecdsa.PublicKey on Curve
+	// nor an ed25519.PublicKey.
+	ErrUnsupportedPublicKey = errors.New("unsupported public key type")
+)
 
 // ID is an elliptic curve identifier, where the ID is the x-value of the (x, y) public key
-// point on the curve. When couples with the private key, this allows something (e.g., a libri
-// peer) to sign messages that a receiver can verify.
+// point on the curve, or the public key itself for Ed25519 IDs. When couples with the private
+// key, this allows something (e.g., a libri peer) to sign messages that a receiver can verify.
 type ID interface {
 	cid.ID
 
-	// ECDSA private key (which includes public key as well)
+	// ECDSA private key (which includes public key as well), which is nil for Ed25519 IDs
 	Key() *ecdsa.PrivateKey
 
 	// underlying ID object
 	ID() cid.ID
+
+	// CurveName returns the name of the curve of the ID's key, either CurveName or
+	// Ed25519CurveName.
+	CurveName() string
+
+	// PublicKey returns the public key, either an *ecdsa.PublicKey or an ed25519.PublicKey.
+	PublicKey() crypto.PublicKey
+
+	// Signer returns the private key, either an *ecdsa.PrivateKey or an ed25519.PrivateKey, or
+	// nil if the ID only has a public key.
+	Signer() crypto.Signer
 }
 
 type ecid struct {
@@ -51,9 +75,23 @@ func NewRandom() ID {
 	return newRandom(crand.Reader)
 }
 
-// NewPseudoRandom creates a new ID instance using a math.Rand source of entropy.
+// NewPseudoRandom creates a new ID instance using a math.Rand source of entropy. The same source
+// state always creates the same ID.
 func NewPseudoRandom(rng *mrand.Rand) ID {
-	return newRandom(rng)
+	return newPseudoRandom(rng)
+}
+
+// NewRandomWithDifficulty creates a new ID instance with at least the given difficulty using a
+// crypto.Reader source of entropy. Each additional bit of difficulty doubles the expected number
+// of keys generated.
+func NewRandomWithDifficulty(minBits uint) ID {
+	return newRandomWithDifficulty(crand.Reader, minBits)
+}
+
+// NewPseudoRandomWithDifficulty creates a new ID instance with at least the given difficulty using
+// a math.Rand source of entropy.
+func NewPseudoRandomWithDifficulty(rng *mrand.Rand, minBits uint) ID {
+	return newDifficultID(rng, minBits, newPseudoRandom)
 }
 
 func newRandom(reader io.Reader) ID {
@@ -64,6 +102,66 @@ func newRandom(reader io.Reader) ID {
 	return FromPrivateKey(key)
 }
 
+// newPseudoRandom creates a new ID with a private key derived from a fixed number of bytes read
+// from the reader. Unlike ecdsa.GenerateKey, which may read an extra byte at random, it always
+// creates the same ID and leaves the reader in the same state for the same reader state.
+func newPseudoRandom(reader io.Reader) ID {
+	params := Curve.Params()
+	b := make([]byte, params.BitSize/8+8)
+	if _, err := io.ReadFull(reader, b); err != nil {
+		panic(err)
+	}
+	one := big.NewInt(1)
+	d := new(big.Int).SetBytes(b)
+	d.Mod(d, new(big.Int).Sub(params.N, one)).Add(d, one) // in [1, N-1]
+	key := &ecdsa.PrivateKey{D: d, PublicKey: ecdsa.PublicKey{Curve: Curve}}
+	key.X, key.Y = Curve.ScalarBaseMult(d.FillBytes(make([]byte, params.BitSize/8)))
+	return FromPrivateKey(key)
+}
+
+// NewRandomOnCurve creates a new ID instance on the named curve, either CurveName or
+// Ed25519CurveName, with at least the given difficulty using a crypto.Reader source of entropy.
+func NewRandomOnCurve(curveName string, minBits uint) (ID, error) {
+	switch curveName {
+	case CurveName:
+		return newRandomWithDifficulty(crand.Reader, minBits), nil
+	case Ed25519CurveName:
+		return newDifficultID(crand.Reader, minBits, newRandomEd25519), nil
+	default:
+		return nil, ErrUnknownCurve
+	}
+}
+
+func newRandomWithDifficulty(reader io.Reader, minBits uint) ID {
+	return newDifficultID(reader, minBits, newRandom)
+}
+
+func newDifficultID(reader io.Reader, minBits uint, newRandom func(io.Reader) ID) ID {
+	for {
+		if x := newRandom(reader); Difficulty(x) >= minBits {
+			return x
+		}
+	}
+}
+
+// Difficulty returns the number of leading zero bits in the SHA-256 hash of the ID, i.e., of the
+// x-value of its public key. Since IDs with higher difficulty are more expensive to generate,
+// requiring a minimum difficulty raises the cost of creating many fake IDs.
+func Difficulty(x cid.ID) uint {
+	hash := sha256.Sum256(x.Bytes())
+	n := uint(0)
+	for _, b := range hash {
+		if b != 0 {
+			for ; b&0x80 == 0; b <<= 1 {
+				n++
+			}
+			return n
+		}
+		n += 8
+	}
+	return n
+}
+
 func (x *ecid) String() string {
 	return x.id.String()
 }
@@ -92,6 +190,21 @@ func (x *ecid) ID() cid.ID {
 	return x.id
 }
 
+func (x *ecid) CurveName() string {
+	return CurveName
+}
+
+func (x *ecid) PublicKey() crypto.PublicKey {
+	return &x.key.PublicKey
+}
+
+func (x *ecid) Signer() crypto.Signer {
+	if x.key.D == nil {
+		return nil
+	}
+	return x.key
+}
+
 // FromPrivateKey creates a new ID from an ECDSA private key.
 func FromPrivateKey(priv *ecdsa.PrivateKey) ID {
 	return &ecid{
@@ -100,6 +213,15 @@ func FromPrivateKey(priv *ecdsa.PrivateKey) ID {
 	}
 }
 
+// FromPublicKey creates a new ID from an ECDSA public key. The ID has no private key, so it can
+// identify a peer (e.g., in request metadata) but cannot sign messages.
+func FromPublicKey(pub *ecdsa.PublicKey) ID {
+	return &ecid{
+		key: &ecdsa.PrivateKey{PublicKey: *pub},
+		id:  cid.FromInt(pub.X),
+	}
+}
+
 // FromPublicKeyBytes creates a new ecdsa.PublicKey from the marshaled byte representation.
 func FromPublicKeyBytes(buf []byte) (*ecdsa.PublicKey, error) {
 	x, y := elliptic.Unmarshal(Curve, buf) // also checks (x, y) is on curve
@@ -113,7 +235,43 @@ func FromPublicKeyBytes(buf []byte) (*ecdsa.PublicKey, error) {
 	}, nil
 }
 
-// ToPublicKeyBytes marshals the public key of the ID to a byte representation.
+// FromAnyPublicKey creates a new (public key only) ID from either an *ecdsa.PublicKey on Curve or
+// an ed25519.PublicKey.
+func FromAnyPublicKey(pub crypto.PublicKey) (ID, error) {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		if pub.Curve != Curve {
+			return nil, ErrUnsupportedPublicKey
+		}
+		return FromPublicKey(pub), nil
+	case ed25519.PublicKey:
+		if len(pub) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedPublicKey
+		}
+		return FromEd25519PublicKey(pub), nil
+	default:
+		return nil, ErrUnsupportedPublicKey
+	}
+}
+
+// FromAnyPublicKeyBytes creates a new (public key only) ID from the marshaled byte
+// representation of a public key on either curve, which ToPublicKeyBytes gives.
+func FromAnyPublicKeyBytes(buf []byte) (ID, error) {
+	if len(buf) == ed25519.PublicKeySize {
+		return FromEd25519PublicKey(append(ed25519.PublicKey{}, buf...)), nil
+	}
+	pub, err := FromPublicKeyBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	return FromPublicKey(pub), nil
+}
+
+// ToPublicKeyBytes marshals the public key of the ID to a byte representation, which for Ed25519
+// IDs is the 32-byte public key.
 func ToPublicKeyBytes(x ID) []byte {
+	if pub, ok := x.PublicKey().(ed25519.PublicKey); ok {
+		return append([]byte{}, pub...)
+	}
 	return elliptic.Marshal(Curve, x.Key().X, x.Key().Y)
 }
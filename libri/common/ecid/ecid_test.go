@@ -2,6 +2,7 @@ package ecid
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"math/rand"
 	"testing"
@@ -41,6 +42,40 @@ func TestEcid_NewPsuedoRandom(t *testing.T) {
 		val := NewPseudoRandom(rng)
 		assert.True(t, val.Cmp(cid.LowerBound) >= 0)
 		assert.True(t, val.Cmp(cid.UpperBound) <= 0)
+		assert.True(t, Curve.IsOnCurve(val.Key().X, val.Key().Y))
+	}
+
+	// check same seed always creates same IDs and leaves generator in same state
+	for c := 0; c < 10; c++ {
+		rng1, rng2 := rand.New(rand.NewSource(int64(c))), rand.New(rand.NewSource(int64(c)))
+		assert.Equal(t, NewPseudoRandom(rng1).Key(), NewPseudoRandom(rng2).Key())
+		assert.Equal(t, rng1.Int63(), rng2.Int63())
+	}
+
+	assert.Panics(t, func() {
+		newPseudoRandom(&truncReader{})
+	})
+}
+
+func TestEcid_NewPseudoRandomWithDifficulty(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for minBits := uint(0); minBits < 8; minBits++ {
+		x := NewPseudoRandomWithDifficulty(rng, minBits)
+		assert.True(t, Difficulty(x) >= minBits)
+	}
+}
+
+func TestDifficulty(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for c := 0; c < 10; c++ {
+		x := NewPseudoRandom(rng)
+		hash := sha256.Sum256(x.Bytes())
+		n := Difficulty(x)
+		if n < 8 {
+			assert.True(t, hash[0]>>(7-n) == 1)
+		} else {
+			assert.Equal(t, byte(0), hash[0])
+		}
 	}
 }
 
@@ -132,3 +167,81 @@ func TestToPublicKeyBytes(t *testing.T) {
 	assert.Equal(t, i.Key().X, pub.X)
 	assert.Equal(t, i.Key().Y, pub.Y)
 }
+
+func TestFromPublicKey(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	i := NewPseudoRandom(rng)
+	pubID := FromPublicKey(&i.Key().PublicKey)
+	assert.Equal(t, i.ID(), pubID.ID())
+	assert.Equal(t, ToPublicKeyBytes(i), ToPublicKeyBytes(pubID))
+	assert.Nil(t, pubID.Key().D)
+}
+
+func TestNewRandomOnCurve_ok(t *testing.T) {
+	for _, curveName := range []string{CurveName, Ed25519CurveName} {
+		x, err := NewRandomOnCurve(curveName, 4)
+		assert.Nil(t, err)
+		assert.Equal(t, curveName, x.CurveName())
+		assert.True(t, Difficulty(x) >= 4)
+	}
+}
+
+func TestNewRandomOnCurve_err(t *testing.T) {
+	x, err := NewRandomOnCurve("other curve", 0)
+	assert.Equal(t, ErrUnknownCurve, err)
+	assert.Nil(t, x)
+}
+
+func TestEcid_PublicKey_Signer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	i := NewPseudoRandom(rng)
+	assert.Equal(t, CurveName, i.CurveName())
+	assert.Equal(t, &i.Key().PublicKey, i.PublicKey())
+	assert.Equal(t, i.Key(), i.Signer())
+
+	// check public key only ID can't sign
+	assert.Nil(t, FromPublicKey(&i.Key().PublicKey).Signer())
+}
+
+func TestFromAnyPublicKey_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for _, i := range []ID{NewPseudoRandom(rng), NewPseudoRandomEd25519(rng)} {
+		pubID, err := FromAnyPublicKey(i.PublicKey())
+		assert.Nil(t, err)
+		assert.Equal(t, i.ID(), pubID.ID())
+		assert.Equal(t, i.CurveName(), pubID.CurveName())
+		assert.Nil(t, pubID.Signer())
+	}
+}
+
+func TestFromAnyPublicKey_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	otherCurveKey, err := ecdsa.GenerateKey(elliptic.P256(), rng)
+	assert.Nil(t, err)
+	pubs := []interface{}{
+		&otherCurveKey.PublicKey,
+		[]byte("not a public key"),
+		ed25519.PublicKey("too short"),
+	}
+	for _, pub := range pubs {
+		pubID, err := FromAnyPublicKey(pub)
+		assert.Equal(t, ErrUnsupportedPublicKey, err)
+		assert.Nil(t, pubID)
+	}
+}
+
+func TestFromAnyPublicKeyBytes_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for _, i := range []ID{NewPseudoRandom(rng), NewPseudoRandomEd25519(rng)} {
+		pubID, err := FromAnyPublicKeyBytes(ToPublicKeyBytes(i))
+		assert.Nil(t, err)
+		assert.Equal(t, i.ID(), pubID.ID())
+		assert.Equal(t, i.PublicKey(), pubID.PublicKey())
+	}
+}
+
+func TestFromAnyPublicKeyBytes_err(t *testing.T) {
+	pubID, err := FromAnyPublicKeyBytes(make([]byte, 1))
+	assert.Equal(t, ErrKeyPointOffCurve, err)
+	assert.Nil(t, pubID)
+}
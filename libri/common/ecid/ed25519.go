@@ -0,0 +1,108 @@
+package ecid
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	crand "crypto/rand"
+	"io"
+	"math/big"
+	mrand "math/rand"
+
+	cid "github.com/drausin/libri/libri/common/id"
+)
+
+// Ed25519CurveName gives the name of the curve used for Ed25519 private keys, which sign
+// requests like ECDSA keys but can't encrypt documents.
+const Ed25519CurveName = "ed25519"
+
+type edid struct {
+	// private key, which is nil when the ID only has a public key
+	key ed25519.PrivateKey
+
+	// public key, whose bytes are also the ID
+	pub ed25519.PublicKey
+
+	// redundant ID instance (from the public key) to take advantage of existing ID methods
+	id cid.ID
+}
+
+// NewRandomEd25519 creates a new Ed25519 ID instance using a crypto.Reader source of entropy.
+func NewRandomEd25519() ID {
+	return newRandomEd25519(crand.Reader)
+}
+
+// NewPseudoRandomEd25519 creates a new Ed25519 ID instance using a math.Rand source of entropy.
+func NewPseudoRandomEd25519(rng *mrand.Rand) ID {
+	return newRandomEd25519(rng)
+}
+
+func newRandomEd25519(reader io.Reader) ID {
+	_, priv, err := ed25519.GenerateKey(reader)
+	if err != nil {
+		panic(err)
+	}
+	return FromEd25519PrivateKey(priv)
+}
+
+// FromEd25519PrivateKey creates a new ID from an Ed25519 private key.
+func FromEd25519PrivateKey(priv ed25519.PrivateKey) ID {
+	pub := priv.Public().(ed25519.PublicKey)
+	return &edid{
+		key: priv,
+		pub: pub,
+		id:  cid.FromBytes(pub),
+	}
+}
+
+// FromEd25519PublicKey creates a new ID from an Ed25519 public key. Like IDs from FromPublicKey,
+// the ID cannot sign messages.
+func FromEd25519PublicKey(pub ed25519.PublicKey) ID {
+	return &edid{
+		pub: pub,
+		id:  cid.FromBytes(pub),
+	}
+}
+
+func (x *edid) String() string {
+	return x.id.String()
+}
+
+func (x *edid) Bytes() []byte {
+	return x.id.Bytes()
+}
+
+func (x *edid) Int() *big.Int {
+	return x.id.Int()
+}
+
+func (x *edid) Cmp(other cid.ID) int {
+	return x.id.Cmp(other)
+}
+
+func (x *edid) Distance(other cid.ID) *big.Int {
+	return x.id.Distance(other)
+}
+
+func (x *edid) Key() *ecdsa.PrivateKey {
+	return nil
+}
+
+func (x *edid) ID() cid.ID {
+	return x.id
+}
+
+func (x *edid) CurveName() string {
+	return Ed25519CurveName
+}
+
+func (x *edid) PublicKey() crypto.PublicKey {
+	return x.pub
+}
+
+func (x *edid) Signer() crypto.Signer {
+	if x.key == nil {
+		return nil
+	}
+	return x.key
+}
@@ -0,0 +1,63 @@
+package ecid
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"math/rand"
+	"testing"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRandomEd25519_ok(t *testing.T) {
+	x1, x2 := NewRandomEd25519(), NewRandomEd25519()
+	assert.NotNil(t, x1)
+	assert.NotEqual(t, x1, x2)
+	assert.NotEqual(t, x1.ID(), x2.ID())
+}
+
+func TestNewRandomEd25519_err(t *testing.T) {
+	assert.Panics(t, func() {
+		newRandomEd25519(&truncReader{})
+	})
+}
+
+func TestNewPseudoRandomEd25519(t *testing.T) {
+	rng1, rng2 := rand.New(rand.NewSource(0)), rand.New(rand.NewSource(0))
+	for c := 0; c < 10; c++ {
+		x1, x2 := NewPseudoRandomEd25519(rng1), NewPseudoRandomEd25519(rng2)
+		assert.Equal(t, x1, x2)
+		assert.Equal(t, cid.Length, len(x1.Bytes()))
+		assert.Equal(t, cid.Length*2, len(x1.String()))
+	}
+}
+
+func TestEdid_cid(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	i, j := NewPseudoRandomEd25519(rng), NewPseudoRandomEd25519(rng)
+	assert.Equal(t, i.(*edid).id, i.ID())
+	assert.Equal(t, []byte(i.PublicKey().(ed25519.PublicKey)), i.Bytes())
+	assert.Equal(t, i.(*edid).id.Int(), i.Int())
+	assert.Equal(t, i.(*edid).id.Cmp(j), i.Cmp(j))
+	assert.Equal(t, i.(*edid).id.Distance(j), i.Distance(j))
+}
+
+func TestEdid_Signer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	x := NewPseudoRandomEd25519(rng)
+	assert.Equal(t, Ed25519CurveName, x.CurveName())
+	assert.Nil(t, x.Key())
+
+	// use Signer to sign message
+	message := []byte("some value to sign")
+	sig, err := x.Signer().Sign(rng, message, crypto.Hash(0))
+	assert.Nil(t, err)
+	assert.True(t, ed25519.Verify(x.PublicKey().(ed25519.PublicKey), message, sig))
+
+	// check public key only ID can't sign
+	pubID := FromEd25519PublicKey(x.PublicKey().(ed25519.PublicKey))
+	assert.Equal(t, x.ID(), pubID.ID())
+	assert.Equal(t, ToPublicKeyBytes(x), ToPublicKeyBytes(pubID))
+	assert.Nil(t, pubID.Signer())
+}
@@ -1,7 +1,9 @@
 package ecid
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"fmt"
 	"math/big"
 
@@ -16,6 +18,8 @@ func FromStored(stored *ECDSAPrivateKey) (ID, error) {
 	switch stored.Curve {
 	case "secp256k1":
 		key.PublicKey.Curve = secp256k1.S256()
+	case Ed25519CurveName:
+		return fromStoredEd25519(stored)
 	default:
 		return nil, fmt.Errorf("unrecognized curve %v", stored.Curve)
 	}
@@ -36,8 +40,30 @@ func FromStored(stored *ECDSAPrivateKey) (ID, error) {
 	}, nil
 }
 
+// fromStoredEd25519 creates a new Ed25519 ID instance from a ECID instance, whose D is the
+// private key seed and X is the public key.
+func fromStoredEd25519(stored *ECDSAPrivateKey) (ID, error) {
+	if len(stored.D) != ed25519.SeedSize {
+		return nil, fmt.Errorf("private key seed has length %d, expected %d", len(stored.D),
+			ed25519.SeedSize)
+	}
+	x := FromEd25519PrivateKey(ed25519.NewKeyFromSeed(stored.D))
+	if !bytes.Equal(stored.X, x.Bytes()) {
+		// redundancy check: should never hit this, but here just in case
+		return nil, fmt.Errorf("public key %x does not match private key", stored.X)
+	}
+	return x, nil
+}
+
 // ToStored creates a new ECID instance from an ID instance.
 func ToStored(ecid ID) *ECDSAPrivateKey {
+	if priv, ok := ecid.Signer().(ed25519.PrivateKey); ok {
+		return &ECDSAPrivateKey{
+			Curve: Ed25519CurveName,
+			X:     ecid.Bytes(),
+			D:     priv.Seed(),
+		}
+	}
 	key := ecid.Key()
 	return &ECDSAPrivateKey{
 		Curve: CurveName,
@@ -29,7 +29,8 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 // ECDSAPrivateKey represents an ECDSA key-pair, whose public key x-value is used as the peer ID
-// to outside world.
+// to outside world. Ed25519 key-pairs have an "ed25519" curve, the private key seed as D, and the
+// public key as X.
 type ECDSAPrivateKey struct {
 	// name of the curve used
 	Curve string `protobuf:"bytes,1,opt,name=curve" json:"curve,omitempty"`
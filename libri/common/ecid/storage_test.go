@@ -41,3 +41,24 @@ func TestToFromStored_pubKeyErr(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, retrieved)
 }
+
+func TestToFromStored_ed25519(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	original := NewPseudoRandomEd25519(rng)
+	stored := ToStored(original)
+	assert.Equal(t, Ed25519CurveName, stored.Curve)
+	retrieved, err := FromStored(stored)
+	assert.Nil(t, err)
+	assert.Equal(t, original, retrieved)
+
+	// check wrong seed length and mismatched public key errors
+	stored.X = NewPseudoRandomEd25519(rng).Bytes()
+	retrieved, err = FromStored(stored)
+	assert.NotNil(t, err)
+	assert.Nil(t, retrieved)
+
+	stored.D = stored.D[1:]
+	retrieved, err = FromStored(stored)
+	assert.NotNil(t, err)
+	assert.Nil(t, retrieved)
+}
@@ -0,0 +1,28 @@
+package id
+
+import "math/big"
+
+// Bits is the number of bits in an ID.
+const Bits = Length * 8
+
+// SharedPrefixLen returns the number of leading bits the two IDs have in common.
+func SharedPrefixLen(x, y ID) uint {
+	return Bits - DistanceBucket(x.Distance(y))
+}
+
+// DistanceBucket returns the bucket of an XOR distance between two IDs, i.e., its bit length. IDs
+// in bucket b from a target share their first Bits - b bits with it, so bucket 0 contains only the
+// target itself.
+func DistanceBucket(distance *big.Int) uint {
+	return uint(distance.BitLen())
+}
+
+// DistanceHistogram returns the number of IDs in each distance bucket from the target, indexed
+// by bucket from 0 to Bits.
+func DistanceHistogram(target ID, ids []ID) []int {
+	counts := make([]int, Bits+1)
+	for _, x := range ids {
+		counts[DistanceBucket(target.Distance(x))]++
+	}
+	return counts
+}
@@ -0,0 +1,63 @@
+package id
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedPrefixLen(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	x := NewPseudoRandom(rng)
+	cases := []struct {
+		x        ID
+		y        ID
+		expected uint
+	}{
+		{x: LowerBound, y: LowerBound, expected: Bits},
+		{x: x, y: x, expected: Bits},
+		{x: LowerBound, y: UpperBound, expected: 0},
+		{x: LowerBound, y: FromInt64(1), expected: Bits - 1},
+		{x: FromInt64(2), y: FromInt64(3), expected: Bits - 1},
+		{x: FromInt64(4), y: FromInt64(3), expected: Bits - 3},
+		{x: FromInt(new(big.Int).Lsh(big.NewInt(1), Bits-2)), y: LowerBound, expected: 1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, SharedPrefixLen(c.x, c.y), "x: %v, y: %v", c.x, c.y)
+		assert.Equal(t, c.expected, SharedPrefixLen(c.y, c.x), "x: %v, y: %v", c.x, c.y)
+	}
+}
+
+func TestDistanceBucket(t *testing.T) {
+	assert.Equal(t, uint(0), DistanceBucket(big.NewInt(0)))
+	assert.Equal(t, uint(1), DistanceBucket(big.NewInt(1)))
+	assert.Equal(t, uint(8), DistanceBucket(big.NewInt(255)))
+	assert.Equal(t, uint(9), DistanceBucket(big.NewInt(256)))
+	assert.Equal(t, uint(Bits), DistanceBucket(UpperBound.Int()))
+}
+
+func TestDistanceHistogram(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	target := LowerBound
+	ids := []ID{LowerBound, FromInt64(1), FromInt64(2), FromInt64(3), UpperBound}
+	counts := DistanceHistogram(target, ids)
+	assert.Len(t, counts, Bits+1)
+	assert.Equal(t, 1, counts[0])
+	assert.Equal(t, 1, counts[1])
+	assert.Equal(t, 2, counts[2])
+	assert.Equal(t, 1, counts[Bits])
+
+	// check counts add up to number of IDs
+	ids = make([]ID, 64)
+	for i := range ids {
+		ids[i] = NewPseudoRandom(rng)
+	}
+	counts = DistanceHistogram(NewPseudoRandom(rng), ids)
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	assert.Equal(t, len(ids), total)
+}
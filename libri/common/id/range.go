@@ -0,0 +1,42 @@
+package id
+
+import "math/big"
+
+// FullRange spans the entire ID space.
+var FullRange = Range{Lower: LowerBound, Upper: UpperBound}
+
+// idSpace is the size of the ID space, i.e., 2^256.
+var idSpace = new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), Bits))
+
+// Range is a contiguous range of IDs.
+type Range struct {
+	// Lower is the (inclusive) lower bound of the range.
+	Lower ID
+
+	// Upper is the (exclusive) upper bound of the range.
+	Upper ID
+}
+
+// Contains returns whether the ID is in the range.
+func (r Range) Contains(x ID) bool {
+	return x.Cmp(r.Lower) >= 0 && x.Cmp(r.Upper) < 0
+}
+
+// Mass returns the proportion of the ID space the range spans.
+func (r Range) Mass() float64 {
+	width := new(big.Float).SetInt(new(big.Int).Sub(r.Upper.Int(), r.Lower.Int()))
+	mass, _ := new(big.Float).Quo(width, idSpace).Float64()
+	return mass
+}
+
+// Split splits a range of IDs sharing a prefix of the given bit depth into the two ranges whose
+// IDs share the prefix followed by a 0 and 1 bit, respectively.
+// e.g.,
+//
+//	[00000000, 11111111) at depth 0 -> [00000000, 10000000), [10000000, 11111111)
+//	[10000000, 11111111) at depth 1 -> [10000000, 11000000), [11000000, 11111111)
+//	[10000000, 11000000) at depth 2 -> [10000000, 10100000), [10100000, 11000000)
+func (r Range) Split(depth uint) (Range, Range) {
+	middle := FromInt(new(big.Int).SetBit(r.Lower.Int(), int(Bits-depth-1), 1))
+	return Range{Lower: r.Lower, Upper: middle}, Range{Lower: middle, Upper: r.Upper}
+}
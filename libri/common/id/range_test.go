@@ -0,0 +1,62 @@
+package id
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange_Contains(t *testing.T) {
+	r := Range{Lower: FromInt64(64), Upper: FromInt64(128)}
+	assert.False(t, r.Contains(FromInt64(63)))
+	assert.True(t, r.Contains(FromInt64(64)))
+	assert.True(t, r.Contains(FromInt64(127)))
+	assert.False(t, r.Contains(FromInt64(128)))
+
+	rng := rand.New(rand.NewSource(0))
+	for c := 0; c < 10; c++ {
+		assert.True(t, FullRange.Contains(NewPseudoRandom(rng)))
+	}
+}
+
+func TestRange_Mass(t *testing.T) {
+	assert.InDelta(t, 1.0, FullRange.Mass(), 1e-9)
+	left, right := FullRange.Split(0)
+	assert.InDelta(t, 0.5, left.Mass(), 1e-9)
+	assert.InDelta(t, 0.5, right.Mass(), 1e-9)
+	leftLeft, leftRight := left.Split(1)
+	assert.InDelta(t, 0.25, leftLeft.Mass(), 1e-9)
+	assert.InDelta(t, 0.25, leftRight.Mass(), 1e-9)
+	assert.Zero(t, Range{Lower: FromInt64(1), Upper: FromInt64(1)}.Mass())
+}
+
+func TestRange_Split(t *testing.T) {
+	check := func(lower ID, depth uint, expectedMiddle ID) {
+		r := Range{Lower: lower, Upper: UpperBound}
+		left, right := r.Split(depth)
+		assert.Equal(t, Range{Lower: lower, Upper: expectedMiddle}, left)
+		assert.Equal(t, Range{Lower: expectedMiddle, Upper: UpperBound}, right)
+	}
+
+	check(LowerBound, 0, newIDLsh(1, 255))                  // no prefix
+	check(newIDLsh(128, 248), 1, newIDLsh(192, 248))        // prefix 1
+	check(newIDLsh(1, 254), 2, newIDLsh(3, 253))            // prefix 01
+	check(newIDLsh(170, 248), 7, newIDLsh(171, 248))        // prefix 1010101
+	check(newIDLsh(0, 248), 8, newIDLsh(128, 240))          // prefix 00000000
+	check(newIDLsh(255, 248), 9, newIDLsh(255<<8|64, 240))  // prefix 11111111 0
+	check(newIDLsh(128, 248), 8, newIDLsh(128<<8|128, 240)) // prefix 10000000
+
+	// check split ranges partition the original
+	rng := rand.New(rand.NewSource(0))
+	left, right := FullRange.Split(0)
+	for c := 0; c < 10; c++ {
+		x := NewPseudoRandom(rng)
+		assert.NotEqual(t, left.Contains(x), right.Contains(x))
+	}
+}
+
+func newIDLsh(x int64, n uint) ID {
+	return FromInt(new(big.Int).Lsh(big.NewInt(x), n))
+}
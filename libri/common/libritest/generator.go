@@ -0,0 +1,136 @@
+package libritest
+
+import (
+	"math/rand"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/drausin/libri/libri/librarian/server/routing"
+)
+
+// Generator generates test fixtures from a seeded random number generator, so the same seed and
+// sequence of calls always generates the same fixtures.
+type Generator interface {
+	// Rand returns the random number generator the fixtures are generated from.
+	Rand() *rand.Rand
+
+	// Bytes generates random bytes of the given length.
+	Bytes(length int) []byte
+
+	// ID generates a random ID.
+	ID() cid.ID
+
+	// ECID generates a random ECDSA ID.
+	ECID() ecid.ID
+
+	// Document generates an Entry document with a single page and returns it with its key.
+	Document() (*api.Document, cid.ID)
+
+	// Envelope generates an Envelope with fake author and reader public keys.
+	Envelope() *api.Envelope
+
+	// Entry generates an Entry with a single page.
+	Entry() *api.Entry
+
+	// MultiPageEntry generates an Entry with two page keys.
+	MultiPageEntry() *api.Entry
+
+	// Page generates a Page with a fake author public key.
+	Page() *api.Page
+
+	// Tombstone generates a Tombstone with a fake author signature.
+	Tombstone() *api.Tombstone
+
+	// Publication generates a Publication whose envelope key matches its other fields.
+	Publication() *api.Publication
+
+	// Peer generates a peer with a random ID and the other fields incrementing with its index.
+	Peer(idx int) peer.Peer
+
+	// Peers generates n peers with indices 0 to n-1.
+	Peers(n int) []peer.Peer
+
+	// StoredPeer generates a stored peer with a random ID and the other fields incrementing with
+	// its index.
+	StoredPeer(idx int) *storage.Peer
+
+	// RoutingTable generates a routing table with the given parameters, a random self ID, and
+	// n peers. It returns the table, its self ID, and the number of peers added to it, which may
+	// be less than n if some buckets fill up.
+	RoutingTable(params *routing.Parameters, n int) (routing.Table, ecid.ID, int)
+}
+
+type generator struct {
+	rng *rand.Rand
+}
+
+// NewGenerator returns a new Generator seeded with the given seed.
+func NewGenerator(seed int64) Generator {
+	return &generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (g *generator) Rand() *rand.Rand {
+	return g.rng
+}
+
+func (g *generator) Bytes(length int) []byte {
+	return api.RandBytes(g.rng, length)
+}
+
+func (g *generator) ID() cid.ID {
+	return cid.NewPseudoRandom(g.rng)
+}
+
+func (g *generator) ECID() ecid.ID {
+	return ecid.NewPseudoRandom(g.rng)
+}
+
+func (g *generator) Document() (*api.Document, cid.ID) {
+	return api.NewTestDocument(g.rng)
+}
+
+func (g *generator) Envelope() *api.Envelope {
+	return api.NewTestEnvelope(g.rng)
+}
+
+func (g *generator) Entry() *api.Entry {
+	return api.NewTestSinglePageEntry(g.rng)
+}
+
+func (g *generator) MultiPageEntry() *api.Entry {
+	return api.NewTestMultiPageEntry(g.rng)
+}
+
+func (g *generator) Page() *api.Page {
+	return api.NewTestPage(g.rng)
+}
+
+func (g *generator) Tombstone() *api.Tombstone {
+	return api.NewTestTombstone(g.rng)
+}
+
+func (g *generator) Publication() *api.Publication {
+	return api.NewTestPublication(g.rng)
+}
+
+func (g *generator) Peer(idx int) peer.Peer {
+	return peer.NewTestPeer(g.rng, idx)
+}
+
+func (g *generator) Peers(n int) []peer.Peer {
+	return peer.NewTestPeers(g.rng, n)
+}
+
+func (g *generator) StoredPeer(idx int) *storage.Peer {
+	return peer.NewTestStoredPeer(g.rng, idx)
+}
+
+func (g *generator) RoutingTable(params *routing.Parameters, n int) (
+	routing.Table, ecid.ID, int) {
+	selfID := g.ECID()
+	rt, nAdded := routing.NewWithPeers(selfID, params, g.Peers(n))
+	return rt, selfID, nAdded
+}
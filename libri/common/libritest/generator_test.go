@@ -0,0 +1,80 @@
+package libritest
+
+import (
+	"testing"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator_deterministic(t *testing.T) {
+	g1, g2, g3 := NewGenerator(0), NewGenerator(0), NewGenerator(1)
+
+	// check same seed generates same fixtures and different seed generates different ones
+	b1, b2, b3 := g1.Bytes(32), g2.Bytes(32), g3.Bytes(32)
+	assert.Equal(t, b1, b2)
+	assert.NotEqual(t, b1, b3)
+
+	id1, id2, id3 := g1.ID(), g2.ID(), g3.ID()
+	assert.Equal(t, id1, id2)
+	assert.NotEqual(t, id1, id3)
+
+	ecid1, ecid2, ecid3 := g1.ECID(), g2.ECID(), g3.ECID()
+	assert.Equal(t, ecid1.String(), ecid2.String())
+	assert.NotEqual(t, ecid1.String(), ecid3.String())
+
+	doc1, key1 := g1.Document()
+	doc2, key2 := g2.Document()
+	doc3, key3 := g3.Document()
+	assert.Equal(t, doc1, doc2)
+	assert.Equal(t, key1, key2)
+	assert.NotEqual(t, key1, key3)
+	assert.NotEqual(t, doc1, doc3)
+
+	assert.Equal(t, g1.Envelope(), g2.Envelope())
+	assert.Equal(t, g1.Entry(), g2.Entry())
+	assert.Equal(t, g1.MultiPageEntry(), g2.MultiPageEntry())
+	assert.Equal(t, g1.Page(), g2.Page())
+	assert.Equal(t, g1.Tombstone(), g2.Tombstone())
+	assert.Equal(t, g1.Publication(), g2.Publication())
+	assert.Equal(t, g1.StoredPeer(0), g2.StoredPeer(0))
+
+	p1, p2 := g1.Peer(1), g2.Peer(1)
+	assert.Equal(t, p1.ID(), p2.ID())
+	assert.Equal(t, p1.Connector().Address(), p2.Connector().Address())
+
+	ps1, ps2 := g1.Peers(8), g2.Peers(8)
+	assert.Len(t, ps1, 8)
+	for i := range ps1 {
+		assert.Equal(t, ps1[i].ID(), ps2[i].ID())
+	}
+
+	params := routing.NewDefaultParameters()
+	rt1, selfID1, nAdded1 := g1.RoutingTable(params, 16)
+	rt2, selfID2, nAdded2 := g2.RoutingTable(params, 16)
+	assert.Equal(t, selfID1.String(), selfID2.String())
+	assert.Equal(t, nAdded1, nAdded2)
+	assert.Equal(t, nAdded1, rt1.NumPeers())
+	assert.Equal(t, rt1.NumBuckets(), rt2.NumBuckets())
+	assert.Equal(t, selfID1.String(), rt1.SelfID().String())
+
+	assert.Equal(t, g1.Rand().Int63(), g2.Rand().Int63())
+}
+
+func TestGenerator_valid(t *testing.T) {
+	g := NewGenerator(0)
+
+	// check generated documents are valid
+	doc, key := g.Document()
+	assert.Nil(t, api.ValidateDocument(doc))
+	docKey, err := api.GetKey(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, key, docKey)
+
+	assert.Nil(t, api.ValidateEnvelope(g.Envelope()))
+	assert.Nil(t, api.ValidateEntry(g.Entry()))
+	assert.Nil(t, api.ValidateEntry(g.MultiPageEntry()))
+	assert.Nil(t, api.ValidatePage(g.Page()))
+	assert.Nil(t, api.ValidatePublication(g.Publication()))
+}
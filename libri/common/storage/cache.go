@@ -0,0 +1,56 @@
+package storage
+
+import (
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// pageCacheDocumentStorerLoader is a DocumentStorerLoader that keeps the most recently stored or
+// loaded pages in an in-memory LRU cache in front of the inner DocumentStorerLoader.
+type pageCacheDocumentStorerLoader struct {
+	inner DocumentStorerLoader
+	pages *lru.Cache
+}
+
+// NewPageCacheDocumentStorerLoader creates a new DocumentStorerLoader that caches up to size of the
+// most recently stored or loaded page documents in memory, so loading them again doesn't need the
+// inner DocumentStorerLoader. Since other documents (e.g., envelopes) may be replaced, only pages
+// are cached.
+func NewPageCacheDocumentStorerLoader(inner DocumentStorerLoader, size uint32) (
+	DocumentStorerLoader, error) {
+	pages, err := lru.New(int(size))
+	if err != nil {
+		return nil, err
+	}
+	return &pageCacheDocumentStorerLoader{
+		inner: inner,
+		pages: pages,
+	}, nil
+}
+
+func (sl *pageCacheDocumentStorerLoader) Store(key cid.ID, value *api.Document) error {
+	if err := sl.inner.Store(key, value); err != nil {
+		return err
+	}
+	sl.maybeCache(key, value)
+	return nil
+}
+
+func (sl *pageCacheDocumentStorerLoader) Load(key cid.ID) (*api.Document, error) {
+	if value, in := sl.pages.Get(key.String()); in {
+		return value.(*api.Document), nil
+	}
+	value, err := sl.inner.Load(key)
+	if err != nil {
+		return nil, err
+	}
+	sl.maybeCache(key, value)
+	return value, nil
+}
+
+func (sl *pageCacheDocumentStorerLoader) maybeCache(key cid.ID, value *api.Document) {
+	if value.GetPage() != nil {
+		sl.pages.Add(key.String(), value)
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPageCacheDocumentStorerLoader_err(t *testing.T) {
+	sl, err := NewPageCacheDocumentStorerLoader(&memDocumentStorerLoader{}, 0)
+	assert.NotNil(t, err)
+	assert.Nil(t, sl)
+}
+
+func TestPageCacheDocumentStorerLoader_StoreLoad(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	inner := &memDocumentStorerLoader{docs: make(map[string]*api.Document)}
+	sl, err := NewPageCacheDocumentStorerLoader(inner, 2)
+	assert.Nil(t, err)
+
+	pages := make([]*api.Document, 3)
+	pageKeys := make([]cid.ID, 3)
+	for i := range pages {
+		pages[i], pageKeys[i] = newTestPageDoc(rng)
+		err = sl.Store(pageKeys[i], pages[i])
+		assert.Nil(t, err)
+	}
+	entry, entryKey := api.NewTestDocument(rng)
+	err = sl.Store(entryKey, entry)
+	assert.Nil(t, err)
+
+	// check recent pages are loaded from cache
+	for i := 1; i < 3; i++ {
+		loaded, err := sl.Load(pageKeys[i])
+		assert.Nil(t, err)
+		assert.Equal(t, pages[i], loaded)
+	}
+	assert.Zero(t, inner.nLoads)
+
+	// check evicted page and non-page are loaded from inner storage
+	loaded, err := sl.Load(pageKeys[0])
+	assert.Nil(t, err)
+	assert.Equal(t, pages[0], loaded)
+	loaded, err = sl.Load(entryKey)
+	assert.Nil(t, err)
+	assert.Equal(t, entry, loaded)
+	assert.Equal(t, 2, inner.nLoads)
+
+	// check page loaded from inner storage is then cached
+	loaded, err = sl.Load(pageKeys[0])
+	assert.Nil(t, err)
+	assert.Equal(t, pages[0], loaded)
+	assert.Equal(t, 2, inner.nLoads)
+
+	// check missing document isn't cached
+	loaded, err = sl.Load(cid.NewPseudoRandom(rng))
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+}
+
+func TestPageCacheDocumentStorerLoader_StoreLoad_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	inner := &memDocumentStorerLoader{
+		docs:     make(map[string]*api.Document),
+		storeErr: errors.New("some Store error"),
+		loadErr:  errors.New("some Load error"),
+	}
+	sl, err := NewPageCacheDocumentStorerLoader(inner, 2)
+	assert.Nil(t, err)
+	page, pageKey := newTestPageDoc(rng)
+
+	// check inner store error bubbles up and page isn't cached
+	err = sl.Store(pageKey, page)
+	assert.NotNil(t, err)
+
+	// check inner load error bubbles up
+	loaded, err := sl.Load(pageKey)
+	assert.NotNil(t, err)
+	assert.Nil(t, loaded)
+}
+
+func newTestPageDoc(rng *rand.Rand) (*api.Document, cid.ID) {
+	doc := &api.Document{Contents: &api.Document_Page{Page: api.NewTestPage(rng)}}
+	key, err := api.GetKey(doc)
+	if err != nil {
+		panic(err)
+	}
+	return doc, key
+}
+
+type memDocumentStorerLoader struct {
+	docs     map[string]*api.Document
+	nLoads   int
+	storeErr error
+	loadErr  error
+}
+
+func (m *memDocumentStorerLoader) Store(key cid.ID, value *api.Document) error {
+	if m.storeErr != nil {
+		return m.storeErr
+	}
+	m.docs[key.String()] = value
+	return nil
+}
+
+func (m *memDocumentStorerLoader) Load(key cid.ID) (*api.Document, error) {
+	if m.loadErr != nil {
+		return nil, m.loadErr
+	}
+	m.nLoads++
+	return m.docs[key.String()], nil
+}
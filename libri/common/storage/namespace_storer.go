@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"bytes"
+
 	"github.com/drausin/libri/libri/common/db"
 	cid "github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/librarian/api"
@@ -21,6 +23,18 @@ const (
 	// on top of the value to account for Entry values other than the actual ciphertext (which
 	// we want to be <= 2MB).
 	MaxEntriesValueLength = 2*1024*1024 + 1024
+
+	// PublicationsKeyLength is the fixed length (in bytes) of all publication keys, which are
+	// big-endian uint64 sequence numbers.
+	PublicationsKeyLength = 8
+
+	// AuditKeyLength is the fixed length (in bytes) of all audit log keys, which are big-endian
+	// uint64 sequence numbers.
+	AuditKeyLength = 8
+
+	// AccessHashLength is the fixed length (in bytes) of all access hashes, which are SHA-256
+	// hashes of reader public keys.
+	AccessHashLength = 32
 )
 
 var (
@@ -32,6 +46,28 @@ var (
 
 	// Documents namespace contains all libri p2p stored values.
 	Documents Namespace = []byte("documents")
+
+	// Publications namespace contains the log of received publications.
+	Publications Namespace = []byte("publications")
+
+	// Audit namespace contains the log of mutating requests.
+	Audit Namespace = []byte("audit")
+
+	// Access namespace contains the access hashes of private documents.
+	Access Namespace = []byte("access")
+
+	// Uploads namespace contains the index of a client's uploaded documents.
+	Uploads Namespace = []byte("uploads")
+
+	// KeyLedger namespace contains the ledger of keys used by a client's envelopes.
+	KeyLedger Namespace = []byte("keyledger")
+
+	// Checkpoints namespace contains the checkpoints of a client's incomplete uploads.
+	Checkpoints Namespace = []byte("checkpoints")
+
+	// CheckpointPages namespace prefixes the namespace of each upload checkpoint's published
+	// pages.
+	CheckpointPages Namespace = []byte("checkpointpages")
 )
 
 // Namespace denotes a storage namespace, which reduces to a key prefix.
@@ -60,6 +96,42 @@ type NamespaceStorerLoader interface {
 	NamespaceLoader
 }
 
+// NamespaceIterator iterates over the values in the configured namespace.
+type NamespaceIterator interface {
+	// Iterate calls the callback for each key-value pair in the configured namespace, in key
+	// order, until either all pairs have been visited or the done channel is closed.
+	Iterate(done chan struct{}, callback func(key, value []byte)) error
+}
+
+// NamespaceStorerLoaderIterator stores, loads, and iterates over values in a configured
+// namespace.
+type NamespaceStorerLoaderIterator interface {
+	NamespaceStorerLoader
+	NamespaceIterator
+}
+
+// NamespaceDeleter deletes values in the configured namespace from the durable storage.
+type NamespaceDeleter interface {
+	// Delete the value for the key in the configured namespace.
+	Delete(key []byte) error
+}
+
+// NamespaceStorerLoaderIteratorDeleter stores, loads, iterates over, and deletes values in a
+// configured namespace.
+type NamespaceStorerLoaderIteratorDeleter interface {
+	NamespaceStorerLoaderIterator
+	NamespaceDeleter
+}
+
+// NamespaceLogStorer stores, loads, iterates over, and deletes values in a configured namespace
+// whose keys sort in the order they are appended, like the entries of a log.
+type NamespaceLogStorer interface {
+	NamespaceStorerLoaderIteratorDeleter
+
+	// IterateFrom is like Iterate but starts at the first key at or after the start key.
+	IterateFrom(start []byte, done chan struct{}, callback func(key, value []byte)) error
+}
+
 type namespaceStorerLoader struct {
 	ns Namespace
 	sl StorerLoader
@@ -73,6 +145,22 @@ func (nsl *namespaceStorerLoader) Load(key []byte) ([]byte, error) {
 	return nsl.sl.Load(nsl.ns.Bytes(), key)
 }
 
+func (nsl *namespaceStorerLoader) Iterate(
+	done chan struct{}, callback func(key, value []byte),
+) error {
+	return nsl.sl.Iterate(nsl.ns.Bytes(), done, callback)
+}
+
+func (nsl *namespaceStorerLoader) IterateFrom(
+	start []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	return nsl.sl.IterateFrom(nsl.ns.Bytes(), start, done, callback)
+}
+
+func (nsl *namespaceStorerLoader) Delete(key []byte) error {
+	return nsl.sl.Delete(nsl.ns.Bytes(), key)
+}
+
 // DocumentStorer stores api.Document values.
 type DocumentStorer interface {
 	// Store an api.Document value under the given key.
@@ -91,13 +179,30 @@ type DocumentStorerLoader interface {
 	DocumentLoader
 }
 
+// DocumentIterator iterates over the keys of stored api.Document values.
+type DocumentIterator interface {
+	// Iterate calls the callback for the key of each stored api.Document until either all
+	// have been visited or the done channel is closed.
+	Iterate(done chan struct{}, callback func(key cid.ID)) error
+
+	// IterateFrom is like Iterate but starts at the first key at or after the start key.
+	IterateFrom(start cid.ID, done chan struct{}, callback func(key cid.ID)) error
+}
+
+// DocumentStorerLoaderIterator stores, loads, and iterates over api.Document values.
+type DocumentStorerLoaderIterator interface {
+	DocumentStorerLoader
+	DocumentIterator
+}
+
 // keyHashNamespaceStorerLoader checks that the key equals the hash of the value before storing it.
 type documentStorerLoader struct {
-	nsl NamespaceStorerLoader
+	nsl *namespaceStorerLoader
 	c   KeyValueChecker
 }
 
-// Store checks that the key equals the SHA256 hash of the value before storing it.
+// Store checks that the key equals the SHA256 hash of the value (or, for a Tombstone, the key of
+// the document it deletes) before storing it.
 func (dnsl *documentStorerLoader) Store(key cid.ID, value *api.Document) error {
 	if err := api.ValidateDocument(value); err != nil {
 		return err
@@ -107,7 +212,7 @@ func (dnsl *documentStorerLoader) Store(key cid.ID, value *api.Document) error {
 		return err
 	}
 	keyBytes := key.Bytes()
-	if err := dnsl.c.Check(keyBytes, valueBytes); err != nil {
+	if err := dnsl.check(keyBytes, value, valueBytes); err != nil {
 		return err
 	}
 	return dnsl.nsl.Store(keyBytes, valueBytes)
@@ -122,14 +227,14 @@ func (dnsl *documentStorerLoader) Load(key cid.ID) (*api.Document, error) {
 	if valueBytes == nil {
 		return nil, nil
 	}
-	if err := dnsl.c.Check(keyBytes, valueBytes); err != nil {
-		// should never happen b/c we check on Store, but being defensive just in case
-		return nil, err
-	}
 	doc := &api.Document{}
 	if err := proto.Unmarshal(valueBytes, doc); err != nil {
 		return nil, err
 	}
+	if err := dnsl.check(keyBytes, doc, valueBytes); err != nil {
+		// should never happen b/c we check on Store, but being defensive just in case
+		return nil, err
+	}
 	if err := api.ValidateDocument(doc); err != nil {
 		// should never happen b/c we check on Store, but being defensive just in case
 		return nil, err
@@ -137,6 +242,35 @@ func (dnsl *documentStorerLoader) Load(key cid.ID) (*api.Document, error) {
 	return doc, nil
 }
 
+// check checks the key against the value, which for a Tombstone is the key of the document it
+// deletes rather than its hash.
+func (dnsl *documentStorerLoader) check(key []byte, value *api.Document, valueBytes []byte) error {
+	if tombstone := value.GetTombstone(); tombstone != nil {
+		if !bytes.Equal(key, tombstone.DocumentKey) {
+			return api.ErrUnexpectedKey
+		}
+		return nil
+	}
+	return dnsl.c.Check(key, valueBytes)
+}
+
+// Iterate calls the callback for the key of each stored api.Document.
+func (dnsl *documentStorerLoader) Iterate(done chan struct{}, callback func(key cid.ID)) error {
+	return dnsl.nsl.Iterate(done, func(key, value []byte) {
+		callback(cid.FromBytes(key))
+	})
+}
+
+// IterateFrom calls the callback for the key of each stored api.Document at or after the start
+// key.
+func (dnsl *documentStorerLoader) IterateFrom(
+	start cid.ID, done chan struct{}, callback func(key cid.ID),
+) error {
+	return dnsl.nsl.IterateFrom(start.Bytes(), done, func(key, value []byte) {
+		callback(cid.FromBytes(key))
+	})
+}
+
 // NewServerStorerLoader creates a new NamespaceStorerLoader for the "server" namespace.
 func NewServerStorerLoader(sl StorerLoader) NamespaceStorerLoader {
 	return &namespaceStorerLoader{
@@ -178,7 +312,7 @@ func NewClientKVDBStorerLoader(kvdb db.KVDB) NamespaceStorerLoader {
 }
 
 // NewDocumentStorerLoader creates a new DocumentStorerLoader for the "documents" namespace.
-func NewDocumentStorerLoader(sl StorerLoader) DocumentStorerLoader {
+func NewDocumentStorerLoader(sl StorerLoader) DocumentStorerLoaderIterator {
 	return &documentStorerLoader{
 		nsl: &namespaceStorerLoader{
 			ns: Documents,
@@ -190,7 +324,7 @@ func NewDocumentStorerLoader(sl StorerLoader) DocumentStorerLoader {
 
 // NewDocumentKVDBStorerLoader creates a new NamespaceStorerLoader for the "entries" namespace
 // backed by a db.KVDB instance.
-func NewDocumentKVDBStorerLoader(kvdb db.KVDB) DocumentStorerLoader {
+func NewDocumentKVDBStorerLoader(kvdb db.KVDB) DocumentStorerLoaderIterator {
 	return NewDocumentStorerLoader(
 		NewKVDBStorerLoader(
 			kvdb,
@@ -199,3 +333,155 @@ func NewDocumentKVDBStorerLoader(kvdb db.KVDB) DocumentStorerLoader {
 		),
 	)
 }
+
+// NewPublicationStorerLoader creates a new NamespaceLogStorer for the "publications" namespace.
+func NewPublicationStorerLoader(sl StorerLoader) NamespaceLogStorer {
+	return &namespaceStorerLoader{
+		ns: Publications,
+		sl: sl,
+	}
+}
+
+// NewPublicationKVDBStorerLoader creates a new NamespaceLogStorer for the "publications"
+// namespace backed by a db.KVDB instance.
+func NewPublicationKVDBStorerLoader(kvdb db.KVDB) NamespaceLogStorer {
+	return NewPublicationStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(PublicationsKeyLength),
+			NewMaxLengthChecker(MaxNamespaceValueLength),
+		),
+	)
+}
+
+// NewAuditStorerLoader creates a new NamespaceStorerLoaderIterator for the "audit" namespace.
+func NewAuditStorerLoader(sl StorerLoader) NamespaceStorerLoaderIterator {
+	return &namespaceStorerLoader{
+		ns: Audit,
+		sl: sl,
+	}
+}
+
+// NewAuditKVDBStorerLoader creates a new NamespaceStorerLoaderIterator for the "audit" namespace
+// backed by a db.KVDB instance.
+func NewAuditKVDBStorerLoader(kvdb db.KVDB) NamespaceStorerLoaderIterator {
+	return NewAuditStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(AuditKeyLength),
+			NewMaxLengthChecker(MaxNamespaceValueLength),
+		),
+	)
+}
+
+// NewAccessStorerLoader creates a new NamespaceStorerLoader for the "access" namespace.
+func NewAccessStorerLoader(sl StorerLoader) NamespaceStorerLoader {
+	return &namespaceStorerLoader{
+		ns: Access,
+		sl: sl,
+	}
+}
+
+// NewAccessKVDBStorerLoader creates a new NamespaceStorerLoader for the "access" namespace backed
+// by a db.KVDB instance.
+func NewAccessKVDBStorerLoader(kvdb db.KVDB) NamespaceStorerLoader {
+	return NewAccessStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(EntriesKeyLength),
+			NewExactLengthChecker(AccessHashLength),
+		),
+	)
+}
+
+// NewUploadsStorerLoader creates a new NamespaceStorerLoaderIterator for the "uploads" namespace.
+func NewUploadsStorerLoader(sl StorerLoader) NamespaceStorerLoaderIterator {
+	return &namespaceStorerLoader{
+		ns: Uploads,
+		sl: sl,
+	}
+}
+
+// NewUploadsKVDBStorerLoader creates a new NamespaceStorerLoaderIterator for the "uploads"
+// namespace backed by a db.KVDB instance.
+func NewUploadsKVDBStorerLoader(kvdb db.KVDB) NamespaceStorerLoaderIterator {
+	return NewUploadsStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(EntriesKeyLength),
+			NewMaxLengthChecker(MaxNamespaceValueLength),
+		),
+	)
+}
+
+// NewKeyLedgerStorerLoader creates a new NamespaceStorerLoaderIterator for the "keyledger"
+// namespace.
+func NewKeyLedgerStorerLoader(sl StorerLoader) NamespaceStorerLoaderIterator {
+	return &namespaceStorerLoader{
+		ns: KeyLedger,
+		sl: sl,
+	}
+}
+
+// NewKeyLedgerKVDBStorerLoader creates a new NamespaceStorerLoaderIterator for the "keyledger"
+// namespace backed by a db.KVDB instance.
+func NewKeyLedgerKVDBStorerLoader(kvdb db.KVDB) NamespaceStorerLoaderIterator {
+	return NewKeyLedgerStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(EntriesKeyLength),
+			NewMaxLengthChecker(MaxNamespaceValueLength),
+		),
+	)
+}
+
+// NewCheckpointStorerLoader creates a new NamespaceStorerLoaderIteratorDeleter for the
+// "checkpoints" namespace.
+func NewCheckpointStorerLoader(sl StorerLoader) NamespaceStorerLoaderIteratorDeleter {
+	return &namespaceStorerLoader{
+		ns: Checkpoints,
+		sl: sl,
+	}
+}
+
+// NewCheckpointKVDBStorerLoader creates a new NamespaceStorerLoaderIteratorDeleter for the
+// "checkpoints" namespace backed by a db.KVDB instance.
+func NewCheckpointKVDBStorerLoader(kvdb db.KVDB) NamespaceStorerLoaderIteratorDeleter {
+	return NewCheckpointStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(EntriesKeyLength),
+			NewMaxLengthChecker(MaxNamespaceValueLength),
+		),
+	)
+}
+
+// NewCheckpointPagesStorerLoader creates a new NamespaceStorerLoaderIteratorDeleter for the
+// published pages of the upload checkpoint with the given entry key.
+func NewCheckpointPagesStorerLoader(
+	sl StorerLoader, entryKey cid.ID,
+) NamespaceStorerLoaderIteratorDeleter {
+	// exact capacity so appending keys to the namespace never shares its backing array
+	entryKeyBytes := entryKey.Bytes()
+	ns := make(Namespace, len(CheckpointPages)+len(entryKeyBytes))
+	copy(ns[copy(ns, CheckpointPages):], entryKeyBytes)
+	return &namespaceStorerLoader{
+		ns: ns,
+		sl: sl,
+	}
+}
+
+// NewCheckpointPagesKVDBStorerLoader creates a new NamespaceStorerLoaderIteratorDeleter for the
+// published pages of the upload checkpoint with the given entry key backed by a db.KVDB instance.
+func NewCheckpointPagesKVDBStorerLoader(
+	kvdb db.KVDB, entryKey cid.ID,
+) NamespaceStorerLoaderIteratorDeleter {
+	return NewCheckpointPagesStorerLoader(
+		NewKVDBStorerLoader(
+			kvdb,
+			NewExactLengthChecker(EntriesKeyLength),
+			NewMaxLengthChecker(MaxNamespaceValueLength),
+		),
+		entryKey,
+	)
+}
@@ -117,6 +117,217 @@ func TestDocumentNamespaceStorerLoader_StoreLoad_ok(t *testing.T) {
 	assert.Equal(t, value1, value2)
 }
 
+func TestDocumentNamespaceStorerLoader_StoreLoad_tombstone(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	dsl := NewDocumentKVDBStorerLoader(kvdb)
+
+	rng := rand.New(rand.NewSource(0))
+	value1, key := api.NewTestDocument(rng)
+	err = dsl.Store(key, value1)
+	assert.Nil(t, err)
+
+	// check tombstone replaces the document it deletes
+	tombstone := api.NewTestTombstone(rng)
+	tombstone.DocumentKey = key.Bytes()
+	value2 := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	err = dsl.Store(key, value2)
+	assert.Nil(t, err)
+	value3, err := dsl.Load(key)
+	assert.Nil(t, err)
+	assert.Equal(t, value2, value3)
+
+	// check tombstone for another document returns error
+	err = dsl.Store(cid.NewPseudoRandom(rng), value2)
+	assert.Equal(t, api.ErrUnexpectedKey, err)
+}
+
+func TestDocumentStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	dsl := NewDocumentKVDBStorerLoader(kvdb)
+	ssl := NewServerKVDBStorerLoader(kvdb)
+
+	rng := rand.New(rand.NewSource(0))
+	nDocs := 4
+	stored := make(map[string]struct{})
+	for i := 0; i < nDocs; i++ {
+		value, key := api.NewTestDocument(rng)
+		assert.Nil(t, dsl.Store(key, value))
+		stored[key.String()] = struct{}{}
+	}
+
+	// value in other namespace shouldn't be visited
+	assert.Nil(t, ssl.Store([]byte("key"), []byte("value")))
+
+	visited := make(map[string]struct{})
+	err = dsl.Iterate(make(chan struct{}), func(key cid.ID) {
+		visited[key.String()] = struct{}{}
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, stored, visited)
+
+	// check iterating from a key only visits it and the keys after it
+	start := cid.NewPseudoRandom(rng)
+	err = dsl.IterateFrom(start, make(chan struct{}), func(key cid.ID) {
+		assert.True(t, key.Cmp(start) >= 0)
+		delete(visited, key.String())
+	})
+	assert.Nil(t, err)
+	for keyStr := range visited {
+		key, err := cid.FromString(keyStr)
+		assert.Nil(t, err)
+		assert.True(t, key.Cmp(start) < 0)
+	}
+}
+
+func TestPublicationStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	psl := NewPublicationKVDBStorerLoader(kvdb)
+	ssl := NewServerKVDBStorerLoader(kvdb)
+
+	// store out of order to check that iteration is in key order
+	keys := [][]byte{
+		{0, 0, 0, 0, 0, 0, 0, 2},
+		{0, 0, 0, 0, 0, 0, 0, 1},
+		{0, 0, 0, 0, 0, 0, 1, 0},
+	}
+	for _, key := range keys {
+		assert.Nil(t, psl.Store(key, []byte("value")))
+	}
+
+	// value in other namespace shouldn't be visited
+	assert.Nil(t, ssl.Store([]byte("key"), []byte("value")))
+
+	// key with wrong length is rejected
+	assert.NotNil(t, psl.Store([]byte("key"), []byte("value")))
+
+	visited := make([][]byte, 0)
+	err = psl.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, key)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{keys[1], keys[0], keys[2]}, visited)
+
+	// check iteration from a key starts there
+	visited = make([][]byte, 0)
+	err = psl.IterateFrom(keys[0], make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, key)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{keys[0], keys[2]}, visited)
+
+	// check deleted key isn't visited
+	assert.Nil(t, psl.Delete(keys[0]))
+	visited = make([][]byte, 0)
+	err = psl.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, key)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{keys[1], keys[2]}, visited)
+}
+
+func TestAccessStorerLoader(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	asl := NewAccessKVDBStorerLoader(kvdb)
+
+	key, hash := cid.NewPseudoRandom(rng).Bytes(), api.RandBytes(rng, AccessHashLength)
+	assert.Nil(t, asl.Store(key, hash))
+	loaded, err := asl.Load(key)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, loaded)
+
+	// check keys and hashes with wrong lengths are rejected
+	assert.NotNil(t, asl.Store([]byte("key"), hash))
+	assert.NotNil(t, asl.Store(key, []byte("hash")))
+}
+
+func TestAuditStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	asl := NewAuditKVDBStorerLoader(kvdb)
+	psl := NewPublicationKVDBStorerLoader(kvdb)
+
+	key := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	assert.Nil(t, asl.Store(key, []byte("value")))
+
+	// value with same key in publications namespace shouldn't be visited
+	assert.Nil(t, psl.Store(key, []byte("other value")))
+
+	// key with wrong length is rejected
+	assert.NotNil(t, asl.Store([]byte("key"), []byte("value")))
+
+	visited := make([][]byte, 0)
+	err = asl.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("value")}, visited)
+}
+
+func TestUploadsStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	usl := NewUploadsKVDBStorerLoader(kvdb)
+	csl := NewClientKVDBStorerLoader(kvdb)
+
+	key := cid.NewPseudoRandom(rand.New(rand.NewSource(0))).Bytes()
+	assert.Nil(t, usl.Store(key, []byte("value")))
+
+	// value with same key in client namespace shouldn't be visited
+	assert.Nil(t, csl.Store(key, []byte("other value")))
+
+	// key with wrong length is rejected
+	assert.NotNil(t, usl.Store([]byte("key"), []byte("value")))
+
+	visited := make([][]byte, 0)
+	err = usl.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("value")}, visited)
+}
+
+func TestKeyLedgerStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	ksl := NewKeyLedgerKVDBStorerLoader(kvdb)
+	usl := NewUploadsKVDBStorerLoader(kvdb)
+
+	key := cid.NewPseudoRandom(rand.New(rand.NewSource(0))).Bytes()
+	assert.Nil(t, ksl.Store(key, []byte("value")))
+
+	// value with same key in uploads namespace shouldn't be visited
+	assert.Nil(t, usl.Store(key, []byte("other value")))
+
+	// key with wrong length is rejected
+	assert.NotNil(t, ksl.Store([]byte("key"), []byte("value")))
+
+	visited := make([][]byte, 0)
+	err = ksl.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("value")}, visited)
+}
+
 func TestDocumentNamespaceStorerLoader_Store_err(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 
@@ -155,6 +366,22 @@ func (fsl *fixedStorerLoader) Load(namespace []byte, key []byte) ([]byte, error)
 	return fsl.loadValue, fsl.loadErr
 }
 
+func (fsl *fixedStorerLoader) Iterate(
+	namespace []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	return nil
+}
+
+func (fsl *fixedStorerLoader) IterateFrom(
+	namespace, start []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	return nil
+}
+
+func (fsl *fixedStorerLoader) Delete(namespace []byte, key []byte) error {
+	return nil
+}
+
 func TestDocumentStorerLoader_Load_empty(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	key := cid.NewPseudoRandom(rng)
@@ -222,3 +449,66 @@ func TestDocumentStorerLoader_Load_validateDocumentErr(t *testing.T) {
 	_, err = dsl.Load(key)
 	assert.NotNil(t, err)
 }
+
+func TestCheckpointStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	rng := rand.New(rand.NewSource(0))
+	entryKey := cid.NewPseudoRandom(rng)
+	csl := NewCheckpointKVDBStorerLoader(kvdb)
+	psl := NewCheckpointPagesKVDBStorerLoader(kvdb, entryKey)
+
+	assert.Nil(t, csl.Store(entryKey.Bytes(), []byte("value")))
+
+	// published pages of the checkpoint shouldn't be visited
+	assert.Nil(t, psl.Store(cid.NewPseudoRandom(rng).Bytes(), []byte("other value")))
+
+	// key with wrong length is rejected
+	assert.NotNil(t, csl.Store([]byte("key"), []byte("value")))
+
+	visited := make([][]byte, 0)
+	err = csl.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("value")}, visited)
+
+	assert.Nil(t, csl.Delete(entryKey.Bytes()))
+	value, err := csl.Load(entryKey.Bytes())
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestCheckpointPagesStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	rng := rand.New(rand.NewSource(0))
+	psl1 := NewCheckpointPagesKVDBStorerLoader(kvdb, cid.NewPseudoRandom(rng))
+	psl2 := NewCheckpointPagesKVDBStorerLoader(kvdb, cid.NewPseudoRandom(rng))
+
+	pageKey := cid.NewPseudoRandom(rng).Bytes()
+	assert.Nil(t, psl1.Store(pageKey, []byte("value")))
+
+	// same page published by another checkpoint shouldn't be visited
+	assert.Nil(t, psl2.Store(pageKey, []byte("other value")))
+
+	visited := make([][]byte, 0)
+	err = psl1.Iterate(make(chan struct{}), func(key, value []byte) {
+		visited = append(visited, value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("value")}, visited)
+
+	// deleting from one checkpoint leaves the other's page
+	assert.Nil(t, psl1.Delete(pageKey))
+	value, err := psl1.Load(pageKey)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	value, err = psl2.Load(pageKey)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("other value"), value)
+}
@@ -14,6 +14,11 @@ It has these top-level messages:
 	QueryTypeOutcomes
 	Peer
 	RoutingTable
+	Subscription
+	Subscriptions
+	LoggedPublication
+	AuditRecord
+	Denylist
 */
 package storage
 
@@ -200,39 +205,247 @@ func (m *RoutingTable) GetPeers() []*Peer {
 	return nil
 }
 
+// Subscription is the essential information associated with a subscription to another peer.
+type Subscription struct {
+	// big-endian byte representation of 32-byte ID of the peer subscribed to
+	PeerId []byte `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	// encoded bloom filter of the author public keys to subscribe to
+	AuthorPublicKeys []byte `protobuf:"bytes,2,opt,name=author_public_keys,json=authorPublicKeys,proto3" json:"author_public_keys,omitempty"`
+	// encoded bloom filter of the reader public keys to subscribe to
+	ReaderPublicKeys []byte `protobuf:"bytes,3,opt,name=reader_public_keys,json=readerPublicKeys,proto3" json:"reader_public_keys,omitempty"`
+}
+
+func (m *Subscription) Reset()                    { *m = Subscription{} }
+func (m *Subscription) String() string            { return proto.CompactTextString(m) }
+func (*Subscription) ProtoMessage()               {}
+func (*Subscription) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Subscription) GetPeerId() []byte {
+	if m != nil {
+		return m.PeerId
+	}
+	return nil
+}
+
+func (m *Subscription) GetAuthorPublicKeys() []byte {
+	if m != nil {
+		return m.AuthorPublicKeys
+	}
+	return nil
+}
+
+func (m *Subscription) GetReaderPublicKeys() []byte {
+	if m != nil {
+		return m.ReaderPublicKeys
+	}
+	return nil
+}
+
+// Subscriptions contains the active subscriptions to other peers.
+type Subscriptions struct {
+	To []*Subscription `protobuf:"bytes,1,rep,name=to" json:"to,omitempty"`
+}
+
+func (m *Subscriptions) Reset()                    { *m = Subscriptions{} }
+func (m *Subscriptions) String() string            { return proto.CompactTextString(m) }
+func (*Subscriptions) ProtoMessage()               {}
+func (*Subscriptions) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Subscriptions) GetTo() []*Subscription {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+// LoggedPublication is a publication received and recorded in the publication log.
+type LoggedPublication struct {
+	// position of the publication in the log
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	// publication key
+	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// marshaled api.Publication value
+	Value []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	// epoch time (in nanoseconds) when the publication was logged
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	// public key of the peer the publication was received from
+	FromPublicKey []byte `protobuf:"bytes,5,opt,name=from_public_key,json=fromPublicKey,proto3" json:"from_public_key,omitempty"`
+}
+
+func (m *LoggedPublication) Reset()                    { *m = LoggedPublication{} }
+func (m *LoggedPublication) String() string            { return proto.CompactTextString(m) }
+func (*LoggedPublication) ProtoMessage()               {}
+func (*LoggedPublication) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *LoggedPublication) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *LoggedPublication) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *LoggedPublication) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *LoggedPublication) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *LoggedPublication) GetFromPublicKey() []byte {
+	if m != nil {
+		return m.FromPublicKey
+	}
+	return nil
+}
+
+// AuditRecord is a mutating request recorded in the audit log.
+type AuditRecord struct {
+	// position of the record in the log
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	// epoch time (in nanoseconds) when the request was recorded
+	Timestamp int64 `protobuf:"varint,2,opt,name=timestamp" json:"timestamp,omitempty"`
+	// public key of the requester, as given in the request metadata
+	RequesterPublicKey []byte `protobuf:"bytes,3,opt,name=requester_public_key,json=requesterPublicKey,proto3" json:"requester_public_key,omitempty"`
+	// name of the RPC requested
+	Rpc string `protobuf:"bytes,4,opt,name=rpc" json:"rpc,omitempty"`
+	// key of the document the request stored
+	Key []byte `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	// error message if the request failed, or empty if it succeeded
+	Error string `protobuf:"bytes,6,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *AuditRecord) Reset()                    { *m = AuditRecord{} }
+func (m *AuditRecord) String() string            { return proto.CompactTextString(m) }
+func (*AuditRecord) ProtoMessage()               {}
+func (*AuditRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *AuditRecord) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *AuditRecord) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *AuditRecord) GetRequesterPublicKey() []byte {
+	if m != nil {
+		return m.RequesterPublicKey
+	}
+	return nil
+}
+
+func (m *AuditRecord) GetRpc() string {
+	if m != nil {
+		return m.Rpc
+	}
+	return ""
+}
+
+func (m *AuditRecord) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *AuditRecord) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// Denylist contains the document keys an operator has denied.
+type Denylist struct {
+	// 32-byte keys of denied documents
+	Keys [][]byte `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (m *Denylist) Reset()                    { *m = Denylist{} }
+func (m *Denylist) String() string            { return proto.CompactTextString(m) }
+func (*Denylist) ProtoMessage()               {}
+func (*Denylist) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *Denylist) GetKeys() [][]byte {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Address)(nil), "storage.Address")
 	proto.RegisterType((*QueryOutcomes)(nil), "storage.QueryOutcomes")
 	proto.RegisterType((*QueryTypeOutcomes)(nil), "storage.QueryTypeOutcomes")
 	proto.RegisterType((*Peer)(nil), "storage.Peer")
 	proto.RegisterType((*RoutingTable)(nil), "storage.RoutingTable")
+	proto.RegisterType((*Subscription)(nil), "storage.Subscription")
+	proto.RegisterType((*Subscriptions)(nil), "storage.Subscriptions")
+	proto.RegisterType((*LoggedPublication)(nil), "storage.LoggedPublication")
+	proto.RegisterType((*AuditRecord)(nil), "storage.AuditRecord")
+	proto.RegisterType((*Denylist)(nil), "storage.Denylist")
 }
 
 func init() { proto.RegisterFile("libri/common/storage/storage.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 367 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x7c, 0x52, 0x3f, 0x8f, 0x9b, 0x30,
-	0x1c, 0x15, 0x84, 0x06, 0xf2, 0x23, 0x44, 0xad, 0x87, 0x94, 0xa6, 0x4b, 0x44, 0x17, 0x96, 0x26,
-	0x12, 0x95, 0xda, 0x2e, 0x1d, 0x3a, 0x74, 0xa8, 0x54, 0xa9, 0x17, 0x2b, 0x3b, 0x82, 0xf0, 0xbb,
-	0xc8, 0x12, 0xd8, 0x8e, 0x6d, 0x86, 0x4c, 0xa7, 0xfb, 0x2a, 0xf7, 0x49, 0x4f, 0x98, 0x3f, 0x77,
-	0xa7, 0x93, 0x6e, 0xc2, 0x4f, 0xef, 0xc1, 0xfb, 0x63, 0x20, 0xa9, 0x59, 0xa9, 0xd8, 0xfe, 0x24,
-	0x9a, 0x46, 0xf0, 0xbd, 0x36, 0x42, 0x15, 0x67, 0x1c, 0x9f, 0x3b, 0xa9, 0x84, 0x11, 0xc4, 0x1f,
-	0x60, 0xf2, 0x15, 0xfc, 0xdf, 0x55, 0xa5, 0x50, 0x6b, 0xb2, 0x02, 0x97, 0xc9, 0xd8, 0xdd, 0x3a,
-	0xe9, 0x82, 0xba, 0x4c, 0x12, 0x02, 0x9e, 0x14, 0xca, 0xc4, 0xb3, 0xad, 0x93, 0x46, 0xd4, 0x9e,
-	0x93, 0x7b, 0x07, 0xa2, 0x43, 0x8b, 0xea, 0xfa, 0xbf, 0x35, 0x27, 0xd1, 0xa0, 0x26, 0xdf, 0x21,
-	0x50, 0x78, 0x69, 0x51, 0x1b, 0x1d, 0x3b, 0x5b, 0x27, 0x0d, 0xb3, 0xcd, 0x6e, 0xf4, 0xb2, 0xca,
-	0xe3, 0x55, 0xe2, 0xa8, 0xa6, 0x93, 0x96, 0xfc, 0x84, 0x85, 0x42, 0x2d, 0x05, 0xd7, 0xa8, 0xad,
-	0xe9, 0xdb, 0x2f, 0x3e, 0x89, 0x93, 0x3b, 0xf8, 0xf0, 0x8a, 0x27, 0x1b, 0x08, 0xb0, 0x50, 0x35,
-	0x43, 0x6d, 0x6c, 0x8c, 0x19, 0x9d, 0x30, 0x59, 0xc3, 0xbc, 0x2e, 0x4c, 0xc7, 0xb8, 0x96, 0x19,
-	0x10, 0xf9, 0x0c, 0x0b, 0x9e, 0x5f, 0x5a, 0x54, 0x0c, 0xb5, 0x6d, 0xe9, 0xd1, 0x80, 0x1f, 0x7a,
-	0x4c, 0x3e, 0x41, 0xc0, 0x73, 0x54, 0x4a, 0x28, 0x1d, 0x7b, 0x96, 0xf3, 0xf9, 0x1f, 0x0b, 0x93,
-	0x07, 0x07, 0xbc, 0x1b, 0x44, 0x65, 0x17, 0xab, 0xac, 0xdd, 0x92, 0xba, 0xac, 0xea, 0x16, 0xe3,
-	0x45, 0x83, 0xc3, 0x86, 0xf6, 0x4c, 0x7e, 0xc0, 0x4a, 0xb6, 0x65, 0xcd, 0x4e, 0x79, 0xd1, 0xef,
-	0x6c, 0x9d, 0xc2, 0xec, 0xfd, 0x54, 0x76, 0xd8, 0x9f, 0x46, 0xbd, 0x6e, 0xbc, 0x8e, 0x5f, 0xb0,
-	0xea, 0xb2, 0x5d, 0x73, 0x31, 0x74, 0xb4, 0x31, 0xc2, 0x6c, 0xfd, 0x72, 0xa5, 0x69, 0xa1, 0xe8,
-	0xf2, 0x1c, 0x26, 0xff, 0x60, 0x49, 0x45, 0x6b, 0x18, 0x3f, 0x1f, 0x8b, 0xb2, 0x46, 0xf2, 0x11,
-	0x7c, 0x8d, 0xf5, 0x6d, 0x3e, 0x05, 0x9e, 0x77, 0xf0, 0x6f, 0x45, 0xbe, 0xc0, 0x3b, 0x89, 0xa8,
-	0xba, 0x4b, 0x98, 0xa5, 0x61, 0x16, 0x4d, 0x9f, 0xef, 0x2a, 0xd2, 0x9e, 0x2b, 0xe7, 0xf6, 0xb7,
-	0xf9, 0xf6, 0x18, 0x00, 0x00, 0xff, 0xff, 0x62, 0x73, 0x70, 0xa4, 0x5c, 0x02, 0x00, 0x00,
+	// 600 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x84, 0x54, 0x4f, 0x6f, 0xd3, 0x4e,
+	0x10, 0x95, 0x9d, 0x7f, 0xce, 0x24, 0xee, 0xaf, 0x5d, 0xf5, 0x57, 0x4c, 0x41, 0xa8, 0x32, 0x02,
+	0xe5, 0x00, 0x2d, 0x0a, 0x52, 0xe1, 0xc2, 0xa1, 0x12, 0x1c, 0x2a, 0x2a, 0xd1, 0x2e, 0xbd, 0x5b,
+	0x8e, 0x3d, 0x0d, 0xab, 0xda, 0x5e, 0x77, 0x77, 0x8d, 0xe4, 0x13, 0x42, 0x7c, 0x0e, 0x2e, 0x7c,
+	0x05, 0xbe, 0x20, 0xda, 0xb1, 0xe3, 0x24, 0x20, 0xc1, 0x29, 0xf3, 0xfc, 0x9e, 0x77, 0xde, 0x9b,
+	0xf1, 0x06, 0xc2, 0x4c, 0x2c, 0x94, 0x38, 0x49, 0x64, 0x9e, 0xcb, 0xe2, 0x44, 0x1b, 0xa9, 0xe2,
+	0x25, 0xae, 0x7e, 0x8f, 0x4b, 0x25, 0x8d, 0x64, 0xa3, 0x16, 0x86, 0xcf, 0x61, 0x74, 0x96, 0xa6,
+	0x0a, 0xb5, 0x66, 0x3b, 0xe0, 0x8a, 0x32, 0x70, 0x8f, 0x9c, 0xd9, 0x98, 0xbb, 0xa2, 0x64, 0x0c,
+	0xfa, 0xa5, 0x54, 0x26, 0xe8, 0x1d, 0x39, 0x33, 0x9f, 0x53, 0x1d, 0x7e, 0x75, 0xc0, 0xbf, 0xaa,
+	0x50, 0xd5, 0x1f, 0x2a, 0x93, 0xc8, 0x1c, 0x35, 0x3b, 0x05, 0x4f, 0xe1, 0x5d, 0x85, 0xda, 0xe8,
+	0xc0, 0x39, 0x72, 0x66, 0x93, 0xf9, 0xe1, 0xf1, 0xaa, 0x17, 0x29, 0xaf, 0xeb, 0x12, 0x57, 0x6a,
+	0xde, 0x69, 0xd9, 0x6b, 0x18, 0x2b, 0xd4, 0xa5, 0x2c, 0x34, 0xea, 0xc0, 0xfd, 0xe7, 0x8b, 0x6b,
+	0x71, 0xf8, 0x05, 0xf6, 0xfe, 0xe0, 0xd9, 0x21, 0x78, 0x18, 0xab, 0x4c, 0xa0, 0x36, 0x64, 0xa3,
+	0xc7, 0x3b, 0xcc, 0x0e, 0x60, 0x98, 0xc5, 0xc6, 0x32, 0x2e, 0x31, 0x2d, 0x62, 0x0f, 0x60, 0x5c,
+	0x44, 0x77, 0x15, 0x2a, 0x81, 0x9a, 0x52, 0xf6, 0xb9, 0x57, 0x5c, 0x35, 0x98, 0xdd, 0x07, 0xaf,
+	0x88, 0x50, 0x29, 0xa9, 0x74, 0xd0, 0x27, 0x6e, 0x54, 0xbc, 0x23, 0x18, 0xfe, 0x70, 0xa0, 0x7f,
+	0x89, 0xa8, 0x68, 0x62, 0x29, 0xb5, 0x9b, 0x72, 0x57, 0xa4, 0x76, 0x62, 0x45, 0x9c, 0x63, 0x3b,
+	0x43, 0xaa, 0xd9, 0x2b, 0xd8, 0x29, 0xab, 0x45, 0x26, 0x92, 0x28, 0x6e, 0xe6, 0x4c, 0x9d, 0x26,
+	0xf3, 0xdd, 0x2e, 0x6c, 0x3b, 0x7f, 0xee, 0x37, 0xba, 0x16, 0xb2, 0x37, 0xb0, 0x63, 0xbd, 0xd5,
+	0x91, 0x6c, 0x33, 0x92, 0x8d, 0xc9, 0xfc, 0x60, 0x7b, 0x4a, 0xdd, 0x84, 0xfc, 0xbb, 0x4d, 0x18,
+	0x5e, 0xc0, 0x94, 0xcb, 0xca, 0x88, 0x62, 0x79, 0x1d, 0x2f, 0x32, 0x64, 0xf7, 0x60, 0xa4, 0x31,
+	0xbb, 0x89, 0x3a, 0xc3, 0x43, 0x0b, 0xcf, 0x53, 0xf6, 0x18, 0x06, 0x25, 0xa2, 0xb2, 0x4b, 0xe8,
+	0xcd, 0x26, 0x73, 0xbf, 0x3b, 0xde, 0x46, 0xe4, 0x0d, 0x17, 0x7e, 0x73, 0x60, 0xfa, 0xb1, 0x5a,
+	0xe8, 0x44, 0x89, 0xd2, 0x08, 0x59, 0xd8, 0xe3, 0x2c, 0xb3, 0x71, 0x9c, 0x85, 0xe7, 0x29, 0x7b,
+	0x06, 0x2c, 0xae, 0xcc, 0x27, 0xa9, 0xa2, 0x36, 0xf6, 0x2d, 0xd6, 0xcd, 0x82, 0xa7, 0x7c, 0xb7,
+	0x61, 0x2e, 0x89, 0x78, 0x8f, 0xb5, 0xb6, 0x6a, 0x85, 0x71, 0x8a, 0xdb, 0xea, 0x5e, 0xa3, 0x6e,
+	0x98, 0xb5, 0x3a, 0x3c, 0x05, 0x7f, 0xd3, 0x84, 0x66, 0x4f, 0xc0, 0x35, 0x32, 0x70, 0xc8, 0xf8,
+	0xff, 0x9d, 0xf1, 0x4d, 0x0d, 0x77, 0x8d, 0x0c, 0xbf, 0x3b, 0xb0, 0x77, 0x21, 0x97, 0x4b, 0x4c,
+	0x9b, 0xc3, 0x62, 0x8a, 0x70, 0x08, 0x9e, 0xb6, 0x5f, 0x63, 0x91, 0x20, 0x65, 0xe8, 0xf3, 0x0e,
+	0xb3, 0x5d, 0xe8, 0xdd, 0x62, 0xdd, 0xda, 0xb6, 0x25, 0xdb, 0x87, 0xc1, 0xe7, 0x38, 0xab, 0xb0,
+	0x35, 0xd7, 0x00, 0xf6, 0x10, 0xc6, 0x46, 0xe4, 0xa8, 0x4d, 0x9c, 0x97, 0xb4, 0x9f, 0x1e, 0x5f,
+	0x3f, 0x60, 0x4f, 0xe1, 0xbf, 0x1b, 0x25, 0xf3, 0x8d, 0x6c, 0xc1, 0x80, 0xde, 0xf6, 0xed, 0xe3,
+	0x2e, 0x58, 0xf8, 0xd3, 0x81, 0xc9, 0x59, 0x95, 0x0a, 0xc3, 0x31, 0x91, 0x2a, 0xfd, 0xab, 0xb3,
+	0xad, 0x8e, 0xee, 0xef, 0x1d, 0x5f, 0xc0, 0x7e, 0x7b, 0xc3, 0xb6, 0x46, 0xda, 0x9a, 0x66, 0x1d,
+	0xd7, 0xf5, 0xb6, 0x49, 0x55, 0x99, 0x90, 0xf7, 0x31, 0xb7, 0xe5, 0x2a, 0xfb, 0x60, 0x2b, 0x3b,
+	0xdd, 0x84, 0x60, 0x48, 0xaa, 0x06, 0x84, 0x8f, 0xc0, 0x7b, 0x8b, 0x45, 0x9d, 0x09, 0x6d, 0xec,
+	0x97, 0x4f, 0x9b, 0xb3, 0xab, 0x98, 0x72, 0xaa, 0x17, 0x43, 0xfa, 0xab, 0x79, 0xf9, 0x6b, 0x00,
+	0x9a, 0x65, 0x31, 0x58, 0x90, 0x04, 0x00, 0x00,
 }
@@ -21,10 +21,29 @@ type Loader interface {
 	Load(namespace []byte, key []byte) ([]byte, error)
 }
 
-// StorerLoader can both store and load values.
+// Iterator iterates over the values in durable storage.
+type Iterator interface {
+	// Iterate calls the callback for each key-value pair in a given namespace until either all
+	// pairs have been visited or the done channel is closed.
+	Iterate(namespace []byte, done chan struct{}, callback func(key, value []byte)) error
+
+	// IterateFrom is like Iterate but starts at the first key at or after the start key.
+	IterateFrom(namespace, start []byte, done chan struct{},
+		callback func(key, value []byte)) error
+}
+
+// Deleter deletes values from durable storage.
+type Deleter interface {
+	// Delete the value for a given key and namespace.
+	Delete(namespace []byte, key []byte) error
+}
+
+// StorerLoader can store, load, iterate over, and delete values.
 type StorerLoader interface {
 	Storer
 	Loader
+	Iterator
+	Deleter
 }
 
 type kvdbStorerLoader struct {
@@ -68,6 +87,34 @@ func (sl *kvdbStorerLoader) Load(namespace []byte, key []byte) ([]byte, error) {
 	return sl.db.Get(namespaceKey(namespace, key))
 }
 
+func (sl *kvdbStorerLoader) Iterate(
+	namespace []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	return sl.IterateFrom(namespace, nil, done, callback)
+}
+
+func (sl *kvdbStorerLoader) IterateFrom(
+	namespace, start []byte, done chan struct{}, callback func(key, value []byte),
+) error {
+	if err := sl.nc.Check(namespace); err != nil {
+		return err
+	}
+	nsStart := namespaceKey(namespace, start)
+	return sl.db.IterateFrom(namespace, nsStart, done, func(nsKey, value []byte) {
+		callback(nsKey[len(namespace):], value)
+	})
+}
+
+func (sl *kvdbStorerLoader) Delete(namespace []byte, key []byte) error {
+	if err := sl.nc.Check(namespace); err != nil {
+		return err
+	}
+	if err := sl.kc.Check(key); err != nil {
+		return err
+	}
+	return sl.db.Delete(namespaceKey(namespace, key))
+}
+
 func namespaceKey(namespace []byte, key []byte) []byte {
 	return append(namespace, key...)
 }
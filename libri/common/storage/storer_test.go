@@ -38,3 +38,72 @@ func TestKvdbStorerLoader_StoreLoad(t *testing.T) {
 		assert.Equal(t, c.value, loaded)
 	}
 }
+
+func TestKvdbStorerLoader_Iterate(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	sl := NewKVDBStorerLoader(kvdb, NewMaxLengthChecker(256), NewMaxLengthChecker(1024))
+
+	assert.Nil(t, sl.Store([]byte("ns1"), []byte("key1"), []byte("value1")))
+	assert.Nil(t, sl.Store([]byte("ns1"), []byte("key2"), []byte("value2")))
+	assert.Nil(t, sl.Store([]byte("ns2"), []byte("key3"), []byte("value3")))
+
+	// check only keys (without namespace prefix) in namespace are visited
+	visited := make(map[string]string)
+	err = sl.Iterate([]byte("ns1"), make(chan struct{}), func(key, value []byte) {
+		visited[string(key)] = string(value)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key1": "value1", "key2": "value2"}, visited)
+
+	// check bad namespace returns error
+	err = sl.Iterate(bytes.Repeat([]byte{0}, 257), make(chan struct{}),
+		func(key, value []byte) {})
+	assert.NotNil(t, err)
+}
+
+func TestKvdbStorerLoader_IterateFrom(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	sl := NewKVDBStorerLoader(kvdb, NewMaxLengthChecker(256), NewMaxLengthChecker(1024))
+
+	assert.Nil(t, sl.Store([]byte("ns1"), []byte("key1"), []byte("value1")))
+	assert.Nil(t, sl.Store([]byte("ns1"), []byte("key2"), []byte("value2")))
+	assert.Nil(t, sl.Store([]byte("ns2"), []byte("key3"), []byte("value3")))
+
+	// check only keys in namespace at or after start are visited
+	visited := make([]string, 0)
+	err = sl.IterateFrom([]byte("ns1"), []byte("key2"), make(chan struct{}),
+		func(key, value []byte) {
+			visited = append(visited, string(key))
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"key2"}, visited)
+
+	// check bad namespace returns error
+	err = sl.IterateFrom(bytes.Repeat([]byte{0}, 257), nil, make(chan struct{}),
+		func(key, value []byte) {})
+	assert.NotNil(t, err)
+}
+
+func TestKvdbStorerLoader_Delete(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	sl := NewKVDBStorerLoader(kvdb, NewMaxLengthChecker(4), NewMaxLengthChecker(1024))
+
+	assert.Nil(t, sl.Store([]byte("ns1"), []byte("key1"), []byte("value1")))
+	assert.Nil(t, sl.Delete([]byte("ns1"), []byte("key1")))
+	value, err := sl.Load([]byte("ns1"), []byte("key1"))
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	// check bad namespace and key return errors
+	assert.NotNil(t, sl.Delete(bytes.Repeat([]byte{0}, 257), []byte("key1")))
+	assert.NotNil(t, sl.Delete([]byte("ns1"), []byte("too long key")))
+}
@@ -0,0 +1,85 @@
+package subscribe
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Batcher packs the publications sent on a subscription into batches, each sent in a single
+// response. It is not safe for concurrent use.
+type Batcher interface {
+	// Add adds a publication to the current batch and returns whether the batch is full, i.e.,
+	// it has the maximum number of publications or at least the maximum number of bytes.
+	Add(pub *KeyedPub) bool
+
+	// Flush returns the publications in the current batch and starts a new, empty one.
+	Flush() []*KeyedPub
+
+	// Len returns the number of publications in the current batch.
+	Len() int
+
+	// Due returns a channel that receives when the maximum delay since the first publication
+	// in the current batch has elapsed. It never receives if the batch is empty.
+	Due() <-chan time.Time
+}
+
+type batcher struct {
+	maxSize  uint32
+	maxBytes uint32
+	maxDelay time.Duration
+	pubs     []*KeyedPub
+	nBytes   uint32
+	timer    *time.Timer
+}
+
+// NewBatcher creates a new Batcher with batches of at most maxSize publications, which are full
+// once they have at least maxBytes bytes, unless it is zero. Batches are due maxDelay after their
+// first publication, unless it is zero.
+func NewBatcher(maxSize, maxBytes uint32, maxDelay time.Duration) Batcher {
+	return &batcher{
+		maxSize:  maxSize,
+		maxBytes: maxBytes,
+		maxDelay: maxDelay,
+		pubs:     make([]*KeyedPub, 0, maxSize),
+	}
+}
+
+// LimitedBatch returns the batching limit for a subscription given the librarian's maximum and
+// the limit requested by the subscriber, where zero indicates no limit.
+func LimitedBatch(max, requested uint32) uint32 {
+	if requested > 0 && (max == 0 || requested < max) {
+		return requested
+	}
+	return max
+}
+
+func (b *batcher) Add(pub *KeyedPub) bool {
+	if len(b.pubs) == 0 && b.maxDelay > 0 {
+		b.timer = time.NewTimer(b.maxDelay)
+	}
+	b.pubs = append(b.pubs, pub)
+	b.nBytes += uint32(len(pub.Key.Bytes()) + proto.Size(pub.Value))
+	return uint32(len(b.pubs)) >= b.maxSize || (b.maxBytes > 0 && b.nBytes >= b.maxBytes)
+}
+
+func (b *batcher) Flush() []*KeyedPub {
+	pubs := b.pubs
+	b.pubs, b.nBytes = make([]*KeyedPub, 0, b.maxSize), 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return pubs
+}
+
+func (b *batcher) Len() int {
+	return len(b.pubs)
+}
+
+func (b *batcher) Due() <-chan time.Time {
+	if b.timer == nil {
+		return nil
+	}
+	return b.timer.C
+}
@@ -0,0 +1,70 @@
+package subscribe
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher_size(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	b := NewBatcher(3, 0, 0)
+	assert.Nil(t, b.Due())
+
+	pubs := []*KeyedPub{
+		newKeyedPub(t, api.NewTestPublication(rng)),
+		newKeyedPub(t, api.NewTestPublication(rng)),
+		newKeyedPub(t, api.NewTestPublication(rng)),
+	}
+	assert.False(t, b.Add(pubs[0]))
+	assert.False(t, b.Add(pubs[1]))
+	assert.True(t, b.Add(pubs[2]))
+	assert.Equal(t, 3, b.Len())
+	assert.Nil(t, b.Due()) // no max delay
+
+	assert.Equal(t, pubs, b.Flush())
+	assert.Equal(t, 0, b.Len())
+	assert.Empty(t, b.Flush())
+}
+
+func TestBatcher_bytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pub := newKeyedPub(t, api.NewTestPublication(rng))
+	pubBytes := uint32(len(pub.Key.Bytes()) + proto.Size(pub.Value))
+	b := NewBatcher(8, pubBytes+1, 0)
+
+	// full once it has at least the max bytes
+	assert.False(t, b.Add(pub))
+	assert.True(t, b.Add(newKeyedPub(t, api.NewTestPublication(rng))))
+
+	// byte count resets on flush
+	b.Flush()
+	assert.False(t, b.Add(pub))
+}
+
+func TestBatcher_Due(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	b := NewBatcher(8, 0, 10*time.Millisecond)
+	assert.Nil(t, b.Due()) // empty batch is never due
+
+	b.Add(newKeyedPub(t, api.NewTestPublication(rng)))
+	select {
+	case <-b.Due():
+	case <-time.After(time.Second):
+		assert.Fail(t, "batch not due after max delay")
+	}
+	assert.Len(t, b.Flush(), 1)
+	assert.Nil(t, b.Due())
+}
+
+func TestLimitedBatch(t *testing.T) {
+	assert.Equal(t, uint32(0), LimitedBatch(0, 0))
+	assert.Equal(t, uint32(8), LimitedBatch(8, 0))
+	assert.Equal(t, uint32(4), LimitedBatch(0, 4))
+	assert.Equal(t, uint32(4), LimitedBatch(8, 4))
+	assert.Equal(t, uint32(8), LimitedBatch(8, 16))
+}
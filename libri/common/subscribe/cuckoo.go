@@ -0,0 +1,222 @@
+package subscribe
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math/rand"
+)
+
+const (
+	// cuckooBucketSize is the number of fingerprints in each cuckoo filter bucket.
+	cuckooBucketSize = 4
+
+	// cuckooMaxLoad is the fraction of a cuckoo filter's slots filled at its capacity, since
+	// adding elements tends to fail above about 95% of them.
+	cuckooMaxLoad = 0.9
+
+	// cuckooMaxKicks is the maximum number of fingerprints relocated when adding an element to a
+	// cuckoo filter before it is considered full.
+	cuckooMaxKicks = 500
+
+	// cuckooHeaderLength is the length of the encoded cuckoo filter fields before its buckets.
+	cuckooHeaderLength = 8 + 8 + 1 + 8 + 1
+)
+
+var (
+	// ErrCuckooFilterFull indicates when an element can't be added to a cuckoo filter because
+	// it is full.
+	ErrCuckooFilterFull = errors.New("cuckoo filter full")
+
+	// ErrInvalidCuckooFilter indicates when an encoded cuckoo filter can't be decoded.
+	ErrInvalidCuckooFilter = errors.New("invalid encoded cuckoo filter")
+)
+
+// CuckooFilter is a cuckoo filter of 8-bit fingerprints, which (unlike a Bloom filter) allows
+// previously added elements to be removed. Its false positive rate is about 3% when nearly full.
+// It is not safe for concurrent use.
+type CuckooFilter struct {
+	buckets [][cuckooBucketSize]byte
+	count   uint64
+	victim  victim
+	rng     *rand.Rand
+	mask    uint64 // number of buckets minus one, since it is a power of two
+}
+
+// victim is a fingerprint that couldn't be relocated when the filter became full.
+type victim struct {
+	used   bool
+	index  uint64
+	fprint byte
+}
+
+// NewCuckooFilter creates a new, empty *CuckooFilter with room for at least the given number of
+// elements.
+func NewCuckooFilter(capacity uint) *CuckooFilter {
+	nBuckets := uint64(1)
+	for float64(nBuckets*cuckooBucketSize)*cuckooMaxLoad < float64(capacity) {
+		nBuckets <<= 1
+	}
+	return newCuckooFilter(nBuckets)
+}
+
+func newCuckooFilter(nBuckets uint64) *CuckooFilter {
+	return &CuckooFilter{
+		buckets: make([][cuckooBucketSize]byte, nBuckets),
+		rng:     rand.New(rand.NewSource(0)),
+		mask:    nBuckets - 1,
+	}
+}
+
+// Add adds the element to the filter, or returns ErrCuckooFilterFull if it is full, in which
+// case the filter still contains it.
+func (f *CuckooFilter) Add(e []byte) error {
+	if f.victim.used {
+		return ErrCuckooFilterFull
+	}
+	i1, fp := f.indexAndFingerprint(e)
+	i2 := f.altIndex(i1, fp)
+	if f.insert(i1, fp) || f.insert(i2, fp) {
+		f.count++
+		return nil
+	}
+
+	// relocate existing fingerprints to make room
+	i := i1
+	if f.rng.Intn(2) == 1 {
+		i = i2
+	}
+	for c := 0; c < cuckooMaxKicks; c++ {
+		j := f.rng.Intn(cuckooBucketSize)
+		fp, f.buckets[i][j] = f.buckets[i][j], fp
+		i = f.altIndex(i, fp)
+		if f.insert(i, fp) {
+			f.count++
+			return nil
+		}
+	}
+	f.victim = victim{used: true, index: i, fprint: fp}
+	f.count++
+	return ErrCuckooFilterFull
+}
+
+// Test returns whether the element may be in the filter.
+func (f *CuckooFilter) Test(e []byte) bool {
+	i1, fp := f.indexAndFingerprint(e)
+	i2 := f.altIndex(i1, fp)
+	if f.victim.used && f.victim.fprint == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		return true
+	}
+	return f.bucketIndex(i1, fp) >= 0 || f.bucketIndex(i2, fp) >= 0
+}
+
+// Remove removes an element previously added to the filter and returns whether it was found.
+// Removing an element that wasn't added may remove another with the same fingerprint.
+func (f *CuckooFilter) Remove(e []byte) bool {
+	i1, fp := f.indexAndFingerprint(e)
+	i2 := f.altIndex(i1, fp)
+	if f.victim.used && f.victim.fprint == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		f.victim = victim{}
+		f.count--
+		return true
+	}
+	for _, i := range []uint64{i1, i2} {
+		if j := f.bucketIndex(i, fp); j >= 0 {
+			f.buckets[i][j] = 0
+			f.count--
+			f.reinsertVictim()
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of elements in the filter.
+func (f *CuckooFilter) Count() uint64 {
+	return f.count
+}
+
+// GobEncode encodes the filter into bytes. The name follows that of bloom.BloomFilter's, so both
+// satisfy the gob.GobEncoder interface used by ToAPI.
+func (f *CuckooFilter) GobEncode() ([]byte, error) {
+	encoded := make([]byte, cuckooHeaderLength+len(f.buckets)*cuckooBucketSize)
+	binary.BigEndian.PutUint64(encoded[0:8], uint64(len(f.buckets)))
+	binary.BigEndian.PutUint64(encoded[8:16], f.count)
+	if f.victim.used {
+		encoded[16] = 1
+	}
+	binary.BigEndian.PutUint64(encoded[17:25], f.victim.index)
+	encoded[25] = f.victim.fprint
+	for i, bucket := range f.buckets {
+		copy(encoded[cuckooHeaderLength+i*cuckooBucketSize:], bucket[:])
+	}
+	return encoded, nil
+}
+
+// GobDecode decodes the filter from bytes encoded by GobEncode.
+func (f *CuckooFilter) GobDecode(encoded []byte) error {
+	if len(encoded) < cuckooHeaderLength {
+		return ErrInvalidCuckooFilter
+	}
+	nBuckets := binary.BigEndian.Uint64(encoded[0:8])
+	if nBuckets == 0 || nBuckets&(nBuckets-1) != 0 || nBuckets > uint64(len(encoded)) ||
+		uint64(len(encoded)-cuckooHeaderLength) != nBuckets*cuckooBucketSize {
+		return ErrInvalidCuckooFilter
+	}
+	decoded := newCuckooFilter(nBuckets)
+	decoded.count = binary.BigEndian.Uint64(encoded[8:16])
+	decoded.victim = victim{
+		used:   encoded[16] == 1,
+		index:  binary.BigEndian.Uint64(encoded[17:25]) & decoded.mask,
+		fprint: encoded[25],
+	}
+	for i := range decoded.buckets {
+		copy(decoded.buckets[i][:], encoded[cuckooHeaderLength+i*cuckooBucketSize:])
+	}
+	*f = *decoded
+	return nil
+}
+
+func (f *CuckooFilter) indexAndFingerprint(e []byte) (uint64, byte) {
+	h := fnv.New64a()
+	_, _ = h.Write(e) // never returns an error
+	hash := h.Sum64()
+	fp := byte(hash >> 56)
+	if fp == 0 {
+		fp = 1 // zero marks empty slots
+	}
+	return hash & f.mask, fp
+}
+
+func (f *CuckooFilter) altIndex(i uint64, fp byte) uint64 {
+	return (i ^ (uint64(fp) * 0x5bd1e995)) & f.mask
+}
+
+func (f *CuckooFilter) insert(i uint64, fp byte) bool {
+	if j := f.bucketIndex(i, 0); j >= 0 {
+		f.buckets[i][j] = fp
+		return true
+	}
+	return false
+}
+
+// bucketIndex returns the index of the fingerprint in bucket i, or -1 if it isn't there.
+func (f *CuckooFilter) bucketIndex(i uint64, fp byte) int {
+	for j, stored := range f.buckets[i] {
+		if stored == fp {
+			return j
+		}
+	}
+	return -1
+}
+
+// reinsertVictim tries to move the victim into the room freed by a removal.
+func (f *CuckooFilter) reinsertVictim() {
+	if !f.victim.used {
+		return
+	}
+	fp, i1 := f.victim.fprint, f.victim.index
+	if f.insert(i1, fp) || f.insert(f.altIndex(i1, fp), fp) {
+		f.victim = victim{}
+	}
+}
@@ -0,0 +1,107 @@
+package subscribe
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCuckooFilter_AddTestRemove(t *testing.T) {
+	rng := rand.New(rand.NewSource(1)) // different from estimateFPRate's source
+	n := 256
+	f := NewCuckooFilter(uint(n))
+	elements := make([][]byte, n)
+	for i := range elements {
+		elements[i] = api.RandBytes(rng, api.ECPubKeyLength)
+		assert.Nil(t, f.Add(elements[i]))
+	}
+	assert.Equal(t, uint64(n), f.Count())
+	for _, e := range elements {
+		assert.True(t, f.Test(e))
+	}
+
+	// false positive rate of 8-bit fingerprints in 4-slot buckets is at most ~3%
+	assert.True(t, estimateFPRate(f) < 0.05)
+
+	// removed elements are no longer in the filter, unless as false positives
+	nRemovedIn := 0
+	for _, e := range elements[:n/2] {
+		assert.True(t, f.Remove(e))
+		if f.Test(e) {
+			nRemovedIn++
+		}
+	}
+	assert.True(t, nRemovedIn < n/20)
+	assert.Equal(t, uint64(n/2), f.Count())
+	for _, e := range elements[n/2:] {
+		assert.True(t, f.Test(e))
+	}
+	assert.False(t, NewCuckooFilter(8).Remove(elements[0]))
+}
+
+func TestCuckooFilter_Add_full(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	f := NewCuckooFilter(8)
+	elements := make([][]byte, 0)
+	var err error
+	for err == nil {
+		e := api.RandBytes(rng, api.ECPubKeyLength)
+		err = f.Add(e)
+		elements = append(elements, e)
+	}
+	assert.Equal(t, ErrCuckooFilterFull, err)
+	assert.True(t, f.victim.used)
+	assert.Equal(t, ErrCuckooFilterFull, f.Add(api.RandBytes(rng, api.ECPubKeyLength)))
+
+	// all elements, including the last, are still in the filter
+	assert.Equal(t, uint64(len(elements)), f.Count())
+	for _, e := range elements {
+		assert.True(t, f.Test(e))
+	}
+
+	// removing elements eventually makes room for the victim in one of its buckets
+	for len(elements) > 0 && f.victim.used {
+		assert.True(t, f.Remove(elements[0]))
+		elements = elements[1:]
+	}
+	assert.False(t, f.victim.used)
+	for _, e := range elements {
+		assert.True(t, f.Test(e))
+	}
+}
+
+func TestCuckooFilter_GobEncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	f1 := NewCuckooFilter(64)
+	for c := 0; c < 32; c++ {
+		assert.Nil(t, f1.Add(api.RandBytes(rng, api.ECPubKeyLength)))
+	}
+	encoded, err := f1.GobEncode()
+	assert.Nil(t, err)
+	f2 := &CuckooFilter{}
+	err = f2.GobDecode(encoded)
+	assert.Nil(t, err)
+	assert.Equal(t, f1.buckets, f2.buckets)
+	assert.Equal(t, f1.count, f2.count)
+	assert.Equal(t, f1.victim, f2.victim)
+	assert.Equal(t, f1.mask, f2.mask)
+}
+
+func TestCuckooFilter_GobDecode_err(t *testing.T) {
+	encoded, err := NewCuckooFilter(64).GobEncode()
+	assert.Nil(t, err)
+	notPowerOf2 := append([]byte{0, 0, 0, 0, 0, 0, 0, 3}, encoded[8:]...)
+	tooManyBuckets := append([]byte{1 << 6, 0, 0, 0, 0, 0, 0, 0}, encoded[8:cuckooHeaderLength]...)
+	cases := [][]byte{
+		nil,
+		encoded[:cuckooHeaderLength-1], // too short for header
+		encoded[:len(encoded)-1],       // too short for buckets
+		notPowerOf2,
+		tooManyBuckets,
+	}
+	for _, c := range cases {
+		assert.Equal(t, ErrInvalidCuckooFilter, (&CuckooFilter{}).GobDecode(c))
+	}
+}
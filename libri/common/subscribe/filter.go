@@ -2,28 +2,52 @@ package subscribe
 
 import (
 	"encoding/gob"
+	"errors"
 	"math/rand"
+	"sync"
 
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/willf/bloom"
 )
 
-var minFilterElements = 10
+var (
+	minFilterElements = 10
 
-// ToAPI converts a *bloom.BloomFilter (via narrower gob.GobEncoder) to an *api.BloomFilter.
+	// number of random elements tested when estimating a filter's false positive rate
+	nFPRateSamples = 1000
+)
+
+// ErrUnexpectedFilterType indicates when an *api.BloomFilter doesn't have the expected filter
+// type.
+var ErrUnexpectedFilterType = errors.New("unexpected filter type")
+
+// filter tests whether elements are in a set of keys, with some false positive rate.
+type filter interface {
+	Test(e []byte) bool
+}
+
+// ToAPI converts a *bloom.BloomFilter or *CuckooFilter (via narrower gob.GobEncoder) to an
+// *api.BloomFilter.
 func ToAPI(f gob.GobEncoder) (*api.BloomFilter, error) {
 	encoded, err := f.GobEncode()
 	if err != nil {
 		// should never happen
 		return nil, err
 	}
-	return &api.BloomFilter{
+	af := &api.BloomFilter{
 		Encoded: encoded,
-	}, nil
+	}
+	if _, ok := f.(*CuckooFilter); ok {
+		af.Type = api.FilterType_CUCKOO
+	}
+	return af, nil
 }
 
 // FromAPI converts an *api.BloomFilter to a *bloom.BloomFilter.
 func FromAPI(f *api.BloomFilter) (*bloom.BloomFilter, error) {
+	if f.Type != api.FilterType_BLOOM {
+		return nil, ErrUnexpectedFilterType
+	}
 	decoded := bloom.New(1, 1)
 	err := decoded.GobDecode(f.Encoded)
 	if err != nil {
@@ -32,6 +56,130 @@ func FromAPI(f *api.BloomFilter) (*bloom.BloomFilter, error) {
 	return decoded, nil
 }
 
+// CuckooFromAPI converts an *api.BloomFilter with the CUCKOO type to a *CuckooFilter.
+func CuckooFromAPI(f *api.BloomFilter) (*CuckooFilter, error) {
+	if f.Type != api.FilterType_CUCKOO {
+		return nil, ErrUnexpectedFilterType
+	}
+	decoded := &CuckooFilter{}
+	if err := decoded.GobDecode(f.Encoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// Filters are the author and reader public key filters, (optional) entry attributes filter, and
+// (optional) sample rate of a subscription, which may be updated while the subscription is active.
+type Filters interface {
+	// Match returns whether the publication's author and reader public keys are both in the
+	// filters, its entry attributes match the entry attributes filter, and its envelope key is in
+	// the sample. Author and reader filters omitted from a sampled subscription match everything.
+	Match(pub *api.Publication) bool
+
+	// Update replaces the filters with those of the given subscription.
+	Update(sub *api.Subscription) error
+
+	// FPRates returns the estimated false positive rates of the author and reader filters.
+	FPRates() (author float32, reader float32)
+
+	// SampleRate returns the fraction of publications sampled, or zero if they aren't sampled.
+	SampleRate() float32
+}
+
+type filters struct {
+	author       filter
+	reader       filter
+	attributes   *api.EntryAttributesFilter
+	sampleRate   float32
+	authorFPRate float32
+	readerFPRate float32
+	mu           sync.RWMutex
+}
+
+// NewFilters creates new Filters from those of the given subscription.
+func NewFilters(sub *api.Subscription) (Filters, error) {
+	f := &filters{}
+	if err := f.Update(sub); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *filters) Match(pub *api.Publication) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return testFilter(f.author, pub.AuthorPublicKey) &&
+		testFilter(f.reader, pub.ReaderPublicKey) &&
+		api.MatchEntryAttributes(f.attributes, pub.EntryAttributes) &&
+		(f.sampleRate == 0.0 || api.InSample(pub.EnvelopeKey, f.sampleRate))
+}
+
+func (f *filters) Update(sub *api.Subscription) error {
+	if err := api.ValidateSubscription(sub); err != nil {
+		return err
+	}
+	author, err := fromOptionalAPI(sub.AuthorPublicKeys)
+	if err != nil {
+		return err
+	}
+	reader, err := fromOptionalAPI(sub.ReaderPublicKeys)
+	if err != nil {
+		return err
+	}
+	authorFPRate, readerFPRate := estimateFPRate(author), estimateFPRate(reader)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.author, f.reader, f.attributes = author, reader, sub.EntryAttributes
+	f.sampleRate = sub.SampleRate
+	f.authorFPRate, f.readerFPRate = authorFPRate, readerFPRate
+	return nil
+}
+
+func (f *filters) FPRates() (float32, float32) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.authorFPRate, f.readerFPRate
+}
+
+func (f *filters) SampleRate() float32 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.sampleRate
+}
+
+// fromOptionalAPI converts an *api.BloomFilter to a filter of its type, returning nil if it is
+// empty, which ValidateSubscription only allows for sampled subscriptions.
+func fromOptionalAPI(f *api.BloomFilter) (filter, error) {
+	if f == nil || f.Encoded == nil {
+		return nil, nil
+	}
+	if f.Type == api.FilterType_CUCKOO {
+		return CuckooFromAPI(f)
+	}
+	return FromAPI(f)
+}
+
+// testFilter tests whether the element is in the filter, which always contains it when nil.
+func testFilter(f filter, e []byte) bool {
+	return f == nil || f.Test(e)
+}
+
+// estimateFPRate estimates the false positive rate of a filter from the fraction of random
+// elements in it.
+func estimateFPRate(f filter) float32 {
+	if f == nil {
+		return 1.0
+	}
+	rng := rand.New(rand.NewSource(0))
+	nIn := 0
+	for c := 0; c < nFPRateSamples; c++ {
+		if f.Test(api.RandBytes(rng, api.ECPubKeyLength)) {
+			nIn++
+		}
+	}
+	return float32(nIn) / float32(nFPRateSamples)
+}
+
 func newFilter(elements [][]byte, fp float64, rng *rand.Rand) *bloom.BloomFilter {
 	if fp == 1.0 {
 		return alwaysInFilter()
@@ -19,10 +19,189 @@ func TestToFromAPI(t *testing.T) {
 	assert.Equal(t, f1, f2)
 }
 
+func TestToFromAPI_cuckoo(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	f1 := NewCuckooFilter(16)
+	assert.Nil(t, f1.Add(api.RandBytes(rng, api.ECPubKeyLength)))
+	a, err := ToAPI(f1)
+	assert.Nil(t, err)
+	assert.Equal(t, api.FilterType_CUCKOO, a.Type)
+	f2, err := CuckooFromAPI(a)
+	assert.Nil(t, err)
+	assert.Equal(t, f1.buckets, f2.buckets)
+
+	// filter types must match
+	_, err = FromAPI(a)
+	assert.Equal(t, ErrUnexpectedFilterType, err)
+	a, err = ToAPI(newFilter([][]byte{}, 0.75, rng))
+	assert.Nil(t, err)
+	_, err = CuckooFromAPI(a)
+	assert.Equal(t, ErrUnexpectedFilterType, err)
+}
+
 func TestFromAPI_err(t *testing.T) {
 	f, err := FromAPI(&api.BloomFilter{Encoded: []byte{}})
 	assert.NotNil(t, err)
 	assert.Nil(t, f)
+
+	c, err := CuckooFromAPI(&api.BloomFilter{Encoded: []byte{}, Type: api.FilterType_CUCKOO})
+	assert.NotNil(t, err)
+	assert.Nil(t, c)
+}
+
+func TestFilters_MatchUpdate(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pub1, pub2 := api.NewTestPublication(rng), api.NewTestPublication(rng)
+	sub1, err := NewSubscription([][]byte{pub1.AuthorPublicKey}, 1e-6,
+		[][]byte{pub1.ReaderPublicKey}, 1e-6, rng)
+	assert.Nil(t, err)
+	f, err := NewFilters(sub1)
+	assert.Nil(t, err)
+	assert.True(t, f.Match(pub1))
+	assert.False(t, f.Match(pub2))
+
+	sub2, err := NewSubscription([][]byte{pub2.AuthorPublicKey}, 1e-6,
+		[][]byte{pub2.ReaderPublicKey}, 1e-6, rng)
+	assert.Nil(t, err)
+	err = f.Update(sub2)
+	assert.Nil(t, err)
+	assert.False(t, f.Match(pub1))
+	assert.True(t, f.Match(pub2))
+
+	// invalid update keeps existing filters
+	sub3, err := NewFPSubscription(1.0, rng)
+	assert.Nil(t, err)
+	sub3.ReaderPublicKeys.Encoded = nil
+	err = f.Update(sub3)
+	assert.NotNil(t, err)
+	assert.True(t, f.Match(pub2))
+}
+
+func TestFilters_MatchUpdate_cuckoo(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pub1, pub2 := api.NewTestPublication(rng), api.NewTestPublication(rng)
+	author := NewCuckooFilter(16)
+	assert.Nil(t, author.Add(pub1.AuthorPublicKey))
+	assert.Nil(t, author.Add(pub2.AuthorPublicKey))
+	sub1, err := NewCuckooSubscription(author, nil)
+	assert.Nil(t, err)
+	f, err := NewFilters(sub1)
+	assert.Nil(t, err)
+	assert.True(t, f.Match(pub1))
+	assert.True(t, f.Match(pub2))
+
+	// removing an author key from a long-lived subscription's filter
+	assert.True(t, author.Remove(pub1.AuthorPublicKey))
+	sub2, err := NewCuckooSubscription(author, nil)
+	assert.Nil(t, err)
+	err = f.Update(sub2)
+	assert.Nil(t, err)
+	assert.False(t, f.Match(pub1))
+	assert.True(t, f.Match(pub2))
+}
+
+func TestFilters_Match_entryAttributes(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	image, text := api.NewTestPublication(rng), api.NewTestPublication(rng)
+	image.EntryAttributes = &api.EntryAttributes{MediaType: "image/png", UncompressedSize: 100}
+	text.EntryAttributes = &api.EntryAttributes{MediaType: "text/plain", UncompressedSize: 10}
+	sub, err := NewFPSubscription(1.0, rng)
+	assert.Nil(t, err)
+	f, err := NewFilters(sub)
+	assert.Nil(t, err)
+	assert.True(t, f.Match(image))
+	assert.True(t, f.Match(text))
+
+	sub.EntryAttributes = &api.EntryAttributesFilter{MediaTypes: []string{"image/*"}}
+	err = f.Update(sub)
+	assert.Nil(t, err)
+	assert.True(t, f.Match(image))
+	assert.False(t, f.Match(text))
+
+	sub.EntryAttributes = &api.EntryAttributesFilter{MaxUncompressedSize: 50}
+	err = f.Update(sub)
+	assert.Nil(t, err)
+	assert.False(t, f.Match(image))
+	assert.True(t, f.Match(text))
+
+	// invalid update keeps existing filters
+	sub.EntryAttributes = &api.EntryAttributesFilter{
+		MinUncompressedSize: 2,
+		MaxUncompressedSize: 1,
+	}
+	err = f.Update(sub)
+	assert.Equal(t, api.ErrInvalidSizeRange, err)
+	assert.True(t, f.Match(text))
+}
+
+func TestFilters_Match_sampled(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sub, err := NewSampledSubscription(0.1)
+	assert.Nil(t, err)
+	f, err := NewFilters(sub)
+	assert.Nil(t, err)
+	nPubs, nMatched := 1000, 0
+	for c := 0; c < nPubs; c++ {
+		pub := api.NewTestPublication(rng)
+		matched := f.Match(pub)
+		assert.Equal(t, api.InSample(pub.EnvelopeKey, 0.1), matched)
+		if matched {
+			nMatched++
+		}
+	}
+	assert.InDelta(t, 0.1, float32(nMatched)/float32(nPubs), 0.03)
+
+	// sampling also applies alongside author and reader filters
+	pub := api.NewTestPublication(rng)
+	for api.InSample(pub.EnvelopeKey, 0.1) {
+		pub = api.NewTestPublication(rng)
+	}
+	sub, err = NewSubscription([][]byte{pub.AuthorPublicKey}, 1e-6,
+		[][]byte{pub.ReaderPublicKey}, 1e-6, rng)
+	assert.Nil(t, err)
+	err = f.Update(sub)
+	assert.Nil(t, err)
+	assert.True(t, f.Match(pub))
+	sub.SampleRate = 0.1
+	err = f.Update(sub)
+	assert.Nil(t, err)
+	assert.False(t, f.Match(pub))
+}
+
+func TestFilters_FPRates(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sub, err := NewSubscription([][]byte{}, 0.5, [][]byte{}, 1.0, rng)
+	assert.Nil(t, err)
+	f, err := NewFilters(sub)
+	assert.Nil(t, err)
+	author, reader := f.FPRates()
+	assert.InDelta(t, 0.5, author, 0.1)
+	assert.Equal(t, float32(1.0), reader)
+	assert.Zero(t, f.SampleRate())
+
+	// omitted filters of sampled subscriptions match everything
+	sub, err = NewSampledSubscription(0.01)
+	assert.Nil(t, err)
+	f, err = NewFilters(sub)
+	assert.Nil(t, err)
+	author, reader = f.FPRates()
+	assert.Equal(t, float32(1.0), author)
+	assert.Equal(t, float32(1.0), reader)
+	assert.Equal(t, float32(0.01), f.SampleRate())
+}
+
+func TestNewFilters_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sub, err := NewFPSubscription(1.0, rng)
+	assert.Nil(t, err)
+	sub.AuthorPublicKeys.Encoded = nil
+	f, err := NewFilters(sub)
+	assert.NotNil(t, err)
+	assert.Nil(t, f)
+
+	f, err = NewFilters(nil)
+	assert.Equal(t, api.ErrUnexpectedNilValue, err)
+	assert.Nil(t, f)
 }
 
 /*
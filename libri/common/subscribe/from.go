@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math/rand"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -17,9 +18,63 @@ const (
 	// subscription.
 	DefaultEndSubscriptionProb = 1.0 / (1 << 20)
 
-	fanSlack = 8
+	// DefaultQueueSize is the default maximum number of publications queued for each
+	// subscription.
+	DefaultQueueSize = 64
+
+	// DefaultDropPolicy is the default policy for handling publications when a subscription's
+	// queue is full.
+	DefaultDropPolicy = DropOldest
+
+	// DefaultMaxPubsPerSecond is the default maximum rate at which publications are sent on each
+	// subscription.
+	DefaultMaxPubsPerSecond = 100
+
+	// DefaultKeepaliveInterval is the default interval between keepalives sent on each
+	// subscription, which should be well below the subscribers' idle timeouts.
+	DefaultKeepaliveInterval = 1 * time.Minute
+
+	// DefaultMaxBatchSize is the default maximum number of publications sent in each batch on
+	// batched subscriptions.
+	DefaultMaxBatchSize = 256
+
+	// DefaultMaxBatchBytes is the default size in bytes at which a batch is sent on batched
+	// subscriptions, which keeps responses well below gRPC's default maximum message size.
+	DefaultMaxBatchBytes = 1 << 20
+
+	// DefaultMaxBatchDelay is the default maximum time publications wait in a batch before it
+	// is sent on batched subscriptions.
+	DefaultMaxBatchDelay = 1 * time.Second
 )
 
+// DropPolicy defines how publications are handled when a subscription's queue is full, usually
+// because the subscriber is slow to consume or acknowledge them.
+type DropPolicy int
+
+const (
+	// DropNewest drops the new publication, keeping those already queued.
+	DropNewest DropPolicy = iota
+
+	// DropOldest drops the oldest queued publication to make room for the new one.
+	DropOldest
+
+	// DropSubscription ends the subscription.
+	DropSubscription
+)
+
+func (p DropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop_newest"
+	case DropOldest:
+		return "drop_oldest"
+	case DropSubscription:
+		return "drop_subscription"
+	default:
+		return "unknown"
+	}
+}
+
 // ErrNotAcceptingNewSubscriptions indicates when new subscriptions are not being accepted.
 var ErrNotAcceptingNewSubscriptions = errors.New("not accepting new subscriptions")
 
@@ -30,6 +85,36 @@ type FromParameters struct {
 
 	// EndSubscriptionprob is the Bernoulli probability of ending a particular subscription.
 	EndSubscriptionProb float64
+
+	// QueueSize is the maximum number of publications queued for each subscription.
+	QueueSize uint32
+
+	// DropPolicy defines how publications are handled when a subscription's queue is full.
+	DropPolicy DropPolicy
+
+	// MaxPubsPerSecond is the maximum rate at which publications are sent on each subscription;
+	// zero disables rate limiting. Subscribers may request a lower rate.
+	MaxPubsPerSecond float32
+
+	// KeepaliveInterval is the interval between keepalives sent on each subscription, which let
+	// subscribers distinguish quiet subscriptions from stalled ones; zero disables keepalives.
+	KeepaliveInterval time.Duration
+
+	// MaxBatchSize is the maximum number of publications sent in each batch on subscriptions
+	// whose subscribers request batching; zero or one disables batching.
+	MaxBatchSize uint32
+
+	// MaxBatchBytes is the size in bytes at which a batch is sent on batched subscriptions,
+	// even if it doesn't have the maximum number of publications.
+	MaxBatchBytes uint32
+
+	// MaxBatchDelay is the maximum time publications wait in a batch before it is sent on
+	// batched subscriptions. Subscribers may request a shorter delay.
+	MaxBatchDelay time.Duration
+
+	// AllowedPublicKeys are the public keys of the only clients allowed to subscribe, which in
+	// a private network should include those of its other librarians; empty allows all clients.
+	AllowedPublicKeys [][]byte
 }
 
 // NewDefaultFromParameters returns a *FromParameters object with default values.
@@ -37,6 +122,13 @@ func NewDefaultFromParameters() *FromParameters {
 	return &FromParameters{
 		NMaxSubscriptions:   DefaultNMaxSubscriptions,
 		EndSubscriptionProb: DefaultEndSubscriptionProb,
+		QueueSize:           DefaultQueueSize,
+		DropPolicy:          DefaultDropPolicy,
+		MaxPubsPerSecond:    DefaultMaxPubsPerSecond,
+		KeepaliveInterval:   DefaultKeepaliveInterval,
+		MaxBatchSize:        DefaultMaxBatchSize,
+		MaxBatchBytes:       DefaultMaxBatchBytes,
+		MaxBatchDelay:       DefaultMaxBatchDelay,
 	}
 }
 
@@ -46,8 +138,15 @@ type From interface {
 	// of each subscriber.
 	Fanout()
 
-	// New creates a new subscriber channel, adds it to the fan-out, and returns it. If
-	New() (chan *KeyedPub, chan struct{}, error)
+	// New creates a new subscriber channel, adds it to the fan-out, and returns it. The
+	// given stats are updated with any publications dropped from the channel. If the
+	// subscriber's channel is full when a publication is fanned out, the DropPolicy is applied
+	// instead of waiting for the subscriber.
+	New(stats *Stats) (chan *KeyedPub, chan struct{}, error)
+
+	// Stats returns the stats of the active subscriptions along with the totals over all
+	// current and past subscriptions.
+	Stats() ([]*Stats, Totals)
 }
 
 type from struct {
@@ -56,6 +155,8 @@ type from struct {
 	out          chan *KeyedPub
 	fanout       map[uint64]chan *KeyedPub
 	done         map[uint64]chan struct{}
+	stats        map[uint64]*Stats
+	ended        Totals
 	nextFanIndex uint64
 	ender        ender
 	mu           sync.Mutex
@@ -69,6 +170,7 @@ func NewFrom(params *FromParameters, logger *zap.Logger, out chan *KeyedPub) Fro
 		out:    out,
 		fanout: make(map[uint64]chan *KeyedPub),
 		done:   make(map[uint64]chan struct{}),
+		stats:  make(map[uint64]*Stats),
 		ender: &bernoulliEnder{
 			p:   params.EndSubscriptionProb,
 			rng: rand.New(rand.NewSource(0)),
@@ -84,10 +186,11 @@ func (f *from) Fanout() {
 				continue
 			}
 			select {
-			// TODO (drausin) add timeout here for channel accepting value (?)
 			case <-f.done[i]:
 				f.endSubscription(i)
 			case f.fanout[i] <- pub:
+			default:
+				f.overflow(i, pub)
 			}
 		}
 	}
@@ -97,20 +200,44 @@ func (f *from) Fanout() {
 	}
 }
 
-func (f *from) New() (chan *KeyedPub, chan struct{}, error) {
+func (f *from) New(stats *Stats) (chan *KeyedPub, chan struct{}, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	if uint32(len(f.fanout)) == f.params.NMaxSubscriptions {
 		return nil, nil, ErrNotAcceptingNewSubscriptions
 	}
-	out := make(chan *KeyedPub, fanSlack)
+	out := make(chan *KeyedPub, f.params.QueueSize)
 	done := make(chan struct{})
 	f.fanout[f.nextFanIndex] = out
 	f.done[f.nextFanIndex] = done
+	f.stats[f.nextFanIndex] = stats
 	f.nextFanIndex++
 	return out, done, nil
 }
 
+// overflow applies the drop policy for the full queue of subscription i.
+func (f *from) overflow(i uint64, pub *KeyedPub) {
+	f.logger.Debug("subscription queue full",
+		zap.Uint64("subscription", i),
+		zap.Stringer("drop_policy", f.params.DropPolicy),
+		zap.String("publication_key", pub.Key.String()),
+	)
+	f.stats[i].AddDropped()
+	switch f.params.DropPolicy {
+	case DropOldest:
+		select {
+		case <-f.fanout[i]:
+		default: // subscriber has since consumed from the queue
+		}
+		select {
+		case f.fanout[i] <- pub:
+		default: // should never happen since only Fanout() adds to the queue
+		}
+	case DropSubscription:
+		f.endSubscription(i)
+	}
+}
+
 func (f *from) endSubscription(i uint64) {
 	f.mu.Lock()
 	select {
@@ -120,11 +247,25 @@ func (f *from) endSubscription(i uint64) {
 		close(f.done[i])
 	}
 	close(f.fanout[i])
+	f.ended.add(f.stats[i])
 	delete(f.fanout, i)
 	delete(f.done, i)
+	delete(f.stats, i)
 	f.mu.Unlock()
 }
 
+func (f *from) Stats() ([]*Stats, Totals) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := make([]*Stats, 0, len(f.stats))
+	totals := f.ended
+	for _, s := range f.stats {
+		stats = append(stats, s)
+		totals.add(s)
+	}
+	return stats, totals
+}
+
 // ender decides whether to end a subscription to a client.
 type ender interface {
 	// end decides whether to end subscription i from a client.
@@ -25,7 +25,7 @@ func TestFrom_Fanout(t *testing.T) {
 	fanout := make(map[uint64]chan *KeyedPub)
 	done := make(map[uint64]chan struct{})
 	for i := uint64(0); int(i) < nFans; i++ {
-		f, d, err := f.New()
+		f, d, err := f.New(NewStats(nil, nil))
 		assert.Nil(t, err)
 		fanout[i], done[i] = f, d
 	}
@@ -66,7 +66,7 @@ func TestFrom_Fanout_close(t *testing.T) {
 	fanout := make(map[uint64]chan *KeyedPub)
 	done := make(map[uint64]chan struct{})
 	for i := uint64(0); int(i) < nFans; i++ {
-		f, d, err := f.New()
+		f, d, err := f.New(NewStats(nil, nil))
 		assert.Nil(t, err)
 		fanout[i], done[i] = f, d
 	}
@@ -129,7 +129,7 @@ func TestFrom_Fanout_end(t *testing.T) {
 	f := NewFrom(params, lg, out).(*from)
 
 	go f.Fanout()
-	fanout, done, err := f.New()
+	fanout, done, err := f.New(NewStats(nil, nil))
 	assert.Nil(t, err)
 
 	outPub := newKeyedPub(t, api.NewTestPublication(rng))
@@ -146,6 +146,71 @@ func TestFrom_Fanout_end(t *testing.T) {
 	}
 }
 
+func TestFrom_Fanout_overflow(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pub1 := newKeyedPub(t, api.NewTestPublication(rng))
+	pub2 := newKeyedPub(t, api.NewTestPublication(rng))
+	cases := map[DropPolicy][]*KeyedPub{
+		DropNewest:       {pub1},
+		DropOldest:       {pub2},
+		DropSubscription: {pub1},
+	}
+	for policy, expected := range cases {
+		params := NewDefaultFromParameters()
+		params.EndSubscriptionProb = 0.0 // never end
+		params.QueueSize = 1
+		params.DropPolicy = policy
+		out := make(chan *KeyedPub, 2)
+		lg := clogging.NewDevInfoLogger()
+		f := NewFrom(params, lg, out).(*from)
+		fan, _, err := f.New(NewStats(nil, nil))
+		assert.Nil(t, err)
+
+		// fan out both pubs without consuming any, so the second overflows the queue
+		out <- pub1
+		out <- pub2
+		close(out)
+		f.Fanout()
+
+		received := make([]*KeyedPub, 0)
+		for pub := range fan {
+			received = append(received, pub)
+		}
+		assert.Equal(t, expected, received, policy.String())
+	}
+}
+
+func TestFrom_Stats(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultFromParameters()
+	params.EndSubscriptionProb = 0.0 // never end
+	params.QueueSize = 1
+	params.DropPolicy = DropNewest
+	out := make(chan *KeyedPub, 2)
+	lg := clogging.NewDevInfoLogger()
+	f := NewFrom(params, lg, out).(*from)
+	stats1, stats2 := NewStats(nil, nil), NewStats(nil, nil)
+	_, _, err := f.New(stats1)
+	assert.Nil(t, err)
+	_, _, err = f.New(stats2)
+	assert.Nil(t, err)
+	stats1.AddPub()
+	stats, totals := f.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, Totals{NPubs: 1}, totals)
+
+	// second pub overflows both queues
+	out <- newKeyedPub(t, api.NewTestPublication(rng))
+	out <- newKeyedPub(t, api.NewTestPublication(rng))
+	close(out)
+	f.Fanout()
+
+	// ended subscriptions still contribute to totals
+	stats, totals = f.Stats()
+	assert.Len(t, stats, 0)
+	assert.Equal(t, Totals{NPubs: 1, NDropped: 2}, totals)
+}
+
 func TestFrom_New_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	params := NewDefaultFromParameters()
@@ -156,9 +221,9 @@ func TestFrom_New_ok(t *testing.T) {
 
 	go f.Fanout()
 
-	fan1, _, err := f.New()
+	fan1, _, err := f.New(NewStats(nil, nil))
 	assert.Nil(t, err)
-	fan2, _, err := f.New()
+	fan2, _, err := f.New(NewStats(nil, nil))
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(f.fanout))
 
@@ -178,7 +243,7 @@ func TestFrom_New_err(t *testing.T) {
 	out := make(chan *KeyedPub)
 	lg := clogging.NewDevInfoLogger()
 	f := NewFrom(params, lg, out).(*from)
-	fan, done, err := f.New()
+	fan, done, err := f.New(NewStats(nil, nil))
 	assert.Equal(t, ErrNotAcceptingNewSubscriptions, err)
 	assert.Nil(t, fan)
 	assert.Nil(t, done)
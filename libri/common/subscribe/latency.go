@@ -0,0 +1,101 @@
+package subscribe
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+// LatencyBuckets are the upper bounds of every latency histogram bucket but the last, which
+// contains the latencies above the largest bound.
+var LatencyBuckets = [...]time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	1 * time.Minute,
+}
+
+// Latencies is a histogram of publication latencies, i.e., the times between when publications
+// were first received by a librarian and when they were received by this one.
+type Latencies struct {
+	// Counts are the number of latencies in each of the LatencyBuckets along with the last,
+	// unbounded bucket.
+	Counts [len(LatencyBuckets) + 1]uint64
+
+	// Sum is the sum of all the latencies.
+	Sum time.Duration
+}
+
+// N returns the total number of latencies in the histogram.
+func (l Latencies) N() uint64 {
+	n := uint64(0)
+	for _, c := range l.Counts {
+		n += c
+	}
+	return n
+}
+
+// Mean returns the mean latency, or zero if the histogram is empty.
+func (l Latencies) Mean() time.Duration {
+	n := l.N()
+	if n == 0 {
+		return 0
+	}
+	return l.Sum / time.Duration(n)
+}
+
+// ToAPI converts the histogram to an *api.LatencyHistogram.
+func (l Latencies) ToAPI() *api.LatencyHistogram {
+	bounds := make([]int64, len(LatencyBuckets))
+	for i, b := range LatencyBuckets {
+		bounds[i] = int64(b)
+	}
+	counts := make([]uint64, len(l.Counts))
+	copy(counts, l.Counts[:])
+	return &api.LatencyHistogram{
+		BucketBounds: bounds,
+		Counts:       counts,
+		Sum:          int64(l.Sum),
+	}
+}
+
+func (l *Latencies) add(other Latencies) {
+	for i, c := range other.Counts {
+		l.Counts[i] += c
+	}
+	l.Sum += other.Sum
+}
+
+// latencyRecorder records latencies into a histogram safely for concurrent use.
+type latencyRecorder struct {
+	counts [len(LatencyBuckets) + 1]uint64
+	sum    int64
+}
+
+// record adds a latency to the histogram. Negative latencies, which can result from clock skew
+// between librarians, are recorded as zero.
+func (r *latencyRecorder) record(latency time.Duration) {
+	if latency < 0 {
+		latency = 0
+	}
+	i := 0
+	for i < len(LatencyBuckets) && latency > LatencyBuckets[i] {
+		i++
+	}
+	atomic.AddUint64(&r.counts[i], 1)
+	atomic.AddInt64(&r.sum, int64(latency))
+}
+
+// latencies returns a snapshot of the histogram.
+func (r *latencyRecorder) latencies() Latencies {
+	l := Latencies{Sum: time.Duration(atomic.LoadInt64(&r.sum))}
+	for i := range r.counts {
+		l.Counts[i] = atomic.LoadUint64(&r.counts[i])
+	}
+	return l
+}
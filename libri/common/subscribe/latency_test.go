@@ -0,0 +1,48 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyRecorder_record(t *testing.T) {
+	r := &latencyRecorder{}
+	r.record(-1 * time.Second) // clock skew
+	r.record(10 * time.Millisecond)
+	r.record(20 * time.Millisecond)
+	r.record(2 * time.Minute)
+
+	l := r.latencies()
+	assert.Equal(t, uint64(2), l.Counts[0])
+	assert.Equal(t, uint64(1), l.Counts[1])
+	assert.Equal(t, uint64(1), l.Counts[len(LatencyBuckets)])
+	assert.Equal(t, uint64(4), l.N())
+	assert.Equal(t, 2*time.Minute+30*time.Millisecond, l.Sum)
+	assert.Equal(t, (2*time.Minute+30*time.Millisecond)/4, l.Mean())
+}
+
+func TestLatencies_add(t *testing.T) {
+	l1, l2 := Latencies{Sum: time.Second}, Latencies{Sum: 2 * time.Second}
+	l1.Counts[0], l2.Counts[0], l2.Counts[1] = 1, 2, 3
+	l1.add(l2)
+	assert.Equal(t, uint64(3), l1.Counts[0])
+	assert.Equal(t, uint64(3), l1.Counts[1])
+	assert.Equal(t, 3*time.Second, l1.Sum)
+}
+
+func TestLatencies_Mean_empty(t *testing.T) {
+	assert.Zero(t, Latencies{}.Mean())
+}
+
+func TestLatencies_ToAPI(t *testing.T) {
+	l := Latencies{Sum: time.Second}
+	l.Counts[len(LatencyBuckets)] = 2
+	al := l.ToAPI()
+	assert.Len(t, al.BucketBounds, len(LatencyBuckets))
+	assert.Equal(t, int64(LatencyBuckets[0]), al.BucketBounds[0])
+	assert.Len(t, al.Counts, len(LatencyBuckets)+1)
+	assert.Equal(t, uint64(2), al.Counts[len(LatencyBuckets)])
+	assert.Equal(t, int64(time.Second), al.Sum)
+}
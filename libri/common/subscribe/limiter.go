@@ -0,0 +1,64 @@
+package subscribe
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RateLimiter limits the rate at which publications are sent on a subscription.
+type RateLimiter interface {
+	// Wait blocks until another publication may be sent, or returns an error if the context is
+	// done first.
+	Wait(ctx context.Context) error
+}
+
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+	mu       sync.Mutex
+}
+
+// NewRateLimiter creates a new RateLimiter allowing at most maxPerSecond publications per
+// second. A zero maxPerSecond never limits sending.
+func NewRateLimiter(maxPerSecond float32) RateLimiter {
+	if maxPerSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / float64(maxPerSecond)),
+	}
+}
+
+// LimitedRate returns the publication rate limit for a subscription given the librarian's
+// maximum rate and the rate requested by the subscriber, where zero indicates no limit.
+func LimitedRate(maxPerSecond, requestedPerSecond float32) float32 {
+	if requestedPerSecond > 0 && (maxPerSecond <= 0 || requestedPerSecond < maxPerSecond) {
+		return requestedPerSecond
+	}
+	return maxPerSecond
+}
+
+func (rl *rateLimiter) Wait(ctx context.Context) error {
+	if rl.interval == 0 {
+		return nil
+	}
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	wait := rl.next.Sub(now)
+	rl.next = rl.next.Add(rl.interval)
+	rl.mu.Unlock()
+	if wait == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRateLimiter_Wait_unlimited(t *testing.T) {
+	rl := NewRateLimiter(0)
+	start := time.Now()
+	for c := 0; c < 100; c++ {
+		assert.Nil(t, rl.Wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+}
+
+func TestRateLimiter_Wait_limited(t *testing.T) {
+	rl := NewRateLimiter(100) // one every 10ms
+	start := time.Now()
+	nWaits := 6
+	for c := 0; c < nWaits; c++ {
+		assert.Nil(t, rl.Wait(context.Background()))
+	}
+
+	// first wait doesn't block
+	assert.True(t, time.Since(start) >= time.Duration(nWaits-1)*10*time.Millisecond)
+}
+
+func TestRateLimiter_Wait_ctxDone(t *testing.T) {
+	rl := NewRateLimiter(0.01) // one every 100s
+	assert.Nil(t, rl.Wait(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, rl.Wait(ctx))
+}
+
+func TestLimitedRate(t *testing.T) {
+	assert.Equal(t, float32(10), LimitedRate(10, 0))
+	assert.Equal(t, float32(5), LimitedRate(10, 5))
+	assert.Equal(t, float32(10), LimitedRate(10, 20))
+	assert.Equal(t, float32(20), LimitedRate(0, 20))
+	assert.Equal(t, float32(0), LimitedRate(0, 0))
+}
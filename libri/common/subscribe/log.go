@@ -0,0 +1,217 @@
+package subscribe
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+)
+
+// ErrUnexpectedSequence indicates when a logged publication's sequence number doesn't match its
+// storage key or when the stored last sequence number is malformed.
+var ErrUnexpectedSequence = errors.New("logged publication has unexpected sequence number")
+
+// PublicationLog durably records received publications in the order they are received, so they
+// can later be replayed from a given sequence number or scanned for analytics and audits. A log
+// with a max length only keeps that many of the most recent publications.
+type PublicationLog interface {
+	// Append records the publication, received from the peer with the given public key, under
+	// the next sequence number, which it sets on the publication and returns.
+	Append(pub *KeyedPub, fromPub []byte) (uint64, error)
+
+	// Replay calls the callback, in sequence order, for each logged publication with a sequence
+	// number of at least from. It stops early and returns the first error the callback returns.
+	Replay(from uint64, callback func(pub *KeyedPub) error) error
+
+	// Scan is like Replay but also gives the callback when and from whom each publication was
+	// received.
+	Scan(from uint64, callback func(logged *LoggedPub) error) error
+
+	// LastSequence returns the sequence number of the last logged publication, or zero if none
+	// have been logged.
+	LastSequence() uint64
+}
+
+// LoggedPub is a publication recorded in the publication log.
+type LoggedPub struct {
+	*KeyedPub
+
+	// Time is when the publication was logged.
+	Time time.Time
+
+	// FromPub is the public key of the peer the publication was received from, which is empty
+	// for publications logged before sources were recorded.
+	FromPub []byte
+}
+
+// lastSequenceKey is the storage key of the last logged sequence number, which no publication is
+// logged under since sequence numbers start at one.
+var lastSequenceKey = sequenceKey(0)
+
+type publicationLog struct {
+	psl       storage.NamespaceLogStorer
+	maxLength uint64
+	last      uint64
+	mu        sync.Mutex
+}
+
+// NewPublicationLog creates a new PublicationLog backed by the given storage, continuing from the
+// last publication already logged there. It keeps only the last maxLength publications, deleting
+// older ones as new ones are appended, or all of them if maxLength is zero.
+func NewPublicationLog(psl storage.NamespaceLogStorer, maxLength uint64) (PublicationLog, error) {
+	pl := &publicationLog{psl: psl, maxLength: maxLength}
+	stored, err := psl.Load(lastSequenceKey)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		if len(stored) != storage.PublicationsKeyLength {
+			return nil, ErrUnexpectedSequence
+		}
+		pl.last = binary.BigEndian.Uint64(stored)
+	}
+
+	// publications may have been logged after the last sequence was stored, e.g., just before a
+	// crash, so continue from the last one actually logged
+	err = psl.IterateFrom(sequenceKey(pl.last+1), make(chan struct{}), func(key, value []byte) {
+		pl.last = binary.BigEndian.Uint64(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := pl.trim(); err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+func (pl *publicationLog) Append(pub *KeyedPub, fromPub []byte) (uint64, error) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	seq := pl.last + 1
+	value, err := proto.Marshal(pub.Value)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := proto.Marshal(&storage.LoggedPublication{
+		Sequence:      seq,
+		Key:           pub.Key.Bytes(),
+		Value:         value,
+		Timestamp:     time.Now().UnixNano(),
+		FromPublicKey: fromPub,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := pl.psl.Store(sequenceKey(seq), stored); err != nil {
+		return 0, err
+	}
+	if err := pl.psl.Store(lastSequenceKey, sequenceKey(seq)); err != nil {
+		return 0, err
+	}
+	pl.last = seq
+	pub.Sequence = seq
+	if pl.maxLength != 0 && seq > pl.maxLength {
+		if err := pl.psl.Delete(sequenceKey(seq - pl.maxLength)); err != nil {
+			return 0, err
+		}
+	}
+	return seq, nil
+}
+
+func (pl *publicationLog) Replay(from uint64, callback func(pub *KeyedPub) error) error {
+	return pl.Scan(from, func(logged *LoggedPub) error {
+		return callback(logged.KeyedPub)
+	})
+}
+
+func (pl *publicationLog) Scan(from uint64, callback func(logged *LoggedPub) error) error {
+	if from == 0 {
+		// no publication is logged under sequence number zero
+		from = 1
+	}
+	done := make(chan struct{})
+	var cbErr error
+	err := pl.psl.IterateFrom(sequenceKey(from), done, func(key, value []byte) {
+		logged, err := fromLogged(key, value)
+		if err == nil {
+			err = callback(logged)
+		}
+		if err != nil {
+			cbErr = err
+			close(done)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return cbErr
+}
+
+func (pl *publicationLog) LastSequence() uint64 {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	return pl.last
+}
+
+// trim deletes the publications before the last maxLength ones, which can be left from before the
+// log had a max length or a smaller one.
+func (pl *publicationLog) trim() error {
+	if pl.maxLength == 0 || pl.last <= pl.maxLength {
+		return nil
+	}
+	firstKept := pl.last - pl.maxLength + 1
+	done, expired := make(chan struct{}), make([][]byte, 0)
+	err := pl.psl.IterateFrom(sequenceKey(1), done, func(key, value []byte) {
+		if binary.BigEndian.Uint64(key) >= firstKept {
+			close(done)
+			return
+		}
+		expired = append(expired, key)
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range expired {
+		if err := pl.psl.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sequenceKey gives the storage key for the given sequence number, which sorts in sequence order.
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, storage.PublicationsKeyLength)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// fromLogged creates a new *LoggedPub from a stored storage.LoggedPublication.
+func fromLogged(key, stored []byte) (*LoggedPub, error) {
+	logged := &storage.LoggedPublication{}
+	if err := proto.Unmarshal(stored, logged); err != nil {
+		return nil, err
+	}
+	if logged.Sequence != binary.BigEndian.Uint64(key) {
+		return nil, ErrUnexpectedSequence
+	}
+	value := &api.Publication{}
+	if err := proto.Unmarshal(logged.Value, value); err != nil {
+		return nil, err
+	}
+	return &LoggedPub{
+		KeyedPub: &KeyedPub{
+			Key:      id.FromBytes(logged.Key),
+			Value:    value,
+			Sequence: logged.Sequence,
+		},
+		Time:    time.Unix(0, logged.Timestamp),
+		FromPub: logged.FromPublicKey,
+	}, nil
+}
@@ -0,0 +1,206 @@
+package subscribe
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicationLog_AppendReplay(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	psl := storage.NewPublicationKVDBStorerLoader(kvdb)
+
+	pl1, err := NewPublicationLog(psl, 0)
+	assert.Nil(t, err)
+	assert.Zero(t, pl1.LastSequence())
+
+	// enough pubs to check replay is in numeric (not lexical) sequence order
+	nPubs := 260
+	pubs := make([]*KeyedPub, nPubs)
+	for i := range pubs {
+		pubs[i] = newTestKeyedPub(rng)
+		seq, err := pl1.Append(pubs[i], nil)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(i+1), seq)
+		assert.Equal(t, seq, pubs[i].Sequence)
+	}
+	assert.Equal(t, uint64(nPubs), pl1.LastSequence())
+
+	// new log on same storage continues from last sequence
+	pl2, err := NewPublicationLog(psl, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(nPubs), pl2.LastSequence())
+
+	from := uint64(200)
+	replayed := make([]*KeyedPub, 0)
+	err = pl2.Replay(from, func(pub *KeyedPub) error {
+		replayed = append(replayed, pub)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, replayed, nPubs-int(from)+1)
+	for i, pub := range replayed {
+		assert.Equal(t, pubs[int(from)-1+i], pub)
+	}
+
+	// replay from beyond the last sequence visits nothing
+	err = pl2.Replay(pl2.LastSequence()+1, func(pub *KeyedPub) error {
+		assert.Fail(t, "unexpected replayed publication")
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestNewPublicationLog_lastSequence(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	psl := storage.NewPublicationKVDBStorerLoader(kvdb)
+
+	// check stored last sequence is used without visiting earlier publications
+	assert.Nil(t, psl.Store(lastSequenceKey, sequenceKey(10)))
+	pl, err := NewPublicationLog(psl, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), pl.LastSequence())
+
+	// check publications logged after the stored last sequence are continued from
+	seq, err := pl.Append(newTestKeyedPub(rng), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(11), seq)
+	assert.Nil(t, psl.Store(lastSequenceKey, sequenceKey(9)))
+	pl, err = NewPublicationLog(psl, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(11), pl.LastSequence())
+
+	// check malformed stored last sequence errors
+	assert.Nil(t, psl.Store(lastSequenceKey, []byte{1, 2, 3}))
+	pl, err = NewPublicationLog(psl, 0)
+	assert.Equal(t, ErrUnexpectedSequence, err)
+	assert.Nil(t, pl)
+}
+
+func TestPublicationLog_maxLength(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	psl := storage.NewPublicationKVDBStorerLoader(kvdb)
+
+	maxLength, nPubs := uint64(4), 10
+	pl, err := NewPublicationLog(psl, maxLength)
+	assert.Nil(t, err)
+	pubs := make([]*KeyedPub, nPubs)
+	for i := range pubs {
+		pubs[i] = newTestKeyedPub(rng)
+		_, err = pl.Append(pubs[i], nil)
+		assert.Nil(t, err)
+	}
+
+	// check only last maxLength publications are kept
+	assert.Equal(t, pubs[nPubs-int(maxLength):], replayAll(t, pl))
+	assert.Equal(t, uint64(nPubs), pl.LastSequence())
+
+	// check new log with smaller max length trims the older publications
+	pl, err = NewPublicationLog(psl, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, pubs[nPubs-2:], replayAll(t, pl))
+	assert.Equal(t, uint64(nPubs), pl.LastSequence())
+}
+
+func TestPublicationLog_Scan(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	pl, err := NewPublicationLog(storage.NewPublicationKVDBStorerLoader(kvdb), 0)
+	assert.Nil(t, err)
+
+	start := time.Now()
+	nPubs := 4
+	pubs, fromPubs := make([]*KeyedPub, nPubs), make([][]byte, nPubs)
+	for i := range pubs {
+		pubs[i] = newTestKeyedPub(rng)
+		fromPubs[i] = api.RandBytes(rng, api.ECPubKeyLength)
+		_, err = pl.Append(pubs[i], fromPubs[i])
+		assert.Nil(t, err)
+	}
+
+	scanned := make([]*LoggedPub, 0)
+	err = pl.Scan(2, func(logged *LoggedPub) error {
+		scanned = append(scanned, logged)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, scanned, nPubs-1)
+	for i, logged := range scanned {
+		assert.Equal(t, pubs[i+1], logged.KeyedPub)
+		assert.Equal(t, fromPubs[i+1], logged.FromPub)
+		assert.False(t, logged.Time.Before(start))
+		assert.False(t, logged.Time.After(time.Now()))
+	}
+}
+
+func TestPublicationLog_Replay_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	psl := storage.NewPublicationKVDBStorerLoader(kvdb)
+	pl, err := NewPublicationLog(psl, 0)
+	assert.Nil(t, err)
+	for c := 0; c < 4; c++ {
+		_, err = pl.Append(newTestKeyedPub(rng), nil)
+		assert.Nil(t, err)
+	}
+
+	// callback error stops replay
+	cbErr, nCalls := errors.New("some callback error"), 0
+	err = pl.Replay(1, func(pub *KeyedPub) error {
+		nCalls++
+		return cbErr
+	})
+	assert.Equal(t, cbErr, err)
+	assert.Equal(t, 1, nCalls)
+
+	// stored sequence not matching its key errors
+	stored, err := psl.Load(sequenceKey(2))
+	assert.Nil(t, err)
+	err = psl.Store(sequenceKey(1), stored)
+	assert.Nil(t, err)
+	err = pl.Replay(1, func(pub *KeyedPub) error { return nil })
+	assert.Equal(t, ErrUnexpectedSequence, err)
+}
+
+func replayAll(t *testing.T, pl PublicationLog) []*KeyedPub {
+	replayed := make([]*KeyedPub, 0)
+	err := pl.Replay(0, func(pub *KeyedPub) error {
+		replayed = append(replayed, pub)
+		return nil
+	})
+	assert.Nil(t, err)
+	return replayed
+}
+
+func newTestKeyedPub(rng *rand.Rand) *KeyedPub {
+	value := api.NewTestPublication(rng)
+	key, err := api.GetKey(value)
+	if err != nil {
+		panic(err)
+	}
+	return &KeyedPub{Key: key, Value: value}
+}
@@ -3,6 +3,7 @@ package subscribe
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/drausin/libri/libri/common/id"
@@ -17,6 +18,21 @@ type KeyedPub struct {
 
 	// Value is the publication values.
 	Value *api.Publication
+
+	// Sequence is the position of the publication in the publication log, or zero if it hasn't
+	// been logged.
+	Sequence uint64
+
+	// OriginTime is when the publication was first received by a librarian, or zero if unknown,
+	// as for publications replayed from the publication log.
+	OriginTime time.Time
+}
+
+// PublicationVerifier verifies publications received from peers against the envelopes they were
+// made from.
+type PublicationVerifier interface {
+	// Verify returns nil if the publication matches the envelope stored under its envelope key.
+	Verify(pub *api.Publication) error
 }
 
 // RecentPublications tracks publications recently received from peers with an internal LRU cache.
@@ -32,23 +48,51 @@ type RecentPublications interface {
 
 	// Len gives the number of items in the cache.
 	Len() int
+
+	// Stats returns the cache's size and hit, miss, and eviction counters.
+	Stats() *RecentPublicationsStats
+
+	// Resize changes the maximum number of items in the cache, evicting the oldest items if
+	// it currently contains more than that.
+	Resize(size uint32) error
+}
+
+// RecentPublicationsStats contains the size and counters of a RecentPublications cache.
+type RecentPublicationsStats struct {
+	// Size is the maximum number of items in the cache.
+	Size uint32
+
+	// Len is the current number of items in the cache.
+	Len uint32
+
+	// NHits is the number of lookups for publications in the cache.
+	NHits uint64
+
+	// NMisses is the number of lookups for publications not in the cache.
+	NMisses uint64
+
+	// NEvictions is the number of publications evicted from the cache to make room for others.
+	NEvictions uint64
 }
 
 type recentPublications struct {
-	recent *lru.Cache
+	recent     *lru.Cache
+	size       uint32
+	nHits      uint64
+	nMisses    uint64
+	nEvictions uint64
+	mu         sync.RWMutex
 }
 
 // NewRecentPublications creates a RecentPublications LRU cache with a given size.
 func NewRecentPublications(size uint32) (RecentPublications, error) {
-	// TODO (drausin) store publicationReceipts on eviction
-	onEvicted := func(key interface{}, value interface{}) {}
-	recent, err := lru.NewWithEvict(int(size), onEvicted)
+	rp := &recentPublications{}
+	recent, err := rp.newCache(size)
 	if err != nil {
 		return nil, err
 	}
-	return &recentPublications{
-		recent: recent,
-	}, nil
+	rp.recent, rp.size = recent, size
+	return rp, nil
 }
 
 func (rp *recentPublications) Add(pvr *pubValueReceipt) bool {
@@ -57,12 +101,21 @@ func (rp *recentPublications) Add(pvr *pubValueReceipt) bool {
 		pubReceipts = newPublicationReceipts(pvr.pub.Value)
 	}
 	pubReceipts.add(pvr.receipt)
+	rp.mu.RLock()
 	rp.recent.Add(pvr.pub.Key.String(), pubReceipts)
+	rp.mu.RUnlock()
 	return in
 }
 
 func (rp *recentPublications) Get(publicationKey id.ID) (*PublicationReceipts, bool) {
+	rp.mu.RLock()
 	pubReceipts, in := rp.recent.Get(publicationKey.String())
+	rp.mu.RUnlock()
+	if in {
+		atomic.AddUint64(&rp.nHits, 1)
+	} else {
+		atomic.AddUint64(&rp.nMisses, 1)
+	}
 	if pubReceipts == nil {
 		return nil, in
 	}
@@ -70,9 +123,49 @@ func (rp *recentPublications) Get(publicationKey id.ID) (*PublicationReceipts, b
 }
 
 func (rp *recentPublications) Len() int {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
 	return rp.recent.Len()
 }
 
+func (rp *recentPublications) Stats() *RecentPublicationsStats {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+	return &RecentPublicationsStats{
+		Size:       rp.size,
+		Len:        uint32(rp.recent.Len()),
+		NHits:      atomic.LoadUint64(&rp.nHits),
+		NMisses:    atomic.LoadUint64(&rp.nMisses),
+		NEvictions: atomic.LoadUint64(&rp.nEvictions),
+	}
+}
+
+func (rp *recentPublications) Resize(size uint32) error {
+	recent, err := rp.newCache(size)
+	if err != nil {
+		return err
+	}
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	// add from oldest to newest, so the newest remain if the new cache is smaller
+	for _, key := range rp.recent.Keys() {
+		if value, in := rp.recent.Peek(key); in {
+			recent.Add(key, value)
+		}
+	}
+	rp.recent, rp.size = recent, size
+	return nil
+}
+
+func (rp *recentPublications) newCache(size uint32) (*lru.Cache, error) {
+	// TODO (drausin) store publicationReceipts on eviction
+	onEvicted := func(key interface{}, value interface{}) {
+		atomic.AddUint64(&rp.nEvictions, 1)
+	}
+	return lru.NewWithEvict(int(size), onEvicted)
+}
+
 // PublicationReceipts is a list of *PubReceipts for a given publication.
 type PublicationReceipts struct {
 	Value    *api.Publication
@@ -114,6 +207,9 @@ func newPublicationValueReceipt(key []byte, value *api.Publication, fromPub []by
 	if !bytes.Equal(valueKey.Bytes(), key) {
 		return nil, api.ErrUnexpectedKey
 	}
+	if err := api.ValidatePublication(value); err != nil {
+		return nil, err
+	}
 	if err := api.ValidatePublicKey(fromPub); err != nil {
 		return nil, err
 	}
@@ -127,6 +127,77 @@ func TestRecentPublications_Len(t *testing.T) {
 	assert.Equal(t, 2, rp.Len())
 }
 
+func TestRecentPublications_Stats(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rp, err := NewRecentPublications(uint32(2))
+	assert.Nil(t, err)
+	pvrs := make([]*pubValueReceipt, 3)
+	for i := range pvrs {
+		pvrs[i] = newTestPubValueReceipt(t, rng)
+	}
+
+	rp.Add(pvrs[0])         // miss
+	rp.Add(pvrs[0])         // hit
+	rp.Add(pvrs[1])         // miss
+	rp.Add(pvrs[2])         // miss, evicting pvrs[0]
+	rp.Get(pvrs[0].pub.Key) // miss
+	assert.Equal(t, &RecentPublicationsStats{
+		Size:       2,
+		Len:        2,
+		NHits:      1,
+		NMisses:    4,
+		NEvictions: 1,
+	}, rp.Stats())
+}
+
+func TestRecentPublications_Resize(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rp, err := NewRecentPublications(uint32(4))
+	assert.Nil(t, err)
+	pvrs := make([]*pubValueReceipt, 4)
+	for i := range pvrs {
+		pvrs[i] = newTestPubValueReceipt(t, rng)
+		rp.Add(pvrs[i])
+	}
+
+	// shrinking evicts the oldest
+	err = rp.Resize(2)
+	assert.Nil(t, err)
+	stats := rp.Stats()
+	assert.Equal(t, uint32(2), stats.Size)
+	assert.Equal(t, uint32(2), stats.Len)
+	assert.Equal(t, uint64(2), stats.NEvictions)
+	for i, pvr := range pvrs {
+		_, in := rp.Get(pvr.pub.Key)
+		assert.Equal(t, i >= 2, in)
+	}
+
+	// growing keeps everything
+	err = rp.Resize(8)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rp.Len())
+	for _, pvr := range pvrs {
+		rp.Add(pvr)
+	}
+	assert.Equal(t, 4, rp.Len())
+
+	// invalid size leaves cache unchanged
+	err = rp.Resize(0)
+	assert.NotNil(t, err)
+	assert.Equal(t, uint32(8), rp.Stats().Size)
+	assert.Equal(t, 4, rp.Len())
+}
+
+func newTestPubValueReceipt(t *testing.T, rng *rand.Rand) *pubValueReceipt {
+	value := api.NewTestPublication(rng)
+	key, err := api.GetKey(value)
+	assert.Nil(t, err)
+	pvr, err := newPublicationValueReceipt(key.Bytes(), value,
+		api.RandBytes(rng, api.ECPubKeyLength))
+	assert.Nil(t, err)
+	return pvr
+}
+
 func TestNewPublicationValueReceipt_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	value := api.NewTestPublication(rng)
@@ -163,4 +234,12 @@ func TestNewPublicationValueReceipt_err(t *testing.T) {
 	pvr, err = newPublicationValueReceipt(key.Bytes(), value, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, pvr)
+
+	// check invalid publication throws error
+	value.EnvelopeKey = nil
+	key, err = api.GetKey(value)
+	assert.Nil(t, err)
+	pvr, err = newPublicationValueReceipt(key.Bytes(), value, fromPub)
+	assert.NotNil(t, err)
+	assert.Nil(t, pvr)
 }
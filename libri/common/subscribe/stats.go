@@ -0,0 +1,108 @@
+package subscribe
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+// Stats contains statistics about a single subscription to or from another peer. Its counts are
+// safe for concurrent updates.
+type Stats struct {
+	// PeerID is the ID of the other peer in the subscription.
+	PeerID id.ID
+
+	// Filters are the subscription's filters.
+	Filters Filters
+
+	nPubs    uint64
+	nDropped uint64
+	latency  latencyRecorder
+}
+
+// NewStats creates a new *Stats instance for a subscription with the given peer and filters.
+func NewStats(peerID id.ID, filters Filters) *Stats {
+	return &Stats{
+		PeerID:  peerID,
+		Filters: filters,
+	}
+}
+
+// AddPub records a publication sent or received on the subscription.
+func (s *Stats) AddPub() {
+	atomic.AddUint64(&s.nPubs, 1)
+}
+
+// AddDropped records a publication dropped from the subscription's queue.
+func (s *Stats) AddDropped() {
+	atomic.AddUint64(&s.nDropped, 1)
+}
+
+// AddLatency records the latency of a publication received on the subscription.
+func (s *Stats) AddLatency(latency time.Duration) {
+	s.latency.record(latency)
+}
+
+// NPubs returns the number of publications sent or received on the subscription.
+func (s *Stats) NPubs() uint64 {
+	return atomic.LoadUint64(&s.nPubs)
+}
+
+// NDropped returns the number of publications dropped from the subscription's queue.
+func (s *Stats) NDropped() uint64 {
+	return atomic.LoadUint64(&s.nDropped)
+}
+
+// Latencies returns the histogram of latencies of publications received on the subscription.
+func (s *Stats) Latencies() Latencies {
+	return s.latency.latencies()
+}
+
+// ToAPI converts the stats to an *api.SubscriptionStats.
+func (s *Stats) ToAPI() *api.SubscriptionStats {
+	as := &api.SubscriptionStats{
+		NPubs:    s.NPubs(),
+		NDropped: s.NDropped(),
+	}
+	if s.PeerID != nil {
+		as.PeerId = s.PeerID.Bytes()
+	}
+	if s.Filters != nil {
+		as.AuthorFpRate, as.ReaderFpRate = s.Filters.FPRates()
+		as.SampleRate = s.Filters.SampleRate()
+	}
+	if latencies := s.Latencies(); latencies.N() > 0 {
+		as.Latency = latencies.ToAPI()
+	}
+	return as
+}
+
+// Totals contains publication counts summed over all current and past subscriptions.
+type Totals struct {
+	// NPubs is the total number of publications sent or received.
+	NPubs uint64
+
+	// NDropped is the total number of publications dropped.
+	NDropped uint64
+
+	// Latencies is the histogram of latencies of all publications received.
+	Latencies Latencies
+}
+
+// add adds the counts of the given subscription stats to the totals.
+func (t *Totals) add(s *Stats) {
+	t.NPubs += s.NPubs()
+	t.NDropped += s.NDropped()
+	t.Latencies.add(s.Latencies())
+}
+
+// ToAPIStats converts a list of *Stats to a list of *api.SubscriptionStats.
+func ToAPIStats(stats []*Stats) []*api.SubscriptionStats {
+	as := make([]*api.SubscriptionStats, len(stats))
+	for i, s := range stats {
+		as[i] = s.ToAPI()
+	}
+	return as
+}
@@ -0,0 +1,61 @@
+package subscribe
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := id.NewPseudoRandom(rng)
+	sub, err := NewFPSubscription(0.5, rng)
+	assert.Nil(t, err)
+	filters, err := NewFilters(sub)
+	assert.Nil(t, err)
+	s := NewStats(peerID, filters)
+
+	s.AddPub()
+	s.AddPub()
+	s.AddDropped()
+	assert.Equal(t, uint64(2), s.NPubs())
+	assert.Equal(t, uint64(1), s.NDropped())
+
+	as := s.ToAPI()
+	author, reader := filters.FPRates()
+	assert.Equal(t, peerID.Bytes(), as.PeerId)
+	assert.Equal(t, author, as.AuthorFpRate)
+	assert.Equal(t, reader, as.ReaderFpRate)
+	assert.Zero(t, as.SampleRate)
+	assert.Equal(t, uint64(2), as.NPubs)
+	assert.Equal(t, uint64(1), as.NDropped)
+	assert.Nil(t, as.Latency)
+
+	s.AddLatency(time.Second)
+	assert.Equal(t, uint64(1), s.Latencies().N())
+	as = s.ToAPI()
+	assert.Equal(t, int64(time.Second), as.Latency.Sum)
+
+	// missing peer ID and filters are left empty
+	as = NewStats(nil, nil).ToAPI()
+	assert.Nil(t, as.PeerId)
+	assert.Zero(t, as.AuthorFpRate)
+	assert.Zero(t, as.ReaderFpRate)
+
+	assert.Len(t, ToAPIStats([]*Stats{s, s}), 2)
+}
+
+func TestTotals_add(t *testing.T) {
+	s := NewStats(nil, nil)
+	s.AddPub()
+	s.AddDropped()
+	s.AddLatency(time.Second)
+	totals := Totals{NPubs: 1}
+	totals.add(s)
+	expected := Totals{NPubs: 2, NDropped: 1, Latencies: Latencies{Sum: time.Second}}
+	expected.Latencies.Counts[4] = 1
+	assert.Equal(t, expected, totals)
+}
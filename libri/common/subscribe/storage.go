@@ -0,0 +1,88 @@
+package subscribe
+
+import (
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+)
+
+var subscriptionsKey = []byte("Subscriptions")
+
+// peerSubscription is a subscription to a particular peer.
+type peerSubscription struct {
+	peerID id.ID
+	sub    *api.Subscription
+	stats  *Stats
+}
+
+// newPeerSubscription creates a new *peerSubscription with fresh stats.
+func newPeerSubscription(peerID id.ID, sub *api.Subscription) (*peerSubscription, error) {
+	filters, err := NewFilters(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &peerSubscription{
+		peerID: peerID,
+		sub:    sub,
+		stats:  NewStats(peerID, filters),
+	}, nil
+}
+
+func (t *to) Save(ns storage.NamespaceStorer) error {
+	t.mu.Lock()
+	stored := &storage.Subscriptions{
+		To: make([]*storage.Subscription, 0, len(t.active)),
+	}
+	for _, ps := range t.active {
+		stored.To = append(stored.To, toStored(ps))
+	}
+	t.mu.Unlock()
+	if len(stored.To) == 0 {
+		// nothing to save, so keep whatever was last saved
+		return nil
+	}
+
+	bytes, err := proto.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return ns.Store(subscriptionsKey, bytes)
+}
+
+func (t *to) Restore(nl storage.NamespaceLoader) error {
+	bytes, err := nl.Load(subscriptionsKey)
+	if bytes == nil || err != nil {
+		return err
+	}
+	stored := &storage.Subscriptions{}
+	if err := proto.Unmarshal(bytes, stored); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range stored.To {
+		t.restored = append(t.restored, fromStored(s))
+	}
+	return nil
+}
+
+// toStored creates a new storage.Subscription from a peerSubscription.
+func toStored(ps *peerSubscription) *storage.Subscription {
+	return &storage.Subscription{
+		PeerId:           ps.peerID.Bytes(),
+		AuthorPublicKeys: ps.sub.AuthorPublicKeys.Encoded,
+		ReaderPublicKeys: ps.sub.ReaderPublicKeys.Encoded,
+	}
+}
+
+// fromStored creates a new peerSubscription from a storage.Subscription.
+func fromStored(stored *storage.Subscription) *peerSubscription {
+	return &peerSubscription{
+		peerID: id.FromBytes(stored.PeerId),
+		sub: &api.Subscription{
+			AuthorPublicKeys: &api.BloomFilter{Encoded: stored.AuthorPublicKeys},
+			ReaderPublicKeys: &api.BloomFilter{Encoded: stored.ReaderPublicKeys},
+		},
+	}
+}
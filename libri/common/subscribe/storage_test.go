@@ -0,0 +1,67 @@
+package subscribe
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToFromStored(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sub, err := NewFPSubscription(0.75, rng)
+	assert.Nil(t, err)
+	ps1 := &peerSubscription{peerID: id.NewPseudoRandom(rng), sub: sub}
+	ps2 := fromStored(toStored(ps1))
+	assert.Equal(t, ps1.peerID, ps2.peerID)
+	assert.Equal(t, ps1.sub, ps2.sub)
+}
+
+func TestTo_SaveRestore(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	ssl := storage.NewServerKVDBStorerLoader(kvdb)
+	params, lg := NewDefaultToParameters(), clogging.NewDevInfoLogger()
+	recent, err := NewRecentPublications(2)
+	assert.Nil(t, err)
+
+	// restoring with nothing saved is a no-op
+	to1 := NewTo(params, lg, ecid.NewPseudoRandom(rng), nil, nil, nil, recent, nil, nil).(*to)
+	err = to1.Restore(ssl)
+	assert.Nil(t, err)
+	assert.Empty(t, to1.restored)
+
+	nActive := 3
+	for i := 0; i < nActive; i++ {
+		sub, err := NewFPSubscription(0.75, rng)
+		assert.Nil(t, err)
+		ps, err := newPeerSubscription(id.NewPseudoRandom(rng), sub)
+		assert.Nil(t, err)
+		to1.setActive(uint32(i), ps)
+	}
+	err = to1.Save(ssl)
+	assert.Nil(t, err)
+
+	to2 := NewTo(params, lg, ecid.NewPseudoRandom(rng), nil, nil, nil, recent, nil, nil).(*to)
+	err = to2.Restore(ssl)
+	assert.Nil(t, err)
+	assert.Len(t, to2.restored, nActive)
+	for _, ps1 := range to1.active {
+		found := false
+		for _, ps2 := range to2.restored {
+			if ps1.peerID.Cmp(ps2.peerID) == 0 {
+				assert.Equal(t, ps1.sub, ps2.sub)
+				found = true
+			}
+		}
+		assert.True(t, found)
+	}
+}
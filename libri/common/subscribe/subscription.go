@@ -67,3 +67,37 @@ func NewReaderSubscription(readerPubs [][]byte, fp float64, rng *rand.Rand) (
 func NewFPSubscription(fp float64, rng *rand.Rand) (*api.Subscription, error) {
 	return NewSubscription([][]byte{}, fp, [][]byte{}, 1.0, rng)
 }
+
+// NewSampledSubscription creates an *api.Subscription matching the given fraction of all
+// publications, sampled deterministically from their envelope keys.
+func NewSampledSubscription(rate float32) (*api.Subscription, error) {
+	if rate <= 0.0 || rate > 1.0 {
+		return nil, api.ErrOutOfBoundsSampleRate
+	}
+	return &api.Subscription{SampleRate: rate}, nil
+}
+
+// NewCuckooSubscription creates an *api.Subscription with the given author and reader cuckoo
+// filters, which (unlike Bloom filters) can have keys removed and then replace those of an active
+// subscription via a subscription update. A nil filter matches all keys.
+func NewCuckooSubscription(author *CuckooFilter, reader *CuckooFilter) (*api.Subscription, error) {
+	authorFilter, err := cuckooToAPI(author)
+	if err != nil {
+		return nil, err
+	}
+	readerFilter, err := cuckooToAPI(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Subscription{
+		AuthorPublicKeys: authorFilter,
+		ReaderPublicKeys: readerFilter,
+	}, nil
+}
+
+func cuckooToAPI(f *CuckooFilter) (*api.BloomFilter, error) {
+	if f == nil {
+		return ToAPI(alwaysInFilter())
+	}
+	return ToAPI(f)
+}
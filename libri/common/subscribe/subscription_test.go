@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,3 +70,35 @@ func TestNewFPSubscription(t *testing.T) {
 	assert.NotNil(t, s.AuthorPublicKeys)
 	assert.NotNil(t, s.ReaderPublicKeys)
 }
+
+func TestNewSampledSubscription(t *testing.T) {
+	s, err := NewSampledSubscription(0.01)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(0.01), s.SampleRate)
+	assert.Nil(t, s.AuthorPublicKeys)
+	assert.Nil(t, s.ReaderPublicKeys)
+	assert.Nil(t, api.ValidateSubscription(s))
+
+	for _, rate := range []float32{-0.5, 0.0, 1.5} {
+		s, err := NewSampledSubscription(rate)
+		assert.Equal(t, api.ErrOutOfBoundsSampleRate, err)
+		assert.Nil(t, s)
+	}
+}
+
+func TestNewCuckooSubscription(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	author := NewCuckooFilter(16)
+	assert.Nil(t, author.Add(api.RandBytes(rng, api.ECPubKeyLength)))
+	s, err := NewCuckooSubscription(author, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, api.FilterType_CUCKOO, s.AuthorPublicKeys.Type)
+	assert.Equal(t, api.FilterType_BLOOM, s.ReaderPublicKeys.Type)
+	assert.Nil(t, api.ValidateSubscription(s))
+
+	// nil filters match everything
+	f, err := NewFilters(s)
+	assert.Nil(t, err)
+	_, readerFP := f.FPRates()
+	assert.Equal(t, float32(1.0), readerFP)
+}
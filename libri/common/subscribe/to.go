@@ -5,18 +5,32 @@ import (
 	"io"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
 )
 
-// ErrTooManySubscriptionErrs indicates when too many subscription errors have occurred.
-var ErrTooManySubscriptionErrs = errors.New("too many subscription errors")
+var (
+	// ErrTooManySubscriptionErrs indicates when too many subscription errors have occurred.
+	ErrTooManySubscriptionErrs = errors.New("too many subscription errors")
+
+	// ErrUpstreamIdle indicates when a subscription was ended because it received no
+	// publications within the idle timeout.
+	ErrUpstreamIdle = errors.New("no publications received from upstream within idle timeout")
+
+	// ErrSubscribeDialTimeout indicates when a subscription couldn't be opened within the dial
+	// timeout.
+	ErrSubscribeDialTimeout = errors.New("subscription not opened within dial timeout")
+)
 
 const (
 	/*
@@ -52,6 +66,18 @@ const (
 	// always be happening.
 	DefaultTimeout = 30 * time.Minute
 
+	// DefaultIdleTimeout is the default maximum time to wait for a publication or keepalive on
+	// a subscription before replacing it with a subscription to another peer.
+	DefaultIdleTimeout = 10 * time.Minute
+
+	// DefaultFailureBackoff is the default time to wait after a subscription to a peer fails
+	// before subscribing to it again.
+	DefaultFailureBackoff = 30 * time.Second
+
+	// DefaultMaxFailureBackoff is the default maximum time to wait after repeated subscription
+	// failures before subscribing to a peer again.
+	DefaultMaxFailureBackoff = 30 * time.Minute
+
 	// DefaultMaxErrRate is the default maximum allowed error rate for Subscribe requests and
 	// received publications before a fatal error is thrown.
 	DefaultMaxErrRate = 0.1
@@ -59,10 +85,63 @@ const (
 	// DefaultRecentCacheSize is the default recent publications LRU cache size.
 	DefaultRecentCacheSize = 1 << 12
 
+	// DefaultWindow is the default maximum number of publications a peer sends on a
+	// subscription without them being acknowledged.
+	DefaultWindow = 64
+
+	// DefaultAckInterval is the default number of publications received on a subscription
+	// between each acknowledgement.
+	DefaultAckInterval = 16
+
+	// DefaultSaveInterval is the default period between saves of the active subscriptions to
+	// other peers.
+	DefaultSaveInterval = 5 * time.Minute
+
+	// DefaultLogMaxLength is the default maximum number of publications kept in the publication
+	// log.
+	DefaultLogMaxLength = 1 << 20
+
+	// DefaultNewQueueSize is the default maximum number of new publications queued for fan-out
+	// to the subscriptions from other peers.
+	DefaultNewQueueSize = 16
+
+	// DefaultNewPolicy is the default policy for handling new publications when their queue is
+	// full.
+	DefaultNewPolicy = BlockNew
+
 	// errQueueSize is the size of the error queue used to calculate the running error rate.
 	errQueueSize = 100
 )
 
+// NewPolicy defines how new publications are handled when their queue is full, usually because
+// they are fanned out to subscriptions from other peers slower than they are received.
+type NewPolicy int
+
+const (
+	// BlockNew waits for room in the queue, which in turn slows the receipt of publications
+	// from all the subscriptions to other peers.
+	BlockNew NewPolicy = iota
+
+	// DropNewestNew drops the new publication, keeping those already queued.
+	DropNewestNew
+
+	// DropOldestNew drops the oldest queued publication to make room for the new one.
+	DropOldestNew
+)
+
+func (p NewPolicy) String() string {
+	switch p {
+	case BlockNew:
+		return "block"
+	case DropNewestNew:
+		return "drop_newest"
+	case DropOldestNew:
+		return "drop_oldest"
+	default:
+		return "unknown"
+	}
+}
+
 // ToParameters define how the collection of subscriptions to other peers will be managed.
 type ToParameters struct {
 	// NSubscriptions is the number of concurrent subscriptions maintained to other peers.
@@ -74,6 +153,23 @@ type ToParameters struct {
 	// Timeout is the timeout for each Subscribe request.
 	Timeout time.Duration
 
+	// DialTimeout is the timeout for opening each subscription and sending its request; zero
+	// disables it.
+	DialTimeout time.Duration
+
+	// IdleTimeout is the maximum time to wait for a publication or keepalive on a subscription
+	// before replacing it with a subscription to another peer; zero disables replacement.
+	IdleTimeout time.Duration
+
+	// FailureBackoff is the time to wait after a subscription to a peer errors or goes idle
+	// before subscribing to it again, which doubles with each consecutive failure; zero
+	// disables backing off.
+	FailureBackoff time.Duration
+
+	// MaxFailureBackoff is the maximum time to wait after repeated subscription failures
+	// before subscribing to a peer again.
+	MaxFailureBackoff time.Duration
+
 	// MaxErrRate is the maximum allowed error rate for Subscribe requests and received
 	// publications before a fatal error is thrown. This value is a running rate over a constant
 	// history of responses (c.f., errQueueSize).
@@ -82,21 +178,65 @@ type ToParameters struct {
 	// RecentCacheSize is the size of the LRU cache used in deduplicating and grouping
 	// publications.
 	RecentCacheSize uint32
+
+	// Window is the maximum number of publications a peer sends on a subscription without them
+	// being acknowledged; zero disables flow control.
+	Window uint32
+
+	// AckInterval is the number of publications received on a subscription between each
+	// acknowledgement. It should be no larger than Window.
+	AckInterval uint32
+
+	// SaveInterval is the period between saves of the active subscriptions to other peers,
+	// which limits how many are lost if the server doesn't shut down cleanly; zero disables
+	// periodic saves.
+	SaveInterval time.Duration
+
+	// LogMaxLength is the maximum number of the most recent publications kept in the
+	// publication log; zero keeps all of them.
+	LogMaxLength uint64
+
+	// NewQueueSize is the maximum number of new publications queued for fan-out to the
+	// subscriptions from other peers.
+	NewQueueSize uint32
+
+	// NewPolicy defines how new publications are handled when their queue is full.
+	NewPolicy NewPolicy
+
+	// MaxBatchSize is the maximum number of publications a peer sends in each response on a
+	// subscription; zero or one disables batching.
+	MaxBatchSize uint32
+
+	// MaxBatchDelay is the maximum time a peer waits after the first publication in a batch
+	// before sending it; zero uses the peer's own maximum.
+	MaxBatchDelay time.Duration
 }
 
 // NewDefaultToParameters returns a *ToParameters object with default values.
 func NewDefaultToParameters() *ToParameters {
 	return &ToParameters{
-		NSubscriptions:  DefaultNSubscriptionsTo,
-		FPRate:          DefaultFPRate,
-		Timeout:         DefaultTimeout,
-		MaxErrRate:      DefaultMaxErrRate,
-		RecentCacheSize: DefaultRecentCacheSize,
+		NSubscriptions:    DefaultNSubscriptionsTo,
+		FPRate:            DefaultFPRate,
+		Timeout:           DefaultTimeout,
+		DialTimeout:       client.DefaultSubscribeDialTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		FailureBackoff:    DefaultFailureBackoff,
+		MaxFailureBackoff: DefaultMaxFailureBackoff,
+		MaxErrRate:        DefaultMaxErrRate,
+		RecentCacheSize:   DefaultRecentCacheSize,
+		Window:            DefaultWindow,
+		AckInterval:       DefaultAckInterval,
+		SaveInterval:      DefaultSaveInterval,
+		LogMaxLength:      DefaultLogMaxLength,
+		NewQueueSize:      DefaultNewQueueSize,
+		NewPolicy:         DefaultNewPolicy,
 	}
 }
 
 // To maintains active subscriptions to a collection of peers, merging their publications into a
-// single, deduplicated stream.
+// single, deduplicated stream. Subscriptions that end, error, or stall (i.e., stop delivering
+// publications and keepalives) are replaced with subscriptions to other peers, and peers whose
+// subscriptions repeatedly fail are increasingly backed off from.
 type To interface {
 	// Begin starts and runs the subscriptions to the peers. It runs indefinitely until either
 	// a fatal error is encountered, or the subscriptions are gracefully stopped via End().
@@ -107,6 +247,19 @@ type To interface {
 
 	// Send sends a publication to the channel of received publications.
 	Send(pub *api.Publication) error
+
+	// Save stores the currently active subscriptions to peers.
+	Save(ns storage.NamespaceStorer) error
+
+	// Restore loads previously saved subscriptions to peers, which Begin() then resumes before
+	// beginning new subscriptions. Subscriptions from other peers are not restored, since those
+	// peers re-establish them themselves.
+	Restore(nl storage.NamespaceLoader) error
+
+	// Stats returns the stats of the active subscriptions to peers along with the totals over
+	// all current and past subscriptions, whose dropped publications are the new ones dropped
+	// per the NewPolicy.
+	Stats() ([]*Stats, Totals)
 }
 
 type to struct {
@@ -116,20 +269,31 @@ type to struct {
 	csb      api.ClientSetBalancer
 	sb       subscriptionBeginner
 	recent   RecentPublications
+	pubLog   PublicationLog
 	received chan *pubValueReceipt
 	new      chan *KeyedPub
 	end      chan struct{}
+	active   map[uint32]*peerSubscription
+	restored []*peerSubscription
+	ended    Totals
+	nDropped uint64
+	failures map[string]uint
+	mu       sync.Mutex
 }
 
-// NewTo creates a new To instance, writing merged, deduplicated publications to the given new
-// channel.
+// NewTo creates a new To instance, writing merged, deduplicated publications to the given
+// publication log, unless it's nil, and new channel, whose capacity should be the NewQueueSize.
+// Publications are written to the new channel per the NewPolicy, and those dropped are counted
+// in the totals of Stats(). Publications received from peers are verified with the verifier.
 func NewTo(
 	params *ToParameters,
 	logger *zap.Logger,
 	clientID ecid.ID,
 	csb api.ClientSetBalancer,
 	signer client.Signer,
+	verifier PublicationVerifier,
 	recent RecentPublications,
+	pubLog PublicationLog,
 	new chan *KeyedPub,
 ) To {
 	return &to{
@@ -140,12 +304,16 @@ func NewTo(
 		sb: &subscriptionBeginnerImpl{
 			clientID: clientID,
 			signer:   signer,
+			verifier: verifier,
 			params:   params,
 		},
 		recent:   recent,
+		pubLog:   pubLog,
 		received: make(chan *pubValueReceipt, params.NSubscriptions),
 		new:      new,
 		end:      make(chan struct{}),
+		active:   make(map[uint32]*peerSubscription),
+		failures: make(map[string]uint),
 	}
 }
 
@@ -170,12 +338,7 @@ func (t *to) Begin() error {
 		go func(i uint32) {
 			rng, fp := rand.New(rand.NewSource(int64(i))), float64(t.params.FPRate)
 			for {
-				lc, peerID, err := t.csb.AddNext()
-				if err != nil {
-					fatal <- err
-					return
-				}
-				sub, err := NewFPSubscription(fp, rng)
+				lc, ps, err := t.next(fp, rng)
 				if err != nil {
 					fatal <- err
 					return
@@ -184,14 +347,26 @@ func (t *to) Begin() error {
 					zap.Int("index", int(i)),
 					zap.Float64("false_positive_rate", fp),
 				)
+				t.setActive(i, ps)
+				err = t.sb.begin(lc, ps.sub, ps.stats, t.received, errs, t.end)
+				failed := err != nil
+				if err == ErrUpstreamIdle {
+					// stalled upstreams don't count toward the error rate, since they may
+					// just be old peers that don't send keepalives, but we still want to
+					// replace them
+					t.logger.Info("replacing stalled subscription",
+						zap.Int("index", int(i)),
+						zap.String("peer_id", ps.peerID.String()),
+					)
+					err = nil
+				}
 				select {
 				case <-t.end:
 					return
-				case errs <- t.sb.begin(lc, sub, t.received, errs, t.end):
-				}
-				if err := t.csb.Remove(peerID); err != nil {
-					panic(err)  // should never happen
+				case errs <- err:
 				}
+				t.setActive(i, nil)
+				t.release(ps.peerID, failed)
 			}
 		}(c)
 	}
@@ -208,6 +383,131 @@ func (t *to) Begin() error {
 	}
 }
 
+// release removes the peer from the client set balancer so a later subscription may be to it
+// again. If the subscription to it failed, the peer is only removed after the failure backoff,
+// which prevents AddNext() from returning it in the meantime.
+func (t *to) release(peerID id.ID, failed bool) {
+	remove := func() {
+		if err := t.csb.Remove(peerID); err != nil {
+			panic(err) // should never happen
+		}
+	}
+	backoff := t.failureBackoff(peerID, failed)
+	if backoff == 0 {
+		remove()
+		return
+	}
+	t.logger.Info("backing off from failed subscription peer",
+		zap.String("peer_id", peerID.String()),
+		zap.Duration("backoff", backoff),
+	)
+	time.AfterFunc(backoff, remove)
+}
+
+// failureBackoff records whether the subscription to the peer failed and returns how long to wait
+// before subscribing to it again, which is zero after a success and otherwise doubles with each
+// consecutive failure up to the maximum.
+func (t *to) failureBackoff(peerID id.ID, failed bool) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !failed || t.params.FailureBackoff == 0 {
+		delete(t.failures, peerID.String())
+		return 0
+	}
+	t.failures[peerID.String()]++
+	backoff := t.params.FailureBackoff
+	for c := uint(1); c < t.failures[peerID.String()]; c++ {
+		if backoff >= t.params.MaxFailureBackoff {
+			break
+		}
+		backoff *= 2
+	}
+	if backoff > t.params.MaxFailureBackoff {
+		return t.params.MaxFailureBackoff
+	}
+	return backoff
+}
+
+// next returns the client and subscription for the next subscription to begin, preferring any
+// restored subscriptions whose peers are still available over new ones.
+func (t *to) next(fp float64, rng *rand.Rand) (api.LibrarianClient, *peerSubscription, error) {
+	for restored := t.popRestored(); restored != nil; restored = t.popRestored() {
+		lc, ps, err := t.resume(restored)
+		if err == nil {
+			return lc, ps, nil
+		}
+		t.logger.Debug("unable to resume restored subscription",
+			zap.String("peer_id", restored.peerID.String()),
+			zap.Error(err),
+		)
+	}
+	lc, peerID, err := t.csb.AddNext()
+	if err != nil {
+		return nil, nil, err
+	}
+	sub, err := NewFPSubscription(fp, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	ps, err := newPeerSubscription(peerID, sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lc, ps, nil
+}
+
+// resume returns the client and subscription for beginning a restored subscription again.
+func (t *to) resume(
+	restored *peerSubscription,
+) (api.LibrarianClient, *peerSubscription, error) {
+	ps, err := newPeerSubscription(restored.peerID, restored.sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	lc, err := t.csb.Add(ps.peerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lc, ps, nil
+}
+
+func (t *to) popRestored() *peerSubscription {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.restored) == 0 {
+		return nil
+	}
+	ps := t.restored[0]
+	t.restored = t.restored[1:]
+	return ps
+}
+
+func (t *to) setActive(i uint32, ps *peerSubscription) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ps == nil {
+		if ended, in := t.active[i]; in {
+			t.ended.add(ended.stats)
+		}
+		delete(t.active, i)
+		return
+	}
+	t.active[i] = ps
+}
+
+func (t *to) Stats() ([]*Stats, Totals) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := make([]*Stats, 0, len(t.active))
+	totals := t.ended
+	for _, ps := range t.active {
+		stats = append(stats, ps.stats)
+		totals.add(ps.stats)
+	}
+	totals.NDropped += atomic.LoadUint64(&t.nDropped)
+	return stats, totals
+}
+
 func (t *to) End() {
 	t.logger.Info("ending subscriptions")
 	select {
@@ -241,6 +541,7 @@ func (t *to) Send(pub *api.Publication) error {
 	if err != nil {
 		return err
 	}
+	pvr.pub.OriginTime = pvr.receipt.Time // this librarian is the publication's origin
 	select {
 	case <-t.end:
 		return errors.New("receive channel closed")
@@ -250,6 +551,15 @@ func (t *to) Send(pub *api.Publication) error {
 	}
 }
 
+// log appends the publication to the publication log, if there is one.
+func (t *to) log(pvr *pubValueReceipt) error {
+	if t.pubLog == nil {
+		return nil
+	}
+	_, err := t.pubLog.Append(pvr.pub, pvr.receipt.FromPub)
+	return err
+}
+
 func (t *to) dedup() {
 	for pvr := range t.received {
 		seen := t.recent.Add(pvr)
@@ -258,7 +568,11 @@ func (t *to) dedup() {
 				zap.String("publication_key", pvr.pub.Key.String()),
 			)
 			t.logger.Debug("publication value", getLoggerValues(pvr.pub.Value)...)
-			t.new <- pvr.pub
+			if err := t.log(pvr); err != nil {
+				// still pass along publication even though it can't be replayed later
+				t.logger.Error("unable to log publication", zap.Error(err))
+			}
+			t.sendNew(pvr.pub)
 		}
 	}
 	select {
@@ -268,6 +582,40 @@ func (t *to) dedup() {
 	}
 }
 
+// sendNew sends the publication to the new channel, applying the NewPolicy if it is full.
+func (t *to) sendNew(pub *KeyedPub) {
+	if t.params.NewPolicy == BlockNew {
+		t.new <- pub
+		return
+	}
+	select {
+	case t.new <- pub:
+		return
+	default:
+	}
+	if t.params.NewPolicy == DropOldestNew {
+		select {
+		case dropped := <-t.new:
+			t.dropNew(dropped)
+		default: // fan-out has since consumed from the queue
+		}
+		select {
+		case t.new <- pub:
+			return
+		default: // only when the queue has no capacity
+		}
+	}
+	t.dropNew(pub)
+}
+
+func (t *to) dropNew(pub *KeyedPub) {
+	atomic.AddUint64(&t.nDropped, 1)
+	t.logger.Debug("new publications queue full",
+		zap.Stringer("new_policy", t.params.NewPolicy),
+		zap.String("dropped_publication_key", pub.Key.String()),
+	)
+}
+
 func getLoggerValues(pub *api.Publication) []zapcore.Field {
 	return []zapcore.Field{
 		zap.String("entry_key", fmt.Sprintf("%032x", pub.EntryKey)),
@@ -278,52 +626,163 @@ func getLoggerValues(pub *api.Publication) []zapcore.Field {
 }
 
 type subscriptionBeginner interface {
-	// begin begins a subscription and writes publications to received and errors to errs
-	begin(lc api.Subscriber, sub *api.Subscription, received chan *pubValueReceipt,
-		errs chan error, end chan struct{}) error
+	// begin begins a subscription and writes publications to received and errors to errs,
+	// recording each received publication and its latency in stats
+	begin(lc api.Subscriber, sub *api.Subscription, stats *Stats,
+		received chan *pubValueReceipt, errs chan error, end chan struct{}) error
 }
 
 type subscriptionBeginnerImpl struct {
 	clientID ecid.ID
 	signer   client.Signer
+	verifier PublicationVerifier
 	params   *ToParameters
 }
 
 func (sb *subscriptionBeginnerImpl) begin(
 	lc api.Subscriber,
 	sub *api.Subscription,
+	stats *Stats,
 	received chan *pubValueReceipt,
 	errs chan error,
 	end chan struct{},
 ) error {
 
 	rq := client.NewSubscribeRequest(sb.clientID, sub)
-	ctx, err := client.NewSignedContext(sb.signer, rq)
+	rq.Window = sb.params.Window
+	rq.MaxBatchSize = sb.params.MaxBatchSize
+	rq.MaxBatchDelay = int64(sb.params.MaxBatchDelay)
+	signedCtx, err := client.NewSignedContext(sb.signer, rq)
 	if err != nil {
 		return err
 	}
-	subscribeClient, err := lc.Subscribe(ctx, rq)
+	ctx, cancel := context.WithCancel(signedCtx)
+	defer cancel()
+	idle := newIdleTimer(sb.params.IdleTimeout, cancel)
+	defer idle.stop()
+	dial := newIdleTimer(sb.params.DialTimeout, cancel)
+	subscribeClient, err := lc.Subscribe(ctx)
+	if err == nil {
+		err = subscribeClient.Send(rq)
+	}
+	dial.stop()
+	if dial.expired() {
+		return ErrSubscribeDialTimeout
+	}
 	if err != nil {
 		return err
 	}
+	ackInterval, nReceived := sb.ackInterval(), uint64(0)
 	for {
 		rp, err := subscribeClient.Recv()
+		if idle.expired() {
+			return ErrUpstreamIdle
+		}
 		if err == io.EOF {
 			return nil
 		}
 		if err != nil {
 			return err
 		}
-		pvr, err := newPublicationValueReceipt(rp.Key, rp.Value, rp.Metadata.PubKey)
-		if err != nil {
-			return err
+		idle.reset()
+		batch := rp.Batch
+		if rp.Value != nil {
+			batch = []*api.BatchedPublication{{
+				Key:        rp.Key,
+				Value:      rp.Value,
+				OriginTime: rp.OriginTime,
+			}}
 		}
-		select {
-		case <-end:
-			return nil
-		case received <- pvr:
-			errs <- nil
+		if len(batch) == 0 {
+			// keepalive while the peer has no publications to send
+			continue
 		}
+		prevReceived := nReceived
+		for _, bp := range batch {
+			pvr, err := newPublicationValueReceipt(bp.Key, bp.Value, rp.Metadata.PubKey)
+			if err != nil {
+				return err
+			}
+			if err := sb.verifier.Verify(bp.Value); err != nil {
+				return err
+			}
+			if bp.OriginTime != 0 {
+				pvr.pub.OriginTime = time.Unix(0, bp.OriginTime).UTC()
+				stats.AddLatency(pvr.receipt.Time.Sub(pvr.pub.OriginTime))
+			}
+			select {
+			case <-end:
+				return nil
+			case received <- pvr:
+				errs <- nil
+			}
+			stats.AddPub()
+			nReceived++
+		}
+		if ackInterval > 0 && nReceived/ackInterval > prevReceived/ackInterval {
+			// acknowledge once per ack interval, even if a batch spans more than one
+			if err := subscribeClient.Send(client.NewSubscribeAck(nReceived)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ackInterval returns the number of publications to receive between each acknowledgement, which
+// is zero when flow control is disabled and never exceeds the window.
+func (sb *subscriptionBeginnerImpl) ackInterval() uint64 {
+	if sb.params.Window == 0 {
+		return 0
+	}
+	if sb.params.AckInterval == 0 || sb.params.AckInterval > sb.params.Window {
+		return uint64(sb.params.Window)
+	}
+	return uint64(sb.params.AckInterval)
+}
+
+// idleTimer cancels a subscription when it isn't reset within a timeout.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	fired   chan struct{}
+}
+
+// newIdleTimer creates a new *idleTimer that calls cancel after the timeout, unless the timeout
+// is zero.
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	it := &idleTimer{
+		timeout: timeout,
+		fired:   make(chan struct{}),
+	}
+	if timeout > 0 {
+		it.timer = time.AfterFunc(timeout, func() {
+			close(it.fired)
+			cancel()
+		})
+	}
+	return it
+}
+
+// reset restarts the timeout, unless it has already expired.
+func (it *idleTimer) reset() {
+	if it.timer != nil && it.timer.Stop() {
+		it.timer.Reset(it.timeout)
+	}
+}
+
+// expired returns whether the timeout has expired.
+func (it *idleTimer) expired() bool {
+	select {
+	case <-it.fired:
+		return true
+	default:
+		return false
+	}
+}
+
+func (it *idleTimer) stop() {
+	if it.timer != nil {
+		it.timer.Stop()
 	}
 }
 
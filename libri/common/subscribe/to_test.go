@@ -5,9 +5,12 @@ import (
 	"io"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
 	clogging "github.com/drausin/libri/libri/common/logging"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/golang/protobuf/proto"
@@ -16,7 +19,6 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
-	"github.com/drausin/libri/libri/common/id"
 )
 
 func TestTo_BeginEnd(t *testing.T) {
@@ -25,11 +27,12 @@ func TestTo_BeginEnd(t *testing.T) {
 	clientID := ecid.NewPseudoRandom(rng)
 	lg := clogging.NewDevInfoLogger()
 	params.NSubscriptions = 2
-	cb := &fixedClientSetBalancer{}
+	cb := &fixedClientSetBalancer{peerID: id.NewPseudoRandom(rng)}
 	recent, err := NewRecentPublications(2)
 	assert.Nil(t, err)
 	newPubs := make(chan *KeyedPub, 1)
-	toImpl := NewTo(params, lg, clientID, cb, nil, recent, newPubs).(*to)
+	toImpl := NewTo(params, lg, clientID, cb, nil, nil, recent, &fixedPublicationLog{},
+		newPubs).(*to)
 
 	// mock what we actually get from subscriptions
 	received := make(chan *pubValueReceipt)
@@ -140,14 +143,15 @@ func TestTo_Begin_err(t *testing.T) {
 	lg := clogging.NewDevInfoLogger()
 	clientID := ecid.NewPseudoRandom(rng)
 	recent, err := NewRecentPublications(2)
-	csb := &fixedClientSetBalancer{}
+	csb := &fixedClientSetBalancer{peerID: id.NewPseudoRandom(rng)}
 	assert.Nil(t, err)
 	newPubs := make(chan *KeyedPub, 1)
 
 	// check csb.Next() error bubbles up
 	nextErr := errors.New("some Next() error")
 	csb1 := &fixedClientSetBalancer{err: nextErr}
-	toImpl1 := NewTo(params, lg, clientID, csb1, nil, recent, newPubs).(*to)
+	toImpl1 := NewTo(params, lg, clientID, csb1, nil, nil, recent, &fixedPublicationLog{},
+		newPubs).(*to)
 	toImpl1.sb = &fixedSubscriptionBeginner{subscribeErr: errors.New("some subscribe error")}
 	err = toImpl1.Begin()
 	assert.Equal(t, nextErr, err)
@@ -155,7 +159,8 @@ func TestTo_Begin_err(t *testing.T) {
 	// check NewFPSubscription error bubbles up
 	params2 := NewDefaultToParameters()
 	params2.FPRate = 0.0 // will trigger error
-	toImpl2 := NewTo(params2, lg, clientID, csb, nil, recent, newPubs).(*to)
+	toImpl2 := NewTo(params2, lg, clientID, csb, nil, nil, recent, &fixedPublicationLog{},
+		newPubs).(*to)
 	toImpl2.sb = &fixedSubscriptionBeginner{subscribeErr: errors.New("some subscribe error")}
 	err = toImpl2.Begin()
 	assert.Equal(t, ErrOutOfBoundsFPRate, err)
@@ -163,7 +168,8 @@ func TestTo_Begin_err(t *testing.T) {
 	// check running error count above threshold triggers error
 	received := make(chan *pubValueReceipt)
 	errs := make(chan error)
-	toImpl3 := NewTo(params, lg, clientID, csb, nil, recent, newPubs).(*to)
+	toImpl3 := NewTo(params, lg, clientID, csb, nil, nil, recent, &fixedPublicationLog{},
+		newPubs).(*to)
 	toImpl3.sb = &fixedSubscriptionBeginner{
 		received:     received,
 		errs:         errs,
@@ -179,6 +185,222 @@ func TestTo_Begin_err(t *testing.T) {
 	assert.Equal(t, ErrTooManySubscriptionErrs, err)
 }
 
+func TestTo_Begin_idle(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	params.NSubscriptions = 1
+	lg := clogging.NewDevInfoLogger()
+	recent, err := NewRecentPublications(2)
+	assert.Nil(t, err)
+	csb := &fixedClientSetBalancer{peerID: id.NewPseudoRandom(rng)}
+	toImpl := NewTo(params, lg, ecid.NewPseudoRandom(rng), csb, nil, nil, recent,
+		&fixedPublicationLog{}, make(chan *KeyedPub)).(*to)
+	toImpl.sb = &fixedSubscriptionBeginner{subscribeErr: ErrUpstreamIdle}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		err = toImpl.Begin()
+	}(wg)
+
+	// idle subscriptions keep being replaced without counting as errors
+	for atomic.LoadUint32(&csb.nAddNext) <= errQueueSize {
+		time.Sleep(time.Millisecond)
+	}
+	toImpl.End()
+	wg.Wait()
+	assert.Nil(t, err)
+}
+
+func TestTo_release(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	params.FailureBackoff = 10 * time.Millisecond
+	csb := &fixedClientSetBalancer{}
+	toImpl := NewTo(params, clogging.NewDevInfoLogger(), ecid.NewPseudoRandom(rng), csb, nil,
+		nil, nil, nil, nil).(*to)
+	peerID := id.NewPseudoRandom(rng)
+
+	// peer with successful subscription is removed immediately
+	toImpl.release(peerID, false)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&csb.nRemoved))
+
+	// peer with failed subscription is removed after backoff
+	toImpl.release(peerID, true)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&csb.nRemoved))
+	for atomic.LoadUint32(&csb.nRemoved) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTo_failureBackoff(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	params.FailureBackoff = 1 * time.Second
+	params.MaxFailureBackoff = 5 * time.Second
+	toImpl := NewTo(params, clogging.NewDevInfoLogger(), ecid.NewPseudoRandom(rng), nil, nil,
+		nil, nil, nil, nil).(*to)
+	peerID1, peerID2 := id.NewPseudoRandom(rng), id.NewPseudoRandom(rng)
+
+	// backoff doubles with each consecutive failure up to the max
+	assert.Equal(t, 1*time.Second, toImpl.failureBackoff(peerID1, true))
+	assert.Equal(t, 2*time.Second, toImpl.failureBackoff(peerID1, true))
+	assert.Equal(t, 4*time.Second, toImpl.failureBackoff(peerID1, true))
+	assert.Equal(t, 5*time.Second, toImpl.failureBackoff(peerID1, true))
+	assert.Equal(t, 5*time.Second, toImpl.failureBackoff(peerID1, true))
+
+	// failures are counted per peer
+	assert.Equal(t, 1*time.Second, toImpl.failureBackoff(peerID2, true))
+
+	// success resets the backoff
+	assert.Zero(t, toImpl.failureBackoff(peerID1, false))
+	assert.Equal(t, 1*time.Second, toImpl.failureBackoff(peerID1, true))
+
+	// zero backoff disables backing off
+	params.FailureBackoff = 0
+	assert.Zero(t, toImpl.failureBackoff(peerID2, true))
+}
+
+func TestTo_next(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	lg := clogging.NewDevInfoLogger()
+	csb := &fixedClientSetBalancer{}
+	recent, err := NewRecentPublications(2)
+	assert.Nil(t, err)
+	toImpl := NewTo(params, lg, ecid.NewPseudoRandom(rng), csb, nil, nil, recent,
+		&fixedPublicationLog{}, nil).(*to)
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	restored := &peerSubscription{
+		peerID: id.NewPseudoRandom(rng),
+		sub:    sub,
+	}
+	toImpl.restored = []*peerSubscription{restored}
+
+	// restored subscription is resumed first
+	_, ps, err := toImpl.next(float64(params.FPRate), rng)
+	assert.Nil(t, err)
+	assert.Equal(t, restored.peerID, ps.peerID)
+	assert.Equal(t, restored.sub, ps.sub)
+	assert.Equal(t, restored.peerID, ps.stats.PeerID)
+	assert.Equal(t, []id.ID{restored.peerID}, csb.added)
+	assert.Empty(t, toImpl.restored)
+
+	// new subscription is created once no restored ones remain
+	_, ps, err = toImpl.next(float64(params.FPRate), rng)
+	assert.Nil(t, err)
+	assert.NotEqual(t, restored.sub, ps.sub)
+	assert.NotNil(t, ps.sub)
+	assert.NotNil(t, ps.stats)
+
+	// restored subscription with invalid filters is skipped
+	invalid := &api.Subscription{
+		AuthorPublicKeys: &api.BloomFilter{Encoded: []byte("invalid")},
+		ReaderPublicKeys: &api.BloomFilter{Encoded: []byte("invalid")},
+	}
+	toImpl.restored = []*peerSubscription{{peerID: restored.peerID, sub: invalid}}
+	_, ps, err = toImpl.next(float64(params.FPRate), rng)
+	assert.Nil(t, err)
+	assert.NotEqual(t, restored.peerID, ps.peerID)
+	assert.Empty(t, toImpl.restored)
+
+	// restored subscription whose peer is unavailable is skipped
+	csb.addErr = errors.New("some Add error")
+	toImpl.restored = []*peerSubscription{restored}
+	_, ps, err = toImpl.next(float64(params.FPRate), rng)
+	assert.Nil(t, err)
+	assert.NotEqual(t, restored.sub, ps.sub)
+	assert.Empty(t, toImpl.restored)
+}
+
+func TestTo_Stats(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params, lg := NewDefaultToParameters(), clogging.NewDevInfoLogger()
+	toImpl := NewTo(params, lg, ecid.NewPseudoRandom(rng), nil, nil, nil, nil, nil, nil).(*to)
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	ps1, err := newPeerSubscription(id.NewPseudoRandom(rng), sub)
+	assert.Nil(t, err)
+	ps2, err := newPeerSubscription(id.NewPseudoRandom(rng), sub)
+	assert.Nil(t, err)
+	toImpl.setActive(0, ps1)
+	toImpl.setActive(1, ps2)
+	ps1.stats.AddPub()
+	ps2.stats.AddPub()
+	ps2.stats.AddPub()
+
+	stats, totals := toImpl.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, Totals{NPubs: 3}, totals)
+
+	// ended subscription still contributes to totals
+	toImpl.setActive(0, nil)
+	stats, totals = toImpl.Stats()
+	assert.Equal(t, []*Stats{ps2.stats}, stats)
+	assert.Equal(t, Totals{NPubs: 3}, totals)
+}
+
+func TestTo_sendNew(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pub1, pub2 := newTestKeyedPub(rng), newTestKeyedPub(rng)
+	cases := map[NewPolicy][]*KeyedPub{
+		DropNewestNew: {pub1},
+		DropOldestNew: {pub2},
+	}
+	for policy, expected := range cases {
+		params := NewDefaultToParameters()
+		params.NewPolicy = policy
+		newPubs := make(chan *KeyedPub, 1)
+		toImpl := NewTo(params, clogging.NewDevInfoLogger(), ecid.NewPseudoRandom(rng), nil,
+			nil, nil, nil, nil, newPubs).(*to)
+
+		// send both pubs without consuming any, so the second overflows the queue
+		toImpl.sendNew(pub1)
+		toImpl.sendNew(pub2)
+		close(newPubs)
+		received := make([]*KeyedPub, 0)
+		for pub := range newPubs {
+			received = append(received, pub)
+		}
+		assert.Equal(t, expected, received, policy.String())
+		_, totals := toImpl.Stats()
+		assert.Equal(t, uint64(1), totals.NDropped, policy.String())
+	}
+
+	// without queue capacity, drop oldest drops the new publication
+	params := NewDefaultToParameters()
+	params.NewPolicy = DropOldestNew
+	toImpl := NewTo(params, clogging.NewDevInfoLogger(), ecid.NewPseudoRandom(rng), nil, nil,
+		nil, nil, nil, make(chan *KeyedPub)).(*to)
+	toImpl.sendNew(pub1)
+	_, totals := toImpl.Stats()
+	assert.Equal(t, uint64(1), totals.NDropped)
+
+	// block waits for room in the queue
+	params = NewDefaultToParameters()
+	newPubs := make(chan *KeyedPub, 1)
+	toImpl = NewTo(params, clogging.NewDevInfoLogger(), ecid.NewPseudoRandom(rng), nil, nil,
+		nil, nil, nil, newPubs).(*to)
+	toImpl.sendNew(pub1)
+	sent := make(chan struct{})
+	go func() {
+		toImpl.sendNew(pub2)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		assert.Fail(t, "send should block while queue is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Equal(t, pub1, <-newPubs)
+	<-sent
+	assert.Equal(t, pub2, <-newPubs)
+	_, totals = toImpl.Stats()
+	assert.Zero(t, totals.NDropped)
+}
+
 func TestFrom_Send(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	toImpl := &to{
@@ -203,6 +425,7 @@ func TestFrom_Send(t *testing.T) {
 	pvr := <-toImpl.received
 	assert.NotNil(t, pvr)
 	assert.Equal(t, pub, pvr.pub.Value)
+	assert.Equal(t, pvr.receipt.Time, pvr.pub.OriginTime)
 
 	wg.Wait()
 }
@@ -215,6 +438,7 @@ func TestSubscriptionBeginnerImpl_Begin_ok(t *testing.T) {
 	sb := subscriptionBeginnerImpl{
 		clientID: clientID,
 		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
 		params:   NewDefaultToParameters(),
 	}
 	responses := make(chan *api.SubscribeResponse, 1)
@@ -234,17 +458,20 @@ func TestSubscriptionBeginnerImpl_Begin_ok(t *testing.T) {
 	value := api.NewTestPublication(rng)
 	key, err := api.GetKey(value)
 	assert.Nil(t, err)
+	originTime := time.Now().Add(-1 * time.Second).UTC()
 	responses <- &api.SubscribeResponse{
 		Metadata: &api.ResponseMetadata{
 			PubKey: fromPubKey,
 		},
-		Key:   key.Bytes(),
-		Value: value,
+		Key:        key.Bytes(),
+		Value:      value,
+		OriginTime: originTime.UnixNano(),
 	}
 	responseErrs <- nil
 
+	stats := NewStats(nil, nil)
 	go func() {
-		beginErr := sb.begin(lc, sub, received, errs, end)
+		beginErr := sb.begin(lc, sub, stats, received, errs, end)
 		assert.Nil(t, beginErr)
 	}()
 
@@ -254,6 +481,10 @@ func TestSubscriptionBeginnerImpl_Begin_ok(t *testing.T) {
 	assert.Equal(t, key, receivedPub.pub.Key)
 	assert.Equal(t, value, receivedPub.pub.Value)
 	assert.Equal(t, fromPubKey, receivedPub.receipt.FromPub)
+	assert.True(t, originTime.Equal(receivedPub.pub.OriginTime))
+	latencies := stats.Latencies()
+	assert.Equal(t, uint64(1), latencies.N())
+	assert.True(t, latencies.Sum >= time.Second)
 
 	// simulate subscription being close on server side
 	responses <- nil
@@ -261,7 +492,7 @@ func TestSubscriptionBeginnerImpl_Begin_ok(t *testing.T) {
 
 	// start again
 	go func() {
-		beginErr := sb.begin(lc, sub, received, errs, end)
+		beginErr := sb.begin(lc, sub, NewStats(nil, nil), received, errs, end)
 		assert.Nil(t, beginErr)
 	}()
 
@@ -299,26 +530,28 @@ func TestSubscriptionBeginnerImpl_Begin_err(t *testing.T) {
 		params:   NewDefaultToParameters(),
 	}
 	lc1 := &fixedSubscriber{}
-	err = sb1.begin(lc1, sub, received, errs, end)
+	err = sb1.begin(lc1, sub, NewStats(nil, nil), received, errs, end)
 	assert.NotNil(t, err)
 
 	// check Subscribe error bubbles up
 	sb2 := subscriptionBeginnerImpl{
 		clientID: clientID,
 		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
 		params:   NewDefaultToParameters(),
 	}
 	lc2 := &fixedSubscriber{
 		client: nil,
 		err:    errors.New("some Subscribe error"),
 	}
-	err = sb2.begin(lc2, sub, received, errs, end)
+	err = sb2.begin(lc2, sub, NewStats(nil, nil), received, errs, end)
 	assert.NotNil(t, err)
 
 	// check Recv error bubbles up
 	sb3 := subscriptionBeginnerImpl{
 		clientID: clientID,
 		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
 		params:   NewDefaultToParameters(),
 	}
 	responses3 := make(chan *api.SubscribeResponse, 1)
@@ -331,13 +564,14 @@ func TestSubscriptionBeginnerImpl_Begin_err(t *testing.T) {
 	}
 	responses3 <- nil
 	responseErrs3 <- errors.New("some Recv error")
-	err = sb3.begin(lc3, sub, received, errs, end)
+	err = sb3.begin(lc3, sub, NewStats(nil, nil), received, errs, end)
 	assert.NotNil(t, err)
 
 	// check newPublicationValueReceipt error bubbles up
 	sb4 := subscriptionBeginnerImpl{
 		clientID: clientID,
 		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
 		params:   NewDefaultToParameters(),
 	}
 	responses4 := make(chan *api.SubscribeResponse, 1)
@@ -357,10 +591,289 @@ func TestSubscriptionBeginnerImpl_Begin_err(t *testing.T) {
 		Value: value,
 	}
 	responseErrs4 <- nil
-	err = sb4.begin(lc4, sub, received, errs, end)
+	err = sb4.begin(lc4, sub, NewStats(nil, nil), received, errs, end)
+	assert.NotNil(t, err)
+
+	// check Verify error bubbles up
+	verifyErr := errors.New("some Verify error")
+	sb6 := subscriptionBeginnerImpl{
+		clientID: clientID,
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{err: verifyErr},
+		params:   NewDefaultToParameters(),
+	}
+	responses6 := make(chan *api.SubscribeResponse, 1)
+	responseErrs6 := make(chan error, 1)
+	lc6 := &fixedSubscriber{
+		client: &fixedLibrarianSubscribeClient{
+			responses: responses6,
+			err:       responseErrs6,
+		},
+	}
+	value = api.NewTestPublication(rng)
+	key, err := api.GetKey(value)
+	assert.Nil(t, err)
+	responses6 <- &api.SubscribeResponse{
+		Metadata: &api.ResponseMetadata{
+			PubKey: fromPubKey,
+		},
+		Key:   key.Bytes(),
+		Value: value,
+	}
+	responseErrs6 <- nil
+	err = sb6.begin(lc6, sub, NewStats(nil, nil), received, errs, end)
+	assert.Equal(t, verifyErr, err)
+
+	// check Send error bubbles up
+	sb5 := subscriptionBeginnerImpl{
+		clientID: clientID,
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
+		params:   NewDefaultToParameters(),
+	}
+	lc5 := &fixedSubscriber{
+		client: &fixedLibrarianSubscribeClient{sendErr: errors.New("some Send error")},
+	}
+	err = sb5.begin(lc5, sub, NewStats(nil, nil), received, errs, end)
 	assert.NotNil(t, err)
 }
 
+func TestSubscriptionBeginnerImpl_Begin_acks(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	fromPubKey := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	params := NewDefaultToParameters()
+	params.Window, params.AckInterval = 4, 2
+	sb := subscriptionBeginnerImpl{
+		clientID: ecid.NewPseudoRandom(rng),
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
+		params:   params,
+	}
+	nPubs := 5
+	responses := make(chan *api.SubscribeResponse, nPubs+1)
+	responseErrs := make(chan error, nPubs+1)
+	lcClient := &fixedLibrarianSubscribeClient{
+		responses: responses,
+		err:       responseErrs,
+	}
+	for c := 0; c < nPubs; c++ {
+		value := api.NewTestPublication(rng)
+		key, err := api.GetKey(value)
+		assert.Nil(t, err)
+		responses <- &api.SubscribeResponse{
+			Metadata: &api.ResponseMetadata{PubKey: fromPubKey},
+			Key:      key.Bytes(),
+			Value:    value,
+		}
+		responseErrs <- nil
+	}
+	responses <- nil
+	responseErrs <- io.EOF
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	received := make(chan *pubValueReceipt, nPubs)
+	errs := make(chan error, nPubs)
+
+	lc := &fixedSubscriber{client: lcClient}
+	err = sb.begin(lc, sub, NewStats(nil, nil), received, errs, make(chan struct{}))
+	assert.Nil(t, err)
+	assert.Len(t, received, nPubs)
+
+	// first request defines subscription and the rest acknowledge every AckInterval pubs
+	assert.Len(t, lcClient.sent, 3)
+	assert.Equal(t, sub, lcClient.sent[0].Subscription)
+	assert.Equal(t, params.Window, lcClient.sent[0].Window)
+	assert.Equal(t, uint64(2), lcClient.sent[1].NAcked)
+	assert.Equal(t, uint64(4), lcClient.sent[2].NAcked)
+}
+
+func TestSubscriptionBeginnerImpl_Begin_batch(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	fromPubKey := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	params := NewDefaultToParameters()
+	params.Window, params.AckInterval = 4, 2
+	params.MaxBatchSize, params.MaxBatchDelay = 3, 10*time.Millisecond
+	sb := subscriptionBeginnerImpl{
+		clientID: ecid.NewPseudoRandom(rng),
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
+		params:   params,
+	}
+	responses := make(chan *api.SubscribeResponse, 3)
+	responseErrs := make(chan error, 3)
+	lcClient := &fixedLibrarianSubscribeClient{
+		responses: responses,
+		err:       responseErrs,
+	}
+	nPubs, batches := 0, [][]*api.BatchedPublication{{}, {}}
+	for i, size := range []int{3, 1} {
+		for c := 0; c < size; c++ {
+			value := api.NewTestPublication(rng)
+			key, err := api.GetKey(value)
+			assert.Nil(t, err)
+			batches[i] = append(batches[i], &api.BatchedPublication{
+				Key:   key.Bytes(),
+				Value: value,
+			})
+			nPubs++
+		}
+		responses <- &api.SubscribeResponse{
+			Metadata: &api.ResponseMetadata{PubKey: fromPubKey},
+			Batch:    batches[i],
+		}
+		responseErrs <- nil
+	}
+	responses <- nil
+	responseErrs <- io.EOF
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	received := make(chan *pubValueReceipt, nPubs)
+	errs := make(chan error, nPubs)
+	stats := NewStats(nil, nil)
+
+	lc := &fixedSubscriber{client: lcClient}
+	err = sb.begin(lc, sub, stats, received, errs, make(chan struct{}))
+	assert.Nil(t, err)
+	assert.Len(t, received, nPubs)
+	assert.Equal(t, uint64(nPubs), stats.NPubs())
+	assert.Equal(t, batches[0][0].Value, (<-received).pub.Value)
+
+	// each batch crosses an AckInterval, so each is acknowledged
+	assert.Len(t, lcClient.sent, 3)
+	assert.Equal(t, params.MaxBatchSize, lcClient.sent[0].MaxBatchSize)
+	assert.Equal(t, int64(params.MaxBatchDelay), lcClient.sent[0].MaxBatchDelay)
+	assert.Equal(t, uint64(3), lcClient.sent[1].NAcked)
+	assert.Equal(t, uint64(4), lcClient.sent[2].NAcked)
+}
+
+func TestSubscriptionBeginnerImpl_Begin_idle(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	params.IdleTimeout = 10 * time.Millisecond
+	sb := subscriptionBeginnerImpl{
+		clientID: ecid.NewPseudoRandom(rng),
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
+		params:   params,
+	}
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	received := make(chan *pubValueReceipt)
+	errs := make(chan error)
+
+	err = sb.begin(&idleSubscriber{}, sub, NewStats(nil, nil), received, errs,
+		make(chan struct{}))
+	assert.Equal(t, ErrUpstreamIdle, err)
+}
+
+func TestSubscriptionBeginnerImpl_Begin_dialTimeout(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	params.DialTimeout = 10 * time.Millisecond
+	sb := subscriptionBeginnerImpl{
+		clientID: ecid.NewPseudoRandom(rng),
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
+		params:   params,
+	}
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	received := make(chan *pubValueReceipt)
+	errs := make(chan error)
+
+	err = sb.begin(&blockingSubscriber{}, sub, NewStats(nil, nil), received, errs,
+		make(chan struct{}))
+	assert.Equal(t, ErrSubscribeDialTimeout, err)
+
+	// check dial timeout doesn't end subscription once opened
+	params.IdleTimeout = 50 * time.Millisecond
+	err = sb.begin(&idleSubscriber{}, sub, NewStats(nil, nil), received, errs,
+		make(chan struct{}))
+	assert.Equal(t, ErrUpstreamIdle, err)
+}
+
+func TestSubscriptionBeginnerImpl_Begin_keepalive(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultToParameters()
+	params.IdleTimeout = 50 * time.Millisecond
+	sb := subscriptionBeginnerImpl{
+		clientID: ecid.NewPseudoRandom(rng),
+		signer:   &fixedSigner{signature: "some.signature.jtw"},
+		verifier: &fixedPublicationVerifier{},
+		params:   params,
+	}
+	responses := make(chan *api.SubscribeResponse)
+	responseErrs := make(chan error)
+	lc := &fixedSubscriber{
+		client: &fixedLibrarianSubscribeClient{
+			responses: responses,
+			err:       responseErrs,
+		},
+	}
+	sub, err := NewFPSubscription(DefaultFPRate, rng)
+	assert.Nil(t, err)
+	stats := NewStats(nil, nil)
+
+	// keepalives for longer than the idle timeout keep the subscription from going idle
+	go func() {
+		keepalive := &api.SubscribeResponse{Metadata: &api.ResponseMetadata{}}
+		for c := 0; c < 4; c++ {
+			time.Sleep(params.IdleTimeout / 2)
+			responses <- keepalive
+			responseErrs <- nil
+		}
+		responses <- nil
+		responseErrs <- io.EOF
+	}()
+	err = sb.begin(lc, sub, stats, make(chan *pubValueReceipt), make(chan error),
+		make(chan struct{}))
+	assert.Nil(t, err)
+	assert.Zero(t, stats.NPubs())
+}
+
+func TestIdleTimer(t *testing.T) {
+	cancelled := make(chan struct{})
+	cancel := func() { close(cancelled) }
+
+	// zero timeout never expires
+	it := newIdleTimer(0, cancel)
+	it.reset()
+	assert.False(t, it.expired())
+	it.stop()
+
+	// reset postpones expiration
+	it = newIdleTimer(200*time.Millisecond, cancel)
+	time.Sleep(100 * time.Millisecond)
+	it.reset()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, it.expired())
+
+	// expires without reset
+	<-cancelled
+	assert.True(t, it.expired())
+	it.reset() // no-op once expired
+	assert.True(t, it.expired())
+	it.stop()
+}
+
+func TestSubscriptionBeginnerImpl_ackInterval(t *testing.T) {
+	cases := []struct {
+		window, ackInterval uint32
+		expected            uint64
+	}{
+		{window: 0, ackInterval: 16, expected: 0},
+		{window: 64, ackInterval: 16, expected: 16},
+		{window: 64, ackInterval: 0, expected: 64},
+		{window: 8, ackInterval: 16, expected: 8},
+	}
+	for _, c := range cases {
+		sb := subscriptionBeginnerImpl{
+			params: &ToParameters{Window: c.window, AckInterval: c.ackInterval},
+		}
+		assert.Equal(t, c.expected, sb.ackInterval())
+	}
+}
+
 func TestDedup(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	value1 := api.NewTestPublication(rng)
@@ -377,10 +890,13 @@ func TestDedup(t *testing.T) {
 	assert.Nil(t, err)
 	newPVRs := make(chan *KeyedPub, slack)
 	receivedPVRs := make(chan *pubValueReceipt, slack)
+	pubLog := &fixedPublicationLog{}
 	toImpl := &to{
+		params:   NewDefaultToParameters(),
 		received: receivedPVRs,
 		new:      newPVRs,
 		recent:   rp,
+		pubLog:   pubLog,
 		logger:   clogging.NewDevLogger(zapcore.DebugLevel),
 	}
 
@@ -392,6 +908,7 @@ func TestDedup(t *testing.T) {
 	receivedPVRs <- pvr1in
 	pv1out := <-newPVRs
 	assert.Equal(t, pvr1in.pub, pv1out)
+	assert.Equal(t, uint64(1), pv1out.Sequence)
 
 	// not new
 	pvr2in, err := newPublicationValueReceipt(key1.Bytes(), value1, fromPub2)
@@ -410,6 +927,50 @@ func TestDedup(t *testing.T) {
 	receivedPVRs <- pvr3in
 	pv3out := <-newPVRs
 	assert.Equal(t, pvr3in.pub, pv3out)
+	assert.Equal(t, uint64(2), pv3out.Sequence)
+
+	// new but unable to log is still passed along
+	pubLog.err = errors.New("some Append error")
+	value3 := api.NewTestPublication(rng)
+	key3, err := api.GetKey(value3)
+	assert.Nil(t, err)
+	pvr4in, err := newPublicationValueReceipt(key3.Bytes(), value3, fromPub1)
+	assert.Nil(t, err)
+	receivedPVRs <- pvr4in
+	pv4out := <-newPVRs
+	assert.Equal(t, pvr4in.pub, pv4out)
+	assert.Zero(t, pv4out.Sequence)
+	assert.Len(t, pubLog.appended, 2)
+	assert.Equal(t, [][]byte{fromPub1, fromPub1}, pubLog.fromPubs)
+}
+
+func TestTo_dedup_noLog(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	value := api.NewTestPublication(rng)
+	key, err := api.GetKey(value)
+	assert.Nil(t, err)
+	rp, err := NewRecentPublications(2)
+	assert.Nil(t, err)
+	newPVRs := make(chan *KeyedPub, 1)
+	receivedPVRs := make(chan *pubValueReceipt, 1)
+	toImpl := &to{
+		params:   NewDefaultToParameters(),
+		received: receivedPVRs,
+		new:      newPVRs,
+		recent:   rp,
+		logger:   clogging.NewDevLogger(zapcore.DebugLevel),
+	}
+	go toImpl.dedup()
+
+	// check new publication is passed along without being logged
+	pvrIn, err := newPublicationValueReceipt(key.Bytes(), value,
+		api.RandBytes(rng, api.ECPubKeyLength))
+	assert.Nil(t, err)
+	receivedPVRs <- pvrIn
+	pvOut := <-newPVRs
+	assert.Equal(t, pvrIn.pub, pvOut)
+	assert.Zero(t, pvOut.Sequence)
+	close(receivedPVRs)
 }
 
 func TestMonitorRunningErrorCount(t *testing.T) {
@@ -454,7 +1015,7 @@ type fixedSubscriptionBeginner struct {
 }
 
 func (f *fixedSubscriptionBeginner) begin(lc api.Subscriber, sub *api.Subscription,
-	received chan *pubValueReceipt, errs chan error, end chan struct{}) error {
+	stats *Stats, received chan *pubValueReceipt, errs chan error, end chan struct{}) error {
 	if f.subscribeErr == nil {
 		prv := <-f.received
 		err := <-f.errs
@@ -465,27 +1026,115 @@ func (f *fixedSubscriptionBeginner) begin(lc api.Subscriber, sub *api.Subscripti
 }
 
 type fixedClientSetBalancer struct {
-	err error
+	err      error
+	addErr   error
+	added    []id.ID
+	peerID   id.ID
+	nAddNext uint32
+	nRemoved uint32
 }
 
 func (f *fixedClientSetBalancer) AddNext() (api.LibrarianClient, id.ID, error) {
-	return nil, nil, f.err
+	atomic.AddUint32(&f.nAddNext, 1)
+	return nil, f.peerID, f.err
+}
+
+func (f *fixedClientSetBalancer) Add(peerID id.ID) (api.LibrarianClient, error) {
+	if f.addErr != nil {
+		return nil, f.addErr
+	}
+	f.added = append(f.added, peerID)
+	return nil, nil
 }
 
 func (f *fixedClientSetBalancer) Remove(id.ID) error {
+	atomic.AddUint32(&f.nRemoved, 1)
+	return nil
+}
+
+type fixedPublicationLog struct {
+	appended []*KeyedPub
+	fromPubs [][]byte
+	err      error
+}
+
+func (f *fixedPublicationLog) Append(pub *KeyedPub, fromPub []byte) (uint64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.appended = append(f.appended, pub)
+	f.fromPubs = append(f.fromPubs, fromPub)
+	pub.Sequence = uint64(len(f.appended))
+	return pub.Sequence, nil
+}
+
+func (f *fixedPublicationLog) Replay(from uint64, callback func(pub *KeyedPub) error) error {
+	for _, pub := range f.appended {
+		if pub.Sequence < from {
+			continue
+		}
+		if err := callback(pub); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (f *fixedPublicationLog) Scan(from uint64, callback func(logged *LoggedPub) error) error {
+	return f.Replay(from, func(pub *KeyedPub) error {
+		return callback(&LoggedPub{KeyedPub: pub, FromPub: f.fromPubs[pub.Sequence-1]})
+	})
+}
+
+func (f *fixedPublicationLog) LastSequence() uint64 {
+	return uint64(len(f.appended))
+}
+
 type fixedSubscriber struct {
 	client api.Librarian_SubscribeClient
 	err    error
 }
 
-func (f *fixedSubscriber) Subscribe(ctx context.Context, in *api.SubscribeRequest,
-	opts ...grpc.CallOption) (api.Librarian_SubscribeClient, error) {
+func (f *fixedSubscriber) Subscribe(ctx context.Context, opts ...grpc.CallOption) (
+	api.Librarian_SubscribeClient, error) {
 	return f.client, f.err
 }
 
+type idleSubscriber struct{}
+
+func (f *idleSubscriber) Subscribe(ctx context.Context, opts ...grpc.CallOption) (
+	api.Librarian_SubscribeClient, error) {
+	return &idleLibrarianSubscribeClient{ctx: ctx}, nil
+}
+
+// blockingSubscriber doesn't open subscriptions until their context is done.
+type blockingSubscriber struct{}
+
+func (f *blockingSubscriber) Subscribe(ctx context.Context, opts ...grpc.CallOption) (
+	api.Librarian_SubscribeClient, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// idleLibrarianSubscribeClient never receives any publications.
+type idleLibrarianSubscribeClient struct {
+	fixedLibrarianSubscribeClient
+	ctx context.Context
+}
+
+func (f *idleLibrarianSubscribeClient) Recv() (*api.SubscribeResponse, error) {
+	<-f.ctx.Done()
+	return nil, f.ctx.Err()
+}
+
+type fixedPublicationVerifier struct {
+	err error
+}
+
+func (f *fixedPublicationVerifier) Verify(pub *api.Publication) error {
+	return f.err
+}
+
 type fixedSigner struct {
 	signature string
 	err       error
@@ -498,6 +1147,16 @@ func (f *fixedSigner) Sign(m proto.Message) (string, error) {
 type fixedLibrarianSubscribeClient struct {
 	responses chan *api.SubscribeResponse
 	err       chan error
+	sent      []*api.SubscribeRequest
+	sendErr   error
+	mu        sync.Mutex
+}
+
+func (f *fixedLibrarianSubscribeClient) Send(rq *api.SubscribeRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, rq)
+	return f.sendErr
 }
 
 func (f *fixedLibrarianSubscribeClient) Recv() (*api.SubscribeResponse, error) {
@@ -0,0 +1,196 @@
+package subscribe
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultWebhookTimeout is the default timeout for each POST to a webhook endpoint.
+	DefaultWebhookTimeout = 5 * time.Second
+
+	webhookContentType = "application/json"
+)
+
+// WebhookParameters define the optional bridge that POSTs publications matching a subscription to
+// HTTP endpoints.
+type WebhookParameters struct {
+	// Endpoints are the URLs each matching publication is POSTed to; empty disables the bridge.
+	Endpoints []string
+
+	// Subscription defines the filters publications must match to be POSTed; nil POSTs all
+	// publications.
+	Subscription *api.Subscription
+
+	// Timeout is the timeout for each POST to an endpoint.
+	Timeout time.Duration
+}
+
+// NewDefaultWebhookParameters returns a *WebhookParameters object with default values.
+func NewDefaultWebhookParameters() *WebhookParameters {
+	return &WebhookParameters{
+		Timeout: DefaultWebhookTimeout,
+	}
+}
+
+// WebhookPublication is the JSON body POSTed to webhook endpoints for each publication, with
+// keys encoded as hex strings.
+type WebhookPublication struct {
+	Key              string `json:"key"`
+	EnvelopeKey      string `json:"envelope_key"`
+	EntryKey         string `json:"entry_key"`
+	AuthorPublicKey  string `json:"author_public_key"`
+	ReaderPublicKey  string `json:"reader_public_key"`
+	MediaType        string `json:"media_type,omitempty"`
+	UncompressedSize uint64 `json:"uncompressed_size,omitempty"`
+	Sequence         uint64 `json:"sequence,omitempty"`
+	OriginTime       int64  `json:"origin_time,omitempty"`
+}
+
+// NewWebhookPublication creates a new *WebhookPublication from a publication.
+func NewWebhookPublication(pub *KeyedPub) *WebhookPublication {
+	wp := &WebhookPublication{
+		Key:             pub.Key.String(),
+		EnvelopeKey:     hex.EncodeToString(pub.Value.EnvelopeKey),
+		EntryKey:        hex.EncodeToString(pub.Value.EntryKey),
+		AuthorPublicKey: hex.EncodeToString(pub.Value.AuthorPublicKey),
+		ReaderPublicKey: hex.EncodeToString(pub.Value.ReaderPublicKey),
+		Sequence:        pub.Sequence,
+	}
+	if pub.Value.EntryAttributes != nil {
+		wp.MediaType = pub.Value.EntryAttributes.MediaType
+		wp.UncompressedSize = pub.Value.EntryAttributes.UncompressedSize
+	}
+	if !pub.OriginTime.IsZero() {
+		wp.OriginTime = pub.OriginTime.UnixNano()
+	}
+	return wp
+}
+
+// Webhook POSTs publications matching its subscription as JSON to HTTP endpoints, so external
+// systems can react to new content without speaking gRPC.
+type Webhook interface {
+	// Begin registers an internal subscription with From and POSTs its matching publications
+	// until End is called or the fan-out ends. It returns immediately if there are no
+	// endpoints. Publications failing to POST to any endpoint are counted as dropped.
+	Begin() error
+
+	// End ends the internal subscription.
+	End()
+}
+
+type webhook struct {
+	params  *WebhookParameters
+	logger  *zap.Logger
+	from    From
+	selfID  id.ID
+	client  *http.Client
+	filters Filters
+	end     chan struct{}
+}
+
+// NewWebhook creates a new Webhook for the publications fanned out by the given From.
+func NewWebhook(params *WebhookParameters, logger *zap.Logger, from From, selfID id.ID) (
+	Webhook, error) {
+
+	sub := params.Subscription
+	if sub == nil {
+		var err error
+		if sub, err = NewFPSubscription(1.0, nil); err != nil {
+			return nil, err
+		}
+	}
+	filters, err := NewFilters(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook{
+		params:  params,
+		logger:  logger,
+		from:    from,
+		selfID:  selfID,
+		client:  &http.Client{Timeout: params.Timeout},
+		filters: filters,
+		end:     make(chan struct{}),
+	}, nil
+}
+
+func (w *webhook) Begin() error {
+	if len(w.params.Endpoints) == 0 {
+		return nil
+	}
+	stats := NewStats(w.selfID, w.filters)
+	pubs, done, err := w.from.New(stats)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-w.end:
+			close(done) // signal to w.from we're finished with this fanout
+			return nil
+		case pub, open := <-pubs:
+			if !open {
+				return nil
+			}
+			if !w.filters.Match(pub.Value) {
+				continue
+			}
+			if err := w.post(pub); err != nil {
+				stats.AddDropped()
+				continue
+			}
+			stats.AddPub()
+		}
+	}
+}
+
+func (w *webhook) End() {
+	select {
+	case <-w.end: // already closed
+	default:
+		close(w.end)
+	}
+}
+
+// post POSTs the publication to each endpoint, returning the last error if any POSTs fail.
+func (w *webhook) post(pub *KeyedPub) error {
+	body, err := json.Marshal(NewWebhookPublication(pub))
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for _, endpoint := range w.params.Endpoints {
+		if err := w.postEndpoint(endpoint, body); err != nil {
+			w.logger.Info("webhook POST failed",
+				zap.String("endpoint", endpoint),
+				zap.String("publication_key", pub.Key.String()),
+				zap.Error(err),
+			)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (w *webhook) postEndpoint(endpoint string, body []byte) error {
+	rp, err := w.client.Post(endpoint, webhookContentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := rp.Body.Close(); err != nil {
+		return err
+	}
+	if rp.StatusCode < 200 || rp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected webhook response status: %s", rp.Status)
+	}
+	return nil
+}
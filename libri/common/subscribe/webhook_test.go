@@ -0,0 +1,176 @@
+package subscribe
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWebhook_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultWebhookParameters()
+	lg := clogging.NewDevInfoLogger()
+	selfID := ecid.NewPseudoRandom(rng).ID()
+
+	// check nil subscription matches all publications
+	w, err := NewWebhook(params, lg, &fixedFrom{}, selfID)
+	assert.Nil(t, err)
+	assert.True(t, w.(*webhook).filters.Match(api.NewTestPublication(rng)))
+	assert.Equal(t, params.Timeout, w.(*webhook).client.Timeout)
+}
+
+func TestNewWebhook_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultWebhookParameters()
+	params.Subscription = &api.Subscription{} // missing filters
+	lg := clogging.NewDevInfoLogger()
+
+	w, err := NewWebhook(params, lg, &fixedFrom{}, ecid.NewPseudoRandom(rng).ID())
+	assert.NotNil(t, err)
+	assert.Nil(t, w)
+}
+
+func TestWebhook_Begin_noEndpoints(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	f := &fixedFrom{}
+	w, err := NewWebhook(NewDefaultWebhookParameters(), clogging.NewDevInfoLogger(), f,
+		ecid.NewPseudoRandom(rng).ID())
+	assert.Nil(t, err)
+
+	// check returns immediately without registering a subscription
+	assert.Nil(t, w.Begin())
+	assert.Nil(t, f.stats)
+}
+
+func TestWebhook_Begin_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	posted := make(chan *WebhookPublication, 8)
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, webhookContentType, r.Header.Get("Content-Type"))
+		wp := &WebhookPublication{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(wp))
+		posted <- wp
+	}))
+	defer okServer.Close()
+
+	matching, other := api.NewTestPublication(rng), api.NewTestPublication(rng)
+	sub, err := NewAuthorSubscription([][]byte{matching.AuthorPublicKey}, 1e-6, rng)
+	assert.Nil(t, err)
+	params := NewDefaultWebhookParameters()
+	params.Endpoints = []string{okServer.URL}
+	params.Subscription = sub
+	f := &fixedFrom{new: make(chan *KeyedPub, 2), done: make(chan struct{})}
+	w, err := NewWebhook(params, clogging.NewDevInfoLogger(), f, ecid.NewPseudoRandom(rng).ID())
+	assert.Nil(t, err)
+
+	begun := make(chan error)
+	go func() { begun <- w.Begin() }()
+	f.new <- newKeyedPub(t, other)
+	f.new <- newKeyedPub(t, matching)
+
+	// check only matching publication is POSTed
+	select {
+	case wp := <-posted:
+		assert.Equal(t, hex.EncodeToString(matching.EnvelopeKey), wp.EnvelopeKey)
+	case <-time.After(5 * time.Second):
+		t.Fatal("matching publication not POSTed")
+	}
+	w.End()
+	assert.Nil(t, <-begun)
+	assert.Equal(t, 0, len(posted))
+	assert.Equal(t, uint64(1), f.stats.NPubs())
+	assert.Equal(t, uint64(0), f.stats.NDropped())
+
+	// check done is closed
+	select {
+	case <-f.done:
+	default:
+		t.Fatal("done not closed")
+	}
+
+	// check End is idempotent
+	w.End()
+}
+
+func TestWebhook_Begin_postErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	errServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer errServer.Close()
+
+	params := NewDefaultWebhookParameters()
+	params.Endpoints = []string{errServer.URL, "http://localhost:0/unreachable"}
+	f := &fixedFrom{new: make(chan *KeyedPub, 2), done: make(chan struct{})}
+	w, err := NewWebhook(params, clogging.NewDevInfoLogger(), f, ecid.NewPseudoRandom(rng).ID())
+	assert.Nil(t, err)
+
+	// check failed POSTs count as dropped and Begin returns once the fanout ends
+	f.new <- newKeyedPub(t, api.NewTestPublication(rng))
+	close(f.new)
+	assert.Nil(t, w.Begin())
+	assert.Equal(t, uint64(0), f.stats.NPubs())
+	assert.Equal(t, uint64(1), f.stats.NDropped())
+}
+
+func TestWebhook_Begin_newErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultWebhookParameters()
+	params.Endpoints = []string{"http://localhost:0/unused"}
+	f := &fixedFrom{err: errors.New("some New error")}
+	w, err := NewWebhook(params, clogging.NewDevInfoLogger(), f, ecid.NewPseudoRandom(rng).ID())
+	assert.Nil(t, err)
+
+	assert.Equal(t, f.err, w.Begin())
+}
+
+func TestNewWebhookPublication(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	value := api.NewTestPublication(rng)
+	pub := newKeyedPub(t, value)
+
+	wp := NewWebhookPublication(pub)
+	assert.Equal(t, pub.Key.String(), wp.Key)
+	assert.Equal(t, hex.EncodeToString(value.EntryKey), wp.EntryKey)
+	assert.Equal(t, hex.EncodeToString(value.AuthorPublicKey), wp.AuthorPublicKey)
+	assert.Equal(t, hex.EncodeToString(value.ReaderPublicKey), wp.ReaderPublicKey)
+	assert.Empty(t, wp.MediaType)
+	assert.Zero(t, wp.OriginTime)
+
+	value.EntryAttributes = &api.EntryAttributes{MediaType: "image/png", UncompressedSize: 100}
+	pub.Sequence, pub.OriginTime = 3, time.Unix(0, 5)
+	wp = NewWebhookPublication(pub)
+	assert.Equal(t, "image/png", wp.MediaType)
+	assert.Equal(t, uint64(100), wp.UncompressedSize)
+	assert.Equal(t, uint64(3), wp.Sequence)
+	assert.Equal(t, int64(5), wp.OriginTime)
+}
+
+type fixedFrom struct {
+	new   chan *KeyedPub
+	done  chan struct{}
+	err   error
+	stats *Stats
+}
+
+func (f *fixedFrom) Fanout() {}
+
+func (f *fixedFrom) New(stats *Stats) (chan *KeyedPub, chan struct{}, error) {
+	f.stats = stats
+	return f.new, f.done, f.err
+}
+
+func (f *fixedFrom) Stats() ([]*Stats, Totals) {
+	return nil, Totals{}
+}
@@ -0,0 +1,106 @@
+package subscribe
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// ErrAcksEnded indicates when a subscriber stops acknowledging publications while the window of
+// unacknowledged publications is full.
+var ErrAcksEnded = errors.New("subscriber stopped acknowledging publications")
+
+// Window limits the number of publications sent to a subscriber that it hasn't yet acknowledged.
+type Window interface {
+	// Wait blocks until the window has room for another publication to be sent, or returns an
+	// error if the subscriber stops acknowledging publications or the context is done first.
+	Wait(ctx context.Context) error
+
+	// Sent records that a publication has been sent to the subscriber.
+	Sent()
+
+	// Open returns whether the window has room for another publication to be sent without
+	// waiting.
+	Open() bool
+
+	// Ack records that the subscriber has received the given total number of publications.
+	Ack(nAcked uint64)
+
+	// EndAcks indicates that the subscriber will send no more acknowledgements.
+	EndAcks()
+}
+
+type window struct {
+	size   uint64
+	nSent  uint64
+	nAcked uint64
+	acked  chan struct{}
+	ended  chan struct{}
+	mu     sync.Mutex
+}
+
+// NewWindow creates a new Window with the given size. A zero size never limits sending.
+func NewWindow(size uint32) Window {
+	return &window{
+		size:  uint64(size),
+		acked: make(chan struct{}, 1),
+		ended: make(chan struct{}),
+	}
+}
+
+func (w *window) Wait(ctx context.Context) error {
+	if w.size == 0 {
+		return nil
+	}
+	for {
+		if w.Open() {
+			return nil
+		}
+		select {
+		case <-w.acked:
+		case <-w.ended:
+			if w.Open() {
+				return nil
+			}
+			return ErrAcksEnded
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (w *window) Sent() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nSent++
+}
+
+func (w *window) Ack(nAcked uint64) {
+	w.mu.Lock()
+	if nAcked > w.nAcked && nAcked <= w.nSent {
+		w.nAcked = nAcked
+	}
+	w.mu.Unlock()
+	select {
+	case w.acked <- struct{}{}:
+	default: // already signaled
+	}
+}
+
+func (w *window) EndAcks() {
+	select {
+	case <-w.ended: // already closed
+	default:
+		close(w.ended)
+	}
+}
+
+func (w *window) Open() bool {
+	if w.size == 0 {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nSent-w.nAcked < w.size
+}
@@ -0,0 +1,59 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestWindow_Wait_disabled(t *testing.T) {
+	w := NewWindow(0)
+	for c := 0; c < 4; c++ {
+		assert.Nil(t, w.Wait(context.Background()))
+		w.Sent()
+	}
+	assert.True(t, w.Open())
+}
+
+func TestWindow_Wait_acked(t *testing.T) {
+	w := NewWindow(2)
+	for c := 0; c < 2; c++ {
+		assert.Nil(t, w.Wait(context.Background()))
+		w.Sent()
+	}
+
+	// window is full until ack is received
+	assert.False(t, w.Open())
+	waited := make(chan error)
+	go func() { waited <- w.Wait(context.Background()) }()
+	select {
+	case <-waited:
+		assert.Fail(t, "unexpected Wait() return with full window")
+	case <-time.After(10 * time.Millisecond):
+	}
+	w.Ack(1)
+	assert.True(t, w.Open())
+	assert.Nil(t, <-waited)
+	w.Sent()
+
+	// acks for more than were sent are ignored
+	w.Ack(10)
+	w.(*window).mu.Lock()
+	assert.Equal(t, uint64(1), w.(*window).nAcked)
+	w.(*window).mu.Unlock()
+
+	// ending acks with full window errors
+	w.EndAcks()
+	w.EndAcks() // ok to end twice
+	assert.Equal(t, ErrAcksEnded, w.Wait(context.Background()))
+}
+
+func TestWindow_Wait_ctxDone(t *testing.T) {
+	w := NewWindow(1)
+	w.Sent()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, w.Wait(ctx))
+}
@@ -20,6 +20,19 @@ type ClientBalancer interface {
 	CloseAll() error
 }
 
+// ExclusionClientBalancer is a ClientBalancer that can exclude librarians, e.g., those known to be
+// unhealthy, from being selected.
+type ExclusionClientBalancer interface {
+	ClientBalancer
+
+	// Exclude excludes the librarian with the given address from being selected by Next, unless
+	// all librarians are excluded.
+	Exclude(addr string)
+
+	// Include reverts the exclusion of the librarian with the given address.
+	Include(addr string)
+}
+
 // ClientSetBalancer load balances between librarian clients, ensuring that a new librarian is
 // always returned.
 type ClientSetBalancer interface {
@@ -27,19 +40,23 @@ type ClientSetBalancer interface {
 	// its peer ID.
 	AddNext() (LibrarianClient, id.ID, error)
 
+	// Add adds the librarian with the given peer ID to the set and returns it.
+	Add(peerID id.ID) (LibrarianClient, error)
+
 	// Remove removes the librarian from the set.
 	Remove(peerID id.ID) error
 }
 
 type uniformRandBalancer struct {
-	rng   *rand.Rand
-	mu    sync.Mutex
-	conns []Connector
+	rng      *rand.Rand
+	mu       sync.Mutex
+	conns    []Connector
+	excluded map[string]struct{}
 }
 
-// NewUniformRandomClientBalancer creates a new ClientBalancer that selects the next client
-// uniformly at random.
-func NewUniformRandomClientBalancer(libAddrs []*net.TCPAddr) (ClientBalancer, error) {
+// NewUniformRandomClientBalancer creates a new ExclusionClientBalancer that selects the next
+// client uniformly at random from those not excluded.
+func NewUniformRandomClientBalancer(libAddrs []*net.TCPAddr) (ExclusionClientBalancer, error) {
 	conns := make([]Connector, len(libAddrs))
 	if libAddrs == nil || len(libAddrs) == 0 {
 		return nil, ErrEmptyLibrarianAddresses
@@ -48,17 +65,40 @@ func NewUniformRandomClientBalancer(libAddrs []*net.TCPAddr) (ClientBalancer, er
 		conns[i] = NewConnector(la)
 	}
 	return &uniformRandBalancer{
-		rng:   rand.New(rand.NewSource(int64(len(conns)))),
-		conns: conns,
+		rng:      rand.New(rand.NewSource(int64(len(conns)))),
+		conns:    conns,
+		excluded: make(map[string]struct{}),
 	}, nil
 }
 
-// Next selects the next librarian client uniformly at random.
+// Next selects the next librarian client uniformly at random from those not excluded, or from all
+// of them if all are excluded.
 func (b *uniformRandBalancer) Next() (LibrarianClient, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	i := b.rng.Int31n(int32(len(b.conns)))
-	return b.conns[i].Connect()
+	included := make([]Connector, 0, len(b.conns))
+	for _, conn := range b.conns {
+		if _, in := b.excluded[conn.Address().String()]; !in {
+			included = append(included, conn)
+		}
+	}
+	if len(included) == 0 {
+		included = b.conns
+	}
+	i := b.rng.Int31n(int32(len(included)))
+	return included[i].Connect()
+}
+
+func (b *uniformRandBalancer) Exclude(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.excluded[addr] = struct{}{}
+}
+
+func (b *uniformRandBalancer) Include(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.excluded, addr)
 }
 
 func (b *uniformRandBalancer) CloseAll() error {
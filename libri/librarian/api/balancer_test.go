@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestNewUniformRandomClientBalancer_err(t *testing.T) {
+	b, err := NewUniformRandomClientBalancer(nil)
+	assert.Equal(t, ErrEmptyLibrarianAddresses, err)
+	assert.Nil(t, b)
+}
+
+func TestUniformRandBalancer_Next(t *testing.T) {
+	addrs := []*net.TCPAddr{
+		{IP: net.ParseIP("127.0.0.1"), Port: 20100},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20101},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20102},
+	}
+	b, err := NewUniformRandomClientBalancer(addrs)
+	assert.Nil(t, err)
+	clients := make(map[LibrarianClient]string)
+	for _, conn := range b.(*uniformRandBalancer).conns {
+		conn.(*connector).dialer = &fixedDialer{clientConn: &grpc.ClientConn{}}
+		lc, err := conn.Connect()
+		assert.Nil(t, err)
+		clients[lc] = conn.Address().String()
+	}
+
+	// check all librarians are selected
+	selected := make(map[string]struct{})
+	for c := 0; c < 64; c++ {
+		lc, err := b.Next()
+		assert.Nil(t, err)
+		selected[clients[lc]] = struct{}{}
+	}
+	assert.Len(t, selected, len(addrs))
+
+	// check excluded librarians aren't selected
+	b.Exclude(addrs[0].String())
+	b.Exclude(addrs[1].String())
+	for c := 0; c < 16; c++ {
+		lc, err := b.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, addrs[2].String(), clients[lc])
+	}
+
+	// check all librarians are selected when all are excluded
+	b.Exclude(addrs[2].String())
+	selected = make(map[string]struct{})
+	for c := 0; c < 64; c++ {
+		lc, err := b.Next()
+		assert.Nil(t, err)
+		selected[clients[lc]] = struct{}{}
+	}
+	assert.Len(t, selected, len(addrs))
+
+	// check included librarian is selected again
+	b.Include(addrs[0].String())
+	for c := 0; c < 16; c++ {
+		lc, err := b.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, addrs[0].String(), clients[lc])
+	}
+}
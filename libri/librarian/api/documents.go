@@ -57,8 +57,12 @@ var (
 	ErrUnexpectedKey = errors.New("unexpected key for value")
 )
 
-// GetKey calculates the key from the has of the proto.Message.
+// GetKey calculates the key from the has of the proto.Message. Tombstone documents instead have
+// the key of the document they delete.
 func GetKey(value proto.Message) (cid.ID, error) {
+	if doc, ok := value.(*Document); ok && doc.GetTombstone() != nil {
+		return cid.FromBytes(doc.GetTombstone().DocumentKey), nil
+	}
 	valueBytes, err := proto.Marshal(value)
 	if err != nil {
 		return nil, err
@@ -76,6 +80,8 @@ func GetAuthorPub(d *Document) []byte {
 		return c.Page.AuthorPublicKey
 	case *Document_Envelope:
 		return c.Envelope.AuthorPublicKey
+	case *Document_Tombstone:
+		return c.Tombstone.AuthorPublicKey
 	}
 	panic(ErrUnknownDocumentType)
 }
@@ -128,6 +134,8 @@ func ValidateDocument(d *Document) error {
 		return ValidateEntry(c.Entry)
 	case *Document_Page:
 		return ValidatePage(c.Page)
+	case *Document_Tombstone:
+		return ValidateTombstone(c.Tombstone)
 	}
 	return ErrUnknownDocumentType
 }
@@ -147,7 +155,14 @@ func ValidateEnvelope(e *Envelope) error {
 	if err := ValidateBytes(e.EntryKey, DocumentKeyLength, "EntryKey"); err != nil {
 		return err
 	}
-	return nil
+	if e.EekCiphertext == nil && e.EekCiphertextMac == nil {
+		// entry encryption keys are derived from author and reader keys
+		return nil
+	}
+	if err := ValidateNotEmpty(e.EekCiphertext, "EekCiphertext"); err != nil {
+		return err
+	}
+	return ValidateHMAC256(e.EekCiphertextMac)
 }
 
 // ValidateEntry checks that all fields of an Entry are populated and have the expected byte
@@ -206,6 +221,27 @@ func ValidatePage(p *Page) error {
 	return nil
 }
 
+// ValidateTombstone checks that all fields of a Tombstone are populated and have the expected
+// lengths.
+func ValidateTombstone(t *Tombstone) error {
+	if t == nil {
+		return errors.New("Tombstone may not be nil")
+	}
+	if err := ValidateBytes(t.DocumentKey, DocumentKeyLength, "DocumentKey"); err != nil {
+		return err
+	}
+	if err := ValidatePublicKey(t.AuthorPublicKey); err != nil {
+		return err
+	}
+	if t.CreatedTime == 0 {
+		return errors.New("CreatedTime must be populated")
+	}
+	if t.AuthorSignature == "" {
+		return errors.New("AuthorSignature must be populated")
+	}
+	return nil
+}
+
 // ValidatePageKeys checks that all fields of a PageKeys are populated and have the expected
 // lengths.
 func ValidatePageKeys(pk *PageKeys) error {
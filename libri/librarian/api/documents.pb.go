@@ -12,17 +12,22 @@ It is generated from these files:
 It has these top-level messages:
 	Document
 	Envelope
+	EntryAttributes
 	Entry
 	Metadata
 	PageKeys
 	Page
+	Tombstone
 	RequestMetadata
 	ResponseMetadata
 	PingRequest
 	PingResponse
 	IntroduceRequest
+	KeyRotation
+	SessionCertificate
 	IntroduceResponse
 	FindRequest
+	AccessProof
 	FindResponse
 	PeerAddress
 	StoreRequest
@@ -33,9 +38,26 @@ It has these top-level messages:
 	PutResponse
 	SubscribeRequest
 	SubscribeResponse
+	BatchedPublication
+	SubscriptionStatsRequest
+	SubscriptionStatsResponse
+	SubscriptionStats
+	LatencyHistogram
 	Publication
 	Subscription
+	EntryAttributesFilter
 	BloomFilter
+	RecentPublicationsRequest
+	ResizeRecentPublicationsRequest
+	RecentPublicationsResponse
+	ScanPublicationsRequest
+	ScanPublicationsResponse
+	LoggedPublication
+	ScanAuditLogRequest
+	ScanAuditLogResponse
+	AuditRecord
+	UpdateDenylistRequest
+	UpdateDenylistResponse
 */
 package api
 
@@ -54,12 +76,13 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
-// Document contains either an Envelope, Entry, or Page message.
+// Document contains either an Envelope, Entry, Page, or Tombstone message.
 type Document struct {
 	// Types that are valid to be assigned to Contents:
 	//	*Document_Envelope
 	//	*Document_Entry
 	//	*Document_Page
+	//	*Document_Tombstone
 	Contents isDocument_Contents `protobuf_oneof:"contents"`
 }
 
@@ -81,10 +104,14 @@ type Document_Entry struct {
 type Document_Page struct {
 	Page *Page `protobuf:"bytes,3,opt,name=page,oneof"`
 }
+type Document_Tombstone struct {
+	Tombstone *Tombstone `protobuf:"bytes,4,opt,name=tombstone,oneof"`
+}
 
-func (*Document_Envelope) isDocument_Contents() {}
-func (*Document_Entry) isDocument_Contents()    {}
-func (*Document_Page) isDocument_Contents()     {}
+func (*Document_Envelope) isDocument_Contents()  {}
+func (*Document_Entry) isDocument_Contents()     {}
+func (*Document_Page) isDocument_Contents()      {}
+func (*Document_Tombstone) isDocument_Contents() {}
 
 func (m *Document) GetContents() isDocument_Contents {
 	if m != nil {
@@ -114,12 +141,20 @@ func (m *Document) GetPage() *Page {
 	return nil
 }
 
+func (m *Document) GetTombstone() *Tombstone {
+	if x, ok := m.GetContents().(*Document_Tombstone); ok {
+		return x.Tombstone
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Document) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Document_OneofMarshaler, _Document_OneofUnmarshaler, _Document_OneofSizer, []interface{}{
 		(*Document_Envelope)(nil),
 		(*Document_Entry)(nil),
 		(*Document_Page)(nil),
+		(*Document_Tombstone)(nil),
 	}
 }
 
@@ -142,6 +177,11 @@ func _Document_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Page); err != nil {
 			return err
 		}
+	case *Document_Tombstone:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Tombstone); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Document.Contents has unexpected type %T", x)
@@ -176,6 +216,14 @@ func _Document_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffe
 		err := b.DecodeMessage(msg)
 		m.Contents = &Document_Page{msg}
 		return true, err
+	case 4: // contents.tombstone
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Tombstone)
+		err := b.DecodeMessage(msg)
+		m.Contents = &Document_Tombstone{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -200,6 +248,11 @@ func _Document_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(3<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Document_Tombstone:
+		s := proto.Size(x.Tombstone)
+		n += proto.SizeVarint(4<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -221,6 +274,14 @@ type Envelope struct {
 	AuthorPublicKey []byte `protobuf:"bytes,2,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
 	// ECDH public key of the entry reader/recipient
 	ReaderPublicKey []byte `protobuf:"bytes,3,opt,name=reader_public_key,json=readerPublicKey,proto3" json:"reader_public_key,omitempty"`
+	// optional plaintext attributes of the entry, disclosed by the author so subscribers can
+	// filter publications on them
+	EntryAttributes *EntryAttributes `protobuf:"bytes,4,opt,name=entry_attributes,json=entryAttributes" json:"entry_attributes,omitempty"`
+	// optional entry encryption keys encrypted with the keys derived from the author and reader
+	// ECDH shared secret, which are otherwise the entry encryption keys themselves
+	EekCiphertext []byte `protobuf:"bytes,5,opt,name=eek_ciphertext,json=eekCiphertext,proto3" json:"eek_ciphertext,omitempty"`
+	// optional 32-byte MAC of the encrypted entry encryption keys
+	EekCiphertextMac []byte `protobuf:"bytes,6,opt,name=eek_ciphertext_mac,json=eekCiphertextMac,proto3" json:"eek_ciphertext_mac,omitempty"`
 }
 
 func (m *Envelope) Reset()                    { *m = Envelope{} }
@@ -249,6 +310,55 @@ func (m *Envelope) GetReaderPublicKey() []byte {
 	return nil
 }
 
+func (m *Envelope) GetEntryAttributes() *EntryAttributes {
+	if m != nil {
+		return m.EntryAttributes
+	}
+	return nil
+}
+
+func (m *Envelope) GetEekCiphertext() []byte {
+	if m != nil {
+		return m.EekCiphertext
+	}
+	return nil
+}
+
+func (m *Envelope) GetEekCiphertextMac() []byte {
+	if m != nil {
+		return m.EekCiphertextMac
+	}
+	return nil
+}
+
+// EntryAttributes are plaintext attributes of an Entry, which its author may choose to disclose
+// (unencrypted) in the Envelopes for it.
+type EntryAttributes struct {
+	// media type of the entry contents
+	MediaType string `protobuf:"bytes,1,opt,name=media_type,json=mediaType" json:"media_type,omitempty"`
+	// total size of the entire uncompressed entry
+	UncompressedSize uint64 `protobuf:"varint,2,opt,name=uncompressed_size,json=uncompressedSize" json:"uncompressed_size,omitempty"`
+}
+
+func (m *EntryAttributes) Reset()                    { *m = EntryAttributes{} }
+func (m *EntryAttributes) String() string            { return proto.CompactTextString(m) }
+func (*EntryAttributes) ProtoMessage()               {}
+func (*EntryAttributes) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *EntryAttributes) GetMediaType() string {
+	if m != nil {
+		return m.MediaType
+	}
+	return ""
+}
+
+func (m *EntryAttributes) GetUncompressedSize() uint64 {
+	if m != nil {
+		return m.UncompressedSize
+	}
+	return 0
+}
+
 // Entry is the main unit of storage in the Libri network.
 type Entry struct {
 	// ECDSA public key of the entry author
@@ -266,12 +376,14 @@ type Entry struct {
 	// 32-byte MAC of metatadata ciphertext, encrypted with the 32-byte Entry AES-256 key and
 	// 12-byte metadata block cipher IV
 	MetadataCiphertextMac []byte `protobuf:"bytes,6,opt,name=metadata_ciphertext_mac,json=metadataCiphertextMac,proto3" json:"metadata_ciphertext_mac,omitempty"`
+	// signature of the entry, without this field, by the author's private key
+	AuthorSignature string `protobuf:"bytes,7,opt,name=author_signature,json=authorSignature" json:"author_signature,omitempty"`
 }
 
 func (m *Entry) Reset()                    { *m = Entry{} }
 func (m *Entry) String() string            { return proto.CompactTextString(m) }
 func (*Entry) ProtoMessage()               {}
-func (*Entry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*Entry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 type isEntry_Contents interface {
 	isEntry_Contents()
@@ -336,6 +448,13 @@ func (m *Entry) GetMetadataCiphertextMac() []byte {
 	return nil
 }
 
+func (m *Entry) GetAuthorSignature() string {
+	if m != nil {
+		return m.AuthorSignature
+	}
+	return ""
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Entry) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Entry_OneofMarshaler, _Entry_OneofUnmarshaler, _Entry_OneofSizer, []interface{}{
@@ -418,7 +537,7 @@ type Metadata struct {
 func (m *Metadata) Reset()                    { *m = Metadata{} }
 func (m *Metadata) String() string            { return proto.CompactTextString(m) }
 func (*Metadata) ProtoMessage()               {}
-func (*Metadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*Metadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
 func (m *Metadata) GetProperties() map[string][]byte {
 	if m != nil {
@@ -435,7 +554,7 @@ type PageKeys struct {
 func (m *PageKeys) Reset()                    { *m = PageKeys{} }
 func (m *PageKeys) String() string            { return proto.CompactTextString(m) }
 func (*PageKeys) ProtoMessage()               {}
-func (*PageKeys) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*PageKeys) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *PageKeys) GetKeys() [][]byte {
 	if m != nil {
@@ -460,7 +579,7 @@ type Page struct {
 func (m *Page) Reset()                    { *m = Page{} }
 func (m *Page) String() string            { return proto.CompactTextString(m) }
 func (*Page) ProtoMessage()               {}
-func (*Page) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*Page) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *Page) GetAuthorPublicKey() []byte {
 	if m != nil {
@@ -490,47 +609,106 @@ func (m *Page) GetCiphertextMac() []byte {
 	return nil
 }
 
+// Tombstone marks a document as deleted by its author. It is stored under the key of the document
+// it deletes, replacing any replicas of that document and preventing it from being stored again.
+type Tombstone struct {
+	// 32-byte key of the deleted document
+	DocumentKey []byte `protobuf:"bytes,1,opt,name=document_key,json=documentKey,proto3" json:"document_key,omitempty"`
+	// ECDSA public key of the deleted document's author
+	AuthorPublicKey []byte `protobuf:"bytes,2,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
+	// created epoch time (seconds since 1970-01-01)
+	CreatedTime int64 `protobuf:"varint,3,opt,name=created_time,json=createdTime" json:"created_time,omitempty"`
+	// signature of the tombstone, without this field, by the author's private key
+	AuthorSignature string `protobuf:"bytes,4,opt,name=author_signature,json=authorSignature" json:"author_signature,omitempty"`
+}
+
+func (m *Tombstone) Reset()                    { *m = Tombstone{} }
+func (m *Tombstone) String() string            { return proto.CompactTextString(m) }
+func (*Tombstone) ProtoMessage()               {}
+func (*Tombstone) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Tombstone) GetDocumentKey() []byte {
+	if m != nil {
+		return m.DocumentKey
+	}
+	return nil
+}
+
+func (m *Tombstone) GetAuthorPublicKey() []byte {
+	if m != nil {
+		return m.AuthorPublicKey
+	}
+	return nil
+}
+
+func (m *Tombstone) GetCreatedTime() int64 {
+	if m != nil {
+		return m.CreatedTime
+	}
+	return 0
+}
+
+func (m *Tombstone) GetAuthorSignature() string {
+	if m != nil {
+		return m.AuthorSignature
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Document)(nil), "api.Document")
 	proto.RegisterType((*Envelope)(nil), "api.Envelope")
+	proto.RegisterType((*EntryAttributes)(nil), "api.EntryAttributes")
 	proto.RegisterType((*Entry)(nil), "api.Entry")
 	proto.RegisterType((*Metadata)(nil), "api.Metadata")
 	proto.RegisterType((*PageKeys)(nil), "api.PageKeys")
 	proto.RegisterType((*Page)(nil), "api.Page")
+	proto.RegisterType((*Tombstone)(nil), "api.Tombstone")
 }
 
 func init() { proto.RegisterFile("libri/librarian/api/documents.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 467 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x93, 0xcb, 0x8b, 0x13, 0x41,
-	0x10, 0xc6, 0x77, 0x5e, 0xcb, 0xa4, 0x92, 0xb8, 0x5a, 0xae, 0x38, 0x28, 0xae, 0x71, 0x44, 0x08,
-	0x2a, 0x09, 0xac, 0x20, 0x22, 0xec, 0xc5, 0x07, 0x2c, 0x2c, 0x0b, 0x61, 0xf0, 0x3e, 0x74, 0x66,
-	0x8a, 0xdd, 0xc6, 0xcc, 0x4c, 0xd3, 0xe9, 0x2c, 0x3b, 0x27, 0xaf, 0xde, 0xbc, 0xfb, 0xd7, 0x4a,
-	0xd7, 0x3c, 0xc8, 0xc6, 0x78, 0xf0, 0x92, 0x74, 0xd7, 0xf7, 0xab, 0xae, 0xaa, 0xaf, 0x7b, 0xe0,
-	0xe5, 0x4a, 0x2e, 0xb5, 0x9c, 0xdb, 0x5f, 0xa1, 0xa5, 0x28, 0xe7, 0x42, 0xc9, 0x79, 0x5e, 0x65,
-	0x9b, 0x82, 0x4a, 0xb3, 0x9e, 0x29, 0x5d, 0x99, 0x0a, 0x3d, 0xa1, 0x64, 0xfc, 0xd3, 0x81, 0xf0,
-	0x4b, 0x2b, 0xe0, 0x1b, 0x08, 0xa9, 0xbc, 0xa1, 0x55, 0xa5, 0x28, 0x72, 0x26, 0xce, 0x74, 0x78,
-	0x3a, 0x9e, 0x09, 0x25, 0x67, 0x5f, 0xdb, 0xe0, 0xf9, 0x41, 0xd2, 0x03, 0x18, 0x43, 0x40, 0xa5,
-	0xd1, 0x75, 0xe4, 0x32, 0x09, 0x2d, 0x69, 0x74, 0x7d, 0x7e, 0x90, 0x34, 0x12, 0x3e, 0x07, 0x5f,
-	0x89, 0x2b, 0x8a, 0x3c, 0x46, 0x06, 0x8c, 0x2c, 0xc4, 0x95, 0x3d, 0x88, 0x85, 0x4f, 0x00, 0x61,
-	0x56, 0x95, 0xc6, 0x76, 0x15, 0xff, 0x80, 0xb0, 0x2b, 0x84, 0x4f, 0x61, 0xc0, 0x27, 0xa4, 0xdf,
-	0xa9, 0xe6, 0x56, 0x46, 0xb6, 0xb2, 0xd1, 0xf5, 0x05, 0xd5, 0xf8, 0x1a, 0x1e, 0x88, 0x8d, 0xb9,
-	0xae, 0x74, 0xaa, 0x36, 0xcb, 0x95, 0xcc, 0x18, 0x72, 0x19, 0x3a, 0x6a, 0x84, 0x05, 0xc7, 0x5b,
-	0x56, 0x93, 0xc8, 0xe9, 0x0e, 0xeb, 0x35, 0x6c, 0x23, 0xf4, 0x6c, 0xfc, 0xdb, 0x85, 0x80, 0x07,
-	0xd8, 0x5f, 0xc1, 0xd9, 0x5f, 0xa1, 0x9b, 0xd1, 0xfd, 0xc7, 0x8c, 0xf8, 0x16, 0x06, 0xf6, 0xdf,
-	0x9e, 0xb1, 0x6e, 0x9d, 0x18, 0xf7, 0xd4, 0x05, 0xd5, 0x6b, 0x6b, 0xab, 0x6a, 0xd7, 0xf8, 0x02,
-	0x46, 0x99, 0x26, 0x61, 0x28, 0x4f, 0x8d, 0x2c, 0x28, 0xf2, 0x27, 0xce, 0xd4, 0x4b, 0x86, 0x6d,
-	0xec, 0x9b, 0x2c, 0x08, 0xe7, 0xf0, 0xb0, 0x20, 0x23, 0x72, 0x61, 0x44, 0x9a, 0x49, 0x75, 0x4d,
-	0xda, 0xd0, 0xad, 0x89, 0x02, 0xee, 0x0f, 0x3b, 0xe9, 0x73, 0xaf, 0xe0, 0x7b, 0x78, 0xbc, 0x27,
-	0x21, 0x2d, 0x44, 0x16, 0x1d, 0x72, 0xd2, 0xa3, 0xbf, 0x93, 0x2e, 0x45, 0x76, 0xe7, 0x76, 0xec,
-	0x43, 0xb9, 0x6c, 0x29, 0x3c, 0x03, 0x50, 0xba, 0x52, 0xa4, 0x8d, 0xa4, 0x75, 0xe4, 0x4c, 0xbc,
-	0xe9, 0xf0, 0xf4, 0x19, 0xcf, 0xd4, 0x21, 0xb3, 0x45, 0xaf, 0xb3, 0xa5, 0xc9, 0x56, 0xc2, 0x93,
-	0x33, 0x38, 0xda, 0x91, 0xf1, 0x3e, 0x78, 0x9d, 0xc7, 0x83, 0xc4, 0x2e, 0xf1, 0x18, 0x82, 0x1b,
-	0xb1, 0xda, 0x50, 0x7b, 0xb3, 0xcd, 0xe6, 0xa3, 0xfb, 0xc1, 0x89, 0x4f, 0x20, 0xec, 0xac, 0x43,
-	0x04, 0x9f, 0x7d, 0xb5, 0x3d, 0x8c, 0x12, 0x5e, 0xc7, 0xbf, 0x1c, 0xf0, 0x2d, 0xf0, 0x5f, 0xd7,
-	0x78, 0x0c, 0x81, 0x2c, 0x73, 0xba, 0xe5, 0x72, 0xe3, 0xa4, 0xd9, 0xe0, 0x09, 0xc0, 0x96, 0xc3,
-	0xcd, 0xbb, 0xd9, 0x8a, 0xe0, 0x2b, 0xb8, 0xb7, 0x63, 0xa8, 0xcf, 0xcc, 0x38, 0xdb, 0x36, 0x72,
-	0x79, 0xc8, 0x5f, 0xdc, 0xbb, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x06, 0x51, 0x3f, 0xd7, 0x98,
-	0x03, 0x00, 0x00,
+	// 641 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x94, 0xdf, 0x6a, 0xdb, 0x3e,
+	0x14, 0xc7, 0xeb, 0xd8, 0xe9, 0x2f, 0x3e, 0x69, 0x9b, 0x54, 0xbf, 0x8e, 0x99, 0x8d, 0x76, 0x9d,
+	0xc7, 0xa0, 0x5b, 0x4b, 0x02, 0x1d, 0x8c, 0x31, 0x28, 0x63, 0xff, 0xa0, 0x50, 0x0a, 0xc5, 0xed,
+	0xed, 0x30, 0x8a, 0x7d, 0x68, 0x45, 0x63, 0x5b, 0xc8, 0x72, 0xa9, 0xfb, 0x04, 0xbb, 0xdb, 0xd5,
+	0xde, 0x61, 0x8f, 0xb0, 0x17, 0xd8, 0x7b, 0x0d, 0xc9, 0x72, 0x6a, 0x27, 0xd9, 0x45, 0x6f, 0x12,
+	0xf9, 0x9c, 0x8f, 0xa4, 0xa3, 0xef, 0xf9, 0x4a, 0xf0, 0x62, 0xca, 0x26, 0x82, 0x8d, 0xd5, 0x2f,
+	0x15, 0x8c, 0xa6, 0x63, 0xca, 0xd9, 0x38, 0xce, 0xa2, 0x22, 0xc1, 0x54, 0xe6, 0x23, 0x2e, 0x32,
+	0x99, 0x11, 0x9b, 0x72, 0xe6, 0xff, 0xb6, 0xa0, 0xf7, 0xc5, 0x24, 0xc8, 0x3e, 0xf4, 0x30, 0xbd,
+	0xc1, 0x69, 0xc6, 0xd1, 0xb3, 0x76, 0xad, 0xbd, 0xfe, 0xe1, 0xfa, 0x88, 0x72, 0x36, 0xfa, 0x6a,
+	0x82, 0xc7, 0x2b, 0xc1, 0x0c, 0x20, 0x3e, 0x74, 0x31, 0x95, 0xa2, 0xf4, 0x3a, 0x9a, 0x04, 0x43,
+	0x4a, 0x51, 0x1e, 0xaf, 0x04, 0x55, 0x8a, 0x3c, 0x03, 0x87, 0xd3, 0x4b, 0xf4, 0x6c, 0x8d, 0xb8,
+	0x1a, 0x39, 0xa3, 0x97, 0x6a, 0x21, 0x9d, 0x20, 0x23, 0x70, 0x65, 0x96, 0x4c, 0x72, 0x99, 0xa5,
+	0xe8, 0x39, 0x9a, 0xda, 0xd0, 0xd4, 0x45, 0x1d, 0x3d, 0x5e, 0x09, 0xee, 0x91, 0x4f, 0x00, 0xbd,
+	0x28, 0x4b, 0xa5, 0x3a, 0x85, 0xff, 0xb3, 0x03, 0xbd, 0xba, 0x32, 0xf2, 0x14, 0x5c, 0xbd, 0x65,
+	0x78, 0x8d, 0xa5, 0xae, 0x7d, 0x4d, 0x95, 0x2a, 0x45, 0x79, 0x82, 0x25, 0x79, 0x0d, 0x9b, 0xb4,
+	0x90, 0x57, 0x99, 0x08, 0x79, 0x31, 0x99, 0xb2, 0x48, 0x43, 0x1d, 0x0d, 0x0d, 0xaa, 0xc4, 0x99,
+	0x8e, 0x1b, 0x56, 0x20, 0x8d, 0xb1, 0xc5, 0xda, 0x15, 0x5b, 0x25, 0xee, 0xd9, 0x0f, 0x30, 0xac,
+	0x36, 0xa5, 0x52, 0x0a, 0x36, 0x29, 0x24, 0xe6, 0xe6, 0x10, 0x5b, 0xf7, 0x6a, 0x7c, 0x9c, 0xe5,
+	0x82, 0x01, 0xb6, 0x03, 0xe4, 0x25, 0x6c, 0x20, 0x5e, 0x87, 0x11, 0xe3, 0x57, 0x28, 0x24, 0xde,
+	0x4a, 0xaf, 0xab, 0x77, 0x5a, 0x47, 0xbc, 0xfe, 0x3c, 0x0b, 0x92, 0x03, 0x20, 0x6d, 0x2c, 0x4c,
+	0x68, 0xe4, 0xad, 0x6a, 0x74, 0xd8, 0x42, 0x4f, 0x69, 0xe4, 0x7f, 0x83, 0xc1, 0xdc, 0xc6, 0x64,
+	0x1b, 0x20, 0xc1, 0x98, 0xd1, 0x50, 0x96, 0xa6, 0xb5, 0x6e, 0xe0, 0xea, 0xc8, 0x45, 0xc9, 0x91,
+	0xec, 0xc3, 0x66, 0x91, 0x46, 0x59, 0xc2, 0x05, 0xe6, 0x39, 0xc6, 0x61, 0xce, 0xee, 0x50, 0xeb,
+	0xe3, 0x04, 0xc3, 0x66, 0xe2, 0x9c, 0xdd, 0xa1, 0xff, 0xa7, 0x03, 0x5d, 0xbd, 0xfe, 0x72, 0x59,
+	0xad, 0xe5, 0xb2, 0xd6, 0x4e, 0xe8, 0xfc, 0xcb, 0x09, 0x07, 0xe0, 0xaa, 0x7f, 0xb5, 0x46, 0xee,
+	0xd9, 0x0d, 0xf3, 0x29, 0xea, 0x04, 0xcb, 0x5c, 0x99, 0x8f, 0x9b, 0x31, 0x79, 0x0e, 0x6b, 0x91,
+	0x40, 0x2a, 0x31, 0x0e, 0x25, 0x4b, 0x2a, 0xeb, 0xd8, 0x41, 0xdf, 0xc4, 0x2e, 0x58, 0x82, 0x64,
+	0x0c, 0xff, 0x27, 0x28, 0x69, 0x4c, 0x25, 0x5d, 0x14, 0x98, 0xd4, 0xa9, 0x86, 0xca, 0x6f, 0xe1,
+	0xf1, 0x92, 0x09, 0x0d, 0xa9, 0x1f, 0x2d, 0x4e, 0x3a, 0xa5, 0x11, 0x79, 0x05, 0x43, 0x23, 0x43,
+	0xce, 0x2e, 0x53, 0x2a, 0x0b, 0x81, 0xde, 0x7f, 0x5a, 0x62, 0xa3, 0xc2, 0x79, 0x1d, 0x6e, 0xd9,
+	0xf7, 0xbb, 0x05, 0xbd, 0x53, 0xb3, 0x20, 0x39, 0x02, 0xe0, 0x22, 0xe3, 0x28, 0x24, 0xc3, 0xdc,
+	0xb3, 0x76, 0xed, 0xbd, 0xfe, 0xe1, 0xb6, 0x3e, 0x7e, 0x8d, 0x8c, 0xce, 0x66, 0x79, 0xad, 0x7e,
+	0xd0, 0x98, 0xf0, 0xe4, 0x08, 0x06, 0x73, 0x69, 0x32, 0x04, 0xbb, 0x6e, 0x87, 0x1b, 0xa8, 0x21,
+	0xd9, 0x82, 0xee, 0x0d, 0x9d, 0x16, 0x68, 0x9c, 0x5f, 0x7d, 0xbc, 0xef, 0xbc, 0xb3, 0xfc, 0x1d,
+	0xe8, 0xd5, 0x2a, 0x13, 0x02, 0x8e, 0x6e, 0x81, 0xaa, 0x61, 0x2d, 0xd0, 0x63, 0xff, 0x87, 0x05,
+	0x8e, 0x02, 0x1e, 0xd4, 0xf1, 0x2d, 0xe8, 0xb2, 0x34, 0xc6, 0x5b, 0xbd, 0xdd, 0x7a, 0x50, 0x7d,
+	0x90, 0x1d, 0x80, 0x46, 0x33, 0xaa, 0x7b, 0xd5, 0x88, 0xa8, 0x1b, 0x31, 0xa7, 0xbd, 0x53, 0xdd,
+	0x88, 0xa8, 0xe5, 0xf1, 0x5f, 0x16, 0xb8, 0xb3, 0x27, 0x42, 0xb9, 0xa1, 0x7e, 0xdc, 0x1a, 0x15,
+	0xf5, 0xeb, 0xd8, 0x43, 0x9f, 0x80, 0x79, 0x73, 0xd9, 0x8b, 0xe6, 0x5a, 0xd6, 0x73, 0x67, 0x69,
+	0xcf, 0x27, 0xab, 0xfa, 0xb5, 0x7d, 0xf3, 0x77, 0x00, 0x19, 0x27, 0xc1, 0x53, 0x94, 0x05, 0x00,
+	0x00,
 }
@@ -15,6 +15,12 @@ func TestGetKey(t *testing.T) {
 	key, err := GetKey(value)
 	assert.Nil(t, err)
 	assert.Nil(t, ValidateBytes(key.Bytes(), DocumentKeyLength, "key"))
+
+	// check tombstone has key of the document it deletes
+	tombstone := NewTestTombstone(rng)
+	key, err = GetKey(&Document{&Document_Tombstone{Tombstone: tombstone}})
+	assert.Nil(t, err)
+	assert.Equal(t, tombstone.DocumentKey, key.Bytes())
 }
 
 func TestGetAuthorPub(t *testing.T) {
@@ -36,6 +42,10 @@ func TestGetAuthorPub(t *testing.T) {
 	envelope := NewTestEnvelope(rng)
 	envelope.AuthorPublicKey = expected
 	assert.Equal(t, expected, GetAuthorPub(&Document{&Document_Envelope{Envelope: envelope}}))
+
+	tombstone := NewTestTombstone(rng)
+	tombstone.AuthorPublicKey = expected
+	assert.Equal(t, expected, GetAuthorPub(&Document{&Document_Tombstone{Tombstone: tombstone}}))
 }
 
 func TestGetEntryPageKeys_ok(t *testing.T) {
@@ -100,12 +110,19 @@ func TestValidateDocument_ok(t *testing.T) {
 
 	d3 := &Document{&Document_Page{NewTestPage(rng)}}
 	assert.Nil(t, ValidateDocument(d3))
+
+	d4 := &Document{&Document_Tombstone{NewTestTombstone(rng)}}
+	assert.Nil(t, ValidateDocument(d4))
 }
 
 func TestValidateEnvelope_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	e := NewTestEnvelope(rng)
 	assert.Nil(t, ValidateEnvelope(e))
+
+	e.EekCiphertext = RandBytes(rng, 128)
+	e.EekCiphertextMac = RandBytes(rng, 32)
+	assert.Nil(t, ValidateEnvelope(e))
 }
 
 func TestValidateEnvelope_err(t *testing.T) {
@@ -134,6 +151,18 @@ func TestValidateEnvelope_err(t *testing.T) {
 		c(badEnvelope)
 		assert.NotNil(t, ValidateEnvelope(badEnvelope), fmt.Sprintf("case %d", i))
 	}
+
+	// check encrypted entry keys need both ciphertext and MAC
+	eekCases := [][2][]byte{
+		{nil, RandBytes(rng, 32)},
+		{RandBytes(rng, 128), nil},
+		{RandBytes(rng, 128), zeros},
+	}
+	for i, c := range eekCases {
+		badEnvelope := NewTestEnvelope(rng)
+		badEnvelope.EekCiphertext, badEnvelope.EekCiphertextMac = c[0], c[1]
+		assert.NotNil(t, ValidateEnvelope(badEnvelope), fmt.Sprintf("eek case %d", i))
+	}
 }
 
 func TestValidateEntry_ok(t *testing.T) {
@@ -213,6 +242,36 @@ func TestValidatePage_err(t *testing.T) {
 	}
 }
 
+func TestValidateTombstone_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	assert.Nil(t, ValidateTombstone(NewTestTombstone(rng)))
+}
+
+func TestValidateTombstone_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	badLen := RandBytes(rng, 100)
+	empty, zeros := []byte{}, []byte{0, 0, 0}
+
+	// each of the cases takes a valid *Tombstone and changes it in some way to make it invalid
+	cases := []func(t *Tombstone){
+		func(t *Tombstone) { t.DocumentKey = nil },        // 0) can't be nil
+		func(t *Tombstone) { t.DocumentKey = empty },      // 1) can't be zero-length
+		func(t *Tombstone) { t.DocumentKey = zeros },      // 2) can't be all zeros
+		func(t *Tombstone) { t.DocumentKey = badLen },     // 3) length must be 32
+		func(t *Tombstone) { t.AuthorPublicKey = nil },    // 4) can't be nil
+		func(t *Tombstone) { t.AuthorPublicKey = badLen }, // 5) length must be 65
+		func(t *Tombstone) { t.CreatedTime = 0 },          // 6) must be populated
+		func(t *Tombstone) { t.AuthorSignature = "" },     // 7) must be populated
+	}
+
+	assert.NotNil(t, ValidateTombstone(nil))
+	for i, c := range cases {
+		badTombstone := NewTestTombstone(rng)
+		c(badTombstone)
+		assert.NotNil(t, ValidateTombstone(badTombstone), fmt.Sprintf("case %d", i))
+	}
+}
+
 func TestValidatePageKeys_ok(t *testing.T) {
 	pk := &PageKeys{
 		Keys: [][]byte{[]byte{0, 1, 2}, []byte{1, 2, 3}},
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"crypto/tls"
+	"net"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Interceptors are the client interceptors installed on connections to peers, e.g., for tracing
+// or debug logging. Each RPC goes through the interceptors of its type in order.
+type Interceptors struct {
+	// Unary are the interceptors of unary RPCs.
+	Unary []grpc.UnaryClientInterceptor
+
+	// Stream are the interceptors of streaming RPCs.
+	Stream []grpc.StreamClientInterceptor
+}
+
+// DialOptions returns the grpc.DialOptions installing the interceptors, if any.
+func (i *Interceptors) DialOptions() []grpc.DialOption {
+	if i == nil {
+		return nil
+	}
+	opts := make([]grpc.DialOption, 0, 2)
+	if len(i.Unary) > 0 {
+		opts = append(opts, grpc.WithUnaryInterceptor(chainUnaryInterceptors(i.Unary)))
+	}
+	if len(i.Stream) > 0 {
+		opts = append(opts, grpc.WithStreamInterceptor(chainStreamInterceptors(i.Stream)))
+	}
+	return opts
+}
+
+// NewInterceptingConnector creates a Connector instance from an address whose connection uses the
+// interceptors, or just a NewConnector one when there are none.
+func NewInterceptingConnector(address *net.TCPAddr, interceptors *Interceptors) Connector {
+	return NewSecureConnector(address, nil, interceptors)
+}
+
+// DialInsecure dials an insecure connection to the address that uses the interceptors.
+func DialInsecure(addr *net.TCPAddr, interceptors *Interceptors) (*grpc.ClientConn, error) {
+	return Dial(addr, nil, interceptors)
+}
+
+// interceptingDialer dials connections whose requests go through the interceptors, using TLS when
+// its config is non-nil.
+type interceptingDialer struct {
+	tlsConfig    *tls.Config
+	interceptors *Interceptors
+}
+
+func (d *interceptingDialer) Dial(addr *net.TCPAddr) (*grpc.ClientConn, error) {
+	return Dial(addr, d.tlsConfig, d.interceptors)
+}
+
+// chainUnaryInterceptors returns a single interceptor calling each of the given interceptors in
+// order, so the first is the outermost, before the invoker.
+func chainUnaryInterceptors(is []grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		chained := invoker
+		for i := len(is) - 1; i >= 0; i-- {
+			chained = wrapUnaryInvoker(is[i], chained)
+		}
+		return chained(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func wrapUnaryInvoker(i grpc.UnaryClientInterceptor, next grpc.UnaryInvoker) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		return i(ctx, method, req, reply, cc, next, opts...)
+	}
+}
+
+// chainStreamInterceptors returns a single interceptor calling each of the given interceptors in
+// order, so the first is the outermost, before the streamer.
+func chainStreamInterceptors(is []grpc.StreamClientInterceptor) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		chained := streamer
+		for i := len(is) - 1; i >= 0; i-- {
+			chained = wrapStreamer(is[i], chained)
+		}
+		return chained(ctx, desc, cc, method, opts...)
+	}
+}
+
+func wrapStreamer(i grpc.StreamClientInterceptor, next grpc.Streamer) grpc.Streamer {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return i(ctx, desc, cc, method, next, opts...)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestInterceptors_DialOptions(t *testing.T) {
+	var nilInterceptors *Interceptors
+	assert.Nil(t, nilInterceptors.DialOptions())
+	assert.Len(t, (&Interceptors{}).DialOptions(), 0)
+
+	calls := make([]string, 0)
+	interceptors := &Interceptors{
+		Unary:  []grpc.UnaryClientInterceptor{recordingUnary(&calls, "unary")},
+		Stream: []grpc.StreamClientInterceptor{recordingStream(&calls, "stream")},
+	}
+	assert.Len(t, interceptors.DialOptions(), 2)
+}
+
+func TestNewInterceptingConnector(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 20100}
+	assert.Equal(t, NewConnector(addr), NewInterceptingConnector(addr, nil))
+
+	interceptors := &Interceptors{}
+	conn := NewInterceptingConnector(addr, interceptors)
+	assert.Equal(t, addr, conn.Address())
+	assert.Equal(t, interceptors, conn.(*connector).dialer.(*interceptingDialer).interceptors)
+
+	// check dialing doesn't wait for connection
+	cc, err := conn.(*connector).dialer.Dial(addr)
+	assert.Nil(t, err)
+	assert.NotNil(t, cc)
+	assert.Nil(t, cc.Close())
+}
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	calls := make([]string, 0)
+	invoker := func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, "invoker "+method)
+		return nil
+	}
+
+	// check single interceptor
+	chain := chainUnaryInterceptors([]grpc.UnaryClientInterceptor{
+		recordingUnary(&calls, "first"),
+	})
+	err := chain(context.Background(), "/api.Librarian/Get", nil, nil, nil, invoker)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "invoker /api.Librarian/Get"}, calls)
+
+	// check multiple interceptors called in order
+	calls = calls[:0]
+	chain = chainUnaryInterceptors([]grpc.UnaryClientInterceptor{
+		recordingUnary(&calls, "first"),
+		recordingUnary(&calls, "second"),
+		recordingUnary(&calls, "third"),
+	})
+	err = chain(context.Background(), "/api.Librarian/Put", nil, nil, nil, invoker)
+	assert.Nil(t, err)
+	expected := []string{"first", "second", "third", "invoker /api.Librarian/Put"}
+	assert.Equal(t, expected, calls)
+}
+
+func TestChainStreamInterceptors(t *testing.T) {
+	calls := make([]string, 0)
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		calls = append(calls, "streamer "+method)
+		return nil, nil
+	}
+
+	// check single interceptor
+	chain := chainStreamInterceptors([]grpc.StreamClientInterceptor{
+		recordingStream(&calls, "first"),
+	})
+	_, err := chain(context.Background(), nil, nil, "/api.Librarian/Subscribe", streamer)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "streamer /api.Librarian/Subscribe"}, calls)
+
+	// check multiple interceptors called in order
+	calls = calls[:0]
+	chain = chainStreamInterceptors([]grpc.StreamClientInterceptor{
+		recordingStream(&calls, "first"),
+		recordingStream(&calls, "second"),
+	})
+	_, err = chain(context.Background(), nil, nil, "/api.Librarian/Subscribe", streamer)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "second", "streamer /api.Librarian/Subscribe"}, calls)
+}
+
+func recordingUnary(calls *[]string, name string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		*calls = append(*calls, name)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func recordingStream(calls *[]string, name string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		*calls = append(*calls, name)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -54,7 +54,14 @@ type PutterGetter interface {
 
 // Subscriber issues Subscribe queries.
 type Subscriber interface {
-	// Subscribe subscribes to a defined publication stream.
-	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (
-		Librarian_SubscribeClient, error)
+	// Subscribe opens a stream for subscribing to a defined publication stream, defined by the
+	// first request sent on it.
+	Subscribe(ctx context.Context, opts ...grpc.CallOption) (Librarian_SubscribeClient, error)
+}
+
+// SubscriptionStatser issues SubscriptionStats queries.
+type SubscriptionStatser interface {
+	// SubscriptionStats returns statistics about the subscriptions to and from other peers.
+	SubscriptionStats(ctx context.Context, in *SubscriptionStatsRequest,
+		opts ...grpc.CallOption) (*SubscriptionStatsResponse, error)
 }
@@ -41,12 +41,38 @@ func (x PutOperation) String() string {
 }
 func (PutOperation) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
 
+type FilterType int32
+
+const (
+	// Bloom filter, whose elements can't be removed
+	FilterType_BLOOM FilterType = 0
+	// cuckoo filter, whose elements can be removed
+	FilterType_CUCKOO FilterType = 1
+)
+
+var FilterType_name = map[int32]string{
+	0: "BLOOM",
+	1: "CUCKOO",
+}
+var FilterType_value = map[string]int32{
+	"BLOOM":  0,
+	"CUCKOO": 1,
+}
+
+func (x FilterType) String() string {
+	return proto.EnumName(FilterType_name, int32(x))
+}
+func (FilterType) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+
 // RequestMetadata defines metadata associated with every request.
 type RequestMetadata struct {
 	// 32-byte unique request ID
 	RequestId []byte `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	// peer's ECDSA public key
 	PubKey []byte `protobuf:"bytes,2,opt,name=pub_key,json=pubKey,proto3" json:"pub_key,omitempty"`
+	// nonce whose hash with the request key has enough leading zero bits, for requests (currently
+	// just Store) on networks requiring proof of work
+	ProofOfWorkNonce uint64 `protobuf:"varint,3,opt,name=proof_of_work_nonce,json=proofOfWorkNonce" json:"proof_of_work_nonce,omitempty"`
 }
 
 func (m *RequestMetadata) Reset()                    { *m = RequestMetadata{} }
@@ -68,6 +94,13 @@ func (m *RequestMetadata) GetPubKey() []byte {
 	return nil
 }
 
+func (m *RequestMetadata) GetProofOfWorkNonce() uint64 {
+	if m != nil {
+		return m.ProofOfWorkNonce
+	}
+	return 0
+}
+
 type ResponseMetadata struct {
 	// 32-byte request ID that generated this response
 	RequestId []byte `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
@@ -124,6 +157,8 @@ type IntroduceRequest struct {
 	Self *PeerAddress `protobuf:"bytes,2,opt,name=self" json:"self,omitempty"`
 	// number of peer librarians to request info for
 	NumPeers uint32 `protobuf:"varint,3,opt,name=num_peers,json=numPeers" json:"num_peers,omitempty"`
+	// rotation linking the peer's public key to its previous one, if it has rotated keys
+	Rotation *KeyRotation `protobuf:"bytes,4,opt,name=rotation" json:"rotation,omitempty"`
 }
 
 func (m *IntroduceRequest) Reset()                    { *m = IntroduceRequest{} }
@@ -152,6 +187,97 @@ func (m *IntroduceRequest) GetNumPeers() uint32 {
 	return 0
 }
 
+func (m *IntroduceRequest) GetRotation() *KeyRotation {
+	if m != nil {
+		return m.Rotation
+	}
+	return nil
+}
+
+// KeyRotation links a peer's new ECDSA public key to its old one, so other peers can update its
+// routing table entry rather than treating it as a new peer.
+type KeyRotation struct {
+	// peer's old ECDSA public key
+	OldPubKey []byte `protobuf:"bytes,1,opt,name=old_pub_key,json=oldPubKey,proto3" json:"old_pub_key,omitempty"`
+	// peer's new ECDSA public key
+	NewPubKey []byte `protobuf:"bytes,2,opt,name=new_pub_key,json=newPubKey,proto3" json:"new_pub_key,omitempty"`
+	// signature by the old key of the rotation with an empty signature
+	Signature string `protobuf:"bytes,3,opt,name=signature" json:"signature,omitempty"`
+}
+
+func (m *KeyRotation) Reset()                    { *m = KeyRotation{} }
+func (m *KeyRotation) String() string            { return proto.CompactTextString(m) }
+func (*KeyRotation) ProtoMessage()               {}
+func (*KeyRotation) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+
+func (m *KeyRotation) GetOldPubKey() []byte {
+	if m != nil {
+		return m.OldPubKey
+	}
+	return nil
+}
+
+func (m *KeyRotation) GetNewPubKey() []byte {
+	if m != nil {
+		return m.NewPubKey
+	}
+	return nil
+}
+
+func (m *KeyRotation) GetSignature() string {
+	if m != nil {
+		return m.Signature
+	}
+	return ""
+}
+
+// SessionCertificate links a short-lived session ECDSA public key to a peer's long-term identity
+// public key, so the peer can sign requests with the session key while keeping the identity
+// private key offline.
+type SessionCertificate struct {
+	// peer's long-term identity ECDSA public key
+	IdentityPubKey []byte `protobuf:"bytes,1,opt,name=identity_pub_key,json=identityPubKey,proto3" json:"identity_pub_key,omitempty"`
+	// peer's session ECDSA public key
+	SessionPubKey []byte `protobuf:"bytes,2,opt,name=session_pub_key,json=sessionPubKey,proto3" json:"session_pub_key,omitempty"`
+	// Unix time after which the session key is no longer valid
+	Expiration int64 `protobuf:"varint,3,opt,name=expiration" json:"expiration,omitempty"`
+	// signature by the identity key of the certificate with an empty signature
+	Signature string `protobuf:"bytes,4,opt,name=signature" json:"signature,omitempty"`
+}
+
+func (m *SessionCertificate) Reset()                    { *m = SessionCertificate{} }
+func (m *SessionCertificate) String() string            { return proto.CompactTextString(m) }
+func (*SessionCertificate) ProtoMessage()               {}
+func (*SessionCertificate) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+
+func (m *SessionCertificate) GetIdentityPubKey() []byte {
+	if m != nil {
+		return m.IdentityPubKey
+	}
+	return nil
+}
+
+func (m *SessionCertificate) GetSessionPubKey() []byte {
+	if m != nil {
+		return m.SessionPubKey
+	}
+	return nil
+}
+
+func (m *SessionCertificate) GetExpiration() int64 {
+	if m != nil {
+		return m.Expiration
+	}
+	return 0
+}
+
+func (m *SessionCertificate) GetSignature() string {
+	if m != nil {
+		return m.Signature
+	}
+	return ""
+}
+
 type IntroduceResponse struct {
 	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	// info about the peer receiving the introduction
@@ -163,7 +289,7 @@ type IntroduceResponse struct {
 func (m *IntroduceResponse) Reset()                    { *m = IntroduceResponse{} }
 func (m *IntroduceResponse) String() string            { return proto.CompactTextString(m) }
 func (*IntroduceResponse) ProtoMessage()               {}
-func (*IntroduceResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+func (*IntroduceResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
 
 func (m *IntroduceResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
@@ -192,12 +318,19 @@ type FindRequest struct {
 	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	// the number of closests peers to return
 	NumPeers uint32 `protobuf:"varint,3,opt,name=num_peers,json=numPeers" json:"num_peers,omitempty"`
+	// whether to return only the closest peers, even if the value for the key is stored
+	PeersOnly bool `protobuf:"varint,4,opt,name=peers_only,json=peersOnly" json:"peers_only,omitempty"`
+	// proof the requester may get the value for the key, if it is private
+	AccessProof *AccessProof `protobuf:"bytes,5,opt,name=access_proof,json=accessProof" json:"access_proof,omitempty"`
+	// whether to return only whether the value for the key is stored rather than the value
+	// itself, which doesn't need an access proof; stored tombstones are still returned
+	PresenceOnly bool `protobuf:"varint,6,opt,name=presence_only,json=presenceOnly" json:"presence_only,omitempty"`
 }
 
 func (m *FindRequest) Reset()                    { *m = FindRequest{} }
 func (m *FindRequest) String() string            { return proto.CompactTextString(m) }
 func (*FindRequest) ProtoMessage()               {}
-func (*FindRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+func (*FindRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
 
 func (m *FindRequest) GetMetadata() *RequestMetadata {
 	if m != nil {
@@ -220,18 +353,78 @@ func (m *FindRequest) GetNumPeers() uint32 {
 	return 0
 }
 
+func (m *FindRequest) GetPeersOnly() bool {
+	if m != nil {
+		return m.PeersOnly
+	}
+	return false
+}
+
+func (m *FindRequest) GetAccessProof() *AccessProof {
+	if m != nil {
+		return m.AccessProof
+	}
+	return nil
+}
+
+func (m *FindRequest) GetPresenceOnly() bool {
+	if m != nil {
+		return m.PresenceOnly
+	}
+	return false
+}
+
+// AccessProof proves that a requester holds the reader key of a private document, whose access
+// hash is stored with it.
+type AccessProof struct {
+	// 32-byte key of the private document
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// ECDSA public key of the reader
+	ReaderPubKey []byte `protobuf:"bytes,2,opt,name=reader_pub_key,json=readerPubKey,proto3" json:"reader_pub_key,omitempty"`
+	// signature by the reader key of the proof with an empty signature
+	Signature string `protobuf:"bytes,3,opt,name=signature" json:"signature,omitempty"`
+}
+
+func (m *AccessProof) Reset()                    { *m = AccessProof{} }
+func (m *AccessProof) String() string            { return proto.CompactTextString(m) }
+func (*AccessProof) ProtoMessage()               {}
+func (*AccessProof) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{9} }
+
+func (m *AccessProof) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *AccessProof) GetReaderPubKey() []byte {
+	if m != nil {
+		return m.ReaderPubKey
+	}
+	return nil
+}
+
+func (m *AccessProof) GetSignature() string {
+	if m != nil {
+		return m.Signature
+	}
+	return ""
+}
+
 type FindResponse struct {
 	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	// list of peers closest to target
 	Peers []*PeerAddress `protobuf:"bytes,2,rep,name=peers" json:"peers,omitempty"`
 	// value, if found
 	Value *Document `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	// whether the (non-tombstone) value is stored, for presence-only requests
+	Stored bool `protobuf:"varint,4,opt,name=stored" json:"stored,omitempty"`
 }
 
 func (m *FindResponse) Reset()                    { *m = FindResponse{} }
 func (m *FindResponse) String() string            { return proto.CompactTextString(m) }
 func (*FindResponse) ProtoMessage()               {}
-func (*FindResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
+func (*FindResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{10} }
 
 func (m *FindResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
@@ -254,6 +447,13 @@ func (m *FindResponse) GetValue() *Document {
 	return nil
 }
 
+func (m *FindResponse) GetStored() bool {
+	if m != nil {
+		return m.Stored
+	}
+	return false
+}
+
 type PeerAddress struct {
 	// 32-byte peer ID
 	PeerId []byte `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
@@ -268,7 +468,7 @@ type PeerAddress struct {
 func (m *PeerAddress) Reset()                    { *m = PeerAddress{} }
 func (m *PeerAddress) String() string            { return proto.CompactTextString(m) }
 func (*PeerAddress) ProtoMessage()               {}
-func (*PeerAddress) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
+func (*PeerAddress) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{11} }
 
 func (m *PeerAddress) GetPeerId() []byte {
 	if m != nil {
@@ -304,12 +504,15 @@ type StoreRequest struct {
 	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	// value to store for key
 	Value *Document `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	// 32-byte SHA-256 hash of the reader public key required to get the value, or empty if the
+	// value is public
+	AccessHash []byte `protobuf:"bytes,4,opt,name=access_hash,json=accessHash,proto3" json:"access_hash,omitempty"`
 }
 
 func (m *StoreRequest) Reset()                    { *m = StoreRequest{} }
 func (m *StoreRequest) String() string            { return proto.CompactTextString(m) }
 func (*StoreRequest) ProtoMessage()               {}
-func (*StoreRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{9} }
+func (*StoreRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{12} }
 
 func (m *StoreRequest) GetMetadata() *RequestMetadata {
 	if m != nil {
@@ -332,6 +535,13 @@ func (m *StoreRequest) GetValue() *Document {
 	return nil
 }
 
+func (m *StoreRequest) GetAccessHash() []byte {
+	if m != nil {
+		return m.AccessHash
+	}
+	return nil
+}
+
 type StoreResponse struct {
 	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 }
@@ -339,7 +549,7 @@ type StoreResponse struct {
 func (m *StoreResponse) Reset()                    { *m = StoreResponse{} }
 func (m *StoreResponse) String() string            { return proto.CompactTextString(m) }
 func (*StoreResponse) ProtoMessage()               {}
-func (*StoreResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{10} }
+func (*StoreResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{13} }
 
 func (m *StoreResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
@@ -352,12 +562,14 @@ type GetRequest struct {
 	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	// 32-byte
 	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// proof the requester may get the value for the key, if it is private
+	AccessProof *AccessProof `protobuf:"bytes,3,opt,name=access_proof,json=accessProof" json:"access_proof,omitempty"`
 }
 
 func (m *GetRequest) Reset()                    { *m = GetRequest{} }
 func (m *GetRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetRequest) ProtoMessage()               {}
-func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{11} }
+func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{14} }
 
 func (m *GetRequest) GetMetadata() *RequestMetadata {
 	if m != nil {
@@ -373,16 +585,29 @@ func (m *GetRequest) GetKey() []byte {
 	return nil
 }
 
+func (m *GetRequest) GetAccessProof() *AccessProof {
+	if m != nil {
+		return m.AccessProof
+	}
+	return nil
+}
+
 type GetResponse struct {
 	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	// value to store for key
 	Value *Document `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+	// whether the search exhausted its peers before finding either the value or the closest peers
+	Exhausted bool `protobuf:"varint,3,opt,name=exhausted" json:"exhausted,omitempty"`
+	// best-effort closest peers found so far when the search was exhausted or timed out
+	ClosestPeers []*PeerAddress `protobuf:"bytes,4,rep,name=closest_peers,json=closestPeers" json:"closest_peers,omitempty"`
+	// whether the search timed out before finding either the value or the closest peers
+	TimedOut bool `protobuf:"varint,5,opt,name=timed_out,json=timedOut" json:"timed_out,omitempty"`
 }
 
 func (m *GetResponse) Reset()                    { *m = GetResponse{} }
 func (m *GetResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetResponse) ProtoMessage()               {}
-func (*GetResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{12} }
+func (*GetResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{15} }
 
 func (m *GetResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
@@ -398,18 +623,45 @@ func (m *GetResponse) GetValue() *Document {
 	return nil
 }
 
+func (m *GetResponse) GetExhausted() bool {
+	if m != nil {
+		return m.Exhausted
+	}
+	return false
+}
+
+func (m *GetResponse) GetClosestPeers() []*PeerAddress {
+	if m != nil {
+		return m.ClosestPeers
+	}
+	return nil
+}
+
+func (m *GetResponse) GetTimedOut() bool {
+	if m != nil {
+		return m.TimedOut
+	}
+	return false
+}
+
 type PutRequest struct {
 	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	// key to store value under
 	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	// value to store for key
 	Value *Document `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	// desired number of replicas to store, bounded by the server's maximum; zero value uses the
+	// server's default
+	NReplicas uint32 `protobuf:"varint,4,opt,name=n_replicas,json=nReplicas" json:"n_replicas,omitempty"`
+	// 32-byte SHA-256 hash of the reader public key required to get the value, or empty if the
+	// value is public
+	AccessHash []byte `protobuf:"bytes,5,opt,name=access_hash,json=accessHash,proto3" json:"access_hash,omitempty"`
 }
 
 func (m *PutRequest) Reset()                    { *m = PutRequest{} }
 func (m *PutRequest) String() string            { return proto.CompactTextString(m) }
 func (*PutRequest) ProtoMessage()               {}
-func (*PutRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{13} }
+func (*PutRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{16} }
 
 func (m *PutRequest) GetMetadata() *RequestMetadata {
 	if m != nil {
@@ -432,6 +684,20 @@ func (m *PutRequest) GetValue() *Document {
 	return nil
 }
 
+func (m *PutRequest) GetNReplicas() uint32 {
+	if m != nil {
+		return m.NReplicas
+	}
+	return 0
+}
+
+func (m *PutRequest) GetAccessHash() []byte {
+	if m != nil {
+		return m.AccessHash
+	}
+	return nil
+}
+
 type PutResponse struct {
 	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	// result of the put operation
@@ -443,7 +709,7 @@ type PutResponse struct {
 func (m *PutResponse) Reset()                    { *m = PutResponse{} }
 func (m *PutResponse) String() string            { return proto.CompactTextString(m) }
 func (*PutResponse) ProtoMessage()               {}
-func (*PutResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{14} }
+func (*PutResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{17} }
 
 func (m *PutResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
@@ -467,14 +733,37 @@ func (m *PutResponse) GetNReplicas() uint32 {
 }
 
 type SubscribeRequest struct {
-	Metadata     *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
-	Subscription *Subscription    `protobuf:"bytes,2,opt,name=subscription" json:"subscription,omitempty"`
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// subscription filters, which replace those of the active subscription when set on requests
+	// after the first
+	Subscription *Subscription `protobuf:"bytes,2,opt,name=subscription" json:"subscription,omitempty"`
+	// if non-zero, sequence number in the librarian's publication log from which to replay
+	// publications before streaming new ones
+	FromSequence uint64 `protobuf:"varint,3,opt,name=from_sequence,json=fromSequence" json:"from_sequence,omitempty"`
+	// if non-zero, maximum number of sent publications not yet acknowledged by the client before
+	// the librarian stops sending more
+	Window uint32 `protobuf:"varint,4,opt,name=window" json:"window,omitempty"`
+	// total number of publications received by the client, set on requests after the first to
+	// acknowledge them
+	NAcked uint64 `protobuf:"varint,5,opt,name=n_acked,json=nAcked" json:"n_acked,omitempty"`
+	// if non-zero, maximum rate at which the librarian sends publications, which may be further
+	// limited by the librarian's own maximum
+	MaxPubsPerSecond float32 `protobuf:"fixed32,6,opt,name=max_pubs_per_second,json=maxPubsPerSecond" json:"max_pubs_per_second,omitempty"`
+	// if greater than one, maximum number of publications the librarian packs into the batch of
+	// each response, which may be further limited by the librarian's own maximum
+	MaxBatchSize uint32 `protobuf:"varint,7,opt,name=max_batch_size,json=maxBatchSize" json:"max_batch_size,omitempty"`
+	// if non-zero, size in bytes at which the librarian sends a batch before it has the maximum
+	// number of publications, which may be further limited by the librarian's own maximum
+	MaxBatchBytes uint32 `protobuf:"varint,8,opt,name=max_batch_bytes,json=maxBatchBytes" json:"max_batch_bytes,omitempty"`
+	// if non-zero, maximum time (in nanoseconds) the librarian waits after the first publication
+	// in a batch before sending it, which may be further limited by the librarian's own maximum
+	MaxBatchDelay int64 `protobuf:"varint,9,opt,name=max_batch_delay,json=maxBatchDelay" json:"max_batch_delay,omitempty"`
 }
 
 func (m *SubscribeRequest) Reset()                    { *m = SubscribeRequest{} }
 func (m *SubscribeRequest) String() string            { return proto.CompactTextString(m) }
 func (*SubscribeRequest) ProtoMessage()               {}
-func (*SubscribeRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{15} }
+func (*SubscribeRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{18} }
 
 func (m *SubscribeRequest) GetMetadata() *RequestMetadata {
 	if m != nil {
@@ -490,16 +779,76 @@ func (m *SubscribeRequest) GetSubscription() *Subscription {
 	return nil
 }
 
+func (m *SubscribeRequest) GetFromSequence() uint64 {
+	if m != nil {
+		return m.FromSequence
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetWindow() uint32 {
+	if m != nil {
+		return m.Window
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetNAcked() uint64 {
+	if m != nil {
+		return m.NAcked
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetMaxPubsPerSecond() float32 {
+	if m != nil {
+		return m.MaxPubsPerSecond
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetMaxBatchSize() uint32 {
+	if m != nil {
+		return m.MaxBatchSize
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetMaxBatchBytes() uint32 {
+	if m != nil {
+		return m.MaxBatchBytes
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetMaxBatchDelay() int64 {
+	if m != nil {
+		return m.MaxBatchDelay
+	}
+	return 0
+}
+
 type SubscribeResponse struct {
 	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
 	Key      []byte            `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Value    *Publication      `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	// publication, or nil for batches and for keepalives sent while there are no publications to
+	// send
+	Value *Publication `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	// sequence number of the publication in the librarian's publication log, or zero if it
+	// wasn't logged
+	Sequence uint64 `protobuf:"varint,4,opt,name=sequence" json:"sequence,omitempty"`
+	// epoch time (in nanoseconds) when the publication was first received by a librarian, or
+	// zero if unknown
+	OriginTime int64 `protobuf:"varint,5,opt,name=origin_time,json=originTime" json:"origin_time,omitempty"`
+	// publications sent together on a batched subscription, in which case the fields above
+	// (except the metadata) are unset
+	Batch []*BatchedPublication `protobuf:"bytes,6,rep,name=batch" json:"batch,omitempty"`
 }
 
 func (m *SubscribeResponse) Reset()                    { *m = SubscribeResponse{} }
 func (m *SubscribeResponse) String() string            { return proto.CompactTextString(m) }
 func (*SubscribeResponse) ProtoMessage()               {}
-func (*SubscribeResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{16} }
+func (*SubscribeResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{19} }
 
 func (m *SubscribeResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
@@ -522,116 +871,1015 @@ func (m *SubscribeResponse) GetValue() *Publication {
 	return nil
 }
 
-type Publication struct {
-	EnvelopeKey     []byte `protobuf:"bytes,1,opt,name=envelope_key,json=envelopeKey,proto3" json:"envelope_key,omitempty"`
-	EntryKey        []byte `protobuf:"bytes,2,opt,name=entry_key,json=entryKey,proto3" json:"entry_key,omitempty"`
-	AuthorPublicKey []byte `protobuf:"bytes,3,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
-	ReaderPublicKey []byte `protobuf:"bytes,4,opt,name=reader_public_key,json=readerPublicKey,proto3" json:"reader_public_key,omitempty"`
+func (m *SubscribeResponse) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
 }
 
-func (m *Publication) Reset()                    { *m = Publication{} }
-func (m *Publication) String() string            { return proto.CompactTextString(m) }
-func (*Publication) ProtoMessage()               {}
-func (*Publication) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{17} }
+func (m *SubscribeResponse) GetOriginTime() int64 {
+	if m != nil {
+		return m.OriginTime
+	}
+	return 0
+}
 
-func (m *Publication) GetEnvelopeKey() []byte {
+func (m *SubscribeResponse) GetBatch() []*BatchedPublication {
 	if m != nil {
-		return m.EnvelopeKey
+		return m.Batch
 	}
 	return nil
 }
 
-func (m *Publication) GetEntryKey() []byte {
+// BatchedPublication is a publication in the batch of a SubscribeResponse.
+type BatchedPublication struct {
+	Key   []byte       `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value *Publication `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+	// sequence number of the publication in the librarian's publication log, or zero if it
+	// wasn't logged
+	Sequence uint64 `protobuf:"varint,3,opt,name=sequence" json:"sequence,omitempty"`
+	// epoch time (in nanoseconds) when the publication was first received by a librarian, or
+	// zero if unknown
+	OriginTime int64 `protobuf:"varint,4,opt,name=origin_time,json=originTime" json:"origin_time,omitempty"`
+}
+
+func (m *BatchedPublication) Reset()                    { *m = BatchedPublication{} }
+func (m *BatchedPublication) String() string            { return proto.CompactTextString(m) }
+func (*BatchedPublication) ProtoMessage()               {}
+func (*BatchedPublication) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{20} }
+
+func (m *BatchedPublication) GetKey() []byte {
 	if m != nil {
-		return m.EntryKey
+		return m.Key
 	}
 	return nil
 }
 
-func (m *Publication) GetAuthorPublicKey() []byte {
+func (m *BatchedPublication) GetValue() *Publication {
 	if m != nil {
-		return m.AuthorPublicKey
+		return m.Value
 	}
 	return nil
 }
 
-func (m *Publication) GetReaderPublicKey() []byte {
+func (m *BatchedPublication) GetSequence() uint64 {
 	if m != nil {
-		return m.ReaderPublicKey
+		return m.Sequence
 	}
-	return nil
+	return 0
 }
 
-type Subscription struct {
-	AuthorPublicKeys *BloomFilter `protobuf:"bytes,1,opt,name=author_public_keys,json=authorPublicKeys" json:"author_public_keys,omitempty"`
-	ReaderPublicKeys *BloomFilter `protobuf:"bytes,2,opt,name=reader_public_keys,json=readerPublicKeys" json:"reader_public_keys,omitempty"`
+func (m *BatchedPublication) GetOriginTime() int64 {
+	if m != nil {
+		return m.OriginTime
+	}
+	return 0
 }
 
-func (m *Subscription) Reset()                    { *m = Subscription{} }
-func (m *Subscription) String() string            { return proto.CompactTextString(m) }
-func (*Subscription) ProtoMessage()               {}
-func (*Subscription) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{18} }
+type SubscriptionStatsRequest struct {
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+}
 
-func (m *Subscription) GetAuthorPublicKeys() *BloomFilter {
+func (m *SubscriptionStatsRequest) Reset()                    { *m = SubscriptionStatsRequest{} }
+func (m *SubscriptionStatsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SubscriptionStatsRequest) ProtoMessage()               {}
+func (*SubscriptionStatsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{21} }
+
+func (m *SubscriptionStatsRequest) GetMetadata() *RequestMetadata {
 	if m != nil {
-		return m.AuthorPublicKeys
+		return m.Metadata
 	}
 	return nil
 }
 
-func (m *Subscription) GetReaderPublicKeys() *BloomFilter {
+type SubscriptionStatsResponse struct {
+	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// active subscriptions from other peers
+	Inbound []*SubscriptionStats `protobuf:"bytes,2,rep,name=inbound" json:"inbound,omitempty"`
+	// active subscriptions to other peers
+	Outbound []*SubscriptionStats `protobuf:"bytes,3,rep,name=outbound" json:"outbound,omitempty"`
+	// total publications sent on all current and past subscriptions from other peers
+	NSent uint64 `protobuf:"varint,4,opt,name=n_sent,json=nSent" json:"n_sent,omitempty"`
+	// total publications dropped from the queues of all current and past subscriptions from
+	// other peers
+	NDropped uint64 `protobuf:"varint,5,opt,name=n_dropped,json=nDropped" json:"n_dropped,omitempty"`
+	// total publications received on all current and past subscriptions to other peers
+	NReceived uint64 `protobuf:"varint,6,opt,name=n_received,json=nReceived" json:"n_received,omitempty"`
+	// total new publications received from other peers but dropped because the queue of new
+	// publications to send was full
+	NReceivedDropped uint64 `protobuf:"varint,7,opt,name=n_received_dropped,json=nReceivedDropped" json:"n_received_dropped,omitempty"`
+	// latencies of the publications received on all current and past subscriptions to other
+	// peers
+	ReceivedLatency *LatencyHistogram `protobuf:"bytes,8,opt,name=received_latency,json=receivedLatency" json:"received_latency,omitempty"`
+}
+
+func (m *SubscriptionStatsResponse) Reset()                    { *m = SubscriptionStatsResponse{} }
+func (m *SubscriptionStatsResponse) String() string            { return proto.CompactTextString(m) }
+func (*SubscriptionStatsResponse) ProtoMessage()               {}
+func (*SubscriptionStatsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{22} }
+
+func (m *SubscriptionStatsResponse) GetMetadata() *ResponseMetadata {
 	if m != nil {
-		return m.ReaderPublicKeys
+		return m.Metadata
 	}
 	return nil
 }
 
-type BloomFilter struct {
-	// using https://godoc.org/github.com/willf/bloom#BloomFilter.GobEncode
-	Encoded []byte `protobuf:"bytes,1,opt,name=encoded,proto3" json:"encoded,omitempty"`
+func (m *SubscriptionStatsResponse) GetInbound() []*SubscriptionStats {
+	if m != nil {
+		return m.Inbound
+	}
+	return nil
 }
 
-func (m *BloomFilter) Reset()                    { *m = BloomFilter{} }
-func (m *BloomFilter) String() string            { return proto.CompactTextString(m) }
-func (*BloomFilter) ProtoMessage()               {}
-func (*BloomFilter) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{19} }
-
-func (m *BloomFilter) GetEncoded() []byte {
+func (m *SubscriptionStatsResponse) GetOutbound() []*SubscriptionStats {
 	if m != nil {
-		return m.Encoded
+		return m.Outbound
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*RequestMetadata)(nil), "api.RequestMetadata")
-	proto.RegisterType((*ResponseMetadata)(nil), "api.ResponseMetadata")
-	proto.RegisterType((*PingRequest)(nil), "api.PingRequest")
-	proto.RegisterType((*PingResponse)(nil), "api.PingResponse")
-	proto.RegisterType((*IntroduceRequest)(nil), "api.IntroduceRequest")
-	proto.RegisterType((*IntroduceResponse)(nil), "api.IntroduceResponse")
-	proto.RegisterType((*FindRequest)(nil), "api.FindRequest")
-	proto.RegisterType((*FindResponse)(nil), "api.FindResponse")
-	proto.RegisterType((*PeerAddress)(nil), "api.PeerAddress")
-	proto.RegisterType((*StoreRequest)(nil), "api.StoreRequest")
-	proto.RegisterType((*StoreResponse)(nil), "api.StoreResponse")
-	proto.RegisterType((*GetRequest)(nil), "api.GetRequest")
-	proto.RegisterType((*GetResponse)(nil), "api.GetResponse")
-	proto.RegisterType((*PutRequest)(nil), "api.PutRequest")
-	proto.RegisterType((*PutResponse)(nil), "api.PutResponse")
-	proto.RegisterType((*SubscribeRequest)(nil), "api.SubscribeRequest")
-	proto.RegisterType((*SubscribeResponse)(nil), "api.SubscribeResponse")
-	proto.RegisterType((*Publication)(nil), "api.Publication")
-	proto.RegisterType((*Subscription)(nil), "api.Subscription")
-	proto.RegisterType((*BloomFilter)(nil), "api.BloomFilter")
-	proto.RegisterEnum("api.PutOperation", PutOperation_name, PutOperation_value)
+func (m *SubscriptionStatsResponse) GetNSent() uint64 {
+	if m != nil {
+		return m.NSent
+	}
+	return 0
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
+func (m *SubscriptionStatsResponse) GetNDropped() uint64 {
+	if m != nil {
+		return m.NDropped
+	}
+	return 0
+}
+
+func (m *SubscriptionStatsResponse) GetNReceived() uint64 {
+	if m != nil {
+		return m.NReceived
+	}
+	return 0
+}
+
+func (m *SubscriptionStatsResponse) GetNReceivedDropped() uint64 {
+	if m != nil {
+		return m.NReceivedDropped
+	}
+	return 0
+}
+
+func (m *SubscriptionStatsResponse) GetReceivedLatency() *LatencyHistogram {
+	if m != nil {
+		return m.ReceivedLatency
+	}
+	return nil
+}
+
+// SubscriptionStats contains statistics about a single subscription.
+type SubscriptionStats struct {
+	// ID of the other peer in the subscription
+	PeerId []byte `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	// estimated false positive rate of the author public key filter
+	AuthorFpRate float32 `protobuf:"fixed32,2,opt,name=author_fp_rate,json=authorFpRate" json:"author_fp_rate,omitempty"`
+	// estimated false positive rate of the reader public key filter
+	ReaderFpRate float32 `protobuf:"fixed32,3,opt,name=reader_fp_rate,json=readerFpRate" json:"reader_fp_rate,omitempty"`
+	// publications sent (for inbound) or received (for outbound) on the subscription
+	NPubs uint64 `protobuf:"varint,4,opt,name=n_pubs,json=nPubs" json:"n_pubs,omitempty"`
+	// publications dropped from the subscription's queue
+	NDropped uint64 `protobuf:"varint,5,opt,name=n_dropped,json=nDropped" json:"n_dropped,omitempty"`
+	// fraction of publications sampled by the subscription, or zero if it isn't sampled
+	SampleRate float32 `protobuf:"fixed32,6,opt,name=sample_rate,json=sampleRate" json:"sample_rate,omitempty"`
+	// latencies of the publications received on the subscription, only set for outbound
+	// subscriptions
+	Latency *LatencyHistogram `protobuf:"bytes,7,opt,name=latency" json:"latency,omitempty"`
+}
+
+func (m *SubscriptionStats) Reset()                    { *m = SubscriptionStats{} }
+func (m *SubscriptionStats) String() string            { return proto.CompactTextString(m) }
+func (*SubscriptionStats) ProtoMessage()               {}
+func (*SubscriptionStats) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{23} }
+
+func (m *SubscriptionStats) GetPeerId() []byte {
+	if m != nil {
+		return m.PeerId
+	}
+	return nil
+}
+
+func (m *SubscriptionStats) GetAuthorFpRate() float32 {
+	if m != nil {
+		return m.AuthorFpRate
+	}
+	return 0
+}
+
+func (m *SubscriptionStats) GetReaderFpRate() float32 {
+	if m != nil {
+		return m.ReaderFpRate
+	}
+	return 0
+}
+
+func (m *SubscriptionStats) GetNPubs() uint64 {
+	if m != nil {
+		return m.NPubs
+	}
+	return 0
+}
+
+func (m *SubscriptionStats) GetNDropped() uint64 {
+	if m != nil {
+		return m.NDropped
+	}
+	return 0
+}
+
+func (m *SubscriptionStats) GetSampleRate() float32 {
+	if m != nil {
+		return m.SampleRate
+	}
+	return 0
+}
+
+func (m *SubscriptionStats) GetLatency() *LatencyHistogram {
+	if m != nil {
+		return m.Latency
+	}
+	return nil
+}
+
+// LatencyHistogram is the distribution of publication latencies, i.e., the times between when
+// publications were first received by a librarian and when they were received by this one.
+type LatencyHistogram struct {
+	// upper bounds (in nanoseconds) of every bucket but the last, which is unbounded
+	BucketBounds []int64 `protobuf:"varint,1,rep,packed,name=bucket_bounds,json=bucketBounds" json:"bucket_bounds,omitempty"`
+	// number of latencies in each bucket
+	Counts []uint64 `protobuf:"varint,2,rep,packed,name=counts" json:"counts,omitempty"`
+	// sum (in nanoseconds) of all the latencies
+	Sum int64 `protobuf:"varint,3,opt,name=sum" json:"sum,omitempty"`
+}
+
+func (m *LatencyHistogram) Reset()                    { *m = LatencyHistogram{} }
+func (m *LatencyHistogram) String() string            { return proto.CompactTextString(m) }
+func (*LatencyHistogram) ProtoMessage()               {}
+func (*LatencyHistogram) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{24} }
+
+func (m *LatencyHistogram) GetBucketBounds() []int64 {
+	if m != nil {
+		return m.BucketBounds
+	}
+	return nil
+}
+
+func (m *LatencyHistogram) GetCounts() []uint64 {
+	if m != nil {
+		return m.Counts
+	}
+	return nil
+}
+
+func (m *LatencyHistogram) GetSum() int64 {
+	if m != nil {
+		return m.Sum
+	}
+	return 0
+}
+
+type Publication struct {
+	EnvelopeKey     []byte `protobuf:"bytes,1,opt,name=envelope_key,json=envelopeKey,proto3" json:"envelope_key,omitempty"`
+	EntryKey        []byte `protobuf:"bytes,2,opt,name=entry_key,json=entryKey,proto3" json:"entry_key,omitempty"`
+	AuthorPublicKey []byte `protobuf:"bytes,3,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
+	ReaderPublicKey []byte `protobuf:"bytes,4,opt,name=reader_public_key,json=readerPublicKey,proto3" json:"reader_public_key,omitempty"`
+	// entry attributes disclosed in the envelope, if any
+	EntryAttributes *EntryAttributes `protobuf:"bytes,5,opt,name=entry_attributes,json=entryAttributes" json:"entry_attributes,omitempty"`
+	// encrypted entry encryption keys and their MAC in the envelope, if any
+	EekCiphertext    []byte `protobuf:"bytes,6,opt,name=eek_ciphertext,json=eekCiphertext,proto3" json:"eek_ciphertext,omitempty"`
+	EekCiphertextMac []byte `protobuf:"bytes,7,opt,name=eek_ciphertext_mac,json=eekCiphertextMac,proto3" json:"eek_ciphertext_mac,omitempty"`
+}
+
+func (m *Publication) Reset()                    { *m = Publication{} }
+func (m *Publication) String() string            { return proto.CompactTextString(m) }
+func (*Publication) ProtoMessage()               {}
+func (*Publication) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{25} }
+
+func (m *Publication) GetEnvelopeKey() []byte {
+	if m != nil {
+		return m.EnvelopeKey
+	}
+	return nil
+}
+
+func (m *Publication) GetEntryKey() []byte {
+	if m != nil {
+		return m.EntryKey
+	}
+	return nil
+}
+
+func (m *Publication) GetAuthorPublicKey() []byte {
+	if m != nil {
+		return m.AuthorPublicKey
+	}
+	return nil
+}
+
+func (m *Publication) GetReaderPublicKey() []byte {
+	if m != nil {
+		return m.ReaderPublicKey
+	}
+	return nil
+}
+
+func (m *Publication) GetEntryAttributes() *EntryAttributes {
+	if m != nil {
+		return m.EntryAttributes
+	}
+	return nil
+}
+
+func (m *Publication) GetEekCiphertext() []byte {
+	if m != nil {
+		return m.EekCiphertext
+	}
+	return nil
+}
+
+func (m *Publication) GetEekCiphertextMac() []byte {
+	if m != nil {
+		return m.EekCiphertextMac
+	}
+	return nil
+}
+
+type Subscription struct {
+	AuthorPublicKeys *BloomFilter `protobuf:"bytes,1,opt,name=author_public_keys,json=authorPublicKeys" json:"author_public_keys,omitempty"`
+	ReaderPublicKeys *BloomFilter `protobuf:"bytes,2,opt,name=reader_public_keys,json=readerPublicKeys" json:"reader_public_keys,omitempty"`
+	// optional filter on the disclosed entry attributes of publications
+	EntryAttributes *EntryAttributesFilter `protobuf:"bytes,3,opt,name=entry_attributes,json=entryAttributes" json:"entry_attributes,omitempty"`
+	// if non-zero, fraction in (0, 1] of publications to match, sampled deterministically from
+	// their envelope keys; the author and reader public key filters are optional when set
+	SampleRate float32 `protobuf:"fixed32,4,opt,name=sample_rate,json=sampleRate" json:"sample_rate,omitempty"`
+}
+
+func (m *Subscription) Reset()                    { *m = Subscription{} }
+func (m *Subscription) String() string            { return proto.CompactTextString(m) }
+func (*Subscription) ProtoMessage()               {}
+func (*Subscription) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{26} }
+
+func (m *Subscription) GetAuthorPublicKeys() *BloomFilter {
+	if m != nil {
+		return m.AuthorPublicKeys
+	}
+	return nil
+}
+
+func (m *Subscription) GetReaderPublicKeys() *BloomFilter {
+	if m != nil {
+		return m.ReaderPublicKeys
+	}
+	return nil
+}
+
+func (m *Subscription) GetEntryAttributes() *EntryAttributesFilter {
+	if m != nil {
+		return m.EntryAttributes
+	}
+	return nil
+}
+
+func (m *Subscription) GetSampleRate() float32 {
+	if m != nil {
+		return m.SampleRate
+	}
+	return 0
+}
+
+// EntryAttributesFilter matches publications whose disclosed entry attributes satisfy all of its
+// set conditions. Publications without disclosed entry attributes never match a set condition.
+type EntryAttributesFilter struct {
+	// if set, media types to match, where a type with a "/*" subtype (e.g., "image/*") matches
+	// all of its subtypes
+	MediaTypes []string `protobuf:"bytes,1,rep,name=media_types,json=mediaTypes" json:"media_types,omitempty"`
+	// if non-zero, minimum uncompressed entry size to match
+	MinUncompressedSize uint64 `protobuf:"varint,2,opt,name=min_uncompressed_size,json=minUncompressedSize" json:"min_uncompressed_size,omitempty"`
+	// if non-zero, maximum uncompressed entry size to match
+	MaxUncompressedSize uint64 `protobuf:"varint,3,opt,name=max_uncompressed_size,json=maxUncompressedSize" json:"max_uncompressed_size,omitempty"`
+}
+
+func (m *EntryAttributesFilter) Reset()                    { *m = EntryAttributesFilter{} }
+func (m *EntryAttributesFilter) String() string            { return proto.CompactTextString(m) }
+func (*EntryAttributesFilter) ProtoMessage()               {}
+func (*EntryAttributesFilter) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{27} }
+
+func (m *EntryAttributesFilter) GetMediaTypes() []string {
+	if m != nil {
+		return m.MediaTypes
+	}
+	return nil
+}
+
+func (m *EntryAttributesFilter) GetMinUncompressedSize() uint64 {
+	if m != nil {
+		return m.MinUncompressedSize
+	}
+	return 0
+}
+
+func (m *EntryAttributesFilter) GetMaxUncompressedSize() uint64 {
+	if m != nil {
+		return m.MaxUncompressedSize
+	}
+	return 0
+}
+
+type BloomFilter struct {
+	// using https://godoc.org/github.com/willf/bloom#BloomFilter.GobEncode for BLOOM filters
+	// and the subscribe package's CuckooFilter.GobEncode for CUCKOO filters
+	Encoded []byte `protobuf:"bytes,1,opt,name=encoded,proto3" json:"encoded,omitempty"`
+	// type of the encoded filter
+	Type FilterType `protobuf:"varint,2,opt,name=type,enum=api.FilterType" json:"type,omitempty"`
+}
+
+func (m *BloomFilter) Reset()                    { *m = BloomFilter{} }
+func (m *BloomFilter) String() string            { return proto.CompactTextString(m) }
+func (*BloomFilter) ProtoMessage()               {}
+func (*BloomFilter) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{28} }
+
+func (m *BloomFilter) GetEncoded() []byte {
+	if m != nil {
+		return m.Encoded
+	}
+	return nil
+}
+
+func (m *BloomFilter) GetType() FilterType {
+	if m != nil {
+		return m.Type
+	}
+	return FilterType_BLOOM
+}
+
+type RecentPublicationsRequest struct {
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+}
+
+func (m *RecentPublicationsRequest) Reset()                    { *m = RecentPublicationsRequest{} }
+func (m *RecentPublicationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*RecentPublicationsRequest) ProtoMessage()               {}
+func (*RecentPublicationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{29} }
+
+func (m *RecentPublicationsRequest) GetMetadata() *RequestMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+type ResizeRecentPublicationsRequest struct {
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// new maximum number of publications in the cache
+	Size uint32 `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *ResizeRecentPublicationsRequest) Reset()         { *m = ResizeRecentPublicationsRequest{} }
+func (m *ResizeRecentPublicationsRequest) String() string { return proto.CompactTextString(m) }
+func (*ResizeRecentPublicationsRequest) ProtoMessage()    {}
+func (*ResizeRecentPublicationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor1, []int{30}
+}
+
+func (m *ResizeRecentPublicationsRequest) GetMetadata() *RequestMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ResizeRecentPublicationsRequest) GetSize() uint32 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type RecentPublicationsResponse struct {
+	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// maximum number of publications in the cache
+	Size uint32 `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+	// current number of publications in the cache
+	Len uint32 `protobuf:"varint,3,opt,name=len" json:"len,omitempty"`
+	// number of lookups for publications in the cache
+	NHits uint64 `protobuf:"varint,4,opt,name=n_hits,json=nHits" json:"n_hits,omitempty"`
+	// number of lookups for publications not in the cache
+	NMisses uint64 `protobuf:"varint,5,opt,name=n_misses,json=nMisses" json:"n_misses,omitempty"`
+	// number of publications evicted from the cache to make room for others
+	NEvictions uint64 `protobuf:"varint,6,opt,name=n_evictions,json=nEvictions" json:"n_evictions,omitempty"`
+}
+
+func (m *RecentPublicationsResponse) Reset()                    { *m = RecentPublicationsResponse{} }
+func (m *RecentPublicationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*RecentPublicationsResponse) ProtoMessage()               {}
+func (*RecentPublicationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{31} }
+
+func (m *RecentPublicationsResponse) GetMetadata() *ResponseMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *RecentPublicationsResponse) GetSize() uint32 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *RecentPublicationsResponse) GetLen() uint32 {
+	if m != nil {
+		return m.Len
+	}
+	return 0
+}
+
+func (m *RecentPublicationsResponse) GetNHits() uint64 {
+	if m != nil {
+		return m.NHits
+	}
+	return 0
+}
+
+func (m *RecentPublicationsResponse) GetNMisses() uint64 {
+	if m != nil {
+		return m.NMisses
+	}
+	return 0
+}
+
+func (m *RecentPublicationsResponse) GetNEvictions() uint64 {
+	if m != nil {
+		return m.NEvictions
+	}
+	return 0
+}
+
+// ScanPublicationsRequest scans the publication log for publications satisfying all of its set
+// conditions.
+type ScanPublicationsRequest struct {
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// sequence number in the librarian's publication log from which to scan
+	FromSequence uint64 `protobuf:"varint,2,opt,name=from_sequence,json=fromSequence" json:"from_sequence,omitempty"`
+	// if non-zero, earliest epoch time (in nanoseconds) of publications to return
+	StartTime int64 `protobuf:"varint,3,opt,name=start_time,json=startTime" json:"start_time,omitempty"`
+	// if non-zero, epoch time (in nanoseconds) before which to return publications
+	EndTime int64 `protobuf:"varint,4,opt,name=end_time,json=endTime" json:"end_time,omitempty"`
+	// if set, 32-byte ID of the peer from which returned publications were received
+	FromPeerId []byte `protobuf:"bytes,5,opt,name=from_peer_id,json=fromPeerId,proto3" json:"from_peer_id,omitempty"`
+	// if set, author public key of publications to return
+	AuthorPublicKey []byte `protobuf:"bytes,6,opt,name=author_public_key,json=authorPublicKey,proto3" json:"author_public_key,omitempty"`
+	// if non-zero, maximum number of publications to return, which may be further limited by the
+	// librarian's own maximum
+	Limit uint32 `protobuf:"varint,7,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *ScanPublicationsRequest) Reset()                    { *m = ScanPublicationsRequest{} }
+func (m *ScanPublicationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ScanPublicationsRequest) ProtoMessage()               {}
+func (*ScanPublicationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{32} }
+
+func (m *ScanPublicationsRequest) GetMetadata() *RequestMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ScanPublicationsRequest) GetFromSequence() uint64 {
+	if m != nil {
+		return m.FromSequence
+	}
+	return 0
+}
+
+func (m *ScanPublicationsRequest) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *ScanPublicationsRequest) GetEndTime() int64 {
+	if m != nil {
+		return m.EndTime
+	}
+	return 0
+}
+
+func (m *ScanPublicationsRequest) GetFromPeerId() []byte {
+	if m != nil {
+		return m.FromPeerId
+	}
+	return nil
+}
+
+func (m *ScanPublicationsRequest) GetAuthorPublicKey() []byte {
+	if m != nil {
+		return m.AuthorPublicKey
+	}
+	return nil
+}
+
+func (m *ScanPublicationsRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ScanPublicationsResponse struct {
+	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// matching publications in sequence order
+	Publications []*LoggedPublication `protobuf:"bytes,2,rep,name=publications" json:"publications,omitempty"`
+	// if non-zero, sequence number from which to continue the scan when the limit was reached
+	NextSequence uint64 `protobuf:"varint,3,opt,name=next_sequence,json=nextSequence" json:"next_sequence,omitempty"`
+}
+
+func (m *ScanPublicationsResponse) Reset()                    { *m = ScanPublicationsResponse{} }
+func (m *ScanPublicationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ScanPublicationsResponse) ProtoMessage()               {}
+func (*ScanPublicationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{33} }
+
+func (m *ScanPublicationsResponse) GetMetadata() *ResponseMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ScanPublicationsResponse) GetPublications() []*LoggedPublication {
+	if m != nil {
+		return m.Publications
+	}
+	return nil
+}
+
+func (m *ScanPublicationsResponse) GetNextSequence() uint64 {
+	if m != nil {
+		return m.NextSequence
+	}
+	return 0
+}
+
+// LoggedPublication is a publication in a librarian's publication log.
+type LoggedPublication struct {
+	// position of the publication in the log
+	Sequence uint64       `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	Key      []byte       `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value    *Publication `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+	// epoch time (in nanoseconds) when the publication was received
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	// public key of the peer the publication was received from, if known
+	FromPublicKey []byte `protobuf:"bytes,5,opt,name=from_public_key,json=fromPublicKey,proto3" json:"from_public_key,omitempty"`
+	// 32-byte ID of the peer the publication was received from, if known
+	FromPeerId []byte `protobuf:"bytes,6,opt,name=from_peer_id,json=fromPeerId,proto3" json:"from_peer_id,omitempty"`
+}
+
+func (m *LoggedPublication) Reset()                    { *m = LoggedPublication{} }
+func (m *LoggedPublication) String() string            { return proto.CompactTextString(m) }
+func (*LoggedPublication) ProtoMessage()               {}
+func (*LoggedPublication) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{34} }
+
+func (m *LoggedPublication) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *LoggedPublication) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *LoggedPublication) GetValue() *Publication {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *LoggedPublication) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *LoggedPublication) GetFromPublicKey() []byte {
+	if m != nil {
+		return m.FromPublicKey
+	}
+	return nil
+}
+
+func (m *LoggedPublication) GetFromPeerId() []byte {
+	if m != nil {
+		return m.FromPeerId
+	}
+	return nil
+}
+
+// ScanAuditLogRequest scans the audit log for records satisfying all of its set conditions.
+type ScanAuditLogRequest struct {
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// sequence number in the librarian's audit log from which to scan
+	FromSequence uint64 `protobuf:"varint,2,opt,name=from_sequence,json=fromSequence" json:"from_sequence,omitempty"`
+	// if non-zero, earliest epoch time (in nanoseconds) of records to return
+	StartTime int64 `protobuf:"varint,3,opt,name=start_time,json=startTime" json:"start_time,omitempty"`
+	// if non-zero, epoch time (in nanoseconds) before which to return records
+	EndTime int64 `protobuf:"varint,4,opt,name=end_time,json=endTime" json:"end_time,omitempty"`
+	// if set, 32-byte ID of the requester of returned records
+	RequesterPeerId []byte `protobuf:"bytes,5,opt,name=requester_peer_id,json=requesterPeerId,proto3" json:"requester_peer_id,omitempty"`
+	// if set, name of the RPC of returned records
+	Rpc string `protobuf:"bytes,6,opt,name=rpc" json:"rpc,omitempty"`
+	// if non-zero, maximum number of records to return, which may be further limited by the
+	// librarian's own maximum
+	Limit uint32 `protobuf:"varint,7,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *ScanAuditLogRequest) Reset()                    { *m = ScanAuditLogRequest{} }
+func (m *ScanAuditLogRequest) String() string            { return proto.CompactTextString(m) }
+func (*ScanAuditLogRequest) ProtoMessage()               {}
+func (*ScanAuditLogRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{35} }
+
+func (m *ScanAuditLogRequest) GetMetadata() *RequestMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ScanAuditLogRequest) GetFromSequence() uint64 {
+	if m != nil {
+		return m.FromSequence
+	}
+	return 0
+}
+
+func (m *ScanAuditLogRequest) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *ScanAuditLogRequest) GetEndTime() int64 {
+	if m != nil {
+		return m.EndTime
+	}
+	return 0
+}
+
+func (m *ScanAuditLogRequest) GetRequesterPeerId() []byte {
+	if m != nil {
+		return m.RequesterPeerId
+	}
+	return nil
+}
+
+func (m *ScanAuditLogRequest) GetRpc() string {
+	if m != nil {
+		return m.Rpc
+	}
+	return ""
+}
+
+func (m *ScanAuditLogRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ScanAuditLogResponse struct {
+	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// matching records in sequence order
+	Records []*AuditRecord `protobuf:"bytes,2,rep,name=records" json:"records,omitempty"`
+	// if non-zero, sequence number from which to continue the scan when the limit was reached
+	NextSequence uint64 `protobuf:"varint,3,opt,name=next_sequence,json=nextSequence" json:"next_sequence,omitempty"`
+}
+
+func (m *ScanAuditLogResponse) Reset()                    { *m = ScanAuditLogResponse{} }
+func (m *ScanAuditLogResponse) String() string            { return proto.CompactTextString(m) }
+func (*ScanAuditLogResponse) ProtoMessage()               {}
+func (*ScanAuditLogResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{36} }
+
+func (m *ScanAuditLogResponse) GetMetadata() *ResponseMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ScanAuditLogResponse) GetRecords() []*AuditRecord {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+func (m *ScanAuditLogResponse) GetNextSequence() uint64 {
+	if m != nil {
+		return m.NextSequence
+	}
+	return 0
+}
+
+// AuditRecord is a mutating request in a librarian's audit log.
+type AuditRecord struct {
+	// position of the record in the log
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	// epoch time (in nanoseconds) when the request was received
+	Timestamp int64 `protobuf:"varint,2,opt,name=timestamp" json:"timestamp,omitempty"`
+	// public key of the requester, as given in the request metadata
+	RequesterPublicKey []byte `protobuf:"bytes,3,opt,name=requester_public_key,json=requesterPublicKey,proto3" json:"requester_public_key,omitempty"`
+	// 32-byte ID of the requester, if their public key is valid
+	RequesterPeerId []byte `protobuf:"bytes,4,opt,name=requester_peer_id,json=requesterPeerId,proto3" json:"requester_peer_id,omitempty"`
+	// name of the RPC requested
+	Rpc string `protobuf:"bytes,5,opt,name=rpc" json:"rpc,omitempty"`
+	// key of the document the request stored
+	Key []byte `protobuf:"bytes,6,opt,name=key,proto3" json:"key,omitempty"`
+	// error message if the request failed, or empty if it succeeded
+	Error string `protobuf:"bytes,7,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *AuditRecord) Reset()                    { *m = AuditRecord{} }
+func (m *AuditRecord) String() string            { return proto.CompactTextString(m) }
+func (*AuditRecord) ProtoMessage()               {}
+func (*AuditRecord) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{37} }
+
+func (m *AuditRecord) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *AuditRecord) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *AuditRecord) GetRequesterPublicKey() []byte {
+	if m != nil {
+		return m.RequesterPublicKey
+	}
+	return nil
+}
+
+func (m *AuditRecord) GetRequesterPeerId() []byte {
+	if m != nil {
+		return m.RequesterPeerId
+	}
+	return nil
+}
+
+func (m *AuditRecord) GetRpc() string {
+	if m != nil {
+		return m.Rpc
+	}
+	return ""
+}
+
+func (m *AuditRecord) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *AuditRecord) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// UpdateDenylistRequest adds and removes document keys from the denylist. A request with neither
+// just returns the current denylist.
+type UpdateDenylistRequest struct {
+	Metadata *RequestMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// 32-byte keys of documents to deny
+	AddKeys [][]byte `protobuf:"bytes,2,rep,name=add_keys,json=addKeys,proto3" json:"add_keys,omitempty"`
+	// 32-byte keys of documents to no longer deny
+	RemoveKeys [][]byte `protobuf:"bytes,3,rep,name=remove_keys,json=removeKeys,proto3" json:"remove_keys,omitempty"`
+}
+
+func (m *UpdateDenylistRequest) Reset()                    { *m = UpdateDenylistRequest{} }
+func (m *UpdateDenylistRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateDenylistRequest) ProtoMessage()               {}
+func (*UpdateDenylistRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{38} }
+
+func (m *UpdateDenylistRequest) GetMetadata() *RequestMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *UpdateDenylistRequest) GetAddKeys() [][]byte {
+	if m != nil {
+		return m.AddKeys
+	}
+	return nil
+}
+
+func (m *UpdateDenylistRequest) GetRemoveKeys() [][]byte {
+	if m != nil {
+		return m.RemoveKeys
+	}
+	return nil
+}
+
+type UpdateDenylistResponse struct {
+	Metadata *ResponseMetadata `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+	// 32-byte keys of all denied documents after the update, in sorted order
+	Keys [][]byte `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (m *UpdateDenylistResponse) Reset()                    { *m = UpdateDenylistResponse{} }
+func (m *UpdateDenylistResponse) String() string            { return proto.CompactTextString(m) }
+func (*UpdateDenylistResponse) ProtoMessage()               {}
+func (*UpdateDenylistResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{39} }
+
+func (m *UpdateDenylistResponse) GetMetadata() *ResponseMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *UpdateDenylistResponse) GetKeys() [][]byte {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*RequestMetadata)(nil), "api.RequestMetadata")
+	proto.RegisterType((*ResponseMetadata)(nil), "api.ResponseMetadata")
+	proto.RegisterType((*PingRequest)(nil), "api.PingRequest")
+	proto.RegisterType((*PingResponse)(nil), "api.PingResponse")
+	proto.RegisterType((*IntroduceRequest)(nil), "api.IntroduceRequest")
+	proto.RegisterType((*KeyRotation)(nil), "api.KeyRotation")
+	proto.RegisterType((*SessionCertificate)(nil), "api.SessionCertificate")
+	proto.RegisterType((*IntroduceResponse)(nil), "api.IntroduceResponse")
+	proto.RegisterType((*FindRequest)(nil), "api.FindRequest")
+	proto.RegisterType((*AccessProof)(nil), "api.AccessProof")
+	proto.RegisterType((*FindResponse)(nil), "api.FindResponse")
+	proto.RegisterType((*PeerAddress)(nil), "api.PeerAddress")
+	proto.RegisterType((*StoreRequest)(nil), "api.StoreRequest")
+	proto.RegisterType((*StoreResponse)(nil), "api.StoreResponse")
+	proto.RegisterType((*GetRequest)(nil), "api.GetRequest")
+	proto.RegisterType((*GetResponse)(nil), "api.GetResponse")
+	proto.RegisterType((*PutRequest)(nil), "api.PutRequest")
+	proto.RegisterType((*PutResponse)(nil), "api.PutResponse")
+	proto.RegisterType((*SubscribeRequest)(nil), "api.SubscribeRequest")
+	proto.RegisterType((*SubscribeResponse)(nil), "api.SubscribeResponse")
+	proto.RegisterType((*BatchedPublication)(nil), "api.BatchedPublication")
+	proto.RegisterType((*SubscriptionStatsRequest)(nil), "api.SubscriptionStatsRequest")
+	proto.RegisterType((*SubscriptionStatsResponse)(nil), "api.SubscriptionStatsResponse")
+	proto.RegisterType((*SubscriptionStats)(nil), "api.SubscriptionStats")
+	proto.RegisterType((*LatencyHistogram)(nil), "api.LatencyHistogram")
+	proto.RegisterType((*Publication)(nil), "api.Publication")
+	proto.RegisterType((*Subscription)(nil), "api.Subscription")
+	proto.RegisterType((*EntryAttributesFilter)(nil), "api.EntryAttributesFilter")
+	proto.RegisterType((*BloomFilter)(nil), "api.BloomFilter")
+	proto.RegisterType((*RecentPublicationsRequest)(nil), "api.RecentPublicationsRequest")
+	proto.RegisterType((*ResizeRecentPublicationsRequest)(nil), "api.ResizeRecentPublicationsRequest")
+	proto.RegisterType((*RecentPublicationsResponse)(nil), "api.RecentPublicationsResponse")
+	proto.RegisterType((*ScanPublicationsRequest)(nil), "api.ScanPublicationsRequest")
+	proto.RegisterType((*ScanPublicationsResponse)(nil), "api.ScanPublicationsResponse")
+	proto.RegisterType((*LoggedPublication)(nil), "api.LoggedPublication")
+	proto.RegisterType((*ScanAuditLogRequest)(nil), "api.ScanAuditLogRequest")
+	proto.RegisterType((*ScanAuditLogResponse)(nil), "api.ScanAuditLogResponse")
+	proto.RegisterType((*AuditRecord)(nil), "api.AuditRecord")
+	proto.RegisterType((*UpdateDenylistRequest)(nil), "api.UpdateDenylistRequest")
+	proto.RegisterType((*UpdateDenylistResponse)(nil), "api.UpdateDenylistResponse")
+	proto.RegisterEnum("api.PutOperation", PutOperation_name, PutOperation_value)
+	proto.RegisterEnum("api.FilterType", FilterType_name, FilterType_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
 // is compatible with the grpc package it is being compiled against.
 const _ = grpc.SupportPackageIsVersion4
 
@@ -650,8 +1898,12 @@ type LibrarianClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	// Put stores a value.
 	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutResponse, error)
-	// Subscribe streams Publications to the client per a subscription filter.
-	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Librarian_SubscribeClient, error)
+	// Subscribe streams Publications to the client per the subscription filter in the first
+	// request. Subsequent requests acknowledge the Publications received or update the
+	// subscription filter.
+	Subscribe(ctx context.Context, opts ...grpc.CallOption) (Librarian_SubscribeClient, error)
+	// SubscriptionStats returns statistics about the subscriptions to and from other peers.
+	SubscriptionStats(ctx context.Context, in *SubscriptionStatsRequest, opts ...grpc.CallOption) (*SubscriptionStatsResponse, error)
 }
 
 type librarianClient struct {
@@ -716,22 +1968,17 @@ func (c *librarianClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.
 	return out, nil
 }
 
-func (c *librarianClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Librarian_SubscribeClient, error) {
+func (c *librarianClient) Subscribe(ctx context.Context, opts ...grpc.CallOption) (Librarian_SubscribeClient, error) {
 	stream, err := grpc.NewClientStream(ctx, &_Librarian_serviceDesc.Streams[0], c.cc, "/api.Librarian/Subscribe", opts...)
 	if err != nil {
 		return nil, err
 	}
 	x := &librarianSubscribeClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
-	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
-	}
 	return x, nil
 }
 
 type Librarian_SubscribeClient interface {
+	Send(*SubscribeRequest) error
 	Recv() (*SubscribeResponse, error)
 	grpc.ClientStream
 }
@@ -740,6 +1987,10 @@ type librarianSubscribeClient struct {
 	grpc.ClientStream
 }
 
+func (x *librarianSubscribeClient) Send(m *SubscribeRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
 func (x *librarianSubscribeClient) Recv() (*SubscribeResponse, error) {
 	m := new(SubscribeResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
@@ -748,6 +1999,15 @@ func (x *librarianSubscribeClient) Recv() (*SubscribeResponse, error) {
 	return m, nil
 }
 
+func (c *librarianClient) SubscriptionStats(ctx context.Context, in *SubscriptionStatsRequest, opts ...grpc.CallOption) (*SubscriptionStatsResponse, error) {
+	out := new(SubscriptionStatsResponse)
+	err := grpc.Invoke(ctx, "/api.Librarian/SubscriptionStats", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Librarian service
 
 type LibrarianServer interface {
@@ -763,8 +2023,12 @@ type LibrarianServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	// Put stores a value.
 	Put(context.Context, *PutRequest) (*PutResponse, error)
-	// Subscribe streams Publications to the client per a subscription filter.
-	Subscribe(*SubscribeRequest, Librarian_SubscribeServer) error
+	// Subscribe streams Publications to the client per the subscription filter in the first
+	// request. Subsequent requests acknowledge the Publications received or update the
+	// subscription filter.
+	Subscribe(Librarian_SubscribeServer) error
+	// SubscriptionStats returns statistics about the subscriptions to and from other peers.
+	SubscriptionStats(context.Context, *SubscriptionStatsRequest) (*SubscriptionStatsResponse, error)
 }
 
 func RegisterLibrarianServer(s *grpc.Server, srv LibrarianServer) {
@@ -880,15 +2144,12 @@ func _Librarian_Put_Handler(srv interface{}, ctx context.Context, dec func(inter
 }
 
 func _Librarian_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(SubscribeRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
-	}
-	return srv.(LibrarianServer).Subscribe(m, &librarianSubscribeServer{stream})
+	return srv.(LibrarianServer).Subscribe(&librarianSubscribeServer{stream})
 }
 
 type Librarian_SubscribeServer interface {
 	Send(*SubscribeResponse) error
+	Recv() (*SubscribeRequest, error)
 	grpc.ServerStream
 }
 
@@ -900,6 +2161,32 @@ func (x *librarianSubscribeServer) Send(m *SubscribeResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func (x *librarianSubscribeServer) Recv() (*SubscribeRequest, error) {
+	m := new(SubscribeRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Librarian_SubscriptionStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibrarianServer).SubscriptionStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Librarian/SubscriptionStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibrarianServer).SubscriptionStats(ctx, req.(*SubscriptionStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Librarian_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.Librarian",
 	HandlerType: (*LibrarianServer)(nil),
@@ -928,72 +2215,386 @@ var _Librarian_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Put",
 			Handler:    _Librarian_Put_Handler,
 		},
+		{
+			MethodName: "SubscriptionStats",
+			Handler:    _Librarian_SubscriptionStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Subscribe",
 			Handler:       _Librarian_Subscribe_Handler,
 			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "libri/librarian/api/librarian.proto",
+}
+
+// Client API for Admin service
+
+type AdminClient interface {
+	// RecentPublications returns the size and counters of the recent publications cache.
+	RecentPublications(ctx context.Context, in *RecentPublicationsRequest, opts ...grpc.CallOption) (*RecentPublicationsResponse, error)
+	// ResizeRecentPublications changes the size of the recent publications cache.
+	ResizeRecentPublications(ctx context.Context, in *ResizeRecentPublicationsRequest, opts ...grpc.CallOption) (*RecentPublicationsResponse, error)
+	// ScanPublications returns the publications in the librarian's publication log matching the
+	// request's conditions.
+	ScanPublications(ctx context.Context, in *ScanPublicationsRequest, opts ...grpc.CallOption) (*ScanPublicationsResponse, error)
+	// ScanAuditLog returns the mutating requests in the librarian's audit log matching the
+	// request's conditions.
+	ScanAuditLog(ctx context.Context, in *ScanAuditLogRequest, opts ...grpc.CallOption) (*ScanAuditLogResponse, error)
+	// UpdateDenylist adds and removes document keys from the librarian's denylist, which it
+	// refuses to store or serve.
+	UpdateDenylist(ctx context.Context, in *UpdateDenylistRequest, opts ...grpc.CallOption) (*UpdateDenylistResponse, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) RecentPublications(ctx context.Context, in *RecentPublicationsRequest, opts ...grpc.CallOption) (*RecentPublicationsResponse, error) {
+	out := new(RecentPublicationsResponse)
+	err := grpc.Invoke(ctx, "/api.Admin/RecentPublications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ResizeRecentPublications(ctx context.Context, in *ResizeRecentPublicationsRequest, opts ...grpc.CallOption) (*RecentPublicationsResponse, error) {
+	out := new(RecentPublicationsResponse)
+	err := grpc.Invoke(ctx, "/api.Admin/ResizeRecentPublications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ScanPublications(ctx context.Context, in *ScanPublicationsRequest, opts ...grpc.CallOption) (*ScanPublicationsResponse, error) {
+	out := new(ScanPublicationsResponse)
+	err := grpc.Invoke(ctx, "/api.Admin/ScanPublications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ScanAuditLog(ctx context.Context, in *ScanAuditLogRequest, opts ...grpc.CallOption) (*ScanAuditLogResponse, error) {
+	out := new(ScanAuditLogResponse)
+	err := grpc.Invoke(ctx, "/api.Admin/ScanAuditLog", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) UpdateDenylist(ctx context.Context, in *UpdateDenylistRequest, opts ...grpc.CallOption) (*UpdateDenylistResponse, error) {
+	out := new(UpdateDenylistResponse)
+	err := grpc.Invoke(ctx, "/api.Admin/UpdateDenylist", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Admin service
+
+type AdminServer interface {
+	// RecentPublications returns the size and counters of the recent publications cache.
+	RecentPublications(context.Context, *RecentPublicationsRequest) (*RecentPublicationsResponse, error)
+	// ResizeRecentPublications changes the size of the recent publications cache.
+	ResizeRecentPublications(context.Context, *ResizeRecentPublicationsRequest) (*RecentPublicationsResponse, error)
+	// ScanPublications returns the publications in the librarian's publication log matching the
+	// request's conditions.
+	ScanPublications(context.Context, *ScanPublicationsRequest) (*ScanPublicationsResponse, error)
+	// ScanAuditLog returns the mutating requests in the librarian's audit log matching the
+	// request's conditions.
+	ScanAuditLog(context.Context, *ScanAuditLogRequest) (*ScanAuditLogResponse, error)
+	// UpdateDenylist adds and removes document keys from the librarian's denylist, which it
+	// refuses to store or serve.
+	UpdateDenylist(context.Context, *UpdateDenylistRequest) (*UpdateDenylistResponse, error)
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_RecentPublications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecentPublicationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RecentPublications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/RecentPublications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RecentPublications(ctx, req.(*RecentPublicationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ResizeRecentPublications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResizeRecentPublicationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ResizeRecentPublications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/ResizeRecentPublications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ResizeRecentPublications(ctx, req.(*ResizeRecentPublicationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ScanPublications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanPublicationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ScanPublications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/ScanPublications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ScanPublications(ctx, req.(*ScanPublicationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ScanAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanAuditLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ScanAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/ScanAuditLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ScanAuditLog(ctx, req.(*ScanAuditLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_UpdateDenylist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDenylistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateDenylist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/UpdateDenylist",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateDenylist(ctx, req.(*UpdateDenylistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "api.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RecentPublications",
+			Handler:    _Admin_RecentPublications_Handler,
+		},
+		{
+			MethodName: "ResizeRecentPublications",
+			Handler:    _Admin_ResizeRecentPublications_Handler,
+		},
+		{
+			MethodName: "ScanPublications",
+			Handler:    _Admin_ScanPublications_Handler,
+		},
+		{
+			MethodName: "ScanAuditLog",
+			Handler:    _Admin_ScanAuditLog_Handler,
+		},
+		{
+			MethodName: "UpdateDenylist",
+			Handler:    _Admin_UpdateDenylist_Handler,
 		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "libri/librarian/api/librarian.proto",
 }
 
 func init() { proto.RegisterFile("libri/librarian/api/librarian.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 841 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xbc, 0x56, 0xcd, 0x8e, 0x23, 0x35,
-	0x10, 0x9e, 0x4e, 0x32, 0xb3, 0xe9, 0xea, 0x64, 0xa7, 0x63, 0xf1, 0x13, 0x05, 0x21, 0x2d, 0x5e,
-	0xb4, 0x8c, 0x46, 0x9a, 0x1f, 0x82, 0xb8, 0xa1, 0x95, 0x58, 0xed, 0xcc, 0x28, 0xec, 0xb2, 0x1b,
-	0x75, 0xe6, 0xc0, 0x2d, 0x72, 0xd2, 0xc5, 0xd0, 0x22, 0xed, 0x36, 0xb6, 0x7b, 0xd1, 0x88, 0x0b,
-	0x37, 0x6e, 0x88, 0x03, 0xaf, 0xc0, 0x03, 0xf2, 0x06, 0xa8, 0x6d, 0xf7, 0x4f, 0x92, 0xd5, 0x08,
-	0xb2, 0x23, 0x2e, 0x51, 0x5c, 0xf5, 0xd9, 0xf5, 0x7d, 0xe5, 0x72, 0x55, 0xc3, 0xe3, 0x55, 0xb2,
-	0x90, 0xc9, 0x59, 0xf1, 0xcb, 0x64, 0xc2, 0xf8, 0x19, 0x13, 0x8d, 0xd5, 0xa9, 0x90, 0x99, 0xce,
-	0x48, 0x9b, 0x89, 0x64, 0xf4, 0x56, 0x64, 0x9c, 0x2d, 0xf3, 0x14, 0xb9, 0x56, 0x16, 0x49, 0x27,
-	0x70, 0x18, 0xe1, 0x4f, 0x39, 0x2a, 0xfd, 0x2d, 0x6a, 0x16, 0x33, 0xcd, 0xc8, 0xc7, 0x00, 0xd2,
-	0x9a, 0xe6, 0x49, 0x3c, 0xf4, 0x1e, 0x79, 0x47, 0xbd, 0xc8, 0x77, 0x96, 0x49, 0x4c, 0x3e, 0x84,
-	0x07, 0x22, 0x5f, 0xcc, 0x7f, 0xc4, 0xdb, 0x61, 0xcb, 0xf8, 0x0e, 0x44, 0xbe, 0x78, 0x81, 0xb7,
-	0xf4, 0x1b, 0x08, 0x23, 0x54, 0x22, 0xe3, 0x0a, 0xdf, 0xf9, 0xac, 0x3e, 0x04, 0xd3, 0x84, 0xdf,
-	0x38, 0x6a, 0xf4, 0x08, 0x7a, 0x76, 0x69, 0x8f, 0x27, 0x43, 0x78, 0x90, 0xa2, 0x52, 0xec, 0x06,
-	0xcd, 0x99, 0x7e, 0x54, 0x2e, 0xe9, 0x6f, 0x1e, 0x84, 0x13, 0xae, 0x65, 0x16, 0xe7, 0x4b, 0x74,
-	0xdb, 0xc9, 0x39, 0x74, 0x53, 0xc7, 0xc8, 0xe0, 0x83, 0xf1, 0x7b, 0xa7, 0x4c, 0x24, 0xa7, 0x1b,
-	0xca, 0xa3, 0x0a, 0x45, 0x3e, 0x85, 0x8e, 0xc2, 0xd5, 0xf7, 0x86, 0x55, 0x30, 0x0e, 0x0d, 0x7a,
-	0x8a, 0x28, 0xbf, 0x8e, 0x63, 0x89, 0x4a, 0x45, 0xc6, 0x4b, 0x3e, 0x02, 0x9f, 0xe7, 0xe9, 0x5c,
-	0x20, 0x4a, 0x35, 0x6c, 0x3f, 0xf2, 0x8e, 0xfa, 0x51, 0x97, 0xe7, 0x69, 0x01, 0x54, 0xf4, 0x4f,
-	0x0f, 0x06, 0x0d, 0x26, 0x8e, 0xf9, 0xe7, 0x5b, 0x54, 0xde, 0x77, 0x54, 0xd6, 0x33, 0xf7, 0x9f,
-	0xb9, 0x3c, 0x81, 0xfd, 0x92, 0x47, 0xfb, 0xad, 0x30, 0xeb, 0xa6, 0x1c, 0x82, 0xcb, 0x84, 0xc7,
-	0xbb, 0xa7, 0x26, 0x84, 0x76, 0x7d, 0x5f, 0xc5, 0xdf, 0xbb, 0xd3, 0xf0, 0xbb, 0x07, 0x3d, 0x1b,
-	0x70, 0xf7, 0x0c, 0x54, 0xda, 0x5a, 0x77, 0x6a, 0x23, 0x8f, 0x61, 0xff, 0x0d, 0x5b, 0xe5, 0x68,
-	0x48, 0x04, 0xe3, 0xbe, 0xc1, 0x3d, 0x77, 0x15, 0x1f, 0x59, 0x1f, 0xbd, 0x81, 0xa0, 0xb1, 0xd5,
-	0x94, 0x20, 0xa2, 0xac, 0xcb, 0xf3, 0xa0, 0x58, 0x4e, 0xe2, 0x42, 0x95, 0x71, 0x70, 0x96, 0xa2,
-	0x51, 0xeb, 0x47, 0xdd, 0xc2, 0xf0, 0x8a, 0xa5, 0x48, 0x1e, 0x42, 0x2b, 0x11, 0x26, 0x8c, 0x1f,
-	0xb5, 0x12, 0x41, 0x08, 0x74, 0x44, 0x26, 0xf5, 0xb0, 0x63, 0xd4, 0x9b, 0xff, 0xf4, 0x67, 0xe8,
-	0xcd, 0x74, 0x26, 0xf1, 0x3e, 0x53, 0xfd, 0xaf, 0x14, 0x3e, 0x83, 0xbe, 0x0b, 0xbc, 0x73, 0xca,
-	0xe9, 0x14, 0xe0, 0x0a, 0xf5, 0x3d, 0x52, 0xa7, 0x08, 0x81, 0x39, 0x71, 0xf7, 0x32, 0xa8, 0xc4,
-	0xb7, 0xee, 0x10, 0x9f, 0x03, 0x4c, 0x73, 0xfd, 0xbf, 0xe7, 0xfc, 0x0f, 0x0f, 0x02, 0x13, 0x77,
-	0x77, 0x79, 0x67, 0xe0, 0x67, 0x02, 0x25, 0xd3, 0x49, 0xc6, 0x4d, 0xfc, 0x87, 0xe3, 0x81, 0xad,
-	0xf4, 0x5c, 0xbf, 0x2e, 0x1d, 0x51, 0x8d, 0x29, 0x9a, 0x2b, 0x9f, 0x4b, 0x14, 0xab, 0x64, 0xc9,
-	0xca, 0x87, 0xe7, 0xf3, 0xc8, 0x19, 0xe8, 0x2f, 0x10, 0xce, 0xf2, 0x85, 0x5a, 0xca, 0x64, 0xf1,
-	0x0e, 0x35, 0xf8, 0x25, 0xf4, 0x94, 0x3d, 0x45, 0x54, 0xc4, 0x02, 0x47, 0x6c, 0xd6, 0x70, 0x44,
-	0x6b, 0x30, 0xfa, 0xab, 0x07, 0x83, 0x46, 0xf4, 0xdd, 0xb3, 0xb2, 0x7d, 0x1f, 0x4f, 0xd6, 0xef,
-	0xc3, 0x75, 0x83, 0x7c, 0x51, 0xa8, 0x36, 0x4c, 0xdc, 0x95, 0xfc, 0x65, 0xae, 0xa4, 0x32, 0x93,
-	0x4f, 0xa0, 0x87, 0xfc, 0x0d, 0xae, 0x32, 0x81, 0x66, 0xe2, 0xd8, 0xe7, 0x1e, 0x94, 0xb6, 0x17,
-	0xb6, 0x93, 0x21, 0xd7, 0xf2, 0xb6, 0x31, 0x91, 0xba, 0xc6, 0x50, 0x38, 0x8f, 0x61, 0xc0, 0x72,
-	0xfd, 0x43, 0x26, 0xe7, 0xc2, 0x9c, 0x6a, 0x40, 0x6d, 0x03, 0x3a, 0xb4, 0x0e, 0x1b, 0xcd, 0x61,
-	0x25, 0xb2, 0x18, 0xd7, 0xb0, 0x1d, 0x8b, 0xb5, 0x8e, 0x0a, 0x6b, 0x3a, 0x64, 0x33, 0x93, 0xe4,
-	0x29, 0x90, 0xad, 0x40, 0xca, 0xe5, 0xcb, 0xaa, 0x7d, 0xb6, 0xca, 0xb2, 0xf4, 0x32, 0x59, 0x69,
-	0x94, 0x51, 0xb8, 0x11, 0x5b, 0x15, 0xfb, 0xb7, 0x82, 0xab, 0xb5, 0xf1, 0xb1, 0xb6, 0x7f, 0x83,
-	0x8f, 0xa2, 0x9f, 0x41, 0xd0, 0x00, 0x14, 0xc3, 0x16, 0xf9, 0x32, 0x8b, 0xb1, 0xec, 0x90, 0xe5,
-	0xf2, 0xf8, 0x04, 0x7a, 0xcd, 0xda, 0x24, 0x00, 0x07, 0xb3, 0xeb, 0xd7, 0xd1, 0xc5, 0xf3, 0x70,
-	0x8f, 0x0c, 0xa0, 0xff, 0xf2, 0xe2, 0xf2, 0x7a, 0x7e, 0xf1, 0xdd, 0x64, 0x76, 0x3d, 0x79, 0x75,
-	0x15, 0x7a, 0xe3, 0xbf, 0x5b, 0xe0, 0xbf, 0x2c, 0xbf, 0x46, 0xc8, 0x09, 0x74, 0x8a, 0x99, 0x4e,
-	0xdc, 0xfd, 0xd5, 0xd3, 0x7e, 0x34, 0x68, 0x58, 0x6c, 0x5d, 0xd0, 0x3d, 0xf2, 0x15, 0xf8, 0xd5,
-	0x34, 0x25, 0xb6, 0x6a, 0x36, 0xe7, 0xfc, 0xe8, 0x83, 0x4d, 0x73, 0xb5, 0xfb, 0x04, 0x3a, 0xc5,
-	0x10, 0x72, 0xc1, 0x1a, 0x03, 0xd0, 0x05, 0x6b, 0x4e, 0x28, 0xba, 0x47, 0xce, 0x61, 0xdf, 0x74,
-	0x50, 0xe2, 0xea, 0xbc, 0xd1, 0xc6, 0x47, 0xa4, 0x69, 0xaa, 0x76, 0x1c, 0x43, 0xfb, 0x0a, 0x35,
-	0x39, 0x34, 0xce, 0xba, 0x73, 0x8e, 0xc2, 0xda, 0xd0, 0xc4, 0x4e, 0xf3, 0x12, 0x5b, 0x37, 0xab,
-	0x51, 0x58, 0x1b, 0x2a, 0xec, 0x53, 0xf0, 0xab, 0x67, 0xe4, 0x64, 0x6f, 0x3e, 0x6a, 0x27, 0x7b,
-	0xeb, 0xb5, 0xd1, 0xbd, 0x73, 0x6f, 0x71, 0x60, 0x3e, 0xf3, 0xbe, 0xf8, 0x27, 0x00, 0x00, 0xff,
-	0xff, 0x29, 0xb8, 0x98, 0x95, 0x37, 0x0a, 0x00, 0x00,
+	// 2395 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xcc, 0x59, 0xcb, 0x6f, 0x1c, 0x4b,
+	0xd5, 0x4f, 0xcf, 0xcb, 0x33, 0x67, 0x66, 0xec, 0x71, 0xe5, 0x35, 0x9e, 0xc4, 0x49, 0xbe, 0x4e,
+	0xbe, 0xc8, 0x8a, 0x6e, 0x1e, 0xf8, 0xea, 0x6e, 0x10, 0x82, 0x6b, 0x27, 0xce, 0x83, 0x38, 0xb1,
+	0xa9, 0x71, 0x74, 0xd9, 0xb5, 0x6a, 0xba, 0xcb, 0x76, 0xcb, 0xd3, 0xd5, 0x4d, 0x57, 0x75, 0xe2,
+	0xb9, 0x7b, 0x56, 0x48, 0x88, 0x05, 0x0b, 0xc4, 0x8a, 0x05, 0x62, 0x85, 0xd8, 0xb1, 0xe2, 0x1f,
+	0x80, 0x25, 0x2b, 0x96, 0x2c, 0xd9, 0xb0, 0xbe, 0x48, 0x2c, 0x10, 0xaa, 0x47, 0x3f, 0xa6, 0x67,
+	0x6c, 0x99, 0x49, 0x84, 0xd8, 0x58, 0x53, 0xbf, 0x73, 0xea, 0xd4, 0xa9, 0xdf, 0x39, 0x75, 0xea,
+	0x74, 0x19, 0xee, 0x8e, 0xfd, 0x51, 0xec, 0x3f, 0x96, 0x7f, 0x49, 0xec, 0x13, 0xf6, 0x98, 0x44,
+	0x85, 0xd1, 0xa3, 0x28, 0x0e, 0x45, 0x88, 0xaa, 0x24, 0xf2, 0x07, 0x73, 0x35, 0xbd, 0xd0, 0x4d,
+	0x02, 0xca, 0x04, 0xd7, 0x9a, 0xf6, 0x29, 0xac, 0x60, 0xfa, 0xa3, 0x84, 0x72, 0xf1, 0x86, 0x0a,
+	0xe2, 0x11, 0x41, 0xd0, 0x3a, 0x40, 0xac, 0x21, 0xc7, 0xf7, 0xfa, 0xd6, 0x1d, 0x6b, 0xa3, 0x83,
+	0x5b, 0x06, 0x79, 0xe5, 0xa1, 0xeb, 0xb0, 0x14, 0x25, 0x23, 0xe7, 0x84, 0x4e, 0xfa, 0x15, 0x25,
+	0x6b, 0x44, 0xc9, 0xe8, 0x35, 0x9d, 0xa0, 0x87, 0x70, 0x39, 0x8a, 0xc3, 0xf0, 0xd0, 0x09, 0x0f,
+	0x9d, 0x0f, 0x61, 0x7c, 0xe2, 0xb0, 0x90, 0xb9, 0xb4, 0x5f, 0xbd, 0x63, 0x6d, 0xd4, 0x70, 0x4f,
+	0x89, 0xf6, 0x0e, 0xbf, 0x0a, 0xe3, 0x93, 0xb7, 0x12, 0xb7, 0xbf, 0x0f, 0x3d, 0x4c, 0x79, 0x14,
+	0x32, 0x4e, 0x3f, 0x76, 0x69, 0xbb, 0x0b, 0xed, 0x7d, 0x9f, 0x1d, 0x99, 0x9d, 0xd8, 0x1b, 0xd0,
+	0xd1, 0x43, 0x6d, 0x1e, 0xf5, 0x61, 0x29, 0xa0, 0x9c, 0x93, 0x23, 0xaa, 0x6c, 0xb6, 0x70, 0x3a,
+	0xb4, 0x7f, 0x6f, 0x41, 0xef, 0x15, 0x13, 0x71, 0xe8, 0x25, 0x2e, 0x35, 0xd3, 0xd1, 0x13, 0x68,
+	0x06, 0xc6, 0x23, 0xa5, 0xdf, 0xde, 0xbc, 0xf2, 0x88, 0x44, 0xfe, 0xa3, 0x12, 0x51, 0x38, 0xd3,
+	0x42, 0xf7, 0xa0, 0xc6, 0xe9, 0xf8, 0x50, 0x79, 0xd5, 0xde, 0xec, 0x29, 0xed, 0x7d, 0x4a, 0xe3,
+	0x2d, 0xcf, 0x8b, 0x29, 0xe7, 0x58, 0x49, 0xd1, 0x0d, 0x68, 0xb1, 0x24, 0x70, 0x22, 0x4a, 0x63,
+	0xae, 0x68, 0xe9, 0xe2, 0x26, 0x4b, 0x02, 0xa9, 0xc8, 0xd1, 0x67, 0xd0, 0x8c, 0x43, 0x41, 0x84,
+	0x1f, 0xb2, 0x7e, 0xad, 0x60, 0xe6, 0x35, 0x9d, 0x60, 0x83, 0xe3, 0x4c, 0xc3, 0x3e, 0x81, 0x76,
+	0x41, 0x80, 0x6e, 0x41, 0x3b, 0x1c, 0x7b, 0x4e, 0x4a, 0x8e, 0x21, 0x2e, 0x1c, 0x7b, 0xfb, 0x3a,
+	0x34, 0xb7, 0xa0, 0xcd, 0xe8, 0x07, 0x67, 0x9a, 0xbc, 0x16, 0xa3, 0x1f, 0x8c, 0xfc, 0x26, 0xb4,
+	0xb8, 0x7f, 0xc4, 0x88, 0x48, 0x62, 0x1d, 0xb0, 0x16, 0xce, 0x01, 0xfb, 0xd7, 0x16, 0xa0, 0x21,
+	0xe5, 0xdc, 0x0f, 0xd9, 0x53, 0x1a, 0x0b, 0xff, 0xd0, 0x77, 0x89, 0xa0, 0x68, 0x03, 0x7a, 0xbe,
+	0x47, 0x99, 0xf0, 0xc5, 0xa4, 0xb4, 0xf2, 0x72, 0x8a, 0x1b, 0xf3, 0xf7, 0x61, 0x85, 0xeb, 0xf9,
+	0x25, 0x17, 0xba, 0x06, 0xce, 0xdc, 0x04, 0x7a, 0x1a, 0xf9, 0xb1, 0x66, 0x41, 0xfa, 0x51, 0xc5,
+	0x05, 0x64, 0xda, 0xcd, 0x5a, 0xd9, 0xcd, 0x9f, 0x5b, 0xb0, 0x5a, 0x88, 0xa5, 0x89, 0xfd, 0xb7,
+	0x66, 0x82, 0x79, 0xd5, 0x04, 0x73, 0x3a, 0xf7, 0xfe, 0xe3, 0x68, 0xde, 0x87, 0x7a, 0x1a, 0xc9,
+	0xea, 0x5c, 0x35, 0x2d, 0xb6, 0xff, 0x66, 0x41, 0xfb, 0xb9, 0xcf, 0xbc, 0xc5, 0xb3, 0xab, 0x07,
+	0xd5, 0x9c, 0x32, 0xf9, 0xf3, 0xfc, 0x4c, 0x5a, 0x07, 0x50, 0x02, 0x27, 0x64, 0xe3, 0x89, 0xa2,
+	0xa9, 0x89, 0x5b, 0x0a, 0xd9, 0x63, 0xe3, 0x09, 0xfa, 0x1c, 0x3a, 0xc4, 0x75, 0x29, 0xe7, 0x8e,
+	0x3a, 0x92, 0xfd, 0x7a, 0x61, 0x97, 0x5b, 0x4a, 0xb0, 0x2f, 0x71, 0xdc, 0x26, 0xf9, 0x00, 0xdd,
+	0x85, 0x6e, 0x14, 0x53, 0x4e, 0x99, 0x4b, 0xb5, 0xd9, 0x86, 0x32, 0xdb, 0x49, 0x41, 0x69, 0xd9,
+	0x76, 0xa1, 0x5d, 0x30, 0x90, 0xba, 0x6d, 0xe5, 0x6e, 0xdf, 0x83, 0xe5, 0x98, 0x12, 0x8f, 0xc6,
+	0xa5, 0x34, 0xe8, 0x68, 0xf4, 0x42, 0xc9, 0xf8, 0x1b, 0x0b, 0x3a, 0x9a, 0xce, 0xc5, 0x03, 0x9c,
+	0x85, 0xae, 0x72, 0x6e, 0xe8, 0xd0, 0x5d, 0xa8, 0xbf, 0x27, 0xe3, 0x44, 0x7b, 0xd1, 0xde, 0xec,
+	0x2a, 0xbd, 0x67, 0xa6, 0x82, 0x62, 0x2d, 0x43, 0xd7, 0xa0, 0xc1, 0x45, 0x18, 0x53, 0xcf, 0x50,
+	0x6d, 0x46, 0xf6, 0x11, 0xb4, 0x0b, 0x26, 0x55, 0xed, 0xa2, 0x34, 0xce, 0xeb, 0x5a, 0x43, 0x0e,
+	0x5f, 0x79, 0x32, 0x96, 0x4a, 0xc0, 0x48, 0x40, 0x15, 0x1f, 0x2d, 0xdc, 0x94, 0xc0, 0x5b, 0x12,
+	0x50, 0xb4, 0x0c, 0x15, 0x3f, 0x32, 0x24, 0x54, 0xfc, 0x08, 0x21, 0xa8, 0x45, 0x61, 0x2c, 0xd4,
+	0x52, 0x5d, 0xac, 0x7e, 0xdb, 0xbf, 0xb0, 0xa0, 0x33, 0x94, 0x6b, 0x7e, 0xca, 0x0c, 0xbb, 0xd0,
+	0xd6, 0x6f, 0x83, 0x49, 0x12, 0xe7, 0x98, 0xf0, 0x63, 0xe5, 0x54, 0x07, 0x83, 0x86, 0x5e, 0x12,
+	0x7e, 0x6c, 0x6f, 0x43, 0xd7, 0x78, 0xb6, 0x70, 0xb0, 0xec, 0x1f, 0x5b, 0x00, 0x2f, 0xa8, 0xf8,
+	0x94, 0x9b, 0x2b, 0x1f, 0x81, 0xea, 0x05, 0x8e, 0x80, 0xfd, 0x17, 0x0b, 0xda, 0xca, 0x8f, 0xc5,
+	0xf3, 0x2e, 0x23, 0xb5, 0x72, 0x0e, 0xa9, 0x37, 0xa1, 0x45, 0x4f, 0x8f, 0x49, 0xc2, 0x05, 0xf5,
+	0x94, 0x67, 0x4d, 0x9c, 0x03, 0xe8, 0x0b, 0xe8, 0xba, 0xe3, 0x90, 0xcb, 0x1b, 0x52, 0xa7, 0x70,
+	0xed, 0x8c, 0x14, 0xee, 0x18, 0x35, 0x5d, 0x13, 0x6e, 0x40, 0x4b, 0xf8, 0x01, 0xf5, 0x9c, 0x30,
+	0x11, 0xea, 0xc4, 0x37, 0x71, 0x53, 0x01, 0x7b, 0x89, 0x90, 0x97, 0x20, 0xec, 0x27, 0xe2, 0xbf,
+	0x9e, 0x3e, 0xeb, 0x00, 0xcc, 0x89, 0x69, 0x34, 0xf6, 0x5d, 0xc2, 0x4d, 0x4a, 0xb7, 0x18, 0x36,
+	0x40, 0x39, 0xbb, 0xea, 0x33, 0xd9, 0xf5, 0x33, 0x0b, 0xda, 0xca, 0xef, 0xc5, 0x23, 0xf2, 0x18,
+	0x5a, 0x61, 0x44, 0xcd, 0x85, 0x23, 0xfd, 0x5f, 0xde, 0x5c, 0xd5, 0x54, 0x26, 0x62, 0x2f, 0x15,
+	0xe0, 0x5c, 0xa7, 0xe4, 0x73, 0xb5, 0xe4, 0xb3, 0xfd, 0x4d, 0x05, 0x7a, 0xc3, 0x64, 0xc4, 0xdd,
+	0xd8, 0x1f, 0x7d, 0xc4, 0x79, 0xfc, 0x02, 0x3a, 0x5c, 0x5b, 0x89, 0x32, 0xcf, 0xda, 0xc6, 0xb3,
+	0x61, 0x41, 0x80, 0xa7, 0xd4, 0x64, 0x95, 0x3e, 0x8c, 0xc3, 0xc0, 0xe1, 0xd2, 0x70, 0xde, 0x7b,
+	0x75, 0x24, 0x38, 0x34, 0x98, 0xac, 0x57, 0x1f, 0x7c, 0xe6, 0x85, 0x1f, 0x0c, 0xe3, 0x66, 0x24,
+	0x0b, 0x14, 0x73, 0x88, 0x7b, 0x42, 0x3d, 0x45, 0x75, 0x0d, 0x37, 0xd8, 0x96, 0x1c, 0xc9, 0xbe,
+	0x2e, 0x20, 0xa7, 0xb2, 0x64, 0x73, 0x27, 0xa2, 0xb1, 0xc3, 0xa9, 0x1b, 0x32, 0x4f, 0xdd, 0x00,
+	0x15, 0xdc, 0x0b, 0xc8, 0xe9, 0x7e, 0x32, 0xe2, 0xfb, 0x34, 0x1e, 0x2a, 0x5c, 0x16, 0x79, 0xa9,
+	0x3e, 0x22, 0xc2, 0x3d, 0x76, 0xb8, 0xff, 0x35, 0xed, 0x2f, 0xa9, 0x75, 0x3a, 0x01, 0x39, 0xdd,
+	0x96, 0xe0, 0xd0, 0xff, 0x9a, 0xca, 0x96, 0x20, 0xd7, 0x1a, 0x4d, 0x04, 0xe5, 0xfd, 0xa6, 0x52,
+	0xeb, 0xa6, 0x6a, 0xdb, 0x12, 0x9c, 0xd6, 0xf3, 0xe8, 0x98, 0x4c, 0xfa, 0x2d, 0xd5, 0x17, 0x64,
+	0x7a, 0xcf, 0x24, 0x68, 0xff, 0xdd, 0x82, 0xd5, 0x02, 0xf1, 0x8b, 0x67, 0xc4, 0x6c, 0x2e, 0xdf,
+	0x9f, 0xce, 0x65, 0x73, 0xd4, 0x92, 0x91, 0x8c, 0xb8, 0x0a, 0x82, 0x16, 0xa3, 0x01, 0x34, 0x33,
+	0xe2, 0x6b, 0x8a, 0xc1, 0x6c, 0x2c, 0x73, 0x39, 0x8c, 0xfd, 0x23, 0x9f, 0x39, 0xf2, 0xd4, 0x29,
+	0x82, 0xab, 0x18, 0x34, 0x74, 0xe0, 0x07, 0x14, 0x3d, 0x84, 0xba, 0xda, 0x63, 0xbf, 0xa1, 0xce,
+	0xf3, 0x75, 0xb5, 0x88, 0xda, 0x1f, 0xf5, 0xa6, 0xd6, 0x52, 0x5a, 0xf6, 0x4f, 0x2c, 0x40, 0xb3,
+	0xd2, 0x39, 0x57, 0xee, 0xfd, 0xe9, 0x92, 0x73, 0x21, 0xe7, 0xab, 0xe7, 0x3b, 0x5f, 0x2b, 0x3b,
+	0x6f, 0xef, 0x42, 0xbf, 0x98, 0x95, 0x43, 0x41, 0x04, 0x5f, 0x38, 0xf9, 0xed, 0x7f, 0x54, 0x60,
+	0x6d, 0x8e, 0xb9, 0xc5, 0x43, 0xfa, 0x04, 0x96, 0x7c, 0x36, 0x0a, 0x13, 0xe6, 0x99, 0x0b, 0xff,
+	0xda, 0xcc, 0x41, 0xd2, 0x6b, 0xa4, 0x6a, 0x68, 0x13, 0x9a, 0x61, 0x22, 0xf4, 0x94, 0xea, 0xb9,
+	0x53, 0x32, 0x3d, 0x74, 0x15, 0x1a, 0xcc, 0xe1, 0x94, 0x09, 0x13, 0xfc, 0x3a, 0x1b, 0x52, 0x26,
+	0x54, 0xab, 0xe6, 0x78, 0x71, 0x18, 0x45, 0xd9, 0xc1, 0x6a, 0xb2, 0x67, 0x7a, 0x9c, 0x56, 0x13,
+	0x97, 0xfa, 0xef, 0xa9, 0x3e, 0x51, 0x35, 0x55, 0x4d, 0x34, 0x80, 0x3e, 0x03, 0x94, 0x8b, 0x33,
+	0x23, 0x4b, 0xfa, 0x83, 0x2a, 0x53, 0x4b, 0x8d, 0x7d, 0x09, 0xbd, 0x4c, 0x77, 0x4c, 0x04, 0x65,
+	0xee, 0xa4, 0xdf, 0x2c, 0x30, 0xb4, 0xab, 0xb1, 0x97, 0x3e, 0x17, 0xe1, 0x51, 0x4c, 0x02, 0xbc,
+	0x92, 0xaa, 0x1b, 0x89, 0xfd, 0xaf, 0xfc, 0x10, 0xe5, 0x5b, 0x3c, 0xbb, 0x73, 0xb9, 0x07, 0xcb,
+	0x24, 0x11, 0xc7, 0x61, 0xec, 0x1c, 0x46, 0x4e, 0x4c, 0x84, 0x4e, 0xb2, 0x0a, 0xee, 0x68, 0xf4,
+	0x79, 0x84, 0x89, 0xa0, 0x85, 0xa6, 0x2f, 0xd5, 0xaa, 0x6a, 0x2d, 0x8d, 0x1a, 0x2d, 0xc5, 0x9e,
+	0x2c, 0x31, 0x19, 0x7b, 0xb2, 0xaa, 0x9c, 0xcf, 0xde, 0x6d, 0x68, 0x73, 0x12, 0x44, 0x63, 0xaa,
+	0xcd, 0xea, 0x82, 0x04, 0x1a, 0x52, 0x46, 0x1f, 0xc3, 0x52, 0x4a, 0xc4, 0xd2, 0x79, 0x44, 0xa4,
+	0x5a, 0x36, 0x81, 0x5e, 0x59, 0x28, 0x8b, 0xea, 0x28, 0x71, 0x4f, 0xa8, 0x70, 0x54, 0x9c, 0x79,
+	0xdf, 0xba, 0x53, 0xdd, 0xa8, 0xe2, 0x8e, 0x06, 0xb7, 0x15, 0x26, 0x8b, 0xaa, 0x1b, 0x26, 0x4c,
+	0xe8, 0x96, 0xb2, 0x86, 0xcd, 0x48, 0x1e, 0x48, 0x9e, 0x04, 0xe6, 0x53, 0x46, 0xfe, 0xb4, 0xff,
+	0x50, 0x81, 0x76, 0xe1, 0xfc, 0xa1, 0xff, 0x83, 0x0e, 0x65, 0xef, 0xe9, 0x38, 0x8c, 0x68, 0xe1,
+	0x0b, 0xaa, 0x9d, 0x62, 0xaf, 0x75, 0xb7, 0x4f, 0x99, 0x88, 0x27, 0x85, 0x8e, 0xb9, 0xa9, 0x00,
+	0x29, 0x7c, 0x00, 0xab, 0x26, 0x08, 0x91, 0xb2, 0xaa, 0x94, 0xaa, 0x4a, 0x69, 0x45, 0x0b, 0xf4,
+	0x6a, 0x46, 0x37, 0xef, 0xbf, 0x53, 0x5d, 0xdd, 0xb5, 0xad, 0x64, 0x2d, 0xb8, 0xd1, 0xfd, 0x1e,
+	0xf4, 0xf4, 0xa2, 0x44, 0x88, 0xd8, 0x1f, 0x25, 0xb2, 0x42, 0xd7, 0x0b, 0xe7, 0x77, 0x47, 0x0a,
+	0xb7, 0x32, 0x19, 0x5e, 0xa1, 0xd3, 0x00, 0xfa, 0x7f, 0x58, 0xa6, 0xf4, 0xc4, 0x71, 0xfd, 0xe8,
+	0x98, 0xc6, 0x82, 0x9e, 0x0a, 0x15, 0xa0, 0x0e, 0xee, 0x52, 0x7a, 0xf2, 0x34, 0x03, 0x65, 0x8e,
+	0x4f, 0xab, 0x39, 0x01, 0x71, 0x55, 0xb8, 0x3a, 0xb8, 0x37, 0xa5, 0xfa, 0x86, 0xb8, 0xf6, 0x37,
+	0xb2, 0xd7, 0x2d, 0x5e, 0x79, 0xdf, 0x05, 0x34, 0xb3, 0x7d, 0xde, 0xb7, 0x0a, 0xc5, 0x6e, 0x7b,
+	0x1c, 0x86, 0xc1, 0x73, 0x7f, 0x2c, 0x68, 0x8c, 0x7b, 0x25, 0x46, 0xb8, 0x9c, 0x3f, 0x43, 0x09,
+	0xef, 0x57, 0xce, 0x9a, 0x5f, 0x62, 0x89, 0xa3, 0x9d, 0x39, 0x34, 0xe9, 0x7b, 0x62, 0x30, 0x8f,
+	0x26, 0x63, 0x67, 0x86, 0xac, 0x52, 0x2a, 0xd7, 0xca, 0xa9, 0x6c, 0xff, 0xca, 0x82, 0xab, 0x73,
+	0x6d, 0xc9, 0xa9, 0x01, 0xf5, 0x7c, 0xe2, 0x88, 0x49, 0x44, 0x75, 0x76, 0xb6, 0x30, 0x28, 0xe8,
+	0x40, 0x22, 0x68, 0x13, 0xae, 0x06, 0x3e, 0x73, 0x12, 0xe6, 0x86, 0x81, 0xfc, 0x5e, 0xe3, 0xd4,
+	0xd3, 0xf7, 0x72, 0x45, 0x9d, 0xa7, 0xcb, 0x81, 0xcf, 0xde, 0x15, 0x64, 0xea, 0x7a, 0x96, 0x73,
+	0xc8, 0xe9, 0x9c, 0x39, 0x55, 0x33, 0x87, 0x9c, 0x96, 0xe7, 0xd8, 0xbb, 0xd0, 0x2e, 0x70, 0x25,
+	0x1f, 0x5d, 0x28, 0x73, 0x43, 0x8f, 0xa6, 0x65, 0x23, 0x1d, 0xa2, 0xbb, 0x50, 0x93, 0xbe, 0x9a,
+	0x7e, 0x6b, 0x45, 0xf1, 0xa4, 0x27, 0x49, 0x87, 0xb1, 0x12, 0xda, 0x6f, 0x60, 0x0d, 0x53, 0x97,
+	0x32, 0x51, 0x38, 0x2c, 0x1f, 0x71, 0xa9, 0x1c, 0xc1, 0x6d, 0x4c, 0xe5, 0x0e, 0x3e, 0xa1, 0x51,
+	0xf9, 0x35, 0x96, 0x11, 0xd9, 0xc5, 0xea, 0xb7, 0xfd, 0x47, 0x0b, 0x06, 0xf3, 0xd6, 0x58, 0xfc,
+	0xfa, 0x9a, 0xb3, 0x8a, 0xac, 0x2b, 0x63, 0xca, 0x4c, 0xff, 0x29, 0x7f, 0xea, 0x02, 0x7a, 0xec,
+	0x8b, 0xbc, 0x80, 0xbe, 0xf4, 0x05, 0x47, 0x6b, 0xd0, 0x64, 0x4e, 0xe0, 0x73, 0x6e, 0x8e, 0x6f,
+	0x0d, 0x2f, 0xb1, 0x37, 0x6a, 0x28, 0x13, 0x87, 0x39, 0xf4, 0xbd, 0xef, 0x2a, 0x0f, 0xcd, 0xed,
+	0x03, 0x6c, 0x27, 0x45, 0xec, 0x9f, 0x56, 0xe0, 0xfa, 0xd0, 0x25, 0xec, 0xd3, 0x90, 0x35, 0xd3,
+	0x9c, 0x56, 0xe6, 0x34, 0xa7, 0xeb, 0x00, 0x5c, 0x90, 0x58, 0xe8, 0x4e, 0x43, 0x97, 0xcd, 0x96,
+	0x42, 0x54, 0x97, 0xb4, 0x06, 0x4d, 0xca, 0xbc, 0x62, 0x1b, 0xb2, 0x44, 0x99, 0xa7, 0x44, 0x77,
+	0x40, 0x59, 0x72, 0xd2, 0xab, 0xca, 0x7c, 0x2e, 0x48, 0x6c, 0x5f, 0x5f, 0x57, 0x73, 0x2b, 0x65,
+	0x63, 0x7e, 0xa5, 0xbc, 0x02, 0xf5, 0xb1, 0x1f, 0xf8, 0xc2, 0xf4, 0xae, 0x7a, 0x60, 0xff, 0xce,
+	0x82, 0xfe, 0x2c, 0x21, 0x8b, 0x47, 0xf6, 0xdb, 0xd0, 0x89, 0x0a, 0xa6, 0xa6, 0xba, 0x93, 0xdd,
+	0xf0, 0xe8, 0x68, 0xba, 0xf5, 0x9b, 0xd2, 0x95, 0x74, 0x32, 0x59, 0x2d, 0xcb, 0xbd, 0xbe, 0x04,
+	0x53, 0x3a, 0xed, 0x3f, 0x5b, 0xb0, 0x3a, 0x63, 0x68, 0xaa, 0xd7, 0xb3, 0x4a, 0xbd, 0xde, 0xe2,
+	0xed, 0xef, 0x4d, 0xfd, 0x89, 0xc9, 0x05, 0x09, 0x22, 0x13, 0x9c, 0x1c, 0x90, 0x7d, 0xbc, 0x0e,
+	0x4f, 0x4e, 0xbd, 0x8e, 0x90, 0x4a, 0x8a, 0x9c, 0xf8, 0x72, 0x18, 0x1b, 0xe5, 0x30, 0xda, 0xff,
+	0xb4, 0xe0, 0xb2, 0x0c, 0xc2, 0x56, 0xe2, 0xf9, 0x62, 0x37, 0x3c, 0xfa, 0x9f, 0xcd, 0x48, 0x75,
+	0xdb, 0xaa, 0xb5, 0x69, 0x9c, 0xed, 0xa7, 0x9e, 0xde, 0xb6, 0x46, 0x60, 0x72, 0xb3, 0x07, 0xd5,
+	0x38, 0x72, 0xd5, 0x6e, 0x5b, 0x58, 0xfe, 0x3c, 0x23, 0x03, 0x7f, 0x69, 0xc1, 0x95, 0xe9, 0xcd,
+	0x2f, 0x9e, 0x7d, 0x0f, 0x60, 0x29, 0xa6, 0x6e, 0x18, 0x7b, 0xd3, 0xef, 0x60, 0xca, 0x34, 0x56,
+	0x02, 0x9c, 0x2a, 0x5c, 0x2c, 0xdb, 0xfe, 0x6a, 0x41, 0xbb, 0x30, 0xfb, 0xdc, 0x3c, 0x9b, 0xca,
+	0x96, 0x4a, 0x39, 0x5b, 0x9e, 0xc0, 0x95, 0x02, 0x75, 0xe5, 0xbe, 0x06, 0xe5, 0xec, 0x4d, 0xb7,
+	0x36, 0x65, 0xb2, 0x6b, 0xe7, 0x92, 0x5d, 0xcf, 0xc9, 0x36, 0x59, 0xdf, 0xc8, 0xb3, 0xfe, 0x0a,
+	0xd4, 0x69, 0x1c, 0x87, 0xb1, 0xa2, 0xbf, 0x85, 0xf5, 0x40, 0xbe, 0x45, 0x5d, 0x7d, 0x17, 0x79,
+	0x44, 0xd0, 0x67, 0x94, 0x4d, 0xc6, 0x3e, 0xff, 0x88, 0x47, 0x93, 0x35, 0x68, 0x12, 0xcf, 0x4b,
+	0xfb, 0x8d, 0xaa, 0xbc, 0x20, 0x89, 0xe7, 0xa9, 0xa6, 0xe2, 0x36, 0xb4, 0x63, 0x1a, 0x84, 0xef,
+	0xa9, 0x96, 0x56, 0x95, 0x14, 0x34, 0x24, 0x15, 0x6c, 0x07, 0xae, 0x95, 0xdd, 0xf8, 0xa8, 0xfb,
+	0xa5, 0xe0, 0x84, 0xfa, 0xfd, 0xe0, 0x21, 0x74, 0x8a, 0x2f, 0x20, 0x08, 0xa0, 0x31, 0x3c, 0xd8,
+	0xc3, 0x3b, 0xcf, 0x7a, 0x97, 0xd0, 0x2a, 0x74, 0x77, 0x77, 0x9e, 0x1f, 0x38, 0x3b, 0x3f, 0x7c,
+	0x35, 0x3c, 0x78, 0xf5, 0xf6, 0x45, 0xcf, 0x7a, 0x70, 0x17, 0x20, 0xbf, 0xc0, 0x51, 0x0b, 0xea,
+	0xdb, 0xbb, 0x7b, 0x7b, 0x6f, 0x7a, 0x97, 0xe4, 0xbc, 0xa7, 0xef, 0x9e, 0xbe, 0xde, 0xdb, 0xeb,
+	0x59, 0x9b, 0x7f, 0xaa, 0x42, 0x6b, 0x37, 0xfd, 0x67, 0x14, 0x7a, 0x08, 0x35, 0xf9, 0x3f, 0x1a,
+	0x64, 0xea, 0x49, 0xfe, 0xdf, 0x9b, 0xc1, 0x6a, 0x01, 0xd1, 0x4e, 0xdb, 0x97, 0xd0, 0x77, 0xa0,
+	0x95, 0xbd, 0xed, 0x23, 0xbd, 0xa5, 0xf2, 0xff, 0x6d, 0x06, 0xd7, 0xca, 0x70, 0x36, 0xfb, 0x21,
+	0xd4, 0xe4, 0x9b, 0xb1, 0x59, 0xac, 0xf0, 0x1a, 0x3f, 0x58, 0x2d, 0x20, 0x99, 0xfa, 0x13, 0xa8,
+	0xab, 0x67, 0x4b, 0xa4, 0xa5, 0xc5, 0xc7, 0xd5, 0x01, 0x2a, 0x42, 0xd9, 0x8c, 0x07, 0x50, 0x7d,
+	0x41, 0x05, 0xd2, 0xbd, 0x4c, 0xfe, 0x5a, 0x39, 0xe8, 0xe5, 0x40, 0x51, 0x77, 0x3f, 0x49, 0x75,
+	0xf7, 0x93, 0x92, 0x6e, 0xe1, 0x41, 0xcb, 0xbe, 0x84, 0xbe, 0x84, 0x56, 0xf6, 0xaa, 0x61, 0xb6,
+	0x5d, 0x7e, 0x5e, 0x1a, 0x5c, 0x2b, 0xc3, 0xe9, 0xec, 0x0d, 0xeb, 0x89, 0x85, 0x0e, 0xe6, 0x7d,
+	0xd2, 0xad, 0x9f, 0xf1, 0x35, 0x6b, 0x2c, 0xde, 0x3a, 0x4b, 0x9c, 0x5a, 0xde, 0xfc, 0x6d, 0x15,
+	0xea, 0x5b, 0x5e, 0xe0, 0x33, 0xf4, 0x15, 0xa0, 0xd9, 0x76, 0x07, 0xdd, 0x32, 0x49, 0x77, 0x46,
+	0xaf, 0x35, 0xb8, 0x7d, 0xa6, 0x3c, 0xdb, 0xba, 0x0b, 0xfd, 0xb3, 0x3a, 0x36, 0x74, 0x2f, 0xcd,
+	0xe9, 0xf3, 0x1a, 0xba, 0x8b, 0x2c, 0xf2, 0x03, 0xe8, 0x95, 0x2f, 0x74, 0x74, 0x53, 0xef, 0x7e,
+	0x7e, 0xe3, 0x33, 0x58, 0x3f, 0x43, 0x9a, 0x99, 0xdc, 0x81, 0x4e, 0xb1, 0x42, 0xa3, 0x7e, 0x36,
+	0xa1, 0x74, 0x63, 0x0d, 0xd6, 0xe6, 0x48, 0x32, 0x33, 0xaf, 0x61, 0x79, 0xfa, 0x88, 0x23, 0xfd,
+	0x41, 0x31, 0xb7, 0xfc, 0x0c, 0x6e, 0xcc, 0x95, 0xa5, 0xc6, 0x46, 0x0d, 0xf5, 0xcf, 0xde, 0xcf,
+	0xff, 0x3d, 0x00, 0xf0, 0x52, 0xa5, 0x95, 0x3d, 0x1e, 0x00, 0x00,
 }
@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/binary"
+	"os"
+	"time"
 
 	"errors"
 )
@@ -32,6 +34,33 @@ const (
 	// entry.
 	MetadataEntryFilepath = metadataEntryPrefix + "filepath"
 
+	// MetadataEntryFileMode indicates the file mode permissions of the data contained in the
+	// entry.
+	MetadataEntryFileMode = metadataEntryPrefix + "file_mode"
+
+	// MetadataEntryModifiedTime indicates the file modified time (in Unix nanoseconds) of the
+	// data contained in the entry.
+	MetadataEntryModifiedTime = metadataEntryPrefix + "modified_time"
+
+	// MetadataEntryCompressionCodec indicates the compression codec of the entry's pages.
+	MetadataEntryCompressionCodec = metadataEntryPrefix + "compression_codec"
+
+	// MetadataEntryChunking indicates how the entry's content is split into pages.
+	MetadataEntryChunking = metadataEntryPrefix + "chunking"
+
+	// MetadataEntryAEADSuite indicates the AEAD suite the entry's pages are encrypted with.
+	MetadataEntryAEADSuite = metadataEntryPrefix + "aead_suite"
+
+	// MetadataEntryDataShards indicates the number of erasure-coded data shards per page.
+	MetadataEntryDataShards = metadataEntryPrefix + "data_shards"
+
+	// MetadataEntryParityShards indicates the number of erasure-coded parity shards per page.
+	MetadataEntryParityShards = metadataEntryPrefix + "parity_shards"
+
+	// MetadataEntryPageSize indicates the size of the compressed content in each of the entry's
+	// pages but the last, when the content is split into pages at fixed offsets.
+	MetadataEntryPageSize = metadataEntryPrefix + "page_size"
+
 	// MetadataEntrySchema indicates the schema (however defined) of the data contained in the
 	// entry.
 	MetadataEntrySchema = metadataEntryPrefix + "schema"
@@ -85,6 +114,16 @@ func ValidateMetadata(m *Metadata) error {
 	return nil
 }
 
+// GetEntryAttributes returns the *EntryAttributes an author may disclose in an Envelope.
+func (m *Metadata) GetEntryAttributes() *EntryAttributes {
+	mediaType, _ := m.GetMediaType()
+	uncompressedSize, _ := m.GetUncompressedSize()
+	return &EntryAttributes{
+		MediaType:        mediaType,
+		UncompressedSize: uncompressedSize,
+	}
+}
+
 // GetMediaType returns the media type.
 func (m *Metadata) GetMediaType() (string, bool) {
 	return m.GetString(MetadataEntryMediaType)
@@ -110,6 +149,97 @@ func (m *Metadata) GetUncompressedMAC() ([]byte, bool) {
 	return m.GetBytes(MetadataEntryUncompressedMAC)
 }
 
+// GetCompressionCodec returns the compression codec.
+func (m *Metadata) GetCompressionCodec() (string, bool) {
+	return m.GetString(MetadataEntryCompressionCodec)
+}
+
+// SetCompressionCodec sets the compression codec.
+func (m *Metadata) SetCompressionCodec(value string) {
+	m.SetString(MetadataEntryCompressionCodec, value)
+}
+
+// GetChunking returns the chunking.
+func (m *Metadata) GetChunking() (string, bool) {
+	return m.GetString(MetadataEntryChunking)
+}
+
+// SetChunking sets the chunking.
+func (m *Metadata) SetChunking(value string) {
+	m.SetString(MetadataEntryChunking, value)
+}
+
+// GetAEADSuite returns the AEAD suite.
+func (m *Metadata) GetAEADSuite() (string, bool) {
+	return m.GetString(MetadataEntryAEADSuite)
+}
+
+// SetAEADSuite sets the AEAD suite.
+func (m *Metadata) SetAEADSuite(value string) {
+	m.SetString(MetadataEntryAEADSuite, value)
+}
+
+// GetErasureShards returns the number of data and parity shards per page.
+func (m *Metadata) GetErasureShards() (uint64, uint64, bool) {
+	dataShards, in := m.GetUint64(MetadataEntryDataShards)
+	if !in {
+		return 0, 0, false
+	}
+	parityShards, in := m.GetUint64(MetadataEntryParityShards)
+	return dataShards, parityShards, in
+}
+
+// SetErasureShards sets the number of data and parity shards per page.
+func (m *Metadata) SetErasureShards(dataShards, parityShards uint64) {
+	m.SetUint64(MetadataEntryDataShards, dataShards)
+	m.SetUint64(MetadataEntryParityShards, parityShards)
+}
+
+// GetPageSize returns the size of the compressed content in each page but the last.
+func (m *Metadata) GetPageSize() (uint64, bool) {
+	return m.GetUint64(MetadataEntryPageSize)
+}
+
+// SetPageSize sets the size of the compressed content in each page but the last.
+func (m *Metadata) SetPageSize(value uint64) {
+	m.SetUint64(MetadataEntryPageSize, value)
+}
+
+// GetFilepath returns the (relative) filepath.
+func (m *Metadata) GetFilepath() (string, bool) {
+	return m.GetString(MetadataEntryFilepath)
+}
+
+// SetFilepath sets the (relative) filepath.
+func (m *Metadata) SetFilepath(value string) {
+	m.SetString(MetadataEntryFilepath, value)
+}
+
+// GetFileMode returns the file mode permissions.
+func (m *Metadata) GetFileMode() (os.FileMode, bool) {
+	value, in := m.GetUint64(MetadataEntryFileMode)
+	return os.FileMode(value), in
+}
+
+// SetFileMode sets the file mode permissions.
+func (m *Metadata) SetFileMode(value os.FileMode) {
+	m.SetUint64(MetadataEntryFileMode, uint64(value.Perm()))
+}
+
+// GetModifiedTime returns the file modified time.
+func (m *Metadata) GetModifiedTime() (time.Time, bool) {
+	value, in := m.GetUint64(MetadataEntryModifiedTime)
+	if !in {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(value)), true
+}
+
+// SetModifiedTime sets the file modified time.
+func (m *Metadata) SetModifiedTime(value time.Time) {
+	m.SetUint64(MetadataEntryModifiedTime, uint64(value.UnixNano()))
+}
+
 // GetBytes returns the byte slice value for a given key.
 func (m *Metadata) GetBytes(key string) ([]byte, bool) {
 	value, in := m.Properties[key]
@@ -2,7 +2,9 @@ package api
 
 import (
 	"math/rand"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -50,6 +52,86 @@ func TestMetadata_GetMediaType(t *testing.T) {
 	assert.True(t, in)
 }
 
+func TestMetadata_GetSetCompressionCodec(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	m, err := NewEntryMetadata("application/x-pdf", 1, RandBytes(rng, 32), 2,
+		RandBytes(rng, 32))
+	assert.Nil(t, err)
+	_, in := m.GetCompressionCodec()
+	assert.False(t, in)
+
+	m.SetCompressionCodec("zstd")
+	value, in := m.GetCompressionCodec()
+	assert.True(t, in)
+	assert.Equal(t, "zstd", value)
+}
+
+func TestMetadata_GetSetChunking(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	m, err := NewEntryMetadata("application/x-pdf", 1, RandBytes(rng, 32), 2,
+		RandBytes(rng, 32))
+	assert.Nil(t, err)
+	_, in := m.GetChunking()
+	assert.False(t, in)
+
+	m.SetChunking("fastcdc")
+	value, in := m.GetChunking()
+	assert.True(t, in)
+	assert.Equal(t, "fastcdc", value)
+}
+
+func TestMetadata_GetSetAEADSuite(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	m, err := NewEntryMetadata("application/x-pdf", 1, RandBytes(rng, 32), 2,
+		RandBytes(rng, 32))
+	assert.Nil(t, err)
+	_, in := m.GetAEADSuite()
+	assert.False(t, in)
+
+	m.SetAEADSuite("xchacha20-poly1305")
+	value, in := m.GetAEADSuite()
+	assert.True(t, in)
+	assert.Equal(t, "xchacha20-poly1305", value)
+}
+
+func TestMetadata_GetSetErasureShards(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	m, err := NewEntryMetadata("application/x-pdf", 1, RandBytes(rng, 32), 2,
+		RandBytes(rng, 32))
+	assert.Nil(t, err)
+	_, _, in := m.GetErasureShards()
+	assert.False(t, in)
+
+	m.SetErasureShards(4, 2)
+	dataShards, parityShards, in := m.GetErasureShards()
+	assert.True(t, in)
+	assert.Equal(t, uint64(4), dataShards)
+	assert.Equal(t, uint64(2), parityShards)
+}
+
+func TestMetadata_GetSetPageSize(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	m, err := NewEntryMetadata("application/x-pdf", 1, RandBytes(rng, 32), 2,
+		RandBytes(rng, 32))
+	assert.Nil(t, err)
+	_, in := m.GetPageSize()
+	assert.False(t, in)
+
+	m.SetPageSize(256)
+	value, in := m.GetPageSize()
+	assert.True(t, in)
+	assert.Equal(t, uint64(256), value)
+}
+
+func TestMetadata_GetEntryAttributes(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	mediaType := "application/x-pdf"
+	m, err := NewEntryMetadata(mediaType, 1, RandBytes(rng, 32), 2, RandBytes(rng, 32))
+	assert.Nil(t, err)
+	assert.Equal(t, &EntryAttributes{MediaType: mediaType, UncompressedSize: 2},
+		m.GetEntryAttributes())
+}
+
 func TestMetadata_GetCiphertextSize(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	mediaType := "application/x-pdf"
@@ -146,3 +228,33 @@ func TestSetGetUint64(t *testing.T) {
 	assert.Equal(t, uint64Value, value)
 	assert.True(t, in)
 }
+
+func TestMetadata_GetSetFileInfo(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	m, err := NewEntryMetadata("application/x-pdf", 1, RandBytes(rng, 32), 2,
+		RandBytes(rng, 32))
+	assert.Nil(t, err)
+
+	// check unset values aren't in
+	_, in := m.GetFilepath()
+	assert.False(t, in)
+	_, in = m.GetFileMode()
+	assert.False(t, in)
+	_, in = m.GetModifiedTime()
+	assert.False(t, in)
+
+	filepath, mode, modTime := "some/dir/file.pdf", os.FileMode(0640), time.Unix(1, 2)
+	m.SetFilepath(filepath)
+	m.SetFileMode(os.ModeDir | mode)
+	m.SetModifiedTime(modTime)
+
+	value1, in := m.GetFilepath()
+	assert.True(t, in)
+	assert.Equal(t, filepath, value1)
+	value2, in := m.GetFileMode()
+	assert.True(t, in)
+	assert.Equal(t, mode, value2) // only permissions are kept
+	value3, in := m.GetModifiedTime()
+	assert.True(t, in)
+	assert.True(t, modTime.Equal(value3))
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInsufficientProofOfWork indicates when a request's proof of work nonce doesn't hash with its
+// key to enough leading zero bits.
+var ErrInsufficientProofOfWork = errors.New("insufficient proof of work")
+
+// ProofOfWorkBits returns the number of leading zero bits in the SHA-256 hash of the key followed
+// by the big-endian nonce.
+func ProofOfWorkBits(key []byte, nonce uint64) uint {
+	value := make([]byte, len(key)+8)
+	copy(value, key)
+	binary.BigEndian.PutUint64(value[len(key):], nonce)
+	hash := sha256.Sum256(value)
+	n := uint(0)
+	for _, b := range hash {
+		if b != 0 {
+			for ; b&0x80 == 0; b <<= 1 {
+				n++
+			}
+			return n
+		}
+		n += 8
+	}
+	return n
+}
+
+// CheckProofOfWork checks that the nonce hashes with the key to at least the given number of
+// leading zero bits, returning ErrInsufficientProofOfWork if not.
+func CheckProofOfWork(key []byte, nonce uint64, minBits uint) error {
+	if ProofOfWorkBits(key, nonce) < minBits {
+		return ErrInsufficientProofOfWork
+	}
+	return nil
+}
+
+// FindProofOfWork finds the smallest nonce that hashes with the key to at least the given number
+// of leading zero bits. Each additional bit doubles the expected number of hashes.
+func FindProofOfWork(key []byte, minBits uint) uint64 {
+	nonce := uint64(0)
+	for ProofOfWorkBits(key, nonce) < minBits {
+		nonce++
+	}
+	return nonce
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProofOfWorkBits(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := RandBytes(rng, DocumentKeyLength)
+
+	// check about half of nonces have at least one leading zero bit
+	nWithZeros, n := 0, 1000
+	for nonce := uint64(0); nonce < uint64(n); nonce++ {
+		if ProofOfWorkBits(key, nonce) >= 1 {
+			nWithZeros++
+		}
+	}
+	assert.InDelta(t, n/2, nWithZeros, float64(n)/10)
+
+	// check hash depends on both key and nonce
+	otherKey := RandBytes(rng, DocumentKeyLength)
+	nDiff := 0
+	for nonce := uint64(0); nonce < uint64(n); nonce++ {
+		if ProofOfWorkBits(key, nonce) != ProofOfWorkBits(otherKey, nonce) {
+			nDiff++
+		}
+	}
+	assert.True(t, nDiff > 0)
+}
+
+func TestFindProofOfWork(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for minBits := uint(0); minBits <= 12; minBits += 4 {
+		key := RandBytes(rng, DocumentKeyLength)
+		nonce := FindProofOfWork(key, minBits)
+		assert.True(t, ProofOfWorkBits(key, nonce) >= minBits)
+		assert.Nil(t, CheckProofOfWork(key, nonce, minBits))
+		for smaller := uint64(0); smaller < nonce; smaller++ {
+			assert.True(t, ProofOfWorkBits(key, smaller) < minBits)
+		}
+	}
+}
+
+func TestCheckProofOfWork_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := RandBytes(rng, DocumentKeyLength)
+	nonce := FindProofOfWork(key, 8)
+	nBits := ProofOfWorkBits(key, nonce)
+	assert.Nil(t, CheckProofOfWork(key, nonce, nBits))
+	assert.Equal(t, ErrInsufficientProofOfWork, CheckProofOfWork(key, nonce, nBits+1))
+}
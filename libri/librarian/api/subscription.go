@@ -1,6 +1,14 @@
 package api
 
-import "errors"
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
 
 // ErrEmptySubscriptionFilters indicates when a *Subscription has an empty author or reader public
 // key filter.
@@ -9,21 +17,137 @@ var ErrEmptySubscriptionFilters = errors.New("subscription has empty filters")
 // ErrUnexpectedNilValue indicates when a value is unexpectedly nil.
 var ErrUnexpectedNilValue = errors.New("unexpected nil value")
 
-// ValidateSubscription validates that a subscription is not missing any required fields. It returns
-// nil if the subscription is valid.
+// ErrInvalidSizeRange indicates when an *EntryAttributesFilter has a minimum size above its
+// maximum size.
+var ErrInvalidSizeRange = errors.New("entry attributes filter minimum size above maximum size")
+
+// ErrOutOfBoundsSampleRate indicates when a *Subscription has a sample rate not in [0, 1].
+var ErrOutOfBoundsSampleRate = errors.New("sample rate out of [0, 1] bounds")
+
+// ErrUnknownFilterType indicates when a *Subscription has a filter of an unknown type.
+var ErrUnknownFilterType = errors.New("subscription filter has unknown type")
+
+// ErrUnexpectedEnvelopeKey indicates when a *Publication's envelope key isn't the key of the
+// envelope it is verified against.
+var ErrUnexpectedEnvelopeKey = errors.New("publication envelope key doesn't match its envelope")
+
+// ErrMissingEnvelope indicates when no envelope is stored under a *Publication's envelope key.
+var ErrMissingEnvelope = errors.New("publication envelope not found")
+
+// ErrUnexpectedPublication indicates when a *Publication's fields differ from those of the
+// envelope stored under its envelope key.
+var ErrUnexpectedPublication = errors.New("publication doesn't match its envelope")
+
+const (
+	anySubtype = "/*"
+
+	// number of leading key bytes used to sample publications
+	sampleKeyLength = 8
+)
+
+// ValidateSubscription validates that a subscription is not missing any required fields. The
+// author and reader public key filters are only required when the subscription isn't sampled, but
+// must have a known type when set. It returns nil if the subscription is valid.
 func ValidateSubscription(s *Subscription) error {
 	if s == nil {
 		return ErrUnexpectedNilValue
 	}
+	if s.SampleRate < 0.0 || s.SampleRate > 1.0 {
+		return ErrOutOfBoundsSampleRate
+	}
+	if err := validateFilterType(s.AuthorPublicKeys); err != nil {
+		return err
+	}
+	if err := validateFilterType(s.ReaderPublicKeys); err != nil {
+		return err
+	}
+	if s.SampleRate > 0.0 {
+		return ValidateEntryAttributesFilter(s.EntryAttributes)
+	}
 	if s.AuthorPublicKeys == nil || s.AuthorPublicKeys.Encoded == nil {
 		return ErrEmptySubscriptionFilters
 	}
 	if s.ReaderPublicKeys == nil || s.ReaderPublicKeys.Encoded == nil {
 		return ErrEmptySubscriptionFilters
 	}
+	return ValidateEntryAttributesFilter(s.EntryAttributes)
+}
+
+// validateFilterType validates that an (optional) filter has a known type.
+func validateFilterType(f *BloomFilter) error {
+	if f == nil {
+		return nil
+	}
+	if _, in := FilterType_name[int32(f.Type)]; !in {
+		return ErrUnknownFilterType
+	}
 	return nil
 }
 
+// ValidateEntryAttributesFilter validates that an (optional) entry attributes filter has a valid
+// size range. It returns nil if the filter is valid.
+func ValidateEntryAttributesFilter(f *EntryAttributesFilter) error {
+	if f == nil || f.MaxUncompressedSize == 0 {
+		return nil
+	}
+	if f.MinUncompressedSize > f.MaxUncompressedSize {
+		return ErrInvalidSizeRange
+	}
+	return nil
+}
+
+// MatchEntryAttributes returns whether the entry attributes satisfy all the set conditions of the
+// filter. A nil filter matches everything, while nil attributes match only filters without any
+// set conditions.
+func MatchEntryAttributes(f *EntryAttributesFilter, a *EntryAttributes) bool {
+	if f == nil {
+		return true
+	}
+	if a == nil {
+		a = &EntryAttributes{}
+	}
+	if len(f.MediaTypes) > 0 && !matchMediaType(f.MediaTypes, a.MediaType) {
+		return false
+	}
+	if f.MinUncompressedSize > 0 && a.UncompressedSize < f.MinUncompressedSize {
+		return false
+	}
+	if f.MaxUncompressedSize > 0 &&
+		(a.UncompressedSize == 0 || a.UncompressedSize > f.MaxUncompressedSize) {
+		return false
+	}
+	return true
+}
+
+// InSample returns whether the given (uniformly distributed) key falls in the sample of the given
+// rate. Since sampling depends only on the key, all subscriptions with the same rate sample the
+// same publications, and those with a lower rate sample a subset of those with a higher rate.
+func InSample(key []byte, rate float32) bool {
+	if rate >= 1.0 {
+		return true
+	}
+	if rate <= 0.0 || len(key) < sampleKeyLength {
+		return false
+	}
+	return binary.BigEndian.Uint64(key[:sampleKeyLength]) < uint64(float64(rate)*math.MaxUint64)
+}
+
+func matchMediaType(filterTypes []string, mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	for _, filterType := range filterTypes {
+		if filterType == mediaType {
+			return true
+		}
+		if strings.HasSuffix(filterType, anySubtype) &&
+			strings.HasPrefix(mediaType, strings.TrimSuffix(filterType, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidatePublication validates that a publication has all fields of the correct length.
 func ValidatePublication(p *Publication) error {
 	if p == nil {
@@ -44,16 +168,59 @@ func ValidatePublication(p *Publication) error {
 	return nil
 }
 
+// VerifyPublication validates a publication and verifies it against the envelope stored under its
+// envelope key, so peers can't forward publications for envelopes that don't exist or that
+// disclose different keys or attributes. Envelopes aren't signed by their authors, so this doesn't
+// verify who created them. It returns nil if the publication is verified.
+func VerifyPublication(p *Publication, envelope *Document) error {
+	if err := ValidatePublication(p); err != nil {
+		return err
+	}
+	if envelope == nil {
+		return ErrMissingEnvelope
+	}
+	envelopeKey, err := GetKey(envelope)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(envelopeKey.Bytes(), p.EnvelopeKey) {
+		return ErrUnexpectedEnvelopeKey
+	}
+	if !proto.Equal(p, GetPublication(p.EnvelopeKey, envelope)) {
+		return ErrUnexpectedPublication
+	}
+	return nil
+}
+
+// GetEnvelopeDocument returns the envelope *Document a *Publication was made from.
+func GetEnvelopeDocument(p *Publication) *Document {
+	return &Document{
+		Contents: &Document_Envelope{
+			Envelope: &Envelope{
+				EntryKey:         p.EntryKey,
+				AuthorPublicKey:  p.AuthorPublicKey,
+				ReaderPublicKey:  p.ReaderPublicKey,
+				EntryAttributes:  p.EntryAttributes,
+				EekCiphertext:    p.EekCiphertext,
+				EekCiphertextMac: p.EekCiphertextMac,
+			},
+		},
+	}
+}
+
 // GetPublication returns a *Publication object if one can be made from the given document key and
 // value. If not, it returns nil.
 func GetPublication(key []byte, value *Document) *Publication {
 	switch x := value.Contents.(type) {
 	case *Document_Envelope:
 		return &Publication{
-			EntryKey:        x.Envelope.EntryKey,
-			EnvelopeKey:     key,
-			AuthorPublicKey: x.Envelope.AuthorPublicKey,
-			ReaderPublicKey: x.Envelope.ReaderPublicKey,
+			EntryKey:         x.Envelope.EntryKey,
+			EnvelopeKey:      key,
+			AuthorPublicKey:  x.Envelope.AuthorPublicKey,
+			ReaderPublicKey:  x.Envelope.ReaderPublicKey,
+			EntryAttributes:  x.Envelope.EntryAttributes,
+			EekCiphertext:    x.Envelope.EekCiphertext,
+			EekCiphertextMac: x.Envelope.EekCiphertextMac,
 		}
 	}
 	return nil
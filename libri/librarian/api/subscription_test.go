@@ -68,6 +68,119 @@ func TestValidateSubscription(t *testing.T) {
 			assert.NotNil(t, err, info)
 		}
 	}
+
+	s := &Subscription{
+		AuthorPublicKeys: &BloomFilter{Encoded: []byte{1, 2, 3}},
+		ReaderPublicKeys: &BloomFilter{Encoded: []byte{4, 5, 6}},
+		EntryAttributes: &EntryAttributesFilter{
+			MinUncompressedSize: 2,
+			MaxUncompressedSize: 1,
+		},
+	}
+	assert.Equal(t, ErrInvalidSizeRange, ValidateSubscription(s))
+
+	// filters must have a known type
+	s = &Subscription{
+		AuthorPublicKeys: &BloomFilter{Encoded: []byte{1, 2, 3}, Type: FilterType_CUCKOO},
+		ReaderPublicKeys: &BloomFilter{Encoded: []byte{4, 5, 6}, Type: FilterType(2)},
+	}
+	assert.Equal(t, ErrUnknownFilterType, ValidateSubscription(s))
+
+	// sampled subscriptions don't require filters
+	s = &Subscription{SampleRate: 0.5}
+	assert.Nil(t, ValidateSubscription(s))
+	s.SampleRate = 1.5
+	assert.Equal(t, ErrOutOfBoundsSampleRate, ValidateSubscription(s))
+	s.SampleRate = -0.5
+	assert.Equal(t, ErrOutOfBoundsSampleRate, ValidateSubscription(s))
+}
+
+func TestValidateEntryAttributesFilter(t *testing.T) {
+	cases := []struct {
+		f        *EntryAttributesFilter
+		expected error
+	}{
+		{nil, nil},
+		{&EntryAttributesFilter{}, nil},
+		{&EntryAttributesFilter{MinUncompressedSize: 2}, nil},
+		{&EntryAttributesFilter{MinUncompressedSize: 1, MaxUncompressedSize: 2}, nil},
+		{&EntryAttributesFilter{MinUncompressedSize: 2, MaxUncompressedSize: 2}, nil},
+		{&EntryAttributesFilter{MinUncompressedSize: 3, MaxUncompressedSize: 2}, ErrInvalidSizeRange},
+	}
+	for i, c := range cases {
+		info := fmt.Sprintf("i: %d", i)
+		assert.Equal(t, c.expected, ValidateEntryAttributesFilter(c.f), info)
+	}
+}
+
+func TestMatchEntryAttributes(t *testing.T) {
+	image := &EntryAttributes{MediaType: "image/png", UncompressedSize: 100}
+	cases := []struct {
+		f        *EntryAttributesFilter
+		a        *EntryAttributes
+		expected bool
+	}{
+		{nil, nil, true},                                                 // 0
+		{nil, image, true},                                               // 1
+		{&EntryAttributesFilter{}, nil, true},                            // 2
+		{&EntryAttributesFilter{}, image, true},                          // 3
+		{mediaTypesFilter("image/png"), image, true},                     // 4
+		{mediaTypesFilter("image/*"), image, true},                       // 5
+		{mediaTypesFilter("text/*", "image/*"), image, true},             // 6
+		{mediaTypesFilter("image/jpeg"), image, false},                   // 7
+		{mediaTypesFilter("text/*"), image, false},                       // 8
+		{mediaTypesFilter("image/*"), nil, false},                        // 9
+		{&EntryAttributesFilter{MinUncompressedSize: 100}, image, true},  // 10
+		{&EntryAttributesFilter{MinUncompressedSize: 101}, image, false}, // 11
+		{&EntryAttributesFilter{MaxUncompressedSize: 100}, image, true},  // 12
+		{&EntryAttributesFilter{MaxUncompressedSize: 99}, image, false},  // 13
+		{&EntryAttributesFilter{MaxUncompressedSize: 100}, nil, false},   // 14
+		{ // 15
+			&EntryAttributesFilter{
+				MediaTypes:          []string{"image/*"},
+				MinUncompressedSize: 50,
+				MaxUncompressedSize: 150,
+			},
+			image,
+			true,
+		},
+	}
+	for i, c := range cases {
+		info := fmt.Sprintf("i: %d", i)
+		assert.Equal(t, c.expected, MatchEntryAttributes(c.f, c.a), info)
+	}
+}
+
+func TestInSample(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	nKeys := 10000
+	rates := []float32{0.01, 0.1, 0.5}
+	nIn := make([]int, len(rates))
+	for c := 0; c < nKeys; c++ {
+		key := RandBytes(rng, DocumentKeyLength)
+		assert.True(t, InSample(key, 1.0))
+		assert.False(t, InSample(key, 0.0))
+		for i, rate := range rates {
+			if InSample(key, rate) {
+				nIn[i]++
+				// keys sampled at a lower rate are also sampled at all higher rates
+				for _, higher := range rates[i:] {
+					assert.True(t, InSample(key, higher))
+				}
+			}
+		}
+	}
+	for i, rate := range rates {
+		info := fmt.Sprintf("rate: %f", rate)
+		assert.InDelta(t, rate, float32(nIn[i])/float32(nKeys), 0.01, info)
+	}
+
+	// keys too short to sample are never in the sample
+	assert.False(t, InSample([]byte{0}, 0.5))
+}
+
+func mediaTypesFilter(mediaTypes ...string) *EntryAttributesFilter {
+	return &EntryAttributesFilter{MediaTypes: mediaTypes}
 }
 
 func TestValidatePublication_ok(t *testing.T) {
@@ -113,9 +226,77 @@ func TestValidatePublication_err(t *testing.T) {
 	}
 }
 
+func TestVerifyPublication_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	p := NewTestPublication(rng)
+	assert.Nil(t, VerifyPublication(p, GetEnvelopeDocument(p)))
+
+	// check shared envelope with entry attributes
+	env := NewTestEnvelope(rng)
+	env.EntryAttributes = &EntryAttributes{MediaType: "image/png", UncompressedSize: 100}
+	env.EekCiphertext = RandBytes(rng, 108)
+	env.EekCiphertextMac = RandBytes(rng, HMAC256Length)
+	doc := &Document{&Document_Envelope{Envelope: env}}
+	key, err := GetKey(doc)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyPublication(GetPublication(key.Bytes(), doc), doc))
+}
+
+func TestVerifyPublication_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	// check ValidatePublication error bubbles up
+	assert.Equal(t, ErrUnexpectedNilValue, VerifyPublication(nil, nil))
+
+	// check missing envelope throws error
+	p := NewTestPublication(rng)
+	assert.Equal(t, ErrMissingEnvelope, VerifyPublication(p, nil))
+
+	// check envelope key of different envelope throws error
+	p = NewTestPublication(rng)
+	envelope := GetEnvelopeDocument(p)
+	p.EnvelopeKey = RandBytes(rng, id.Length)
+	assert.Equal(t, ErrUnexpectedEnvelopeKey, VerifyPublication(p, envelope))
+
+	// check self-consistent publication for a different envelope throws error
+	p = NewTestPublication(rng)
+	assert.Equal(t, ErrUnexpectedEnvelopeKey,
+		VerifyPublication(p, GetEnvelopeDocument(NewTestPublication(rng))))
+
+	// check publication with fields differing from its envelope throws error
+	p = NewTestPublication(rng)
+	envelope = GetEnvelopeDocument(p)
+	p.EntryAttributes = &EntryAttributes{MediaType: "image/png"}
+	assert.Equal(t, ErrUnexpectedPublication, VerifyPublication(p, envelope))
+
+	// check non-envelope document under envelope key throws error
+	p = NewTestPublication(rng)
+	doc, key := NewTestDocument(rng)
+	p.EnvelopeKey = key.Bytes()
+	assert.Equal(t, ErrUnexpectedPublication, VerifyPublication(p, doc))
+}
+
+func TestGetEnvelopeDocument(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	env := NewTestEnvelope(rng)
+	env.EntryAttributes = &EntryAttributes{MediaType: "image/png", UncompressedSize: 100}
+	env.EekCiphertext = RandBytes(rng, 108)
+	env.EekCiphertextMac = RandBytes(rng, HMAC256Length)
+	doc := &Document{&Document_Envelope{Envelope: env}}
+	key, err := GetKey(doc)
+	assert.Nil(t, err)
+
+	p := GetPublication(key.Bytes(), doc)
+	assert.Equal(t, doc, GetEnvelopeDocument(p))
+	assert.Nil(t, VerifyPublication(p, doc))
+}
+
 func TestGetPublication_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	env := NewTestEnvelope(rng)
+	env.EntryAttributes = &EntryAttributes{MediaType: "image/png", UncompressedSize: 100}
+	env.EekCiphertext = RandBytes(rng, 108)
+	env.EekCiphertextMac = RandBytes(rng, HMAC256Length)
 	doc := &Document{&Document_Envelope{Envelope: env}}
 	key, err := GetKey(doc)
 	assert.Nil(t, err)
@@ -126,6 +307,9 @@ func TestGetPublication_ok(t *testing.T) {
 	assert.Equal(t, key.Bytes(), p.EnvelopeKey)
 	assert.Equal(t, env.AuthorPublicKey, p.AuthorPublicKey)
 	assert.Equal(t, env.ReaderPublicKey, p.ReaderPublicKey)
+	assert.Equal(t, env.EntryAttributes, p.EntryAttributes)
+	assert.Equal(t, env.EekCiphertext, p.EekCiphertext)
+	assert.Equal(t, env.EekCiphertextMac, p.EekCiphertextMac)
 
 	// check non-envelope type has nil pub with no error
 	doc, _ = NewTestDocument(rng)
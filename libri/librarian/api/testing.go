@@ -62,14 +62,30 @@ func NewTestPage(rng *rand.Rand) *Page {
 	}
 }
 
+// NewTestTombstone generates a dummy Tombstone, with a fake signature, for use in testing.
+func NewTestTombstone(rng *rand.Rand) *Tombstone {
+	return &Tombstone{
+		DocumentKey:     RandBytes(rng, DocumentKeyLength),
+		AuthorPublicKey: fakePubKey(rng),
+		CreatedTime:     1,
+		AuthorSignature: "fake.author.signature",
+	}
+}
+
 // NewTestPublication generates a dummy Publication for use in testing.
+// Its envelope key is that of the envelope with its other fields, so it can be verified.
 func NewTestPublication(rng *rand.Rand) *Publication {
-	return &Publication{
-		EnvelopeKey:     RandBytes(rng, cid.Length),
+	pub := &Publication{
 		EntryKey:        RandBytes(rng, cid.Length),
 		AuthorPublicKey: fakePubKey(rng),
 		ReaderPublicKey: fakePubKey(rng),
 	}
+	envelopeKey, err := GetKey(GetEnvelopeDocument(pub))
+	if err != nil {
+		panic(err)
+	}
+	pub.EnvelopeKey = envelopeKey.Bytes()
+	return pub
 }
 
 // RandBytes generates a random bytes slice of a given length.
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// ErrInvalidCA indicates when a TLS CA file doesn't contain any PEM certificates.
+var ErrInvalidCA = errors.New("TLS CA file has no valid PEM certificates")
+
+// NewClientTLSConfig returns the TLS config for dialing peers whose certificates are signed by the
+// CA certificates in the PEM file and name the server name. An empty CA file uses the system's
+// CA certificates, and an empty server name uses the dialed host.
+func NewClientTLSConfig(caFile, serverName string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: serverName}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, ErrInvalidCA
+	}
+	tlsConfig.RootCAs = rootCAs
+	return tlsConfig, nil
+}
+
+// Dial dials a connection to the address that uses the interceptors and, when the TLS config is
+// non-nil, TLS credentials from it. A nil TLS config dials an insecure connection.
+func Dial(addr *net.TCPAddr, tlsConfig *tls.Config, interceptors *Interceptors) (
+	*grpc.ClientConn, error) {
+	security := grpc.WithInsecure()
+	if tlsConfig != nil {
+		security = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	opts := append([]grpc.DialOption{security}, interceptors.DialOptions()...)
+	return grpc.Dial(addr.String(), opts...)
+}
+
+// NewSecureConnector creates a Connector instance from an address whose connection uses the
+// interceptors and, when the TLS config is non-nil, TLS. It returns just a NewConnector one
+// when there are neither.
+func NewSecureConnector(address *net.TCPAddr, tlsConfig *tls.Config,
+	interceptors *Interceptors) Connector {
+	if tlsConfig == nil && interceptors == nil {
+		return NewConnector(address)
+	}
+	return &connector{
+		publicAddress: address,
+		dialer:        &interceptingDialer{tlsConfig: tlsConfig, interceptors: interceptors},
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNewClientTLSConfig_ok(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-tls")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	certFile, _ := writeTestKeyPair(t, dir)
+
+	// check empty CA file uses system CAs
+	tlsConfig, err := NewClientTLSConfig("", "")
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig.RootCAs)
+
+	tlsConfig, err = NewClientTLSConfig(certFile, "localhost")
+	assert.Nil(t, err)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Equal(t, "localhost", tlsConfig.ServerName)
+}
+
+func TestNewClientTLSConfig_err(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-tls")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	notPEMFile := filepath.Join(dir, "not-pem.txt")
+	assert.Nil(t, ioutil.WriteFile(notPEMFile, []byte("not PEM"), 0600))
+
+	tlsConfig, err := NewClientTLSConfig(notPEMFile, "")
+	assert.Equal(t, ErrInvalidCA, err)
+	assert.Nil(t, tlsConfig)
+
+	// check file error bubbles up
+	tlsConfig, err = NewClientTLSConfig(filepath.Join(dir, "missing.pem"), "")
+	assert.NotNil(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func TestDial_tls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-tls")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	certFile, keyFile := writeTestKeyPair(t, dir)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	assert.Nil(t, err)
+
+	// serve health checks over TLS
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{cert}}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+	addr := lis.Addr().(*net.TCPAddr)
+
+	// check TLS connection trusting the server's CA succeeds
+	tlsConfig, err := NewClientTLSConfig(certFile, "localhost")
+	assert.Nil(t, err)
+	conn, err := Dial(addr, tlsConfig, &Interceptors{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, conn.Close()) }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rp.Status)
+
+	// check secure connector dials over TLS too
+	lc, err := NewSecureConnector(addr, tlsConfig, nil).(*connector).dialer.Dial(addr)
+	assert.Nil(t, err)
+	rp, err = healthpb.NewHealthClient(lc).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rp.Status)
+	assert.Nil(t, lc.Close())
+
+	// check insecure connection fails
+	insecureConn, err := DialInsecure(addr, nil)
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, insecureConn.Close()) }()
+	insecureCtx, insecureCancel := context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer insecureCancel()
+	_, err = healthpb.NewHealthClient(insecureConn).Check(insecureCtx,
+		&healthpb.HealthCheckRequest{})
+	assert.NotNil(t, err)
+}
+
+func TestNewSecureConnector(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 20100}
+	assert.Equal(t, NewConnector(addr), NewSecureConnector(addr, nil, nil))
+
+	tlsConfig := &tls.Config{ServerName: "localhost"}
+	conn := NewSecureConnector(addr, tlsConfig, nil)
+	assert.Equal(t, addr, conn.Address())
+	assert.Equal(t, tlsConfig, conn.(*connector).dialer.(*interceptingDialer).tlsConfig)
+}
+
+// writeTestKeyPair writes a self-signed certificate for localhost and its key to PEM files in
+// the directory and returns their paths.
+func writeTestKeyPair(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	assert.Nil(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+	return certFile, keyFile
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"crypto/tls"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// statsDecay is the weight of each new request in a librarian's exponentially-weighted moving
+	// average success rate and latency.
+	statsDecay = 0.2
+
+	// minWeightLatency is the latency below which librarians are weighted equally, so the fastest
+	// librarian doesn't get almost all the requests.
+	minWeightLatency = 10 * time.Millisecond
+
+	// minWeightSuccessRate is the success rate below which librarians are weighted equally, so
+	// librarians with only recent failures are still occasionally selected and can recover.
+	minWeightSuccessRate = 0.05
+)
+
+// WeightedClientBalancer is an ExclusionClientBalancer that can also pin librarians to keys, e.g.,
+// of streaming subscriptions.
+type WeightedClientBalancer interface {
+	ExclusionClientBalancer
+
+	// Pin returns the client of the librarian pinned to the key, so repeated calls keep using the
+	// same librarian. If no librarian is pinned to the key or the pinned one is excluded, it first
+	// pins the librarian Next would select.
+	Pin(key string) (LibrarianClient, error)
+
+	// Unpin removes the librarian pinned to the key, if any.
+	Unpin(key string)
+}
+
+// AddressInterceptor returns the unary client interceptor for requests to the librarian address.
+type AddressInterceptor func(addr *net.TCPAddr) grpc.UnaryClientInterceptor
+
+type weightedBalancer struct {
+	rng      *rand.Rand
+	mu       sync.Mutex
+	conns    []Connector
+	stats    []*librarianStats
+	excluded map[string]struct{}
+	pins     map[string]int
+}
+
+// NewWeightedClientBalancer creates a new WeightedClientBalancer that selects the next client at
+// random from those not excluded, weighted by each librarian's recent request success rate divided
+// by its latency. Librarians without requests yet have the highest weight, so they are tried
+// soon. Each librarian's requests also go through the given interceptors and then those of the
+// address interceptors, in order, after its stats are recorded. Each connection uses TLS when the
+// TLS config is non-nil.
+func NewWeightedClientBalancer(
+	libAddrs []*net.TCPAddr,
+	tlsConfig *tls.Config,
+	interceptors *Interceptors,
+	addrInterceptors ...AddressInterceptor,
+) (WeightedClientBalancer, error) {
+	if len(libAddrs) == 0 {
+		return nil, ErrEmptyLibrarianAddresses
+	}
+	if interceptors == nil {
+		interceptors = &Interceptors{}
+	}
+	conns := make([]Connector, len(libAddrs))
+	stats := make([]*librarianStats, len(libAddrs))
+	for i, la := range libAddrs {
+		stats[i] = newLibrarianStats()
+		unary := []grpc.UnaryClientInterceptor{stats[i].intercept}
+		unary = append(unary, interceptors.Unary...)
+		for _, addrInterceptor := range addrInterceptors {
+			unary = append(unary, addrInterceptor(la))
+		}
+		conns[i] = NewSecureConnector(la, tlsConfig, &Interceptors{
+			Unary:  unary,
+			Stream: interceptors.Stream,
+		})
+	}
+	return &weightedBalancer{
+		rng:      rand.New(rand.NewSource(int64(len(conns)))),
+		conns:    conns,
+		stats:    stats,
+		excluded: make(map[string]struct{}),
+		pins:     make(map[string]int),
+	}, nil
+}
+
+// Next selects the next librarian client at random from those not excluded, or from all of them
+// if all are excluded, weighted by their recent success rates and latencies.
+func (b *weightedBalancer) Next() (LibrarianClient, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conns[b.next()].Connect()
+}
+
+func (b *weightedBalancer) Pin(key string) (LibrarianClient, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i, in := b.pins[key]
+	if !in || b.isExcluded(i) {
+		i = b.next()
+		b.pins[key] = i
+	}
+	return b.conns[i].Connect()
+}
+
+func (b *weightedBalancer) Unpin(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pins, key)
+}
+
+func (b *weightedBalancer) Exclude(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.excluded[addr] = struct{}{}
+}
+
+func (b *weightedBalancer) Include(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.excluded, addr)
+}
+
+func (b *weightedBalancer) CloseAll() error {
+	for _, conn := range b.conns {
+		err := conn.Disconnect()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// next returns the index of the next librarian to select.
+func (b *weightedBalancer) next() int {
+	included := make([]int, 0, len(b.conns))
+	for i := range b.conns {
+		if !b.isExcluded(i) {
+			included = append(included, i)
+		}
+	}
+	if len(included) == 0 {
+		for i := range b.conns {
+			included = append(included, i)
+		}
+	}
+	weights := make([]float64, len(included))
+	total := 0.0
+	for j, i := range included {
+		weights[j] = b.stats[i].weight()
+		total += weights[j]
+	}
+	r := b.rng.Float64() * total
+	for j, w := range weights {
+		if r < w {
+			return included[j]
+		}
+		r -= w
+	}
+	return included[len(included)-1] // only reached via float rounding
+}
+
+func (b *weightedBalancer) isExcluded(i int) bool {
+	_, in := b.excluded[b.conns[i].Address().String()]
+	return in
+}
+
+// librarianStats tracks the moving average success rate and latency of requests to a librarian.
+type librarianStats struct {
+	mu          sync.Mutex
+	successRate float64
+	latency     time.Duration
+}
+
+func newLibrarianStats() *librarianStats {
+	return &librarianStats{successRate: 1.0}
+}
+
+// record updates the moving averages with a request's latency and error. Errors meaning the
+// librarian responded, e.g., when a request is invalid, count as successes.
+func (s *librarianStats) record(latency time.Duration, err error) {
+	success := 1.0
+	switch grpc.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal,
+		codes.Unknown:
+		success = 0.0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.successRate = statsDecay*success + (1-statsDecay)*s.successRate
+	if s.latency == 0 {
+		s.latency = latency
+		return
+	}
+	s.latency = time.Duration(statsDecay*float64(latency) + (1-statsDecay)*float64(s.latency))
+}
+
+// weight returns the librarian's selection weight, its success rate divided by its latency in
+// seconds.
+func (s *librarianStats) weight() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latency := s.latency
+	if latency < minWeightLatency {
+		latency = minWeightLatency
+	}
+	return math.Max(s.successRate, minWeightSuccessRate) / latency.Seconds()
+}
+
+// intercept records the latency and error of each unary request.
+func (s *librarianStats) intercept(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	s.record(time.Since(start), err)
+	return err
+}
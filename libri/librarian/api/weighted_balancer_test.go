@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewWeightedClientBalancer_err(t *testing.T) {
+	b, err := NewWeightedClientBalancer(nil, nil, nil)
+	assert.Equal(t, ErrEmptyLibrarianAddresses, err)
+	assert.Nil(t, b)
+}
+
+func TestWeightedBalancer_Next(t *testing.T) {
+	addrs := []*net.TCPAddr{
+		{IP: net.ParseIP("127.0.0.1"), Port: 20100},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20101},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20102},
+	}
+	b, err := NewWeightedClientBalancer(addrs, nil, nil)
+	assert.Nil(t, err)
+	clients := connectFixed(t, b.(*weightedBalancer).conns)
+
+	// check all librarians are selected without stats
+	assert.Len(t, countSelected(t, b, clients, 64), len(addrs))
+
+	// check slow and failing librarians are selected less often
+	stats := b.(*weightedBalancer).stats
+	for c := 0; c < 10; c++ {
+		stats[0].record(10*time.Millisecond, nil)
+		stats[1].record(500*time.Millisecond, nil)
+		stats[2].record(10*time.Millisecond, status.Error(codes.Unavailable, "unavailable"))
+	}
+	counts := countSelected(t, b, clients, 256)
+	assert.True(t, counts[addrs[0].String()] > counts[addrs[1].String()])
+	assert.True(t, counts[addrs[0].String()] > counts[addrs[2].String()])
+
+	// check excluded librarians aren't selected
+	b.Exclude(addrs[0].String())
+	b.Exclude(addrs[1].String())
+	counts = countSelected(t, b, clients, 16)
+	assert.Equal(t, map[string]int{addrs[2].String(): 16}, counts)
+
+	// check all librarians are selected when all are excluded
+	b.Exclude(addrs[2].String())
+	assert.Len(t, countSelected(t, b, clients, 256), len(addrs))
+
+	// check included librarian is selected again
+	b.Include(addrs[1].String())
+	counts = countSelected(t, b, clients, 16)
+	assert.Equal(t, map[string]int{addrs[1].String(): 16}, counts)
+}
+
+func TestWeightedBalancer_Pin(t *testing.T) {
+	addrs := []*net.TCPAddr{
+		{IP: net.ParseIP("127.0.0.1"), Port: 20100},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20101},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20102},
+	}
+	b, err := NewWeightedClientBalancer(addrs, nil, nil)
+	assert.Nil(t, err)
+	clients := connectFixed(t, b.(*weightedBalancer).conns)
+
+	// check pinned librarian stays the same
+	lc1, err := b.Pin("key1")
+	assert.Nil(t, err)
+	for c := 0; c < 16; c++ {
+		lc2, err := b.Pin("key1")
+		assert.Nil(t, err)
+		assert.Equal(t, clients[lc1], clients[lc2])
+	}
+
+	// check excluded pinned librarian is replaced
+	b.Exclude(clients[lc1])
+	lc3, err := b.Pin("key1")
+	assert.Nil(t, err)
+	assert.NotEqual(t, clients[lc1], clients[lc3])
+	lc4, err := b.Pin("key1")
+	assert.Nil(t, err)
+	assert.Equal(t, clients[lc3], clients[lc4])
+	b.Include(clients[lc1])
+
+	// check unpinned key pins a new selection
+	b.Unpin("key1")
+	assert.Empty(t, b.(*weightedBalancer).pins)
+	pinned := make(map[string]struct{})
+	for c := 0; c < 64; c++ {
+		lc, err := b.Pin("key1")
+		assert.Nil(t, err)
+		pinned[clients[lc]] = struct{}{}
+		b.Unpin("key1")
+	}
+	assert.Len(t, pinned, len(addrs))
+}
+
+func TestLibrarianStats(t *testing.T) {
+	s := newLibrarianStats()
+	assert.Equal(t, 1.0, s.successRate)
+	assert.Equal(t, 1.0/minWeightLatency.Seconds(), s.weight())
+
+	s.record(100*time.Millisecond, nil)
+	assert.Equal(t, 100*time.Millisecond, s.latency)
+	assert.Equal(t, 1.0, s.successRate)
+	assert.InDelta(t, 10.0, s.weight(), 1e-9)
+
+	// check responded errors count as successes
+	s.record(100*time.Millisecond, status.Error(codes.InvalidArgument, "invalid"))
+	assert.Equal(t, 1.0, s.successRate)
+
+	s.record(200*time.Millisecond, status.Error(codes.Unavailable, "unavailable"))
+	assert.InDelta(t, 1-statsDecay, s.successRate, 1e-9)
+	assert.Equal(t, 120*time.Millisecond, s.latency)
+
+	s.record(200*time.Millisecond, errors.New("some error"))
+	assert.InDelta(t, (1-statsDecay)*(1-statsDecay), s.successRate, 1e-9)
+
+	// check weight has success rate floor
+	for c := 0; c < 64; c++ {
+		s.record(minWeightLatency, status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+	}
+	assert.InDelta(t, minWeightSuccessRate/minWeightLatency.Seconds(), s.weight(), 1e-3)
+}
+
+func TestLibrarianStats_intercept(t *testing.T) {
+	s := newLibrarianStats()
+	invokeErr := status.Error(codes.Unavailable, "unavailable")
+	invoker := func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return invokeErr
+	}
+	err := s.intercept(context.Background(), "/api.Librarian/Get", nil, nil, nil, invoker)
+	assert.Equal(t, invokeErr, err)
+	assert.InDelta(t, 1-statsDecay, s.successRate, 1e-9)
+	assert.NotZero(t, s.latency)
+}
+
+func TestNewWeightedClientBalancer_interceptors(t *testing.T) {
+	addrs := []*net.TCPAddr{
+		{IP: net.ParseIP("127.0.0.1"), Port: 20100},
+		{IP: net.ParseIP("127.0.0.1"), Port: 20101},
+	}
+	calls := make([]string, 0)
+	recording := func(name string) AddressInterceptor {
+		return func(addr *net.TCPAddr) grpc.UnaryClientInterceptor {
+			return func(ctx context.Context, method string, req, reply interface{},
+				cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+				opts ...grpc.CallOption) error {
+				calls = append(calls, name+" "+addr.String())
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}
+		}
+	}
+	interceptors := &Interceptors{
+		Unary: []grpc.UnaryClientInterceptor{recording("custom")(addrs[0])},
+	}
+	b, err := NewWeightedClientBalancer(addrs, nil, interceptors, recording("first"),
+		recording("second"))
+	assert.Nil(t, err)
+
+	invokeErr := status.Error(codes.Unavailable, "unavailable")
+	invoker := func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, "invoker")
+		return invokeErr
+	}
+	wb := b.(*weightedBalancer)
+	for i, conn := range wb.conns {
+		calls = calls[:0]
+		unary := conn.(*connector).dialer.(*interceptingDialer).interceptors.Unary
+		interceptor := chainUnaryInterceptors(unary)
+		err = interceptor(context.Background(), "/api.Librarian/Put", nil, nil, nil, invoker)
+
+		// check interceptors called in order after stats recorded
+		assert.Equal(t, invokeErr, err)
+		expected := []string{"custom " + addrs[0].String(), "first " + addrs[i].String(),
+			"second " + addrs[i].String(), "invoker"}
+		assert.Equal(t, expected, calls)
+		assert.InDelta(t, 1-statsDecay, wb.stats[i].successRate, 1e-9)
+	}
+}
+
+func connectFixed(t *testing.T, conns []Connector) map[LibrarianClient]string {
+	clients := make(map[LibrarianClient]string)
+	for _, conn := range conns {
+		conn.(*connector).dialer = &fixedDialer{clientConn: &grpc.ClientConn{}}
+		lc, err := conn.Connect()
+		assert.Nil(t, err)
+		clients[lc] = conn.Address().String()
+	}
+	return clients
+}
+
+func countSelected(
+	t *testing.T, b ClientBalancer, clients map[LibrarianClient]string, n int,
+) map[string]int {
+	counts := make(map[string]int)
+	for c := 0; c < n; c++ {
+		lc, err := b.Next()
+		assert.Nil(t, err)
+		counts[clients[lc]]++
+	}
+	return counts
+}
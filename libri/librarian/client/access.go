@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+var (
+	// ErrMissingAccessProof indicates when a request for a private document lacks an access
+	// proof.
+	ErrMissingAccessProof = errors.New("missing access proof for private document")
+
+	// ErrUnexpectedAccessKey indicates when an access proof is for a different document key.
+	ErrUnexpectedAccessKey = errors.New("access proof has unexpected document key")
+
+	// ErrUnauthorizedAccess indicates when an access proof's reader public key doesn't match the
+	// private document's access hash.
+	ErrUnauthorizedAccess = errors.New("access proof reader key not authorized")
+)
+
+// accessVerifyParams require access proofs to have been signed recently, which bounds how long an
+// observed proof can be replayed.
+var accessVerifyParams = &VerifyParameters{
+	ClockSkew:       DefaultClockSkew,
+	MaxAge:          DefaultMaxSignatureAge,
+	RequireIssuedAt: true,
+}
+
+// NewAccessHash returns the access hash of the reader public key, which is stored with a private
+// document to restrict getting it to holders of the reader key.
+func NewAccessHash(readerPubKey []byte) []byte {
+	hash := sha256.Sum256(readerPubKey)
+	return hash[:]
+}
+
+// NewAccessProof creates a new AccessProof for the document key, signed by the reader key.
+func NewAccessProof(readerID ecid.ID, key cid.ID) (*api.AccessProof, error) {
+	proof := &api.AccessProof{
+		Key:          key.Bytes(),
+		ReaderPubKey: ecid.ToPublicKeyBytes(readerID),
+	}
+	signature, err := NewSigner(readerID.Key()).Sign(proof)
+	if err != nil {
+		return nil, err
+	}
+	proof.Signature = signature
+	return proof, nil
+}
+
+// VerifyAccessProof verifies that the proof is for the document key and was recently signed by
+// the reader key whose hash is the document's access hash. It returns nil if the proof is verified.
+func VerifyAccessProof(proof *api.AccessProof, key cid.ID, accessHash []byte) error {
+	if proof == nil {
+		return ErrMissingAccessProof
+	}
+	if !bytes.Equal(key.Bytes(), proof.Key) {
+		return ErrUnexpectedAccessKey
+	}
+	if !bytes.Equal(accessHash, NewAccessHash(proof.ReaderPubKey)) {
+		return ErrUnauthorizedAccess
+	}
+	readerPubKey, err := ecid.FromPublicKeyBytes(proof.ReaderPubKey)
+	if err != nil {
+		return err
+	}
+	unsigned := &api.AccessProof{
+		Key:          proof.Key,
+		ReaderPubKey: proof.ReaderPubKey,
+	}
+	return NewVerifierWithParameters(accessVerifyParams).Verify(proof.Signature, readerPubKey,
+		unsigned)
+}
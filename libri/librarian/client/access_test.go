@@ -0,0 +1,76 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAccessHash(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pub1 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	pub2 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+
+	assert.Len(t, NewAccessHash(pub1), 32)
+	assert.Equal(t, NewAccessHash(pub1), NewAccessHash(pub1))
+	assert.NotEqual(t, NewAccessHash(pub1), NewAccessHash(pub2))
+}
+
+func TestNewAccessProof_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	readerID, key := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+
+	proof, err := NewAccessProof(readerID, key)
+	assert.Nil(t, err)
+	assert.Equal(t, key.Bytes(), proof.Key)
+	assert.Equal(t, ecid.ToPublicKeyBytes(readerID), proof.ReaderPubKey)
+	assert.NotEmpty(t, proof.Signature)
+
+	accessHash := NewAccessHash(ecid.ToPublicKeyBytes(readerID))
+	assert.Nil(t, VerifyAccessProof(proof, key, accessHash))
+}
+
+func TestVerifyAccessProof_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	readerID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	key := cid.NewPseudoRandom(rng)
+	accessHash := NewAccessHash(ecid.ToPublicKeyBytes(readerID))
+	newProof := func() *api.AccessProof {
+		proof, err := NewAccessProof(readerID, key)
+		assert.Nil(t, err)
+		return proof
+	}
+
+	// check missing proof
+	assert.Equal(t, ErrMissingAccessProof, VerifyAccessProof(nil, key, accessHash))
+
+	// check proof for another key
+	proof := newProof()
+	err := VerifyAccessProof(proof, cid.NewPseudoRandom(rng), accessHash)
+	assert.Equal(t, ErrUnexpectedAccessKey, err)
+
+	// check proof from unauthorized reader
+	other, err := NewAccessProof(otherID, key)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrUnauthorizedAccess, VerifyAccessProof(other, key, accessHash))
+
+	// check bad reader public key
+	proof = newProof()
+	proof.ReaderPubKey = []byte("bad reader pub key")
+	err = VerifyAccessProof(proof, key, NewAccessHash(proof.ReaderPubKey))
+	assert.NotNil(t, err)
+
+	// check authorized reader key with signature by another key
+	proof = newProof()
+	proof.Signature = other.Signature
+	assert.NotNil(t, VerifyAccessProof(proof, key, accessHash))
+
+	// check missing signature
+	proof = newProof()
+	proof.Signature = ""
+	assert.NotNil(t, VerifyAccessProof(proof, key, accessHash))
+}
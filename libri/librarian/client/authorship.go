@@ -0,0 +1,98 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	// ErrMissingAuthorSignature indicates when an entry or tombstone lacks an author signature.
+	ErrMissingAuthorSignature = errors.New("missing author signature")
+
+	// ErrUnexpectedAuthorKey indicates when an entry's author public key doesn't match the
+	// signing author key.
+	ErrUnexpectedAuthorKey = errors.New("entry author public key does not match signing key")
+)
+
+// entryVerifyParams don't expire author signatures, since entries are stored and replicated
+// long after they are signed.
+var entryVerifyParams = &VerifyParameters{ClockSkew: DefaultClockSkew}
+
+// SignEntry sets the entry's author signature over its other contents with the author's private
+// key.
+func SignEntry(authorID ecid.ID, entry *api.Entry) error {
+	if entry == nil {
+		return api.ErrUnexpectedNilValue
+	}
+	authorPub := ecid.ToPublicKeyBytes(authorID)
+	if !bytes.Equal(entry.AuthorPublicKey, authorPub) {
+		return ErrUnexpectedAuthorKey
+	}
+	entry.AuthorSignature = ""
+	signature, err := NewSigner(authorID.Key()).Sign(entry)
+	if err != nil {
+		return err
+	}
+	entry.AuthorSignature = signature
+	return nil
+}
+
+// VerifyEntrySignature verifies that the entry's author signature was made by its author public
+// key over its other contents. It returns nil if the signature is verified.
+func VerifyEntrySignature(entry *api.Entry) error {
+	if entry == nil {
+		return api.ErrUnexpectedNilValue
+	}
+	if entry.AuthorSignature == "" {
+		return ErrMissingAuthorSignature
+	}
+	authorPubKey, err := ecid.FromPublicKeyBytes(entry.AuthorPublicKey)
+	if err != nil {
+		return err
+	}
+	unsigned := proto.Clone(entry).(*api.Entry)
+	unsigned.AuthorSignature = ""
+	return NewVerifierWithParameters(entryVerifyParams).Verify(entry.AuthorSignature,
+		authorPubKey, unsigned)
+}
+
+// NewTombstone creates a new Tombstone deleting the document with the given key, signed by the
+// author's private key.
+func NewTombstone(authorID ecid.ID, key cid.ID) (*api.Tombstone, error) {
+	tombstone := &api.Tombstone{
+		DocumentKey:     key.Bytes(),
+		AuthorPublicKey: ecid.ToPublicKeyBytes(authorID),
+		CreatedTime:     time.Now().Unix(),
+	}
+	signature, err := NewSigner(authorID.Key()).Sign(tombstone)
+	if err != nil {
+		return nil, err
+	}
+	tombstone.AuthorSignature = signature
+	return tombstone, nil
+}
+
+// VerifyTombstone verifies that the tombstone's author signature was made by its author public
+// key over its other contents. It returns nil if the signature is verified.
+func VerifyTombstone(tombstone *api.Tombstone) error {
+	if tombstone == nil {
+		return api.ErrUnexpectedNilValue
+	}
+	if tombstone.AuthorSignature == "" {
+		return ErrMissingAuthorSignature
+	}
+	authorPubKey, err := ecid.FromPublicKeyBytes(tombstone.AuthorPublicKey)
+	if err != nil {
+		return err
+	}
+	unsigned := proto.Clone(tombstone).(*api.Tombstone)
+	unsigned.AuthorSignature = ""
+	return NewVerifierWithParameters(entryVerifyParams).Verify(tombstone.AuthorSignature,
+		authorPubKey, unsigned)
+}
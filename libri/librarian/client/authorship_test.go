@@ -0,0 +1,122 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignEntry_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorID := ecid.NewPseudoRandom(rng)
+	entry := api.NewTestSinglePageEntry(rng)
+	entry.AuthorPublicKey = ecid.ToPublicKeyBytes(authorID)
+
+	err := SignEntry(authorID, entry)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, entry.AuthorSignature)
+	assert.Nil(t, VerifyEntrySignature(entry))
+
+	// check re-signing gives a verified signature
+	err = SignEntry(authorID, entry)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyEntrySignature(entry))
+}
+
+func TestSignEntry_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorID := ecid.NewPseudoRandom(rng)
+
+	// check nil entry
+	assert.Equal(t, api.ErrUnexpectedNilValue, SignEntry(authorID, nil))
+
+	// check author public key different from signing key
+	entry := api.NewTestSinglePageEntry(rng)
+	assert.Equal(t, ErrUnexpectedAuthorKey, SignEntry(authorID, entry))
+	assert.Empty(t, entry.AuthorSignature)
+}
+
+func TestVerifyEntrySignature_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	newEntry := func() *api.Entry {
+		entry := api.NewTestMultiPageEntry(rng)
+		entry.AuthorPublicKey = ecid.ToPublicKeyBytes(authorID)
+		assert.Nil(t, SignEntry(authorID, entry))
+		return entry
+	}
+
+	// check nil entry
+	assert.Equal(t, api.ErrUnexpectedNilValue, VerifyEntrySignature(nil))
+
+	// check missing signature
+	entry := newEntry()
+	entry.AuthorSignature = ""
+	assert.Equal(t, ErrMissingAuthorSignature, VerifyEntrySignature(entry))
+
+	// check bad author public key
+	entry = newEntry()
+	entry.AuthorPublicKey = []byte("bad author pub key")
+	assert.NotNil(t, VerifyEntrySignature(entry))
+
+	// check forged author public key doesn't match signature
+	entry = newEntry()
+	entry.AuthorPublicKey = ecid.ToPublicKeyBytes(otherID)
+	assert.NotNil(t, VerifyEntrySignature(entry))
+
+	// check changed contents don't match signature
+	entry = newEntry()
+	entry.CreatedTime++
+	assert.NotNil(t, VerifyEntrySignature(entry))
+}
+
+func TestNewTombstone_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorID, key := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+
+	tombstone, err := NewTombstone(authorID, key)
+	assert.Nil(t, err)
+	assert.Equal(t, key.Bytes(), tombstone.DocumentKey)
+	assert.Equal(t, ecid.ToPublicKeyBytes(authorID), tombstone.AuthorPublicKey)
+	assert.NotZero(t, tombstone.CreatedTime)
+	assert.Nil(t, api.ValidateTombstone(tombstone))
+	assert.Nil(t, VerifyTombstone(tombstone))
+}
+
+func TestVerifyTombstone_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	key := cid.NewPseudoRandom(rng)
+	newTombstone := func() *api.Tombstone {
+		tombstone, err := NewTombstone(authorID, key)
+		assert.Nil(t, err)
+		return tombstone
+	}
+
+	// check missing tombstone
+	assert.Equal(t, api.ErrUnexpectedNilValue, VerifyTombstone(nil))
+
+	// check missing signature
+	tombstone := newTombstone()
+	tombstone.AuthorSignature = ""
+	assert.Equal(t, ErrMissingAuthorSignature, VerifyTombstone(tombstone))
+
+	// check bad author public key
+	tombstone = newTombstone()
+	tombstone.AuthorPublicKey = []byte("bad author pub key")
+	assert.NotNil(t, VerifyTombstone(tombstone))
+
+	// check signature by another key
+	tombstone = newTombstone()
+	tombstone.AuthorPublicKey = ecid.ToPublicKeyBytes(otherID)
+	assert.NotNil(t, VerifyTombstone(tombstone))
+
+	// check tombstone changed to delete another document
+	tombstone = newTombstone()
+	tombstone.DocumentKey = cid.NewPseudoRandom(rng).Bytes()
+	assert.NotNil(t, VerifyTombstone(tombstone))
+}
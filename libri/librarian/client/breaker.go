@@ -0,0 +1,254 @@
+package client
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultBreakerMaxFailures is the default number of consecutive failed requests to a
+	// librarian after which its circuit opens.
+	DefaultBreakerMaxFailures = 5
+
+	// DefaultBreakerCooldown is the default time a librarian's circuit stays open before a trial
+	// request is allowed through.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen indicates when a request isn't made because the librarian's circuit is open.
+// It has the Unavailable code, so callers treat it like any other unreachable librarian.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "circuit open for librarian")
+
+// CircuitBreaker tracks consecutive failed requests to each librarian address. Once a librarian
+// has too many, its circuit opens and requests to it fail immediately with ErrCircuitOpen rather
+// than timing out. After a cool-down, a single trial request is allowed through, which closes the
+// circuit if it succeeds and opens it again if it fails.
+type CircuitBreaker interface {
+	// Allow returns ErrCircuitOpen if a request to the address shouldn't be made, or nil if it
+	// should, in which case its error must then be passed to Record.
+	Allow(addr string) error
+
+	// Record records the error, if any, of an allowed request to the address.
+	Record(addr string, err error)
+}
+
+type circuit struct {
+	failures  uint
+	openUntil time.Time
+	trial     bool
+}
+
+type circuitBreaker struct {
+	mu          sync.Mutex
+	maxFailures uint
+	cooldown    time.Duration
+	circuits    map[string]*circuit
+	now         func() time.Time
+}
+
+// NewCircuitBreaker returns a new CircuitBreaker that opens a librarian's circuit after
+// maxFailures consecutive failed requests, keeping it open for the cool-down.
+func NewCircuitBreaker(maxFailures uint, cooldown time.Duration) CircuitBreaker {
+	return &circuitBreaker{
+		maxFailures: maxFailures,
+		cooldown:    cooldown,
+		circuits:    make(map[string]*circuit),
+		now:         time.Now,
+	}
+}
+
+// NewDefaultCircuitBreaker returns a new CircuitBreaker with the default max failures and
+// cool-down.
+func NewDefaultCircuitBreaker() CircuitBreaker {
+	return NewCircuitBreaker(DefaultBreakerMaxFailures, DefaultBreakerCooldown)
+}
+
+func (b *circuitBreaker) Allow(addr string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, in := b.circuits[addr]
+	if !in || c.failures < b.maxFailures {
+		return nil
+	}
+	if c.trial || b.now().Before(c.openUntil) {
+		return ErrCircuitOpen
+	}
+	c.trial = true
+	return nil
+}
+
+func (b *circuitBreaker) Record(addr string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch grpc.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		c, in := b.circuits[addr]
+		if !in {
+			c = &circuit{}
+			b.circuits[addr] = c
+		}
+		c.failures++
+		c.trial = false
+		if c.failures >= b.maxFailures {
+			c.openUntil = b.now().Add(b.cooldown)
+		}
+	case codes.Canceled:
+		// caller gave up, which says nothing about the librarian
+		if c, in := b.circuits[addr]; in {
+			c.trial = false
+		}
+	default:
+		// other errors mean the librarian responded
+		delete(b.circuits, addr)
+	}
+}
+
+// breakerCall makes the call if the breaker allows it and records its error.
+func breakerCall(b CircuitBreaker, addr string, call func() error) error {
+	if err := b.Allow(addr); err != nil {
+		return err
+	}
+	err := call()
+	b.Record(addr, err)
+	return err
+}
+
+// NewBreakerUnaryInterceptor returns a unary client interceptor for connections to the librarian
+// address that makes requests through the CircuitBreaker.
+func NewBreakerUnaryInterceptor(b CircuitBreaker, addr *net.TCPAddr) grpc.UnaryClientInterceptor {
+	key := addr.String()
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return breakerCall(b, key, func() error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+	}
+}
+
+type breakerIntroduceQuerier struct {
+	inner   IntroduceQuerier
+	breaker CircuitBreaker
+}
+
+// NewBreakerIntroduceQuerier creates a new IntroduceQuerier that makes the inner
+// IntroduceQuerier's queries through the CircuitBreaker.
+func NewBreakerIntroduceQuerier(inner IntroduceQuerier, b CircuitBreaker) IntroduceQuerier {
+	return &breakerIntroduceQuerier{inner: inner, breaker: b}
+}
+
+func (q *breakerIntroduceQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.IntroduceRequest, opts ...grpc.CallOption,
+) (*api.IntroduceResponse, error) {
+	var rp *api.IntroduceResponse
+	err := breakerCall(q.breaker, pConn.Address().String(), func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type breakerFindQuerier struct {
+	inner   FindQuerier
+	breaker CircuitBreaker
+}
+
+// NewBreakerFindQuerier creates a new FindQuerier that makes the inner FindQuerier's queries
+// through the CircuitBreaker.
+func NewBreakerFindQuerier(inner FindQuerier, b CircuitBreaker) FindQuerier {
+	return &breakerFindQuerier{inner: inner, breaker: b}
+}
+
+func (q *breakerFindQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.FindRequest, opts ...grpc.CallOption,
+) (*api.FindResponse, error) {
+	var rp *api.FindResponse
+	err := breakerCall(q.breaker, pConn.Address().String(), func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type breakerStoreQuerier struct {
+	inner   StoreQuerier
+	breaker CircuitBreaker
+}
+
+// NewBreakerStoreQuerier creates a new StoreQuerier that makes the inner StoreQuerier's queries
+// through the CircuitBreaker.
+func NewBreakerStoreQuerier(inner StoreQuerier, b CircuitBreaker) StoreQuerier {
+	return &breakerStoreQuerier{inner: inner, breaker: b}
+}
+
+func (q *breakerStoreQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.StoreRequest, opts ...grpc.CallOption,
+) (*api.StoreResponse, error) {
+	var rp *api.StoreResponse
+	err := breakerCall(q.breaker, pConn.Address().String(), func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type breakerGetQuerier struct {
+	inner   GetQuerier
+	breaker CircuitBreaker
+}
+
+// NewBreakerGetQuerier creates a new GetQuerier that makes the inner GetQuerier's queries
+// through the CircuitBreaker.
+func NewBreakerGetQuerier(inner GetQuerier, b CircuitBreaker) GetQuerier {
+	return &breakerGetQuerier{inner: inner, breaker: b}
+}
+
+func (q *breakerGetQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.GetRequest, opts ...grpc.CallOption,
+) (*api.GetResponse, error) {
+	var rp *api.GetResponse
+	err := breakerCall(q.breaker, pConn.Address().String(), func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type breakerPutQuerier struct {
+	inner   PutQuerier
+	breaker CircuitBreaker
+}
+
+// NewBreakerPutQuerier creates a new PutQuerier that makes the inner PutQuerier's queries
+// through the CircuitBreaker.
+func NewBreakerPutQuerier(inner PutQuerier, b CircuitBreaker) PutQuerier {
+	return &breakerPutQuerier{inner: inner, breaker: b}
+}
+
+func (q *breakerPutQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.PutRequest, opts ...grpc.CallOption,
+) (*api.PutResponse, error) {
+	var rp *api.PutResponse
+	err := breakerCall(q.breaker, pConn.Address().String(), func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
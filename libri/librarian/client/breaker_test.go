@@ -0,0 +1,154 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Minute).(*circuitBreaker)
+	now := time.Unix(1500000000, 0)
+	b.now = func() time.Time { return now }
+	addr1, addr2 := "127.0.0.1:20100", "127.0.0.1:20101"
+	deadlineErr := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+
+	// check circuit stays closed until max consecutive failures
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, b.Allow(addr1))
+		b.Record(addr1, errUnavailable)
+	}
+	assert.Nil(t, b.Allow(addr1))
+	b.Record(addr1, status.Error(codes.InvalidArgument, "invalid"))
+	assert.NotContains(t, b.circuits, addr1)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, b.Allow(addr1))
+		b.Record(addr1, deadlineErr)
+	}
+
+	// check open circuit rejects requests only to its address
+	assert.Equal(t, ErrCircuitOpen, b.Allow(addr1))
+	assert.Nil(t, b.Allow(addr2))
+	b.Record(addr2, nil)
+
+	// check single trial request allowed after cool-down, which reopens circuit if it fails
+	now = now.Add(time.Minute)
+	assert.Nil(t, b.Allow(addr1))
+	assert.Equal(t, ErrCircuitOpen, b.Allow(addr1))
+	b.Record(addr1, errUnavailable)
+	assert.Equal(t, ErrCircuitOpen, b.Allow(addr1))
+
+	// check canceled trial allows another trial
+	now = now.Add(time.Minute)
+	assert.Nil(t, b.Allow(addr1))
+	b.Record(addr1, status.Error(codes.Canceled, "canceled"))
+	assert.Nil(t, b.Allow(addr1))
+
+	// check successful trial closes circuit
+	b.Record(addr1, nil)
+	assert.Nil(t, b.Allow(addr1))
+	assert.Nil(t, b.Allow(addr1))
+	assert.Len(t, b.circuits, 0)
+}
+
+func TestNewDefaultCircuitBreaker(t *testing.T) {
+	b := NewDefaultCircuitBreaker().(*circuitBreaker)
+	assert.Equal(t, uint(DefaultBreakerMaxFailures), b.maxFailures)
+	assert.Equal(t, DefaultBreakerCooldown, b.cooldown)
+}
+
+func TestNewBreakerUnaryInterceptor(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Minute)
+	addr := peer.NewTestPublicAddr(1)
+	interceptor := NewBreakerUnaryInterceptor(b, addr)
+	nCalls := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		nCalls++
+		return errUnavailable
+	}
+
+	// check failure opens circuit for librarian, so next request isn't made
+	err := interceptor(context.Background(), "/api.Librarian/Put", nil, nil, nil, invoker)
+	assert.Equal(t, errUnavailable, err)
+	err = interceptor(context.Background(), "/api.Librarian/Put", nil, nil, nil, invoker)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, 1, nCalls)
+	assert.Equal(t, ErrCircuitOpen, b.Allow(addr.String()))
+}
+
+func TestBreakerQueriers(t *testing.T) {
+	pConn := peer.NewTestConnector(1)
+	addr := pConn.Address().String()
+
+	intro := &fixedIntroduceQuerier{nFailures: 1, rp: &api.IntroduceResponse{}}
+	b := NewCircuitBreaker(1, time.Minute)
+	q1 := NewBreakerIntroduceQuerier(intro, b)
+	_, err := q1.Query(nil, pConn, nil)
+	assert.Equal(t, errUnavailable, err)
+	introRp, err := q1.Query(nil, pConn, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Nil(t, introRp)
+	assert.Equal(t, 1, intro.nCalls)
+
+	find := &fixedFindQuerier{nFailures: 1, rp: &api.FindResponse{}}
+	b = NewCircuitBreaker(1, time.Minute)
+	q2 := NewBreakerFindQuerier(find, b)
+	_, err = q2.Query(nil, pConn, nil)
+	assert.Equal(t, errUnavailable, err)
+	findRp, err := q2.Query(nil, pConn, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Nil(t, findRp)
+	assert.Equal(t, 1, find.nCalls)
+
+	store := &fixedStoreQuerier{nFailures: 1, rp: &api.StoreResponse{}}
+	b = NewCircuitBreaker(1, time.Minute)
+	q3 := NewBreakerStoreQuerier(store, b)
+	_, err = q3.Query(nil, pConn, nil)
+	assert.Equal(t, errUnavailable, err)
+	storeRp, err := q3.Query(nil, pConn, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Nil(t, storeRp)
+	assert.Equal(t, 1, store.nCalls)
+
+	get := &fixedGetQuerier{nFailures: 1, rp: &api.GetResponse{}}
+	b = NewCircuitBreaker(1, time.Minute)
+	q4 := NewBreakerGetQuerier(get, b)
+	_, err = q4.Query(nil, pConn, nil)
+	assert.Equal(t, errUnavailable, err)
+	getRp, err := q4.Query(nil, pConn, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Nil(t, getRp)
+	assert.Equal(t, 1, get.nCalls)
+
+	put := &fixedPutQuerier{nFailures: 1, rp: &api.PutResponse{}}
+	b = NewCircuitBreaker(1, time.Minute)
+	q5 := NewBreakerPutQuerier(put, b)
+	_, err = q5.Query(nil, pConn, nil)
+	assert.Equal(t, errUnavailable, err)
+	putRp, err := q5.Query(nil, pConn, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Nil(t, putRp)
+	assert.Equal(t, 1, put.nCalls)
+
+	// check successful queries are returned and keep circuit closed
+	b = NewCircuitBreaker(1, time.Minute)
+	find = &fixedFindQuerier{rp: &api.FindResponse{}}
+	findRp, err = NewBreakerFindQuerier(find, b).Query(nil, pConn, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, find.rp, findRp)
+	assert.Nil(t, b.Allow(addr))
+
+	// check errors that mean the librarian responded don't open circuit
+	b = NewCircuitBreaker(1, time.Minute)
+	b.Record(addr, errors.New("some non-status error"))
+	assert.Nil(t, b.Allow(addr))
+}
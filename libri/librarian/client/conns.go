@@ -0,0 +1,295 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// DefaultConnIdleTimeout is the default time a connection without any references stays open
+// before it is closed.
+const DefaultConnIdleTimeout = 5 * time.Minute
+
+var (
+	// ErrConnManagerClosed indicates when a connection is acquired from a closed ConnManager.
+	ErrConnManagerClosed = errors.New("connection manager closed")
+
+	// ErrConnNotAcquired indicates when a connection is released more times than it was
+	// acquired.
+	ErrConnNotAcquired = errors.New("connection not acquired")
+)
+
+// ConnManager shares a single grpc connection to each address among all its users. Connections
+// are reference counted and closed once they have had no references for the idle timeout.
+type ConnManager interface {
+	// Acquire returns a client for the shared connection to the address, dialing it if it
+	// doesn't already exist, and adds a reference to it.
+	Acquire(addr *net.TCPAddr) (api.LibrarianClient, error)
+
+	// Release removes a reference to the connection to the address.
+	Release(addr *net.TCPAddr) error
+
+	// Connector returns an api.Connector for the address that acquires the shared connection
+	// when connecting and releases it when disconnecting.
+	Connector(addr *net.TCPAddr) api.Connector
+
+	// Close closes all connections, regardless of their references.
+	Close() error
+}
+
+type connManager struct {
+	mu          sync.Mutex
+	idleTimeout time.Duration
+	dial        func(addr *net.TCPAddr) (*grpc.ClientConn, error)
+	conns       map[string]*sharedConn
+	closed      bool
+}
+
+// sharedConn is a connection to an address and its number of references.
+type sharedConn struct {
+	conn   *grpc.ClientConn
+	client api.LibrarianClient
+	refs   uint
+	idle   *time.Timer
+}
+
+// NewConnManager returns a new ConnManager that closes connections once they have had no
+// references for the idle timeout.
+func NewConnManager(idleTimeout time.Duration) ConnManager {
+	return NewInterceptingConnManager(idleTimeout, nil)
+}
+
+// NewInterceptingConnManager returns a new ConnManager like NewConnManager whose connections use
+// the interceptors.
+func NewInterceptingConnManager(
+	idleTimeout time.Duration, interceptors *api.Interceptors,
+) ConnManager {
+	return NewSecureConnManager(idleTimeout, nil, interceptors)
+}
+
+// NewSecureConnManager returns a new ConnManager like NewInterceptingConnManager whose
+// connections also use TLS when the TLS config is non-nil.
+func NewSecureConnManager(
+	idleTimeout time.Duration, tlsConfig *tls.Config, interceptors *api.Interceptors,
+) ConnManager {
+	return &connManager{
+		idleTimeout: idleTimeout,
+		dial: func(addr *net.TCPAddr) (*grpc.ClientConn, error) {
+			return api.Dial(addr, tlsConfig, interceptors)
+		},
+		conns: make(map[string]*sharedConn),
+	}
+}
+
+func (m *connManager) Acquire(addr *net.TCPAddr) (api.LibrarianClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrConnManagerClosed
+	}
+	sc, in := m.conns[addr.String()]
+	if !in {
+		conn, err := m.dial(addr)
+		if err != nil {
+			return nil, err
+		}
+		sc = &sharedConn{conn: conn, client: api.NewLibrarianClient(conn)}
+		m.conns[addr.String()] = sc
+	}
+	if sc.idle != nil {
+		sc.idle.Stop()
+		sc.idle = nil
+	}
+	sc.refs++
+	return sc.client, nil
+}
+
+func (m *connManager) Release(addr *net.TCPAddr) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sc, in := m.conns[addr.String()]
+	if !in || sc.refs == 0 {
+		return ErrConnNotAcquired
+	}
+	sc.refs--
+	if sc.refs == 0 {
+		sc.idle = time.AfterFunc(m.idleTimeout, func() { m.expire(addr.String(), sc) })
+	}
+	return nil
+}
+
+func (m *connManager) Connector(addr *net.TCPAddr) api.Connector {
+	return &sharedConnector{addr: addr, conns: m}
+}
+
+func (m *connManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	var firstErr error
+	for addr, sc := range m.conns {
+		if sc.idle != nil {
+			sc.idle.Stop()
+		}
+		if err := sc.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(m.conns, addr)
+	}
+	return firstErr
+}
+
+// expire closes the connection to the address if it still has no references.
+func (m *connManager) expire(addr string, sc *sharedConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conns[addr] != sc || sc.refs > 0 {
+		// connection was acquired again or closed since it went idle
+		return
+	}
+	delete(m.conns, addr)
+	_ = sc.conn.Close()
+}
+
+// sharedConnector is an api.Connector holding at most one reference to a ConnManager's
+// connection.
+type sharedConnector struct {
+	mu     sync.Mutex
+	addr   *net.TCPAddr
+	conns  ConnManager
+	client api.LibrarianClient
+}
+
+func (c *sharedConnector) Connect() (api.LibrarianClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		client, err := c.conns.Acquire(c.addr)
+		if err != nil {
+			return nil, err
+		}
+		c.client = client
+	}
+	return c.client, nil
+}
+
+func (c *sharedConnector) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		return nil
+	}
+	c.client = nil
+	return c.conns.Release(c.addr)
+}
+
+func (c *sharedConnector) Address() *net.TCPAddr {
+	return c.addr
+}
+
+type sharedIntroduceQuerier struct {
+	inner IntroduceQuerier
+	conns ConnManager
+}
+
+// NewSharedIntroduceQuerier creates a new IntroduceQuerier that makes the inner IntroduceQuerier's
+// queries over the ConnManager's shared connection to each peer instead of the peer's own.
+func NewSharedIntroduceQuerier(inner IntroduceQuerier, conns ConnManager) IntroduceQuerier {
+	return &sharedIntroduceQuerier{inner: inner, conns: conns}
+}
+
+func (q *sharedIntroduceQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.IntroduceRequest, opts ...grpc.CallOption,
+) (*api.IntroduceResponse, error) {
+	shared := q.conns.Connector(pConn.Address())
+	defer disconnect(shared)
+	return q.inner.Query(ctx, shared, rq, opts...)
+}
+
+type sharedFindQuerier struct {
+	inner FindQuerier
+	conns ConnManager
+}
+
+// NewSharedFindQuerier creates a new FindQuerier that makes the inner FindQuerier's queries over
+// the ConnManager's shared connection to each peer instead of the peer's own.
+func NewSharedFindQuerier(inner FindQuerier, conns ConnManager) FindQuerier {
+	return &sharedFindQuerier{inner: inner, conns: conns}
+}
+
+func (q *sharedFindQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.FindRequest, opts ...grpc.CallOption,
+) (*api.FindResponse, error) {
+	shared := q.conns.Connector(pConn.Address())
+	defer disconnect(shared)
+	return q.inner.Query(ctx, shared, rq, opts...)
+}
+
+type sharedStoreQuerier struct {
+	inner StoreQuerier
+	conns ConnManager
+}
+
+// NewSharedStoreQuerier creates a new StoreQuerier that makes the inner StoreQuerier's queries
+// over the ConnManager's shared connection to each peer instead of the peer's own.
+func NewSharedStoreQuerier(inner StoreQuerier, conns ConnManager) StoreQuerier {
+	return &sharedStoreQuerier{inner: inner, conns: conns}
+}
+
+func (q *sharedStoreQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.StoreRequest, opts ...grpc.CallOption,
+) (*api.StoreResponse, error) {
+	shared := q.conns.Connector(pConn.Address())
+	defer disconnect(shared)
+	return q.inner.Query(ctx, shared, rq, opts...)
+}
+
+type sharedGetQuerier struct {
+	inner GetQuerier
+	conns ConnManager
+}
+
+// NewSharedGetQuerier creates a new GetQuerier that makes the inner GetQuerier's queries over the
+// ConnManager's shared connection to each peer instead of the peer's own.
+func NewSharedGetQuerier(inner GetQuerier, conns ConnManager) GetQuerier {
+	return &sharedGetQuerier{inner: inner, conns: conns}
+}
+
+func (q *sharedGetQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.GetRequest, opts ...grpc.CallOption,
+) (*api.GetResponse, error) {
+	shared := q.conns.Connector(pConn.Address())
+	defer disconnect(shared)
+	return q.inner.Query(ctx, shared, rq, opts...)
+}
+
+type sharedPutQuerier struct {
+	inner PutQuerier
+	conns ConnManager
+}
+
+// NewSharedPutQuerier creates a new PutQuerier that makes the inner PutQuerier's queries over the
+// ConnManager's shared connection to each peer instead of the peer's own.
+func NewSharedPutQuerier(inner PutQuerier, conns ConnManager) PutQuerier {
+	return &sharedPutQuerier{inner: inner, conns: conns}
+}
+
+func (q *sharedPutQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.PutRequest, opts ...grpc.CallOption,
+) (*api.PutResponse, error) {
+	shared := q.conns.Connector(pConn.Address())
+	defer disconnect(shared)
+	return q.inner.Query(ctx, shared, rq, opts...)
+}
+
+// disconnect releases the shared connection after a query; errors just mean the manager was
+// closed during the query, which already closed the connection.
+func disconnect(shared api.Connector) {
+	_ = shared.Disconnect()
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestConnManager_AcquireRelease(t *testing.T) {
+	m := NewConnManager(DefaultConnIdleTimeout)
+	addr1, addr2 := peer.NewTestPublicAddr(1), peer.NewTestPublicAddr(2)
+
+	// check same address shares a connection and different addresses don't
+	lc1, err := m.Acquire(addr1)
+	assert.Nil(t, err)
+	lc2, err := m.Acquire(addr1)
+	assert.Nil(t, err)
+	lc3, err := m.Acquire(addr2)
+	assert.Nil(t, err)
+	assert.True(t, lc1 == lc2)
+	assert.False(t, lc1 == lc3)
+	assert.Len(t, m.(*connManager).conns, 2)
+	assert.Equal(t, uint(2), m.(*connManager).conns[addr1.String()].refs)
+
+	// check connection is kept open while it has references
+	assert.Nil(t, m.Release(addr1))
+	assert.Equal(t, uint(1), m.(*connManager).conns[addr1.String()].refs)
+	assert.Nil(t, m.Release(addr1))
+	assert.Equal(t, uint(0), m.(*connManager).conns[addr1.String()].refs)
+
+	// check releasing more than acquired errors
+	assert.Equal(t, ErrConnNotAcquired, m.Release(addr1))
+	assert.Equal(t, ErrConnNotAcquired, m.Release(peer.NewTestPublicAddr(3)))
+
+	// check idle connection is reused when acquired again
+	lc4, err := m.Acquire(addr1)
+	assert.Nil(t, err)
+	assert.True(t, lc1 == lc4)
+	assert.Nil(t, m.(*connManager).conns[addr1.String()].idle)
+
+	// check closing closes all connections
+	assert.Nil(t, m.Close())
+	assert.Len(t, m.(*connManager).conns, 0)
+	lc5, err := m.Acquire(addr1)
+	assert.Equal(t, ErrConnManagerClosed, err)
+	assert.Nil(t, lc5)
+}
+
+func TestNewInterceptingConnManager(t *testing.T) {
+	methods := make([]string, 0)
+	interceptors := &api.Interceptors{
+		Unary: []grpc.UnaryClientInterceptor{
+			func(ctx context.Context, method string, req, reply interface{},
+				cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+				opts ...grpc.CallOption) error {
+				methods = append(methods, method)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			},
+		},
+	}
+	m := NewInterceptingConnManager(DefaultConnIdleTimeout, interceptors)
+	defer func() { assert.Nil(t, m.Close()) }()
+
+	// check shared connection requests go through interceptors, even if no peer is listening
+	lc, err := m.Acquire(peer.NewTestPublicAddr(1))
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = lc.Find(ctx, &api.FindRequest{})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"/api.Librarian/Find"}, methods)
+}
+
+func TestNewSecureConnManager(t *testing.T) {
+	m := NewSecureConnManager(DefaultConnIdleTimeout, &tls.Config{ServerName: "localhost"}, nil)
+	defer func() { assert.Nil(t, m.Close()) }()
+
+	// check TLS connection dialed without waiting for the handshake
+	lc, err := m.Acquire(peer.NewTestPublicAddr(1))
+	assert.Nil(t, err)
+	assert.NotNil(t, lc)
+}
+
+func TestConnManager_idleExpiry(t *testing.T) {
+	m := NewConnManager(10 * time.Millisecond)
+	addr := peer.NewTestPublicAddr(1)
+
+	lc1, err := m.Acquire(addr)
+	assert.Nil(t, err)
+	assert.Nil(t, m.Release(addr))
+
+	// check idle connection is closed after timeout
+	time.Sleep(100 * time.Millisecond)
+	m.(*connManager).mu.Lock()
+	assert.Len(t, m.(*connManager).conns, 0)
+	m.(*connManager).mu.Unlock()
+
+	// check new connection is dialed after expiry
+	lc2, err := m.Acquire(addr)
+	assert.Nil(t, err)
+	assert.False(t, lc1 == lc2)
+	assert.Nil(t, m.Close())
+}
+
+func TestConnManager_Acquire_err(t *testing.T) {
+	m := NewConnManager(DefaultConnIdleTimeout)
+	m.(*connManager).dial = func(addr *net.TCPAddr) (*grpc.ClientConn, error) {
+		return nil, errors.New("some dial error")
+	}
+	lc, err := m.Acquire(peer.NewTestPublicAddr(1))
+	assert.NotNil(t, err)
+	assert.Nil(t, lc)
+	assert.Len(t, m.(*connManager).conns, 0)
+}
+
+func TestSharedConnector(t *testing.T) {
+	m := NewConnManager(DefaultConnIdleTimeout)
+	addr := peer.NewTestPublicAddr(1)
+	c1, c2 := m.Connector(addr), m.Connector(addr)
+	assert.Equal(t, addr, c1.Address())
+
+	// check connectors share connection but each holds only one reference
+	lc1, err := c1.Connect()
+	assert.Nil(t, err)
+	lc1, err = c1.Connect()
+	assert.Nil(t, err)
+	lc2, err := c2.Connect()
+	assert.Nil(t, err)
+	assert.True(t, lc1 == lc2)
+	assert.Equal(t, uint(2), m.(*connManager).conns[addr.String()].refs)
+
+	assert.Nil(t, c1.Disconnect())
+	assert.Nil(t, c1.Disconnect())
+	assert.Equal(t, uint(1), m.(*connManager).conns[addr.String()].refs)
+	assert.Nil(t, c2.Disconnect())
+	assert.Equal(t, uint(0), m.(*connManager).conns[addr.String()].refs)
+	assert.Nil(t, m.Close())
+
+	// check connect error surfaces
+	lc1, err = c1.Connect()
+	assert.Equal(t, ErrConnManagerClosed, err)
+	assert.Nil(t, lc1)
+}
+
+func TestSharedQueriers(t *testing.T) {
+	m := NewConnManager(DefaultConnIdleTimeout)
+	pConn := peer.NewTestConnector(1)
+	c := &sharedChecker{t: t, conns: m}
+
+	_, err := NewSharedIntroduceQuerier(&sharedCheckingIntroduceQuerier{c}, m).
+		Query(nil, pConn, nil)
+	assert.Nil(t, err)
+	_, err = NewSharedFindQuerier(&sharedCheckingFindQuerier{c}, m).Query(nil, pConn, nil)
+	assert.Nil(t, err)
+	_, err = NewSharedStoreQuerier(&sharedCheckingStoreQuerier{c}, m).Query(nil, pConn, nil)
+	assert.Nil(t, err)
+	_, err = NewSharedGetQuerier(&sharedCheckingGetQuerier{c}, m).Query(nil, pConn, nil)
+	assert.Nil(t, err)
+	_, err = NewSharedPutQuerier(&sharedCheckingPutQuerier{c}, m).Query(nil, pConn, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, c.nCalls)
+
+	// check reference is released after each query
+	assert.Len(t, m.(*connManager).conns, 1)
+	assert.Equal(t, uint(0), m.(*connManager).conns[pConn.Address().String()].refs)
+	assert.Nil(t, m.Close())
+}
+
+// sharedChecker checks each query is made over a shared connection to the peer's address.
+type sharedChecker struct {
+	t      *testing.T
+	conns  ConnManager
+	nCalls int
+}
+
+func (c *sharedChecker) check(pConn api.Connector) {
+	c.nCalls++
+	assert.IsType(c.t, &sharedConnector{}, pConn)
+	assert.Equal(c.t, peer.NewTestPublicAddr(1), pConn.Address())
+	lc, err := pConn.Connect()
+	assert.Nil(c.t, err)
+	assert.NotNil(c.t, lc)
+	assert.Equal(c.t, uint(1), c.conns.(*connManager).conns[pConn.Address().String()].refs)
+}
+
+type sharedCheckingIntroduceQuerier struct {
+	*sharedChecker
+}
+
+func (q *sharedCheckingIntroduceQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.IntroduceRequest, opts ...grpc.CallOption) (*api.IntroduceResponse, error) {
+	q.check(pConn)
+	return &api.IntroduceResponse{}, nil
+}
+
+type sharedCheckingFindQuerier struct {
+	*sharedChecker
+}
+
+func (q *sharedCheckingFindQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.FindRequest, opts ...grpc.CallOption) (*api.FindResponse, error) {
+	q.check(pConn)
+	return &api.FindResponse{}, nil
+}
+
+type sharedCheckingStoreQuerier struct {
+	*sharedChecker
+}
+
+func (q *sharedCheckingStoreQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.StoreRequest, opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	q.check(pConn)
+	return &api.StoreResponse{}, nil
+}
+
+type sharedCheckingGetQuerier struct {
+	*sharedChecker
+}
+
+func (q *sharedCheckingGetQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.GetRequest, opts ...grpc.CallOption) (*api.GetResponse, error) {
+	q.check(pConn)
+	return &api.GetResponse{}, nil
+}
+
+type sharedCheckingPutQuerier struct {
+	*sharedChecker
+}
+
+func (q *sharedCheckingPutQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.PutRequest, opts ...grpc.CallOption) (*api.PutResponse, error) {
+	q.check(pConn)
+	return &api.PutResponse{}, nil
+}
@@ -60,11 +60,20 @@ func NewSignedContext(signer Signer, request proto.Message) (context.Context, er
 // NewSignedTimeoutContext creates a new context with a timeout and request signature.
 func NewSignedTimeoutContext(signer Signer, request proto.Message, timeout time.Duration) (
 	context.Context, context.CancelFunc, error) {
+	return NewSignedTimeoutContextFrom(context.Background(), signer, request, timeout)
+}
+
+// NewSignedTimeoutContextFrom creates a new context with a timeout and request signature that is
+// also cancelled when the parent context is.
+func NewSignedTimeoutContextFrom(
+	parent context.Context, signer Signer, request proto.Message, timeout time.Duration,
+) (context.Context, context.CancelFunc, error) {
 
-	ctx, err := NewSignedContext(signer, request)
+	// sign the message
+	signedJWT, err := signer.Sign(request)
 	if err != nil {
 		return nil, func() {}, err
 	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	ctx, cancel := context.WithTimeout(NewSignatureContext(parent, signedJWT), timeout)
 	return ctx, cancel, nil
 }
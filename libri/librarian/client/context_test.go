@@ -73,3 +73,25 @@ func TestNewSignedTimeoutContext_err(t *testing.T) {
 	assert.NotNil(t, cancel)
 	assert.NotNil(t, err)
 }
+
+func TestNewSignedTimeoutContextFrom(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel, err := NewSignedTimeoutContextFrom(
+		parent,
+		&TestNoOpSigner{},
+		NewFindRequest(ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng), 20),
+		5*time.Second,
+	)
+	defer cancel()
+	assert.Nil(t, err)
+	md, in := metadata.FromOutgoingContext(ctx)
+	assert.True(t, in)
+	assert.NotNil(t, md[signatureKey])
+
+	// cancelling parent also cancels child
+	assert.Nil(t, ctx.Err())
+	cancelParent()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ed25519"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/golang/protobuf/proto"
+)
+
+// SigningMethodEdDSA is the JWT signing method (RFC 8037) of signatures by Ed25519 keys.
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+type signingMethodEdDSA struct{}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify verifies the signature of the signing string with an ed25519.PublicKey.
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Sign signs the signing string with an ed25519.PrivateKey.
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok || len(priv) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(priv, []byte(signingString))), nil
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewIDSigner returns a new Signer instance using the private key of the ID, whose signatures
+// use the JWT signing method of the ID's curve.
+func NewIDSigner(id ecid.ID) Signer {
+	if key, ok := id.Signer().(ed25519.PrivateKey); ok {
+		return &ed25519Signer{key}
+	}
+	return NewSigner(id.Key())
+}
+
+func (s *ed25519Signer) Sign(m proto.Message) (string, error) {
+	hash, err := hashMessage(m)
+	if err != nil {
+		return "", err
+	}
+	return signClaims(NewSignatureClaims(hash), s.key)
+}
+
+// signingMethod returns the JWT signing method of signatures by the private key.
+func signingMethod(key crypto.Signer) jwt.SigningMethod {
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		return SigningMethodEdDSA
+	}
+	return jwt.SigningMethodES256
+}
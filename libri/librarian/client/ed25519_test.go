@@ -0,0 +1,110 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningMethodEdDSA_SignVerify(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	x1, x2 := ecid.NewPseudoRandomEd25519(rng), ecid.NewPseudoRandomEd25519(rng)
+	assert.Equal(t, SigningMethodEdDSA, jwt.GetSigningMethod("EdDSA"))
+
+	sig, err := SigningMethodEdDSA.Sign("some signing string", x1.Signer())
+	assert.Nil(t, err)
+	assert.Nil(t, SigningMethodEdDSA.Verify("some signing string", sig, x1.PublicKey()))
+
+	// check wrong key, string, and signature
+	err = SigningMethodEdDSA.Verify("some signing string", sig, x2.PublicKey())
+	assert.Equal(t, jwt.ErrSignatureInvalid, err)
+	err = SigningMethodEdDSA.Verify("other signing string", sig, x1.PublicKey())
+	assert.Equal(t, jwt.ErrSignatureInvalid, err)
+	err = SigningMethodEdDSA.Verify("some signing string", "not base64!", x1.PublicKey())
+	assert.NotNil(t, err)
+
+	// check wrong key types
+	ecdsaID := ecid.NewPseudoRandom(rng)
+	sig, err = SigningMethodEdDSA.Sign("some signing string", ecdsaID.Key())
+	assert.Equal(t, jwt.ErrInvalidKeyType, err)
+	assert.Empty(t, sig)
+	err = SigningMethodEdDSA.Verify("some signing string", sig, ecdsaID.PublicKey())
+	assert.Equal(t, jwt.ErrInvalidKeyType, err)
+}
+
+func TestIDSignerVerifier_SignVerify_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	value, key := api.NewTestDocument(rng)
+	for _, peerID := range []ecid.ID{ecid.NewPseudoRandom(rng), ecid.NewPseudoRandomEd25519(rng)} {
+		signer, verifier := NewIDSigner(peerID), NewVerifier()
+		cases := []proto.Message{
+			NewFindRequest(peerID, key, 20),
+			NewStoreRequest(peerID, key, value),
+			NewGetRequest(peerID, key),
+			NewPutRequest(peerID, key, value),
+		}
+		for _, c := range cases {
+			encToken, err := signer.Sign(c)
+			assert.Nil(t, err)
+			assert.Nil(t, verifier.Verify(encToken, peerID.PublicKey(), c))
+		}
+	}
+}
+
+func TestIDSignerVerifier_SignVerify_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	edID, ecdsaID := ecid.NewPseudoRandomEd25519(rng), ecid.NewPseudoRandom(rng)
+	message := NewFindRequest(edID, ecid.NewPseudoRandom(rng), 20)
+
+	// check nil message error
+	_, err := NewIDSigner(edID).Sign(nil)
+	assert.NotNil(t, err)
+
+	encToken, err := NewIDSigner(edID).Sign(message)
+	assert.Nil(t, err)
+
+	// check verifying with a key on the other curve or a different message fails
+	assert.NotNil(t, NewVerifier().Verify(encToken, ecdsaID.PublicKey(), message))
+	assert.NotNil(t, NewVerifier().Verify(encToken, ecid.NewPseudoRandomEd25519(rng).PublicKey(),
+		message))
+	assert.NotNil(t, NewVerifier().Verify(encToken, edID.PublicKey(),
+		NewFindRequest(edID, ecid.NewPseudoRandom(rng), 20)))
+
+	// check ECDSA signature doesn't verify with Ed25519 key
+	encToken, err = NewIDSigner(ecdsaID).Sign(message)
+	assert.Nil(t, err)
+	assert.NotNil(t, NewVerifier().Verify(encToken, edID.PublicKey(), message))
+}
+
+func TestEd25519KeyRotationSession(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	ecdsaID, edID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandomEd25519(rng)
+
+	// check rotations between curves
+	for _, ids := range [][]ecid.ID{{ecdsaID, edID}, {edID, ecdsaID}} {
+		rotation, err := NewKeyRotation(ids[0], ids[1])
+		assert.Nil(t, err)
+		assert.Nil(t, VerifyKeyRotation(rotation))
+	}
+
+	// check session keys certified by Ed25519 identity
+	for _, sessionID := range []ecid.ID{ecid.NewPseudoRandom(rng),
+		ecid.NewPseudoRandomEd25519(rng)} {
+		cert, err := NewSessionCertificate(edID, sessionID, DefaultMaxSignatureAge)
+		assert.Nil(t, err)
+		assert.Nil(t, VerifySessionCertificate(cert, edID.PublicKey()))
+
+		signer, err := NewSessionSigner(sessionID, cert)
+		assert.Nil(t, err)
+		m := NewFindRequest(ecid.FromEd25519PublicKey(edID.Bytes()), ecid.NewPseudoRandom(rng),
+			20)
+		encToken, err := signer.Sign(m)
+		assert.Nil(t, err)
+		assert.Nil(t, NewVerifier().Verify(encToken, edID.PublicKey(), m))
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	// ErrMissingSharedSecret indicates when an HMAC Signer or Verifier is created without a
+	// shared secret.
+	ErrMissingSharedSecret = errors.New("missing shared secret")
+
+	// ErrUnexpectedSigningMethod indicates when a signature wasn't made with the signing method
+	// the verifier expects.
+	ErrUnexpectedSigningMethod = errors.New("unexpected signature signing method")
+)
+
+type hmacSigner struct {
+	secret []byte
+}
+
+// NewHMACSigner returns a new Signer instance that signs with an HMAC using the secret shared by
+// all peers and clients in a fully trusted private cluster. HMAC signatures are much cheaper to
+// make and verify than ECDSA signatures, but anyone with the secret can sign as any peer.
+func NewHMACSigner(secret []byte) (Signer, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingSharedSecret
+	}
+	return &hmacSigner{secret: secret}, nil
+}
+
+func (s *hmacSigner) Sign(m proto.Message) (string, error) {
+	hash, err := hashMessage(m)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, NewSignatureClaims(hash))
+	return token.SignedString(s.secret)
+}
+
+type hmacVerifier struct {
+	secret []byte
+	params *VerifyParameters
+	now    func() time.Time
+}
+
+// NewHMACVerifier returns a new Verifier instance that verifies HMAC signatures made with the
+// shared secret. Since the secret doesn't identify any one peer, the verifier ignores the public
+// key the message is from.
+func NewHMACVerifier(secret []byte, params *VerifyParameters) (Verifier, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingSharedSecret
+	}
+	return &hmacVerifier{
+		secret: secret,
+		params: params,
+		now:    time.Now,
+	}, nil
+}
+
+func (v *hmacVerifier) Verify(encToken string, fromPubKey crypto.PublicKey,
+	m proto.Message) error {
+	token, err := jwt.ParseWithClaims(encToken, &Claims{}, func(token *jwt.Token) (
+		interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnexpectedSigningMethod
+		}
+		return v.secret, nil
+	})
+	if err != nil {
+		// received error when parsing claims or verifying signature
+		return err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return fmt.Errorf("token claims %v are not expected SignatureClaims", token.Claims)
+	}
+	if err := verifyIssuedAt(v.params, v.now(), claims.IssuedAt); err != nil {
+		return err
+	}
+
+	return verifyMessageHash(m, claims.Hash)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMACSignerVerifier_SignVerify_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	secret := []byte("some shared secret")
+
+	signer, err := NewHMACSigner(secret)
+	assert.Nil(t, err)
+	verifier, err := NewHMACVerifier(secret, NewDefaultVerifyParameters())
+	assert.Nil(t, err)
+
+	cases := []proto.Message{
+		NewFindRequest(peerID, key, 20),
+		NewStoreRequest(peerID, key, value),
+		NewGetRequest(peerID, key),
+		NewPutRequest(peerID, key, value),
+	}
+	for _, c := range cases {
+		encToken, err := signer.Sign(c)
+		assert.Nil(t, err)
+		err = verifier.Verify(encToken, &peerID.Key().PublicKey, c)
+		assert.Nil(t, err)
+	}
+}
+
+func TestNewHMACSignerVerifier_err(t *testing.T) {
+	signer, err := NewHMACSigner(nil)
+	assert.Equal(t, ErrMissingSharedSecret, err)
+	assert.Nil(t, signer)
+
+	verifier, err := NewHMACVerifier([]byte{}, NewDefaultVerifyParameters())
+	assert.Equal(t, ErrMissingSharedSecret, err)
+	assert.Nil(t, verifier)
+}
+
+func TestHMACSigner_Sign_err(t *testing.T) {
+	signer, err := NewHMACSigner([]byte("some shared secret"))
+	assert.Nil(t, err)
+	_, err = signer.Sign(nil)
+	assert.NotNil(t, err) // protobuf needs to be not-nil
+}
+
+func TestHMACVerifier_Verify_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	message := NewFindRequest(peerID, ecid.NewPseudoRandom(rng), 20)
+	signer, err := NewHMACSigner([]byte("some shared secret"))
+	assert.Nil(t, err)
+	verifier, err := NewHMACVerifier([]byte("some shared secret"),
+		NewDefaultVerifyParameters())
+	assert.Nil(t, err)
+	encToken, err := signer.Sign(message)
+	assert.Nil(t, err)
+
+	// check different secret
+	otherVerifier, err := NewHMACVerifier([]byte("some other secret"),
+		NewDefaultVerifyParameters())
+	assert.Nil(t, err)
+	assert.NotNil(t, otherVerifier.Verify(encToken, &peerID.Key().PublicKey, message))
+
+	// check different message
+	otherMessage := NewFindRequest(peerID, ecid.NewPseudoRandom(rng), 20)
+	assert.NotNil(t, verifier.Verify(encToken, &peerID.Key().PublicKey, otherMessage))
+
+	// check ECDSA signature
+	ecdsaToken, err := NewSigner(peerID.Key()).Sign(message)
+	assert.Nil(t, err)
+	assert.NotNil(t, verifier.Verify(ecdsaToken, &peerID.Key().PublicKey, message))
+
+	// check HMAC signature with ECDSA verifier
+	assert.NotNil(t, NewVerifier().Verify(encToken, &peerID.Key().PublicKey, message))
+
+	// check expired signature
+	v := verifier.(*hmacVerifier)
+	v.now = func() time.Time { return time.Now().Add(time.Hour) }
+	assert.Equal(t, ErrSignatureExpired, v.Verify(encToken, &peerID.Key().PublicKey, message))
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/golang/protobuf/proto"
+)
+
+// ErrUnsupportedKeySigner indicates when a key signer's public key isn't an ECDSA key on the
+// ecid.Curve.
+var ErrUnsupportedKeySigner = errors.New("key signer public key not on expected curve")
+
+// ecdsaSignature is the ASN.1 ECDSA signature crypto.Signers return.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+type keySigner struct {
+	key     crypto.Signer
+	keySize int
+}
+
+// NewKeySigner returns a new Signer instance that signs with the crypto.Signer, like a key in a
+// PKCS #11 module, HSM, or OS keystore, so the private key never lives in process memory. Its
+// signatures are identical in form to those of a Signer from NewSigner.
+func NewKeySigner(key crypto.Signer) (Signer, error) {
+	if _, err := KeySignerID(key); err != nil {
+		return nil, err
+	}
+	return &keySigner{
+		key:     key,
+		keySize: (ecid.Curve.Params().BitSize + 7) / 8,
+	}, nil
+}
+
+// KeySignerID returns the (public key only) ecid.ID of the crypto.Signer's key.
+func KeySignerID(key crypto.Signer) (ecid.ID, error) {
+	pub, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok || !onCurve(pub) {
+		return nil, ErrUnsupportedKeySigner
+	}
+	return ecid.FromPublicKey(&ecdsa.PublicKey{Curve: ecid.Curve, X: pub.X, Y: pub.Y}), nil
+}
+
+func (s *keySigner) Sign(m proto.Message) (string, error) {
+	hash, err := hashMessage(m)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, NewSignatureClaims(hash))
+	signingString, err := token.SigningString()
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signingString))
+	der, err := s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	sig := &ecdsaSignature{}
+	if _, err := asn1.Unmarshal(der, sig); err != nil {
+		return "", err
+	}
+
+	// JWS ECDSA signatures are the fixed-size big-endian R and S values concatenated
+	sigBytes := make([]byte, 2*s.keySize)
+	rBytes, sBytes := sig.R.Bytes(), sig.S.Bytes()
+	copy(sigBytes[s.keySize-len(rBytes):s.keySize], rBytes)
+	copy(sigBytes[2*s.keySize-len(sBytes):], sBytes)
+	return strings.Join([]string{signingString, jwt.EncodeSegment(sigBytes)}, "."), nil
+}
+
+// onCurve returns whether the public key is on the ecid.Curve, which may be represented by a
+// different elliptic.Curve implementation.
+func onCurve(pub *ecdsa.PublicKey) bool {
+	if pub.Curve == nil || pub.X == nil || pub.Y == nil {
+		return false
+	}
+	params, expected := pub.Curve.Params(), ecid.Curve.Params()
+	return params.P.Cmp(expected.P) == 0 && params.N.Cmp(expected.N) == 0 &&
+		params.B.Cmp(expected.B) == 0 && params.Gx.Cmp(expected.Gx) == 0 &&
+		params.Gy.Cmp(expected.Gy) == 0 && ecid.Curve.IsOnCurve(pub.X, pub.Y)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeySigner_ok(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	_, key := api.NewTestDocument(rng)
+	signer, err := NewKeySigner(&fixedKeySigner{key: peerID.Key()})
+	assert.Nil(t, err)
+
+	// check key signer signatures verify like those of a private key signer
+	rq := NewFindRequest(peerID, key, 20)
+	encToken, err := signer.Sign(rq)
+	assert.Nil(t, err)
+	err = NewVerifier().Verify(encToken, &peerID.Key().PublicKey, rq)
+	assert.Nil(t, err)
+
+	// check signature with another key doesn't verify
+	err = NewVerifier().Verify(encToken, &ecid.NewPseudoRandom(rng).Key().PublicKey, rq)
+	assert.NotNil(t, err)
+}
+
+func TestNewKeySigner_err(t *testing.T) {
+	signer, err := NewKeySigner(&fixedKeySigner{key: &ecdsa.PrivateKey{}})
+	assert.Equal(t, ErrUnsupportedKeySigner, err)
+	assert.Nil(t, signer)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	signer, err = NewKeySigner(&fixedKeySigner{key: p256Key})
+	assert.Equal(t, ErrUnsupportedKeySigner, err)
+	assert.Nil(t, signer)
+}
+
+func TestKeySigner_Sign_err(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	_, key := api.NewTestDocument(rng)
+	rq := NewFindRequest(peerID, key, 20)
+
+	// check nil message errors
+	signer, err := NewKeySigner(&fixedKeySigner{key: peerID.Key()})
+	assert.Nil(t, err)
+	_, err = signer.Sign(nil)
+	assert.NotNil(t, err)
+
+	// check crypto.Signer error bubbles up
+	signer, err = NewKeySigner(&fixedKeySigner{key: peerID.Key(),
+		signErr: errors.New("some Sign error")})
+	assert.Nil(t, err)
+	_, err = signer.Sign(rq)
+	assert.NotNil(t, err)
+
+	// check malformed signature errors
+	signer, err = NewKeySigner(&fixedKeySigner{key: peerID.Key(), signature: []byte{1, 2}})
+	assert.Nil(t, err)
+	_, err = signer.Sign(rq)
+	assert.NotNil(t, err)
+}
+
+func TestKeySignerID(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	id, err := KeySignerID(&fixedKeySigner{key: peerID.Key()})
+	assert.Nil(t, err)
+	assert.Equal(t, peerID.ID(), id.ID())
+	assert.Equal(t, ecid.ToPublicKeyBytes(peerID), ecid.ToPublicKeyBytes(id))
+	assert.Nil(t, id.Key().D)
+}
+
+// fixedKeySigner is a crypto.Signer with an in-memory key, standing in for a hardware-backed
+// key.
+type fixedKeySigner struct {
+	key       *ecdsa.PrivateKey
+	signature []byte
+	signErr   error
+}
+
+func (f *fixedKeySigner) Public() crypto.PublicKey {
+	return &f.key.PublicKey
+}
+
+func (f *fixedKeySigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) (
+	[]byte, error) {
+	if f.signErr != nil || f.signature != nil {
+		return f.signature, f.signErr
+	}
+	return f.key.Sign(rand, digest, opts)
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// QueryInfo describes a query made by a Querier.
+type QueryInfo struct {
+	// Method is the name of the queried RPC, e.g., "Find".
+	Method string
+
+	// Peer is the address of the queried peer.
+	Peer *net.TCPAddr
+
+	// RequestID is the ID in the request metadata, if any.
+	RequestID []byte
+}
+
+// QueryResult describes the outcome of a query made by a Querier.
+type QueryResult struct {
+	// Duration is the time from the start to the end of the query.
+	Duration time.Duration
+
+	// Code is the status code of the query's error, or codes.OK if it succeeded.
+	Code codes.Code
+
+	// Err is the query's error, if any.
+	Err error
+}
+
+// QueryObserver is notified of each query made by the Queriers it observes, e.g., to export
+// client-side metrics and traces.
+type QueryObserver interface {
+	// Started is called before the query is made and returns the context to make it with,
+	// e.g., one carrying a trace span.
+	Started(ctx context.Context, info *QueryInfo) context.Context
+
+	// Finished is called after the query is made with the context returned by Started.
+	Finished(ctx context.Context, info *QueryInfo, result *QueryResult)
+}
+
+// observe makes the query between notifying the observer of its start and finish.
+func observe(
+	ctx context.Context, obs QueryObserver, info *QueryInfo, query func(context.Context) error,
+) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = obs.Started(ctx, info)
+	start := time.Now()
+	err := query(ctx)
+	obs.Finished(ctx, info, &QueryResult{
+		Duration: time.Since(start),
+		Code:     grpc.Code(err),
+		Err:      err,
+	})
+	return err
+}
+
+// MethodMetrics are the aggregate metrics of the queries of a single method.
+type MethodMetrics struct {
+	// Started is the number of started queries.
+	Started uint64
+
+	// Finished is the number of finished queries.
+	Finished uint64
+
+	// Codes is the number of finished queries with each status code, including codes.OK.
+	Codes map[codes.Code]uint64
+
+	// Duration is the total duration of the finished queries.
+	Duration time.Duration
+}
+
+// QueryMetrics is a QueryObserver that aggregates metrics of the queries of each method.
+type QueryMetrics interface {
+	QueryObserver
+
+	// Metrics returns a copy of the current metrics of each method.
+	Metrics() map[string]*MethodMetrics
+}
+
+type queryMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*MethodMetrics
+}
+
+// NewQueryMetrics returns a new, empty QueryMetrics.
+func NewQueryMetrics() QueryMetrics {
+	return &queryMetrics{methods: make(map[string]*MethodMetrics)}
+}
+
+func (m *queryMetrics) Started(ctx context.Context, info *QueryInfo) context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.method(info.Method).Started++
+	return ctx
+}
+
+func (m *queryMetrics) Finished(ctx context.Context, info *QueryInfo, result *QueryResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mm := m.method(info.Method)
+	mm.Finished++
+	mm.Codes[result.Code]++
+	mm.Duration += result.Duration
+}
+
+func (m *queryMetrics) Metrics() map[string]*MethodMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := make(map[string]*MethodMetrics, len(m.methods))
+	for method, mm := range m.methods {
+		mmCopy := *mm
+		mmCopy.Codes = make(map[codes.Code]uint64, len(mm.Codes))
+		for code, n := range mm.Codes {
+			mmCopy.Codes[code] = n
+		}
+		metrics[method] = &mmCopy
+	}
+	return metrics
+}
+
+func (m *queryMetrics) method(method string) *MethodMetrics {
+	mm, in := m.methods[method]
+	if !in {
+		mm = &MethodMetrics{Codes: make(map[codes.Code]uint64)}
+		m.methods[method] = mm
+	}
+	return mm
+}
+
+type observedIntroduceQuerier struct {
+	inner IntroduceQuerier
+	obs   QueryObserver
+}
+
+// NewObservedIntroduceQuerier creates a new IntroduceQuerier that notifies the QueryObserver of
+// each query made by the inner IntroduceQuerier.
+func NewObservedIntroduceQuerier(inner IntroduceQuerier, obs QueryObserver) IntroduceQuerier {
+	return &observedIntroduceQuerier{inner: inner, obs: obs}
+}
+
+func (q *observedIntroduceQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.IntroduceRequest, opts ...grpc.CallOption,
+) (*api.IntroduceResponse, error) {
+	var rp *api.IntroduceResponse
+	info := &QueryInfo{
+		Method:    "Introduce",
+		Peer:      pConn.Address(),
+		RequestID: rq.GetMetadata().GetRequestId(),
+	}
+	err := observe(ctx, q.obs, info, func(ctx context.Context) error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type observedFindQuerier struct {
+	inner FindQuerier
+	obs   QueryObserver
+}
+
+// NewObservedFindQuerier creates a new FindQuerier that notifies the QueryObserver of each query
+// made by the inner FindQuerier.
+func NewObservedFindQuerier(inner FindQuerier, obs QueryObserver) FindQuerier {
+	return &observedFindQuerier{inner: inner, obs: obs}
+}
+
+func (q *observedFindQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.FindRequest, opts ...grpc.CallOption,
+) (*api.FindResponse, error) {
+	var rp *api.FindResponse
+	info := &QueryInfo{
+		Method:    "Find",
+		Peer:      pConn.Address(),
+		RequestID: rq.GetMetadata().GetRequestId(),
+	}
+	err := observe(ctx, q.obs, info, func(ctx context.Context) error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type observedStoreQuerier struct {
+	inner StoreQuerier
+	obs   QueryObserver
+}
+
+// NewObservedStoreQuerier creates a new StoreQuerier that notifies the QueryObserver of each
+// query made by the inner StoreQuerier.
+func NewObservedStoreQuerier(inner StoreQuerier, obs QueryObserver) StoreQuerier {
+	return &observedStoreQuerier{inner: inner, obs: obs}
+}
+
+func (q *observedStoreQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.StoreRequest, opts ...grpc.CallOption,
+) (*api.StoreResponse, error) {
+	var rp *api.StoreResponse
+	info := &QueryInfo{
+		Method:    "Store",
+		Peer:      pConn.Address(),
+		RequestID: rq.GetMetadata().GetRequestId(),
+	}
+	err := observe(ctx, q.obs, info, func(ctx context.Context) error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type observedGetQuerier struct {
+	inner GetQuerier
+	obs   QueryObserver
+}
+
+// NewObservedGetQuerier creates a new GetQuerier that notifies the QueryObserver of each query
+// made by the inner GetQuerier.
+func NewObservedGetQuerier(inner GetQuerier, obs QueryObserver) GetQuerier {
+	return &observedGetQuerier{inner: inner, obs: obs}
+}
+
+func (q *observedGetQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.GetRequest, opts ...grpc.CallOption,
+) (*api.GetResponse, error) {
+	var rp *api.GetResponse
+	info := &QueryInfo{
+		Method:    "Get",
+		Peer:      pConn.Address(),
+		RequestID: rq.GetMetadata().GetRequestId(),
+	}
+	err := observe(ctx, q.obs, info, func(ctx context.Context) error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
+
+type observedPutQuerier struct {
+	inner PutQuerier
+	obs   QueryObserver
+}
+
+// NewObservedPutQuerier creates a new PutQuerier that notifies the QueryObserver of each query
+// made by the inner PutQuerier.
+func NewObservedPutQuerier(inner PutQuerier, obs QueryObserver) PutQuerier {
+	return &observedPutQuerier{inner: inner, obs: obs}
+}
+
+func (q *observedPutQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.PutRequest, opts ...grpc.CallOption,
+) (*api.PutResponse, error) {
+	var rp *api.PutResponse
+	info := &QueryInfo{
+		Method:    "Put",
+		Peer:      pConn.Address(),
+		RequestID: rq.GetMetadata().GetRequestId(),
+	}
+	err := observe(ctx, q.obs, info, func(ctx context.Context) error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	return rp, err
+}
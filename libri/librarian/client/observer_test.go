@@ -0,0 +1,132 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+type ctxKey struct{}
+
+func TestObservedQueriers(t *testing.T) {
+	obs := &recordingObserver{}
+	pConn := peer.NewTestConnector(1)
+	rqMetadata := &api.RequestMetadata{RequestId: []byte{1, 2, 3}}
+
+	intro := &fixedIntroduceQuerier{rp: &api.IntroduceResponse{}}
+	introRp, err := NewObservedIntroduceQuerier(intro, obs).
+		Query(nil, pConn, &api.IntroduceRequest{Metadata: rqMetadata})
+	assert.Nil(t, err)
+	assert.Equal(t, intro.rp, introRp)
+
+	find := &fixedFindQuerier{rp: &api.FindResponse{}}
+	findRp, err := NewObservedFindQuerier(find, obs).
+		Query(nil, pConn, &api.FindRequest{Metadata: rqMetadata})
+	assert.Nil(t, err)
+	assert.Equal(t, find.rp, findRp)
+
+	store := &fixedStoreQuerier{rp: &api.StoreResponse{}}
+	storeRp, err := NewObservedStoreQuerier(store, obs).
+		Query(nil, pConn, &api.StoreRequest{Metadata: rqMetadata})
+	assert.Nil(t, err)
+	assert.Equal(t, store.rp, storeRp)
+
+	get := &fixedGetQuerier{rp: &api.GetResponse{}}
+	getRp, err := NewObservedGetQuerier(get, obs).
+		Query(nil, pConn, &api.GetRequest{Metadata: rqMetadata})
+	assert.Nil(t, err)
+	assert.Equal(t, get.rp, getRp)
+
+	put := &fixedPutQuerier{rp: &api.PutResponse{}}
+	putRp, err := NewObservedPutQuerier(put, obs).
+		Query(nil, pConn, &api.PutRequest{Metadata: rqMetadata})
+	assert.Nil(t, err)
+	assert.Equal(t, put.rp, putRp)
+
+	// check observer is notified of each query's start and finish
+	assert.Len(t, obs.started, 5)
+	assert.Len(t, obs.finished, 5)
+	for i, method := range []string{"Introduce", "Find", "Store", "Get", "Put"} {
+		assert.Equal(t, method, obs.started[i].Method)
+		assert.Equal(t, pConn.Address(), obs.started[i].Peer)
+		assert.Equal(t, rqMetadata.RequestId, obs.started[i].RequestID)
+		assert.Equal(t, obs.started[i], obs.finished[i])
+		assert.Equal(t, codes.OK, obs.results[i].Code)
+		assert.Nil(t, obs.results[i].Err)
+	}
+
+	// check error is observed and returned
+	find = &fixedFindQuerier{nFailures: 1}
+	findRp, err = NewObservedFindQuerier(find, obs).Query(nil, pConn, nil)
+	assert.Equal(t, errUnavailable, err)
+	assert.Nil(t, findRp)
+	assert.Nil(t, obs.finished[5].RequestID)
+	assert.Equal(t, codes.Unavailable, obs.results[5].Code)
+	assert.Equal(t, errUnavailable, obs.results[5].Err)
+}
+
+func TestObserve_ctx(t *testing.T) {
+	obs := &recordingObserver{}
+	info := &QueryInfo{Method: "Find"}
+	err := observe(context.Background(), obs, info, func(ctx context.Context) error {
+		// check query is made with context from Started
+		assert.Equal(t, "some value", ctx.Value(ctxKey{}))
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "some value", obs.finishedCtx.Value(ctxKey{}))
+	assert.True(t, obs.results[0].Duration >= time.Millisecond)
+}
+
+func TestQueryMetrics(t *testing.T) {
+	m := NewQueryMetrics()
+	find := &QueryInfo{Method: "Find"}
+	ctx := context.Background()
+	assert.Equal(t, ctx, m.Started(ctx, find))
+	m.Finished(ctx, find, &QueryResult{Duration: time.Second, Code: codes.OK})
+	m.Started(ctx, find)
+	m.Finished(ctx, find, &QueryResult{Duration: 2 * time.Second, Code: codes.Unavailable})
+	m.Started(ctx, find)
+	m.Started(ctx, &QueryInfo{Method: "Store"})
+
+	metrics := m.Metrics()
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, &MethodMetrics{
+		Started:  3,
+		Finished: 2,
+		Codes:    map[codes.Code]uint64{codes.OK: 1, codes.Unavailable: 1},
+		Duration: 3 * time.Second,
+	}, metrics["Find"])
+	assert.Equal(t, uint64(1), metrics["Store"].Started)
+	assert.Zero(t, metrics["Store"].Finished)
+
+	// check returned metrics are a copy
+	metrics["Find"].Codes[codes.OK] = 10
+	assert.Equal(t, uint64(1), m.Metrics()["Find"].Codes[codes.OK])
+}
+
+type recordingObserver struct {
+	started     []*QueryInfo
+	finished    []*QueryInfo
+	results     []*QueryResult
+	finishedCtx context.Context
+}
+
+func (o *recordingObserver) Started(ctx context.Context, info *QueryInfo) context.Context {
+	o.started = append(o.started, info)
+	return context.WithValue(ctx, ctxKey{}, "some value")
+}
+
+func (o *recordingObserver) Finished(
+	ctx context.Context, info *QueryInfo, result *QueryResult,
+) {
+	o.finished = append(o.finished, info)
+	o.results = append(o.results, result)
+	o.finishedCtx = ctx
+}
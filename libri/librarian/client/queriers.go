@@ -1,6 +1,8 @@
 package client
 
 import (
+	"time"
+
 	"github.com/drausin/libri/libri/librarian/api"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -13,11 +15,13 @@ type IntroduceQuerier interface {
 		opts ...grpc.CallOption) (*api.IntroduceResponse, error)
 }
 
-type introQuerier struct{}
+type introQuerier struct {
+	timeout time.Duration
+}
 
-// NewIntroduceQuerier creates a new IntroduceQuerier.
+// NewIntroduceQuerier creates a new IntroduceQuerier with the default Introduce timeout.
 func NewIntroduceQuerier() IntroduceQuerier {
-	return &introQuerier{}
+	return &introQuerier{timeout: DefaultIntroduceTimeout}
 }
 
 func (q *introQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.IntroduceRequest,
@@ -26,6 +30,8 @@ func (q *introQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.I
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	return client.Introduce(ctx, rq, opts...)
 }
 
@@ -37,11 +43,14 @@ type FindQuerier interface {
 		opts ...grpc.CallOption) (*api.FindResponse, error)
 }
 
-type findQuerier struct{}
+type findQuerier struct {
+	timeout time.Duration
+}
 
-// NewFindQuerier creates a new FindQuerier instance for FindPeers queries.
+// NewFindQuerier creates a new FindQuerier instance for FindPeers queries with the default Find
+// timeout.
 func NewFindQuerier() FindQuerier {
-	return &findQuerier{}
+	return &findQuerier{timeout: DefaultFindTimeout}
 }
 
 func (q *findQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.FindRequest,
@@ -50,6 +59,8 @@ func (q *findQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.Fi
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	return client.Find(ctx, rq, opts...)
 }
 
@@ -60,11 +71,13 @@ type StoreQuerier interface {
 		opts ...grpc.CallOption) (*api.StoreResponse, error)
 }
 
-type storeQuerier struct{}
+type storeQuerier struct {
+	timeout time.Duration
+}
 
-// NewStoreQuerier creates a new StoreQuerier instance.
+// NewStoreQuerier creates a new StoreQuerier instance with the default Store timeout.
 func NewStoreQuerier() StoreQuerier {
-	return &storeQuerier{}
+	return &storeQuerier{timeout: DefaultStoreTimeout}
 }
 
 func (q *storeQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.StoreRequest,
@@ -73,6 +86,8 @@ func (q *storeQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.S
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	return client.Store(ctx, rq, opts...)
 }
 
@@ -83,11 +98,13 @@ type GetQuerier interface {
 		opts ...grpc.CallOption) (*api.GetResponse, error)
 }
 
-type getQuerier struct{}
+type getQuerier struct {
+	timeout time.Duration
+}
 
-// NewGetQuerier returns a new GetQuerier instance.
+// NewGetQuerier returns a new GetQuerier instance with the default Get timeout.
 func NewGetQuerier() GetQuerier {
-	return &getQuerier{}
+	return &getQuerier{timeout: DefaultGetTimeout}
 }
 
 func (q *getQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.GetRequest,
@@ -96,6 +113,8 @@ func (q *getQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.Get
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	return client.Get(ctx, rq, opts...)
 }
 
@@ -106,11 +125,13 @@ type PutQuerier interface {
 		opts ...grpc.CallOption) (*api.PutResponse, error)
 }
 
-type putQuerier struct{}
+type putQuerier struct {
+	timeout time.Duration
+}
 
-// NewPutQuerier returns a new PutQuerier instance.
+// NewPutQuerier returns a new PutQuerier instance with the default Put timeout.
 func NewPutQuerier() PutQuerier {
-	return &putQuerier{}
+	return &putQuerier{timeout: DefaultPutTimeout}
 }
 
 func (q *putQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.PutRequest,
@@ -119,5 +140,7 @@ func (q *putQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.Put
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	return client.Put(ctx, rq, opts...)
 }
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+const (
+	// DefaultRemoteSignTimeout is the default timeout for each request to a remote signing
+	// service.
+	DefaultRemoteSignTimeout = 5 * time.Second
+
+	remoteSignContentType = "application/json"
+)
+
+// ErrUnsupportedRemoteSignHash indicates when a remote key is asked to sign a digest from a hash
+// function other than SHA-256.
+var ErrUnsupportedRemoteSignHash = errors.New("remote key only signs SHA-256 digests")
+
+// SigningService signs digests with an ECDSA key on the ecid.Curve held outside the process,
+// e.g., in a central KMS. Only digests are sent to the service; the messages and JWTs they are
+// computed from are hashed locally.
+type SigningService interface {
+	// PublicKey returns the public key of the service's signing key, in the form of
+	// ecid.ToPublicKeyBytes.
+	PublicKey(ctx context.Context) ([]byte, error)
+
+	// SignDigest returns the ASN.1 DER ECDSA signature of the SHA-256 digest.
+	SignDigest(ctx context.Context, digest []byte) ([]byte, error)
+}
+
+type remoteKey struct {
+	svc     SigningService
+	pub     crypto.PublicKey
+	timeout time.Duration
+}
+
+// NewRemoteKey returns a new crypto.Signer that signs with the SigningService, e.g., for use as
+// a server or author KeySigner. It fetches the service's public key once when created.
+func NewRemoteKey(svc SigningService, timeout time.Duration) (crypto.Signer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	pubBytes, err := svc.PublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ecid.FromPublicKeyBytes(pubBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteKey{svc: svc, pub: pub, timeout: timeout}, nil
+}
+
+// NewRemoteSigner returns a new Signer instance that signs with the SigningService, so the
+// private key never lives in process memory. Its signatures are identical in form to those of a
+// Signer from NewSigner.
+func NewRemoteSigner(svc SigningService, timeout time.Duration) (Signer, error) {
+	key, err := NewRemoteKey(svc, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key)
+}
+
+func (k *remoteKey) Public() crypto.PublicKey {
+	return k.pub
+}
+
+func (k *remoteKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA256 {
+		return nil, ErrUnsupportedRemoteSignHash
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), k.timeout)
+	defer cancel()
+	return k.svc.SignDigest(ctx, digest)
+}
+
+// RemotePublicKey is the JSON body an HTTP signing service responds to public key requests with,
+// with the key base-64-url encoded.
+type RemotePublicKey struct {
+	PublicKey string `json:"public_key"`
+}
+
+// RemoteSignRequest is the JSON body POSTed to an HTTP signing service to sign a digest, with
+// the digest base-64-url encoded.
+type RemoteSignRequest struct {
+	Digest string `json:"digest"`
+	Hash   string `json:"hash"`
+}
+
+// RemoteSignResponse is the JSON body an HTTP signing service responds to sign requests with,
+// with the ASN.1 DER signature base-64-url encoded.
+type RemoteSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+type httpSigningService struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSigningService returns a new SigningService that GETs its public key from the
+// "/public_key" path and POSTs digests to sign to the "/sign" path of the base URL.
+func NewHTTPSigningService(baseURL string, client *http.Client) SigningService {
+	return &httpSigningService{
+		url:    strings.TrimSuffix(baseURL, "/"),
+		client: client,
+	}
+}
+
+func (s *httpSigningService) PublicKey(ctx context.Context) ([]byte, error) {
+	rp, err := ctxhttp.Get(ctx, s.client, s.url+"/public_key")
+	if err != nil {
+		return nil, err
+	}
+	pub := &RemotePublicKey{}
+	if err := decodeRemoteResponse(rp, pub); err != nil {
+		return nil, err
+	}
+	return base64.URLEncoding.DecodeString(pub.PublicKey)
+}
+
+func (s *httpSigningService) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	body, err := json.Marshal(&RemoteSignRequest{
+		Digest: base64.URLEncoding.EncodeToString(digest),
+		Hash:   "SHA-256",
+	})
+	if err != nil {
+		return nil, err
+	}
+	rp, err := ctxhttp.Post(ctx, s.client, s.url+"/sign", remoteSignContentType,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	sig := &RemoteSignResponse{}
+	if err := decodeRemoteResponse(rp, sig); err != nil {
+		return nil, err
+	}
+	return base64.URLEncoding.DecodeString(sig.Signature)
+}
+
+// decodeRemoteResponse decodes the JSON body of a successful response into the value and closes
+// it.
+func decodeRemoteResponse(rp *http.Response, value interface{}) error {
+	defer func() { _ = rp.Body.Close() }()
+	if rp.StatusCode < 200 || rp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected signing service response status: %s", rp.Status)
+	}
+	return json.NewDecoder(rp.Body).Decode(value)
+}
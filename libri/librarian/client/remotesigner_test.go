@@ -0,0 +1,165 @@
+package client
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	mrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestNewRemoteSigner_ok(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	_, key := api.NewTestDocument(rng)
+	server := newTestSigningServer(peerID)
+	defer server.Close()
+
+	svc := NewHTTPSigningService(server.URL+"/", &http.Client{})
+	signer, err := NewRemoteSigner(svc, DefaultRemoteSignTimeout)
+	assert.Nil(t, err)
+
+	// check remote signatures verify like those of a private key signer
+	rq := NewFindRequest(peerID, key, 20)
+	encToken, err := signer.Sign(rq)
+	assert.Nil(t, err)
+	err = NewVerifier().Verify(encToken, &peerID.Key().PublicKey, rq)
+	assert.Nil(t, err)
+
+	// check remote key has the service's public key
+	remote, err := NewRemoteKey(svc, DefaultRemoteSignTimeout)
+	assert.Nil(t, err)
+	id, err := KeySignerID(remote)
+	assert.Nil(t, err)
+	assert.Equal(t, peerID.ID(), id.ID())
+}
+
+func TestNewRemoteSigner_err(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+
+	// check public key error bubbles up
+	svc := &fixedSigningService{pubErr: errors.New("some PublicKey error")}
+	signer, err := NewRemoteSigner(svc, DefaultRemoteSignTimeout)
+	assert.NotNil(t, err)
+	assert.Nil(t, signer)
+
+	// check malformed public key errors
+	svc = &fixedSigningService{pub: []byte{1, 2, 3}}
+	signer, err = NewRemoteSigner(svc, DefaultRemoteSignTimeout)
+	assert.NotNil(t, err)
+	assert.Nil(t, signer)
+
+	// check sign error bubbles up
+	svc = &fixedSigningService{
+		pub:     ecid.ToPublicKeyBytes(peerID),
+		signErr: errors.New("some SignDigest error"),
+	}
+	signer, err = NewRemoteSigner(svc, DefaultRemoteSignTimeout)
+	assert.Nil(t, err)
+	_, err = signer.Sign(NewFindRequest(peerID, peerID.ID(), 20))
+	assert.NotNil(t, err)
+}
+
+func TestRemoteKey_Sign(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	svc := &fixedSigningService{pub: ecid.ToPublicKeyBytes(peerID), sig: []byte{1, 2, 3}}
+	key, err := NewRemoteKey(svc, time.Second)
+	assert.Nil(t, err)
+
+	// check digest is signed with a deadline
+	digest := make([]byte, 32)
+	sig, err := key.Sign(rand.Reader, digest, crypto.SHA256)
+	assert.Nil(t, err)
+	assert.Equal(t, svc.sig, sig)
+	assert.Equal(t, digest, svc.digest)
+	assert.True(t, svc.hadDeadline)
+
+	// check other hash functions error
+	sig, err = key.Sign(rand.Reader, digest, crypto.SHA512)
+	assert.Equal(t, ErrUnsupportedRemoteSignHash, err)
+	assert.Nil(t, sig)
+}
+
+func TestHTTPSigningService_err(t *testing.T) {
+	errServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer errServer.Close()
+	badServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("not JSON"))
+		assert.Nil(t, err)
+	}))
+	defer badServer.Close()
+
+	for _, url := range []string{errServer.URL, badServer.URL, "http://127.0.0.1:1"} {
+		svc := NewHTTPSigningService(url, &http.Client{})
+		pub, err := svc.PublicKey(context.Background())
+		assert.NotNil(t, err, url)
+		assert.Nil(t, pub)
+		sig, err := svc.SignDigest(context.Background(), make([]byte, 32))
+		assert.NotNil(t, err, url)
+		assert.Nil(t, sig)
+	}
+}
+
+// newTestSigningServer returns an HTTP signing service server signing with the key.
+func newTestSigningServer(key ecid.ID) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/public_key", func(w http.ResponseWriter, r *http.Request) {
+		pub := base64.URLEncoding.EncodeToString(ecid.ToPublicKeyBytes(key))
+		_ = json.NewEncoder(w).Encode(&RemotePublicKey{PublicKey: pub})
+	})
+	mux.HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		rq := &RemoteSignRequest{}
+		if err := json.NewDecoder(r.Body).Decode(rq); err != nil || r.Method != "POST" ||
+			rq.Hash != "SHA-256" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		digest, err := base64.URLEncoding.DecodeString(rq.Digest)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sig, err := key.Key().Sign(rand.Reader, digest, crypto.SHA256)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&RemoteSignResponse{
+			Signature: base64.URLEncoding.EncodeToString(sig),
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+type fixedSigningService struct {
+	pub         []byte
+	pubErr      error
+	sig         []byte
+	signErr     error
+	digest      []byte
+	hadDeadline bool
+}
+
+func (f *fixedSigningService) PublicKey(ctx context.Context) ([]byte, error) {
+	return f.pub, f.pubErr
+}
+
+func (f *fixedSigningService) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	f.digest = digest
+	_, f.hadDeadline = ctx.Deadline()
+	return f.sig, f.signErr
+}
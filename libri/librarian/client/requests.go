@@ -40,6 +40,22 @@ func NewFindRequest(peerID ecid.ID, key cid.ID, nPeers uint) *api.FindRequest {
 	}
 }
 
+// NewFindPeersRequest creates a FindRequest object for only the closest peers to the key, even if
+// the value for the key is stored.
+func NewFindPeersRequest(peerID ecid.ID, key cid.ID, nPeers uint) *api.FindRequest {
+	rq := NewFindRequest(peerID, key, nPeers)
+	rq.PeersOnly = true
+	return rq
+}
+
+// NewFindPresenceRequest creates a FindRequest object for only whether the value for the key is
+// stored, which peers answer even for private values without an access proof.
+func NewFindPresenceRequest(peerID ecid.ID, key cid.ID, nPeers uint) *api.FindRequest {
+	rq := NewFindRequest(peerID, key, nPeers)
+	rq.PresenceOnly = true
+	return rq
+}
+
 // NewStoreRequest creates a StoreRequest object.
 func NewStoreRequest(peerID ecid.ID, key cid.ID, value *api.Document) *api.StoreRequest {
 	return &api.StoreRequest{
@@ -73,3 +89,97 @@ func NewSubscribeRequest(peerID ecid.ID, subscription *api.Subscription) *api.Su
 		Subscription: subscription,
 	}
 }
+
+// NewSubscribeAck creates a SubscribeRequest object acknowledging the total number of
+// publications received on a subscription.
+func NewSubscribeAck(nAcked uint64) *api.SubscribeRequest {
+	return &api.SubscribeRequest{
+		NAcked: nAcked,
+	}
+}
+
+// NewSubscriptionUpdate creates a SubscribeRequest object replacing the filters of an active
+// subscription.
+func NewSubscriptionUpdate(subscription *api.Subscription) *api.SubscribeRequest {
+	return &api.SubscribeRequest{
+		Subscription: subscription,
+	}
+}
+
+// NewReplaySubscribeRequest creates a SubscribeRequest object that first replays publications
+// logged from the given sequence number.
+func NewReplaySubscribeRequest(
+	peerID ecid.ID, subscription *api.Subscription, fromSequence uint64,
+) *api.SubscribeRequest {
+	rq := NewSubscribeRequest(peerID, subscription)
+	rq.FromSequence = fromSequence
+	return rq
+}
+
+// NewSubscriptionStatsRequest creates a SubscriptionStatsRequest object.
+func NewSubscriptionStatsRequest(peerID ecid.ID) *api.SubscriptionStatsRequest {
+	return &api.SubscriptionStatsRequest{
+		Metadata: NewRequestMetadata(peerID),
+	}
+}
+
+// NewRecentPublicationsRequest creates a RecentPublicationsRequest object.
+func NewRecentPublicationsRequest(peerID ecid.ID) *api.RecentPublicationsRequest {
+	return &api.RecentPublicationsRequest{
+		Metadata: NewRequestMetadata(peerID),
+	}
+}
+
+// NewResizeRecentPublicationsRequest creates a ResizeRecentPublicationsRequest object.
+func NewResizeRecentPublicationsRequest(
+	peerID ecid.ID, size uint32,
+) *api.ResizeRecentPublicationsRequest {
+	return &api.ResizeRecentPublicationsRequest{
+		Metadata: NewRequestMetadata(peerID),
+		Size:     size,
+	}
+}
+
+// NewScanPublicationsRequest creates a ScanPublicationsRequest object for the publication log from
+// the given sequence number with the given limit. Other scan conditions may be set on the returned
+// request.
+func NewScanPublicationsRequest(
+	peerID ecid.ID, fromSequence uint64, limit uint32,
+) *api.ScanPublicationsRequest {
+	return &api.ScanPublicationsRequest{
+		Metadata:     NewRequestMetadata(peerID),
+		FromSequence: fromSequence,
+		Limit:        limit,
+	}
+}
+
+// NewScanAuditLogRequest creates a ScanAuditLogRequest object for the audit log from the given
+// sequence number with the given limit. Other scan conditions may be set on the returned request.
+func NewScanAuditLogRequest(
+	peerID ecid.ID, fromSequence uint64, limit uint32,
+) *api.ScanAuditLogRequest {
+	return &api.ScanAuditLogRequest{
+		Metadata:     NewRequestMetadata(peerID),
+		FromSequence: fromSequence,
+		Limit:        limit,
+	}
+}
+
+// NewUpdateDenylistRequest creates an UpdateDenylistRequest object adding and removing the given
+// document keys from the denylist.
+func NewUpdateDenylistRequest(
+	peerID ecid.ID, addKeys []cid.ID, removeKeys []cid.ID,
+) *api.UpdateDenylistRequest {
+	rq := &api.UpdateDenylistRequest{
+		Metadata:   NewRequestMetadata(peerID),
+		AddKeys:    make([][]byte, len(addKeys)),
+		RemoveKeys: make([][]byte, len(removeKeys)),
+	}
+	for i, key := range addKeys {
+		rq.AddKeys[i] = key.Bytes()
+	}
+	for i, key := range removeKeys {
+		rq.RemoveKeys[i] = key.Bytes()
+	}
+	return rq
+}
@@ -39,6 +39,28 @@ func TestNewFindRequest(t *testing.T) {
 	assert.NotNil(t, rq.Metadata)
 	assert.Equal(t, key.Bytes(), rq.Key)
 	assert.Equal(t, uint32(nPeers), rq.NumPeers)
+	assert.False(t, rq.PeersOnly)
+}
+
+func TestNewFindPeersRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID, key, nPeers := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng), uint(8)
+	rq := NewFindPeersRequest(peerID, key, nPeers)
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, key.Bytes(), rq.Key)
+	assert.Equal(t, uint32(nPeers), rq.NumPeers)
+	assert.True(t, rq.PeersOnly)
+}
+
+func TestNewFindPresenceRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID, key, nPeers := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng), uint(8)
+	rq := NewFindPresenceRequest(peerID, key, nPeers)
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, key.Bytes(), rq.Key)
+	assert.Equal(t, uint32(nPeers), rq.NumPeers)
+	assert.True(t, rq.PresenceOnly)
+	assert.False(t, rq.PeersOnly)
 }
 
 func TestNewStoreRequest(t *testing.T) {
@@ -76,4 +98,79 @@ func TestNewSubscribeRequest(t *testing.T) {
 	rq := NewSubscribeRequest(peerID, sub)
 	assert.NotNil(t, rq.Metadata)
 	assert.Equal(t, sub, rq.Subscription)
+	assert.Zero(t, rq.FromSequence)
+}
+
+func TestNewSubscribeAck(t *testing.T) {
+	rq := NewSubscribeAck(16)
+	assert.Equal(t, uint64(16), rq.NAcked)
+	assert.Nil(t, rq.Subscription)
+}
+
+func TestNewSubscriptionUpdate(t *testing.T) {
+	sub := &api.Subscription{}
+	rq := NewSubscriptionUpdate(sub)
+	assert.Equal(t, sub, rq.Subscription)
+	assert.Nil(t, rq.Metadata)
+	assert.Zero(t, rq.NAcked)
+}
+
+func TestNewReplaySubscribeRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	sub := &api.Subscription{}
+	rq := NewReplaySubscribeRequest(peerID, sub, 3)
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, sub, rq.Subscription)
+	assert.Equal(t, uint64(3), rq.FromSequence)
+}
+
+func TestNewSubscriptionStatsRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	rq := NewSubscriptionStatsRequest(peerID)
+	assert.NotNil(t, rq.Metadata)
+}
+
+func TestNewRecentPublicationsRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	rq := NewRecentPublicationsRequest(peerID)
+	assert.NotNil(t, rq.Metadata)
+}
+
+func TestNewResizeRecentPublicationsRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	rq := NewResizeRecentPublicationsRequest(peerID, 64)
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, uint32(64), rq.Size)
+}
+
+func TestNewScanPublicationsRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	rq := NewScanPublicationsRequest(peerID, 2, 64)
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, uint64(2), rq.FromSequence)
+	assert.Equal(t, uint32(64), rq.Limit)
+}
+
+func TestNewScanAuditLogRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	rq := NewScanAuditLogRequest(peerID, 2, 64)
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, uint64(2), rq.FromSequence)
+	assert.Equal(t, uint32(64), rq.Limit)
+}
+
+func TestNewUpdateDenylistRequest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	add, remove := cid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+	rq := NewUpdateDenylistRequest(peerID, []cid.ID{add}, []cid.ID{remove})
+	assert.NotNil(t, rq.Metadata)
+	assert.Equal(t, [][]byte{add.Bytes()}, rq.AddKeys)
+	assert.Equal(t, [][]byte{remove.Bytes()}, rq.RemoveKeys)
 }
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	cbackoff "github.com/cenkalti/backoff"
+	"github.com/drausin/libri/libri/librarian/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the default maximum number of attempts for a request.
+	DefaultRetryMaxAttempts = 3
+
+	// DefaultRetryInitialBackoff is the default time to wait after the first failed attempt.
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the default maximum time to wait between attempts.
+	DefaultRetryMaxBackoff = 1 * time.Second
+
+	// DefaultRetryJitter is the default fraction by which each wait is randomly lengthened or
+	// shortened, so many clients failing at once don't all retry at once.
+	DefaultRetryJitter = 0.5
+)
+
+var (
+	// DefaultRetryCodes are the default status codes of errors to retry, which indicate the
+	// request might succeed if tried again.
+	DefaultRetryCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+
+	// ErrRetryMaxAttemptsZeroValue indicates when the MaxAttempts parameter has the zero value.
+	ErrRetryMaxAttemptsZeroValue = errors.New("MaxAttempts must be greater than zero")
+
+	// ErrRetryInitialBackoffZeroValue indicates when the InitialBackoff parameter has the zero
+	// value.
+	ErrRetryInitialBackoffZeroValue = errors.New("InitialBackoff must be greater than zero")
+
+	// ErrRetryMaxBackoffTooSmall indicates when the MaxBackoff parameter is smaller than the
+	// InitialBackoff.
+	ErrRetryMaxBackoffTooSmall = errors.New("MaxBackoff must be at least InitialBackoff")
+
+	// ErrRetryJitterOutOfRange indicates when the Jitter parameter is outside [0, 1).
+	ErrRetryJitterOutOfRange = errors.New("Jitter must be in [0, 1)")
+)
+
+// RetryPolicy defines how a request failing with a retryable status code is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for each request. One disables retries.
+	MaxAttempts uint32
+
+	// InitialBackoff is the time to wait after the first failed attempt, which grows
+	// exponentially with each subsequent failed attempt.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between attempts, before jitter.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction by which each wait is randomly lengthened or shortened.
+	Jitter float64
+
+	// Codes are the status codes of errors to retry. Errors with other codes are returned
+	// immediately.
+	Codes []codes.Code
+}
+
+// NewRetryPolicy validates the parameters and returns a new *RetryPolicy instance.
+func NewRetryPolicy(
+	maxAttempts uint32,
+	initialBackoff time.Duration,
+	maxBackoff time.Duration,
+	jitter float64,
+	retryCodes []codes.Code,
+) (*RetryPolicy, error) {
+	if maxAttempts == 0 {
+		return nil, ErrRetryMaxAttemptsZeroValue
+	}
+	if initialBackoff == 0 {
+		return nil, ErrRetryInitialBackoffZeroValue
+	}
+	if maxBackoff < initialBackoff {
+		return nil, ErrRetryMaxBackoffTooSmall
+	}
+	if jitter < 0 || jitter >= 1 {
+		return nil, ErrRetryJitterOutOfRange
+	}
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+		Jitter:         jitter,
+		Codes:          retryCodes,
+	}, nil
+}
+
+// NewDefaultRetryPolicy creates a default *RetryPolicy instance.
+func NewDefaultRetryPolicy() *RetryPolicy {
+	policy, err := NewRetryPolicy(DefaultRetryMaxAttempts, DefaultRetryInitialBackoff,
+		DefaultRetryMaxBackoff, DefaultRetryJitter, DefaultRetryCodes)
+	if err != nil {
+		// should never happen; if does, it's programmer error
+		panic(err)
+	}
+	return policy
+}
+
+// retryable returns whether the error has one of the policy's retryable status codes.
+func (p *RetryPolicy) retryable(err error) bool {
+	code := grpc.Code(err)
+	for _, c := range p.Codes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// retry calls the operation until it succeeds, returns a non-retryable error, has been attempted
+// MaxAttempts times, or the context is done, waiting with jittered exponential backoff between
+// attempts. It returns the last error.
+func (p *RetryPolicy) retry(ctx context.Context, operation func() error) error {
+	return cbackoff.Retry(func() error {
+		err := operation()
+		if err != nil && !p.retryable(err) {
+			return cbackoff.Permanent(err)
+		}
+		return err
+	}, p.newBackOff(ctx))
+}
+
+func (p *RetryPolicy) newBackOff(ctx context.Context) cbackoff.BackOff {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.MaxAttempts <= 1 {
+		return cbackoff.WithContext(&cbackoff.StopBackOff{}, ctx)
+	}
+	backoff := cbackoff.NewExponentialBackOff()
+	backoff.InitialInterval = p.InitialBackoff
+	backoff.MaxInterval = p.MaxBackoff
+	backoff.RandomizationFactor = p.Jitter
+	backoff.MaxElapsedTime = 0 // only stop after MaxAttempts
+	return cbackoff.WithContext(cbackoff.WithMaxTries(backoff, uint64(p.MaxAttempts-1)), ctx)
+}
+
+// NewRetryUnaryInterceptor returns a unary client interceptor that retries requests according to
+// the RetryPolicy.
+func NewRetryUnaryInterceptor(policy *RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return policy.retry(ctx, func() error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+	}
+}
+
+type retryIntroduceQuerier struct {
+	inner  IntroduceQuerier
+	policy *RetryPolicy
+}
+
+// NewRetryIntroduceQuerier creates a new IntroduceQuerier that retries the inner IntroduceQuerier
+// according to the RetryPolicy.
+func NewRetryIntroduceQuerier(inner IntroduceQuerier, policy *RetryPolicy) IntroduceQuerier {
+	return &retryIntroduceQuerier{inner: inner, policy: policy}
+}
+
+func (q *retryIntroduceQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.IntroduceRequest, opts ...grpc.CallOption,
+) (*api.IntroduceResponse, error) {
+	var rp *api.IntroduceResponse
+	err := q.policy.retry(ctx, func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+type retryFindQuerier struct {
+	inner  FindQuerier
+	policy *RetryPolicy
+}
+
+// NewRetryFindQuerier creates a new FindQuerier that retries the inner FindQuerier according to
+// the RetryPolicy.
+func NewRetryFindQuerier(inner FindQuerier, policy *RetryPolicy) FindQuerier {
+	return &retryFindQuerier{inner: inner, policy: policy}
+}
+
+func (q *retryFindQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.FindRequest, opts ...grpc.CallOption,
+) (*api.FindResponse, error) {
+	var rp *api.FindResponse
+	err := q.policy.retry(ctx, func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+type retryStoreQuerier struct {
+	inner  StoreQuerier
+	policy *RetryPolicy
+}
+
+// NewRetryStoreQuerier creates a new StoreQuerier that retries the inner StoreQuerier according
+// to the RetryPolicy.
+func NewRetryStoreQuerier(inner StoreQuerier, policy *RetryPolicy) StoreQuerier {
+	return &retryStoreQuerier{inner: inner, policy: policy}
+}
+
+func (q *retryStoreQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.StoreRequest, opts ...grpc.CallOption,
+) (*api.StoreResponse, error) {
+	var rp *api.StoreResponse
+	err := q.policy.retry(ctx, func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+type retryGetQuerier struct {
+	inner  GetQuerier
+	policy *RetryPolicy
+}
+
+// NewRetryGetQuerier creates a new GetQuerier that retries the inner GetQuerier according to the
+// RetryPolicy.
+func NewRetryGetQuerier(inner GetQuerier, policy *RetryPolicy) GetQuerier {
+	return &retryGetQuerier{inner: inner, policy: policy}
+}
+
+func (q *retryGetQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.GetRequest, opts ...grpc.CallOption,
+) (*api.GetResponse, error) {
+	var rp *api.GetResponse
+	err := q.policy.retry(ctx, func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+type retryPutQuerier struct {
+	inner  PutQuerier
+	policy *RetryPolicy
+}
+
+// NewRetryPutQuerier creates a new PutQuerier that retries the inner PutQuerier according to the
+// RetryPolicy.
+func NewRetryPutQuerier(inner PutQuerier, policy *RetryPolicy) PutQuerier {
+	return &retryPutQuerier{inner: inner, policy: policy}
+}
+
+func (q *retryPutQuerier) Query(
+	ctx context.Context, pConn api.Connector, rq *api.PutRequest, opts ...grpc.CallOption,
+) (*api.PutResponse, error) {
+	var rp *api.PutResponse
+	err := q.policy.retry(ctx, func() error {
+		var err error
+		rp, err = q.inner.Query(ctx, pConn, rq, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
@@ -0,0 +1,265 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errUnavailable = status.Error(codes.Unavailable, "unavailable")
+
+func TestNewRetryPolicy_ok(t *testing.T) {
+	policy, err := NewRetryPolicy(DefaultRetryMaxAttempts, DefaultRetryInitialBackoff,
+		DefaultRetryMaxBackoff, DefaultRetryJitter, DefaultRetryCodes)
+	assert.Nil(t, err)
+	assert.Equal(t, NewDefaultRetryPolicy(), policy)
+}
+
+func TestNewRetryPolicy_err(t *testing.T) {
+	policy, err := NewRetryPolicy(0, DefaultRetryInitialBackoff, DefaultRetryMaxBackoff,
+		DefaultRetryJitter, DefaultRetryCodes)
+	assert.Equal(t, ErrRetryMaxAttemptsZeroValue, err)
+	assert.Nil(t, policy)
+
+	policy, err = NewRetryPolicy(DefaultRetryMaxAttempts, 0, DefaultRetryMaxBackoff,
+		DefaultRetryJitter, DefaultRetryCodes)
+	assert.Equal(t, ErrRetryInitialBackoffZeroValue, err)
+	assert.Nil(t, policy)
+
+	policy, err = NewRetryPolicy(DefaultRetryMaxAttempts, time.Second, time.Millisecond,
+		DefaultRetryJitter, DefaultRetryCodes)
+	assert.Equal(t, ErrRetryMaxBackoffTooSmall, err)
+	assert.Nil(t, policy)
+
+	for _, jitter := range []float64{-0.1, 1.0} {
+		policy, err = NewRetryPolicy(DefaultRetryMaxAttempts, DefaultRetryInitialBackoff,
+			DefaultRetryMaxBackoff, jitter, DefaultRetryCodes)
+		assert.Equal(t, ErrRetryJitterOutOfRange, err)
+		assert.Nil(t, policy)
+	}
+}
+
+func TestRetryPolicy_retry(t *testing.T) {
+	policy := newTestRetryPolicy(3)
+	invalidErr := status.Error(codes.InvalidArgument, "invalid")
+	cases := []struct {
+		nFailures        int
+		failErr          error
+		expectedAttempts int
+		expectErr        bool
+	}{
+		{nFailures: 0, failErr: errUnavailable, expectedAttempts: 1},
+		{nFailures: 2, failErr: errUnavailable, expectedAttempts: 3},
+		{nFailures: 3, failErr: errUnavailable, expectedAttempts: 3, expectErr: true},
+		{nFailures: 1, failErr: invalidErr, expectedAttempts: 1, expectErr: true},
+		{nFailures: 1, failErr: errors.New("some error"), expectedAttempts: 1, expectErr: true},
+	}
+	for i, c := range cases {
+		attempts := 0
+		err := policy.retry(context.Background(), func() error {
+			attempts++
+			if attempts <= c.nFailures {
+				return c.failErr
+			}
+			return nil
+		})
+		if c.expectErr {
+			assert.Equal(t, c.failErr, err, "case %d", i)
+		} else {
+			assert.Nil(t, err, "case %d", i)
+		}
+		assert.Equal(t, c.expectedAttempts, attempts, "case %d", i)
+	}
+
+	// check no retries with single max attempt
+	attempts := 0
+	err := newTestRetryPolicy(1).retry(nil, func() error {
+		attempts++
+		return errUnavailable
+	})
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, 1, attempts)
+
+	// check no retries after context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	err = policy.retry(ctx, func() error {
+		attempts++
+		return errUnavailable
+	})
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestNewRetryUnaryInterceptor(t *testing.T) {
+	interceptor := NewRetryUnaryInterceptor(newTestRetryPolicy(3))
+	attempts := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		assert.Equal(t, "/api.Librarian/Get", method)
+		if attempts < 2 {
+			return errUnavailable
+		}
+		return nil
+	}
+	err := interceptor(context.Background(), "/api.Librarian/Get", nil, nil, nil, invoker)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryQueriers_ok(t *testing.T) {
+	policy := newTestRetryPolicy(3)
+
+	intro := &fixedIntroduceQuerier{nFailures: 2, rp: &api.IntroduceResponse{}}
+	introRp, err := NewRetryIntroduceQuerier(intro, policy).Query(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, intro.rp, introRp)
+	assert.Equal(t, 3, intro.nCalls)
+
+	find := &fixedFindQuerier{nFailures: 2, rp: &api.FindResponse{}}
+	findRp, err := NewRetryFindQuerier(find, policy).Query(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, find.rp, findRp)
+	assert.Equal(t, 3, find.nCalls)
+
+	store := &fixedStoreQuerier{nFailures: 2, rp: &api.StoreResponse{}}
+	storeRp, err := NewRetryStoreQuerier(store, policy).Query(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, store.rp, storeRp)
+	assert.Equal(t, 3, store.nCalls)
+
+	get := &fixedGetQuerier{nFailures: 2, rp: &api.GetResponse{}}
+	getRp, err := NewRetryGetQuerier(get, policy).Query(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, get.rp, getRp)
+	assert.Equal(t, 3, get.nCalls)
+
+	put := &fixedPutQuerier{nFailures: 2, rp: &api.PutResponse{}}
+	putRp, err := NewRetryPutQuerier(put, policy).Query(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, put.rp, putRp)
+	assert.Equal(t, 3, put.nCalls)
+}
+
+func TestRetryQueriers_err(t *testing.T) {
+	policy := newTestRetryPolicy(3)
+
+	intro := &fixedIntroduceQuerier{nFailures: 3, rp: &api.IntroduceResponse{}}
+	introRp, err := NewRetryIntroduceQuerier(intro, policy).Query(nil, nil, nil)
+	assert.Equal(t, errUnavailable, err)
+	assert.Nil(t, introRp)
+
+	find := &fixedFindQuerier{nFailures: 3, rp: &api.FindResponse{}}
+	findRp, err := NewRetryFindQuerier(find, policy).Query(nil, nil, nil)
+	assert.Equal(t, errUnavailable, err)
+	assert.Nil(t, findRp)
+
+	store := &fixedStoreQuerier{nFailures: 3, rp: &api.StoreResponse{}}
+	storeRp, err := NewRetryStoreQuerier(store, policy).Query(nil, nil, nil)
+	assert.Equal(t, errUnavailable, err)
+	assert.Nil(t, storeRp)
+
+	get := &fixedGetQuerier{nFailures: 3, rp: &api.GetResponse{}}
+	getRp, err := NewRetryGetQuerier(get, policy).Query(nil, nil, nil)
+	assert.Equal(t, errUnavailable, err)
+	assert.Nil(t, getRp)
+
+	put := &fixedPutQuerier{nFailures: 3, rp: &api.PutResponse{}}
+	putRp, err := NewRetryPutQuerier(put, policy).Query(nil, nil, nil)
+	assert.Equal(t, errUnavailable, err)
+	assert.Nil(t, putRp)
+}
+
+func newTestRetryPolicy(maxAttempts uint32) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		Jitter:         DefaultRetryJitter,
+		Codes:          DefaultRetryCodes,
+	}
+}
+
+type fixedIntroduceQuerier struct {
+	nFailures int
+	nCalls    int
+	rp        *api.IntroduceResponse
+}
+
+func (q *fixedIntroduceQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.IntroduceRequest, opts ...grpc.CallOption) (*api.IntroduceResponse, error) {
+	q.nCalls++
+	if q.nCalls <= q.nFailures {
+		return nil, errUnavailable
+	}
+	return q.rp, nil
+}
+
+type fixedFindQuerier struct {
+	nFailures int
+	nCalls    int
+	rp        *api.FindResponse
+}
+
+func (q *fixedFindQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.FindRequest,
+	opts ...grpc.CallOption) (*api.FindResponse, error) {
+	q.nCalls++
+	if q.nCalls <= q.nFailures {
+		return nil, errUnavailable
+	}
+	return q.rp, nil
+}
+
+type fixedStoreQuerier struct {
+	nFailures int
+	nCalls    int
+	rp        *api.StoreResponse
+}
+
+func (q *fixedStoreQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.StoreRequest,
+	opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	q.nCalls++
+	if q.nCalls <= q.nFailures {
+		return nil, errUnavailable
+	}
+	return q.rp, nil
+}
+
+type fixedGetQuerier struct {
+	nFailures int
+	nCalls    int
+	rp        *api.GetResponse
+}
+
+func (q *fixedGetQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.GetRequest,
+	opts ...grpc.CallOption) (*api.GetResponse, error) {
+	q.nCalls++
+	if q.nCalls <= q.nFailures {
+		return nil, errUnavailable
+	}
+	return q.rp, nil
+}
+
+type fixedPutQuerier struct {
+	nFailures int
+	nCalls    int
+	rp        *api.PutResponse
+}
+
+func (q *fixedPutQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.PutRequest,
+	opts ...grpc.CallOption) (*api.PutResponse, error) {
+	q.nCalls++
+	if q.nCalls <= q.nFailures {
+		return nil, errUnavailable
+	}
+	return q.rp, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+)
+
+// ErrSameRotationKeys indicates when a key rotation has the same old and new public keys.
+var ErrSameRotationKeys = errors.New("key rotation has same old and new public keys")
+
+// rotationVerifyParams don't expire rotation signatures, since a librarian introduces itself with
+// the same rotation across restarts.
+var rotationVerifyParams = &VerifyParameters{ClockSkew: DefaultClockSkew}
+
+// NewKeyRotation creates a new KeyRotation from the old peer ID to the new one, signed by the old
+// peer ID's private key.
+func NewKeyRotation(oldID, newID ecid.ID) (*api.KeyRotation, error) {
+	rotation := &api.KeyRotation{
+		OldPubKey: ecid.ToPublicKeyBytes(oldID),
+		NewPubKey: ecid.ToPublicKeyBytes(newID),
+	}
+	signature, err := NewIDSigner(oldID).Sign(rotation)
+	if err != nil {
+		return nil, err
+	}
+	rotation.Signature = signature
+	return rotation, nil
+}
+
+// VerifyKeyRotation verifies that the rotation links two different valid public keys and was
+// signed by the old one. It returns nil if the rotation is verified.
+func VerifyKeyRotation(rotation *api.KeyRotation) error {
+	if rotation == nil {
+		return api.ErrUnexpectedNilValue
+	}
+	oldID, err := ecid.FromAnyPublicKeyBytes(rotation.OldPubKey)
+	if err != nil {
+		return err
+	}
+	if _, err := ecid.FromAnyPublicKeyBytes(rotation.NewPubKey); err != nil {
+		return err
+	}
+	if bytes.Equal(rotation.OldPubKey, rotation.NewPubKey) {
+		return ErrSameRotationKeys
+	}
+	unsigned := &api.KeyRotation{
+		OldPubKey: rotation.OldPubKey,
+		NewPubKey: rotation.NewPubKey,
+	}
+	return NewVerifierWithParameters(rotationVerifyParams).Verify(rotation.Signature,
+		oldID.PublicKey(), unsigned)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeyRotation_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	oldID, newID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+
+	rotation, err := NewKeyRotation(oldID, newID)
+	assert.Nil(t, err)
+	assert.Equal(t, ecid.ToPublicKeyBytes(oldID), rotation.OldPubKey)
+	assert.Equal(t, ecid.ToPublicKeyBytes(newID), rotation.NewPubKey)
+	assert.NotEmpty(t, rotation.Signature)
+	assert.Nil(t, VerifyKeyRotation(rotation))
+}
+
+func TestVerifyKeyRotation_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	oldID, newID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng),
+		ecid.NewPseudoRandom(rng)
+	newRotation := func() *api.KeyRotation {
+		rotation, err := NewKeyRotation(oldID, newID)
+		assert.Nil(t, err)
+		return rotation
+	}
+
+	// check nil rotation
+	assert.Equal(t, api.ErrUnexpectedNilValue, VerifyKeyRotation(nil))
+
+	// check bad public keys
+	rotation := newRotation()
+	rotation.OldPubKey = []byte("bad old pub key")
+	assert.NotNil(t, VerifyKeyRotation(rotation))
+	rotation = newRotation()
+	rotation.NewPubKey = []byte("bad new pub key")
+	assert.NotNil(t, VerifyKeyRotation(rotation))
+
+	// check rotation to same key
+	rotation, err := NewKeyRotation(oldID, oldID)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrSameRotationKeys, VerifyKeyRotation(rotation))
+
+	// check changed new key doesn't match signature
+	rotation = newRotation()
+	rotation.NewPubKey = ecid.ToPublicKeyBytes(otherID)
+	assert.NotNil(t, VerifyKeyRotation(rotation))
+
+	// check signature by another key
+	rotation = newRotation()
+	other, err := NewKeyRotation(otherID, newID)
+	assert.Nil(t, err)
+	rotation.Signature = other.Signature
+	assert.NotNil(t, VerifyKeyRotation(rotation))
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	// ErrSessionsNotAllowed indicates when a signature was made by a session key but the
+	// verifier only accepts signatures by identity keys.
+	ErrSessionsNotAllowed = errors.New("session key signatures not allowed")
+
+	// ErrUnexpectedSessionIdentity indicates when a session certificate is for a different
+	// identity key than the one expected to have signed the message.
+	ErrUnexpectedSessionIdentity = errors.New("session certificate has unexpected identity key")
+
+	// ErrSessionExpired indicates when a session certificate is past its expiration.
+	ErrSessionExpired = errors.New("session certificate expired")
+
+	// ErrSessionKeyMismatch indicates when a session key doesn't match the public key in its
+	// certificate.
+	ErrSessionKeyMismatch = errors.New("session key does not match certificate")
+)
+
+// sessionVerifyParams don't expire certificate signatures, since certificates have their own
+// expiration, and don't allow sessions, so session keys can't certify other session keys.
+var sessionVerifyParams = &VerifyParameters{ClockSkew: DefaultClockSkew}
+
+// NewSessionCertificate creates a new SessionCertificate linking the session ID to the identity
+// ID for the given lifetime, signed by the identity ID's private key.
+func NewSessionCertificate(identityID, sessionID ecid.ID, lifetime time.Duration) (
+	*api.SessionCertificate, error) {
+	cert := &api.SessionCertificate{
+		IdentityPubKey: ecid.ToPublicKeyBytes(identityID),
+		SessionPubKey:  ecid.ToPublicKeyBytes(sessionID),
+		Expiration:     time.Now().Add(lifetime).Unix(),
+	}
+	signature, err := NewIDSigner(identityID).Sign(cert)
+	if err != nil {
+		return nil, err
+	}
+	cert.Signature = signature
+	return cert, nil
+}
+
+// VerifySessionCertificate verifies that the certificate is for the identity public key (on
+// either curve), hasn't expired, and was signed by the identity key. It returns nil if the
+// certificate is verified.
+func VerifySessionCertificate(
+	cert *api.SessionCertificate, identityPubKey crypto.PublicKey,
+) error {
+	return verifySessionCertificate(cert, identityPubKey, time.Now())
+}
+
+func verifySessionCertificate(
+	cert *api.SessionCertificate, identityPubKey crypto.PublicKey, now time.Time,
+) error {
+	if cert == nil {
+		return api.ErrUnexpectedNilValue
+	}
+	identityID, err := ecid.FromAnyPublicKey(identityPubKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(ecid.ToPublicKeyBytes(identityID), cert.IdentityPubKey) {
+		return ErrUnexpectedSessionIdentity
+	}
+	if now.After(time.Unix(cert.Expiration, 0).Add(DefaultClockSkew)) {
+		return ErrSessionExpired
+	}
+	if _, err := ecid.FromAnyPublicKeyBytes(cert.SessionPubKey); err != nil {
+		return err
+	}
+	unsigned := &api.SessionCertificate{
+		IdentityPubKey: cert.IdentityPubKey,
+		SessionPubKey:  cert.SessionPubKey,
+		Expiration:     cert.Expiration,
+	}
+	return NewVerifierWithParameters(sessionVerifyParams).Verify(cert.Signature, identityPubKey,
+		unsigned)
+}
+
+type sessionSigner struct {
+	key         crypto.Signer
+	encodedCert string
+}
+
+// NewSessionSigner returns a new Signer instance that signs with the session key and includes
+// its certificate in each signature, so verifiers allowing sessions attribute the signatures to
+// the certificate's identity key.
+func NewSessionSigner(sessionID ecid.ID, cert *api.SessionCertificate) (Signer, error) {
+	if cert == nil {
+		return nil, api.ErrUnexpectedNilValue
+	}
+	if !bytes.Equal(ecid.ToPublicKeyBytes(sessionID), cert.SessionPubKey) {
+		return nil, ErrSessionKeyMismatch
+	}
+	certBytes, err := proto.Marshal(cert)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionSigner{
+		key:         sessionID.Signer(),
+		encodedCert: base64.URLEncoding.EncodeToString(certBytes),
+	}, nil
+}
+
+func (s *sessionSigner) Sign(m proto.Message) (string, error) {
+	hash, err := hashMessage(m)
+	if err != nil {
+		return "", err
+	}
+	claims := NewSignatureClaims(hash)
+	claims.Session = s.encodedCert
+	return signClaims(claims, s.key)
+}
+
+// sessionPubKey returns the session public key from the encoded certificate after verifying it
+// links the session key to the identity public key.
+func sessionPubKey(encodedCert string, identityPubKey crypto.PublicKey, now time.Time) (
+	crypto.PublicKey, error) {
+	certBytes, err := base64.URLEncoding.DecodeString(encodedCert)
+	if err != nil {
+		return nil, err
+	}
+	cert := &api.SessionCertificate{}
+	if err := proto.Unmarshal(certBytes, cert); err != nil {
+		return nil, err
+	}
+	if err := verifySessionCertificate(cert, identityPubKey, now); err != nil {
+		return nil, err
+	}
+	sessionID, err := ecid.FromAnyPublicKeyBytes(cert.SessionPubKey)
+	if err != nil {
+		return nil, err
+	}
+	return sessionID.PublicKey(), nil
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSessionCertificate_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+
+	cert, err := NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, ecid.ToPublicKeyBytes(identityID), cert.IdentityPubKey)
+	assert.Equal(t, ecid.ToPublicKeyBytes(sessionID), cert.SessionPubKey)
+	assert.True(t, cert.Expiration > time.Now().Unix())
+	assert.NotEmpty(t, cert.Signature)
+
+	assert.Nil(t, VerifySessionCertificate(cert, &identityID.Key().PublicKey))
+}
+
+func TestVerifySessionCertificate_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	otherID := ecid.NewPseudoRandom(rng)
+	identityPubKey := &identityID.Key().PublicKey
+	newCert := func() *api.SessionCertificate {
+		cert, err := NewSessionCertificate(identityID, sessionID, time.Hour)
+		assert.Nil(t, err)
+		return cert
+	}
+
+	// check missing certificate
+	assert.Equal(t, api.ErrUnexpectedNilValue, VerifySessionCertificate(nil, identityPubKey))
+
+	// check certificate for another identity
+	cert := newCert()
+	err := VerifySessionCertificate(cert, &otherID.Key().PublicKey)
+	assert.Equal(t, ErrUnexpectedSessionIdentity, err)
+
+	// check expired certificate
+	cert, err = NewSessionCertificate(identityID, sessionID, -time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrSessionExpired, VerifySessionCertificate(cert, identityPubKey))
+
+	// check bad session public key
+	cert = newCert()
+	cert.SessionPubKey = []byte("bad session pub key")
+	assert.NotNil(t, VerifySessionCertificate(cert, identityPubKey))
+
+	// check extended expiration invalidates signature
+	cert = newCert()
+	cert.Expiration += 3600
+	assert.NotNil(t, VerifySessionCertificate(cert, identityPubKey))
+
+	// check certificate signed by its session key
+	cert = newCert()
+	cert.Signature, err = NewSigner(sessionID.Key()).Sign(&api.SessionCertificate{
+		IdentityPubKey: cert.IdentityPubKey,
+		SessionPubKey:  cert.SessionPubKey,
+		Expiration:     cert.Expiration,
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifySessionCertificate(cert, identityPubKey))
+}
+
+func TestNewSessionSigner_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+
+	// check missing certificate
+	signer, err := NewSessionSigner(sessionID, nil)
+	assert.Equal(t, api.ErrUnexpectedNilValue, err)
+	assert.Nil(t, signer)
+
+	// check session key not in certificate
+	signer, err = NewSessionSigner(identityID, cert)
+	assert.Equal(t, ErrSessionKeyMismatch, err)
+	assert.Nil(t, signer)
+}
+
+func TestSessionSignerVerifier_SignVerify_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	signer, err := NewSessionSigner(sessionID, cert)
+	assert.Nil(t, err)
+	m := NewFindRequest(ecid.FromPublicKey(&identityID.Key().PublicKey), ecid.NewPseudoRandom(rng),
+		20)
+
+	encToken, err := signer.Sign(m)
+	assert.Nil(t, err)
+	assert.Nil(t, NewVerifier().Verify(encToken, &identityID.Key().PublicKey, m))
+}
+
+func TestSessionSignerVerifier_SignVerify_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	otherID := ecid.NewPseudoRandom(rng)
+	cert, err := NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	signer, err := NewSessionSigner(sessionID, cert)
+	assert.Nil(t, err)
+	m := NewFindRequest(identityID, ecid.NewPseudoRandom(rng), 20)
+	encToken, err := signer.Sign(m)
+	assert.Nil(t, err)
+
+	// check session signature attributed to another identity
+	assert.NotNil(t, NewVerifier().Verify(encToken, &otherID.Key().PublicKey, m))
+
+	// check verifier not allowing sessions
+	params := NewDefaultVerifyParameters()
+	params.AllowSessions = false
+	err = NewVerifierWithParameters(params).Verify(encToken, &identityID.Key().PublicKey, m)
+	assert.NotNil(t, err)
+
+	// check session signature expired since signing
+	v := NewVerifier().(*ecsdaVerifier)
+	v.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	v.params.MaxAge = 0
+	assert.NotNil(t, v.Verify(encToken, &identityID.Key().PublicKey, m))
+}
@@ -2,16 +2,47 @@ package client
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/golang/protobuf/proto"
 )
 
+const (
+	// DefaultClockSkew is the default amount a signature's issued time may be ahead of the
+	// verifier's clock, or its age may exceed the max age.
+	DefaultClockSkew = 1 * time.Minute
+
+	// DefaultMaxSignatureAge is the default maximum age of a verified signature.
+	DefaultMaxSignatureAge = 10 * time.Minute
+
+	// DefaultRequireIssuedAt is the default for whether signatures must have an issued time.
+	DefaultRequireIssuedAt = false
+
+	// DefaultAllowSessions is the default for whether signatures by certified session keys are
+	// accepted as signatures by their identity keys.
+	DefaultAllowSessions = true
+)
+
+var (
+	// ErrMissingIssuedAt indicates when a signature is missing a required issued time.
+	ErrMissingIssuedAt = errors.New("signature missing required issued at claim")
+
+	// ErrSignatureIssuedInFuture indicates when a signature's issued time is further ahead of
+	// the verifier's clock than the allowed clock skew.
+	ErrSignatureIssuedInFuture = errors.New("signature issued in the future")
+
+	// ErrSignatureExpired indicates when a signature is older than the max age.
+	ErrSignatureExpired = errors.New("signature expired")
+)
+
 // regex pattern for a base-64 url-encoded string for a 256-bit number
 var b64url256bit *regexp.Regexp
 
@@ -28,6 +59,12 @@ func init() {
 type Claims struct {
 	// base-64-url encoded string of the hash of the message being signed
 	Hash string `json:"hash"`
+
+	// Unix time when the message was signed
+	IssuedAt int64 `json:"iat,omitempty"`
+
+	// base-64-url encoded session certificate, if the message was signed by a session key
+	Session string `json:"ses,omitempty"`
 }
 
 // Valid returns whether the claim is valid or invalid via an error.
@@ -40,10 +77,12 @@ func (c *Claims) Valid() error {
 	return nil
 }
 
-// NewSignatureClaims creates a new SignatureClaims instance with the given message hash.
+// NewSignatureClaims creates a new SignatureClaims instance with the given message hash, issued
+// now.
 func NewSignatureClaims(hash [sha256.Size]byte) *Claims {
 	return &Claims{
-		Hash: base64.URLEncoding.EncodeToString(hash[:]),
+		Hash:     base64.URLEncoding.EncodeToString(hash[:]),
+		IssuedAt: time.Now().Unix(),
 	}
 }
 
@@ -68,31 +107,82 @@ func (s *ecdsaSigner) Sign(m proto.Message) (string, error) {
 		return "", err
 	}
 
+	return signClaims(NewSignatureClaims(hash), s.key)
+}
+
+func signClaims(claims *Claims, key crypto.Signer) (string, error) {
 	// create token
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, NewSignatureClaims(hash))
+	token := jwt.NewWithClaims(signingMethod(key), claims)
 
 	// sign with key, yield encoded token string like XXXXXX.YYYYYY.ZZZZZZ
-	return token.SignedString(s.key)
+	return token.SignedString(key)
 }
 
 // Verifier verifies the signature on a message.
 type Verifier interface {
-	// Verify verifies that the encoded token is well formed and has been signed by the peer.
-	Verify(encToken string, fromPubKey *ecdsa.PublicKey, m proto.Message) error
+	// Verify verifies that the encoded token is well formed and has been signed by the peer,
+	// whose public key is either an *ecdsa.PublicKey or an ed25519.PublicKey.
+	Verify(encToken string, fromPubKey crypto.PublicKey, m proto.Message) error
 }
 
-type ecsdaVerifier struct{}
+// VerifyParameters define how strictly a Verifier checks signature times.
+type VerifyParameters struct {
+	// ClockSkew is the amount a signature's issued time may be ahead of the verifier's clock, or
+	// its age may exceed MaxAge, to allow for imperfect time sync between peers.
+	ClockSkew time.Duration
+
+	// MaxAge is the maximum age of a verified signature; zero value disables expiry.
+	MaxAge time.Duration
 
-// NewVerifier creates a new Verifier instance.
+	// RequireIssuedAt is whether signatures without an issued time are rejected rather than
+	// skipping their time checks.
+	RequireIssuedAt bool
+
+	// AllowSessions is whether signatures by session keys with valid certificates from the
+	// expected identity key are accepted.
+	AllowSessions bool
+}
+
+// NewDefaultVerifyParameters returns a *VerifyParameters object with default values.
+func NewDefaultVerifyParameters() *VerifyParameters {
+	return &VerifyParameters{
+		ClockSkew:       DefaultClockSkew,
+		MaxAge:          DefaultMaxSignatureAge,
+		RequireIssuedAt: DefaultRequireIssuedAt,
+		AllowSessions:   DefaultAllowSessions,
+	}
+}
+
+type ecsdaVerifier struct {
+	params *VerifyParameters
+	now    func() time.Time
+}
+
+// NewVerifier creates a new Verifier instance with default parameters.
 func NewVerifier() Verifier {
-	return &ecsdaVerifier{}
+	return NewVerifierWithParameters(NewDefaultVerifyParameters())
 }
 
-func (v *ecsdaVerifier) Verify(encToken string, fromPubKey *ecdsa.PublicKey,
+// NewVerifierWithParameters creates a new Verifier instance with the given parameters.
+func NewVerifierWithParameters(params *VerifyParameters) Verifier {
+	return &ecsdaVerifier{
+		params: params,
+		now:    time.Now,
+	}
+}
+
+func (v *ecsdaVerifier) Verify(encToken string, fromPubKey crypto.PublicKey,
 	m proto.Message) error {
 	token, err := jwt.ParseWithClaims(encToken, &Claims{}, func(token *jwt.Token) (
 		interface{}, error) {
-		return fromPubKey, nil
+		claims, ok := token.Claims.(*Claims)
+		if !ok || claims.Session == "" {
+			return fromPubKey, nil
+		}
+		if !v.params.AllowSessions {
+			return nil, ErrSessionsNotAllowed
+		}
+		return sessionPubKey(claims.Session, fromPubKey, v.now())
 	})
 	if err != nil {
 		// received error when parsing claims or verifying signature
@@ -103,10 +193,34 @@ func (v *ecsdaVerifier) Verify(encToken string, fromPubKey *ecdsa.PublicKey,
 	if !ok {
 		return fmt.Errorf("token claims %v are not expected SignatureClaims", token.Claims)
 	}
+	if err := v.verifyIssuedAt(claims.IssuedAt); err != nil {
+		return err
+	}
 
 	return verifyMessageHash(m, claims.Hash)
 }
 
+func (v *ecsdaVerifier) verifyIssuedAt(issuedAt int64) error {
+	return verifyIssuedAt(v.params, v.now(), issuedAt)
+}
+
+func verifyIssuedAt(params *VerifyParameters, now time.Time, issuedAt int64) error {
+	if issuedAt == 0 {
+		if params.RequireIssuedAt {
+			return ErrMissingIssuedAt
+		}
+		return nil
+	}
+	age := now.Sub(time.Unix(issuedAt, 0))
+	if age < -params.ClockSkew {
+		return ErrSignatureIssuedInFuture
+	}
+	if params.MaxAge > 0 && age > params.MaxAge+params.ClockSkew {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
 func verifyMessageHash(m proto.Message, encClaimedHash string) error {
 	messageHash, err := hashMessage(m)
 	if err != nil {
@@ -3,6 +3,7 @@ package client
 import (
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
 	cid "github.com/drausin/libri/libri/common/id"
@@ -14,10 +15,10 @@ import (
 func TestSignatureClaims_Valid_ok(t *testing.T) {
 	// all of these should be considered valid hashes
 	cases := []*Claims{
-		{"n4bQgYhMfWWaL-qgxVrQFaO_TxsrC4Is0V1sFbDwCgg="},
-		{"9nITsSKl1ELSuTvajMRcVkpw7F0qTg6Vu1hc8ZmGnJg="},
-		{"-MAqRWZ-E5DpcCh23U3GwAZuSbXNqm7ByD59iL6S4uI="},
-		{"47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU="},
+		{Hash: "n4bQgYhMfWWaL-qgxVrQFaO_TxsrC4Is0V1sFbDwCgg="},
+		{Hash: "9nITsSKl1ELSuTvajMRcVkpw7F0qTg6Vu1hc8ZmGnJg="},
+		{Hash: "-MAqRWZ-E5DpcCh23U3GwAZuSbXNqm7ByD59iL6S4uI="},
+		{Hash: "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU="},
 	}
 	for _, c := range cases {
 		assert.Nil(t, c.Valid())
@@ -27,12 +28,12 @@ func TestSignatureClaims_Valid_ok(t *testing.T) {
 func TestSignatureClaims_Valid_err(t *testing.T) {
 	// none of these is valid
 	cases := []*Claims{
-		{"n4bQgYhMfWWaL-qgxVrQFaO_TxsrC4Is0V1sFbDwCgga"},       // missing last =
-		{"n4bQgYhMfWWaL+qgxVrQFaO_TxsrC4Is0V1sFbDwCgga"},       // + part of non-url base-64
-		{"9nITsSKl1ELSuTvajMRcVkpw7F0qTg6Vu1hc8ZmGnJg"},        // too short
-		{"9nITsSKl1ELSuTvajMRcVkpw7F0qTg6Vu1hc8ZmGnJgggggggg"}, // too long
-		{""},            // too short
-		{"test *&*&*&"}, // invalid chars
+		{Hash: "n4bQgYhMfWWaL-qgxVrQFaO_TxsrC4Is0V1sFbDwCgga"},       // missing last =
+		{Hash: "n4bQgYhMfWWaL+qgxVrQFaO_TxsrC4Is0V1sFbDwCgga"},       // + part of non-url base-64
+		{Hash: "9nITsSKl1ELSuTvajMRcVkpw7F0qTg6Vu1hc8ZmGnJg"},        // too short
+		{Hash: "9nITsSKl1ELSuTvajMRcVkpw7F0qTg6Vu1hc8ZmGnJgggggggg"}, // too long
+		{Hash: ""},            // too short
+		{Hash: "test *&*&*&"}, // invalid chars
 	}
 	for _, c := range cases {
 		assert.NotNil(t, c.Valid())
@@ -109,6 +110,44 @@ func TestEcdsaVerifer_Verify_err(t *testing.T) {
 	})
 }
 
+func TestEcdsaVerifier_Verify_issuedAt(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	message := NewFindRequest(peerID, cid.NewPseudoRandom(rng), 20)
+	encToken, err := NewSigner(peerID.Key()).Sign(message)
+	assert.Nil(t, err)
+	signed := time.Now()
+
+	cases := []struct {
+		now      time.Time
+		expected error
+	}{
+		{signed, nil},
+		{signed.Add(-DefaultClockSkew / 2), nil}, // within clock skew
+		{signed.Add(-2 * DefaultClockSkew), ErrSignatureIssuedInFuture},
+		{signed.Add(DefaultMaxSignatureAge), nil},
+		{signed.Add(DefaultMaxSignatureAge + 2*DefaultClockSkew), ErrSignatureExpired},
+	}
+	for i, c := range cases {
+		v := NewVerifier().(*ecsdaVerifier)
+		v.now = func() time.Time { return c.now }
+		assert.Equal(t, c.expected, v.Verify(encToken, &peerID.Key().PublicKey, message), i)
+	}
+
+	// check zero max age disables expiry
+	v := NewVerifierWithParameters(&VerifyParameters{ClockSkew: DefaultClockSkew})
+	v.(*ecsdaVerifier).now = func() time.Time { return signed.Add(24 * time.Hour) }
+	assert.Nil(t, v.Verify(encToken, &peerID.Key().PublicKey, message))
+}
+
+func TestEcdsaVerifier_verifyIssuedAt_missing(t *testing.T) {
+	v := NewVerifier().(*ecsdaVerifier)
+	assert.Nil(t, v.verifyIssuedAt(0))
+
+	v.params.RequireIssuedAt = true
+	assert.Equal(t, ErrMissingIssuedAt, v.verifyIssuedAt(0))
+}
+
 func TestTestNoOpSigner_Sign(t *testing.T) {
 	s := &TestNoOpSigner{}
 	token, err := s.Sign(nil)
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultPingTimeout is the default timeout for Ping and health check requests.
+	DefaultPingTimeout = 2 * time.Second
+
+	// DefaultIntroduceTimeout is the default timeout for Introduce requests.
+	DefaultIntroduceTimeout = 5 * time.Second
+
+	// DefaultFindTimeout is the default timeout for Find requests.
+	DefaultFindTimeout = 5 * time.Second
+
+	// DefaultStoreTimeout is the default timeout for Store requests.
+	DefaultStoreTimeout = 5 * time.Second
+
+	// DefaultGetTimeout is the default timeout for Get requests.
+	DefaultGetTimeout = 5 * time.Second
+
+	// DefaultPutTimeout is the default timeout for Put requests.
+	DefaultPutTimeout = 5 * time.Second
+
+	// DefaultSubscribeDialTimeout is the default timeout for opening a Subscribe stream and
+	// sending its request. The stream itself stays open much longer.
+	DefaultSubscribeDialTimeout = 10 * time.Second
+)
+
+// Timeouts define the timeout of each type of request a client makes to a peer. A zero timeout
+// disables it, leaving only any deadline the caller's context already has.
+type Timeouts struct {
+	// Ping is the timeout for Ping and health check requests.
+	Ping time.Duration
+
+	// Introduce is the timeout for Introduce requests.
+	Introduce time.Duration
+
+	// Find is the timeout for Find requests.
+	Find time.Duration
+
+	// Store is the timeout for Store requests.
+	Store time.Duration
+
+	// Get is the timeout for Get requests.
+	Get time.Duration
+
+	// Put is the timeout for Put requests.
+	Put time.Duration
+
+	// SubscribeDial is the timeout for opening a Subscribe stream and sending its request.
+	SubscribeDial time.Duration
+}
+
+// NewDefaultTimeouts returns a *Timeouts object with default values.
+func NewDefaultTimeouts() *Timeouts {
+	return &Timeouts{
+		Ping:          DefaultPingTimeout,
+		Introduce:     DefaultIntroduceTimeout,
+		Find:          DefaultFindTimeout,
+		Store:         DefaultStoreTimeout,
+		Get:           DefaultGetTimeout,
+		Put:           DefaultPutTimeout,
+		SubscribeDial: DefaultSubscribeDialTimeout,
+	}
+}
+
+// Queriers holds a Querier for each type of query, each with its timeout from Timeouts.
+type Queriers struct {
+	Introduce IntroduceQuerier
+	Find      FindQuerier
+	Store     StoreQuerier
+	Get       GetQuerier
+	Put       PutQuerier
+}
+
+// NewQueriers returns a new *Queriers instance whose queries time out after the given timeouts.
+func NewQueriers(timeouts *Timeouts) *Queriers {
+	return &Queriers{
+		Introduce: &introQuerier{timeout: timeouts.Introduce},
+		Find:      &findQuerier{timeout: timeouts.Find},
+		Store:     &storeQuerier{timeout: timeouts.Store},
+		Get:       &getQuerier{timeout: timeouts.Get},
+		Put:       &putQuerier{timeout: timeouts.Put},
+	}
+}
+
+// withTimeout returns a context from the parent that times out after the timeout, or just the
+// parent if the timeout is zero.
+func withTimeout(parent context.Context, timeout time.Duration) (
+	context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if timeout == 0 {
+		return parent, func() {}
+	}
+	return context.WithTimeout(parent, timeout)
+}
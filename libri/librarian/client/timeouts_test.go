@@ -0,0 +1,156 @@
+package client
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestNewDefaultTimeouts(t *testing.T) {
+	ts := NewDefaultTimeouts()
+	assert.Equal(t, DefaultPingTimeout, ts.Ping)
+	assert.Equal(t, DefaultIntroduceTimeout, ts.Introduce)
+	assert.Equal(t, DefaultFindTimeout, ts.Find)
+	assert.Equal(t, DefaultStoreTimeout, ts.Store)
+	assert.Equal(t, DefaultGetTimeout, ts.Get)
+	assert.Equal(t, DefaultPutTimeout, ts.Put)
+	assert.Equal(t, DefaultSubscribeDialTimeout, ts.SubscribeDial)
+}
+
+func TestNewQueriers(t *testing.T) {
+	ts := &Timeouts{
+		Introduce: 1 * time.Second,
+		Find:      2 * time.Second,
+		Store:     3 * time.Second,
+		Get:       4 * time.Second,
+		Put:       5 * time.Second,
+	}
+	qs := NewQueriers(ts)
+	assert.Equal(t, ts.Introduce, qs.Introduce.(*introQuerier).timeout)
+	assert.Equal(t, ts.Find, qs.Find.(*findQuerier).timeout)
+	assert.Equal(t, ts.Store, qs.Store.(*storeQuerier).timeout)
+	assert.Equal(t, ts.Get, qs.Get.(*getQuerier).timeout)
+	assert.Equal(t, ts.Put, qs.Put.(*putQuerier).timeout)
+
+	// check each query is made with its timeout
+	lc := &deadlineLibrarianClient{}
+	pConn := &fixedConnector{client: lc}
+	start := time.Now()
+	_, err := qs.Introduce.Query(context.Background(), pConn, nil)
+	assert.Nil(t, err)
+	_, err = qs.Find.Query(context.Background(), pConn, nil)
+	assert.Nil(t, err)
+	_, err = qs.Store.Query(context.Background(), pConn, nil)
+	assert.Nil(t, err)
+	_, err = qs.Get.Query(context.Background(), pConn, nil)
+	assert.Nil(t, err)
+	_, err = qs.Put.Query(context.Background(), pConn, nil)
+	assert.Nil(t, err)
+	expected := []time.Duration{ts.Introduce, ts.Find, ts.Store, ts.Get, ts.Put}
+	assert.Len(t, lc.deadlines, len(expected))
+	for i, timeout := range expected {
+		assert.InDelta(t, float64(timeout), float64(lc.deadlines[i].Sub(start)),
+			float64(time.Second/2))
+	}
+
+	// check zero timeout leaves caller's context without deadline
+	lc.deadlines = nil
+	_, err = NewQueriers(&Timeouts{}).Find.Query(context.Background(), pConn, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{{}}, lc.deadlines)
+}
+
+func TestWithTimeout(t *testing.T) {
+	// check zero timeout returns parent
+	parent := context.Background()
+	ctx, cancel := withTimeout(parent, 0)
+	assert.Equal(t, parent, ctx)
+	cancel()
+
+	// check nil parent is replaced
+	ctx, cancel = withTimeout(nil, 0)
+	assert.NotNil(t, ctx)
+	cancel()
+
+	// check timeout sets deadline
+	ctx, cancel = withTimeout(parent, time.Second)
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, deadline.After(time.Now()))
+	cancel()
+	assert.NotNil(t, ctx.Err())
+
+	// check shorter parent deadline is kept
+	shortParent, shortCancel := context.WithTimeout(parent, time.Millisecond)
+	defer shortCancel()
+	parentDeadline, _ := shortParent.Deadline()
+	ctx, cancel = withTimeout(shortParent, time.Hour)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
+
+// fixedConnector is an api.Connector that always connects with the same client.
+type fixedConnector struct {
+	client api.LibrarianClient
+}
+
+func (c *fixedConnector) Connect() (api.LibrarianClient, error) {
+	return c.client, nil
+}
+
+func (c *fixedConnector) Disconnect() error {
+	return nil
+}
+
+func (c *fixedConnector) Address() *net.TCPAddr {
+	return peer.NewTestPublicAddr(1)
+}
+
+// deadlineLibrarianClient records the deadline of each Introduce, Find, Store, Get, and Put
+// request, or the zero time if the request has none.
+type deadlineLibrarianClient struct {
+	api.LibrarianClient
+	deadlines []time.Time
+}
+
+func (c *deadlineLibrarianClient) record(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	c.deadlines = append(c.deadlines, deadline)
+}
+
+func (c *deadlineLibrarianClient) Introduce(ctx context.Context, in *api.IntroduceRequest,
+	opts ...grpc.CallOption) (*api.IntroduceResponse, error) {
+	c.record(ctx)
+	return &api.IntroduceResponse{}, nil
+}
+
+func (c *deadlineLibrarianClient) Find(ctx context.Context, in *api.FindRequest,
+	opts ...grpc.CallOption) (*api.FindResponse, error) {
+	c.record(ctx)
+	return &api.FindResponse{}, nil
+}
+
+func (c *deadlineLibrarianClient) Store(ctx context.Context, in *api.StoreRequest,
+	opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	c.record(ctx)
+	return &api.StoreResponse{}, nil
+}
+
+func (c *deadlineLibrarianClient) Get(ctx context.Context, in *api.GetRequest,
+	opts ...grpc.CallOption) (*api.GetResponse, error) {
+	c.record(ctx)
+	return &api.GetResponse{}, nil
+}
+
+func (c *deadlineLibrarianClient) Put(ctx context.Context, in *api.PutRequest,
+	opts ...grpc.CallOption) (*api.PutResponse, error) {
+	c.record(ctx)
+	return &api.PutResponse{}, nil
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"net"
+
+	"github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/common/subscribe"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	grpcpeer "google.golang.org/grpc/peer"
+)
+
+const (
+	// MaxScanPublications is the maximum number of publications returned by a ScanPublications
+	// request.
+	MaxScanPublications = 1024
+
+	// MaxScanAuditRecords is the maximum number of records returned by a ScanAuditLog request.
+	MaxScanAuditRecords = 1024
+)
+
+// ErrNonLocalAdminRequest indicates when an Admin request comes from a host other than the
+// librarian's own.
+var ErrNonLocalAdminRequest = errors.New("admin requests must come from the local host")
+
+// ErrAuditLogDisabled indicates when the audit log is scanned without being enabled.
+var ErrAuditLogDisabled = errors.New("audit log not enabled")
+
+// errScanLimit stops a scan of the publication log once the limit has been reached.
+var errScanLimit = errors.New("scan limit reached")
+
+// RecentPublications returns the size and counters of the recent publications cache.
+func (l *Librarian) RecentPublications(ctx context.Context, rq *api.RecentPublicationsRequest) (
+	*api.RecentPublicationsResponse, error) {
+	if err := l.checkAdminRequest(ctx, rq, rq.Metadata); err != nil {
+		return nil, err
+	}
+	return newRecentPublicationsResponse(l.NewResponseMetadata(rq.Metadata),
+		l.RecentPubs.Stats()), nil
+}
+
+// ResizeRecentPublications changes the size of the recent publications cache, whose best value
+// depends on the rate of publications in the network.
+func (l *Librarian) ResizeRecentPublications(
+	ctx context.Context, rq *api.ResizeRecentPublicationsRequest,
+) (*api.RecentPublicationsResponse, error) {
+	if err := l.checkAdminRequest(ctx, rq, rq.Metadata); err != nil {
+		return nil, err
+	}
+	if err := l.RecentPubs.Resize(rq.Size); err != nil {
+		return nil, err
+	}
+	l.logger.Info("resized recent publications cache", zap.Uint32("size", rq.Size))
+	return newRecentPublicationsResponse(l.NewResponseMetadata(rq.Metadata),
+		l.RecentPubs.Stats()), nil
+}
+
+// ScanPublications returns the publications in the publication log matching the request's
+// conditions, which is useful for network analytics and abuse investigations. Scans matching more
+// publications than the limit can be continued from the response's NextSequence.
+func (l *Librarian) ScanPublications(ctx context.Context, rq *api.ScanPublicationsRequest) (
+	*api.ScanPublicationsResponse, error) {
+	if err := l.checkAdminRequest(ctx, rq, rq.Metadata); err != nil {
+		return nil, err
+	}
+	if len(rq.FromPeerId) > 0 {
+		if err := api.ValidateBytes(rq.FromPeerId, id.Length, "FromPeerId"); err != nil {
+			return nil, err
+		}
+	}
+	if l.pubLog == nil {
+		return nil, ErrPublicationLogDisabled
+	}
+	limit := rq.Limit
+	if limit == 0 || limit > MaxScanPublications {
+		limit = MaxScanPublications
+	}
+	rp := &api.ScanPublicationsResponse{
+		Metadata:     l.NewResponseMetadata(rq.Metadata),
+		Publications: make([]*api.LoggedPublication, 0),
+	}
+	err := l.pubLog.Scan(rq.FromSequence, func(logged *subscribe.LoggedPub) error {
+		lp := newLoggedPublication(logged)
+		if !matchScan(rq, lp) {
+			return nil
+		}
+		if uint32(len(rp.Publications)) == limit {
+			rp.NextSequence = lp.Sequence
+			return errScanLimit
+		}
+		rp.Publications = append(rp.Publications, lp)
+		return nil
+	})
+	if err != nil && err != errScanLimit {
+		return nil, err
+	}
+	l.logger.Info("scanned publications",
+		zap.Uint64("from_sequence", rq.FromSequence),
+		zap.Int("n_publications", len(rp.Publications)),
+		zap.Uint64("next_sequence", rp.NextSequence),
+	)
+	return rp, nil
+}
+
+// ScanAuditLog returns the records in the audit log matching the request's conditions, which is
+// useful for investigating abuse of a public librarian. Scans matching more records than the
+// limit can be continued from the response's NextSequence.
+func (l *Librarian) ScanAuditLog(ctx context.Context, rq *api.ScanAuditLogRequest) (
+	*api.ScanAuditLogResponse, error) {
+	if err := l.checkAdminRequest(ctx, rq, rq.Metadata); err != nil {
+		return nil, err
+	}
+	if l.auditLog == nil {
+		return nil, ErrAuditLogDisabled
+	}
+	if len(rq.RequesterPeerId) > 0 {
+		err := api.ValidateBytes(rq.RequesterPeerId, id.Length, "RequesterPeerId")
+		if err != nil {
+			return nil, err
+		}
+	}
+	limit := rq.Limit
+	if limit == 0 || limit > MaxScanAuditRecords {
+		limit = MaxScanAuditRecords
+	}
+	rp := &api.ScanAuditLogResponse{
+		Metadata: l.NewResponseMetadata(rq.Metadata),
+		Records:  make([]*api.AuditRecord, 0),
+	}
+	err := l.auditLog.Scan(rq.FromSequence, func(stored *storage.AuditRecord) error {
+		record := newAuditRecord(stored)
+		if !matchAuditScan(rq, record) {
+			return nil
+		}
+		if uint32(len(rp.Records)) == limit {
+			rp.NextSequence = record.Sequence
+			return errScanLimit
+		}
+		rp.Records = append(rp.Records, record)
+		return nil
+	})
+	if err != nil && err != errScanLimit {
+		return nil, err
+	}
+	l.logger.Info("scanned audit log",
+		zap.Uint64("from_sequence", rq.FromSequence),
+		zap.Int("n_records", len(rp.Records)),
+		zap.Uint64("next_sequence", rp.NextSequence),
+	)
+	return rp, nil
+}
+
+// UpdateDenylist adds and removes keys from the denylist and saves it, so the update persists
+// across restarts.
+func (l *Librarian) UpdateDenylist(ctx context.Context, rq *api.UpdateDenylistRequest) (
+	*api.UpdateDenylistResponse, error) {
+	if err := l.checkAdminRequest(ctx, rq, rq.Metadata); err != nil {
+		return nil, err
+	}
+	addKeys, err := toDenylistKeys(rq.AddKeys, "AddKeys")
+	if err != nil {
+		return nil, err
+	}
+	removeKeys, err := toDenylistKeys(rq.RemoveKeys, "RemoveKeys")
+	if err != nil {
+		return nil, err
+	}
+	l.denylist.Add(addKeys...)
+	l.denylist.Remove(removeKeys...)
+	if err := saveDenylist(l.serverSL, l.denylist); err != nil {
+		return nil, err
+	}
+	stored := l.denylist.ToStored()
+	l.logger.Info("updated denylist",
+		zap.Int("n_added", len(addKeys)),
+		zap.Int("n_removed", len(removeKeys)),
+		zap.Int("n_keys", len(stored.Keys)),
+	)
+	return &api.UpdateDenylistResponse{
+		Metadata: l.NewResponseMetadata(rq.Metadata),
+		Keys:     stored.Keys,
+	}, nil
+}
+
+// checkAdminRequest verifies the request signature, that the requester has the admin role, and
+// that the request comes from the local host. Unlike other requests, admin requests are rejected
+// when no roles are configured.
+func (l *Librarian) checkAdminRequest(ctx context.Context, rq proto.Message,
+	meta *api.RequestMetadata) error {
+	if _, err := l.checkRequest(ctx, rq, meta); err != nil {
+		return err
+	}
+	if l.config == nil || l.config.Roles == nil ||
+		l.config.Roles.Of(ctx, meta.PubKey)&AdminRole == 0 {
+		l.logger.Info("rejected unauthorized admin request")
+		return ErrUnauthorizedRole
+	}
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return ErrNonLocalAdminRequest
+	}
+	addr, ok := p.Addr.(*net.TCPAddr)
+	if !ok || !addr.IP.IsLoopback() {
+		l.logger.Info("rejected non-local admin request", zap.Stringer("addr", p.Addr))
+		return ErrNonLocalAdminRequest
+	}
+	return nil
+}
+
+func newRecentPublicationsResponse(
+	meta *api.ResponseMetadata, stats *subscribe.RecentPublicationsStats,
+) *api.RecentPublicationsResponse {
+	return &api.RecentPublicationsResponse{
+		Metadata:   meta,
+		Size:       stats.Size,
+		Len:        stats.Len,
+		NHits:      stats.NHits,
+		NMisses:    stats.NMisses,
+		NEvictions: stats.NEvictions,
+	}
+}
+
+func newLoggedPublication(logged *subscribe.LoggedPub) *api.LoggedPublication {
+	lp := &api.LoggedPublication{
+		Sequence:      logged.Sequence,
+		Key:           logged.Key.Bytes(),
+		Value:         logged.Value,
+		Timestamp:     logged.Time.UnixNano(),
+		FromPublicKey: logged.FromPub,
+	}
+	if fromPeerID, err := newIDFromPublicKeyBytes(logged.FromPub); err == nil {
+		lp.FromPeerId = fromPeerID.Bytes()
+	}
+	return lp
+}
+
+// matchScan returns whether the logged publication satisfies all the set conditions of the scan
+// request.
+func matchScan(rq *api.ScanPublicationsRequest, lp *api.LoggedPublication) bool {
+	if rq.StartTime != 0 && lp.Timestamp < rq.StartTime {
+		return false
+	}
+	if rq.EndTime != 0 && lp.Timestamp >= rq.EndTime {
+		return false
+	}
+	if len(rq.FromPeerId) > 0 && !bytes.Equal(rq.FromPeerId, lp.FromPeerId) {
+		return false
+	}
+	if len(rq.AuthorPublicKey) > 0 &&
+		!bytes.Equal(rq.AuthorPublicKey, lp.Value.AuthorPublicKey) {
+		return false
+	}
+	return true
+}
+
+func newAuditRecord(stored *storage.AuditRecord) *api.AuditRecord {
+	record := &api.AuditRecord{
+		Sequence:           stored.Sequence,
+		Timestamp:          stored.Timestamp,
+		RequesterPublicKey: stored.RequesterPublicKey,
+		Rpc:                stored.Rpc,
+		Key:                stored.Key,
+		Error:              stored.Error,
+	}
+	if requesterID, err := newIDFromPublicKeyBytes(stored.RequesterPublicKey); err == nil {
+		record.RequesterPeerId = requesterID.Bytes()
+	}
+	return record
+}
+
+// matchAuditScan returns whether the audit record satisfies all the set conditions of the scan
+// request.
+func matchAuditScan(rq *api.ScanAuditLogRequest, record *api.AuditRecord) bool {
+	if rq.StartTime != 0 && record.Timestamp < rq.StartTime {
+		return false
+	}
+	if rq.EndTime != 0 && record.Timestamp >= rq.EndTime {
+		return false
+	}
+	if len(rq.RequesterPeerId) > 0 && !bytes.Equal(rq.RequesterPeerId, record.RequesterPeerId) {
+		return false
+	}
+	if rq.Rpc != "" && rq.Rpc != record.Rpc {
+		return false
+	}
+	return true
+}
+
+// toDenylistKeys validates and converts the byte keys of a denylist update.
+func toDenylistKeys(keys [][]byte, name string) ([]id.ID, error) {
+	ids := make([]id.ID, len(keys))
+	for i, key := range keys {
+		if err := api.ValidateBytes(key, id.Length, name); err != nil {
+			return nil, err
+		}
+		ids[i] = id.FromBytes(key)
+	}
+	return ids, nil
+}
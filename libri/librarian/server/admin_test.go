@@ -0,0 +1,417 @@
+package server
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/common/subscribe"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	grpcpeer "google.golang.org/grpc/peer"
+)
+
+func TestLibrarian_RecentPublications_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+	rq := client.NewRecentPublicationsRequest(ecid.NewPseudoRandom(rng))
+
+	rp, err := l.RecentPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	assert.Equal(t, uint32(8), rp.Size)
+	assert.Zero(t, rp.Len)
+}
+
+func TestLibrarian_RecentPublications_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+
+	// bad request
+	rq := client.NewRecentPublicationsRequest(ecid.NewPseudoRandom(rng))
+	rq.Metadata.PubKey = []byte("corrupted pub key")
+	rp, err := l.RecentPublications(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+
+	// non-local request
+	rq = client.NewRecentPublicationsRequest(ecid.NewPseudoRandom(rng))
+	rp, err = l.RecentPublications(newAdminContext("10.0.0.1"), rq)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+	assert.Nil(t, rp)
+
+	// request without peer
+	rp, err = l.RecentPublications(context.Background(), rq)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_ResizeRecentPublications_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+	rq := client.NewResizeRecentPublicationsRequest(ecid.NewPseudoRandom(rng), 16)
+
+	rp, err := l.ResizeRecentPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(16), rp.Size)
+	assert.Equal(t, uint32(16), l.RecentPubs.Stats().Size)
+}
+
+func TestLibrarian_ResizeRecentPublications_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+
+	// non-local request
+	rq := client.NewResizeRecentPublicationsRequest(ecid.NewPseudoRandom(rng), 16)
+	rp, err := l.ResizeRecentPublications(newAdminContext("10.0.0.1"), rq)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+	assert.Nil(t, rp)
+
+	// invalid size
+	rq = client.NewResizeRecentPublicationsRequest(ecid.NewPseudoRandom(rng), 0)
+	rp, err = l.ResizeRecentPublications(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+	assert.Equal(t, uint32(8), l.RecentPubs.Stats().Size)
+}
+
+func TestLibrarian_ScanPublications_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	l := newAdminLibrarian(t, rng)
+	l.pubLog, err = subscribe.NewPublicationLog(storage.NewPublicationKVDBStorerLoader(kvdb), 0)
+	assert.Nil(t, err)
+
+	// pubs 1-3 from peer1 and pubs 4-6 from peer2
+	peer1, peer2 := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	pubs := make([]*api.Publication, 6)
+	var mid time.Time
+	for i := range pubs {
+		from := peer1
+		if i >= 3 {
+			from = peer2
+		}
+		if i == 3 {
+			mid = time.Now()
+		}
+		pubs[i] = api.NewTestPublication(rng)
+		_, err = l.pubLog.Append(newKeyedPub(t, pubs[i]), ecid.ToPublicKeyBytes(from))
+		assert.Nil(t, err)
+	}
+
+	// all
+	rq := client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rp, err := l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	assert.Len(t, rp.Publications, len(pubs))
+	assert.Zero(t, rp.NextSequence)
+	for i, lp := range rp.Publications {
+		assert.Equal(t, uint64(i+1), lp.Sequence)
+		assert.Equal(t, pubs[i], lp.Value)
+		assert.NotZero(t, lp.Timestamp)
+	}
+	assert.Equal(t, ecid.ToPublicKeyBytes(peer1), rp.Publications[0].FromPublicKey)
+	assert.Equal(t, peer1.ID().Bytes(), rp.Publications[0].FromPeerId)
+
+	// limited and continued
+	rq = client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 2, 3)
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Publications, 3)
+	assert.Equal(t, uint64(2), rp.Publications[0].Sequence)
+	assert.Equal(t, uint64(5), rp.NextSequence)
+
+	// from peer
+	rq = client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.FromPeerId = peer2.ID().Bytes()
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Publications, 3)
+	assert.Equal(t, pubs[3], rp.Publications[0].Value)
+
+	// by author
+	rq = client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.AuthorPublicKey = pubs[1].AuthorPublicKey
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Publications, 1)
+	assert.Equal(t, pubs[1], rp.Publications[0].Value)
+
+	// time range
+	rq = client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.EndTime = mid.UnixNano()
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Publications, 3)
+	rq.StartTime, rq.EndTime = mid.UnixNano(), 0
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Publications, 3)
+	assert.Equal(t, pubs[3], rp.Publications[0].Value)
+}
+
+func TestLibrarian_ScanPublications_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+
+	// non-local request
+	rq := client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rp, err := l.ScanPublications(newAdminContext("10.0.0.1"), rq)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+	assert.Nil(t, rp)
+
+	// invalid from peer ID
+	rq = client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.FromPeerId = []byte{1, 2, 3}
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+
+	// publication log not enabled
+	l.pubLog = nil
+	rq = client.NewScanPublicationsRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rp, err = l.ScanPublications(newAdminContext("127.0.0.1"), rq)
+	assert.Equal(t, ErrPublicationLogDisabled, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_ScanAuditLog_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	l := newAdminLibrarian(t, rng)
+	l.auditLog, err = NewAuditLog(storage.NewAuditKVDBStorerLoader(kvdb))
+	assert.Nil(t, err)
+
+	// Store records 1-3 from peer1 and Put records 4-6 from peer2
+	peer1, peer2 := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	var mid time.Time
+	for i := 0; i < 6; i++ {
+		rpc, from := AuditStore, peer1
+		if i >= 3 {
+			rpc, from = AuditPut, peer2
+		}
+		if i == 3 {
+			mid = time.Now()
+		}
+		l.audit(rpc, client.NewRequestMetadata(from), api.RandBytes(rng, id.Length), nil)
+	}
+
+	// all
+	rq := client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rp, err := l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	assert.Len(t, rp.Records, 6)
+	assert.Zero(t, rp.NextSequence)
+	for i, record := range rp.Records {
+		assert.Equal(t, uint64(i+1), record.Sequence)
+		assert.NotZero(t, record.Timestamp)
+		assert.Empty(t, record.Error)
+	}
+	assert.Equal(t, ecid.ToPublicKeyBytes(peer1), rp.Records[0].RequesterPublicKey)
+	assert.Equal(t, peer1.ID().Bytes(), rp.Records[0].RequesterPeerId)
+
+	// limited and continued
+	rq = client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 2, 3)
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Records, 3)
+	assert.Equal(t, uint64(2), rp.Records[0].Sequence)
+	assert.Equal(t, uint64(5), rp.NextSequence)
+
+	// by requester
+	rq = client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.RequesterPeerId = peer2.ID().Bytes()
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Records, 3)
+	assert.Equal(t, uint64(4), rp.Records[0].Sequence)
+
+	// by RPC
+	rq = client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.Rpc = AuditStore
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Records, 3)
+	assert.Equal(t, AuditStore, rp.Records[2].Rpc)
+
+	// time range
+	rq = client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.EndTime = mid.UnixNano()
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Records, 3)
+	rq.StartTime, rq.EndTime = mid.UnixNano(), 0
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Len(t, rp.Records, 3)
+	assert.Equal(t, uint64(4), rp.Records[0].Sequence)
+}
+
+func TestLibrarian_ScanAuditLog_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+
+	// non-local request
+	rq := client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rp, err := l.ScanAuditLog(newAdminContext("10.0.0.1"), rq)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+	assert.Nil(t, rp)
+
+	// audit log not enabled
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.Equal(t, ErrAuditLogDisabled, err)
+	assert.Nil(t, rp)
+
+	// invalid requester peer ID
+	l.auditLog = &fixedAuditLog{}
+	rq = client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rq.RequesterPeerId = []byte{1, 2, 3}
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+
+	// scan error
+	l.auditLog = &fixedAuditLog{err: errors.New("some Scan error")}
+	rq = client.NewScanAuditLogRequest(ecid.NewPseudoRandom(rng), 0, 0)
+	rp, err = l.ScanAuditLog(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_UpdateDenylist_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	key1, key2 := id.NewPseudoRandom(rng), id.NewPseudoRandom(rng)
+	l := newAdminLibrarian(t, rng)
+	l.serverSL = storage.NewServerKVDBStorerLoader(kvdb)
+	l.denylist = NewDenylist(key1)
+
+	rq := client.NewUpdateDenylistRequest(ecid.NewPseudoRandom(rng), []id.ID{key2},
+		[]id.ID{key1})
+	rp, err := l.UpdateDenylist(newAdminContext("127.0.0.1"), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	assert.Equal(t, [][]byte{key2.Bytes()}, rp.Keys)
+	assert.False(t, l.denylist.Contains(key1))
+	assert.True(t, l.denylist.Contains(key2))
+
+	// check update is saved
+	loaded, err := loadDenylist(l.logger, l.serverSL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []id.ID{key2}, loaded.Keys())
+}
+
+func TestLibrarian_UpdateDenylist_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+	l.serverSL = &fixedStorerLoader{storeErr: errors.New("some store error")}
+	l.denylist = NewDenylist()
+	key := id.NewPseudoRandom(rng)
+
+	// non-local request
+	rq := client.NewUpdateDenylistRequest(ecid.NewPseudoRandom(rng), []id.ID{key}, nil)
+	rp, err := l.UpdateDenylist(newAdminContext("10.0.0.1"), rq)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+	assert.Nil(t, rp)
+
+	// invalid keys
+	rq = client.NewUpdateDenylistRequest(ecid.NewPseudoRandom(rng), nil, nil)
+	rq.AddKeys = [][]byte{{1, 2, 3}}
+	rp, err = l.UpdateDenylist(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+	rq = client.NewUpdateDenylistRequest(ecid.NewPseudoRandom(rng), nil, nil)
+	rq.RemoveKeys = [][]byte{{1, 2, 3}}
+	rp, err = l.UpdateDenylist(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+
+	// save error
+	rq = client.NewUpdateDenylistRequest(ecid.NewPseudoRandom(rng), []id.ID{key}, nil)
+	rp, err = l.UpdateDenylist(newAdminContext("127.0.0.1"), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_checkAdminRequest_role(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := newAdminLibrarian(t, rng)
+	admin, other := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	l.config.Roles = NewDefaultRoles().AssignPublicKey(ecid.ToPublicKeyBytes(admin), AdminRole)
+
+	// check requester with admin role allowed
+	rq := client.NewRecentPublicationsRequest(admin)
+	err := l.checkAdminRequest(newAdminContext("127.0.0.1"), rq, rq.Metadata)
+	assert.Nil(t, err)
+
+	// check requester without admin role rejected, even from local host
+	rq = client.NewRecentPublicationsRequest(other)
+	err = l.checkAdminRequest(newAdminContext("127.0.0.1"), rq, rq.Metadata)
+	assert.Equal(t, ErrUnauthorizedRole, err)
+
+	// check every requester rejected when roles aren't configured
+	l.config.Roles = nil
+	rq = client.NewRecentPublicationsRequest(admin)
+	err = l.checkAdminRequest(newAdminContext("127.0.0.1"), rq, rq.Metadata)
+	assert.Equal(t, ErrUnauthorizedRole, err)
+
+	// check admin still rejected from non-local host
+	l.config.Roles = NewDefaultRoles().AssignPublicKey(ecid.ToPublicKeyBytes(admin), AdminRole)
+	err = l.checkAdminRequest(newAdminContext("10.0.0.1"), rq, rq.Metadata)
+	assert.Equal(t, ErrNonLocalAdminRequest, err)
+}
+
+func newAdminLibrarian(t *testing.T, rng *rand.Rand) *Librarian {
+	recent, err := subscribe.NewRecentPublications(8)
+	assert.Nil(t, err)
+	roles := NewDefaultRoles()
+	roles.Default |= AdminRole
+	return &Librarian{
+		selfID:      ecid.NewPseudoRandom(rng),
+		config:      &Config{Roles: roles},
+		RecentPubs:  recent,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+}
+
+func newAdminContext(ip string) context.Context {
+	return grpcpeer.NewContext(context.Background(), &grpcpeer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 20100},
+	})
+}
+
+type fixedAuditLog struct {
+	appended []*storage.AuditRecord
+	err      error
+}
+
+func (f *fixedAuditLog) Append(record *storage.AuditRecord) error {
+	f.appended = append(f.appended, record)
+	return f.err
+}
+
+func (f *fixedAuditLog) Scan(from uint64, callback func(record *storage.AuditRecord) error) error {
+	return f.err
+}
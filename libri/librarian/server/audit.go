@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/golang/protobuf/proto"
+)
+
+// RPC names recorded in the audit log.
+const (
+	// AuditStore is the name of the Store RPC.
+	AuditStore = "Store"
+
+	// AuditPut is the name of the Put RPC.
+	AuditPut = "Put"
+)
+
+// ErrUnexpectedAuditSequence indicates when an audit record's sequence number doesn't match its
+// storage key.
+var ErrUnexpectedAuditSequence = errors.New("audit record has unexpected sequence number")
+
+// AuditLog durably records mutating requests in the order they are received, so abuse of a
+// public librarian can later be investigated.
+type AuditLog interface {
+	// Append records the request under the next sequence number, which it sets on the record
+	// along with the current time.
+	Append(record *storage.AuditRecord) error
+
+	// Scan calls the callback, in sequence order, for each audit record with a sequence number
+	// of at least from. It stops early and returns the first error the callback returns.
+	Scan(from uint64, callback func(record *storage.AuditRecord) error) error
+}
+
+type auditLog struct {
+	asl  storage.NamespaceStorerLoaderIterator
+	last uint64
+	mu   sync.Mutex
+}
+
+// NewAuditLog creates a new AuditLog backed by the given storage, continuing from the last record
+// already there.
+func NewAuditLog(asl storage.NamespaceStorerLoaderIterator) (AuditLog, error) {
+	al := &auditLog{asl: asl}
+	err := asl.Iterate(make(chan struct{}), func(key, value []byte) {
+		al.last = binary.BigEndian.Uint64(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return al, nil
+}
+
+func (al *auditLog) Append(record *storage.AuditRecord) error {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	record.Sequence = al.last + 1
+	record.Timestamp = time.Now().UnixNano()
+	stored, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := al.asl.Store(auditKey(record.Sequence), stored); err != nil {
+		return err
+	}
+	al.last = record.Sequence
+	return nil
+}
+
+func (al *auditLog) Scan(from uint64, callback func(record *storage.AuditRecord) error) error {
+	done := make(chan struct{})
+	var cbErr error
+	err := al.asl.Iterate(done, func(key, value []byte) {
+		seq := binary.BigEndian.Uint64(key)
+		if seq < from {
+			return
+		}
+		record := &storage.AuditRecord{}
+		err := proto.Unmarshal(value, record)
+		if err == nil && record.Sequence != seq {
+			err = ErrUnexpectedAuditSequence
+		}
+		if err == nil {
+			err = callback(record)
+		}
+		if err != nil {
+			cbErr = err
+			close(done)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return cbErr
+}
+
+// auditKey gives the storage key for the given sequence number, which sorts in sequence order.
+func auditKey(seq uint64) []byte {
+	key := make([]byte, storage.AuditKeyLength)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
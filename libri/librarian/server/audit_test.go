@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog_AppendScan(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	asl := storage.NewAuditKVDBStorerLoader(kvdb)
+
+	al1, err := NewAuditLog(asl)
+	assert.Nil(t, err)
+
+	// enough records to check scan is in numeric (not lexical) sequence order
+	nRecords := 260
+	for i := 0; i < nRecords; i++ {
+		record := &storage.AuditRecord{Rpc: AuditStore, Key: []byte{byte(i)}}
+		assert.Nil(t, al1.Append(record))
+		assert.Equal(t, uint64(i+1), record.Sequence)
+		assert.NotZero(t, record.Timestamp)
+	}
+
+	// new log on same storage continues from last sequence
+	al2, err := NewAuditLog(asl)
+	assert.Nil(t, err)
+	record := &storage.AuditRecord{Rpc: AuditPut, Error: "some Put error"}
+	assert.Nil(t, al2.Append(record))
+	assert.Equal(t, uint64(nRecords+1), record.Sequence)
+
+	from := uint64(200)
+	scanned := make([]*storage.AuditRecord, 0)
+	err = al2.Scan(from, func(record *storage.AuditRecord) error {
+		scanned = append(scanned, record)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, scanned, nRecords-int(from)+2)
+	for i, record := range scanned {
+		assert.Equal(t, from+uint64(i), record.Sequence)
+	}
+	assert.Equal(t, "some Put error", scanned[len(scanned)-1].Error)
+}
+
+func TestAuditLog_Scan_err(t *testing.T) {
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	asl := storage.NewAuditKVDBStorerLoader(kvdb)
+	al, err := NewAuditLog(asl)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, al.Append(&storage.AuditRecord{Rpc: AuditStore}))
+	}
+
+	// check callback error stops scan
+	cbErr, nScanned := errors.New("some callback error"), 0
+	err = al.Scan(0, func(record *storage.AuditRecord) error {
+		nScanned++
+		return cbErr
+	})
+	assert.Equal(t, cbErr, err)
+	assert.Equal(t, 1, nScanned)
+
+	// check record stored under the wrong key errors
+	wrongSeq, err := proto.Marshal(&storage.AuditRecord{Sequence: 3, Rpc: AuditStore})
+	assert.Nil(t, err)
+	assert.Nil(t, asl.Store(auditKey(2), wrongSeq))
+	err = al.Scan(0, func(record *storage.AuditRecord) error { return nil })
+	assert.Equal(t, ErrUnexpectedAuditSequence, err)
+}
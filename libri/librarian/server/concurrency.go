@@ -0,0 +1,128 @@
+package server
+
+import (
+	"sync"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultMaxSubscriptionsPerPeer is the default number of simultaneous Subscribe streams
+	// allowed from each peer.
+	DefaultMaxSubscriptionsPerPeer = uint(4)
+
+	// DefaultMaxSubscriptions is the default number of simultaneous Subscribe streams allowed in
+	// total.
+	DefaultMaxSubscriptions = uint(256)
+
+	// DefaultMaxOperationsPerPeer is the default number of in-flight Get and Put operations
+	// allowed from each peer.
+	DefaultMaxOperationsPerPeer = uint(16)
+
+	// DefaultMaxOperations is the default number of in-flight Get and Put operations allowed in
+	// total.
+	DefaultMaxOperations = uint(1024)
+)
+
+var (
+	// ErrTooManySubscriptions indicates when a Subscribe stream is rejected because either its
+	// requester or the server has reached their cap on simultaneous streams.
+	ErrTooManySubscriptions = status.Error(codes.ResourceExhausted,
+		"too many simultaneous subscriptions")
+
+	// ErrTooManyOperations indicates when a Get or Put request is rejected because either its
+	// requester or the server has reached their cap on in-flight operations.
+	ErrTooManyOperations = status.Error(codes.ResourceExhausted,
+		"too many in-flight Get and Put operations")
+)
+
+// ConcurrencyParameters define caps on the simultaneous long-lived requests the server handles,
+// so a single peer can't exhaust its goroutines. Zero values disable the respective cap.
+type ConcurrencyParameters struct {
+	// MaxSubscriptionsPerPeer is the number of simultaneous Subscribe streams allowed from each
+	// peer.
+	MaxSubscriptionsPerPeer uint
+
+	// MaxSubscriptions is the number of simultaneous Subscribe streams allowed in total.
+	MaxSubscriptions uint
+
+	// MaxOperationsPerPeer is the number of in-flight Get and Put operations allowed from each
+	// peer.
+	MaxOperationsPerPeer uint
+
+	// MaxOperations is the number of in-flight Get and Put operations allowed in total.
+	MaxOperations uint
+}
+
+// NewDefaultConcurrencyParameters returns a *ConcurrencyParameters object with default values.
+func NewDefaultConcurrencyParameters() *ConcurrencyParameters {
+	return &ConcurrencyParameters{
+		MaxSubscriptionsPerPeer: DefaultMaxSubscriptionsPerPeer,
+		MaxSubscriptions:        DefaultMaxSubscriptions,
+		MaxOperationsPerPeer:    DefaultMaxOperationsPerPeer,
+		MaxOperations:           DefaultMaxOperations,
+	}
+}
+
+// ConcurrencyLimiter caps the number of simultaneous requests of one kind, both from each peer
+// and in total.
+type ConcurrencyLimiter interface {
+	// Acquire reserves a slot for a request from the peer, returning false without reserving
+	// one if either the peer or total cap has been reached.
+	Acquire(peerID cid.ID) bool
+
+	// Release frees a slot previously reserved for a request from the peer.
+	Release(peerID cid.ID)
+}
+
+type concurrencyLimiter struct {
+	maxPerPeer uint
+	max        uint
+	perPeer    map[string]uint
+	total      uint
+	mu         sync.Mutex
+}
+
+// NewConcurrencyLimiter creates a new ConcurrencyLimiter with the given per-peer and total caps,
+// where zero disables the respective cap.
+func NewConcurrencyLimiter(maxPerPeer, max uint) ConcurrencyLimiter {
+	return &concurrencyLimiter{
+		maxPerPeer: maxPerPeer,
+		max:        max,
+		perPeer:    make(map[string]uint),
+	}
+}
+
+func (cl *concurrencyLimiter) Acquire(peerID cid.ID) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	peerKey := peerID.String()
+	if cl.max > 0 && cl.total >= cl.max {
+		return false
+	}
+	if cl.maxPerPeer > 0 && cl.perPeer[peerKey] >= cl.maxPerPeer {
+		return false
+	}
+	cl.perPeer[peerKey]++
+	cl.total++
+	return true
+}
+
+func (cl *concurrencyLimiter) Release(peerID cid.ID) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	peerKey := peerID.String()
+	n, in := cl.perPeer[peerKey]
+	if !in {
+		return
+	}
+	if n <= 1 {
+		// drop peers without requests so the map doesn't grow without bound
+		delete(cl.perPeer, peerKey)
+	} else {
+		cl.perPeer[peerKey] = n - 1
+	}
+	cl.total--
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"math/rand"
+	"testing"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter_perPeer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	cl := NewConcurrencyLimiter(2, 0)
+	peer1, peer2 := cid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+
+	// check peer is capped
+	assert.True(t, cl.Acquire(peer1))
+	assert.True(t, cl.Acquire(peer1))
+	assert.False(t, cl.Acquire(peer1))
+
+	// check other peers have separate caps
+	assert.True(t, cl.Acquire(peer2))
+
+	// check released slot can be acquired again
+	cl.Release(peer1)
+	assert.True(t, cl.Acquire(peer1))
+	assert.False(t, cl.Acquire(peer1))
+
+	// check peers without requests are dropped
+	cl.Release(peer2)
+	assert.Len(t, cl.(*concurrencyLimiter).perPeer, 1)
+	assert.Equal(t, uint(2), cl.(*concurrencyLimiter).total)
+
+	// check releasing unknown peer is a no-op
+	cl.Release(cid.NewPseudoRandom(rng))
+	assert.Equal(t, uint(2), cl.(*concurrencyLimiter).total)
+}
+
+func TestConcurrencyLimiter_total(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	cl := NewConcurrencyLimiter(0, 3)
+	peer1, peer2 := cid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+
+	assert.True(t, cl.Acquire(peer1))
+	assert.True(t, cl.Acquire(peer1))
+	assert.True(t, cl.Acquire(peer2))
+	assert.False(t, cl.Acquire(peer1))
+	assert.False(t, cl.Acquire(peer2))
+
+	cl.Release(peer1)
+	assert.True(t, cl.Acquire(peer2))
+}
+
+func TestConcurrencyLimiter_unlimited(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	cl := NewConcurrencyLimiter(0, 0)
+	peerID := cid.NewPseudoRandom(rng)
+	for c := 0; c < 100; c++ {
+		assert.True(t, cl.Acquire(peerID))
+	}
+}
@@ -1,18 +1,27 @@
 package server
 
 import (
+	"crypto"
 	"crypto/md5"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/common/subscribe"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/introduce"
+	"github.com/drausin/libri/libri/librarian/server/replicate"
 	"github.com/drausin/libri/libri/librarian/server/routing"
 	"github.com/drausin/libri/libri/librarian/server/search"
 	"github.com/drausin/libri/libri/librarian/server/store"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
 const (
@@ -31,8 +40,34 @@ const (
 
 	// DBSubDir is the default DB subdirectory within the data dir.
 	DBSubDir = "db"
+
+	// DefaultRebootstrapMinPeers is the default routing table size below which the server
+	// re-bootstraps its peers.
+	DefaultRebootstrapMinPeers = uint(8)
+
+	// DefaultRebootstrapCheckInterval is the default period between routing table size checks.
+	DefaultRebootstrapCheckInterval = 30 * time.Second
 )
 
+// RebootstrapParameters define when the server re-bootstraps its routing table from the
+// bootstrap addresses.
+type RebootstrapParameters struct {
+	// MinPeers is the routing table size below which the server re-bootstraps its peers; zero
+	// value disables re-bootstrapping.
+	MinPeers uint
+
+	// CheckInterval is the period between routing table size checks.
+	CheckInterval time.Duration
+}
+
+// NewDefaultRebootstrapParameters returns a *RebootstrapParameters object with default values.
+func NewDefaultRebootstrapParameters() *RebootstrapParameters {
+	return &RebootstrapParameters{
+		MinPeers:      DefaultRebootstrapMinPeers,
+		CheckInterval: DefaultRebootstrapCheckInterval,
+	}
+}
+
 // Config is used to configure a Librarian server
 type Config struct {
 	// LocalAddr is the local address the server listens to.
@@ -60,18 +95,131 @@ type Config struct {
 	// Introduce defines parameters for introductions the server performs.
 	Introduce *introduce.Parameters
 
+	// Rebootstrap defines when the server re-bootstraps its routing table.
+	Rebootstrap *RebootstrapParameters
+
+	// RateLimit defines how requests from each peer are rate limited.
+	RateLimit *RateLimitParameters
+
+	// Concurrency defines caps on simultaneous Subscribe streams and Get and Put operations.
+	Concurrency *ConcurrencyParameters
+
+	// Verify defines how strictly request signature times are verified.
+	Verify *client.VerifyParameters
+
 	// Search defines parameters for searches the server performs.
 	Search *search.Parameters
 
+	// SearchCache defines how the results of recent searches the server performs are cached.
+	SearchCache *search.CacheParameters
+
 	// Store defines parameters for stores the server performs.
 	Store *store.Parameters
 
+	// Replicate defines parameters for verifying and repairing replicas of stored documents.
+	Replicate *replicate.Parameters
+
 	// SubscribeTo defines parameters for subscriptions to other peers.
 	SubscribeTo *subscribe.ToParameters
 
 	// SubscribeFrom defines parameters for subscriptions to other peers.
 	SubscribeFrom *subscribe.FromParameters
 
+	// Webhook defines the optional bridge POSTing publications to HTTP endpoints.
+	Webhook *subscribe.WebhookParameters
+
+	// TLSCertFile is the PEM certificate file the server uses for TLS; empty serves plaintext.
+	TLSCertFile string
+
+	// TLSKeyFile is the PEM private key file for the TLS certificate.
+	TLSKeyFile string
+
+	// TLSClientCAFile is the optional PEM file of CA certificates that client certificates must
+	// be signed by; empty doesn't require client certificates.
+	TLSClientCAFile string
+
+	// PeerTLSCAFile is the optional PEM file of CA certificates that peers' TLS certificates
+	// must be signed by. Setting it or TLSCertFile dials peers over TLS, with the server's own
+	// certificate as the client certificate when configured; an empty file uses the system's
+	// CA certificates.
+	PeerTLSCAFile string
+
+	// PeerTLSServerName is the optional name peers' TLS certificates must have, instead of their
+	// dialed hosts.
+	PeerTLSServerName string
+
+	// UnaryInterceptors are called in order around each unary request the server handles, e.g.,
+	// for embedders adding their own auth, quotas, or logging.
+	UnaryInterceptors []grpc.UnaryServerInterceptor
+
+	// StreamInterceptors are called in order around each stream the server handles.
+	StreamInterceptors []grpc.StreamServerInterceptor
+
+	// RotatePeerID is whether the server replaces its stored peer ID with a new one on startup,
+	// introducing the new one to peers along with a rotation signed by the old one.
+	RotatePeerID bool
+
+	// PeerIDDifficulty is the minimum difficulty (see ecid.Difficulty) of peer IDs the server
+	// adds to its routing table and of the peer ID it creates for itself; zero value
+	// disables the requirement.
+	PeerIDDifficulty uint
+
+	// PeerIDCurve is the curve name (see ecid.CurveName and ecid.Ed25519CurveName) of the peer
+	// ID the server creates for itself, including when rotating it. It doesn't affect an
+	// existing stored peer ID.
+	PeerIDCurve string
+
+	// SessionKey is the optional session key the server signs requests with instead of its
+	// identity key, which can then be kept offline. The server uses the identity in
+	// SessionCertificate as its peer ID rather than the peer ID it stores.
+	SessionKey ecid.ID
+
+	// SessionCertificate links SessionKey to the server's identity key.
+	SessionCertificate *api.SessionCertificate
+
+	// KeySigner is the optional signer, usually backed by a PKCS #11 module, HSM, or OS
+	// keystore, the server signs requests with instead of a key in process memory. The server
+	// uses the signer's public key as its peer ID rather than the peer ID it stores.
+	KeySigner crypto.Signer
+
+	// QueryObserver is the optional observer notified of each Introduce, Find, and Store query
+	// the server makes to other peers.
+	QueryObserver client.QueryObserver
+
+	// ClientInterceptors are the optional client interceptors, e.g., for tracing or debug
+	// logging, installed on the server's connections to other peers.
+	ClientInterceptors *api.Interceptors
+
+	// Roles optionally restricts the requests each caller may make by its assigned roles; nil
+	// allows every caller to make every request except Admin requests, which always require the
+	// admin role.
+	Roles *Roles
+
+	// SharedSecret is the optional secret every peer and client in a fully trusted private
+	// cluster signs requests with, using cheaper HMAC signatures rather than ECDSA ones. Peers
+	// with the secret can sign requests as any other peer.
+	SharedSecret []byte
+
+	// Denylist contains document keys the server refuses to store or serve, in addition to
+	// those added via the Admin API.
+	Denylist []cid.ID
+
+	// AuditLog is whether the server records the requester, key, and outcome of each Store and
+	// Put request in its audit log.
+	AuditLog bool
+
+	// PublicationLog is whether the server records received publications in its publication
+	// log, so subscribers can replay them and admins can scan them.
+	PublicationLog bool
+
+	// RequireEntrySignatures is whether the server refuses to store Entry documents without an
+	// author signature; signatures present on entries are always verified.
+	RequireEntrySignatures bool
+
+	// AccessControl is whether the server only serves private documents, stored with an access
+	// hash, to requesters with an access proof from the matching reader key.
+	AccessControl bool
+
 	// LogLevel is the log level
 	LogLevel zapcore.Level
 }
@@ -90,10 +238,18 @@ func NewDefaultConfig() *Config {
 	config.WithDefaultBootstrapAddrs()
 	config.WithDefaultRouting()
 	config.WithDefaultIntroduce()
+	config.WithDefaultRebootstrap()
+	config.WithDefaultRateLimit()
+	config.WithDefaultConcurrency()
+	config.WithDefaultVerify()
+	config.WithDefaultPeerIDCurve()
 	config.WithDefaultSearch()
+	config.WithDefaultSearchCache()
 	config.WithDefaultStore()
+	config.WithDefaultReplicate()
 	config.WithDefaultSubscribeTo()
 	config.WithDefaultSubscribeFrom()
+	config.WithDefaultWebhook()
 	config.WithDefaultLogLevel()
 
 	return config
@@ -243,6 +399,68 @@ func (c *Config) WithDefaultIntroduce() *Config {
 	return c
 }
 
+// WithRebootstrap sets the rebootstrap parameters to the given value or the default if it is
+// nil.
+func (c *Config) WithRebootstrap(params *RebootstrapParameters) *Config {
+	if params == nil {
+		return c.WithDefaultRebootstrap()
+	}
+	c.Rebootstrap = params
+	return c
+}
+
+// WithDefaultRebootstrap sets the rebootstrap parameters to their default values.
+func (c *Config) WithDefaultRebootstrap() *Config {
+	c.Rebootstrap = NewDefaultRebootstrapParameters()
+	return c
+}
+
+// WithRateLimit sets the rate limit parameters to the given value or the default if it is nil.
+func (c *Config) WithRateLimit(params *RateLimitParameters) *Config {
+	if params == nil {
+		return c.WithDefaultRateLimit()
+	}
+	c.RateLimit = params
+	return c
+}
+
+// WithDefaultRateLimit sets the rate limit parameters to the default.
+func (c *Config) WithDefaultRateLimit() *Config {
+	c.RateLimit = NewDefaultRateLimitParameters()
+	return c
+}
+
+// WithConcurrency sets the concurrency parameters to the given value or the default if it is nil.
+func (c *Config) WithConcurrency(params *ConcurrencyParameters) *Config {
+	if params == nil {
+		return c.WithDefaultConcurrency()
+	}
+	c.Concurrency = params
+	return c
+}
+
+// WithDefaultConcurrency sets the concurrency parameters to the default.
+func (c *Config) WithDefaultConcurrency() *Config {
+	c.Concurrency = NewDefaultConcurrencyParameters()
+	return c
+}
+
+// WithVerify sets the signature verification parameters to the given value or the default if it
+// is nil.
+func (c *Config) WithVerify(params *client.VerifyParameters) *Config {
+	if params == nil {
+		return c.WithDefaultVerify()
+	}
+	c.Verify = params
+	return c
+}
+
+// WithDefaultVerify sets the signature verification parameters to the default.
+func (c *Config) WithDefaultVerify() *Config {
+	c.Verify = client.NewDefaultVerifyParameters()
+	return c
+}
+
 // WithSearch sets the search parameters to the given value or the default if it is nil.
 func (c *Config) WithSearch(params *search.Parameters) *Config {
 	if params == nil {
@@ -259,6 +477,23 @@ func (c *Config) WithDefaultSearch() *Config {
 	return c
 }
 
+// WithSearchCache sets the search cache parameters to the given value or the default if it is
+// nil.
+func (c *Config) WithSearchCache(params *search.CacheParameters) *Config {
+	if params == nil {
+		return c.WithDefaultSearchCache()
+	}
+	c.SearchCache = params
+	return c
+}
+
+// WithDefaultSearchCache sets the search cache parameters to their default values specified in
+// the search package.
+func (c *Config) WithDefaultSearchCache() *Config {
+	c.SearchCache = search.NewDefaultCacheParameters()
+	return c
+}
+
 // WithStore sets the store parameters to the given value or the default if it is nil.
 func (c *Config) WithStore(params *store.Parameters) *Config {
 	if params == nil {
@@ -275,6 +510,22 @@ func (c *Config) WithDefaultStore() *Config {
 	return c
 }
 
+// WithReplicate sets the replicate parameters to the given value or the default if it is nil.
+func (c *Config) WithReplicate(params *replicate.Parameters) *Config {
+	if params == nil {
+		return c.WithDefaultReplicate()
+	}
+	c.Replicate = params
+	return c
+}
+
+// WithDefaultReplicate sets the replicate parameters to their default values specified in the
+// replicate package.
+func (c *Config) WithDefaultReplicate() *Config {
+	c.Replicate = replicate.NewDefaultParameters()
+	return c
+}
+
 // WithSubscribeTo sets the subscription to parameters to the given value or the default it it is
 // nil.
 func (c *Config) WithSubscribeTo(params *subscribe.ToParameters) *Config {
@@ -307,6 +558,139 @@ func (c *Config) WithDefaultSubscribeFrom() *Config {
 	return c
 }
 
+// WithWebhook sets the webhook parameters to the given value or the default if it is nil.
+func (c *Config) WithWebhook(params *subscribe.WebhookParameters) *Config {
+	if params == nil {
+		return c.WithDefaultWebhook()
+	}
+	c.Webhook = params
+	return c
+}
+
+// WithDefaultWebhook sets the webhook parameters to the default.
+func (c *Config) WithDefaultWebhook() *Config {
+	c.Webhook = subscribe.NewDefaultWebhookParameters()
+	return c
+}
+
+// WithTLS sets the TLS certificate, key, and client CA files, where empty certificate and key
+// files serve plaintext.
+func (c *Config) WithTLS(certFile, keyFile, clientCAFile string) *Config {
+	c.TLSCertFile, c.TLSKeyFile, c.TLSClientCAFile = certFile, keyFile, clientCAFile
+	return c
+}
+
+// WithPeerTLS sets the CA file and server name of the TLS connections to peers.
+func (c *Config) WithPeerTLS(caFile, serverName string) *Config {
+	c.PeerTLSCAFile, c.PeerTLSServerName = caFile, serverName
+	return c
+}
+
+// WithInterceptors sets the unary and stream interceptors the server calls around each request.
+func (c *Config) WithInterceptors(unary []grpc.UnaryServerInterceptor,
+	stream []grpc.StreamServerInterceptor) *Config {
+	c.UnaryInterceptors, c.StreamInterceptors = unary, stream
+	return c
+}
+
+// WithRotatePeerID sets whether the server rotates its peer ID on startup.
+func (c *Config) WithRotatePeerID(rotate bool) *Config {
+	c.RotatePeerID = rotate
+	return c
+}
+
+// WithPeerIDDifficulty sets the minimum difficulty of peer IDs in the routing table.
+func (c *Config) WithPeerIDDifficulty(minBits uint) *Config {
+	c.PeerIDDifficulty = minBits
+	return c
+}
+
+// WithPeerIDCurve sets the curve name of created peer IDs to the given value or to the default if
+// the given value is empty.
+func (c *Config) WithPeerIDCurve(curveName string) *Config {
+	if curveName == "" {
+		return c.WithDefaultPeerIDCurve()
+	}
+	c.PeerIDCurve = curveName
+	return c
+}
+
+// WithDefaultPeerIDCurve sets the curve name of created peer IDs to the default value.
+func (c *Config) WithDefaultPeerIDCurve() *Config {
+	c.PeerIDCurve = ecid.CurveName
+	return c
+}
+
+// WithSession sets the session key the server signs requests with and the certificate linking
+// it to the server's identity key.
+func (c *Config) WithSession(key ecid.ID, cert *api.SessionCertificate) *Config {
+	c.SessionKey, c.SessionCertificate = key, cert
+	return c
+}
+
+// WithKeySigner sets the signer the server signs requests with instead of its stored peer ID key.
+func (c *Config) WithKeySigner(key crypto.Signer) *Config {
+	c.KeySigner = key
+	return c
+}
+
+// WithQueryObserver sets the observer notified of each query the server makes to other peers.
+func (c *Config) WithQueryObserver(obs client.QueryObserver) *Config {
+	c.QueryObserver = obs
+	return c
+}
+
+// WithClientInterceptors sets the client interceptors installed on the server's connections to
+// other peers.
+func (c *Config) WithClientInterceptors(interceptors *api.Interceptors) *Config {
+	c.ClientInterceptors = interceptors
+	return c
+}
+
+// WithRoles sets the roles restricting the requests each caller may make.
+func (c *Config) WithRoles(roles *Roles) *Config {
+	c.Roles = roles
+	return c
+}
+
+// WithSharedSecret sets the secret the server signs and verifies requests with instead of peer
+// keys.
+func (c *Config) WithSharedSecret(secret []byte) *Config {
+	c.SharedSecret = secret
+	return c
+}
+
+// WithDenylist sets the configured denylist keys.
+func (c *Config) WithDenylist(keys []cid.ID) *Config {
+	c.Denylist = keys
+	return c
+}
+
+// WithAuditLog sets whether the server records mutating requests in its audit log.
+func (c *Config) WithAuditLog(audit bool) *Config {
+	c.AuditLog = audit
+	return c
+}
+
+// WithPublicationLog sets whether the server records received publications in its publication
+// log.
+func (c *Config) WithPublicationLog(log bool) *Config {
+	c.PublicationLog = log
+	return c
+}
+
+// WithRequireEntrySignatures sets whether the server refuses to store unsigned entries.
+func (c *Config) WithRequireEntrySignatures(require bool) *Config {
+	c.RequireEntrySignatures = require
+	return c
+}
+
+// WithAccessControl sets whether the server restricts getting private documents.
+func (c *Config) WithAccessControl(accessControl bool) *Config {
+	c.AccessControl = accessControl
+	return c
+}
+
 // WithLogLevel sets the log level to the given value, though this doesn't have any direct effect
 // on the creation of the logger instance.
 func (c *Config) WithLogLevel(logLevel zapcore.Level) *Config {
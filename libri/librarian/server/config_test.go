@@ -1,11 +1,18 @@
 package server
 
 import (
+	"math/rand"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/common/subscribe"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/introduce"
+	"github.com/drausin/libri/libri/librarian/server/replicate"
 	"github.com/drausin/libri/libri/librarian/server/routing"
 	"github.com/drausin/libri/libri/librarian/server/search"
 	"github.com/drausin/libri/libri/librarian/server/store"
@@ -23,10 +30,18 @@ func TestDefaultConfig(t *testing.T) {
 	assert.NotEmpty(t, c.BootstrapAddrs)
 	assert.NotEmpty(t, c.Routing)
 	assert.NotEmpty(t, c.Introduce)
+	assert.NotEmpty(t, c.Rebootstrap)
+	assert.NotEmpty(t, c.RateLimit)
+	assert.NotEmpty(t, c.Concurrency)
+	assert.NotEmpty(t, c.Verify)
+	assert.NotEmpty(t, c.PeerIDCurve)
 	assert.NotEmpty(t, c.Search)
+	assert.NotEmpty(t, c.SearchCache)
 	assert.NotEmpty(t, c.Store)
+	assert.NotEmpty(t, c.Replicate)
 	assert.NotEmpty(t, c.SubscribeTo)
 	assert.NotEmpty(t, c.SubscribeFrom)
+	assert.NotEmpty(t, c.Webhook)
 	assert.NotEmpty(t, c.LogLevel)
 }
 
@@ -104,6 +119,46 @@ func TestConfig_WithIntroduce(t *testing.T) {
 	)
 }
 
+func TestConfig_WithRebootstrap(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultRebootstrap()
+	assert.Equal(t, c1.Rebootstrap, c2.WithRebootstrap(nil).Rebootstrap)
+	assert.NotEqual(t,
+		c1.Rebootstrap,
+		c3.WithRebootstrap(&RebootstrapParameters{MinPeers: 1}).Rebootstrap,
+	)
+}
+
+func TestConfig_WithRateLimit(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultRateLimit()
+	assert.Equal(t, c1.RateLimit, c2.WithRateLimit(nil).RateLimit)
+	assert.NotEqual(t,
+		c1.RateLimit,
+		c3.WithRateLimit(&RateLimitParameters{Burst: 1}).RateLimit,
+	)
+}
+
+func TestConfig_WithConcurrency(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultConcurrency()
+	assert.Equal(t, c1.Concurrency, c2.WithConcurrency(nil).Concurrency)
+	assert.NotEqual(t,
+		c1.Concurrency,
+		c3.WithConcurrency(&ConcurrencyParameters{MaxSubscriptions: 1}).Concurrency,
+	)
+}
+
+func TestConfig_WithVerify(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultVerify()
+	assert.Equal(t, c1.Verify, c2.WithVerify(nil).Verify)
+	assert.NotEqual(t,
+		c1.Verify,
+		c3.WithVerify(&client.VerifyParameters{MaxAge: time.Second}).Verify,
+	)
+}
+
 func TestConfig_WithSearch(t *testing.T) {
 	c1, c2, c3 := &Config{}, &Config{}, &Config{}
 	c1.WithDefaultSearch()
@@ -114,6 +169,16 @@ func TestConfig_WithSearch(t *testing.T) {
 	)
 }
 
+func TestConfig_WithSearchCache(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultSearchCache()
+	assert.Equal(t, c1.SearchCache, c2.WithSearchCache(nil).SearchCache)
+	assert.NotEqual(t,
+		c1.SearchCache,
+		c3.WithSearchCache(&search.CacheParameters{Size: 1}).SearchCache,
+	)
+}
+
 func TestConfig_WithStore(t *testing.T) {
 	c1, c2, c3 := &Config{}, &Config{}, &Config{}
 	c1.WithDefaultStore()
@@ -124,6 +189,16 @@ func TestConfig_WithStore(t *testing.T) {
 	)
 }
 
+func TestConfig_WithReplicate(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultReplicate()
+	assert.Equal(t, c1.Replicate, c2.WithReplicate(nil).Replicate)
+	assert.NotEqual(t,
+		c1.Replicate,
+		c3.WithReplicate(&replicate.Parameters{NSamples: 1}).Replicate,
+	)
+}
+
 func TestConfig_WithSubscribeTo(t *testing.T) {
 	c1, c2, c3 := &Config{}, &Config{}, &Config{}
 	c1.WithDefaultSubscribeTo()
@@ -144,6 +219,116 @@ func TestConfig_WithSubscribeFrom(t *testing.T) {
 	)
 }
 
+func TestConfig_WithWebhook(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultWebhook()
+	assert.Equal(t, c1.Webhook, c2.WithWebhook(nil).Webhook)
+	assert.NotEqual(t,
+		c1.Webhook,
+		c3.WithWebhook(&subscribe.WebhookParameters{Timeout: time.Second}).Webhook,
+	)
+}
+
+func TestConfig_WithTLS(t *testing.T) {
+	c := (&Config{}).WithTLS("cert.pem", "key.pem", "ca.pem")
+	assert.Equal(t, "cert.pem", c.TLSCertFile)
+	assert.Equal(t, "key.pem", c.TLSKeyFile)
+	assert.Equal(t, "ca.pem", c.TLSClientCAFile)
+}
+
+func TestConfig_WithPeerTLS(t *testing.T) {
+	c := (&Config{}).WithPeerTLS("ca.pem", "librarians.example.com")
+	assert.Equal(t, "ca.pem", c.PeerTLSCAFile)
+	assert.Equal(t, "librarians.example.com", c.PeerTLSServerName)
+}
+
+func TestConfig_WithRotatePeerID(t *testing.T) {
+	c := &Config{}
+	assert.False(t, c.RotatePeerID)
+	assert.True(t, c.WithRotatePeerID(true).RotatePeerID)
+}
+
+func TestConfig_WithPeerIDDifficulty(t *testing.T) {
+	c := &Config{}
+	assert.Zero(t, c.PeerIDDifficulty)
+	assert.Equal(t, uint(8), c.WithPeerIDDifficulty(8).PeerIDDifficulty)
+}
+
+func TestConfig_WithPeerIDCurve(t *testing.T) {
+	c1, c2, c3 := &Config{}, &Config{}, &Config{}
+	c1.WithDefaultPeerIDCurve()
+	assert.Equal(t, ecid.CurveName, c1.PeerIDCurve)
+	assert.Equal(t, c1.PeerIDCurve, c2.WithPeerIDCurve("").PeerIDCurve)
+	assert.Equal(t, ecid.Ed25519CurveName,
+		c3.WithPeerIDCurve(ecid.Ed25519CurveName).PeerIDCurve)
+}
+
+func TestConfig_WithSession(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	c := (&Config{}).WithSession(sessionID, cert)
+	assert.Equal(t, sessionID, c.SessionKey)
+	assert.Equal(t, cert, c.SessionCertificate)
+}
+
+func TestConfig_WithKeySigner(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := ecid.NewPseudoRandom(rng).Key()
+	assert.Equal(t, key, (&Config{}).WithKeySigner(key).KeySigner)
+}
+
+func TestConfig_WithQueryObserver(t *testing.T) {
+	obs := client.NewQueryMetrics()
+	assert.Equal(t, obs, (&Config{}).WithQueryObserver(obs).QueryObserver)
+}
+
+func TestConfig_WithClientInterceptors(t *testing.T) {
+	interceptors := &api.Interceptors{}
+	c := (&Config{}).WithClientInterceptors(interceptors)
+	assert.Equal(t, interceptors, c.ClientInterceptors)
+}
+
+func TestConfig_WithRoles(t *testing.T) {
+	roles := NewDefaultRoles()
+	assert.Equal(t, roles, (&Config{}).WithRoles(roles).Roles)
+}
+
+func TestConfig_WithSharedSecret(t *testing.T) {
+	secret := []byte("some shared secret")
+	assert.Equal(t, secret, (&Config{}).WithSharedSecret(secret).SharedSecret)
+}
+
+func TestConfig_WithDenylist(t *testing.T) {
+	keys := []cid.ID{cid.FromInt64(1), cid.FromInt64(2)}
+	assert.Equal(t, keys, (&Config{}).WithDenylist(keys).Denylist)
+}
+
+func TestConfig_WithAuditLog(t *testing.T) {
+	c := &Config{}
+	assert.False(t, c.AuditLog)
+	assert.True(t, c.WithAuditLog(true).AuditLog)
+}
+
+func TestConfig_WithPublicationLog(t *testing.T) {
+	c := &Config{}
+	assert.False(t, c.PublicationLog)
+	assert.True(t, c.WithPublicationLog(true).PublicationLog)
+}
+
+func TestConfig_WithRequireEntrySignatures(t *testing.T) {
+	c := &Config{}
+	assert.False(t, c.RequireEntrySignatures)
+	assert.True(t, c.WithRequireEntrySignatures(true).RequireEntrySignatures)
+}
+
+func TestConfig_WithAccessControl(t *testing.T) {
+	c := &Config{}
+	assert.False(t, c.AccessControl)
+	assert.True(t, c.WithAccessControl(true).AccessControl)
+}
+
 func TestConfig_WithLogLevel(t *testing.T) {
 	c1, c2, c3 := &Config{}, &Config{}, &Config{}
 	c1.WithDefaultLogLevel()
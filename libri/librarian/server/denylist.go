@@ -0,0 +1,102 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+)
+
+// ErrDeniedKey indicates when a request is refused because its key is on the denylist.
+var ErrDeniedKey = errors.New("document key denied by operator")
+
+// Denylist is a set of document keys the librarian refuses to store or serve, which lets
+// operators comply with takedown obligations on content they replicate.
+type Denylist interface {
+	// Contains returns whether the key is denied.
+	Contains(key cid.ID) bool
+
+	// Add adds the keys to the denylist.
+	Add(keys ...cid.ID)
+
+	// Remove removes the keys from the denylist.
+	Remove(keys ...cid.ID)
+
+	// Keys returns the denied keys in sorted order.
+	Keys() []cid.ID
+
+	// ToStored returns a storage.Denylist version of the denylist.
+	ToStored() *storage.Denylist
+}
+
+type denylist struct {
+	keys map[string]cid.ID
+	mu   sync.RWMutex
+}
+
+// NewDenylist creates a new Denylist with the given keys.
+func NewDenylist(keys ...cid.ID) Denylist {
+	dl := &denylist{keys: make(map[string]cid.ID)}
+	dl.Add(keys...)
+	return dl
+}
+
+// FromStoredDenylist creates a new Denylist from a storage.Denylist.
+func FromStoredDenylist(stored *storage.Denylist) Denylist {
+	keys := make([]cid.ID, len(stored.Keys))
+	for i, key := range stored.Keys {
+		keys[i] = cid.FromBytes(key)
+	}
+	return NewDenylist(keys...)
+}
+
+func (dl *denylist) Contains(key cid.ID) bool {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+	_, in := dl.keys[key.String()]
+	return in
+}
+
+func (dl *denylist) Add(keys ...cid.ID) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	for _, key := range keys {
+		dl.keys[key.String()] = key
+	}
+}
+
+func (dl *denylist) Remove(keys ...cid.ID) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	for _, key := range keys {
+		delete(dl.keys, key.String())
+	}
+}
+
+func (dl *denylist) Keys() []cid.ID {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+
+	// fixed-length hex strings sort in the same order as their keys
+	strs := make([]string, 0, len(dl.keys))
+	for str := range dl.keys {
+		strs = append(strs, str)
+	}
+	sort.Strings(strs)
+	keys := make([]cid.ID, len(strs))
+	for i, str := range strs {
+		keys[i] = dl.keys[str]
+	}
+	return keys
+}
+
+func (dl *denylist) ToStored() *storage.Denylist {
+	keys := dl.Keys()
+	stored := &storage.Denylist{Keys: make([][]byte, len(keys))}
+	for i, key := range keys {
+		stored.Keys[i] = key.Bytes()
+	}
+	return stored
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"math/rand"
+	"testing"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDenylist(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key1, key2, key3 := cid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng),
+		cid.NewPseudoRandom(rng)
+
+	dl := NewDenylist(key1)
+	assert.True(t, dl.Contains(key1))
+	assert.False(t, dl.Contains(key2))
+
+	dl.Add(key2, key3)
+	assert.True(t, dl.Contains(key2))
+	assert.True(t, dl.Contains(key3))
+
+	// check keys are sorted
+	keys := dl.Keys()
+	assert.Len(t, keys, 3)
+	for i := 1; i < len(keys); i++ {
+		assert.True(t, keys[i-1].Cmp(keys[i]) < 0)
+	}
+
+	dl.Remove(key1, key3)
+	assert.False(t, dl.Contains(key1))
+	assert.True(t, dl.Contains(key2))
+	assert.Equal(t, []cid.ID{key2}, dl.Keys())
+
+	// check adding existing and removing missing keys are no-ops
+	dl.Add(key2)
+	dl.Remove(key1)
+	assert.Equal(t, []cid.ID{key2}, dl.Keys())
+}
+
+func TestDenylist_ToStored_FromStored(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	dl1 := NewDenylist(cid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng))
+	stored := dl1.ToStored()
+	assert.Len(t, stored.Keys, 2)
+
+	dl2 := FromStoredDenylist(stored)
+	assert.Equal(t, dl1.Keys(), dl2.Keys())
+}
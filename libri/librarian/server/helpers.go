@@ -1,21 +1,26 @@
 package server
 
 import (
+	"bytes"
+
 	"github.com/drausin/libri/libri/common/ecid"
 	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
 	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
 	"golang.org/x/net/context"
 )
 
-// newStubPeerFromPublicKeyBytes creates a new stub peer with an ID coming from an ECDSA public key.
+// newIDFromPublicKeyBytes creates a new ID coming from an ECDSA or Ed25519 public key.
 func newIDFromPublicKeyBytes(pubKeyBytes []byte) (cid.ID, error) {
-	pubKey, err := ecid.FromPublicKeyBytes(pubKeyBytes)
+	pubID, err := ecid.FromAnyPublicKeyBytes(pubKeyBytes)
 	if err != nil {
 		return nil, err
 	}
-	return cid.FromPublicKey(pubKey), nil
+	return pubID.ID(), nil
 }
 
 // NewResponseMetadata creates a new api.ResponseMatadata object with the same RequestID as that
@@ -27,8 +32,8 @@ func (l *Librarian) NewResponseMetadata(m *api.RequestMetadata) *api.ResponseMet
 	}
 }
 
-// checkRequest verifies the request signature and records an error with the peer if necessary. It
-// returns the ID of the requester or an error.
+// checkRequest verifies the request signature and the requester's role and records an error with
+// the peer if necessary. It returns the ID of the requester or an error.
 func (l *Librarian) checkRequest(ctx context.Context, rq proto.Message, meta *api.RequestMetadata) (
 	cid.ID, error) {
 	requesterID, err := newIDFromPublicKeyBytes(meta.PubKey)
@@ -41,9 +46,35 @@ func (l *Librarian) checkRequest(ctx context.Context, rq proto.Message, meta *ap
 		l.record(requesterID, peer.Request, peer.Error)
 		return nil, err
 	}
+	if err := l.checkRole(ctx, rq, meta); err != nil {
+		return nil, err
+	}
+
+	// only rate limit verified requests, so peers can't use up others' limits
+	if !l.rateLimiter.Allow(requesterID) {
+		l.record(requesterID, peer.Request, peer.Error)
+		l.evictIfAbusive(requesterID)
+		return nil, ErrRequestRateExceeded
+	}
 	return requesterID, nil
 }
 
+// checkPeerIDDifficulty checks that the peer ID has at least the difficulty the server requires
+// of peers in its routing table.
+func (l *Librarian) checkPeerIDDifficulty(peerID cid.ID) error {
+	if ecid.Difficulty(peerID) < l.config.PeerIDDifficulty {
+		return ErrInsufficientPeerIDDifficulty
+	}
+	return nil
+}
+
+// pushPeer adds the peer to the routing table if its ID has the difficulty the server requires.
+func (l *Librarian) pushPeer(p peer.Peer) {
+	if l.checkPeerIDDifficulty(p.ID()) == nil {
+		l.rt.Push(p)
+	}
+}
+
 // checkRequestAndKey verifies the request signature and key, recording errors with the peer if
 // necessary. It returns the ID of the requester or an error.
 func (l *Librarian) checkRequestAndKey(ctx context.Context, rq proto.Message,
@@ -59,25 +90,86 @@ func (l *Librarian) checkRequestAndKey(ctx context.Context, rq proto.Message,
 	return requester, nil
 }
 
-// checkRequestAndKey verifies the request signature and key/value combo, recording errors with
-// the peer if necessary. It returns the ID of the requester or an error.
+// checkRequestAndKeyValue verifies the request signature, key/value combo, entry author signature,
+// and proof of work with at least powBits leading zero bits, recording errors with the peer if
+// necessary. It returns the ID of the requester or an error.
 func (l *Librarian) checkRequestAndKeyValue(ctx context.Context, rq proto.Message,
-	meta *api.RequestMetadata, key []byte, value *api.Document) (cid.ID, error) {
+	meta *api.RequestMetadata, key []byte, value *api.Document, powBits uint) (cid.ID, error) {
 	requester, err := l.checkRequest(ctx, rq, meta)
 	if err != nil {
 		return nil, err
 	}
-	valueBytes, err := proto.Marshal(value)
-	if err != nil {
+	if err := api.CheckProofOfWork(key, meta.ProofOfWorkNonce, powBits); err != nil {
+		l.record(requester, peer.Request, peer.Error)
 		return nil, err
 	}
-	if err := l.kvc.Check(key, valueBytes); err != nil {
+	if err := l.checkKeyValue(key, value); err != nil {
+		l.record(requester, peer.Request, peer.Error)
+		return nil, err
+	}
+	if err := l.checkAuthorSignature(value); err != nil {
 		l.record(requester, peer.Request, peer.Error)
 		return nil, err
 	}
 	return requester, nil
 }
 
+// checkKeyValue checks that the key is the hash of the value or, for a Tombstone, the key of the
+// document it deletes.
+func (l *Librarian) checkKeyValue(key []byte, value *api.Document) error {
+	if tombstone := value.GetTombstone(); tombstone != nil {
+		if !bytes.Equal(key, tombstone.DocumentKey) {
+			return api.ErrUnexpectedKey
+		}
+		return nil
+	}
+	valueBytes, err := proto.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return l.kvc.Check(key, valueBytes)
+}
+
+// checkAuthorSignature verifies that an Entry or Tombstone document was signed by its author.
+// Unsigned entries are only allowed when the server doesn't require entry signatures, but
+// tombstones must always be signed.
+func (l *Librarian) checkAuthorSignature(value *api.Document) error {
+	if tombstone := value.GetTombstone(); tombstone != nil {
+		return client.VerifyTombstone(tombstone)
+	}
+	entry := value.GetEntry()
+	if entry == nil {
+		return nil
+	}
+	if entry.AuthorSignature == "" && !l.config.RequireEntrySignatures {
+		return nil
+	}
+	return client.VerifyEntrySignature(entry)
+}
+
+// checkTombstone checks that storing the value doesn't undo a tombstone for the key: a document may
+// not replace a tombstone by its author, and a tombstone may only replace a document (or another
+// tombstone) by the same author.
+func (l *Librarian) checkTombstone(key cid.ID, value *api.Document) error {
+	existing, err := l.documentSL.Load(key)
+	if err != nil || existing == nil {
+		return err
+	}
+	existingTombstone := existing.GetTombstone()
+	if value.GetTombstone() == nil {
+		if existingTombstone != nil &&
+			bytes.Equal(api.GetAuthorPub(value), existingTombstone.AuthorPublicKey) {
+			return ErrDeletedDocument
+		}
+		// any other tombstone wasn't by the document's author, so the document replaces it
+		return nil
+	}
+	if !bytes.Equal(api.GetAuthorPub(existing), api.GetAuthorPub(value)) {
+		return ErrUnexpectedTombstoneAuthor
+	}
+	return nil
+}
+
 // record records query outcome for a particular peer if that peer is in the routing table.
 func (l *Librarian) record(fromPeerID cid.ID, t peer.QueryType, o peer.Outcome) {
 	if existing := l.rt.Get(fromPeerID); existing != nil {
@@ -89,3 +181,148 @@ func (l *Librarian) record(fromPeerID cid.ID, t peer.QueryType, o peer.Outcome)
 		l.rt.Push(existing)
 	}
 }
+
+// audit records the outcome of a mutating request in the audit log, if it is enabled.
+func (l *Librarian) audit(rpc string, meta *api.RequestMetadata, key []byte, err error) {
+	if l.auditLog == nil {
+		return
+	}
+	record := &storage.AuditRecord{
+		Rpc: rpc,
+		Key: key,
+	}
+	if meta != nil {
+		record.RequesterPublicKey = meta.PubKey
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := l.auditLog.Append(record); err != nil {
+		l.logger.Error("unable to append to audit log", zap.Error(err))
+	}
+}
+
+// acquire reserves a slot with the limiter for a request from the peer, returning the function to
+// release it or errAtCap if no slot is available. A nil limiter has unlimited slots.
+func acquire(limiter ConcurrencyLimiter, peerID cid.ID, errAtCap error) (func(), error) {
+	if limiter == nil {
+		return func() {}, nil
+	}
+	if !limiter.Acquire(peerID) {
+		return nil, errAtCap
+	}
+	return func() { limiter.Release(peerID) }, nil
+}
+
+// denied returns whether the key is on the denylist.
+func (l *Librarian) denied(key cid.ID) bool {
+	return l.denylist != nil && l.denylist.Contains(key)
+}
+
+// checkAccess verifies that the requester may get the value for the key, which requires an access
+// proof from the reader key if access control is enabled and the value is private.
+func (l *Librarian) checkAccess(key cid.ID, proof *api.AccessProof) error {
+	if l.accessSL == nil {
+		return nil
+	}
+	accessHash, err := l.accessSL.Load(key.Bytes())
+	if err != nil {
+		return err
+	}
+	if accessHash == nil {
+		// public value
+		return nil
+	}
+	return client.VerifyAccessProof(proof, key, accessHash)
+}
+
+// storeAccessHash stores the access hash of a private value if access control is enabled. It
+// leaves any existing access hash unchanged, so a private value can't be made public (or given
+// another reader) by storing it again.
+func (l *Librarian) storeAccessHash(key cid.ID, accessHash []byte) error {
+	if l.accessSL == nil || len(accessHash) == 0 {
+		return nil
+	}
+	existing, err := l.accessSL.Load(key.Bytes())
+	if err != nil || existing != nil {
+		return err
+	}
+	return l.accessSL.Store(key.Bytes(), accessHash)
+}
+
+// validateAccessHash checks that the access hash is either empty or has the expected length.
+func validateAccessHash(accessHash []byte) error {
+	if len(accessHash) == 0 {
+		return nil
+	}
+	return api.ValidateBytes(accessHash, storage.AccessHashLength, "AccessHash")
+}
+
+// evictIfAbusive removes a peer from the routing table if their request error rate is at least
+// the configured eviction error rate.
+func (l *Librarian) evictIfAbusive(peerID cid.ID) {
+	evictErrorRate := l.config.RateLimit.EvictErrorRate
+	existing := l.rt.Get(peerID)
+	if evictErrorRate <= 0 || existing == nil ||
+		existing.Recorder().ErrorRate(peer.Request) < evictErrorRate {
+		return
+	}
+	if removed := l.rt.Remove(peerID); removed != nil {
+		l.logger.Info("evicted peer exceeding request rate limit",
+			zap.Stringer(LoggerPeerID, peerID),
+			zap.Float64("request_error_rate", removed.Recorder().ErrorRate(peer.Request)),
+		)
+		if err := removed.Connector().Disconnect(); err != nil {
+			l.logger.Error("unable to disconnect from evicted peer", zap.Error(err))
+		}
+	}
+}
+
+// acceptRotation verifies that a rotation links the requester's new key to its old one and, if so,
+// replaces the old peer in the routing table with the requester, keeping its query outcomes.
+func (l *Librarian) acceptRotation(
+	rotation *api.KeyRotation, meta *api.RequestMetadata, requester peer.Peer,
+) error {
+	if err := client.VerifyKeyRotation(rotation); err != nil {
+		return err
+	}
+	if !bytes.Equal(rotation.NewPubKey, meta.PubKey) {
+		return ErrUnexpectedRotationKey
+	}
+	oldID, err := newIDFromPublicKeyBytes(rotation.OldPubKey)
+	if err != nil {
+		return err
+	}
+	if old := l.rt.Remove(oldID); old != nil {
+		requester.Recorder().Merge(old.Recorder())
+		l.logger.Info("replaced rotated peer",
+			zap.Stringer("old_"+LoggerPeerID, oldID),
+			zap.Stringer(LoggerPeerID, requester.ID()),
+		)
+		if err := old.Connector().Disconnect(); err != nil {
+			l.logger.Error("unable to disconnect from rotated peer", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// peakSeeds returns up to n peers from the routing table closest to the key, excluding this
+// librarian and the requester, which would otherwise waste queries in a search.
+func (l *Librarian) peakSeeds(key cid.ID, n uint, requesterID cid.ID) []peer.Peer {
+	// peak a few extra peers to make up for ones we exclude
+	candidates := l.rt.Peak(key, n+2)
+	seeds := make([]peer.Peer, 0, n)
+	for _, p := range candidates {
+		if uint(len(seeds)) == n {
+			break
+		}
+		if p.ID().Cmp(l.selfID.ID()) == 0 {
+			continue
+		}
+		if requesterID != nil && p.ID().Cmp(requesterID) == 0 {
+			continue
+		}
+		seeds = append(seeds, p)
+	}
+	return seeds
+}
@@ -6,11 +6,14 @@ import (
 
 	"errors"
 
+	"github.com/drausin/libri/libri/common/db"
 	"github.com/drausin/libri/libri/common/ecid"
 	cid "github.com/drausin/libri/libri/common/id"
+	clogging "github.com/drausin/libri/libri/common/logging"
 	"github.com/drausin/libri/libri/common/storage"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/drausin/libri/libri/librarian/server/routing"
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
@@ -34,7 +37,7 @@ func TestNewIDFromPublicKeyBytes_err(t *testing.T) {
 
 func TestCheckRequest_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
-	l := &Librarian{rqv: &alwaysRequestVerifier{}}
+	l := &Librarian{rqv: &alwaysRequestVerifier{}, rateLimiter: &neverRateLimiter{}}
 	selfID := ecid.NewPseudoRandom(rng)
 	rq := client.NewGetRequest(selfID, cid.NewPseudoRandom(rng))
 	requesterID, err := l.checkRequest(nil, rq, rq.Metadata)
@@ -67,8 +70,9 @@ func TestCheckRequest_verifyErr(t *testing.T) {
 	selfID := ecid.NewPseudoRandom(rng)
 	rq := client.NewGetRequest(selfID, cid.NewPseudoRandom(rng))
 	l := &Librarian{
-		rqv: &neverRequestVerifier{},
-		rt:  routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &neverRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
 	}
 	requesterID, err := l.checkRequest(nil, rq, rq.Metadata)
 
@@ -76,13 +80,85 @@ func TestCheckRequest_verifyErr(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestCheckRequest_rateLimitErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID, requester := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	rt := routing.NewEmpty(selfID, routing.NewDefaultParameters())
+	rt.Push(peer.New(requester.ID(), "requester", peer.NewTestConnector(1)))
+	config := NewDefaultConfig()
+	config.RateLimit.Burst = 2
+	rl, err := NewPeerRateLimiter(config.RateLimit)
+	assert.Nil(t, err)
+	l := &Librarian{
+		config:      config,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: rl,
+		rt:          rt,
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	rq := client.NewGetRequest(requester, cid.NewPseudoRandom(rng))
+	for c := uint(0); c < config.RateLimit.Burst; c++ {
+		requesterID, err := l.checkRequest(nil, rq, rq.Metadata)
+		assert.Nil(t, err)
+		l.record(requesterID, peer.Request, peer.Success)
+	}
+
+	// check requests over the limit are rejected and recorded as errors
+	requesterID, err := l.checkRequest(nil, rq, rq.Metadata)
+	assert.Equal(t, ErrRequestRateExceeded, err)
+	assert.Nil(t, requesterID)
+	existing := rt.Get(requester.ID())
+	assert.NotNil(t, existing)
+	assert.Equal(t, 1.0/3.0, existing.Recorder().ErrorRate(peer.Request))
+
+	// check requester is evicted once their error rate reaches the eviction rate
+	requesterID, err = l.checkRequest(nil, rq, rq.Metadata)
+	assert.Equal(t, ErrRequestRateExceeded, err)
+	assert.Nil(t, requesterID)
+	assert.Nil(t, rt.Get(requester.ID()))
+	assert.Equal(t, 0, rt.NumPeers())
+}
+
+func TestLibrarian_evictIfAbusive_disabled(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID, peerID := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+	rt := routing.NewEmpty(selfID, routing.NewDefaultParameters())
+	rt.Push(peer.New(peerID, "requester", peer.NewTestConnector(1)))
+	config := NewDefaultConfig()
+	config.RateLimit.EvictErrorRate = 0
+	l := &Librarian{config: config, rt: rt, logger: clogging.NewDevInfoLogger()}
+	l.record(peerID, peer.Request, peer.Error)
+
+	l.evictIfAbusive(peerID)
+	assert.NotNil(t, rt.Get(peerID))
+
+	// check peers not in the routing table are ignored
+	l.evictIfAbusive(cid.NewPseudoRandom(rng))
+	assert.Equal(t, 1, rt.NumPeers())
+}
+
+func TestLibrarian_checkPeerIDDifficulty(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := &Librarian{config: &Config{}}
+	assert.Nil(t, l.checkPeerIDDifficulty(ecid.NewPseudoRandom(rng)))
+
+	l.config.PeerIDDifficulty = 8
+	peerID := ecid.NewPseudoRandomWithDifficulty(rng, 8)
+	assert.Nil(t, l.checkPeerIDDifficulty(peerID))
+	for ecid.Difficulty(peerID) >= 8 {
+		peerID = ecid.NewPseudoRandom(rng)
+	}
+	assert.Equal(t, ErrInsufficientPeerIDDifficulty, l.checkPeerIDDifficulty(peerID))
+}
+
 func TestCheckRequestAndKey_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	selfID, key := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
 	l := &Librarian{
-		rqv: &alwaysRequestVerifier{},
-		kc:  storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		rt:  routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
 	}
 	rq := client.NewGetRequest(selfID, key)
 	requesterID, err := l.checkRequestAndKey(nil, rq, rq.Metadata, key.Bytes())
@@ -108,9 +184,10 @@ func TestCheckRequestAndKey_checkErr(t *testing.T) {
 	selfID, key := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
 	rq := client.NewGetRequest(selfID, key)
 	l := &Librarian{
-		rqv: &alwaysRequestVerifier{},
-		kc:  storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		rt:  routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
 	}
 	requesterID, err := l.checkRequestAndKey(nil, rq, rq.Metadata, []byte("bad key"))
 
@@ -123,13 +200,15 @@ func TestCheckRequestAndKeyValue_ok(t *testing.T) {
 	selfID := ecid.NewPseudoRandom(rng)
 	value, key := api.NewTestDocument(rng)
 	l := &Librarian{
-		rt:  routing.NewEmpty(selfID, routing.NewDefaultParameters()),
-		rqv: &alwaysRequestVerifier{},
-		kc:  storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		kvc: storage.NewHashKeyValueChecker(),
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		config:      NewDefaultConfig(),
 	}
 	rq := client.NewGetRequest(selfID, key)
-	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value)
+	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value, 0)
 
 	assert.Nil(t, err)
 	assert.Equal(t, selfID.ID(), requesterID)
@@ -142,7 +221,7 @@ func TestCheckRequestAndKeyValue_checkRequestErr(t *testing.T) {
 	rq := client.NewGetRequest(selfID, key)
 	rq.Metadata.PubKey = []byte("bad pub key")
 	l := &Librarian{}
-	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value)
+	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value, 0)
 
 	assert.Nil(t, requesterID)
 	assert.NotNil(t, err)
@@ -155,13 +234,228 @@ func TestCheckRequestAndKeyValue_checkErr(t *testing.T) {
 	key := cid.NewPseudoRandom(rng) // bad key, not hash of value
 	rq := client.NewGetRequest(selfID, key)
 	l := &Librarian{
-		rt:  routing.NewEmpty(selfID, routing.NewDefaultParameters()),
-		rqv: &alwaysRequestVerifier{},
-		kc:  storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		kvc: storage.NewHashKeyValueChecker(),
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
 	}
-	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value)
+	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value, 0)
 
 	assert.Nil(t, requesterID)
 	assert.NotNil(t, err)
 }
+
+func TestCheckRequestAndKeyValue_proofOfWork(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	l := &Librarian{
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		kvc:         storage.NewHashKeyValueChecker(),
+		config:      NewDefaultConfig(),
+	}
+	powBits := uint(8)
+	rq := client.NewStoreRequest(selfID, key, value)
+	rq.Metadata.ProofOfWorkNonce = api.FindProofOfWork(key.Bytes(), powBits)
+	requesterID, err := l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value,
+		powBits)
+	assert.Nil(t, err)
+	assert.Equal(t, selfID.ID(), requesterID)
+
+	// check insufficient proof of work throws error
+	requesterID, err = l.checkRequestAndKeyValue(nil, rq, rq.Metadata, key.Bytes(), value,
+		api.ProofOfWorkBits(key.Bytes(), rq.Metadata.ProofOfWorkNonce)+1)
+	assert.Equal(t, api.ErrInsufficientProofOfWork, err)
+	assert.Nil(t, requesterID)
+}
+
+func TestLibrarian_checkAuthorSignature(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	authorID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	l := &Librarian{config: NewDefaultConfig()}
+
+	// check non-entry documents aren't checked
+	envelope := &api.Document{Contents: &api.Document_Envelope{
+		Envelope: api.NewTestEnvelope(rng),
+	}}
+	assert.Nil(t, l.checkAuthorSignature(envelope))
+
+	// check unsigned entries allowed unless required
+	unsigned, _ := api.NewTestDocument(rng)
+	assert.Nil(t, l.checkAuthorSignature(unsigned))
+	l.config.WithRequireEntrySignatures(true)
+	assert.Equal(t, client.ErrMissingAuthorSignature, l.checkAuthorSignature(unsigned))
+
+	// check signed entry ok
+	entry := api.NewTestSinglePageEntry(rng)
+	entry.AuthorPublicKey = ecid.ToPublicKeyBytes(authorID)
+	assert.Nil(t, client.SignEntry(authorID, entry))
+	signed := &api.Document{Contents: &api.Document_Entry{Entry: entry}}
+	assert.Nil(t, l.checkAuthorSignature(signed))
+
+	// check entry with forged author always errors
+	entry.AuthorPublicKey = ecid.ToPublicKeyBytes(otherID)
+	l.config.WithRequireEntrySignatures(false)
+	assert.NotNil(t, l.checkAuthorSignature(signed))
+
+	// check tombstones always verified
+	tombstone, err := client.NewTombstone(authorID, cid.NewPseudoRandom(rng))
+	assert.Nil(t, err)
+	tombstoneDoc := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	assert.Nil(t, l.checkAuthorSignature(tombstoneDoc))
+	tombstone.AuthorSignature = ""
+	assert.Equal(t, client.ErrMissingAuthorSignature, l.checkAuthorSignature(tombstoneDoc))
+}
+
+func TestLibrarian_checkKeyValue(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := &Librarian{kvc: storage.NewHashKeyValueChecker()}
+	value, key := api.NewTestDocument(rng)
+	assert.Nil(t, l.checkKeyValue(key.Bytes(), value))
+	assert.NotNil(t, l.checkKeyValue(cid.NewPseudoRandom(rng).Bytes(), value))
+
+	// check tombstone key is that of the document it deletes
+	tombstone := api.NewTestTombstone(rng)
+	tombstone.DocumentKey = key.Bytes()
+	tombstoneDoc := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	assert.Nil(t, l.checkKeyValue(key.Bytes(), tombstoneDoc))
+	err := l.checkKeyValue(cid.NewPseudoRandom(rng).Bytes(), tombstoneDoc)
+	assert.Equal(t, api.ErrUnexpectedKey, err)
+}
+
+func TestLibrarian_checkTombstone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	l := &Librarian{documentSL: storage.NewDocumentKVDBStorerLoader(kvdb)}
+	authorID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	entry := api.NewTestMultiPageEntry(rng)
+	entry.AuthorPublicKey = ecid.ToPublicKeyBytes(authorID)
+	value := &api.Document{Contents: &api.Document_Entry{Entry: entry}}
+	key, err := api.GetKey(value)
+	assert.Nil(t, err)
+	newTombstoneDoc := func(id ecid.ID) *api.Document {
+		tombstone, err := client.NewTombstone(id, key)
+		assert.Nil(t, err)
+		return &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	}
+
+	// check anything may be stored when nothing is
+	assert.Nil(t, l.checkTombstone(key, value))
+	assert.Nil(t, l.checkTombstone(key, newTombstoneDoc(otherID)))
+
+	// check only the author's tombstone may replace the document
+	assert.Nil(t, l.documentSL.Store(key, value))
+	assert.Equal(t, ErrUnexpectedTombstoneAuthor, l.checkTombstone(key, newTombstoneDoc(otherID)))
+	assert.Nil(t, l.checkTombstone(key, newTombstoneDoc(authorID)))
+
+	// check document may not replace the author's tombstone
+	assert.Nil(t, l.documentSL.Store(key, newTombstoneDoc(authorID)))
+	assert.Equal(t, ErrDeletedDocument, l.checkTombstone(key, value))
+	assert.Equal(t, ErrUnexpectedTombstoneAuthor, l.checkTombstone(key, newTombstoneDoc(otherID)))
+	assert.Nil(t, l.checkTombstone(key, newTombstoneDoc(authorID)))
+
+	// check document replaces another author's tombstone
+	assert.Nil(t, l.documentSL.Store(key, newTombstoneDoc(otherID)))
+	assert.Nil(t, l.checkTombstone(key, value))
+
+	// check load error bubbles up
+	l.documentSL = &errDocStorerLoader{}
+	assert.NotNil(t, l.checkTombstone(key, value))
+}
+
+func TestLibrarian_checkAccess_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	key := cid.NewPseudoRandom(rng)
+
+	// check access always allowed when disabled
+	l := &Librarian{}
+	assert.Nil(t, l.checkAccess(key, nil))
+
+	// check load error bubbles up
+	l.accessSL = &fixedStorerLoader{loadErr: errors.New("some Load error")}
+	assert.NotNil(t, l.checkAccess(key, nil))
+	assert.NotNil(t, l.storeAccessHash(key, api.RandBytes(rng, storage.AccessHashLength)))
+}
+
+func TestAcquire(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := cid.NewPseudoRandom(rng)
+	errAtCap := errors.New("some cap error")
+
+	// check nil limiter is unlimited
+	release, err := acquire(nil, peerID, errAtCap)
+	assert.Nil(t, err)
+	release()
+
+	limiter := NewConcurrencyLimiter(1, 0)
+	release, err = acquire(limiter, peerID, errAtCap)
+	assert.Nil(t, err)
+	_, err = acquire(limiter, peerID, errAtCap)
+	assert.Equal(t, errAtCap, err)
+	release()
+	_, err = acquire(limiter, peerID, errAtCap)
+	assert.Nil(t, err)
+}
+
+func TestLibrarian_peakSeeds(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	n := 16
+	rt, selfID, _ := routing.NewTestWithPeers(rng, n)
+	key := cid.NewPseudoRandom(rng)
+	l := &Librarian{selfID: selfID, rt: rt}
+
+	// w/o self or requester in the routing table, seeds are just the closest peers
+	nSeeds := uint(3)
+	closest := rt.Peak(key, nSeeds)
+	requesterID := cid.NewPseudoRandom(rng)
+	assert.Equal(t, closest, l.peakSeeds(key, nSeeds, requesterID))
+
+	// add (stale) entries for self and requester, which should never be seeds
+	closestID := closest[0].ID()
+	rt.Push(peer.New(selfID.ID(), "self", nil))
+	rt.Push(peer.New(requesterID, "requester", nil))
+	seeds := l.peakSeeds(key, nSeeds, requesterID)
+	assert.Equal(t, int(nSeeds), len(seeds))
+	for _, seed := range seeds {
+		assert.NotEqual(t, selfID.ID(), seed.ID())
+		assert.NotEqual(t, requesterID, seed.ID())
+	}
+	assert.Equal(t, closestID, seeds[0].ID())
+
+	// nil requester ID only excludes self
+	seeds = l.peakSeeds(key, uint(n+2), nil)
+	for _, seed := range seeds {
+		assert.NotEqual(t, selfID.ID(), seed.ID())
+	}
+}
+
+func TestLibrarian_audit(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	meta := client.NewRequestMetadata(ecid.NewPseudoRandom(rng))
+	key := api.RandBytes(rng, cid.Length)
+
+	// check no-op without audit log
+	l := &Librarian{logger: clogging.NewDevInfoLogger()}
+	l.audit(AuditPut, meta, key, nil)
+
+	// check error recorded
+	al := &fixedAuditLog{}
+	l.auditLog = al
+	l.audit(AuditPut, meta, key, errors.New("some Put error"))
+	assert.Len(t, al.appended, 1)
+	assert.Equal(t, AuditPut, al.appended[0].Rpc)
+	assert.Equal(t, meta.PubKey, al.appended[0].RequesterPublicKey)
+	assert.Equal(t, "some Put error", al.appended[0].Error)
+
+	// check append error is only logged
+	al.err = errors.New("some Append error")
+	l.audit(AuditPut, nil, key, nil)
+	assert.Len(t, al.appended, 2)
+	assert.Nil(t, al.appended[1].RequesterPublicKey)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// interceptorOptions returns the options to create the grpc.Server with for the configured unary
+// and stream interceptors. It returns no options when neither is configured.
+func (c *Config) interceptorOptions() []grpc.ServerOption {
+	opts := make([]grpc.ServerOption, 0, 2)
+	if len(c.UnaryInterceptors) > 0 {
+		opts = append(opts, grpc.UnaryInterceptor(chainUnaryInterceptors(c.UnaryInterceptors)))
+	}
+	if len(c.StreamInterceptors) > 0 {
+		opts = append(opts,
+			grpc.StreamInterceptor(chainStreamInterceptors(c.StreamInterceptors)))
+	}
+	return opts
+}
+
+// chainUnaryInterceptors returns a single interceptor calling each of the given interceptors in
+// order, so the first is the outermost, before the handler.
+func chainUnaryInterceptors(is []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, rq interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		chained := handler
+		for i := len(is) - 1; i >= 0; i-- {
+			chained = wrapUnaryHandler(is[i], info, chained)
+		}
+		return chained(ctx, rq)
+	}
+}
+
+func wrapUnaryHandler(i grpc.UnaryServerInterceptor, info *grpc.UnaryServerInfo,
+	next grpc.UnaryHandler) grpc.UnaryHandler {
+	return func(ctx context.Context, rq interface{}) (interface{}, error) {
+		return i(ctx, rq, info, next)
+	}
+}
+
+// chainStreamInterceptors returns a single interceptor calling each of the given interceptors in
+// order, so the first is the outermost, before the handler.
+func chainStreamInterceptors(is []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		chained := handler
+		for i := len(is) - 1; i >= 0; i-- {
+			chained = wrapStreamHandler(is[i], info, chained)
+		}
+		return chained(srv, ss)
+	}
+}
+
+func wrapStreamHandler(i grpc.StreamServerInterceptor, info *grpc.StreamServerInfo,
+	next grpc.StreamHandler) grpc.StreamHandler {
+	return func(srv interface{}, ss grpc.ServerStream) error {
+		return i(srv, ss, info, next)
+	}
+}
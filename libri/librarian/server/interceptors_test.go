@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestConfig_interceptorOptions(t *testing.T) {
+	assert.Empty(t, (&Config{}).interceptorOptions())
+
+	unary := []grpc.UnaryServerInterceptor{newOrderedUnaryInterceptor(nil, 1)}
+	stream := []grpc.StreamServerInterceptor{newOrderedStreamInterceptor(nil, 1)}
+	assert.Len(t, (&Config{}).WithInterceptors(unary, nil).interceptorOptions(), 1)
+	assert.Len(t, (&Config{}).WithInterceptors(nil, stream).interceptorOptions(), 1)
+	assert.Len(t, (&Config{}).WithInterceptors(unary, stream).interceptorOptions(), 2)
+}
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	calls := make([]int, 0)
+	chained := chainUnaryInterceptors([]grpc.UnaryServerInterceptor{
+		newOrderedUnaryInterceptor(&calls, 1),
+		newOrderedUnaryInterceptor(&calls, 2),
+	})
+	handler := func(ctx context.Context, rq interface{}) (interface{}, error) {
+		calls = append(calls, 0)
+		return rq, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.Librarian/Get"}
+
+	rp, err := chained(context.Background(), "some request", info, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "some request", rp)
+	assert.Equal(t, []int{1, 2, 0}, calls)
+}
+
+func TestChainStreamInterceptors(t *testing.T) {
+	calls := make([]int, 0)
+	chained := chainStreamInterceptors([]grpc.StreamServerInterceptor{
+		newOrderedStreamInterceptor(&calls, 1),
+		newOrderedStreamInterceptor(&calls, 2),
+	})
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		calls = append(calls, 0)
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/api.Librarian/Subscribe"}
+
+	err := chained(nil, nil, info, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 0}, calls)
+}
+
+func newOrderedUnaryInterceptor(calls *[]int, i int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, rq interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		*calls = append(*calls, i)
+		return handler(ctx, rq)
+	}
+}
+
+func newOrderedStreamInterceptor(calls *[]int, i int) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		*calls = append(*calls, i)
+		return handler(srv, ss)
+	}
+}
@@ -91,6 +91,7 @@ func (i *introducer) introduceWork(intro *Introduction, wg *sync.WaitGroup) {
 		// process the heap's response
 		intro.wrapLock(func() {
 			delete(intro.Result.Unqueried, nextIDStr)
+			intro.Result.NResponses++
 			err = i.repProcessor.Process(response, intro.Result)
 		})
 		if err != nil {
@@ -158,14 +159,19 @@ func (irp *responseProcessor) Process(rp *api.IntroduceResponse, result *Result)
 	idStr := cid.FromBytes(rp.Self.PeerId).String()
 	newPeer := irp.fromer.FromAPI(rp.Self)
 	result.Responded[idStr] = newPeer
+	result.Discovered[idStr] = struct{}{}
 
 	// add newly discovered peers to list of peers to query if they're not already there
 	selfIDStr := irp.selfID.String()
 	for _, pa := range rp.Peers {
 		newIDStr := cid.FromBytes(pa.PeerId).String()
+		if newIDStr == selfIDStr {
+			continue
+		}
+		result.Discovered[newIDStr] = struct{}{}
 		_, inResponded := result.Responded[newIDStr]
 		_, inUnqueried := result.Unqueried[newIDStr]
-		if !inResponded && !inUnqueried && newIDStr != selfIDStr {
+		if !inResponded && !inUnqueried {
 			newPeer := irp.fromer.FromAPI(pa)
 			result.Unqueried[newIDStr] = newPeer
 		}
@@ -70,6 +70,35 @@ func TestIntroducer_Introduce_ok(t *testing.T) {
 	}
 }
 
+func TestIntroducer_Introduce_stopCriteria(t *testing.T) {
+	for concurrency := uint(1); concurrency <= 3; concurrency++ {
+		// stop after a small number of responses
+		introducer, intro, selfPeerIdxs, peers := newTestIntros(concurrency)
+		intro.Params.NMaxResponses = 4
+		seeds := search.NewTestSeeds(peers, selfPeerIdxs[:3])
+
+		err := introducer.Introduce(intro, seeds)
+		assert.Nil(t, err)
+		assert.True(t, intro.Finished())
+		assert.True(t, intro.ReachedMaxResponses())
+		assert.False(t, intro.ReachedTarget())
+
+		// in-flight queries may finish after the max is reached
+		assert.True(t, intro.Result.NResponses < intro.Params.NMaxResponses+concurrency)
+
+		// stop after discovering a small number of distinct peers
+		introducer, intro, selfPeerIdxs, peers = newTestIntros(concurrency)
+		intro.Params.NMaxDiscovered = 8
+		seeds = search.NewTestSeeds(peers, selfPeerIdxs[:3])
+
+		err = introducer.Introduce(intro, seeds)
+		assert.Nil(t, err)
+		assert.True(t, intro.Finished())
+		assert.True(t, intro.ReachedMaxDiscovered())
+		assert.False(t, intro.ReachedTarget())
+	}
+}
+
 func TestIntroducer_Introduce_queryErr(t *testing.T) {
 	introducerImpl, intro, selfPeerIdxs, peers := newTestIntros(1)
 	seeds := search.NewTestSeeds(peers, selfPeerIdxs)
@@ -191,6 +220,11 @@ func TestResponseProcessor_Process(t *testing.T) {
 	_, in = result.Unqueried[selfPeer.ID().String()]
 	assert.False(t, in)
 
+	// make sure responder and peers (but not selfPeer) have been discovered
+	assert.Equal(t, nPeers+1, len(result.Discovered))
+	_, in = result.Discovered[selfPeer.ID().String()]
+	assert.False(t, in)
+
 	// make in identical response
 	response2 := &api.IntroduceResponse{
 		Self:  responder.ToAPI(),
@@ -200,8 +234,9 @@ func TestResponseProcessor_Process(t *testing.T) {
 
 	assert.Nil(t, err)
 
-	// make sure nothing has changed with Unqueried map
+	// make sure nothing has changed with Unqueried map or discovered peers
 	assert.Equal(t, nPeers, len(result.Unqueried))
+	assert.Equal(t, nPeers+1, len(result.Discovered))
 	for _, p := range peers {
 		_, in = result.Unqueried[p.ID().String()]
 		assert.True(t, in)
@@ -27,6 +27,14 @@ var (
 
 	// DefaultQueryTimeout is the timeout for each query to a peer.
 	DefaultQueryTimeout = 5 * time.Second
+
+	// DefaultNMaxDiscovered is the default number of distinct peers to discover before stopping
+	// the introduction.
+	DefaultNMaxDiscovered = uint(1024)
+
+	// DefaultNMaxResponses is the default number of responses to receive before stopping the
+	// introduction.
+	DefaultNMaxResponses = uint(256)
 )
 
 // Parameters define the parameters of the introduction.
@@ -45,6 +53,14 @@ type Parameters struct {
 
 	// timeout for queries to individual peers
 	Timeout time.Duration
+
+	// number of distinct peers to discover before stopping the introduction; zero value means
+	// no limit
+	NMaxDiscovered uint
+
+	// number of responses to receive before stopping the introduction; zero value means no
+	// limit
+	NMaxResponses uint
 }
 
 // NewDefaultParameters creates a new instance of default introduction parameters.
@@ -55,6 +71,8 @@ func NewDefaultParameters() *Parameters {
 		NMaxErrors:             DefaultNMaxErrors,
 		Concurrency:            DefaultConcurrency,
 		Timeout:                DefaultQueryTimeout,
+		NMaxDiscovered:         DefaultNMaxDiscovered,
+		NMaxResponses:          DefaultNMaxResponses,
 	}
 }
 
@@ -67,6 +85,12 @@ type Result struct {
 	// map of all peers that that responded to introductions
 	Responded map[string]peer.Peer
 
+	// set of IDs of all distinct peers discovered, either as responders or in their responses
+	Discovered map[string]struct{}
+
+	// number of responses received
+	NResponses uint
+
 	// number of errors encountered while querying peers
 	NErrors uint
 
@@ -77,8 +101,9 @@ type Result struct {
 // NewInitialResult creates a new Result for the beginning of an introduction.
 func NewInitialResult() *Result {
 	return &Result{
-		Unqueried: make(map[string]peer.Peer),
-		Responded: make(map[string]peer.Peer),
+		Unqueried:  make(map[string]peer.Peer),
+		Responded:  make(map[string]peer.Peer),
+		Discovered: make(map[string]struct{}),
 	}
 }
 
@@ -108,6 +133,18 @@ func NewIntroduction(selfID ecid.ID, apiSelf *api.PeerAddress, params *Parameter
 	}
 }
 
+// WithRotation includes the key rotation in each of the introduction's requests, so the peers
+// introduced to can update their routing table entries for the librarian's old key.
+func (i *Introduction) WithRotation(rotation *api.KeyRotation) *Introduction {
+	newRequest := i.NewRequest
+	i.NewRequest = func() *api.IntroduceRequest {
+		rq := newRequest()
+		rq.Rotation = rotation
+		return rq
+	}
+	return i
+}
+
 func (i *Introduction) wrapLock(operation func()) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
@@ -119,6 +156,19 @@ func (i *Introduction) ReachedTarget() bool {
 	return uint(len(i.Result.Responded)) >= i.Params.TargetNumIntroductions
 }
 
+// ReachedMaxDiscovered returns whether the introduction has discovered the maximum number of
+// distinct peers.
+func (i *Introduction) ReachedMaxDiscovered() bool {
+	return i.Params.NMaxDiscovered > 0 &&
+		uint(len(i.Result.Discovered)) >= i.Params.NMaxDiscovered
+}
+
+// ReachedMaxResponses returns whether the introduction has received the maximum number of
+// responses.
+func (i *Introduction) ReachedMaxResponses() bool {
+	return i.Params.NMaxResponses > 0 && i.Result.NResponses >= i.Params.NMaxResponses
+}
+
 // Exhausted returns whether all of the possible peers have been queried.
 func (i *Introduction) Exhausted() bool {
 	return len(i.Result.Unqueried) == 0 && !i.ReachedTarget()
@@ -130,10 +180,12 @@ func (i *Introduction) Errored() bool {
 }
 
 // Finished returns whether the introduction has finished, either because it has reached the target
-// number of peers, or has exhausted all the possible peers to query, or has encountered too many
-// errors.
+// number of peers, or has discovered the maximum number of peers, or has received the maximum
+// number of responses, or has exhausted all the possible peers to query, or has encountered too
+// many errors.
 func (i *Introduction) Finished() bool {
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	return i.ReachedTarget() || i.Errored() || i.Exhausted()
+	return i.ReachedTarget() || i.ReachedMaxDiscovered() || i.ReachedMaxResponses() ||
+		i.Errored() || i.Exhausted()
 }
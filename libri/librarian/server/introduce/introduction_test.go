@@ -7,6 +7,8 @@ import (
 	"errors"
 
 	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,6 +20,24 @@ func TestNewDefaultParameters(t *testing.T) {
 	assert.NotNil(t, params.NMaxErrors)
 	assert.NotNil(t, params.Concurrency)
 	assert.NotNil(t, params.Timeout)
+	assert.NotZero(t, params.NMaxDiscovered)
+	assert.NotZero(t, params.NMaxResponses)
+}
+
+func TestIntroduction_WithRotation(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	oldID, selfID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	rotation, err := client.NewKeyRotation(oldID, selfID)
+	assert.Nil(t, err)
+	apiSelf := api.FromAddress(selfID.ID(), "self", peer.NewTestPublicAddr(0))
+	intro := NewIntroduction(selfID, apiSelf, NewDefaultParameters())
+
+	assert.Nil(t, intro.NewRequest().Rotation)
+	intro.WithRotation(rotation)
+	rq1, rq2 := intro.NewRequest(), intro.NewRequest()
+	assert.Equal(t, rotation, rq1.Rotation)
+	assert.Equal(t, apiSelf, rq1.Self)
+	assert.NotEqual(t, rq1.Metadata.RequestId, rq2.Metadata.RequestId)
 }
 
 func TestIntroduction_ReachedTarget(t *testing.T) {
@@ -51,6 +71,54 @@ func TestIntroduction_ReachedTarget(t *testing.T) {
 	assert.True(t, intro.Finished())
 }
 
+func TestIntroduction_ReachedMaxDiscovered(t *testing.T) {
+	intro := newTestIntroduction(4, &Parameters{
+		TargetNumIntroductions: 3,
+		NMaxErrors:             3,
+		NMaxDiscovered:         2,
+	})
+	assert.False(t, intro.ReachedMaxDiscovered())
+	assert.False(t, intro.Finished())
+
+	intro.Result.Discovered["peer1"] = struct{}{}
+	assert.False(t, intro.ReachedMaxDiscovered())
+	assert.False(t, intro.Finished())
+
+	intro.Result.Discovered["peer2"] = struct{}{}
+	assert.True(t, intro.ReachedMaxDiscovered())
+	assert.False(t, intro.ReachedTarget())
+	assert.True(t, intro.Finished())
+
+	// zero value means no limit
+	intro.Params.NMaxDiscovered = 0
+	assert.False(t, intro.ReachedMaxDiscovered())
+	assert.False(t, intro.Finished())
+}
+
+func TestIntroduction_ReachedMaxResponses(t *testing.T) {
+	intro := newTestIntroduction(4, &Parameters{
+		TargetNumIntroductions: 3,
+		NMaxErrors:             3,
+		NMaxResponses:          2,
+	})
+	assert.False(t, intro.ReachedMaxResponses())
+	assert.False(t, intro.Finished())
+
+	intro.Result.NResponses++
+	assert.False(t, intro.ReachedMaxResponses())
+	assert.False(t, intro.Finished())
+
+	intro.Result.NResponses++
+	assert.True(t, intro.ReachedMaxResponses())
+	assert.False(t, intro.ReachedTarget())
+	assert.True(t, intro.Finished())
+
+	// zero value means no limit
+	intro.Params.NMaxResponses = 0
+	assert.False(t, intro.ReachedMaxResponses())
+	assert.False(t, intro.Finished())
+}
+
 func TestIntroduction_Exhausted(t *testing.T) {
 	intro := newTestIntroduction(4, &Parameters{
 		TargetNumIntroductions: 3,
@@ -12,7 +12,9 @@ import (
 	"github.com/drausin/libri/libri/librarian/server/introduce"
 	cbackoff "github.com/cenkalti/backoff"
 	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/drausin/libri/libri/librarian/server/search"
 	"go.uber.org/zap"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
@@ -69,6 +71,9 @@ func (l *Librarian) bootstrapPeers(bootstrapAddrs []*net.TCPAddr) error {
 	var intro *introduce.Introduction
 	operation := func() error {
 		intro = introduce.NewIntroduction(l.selfID, l.apiSelf, l.config.Introduce)
+		if l.rotation != nil {
+			intro = intro.WithRotation(l.rotation)
+		}
 		if err := l.introducer.Introduce(intro, bootstraps); err != nil {
 			l.logger.Debug("introduction error", zap.String("error", err.Error()))
 			return err
@@ -96,7 +101,7 @@ func (l *Librarian) bootstrapPeers(bootstrapAddrs []*net.TCPAddr) error {
 
 	// add bootstrapped peers to routing table
 	for _, p := range intro.Result.Responded {
-		l.rt.Push(p)
+		l.pushPeer(p)
 	}
 	l.logger.Info("bootstrapped peers",
 		zap.Int(LoggerNBootstrappedPeers, len(intro.Result.Responded)))
@@ -118,14 +123,21 @@ func makeBootstrapPeers(bootstrapAddrs []*net.TCPAddr, selfPublicAddr fmt.String
 }
 
 func (l *Librarian) listenAndServe(up chan *Librarian) error {
+	opts, err := l.config.serverOptions()
+	if err != nil {
+		l.logger.Error("failed to load TLS credentials", zap.Error(err))
+		return err
+	}
 	lis, err := net.Listen("tcp", l.config.LocalAddr.String())
 	if err != nil {
 		l.logger.Error("failed to listen", zap.Error(err))
 		return err
 	}
 
-	s := grpc.NewServer()
+	opts = append(opts, l.config.interceptorOptions()...)
+	s := grpc.NewServer(opts...)
 	api.RegisterLibrarianServer(s, l)
+	api.RegisterAdminServer(s, l)
 	healthpb.RegisterHealthServer(s, l.health)
 	reflection.Register(s)
 
@@ -162,6 +174,29 @@ func (l *Librarian) listenAndServe(up chan *Librarian) error {
 		}
 	}()
 
+	// long-running goroutine periodically saving subscriptions to other peers
+	go l.saveSubscriptionsPeriodically()
+
+	// long-running goroutine POSTing publications to webhook endpoints
+	go func() {
+		if err := l.webhook.Begin(); err != nil {
+			l.logger.Error("webhook error", zap.Error(err))
+		}
+	}()
+
+	// long-running goroutine re-bootstrapping peers when the routing table shrinks too much
+	go l.rebootstrapWhenShrunk()
+
+	// long-running goroutine verifying and repairing replicas of stored documents
+	go func() {
+		if err := l.replicator.Begin(); err != nil {
+			l.logger.Error("fatal replicator error", zap.Error(err))
+			if err := l.Close(); err != nil {
+				panic(err) // don't try to recover from Close error
+			}
+		}
+	}()
+
 	// notify up channel shortly after starting to serve requests
 	go func() {
 		time.Sleep(postListenNotifyWait)
@@ -184,12 +219,94 @@ func (l *Librarian) listenAndServe(up chan *Librarian) error {
 	return nil
 }
 
+// rebootstrapWhenShrunk periodically checks the size of the routing table and re-bootstraps
+// peers from the bootstrap addresses when it falls below the minimum, e.g., after mass peer
+// churn. It runs until the stop signal is received.
+func (l *Librarian) rebootstrapWhenShrunk() {
+	params := l.config.Rebootstrap
+	if params.MinPeers == 0 {
+		return
+	}
+	ticker := time.NewTicker(params.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		nPeers := l.rt.NumPeers()
+		if uint(nPeers) >= params.MinPeers {
+			continue
+		}
+		l.logger.Info("routing table below minimum size, re-bootstrapping peers",
+			zap.Int("n_peers", nPeers),
+			zap.Uint("min_peers", params.MinPeers),
+		)
+		if err := l.bootstrapPeers(l.config.BootstrapAddrs); err != nil {
+			l.logger.Error("failed to re-bootstrap peers", zap.Error(err))
+			continue
+		}
+		if err := l.findPeersNearSelf(); err != nil {
+			l.logger.Error("failed to find peers near self", zap.Error(err))
+		}
+	}
+}
+
+// saveSubscriptionsPeriodically saves the active subscriptions to other peers every save
+// interval, so a restart after an unclean shutdown can still resume most of them. It runs until
+// the stop signal is received.
+func (l *Librarian) saveSubscriptionsPeriodically() {
+	interval := l.config.SubscribeTo.SaveInterval
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		if err := l.subscribeTo.Save(l.serverSL); err != nil {
+			l.logger.Error("failed to save subscriptions", zap.Error(err))
+		}
+	}
+}
+
+// findPeersNearSelf runs a peers-only search for the server's own ID, adding the peers that
+// respond with sufficient ID difficulty to the routing table.
+func (l *Librarian) findPeersNearSelf() error {
+	key := l.selfID.ID()
+	s := search.NewPeersSearch(l.selfID, key, l.config.Search)
+	seeds := l.rt.Peak(key, s.Params.Concurrency)
+	if err := l.searcher.Search(context.Background(), s, seeds); err != nil {
+		return err
+	}
+	for _, p := range s.Result.Responded {
+		l.pushPeer(p)
+	}
+	l.logger.Info("found peers near self", zap.Int("n_responded", len(s.Result.Responded)))
+	return nil
+}
+
 // Close handles cleanup involved in closing down the server.
 func (l *Librarian) Close() error {
 
-	// end subscriptions to other peers
+	// save and end subscriptions to other peers
+	if err := l.subscribeTo.Save(l.serverSL); err != nil {
+		// still shut down, just without resuming these subscriptions on restart
+		l.logger.Error("failed to save subscriptions", zap.Error(err))
+	}
 	l.subscribeTo.End()
 
+	// end internal subscription POSTing to webhook endpoints
+	l.webhook.End()
+
+	// end replication checks
+	l.replicator.End()
+
 	// send stop signal to listener
 	select {
 	case <-l.stop: // already closed
@@ -201,6 +318,9 @@ func (l *Librarian) Close() error {
 	if err := l.rt.Disconnect(); err != nil {
 		return err
 	}
+	if err := l.conns.Close(); err != nil {
+		return err
+	}
 
 	// save routing table state
 	if err := l.rt.Save(l.serverSL); err != nil {
@@ -14,6 +14,7 @@ import (
 	"github.com/drausin/libri/libri/librarian/server/introduce"
 	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/drausin/libri/libri/librarian/server/routing"
+	"github.com/drausin/libri/libri/librarian/server/search"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
@@ -121,6 +122,40 @@ func TestLibrarian_bootstrapPeers_ok(t *testing.T) {
 	}
 }
 
+func TestLibrarian_bootstrapPeers_lowDifficulty(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	seeds := []*net.TCPAddr{peer.NewTestPublicAddr(0)}
+	difficulty := uint(8)
+
+	// define our fixed introduction result with one peer of each difficulty
+	highID := ecid.NewPseudoRandomWithDifficulty(rng, difficulty)
+	lowID := ecid.NewPseudoRandom(rng)
+	for ecid.Difficulty(lowID) >= difficulty {
+		lowID = ecid.NewPseudoRandom(rng)
+	}
+	highPeer := peer.New(highID.ID(), "", api.NewConnector(peer.NewTestPublicAddr(1)))
+	lowPeer := peer.New(lowID.ID(), "", api.NewConnector(peer.NewTestPublicAddr(2)))
+	fixedResult := introduce.NewInitialResult()
+	fixedResult.Responded[highPeer.ID().String()] = highPeer
+	fixedResult.Responded[lowPeer.ID().String()] = lowPeer
+
+	l := &Librarian{
+		config: NewDefaultConfig().WithPeerIDDifficulty(difficulty),
+		introducer: &fixedIntroducer{
+			result: fixedResult,
+		},
+		rt:     routing.NewEmpty(cid.NewPseudoRandom(rng), routing.NewDefaultParameters()),
+		logger: clogging.NewDevInfoLogger(),
+	}
+
+	err := l.bootstrapPeers(seeds)
+	assert.Nil(t, err)
+
+	// make sure only the peer with sufficient difficulty is in the routing table
+	assert.NotNil(t, l.rt.Get(highPeer.ID()))
+	assert.Nil(t, l.rt.Get(lowPeer.ID()))
+}
+
 func TestLibrarian_bootstrapPeers_introduceErr(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	nSeeds := 3
@@ -176,6 +211,103 @@ func TestLibrarian_bootstrapPeers_noResponsesErr(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestLibrarian_rebootstrapWhenShrunk(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	nPeers := 8
+
+	// define out fixed introduction result
+	fixedResult := introduce.NewInitialResult()
+	for _, p := range peer.NewTestPeers(rng, nPeers) {
+		fixedResult.Responded[p.ID().String()] = p
+	}
+
+	// define our fixed peers-only search result, with additional peers near self
+	nNearPeers := 4
+	fixedSearchResult := search.NewInitialResult(cid.NewPseudoRandom(rng),
+		search.NewDefaultParameters())
+	for _, p := range peer.NewTestPeers(rng, nPeers+nNearPeers)[nPeers:] {
+		fixedSearchResult.Responded[p.ID().String()] = p
+	}
+	searcher := &fixedSearcher{result: fixedSearchResult}
+
+	config := NewDefaultConfig()
+	config.BootstrapAddrs = []*net.TCPAddr{peer.NewTestPublicAddr(0)}
+	config.Rebootstrap = &RebootstrapParameters{
+		MinPeers:      uint(nPeers),
+		CheckInterval: 10 * time.Millisecond,
+	}
+	selfID := ecid.NewPseudoRandom(rng)
+	l := &Librarian{
+		selfID: selfID,
+		config: config,
+		introducer: &fixedIntroducer{
+			result: fixedResult,
+		},
+		searcher: searcher,
+		rt:       routing.NewEmpty(selfID.ID(), routing.NewDefaultParameters()),
+		logger:   clogging.NewDevInfoLogger(),
+		stop:     make(chan struct{}),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.rebootstrapWhenShrunk()
+		close(done)
+	}()
+
+	// wait for routing table to be re-populated with bootstrapped and near peers
+	for i := 0; i < 100 && l.rt.NumPeers() < nPeers+nNearPeers; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, nPeers+nNearPeers, l.rt.NumPeers())
+	searcher.mu.Lock()
+	assert.True(t, searcher.last.PeersOnly())
+	assert.Equal(t, selfID.ID(), searcher.last.Key)
+	searcher.mu.Unlock()
+
+	// check stop signal ends monitor
+	close(l.stop)
+	<-done
+
+	// check zero MinPeers disables monitor
+	l.config.Rebootstrap.MinPeers = 0
+	l.stop = make(chan struct{})
+	l.rebootstrapWhenShrunk() // returns immediately
+}
+
+func TestLibrarian_saveSubscriptionsPeriodically(t *testing.T) {
+	config := NewDefaultConfig()
+	config.SubscribeTo.SaveInterval = 10 * time.Millisecond
+	to := &fixedTo{saveErr: errors.New("some Save error")}
+	l := &Librarian{
+		config:      config,
+		subscribeTo: to,
+		logger:      clogging.NewDevInfoLogger(),
+		stop:        make(chan struct{}),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.saveSubscriptionsPeriodically()
+		close(done)
+	}()
+
+	// check saves continue despite errors
+	for i := 0; i < 100 && to.saves() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, to.saves() >= 2)
+
+	// check stop signal ends saves
+	close(l.stop)
+	<-done
+
+	// check zero SaveInterval disables saves
+	l.config.SubscribeTo.SaveInterval = 0
+	l.stop = make(chan struct{})
+	l.saveSubscriptionsPeriodically() // returns immediately
+}
+
 type fixedIntroducer struct {
 	result *introduce.Result
 	err    error
@@ -1,6 +1,7 @@
 package peer
 
 import (
+	"crypto/tls"
 	"fmt"
 
 	cid "github.com/drausin/libri/libri/common/id"
@@ -136,23 +137,47 @@ func ToAPIs(peers []Peer) []*api.PeerAddress {
 	return addresses
 }
 
-// Fromer creates new Peer instances from api.PeerAddresses.
+// Fromer creates new Peer instances from api.PeerAddresses and storage.Peers.
 type Fromer interface {
 	// New creates a new Peer instance.
 	FromAPI(address *api.PeerAddress) Peer
+
+	// FromStored creates a new Peer instance from a storage.Peer instance.
+	FromStored(stored *storage.Peer) Peer
 }
 
-type fromer struct{}
+type fromer struct {
+	tlsConfig    *tls.Config
+	interceptors *api.Interceptors
+}
 
 // NewFromer returns a new Fromer instance.
 func NewFromer() Fromer {
 	return &fromer{}
 }
 
+// NewInterceptingFromer returns a new Fromer instance whose peers' connections use the
+// interceptors.
+func NewInterceptingFromer(interceptors *api.Interceptors) Fromer {
+	return &fromer{interceptors: interceptors}
+}
+
+// NewSecureFromer returns a new Fromer instance whose peers' connections use the interceptors
+// and, when the TLS config is non-nil, TLS.
+func NewSecureFromer(tlsConfig *tls.Config, interceptors *api.Interceptors) Fromer {
+	return &fromer{tlsConfig: tlsConfig, interceptors: interceptors}
+}
+
 func (f *fromer) FromAPI(apiAddress *api.PeerAddress) Peer {
 	return New(
 		cid.FromBytes(apiAddress.PeerId),
 		apiAddress.PeerName,
-		api.NewConnector(api.ToAddress(apiAddress)),
+		api.NewSecureConnector(api.ToAddress(apiAddress), f.tlsConfig, f.interceptors),
 	)
 }
+
+func (f *fromer) FromStored(stored *storage.Peer) Peer {
+	address := fromStoredAddress(stored.PublicAddress)
+	conn := api.NewSecureConnector(address, f.tlsConfig, f.interceptors)
+	return fromStored(stored, conn)
+}
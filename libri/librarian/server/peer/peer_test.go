@@ -1,6 +1,7 @@
 package peer
 
 import (
+	"crypto/tls"
 	"math/rand"
 	"net"
 	"testing"
@@ -166,6 +167,26 @@ func TestFromer_FromAPI(t *testing.T) {
 	assert.Equal(t, p1.Connector().Address(), p2.Connector().Address())
 }
 
+func TestFromer_FromStored(t *testing.T) {
+	sp := NewTestStoredPeer(rand.New(rand.NewSource(0)), 0)
+	f := NewInterceptingFromer(&api.Interceptors{})
+	p := f.FromStored(sp)
+	AssertPeersEqual(t, sp, p)
+}
+
+func TestNewSecureFromer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	tlsConfig := &tls.Config{ServerName: "localhost"}
+	f := NewSecureFromer(tlsConfig, nil)
+	assert.Equal(t, tlsConfig, f.(*fromer).tlsConfig)
+
+	p1 := NewTestPeer(rng, 0)
+	p2 := f.FromAPI(p1.ToAPI())
+	assert.Equal(t, p1.ID(), p2.ID())
+	assert.Equal(t, p1.Connector().Address(), p2.Connector().Address())
+	assert.NotEqual(t, api.NewConnector(p2.Connector().Address()), p2.Connector())
+}
+
 func TestToAPIs(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	ns := []int{0, 1, 2, 4}
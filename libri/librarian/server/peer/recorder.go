@@ -37,6 +37,10 @@ type Recorder interface {
 	// Merge combines the stats of the other and current recorder instances.
 	Merge(other Recorder)
 
+	// ErrorRate returns the fraction of queries of a particular type that resulted in an error,
+	// or zero if there have been no such queries.
+	ErrorRate(t QueryType) float64
+
 	// ToStored creates a storage.ResponseStats.
 	ToStored() *storage.QueryOutcomes
 }
@@ -69,6 +73,13 @@ func (qr *queryRecorder) Merge(other Recorder) {
 	qr.responses.Merge(other.(*queryRecorder).responses)
 }
 
+func (qr *queryRecorder) ErrorRate(t QueryType) float64 {
+	if t == Request {
+		return qr.requests.ErrorRate()
+	}
+	return qr.responses.ErrorRate()
+}
+
 func (qr *queryRecorder) ToStored() *storage.QueryOutcomes {
 	return &storage.QueryOutcomes{
 		Requests:  qr.requests.ToStored(),
@@ -121,6 +132,13 @@ func (qto *queryTypeOutcomes) Merge(other *queryTypeOutcomes) {
 	qto.nErrors += other.nErrors
 }
 
+func (qto *queryTypeOutcomes) ErrorRate() float64 {
+	if qto.nQueries == 0 {
+		return 0
+	}
+	return float64(qto.nErrors) / float64(qto.nQueries)
+}
+
 func (qto *queryTypeOutcomes) ToStored() *storage.QueryTypeOutcomes {
 	return &storage.QueryTypeOutcomes{
 		Earliest: qto.earliest.Unix(),
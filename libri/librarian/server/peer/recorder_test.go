@@ -71,6 +71,20 @@ func TestQueryRecorder_Record_error(t *testing.T) {
 	assert.True(t, r.requests.earliest.Unix() > 0)
 }
 
+func TestQueryRecorder_ErrorRate(t *testing.T) {
+	r := newQueryRecorder()
+	assert.Zero(t, r.ErrorRate(Response))
+	assert.Zero(t, r.ErrorRate(Request))
+
+	r.Record(Response, Success)
+	r.Record(Response, Error)
+	r.Record(Response, Success)
+	r.Record(Response, Error)
+	r.Record(Request, Error)
+	assert.Equal(t, 0.5, r.ErrorRate(Response))
+	assert.Equal(t, 1.0, r.ErrorRate(Request))
+}
+
 func TestQueryRecorder_Merge(t *testing.T) {
 	r1 := newQueryRecorder()
 	r1.Record(Response, Success)
@@ -11,7 +11,10 @@ import (
 
 // FromStored creates a new peer.Peer instance from a storage.Peer instance.
 func FromStored(stored *storage.Peer) Peer {
-	conn := api.NewConnector(fromStoredAddress(stored.PublicAddress))
+	return fromStored(stored, api.NewConnector(fromStoredAddress(stored.PublicAddress)))
+}
+
+func fromStored(stored *storage.Peer, conn api.Connector) Peer {
 	return New(id.FromBytes(stored.Id), stored.Name, conn).(*peer).
 		WithQueryRecorder(fromStoredQueryOutcomes(stored.QueryOutcomes))
 }
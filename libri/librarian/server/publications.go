@@ -0,0 +1,75 @@
+package server
+
+import (
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/common/subscribe"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/routing"
+	"github.com/drausin/libri/libri/librarian/server/search"
+	"golang.org/x/net/context"
+)
+
+// envelopeVerifier verifies publications against the envelopes stored under their envelope keys,
+// which it loads from this librarian's documents if it has them and otherwise searches for.
+type envelopeVerifier struct {
+	selfID      ecid.ID
+	documentSL  storage.DocumentLoader
+	rt          routing.Table
+	searcher    search.Searcher
+	searchCache search.ResultCache
+	params      *search.Parameters
+}
+
+// newEnvelopeVerifier creates a new subscribe.PublicationVerifier that finds envelopes via the
+// local documents, the cache of recent search results, and new searches.
+func newEnvelopeVerifier(
+	selfID ecid.ID,
+	documentSL storage.DocumentLoader,
+	rt routing.Table,
+	searcher search.Searcher,
+	searchCache search.ResultCache,
+	params *search.Parameters,
+) subscribe.PublicationVerifier {
+	return &envelopeVerifier{
+		selfID:      selfID,
+		documentSL:  documentSL,
+		rt:          rt,
+		searcher:    searcher,
+		searchCache: searchCache,
+		params:      params,
+	}
+}
+
+func (v *envelopeVerifier) Verify(pub *api.Publication) error {
+	if err := api.ValidatePublication(pub); err != nil {
+		return err
+	}
+	envelope, err := v.getEnvelope(cid.FromBytes(pub.EnvelopeKey))
+	if err != nil {
+		return err
+	}
+	return api.VerifyPublication(pub, envelope)
+}
+
+func (v *envelopeVerifier) getEnvelope(key cid.ID) (*api.Document, error) {
+	value, err := v.documentSL.Load(key)
+	if err != nil || value != nil {
+		return value, err
+	}
+	if cached, in := v.searchCache.Get(key); in {
+		// publications are usually received from many peers, so only search for the first
+		return cached.Value, nil
+	}
+	s := search.NewSearch(v.selfID, key, v.params)
+	seeds := v.rt.Peak(key, s.Params.Concurrency)
+	if err := v.searcher.Search(context.Background(), s, seeds); err != nil {
+		return nil, err
+	}
+	v.searchCache.Add(s)
+	if !s.FoundValue() {
+		return nil, api.ErrMissingEnvelope
+	}
+	return s.Result.Value, nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/db"
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/routing"
+	"github.com/drausin/libri/libri/librarian/server/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelopeVerifier_Verify_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 8)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	documentSL := storage.NewDocumentKVDBStorerLoader(kvdb)
+
+	// check publication verified against locally stored envelope
+	pub1 := api.NewTestPublication(rng)
+	envelope1 := api.GetEnvelopeDocument(pub1)
+	err = documentSL.Store(cid.FromBytes(pub1.EnvelopeKey), envelope1)
+	assert.Nil(t, err)
+	searcher := &fixedSearcher{err: errors.New("some Search error")}
+	v := newEnvelopeVerifier(peerID, documentSL, rt, searcher, search.NewDefaultResultCache(),
+		search.NewDefaultParameters())
+	assert.Nil(t, v.Verify(pub1))
+	assert.Nil(t, searcher.last)
+
+	// check publication verified against envelope found by search, which is then cached
+	pub2 := api.NewTestPublication(rng)
+	envelopeKey2 := cid.FromBytes(pub2.EnvelopeKey)
+	result := search.NewInitialResult(envelopeKey2, search.NewDefaultParameters())
+	result.Value = api.GetEnvelopeDocument(pub2)
+	searcher = &fixedSearcher{result: result}
+	v = newEnvelopeVerifier(peerID, documentSL, rt, searcher, search.NewDefaultResultCache(),
+		search.NewDefaultParameters())
+	assert.Nil(t, v.Verify(pub2))
+	assert.Equal(t, envelopeKey2, searcher.last.Key)
+
+	searcher.err = errors.New("some Search error")
+	assert.Nil(t, v.Verify(pub2))
+}
+
+func TestEnvelopeVerifier_Verify_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 8)
+	ev := &envelopeVerifier{
+		selfID:      peerID,
+		rt:          rt,
+		searchCache: search.NewDefaultResultCache(),
+		params:      search.NewDefaultParameters(),
+	}
+
+	// check invalid publication throws error
+	assert.Equal(t, api.ErrUnexpectedNilValue, ev.Verify(nil))
+
+	// check documentSL.Load error bubbles up
+	ev.documentSL = &errDocStorerLoader{}
+	assert.NotNil(t, ev.Verify(api.NewTestPublication(rng)))
+
+	// check Search error bubbles up
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	ev.documentSL = storage.NewDocumentKVDBStorerLoader(kvdb)
+	searchErr := errors.New("some Search error")
+	ev.searcher = &fixedSearcher{err: searchErr}
+	assert.Equal(t, searchErr, ev.Verify(api.NewTestPublication(rng)))
+
+	// check envelope not found throws error
+	pub := api.NewTestPublication(rng)
+	result := search.NewInitialResult(cid.FromBytes(pub.EnvelopeKey),
+		search.NewDefaultParameters())
+	ev.searcher = &fixedSearcher{result: result}
+	assert.Equal(t, api.ErrMissingEnvelope, ev.Verify(pub))
+
+	// check self-consistent but forged publication throws error
+	pub = api.NewTestPublication(rng)
+	envelope := api.GetEnvelopeDocument(pub)
+	err = ev.documentSL.(storage.DocumentStorer).Store(cid.FromBytes(pub.EnvelopeKey), envelope)
+	assert.Nil(t, err)
+	forged := &api.Publication{
+		EntryKey:        pub.EntryKey,
+		AuthorPublicKey: pub.AuthorPublicKey,
+		ReaderPublicKey: ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng)),
+	}
+	forgedKey, err := api.GetKey(api.GetEnvelopeDocument(forged))
+	assert.Nil(t, err)
+	forged.EnvelopeKey = forgedKey.Bytes()
+	assert.Equal(t, api.ErrMissingEnvelope, ev.Verify(forged))
+
+	// check publication differing from stored envelope throws error
+	pub.EntryAttributes = &api.EntryAttributes{MediaType: "image/png"}
+	assert.Equal(t, api.ErrUnexpectedPublication, ev.Verify(pub))
+}
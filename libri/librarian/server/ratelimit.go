@@ -0,0 +1,119 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	lru "github.com/hashicorp/golang-lru"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultRequestsPerSecond is the default sustained rate of requests allowed from each peer.
+	DefaultRequestsPerSecond = 100.0
+
+	// DefaultRequestBurst is the default number of requests allowed from each peer in a burst
+	// above the sustained rate.
+	DefaultRequestBurst = uint(200)
+
+	// DefaultMaxLimitedPeers is the default number of peers whose request rates are tracked.
+	DefaultMaxLimitedPeers = 1 << 12
+
+	// DefaultEvictErrorRate is the default request error rate at or above which a peer
+	// exceeding its rate limit is evicted from the routing table.
+	DefaultEvictErrorRate = 0.5
+)
+
+// ErrRequestRateExceeded indicates when a request is rejected because its requester has exceeded
+// their rate limit.
+var ErrRequestRateExceeded = status.Error(codes.ResourceExhausted, "request rate limit exceeded")
+
+// RateLimitParameters define how requests from each peer are rate limited.
+type RateLimitParameters struct {
+	// RequestsPerSecond is the sustained rate of requests allowed from each peer; zero disables
+	// rate limiting.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests allowed from a peer in a burst above the sustained rate.
+	Burst uint
+
+	// MaxPeers is the number of peers whose request rates are tracked, beyond which the least
+	// recent requesters start again with a full burst.
+	MaxPeers int
+
+	// EvictErrorRate is the request error rate (including rate limit violations) at or above
+	// which a peer exceeding its rate limit is evicted from the routing table; zero disables
+	// evictions.
+	EvictErrorRate float64
+}
+
+// NewDefaultRateLimitParameters returns a *RateLimitParameters object with default values.
+func NewDefaultRateLimitParameters() *RateLimitParameters {
+	return &RateLimitParameters{
+		RequestsPerSecond: DefaultRequestsPerSecond,
+		Burst:             DefaultRequestBurst,
+		MaxPeers:          DefaultMaxLimitedPeers,
+		EvictErrorRate:    DefaultEvictErrorRate,
+	}
+}
+
+// PeerRateLimiter limits the rate of requests from each peer with a token bucket.
+type PeerRateLimiter interface {
+	// Allow returns whether a request from the given peer is within their rate limit, using up
+	// one of their tokens if so.
+	Allow(peerID cid.ID) bool
+}
+
+type peerRateLimiter struct {
+	params  *RateLimitParameters
+	buckets *lru.Cache
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// tokenBucket holds the tokens a peer has for requests as of the last time it was updated.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewPeerRateLimiter creates a new PeerRateLimiter with the given parameters.
+func NewPeerRateLimiter(params *RateLimitParameters) (PeerRateLimiter, error) {
+	buckets, err := lru.New(params.MaxPeers)
+	if err != nil {
+		return nil, err
+	}
+	return &peerRateLimiter{
+		params:  params,
+		buckets: buckets,
+		now:     time.Now,
+	}, nil
+}
+
+func (rl *peerRateLimiter) Allow(peerID cid.ID) bool {
+	if rl.params.RequestsPerSecond <= 0 {
+		return true
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now, burst := rl.now(), float64(rl.params.Burst)
+	var b *tokenBucket
+	if value, in := rl.buckets.Get(peerID.String()); in {
+		b = value.(*tokenBucket)
+		b.tokens += now.Sub(b.updated).Seconds() * rl.params.RequestsPerSecond
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.updated = now
+	} else {
+		b = &tokenBucket{tokens: burst, updated: now}
+		rl.buckets.Add(peerID.String(), b)
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
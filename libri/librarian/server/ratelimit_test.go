@@ -0,0 +1,76 @@
+package server
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrRequestRateExceeded(t *testing.T) {
+	// check clients see a retryable code, like for the concurrency limit errors
+	st, ok := status.FromError(ErrRequestRateExceeded)
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+}
+
+func TestNewPeerRateLimiter_err(t *testing.T) {
+	params := NewDefaultRateLimitParameters()
+	params.MaxPeers = 0 // not allowed by LRU cache
+	rl, err := NewPeerRateLimiter(params)
+	assert.NotNil(t, err)
+	assert.Nil(t, rl)
+}
+
+func TestPeerRateLimiter_Allow(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := &RateLimitParameters{RequestsPerSecond: 2, Burst: 4, MaxPeers: 2}
+	rl, err := NewPeerRateLimiter(params)
+	assert.Nil(t, err)
+	now := time.Unix(0, 0)
+	rl.(*peerRateLimiter).now = func() time.Time { return now }
+	peer1, peer2, peer3 := cid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng),
+		cid.NewPseudoRandom(rng)
+
+	// check new peer gets a full burst
+	for c := uint(0); c < params.Burst; c++ {
+		assert.True(t, rl.Allow(peer1))
+	}
+	assert.False(t, rl.Allow(peer1))
+
+	// check other peers have separate limits
+	assert.True(t, rl.Allow(peer2))
+
+	// check tokens refill at the sustained rate
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, rl.Allow(peer1))
+	assert.False(t, rl.Allow(peer1))
+
+	// check tokens refill up to the burst
+	now = now.Add(time.Hour)
+	for c := uint(0); c < params.Burst; c++ {
+		assert.True(t, rl.Allow(peer1))
+	}
+	assert.False(t, rl.Allow(peer1))
+
+	// check least recent peer is forgotten once MaxPeers are tracked
+	assert.True(t, rl.Allow(peer3)) // evicts peer2
+	assert.True(t, rl.Allow(peer2)) // evicts peer1
+	assert.True(t, rl.Allow(peer1))
+}
+
+func TestPeerRateLimiter_Allow_disabled(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	params := NewDefaultRateLimitParameters()
+	params.RequestsPerSecond = 0
+	rl, err := NewPeerRateLimiter(params)
+	assert.Nil(t, err)
+	peerID := cid.NewPseudoRandom(rng)
+	for c := uint(0); c < 2*params.Burst; c++ {
+		assert.True(t, rl.Allow(peerID))
+	}
+}
@@ -0,0 +1,300 @@
+package replicate
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/drausin/libri/libri/librarian/server/routing"
+	"github.com/drausin/libri/libri/librarian/server/store"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultVerifyInterval is the default period between successive replication checks.
+	DefaultVerifyInterval = 1 * time.Minute
+
+	// DefaultNSamples is the default number of locally stored documents verified in each
+	// replication check.
+	DefaultNSamples = uint(16)
+
+	// DefaultNReplicas is the default number of closest peers that should each have a replica
+	// of a document.
+	DefaultNReplicas = uint(3)
+
+	// DefaultQueryTimeout is the default timeout for each query to a peer.
+	DefaultQueryTimeout = 5 * time.Second
+)
+
+var errMissingDocument = errors.New("sampled document missing from local storage")
+
+// Parameters define how the replicator verifies and repairs replicas.
+type Parameters struct {
+	// VerifyInterval is the period between successive replication checks.
+	VerifyInterval time.Duration
+
+	// NSamples is the maximum number of locally stored documents verified in each check.
+	NSamples uint
+
+	// NReplicas is the number of closest peers that should each have a replica of a document.
+	NReplicas uint
+
+	// Timeout is the timeout for each query to a peer.
+	Timeout time.Duration
+
+	// ProofOfWorkBits is the number of leading zero bits the proof of work on re-stores must
+	// have, which should equal the store.Parameters value required by peers.
+	ProofOfWorkBits uint
+}
+
+// NewDefaultParameters returns a *Parameters object with default values.
+func NewDefaultParameters() *Parameters {
+	return &Parameters{
+		VerifyInterval: DefaultVerifyInterval,
+		NSamples:       DefaultNSamples,
+		NReplicas:      DefaultNReplicas,
+		Timeout:        DefaultQueryTimeout,
+	}
+}
+
+// Replicator periodically samples the documents stored locally, verifies that each of the
+// closest peers to a document has a replica of it, and re-stores it in the peers that don't.
+type Replicator interface {
+	// Begin starts the periodic replication checks. It runs indefinitely until either a fatal
+	// error is encountered, or the checks are gracefully stopped via End().
+	Begin() error
+
+	// End gracefully stops the replication checks.
+	End()
+}
+
+// Result summarizes the replication check for a single document.
+type Result struct {
+	// NVerified is the number of closest peers that already had a replica.
+	NVerified uint
+
+	// NStored is the number of closest peers the document was re-stored in.
+	NStored uint
+
+	// NErrors is the number of peers that errored when being queried.
+	NErrors uint
+}
+
+type replicator struct {
+	selfID ecid.ID
+	params *Parameters
+	rt     routing.Table
+	docs   storage.DocumentStorerLoaderIterator
+	access storage.NamespaceLoader
+	signer client.Signer
+	finder client.FindQuerier
+	storer store.Storer
+	rng    *rand.Rand
+	logger *zap.Logger
+	end    chan struct{}
+}
+
+// NewReplicator creates a new Replicator for the documents in the given storage, using the
+// routing table to find the closest peers to each document and the Storer to re-store documents
+// in the peers missing them. Private documents are re-stored with their access hashes from the
+// access storage, which is nil when access control is disabled.
+func NewReplicator(
+	selfID ecid.ID,
+	params *Parameters,
+	rt routing.Table,
+	docs storage.DocumentStorerLoaderIterator,
+	access storage.NamespaceLoader,
+	signer client.Signer,
+	storer store.Storer,
+	logger *zap.Logger,
+) Replicator {
+	return &replicator{
+		selfID: selfID,
+		params: params,
+		rt:     rt,
+		docs:   docs,
+		access: access,
+		signer: signer,
+		finder: client.NewFindQuerier(),
+		storer: storer,
+		// seed with own ID so peers don't all sample the same documents
+		rng:    rand.New(rand.NewSource(selfID.Int().Int64())),
+		logger: logger,
+		end:    make(chan struct{}),
+	}
+}
+
+func (r *replicator) Begin() error {
+	ticker := time.NewTicker(r.params.VerifyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.end:
+			return nil
+		case <-ticker.C:
+		}
+		keys, err := r.sample()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			select {
+			case <-r.end:
+				return nil
+			default:
+			}
+			result, err := r.replicate(key)
+			if err != nil {
+				r.logger.Error("failed to replicate document",
+					zap.String("key", key.String()),
+					zap.Error(err),
+				)
+				continue
+			}
+			r.logger.Debug("replicated document",
+				zap.String("key", key.String()),
+				zap.Uint("n_verified", result.NVerified),
+				zap.Uint("n_stored", result.NStored),
+				zap.Uint("n_errors", result.NErrors),
+			)
+		}
+	}
+}
+
+func (r *replicator) End() {
+	select {
+	case <-r.end: // already closed
+	default:
+		close(r.end)
+	}
+}
+
+// sample returns (at most) NSamples keys of the locally stored documents, visiting them in key
+// order from a random start key and wrapping around to the first key if needed. Since document
+// keys are hashes, this approximates a uniform sample while only visiting the sampled documents.
+func (r *replicator) sample() ([]cid.ID, error) {
+	samples := make([]cid.ID, 0, r.params.NSamples)
+	if r.params.NSamples == 0 {
+		return samples, nil
+	}
+	start := cid.NewPseudoRandom(r.rng)
+	done := make(chan struct{})
+	stop := func() {
+		select {
+		case <-done: // already closed
+		default:
+			close(done)
+		}
+	}
+	add := func(key cid.ID) {
+		select {
+		case <-r.end:
+			stop()
+			return
+		default:
+		}
+		if uint(len(samples)) < r.params.NSamples {
+			samples = append(samples, key)
+		}
+		if uint(len(samples)) == r.params.NSamples {
+			stop()
+		}
+	}
+	if err := r.docs.IterateFrom(start, done, add); err != nil {
+		return nil, err
+	}
+	err := r.docs.Iterate(done, func(key cid.ID) {
+		if key.Cmp(start) >= 0 {
+			// wrapped around to the keys already visited
+			stop()
+			return
+		}
+		add(key)
+	})
+	return samples, err
+}
+
+// replicate verifies that each of the NReplicas closest peers to the key has a replica of the
+// document and stores it in the ones that don't.
+func (r *replicator) replicate(key cid.ID) (*Result, error) {
+	result := &Result{}
+	value, err := r.docs.Load(key)
+	if err != nil {
+		return result, err
+	}
+	if value == nil {
+		return result, errMissingDocument
+	}
+
+	// peers with the document a tombstone deletes don't yet have a replica of the tombstone
+	tombstone := value.GetTombstone() != nil
+	missing := make([]peer.Peer, 0, r.params.NReplicas)
+	for _, next := range r.rt.Peak(key, r.params.NReplicas) {
+		has, err := r.verify(next.Connector(), key, tombstone)
+		if err != nil {
+			next.Recorder().Record(peer.Response, peer.Error)
+			result.NErrors++
+			continue
+		}
+		next.Recorder().Record(peer.Response, peer.Success)
+		if has {
+			result.NVerified++
+			continue
+		}
+		missing = append(missing, next)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	nMissing := uint(len(missing))
+	s := store.NewDirectedStore(r.selfID, key, value, &store.Parameters{
+		NReplicas:       nMissing,
+		NMaxErrors:      nMissing,
+		Concurrency:     nMissing,
+		Timeout:         r.params.Timeout,
+		ProofOfWorkBits: r.params.ProofOfWorkBits,
+	})
+	if r.access != nil {
+		// re-store private documents as private
+		if s.Request.AccessHash, err = r.access.Load(key.Bytes()); err != nil {
+			return result, err
+		}
+	}
+	if err := r.storer.Store(context.Background(), s, missing); err != nil {
+		return result, err
+	}
+	result.NStored = uint(len(s.Result.Responded))
+	result.NErrors += s.Result.NErrors
+	return result, nil
+}
+
+// verify returns whether the peer has a replica of the document with the given key, which must be
+// a tombstone if the document is one. It asks only whether the document is stored, so neither
+// its value nor access to it if it is private are needed.
+func (r *replicator) verify(pConn api.Connector, key cid.ID, tombstone bool) (bool, error) {
+	rq := client.NewFindPresenceRequest(r.selfID, key, r.params.NReplicas)
+	ctx, cancel, err := client.NewSignedTimeoutContext(r.signer, rq, r.params.Timeout)
+	if err != nil {
+		return false, err
+	}
+	rp, err := r.finder.Query(ctx, pConn, rq)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(rp.Metadata.RequestId, rq.Metadata.RequestId) {
+		return false, client.ErrUnexpectedRequestID
+	}
+	if tombstone {
+		return rp.Value.GetTombstone() != nil, nil
+	}
+	return rp.Stored || rp.Value != nil, nil
+}
@@ -0,0 +1,370 @@
+package replicate
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/db"
+	cid "github.com/drausin/libri/libri/common/id"
+	clogging "github.com/drausin/libri/libri/common/logging"
+	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/drausin/libri/libri/librarian/server/routing"
+	"github.com/drausin/libri/libri/librarian/server/store"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestNewDefaultParameters(t *testing.T) {
+	p := NewDefaultParameters()
+	assert.NotZero(t, p.VerifyInterval)
+	assert.NotZero(t, p.NSamples)
+	assert.NotZero(t, p.NReplicas)
+	assert.NotZero(t, p.Timeout)
+}
+
+func TestNewReplicator(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 8)
+	r := NewReplicator(peerID, NewDefaultParameters(), rt, nil, nil, &client.TestNoOpSigner{},
+		store.NewDefaultStorer(peerID), clogging.NewDevInfoLogger()).(*replicator)
+	assert.NotNil(t, r.finder)
+	assert.NotNil(t, r.storer)
+	assert.NotNil(t, r.rng)
+	assert.NotNil(t, r.end)
+}
+
+func TestReplicator_sample(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	docs := &countingDocIterator{
+		DocumentStorerLoaderIterator: storage.NewDocumentKVDBStorerLoader(kvdb),
+	}
+
+	nDocs := 32
+	stored := make(map[string]struct{})
+	for i := 0; i < nDocs; i++ {
+		value, key := api.NewTestDocument(rng)
+		assert.Nil(t, docs.Store(key, value))
+		stored[key.String()] = struct{}{}
+	}
+
+	for _, nSamples := range []uint{0, 1, 4, 16, 32, 64} {
+		docs.nVisited = 0
+		r := &replicator{
+			params: &Parameters{NSamples: nSamples},
+			docs:   docs,
+			rng:    rng,
+			end:    make(chan struct{}),
+		}
+		samples, err := r.sample()
+		assert.Nil(t, err)
+		if nSamples < uint(nDocs) {
+			assert.Equal(t, int(nSamples), len(samples))
+		} else {
+			assert.Equal(t, nDocs, len(samples))
+		}
+
+		// check only sampled docs visited, plus at most one to detect wrapping around
+		assert.True(t, docs.nVisited <= len(samples)+1)
+
+		distinct := make(map[string]struct{})
+		for _, key := range samples {
+			_, in := stored[key.String()]
+			assert.True(t, in)
+			distinct[key.String()] = struct{}{}
+		}
+		assert.Equal(t, len(samples), len(distinct))
+	}
+}
+
+func TestReplicator_replicate_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r, key, nReplicas := newTestReplicator(rng)
+	closest := r.rt.Peak(key, nReplicas)
+
+	// first closest peer already has the value
+	finder := &fixedFindQuerier{has: map[string]bool{
+		closest[0].Connector().Address().String(): true,
+	}}
+	storer := &recordingStoreQuerier{}
+	r.finder, r.storer = finder, newTestStorer(storer)
+
+	result, err := r.replicate(key)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), result.NVerified)
+	assert.Equal(t, nReplicas-1, result.NStored)
+	assert.Zero(t, result.NErrors)
+
+	// check that remaining closest peers were stored to
+	for _, p := range closest[1:] {
+		_, in := storer.stored[p.Connector().Address().String()]
+		assert.True(t, in)
+	}
+	_, in := storer.stored[closest[0].Connector().Address().String()]
+	assert.False(t, in)
+}
+
+func TestReplicator_replicate_tombstone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r, key, nReplicas := newTestReplicator(rng)
+	closest := r.rt.Peak(key, nReplicas)
+	tombstone := api.NewTestTombstone(rng)
+	tombstone.DocumentKey = key.Bytes()
+	err := r.docs.Store(key, &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}})
+	assert.Nil(t, err)
+
+	// first closest peer still has the deleted document rather than its tombstone
+	finder := &fixedFindQuerier{has: map[string]bool{
+		closest[0].Connector().Address().String(): true,
+	}}
+	storer := &recordingStoreQuerier{}
+	r.finder, r.storer = finder, newTestStorer(storer)
+
+	result, err := r.replicate(key)
+	assert.Nil(t, err)
+	assert.Zero(t, result.NVerified)
+	assert.Equal(t, nReplicas, result.NStored)
+	for _, p := range closest {
+		_, in := storer.stored[p.Connector().Address().String()]
+		assert.True(t, in)
+	}
+}
+
+func TestReplicator_replicate_private(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r, key, nReplicas := newTestReplicator(rng)
+	closest := r.rt.Peak(key, nReplicas)
+	accessHash := api.RandBytes(rng, storage.AccessHashLength)
+	r.access = &fixedAccessLoader{hashes: map[string][]byte{string(key.Bytes()): accessHash}}
+
+	// first closest peer has the private value, which it reports without an access proof
+	finder := &fixedFindQuerier{has: map[string]bool{
+		closest[0].Connector().Address().String(): true,
+	}}
+	storer := &recordingStoreQuerier{}
+	r.finder, r.storer = finder, newTestStorer(storer)
+
+	result, err := r.replicate(key)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), result.NVerified)
+	assert.Equal(t, nReplicas-1, result.NStored)
+	assert.True(t, finder.presenceOnly)
+
+	// check private value re-stored with its access hash
+	assert.Equal(t, accessHash, storer.lastAccessHash)
+
+	// check access hash Load error bubbles up
+	r.access = &fixedAccessLoader{err: errors.New("some Load error")}
+	_, err = r.replicate(key)
+	assert.NotNil(t, err)
+}
+
+func TestReplicator_replicate_queryErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r, key, nReplicas := newTestReplicator(rng)
+
+	// all verify queries error
+	r.finder = &fixedFindQuerier{err: errors.New("some Find error")}
+	r.storer = newTestStorer(&recordingStoreQuerier{})
+	result, err := r.replicate(key)
+	assert.Nil(t, err)
+	assert.Equal(t, nReplicas, result.NErrors)
+	assert.Zero(t, result.NStored)
+
+	// all store queries error
+	r.finder = &fixedFindQuerier{}
+	r.storer = newTestStorer(&recordingStoreQuerier{err: errors.New("some Store error")})
+	result, err = r.replicate(key)
+	assert.Nil(t, err)
+	assert.Equal(t, nReplicas, result.NErrors)
+	assert.Zero(t, result.NStored)
+}
+
+func TestReplicator_replicate_missingDoc(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r, _, _ := newTestReplicator(rng)
+	r.finder = &fixedFindQuerier{}
+	r.storer = newTestStorer(&recordingStoreQuerier{})
+
+	// key for document not stored locally
+	result, err := r.replicate(cid.NewPseudoRandom(rng))
+	assert.Equal(t, errMissingDocument, err)
+	assert.Zero(t, result.NStored)
+}
+
+func TestReplicator_BeginEnd(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r, _, _ := newTestReplicator(rng)
+	r.params.VerifyInterval = 10 * time.Millisecond
+	storer := &recordingStoreQuerier{}
+	r.finder, r.storer = &fixedFindQuerier{}, newTestStorer(storer)
+
+	errs := make(chan error)
+	go func() { errs <- r.Begin() }()
+	time.Sleep(50 * time.Millisecond)
+	r.End()
+	assert.Nil(t, <-errs)
+	assert.NotZero(t, storer.nStored())
+
+	// second End shouldn't panic
+	r.End()
+}
+
+func newTestReplicator(rng *rand.Rand) (*replicator, cid.ID, uint) {
+	nReplicas := uint(3)
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 16)
+	docs := &memDocStorerLoader{docs: make(map[string]*api.Document)}
+	value, key := api.NewTestDocument(rng)
+	if err := docs.Store(key, value); err != nil {
+		panic(err)
+	}
+	params := NewDefaultParameters()
+	params.NReplicas = nReplicas
+	r := NewReplicator(peerID, params, rt, docs, nil, &client.TestNoOpSigner{},
+		store.NewDefaultStorer(peerID), clogging.NewDevInfoLogger())
+	return r.(*replicator), key, nReplicas
+}
+
+func newTestStorer(q client.StoreQuerier) store.Storer {
+	// directed stores don't use the searcher
+	return store.NewStorer(&client.TestNoOpSigner{}, nil, q)
+}
+
+type fixedFindQuerier struct {
+	has          map[string]bool
+	presenceOnly bool
+	err          error
+	mu           sync.Mutex
+}
+
+func (f *fixedFindQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.FindRequest,
+	opts ...grpc.CallOption) (*api.FindResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	f.presenceOnly = rq.PresenceOnly
+	f.mu.Unlock()
+	rp := &api.FindResponse{
+		Metadata: &api.ResponseMetadata{RequestId: rq.Metadata.RequestId},
+	}
+	if f.has[pConn.Address().String()] && rq.PresenceOnly {
+		rp.Stored = true
+	} else if f.has[pConn.Address().String()] {
+		rp.Value, _ = api.NewTestDocument(rand.New(rand.NewSource(0)))
+	}
+	return rp, nil
+}
+
+type recordingStoreQuerier struct {
+	stored         map[string]struct{}
+	lastAccessHash []byte
+	err            error
+	mu             sync.Mutex
+}
+
+func (f *recordingStoreQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.StoreRequest, opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stored == nil {
+		f.stored = make(map[string]struct{})
+	}
+	f.stored[pConn.Address().String()] = struct{}{}
+	f.lastAccessHash = rq.AccessHash
+	return &api.StoreResponse{
+		Metadata: &api.ResponseMetadata{RequestId: rq.Metadata.RequestId},
+	}, nil
+}
+
+func (f *recordingStoreQuerier) nStored() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.stored)
+}
+
+type fixedAccessLoader struct {
+	hashes map[string][]byte
+	err    error
+}
+
+func (f *fixedAccessLoader) Load(key []byte) ([]byte, error) {
+	return f.hashes[string(key)], f.err
+}
+
+type memDocStorerLoader struct {
+	docs map[string]*api.Document
+}
+
+func (m *memDocStorerLoader) Store(key cid.ID, value *api.Document) error {
+	m.docs[key.String()] = value
+	return nil
+}
+
+func (m *memDocStorerLoader) Load(key cid.ID) (*api.Document, error) {
+	return m.docs[key.String()], nil
+}
+
+func (m *memDocStorerLoader) Iterate(done chan struct{}, callback func(key cid.ID)) error {
+	return m.IterateFrom(cid.FromInt64(0), done, callback)
+}
+
+func (m *memDocStorerLoader) IterateFrom(
+	start cid.ID, done chan struct{}, callback func(key cid.ID),
+) error {
+	keys := make([]cid.ID, 0, len(m.docs))
+	for keyStr := range m.docs {
+		key, err := cid.FromString(keyStr)
+		if err != nil {
+			return err
+		}
+		if key.Cmp(start) >= 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+	for _, key := range keys {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		callback(key)
+	}
+	return nil
+}
+
+type countingDocIterator struct {
+	storage.DocumentStorerLoaderIterator
+	nVisited int
+}
+
+func (c *countingDocIterator) Iterate(done chan struct{}, callback func(key cid.ID)) error {
+	return c.DocumentStorerLoaderIterator.Iterate(done, c.counting(callback))
+}
+
+func (c *countingDocIterator) IterateFrom(
+	start cid.ID, done chan struct{}, callback func(key cid.ID),
+) error {
+	return c.DocumentStorerLoaderIterator.IterateFrom(start, done, c.counting(callback))
+}
+
+func (c *countingDocIterator) counting(callback func(key cid.ID)) func(key cid.ID) {
+	return func(key cid.ID) {
+		c.nVisited++
+		callback(key)
+	}
+}
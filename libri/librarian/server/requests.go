@@ -22,20 +22,32 @@ type verifier struct {
 	sigVerifier client.Verifier
 }
 
-// NewRequestVerifier creates a new RequestVerifier instance.
-func NewRequestVerifier() RequestVerifier {
+// NewRequestVerifier creates a new RequestVerifier instance with the given signature
+// verification parameters.
+func NewRequestVerifier(params *client.VerifyParameters) RequestVerifier {
 	return &verifier{
-		sigVerifier: client.NewVerifier(),
+		sigVerifier: client.NewVerifierWithParameters(params),
 	}
 }
 
+// NewHMACRequestVerifier creates a new RequestVerifier instance that verifies HMAC signatures
+// made with the shared secret, using the given signature verification parameters.
+func NewHMACRequestVerifier(secret []byte, params *client.VerifyParameters) (
+	RequestVerifier, error) {
+	sigVerifier, err := client.NewHMACVerifier(secret, params)
+	if err != nil {
+		return nil, err
+	}
+	return &verifier{sigVerifier: sigVerifier}, nil
+}
+
 func (rv *verifier) Verify(ctx context.Context, msg proto.Message,
 	meta *api.RequestMetadata) error {
 	encToken, err := client.FromSignatureContext(ctx)
 	if err != nil {
 		return err
 	}
-	pubKey, err := ecid.FromPublicKeyBytes(meta.PubKey)
+	requesterID, err := ecid.FromAnyPublicKeyBytes(meta.PubKey)
 	if err != nil {
 		return err
 	}
@@ -47,5 +59,5 @@ func (rv *verifier) Verify(ctx context.Context, msg proto.Message,
 			len(meta.RequestId), cid.Length)
 	}
 
-	return rv.sigVerifier.Verify(encToken, pubKey, msg)
+	return rv.sigVerifier.Verify(encToken, requesterID.PublicKey(), msg)
 }
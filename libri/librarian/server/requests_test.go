@@ -1,11 +1,13 @@
 package server
 
 import (
-	"crypto/ecdsa"
+	"crypto"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/golang/protobuf/proto"
@@ -17,11 +19,68 @@ import (
 // every signature.
 type alwaysSigVerifier struct{}
 
-func (asv *alwaysSigVerifier) Verify(encToken string, fromPubKey *ecdsa.PublicKey,
+func (asv *alwaysSigVerifier) Verify(encToken string, fromPubKey crypto.PublicKey,
 	m proto.Message) error {
 	return nil
 }
 
+func TestNewRequestVerifier(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	key := id.NewPseudoRandom(rng)
+	rq := client.NewFindRequest(peerID, key, 20)
+	encToken, err := client.NewSigner(peerID.Key()).Sign(rq)
+	assert.Nil(t, err)
+	ctx := client.NewIncomingSignatureContext(context.Background(), encToken)
+
+	rv := NewRequestVerifier(client.NewDefaultVerifyParameters())
+	assert.Nil(t, rv.Verify(ctx, rq, rq.Metadata))
+	assert.NotNil(t, rv.Verify(ctx, client.NewFindRequest(peerID, key, 10), rq.Metadata))
+}
+
+func TestNewRequestVerifier_session(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	signer, err := client.NewSessionSigner(sessionID, cert)
+	assert.Nil(t, err)
+	rq := client.NewFindRequest(ecid.FromPublicKey(&identityID.Key().PublicKey),
+		id.NewPseudoRandom(rng), 20)
+	encToken, err := signer.Sign(rq)
+	assert.Nil(t, err)
+	ctx := client.NewIncomingSignatureContext(context.Background(), encToken)
+
+	rv := NewRequestVerifier(client.NewDefaultVerifyParameters())
+	assert.Nil(t, rv.Verify(ctx, rq, rq.Metadata))
+
+	// check session signature not attributed to the session key's own ID
+	rq.Metadata.PubKey = ecid.ToPublicKeyBytes(sessionID)
+	assert.NotNil(t, rv.Verify(ctx, rq, rq.Metadata))
+}
+
+func TestNewHMACRequestVerifier(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	key := id.NewPseudoRandom(rng)
+	rq := client.NewFindRequest(peerID, key, 20)
+	secret := []byte("some shared secret")
+	signer, err := client.NewHMACSigner(secret)
+	assert.Nil(t, err)
+	encToken, err := signer.Sign(rq)
+	assert.Nil(t, err)
+	ctx := client.NewIncomingSignatureContext(context.Background(), encToken)
+
+	rv, err := NewHMACRequestVerifier(secret, client.NewDefaultVerifyParameters())
+	assert.Nil(t, err)
+	assert.Nil(t, rv.Verify(ctx, rq, rq.Metadata))
+	assert.NotNil(t, rv.Verify(ctx, client.NewFindRequest(peerID, key, 10), rq.Metadata))
+
+	rv, err = NewHMACRequestVerifier(nil, client.NewDefaultVerifyParameters())
+	assert.Equal(t, client.ErrMissingSharedSecret, err)
+	assert.Nil(t, rv)
+}
+
 func TestRequestVerifier_Verify_ok(t *testing.T) {
 	rv := &verifier{
 		sigVerifier: &alwaysSigVerifier{},
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	grpcpeer "google.golang.org/grpc/peer"
+)
+
+// Role is a set of kinds of operations a caller may request.
+type Role uint8
+
+const (
+	// PeerRole allows the requests other librarians make: Introduce, Find, Store, and Subscribe.
+	PeerRole Role = 1 << iota
+
+	// AuthorRole allows the requests author clients make: Get, Put, and Subscribe.
+	AuthorRole
+
+	// AdminRole allows Admin requests.
+	AdminRole
+)
+
+// ErrUnauthorizedRole indicates when a caller doesn't have a role allowing the request.
+var ErrUnauthorizedRole = errors.New("caller role not authorized for request")
+
+// Roles assigns roles to callers by their request public key or TLS client certificate.
+type Roles struct {
+	// PublicKeys maps hex-encoded request public keys to their roles.
+	PublicKeys map[string]Role
+
+	// CertificateNames maps TLS client certificate subject common names to their roles.
+	CertificateNames map[string]Role
+
+	// Default is the role of every caller, in addition to any assigned to it.
+	Default Role
+}
+
+// NewDefaultRoles returns a *Roles object where every caller has the peer and author roles but
+// none has the admin role.
+func NewDefaultRoles() *Roles {
+	return &Roles{
+		PublicKeys:       make(map[string]Role),
+		CertificateNames: make(map[string]Role),
+		Default:          PeerRole | AuthorRole,
+	}
+}
+
+// AssignPublicKey adds the role to those of callers signing requests with the public key.
+func (r *Roles) AssignPublicKey(pubKey []byte, role Role) *Roles {
+	key := hex.EncodeToString(pubKey)
+	r.PublicKeys[key] |= role
+	return r
+}
+
+// AssignCertificateName adds the role to those of callers with a TLS client certificate with the
+// subject common name.
+func (r *Roles) AssignCertificateName(name string, role Role) *Roles {
+	r.CertificateNames[name] |= role
+	return r
+}
+
+// Of returns the roles of the caller signing requests with the public key, including those of the
+// caller's verified TLS client certificate in the context, if any.
+func (r *Roles) Of(ctx context.Context, pubKey []byte) Role {
+	role := r.Default | r.PublicKeys[hex.EncodeToString(pubKey)]
+	if ctx == nil {
+		return role
+	}
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return role
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		for _, chain := range tlsInfo.State.VerifiedChains {
+			if len(chain) > 0 {
+				role |= r.CertificateNames[chain[0].Subject.CommonName]
+			}
+		}
+	}
+	return role
+}
+
+// requiredRole returns the roles, any of which allows the request.
+func requiredRole(rq proto.Message) Role {
+	switch rq.(type) {
+	case *api.IntroduceRequest, *api.FindRequest, *api.StoreRequest:
+		return PeerRole
+	case *api.GetRequest, *api.PutRequest:
+		return AuthorRole
+	case *api.SubscribeRequest, *api.SubscriptionStatsRequest:
+		return PeerRole | AuthorRole
+	default:
+		// Admin requests
+		return AdminRole
+	}
+}
+
+// checkRole checks that the caller has a role allowing the request, if roles are configured.
+func (l *Librarian) checkRole(ctx context.Context, rq proto.Message,
+	meta *api.RequestMetadata) error {
+	if l.config == nil || l.config.Roles == nil {
+		return nil
+	}
+	if l.config.Roles.Of(ctx, meta.PubKey)&requiredRole(rq) == 0 {
+		return ErrUnauthorizedRole
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/rand"
+	"testing"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	grpcpeer "google.golang.org/grpc/peer"
+)
+
+func TestRoles_Of(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	adminKey := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	otherKey := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	roles := NewDefaultRoles().
+		AssignPublicKey(adminKey, AdminRole).
+		AssignCertificateName("some-admin", AdminRole)
+
+	assert.Equal(t, PeerRole|AuthorRole|AdminRole, roles.Of(nil, adminKey))
+	assert.Equal(t, PeerRole|AuthorRole, roles.Of(nil, otherKey))
+	assert.Equal(t, PeerRole|AuthorRole, roles.Of(context.Background(), otherKey))
+
+	// check role from verified TLS client certificate
+	ctx := newTestTLSPeerContext("some-admin")
+	assert.Equal(t, PeerRole|AuthorRole|AdminRole, roles.Of(ctx, otherKey))
+	ctx = newTestTLSPeerContext("some-other-name")
+	assert.Equal(t, PeerRole|AuthorRole, roles.Of(ctx, otherKey))
+
+	// check no roles by default
+	roles.Default = 0
+	assert.Equal(t, AdminRole, roles.Of(nil, adminKey))
+	assert.Equal(t, Role(0), roles.Of(nil, otherKey))
+}
+
+func TestRequiredRole(t *testing.T) {
+	assert.Equal(t, PeerRole, requiredRole(&api.IntroduceRequest{}))
+	assert.Equal(t, PeerRole, requiredRole(&api.FindRequest{}))
+	assert.Equal(t, PeerRole, requiredRole(&api.StoreRequest{}))
+	assert.Equal(t, AuthorRole, requiredRole(&api.GetRequest{}))
+	assert.Equal(t, AuthorRole, requiredRole(&api.PutRequest{}))
+	assert.Equal(t, PeerRole|AuthorRole, requiredRole(&api.SubscribeRequest{}))
+	assert.Equal(t, PeerRole|AuthorRole, requiredRole(&api.SubscriptionStatsRequest{}))
+	assert.Equal(t, AdminRole, requiredRole(&api.UpdateDenylistRequest{}))
+	assert.Equal(t, AdminRole, requiredRole(&api.ScanAuditLogRequest{}))
+}
+
+func TestLibrarian_checkRole(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	meta := client.NewRequestMetadata(ecid.NewPseudoRandom(rng))
+
+	// check all requests allowed without roles
+	l := &Librarian{config: &Config{}}
+	assert.Nil(t, l.checkRole(nil, &api.UpdateDenylistRequest{}, meta))
+
+	l.config.WithRoles(NewDefaultRoles())
+	assert.Nil(t, l.checkRole(nil, &api.GetRequest{}, meta))
+	assert.Nil(t, l.checkRole(nil, &api.IntroduceRequest{}, meta))
+	err := l.checkRole(nil, &api.UpdateDenylistRequest{}, meta)
+	assert.Equal(t, ErrUnauthorizedRole, err)
+
+	l.config.Roles.AssignPublicKey(meta.PubKey, AdminRole)
+	assert.Nil(t, l.checkRole(nil, &api.UpdateDenylistRequest{}, meta))
+
+	// check author-only caller can't make peer requests
+	l.config.Roles = &Roles{Default: AuthorRole}
+	assert.Nil(t, l.checkRole(nil, &api.PutRequest{}, meta))
+	assert.Nil(t, l.checkRole(nil, &api.SubscribeRequest{}, meta))
+	assert.Equal(t, ErrUnauthorizedRole, l.checkRole(nil, &api.StoreRequest{}, meta))
+}
+
+func TestCheckRequest_roleErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	rq := client.NewFindRequest(peerID, ecid.NewPseudoRandom(rng), 20)
+	l := &Librarian{
+		config:      (&Config{}).WithRoles(&Roles{Default: AuthorRole}),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+	}
+	requesterID, err := l.checkRequest(nil, rq, rq.Metadata)
+	assert.Equal(t, ErrUnauthorizedRole, err)
+	assert.Nil(t, requesterID)
+}
+
+func newTestTLSPeerContext(commonName string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return grpcpeer.NewContext(context.Background(), &grpcpeer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		},
+	})
+}
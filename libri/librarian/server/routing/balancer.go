@@ -3,6 +3,7 @@ package routing
 import (
 	"errors"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -19,13 +20,21 @@ var (
 	// ErrClientMissingFromSet indicates when a client ID is expected to be in a set but isn't.
 	ErrClientMissingFromSet = errors.New("client missing from set")
 
+	// ErrClientInSet indicates when a client ID is unexpectedly already in a set.
+	ErrClientInSet = errors.New("client already in set")
+
+	// ErrClientMissingFromTable indicates when a client ID is expected to be in the routing
+	// table but isn't.
+	ErrClientMissingFromTable = errors.New("client missing from routing table")
+
 	tableSampleRetryWait = 15 * time.Second
 	numRetries           = 32
 	sampleBatchSize      = uint(8)
 )
 
 // NewClientBalancer returns a new api.ClientBalancer that uses the routing tables's Sample()
-// method and returns a unique client on every Next() call.
+// method and returns a unique client on every Next() call. Among each batch of sampled peers, those
+// with lower response error rates are returned first.
 func NewClientBalancer(rt Table) api.ClientSetBalancer {
 	return &tableSetBalancer{
 		rt:    rt,
@@ -54,6 +63,11 @@ func (b *tableSetBalancer) AddNext() (api.LibrarianClient, id.ID, error) {
 				time.Sleep(tableSampleRetryWait)
 				continue
 			}
+			// prefer peers with fewer recent response errors
+			sort.SliceStable(b.cache, func(i, j int) bool {
+				return b.cache[i].Recorder().ErrorRate(peer.Response) <
+					b.cache[j].Recorder().ErrorRate(peer.Response)
+			})
 		}
 		nextPeer := b.cache[0]
 		b.cache = b.cache[1:]
@@ -61,7 +75,7 @@ func (b *tableSetBalancer) AddNext() (api.LibrarianClient, id.ID, error) {
 			// update current state & return connection to new peer
 			b.set[nextPeer.ID().String()] = struct{}{}
 			b.mu.Unlock()
-			lc, err := nextPeer.Connector().Connect()
+			lc, err := b.connect(nextPeer)
 			return lc, nextPeer.ID(), err
 		}
 		b.mu.Unlock()
@@ -72,6 +86,21 @@ func (b *tableSetBalancer) AddNext() (api.LibrarianClient, id.ID, error) {
 	return nil, nil, ErrNoNewClients
 }
 
+func (b *tableSetBalancer) Add(peerID id.ID) (api.LibrarianClient, error) {
+	p := b.rt.Get(peerID)
+	if p == nil {
+		return nil, ErrClientMissingFromTable
+	}
+	b.mu.Lock()
+	if _, in := b.set[peerID.String()]; in {
+		b.mu.Unlock()
+		return nil, ErrClientInSet
+	}
+	b.set[peerID.String()] = struct{}{}
+	b.mu.Unlock()
+	return b.connect(p)
+}
+
 func (b *tableSetBalancer) Remove(peerID id.ID) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -81,3 +110,16 @@ func (b *tableSetBalancer) Remove(peerID id.ID) error {
 	delete(b.set, peerID.String())
 	return nil
 }
+
+// connect connects to a peer already added to the set, removing it from the set again if the
+// connection fails so it may be added later.
+func (b *tableSetBalancer) connect(p peer.Peer) (api.LibrarianClient, error) {
+	lc, err := p.Connector().Connect()
+	if err != nil {
+		b.mu.Lock()
+		delete(b.set, p.ID().String())
+		b.mu.Unlock()
+		return nil, err
+	}
+	return lc, nil
+}
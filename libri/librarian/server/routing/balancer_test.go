@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"errors"
 	"math/rand"
 	"net"
 	"testing"
@@ -52,6 +53,27 @@ func TestTableUniqueBalancer_Next_ok(t *testing.T) {
 	assert.NotNil(t, peerID)
 }
 
+func TestTableUniqueBalancer_Next_errorRate(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt := NewEmpty(id.NewPseudoRandom(rng), NewDefaultParameters())
+	peers := peer.NewTestPeers(rng, 4)
+	for i, p := range peers {
+		// peer i has i errors
+		for c := 0; c < i; c++ {
+			p.Recorder().Record(peer.Response, peer.Error)
+		}
+		rt.Push(p)
+	}
+	csb := NewClientBalancer(rt)
+
+	// check peers are returned in order of increasing error rate
+	for _, p := range peers {
+		_, peerID, err := csb.AddNext()
+		assert.Nil(t, err)
+		assert.Equal(t, p.ID(), peerID)
+	}
+}
+
 func TestTableUniqueBalancer_Next_err(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	rt := NewEmpty(id.NewPseudoRandom(rng), NewDefaultParameters())
@@ -79,6 +101,45 @@ func TestTableUniqueBalancer_Next_err(t *testing.T) {
 	assert.NotNil(t, peerID)
 }
 
+func TestRoutingTableBalancer_Add(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	lc1 := api.NewLibrarianClient(nil)
+	rt := NewEmpty(id.NewPseudoRandom(rng), NewDefaultParameters())
+	peerID := id.NewPseudoRandom(rng)
+	rt.Push(peer.New(peerID, "test-peer-1", &fixedConnector{connectLC: lc1}))
+	csb := NewClientBalancer(rt)
+
+	lc2, err := csb.Add(peerID)
+	assert.Nil(t, err)
+	assert.Equal(t, lc1, lc2)
+	assert.Equal(t, 1, len(csb.(*tableSetBalancer).set))
+
+	// check adding peer already in set errors
+	lc2, err = csb.Add(peerID)
+	assert.Equal(t, ErrClientInSet, err)
+	assert.Nil(t, lc2)
+
+	// check adding peer not in routing table errors
+	lc2, err = csb.Add(id.NewPseudoRandom(rng))
+	assert.Equal(t, ErrClientMissingFromTable, err)
+	assert.Nil(t, lc2)
+
+	// check peer whose connection fails is removed from set so it can be added again
+	connectErr := errors.New("some Connect error")
+	conn := &fixedConnector{connectErr: connectErr}
+	peerID2 := id.NewPseudoRandom(rng)
+	rt.Push(peer.New(peerID2, "test-peer-2", conn))
+	lc2, err = csb.Add(peerID2)
+	assert.Equal(t, connectErr, err)
+	assert.Nil(t, lc2)
+	assert.Equal(t, 1, len(csb.(*tableSetBalancer).set))
+
+	conn.connectErr, conn.connectLC = nil, lc1
+	lc2, err = csb.Add(peerID2)
+	assert.Nil(t, err)
+	assert.Equal(t, lc1, lc2)
+}
+
 func TestRoutingTableBalancer_Remove(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	lc1 := api.NewLibrarianClient(nil)
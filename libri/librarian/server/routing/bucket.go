@@ -96,5 +96,10 @@ func (b *bucket) Vacancy() bool {
 
 // Contains returns whether the bucket's ID range contains the target.
 func (b *bucket) Contains(target cid.ID) bool {
-	return target.Cmp(b.lowerBound) >= 0 && target.Cmp(b.upperBound) < 0
+	return b.idRange().Contains(target)
+}
+
+// idRange returns the bucket's ID range.
+func (b *bucket) idRange() cid.Range {
+	return cid.Range{Lower: b.lowerBound, Upper: b.upperBound}
 }
@@ -3,7 +3,6 @@ package routing
 import (
 	"container/heap"
 	"errors"
-	"math/big"
 	"math/rand"
 	"sort"
 	"sync"
@@ -48,6 +47,10 @@ type Table interface {
 	// table.
 	Get(peerID cid.ID) peer.Peer
 
+	// Remove removes and returns the peer with the given ID. It returns nil if the peer doesn't
+	// exist in the table.
+	Remove(peerID cid.ID) peer.Peer
+
 	// Sample returns k peers in the table sampled (approximately) uniformly from the ID space.
 	// Peers are sampled from buckets with probability proportional to the amount of ID
 	// space the bucket covers.
@@ -133,6 +136,8 @@ func (rt *table) SelfID() cid.ID {
 }
 
 func (rt *table) NumPeers() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
 	return len(rt.peers)
 }
 
@@ -200,7 +205,7 @@ func (rt *table) Push(new peer.Peer) PushStatus {
 func (rt *table) Pop(target cid.ID, k uint) []peer.Peer {
 	rt.mu.Lock()
 	defer rt.mu.Unlock()
-	if np := rt.NumPeers(); k > uint(np) {
+	if np := len(rt.peers); k > uint(np) {
 		// if we're requesting more peers than we have, just return number we have
 		k = uint(np)
 	}
@@ -252,6 +257,21 @@ func (rt *table) Get(peerID cid.ID) peer.Peer {
 	return nil
 }
 
+// Remove removes the peer (if it exists) in the table with the given ID. This method is
+// concurrency safe.
+func (rt *table) Remove(peerID cid.ID) peer.Peer {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	bucket := rt.buckets[rt.bucketIndex(peerID)]
+	pHeapIdx, exists := bucket.positions[peerID.String()]
+	if !exists {
+		return nil
+	}
+	removed := heap.Remove(bucket, pHeapIdx).(peer.Peer)
+	delete(rt.peers, peerID.String())
+	return removed
+}
+
 func (rt *table) Sample(k uint, rng *rand.Rand) []peer.Peer {
 	rt.mu.Lock()
 	defer rt.mu.Unlock()
@@ -365,14 +385,14 @@ func (rt *table) splitBucket(bucketIdx int) {
 	current := rt.buckets[bucketIdx]
 
 	// define the bounds of the two new buckets from those of the current bucket
-	middle := splitLowerBound(current.lowerBound, current.depth)
+	leftRange, rightRange := current.idRange().Split(current.depth)
 	newIdMass := current.idMass / 2.0
 
 	// create the new buckets
 	left := &bucket{
 		depth:          current.depth + 1,
-		lowerBound:     current.lowerBound,
-		upperBound:     middle,
+		lowerBound:     leftRange.Lower,
+		upperBound:     leftRange.Upper,
 		idMass:         newIdMass,
 		idCumMass:      current.idCumMass - newIdMass,
 		maxActivePeers: current.maxActivePeers,
@@ -383,8 +403,8 @@ func (rt *table) splitBucket(bucketIdx int) {
 
 	right := &bucket{
 		depth:          current.depth + 1,
-		lowerBound:     middle,
-		upperBound:     current.upperBound,
+		lowerBound:     rightRange.Lower,
+		upperBound:     rightRange.Upper,
 		idMass:         newIdMass,
 		idCumMass:      current.idCumMass,
 		maxActivePeers: current.maxActivePeers,
@@ -407,14 +427,3 @@ func (rt *table) splitBucket(bucketIdx int) {
 	rt.buckets = append(rt.buckets, right) // right should actually be just to the right of left
 	sort.Sort(rt)                          // but we let Sort handle moving it back there
 }
-
-// splitLowerBound extends a lower bound one bit deeper with a 1 bit, thereby splitting
-// the domain implied by the current lower bound and depth
-// e.g.,
-// 	extendLowerBound(00000000, 0) -> 10000000
-// 	extendLowerBound(10000000, 1) -> 20100000
-//	...
-// 	extendLowerBound(20100000, 4) -> 11001000
-func splitLowerBound(lowerBound cid.ID, depth uint) cid.ID {
-	return cid.FromInt(new(big.Int).SetBit(lowerBound.Int(), int(cid.Length*8-depth-1), 1))
-}
@@ -242,6 +242,32 @@ func TestTable_Peak_concurrent(t *testing.T) {
 	}
 }
 
+func TestTable_Remove(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, _, _ := NewTestWithPeers(rng, 64)
+	nPeers := rt.NumPeers()
+	ps := rt.Peak(cid.NewPseudoRandom(rng), 4)
+
+	for i, p := range ps {
+		removed := rt.Remove(p.ID())
+		assert.Equal(t, p, removed)
+		assert.Nil(t, rt.Get(p.ID()))
+		assert.Equal(t, nPeers-i-1, rt.NumPeers())
+	}
+
+	// check removing a missing peer is a no-op
+	assert.Nil(t, rt.Remove(ps[0].ID()))
+	assert.Nil(t, rt.Remove(cid.NewPseudoRandom(rng)))
+	assert.Equal(t, nPeers-len(ps), rt.NumPeers())
+
+	// check remaining peers are still ordered in their buckets
+	for _, b := range rt.(*table).buckets {
+		for id, i := range b.positions {
+			assert.Equal(t, id, b.activePeers[i].ID().String())
+		}
+	}
+}
+
 func TestTable_Sample(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	for n := 2; n <= 256; n *= 2 {
@@ -451,10 +477,14 @@ func TestTable_splitBucket(t *testing.T) {
 	}
 }
 
-func TestSplitLowerBound_Ok(t *testing.T) {
+func TestBucket_idRange_Split(t *testing.T) {
 	check := func(lowerBound cid.ID, depth uint, expected cid.ID) {
-		actual := splitLowerBound(lowerBound, depth)
-		assert.Equal(t, expected, actual)
+		b := &bucket{depth: depth, lowerBound: lowerBound, upperBound: cid.UpperBound}
+		left, right := b.idRange().Split(b.depth)
+		assert.Equal(t, lowerBound, left.Lower)
+		assert.Equal(t, expected, left.Upper)
+		assert.Equal(t, expected, right.Lower)
+		assert.Equal(t, cid.UpperBound, right.Upper)
 	}
 
 	check(cid.FromInt64(0), 0, newIDLsh(1, 255))                   // no prefix
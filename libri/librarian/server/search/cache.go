@@ -0,0 +1,129 @@
+package search
+
+import (
+	"time"
+
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// DefaultCacheSize is the default maximum number of search results cached.
+	DefaultCacheSize = uint(1024)
+
+	// DefaultCacheTTL is the default duration for which a search result is cached.
+	DefaultCacheTTL = 5 * time.Second
+)
+
+// CacheParameters define how recent search results are cached.
+type CacheParameters struct {
+	// Size is the maximum number of search results cached
+	Size uint
+
+	// TTL is the duration for which a search result is cached; zero value disables caching
+	TTL time.Duration
+}
+
+// NewDefaultCacheParameters creates an instance with default cache parameters.
+func NewDefaultCacheParameters() *CacheParameters {
+	return &CacheParameters{
+		Size: DefaultCacheSize,
+		TTL:  DefaultCacheTTL,
+	}
+}
+
+// CachedResult is the final result of a recent search.
+type CachedResult struct {
+	// Value found by the search, or nil if the search found only the closest peers
+	Value *api.Document
+
+	// Closest peers to the key found by the search
+	Closest []peer.Peer
+
+	// time after which the result is no longer valid
+	expiration time.Time
+}
+
+// ResultCache caches recent search results by their target key, so repeated searches for the
+// same key within a short window can reuse the previous result.
+type ResultCache interface {
+	// Get returns the unexpired cached result for the key and whether it exists.
+	Get(key cid.ID) (*CachedResult, bool)
+
+	// Add caches the result of the given search if it found either the value or the closest
+	// peers.
+	Add(s *Search)
+
+	// Remove removes any cached result for the key.
+	Remove(key cid.ID)
+
+	// Len gives the number of items in the cache.
+	Len() int
+}
+
+type resultCache struct {
+	recent *lru.Cache
+	ttl    time.Duration
+}
+
+// NewResultCache creates a new ResultCache with the given parameters.
+func NewResultCache(params *CacheParameters) (ResultCache, error) {
+	recent, err := lru.New(int(params.Size))
+	if err != nil {
+		return nil, err
+	}
+	return &resultCache{
+		recent: recent,
+		ttl:    params.TTL,
+	}, nil
+}
+
+// NewDefaultResultCache creates a new ResultCache with default parameters.
+func NewDefaultResultCache() ResultCache {
+	rc, err := NewResultCache(NewDefaultCacheParameters())
+	if err != nil {
+		// should never happen with default (positive) size
+		panic(err)
+	}
+	return rc
+}
+
+func (rc *resultCache) Get(key cid.ID) (*CachedResult, bool) {
+	value, in := rc.recent.Get(key.String())
+	if !in {
+		return nil, false
+	}
+	cached := value.(*CachedResult)
+	if time.Now().After(cached.expiration) {
+		rc.recent.Remove(key.String())
+		return nil, false
+	}
+	return cached, true
+}
+
+func (rc *resultCache) Add(s *Search) {
+	if rc.ttl == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.FoundValue() && !s.FoundClosestPeers() {
+		// only cache successful searches
+		return
+	}
+	rc.recent.Add(s.Key.String(), &CachedResult{
+		Value:      s.Result.Value,
+		Closest:    s.Result.Closest.Peers(),
+		expiration: time.Now().Add(rc.ttl),
+	})
+}
+
+func (rc *resultCache) Remove(key cid.ID) {
+	rc.recent.Remove(key.String())
+}
+
+func (rc *resultCache) Len() int {
+	return rc.recent.Len()
+}
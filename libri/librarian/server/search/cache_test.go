@@ -0,0 +1,101 @@
+package search
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/drausin/libri/libri/common/ecid"
+	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDefaultCacheParameters(t *testing.T) {
+	p := NewDefaultCacheParameters()
+	assert.NotZero(t, p.Size)
+	assert.NotZero(t, p.TTL)
+}
+
+func TestNewResultCache_err(t *testing.T) {
+	rc, err := NewResultCache(&CacheParameters{Size: 0, TTL: DefaultCacheTTL})
+	assert.NotNil(t, err)
+	assert.Nil(t, rc)
+}
+
+func TestResultCache_AddGetRemove(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rc := NewDefaultResultCache()
+
+	// search that found value is cached
+	s1 := NewSearch(ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng), NewDefaultParameters())
+	s1.Result.Value, _ = api.NewTestDocument(rng)
+	rc.Add(s1)
+	cached, in := rc.Get(s1.Key)
+	assert.True(t, in)
+	assert.Equal(t, s1.Result.Value, cached.Value)
+
+	// search that found closest peers is cached
+	s2 := newTestFoundClosestPeersSearch(rng)
+	rc.Add(s2)
+	cached, in = rc.Get(s2.Key)
+	assert.True(t, in)
+	assert.Nil(t, cached.Value)
+	assert.Equal(t, s2.Result.Closest.Len(), len(cached.Closest))
+	assert.Equal(t, 2, rc.Len())
+
+	// unfinished search isn't cached
+	s3 := NewSearch(ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng), NewDefaultParameters())
+	rc.Add(s3)
+	cached, in = rc.Get(s3.Key)
+	assert.False(t, in)
+	assert.Nil(t, cached)
+
+	rc.Remove(s1.Key)
+	_, in = rc.Get(s1.Key)
+	assert.False(t, in)
+	assert.Equal(t, 1, rc.Len())
+}
+
+func TestResultCache_Get_expired(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rc, err := NewResultCache(&CacheParameters{Size: 8, TTL: 10 * time.Millisecond})
+	assert.Nil(t, err)
+
+	s := newTestFoundClosestPeersSearch(rng)
+	rc.Add(s)
+	_, in := rc.Get(s.Key)
+	assert.True(t, in)
+
+	time.Sleep(20 * time.Millisecond)
+	cached, in := rc.Get(s.Key)
+	assert.False(t, in)
+	assert.Nil(t, cached)
+	assert.Zero(t, rc.Len())
+}
+
+func TestResultCache_Add_disabled(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rc, err := NewResultCache(&CacheParameters{Size: 8, TTL: 0})
+	assert.Nil(t, err)
+
+	s := newTestFoundClosestPeersSearch(rng)
+	rc.Add(s)
+	_, in := rc.Get(s.Key)
+	assert.False(t, in)
+}
+
+func newTestFoundClosestPeersSearch(rng *rand.Rand) *Search {
+	s := NewSearch(ecid.NewPseudoRandom(rng), cid.FromInt64(0), &Parameters{
+		NClosestResponses: 2,
+	})
+	err := s.Result.Closest.SafePushMany([]peer.Peer{
+		peer.New(cid.FromInt64(1), "", nil),
+		peer.New(cid.FromInt64(2), "", nil),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
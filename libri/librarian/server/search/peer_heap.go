@@ -193,3 +193,27 @@ func (pdh *peerDistanceHeap) Pop() interface{} {
 func less(sign int, x, y *big.Int) bool {
 	return sign*x.Cmp(y) < 0
 }
+
+// popPreferred removes and returns the next peer to query from the unqueried peers: among those in
+// the same distance bucket as the closest, the one with the lowest historical response error rate.
+// Error rates change as peers are queried, so they're compared only when selecting the next peer
+// rather than when ordering the heap.
+func popPreferred(unqueried ClosestPeers) peer.Peer {
+	next := heap.Pop(unqueried).(peer.Peer)
+	bucket := cid.DistanceBucket(unqueried.Distance(next))
+	nextErrorRate := next.Recorder().ErrorRate(peer.Response)
+	others := make([]peer.Peer, 0)
+	for unqueried.Len() > 0 && cid.DistanceBucket(unqueried.PeakDistance()) == bucket {
+		p := heap.Pop(unqueried).(peer.Peer)
+		if errorRate := p.Recorder().ErrorRate(peer.Response); errorRate < nextErrorRate {
+			others = append(others, next)
+			next, nextErrorRate = p, errorRate
+			continue
+		}
+		others = append(others, p)
+	}
+	for _, p := range others {
+		heap.Push(unqueried, p)
+	}
+	return next
+}
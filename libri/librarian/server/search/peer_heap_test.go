@@ -90,6 +90,51 @@ func testPeerDistanceHeap(t *testing.T, minHeap bool) {
 	}
 }
 
+func TestPopPreferred(t *testing.T) {
+	target := cid.FromInt64(0)
+
+	// peers 4-7 are all in the same distance bucket from the target
+	reliable := peer.New(cid.FromInt64(7), "", nil)
+	reliable.Recorder().Record(peer.Response, peer.Success)
+	flaky := peer.New(cid.FromInt64(4), "", nil)
+	flaky.Recorder().Record(peer.Response, peer.Error)
+	closer := peer.New(cid.FromInt64(3), "", nil)
+	closer.Recorder().Record(peer.Response, peer.Error)
+
+	cp := newClosestPeers(target, 8)
+	assert.Nil(t, cp.SafePushMany([]peer.Peer{flaky, reliable, closer}))
+
+	// peer in a closer bucket comes first regardless of its error rate
+	assert.Equal(t, closer, popPreferred(cp))
+
+	// among equally distant peers, the more reliable one comes first even though it's farther
+	assert.Equal(t, reliable, popPreferred(cp))
+	assert.True(t, cp.In(flaky.ID()))
+	assert.Equal(t, flaky, popPreferred(cp))
+	assert.Zero(t, cp.Len())
+}
+
+func TestClosestPeers_errorRateChange(t *testing.T) {
+	target := cid.FromInt64(0)
+	ps := []peer.Peer{
+		peer.New(cid.FromInt64(4), "", nil),
+		peer.New(cid.FromInt64(5), "", nil),
+		peer.New(cid.FromInt64(6), "", nil),
+		peer.New(cid.FromInt64(7), "", nil),
+	}
+	cp := newClosestPeers(target, 8)
+	assert.Nil(t, cp.SafePushMany(ps))
+
+	// error rates changing while peers are in the heap don't change its order, so the root
+	// always stays the closest
+	ps[0].Recorder().Record(peer.Response, peer.Error)
+	ps[3].Recorder().Record(peer.Response, peer.Success)
+	for _, p := range ps {
+		assert.Equal(t, 0, cp.PeakDistance().Cmp(cp.Distance(p)))
+		assert.Equal(t, p, heap.Pop(cp))
+	}
+}
+
 func TestClosestPeers_SafePush_capacity(t *testing.T) {
 	rng := rand.New(rand.NewSource(int64(0)))
 	target := cid.NewPseudoRandom(rng)
@@ -23,6 +23,9 @@ const (
 
 	// DefaultQueryTimeout is the timeout for each query to a peer.
 	DefaultQueryTimeout = 5 * time.Second
+
+	// DefaultOpTimeout is the default timeout for the entire search operation.
+	DefaultOpTimeout = 20 * time.Second
 )
 
 // Parameters defines the parameters of the search.
@@ -38,6 +41,10 @@ type Parameters struct {
 
 	// timeout for queries to individual peers
 	Timeout time.Duration
+
+	// timeout for the entire search operation, after which it returns the result found so far;
+	// zero value means no overall timeout
+	OpTimeout time.Duration
 }
 
 // NewDefaultParameters creates an instance with default parameters.
@@ -47,6 +54,7 @@ func NewDefaultParameters() *Parameters {
 		NMaxErrors:        DefaultNMaxErrors,
 		Concurrency:       DefaultConcurrency,
 		Timeout:           DefaultQueryTimeout,
+		OpTimeout:         DefaultOpTimeout,
 	}
 }
 
@@ -96,6 +104,13 @@ type Search struct {
 	// parameters defining the search
 	Params *Parameters
 
+	// time after which the search is considered timed out, which is set when the search starts
+	// running, or zero value if it never is
+	deadline time.Time
+
+	// whether the search looks only for the closest peers and never for the value
+	peersOnly bool
+
 	// mutex used to synchronizes reads and writes to this instance
 	mu sync.Mutex
 }
@@ -110,6 +125,20 @@ func NewSearch(selfID ecid.ID, key cid.ID, params *Parameters) *Search {
 	}
 }
 
+// NewPeersSearch creates a new Search instance that looks only for the closest peers to the key
+// and never for its value, finishing once it has found them.
+func NewPeersSearch(selfID ecid.ID, key cid.ID, params *Parameters) *Search {
+	s := NewSearch(selfID, key, params)
+	s.Request = client.NewFindPeersRequest(selfID, key, params.NClosestResponses)
+	s.peersOnly = true
+	return s
+}
+
+// PeersOnly returns whether the search looks only for the closest peers and never for the value.
+func (s *Search) PeersOnly() bool {
+	return s.peersOnly
+}
+
 // FoundClosestPeers returns whether the search has found the closest peers to a target. This event
 // occurs when it has received responses from the required number of peers, and the max distance of
 // those peers to the target is less than the min distance of the peers we haven't queried yet.
@@ -140,11 +169,49 @@ func (s *Search) Exhausted() bool {
 	return s.Result.Unqueried.Len() == 0
 }
 
+// TimedOut returns whether the search has exceeded its overall operation timeout.
+func (s *Search) TimedOut() bool {
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
 // Finished returns whether the search has finished, either because it has found the target or
-// closest peers or errored or exhausted the list of peers to query. This operation is concurrency
-// safe.
+// closest peers or errored or exhausted the list of peers to query or timed out. This operation
+// is concurrency safe.
 func (s *Search) Finished() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.FoundValue() || s.FoundClosestPeers() || s.Errored() || s.Exhausted()
+	return s.FoundValue() || s.FoundClosestPeers() || s.Errored() || s.Exhausted() ||
+		s.TimedOut()
+}
+
+// start sets the search deadline from its operation timeout as it starts running.
+func (s *Search) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadline = newDeadline(s.Params.OpTimeout)
+}
+
+// queryTimeout returns the timeout for the next query, which is the per-query timeout unless
+// less time remains before the search deadline.
+func (s *Search) queryTimeout() time.Duration {
+	return QueryTimeout(s.Params.Timeout, s.deadline)
+}
+
+// QueryTimeout returns the given per-query timeout, capped by the time remaining before the
+// deadline (if it is non-zero).
+func QueryTimeout(timeout time.Duration, deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return timeout
+	}
+	if remaining := time.Until(deadline); remaining < timeout {
+		return remaining
+	}
+	return timeout
+}
+
+func newDeadline(opTimeout time.Duration) time.Time {
+	if opTimeout == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(opTimeout)
 }
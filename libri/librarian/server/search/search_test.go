@@ -2,6 +2,7 @@ package search
 
 import (
 	"testing"
+	"time"
 
 	"math/rand"
 
@@ -20,6 +21,23 @@ func TestNewDefaultParameters(t *testing.T) {
 	assert.NotZero(t, p.NMaxErrors)
 	assert.NotZero(t, p.Concurrency)
 	assert.NotZero(t, p.Timeout)
+	assert.NotZero(t, p.OpTimeout)
+}
+
+func TestNewPeersSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID, key := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
+	params := NewDefaultParameters()
+
+	s1 := NewSearch(selfID, key, params)
+	assert.False(t, s1.PeersOnly())
+	assert.False(t, s1.Request.PeersOnly)
+
+	s2 := NewPeersSearch(selfID, key, params)
+	assert.True(t, s2.PeersOnly())
+	assert.True(t, s2.Request.PeersOnly)
+	assert.Equal(t, key.Bytes(), s2.Request.Key)
+	assert.Equal(t, uint32(params.NClosestResponses), s2.Request.NumPeers)
 }
 
 func TestSearch_FoundClosestPeers(t *testing.T) {
@@ -112,3 +130,42 @@ func TestSearch_Exhausted(t *testing.T) {
 	assert.Nil(t, err)
 	assert.False(t, search1.Exhausted())
 }
+
+func TestSearch_TimedOut(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	target, selfID := cid.FromInt64(0), ecid.NewPseudoRandom(rng)
+
+	// never times out w/o an OpTimeout
+	search1 := NewSearch(selfID, target, &Parameters{})
+	assert.False(t, search1.TimedOut())
+
+	// not timed out yet
+	search2 := NewSearch(selfID, target, NewDefaultParameters())
+	assert.False(t, search2.TimedOut())
+
+	// not timed out b/c deadline only starts when search does
+	search3 := NewSearch(selfID, target, &Parameters{OpTimeout: time.Millisecond})
+	err := search3.Result.Unqueried.SafePush(peer.New(cid.FromInt64(1), "", nil))
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond)
+	assert.False(t, search3.TimedOut())
+
+	// timed out b/c deadline has passed
+	search3.start()
+	time.Sleep(2 * time.Millisecond)
+	assert.True(t, search3.TimedOut())
+	assert.True(t, search3.Finished())
+}
+
+func TestQueryTimeout(t *testing.T) {
+	timeout := 5 * time.Second
+
+	// no deadline, so just use query timeout
+	assert.Equal(t, timeout, QueryTimeout(timeout, time.Time{}))
+
+	// deadline far away, so just use query timeout
+	assert.Equal(t, timeout, QueryTimeout(timeout, time.Now().Add(time.Minute)))
+
+	// deadline sooner than query timeout
+	assert.True(t, QueryTimeout(timeout, time.Now().Add(time.Second)) <= time.Second)
+}
@@ -2,13 +2,13 @@ package search
 
 import (
 	"bytes"
-	"container/heap"
 	"sync"
 	"errors"
 	cid "github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/peer"
+	"golang.org/x/net/context"
 )
 
 // ErrTooManyFindErrors indicates when a search has encountered too many Find request errors.
@@ -18,8 +18,9 @@ var (
 
 // Searcher executes searches for particular keys.
 type Searcher interface {
-	// Search executes a search from a list of seeds.
-	Search(search *Search, seeds []peer.Peer) error
+	// Search executes a search from a list of seeds. Cancelling the context stops any remaining
+	// queries to peers.
+	Search(ctx context.Context, search *Search, seeds []peer.Peer) error
 }
 
 type searcher struct {
@@ -47,7 +48,8 @@ func NewDefaultSearcher(signer client.Signer) Searcher {
 	)
 }
 
-func (s *searcher) Search(search *Search, seeds []peer.Peer) error {
+func (s *searcher) Search(ctx context.Context, search *Search, seeds []peer.Peer) error {
+	search.start()
 	if err := search.Result.Unqueried.SafePushMany(seeds); err != nil {
 		panic(err)  // should never happen
 	}
@@ -55,20 +57,30 @@ func (s *searcher) Search(search *Search, seeds []peer.Peer) error {
 	var wg sync.WaitGroup
 	for c := uint(0); c < search.Params.Concurrency; c++ {
 		wg.Add(1)
-		go s.searchWork(search, &wg)
+		go s.searchWork(ctx, search, &wg)
 	}
 	wg.Wait()
 
 	return search.Result.FatalErr
 }
 
-func (s *searcher) searchWork(search *Search, wg *sync.WaitGroup) {
+func (s *searcher) searchWork(ctx context.Context, search *Search, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for !search.Finished() {
+		select {
+		case <-ctx.Done():
+			search.mu.Lock()
+			if search.Result.FatalErr == nil {
+				search.Result.FatalErr = ctx.Err()
+			}
+			search.mu.Unlock()
+			return
+		default:
+		}
 
 		// get next peer to query
 		search.mu.Lock()
-		next := heap.Pop(search.Result.Unqueried).(peer.Peer)
+		next := popPreferred(search.Result.Unqueried)
 		nextIDStr := next.ID().String()
 		if _, in := search.Result.Responded[nextIDStr]; in {
 			search.mu.Unlock()
@@ -81,10 +93,15 @@ func (s *searcher) searchWork(search *Search, wg *sync.WaitGroup) {
 		search.mu.Unlock()
 
 		// do the query
-		response, err := s.query(next.Connector(), search)
+		response, err := s.query(ctx, next.Connector(), search)
 		if err != nil {
-			// if we had an issue querying, skip to next peer
 			search.mu.Lock()
+			if search.TimedOut() {
+				// query was cut short by the search deadline rather than failing
+				search.mu.Unlock()
+				continue
+			}
+			// if we had an issue querying, skip to next peer
 			search.Result.Errored[nextIDStr] = err
 			next.Recorder().Record(peer.Response, peer.Error)
 			if search.Errored() {
@@ -97,6 +114,11 @@ func (s *searcher) searchWork(search *Search, wg *sync.WaitGroup) {
 		next.Recorder().Record(peer.Response, peer.Success)
 		search.mu.Unlock()
 
+		if search.PeersOnly() {
+			// ignore any value from peers that return it anyway
+			response.Value = nil
+		}
+
 		// process the heap's response
 		search.mu.Lock()
 		err = s.rp.Process(response, search.Result)
@@ -125,9 +147,10 @@ func (s *searcher) searchWork(search *Search, wg *sync.WaitGroup) {
 	}
 }
 
-func (s *searcher) query(pConn api.Connector, search *Search) (*api.FindResponse, error) {
-	ctx, cancel, err := client.NewSignedTimeoutContext(s.signer, search.Request,
-		search.Params.Timeout)
+func (s *searcher) query(ctx context.Context, pConn api.Connector, search *Search) (
+	*api.FindResponse, error) {
+	ctx, cancel, err := client.NewSignedTimeoutContextFrom(ctx, s.signer, search.Request,
+		search.queryTimeout())
 	if err != nil {
 		return nil, err
 	}
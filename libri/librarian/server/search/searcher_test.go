@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
 	"errors"
 
@@ -47,7 +48,7 @@ func TestSearcher_Search_ok(t *testing.T) {
 		seeds := NewTestSeeds(peers, selfPeerIdxs)
 
 		// do the search!
-		err := searcher.Search(search, seeds)
+		err := searcher.Search(context.Background(), search, seeds)
 
 		// checks
 		assert.Nil(t, err)
@@ -90,7 +91,7 @@ func TestSearcher_Search_queryErr(t *testing.T) {
 	searcherImpl.(*searcher).querier = &timeoutQuerier{}
 
 	// do the search!
-	err := searcherImpl.Search(search, seeds)
+	err := searcherImpl.Search(context.Background(), search, seeds)
 
 	// checks
 	assert.Equal(t, ErrTooManyFindErrors, err)
@@ -104,6 +105,101 @@ func TestSearcher_Search_queryErr(t *testing.T) {
 	assert.Equal(t, 0, len(search.Result.Responded))
 }
 
+// valueFindQuerier returns both the closest peers and a value, as if every peer had the value.
+type valueFindQuerier struct {
+	TestFindQuerier
+	value *api.Document
+}
+
+func (f *valueFindQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.FindRequest,
+	opts ...grpc.CallOption) (*api.FindResponse, error) {
+	rp, err := f.TestFindQuerier.Query(ctx, pConn, rq, opts...)
+	if err != nil {
+		return nil, err
+	}
+	rp.Value = f.value
+	return rp, nil
+}
+
+func TestSearcher_Search_peersOnly(t *testing.T) {
+	n, nClosestResponses := 32, uint(8)
+	rng := rand.New(rand.NewSource(int64(n)))
+	peers, peersMap, selfPeerIdxs, selfID := NewTestPeers(rng, n)
+	value, key := api.NewTestDocument(rng)
+	searcherImpl := NewTestSearcher(peersMap)
+	searcherImpl.(*searcher).querier = &valueFindQuerier{value: value}
+
+	search := NewPeersSearch(selfID, key, &Parameters{
+		NClosestResponses: nClosestResponses,
+		NMaxErrors:        DefaultNMaxErrors,
+		Concurrency:       3,
+		Timeout:           DefaultQueryTimeout,
+	})
+	err := searcherImpl.Search(context.Background(), search, NewTestSeeds(peers, selfPeerIdxs))
+
+	// checks that search ignores the value and converges on the closest peers
+	assert.Nil(t, err)
+	assert.False(t, search.FoundValue())
+	assert.Nil(t, search.Result.Value)
+	assert.True(t, search.FoundClosestPeers())
+	assert.Equal(t, int(nClosestResponses), search.Result.Closest.Len())
+}
+
+// slowQuerier blocks until the request context is done, simulating unresponsive peers
+type slowQuerier struct{}
+
+func (f *slowQuerier) Query(ctx context.Context, pConn api.Connector, fr *api.FindRequest,
+	opts ...grpc.CallOption) (*api.FindResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSearcher_Search_opTimeout(t *testing.T) {
+	searcherImpl, search, selfPeerIdxs, peers := newTestSearch()
+	seeds := NewTestSeeds(peers, selfPeerIdxs)
+	searcherImpl.(*searcher).querier = &slowQuerier{}
+
+	// make the whole search time out well before any individual query would, and check that
+	// queries cut short by it aren't counted as errors
+	search.Params.NMaxErrors = 0
+	search.Params.OpTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	err := searcherImpl.Search(context.Background(), search, seeds)
+
+	// checks
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) < search.Params.Timeout)
+	assert.True(t, search.TimedOut())
+	assert.True(t, search.Finished())
+	assert.False(t, search.FoundClosestPeers())
+	assert.Nil(t, search.Result.FatalErr)
+	assert.Len(t, search.Result.Errored, 0)
+}
+
+func TestSearcher_Search_cancelled(t *testing.T) {
+	searcherImpl, search, selfPeerIdxs, peers := newTestSearch()
+	seeds := NewTestSeeds(peers, selfPeerIdxs)
+	searcherImpl.(*searcher).querier = &slowQuerier{}
+	search.Params.NMaxErrors = uint(len(seeds))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(25 * time.Millisecond)
+		cancel()
+	}()
+
+	// check search stops as soon as context is cancelled rather than after query timeouts
+	start := time.Now()
+	err := searcherImpl.Search(ctx, search, seeds)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(start) < search.Params.Timeout)
+	assert.True(t, search.Errored())
+	assert.True(t, search.Finished())
+	assert.False(t, search.FoundClosestPeers())
+	assert.Equal(t, 0, search.Result.Closest.Len())
+}
+
 type errResponseProcessor struct{}
 
 func (erp *errResponseProcessor) Process(rp *api.FindResponse, result *Result) error {
@@ -118,7 +214,7 @@ func TestSearcher_Search_rpErr(t *testing.T) {
 	searcherImpl.(*searcher).rp = &errResponseProcessor{}
 
 	// do the search!
-	err := searcherImpl.Search(search, seeds)
+	err := searcherImpl.Search(context.Background(), search, seeds)
 
 	// checks
 	assert.NotNil(t, err)
@@ -173,7 +269,7 @@ func TestSearcher_query_ok(t *testing.T) {
 	}
 	connClient := api.NewConnector(nil) // won't actually be uses since we're mocking the finder
 
-	rp, err := s.query(connClient, search)
+	rp, err := s.query(context.Background(), connClient, search)
 	assert.Nil(t, err)
 	assert.NotNil(t, rp.Metadata.RequestId)
 	assert.Nil(t, rp.Value)
@@ -212,7 +308,7 @@ func TestSearcher_query_err(t *testing.T) {
 		// use querier that simulates a timeout
 		querier: &timeoutQuerier{},
 	}
-	rp1, err := s1.query(connClient, search)
+	rp1, err := s1.query(context.Background(), connClient, search)
 	assert.Nil(t, rp1)
 	assert.NotNil(t, err)
 
@@ -223,14 +319,14 @@ func TestSearcher_query_err(t *testing.T) {
 			rng: rng,
 		},
 	}
-	rp2, err := s2.query(connClient, search)
+	rp2, err := s2.query(context.Background(), connClient, search)
 	assert.Nil(t, rp2)
 	assert.NotNil(t, err)
 
 	s3 := &searcher{
 		signer: &client.TestErrSigner{},
 	}
-	rp3, err := s3.query(connClient, search)
+	rp3, err := s3.query(context.Background(), connClient, search)
 	assert.Nil(t, rp3)
 	assert.NotNil(t, err)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/drausin/libri/libri/common/ecid"
 	cid "github.com/drausin/libri/libri/common/id"
+	"github.com/drausin/libri/libri/common/storage"
 	"github.com/drausin/libri/libri/librarian/api"
 	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/peer"
@@ -43,6 +44,12 @@ func (f *TestFromer) FromAPI(apiAddress *api.PeerAddress) peer.Peer {
 	return f.Peers[cid.FromBytes(apiAddress.PeerId).String()]
 }
 
+// FromStored mocks creating a new peer.Peer instance, instead looking up an existing peer stored
+// in the TestFromer instance.
+func (f *TestFromer) FromStored(stored *storage.Peer) peer.Peer {
+	return f.Peers[cid.FromBytes(stored.Id).String()]
+}
+
 // NewTestSearcher creates a new Searcher instance with a FindQuerier and FindResponseProcessor that
 // each just return fixed addresses and peers, respectively.
 func NewTestSearcher(peersMap map[string]peer.Peer) Searcher {
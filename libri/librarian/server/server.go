@@ -1,10 +1,12 @@
 package server
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/drausin/libri/libri/common/db"
 	"github.com/drausin/libri/libri/common/ecid"
@@ -15,20 +17,64 @@ import (
 	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/introduce"
 	"github.com/drausin/libri/libri/librarian/server/peer"
+	"github.com/drausin/libri/libri/librarian/server/replicate"
 	"github.com/drausin/libri/libri/librarian/server/routing"
 	"github.com/drausin/libri/libri/librarian/server/search"
 	"github.com/drausin/libri/libri/librarian/server/store"
-	"github.com/willf/bloom"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/health"
 )
 
+// ErrSubscribeNotAllowed indicates when a client's public key isn't among those allowed to
+// subscribe.
+var ErrSubscribeNotAllowed = errors.New("client not allowed to subscribe")
+
+// ErrPublicationLogDisabled indicates when logged publications are replayed or scanned without the
+// publication log being enabled.
+var ErrPublicationLogDisabled = errors.New("publication log not enabled")
+
+// ErrUnexpectedRotationKey indicates when an introduction's key rotation has a new public key
+// other than the one signing the request.
+var ErrUnexpectedRotationKey = errors.New("key rotation new public key does not match request")
+
+// ErrDeletedDocument indicates when a document can't be stored because its author has deleted it
+// with a tombstone.
+var ErrDeletedDocument = errors.New("document deleted by its author")
+
+// ErrUnexpectedTombstoneAuthor indicates when a tombstone's author public key doesn't match that
+// of the document it deletes.
+var ErrUnexpectedTombstoneAuthor = errors.New("tombstone author does not match document author")
+
+// ErrRotateWithSession indicates when the server is configured to both rotate its peer ID and use
+// a session key, since rotating requires the offline identity private key.
+var ErrRotateWithSession = errors.New("cannot rotate peer ID when using a session key")
+
+// ErrSessionWithSharedSecret indicates when the server is configured to sign requests with both a
+// session key and a shared secret.
+var ErrSessionWithSharedSecret = errors.New("cannot use both a session key and a shared secret")
+
+// ErrRotateWithKeySigner indicates when the server is configured to both rotate its peer ID and
+// use a key signer, since the key signer's key is fixed.
+var ErrRotateWithKeySigner = errors.New("cannot rotate peer ID when using a key signer")
+
+// ErrKeySignerConflict indicates when the server is configured to sign requests with both a key
+// signer and either a session key or a shared secret.
+var ErrKeySignerConflict = errors.New(
+	"cannot use a key signer with either a session key or a shared secret")
+
+// ErrInsufficientPeerIDDifficulty indicates when a peer ID doesn't have the difficulty required to
+// be added to the routing table.
+var ErrInsufficientPeerIDDifficulty = errors.New("insufficient peer ID difficulty")
+
 // Librarian is the main service of a single peer in the peer to peer network.
 type Librarian struct {
 	// SelfID is the random 256-bit identification number of this node in the hash table
 	selfID ecid.ID
 
+	// links selfID to the previous peer ID it was rotated from, if any
+	rotation *api.KeyRotation
+
 	// Config holds the configuration parameters of the server
 	config *Config
 
@@ -41,21 +87,52 @@ type Librarian struct {
 	// executes searches for peers and keys
 	searcher search.Searcher
 
+	// caches the results of recent searches for keys
+	searchCache search.ResultCache
+
 	// executes stores for key/value
 	storer store.Storer
 
+	// deduplicates concurrent stores for the same key/value
+	storeFlights store.InFlight
+
+	// verifies and repairs replicas of stored documents
+	replicator replicate.Replicator
+
 	// manages subscriptions from other peers
 	subscribeFrom subscribe.From
 
 	// manages subscriptions to other peers
 	subscribeTo subscribe.To
 
+	// POSTs publications matching its subscription to HTTP endpoints
+	webhook subscribe.Webhook
+
 	// RecentPubs is an LRU cache of recent publications librarian has received
 	RecentPubs subscribe.RecentPublications
 
+	// durable log of publications librarian has received
+	pubLog subscribe.PublicationLog
+
+	// durable log of mutating requests librarian has received, if enabled
+	auditLog AuditLog
+
+	// document keys librarian refuses to store or serve
+	denylist Denylist
+
+	// SL for access hashes of private documents, if access control is enabled
+	accessSL storage.NamespaceStorerLoader
+
 	// verifies requests from peers
 	rqv RequestVerifier
 
+	// limits the rate of requests from each peer
+	rateLimiter PeerRateLimiter
+
+	// limit the simultaneous Subscribe streams and Get and Put operations from each peer
+	subLimiter ConcurrencyLimiter
+	opLimiter  ConcurrencyLimiter
+
 	// key-value store DB used for all external storage
 	db db.KVDB
 
@@ -77,6 +154,9 @@ type Librarian struct {
 	// signs requests
 	signer client.Signer
 
+	// shares connections to peers among the searcher's, storer's, and introducer's queries
+	conns client.ConnManager
+
 	// routing table of peers
 	rt routing.Table
 
@@ -90,10 +170,20 @@ type Librarian struct {
 	stop chan struct{}
 }
 
-var newPublicationsSlack = 16
-
 // NewLibrarian creates a new librarian instance.
 func NewLibrarian(config *Config, logger *zap.Logger) (*Librarian, error) {
+	if config.RotatePeerID && config.SessionKey != nil {
+		return nil, ErrRotateWithSession
+	}
+	if config.SessionKey != nil && config.SharedSecret != nil {
+		return nil, ErrSessionWithSharedSecret
+	}
+	if config.KeySigner != nil && config.RotatePeerID {
+		return nil, ErrRotateWithKeySigner
+	}
+	if config.KeySigner != nil && (config.SessionKey != nil || config.SharedSecret != nil) {
+		return nil, ErrKeySignerConflict
+	}
 	rdb, err := db.NewRocksDB(config.DbDir)
 	if err != nil {
 		logger.Error("unable to init RocksDB", zap.Error(err))
@@ -102,47 +192,169 @@ func NewLibrarian(config *Config, logger *zap.Logger) (*Librarian, error) {
 	serverSL := storage.NewServerKVDBStorerLoader(rdb)
 	documentSL := storage.NewDocumentKVDBStorerLoader(rdb)
 
-	// get peer ID and immediately save it so subsequent restarts have it
-	peerID, err := loadOrCreatePeerID(logger, serverSL)
+	var peerID ecid.ID
+	if config.SessionKey != nil {
+		peerID, err = loadSessionPeerID(logger, config.SessionKey, config.SessionCertificate)
+	} else if config.KeySigner != nil {
+		peerID, err = loadKeySignerPeerID(logger, config.KeySigner)
+	} else {
+		// get peer ID and immediately save it so subsequent restarts have it
+		peerID, err = loadOrCreatePeerID(logger, serverSL, config.PeerIDCurve,
+			config.PeerIDDifficulty)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rotation *api.KeyRotation
+	if config.RotatePeerID {
+		peerID, rotation, err = rotatePeerID(logger, serverSL, peerID, config.Routing,
+			config.PeerIDCurve, config.PeerIDDifficulty)
+	} else {
+		rotation, err = loadPeerIDRotation(serverSL, peerID)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	rt, err := loadOrCreateRoutingTable(logger, serverSL, peerID, config.Routing)
+	clientTLS, err := config.clientTLSConfig()
+	if err != nil {
+		logger.Error("failed to load peer TLS credentials", zap.Error(err))
+		return nil, err
+	}
+	fromer := peer.NewSecureFromer(clientTLS, config.ClientInterceptors)
+	rt, err := loadOrCreateRoutingTable(logger, serverSL, peerID, config.Routing, fromer)
 	if err != nil {
 		return nil, err
 	}
 
-	signer := client.NewSigner(peerID.Key())
-	searcher := search.NewDefaultSearcher(signer)
-	newPubs := make(chan *subscribe.KeyedPub, newPublicationsSlack)
+	signer := client.NewIDSigner(peerID)
+	if config.SessionKey != nil {
+		// identity private key is offline, so sign with the session key and its certificate
+		if signer, err = client.NewSessionSigner(config.SessionKey,
+			config.SessionCertificate); err != nil {
+			return nil, err
+		}
+	}
+	if config.KeySigner != nil {
+		// private key lives outside the process, so sign via the key signer
+		if signer, err = client.NewKeySigner(config.KeySigner); err != nil {
+			return nil, err
+		}
+	}
+	rqv := NewRequestVerifier(config.Verify)
+	if config.SharedSecret != nil {
+		// every peer and client in the private cluster signs with the same shared secret
+		if signer, err = client.NewHMACSigner(config.SharedSecret); err != nil {
+			return nil, err
+		}
+		if rqv, err = NewHMACRequestVerifier(config.SharedSecret, config.Verify); err != nil {
+			return nil, err
+		}
+	}
+	conns := client.NewSecureConnManager(client.DefaultConnIdleTimeout, clientTLS,
+		config.ClientInterceptors)
+	breaker := client.NewDefaultCircuitBreaker()
+	findQuerier := client.NewBreakerFindQuerier(
+		client.NewSharedFindQuerier(client.NewFindQuerier(), conns), breaker)
+	storeQuerier := client.NewBreakerStoreQuerier(
+		client.NewSharedStoreQuerier(client.NewStoreQuerier(), conns), breaker)
+	introQuerier := client.NewSharedIntroduceQuerier(client.NewIntroduceQuerier(), conns)
+	if config.QueryObserver != nil {
+		findQuerier = client.NewObservedFindQuerier(findQuerier, config.QueryObserver)
+		storeQuerier = client.NewObservedStoreQuerier(storeQuerier, config.QueryObserver)
+		introQuerier = client.NewObservedIntroduceQuerier(introQuerier, config.QueryObserver)
+	}
+	searcher := search.NewSearcher(signer, findQuerier,
+		search.NewResponseProcessor(fromer))
+	searchCache, err := search.NewResultCache(config.SearchCache)
+	if err != nil {
+		return nil, err
+	}
+	newPubs := make(chan *subscribe.KeyedPub, config.SubscribeTo.NewQueueSize)
 
 	recentPubs, err := subscribe.NewRecentPublications(config.SubscribeTo.RecentCacheSize)
 	if err != nil {
 		return nil, err
 	}
+	var pubLog subscribe.PublicationLog
+	if config.PublicationLog {
+		if pubLog, err = subscribe.NewPublicationLog(
+			storage.NewPublicationKVDBStorerLoader(rdb), config.SubscribeTo.LogMaxLength,
+		); err != nil {
+			return nil, err
+		}
+	}
+	denylist, err := loadDenylist(logger, serverSL, config.Denylist)
+	if err != nil {
+		return nil, err
+	}
+	var accessSL storage.NamespaceStorerLoader
+	if config.AccessControl {
+		accessSL = storage.NewAccessKVDBStorerLoader(rdb)
+	}
+	var auditLog AuditLog
+	if config.AuditLog {
+		if auditLog, err = NewAuditLog(storage.NewAuditKVDBStorerLoader(rdb)); err != nil {
+			return nil, err
+		}
+	}
 	clientBalancer := routing.NewClientBalancer(rt)
+	verifier := newEnvelopeVerifier(peerID, documentSL, rt, searcher, searchCache, config.Search)
 	subscribeTo := subscribe.NewTo(config.SubscribeTo, logger, peerID, clientBalancer, signer,
-		recentPubs, newPubs)
+		verifier, recentPubs, pubLog, newPubs)
+	if err := subscribeTo.Restore(serverSL); err != nil {
+		return nil, err
+	}
+	subscribeFrom := subscribe.NewFrom(config.SubscribeFrom, logger, newPubs)
+	webhook, err := subscribe.NewWebhook(config.Webhook, logger, subscribeFrom, peerID.ID())
+	if err != nil {
+		return nil, err
+	}
+	rateLimiter, err := NewPeerRateLimiter(config.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	subLimiter := NewConcurrencyLimiter(config.Concurrency.MaxSubscriptionsPerPeer,
+		config.Concurrency.MaxSubscriptions)
+	opLimiter := NewConcurrencyLimiter(config.Concurrency.MaxOperationsPerPeer,
+		config.Concurrency.MaxOperations)
+	storer := store.NewStorer(signer, searcher, storeQuerier)
+	replicator := replicate.NewReplicator(peerID, config.Replicate, rt, documentSL, accessSL,
+		signer, storer, logger)
+	introducer := introduce.NewIntroducer(signer, introQuerier,
+		introduce.NewResponseProcessor(fromer, peerID.ID()))
 
 	return &Librarian{
 		selfID:        peerID,
+		rotation:      rotation,
 		config:        config,
 		apiSelf:       api.FromAddress(peerID.ID(), config.PublicName, config.PublicAddr),
-		introducer:    introduce.NewDefaultIntroducer(signer, peerID.ID()),
+		introducer:    introducer,
 		searcher:      searcher,
-		storer:        store.NewStorer(signer, searcher, client.NewStoreQuerier()),
-		subscribeFrom: subscribe.NewFrom(config.SubscribeFrom, logger, newPubs),
+		searchCache:   searchCache,
+		storer:        storer,
+		storeFlights:  store.NewInFlight(),
+		replicator:    replicator,
+		subscribeFrom: subscribeFrom,
 		subscribeTo:   subscribeTo,
+		webhook:       webhook,
 		RecentPubs:    recentPubs,
-		rqv:           NewRequestVerifier(),
+		pubLog:        pubLog,
+		auditLog:      auditLog,
+		denylist:      denylist,
+		accessSL:      accessSL,
+		rqv:           rqv,
+		rateLimiter:   rateLimiter,
+		subLimiter:    subLimiter,
+		opLimiter:     opLimiter,
 		db:            rdb,
 		serverSL:      serverSL,
 		documentSL:    documentSL,
 		kc:            storage.NewExactLengthChecker(storage.EntriesKeyLength),
 		kvc:           storage.NewHashKeyValueChecker(),
-		fromer:        peer.NewFromer(),
+		fromer:        fromer,
 		signer:        signer,
+		conns:         conns,
 		rt:            rt,
 		logger:        logger,
 		health:        health.NewServer(),
@@ -170,6 +382,16 @@ func (l *Librarian) Introduce(ctx context.Context, rq *api.IntroduceRequest) (
 	if requester.ID().Cmp(requesterID) != 0 {
 		return nil, errors.New("stated client peer ID does not match signature")
 	}
+	if err := l.checkPeerIDDifficulty(requesterID); err != nil {
+		l.record(requesterID, peer.Request, peer.Error)
+		return nil, err
+	}
+	if rq.Rotation != nil {
+		if err := l.acceptRotation(rq.Rotation, rq.Metadata, requester); err != nil {
+			l.logger.Debug("key rotation error", zap.String("error", err.Error()))
+			return nil, err
+		}
+	}
 	l.record(requesterID, peer.Request, peer.Success)
 
 	// add peer to routing table (if space)
@@ -190,7 +412,8 @@ func (l *Librarian) Introduce(ctx context.Context, rq *api.IntroduceRequest) (
 	}, nil
 }
 
-// Find returns either the value at a given target or the peers closest to it.
+// Find returns either the value at a given target or the peers closest to it. Presence-only
+// requests get only whether a value that isn't a tombstone is stored rather than the value.
 func (l *Librarian) Find(ctx context.Context, rq *api.FindRequest) (*api.FindResponse, error) {
 	requesterID, err := l.checkRequestAndKey(ctx, rq, rq.Metadata, rq.Key)
 	if err != nil {
@@ -198,10 +421,33 @@ func (l *Librarian) Find(ctx context.Context, rq *api.FindRequest) (*api.FindRes
 	}
 	l.record(requesterID, peer.Request, peer.Success)
 
-	value, err := l.documentSL.Load(cid.FromBytes(rq.Key))
-	if err != nil {
-		// something went wrong during load
-		return nil, err
+	key := cid.FromBytes(rq.Key)
+	var value *api.Document
+	if !rq.PeersOnly && !l.denied(key) {
+		value, err = l.documentSL.Load(key)
+		if err != nil {
+			// something went wrong during load
+			return nil, err
+		}
+	}
+	if value != nil && value.GetTombstone() == nil && rq.PresenceOnly {
+		// report the value is stored without returning it, so replicas of private values can
+		// be verified without access to them
+		return &api.FindResponse{
+			Metadata: l.NewResponseMetadata(rq.Metadata),
+			Stored:   true,
+		}, nil
+	}
+	if value != nil && value.GetTombstone() == nil {
+		// tombstones are returned regardless of access, so replicas of private values are purged
+		if err := l.checkAccess(key, rq.AccessProof); err != nil {
+			// return the closest peers, as if the value wasn't stored
+			l.logger.Debug("denied access to private value",
+				zap.String("key", key.String()),
+				zap.Error(err),
+			)
+			value = nil
+		}
 	}
 
 	// we have the value, so return it
@@ -213,7 +459,6 @@ func (l *Librarian) Find(ctx context.Context, rq *api.FindRequest) (*api.FindRes
 	}
 
 	// otherwise, return the peers closest to the key
-	key := cid.FromBytes(rq.Key)
 	closest := l.rt.Peak(key, uint(rq.NumPeers))
 	return &api.FindResponse{
 		Metadata: l.NewResponseMetadata(rq.Metadata),
@@ -224,15 +469,39 @@ func (l *Librarian) Find(ctx context.Context, rq *api.FindRequest) (*api.FindRes
 // Store stores the value.
 func (l *Librarian) Store(ctx context.Context, rq *api.StoreRequest) (
 	*api.StoreResponse, error) {
-	requesterID, err := l.checkRequestAndKeyValue(ctx, rq, rq.Metadata, rq.Key, rq.Value)
+	rp, err := l.store(ctx, rq)
+	l.audit(AuditStore, rq.Metadata, rq.Key, err)
+	return rp, err
+}
+
+func (l *Librarian) store(ctx context.Context, rq *api.StoreRequest) (
+	*api.StoreResponse, error) {
+	requesterID, err := l.checkRequestAndKeyValue(ctx, rq, rq.Metadata, rq.Key, rq.Value,
+		l.config.Store.ProofOfWorkBits)
 	if err != nil {
 		return nil, err
 	}
 	l.record(requesterID, peer.Request, peer.Success)
+	if l.denied(cid.FromBytes(rq.Key)) {
+		return nil, ErrDeniedKey
+	}
+	if err := validateAccessHash(rq.AccessHash); err != nil {
+		return nil, err
+	}
+	if err := l.checkTombstone(cid.FromBytes(rq.Key), rq.Value); err != nil {
+		return nil, err
+	}
 
+	// store the access hash first so a private value is never stored without it
+	if err := l.storeAccessHash(cid.FromBytes(rq.Key), rq.AccessHash); err != nil {
+		return nil, err
+	}
 	if err := l.documentSL.Store(cid.FromBytes(rq.Key), rq.Value); err != nil {
 		return nil, err
 	}
+
+	// any recent search result for the key no longer reflects the stored value
+	l.searchCache.Remove(cid.FromBytes(rq.Key))
 	if err := l.subscribeTo.Send(api.GetPublication(rq.Key, rq.Value)); err != nil {
 		return nil, err
 	}
@@ -255,18 +524,50 @@ func (l *Librarian) Get(ctx context.Context, rq *api.GetRequest) (*api.GetRespon
 		return nil, err
 	}
 	l.record(requesterID, peer.Request, peer.Success)
+	release, err := acquire(l.opLimiter, requesterID, ErrTooManyOperations)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	key := cid.FromBytes(rq.Key)
+	if l.denied(key) {
+		// return the nil value, as if the value wasn't found
+		l.logger.Info("denied get", zap.String("key", key.String()))
+		return &api.GetResponse{
+			Metadata: l.NewResponseMetadata(rq.Metadata),
+		}, nil
+	}
+
+	// searches with access proofs may find private values, which must not be served to other
+	// requesters from the cache
+	cacheable := rq.AccessProof == nil
+	if cached, in := l.searchCache.Get(key); in && cacheable {
+		// return the value (or lack thereof) found by a recent search for the same key
+		l.logger.Info("got cached search result",
+			zap.String("key", key.String()),
+			zap.Bool("found_value", cached.Value != nil),
+		)
+		return &api.GetResponse{
+			Metadata: l.NewResponseMetadata(rq.Metadata),
+			Value:    cached.Value,
+		}, nil
+	}
+
 	s := search.NewSearch(l.selfID, key, l.config.Search)
-	seeds := l.rt.Peak(key, s.Params.Concurrency)
-	err = l.searcher.Search(s, seeds)
+	s.Request.AccessProof = rq.AccessProof
+	seeds := l.peakSeeds(key, s.Params.Concurrency, requesterID)
+	err = l.searcher.Search(ctx, s, seeds)
 	if err != nil {
 		return nil, err
 	}
 
 	// add found peers to routing table
 	for _, p := range s.Result.Closest.Peers() {
-		l.rt.Push(p)
+		l.pushPeer(p)
+	}
+	if cacheable {
+		l.searchCache.Add(s)
 	}
 
 	if s.FoundValue() {
@@ -288,8 +589,22 @@ func (l *Librarian) Get(ctx context.Context, rq *api.GetRequest) (*api.GetRespon
 	if s.Errored() {
 		return nil, errors.New("search for key errored")
 	}
-	if s.Exhausted() {
-		return nil, errors.New("search for key exhausted")
+	if exhausted, timedOut := s.Exhausted(), s.TimedOut(); exhausted || timedOut {
+		// return the closest peers found so far, so the client can retry against them directly
+		closest := s.Result.Closest.Peers()
+		l.logger.Info("search for value exhausted or timed out",
+			zap.String("key", key.String()),
+			zap.Bool("exhausted", exhausted),
+			zap.Bool("timed_out", timedOut),
+			zap.Int("n_closest_peers", len(closest)),
+		)
+		return &api.GetResponse{
+			Metadata:     l.NewResponseMetadata(rq.Metadata),
+			Value:        nil,
+			Exhausted:    exhausted,
+			TimedOut:     timedOut,
+			ClosestPeers: peer.ToAPIs(closest),
+		}, nil
 	}
 
 	return nil, errors.New("unexpected search result")
@@ -298,30 +613,68 @@ func (l *Librarian) Get(ctx context.Context, rq *api.GetRequest) (*api.GetRespon
 // Put stores a given key and value. This endpoint handles the internals of finding the right
 // peers to store the value in and then sending them store requests.
 func (l *Librarian) Put(ctx context.Context, rq *api.PutRequest) (*api.PutResponse, error) {
-	requesterID, err := l.checkRequestAndKeyValue(ctx, rq, rq.Metadata, rq.Key, rq.Value)
+	rp, err := l.put(ctx, rq)
+	l.audit(AuditPut, rq.Metadata, rq.Key, err)
+	return rp, err
+}
+
+func (l *Librarian) put(ctx context.Context, rq *api.PutRequest) (*api.PutResponse, error) {
+	// Put requests come from clients rather than peers, so don't need proof of work
+	requesterID, err := l.checkRequestAndKeyValue(ctx, rq, rq.Metadata, rq.Key, rq.Value, 0)
 	if err != nil {
 		return nil, err
 	}
 	l.record(requesterID, peer.Request, peer.Success)
+	release, err := acquire(l.opLimiter, requesterID, ErrTooManyOperations)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	key := cid.FromBytes(rq.Key)
-	s := store.NewStore(
+	if l.denied(key) {
+		return nil, ErrDeniedKey
+	}
+	if err := validateAccessHash(rq.AccessHash); err != nil {
+		return nil, err
+	}
+	newStore := store.NewStore(
 		l.selfID,
 		key,
 		rq.Value,
 		l.config.Search,
-		l.config.Store,
+		l.config.Store.WithNReplicas(uint(rq.NReplicas)),
 	)
-	seeds := l.rt.Peak(key, s.Search.Params.Concurrency)
-	err = l.storer.Store(s, seeds)
+	newStore.Request.AccessHash = rq.AccessHash
+	// tombstones have the key of the document they delete, so don't share its store
+	flightKey := fmt.Sprintf("%s/%d/%x/%t", key.String(), newStore.Params.NReplicas,
+		rq.AccessHash, rq.Value.GetTombstone() != nil)
+	s, shared, err := l.storeFlights.Do(ctx, flightKey, func() (*store.Store, error) {
+		// callers share the store, so it can't stop when the first one's ctx is done
+		opCtx, cancel := detachedContext(newStore.Params.OpTimeout)
+		defer cancel()
+		seeds := l.peakSeeds(key, newStore.Search.Params.Concurrency, requesterID)
+		if err := l.storer.Store(opCtx, newStore, seeds); err != nil {
+			return nil, err
+		}
+
+		// any recent search result for the key no longer reflects the stored value
+		l.searchCache.Remove(key)
+
+		// remaining replicas may still be being stored in the background if quorum was reached
+		return newStore.Snapshot(), nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	if shared {
+		l.logger.Debug("shared in-flight store", zap.String("key", key.String()))
+	}
 	debugLogStoreResult("store result", s, l.logger)
 	for _, p := range s.Result.Responded {
-		l.rt.Push(p)
+		l.pushPeer(p)
 	}
-	if s.Stored() {
+	if s.Stored() || s.QuorumReached() {
 		l.logger.Info("put value",
 			zap.String("key", key.String()),
 			zap.String("operation", api.PutOperation_STORED.String()),
@@ -350,6 +703,9 @@ func (l *Librarian) Put(ctx context.Context, rq *api.PutRequest) (*api.PutRespon
 	if s.Exhausted() {
 		return nil, errors.New("store for key exhausted")
 	}
+	if s.TimedOut() {
+		return nil, errors.New("store for key timed out")
+	}
 
 	return nil, fmt.Errorf("unexpected store result: %v", s.Result)
 }
@@ -359,6 +715,7 @@ func debugLogSearchResult(message string, s *search.Search, logger *zap.Logger)
 		zap.Bool("finished", s.Finished()),
 		zap.Bool("errored", s.Errored()),
 		zap.Bool("exhausted", s.Exhausted()),
+		zap.Bool("timed_out", s.TimedOut()),
 		zap.Bool("found_value", s.FoundValue()),
 		zap.Bool("found_closest_peers", s.FoundClosestPeers()),
 		zap.Int("n_closest", s.Result.Closest.Len()),
@@ -374,8 +731,10 @@ func debugLogStoreResult(message string, s *store.Store, logger *zap.Logger) {
 	logger.Debug(message,
 		zap.Bool("finished", s.Finished()),
 		zap.Bool("stored", s.Stored()),
+		zap.Bool("quorum_reached", s.QuorumReached()),
 		zap.Bool("errored", s.Errored()),
 		zap.Bool("exhausted", s.Exhausted()),
+		zap.Bool("timed_out", s.TimedOut()),
 		zap.Bool("exists", s.Exists()),
 		zap.Int("n_unqueried", len(s.Result.Unqueried)),
 		zap.Int("n_responded", len(s.Result.Responded)),
@@ -383,29 +742,102 @@ func debugLogStoreResult(message string, s *store.Store, logger *zap.Logger) {
 	)
 }
 
+// detachedContext returns a new context independent of any request's that times out after the
+// timeout, if it isn't zero.
+func detachedContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
 // Subscribe begins a subscription to the peer's publication stream (from its own subscriptions to
-// other peers).
-func (l *Librarian) Subscribe(rq *api.SubscribeRequest, from api.Librarian_SubscribeServer) error {
-	if _, err := l.checkRequest(from.Context(), rq, rq.Metadata); err != nil {
+// other peers). If the request has a non-zero FromSequence, logged publications from that
+// sequence number are replayed before new publications are streamed. If it has a non-zero
+// Window, no more than that many publications are sent without being acknowledged by subsequent
+// requests on the stream. Publications are sent no faster than the lower of the librarian's
+// maximum rate and the request's MaxPubsPerSecond. Subsequent requests with a Subscription
+// replace the filters of the active subscription. If the librarian has allowed public keys for
+// subscriptions from other peers, only clients with one of them may subscribe. Keepalives (i.e.,
+// responses without a value) are sent periodically so subscribers can tell a quiet subscription
+// from a stalled one. If the request has a MaxBatchSize greater than one, publications are
+// instead packed into batches, each sent once it is full or its maximum delay has elapsed.
+func (l *Librarian) Subscribe(from api.Librarian_SubscribeServer) error {
+	rq, err := from.Recv()
+	if err != nil {
 		return err
 	}
-	authorFilter, err := subscribe.FromAPI(rq.Subscription.AuthorPublicKeys)
+	requesterID, err := l.checkRequest(from.Context(), rq, rq.Metadata)
 	if err != nil {
 		return err
 	}
-	readerFilter, err := subscribe.FromAPI(rq.Subscription.ReaderPublicKeys)
+	if !allowedSubscriber(l.config.SubscribeFrom.AllowedPublicKeys, rq.Metadata.PubKey) {
+		l.logger.Info("rejected subscription from client not allowed to subscribe",
+			zap.Stringer("peer_id", requesterID))
+		return ErrSubscribeNotAllowed
+	}
+	release, err := acquire(l.subLimiter, requesterID, ErrTooManySubscriptions)
 	if err != nil {
 		return err
 	}
-	pubs, done, err := l.subscribeFrom.New()
+	defer release()
+	filters, err := subscribe.NewFilters(rq.Subscription)
 	if err != nil {
 		return err
 	}
-
+	stats := subscribe.NewStats(requesterID, filters)
 	responseMetadata := l.NewResponseMetadata(rq.Metadata)
-	for pub := range pubs {
-		err = l.maybeSend(pub, authorFilter, readerFilter, from, responseMetadata, done)
+	window := subscribe.NewWindow(rq.Window)
+	go l.receiveSubscribeUpdates(from, window, filters)
+	limiter := subscribe.NewRateLimiter(subscribe.LimitedRate(
+		l.config.SubscribeFrom.MaxPubsPerSecond, rq.MaxPubsPerSecond))
+	batcher := newSubscribeBatcher(l.config.SubscribeFrom, rq)
+	send := func(pub *subscribe.KeyedPub) error {
+		return l.maybeSend(pub, filters, from, responseMetadata, window, limiter, batcher, stats)
+	}
+
+	// replay before adding to the fanout, so a long replay doesn't hold up other subscriptions
+	replay, lastSent := rq.FromSequence > 0, uint64(0)
+	if replay {
+		if lastSent, err = l.replay(rq.FromSequence, send); err != nil {
+			return err
+		}
+	}
+	pubs, done, err := l.subscribeFrom.New(stats)
+	if err != nil {
+		return err
+	}
+	if replay {
+		// catch up on publications logged after the replay above but before joining the fanout
+		if lastSent, err = l.replay(lastSent+1, send); err != nil {
+			close(done) // signal to l.subscribeFrom we're finished with this fanout
+			return err
+		}
+	}
+
+	keepalives, stopKeepalives := newKeepalives(l.config.SubscribeFrom.KeepaliveInterval)
+	defer stopKeepalives()
+	for open := true; open; {
+		var pub *subscribe.KeyedPub
+		select {
+		case pub, open = <-pubs:
+			if !open {
+				// fanout ended, so send what's left of the batch
+				err = l.sendBatch(from, responseMetadata, batcher)
+				break
+			}
+			if replay && pub.Sequence != 0 && pub.Sequence <= lastSent {
+				continue // already sent during replay
+			}
+			err = send(pub)
+		case <-batchDue(batcher):
+			err = l.sendBatch(from, responseMetadata, batcher)
+		case <-keepalives:
+			// let the subscriber know the subscription is still healthy
+			err = from.Send(&api.SubscribeResponse{Metadata: responseMetadata})
+		}
 		if err != nil {
+			close(done) // signal to l.subscribeFrom we're finished with this fanout
 			return err
 		}
 	}
@@ -419,34 +851,215 @@ func (l *Librarian) Subscribe(rq *api.SubscribeRequest, from api.Librarian_Subsc
 	return nil
 }
 
+// newKeepalives returns a channel receiving at the given interval, which never receives if the
+// interval is zero, along with a function to stop it.
+func newKeepalives(interval time.Duration) (<-chan time.Time, func()) {
+	if interval == 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// newSubscribeBatcher returns a batcher for the publications of a subscription if the subscriber
+// requested batching and the librarian allows it, and otherwise nil.
+func newSubscribeBatcher(
+	params *subscribe.FromParameters, rq *api.SubscribeRequest,
+) subscribe.Batcher {
+	if rq.MaxBatchSize <= 1 || params.MaxBatchSize <= 1 {
+		return nil
+	}
+	maxSize := subscribe.LimitedBatch(params.MaxBatchSize, rq.MaxBatchSize)
+	maxDelay := params.MaxBatchDelay
+	requestedDelay := time.Duration(rq.MaxBatchDelay)
+	if requestedDelay > 0 && (maxDelay == 0 || requestedDelay < maxDelay) {
+		maxDelay = requestedDelay
+	}
+	maxBytes := subscribe.LimitedBatch(params.MaxBatchBytes, rq.MaxBatchBytes)
+	return subscribe.NewBatcher(maxSize, maxBytes, maxDelay)
+}
+
+// batchDue returns the channel receiving when the batcher's current batch is due, which never
+// receives if the batcher is nil.
+func batchDue(batcher subscribe.Batcher) <-chan time.Time {
+	if batcher == nil {
+		return nil
+	}
+	return batcher.Due()
+}
+
+// receiveSubscribeUpdates handles the requests following the first on a Subscribe stream, which
+// acknowledge sent publications and update the subscription filters, until the stream ends.
+func (l *Librarian) receiveSubscribeUpdates(
+	from api.Librarian_SubscribeServer, window subscribe.Window, filters subscribe.Filters,
+) {
+	defer window.EndAcks()
+	for {
+		rq, err := from.Recv()
+		if err != nil {
+			return
+		}
+		if rq.NAcked > 0 {
+			window.Ack(rq.NAcked)
+		}
+		if rq.Subscription != nil {
+			if err := filters.Update(rq.Subscription); err != nil {
+				// keep existing filters
+				l.logger.Error("unable to update subscription filters", zap.Error(err))
+				continue
+			}
+			l.logger.Debug("updated subscription filters")
+		}
+	}
+}
+
+// allowedSubscriber returns whether the client with the given public key is allowed to subscribe,
+// which all clients are when there are no allowed public keys.
+func allowedSubscriber(allowed [][]byte, pubKey []byte) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if bytes.Equal(a, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// replay sends the logged publications with sequence numbers of at least from and returns the
+// sequence number of the last one sent.
+func (l *Librarian) replay(from uint64, send func(pub *subscribe.KeyedPub) error) (
+	uint64, error) {
+
+	if l.pubLog == nil {
+		return 0, ErrPublicationLogDisabled
+	}
+	lastSent := from - 1
+	err := l.pubLog.Replay(from, func(pub *subscribe.KeyedPub) error {
+		if err := send(pub); err != nil {
+			return err
+		}
+		lastSent = pub.Sequence
+		return nil
+	})
+	l.logger.Debug("replayed publications",
+		zap.Uint64("from_sequence", from),
+		zap.Uint64("last_sequence", lastSent),
+	)
+	return lastSent, err
+}
+
 func (l *Librarian) maybeSend(
 	pub *subscribe.KeyedPub,
-	authorFilter *bloom.BloomFilter,
-	readerFilter *bloom.BloomFilter,
+	filters subscribe.Filters,
 	from api.Librarian_SubscribeServer,
 	responseMetadata *api.ResponseMetadata,
-	done chan struct{},
+	window subscribe.Window,
+	limiter subscribe.RateLimiter,
+	batcher subscribe.Batcher,
+	stats *subscribe.Stats,
 ) error {
 
-	if !authorFilter.Test(pub.Value.AuthorPublicKey) {
+	if !filters.Match(pub.Value) {
 		return nil
 	}
-	if !readerFilter.Test(pub.Value.ReaderPublicKey) {
-		return nil
+
+	if batcher != nil && !window.Open() {
+		// send the batch so far, since the subscriber can't acknowledge it otherwise
+		if err := l.sendBatch(from, responseMetadata, batcher); err != nil {
+			return err
+		}
 	}
 
 	// if we get to here, we know that both author and reader keys are in the filters,
-	// so we want to send the response
+	// so we want to send the response once the subscriber has acknowledged enough previous ones
+	if err := window.Wait(from.Context()); err != nil {
+		l.logger.Error("subscribe window error", zap.Error(err))
+		return err
+	}
+	if err := limiter.Wait(from.Context()); err != nil {
+		return err
+	}
+	if batcher != nil {
+		window.Sent()
+		stats.AddPub()
+		if batcher.Add(pub) {
+			return l.sendBatch(from, responseMetadata, batcher)
+		}
+		return nil
+	}
 	rp := &api.SubscribeResponse{
 		Metadata: responseMetadata,
 		Key:      pub.Key.Bytes(),
 		Value:    pub.Value,
+		Sequence: pub.Sequence,
+	}
+	if !pub.OriginTime.IsZero() {
+		rp.OriginTime = pub.OriginTime.UnixNano()
 	}
 	if err := from.Send(rp); err != nil {
 		l.logger.Error("subscribe send error", zap.Error(err))
-		close(done) // signal to l.subscribeFrom we're finished with this fanout
 		return err
 	}
+	window.Sent()
+	stats.AddPub()
 	l.logger.Debug("sent publication", zap.String("publication_key", pub.Key.String()))
 	return nil
 }
+
+// sendBatch sends the publications in the batcher's current batch, if any, in a single response.
+func (l *Librarian) sendBatch(
+	from api.Librarian_SubscribeServer,
+	responseMetadata *api.ResponseMetadata,
+	batcher subscribe.Batcher,
+) error {
+	if batcher == nil || batcher.Len() == 0 {
+		return nil
+	}
+	pubs := batcher.Flush()
+	rp := &api.SubscribeResponse{
+		Metadata: responseMetadata,
+		Batch:    make([]*api.BatchedPublication, len(pubs)),
+	}
+	for i, pub := range pubs {
+		rp.Batch[i] = &api.BatchedPublication{
+			Key:      pub.Key.Bytes(),
+			Value:    pub.Value,
+			Sequence: pub.Sequence,
+		}
+		if !pub.OriginTime.IsZero() {
+			rp.Batch[i].OriginTime = pub.OriginTime.UnixNano()
+		}
+	}
+	if err := from.Send(rp); err != nil {
+		l.logger.Error("subscribe send error", zap.Error(err))
+		return err
+	}
+	l.logger.Debug("sent publication batch", zap.Int("n_publications", len(pubs)))
+	return nil
+}
+
+// SubscriptionStats returns statistics about the active subscriptions from other peers (inbound)
+// and to other peers (outbound) along with publication totals over all current and past
+// subscriptions.
+func (l *Librarian) SubscriptionStats(ctx context.Context, rq *api.SubscriptionStatsRequest) (
+	*api.SubscriptionStatsResponse, error) {
+	requesterID, err := l.checkRequest(ctx, rq, rq.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	l.record(requesterID, peer.Request, peer.Success)
+	inbound, fromTotals := l.subscribeFrom.Stats()
+	outbound, toTotals := l.subscribeTo.Stats()
+	return &api.SubscriptionStatsResponse{
+		Metadata:         l.NewResponseMetadata(rq.Metadata),
+		Inbound:          subscribe.ToAPIStats(inbound),
+		Outbound:         subscribe.ToAPIStats(outbound),
+		NSent:            fromTotals.NPubs,
+		NDropped:         fromTotals.NDropped,
+		NReceived:        toTotals.NPubs,
+		NReceivedDropped: toTotals.NDropped,
+		ReceivedLatency:  toTotals.Latencies.ToAPI(),
+	}, nil
+}
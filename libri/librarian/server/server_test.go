@@ -1,13 +1,16 @@
 package server
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/rand"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/drausin/libri/libri/common/db"
 	"github.com/drausin/libri/libri/common/ecid"
@@ -24,6 +27,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -46,6 +50,125 @@ func TestNewLibrarian(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestLibrarian_Close_saveErr(t *testing.T) {
+	l := newTestLibrarian()
+	go func() { <-l.stop }() // dummy stop signal acceptor
+	to := &fixedTo{saveErr: errors.New("some Save error")}
+	l.subscribeTo = to
+
+	// check shutdown continues despite failing to save subscriptions
+	assert.Nil(t, l.CloseAndRemove())
+	assert.Equal(t, 1, to.saves())
+	select {
+	case <-l.stop:
+	default:
+		t.Error("stop signal not sent")
+	}
+}
+
+func TestNewLibrarian_session(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	config := newTestConfig().WithSession(sessionID, cert)
+
+	l, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Nil(t, err)
+	go func() { <-l.stop }() // dummy stop signal acceptor
+	assert.Equal(t, identityID.ID(), l.selfID.ID())
+
+	// check requests are signed by the session key on behalf of the identity
+	rq := client.NewFindRequest(l.selfID, cid.NewPseudoRandom(rng), 20)
+	encToken, err := l.signer.Sign(rq)
+	assert.Nil(t, err)
+	err = client.NewVerifier().Verify(encToken, &identityID.Key().PublicKey, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, l.CloseAndRemove())
+
+	// check rotating with a session key errors
+	config = newTestConfig().WithSession(sessionID, cert).WithRotatePeerID(true)
+	l2, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Equal(t, ErrRotateWithSession, err)
+	assert.Nil(t, l2)
+}
+
+func TestNewLibrarian_sharedSecret(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	secret := []byte("some shared secret")
+	config := newTestConfig().WithSharedSecret(secret)
+
+	l, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Nil(t, err)
+	go func() { <-l.stop }() // dummy stop signal acceptor
+
+	// check requests are signed and verified with the shared secret
+	rq := client.NewFindRequest(l.selfID, cid.NewPseudoRandom(rng), 20)
+	encToken, err := l.signer.Sign(rq)
+	assert.Nil(t, err)
+	verifier, err := client.NewHMACVerifier(secret, client.NewDefaultVerifyParameters())
+	assert.Nil(t, err)
+	assert.Nil(t, verifier.Verify(encToken, &l.selfID.Key().PublicKey, rq))
+	ctx := client.NewIncomingSignatureContext(context.Background(), encToken)
+	assert.Nil(t, l.rqv.Verify(ctx, rq, rq.Metadata))
+	assert.Nil(t, l.CloseAndRemove())
+
+	// check using a session key with a shared secret errors
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	config = newTestConfig().WithSharedSecret(secret).WithSession(sessionID, cert)
+	l2, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Equal(t, ErrSessionWithSharedSecret, err)
+	assert.Nil(t, l2)
+}
+
+func TestNewLibrarian_keySigner(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID := ecid.NewPseudoRandom(rng)
+	config := newTestConfig().WithKeySigner(identityID.Key())
+
+	l, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Nil(t, err)
+	go func() { <-l.stop }() // dummy stop signal acceptor
+	assert.Equal(t, identityID.ID(), l.selfID.ID())
+	assert.Nil(t, l.selfID.Key().D)
+
+	// check requests are signed by the key signer
+	rq := client.NewFindRequest(l.selfID, cid.NewPseudoRandom(rng), 20)
+	encToken, err := l.signer.Sign(rq)
+	assert.Nil(t, err)
+	err = client.NewVerifier().Verify(encToken, &identityID.Key().PublicKey, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, l.CloseAndRemove())
+
+	// check rotating with a key signer errors
+	config = newTestConfig().WithKeySigner(identityID.Key()).WithRotatePeerID(true)
+	l2, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Equal(t, ErrRotateWithKeySigner, err)
+	assert.Nil(t, l2)
+
+	// check using a key signer with a session key or shared secret errors
+	sessionID := ecid.NewPseudoRandom(rng)
+	cert, err := client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	configs := []*Config{
+		newTestConfig().WithKeySigner(identityID.Key()).WithSession(sessionID, cert),
+		newTestConfig().WithKeySigner(identityID.Key()).WithSharedSecret([]byte("secret")),
+	}
+	for _, c := range configs {
+		l3, err := NewLibrarian(c, clogging.NewDevInfoLogger())
+		assert.Equal(t, ErrKeySignerConflict, err)
+		assert.Nil(t, l3)
+	}
+
+	// check unsupported key signer errors
+	config = newTestConfig().WithKeySigner(&ecdsa.PrivateKey{})
+	l4, err := NewLibrarian(config, clogging.NewDevInfoLogger())
+	assert.Equal(t, client.ErrUnsupportedKeySigner, err)
+	assert.Nil(t, l4)
+}
+
 func newTestLibrarian() *Librarian {
 	config := newTestConfig()
 	l, err := NewLibrarian(config, clogging.NewDevInfoLogger())
@@ -74,6 +197,13 @@ func (av *alwaysRequestVerifier) Verify(ctx context.Context, msg proto.Message,
 	return nil
 }
 
+// neverRateLimiter implements the PeerRateLimiter interface but never limits requests.
+type neverRateLimiter struct{}
+
+func (nl *neverRateLimiter) Allow(peerID cid.ID) bool {
+	return true
+}
+
 // TestLibrarian_Ping verifies that we receive the expected response ("pong") to a ping request.
 func TestLibrarian_Ping(t *testing.T) {
 	lib := &Librarian{}
@@ -93,12 +223,13 @@ func TestLibrarian_Introduce_ok(t *testing.T) {
 			PublicName: peerName,
 			LocalAddr:  publicAddr,
 		},
-		apiSelf: api.FromAddress(serverID.ID(), peerName, publicAddr),
-		fromer:  peer.NewFromer(),
-		selfID:  serverID,
-		rt:      rt,
-		rqv:     &alwaysRequestVerifier{},
-		logger:  clogging.NewDevInfoLogger(),
+		apiSelf:     api.FromAddress(serverID.ID(), peerName, publicAddr),
+		fromer:      peer.NewFromer(),
+		selfID:      serverID,
+		rt:          rt,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
 	}
 
 	clientID, clientPeerIdx := ecid.NewPseudoRandom(rng), 1
@@ -125,10 +256,88 @@ func TestLibrarian_Introduce_ok(t *testing.T) {
 	assert.Equal(t, int(numPeers), len(rp.Peers))
 }
 
+func TestLibrarian_Introduce_rotation(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, serverID, _ := routing.NewTestWithPeers(rng, 8)
+	lib := &Librarian{
+		config:      NewDefaultConfig(),
+		fromer:      peer.NewFromer(),
+		selfID:      serverID,
+		rt:          rt,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+
+	oldID, newID, clientPeerIdx := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng), 1
+	oldPeer := peer.New(oldID.ID(), "client", peer.NewTestConnector(clientPeerIdx))
+	oldPeer.Recorder().Record(peer.Response, peer.Error)
+	lib.rt.Push(oldPeer)
+	assert.NotNil(t, lib.rt.Get(oldID.ID()))
+	newPeer := peer.New(newID.ID(), "client", peer.NewTestConnector(clientPeerIdx))
+	rotation, err := client.NewKeyRotation(oldID, newID)
+	assert.Nil(t, err)
+
+	rq := &api.IntroduceRequest{
+		Metadata: newTestRequestMetadata(rng, newID),
+		Self:     newPeer.ToAPI(),
+		NumPeers: 8,
+		Rotation: rotation,
+	}
+	rp, err := lib.Introduce(nil, rq)
+	assert.Nil(t, err)
+	assert.NotNil(t, rp)
+
+	// check old peer replaced by new one with its query outcomes
+	assert.Nil(t, lib.rt.Get(oldID.ID()))
+	added := lib.rt.Get(newID.ID())
+	assert.NotNil(t, added)
+	assert.Equal(t, float64(1), added.Recorder().ErrorRate(peer.Response))
+}
+
+func TestLibrarian_Introduce_rotationErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, serverID, _ := routing.NewTestWithPeers(rng, 8)
+	lib := &Librarian{
+		config:      NewDefaultConfig(),
+		fromer:      peer.NewFromer(),
+		selfID:      serverID,
+		rt:          rt,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	oldID, newID, otherID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng),
+		ecid.NewPseudoRandom(rng)
+	newPeer := peer.New(newID.ID(), "client", peer.NewTestConnector(1))
+
+	// check rotation to a key other than the requester's
+	rotation, err := client.NewKeyRotation(oldID, otherID)
+	assert.Nil(t, err)
+	rq := &api.IntroduceRequest{
+		Metadata: newTestRequestMetadata(rng, newID),
+		Self:     newPeer.ToAPI(),
+		Rotation: rotation,
+	}
+	rp, err := lib.Introduce(nil, rq)
+	assert.Equal(t, ErrUnexpectedRotationKey, err)
+	assert.Nil(t, rp)
+
+	// check rotation with a bad signature
+	rotation, err = client.NewKeyRotation(oldID, newID)
+	assert.Nil(t, err)
+	rotation.Signature = "bad signature"
+	rq.Rotation = rotation
+	rp, err = lib.Introduce(nil, rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+	assert.Nil(t, lib.rt.Get(newID.ID()))
+}
+
 func TestLibrarian_Introduce_checkRequestErr(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	l := &Librarian{
-		logger:      clogging.NewDevInfoLogger(),
+		logger: clogging.NewDevInfoLogger(),
 	}
 	rq := &api.IntroduceRequest{
 		Metadata: client.NewRequestMetadata(ecid.NewPseudoRandom(rng)),
@@ -145,9 +354,10 @@ func TestLibrarian_Introduce_peerIDErr(t *testing.T) {
 	rt, _, _ := routing.NewTestWithPeers(rng, 0)
 
 	lib := &Librarian{
-		fromer: peer.NewFromer(),
-		rt:     rt,
-		rqv:    &alwaysRequestVerifier{},
+		fromer:      peer.NewFromer(),
+		rt:          rt,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
 		logger:      clogging.NewDevInfoLogger(),
 	}
 
@@ -170,6 +380,47 @@ func TestLibrarian_Introduce_peerIDErr(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestLibrarian_Introduce_difficultyErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, serverID, _ := routing.NewTestWithPeers(rng, 0)
+
+	lib := &Librarian{
+		config:      &Config{PeerIDDifficulty: 8},
+		selfID:      serverID,
+		fromer:      peer.NewFromer(),
+		rt:          rt,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+
+	clientID := ecid.NewPseudoRandom(rng)
+	for ecid.Difficulty(clientID) >= 8 {
+		clientID = ecid.NewPseudoRandom(rng)
+	}
+	client := peer.New(clientID.ID(), "client", peer.NewTestConnector(1))
+	rq := &api.IntroduceRequest{
+		Metadata: newTestRequestMetadata(rng, clientID),
+		Self:     client.ToAPI(),
+	}
+	rp, err := lib.Introduce(nil, rq)
+	assert.Nil(t, rp)
+	assert.Equal(t, ErrInsufficientPeerIDDifficulty, err)
+	assert.Nil(t, lib.rt.Get(client.ID()))
+
+	// check peer ID with enough difficulty is added to routing table
+	clientID = ecid.NewPseudoRandomWithDifficulty(rng, 8)
+	client = peer.New(clientID.ID(), "client", peer.NewTestConnector(1))
+	rq = &api.IntroduceRequest{
+		Metadata: newTestRequestMetadata(rng, clientID),
+		Self:     client.ToAPI(),
+	}
+	rp, err = lib.Introduce(nil, rq)
+	assert.Nil(t, err)
+	assert.NotNil(t, rp)
+	assert.NotNil(t, lib.rt.Get(client.ID()))
+}
+
 func TestLibrarian_Find(t *testing.T) {
 	kvdb, cleanup, err := db.NewTempDirRocksDB()
 	defer cleanup()
@@ -184,11 +435,12 @@ func TestLibrarian_Find(t *testing.T) {
 			rng := rand.New(rand.NewSource(int64(s)))
 			rt, peerID, nAdded := routing.NewTestWithPeers(rng, n)
 			l := &Librarian{
-				selfID:     peerID,
-				documentSL: storage.NewDocumentKVDBStorerLoader(kvdb),
-				kc:         storage.NewExactLengthChecker(storage.EntriesKeyLength),
-				rt:         rt,
-				rqv:        &alwaysRequestVerifier{},
+				selfID:      peerID,
+				documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+				kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+				rt:          rt,
+				rqv:         &alwaysRequestVerifier{},
+				rateLimiter: &neverRateLimiter{},
 			}
 
 			numClosest := uint32(routing.DefaultMaxActivePeers)
@@ -236,13 +488,14 @@ func TestLibrarian_Find_present(t *testing.T) {
 	assert.Nil(t, err)
 
 	l := &Librarian{
-		selfID:     peerID,
-		db:         kvdb,
-		serverSL:   storage.NewServerKVDBStorerLoader(kvdb),
-		documentSL: storage.NewDocumentKVDBStorerLoader(kvdb),
-		rt:         rt,
-		kc:         storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		rqv:        &alwaysRequestVerifier{},
+		selfID:      peerID,
+		db:          kvdb,
+		serverSL:    storage.NewServerKVDBStorerLoader(kvdb),
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		rt:          rt,
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
 	}
 
 	// create key-value and store
@@ -266,6 +519,109 @@ func TestLibrarian_Find_present(t *testing.T) {
 	assert.Nil(t, rp.Peers)
 	assert.Equal(t, value, rp.Value)
 	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+
+	// peers-only request should get back the closest peers instead
+	rq.PeersOnly = true
+	rp, err = l.Find(nil, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+	assert.NotEmpty(t, rp.Peers)
+}
+
+func TestLibrarian_Find_denied(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+
+	l := &Librarian{
+		selfID:      peerID,
+		db:          kvdb,
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		rt:          rt,
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+	}
+	value, key := api.NewTestDocument(rng)
+	err = l.documentSL.Store(key, value)
+	assert.Nil(t, err)
+	l.denylist = NewDenylist(key)
+
+	// denied value should be treated as missing, so we get back the closest peers
+	rq := &api.FindRequest{
+		Metadata: newTestRequestMetadata(rng, l.selfID),
+		Key:      key.Bytes(),
+		NumPeers: uint32(routing.DefaultMaxActivePeers),
+	}
+	rp, err := l.Find(nil, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+	assert.NotEmpty(t, rp.Peers)
+}
+
+func TestLibrarian_Find_private(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+
+	l := &Librarian{
+		selfID:      peerID,
+		db:          kvdb,
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		accessSL:    storage.NewAccessKVDBStorerLoader(kvdb),
+		rt:          rt,
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	readerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	err = l.documentSL.Store(key, value)
+	assert.Nil(t, err)
+	err = l.accessSL.Store(key.Bytes(), client.NewAccessHash(ecid.ToPublicKeyBytes(readerID)))
+	assert.Nil(t, err)
+
+	// private value without access proof should be treated as missing
+	rq := &api.FindRequest{
+		Metadata: newTestRequestMetadata(rng, l.selfID),
+		Key:      key.Bytes(),
+		NumPeers: uint32(routing.DefaultMaxActivePeers),
+	}
+	rp, err := l.Find(nil, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+	assert.NotEmpty(t, rp.Peers)
+
+	// same with access proof from another reader
+	rq.AccessProof, err = client.NewAccessProof(ecid.NewPseudoRandom(rng), key)
+	assert.Nil(t, err)
+	rp, err = l.Find(nil, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+
+	// access proof from reader gets value
+	rq.AccessProof, err = client.NewAccessProof(readerID, key)
+	assert.Nil(t, err)
+	rp, err = l.Find(nil, rq)
+	assert.Nil(t, err)
+	assert.Equal(t, value, rp.Value)
+	assert.Nil(t, rp.Peers)
+
+	// presence-only request without access proof gets that it is stored, but not the value
+	rq = client.NewFindPresenceRequest(ecid.NewPseudoRandom(rng), key,
+		routing.DefaultMaxActivePeers)
+	rp, err = l.Find(nil, rq)
+	assert.Nil(t, err)
+	assert.True(t, rp.Stored)
+	assert.Nil(t, rp.Value)
+	assert.Nil(t, rp.Peers)
 }
 
 func TestLibrarian_Find_missing(t *testing.T) {
@@ -277,13 +633,14 @@ func TestLibrarian_Find_missing(t *testing.T) {
 	assert.Nil(t, err)
 
 	l := &Librarian{
-		selfID:     peerID,
-		rt:         rt,
-		db:         kvdb,
-		serverSL:   storage.NewServerKVDBStorerLoader(kvdb),
-		documentSL: storage.NewDocumentKVDBStorerLoader(kvdb),
-		kc:         storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		rqv:        &alwaysRequestVerifier{},
+		selfID:      peerID,
+		rt:          rt,
+		db:          kvdb,
+		serverSL:    storage.NewServerKVDBStorerLoader(kvdb),
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
 	}
 
 	// make request
@@ -322,14 +679,18 @@ func TestLibrarian_Store_ok(t *testing.T) {
 
 	l := &Librarian{
 		selfID:      peerID,
+		config:      NewDefaultConfig(),
 		rt:          rt,
 		db:          kvdb,
 		serverSL:    storage.NewServerKVDBStorerLoader(kvdb),
 		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
 		subscribeTo: &fixedTo{},
+		searchCache: search.NewDefaultResultCache(),
+		auditLog:    &fixedAuditLog{},
 		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
 		kvc:         storage.NewHashKeyValueChecker(),
 		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
 		logger:      clogging.NewDevInfoLogger(),
 	}
 
@@ -342,6 +703,15 @@ func TestLibrarian_Store_ok(t *testing.T) {
 		Key:      key.Bytes(),
 		Value:    value,
 	}
+
+	// cache a recent search that didn't find the value
+	notFound := search.NewSearch(peerID, key, search.NewDefaultParameters())
+	err = notFound.Result.Closest.SafePushMany(peer.NewTestPeers(rng, 3))
+	assert.Nil(t, err)
+	l.searchCache.Add(notFound)
+	_, in := l.searchCache.Get(key)
+	assert.True(t, in)
+
 	rp, err := l.Store(nil, rq)
 	assert.Nil(t, err)
 	assert.NotNil(t, rp)
@@ -350,47 +720,334 @@ func TestLibrarian_Store_ok(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, value, stored)
 	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
-}
 
-func newTestRequestMetadata(rng *rand.Rand, peerID ecid.ID) *api.RequestMetadata {
-	return &api.RequestMetadata{
-		RequestId: cid.NewPseudoRandom(rng).Bytes(),
-		PubKey:    ecid.ToPublicKeyBytes(peerID),
-	}
+	// stale cached search result should have been removed
+	_, in = l.searchCache.Get(key)
+	assert.False(t, in)
+
+	// check store is audited
+	audited := l.auditLog.(*fixedAuditLog).appended
+	assert.Len(t, audited, 1)
+	assert.Equal(t, AuditStore, audited[0].Rpc)
+	assert.Equal(t, rq.Key, audited[0].Key)
+	assert.Equal(t, rq.Metadata.PubKey, audited[0].RequesterPublicKey)
+	assert.Empty(t, audited[0].Error)
 }
 
-func TestLibrarian_Store_checkRequestError(t *testing.T) {
-	rng := rand.New(rand.NewSource(0))
-	l := &Librarian{}
-	value, key := api.NewTestDocument(rng)
-	rq := client.NewStoreRequest(ecid.NewPseudoRandom(rng), key, value)
-	rq.Metadata.PubKey = []byte("corrupted pub key")
+func TestLibrarian_Store_sharedEnvelope(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
 
-	rp, err := l.Store(nil, rq)
-	assert.Nil(t, rp)
-	assert.NotNil(t, err)
-}
+	recent, err := subscribe.NewRecentPublications(2)
+	assert.Nil(t, err)
+	pubLog, err := subscribe.NewPublicationLog(storage.NewPublicationKVDBStorerLoader(kvdb), 0)
+	assert.Nil(t, err)
+	newPubs := make(chan *subscribe.KeyedPub, 1)
+	lg := clogging.NewDevInfoLogger()
+	toParams := subscribe.NewDefaultToParameters()
+	subscribeTo := subscribe.NewTo(toParams, lg, peerID, nil, nil, nil, recent, pubLog, newPubs)
 
-type errDocStorerLoader struct{}
+	l := &Librarian{
+		selfID:      peerID,
+		config:      NewDefaultConfig(),
+		rt:          rt,
+		db:          kvdb,
+		serverSL:    storage.NewServerKVDBStorerLoader(kvdb),
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		subscribeTo: subscribeTo,
+		searchCache: search.NewDefaultResultCache(),
+		auditLog:    &fixedAuditLog{},
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
 
-func (*errDocStorerLoader) Store(key cid.ID, value *api.Document) error {
-	return errors.New("some store error")
-}
+	// create shared envelope, with its entry encryption keys encrypted for the reader
+	env := api.NewTestEnvelope(rng)
+	env.EekCiphertext = api.RandBytes(rng, 108)
+	env.EekCiphertextMac = api.RandBytes(rng, api.HMAC256Length)
+	value := &api.Document{Contents: &api.Document_Envelope{Envelope: env}}
+	key, err := api.GetKey(value)
+	assert.Nil(t, err)
 
-func (*errDocStorerLoader) Load(key cid.ID) (*api.Document, error) {
-	return nil, errors.New("some load error")
+	// check storing it also sends its publication
+	rq := &api.StoreRequest{
+		Metadata: newTestRequestMetadata(rng, l.selfID),
+		Key:      key.Bytes(),
+		Value:    value,
+	}
+	rp, err := l.Store(nil, rq)
+	assert.Nil(t, err)
+	assert.NotNil(t, rp)
+
+	stored, err := l.documentSL.Load(key)
+	assert.Nil(t, err)
+	assert.Equal(t, value, stored)
 }
 
-func TestLibrarian_Store_storeError(t *testing.T) {
+func TestLibrarian_Store_denied(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
-	l := &Librarian{
-		selfID:     peerID,
-		rt:         rt,
-		kc:         storage.NewExactLengthChecker(storage.EntriesKeyLength),
-		kvc:        storage.NewHashKeyValueChecker(),
-		rqv:        &alwaysRequestVerifier{},
-		documentSL: &errDocStorerLoader{},
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+
+	value, key := api.NewTestDocument(rng)
+	l := &Librarian{
+		selfID:      peerID,
+		config:      NewDefaultConfig(),
+		rt:          rt,
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		subscribeTo: &fixedTo{},
+		searchCache: search.NewDefaultResultCache(),
+		denylist:    NewDenylist(key),
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	rq := &api.StoreRequest{
+		Metadata: newTestRequestMetadata(rng, l.selfID),
+		Key:      key.Bytes(),
+		Value:    value,
+	}
+	rp, err := l.Store(nil, rq)
+	assert.Equal(t, ErrDeniedKey, err)
+	assert.Nil(t, rp)
+
+	stored, err := l.documentSL.Load(key)
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestLibrarian_Store_unsignedEntry(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+
+	value, key := api.NewTestDocument(rng)
+	l := &Librarian{
+		selfID:      peerID,
+		config:      NewDefaultConfig().WithRequireEntrySignatures(true),
+		rt:          rt,
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		subscribeTo: &fixedTo{},
+		searchCache: search.NewDefaultResultCache(),
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	rq := &api.StoreRequest{
+		Metadata: newTestRequestMetadata(rng, l.selfID),
+		Key:      key.Bytes(),
+		Value:    value,
+	}
+	rp, err := l.Store(nil, rq)
+	assert.Equal(t, client.ErrMissingAuthorSignature, err)
+	assert.Nil(t, rp)
+
+	stored, err := l.documentSL.Load(key)
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestLibrarian_Store_tombstone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+
+	l := &Librarian{
+		selfID:      peerID,
+		config:      NewDefaultConfig(),
+		rt:          rt,
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		subscribeTo: &fixedTo{},
+		searchCache: search.NewDefaultResultCache(),
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	authorID := ecid.NewPseudoRandom(rng)
+	entry := api.NewTestMultiPageEntry(rng)
+	entry.AuthorPublicKey = ecid.ToPublicKeyBytes(authorID)
+	value := &api.Document{Contents: &api.Document_Entry{Entry: entry}}
+	key, err := api.GetKey(value)
+	assert.Nil(t, err)
+	rq := &api.StoreRequest{
+		Metadata: newTestRequestMetadata(rng, l.selfID),
+		Key:      key.Bytes(),
+		Value:    value,
+	}
+	_, err = l.Store(nil, rq)
+	assert.Nil(t, err)
+
+	// check tombstone replaces the stored document
+	tombstone, err := client.NewTombstone(authorID, key)
+	assert.Nil(t, err)
+	tombstoneDoc := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	rq.Value = tombstoneDoc
+	_, err = l.Store(nil, rq)
+	assert.Nil(t, err)
+	stored, err := l.documentSL.Load(key)
+	assert.Nil(t, err)
+	assert.Equal(t, tombstoneDoc, stored)
+
+	// check deleted document can't be stored again
+	rq.Value = value
+	rp, err := l.Store(nil, rq)
+	assert.Equal(t, ErrDeletedDocument, err)
+	assert.Nil(t, rp)
+	stored, err = l.documentSL.Load(key)
+	assert.Nil(t, err)
+	assert.Equal(t, tombstoneDoc, stored)
+}
+
+func TestLibrarian_Store_private(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+
+	l := &Librarian{
+		selfID:      peerID,
+		config:      NewDefaultConfig().WithAccessControl(true),
+		rt:          rt,
+		documentSL:  storage.NewDocumentKVDBStorerLoader(kvdb),
+		accessSL:    storage.NewAccessKVDBStorerLoader(kvdb),
+		subscribeTo: &fixedTo{},
+		searchCache: search.NewDefaultResultCache(),
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	value, key := api.NewTestDocument(rng)
+	accessHash := client.NewAccessHash(ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng)))
+	rq := &api.StoreRequest{
+		Metadata:   newTestRequestMetadata(rng, l.selfID),
+		Key:        key.Bytes(),
+		Value:      value,
+		AccessHash: accessHash,
+	}
+	_, err = l.Store(nil, rq)
+	assert.Nil(t, err)
+	stored, err := l.accessSL.Load(key.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, accessHash, stored)
+
+	// check storing again, with or without another access hash, leaves access hash unchanged
+	for _, otherHash := range [][]byte{nil, api.RandBytes(rng, storage.AccessHashLength)} {
+		rq.Metadata, rq.AccessHash = newTestRequestMetadata(rng, l.selfID), otherHash
+		_, err = l.Store(nil, rq)
+		assert.Nil(t, err)
+		stored, err = l.accessSL.Load(key.Bytes())
+		assert.Nil(t, err)
+		assert.Equal(t, accessHash, stored)
+	}
+
+	// check access hash with bad length errors
+	value2, key2 := api.NewTestDocument(rng)
+	rq = &api.StoreRequest{
+		Metadata:   newTestRequestMetadata(rng, l.selfID),
+		Key:        key2.Bytes(),
+		Value:      value2,
+		AccessHash: []byte("bad access hash"),
+	}
+	rp, err := l.Store(nil, rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+	stored2, err := l.documentSL.Load(key2)
+	assert.Nil(t, err)
+	assert.Nil(t, stored2)
+}
+
+func TestLibrarian_Store_proofOfWorkErr(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	config := NewDefaultConfig()
+	config.Store.ProofOfWorkBits = 8
+	l := &Librarian{
+		selfID:      peerID,
+		config:      config,
+		rt:          rt,
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		documentSL:  &errDocStorerLoader{}, // should never be reached
+	}
+	value, key := api.NewTestDocument(rng)
+	rq := client.NewStoreRequest(ecid.NewPseudoRandom(rng), key, value)
+	for api.ProofOfWorkBits(key.Bytes(), rq.Metadata.ProofOfWorkNonce) >= 8 {
+		rq.Metadata.ProofOfWorkNonce++
+	}
+
+	rp, err := l.Store(nil, rq)
+	assert.Nil(t, rp)
+	assert.Equal(t, api.ErrInsufficientProofOfWork, err)
+}
+
+func newTestRequestMetadata(rng *rand.Rand, peerID ecid.ID) *api.RequestMetadata {
+	return &api.RequestMetadata{
+		RequestId: cid.NewPseudoRandom(rng).Bytes(),
+		PubKey:    ecid.ToPublicKeyBytes(peerID),
+	}
+}
+
+func TestLibrarian_Store_checkRequestError(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := &Librarian{config: NewDefaultConfig()}
+	value, key := api.NewTestDocument(rng)
+	rq := client.NewStoreRequest(ecid.NewPseudoRandom(rng), key, value)
+	rq.Metadata.PubKey = []byte("corrupted pub key")
+
+	rp, err := l.Store(nil, rq)
+	assert.Nil(t, rp)
+	assert.NotNil(t, err)
+}
+
+type errDocStorerLoader struct{}
+
+func (*errDocStorerLoader) Store(key cid.ID, value *api.Document) error {
+	return errors.New("some store error")
+}
+
+func (*errDocStorerLoader) Load(key cid.ID) (*api.Document, error) {
+	return nil, errors.New("some load error")
+}
+
+func TestLibrarian_Store_storeError(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 64)
+	l := &Librarian{
+		selfID:      peerID,
+		config:      NewDefaultConfig(),
+		rt:          rt,
+		kc:          storage.NewExactLengthChecker(storage.EntriesKeyLength),
+		kvc:         storage.NewHashKeyValueChecker(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		documentSL:  &errDocStorerLoader{},
 	}
 	value, key := api.NewTestDocument(rng)
 	rq := client.NewStoreRequest(ecid.NewPseudoRandom(rng), key, value)
@@ -403,9 +1060,15 @@ func TestLibrarian_Store_storeError(t *testing.T) {
 type fixedSearcher struct {
 	result *search.Result
 	err    error
+	last   *search.Search
+	mu     sync.Mutex
 }
 
-func (s *fixedSearcher) Search(search *search.Search, seeds []peer.Peer) error {
+func (s *fixedSearcher) Search(ctx context.Context, search *search.Search,
+	seeds []peer.Peer) error {
+	s.mu.Lock()
+	s.last = search
+	s.mu.Unlock()
 	if s.err != nil {
 		return s.err
 	}
@@ -434,6 +1097,63 @@ func TestLibrarian_Get_FoundValue(t *testing.T) {
 	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
 }
 
+func TestLibrarian_Get_denied(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+	foundValueResult := search.NewInitialResult(key, search.NewDefaultParameters())
+	foundValueResult.Value = value
+
+	l := newGetLibrarian(rng, foundValueResult, nil)
+	l.denylist = NewDenylist(key)
+	rq := client.NewGetRequest(peerID, key)
+
+	// denied value should be treated as not found, even though the search would find it
+	rp, err := l.Get(nil, rq)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+}
+
+func TestLibrarian_Get_accessProof(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID, readerID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	foundValueResult := search.NewInitialResult(key, search.NewDefaultParameters())
+	foundValueResult.Value = value
+
+	l := newGetLibrarian(rng, foundValueResult, nil)
+	rq := client.NewGetRequest(peerID, key)
+	proof, err := client.NewAccessProof(readerID, key)
+	assert.Nil(t, err)
+	rq.AccessProof = proof
+
+	// check access proof is forwarded to peers and search result isn't cached
+	rp, err := l.Get(nil, rq)
+	assert.Nil(t, err)
+	assert.Equal(t, value, rp.Value)
+	assert.Equal(t, proof, l.searcher.(*fixedSearcher).last.Request.AccessProof)
+	assert.Equal(t, 0, l.searchCache.Len())
+}
+
+func TestLibrarian_Get_tooManyOperations(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	key, peerID := cid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	l := newGetLibrarian(rng, search.NewInitialResult(key, search.NewDefaultParameters()), nil)
+	l.opLimiter = NewConcurrencyLimiter(1, 0)
+	assert.True(t, l.opLimiter.Acquire(peerID.ID()))
+
+	rp, err := l.Get(nil, client.NewGetRequest(peerID, key))
+	assert.Equal(t, ErrTooManyOperations, err)
+	assert.Nil(t, rp)
+
+	// check slot is released after the operation finishes
+	l.opLimiter.Release(peerID.ID())
+	_, err = l.Get(nil, client.NewGetRequest(peerID, key))
+	assert.Nil(t, err)
+	assert.True(t, l.opLimiter.Acquire(peerID.ID()))
+}
+
 func TestLibrarian_Get_FoundClosestPeers(t *testing.T) {
 	rng := rand.New(rand.NewSource(int64(0)))
 	key, peerID := cid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
@@ -457,6 +1177,36 @@ func TestLibrarian_Get_FoundClosestPeers(t *testing.T) {
 	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
 }
 
+func TestLibrarian_Get_cached(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+
+	searchParams := search.NewDefaultParameters()
+	foundValueResult := search.NewInitialResult(key, searchParams)
+	foundValueResult.Value = value
+	l := newGetLibrarian(rng, foundValueResult, nil)
+
+	rp, err := l.Get(nil, client.NewGetRequest(peerID, key))
+	assert.Nil(t, err)
+	assert.Equal(t, value, rp.Value)
+	assert.Equal(t, 1, l.searchCache.Len())
+
+	// subsequent search would error, but cached result is returned instead
+	l.searcher = &fixedSearcher{err: errors.New("some unexpected search error")}
+	rq := client.NewGetRequest(peerID, key)
+	rp, err = l.Get(nil, rq)
+	assert.Nil(t, err)
+	assert.Equal(t, value, rp.Value)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+
+	// after removing cached result, search is performed again
+	l.searchCache.Remove(key)
+	rp, err = l.Get(nil, client.NewGetRequest(peerID, key))
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+}
+
 func TestLibrarian_Get_Errored(t *testing.T) {
 	rng := rand.New(rand.NewSource(int64(0)))
 	key, peerID := cid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
@@ -480,32 +1230,75 @@ func TestLibrarian_Get_Exhausted(t *testing.T) {
 	rng := rand.New(rand.NewSource(int64(0)))
 	key, peerID := cid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
 
-	// create mock search result with Exhausted() true
+	// create mock search result with Exhausted() true, having found fewer than the required
+	// number of closest peers
 	searchParams := search.NewDefaultParameters()
 	exhaustedResult := search.NewInitialResult(key, searchParams)
+	nClosest := int(searchParams.NClosestResponses) - 1
+	err := exhaustedResult.Closest.SafePushMany(peer.NewTestPeers(rng, nClosest))
+	assert.Nil(t, err)
 
 	// create librarian and request
 	l := newGetLibrarian(rng, exhaustedResult, nil)
 	rq := client.NewGetRequest(peerID, key)
 
-	// since we have a fatal search error, Get() should also return an error
+	// since search is exhausted, Get() should return the closest peers found so far
 	rp, err := l.Get(nil, rq)
-	assert.NotNil(t, err)
-	assert.Nil(t, rp)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+	assert.True(t, rp.Exhausted)
+	assert.False(t, rp.TimedOut)
+	assert.Equal(t, nClosest, len(rp.ClosestPeers))
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+
+	// exhausted search result shouldn't be cached
+	assert.Zero(t, l.searchCache.Len())
 }
 
-func TestLibrarian_Get_err(t *testing.T) {
+func TestLibrarian_Get_TimedOut(t *testing.T) {
 	rng := rand.New(rand.NewSource(int64(0)))
 	key, peerID := cid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
 
-	// create librarian and request
-	l := newGetLibrarian(rng, nil, errors.New("some unexpected search error"))
+	// create librarian whose peers never respond, with a search deadline well before any
+	// individual query times out
+	l := newGetLibrarian(rng, nil, nil)
+	l.config.Search.OpTimeout = 50 * time.Millisecond
+	l.searcher = search.NewSearcher(&client.TestNoOpSigner{}, &slowFindQuerier{},
+		search.NewResponseProcessor(peer.NewFromer()))
 	rq := client.NewGetRequest(peerID, key)
 
-	// since fixedSearcher returns fixed value, should get that back in response
-	rp, err := l.Get(nil, rq)
-	assert.NotNil(t, err)
-	assert.Nil(t, rp)
+	// since search timed out, Get() should return the best result so far rather than an error
+	rp, err := l.Get(context.Background(), rq)
+	assert.Nil(t, err)
+	assert.Nil(t, rp.Value)
+	assert.True(t, rp.TimedOut)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+
+	// timed out search result shouldn't be cached
+	assert.Zero(t, l.searchCache.Len())
+}
+
+// slowFindQuerier blocks until the request context is done, simulating unresponsive peers
+type slowFindQuerier struct{}
+
+func (f *slowFindQuerier) Query(ctx context.Context, pConn api.Connector, rq *api.FindRequest,
+	opts ...grpc.CallOption) (*api.FindResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestLibrarian_Get_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	key, peerID := cid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+
+	// create librarian and request
+	l := newGetLibrarian(rng, nil, errors.New("some unexpected search error"))
+	rq := client.NewGetRequest(peerID, key)
+
+	// since fixedSearcher returns fixed value, should get that back in response
+	rp, err := l.Get(nil, rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
 }
 
 func TestLibrarian_Get_checkRequestError(t *testing.T) {
@@ -531,17 +1324,56 @@ func newGetLibrarian(rng *rand.Rand, searchResult *search.Result, searchErr erro
 			result: searchResult,
 			err:    searchErr,
 		},
-		rqv:    &alwaysRequestVerifier{},
-		logger: clogging.NewDevInfoLogger(),
+		searchCache: search.NewDefaultResultCache(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
 	}
 }
 
+type blockingStorer struct {
+	result  *store.Result
+	release chan struct{}
+	n       int
+	ctxErrs []error
+	mu      sync.Mutex
+}
+
+func (s *blockingStorer) Store(ctx context.Context, store *store.Store,
+	seeds []peer.Peer) error {
+	s.mu.Lock()
+	s.n++
+	s.mu.Unlock()
+	<-s.release
+	s.mu.Lock()
+	s.ctxErrs = append(s.ctxErrs, ctx.Err())
+	s.mu.Unlock()
+	store.Result = s.result
+	return nil
+}
+
+func (s *blockingStorer) finishedCtxErrs() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctxErrs
+}
+
+func (s *blockingStorer) nCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
 type fixedStorer struct {
-	result *store.Result
-	err    error
+	result      *store.Result
+	err         error
+	lastParams  *store.Parameters
+	lastRequest *api.StoreRequest
 }
 
-func (s *fixedStorer) Store(store *store.Store, seeds []peer.Peer) error {
+func (s *fixedStorer) Store(ctx context.Context, store *store.Store,
+	seeds []peer.Peer) error {
+	s.lastParams, s.lastRequest = store.Params, store.Request
 	if s.err != nil {
 		return s.err
 	}
@@ -563,154 +1395,911 @@ func TestLibrarian_Put_Stored(t *testing.T) {
 	// create librarian and request
 	l := newPutLibrarian(rng, addedResult, nil)
 	rq := client.NewPutRequest(peerID, key, value)
+	rq.AccessHash = api.RandBytes(rng, storage.AccessHashLength)
+
+	// cache a recent search that didn't find the value
+	notFound := search.NewSearch(peerID, key, searchParams)
+	err := notFound.Result.Closest.SafePushMany(peer.NewTestPeers(rng, int(nReplicas)))
+	assert.Nil(t, err)
+	l.searchCache.Add(notFound)
+	_, in := l.searchCache.Get(key)
+	assert.True(t, in)
+
+	// since fixedSearcher returns fixed value, should get that back in response
+	rp, err := l.Put(context.Background(), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(nReplicas), rp.NReplicas)
+	assert.Equal(t, api.PutOperation_STORED, rp.Operation)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	assert.Equal(t, rq.AccessHash, l.storer.(*fixedStorer).lastRequest.AccessHash)
+
+	// stale cached search result should have been removed
+	_, in = l.searchCache.Get(key)
+	assert.False(t, in)
+}
+
+func TestLibrarian_Put_tooManyOperations(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+	addedResult := store.NewInitialResult(search.NewInitialResult(key,
+		search.NewDefaultParameters()))
+
+	l := newPutLibrarian(rng, addedResult, nil)
+	l.opLimiter = NewConcurrencyLimiter(0, 1)
+	assert.True(t, l.opLimiter.Acquire(ecid.NewPseudoRandom(rng).ID()))
+
+	// check global cap applies to other peers
+	rp, err := l.Put(context.Background(), client.NewPutRequest(peerID, key, value))
+	assert.Equal(t, ErrTooManyOperations, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_Put_denied(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+	addedResult := store.NewInitialResult(search.NewInitialResult(key,
+		search.NewDefaultParameters()))
+
+	l := newPutLibrarian(rng, addedResult, nil)
+	l.denylist = NewDenylist(key)
+	rq := client.NewPutRequest(peerID, key, value)
+
+	rp, err := l.Put(context.Background(), rq)
+	assert.Equal(t, ErrDeniedKey, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_Put_nReplicas(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+	searchParams := search.NewDefaultParameters()
+
+	cases := map[uint32]uint{
+		0:   store.DefaultNReplicas,    // server default
+		5:   5,                         // requested
+		100: store.DefaultNMaxReplicas, // capped by server max
+	}
+	for rqNReplicas, expected := range cases {
+		addedResult := store.NewInitialResult(search.NewInitialResult(key, searchParams))
+		addedResult.Responded = peer.NewTestPeers(rng, int(expected))
+		l := newPutLibrarian(rng, addedResult, nil)
+		rq := client.NewPutRequest(peerID, key, value)
+		rq.NReplicas = rqNReplicas
+
+		rp, err := l.Put(context.Background(), rq)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, l.storer.(*fixedStorer).lastParams.NReplicas)
+		assert.Equal(t, api.PutOperation_STORED, rp.Operation)
+
+		// global config unchanged
+		assert.Equal(t, store.DefaultNReplicas, l.config.Store.NReplicas)
+	}
+}
+
+func TestLibrarian_Put_quorum(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+
+	// create mock store result where only a quorum of the replicas have been stored
+	searchParams := search.NewDefaultParameters()
+	addedResult := store.NewInitialResult(search.NewInitialResult(key, searchParams))
+	addedResult.Responded = peer.NewTestPeers(rng, 2)
+
+	l := newPutLibrarian(rng, addedResult, nil)
+	l.config.Store.NReplicas, l.config.Store.Quorum = 3, 2
+	rq := client.NewPutRequest(peerID, key, value)
+
+	rp, err := l.Put(context.Background(), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), rp.NReplicas)
+	assert.Equal(t, api.PutOperation_STORED, rp.Operation)
+}
+
+func TestLibrarian_Put_shared(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+
+	searchParams := search.NewDefaultParameters()
+	nReplicas := searchParams.NClosestResponses
+	addedResult := store.NewInitialResult(search.NewInitialResult(key, searchParams))
+	addedResult.Responded = peer.NewTestPeers(rng, int(nReplicas))
+
+	l := newPutLibrarian(rng, addedResult, nil)
+	storer := &blockingStorer{result: addedResult, release: make(chan struct{})}
+	l.storer = storer
+
+	// concurrent Puts for the same key share the first one's store operation
+	nPuts := 4
+	var wg sync.WaitGroup
+	for c := 0; c < nPuts; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rq := client.NewPutRequest(peerID, key, value)
+			rp, err := l.Put(context.Background(), rq)
+			assert.Nil(t, err)
+			assert.Equal(t, api.PutOperation_STORED, rp.Operation)
+			assert.Equal(t, uint32(nReplicas), rp.NReplicas)
+			assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+		}()
+	}
+	time.Sleep(25 * time.Millisecond)
+	close(storer.release)
+	wg.Wait()
+	assert.Equal(t, 1, storer.nCalls())
+}
+
+func TestLibrarian_Put_ctxDone(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+
+	searchParams := search.NewDefaultParameters()
+	addedResult := store.NewInitialResult(search.NewInitialResult(key, searchParams))
+	addedResult.Responded = peer.NewTestPeers(rng, int(searchParams.NClosestResponses))
+
+	l := newPutLibrarian(rng, addedResult, nil)
+	storer := &blockingStorer{result: addedResult, release: make(chan struct{})}
+	l.storer = storer
+
+	// check first caller stops waiting once its ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	rp, err := l.Put(ctx, client.NewPutRequest(peerID, key, value))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, rp)
+
+	// check store continues on its own ctx after the first caller's is done
+	close(storer.release)
+	for len(storer.finishedCtxErrs()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []error{nil}, storer.finishedCtxErrs())
+	assert.Equal(t, 1, storer.nCalls())
+}
+
+func TestLibrarian_Put_Exists(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+
+	// create mock search result where the value has been stored
+	searchParams := search.NewDefaultParameters()
+	foundValueResult := search.NewInitialResult(key, searchParams)
+	foundValueResult.Value = value
+	existsResult := store.NewInitialResult(foundValueResult)
+
+	// create librarian and request
+	l := newPutLibrarian(rng, existsResult, nil)
+	rq := client.NewPutRequest(peerID, key, value)
 
 	// since fixedSearcher returns fixed value, should get that back in response
-	rp, err := l.Put(nil, rq)
+	rp, err := l.Put(context.Background(), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, api.PutOperation_LEFT_EXISTING, rp.Operation)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+}
+
+func TestLibrarian_Put_tombstone(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	authorID, peerID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	tombstone, err := client.NewTombstone(authorID, key)
+	assert.Nil(t, err)
+	tombstoneDoc := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+
+	// peers store the live document, which searches for the value find
+	l := newPutLibrarian(rng, nil, nil)
+	storeParams := l.config.Store
+	closest := peer.NewTestPeers(rng, int(storeParams.NReplicas+storeParams.NMaxErrors))
+	searcher := &storedValueSearcher{value: value, closest: closest}
+	l.storer = store.NewStorer(client.NewIDSigner(l.selfID), searcher, &fixedStoreQuerier{})
+
+	// check document itself is left existing
+	rp, err := l.Put(context.Background(), client.NewPutRequest(peerID, key, value))
+	assert.Nil(t, err)
+	assert.Equal(t, api.PutOperation_LEFT_EXISTING, rp.Operation)
+
+	// check tombstone is stored over the existing document
+	rq := client.NewPutRequest(peerID, key, tombstoneDoc)
+	rp, err = l.Put(context.Background(), rq)
+	assert.Nil(t, err)
+	assert.Equal(t, api.PutOperation_STORED, rp.Operation)
+	assert.True(t, rp.NReplicas >= uint32(storeParams.NReplicas))
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+}
+
+// storedValueSearcher searches peers that all store the value, which they return unless the
+// search is only for peers.
+type storedValueSearcher struct {
+	value   *api.Document
+	closest []peer.Peer
+}
+
+func (s *storedValueSearcher) Search(ctx context.Context, search *search.Search,
+	seeds []peer.Peer) error {
+	if err := search.Result.Closest.SafePushMany(s.closest); err != nil {
+		return err
+	}
+	if !search.PeersOnly() {
+		search.Result.Value = s.value
+	}
+	return nil
+}
+
+type fixedStoreQuerier struct{}
+
+func (f *fixedStoreQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.StoreRequest, opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	return &api.StoreResponse{
+		Metadata: &api.ResponseMetadata{RequestId: rq.Metadata.RequestId},
+	}, nil
+}
+
+func TestLibrarian_Put_Errored(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+
+	// create mock search result where the value has been stored
+	searchParams := search.NewDefaultParameters()
+	erroredResult := store.NewInitialResult(search.NewInitialResult(key, searchParams))
+	erroredResult.FatalErr = errors.New("some fatal error")
+
+	// create librarian and request
+	l := newPutLibrarian(rng, erroredResult, nil)
+	rq := client.NewPutRequest(peerID, key, value)
+
+	// since fixedSearcher returns fixed value, should get that back in response
+	rp, err := l.Put(context.Background(), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_Put_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(int64(0)))
+	value, key := api.NewTestDocument(rng)
+	peerID := ecid.NewPseudoRandom(rng)
+
+	// create librarian and request
+	l := newPutLibrarian(rng, nil, errors.New("some store error"))
+	rq := client.NewPutRequest(peerID, key, value)
+
+	// since fixedSearcher returns fixed value, should get that back in response
+	rp, err := l.Put(context.Background(), rq)
+	assert.NotNil(t, err)
+	assert.Nil(t, rp)
+}
+
+func TestLibrarian_Put_checkRequestError(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := &Librarian{}
+	value, key := api.NewTestDocument(rng)
+	rq := client.NewPutRequest(ecid.NewPseudoRandom(rng), key, value)
+	rq.Metadata.PubKey = []byte("corrupted pub key")
+
+	rp, err := l.Put(context.Background(), rq)
+	assert.Nil(t, rp)
+	assert.NotNil(t, err)
+}
+
+func TestLibrarian_SubscriptionStats_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	rt, peerID, _ := routing.NewTestWithPeers(rng, 8)
+	sub, err := subscribe.NewFPSubscription(0.5, rng)
+	assert.Nil(t, err)
+	filters, err := subscribe.NewFilters(sub)
+	assert.Nil(t, err)
+	inbound := subscribe.NewStats(ecid.NewPseudoRandom(rng), filters)
+	inbound.AddPub()
+	outbound := subscribe.NewStats(ecid.NewPseudoRandom(rng), filters)
+	l := &Librarian{
+		selfID: peerID,
+		rt:     rt,
+		subscribeFrom: &fixedFrom{
+			activeStats: []*subscribe.Stats{inbound},
+			totals:      subscribe.Totals{NPubs: 3, NDropped: 1},
+		},
+		subscribeTo: &fixedTo{
+			activeStats: []*subscribe.Stats{outbound},
+			totals: subscribe.Totals{
+				NPubs:     5,
+				NDropped:  2,
+				Latencies: subscribe.Latencies{Sum: time.Second},
+			},
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	rq := client.NewSubscriptionStatsRequest(ecid.NewPseudoRandom(rng))
+
+	rp, err := l.SubscriptionStats(nil, rq)
+	assert.Nil(t, err)
+	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	assert.Equal(t, []*api.SubscriptionStats{inbound.ToAPI()}, rp.Inbound)
+	assert.Equal(t, []*api.SubscriptionStats{outbound.ToAPI()}, rp.Outbound)
+	assert.Equal(t, uint64(3), rp.NSent)
+	assert.Equal(t, uint64(1), rp.NDropped)
+	assert.Equal(t, uint64(5), rp.NReceived)
+	assert.Equal(t, uint64(2), rp.NReceivedDropped)
+	assert.Equal(t, int64(time.Second), rp.ReceivedLatency.Sum)
+}
+
+func TestLibrarian_SubscriptionStats_checkRequestError(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := &Librarian{}
+	rq := client.NewSubscriptionStatsRequest(ecid.NewPseudoRandom(rng))
+	rq.Metadata.PubKey = []byte("corrupted pub key")
+
+	rp, err := l.SubscriptionStats(nil, rq)
+	assert.Nil(t, rp)
+	assert.NotNil(t, err)
+}
+
+func TestLibrarian_Subscribe_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	nPubs := 64
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	sf := &fixedFrom{
+		new:  newPubs,
+		done: done,
+	}
+	l := &Librarian{
+		selfID:        ecid.NewPseudoRandom(rng),
+		config:        NewDefaultConfig(),
+		subscribeFrom: sf,
+		rqv:           &alwaysRequestVerifier{},
+		rateLimiter:   &neverRateLimiter{},
+		logger:        clogging.NewDevInfoLogger(),
+	}
+
+	// create subscription that should cover both the author and reader filter code branches
+	targetFP := float64(0.5)
+	filterFP := math.Sqrt(targetFP) // so product of author and reader FP rates is targetFP
+	sub, err := subscribe.NewSubscription([][]byte{}, filterFP, [][]byte{}, filterFP, rng)
+	assert.Nil(t, err)
+
+	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	from := &fixedLibrarianSubscribeServer{
+		sent: make(chan *api.SubscribeResponse),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		from.rq = rq
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
+
+	// generate pubs we're going to send
+	newPubsMap := make(map[string]*api.Publication)
+	for c := 0; c < nPubs; c++ {
+		newPub := newKeyedPub(t, api.NewTestPublication(rng))
+		newPubsMap[newPub.Key.String()] = newPub.Value
+	}
+
+	// check % pubs sent to client is >= targetFP
+	sentPubs := 0
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		for sentPub := range from.sent {
+			key := cid.FromBytes(sentPub.Key)
+			value, in := newPubsMap[key.String()]
+			assert.True(t, in)
+			assert.Equal(t, value, sentPub.Value)
+			sentPubs++
+		}
+		info := fmt.Sprintf("sentPubs: %d, nPubs: %d", sentPubs, nPubs)
+		assert.True(t, int(float64(nPubs)*targetFP) < sentPubs, info)
+	}(wg)
+
+	// send pubs
+	for _, value := range newPubsMap {
+		newPubs <- newKeyedPub(t, value)
+	}
+
+	// ensure graceful end of l.Subscribe()
+	close(newPubs)
+	<-done
+
+	// ensure end to goroutine above that reads from from.sent
+	close(from.sent)
+
+	// wait for all goroutines to finish
+	wg.Wait()
+
+	// check subscription stats count the sent pubs
+	assert.Equal(t, uint64(sentPubs), sf.stats.NPubs())
+}
+
+func TestLibrarian_Subscribe_replay(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	pubLog, err := subscribe.NewPublicationLog(storage.NewPublicationKVDBStorerLoader(kvdb), 0)
+	assert.Nil(t, err)
+	nLogged := 4
+	for c := 0; c < nLogged; c++ {
+		_, err = pubLog.Append(newKeyedPub(t, api.NewTestPublication(rng)), nil)
+		assert.Nil(t, err)
+	}
+
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		pubLog:      pubLog,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
+	assert.Nil(t, err)
+	rq := client.NewReplaySubscribeRequest(ecid.NewPseudoRandom(rng), sub, 2)
+	from := &fixedLibrarianSubscribeServer{
+		sent: make(chan *api.SubscribeResponse, 2*nLogged),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		from.rq = rq
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
+
+	// live pub already replayed is skipped, while new logged and unlogged ones are sent
+	lastLogged := newKeyedPub(t, api.NewTestPublication(rng))
+	_, err = pubLog.Append(lastLogged, nil)
+	assert.Nil(t, err)
+	newPubs <- lastLogged
+	unlogged := newKeyedPub(t, api.NewTestPublication(rng))
+	newPubs <- unlogged
+	close(newPubs)
+	<-done
+	wg.Wait()
+	close(from.sent)
+
+	sentSeqs := make([]uint64, 0)
+	for rp := range from.sent {
+		sentSeqs = append(sentSeqs, rp.Sequence)
+	}
+	assert.Equal(t, []uint64{2, 3, 4, 5, 0}, sentSeqs)
+}
+
+func TestLibrarian_Subscribe_replayDisabled(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	l := &Librarian{
+		selfID:      ecid.NewPseudoRandom(rng),
+		config:      NewDefaultConfig(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng)
+	assert.Nil(t, err)
+	from := &fixedLibrarianSubscribeServer{
+		rq:   client.NewReplaySubscribeRequest(ecid.NewPseudoRandom(rng), sub, 2),
+		sent: make(chan *api.SubscribeResponse),
+	}
+
+	// check replay without a publication log errors
+	err = l.Subscribe(from)
+	assert.Equal(t, ErrPublicationLogDisabled, err)
+}
+
+func TestLibrarian_Subscribe_window(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
+	assert.Nil(t, err)
+	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	rq.Window = 2
+	from := &fixedLibrarianSubscribeServer{
+		rq:   rq,
+		acks: make(chan *api.SubscribeRequest),
+		sent: make(chan *api.SubscribeResponse, 4),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
+
+	// window fills after two pubs, so third is held until they're acknowledged
+	for c := 0; c < 2; c++ {
+		newPubs <- newKeyedPub(t, api.NewTestPublication(rng))
+		<-from.sent
+	}
+	newPubs <- newKeyedPub(t, api.NewTestPublication(rng))
+	select {
+	case <-from.sent:
+		assert.Fail(t, "unexpected send with full window")
+	case <-time.After(10 * time.Millisecond):
+	}
+	from.acks <- client.NewSubscribeAck(2)
+	<-from.sent
+
+	close(newPubs)
+	<-done
+	wg.Wait()
+	close(from.acks)
+}
+
+func TestLibrarian_Subscribe_rateLimited(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
+	assert.Nil(t, err)
+	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	rq.MaxPubsPerSecond = 50 // lower than librarian's max, so one every 20ms
+	nPubs := 4
+	from := &fixedLibrarianSubscribeServer{
+		rq:   rq,
+		sent: make(chan *api.SubscribeResponse, nPubs),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
+
+	start := time.Now()
+	for c := 0; c < nPubs; c++ {
+		newPubs <- newKeyedPub(t, api.NewTestPublication(rng))
+	}
+	close(newPubs)
+	<-done
+	wg.Wait()
+	assert.Len(t, from.sent, nPubs)
+	assert.True(t, time.Since(start) >= time.Duration(nPubs-1)*20*time.Millisecond)
+}
+
+func TestLibrarian_Subscribe_batch(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
 	assert.Nil(t, err)
-	assert.Equal(t, uint32(nReplicas), rp.NReplicas)
-	assert.Equal(t, api.PutOperation_STORED, rp.Operation)
-	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
-}
-
-func TestLibrarian_Put_Exists(t *testing.T) {
-	rng := rand.New(rand.NewSource(int64(0)))
-	value, key := api.NewTestDocument(rng)
-	peerID := ecid.NewPseudoRandom(rng)
-
-	// create mock search result where the value has been stored
-	searchParams := search.NewDefaultParameters()
-	foundValueResult := search.NewInitialResult(key, searchParams)
-	foundValueResult.Value = value
-	existsResult := store.NewInitialResult(foundValueResult)
+	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	rq.MaxBatchSize = 3
+	from := &fixedLibrarianSubscribeServer{
+		rq:   rq,
+		sent: make(chan *api.SubscribeResponse, 4),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
 
-	// create librarian and request
-	l := newPutLibrarian(rng, existsResult, nil)
-	rq := client.NewPutRequest(peerID, key, value)
+	// batch is sent once full
+	pubs := make([]*subscribe.KeyedPub, 4)
+	for c := range pubs {
+		pubs[c] = newKeyedPub(t, api.NewTestPublication(rng))
+		pubs[c].OriginTime = time.Now().UTC()
+		newPubs <- pubs[c]
+	}
+	rp := <-from.sent
+	assert.Nil(t, rp.Value)
+	assert.Len(t, rp.Batch, 3)
+	for c, bp := range rp.Batch {
+		assert.Equal(t, pubs[c].Key.Bytes(), bp.Key)
+		assert.Equal(t, pubs[c].Value, bp.Value)
+		assert.Equal(t, pubs[c].OriginTime.UnixNano(), bp.OriginTime)
+	}
 
-	// since fixedSearcher returns fixed value, should get that back in response
-	rp, err := l.Put(nil, rq)
-	assert.Nil(t, err)
-	assert.Equal(t, api.PutOperation_LEFT_EXISTING, rp.Operation)
-	assert.Equal(t, rq.Metadata.RequestId, rp.Metadata.RequestId)
+	// what's left of the batch is sent when the fanout ends
+	close(newPubs)
+	<-done
+	wg.Wait()
+	rp = <-from.sent
+	assert.Len(t, rp.Batch, 1)
+	assert.Equal(t, pubs[3].Value, rp.Batch[0].Value)
 }
 
-func TestLibrarian_Put_Errored(t *testing.T) {
-	rng := rand.New(rand.NewSource(int64(0)))
-	value, key := api.NewTestDocument(rng)
-	peerID := ecid.NewPseudoRandom(rng)
-
-	// create mock search result where the value has been stored
-	searchParams := search.NewDefaultParameters()
-	erroredResult := store.NewInitialResult(search.NewInitialResult(key, searchParams))
-	erroredResult.FatalErr = errors.New("some fatal error")
+func TestLibrarian_Subscribe_batchDelay(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
+	assert.Nil(t, err)
+	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	rq.MaxBatchSize, rq.MaxBatchDelay = 4, int64(10*time.Millisecond)
+	from := &fixedLibrarianSubscribeServer{
+		rq:   rq,
+		sent: make(chan *api.SubscribeResponse, 4),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
 
-	// create librarian and request
-	l := newPutLibrarian(rng, erroredResult, nil)
-	rq := client.NewPutRequest(peerID, key, value)
+	// batch is sent once its max delay elapses, even though it isn't full
+	newPubs <- newKeyedPub(t, api.NewTestPublication(rng))
+	assert.Len(t, (<-from.sent).Batch, 1)
 
-	// since fixedSearcher returns fixed value, should get that back in response
-	rp, err := l.Put(nil, rq)
-	assert.NotNil(t, err)
-	assert.Nil(t, rp)
+	close(newPubs)
+	<-done
+	wg.Wait()
 }
 
-func TestLibrarian_Put_err(t *testing.T) {
-	rng := rand.New(rand.NewSource(int64(0)))
-	value, key := api.NewTestDocument(rng)
-	peerID := ecid.NewPseudoRandom(rng)
+func TestLibrarian_Subscribe_batchWindow(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
+	assert.Nil(t, err)
+	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	rq.Window, rq.MaxBatchSize = 2, 4
+	from := &fixedLibrarianSubscribeServer{
+		rq:   rq,
+		acks: make(chan *api.SubscribeRequest),
+		sent: make(chan *api.SubscribeResponse, 4),
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
+	}(wg)
 
-	// create librarian and request
-	l := newPutLibrarian(rng, nil, errors.New("some store error"))
-	rq := client.NewPutRequest(peerID, key, value)
+	// batch is sent before it's full when the window fills, so its pubs can be acknowledged
+	for c := 0; c < 3; c++ {
+		newPubs <- newKeyedPub(t, api.NewTestPublication(rng))
+	}
+	assert.Len(t, (<-from.sent).Batch, 2)
+	from.acks <- client.NewSubscribeAck(2)
 
-	// since fixedSearcher returns fixed value, should get that back in response
-	rp, err := l.Put(nil, rq)
-	assert.NotNil(t, err)
-	assert.Nil(t, rp)
+	close(newPubs)
+	<-done
+	wg.Wait()
+	close(from.acks)
+	assert.Len(t, (<-from.sent).Batch, 1)
 }
 
-func TestLibrarian_Put_checkRequestError(t *testing.T) {
+func TestNewSubscribeBatcher(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
-	l := &Librarian{}
-	value, key := api.NewTestDocument(rng)
-	rq := client.NewPutRequest(ecid.NewPseudoRandom(rng), key, value)
-	rq.Metadata.PubKey = []byte("corrupted pub key")
-
-	rp, err := l.Put(nil, rq)
-	assert.Nil(t, rp)
-	assert.NotNil(t, err)
+	params := subscribe.NewDefaultFromParameters()
+	rq := &api.SubscribeRequest{}
+	assert.Nil(t, newSubscribeBatcher(params, rq))
+
+	rq.MaxBatchSize = 1
+	assert.Nil(t, newSubscribeBatcher(params, rq))
+
+	rq.MaxBatchSize = 4
+	params.MaxBatchSize = 0
+	assert.Nil(t, newSubscribeBatcher(params, rq))
+
+	params = subscribe.NewDefaultFromParameters()
+	rq.MaxBatchDelay = int64(time.Millisecond)
+	b := newSubscribeBatcher(params, rq)
+	assert.NotNil(t, b)
+	for c := 0; c < 3; c++ {
+		assert.False(t, b.Add(newKeyedPub(t, api.NewTestPublication(rng))))
+	}
+	assert.True(t, b.Add(newKeyedPub(t, api.NewTestPublication(rng))))
+	assert.Nil(t, batchDue(nil))
 }
 
-func TestLibrarian_Subscribe_ok(t *testing.T) {
+func TestLibrarian_Subscribe_keepalive(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
-	nPubs := 64
 	newPubs := make(chan *subscribe.KeyedPub)
 	done := make(chan struct{})
+	config := NewDefaultConfig()
+	config.SubscribeFrom.KeepaliveInterval = 10 * time.Millisecond
 	l := &Librarian{
 		selfID: ecid.NewPseudoRandom(rng),
+		config: config,
 		subscribeFrom: &fixedFrom{
 			new:  newPubs,
 			done: done,
 		},
-		rqv:    &alwaysRequestVerifier{},
-		logger: clogging.NewDevInfoLogger(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
 	}
-
-	// create subscription that should cover both the author and reader filter code branches
-	targetFP := float64(0.5)
-	filterFP := math.Sqrt(targetFP) // so product of author and reader FP rates is targetFP
-	sub, err := subscribe.NewSubscription([][]byte{}, filterFP, [][]byte{}, filterFP, rng)
+	sub, err := subscribe.NewFPSubscription(1.0, rng) // get everything
 	assert.Nil(t, err)
-
-	rq := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
 	from := &fixedLibrarianSubscribeServer{
-		sent: make(chan *api.SubscribeResponse),
+		rq:   client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub),
+		sent: make(chan *api.SubscribeResponse, 8),
 	}
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
 	go func(wg *sync.WaitGroup) {
 		defer wg.Done()
-		err = l.Subscribe(rq, from)
+		err = l.Subscribe(from)
 		assert.Nil(t, err)
 	}(wg)
 
-	// generate pubs we're going to send
-	newPubsMap := make(map[string]*api.Publication)
-	for c := 0; c < nPubs; c++ {
-		newPub := newKeyedPub(t, api.NewTestPublication(rng))
-		newPubsMap[newPub.Key.String()] = newPub.Value
+	// keepalives are sent while there are no publications
+	keepalive := <-from.sent
+	assert.NotNil(t, keepalive.Metadata)
+	assert.Nil(t, keepalive.Value)
+
+	// publications carry their origin time
+	pub := newKeyedPub(t, api.NewTestPublication(rng))
+	pub.OriginTime = time.Now().UTC()
+	newPubs <- pub
+	close(newPubs)
+	<-done
+	wg.Wait()
+	close(from.sent)
+	sent := make([]*api.SubscribeResponse, 0)
+	for rp := range from.sent {
+		if rp.Value != nil {
+			sent = append(sent, rp)
+		}
 	}
+	assert.Len(t, sent, 1)
+	assert.Equal(t, pub.Value, sent[0].Value)
+	assert.Equal(t, pub.OriginTime.UnixNano(), sent[0].OriginTime)
+}
 
-	// check % pubs sent to client is >= targetFP
+func TestNewKeepalives(t *testing.T) {
+	keepalives, stop := newKeepalives(0)
+	assert.Nil(t, keepalives)
+	stop()
+
+	keepalives, stop = newKeepalives(time.Millisecond)
+	defer stop()
+	select {
+	case <-keepalives:
+	case <-time.After(time.Second):
+		t.Fatal("keepalive not received")
+	}
+}
+
+func TestLibrarian_Subscribe_update(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	newPubs := make(chan *subscribe.KeyedPub)
+	done := make(chan struct{})
+	l := &Librarian{
+		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
+		subscribeFrom: &fixedFrom{
+			new:  newPubs,
+			done: done,
+		},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	pub1, pub2 := api.NewTestPublication(rng), api.NewTestPublication(rng)
+	pub3 := api.NewTestPublication(rng) // matches neither filter
+	sub1, err := subscribe.NewSubscription([][]byte{pub1.AuthorPublicKey}, 1e-6,
+		[][]byte{pub1.ReaderPublicKey}, 1e-6, rng)
+	assert.Nil(t, err)
+	from := &fixedLibrarianSubscribeServer{
+		rq:   client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub1),
+		acks: make(chan *api.SubscribeRequest),
+		sent: make(chan *api.SubscribeResponse, 4),
+	}
+	wg := new(sync.WaitGroup)
 	wg.Add(1)
 	go func(wg *sync.WaitGroup) {
 		defer wg.Done()
-		sentPubs := 0
-		for sentPub := range from.sent {
-			key := cid.FromBytes(sentPub.Key)
-			value, in := newPubsMap[key.String()]
-			assert.True(t, in)
-			assert.Equal(t, value, sentPub.Value)
-			sentPubs++
-		}
-		info := fmt.Sprintf("sentPubs: %d, nPubs: %d", sentPubs, nPubs)
-		assert.True(t, int(float64(nPubs)*targetFP) < sentPubs, info)
+		err = l.Subscribe(from)
+		assert.Nil(t, err)
 	}(wg)
 
-	// send pubs
-	for _, value := range newPubsMap {
-		newPubs <- newKeyedPub(t, value)
-	}
+	// only pub1 matches initial filters
+	newPubs <- newKeyedPub(t, pub1)
+	newPubs <- newKeyedPub(t, pub2)
+	newPubs <- newKeyedPub(t, pub3) // ensures pub2 has been handled before update
+
+	// after update, only pub2 matches
+	sub2, err := subscribe.NewSubscription([][]byte{pub2.AuthorPublicKey}, 1e-6,
+		[][]byte{pub2.ReaderPublicKey}, 1e-6, rng)
+	assert.Nil(t, err)
+	from.acks <- client.NewSubscriptionUpdate(sub2)
+	from.acks <- client.NewSubscribeAck(1) // ensures update has been handled
+	newPubs <- newKeyedPub(t, pub1)
+	newPubs <- newKeyedPub(t, pub2)
 
-	// ensure graceful end of l.Subscribe()
 	close(newPubs)
 	<-done
-
-	// ensure end to goroutine above that reads from from.sent
+	wg.Wait()
+	close(from.acks)
 	close(from.sent)
+	sent := make([]*api.Publication, 0)
+	for rp := range from.sent {
+		sent = append(sent, rp.Value)
+	}
+	assert.Equal(t, []*api.Publication{pub1, pub2}, sent)
+}
 
-	// wait for all goroutines to finish
-	wg.Wait()
+func TestAllowedSubscriber(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	pubKey1 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	pubKey2 := ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng))
+	assert.True(t, allowedSubscriber(nil, pubKey1))
+	assert.True(t, allowedSubscriber([][]byte{pubKey2, pubKey1}, pubKey1))
+	assert.False(t, allowedSubscriber([][]byte{pubKey2}, pubKey1))
 }
 
 func TestLibrarian_Subscribe_err(t *testing.T) {
@@ -723,21 +2312,61 @@ func TestLibrarian_Subscribe_err(t *testing.T) {
 		sent: make(chan *api.SubscribeResponse),
 	}
 
+	// check Recv error bubbles up
+	l0 := &Librarian{rqv: &alwaysRequestVerifier{}, rateLimiter: &neverRateLimiter{}}
+	err = l0.Subscribe(&fixedLibrarianSubscribeServer{recvErr: errors.New("some Recv error")})
+	assert.NotNil(t, err)
+
 	// check request error bubbles up
 	l1 := &Librarian{
-		rqv: &neverRequestVerifier{},
-		rt:  routing.NewEmpty(selfID, routing.NewDefaultParameters()),
+		rqv:         &neverRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		rt:          routing.NewEmpty(selfID, routing.NewDefaultParameters()),
 	}
-	err = l1.Subscribe(rq, from)
+	from.rq = rq
+	err = l1.Subscribe(from)
 	assert.NotNil(t, err)
 
+	// check client not allowed to subscribe errors
+	config := NewDefaultConfig()
+	config.SubscribeFrom.AllowedPublicKeys = [][]byte{
+		ecid.ToPublicKeyBytes(ecid.NewPseudoRandom(rng)),
+	}
+	l1a := &Librarian{
+		config:      config,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	from.rq = client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub)
+	err = l1a.Subscribe(from)
+	assert.Equal(t, ErrSubscribeNotAllowed, err)
+
+	// check too many simultaneous subscriptions errors
+	subID := ecid.NewPseudoRandom(rng)
+	l1b := &Librarian{
+		config:      NewDefaultConfig(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		subLimiter:  NewConcurrencyLimiter(1, 0),
+	}
+	assert.True(t, l1b.subLimiter.Acquire(subID.ID()))
+	from.rq = client.NewSubscribeRequest(subID, sub)
+	err = l1b.Subscribe(from)
+	assert.Equal(t, ErrTooManySubscriptions, err)
+
 	// check author filter error bubbles up
 	sub2, err := subscribe.NewFPSubscription(1.0, rng)
 	assert.Nil(t, err)
 	sub2.AuthorPublicKeys.Encoded = nil // will trigger error
 	rq2 := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub2)
-	l2 := &Librarian{rqv: &alwaysRequestVerifier{}}
-	err = l2.Subscribe(rq2, from)
+	l2 := &Librarian{
+		config:      NewDefaultConfig(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+	}
+	from.rq = rq2
+	err = l2.Subscribe(from)
 	assert.NotNil(t, err)
 
 	// check reader filter error bubbles up
@@ -745,8 +2374,13 @@ func TestLibrarian_Subscribe_err(t *testing.T) {
 	assert.Nil(t, err)
 	sub3.ReaderPublicKeys.Encoded = nil // will trigger error
 	rq3 := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub3)
-	l3 := &Librarian{rqv: &alwaysRequestVerifier{}}
-	err = l3.Subscribe(rq3, from)
+	l3 := &Librarian{
+		config:      NewDefaultConfig(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+	}
+	from.rq = rq3
+	err = l3.Subscribe(from)
 	assert.NotNil(t, err)
 
 	// check subscribeFrom.New() bubbles up
@@ -755,12 +2389,15 @@ func TestLibrarian_Subscribe_err(t *testing.T) {
 	rq4 := client.NewSubscribeRequest(ecid.NewPseudoRandom(rng), sub4)
 	l4 := &Librarian{
 		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
 		subscribeFrom: &fixedFrom{
 			err: subscribe.ErrNotAcceptingNewSubscriptions,
 		},
-		rqv: &alwaysRequestVerifier{},
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
 	}
-	err = l4.Subscribe(rq4, from)
+	from.rq = rq4
+	err = l4.Subscribe(from)
 	assert.Equal(t, subscribe.ErrNotAcceptingNewSubscriptions, err)
 
 	// check from.Send() error bubbles up
@@ -770,12 +2407,14 @@ func TestLibrarian_Subscribe_err(t *testing.T) {
 	newPubs := make(chan *subscribe.KeyedPub)
 	l5 := &Librarian{
 		selfID: ecid.NewPseudoRandom(rng),
+		config: NewDefaultConfig(),
 		subscribeFrom: &fixedFrom{
 			new:  newPubs,
 			done: make(chan struct{}),
 		},
-		rqv:    &alwaysRequestVerifier{},
-		logger: clogging.NewDevInfoLogger(),
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
 	}
 	from5 := &fixedLibrarianSubscribeServer{
 		err: errors.New("some Subscribe error"),
@@ -784,11 +2423,39 @@ func TestLibrarian_Subscribe_err(t *testing.T) {
 	wg.Add(1)
 	go func(wg *sync.WaitGroup) {
 		defer wg.Done()
-		err = l5.Subscribe(rq5, from5)
+		from5.rq = rq5
+		err = l5.Subscribe(from5)
 		assert.NotNil(t, err)
 	}(wg)
 	newPubs <- newKeyedPub(t, api.NewTestPublication(rng))
 	wg.Wait()
+
+	// check replay from.Send() error bubbles up
+	sub6, err := subscribe.NewFPSubscription(1.0, rng)
+	assert.Nil(t, err)
+	rq6 := client.NewReplaySubscribeRequest(ecid.NewPseudoRandom(rng), sub6, 1)
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	pubLog, err := subscribe.NewPublicationLog(storage.NewPublicationKVDBStorerLoader(kvdb), 0)
+	assert.Nil(t, err)
+	_, err = pubLog.Append(newKeyedPub(t, api.NewTestPublication(rng)), nil)
+	assert.Nil(t, err)
+	l6 := &Librarian{
+		selfID:      ecid.NewPseudoRandom(rng),
+		config:      NewDefaultConfig(),
+		pubLog:      pubLog,
+		rqv:         &alwaysRequestVerifier{},
+		rateLimiter: &neverRateLimiter{},
+		logger:      clogging.NewDevInfoLogger(),
+	}
+	from6 := &fixedLibrarianSubscribeServer{
+		rq:  rq6,
+		err: errors.New("some Subscribe error"),
+	}
+	err = l6.Subscribe(from6)
+	assert.NotNil(t, err)
 }
 
 func newKeyedPub(t *testing.T, pub *api.Publication) *subscribe.KeyedPub {
@@ -801,20 +2468,33 @@ func newKeyedPub(t *testing.T, pub *api.Publication) *subscribe.KeyedPub {
 }
 
 type fixedFrom struct {
-	new  chan *subscribe.KeyedPub
-	done chan struct{}
-	err  error
+	new         chan *subscribe.KeyedPub
+	done        chan struct{}
+	err         error
+	stats       *subscribe.Stats
+	activeStats []*subscribe.Stats
+	totals      subscribe.Totals
 }
 
-func (f *fixedFrom) New() (chan *subscribe.KeyedPub, chan struct{}, error) {
+func (f *fixedFrom) New(stats *subscribe.Stats) (chan *subscribe.KeyedPub, chan struct{}, error) {
+	f.stats = stats
 	return f.new, f.done, f.err
 }
 
+func (f *fixedFrom) Stats() ([]*subscribe.Stats, subscribe.Totals) {
+	return f.activeStats, f.totals
+}
+
 func (f *fixedFrom) Fanout() {}
 
 type fixedTo struct {
-	beginErr error
-	sendErr  error
+	beginErr    error
+	sendErr     error
+	saveErr     error
+	nSaves      int
+	activeStats []*subscribe.Stats
+	totals      subscribe.Totals
+	mu          sync.Mutex
 }
 
 func (t *fixedTo) Begin() error {
@@ -823,13 +2503,60 @@ func (t *fixedTo) Begin() error {
 
 func (t *fixedTo) End() {}
 
+func (t *fixedTo) Save(ns storage.NamespaceStorer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nSaves++
+	return t.saveErr
+}
+
+func (t *fixedTo) saves() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.nSaves
+}
+
+func (t *fixedTo) Restore(nl storage.NamespaceLoader) error {
+	return nil
+}
+
 func (t *fixedTo) Send(pub *api.Publication) error {
 	return t.sendErr
 }
 
+func (t *fixedTo) Stats() ([]*subscribe.Stats, subscribe.Totals) {
+	return t.activeStats, t.totals
+}
+
 type fixedLibrarianSubscribeServer struct {
-	sent chan *api.SubscribeResponse
-	err  error
+	rq      *api.SubscribeRequest
+	acks    chan *api.SubscribeRequest
+	recvErr error
+	sent    chan *api.SubscribeResponse
+	err     error
+	mu      sync.Mutex
+}
+
+// Recv returns the subscription request first and then any acks until the acks channel is closed.
+func (f *fixedLibrarianSubscribeServer) Recv() (*api.SubscribeRequest, error) {
+	if f.recvErr != nil {
+		return nil, f.recvErr
+	}
+	f.mu.Lock()
+	rq := f.rq
+	f.rq = nil
+	f.mu.Unlock()
+	if rq != nil {
+		return rq, nil
+	}
+	if f.acks == nil {
+		return nil, io.EOF
+	}
+	ack, open := <-f.acks
+	if !open {
+		return nil, io.EOF
+	}
+	return ack, nil
 }
 
 func (f *fixedLibrarianSubscribeServer) Send(rp *api.SubscribeResponse) error {
@@ -852,7 +2579,7 @@ func (f *fixedLibrarianSubscribeServer) SendHeader(metadata.MD) error {
 func (f *fixedLibrarianSubscribeServer) SetTrailer(metadata.MD) {}
 
 func (f *fixedLibrarianSubscribeServer) Context() context.Context {
-	return nil
+	return context.Background()
 }
 
 func (f *fixedLibrarianSubscribeServer) SendMsg(m interface{}) error {
@@ -876,7 +2603,10 @@ func newPutLibrarian(rng *rand.Rand, storeResult *store.Result, searchErr error)
 			result: storeResult,
 			err:    searchErr,
 		},
-		rqv:    &alwaysRequestVerifier{},
-		logger: clogging.NewDevInfoLogger(),
+		searchCache:  search.NewDefaultResultCache(),
+		storeFlights: store.NewInFlight(),
+		rqv:          &alwaysRequestVerifier{},
+		rateLimiter:  &neverRateLimiter{},
+		logger:       clogging.NewDevInfoLogger(),
 	}
 }
@@ -1,13 +1,19 @@
 package server
 
 import (
+	"bytes"
+	"crypto"
 	"fmt"
+	"time"
 
 	"errors"
 
 	"github.com/drausin/libri/libri/common/ecid"
 	cid "github.com/drausin/libri/libri/common/id"
 	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/api"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/drausin/libri/libri/librarian/server/routing"
 	"github.com/golang/protobuf/proto"
 	"go.uber.org/zap"
@@ -26,10 +32,16 @@ const (
 )
 
 var (
-	peerIDKey = []byte("PeerID")
+	peerIDKey         = []byte("PeerID")
+	peerIDRotationKey = []byte("PeerIDRotation")
+	denylistKey       = []byte("Denylist")
 )
 
-func loadOrCreatePeerID(logger *zap.Logger, nsl storage.NamespaceStorerLoader) (ecid.ID, error) {
+// loadOrCreatePeerID loads the saved peer ID or creates and saves a new one on the named curve with
+// at least the given difficulty.
+func loadOrCreatePeerID(
+	logger *zap.Logger, nsl storage.NamespaceStorerLoader, curveName string, minDifficulty uint,
+) (ecid.ID, error) {
 	bytes, err := nsl.Load(peerIDKey)
 	if err != nil {
 		logger.Error("error loading peer ID", zap.Error(err))
@@ -47,13 +59,26 @@ func loadOrCreatePeerID(logger *zap.Logger, nsl storage.NamespaceStorerLoader) (
 			logger.Error("error deserializing peer ID keys", zap.Error(err))
 			return nil, err
 		}
+		if ecid.Difficulty(peerID) < minDifficulty {
+			logger.Warn("loaded peer ID has insufficient difficulty; other peers requiring "+
+				"it will not add this peer to their routing tables",
+				zap.String(LoggerPeerID, peerID.String()),
+				zap.Uint("difficulty", ecid.Difficulty(peerID)),
+			)
+		}
 		logger.Info("loaded exsting peer ID", zap.String(LoggerPeerID, peerID.String()))
 		return peerID, nil
 	}
 
 	// return new PeerID
-	peerID := ecid.NewRandom()
-	logger.Info("created new peer ID", zap.String(LoggerPeerID, peerID.String()))
+	peerID, err := ecid.NewRandomOnCurve(curveName, minDifficulty)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("created new peer ID",
+		zap.String(LoggerPeerID, peerID.String()),
+		zap.String("curve", peerID.CurveName()),
+	)
 	return peerID, savePeerID(nsl, peerID)
 }
 
@@ -65,9 +90,104 @@ func savePeerID(ns storage.NamespaceStorer, peerID ecid.ID) error {
 	return ns.Store(peerIDKey, bytes)
 }
 
+// rotatePeerID replaces the peer ID with a new one, saving it along with the rotation linking it
+// to the old one. The new ID is on the named curve, which may differ from the old one's, and has at
+// least the given difficulty. The saved routing table is re-keyed to the new ID.
+func rotatePeerID(logger *zap.Logger, nsl storage.NamespaceStorerLoader, oldID ecid.ID,
+	params *routing.Parameters, curveName string, minDifficulty uint) (
+	ecid.ID, *api.KeyRotation, error) {
+	newID, err := ecid.NewRandomOnCurve(curveName, minDifficulty)
+	if err != nil {
+		return nil, nil, err
+	}
+	rotation, err := client.NewKeyRotation(oldID, newID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rt, err := routing.Load(nsl, params, peer.NewFromer())
+	if err != nil {
+		logger.Error("error loading routing table", zap.Error(err))
+		return nil, nil, err
+	}
+	if rt != nil {
+		peers := rt.Pop(rt.SelfID(), uint(rt.NumPeers()))
+		rekeyed, _ := routing.NewWithPeers(newID.ID(), params, peers)
+		if err := rekeyed.Save(nsl); err != nil {
+			return nil, nil, err
+		}
+	}
+	rotationBytes, err := proto.Marshal(rotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := nsl.Store(peerIDRotationKey, rotationBytes); err != nil {
+		return nil, nil, err
+	}
+	logger.Info("rotated peer ID",
+		zap.Stringer("old_"+LoggerPeerID, oldID),
+		zap.Stringer(LoggerPeerID, newID),
+	)
+	return newID, rotation, savePeerID(nsl, newID)
+}
+
+// loadSessionPeerID returns the identity in the session certificate as the peer ID, after
+// verifying that the certificate links the session key to it. The returned peer ID has no private
+// key, since the identity private key is kept offline.
+func loadSessionPeerID(logger *zap.Logger, sessionKey ecid.ID, cert *api.SessionCertificate) (
+	ecid.ID, error) {
+	if cert == nil {
+		return nil, api.ErrUnexpectedNilValue
+	}
+	if !bytes.Equal(ecid.ToPublicKeyBytes(sessionKey), cert.SessionPubKey) {
+		return nil, client.ErrSessionKeyMismatch
+	}
+	peerID, err := ecid.FromAnyPublicKeyBytes(cert.IdentityPubKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.VerifySessionCertificate(cert, peerID.PublicKey()); err != nil {
+		logger.Error("error verifying session certificate", zap.Error(err))
+		return nil, err
+	}
+	logger.Info("using session key for peer ID",
+		zap.String(LoggerPeerID, peerID.String()),
+		zap.Time("session_expiration", time.Unix(cert.Expiration, 0)),
+	)
+	return peerID, nil
+}
+
+// loadKeySignerPeerID returns the key signer's public key as the peer ID. The returned peer ID has
+// no private key, since the key signer keeps it outside the process.
+func loadKeySignerPeerID(logger *zap.Logger, key crypto.Signer) (ecid.ID, error) {
+	peerID, err := client.KeySignerID(key)
+	if err != nil {
+		logger.Error("error loading key signer public key", zap.Error(err))
+		return nil, err
+	}
+	logger.Info("using key signer for peer ID", zap.String(LoggerPeerID, peerID.String()))
+	return peerID, nil
+}
+
+// loadPeerIDRotation loads the rotation linking the peer ID to its previous one, returning nil if
+// there isn't one for the current peer ID.
+func loadPeerIDRotation(nl storage.NamespaceLoader, peerID ecid.ID) (*api.KeyRotation, error) {
+	rotationBytes, err := nl.Load(peerIDRotationKey)
+	if err != nil || rotationBytes == nil {
+		return nil, err
+	}
+	rotation := &api.KeyRotation{}
+	if err := proto.Unmarshal(rotationBytes, rotation); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(rotation.NewPubKey, ecid.ToPublicKeyBytes(peerID)) {
+		return nil, nil
+	}
+	return rotation, nil
+}
+
 func loadOrCreateRoutingTable(logger *zap.Logger, nl storage.NamespaceLoader, selfID cid.ID,
-	params *routing.Parameters) (routing.Table, error) {
-	rt, err := routing.Load(nl, params)
+	params *routing.Parameters, fromer peer.Fromer) (routing.Table, error) {
+	rt, err := routing.Load(nl, params, fromer)
 	if err != nil {
 		logger.Error("error loading routing table", zap.Error(err))
 		return nil, err
@@ -91,3 +211,33 @@ func loadOrCreateRoutingTable(logger *zap.Logger, nl storage.NamespaceLoader, se
 	defer logger.Info("created new routing table")
 	return routing.NewEmpty(selfID, params), nil
 }
+
+// loadDenylist loads the denylist saved by previous admin updates, adding the configured keys to
+// it.
+func loadDenylist(logger *zap.Logger, nl storage.NamespaceLoader, configured []cid.ID) (
+	Denylist, error) {
+	denylistBytes, err := nl.Load(denylistKey)
+	if err != nil {
+		logger.Error("error loading denylist", zap.Error(err))
+		return nil, err
+	}
+	dl := NewDenylist()
+	if denylistBytes != nil {
+		stored := &storage.Denylist{}
+		if err := proto.Unmarshal(denylistBytes, stored); err != nil {
+			return nil, err
+		}
+		dl = FromStoredDenylist(stored)
+	}
+	dl.Add(configured...)
+	logger.Info("loaded denylist", zap.Int("n_keys", len(dl.Keys())))
+	return dl, nil
+}
+
+func saveDenylist(ns storage.NamespaceStorer, dl Denylist) error {
+	denylistBytes, err := proto.Marshal(dl.ToStored())
+	if err != nil {
+		return err
+	}
+	return ns.Store(denylistKey, denylistBytes)
+}
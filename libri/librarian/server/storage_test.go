@@ -3,13 +3,17 @@ package server
 import (
 	"math/rand"
 	"testing"
+	"time"
 
 	"errors"
 
+	"github.com/drausin/libri/libri/common/db"
 	"github.com/drausin/libri/libri/common/ecid"
 	"github.com/drausin/libri/libri/common/id"
 	clogging "github.com/drausin/libri/libri/common/logging"
 	"github.com/drausin/libri/libri/common/storage"
+	"github.com/drausin/libri/libri/librarian/client"
+	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/drausin/libri/libri/librarian/server/routing"
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
@@ -18,17 +22,31 @@ import (
 func TestLoadOrCreatePeerID_ok(t *testing.T) {
 
 	// create new peer ID
-	id1, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{})
+	id1, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		ecid.CurveName, 0)
 	assert.NotNil(t, id1)
 	assert.Nil(t, err)
 
-	// load existing
+	// create new peer ID with difficulty
+	id1, err = loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		ecid.CurveName, 4)
+	assert.Nil(t, err)
+	assert.True(t, ecid.Difficulty(id1) >= 4)
+
+	// create new Ed25519 peer ID
+	id1, err = loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		ecid.Ed25519CurveName, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, ecid.Ed25519CurveName, id1.CurveName())
+
+	// load existing, whose curve differs from the one for new peer IDs
 	rng := rand.New(rand.NewSource(0))
 	peerID2 := ecid.NewPseudoRandom(rng)
 	bytes, err := proto.Marshal(ecid.ToStored(peerID2))
 	assert.Nil(t, err)
 
-	id2, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{loadBytes: bytes})
+	id2, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(),
+		&fixedStorerLoader{loadBytes: bytes}, ecid.Ed25519CurveName, 0)
 
 	assert.Equal(t, peerID2, id2)
 	assert.Nil(t, err)
@@ -37,15 +55,20 @@ func TestLoadOrCreatePeerID_ok(t *testing.T) {
 func TestLoadOrCreatePeerID_err(t *testing.T) {
 	id1, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{
 		loadErr: errors.New("some load error"),
-	})
+	}, ecid.CurveName, 0)
 	assert.Nil(t, id1)
 	assert.NotNil(t, err)
 
 	id2, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{
 		loadBytes: []byte("the wrong bytes"),
-	})
+	}, ecid.CurveName, 0)
 	assert.Nil(t, id2)
 	assert.NotNil(t, err)
+
+	id3, err := loadOrCreatePeerID(clogging.NewDevInfoLogger(), &fixedStorerLoader{},
+		"other curve", 0)
+	assert.Nil(t, id3)
+	assert.Equal(t, ecid.ErrUnknownCurve, err)
 }
 
 func TestSavePeerID(t *testing.T) {
@@ -53,6 +76,173 @@ func TestSavePeerID(t *testing.T) {
 	assert.Nil(t, savePeerID(&fixedStorerLoader{}, ecid.NewPseudoRandom(rng)))
 }
 
+func TestRotatePeerID_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	nsl := storage.NewServerKVDBStorerLoader(kvdb)
+	lg, params := clogging.NewDevInfoLogger(), routing.NewDefaultParameters()
+
+	oldID, err := loadOrCreatePeerID(lg, nsl, ecid.CurveName, 0)
+	assert.Nil(t, err)
+	rt1, _, _ := routing.NewTestWithPeers(rng, 8)
+	rt1, _ = routing.NewWithPeers(oldID.ID(), params, rt1.Peak(rt1.SelfID(), 8))
+	assert.Nil(t, rt1.Save(nsl))
+
+	newID, rotation, err := rotatePeerID(lg, nsl, oldID, params, ecid.Ed25519CurveName, 4)
+	assert.Nil(t, err)
+	assert.NotEqual(t, oldID, newID)
+	assert.Equal(t, ecid.Ed25519CurveName, newID.CurveName())
+	assert.True(t, ecid.Difficulty(newID) >= 4)
+	assert.Nil(t, client.VerifyKeyRotation(rotation))
+	assert.Equal(t, ecid.ToPublicKeyBytes(oldID), rotation.OldPubKey)
+	assert.Equal(t, ecid.ToPublicKeyBytes(newID), rotation.NewPubKey)
+
+	// check new peer ID, rotation, and re-keyed routing table are saved
+	loadedID, err := loadOrCreatePeerID(lg, nsl, ecid.CurveName, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, newID, loadedID)
+	loadedRotation, err := loadPeerIDRotation(nsl, loadedID)
+	assert.Nil(t, err)
+	assert.Equal(t, rotation, loadedRotation)
+	rt2, err := loadOrCreateRoutingTable(lg, nsl, loadedID, params, peer.NewFromer())
+	assert.Nil(t, err)
+	assert.Equal(t, rt1.NumPeers(), rt2.NumPeers())
+
+	// check rotation isn't returned for another peer ID
+	loadedRotation, err = loadPeerIDRotation(nsl, ecid.NewPseudoRandom(rng))
+	assert.Nil(t, err)
+	assert.Nil(t, loadedRotation)
+}
+
+func TestRotatePeerID_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	lg, params := clogging.NewDevInfoLogger(), routing.NewDefaultParameters()
+
+	newID, rotation, err := rotatePeerID(lg, &fixedStorerLoader{
+		loadErr: errors.New("some load error"),
+	}, ecid.NewPseudoRandom(rng), params, ecid.CurveName, 0)
+	assert.NotNil(t, err)
+	assert.Nil(t, newID)
+	assert.Nil(t, rotation)
+
+	newID, rotation, err = rotatePeerID(lg, &fixedStorerLoader{
+		storeErr: errors.New("some store error"),
+	}, ecid.NewPseudoRandom(rng), params, ecid.CurveName, 0)
+	assert.NotNil(t, err)
+	assert.Nil(t, newID)
+	assert.Nil(t, rotation)
+
+	newID, rotation, err = rotatePeerID(lg, &fixedStorerLoader{}, ecid.NewPseudoRandom(rng),
+		params, "other curve", 0)
+	assert.Equal(t, ecid.ErrUnknownCurve, err)
+	assert.Nil(t, newID)
+	assert.Nil(t, rotation)
+}
+
+func TestLoadPeerIDRotation(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+
+	// check no rotation
+	rotation, err := loadPeerIDRotation(&fixedStorerLoader{}, peerID)
+	assert.Nil(t, err)
+	assert.Nil(t, rotation)
+
+	rotation, err = loadPeerIDRotation(&fixedStorerLoader{
+		loadErr: errors.New("some load error"),
+	}, peerID)
+	assert.NotNil(t, err)
+	assert.Nil(t, rotation)
+
+	rotation, err = loadPeerIDRotation(&fixedStorerLoader{
+		loadBytes: []byte("the wrong bytes"),
+	}, peerID)
+	assert.NotNil(t, err)
+	assert.Nil(t, rotation)
+}
+
+func TestLoadSessionPeerID_ok(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+	cert, err := client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+
+	peerID, err := loadSessionPeerID(clogging.NewDevInfoLogger(), sessionID, cert)
+	assert.Nil(t, err)
+	assert.Equal(t, identityID.ID(), peerID.ID())
+	assert.Nil(t, peerID.Key().D)
+}
+
+func TestLoadSessionPeerID_err(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	lg := clogging.NewDevInfoLogger()
+	identityID, sessionID := ecid.NewPseudoRandom(rng), ecid.NewPseudoRandom(rng)
+
+	// check missing certificate
+	peerID, err := loadSessionPeerID(lg, sessionID, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, peerID)
+
+	// check certificate for another session key
+	cert, err := client.NewSessionCertificate(identityID, ecid.NewPseudoRandom(rng), time.Hour)
+	assert.Nil(t, err)
+	peerID, err = loadSessionPeerID(lg, sessionID, cert)
+	assert.Equal(t, client.ErrSessionKeyMismatch, err)
+	assert.Nil(t, peerID)
+
+	// check expired certificate
+	cert, err = client.NewSessionCertificate(identityID, sessionID, -time.Hour)
+	assert.Nil(t, err)
+	peerID, err = loadSessionPeerID(lg, sessionID, cert)
+	assert.Equal(t, client.ErrSessionExpired, err)
+	assert.Nil(t, peerID)
+
+	// check bad identity public key
+	cert, err = client.NewSessionCertificate(identityID, sessionID, time.Hour)
+	assert.Nil(t, err)
+	cert.IdentityPubKey = []byte("bad identity pub key")
+	peerID, err = loadSessionPeerID(lg, sessionID, cert)
+	assert.NotNil(t, err)
+	assert.Nil(t, peerID)
+}
+
+func TestLoadSaveDenylist(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	kvdb, cleanup, err := db.NewTempDirRocksDB()
+	defer cleanup()
+	defer kvdb.Close()
+	assert.Nil(t, err)
+	nsl := storage.NewServerKVDBStorerLoader(kvdb)
+	lg := clogging.NewDevInfoLogger()
+	saved, configured := id.NewPseudoRandom(rng), id.NewPseudoRandom(rng)
+
+	// check only configured keys without saved denylist
+	dl1, err := loadDenylist(lg, nsl, []id.ID{configured})
+	assert.Nil(t, err)
+	assert.Equal(t, []id.ID{configured}, dl1.Keys())
+
+	// check saved and configured keys are both loaded
+	assert.Nil(t, saveDenylist(nsl, NewDenylist(saved)))
+	dl2, err := loadDenylist(lg, nsl, []id.ID{configured})
+	assert.Nil(t, err)
+	assert.True(t, dl2.Contains(saved))
+	assert.True(t, dl2.Contains(configured))
+}
+
+func TestLoadDenylist_err(t *testing.T) {
+	lg := clogging.NewDevInfoLogger()
+	dl, err := loadDenylist(lg, &fixedStorerLoader{loadErr: errors.New("some load error")}, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, dl)
+
+	dl, err = loadDenylist(lg, &fixedStorerLoader{loadBytes: []byte("the wrong bytes")}, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, dl)
+}
+
 func TestLoadOrCreateRoutingTable_ok(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 
@@ -68,14 +258,14 @@ func TestLoadOrCreateRoutingTable_ok(t *testing.T) {
 		loadBytes: bytes,
 	}
 	rt1, err := loadOrCreateRoutingTable(clogging.NewDevInfoLogger(), fullLoader, selfID1,
-		routing.NewDefaultParameters())
+		routing.NewDefaultParameters(), peer.NewFromer())
 	assert.Equal(t, selfID1, rt1.SelfID())
 	assert.Nil(t, err)
 
 	// create new RT
 	selfID2 := id.NewPseudoRandom(rng)
 	rt2, err := loadOrCreateRoutingTable(clogging.NewDevInfoLogger(), &fixedStorerLoader{}, selfID2,
-		routing.NewDefaultParameters())
+		routing.NewDefaultParameters(), peer.NewFromer())
 	assert.Equal(t, selfID2, rt2.SelfID())
 	assert.Nil(t, err)
 }
@@ -89,7 +279,7 @@ func TestLoadOrCreateRoutingTable_loadErr(t *testing.T) {
 	}
 
 	rt1, err := loadOrCreateRoutingTable(clogging.NewDevInfoLogger(), errLoader, selfID,
-		routing.NewDefaultParameters())
+		routing.NewDefaultParameters(), peer.NewFromer())
 	assert.Nil(t, rt1)
 	assert.NotNil(t, err)
 }
@@ -111,7 +301,7 @@ func TestLoadOrCreateRoutingTable_selfIDErr(t *testing.T) {
 	// error with conflicting/different selfID
 	selfID2 := id.NewPseudoRandom(rng)
 	rt1, err := loadOrCreateRoutingTable(clogging.NewDevInfoLogger(), fullLoader, selfID2,
-		routing.NewDefaultParameters())
+		routing.NewDefaultParameters(), peer.NewFromer())
 	assert.Nil(t, rt1)
 	assert.NotNil(t, err)
 }
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// InFlight deduplicates concurrent identical store operations, so they share a single underlying
+// operation and its result.
+type InFlight interface {
+	// Do executes the store operation for the given key unless one for the same key is already in
+	// flight, in which case it waits for and returns the result of that operation instead. The
+	// returned shared flag indicates whether the result came from another caller's operation.
+	// The operation runs independently of any caller, so each caller stops waiting for it once
+	// its context is done, without affecting the operation or the other callers.
+	Do(ctx context.Context, key string, operation func() (*Store, error)) (
+		s *Store, shared bool, err error)
+}
+
+type flight struct {
+	done    chan struct{}
+	store   *Store
+	err     error
+	nShared uint
+}
+
+type inFlight struct {
+	flights map[string]*flight
+	mu      sync.Mutex
+}
+
+// NewInFlight creates a new InFlight instance with no operations in flight.
+func NewInFlight() InFlight {
+	return &inFlight{
+		flights: make(map[string]*flight),
+	}
+}
+
+func (f *inFlight) Do(ctx context.Context, key string, operation func() (*Store, error)) (
+	*Store, bool, error) {
+	f.mu.Lock()
+	existing, shared := f.flights[key]
+	if shared {
+		existing.nShared++
+		f.mu.Unlock()
+		return wait(ctx, existing, true)
+	}
+	next := &flight{done: make(chan struct{})}
+	f.flights[key] = next
+	f.mu.Unlock()
+
+	go func() {
+		next.store, next.err = operation()
+
+		f.mu.Lock()
+		delete(f.flights, key)
+		f.mu.Unlock()
+		close(next.done)
+	}()
+	return wait(ctx, next, false)
+}
+
+// wait returns the flight's result once it's done or the context's error if that's done first.
+func wait(ctx context.Context, fl *flight, shared bool) (*Store, bool, error) {
+	select {
+	case <-fl.done:
+		return fl.store, shared, fl.err
+	case <-ctx.Done():
+		return nil, shared, ctx.Err()
+	}
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestInFlight_Do_shared(t *testing.T) {
+	f := NewInFlight()
+	release, started := make(chan struct{}), make(chan struct{})
+	expected := &Store{}
+	nCalls := int32(0)
+	operation := func() (*Store, error) {
+		atomic.AddInt32(&nCalls, 1)
+		close(started)
+		<-release
+		return expected, nil
+	}
+
+	// first operation blocks until released
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s, shared, err := f.Do(context.Background(), "key", operation)
+		assert.Nil(t, err)
+		assert.False(t, shared)
+		assert.Equal(t, expected, s)
+	}()
+	<-started
+
+	// concurrent callers for the same key wait on the first operation
+	nWaiters := 4
+	for c := 0; c < nWaiters; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, shared, err := f.Do(context.Background(), "key", operation)
+			assert.Nil(t, err)
+			assert.True(t, shared)
+			assert.Equal(t, expected, s)
+		}()
+	}
+
+	// wait for all waiters to join the in-flight operation before releasing it
+	for {
+		f.(*inFlight).mu.Lock()
+		nShared := f.(*inFlight).flights["key"].nShared
+		f.(*inFlight).mu.Unlock()
+		if nShared == uint(nWaiters) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&nCalls))
+	assert.Empty(t, f.(*inFlight).flights)
+}
+
+func TestInFlight_Do_sequential(t *testing.T) {
+	f := NewInFlight()
+	nCalls := 0
+	operation := func() (*Store, error) {
+		nCalls++
+		return &Store{}, nil
+	}
+
+	// sequential operations for the same key aren't shared
+	_, shared, err := f.Do(context.Background(), "key", operation)
+	assert.Nil(t, err)
+	assert.False(t, shared)
+	_, shared, err = f.Do(context.Background(), "key", operation)
+	assert.Nil(t, err)
+	assert.False(t, shared)
+	assert.Equal(t, 2, nCalls)
+}
+
+func TestInFlight_Do_err(t *testing.T) {
+	f := NewInFlight()
+	s, shared, err := f.Do(context.Background(), "key", func() (*Store, error) {
+		return nil, errors.New("some store error")
+	})
+	assert.NotNil(t, err)
+	assert.False(t, shared)
+	assert.Nil(t, s)
+	assert.Empty(t, f.(*inFlight).flights)
+}
+
+func TestInFlight_Do_ctxDone(t *testing.T) {
+	f := NewInFlight()
+	release, finished := make(chan struct{}), make(chan struct{})
+	expected := &Store{}
+	operation := func() (*Store, error) {
+		<-release
+		defer close(finished)
+		return expected, nil
+	}
+
+	// check first caller stops waiting once its context is done
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	cancel1()
+	s, shared, err := f.Do(ctx1, "key", operation)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, shared)
+	assert.Nil(t, s)
+
+	// check shared caller stops waiting once its context is done too
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel2()
+	s, shared, err = f.Do(ctx2, "key", operation)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, shared)
+	assert.Nil(t, s)
+
+	// check operation outlives its callers and another caller still gets its result
+	results := make(chan *Store)
+	go func() {
+		s, shared, err := f.Do(context.Background(), "key", operation)
+		assert.Nil(t, err)
+		assert.True(t, shared)
+		results <- s
+	}()
+	for {
+		f.(*inFlight).mu.Lock()
+		nShared := f.(*inFlight).flights["key"].nShared
+		f.(*inFlight).mu.Unlock()
+		if nShared == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	assert.Equal(t, expected, <-results)
+	<-finished
+}
@@ -17,6 +17,9 @@ var (
 	// DefaultNReplicas is the numeber of
 	DefaultNReplicas = uint(3)
 
+	// DefaultNMaxReplicas is the maximum number of replicas a Put request may ask for.
+	DefaultNMaxReplicas = uint(8)
+
 	// DefaultNMaxErrors is the maximum number of errors tolerated during a search.
 	DefaultNMaxErrors = uint(3)
 
@@ -25,6 +28,9 @@ var (
 
 	// DefaultQueryTimeout is the timeout for each query to a peer.
 	DefaultQueryTimeout = 5 * time.Second
+
+	// DefaultOpTimeout is the timeout for the entire store operation, including its search.
+	DefaultOpTimeout = 30 * time.Second
 )
 
 // Parameters defines the parameters of the store.
@@ -32,6 +38,13 @@ type Parameters struct {
 	// NReplicas is the number of replicas to store
 	NReplicas uint
 
+	// maximum number of replicas a request may ask to store
+	NMaxReplicas uint
+
+	// number of replicas that must have stored the value before the store returns, with the
+	// remaining replicas stored in the background; zero value waits for all NReplicas
+	Quorum uint
+
 	// maximum number of errors tolerated when querying peers during the store
 	NMaxErrors uint
 
@@ -40,16 +53,41 @@ type Parameters struct {
 
 	// timeout for queries to individual peers
 	Timeout time.Duration
+
+	// timeout for the entire store operation (including the search), after which it returns
+	// the result so far; zero value means no overall timeout
+	OpTimeout time.Duration
+
+	// number of leading zero bits the proof of work on Store requests must have, which should
+	// be the same for all peers in the network, since they both attach it to the stores they
+	// perform and require it of those they receive; zero value disables proof of work
+	ProofOfWorkBits uint
 }
 
 // NewDefaultParameters creates an instance with default parameters.
 func NewDefaultParameters() *Parameters {
 	return &Parameters{
-		NReplicas:   DefaultNReplicas,
-		NMaxErrors:  DefaultNMaxErrors,
-		Concurrency: DefaultConcurrency,
-		Timeout:     DefaultQueryTimeout,
+		NReplicas:    DefaultNReplicas,
+		NMaxReplicas: DefaultNMaxReplicas,
+		NMaxErrors:   DefaultNMaxErrors,
+		Concurrency:  DefaultConcurrency,
+		Timeout:      DefaultQueryTimeout,
+		OpTimeout:    DefaultOpTimeout,
+	}
+}
+
+// WithNReplicas returns a copy of the parameters with NReplicas set to the requested number of
+// replicas, capped by NMaxReplicas. A zero nReplicas leaves the NReplicas unchanged.
+func (p *Parameters) WithNReplicas(nReplicas uint) *Parameters {
+	updated := *p
+	if nReplicas == 0 {
+		return &updated
 	}
+	if p.NMaxReplicas != 0 && nReplicas > p.NMaxReplicas {
+		nReplicas = p.NMaxReplicas
+	}
+	updated.NReplicas = nReplicas
+	return &updated
 }
 
 // Result holds the store's (intermediate) result: the number of peers that have successfully
@@ -82,6 +120,15 @@ func NewInitialResult(sr *search.Result) *Result {
 	}
 }
 
+// NewDirectedResult creates a new Result object for a directed store to the given peers.
+func NewDirectedResult(peers []peer.Peer) *Result {
+	return &Result{
+		Unqueried: peers,
+		Responded: make([]peer.Peer, 0, len(peers)),
+		NErrors:   0,
+	}
+}
+
 // NewFatalResult creates a new Result object with a fatal error.
 func NewFatalResult(fatalErr error) *Result {
 	return &Result {
@@ -97,12 +144,22 @@ type Store struct {
 	// result of the store
 	Result *Result
 
-	// first part of store operation is the search
+	// first part of store operation is the search, or nil if the store is directed
 	Search *search.Search
 
 	// parameters defining the store part of the operation
 	Params *Parameters
 
+	// time after which the store is considered timed out, which is set when the store starts
+	// running, or zero value if it never is
+	deadline time.Time
+
+	// whether the store skips the search and stores directly in an explicit list of peers
+	directed bool
+
+	// closed when the quorum of replicas has been reached
+	quorum chan struct{}
+
 	// mutex used to synchronizes reads and writes to this instance
 	mu sync.Mutex
 }
@@ -119,21 +176,72 @@ func NewStore(
 	// closest peers found during search
 	updatedSearchParams := *searchParams  // by value to avoid change original search params
 	updatedSearchParams.NClosestResponses = storeParams.NReplicas + storeParams.NMaxErrors
+
+	// search shouldn't be able to take longer than the whole store
+	if storeParams.OpTimeout != 0 && (updatedSearchParams.OpTimeout == 0 ||
+		updatedSearchParams.OpTimeout > storeParams.OpTimeout) {
+		updatedSearchParams.OpTimeout = storeParams.OpTimeout
+	}
+	s := search.NewSearch(peerID, key, &updatedSearchParams)
+	if value.GetTombstone() != nil {
+		// tombstones replace the document stored under the same key, so finding that
+		// document shouldn't stop the search for the peers to store them in
+		s = search.NewPeersSearch(peerID, key, &updatedSearchParams)
+	}
 	return &Store{
-		Request: client.NewStoreRequest(peerID, key, value),
-		Search:  search.NewSearch(peerID, key, &updatedSearchParams),
+		Request: newStoreRequest(peerID, key, value, storeParams),
+		Search:  s,
 		Params:  storeParams,
 	}
 }
 
+// NewDirectedStore creates a new Store instance that skips the search and stores the value
+// directly in the peers given to the Storer.
+func NewDirectedStore(
+	peerID ecid.ID,
+	key id.ID,
+	value *api.Document,
+	storeParams *Parameters,
+) *Store {
+	return &Store{
+		Request:  newStoreRequest(peerID, key, value, storeParams),
+		Params:   storeParams,
+		directed: true,
+	}
+}
+
+// newStoreRequest creates a StoreRequest with a proof of work nonce if the parameters require it.
+func newStoreRequest(
+	peerID ecid.ID, key id.ID, value *api.Document, params *Parameters,
+) *api.StoreRequest {
+	rq := client.NewStoreRequest(peerID, key, value)
+	if params.ProofOfWorkBits > 0 {
+		rq.Metadata.ProofOfWorkNonce = api.FindProofOfWork(rq.Key, params.ProofOfWorkBits)
+	}
+	return rq
+}
+
+// Directed returns whether the store skips the search and stores directly in the given peers.
+func (s *Store) Directed() bool {
+	return s.directed
+}
+
 // Stored returns whether the store has stored sufficient replicas.
 func (s *Store) Stored() bool {
 	return uint(len(s.Result.Responded)) >= s.Params.NReplicas
 }
 
+// QuorumReached returns whether the store has stored a quorum of replicas smaller than NReplicas,
+// which is sufficient for the store to return while the remaining replicas are stored in the
+// background.
+func (s *Store) QuorumReached() bool {
+	q := s.Params.Quorum
+	return q > 0 && q < s.Params.NReplicas && uint(len(s.Result.Responded)) >= q
+}
+
 // Exists returns whether the value already exists (and the search has found it).
 func (s *Store) Exists() bool {
-	return s.Result.Search.Value != nil
+	return s.Result.Search != nil && s.Result.Search.Value != nil
 }
 
 // Errored returns whether the store has encountered too many errors when querying the peers.
@@ -146,11 +254,52 @@ func (s *Store) Exhausted() bool {
 	return len(s.Result.Unqueried) == 0
 }
 
+// TimedOut returns whether the store has exceeded its overall operation timeout.
+func (s *Store) TimedOut() bool {
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
 // Finished returns whether the store operation has finished.
 func (s *Store) Finished() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.Stored() || s.Errored() || s.Exists() || s.Exhausted()
+	return s.Stored() || s.Errored() || s.Exists() || s.Exhausted() || s.TimedOut()
+}
+
+// Snapshot returns a copy of the store with a copy of its current result, which is safe to read
+// while the remaining replicas are stored in the background after a quorum has been reached.
+func (s *Store) Snapshot() *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result *Result
+	if s.Result != nil {
+		copied := *s.Result
+		copied.Responded = append([]peer.Peer(nil), s.Result.Responded...)
+		copied.Unqueried = append([]peer.Peer(nil), s.Result.Unqueried...)
+		result = &copied
+	}
+	return &Store{
+		Request:  s.Request,
+		Result:   result,
+		Search:   s.Search,
+		Params:   s.Params,
+		deadline: s.deadline,
+		directed: s.directed,
+	}
+}
+
+// start sets the store deadline from its operation timeout as it starts running.
+func (s *Store) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadline = newDeadline(s.Params.OpTimeout)
+}
+
+func newDeadline(opTimeout time.Duration) time.Time {
+	if opTimeout == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(opTimeout)
 }
 
 func (s *Store) moreUnqueried() bool {
@@ -3,6 +3,7 @@ package store
 import (
 	"math/rand"
 	"testing"
+	"time"
 	"errors"
 	"github.com/drausin/libri/libri/common/ecid"
 	cid "github.com/drausin/libri/libri/common/id"
@@ -17,6 +18,88 @@ func TestNewDefaultParameters(t *testing.T) {
 	assert.NotZero(t, p.NMaxErrors)
 	assert.NotZero(t, p.Concurrency)
 	assert.NotZero(t, p.Timeout)
+	assert.NotZero(t, p.OpTimeout)
+	assert.True(t, p.NMaxReplicas >= p.NReplicas)
+}
+
+func TestParameters_WithNReplicas(t *testing.T) {
+	p := &Parameters{NReplicas: 3, NMaxReplicas: 8}
+
+	// zero value keeps default
+	assert.Equal(t, uint(3), p.WithNReplicas(0).NReplicas)
+
+	// requested value within bounds
+	assert.Equal(t, uint(5), p.WithNReplicas(5).NReplicas)
+
+	// requested value capped by maximum
+	assert.Equal(t, uint(8), p.WithNReplicas(10).NReplicas)
+
+	// no maximum means no cap
+	p2 := &Parameters{NReplicas: 3}
+	assert.Equal(t, uint(10), p2.WithNReplicas(10).NReplicas)
+
+	// original unchanged
+	assert.Equal(t, uint(3), p.NReplicas)
+}
+
+func TestNewDirectedStore(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	peers := peer.NewTestPeers(rng, 3)
+
+	store := NewDirectedStore(peerID, key, value, &Parameters{NReplicas: 3, NMaxErrors: 3})
+	assert.True(t, store.Directed())
+	assert.Nil(t, store.Search)
+	assert.Equal(t, key.Bytes(), store.Request.Key)
+
+	// directed store never has an existing value from a search
+	store.Result = NewDirectedResult(peers)
+	assert.Equal(t, peers, store.Result.Unqueried)
+	assert.False(t, store.Exists())
+	assert.False(t, store.Finished())
+
+	// non-directed store
+	store = NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{})
+	assert.False(t, store.Directed())
+}
+
+func TestNewStore_proofOfWork(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	params := &Parameters{NReplicas: 3, ProofOfWorkBits: 8}
+
+	stores := []*Store{
+		NewStore(peerID, key, value, &ssearch.Parameters{}, params),
+		NewDirectedStore(peerID, key, value, params),
+	}
+	for _, store := range stores {
+		nonce := store.Request.Metadata.ProofOfWorkNonce
+		assert.Nil(t, api.CheckProofOfWork(key.Bytes(), nonce, params.ProofOfWorkBits))
+	}
+
+	// check no proof of work when not required
+	store := NewDirectedStore(peerID, key, value, &Parameters{NReplicas: 3})
+	assert.Zero(t, store.Request.Metadata.ProofOfWorkNonce)
+}
+
+func TestNewStore_tombstone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+
+	// check stores of documents search for their value
+	store := NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{})
+	assert.False(t, store.Search.PeersOnly())
+
+	// check stores of tombstones search only for peers, since they replace the document
+	tombstone := api.NewTestTombstone(rng)
+	tombstone.DocumentKey = key.Bytes()
+	tombstoneDoc := &api.Document{Contents: &api.Document_Tombstone{Tombstone: tombstone}}
+	store = NewStore(peerID, key, tombstoneDoc, &ssearch.Parameters{}, &Parameters{})
+	assert.True(t, store.Search.PeersOnly())
+	assert.True(t, store.Search.Request.PeersOnly)
 }
 
 func TestStore_Stored(t *testing.T) {
@@ -47,6 +130,57 @@ func TestStore_Stored(t *testing.T) {
 	assert.True(t, store.Finished())
 }
 
+func TestStore_QuorumReached(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	peers := peer.NewTestPeers(rng, 3)
+
+	cases := []struct {
+		quorum     uint
+		nResponded int
+		expected   bool
+	}{
+		{0, 3, false}, // no quorum configured
+		{2, 1, false}, // quorum not reached yet
+		{2, 2, true},  // quorum reached
+		{3, 3, false}, // quorum not less than NReplicas
+	}
+	for _, c := range cases {
+		store := NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{
+			NReplicas: 3,
+			Quorum:    c.quorum,
+		})
+		store.Result = NewInitialResult(store.Search.Result)
+		store.Result.Responded = peers[:c.nResponded]
+		assert.Equal(t, c.expected, store.QuorumReached())
+	}
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	peers := peer.NewTestPeers(rng, 3)
+
+	store := NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{NReplicas: 3})
+	store.Result = NewInitialResult(store.Search.Result)
+	store.Result.Responded = peers[:1]
+	store.Result.Unqueried = peers[1:]
+
+	snapshot := store.Snapshot()
+	assert.Equal(t, store.Result.Responded, snapshot.Result.Responded)
+	assert.Equal(t, store.Result.Unqueried, snapshot.Result.Unqueried)
+	assert.Equal(t, store.Params, snapshot.Params)
+	assert.Equal(t, store.Search, snapshot.Search)
+
+	// check subsequent changes to the original don't affect the snapshot
+	store.Result.Responded = append(store.Result.Responded, peers[1])
+	store.Result.NErrors++
+	assert.Equal(t, 1, len(snapshot.Result.Responded))
+	assert.Zero(t, snapshot.Result.NErrors)
+}
+
 func TestStore_Errored(t *testing.T) {
 	rng := rand.New(rand.NewSource(0))
 	peerID, key := ecid.NewPseudoRandom(rng), cid.NewPseudoRandom(rng)
@@ -93,3 +227,52 @@ func TestStore_Errored(t *testing.T) {
 	assert.True(t, s.Errored())
 	assert.True(t, s.Finished())
 }
+
+func TestNewStore_opTimeout(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+
+	// search OpTimeout is capped by the store OpTimeout
+	searchParams := &ssearch.Parameters{OpTimeout: time.Minute}
+	store := NewStore(peerID, key, value, searchParams, &Parameters{OpTimeout: time.Second})
+	assert.Equal(t, time.Second, store.Search.Params.OpTimeout)
+	assert.Equal(t, time.Minute, searchParams.OpTimeout) // original unchanged
+
+	// search w/o OpTimeout gets the store's
+	store = NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{
+		OpTimeout: time.Second,
+	})
+	assert.Equal(t, time.Second, store.Search.Params.OpTimeout)
+
+	// shorter search OpTimeout is left as is
+	store = NewStore(peerID, key, value, &ssearch.Parameters{OpTimeout: time.Millisecond},
+		&Parameters{OpTimeout: time.Second})
+	assert.Equal(t, time.Millisecond, store.Search.Params.OpTimeout)
+}
+
+func TestStore_TimedOut(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	peerID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+
+	// never times out w/o OpTimeout
+	store := NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{})
+	assert.False(t, store.TimedOut())
+
+	store = NewStore(peerID, key, value, &ssearch.Parameters{}, &Parameters{
+		NReplicas:  3,
+		NMaxErrors: 3,
+		OpTimeout:  time.Millisecond,
+	})
+	store.Result = NewInitialResult(store.Search.Result)
+	store.Result.Unqueried = []peer.Peer{nil} // just needs to be non-zero length
+	time.Sleep(2 * time.Millisecond)
+	assert.False(t, store.TimedOut()) // deadline only starts when store does
+
+	store.start()
+	time.Sleep(2 * time.Millisecond)
+	assert.True(t, store.TimedOut())
+	assert.True(t, store.Finished())
+	assert.False(t, store.Stored())
+}
@@ -9,12 +9,17 @@ import (
 	"github.com/drausin/libri/libri/librarian/client"
 	"github.com/drausin/libri/libri/librarian/server/peer"
 	"github.com/drausin/libri/libri/librarian/server/search"
+	"golang.org/x/net/context"
 )
 
 // Storer executes store operations.
 type Storer interface {
-	// Store executes a store operation, starting with a given set of seed peers.
-	Store(store *Store, seeds []peer.Peer) error
+	// Store executes a store operation, starting with a given set of seed peers. If the store
+	// is directed, the value is stored directly in the seed peers without a search. If the
+	// store has a quorum, Store may return once it's reached, in which case the store's result
+	// should be read via Snapshot() while the remaining replicas are stored in the background.
+	// Cancelling the context before then stops any remaining queries to peers.
+	Store(ctx context.Context, store *Store, seeds []peer.Peer) error
 }
 
 type storer struct {
@@ -39,7 +44,7 @@ func NewStorer(signer client.Signer, searcher search.Searcher, q client.StoreQue
 
 // NewDefaultStorer creates a new Storer with default Searcher and StoreQuerier instances.
 func NewDefaultStorer(peerID ecid.ID) Storer {
-	signer := client.NewSigner(peerID.Key())
+	signer := client.NewIDSigner(peerID)
 	return NewStorer(
 		signer,
 		search.NewDefaultSearcher(signer),
@@ -47,28 +52,74 @@ func NewDefaultStorer(peerID ecid.ID) Storer {
 	)
 }
 
-func (s *storer) Store(store *Store, seeds []peer.Peer) error {
-	if err := s.searcher.Search(store.Search, seeds); err != nil {
-		store.Result = NewFatalResult(err)
-		return err
+func (s *storer) Store(ctx context.Context, store *Store, seeds []peer.Peer) error {
+	store.start()
+	if store.Directed() {
+		store.Result = NewDirectedResult(seeds)
+	} else {
+		if err := s.searcher.Search(ctx, store.Search, seeds); err != nil {
+			store.Result = NewFatalResult(err)
+			return err
+		}
+		store.Result = NewInitialResult(store.Search.Result)
 	}
-	store.Result = NewInitialResult(store.Search.Result)
 
+	store.quorum = make(chan struct{})
+
+	// workers are detached from the upstream context so that, once a quorum has been reached,
+	// the remaining replicas can be stored in the background after the upstream has returned
+	workCtx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
 	for c := uint(0); c < store.Params.Concurrency; c++ {
 		wg.Add(1)
-		go s.storeWork(store, &wg)
+		go s.storeWork(workCtx, store, &wg)
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(workersDone)
+	}()
+	go func() {
+		defer cancel()
+		select {
+		case <-workersDone:
+		case <-ctx.Done():
+			select {
+			case <-store.quorum:
+				// quorum already reached, so let remaining workers finish
+				<-workersDone
+			default:
+				store.wrapLock(func() {
+					if store.Result.FatalErr == nil {
+						store.Result.FatalErr = ctx.Err()
+					}
+				})
+			}
+		}
+	}()
+
+	// return either when all the workers have finished or, if a quorum is configured, as soon
+	// as the quorum has been reached, leaving the remaining workers to finish in the background
+	select {
+	case <-workersDone:
+	case <-store.quorum:
 	}
-	wg.Wait()
 
+	store.mu.Lock()
+	defer store.mu.Unlock()
 	return store.Result.FatalErr
 }
 
-func (s *storer) storeWork(store *Store, wg *sync.WaitGroup) {
+func (s *storer) storeWork(ctx context.Context, store *Store, wg *sync.WaitGroup) {
 	defer wg.Done()
 	// work is finished when either the store is finished or we have no more unqueried peers
 	// (but the final, remaining queried peers may not have responded yet)
 	for !store.Finished() && store.safeMoreUnqueried() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
 
 		// get next peer to query
 		store.mu.Lock()
@@ -83,7 +134,7 @@ func (s *storer) storeWork(store *Store, wg *sync.WaitGroup) {
 		store.mu.Unlock()
 
 		// do the query
-		if _, err := s.query(next.Connector(), store); err != nil {
+		if _, err := s.query(ctx, next.Connector(), store); err != nil {
 			// if we had an issue querying, skip to next peer
 			store.wrapLock(func() {
 				store.Result.NErrors++
@@ -96,13 +147,21 @@ func (s *storer) storeWork(store *Store, wg *sync.WaitGroup) {
 		// add to slice of responded peers
 		store.wrapLock(func() {
 			store.Result.Responded = append(store.Result.Responded, next)
+			if store.QuorumReached() {
+				select {
+				case <-store.quorum: // already closed
+				default:
+					close(store.quorum)
+				}
+			}
 		})
 	}
 }
 
-func (s *storer) query(pConn api.Connector, store *Store) (*api.StoreResponse, error) {
-	ctx, cancel, err := client.NewSignedTimeoutContext(s.signer, store.Request,
-		store.Params.Timeout)
+func (s *storer) query(ctx context.Context, pConn api.Connector, store *Store) (
+	*api.StoreResponse, error) {
+	ctx, cancel, err := client.NewSignedTimeoutContextFrom(ctx, s.signer, store.Request,
+		search.QueryTimeout(store.Params.Timeout, store.deadline))
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package store
 import (
 	"math/rand"
 	"testing"
+	"time"
 
 	"errors"
 
@@ -80,7 +81,7 @@ func TestStorer_Store_ok(t *testing.T) {
 		}
 
 		// do the search!
-		err := storer.Store(store, seeds)
+		err := storer.Store(context.Background(), store, seeds)
 
 		// checks
 		assert.Nil(t, err)
@@ -95,11 +96,134 @@ func TestStorer_Store_ok(t *testing.T) {
 	}
 }
 
+func TestStorer_Store_directed(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	peers := peer.NewTestPeers(rng, 4)
+	s := &storer{
+		searcher: &errSearcher{}, // shouldn't be used
+		querier:  &TestStoreQuerier{peerID: selfID},
+		signer:   &client.TestNoOpSigner{},
+	}
+	store := NewDirectedStore(selfID, key, value, &Parameters{
+		NReplicas:   uint(len(peers)),
+		NMaxErrors:  DefaultNMaxErrors,
+		Concurrency: 2,
+		Timeout:     DefaultQueryTimeout,
+	})
+
+	err := s.Store(context.Background(), store, peers)
+	assert.Nil(t, err)
+	assert.True(t, store.Stored())
+	assert.True(t, store.Finished())
+	assert.False(t, store.Errored())
+	assert.Nil(t, store.Result.Search)
+	assert.Equal(t, len(peers), len(store.Result.Responded))
+	assert.Equal(t, 0, len(store.Result.Unqueried))
+}
+
+// blockingStoreQuerier blocks Store queries to the given addresses until released.
+type blockingStoreQuerier struct {
+	peerID  ecid.ID
+	blocked map[string]struct{}
+	release chan struct{}
+}
+
+func (c *blockingStoreQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.StoreRequest, opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	if _, in := c.blocked[pConn.Address().String()]; in {
+		<-c.release
+	}
+	return &api.StoreResponse{
+		Metadata: &api.ResponseMetadata{
+			RequestId: rq.Metadata.RequestId,
+			PubKey:    ecid.ToPublicKeyBytes(c.peerID),
+		},
+	}, nil
+}
+
+func TestStorer_Store_quorum(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	peers := peer.NewTestPeers(rng, 3)
+	querier := &blockingStoreQuerier{
+		peerID:  selfID,
+		blocked: map[string]struct{}{peers[2].Connector().Address().String(): {}},
+		release: make(chan struct{}),
+	}
+	s := &storer{querier: querier, signer: &client.TestNoOpSigner{}}
+	store := NewDirectedStore(selfID, key, value, &Parameters{
+		NReplicas:   3,
+		Quorum:      2,
+		NMaxErrors:  DefaultNMaxErrors,
+		Concurrency: 3,
+		Timeout:     DefaultQueryTimeout,
+	})
+
+	// check Store returns after quorum even though last query hasn't returned yet
+	ctx, cancel := context.WithCancel(context.Background())
+	err := s.Store(ctx, store, peers)
+	assert.Nil(t, err)
+	snapshot := store.Snapshot()
+	assert.True(t, snapshot.QuorumReached())
+	assert.False(t, snapshot.Stored())
+	assert.Equal(t, 2, len(snapshot.Result.Responded))
+
+	// check remaining replica is stored in background, even after upstream context is done
+	cancel()
+	close(querier.release)
+	for i := 0; i < 100 && !store.Snapshot().Stored(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, store.Snapshot().Stored())
+}
+
+// doneStoreQuerier blocks Store queries until the request context is done.
+type doneStoreQuerier struct{}
+
+func (c *doneStoreQuerier) Query(ctx context.Context, pConn api.Connector,
+	rq *api.StoreRequest, opts ...grpc.CallOption) (*api.StoreResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestStorer_Store_cancelled(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	selfID := ecid.NewPseudoRandom(rng)
+	value, key := api.NewTestDocument(rng)
+	peers := peer.NewTestPeers(rng, 8)
+	s := &storer{querier: &doneStoreQuerier{}, signer: &client.TestNoOpSigner{}}
+	store := NewDirectedStore(selfID, key, value, &Parameters{
+		NReplicas:   3,
+		NMaxErrors:  uint(len(peers)),
+		Concurrency: 3,
+		Timeout:     DefaultQueryTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(25 * time.Millisecond)
+		cancel()
+	}()
+
+	// check Store returns as soon as context is cancelled rather than after query timeouts
+	start := time.Now()
+	err := s.Store(ctx, store, peers)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(start) < store.Params.Timeout)
+	assert.True(t, store.Snapshot().Errored())
+	assert.Zero(t, len(store.Snapshot().Result.Responded))
+	assert.True(t, len(store.Snapshot().Result.Unqueried) > 0)
+}
+
 type fixedSearcher struct {
 	fixed *ssearch.Result
 }
 
-func (s *fixedSearcher) Search(search *ssearch.Search, seeds []peer.Peer) error {
+func (s *fixedSearcher) Search(ctx context.Context, search *ssearch.Search,
+	seeds []peer.Peer) error {
 	search.Result = s.fixed
 	return nil
 }
@@ -112,7 +236,7 @@ func TestStorer_Store_queryErr(t *testing.T) {
 	storerImpl.(*storer).querier = &timeoutQuerier{}
 
 	// do the search!
-	err := storerImpl.Store(store, seeds)
+	err := storerImpl.Store(context.Background(), store, seeds)
 
 	// checks
 	assert.Nil(t, err)
@@ -154,7 +278,8 @@ func newTestStore() (Storer, *Store, []int, []peer.Peer, cid.ID) {
 
 type errSearcher struct{}
 
-func (es *errSearcher) Search(search *ssearch.Search, seeds []peer.Peer) error {
+func (es *errSearcher) Search(ctx context.Context, search *ssearch.Search,
+	seeds []peer.Peer) error {
 	return errors.New("some search error")
 }
 
@@ -166,8 +291,9 @@ func TestStorer_Store_err(t *testing.T) {
 	// check that Store() surfaces searcher error
 	store := &Store{
 		Result: &Result{},
+		Params: &Parameters{},
 	}
-	assert.NotNil(t, s.Store(store, nil))
+	assert.NotNil(t, s.Store(context.Background(), store, nil))
 }
 
 // timeoutQuerier returns an error simulating a request timeout
@@ -207,7 +333,7 @@ func TestStorer_query_err(t *testing.T) {
 		// use querier that simulates a timeout
 		querier: &timeoutQuerier{},
 	}
-	rp1, err := s1.query(clientConn, store)
+	rp1, err := s1.query(context.Background(), clientConn, store)
 	assert.Nil(t, rp1)
 	assert.NotNil(t, err)
 
@@ -219,7 +345,7 @@ func TestStorer_query_err(t *testing.T) {
 			peerID: selfID,
 		},
 	}
-	rp2, err := s2.query(clientConn, store)
+	rp2, err := s2.query(context.Background(), clientConn, store)
 	assert.Nil(t, rp2)
 	assert.NotNil(t, err)
 
@@ -227,7 +353,7 @@ func TestStorer_query_err(t *testing.T) {
 		// use signer that returns an error
 		signer: &client.TestErrSigner{},
 	}
-	rp3, err := s3.query(clientConn, store)
+	rp3, err := s3.query(context.Background(), clientConn, store)
 	assert.Nil(t, rp3)
 	assert.NotNil(t, err)
 }
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+
+	"github.com/drausin/libri/libri/librarian/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	// ErrMissingTLSKeyPair indicates when only one of the TLS certificate and key files is
+	// configured.
+	ErrMissingTLSKeyPair = errors.New("TLS certificate and key files must both be set")
+
+	// ErrClientCAWithoutTLS indicates when a TLS client CA file is configured without a TLS
+	// certificate and key.
+	ErrClientCAWithoutTLS = errors.New("TLS client CA file set without certificate and key")
+
+	// ErrInvalidClientCA indicates when the TLS client CA file doesn't contain any PEM
+	// certificates.
+	ErrInvalidClientCA = errors.New("TLS client CA file has no valid PEM certificates")
+)
+
+// serverOptions returns the options to create the grpc.Server with, which use TLS credentials
+// when a certificate and key are configured. It returns no options when neither is.
+func (c *Config) serverOptions() ([]grpc.ServerOption, error) {
+	if c.TLSCertFile == "" && c.TLSKeyFile == "" {
+		if c.TLSClientCAFile != "" {
+			return nil, ErrClientCAWithoutTLS
+		}
+		return nil, nil
+	}
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+		return nil, ErrMissingTLSKeyPair
+	}
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsConfig))}, nil
+}
+
+// tlsConfig loads the server's certificate and key and, if configured, the CA certificates
+// client certificates must be signed by.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if c.TLSClientCAFile == "" {
+		return tlsConfig, nil
+	}
+	caPEM, err := ioutil.ReadFile(c.TLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, ErrInvalidClientCA
+	}
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// clientTLSConfig returns the TLS config for dialing peers, which presents the server's own
+// certificate when configured. It returns nil, dialing insecurely, when neither the server's
+// certificate nor a peer CA file is configured.
+func (c *Config) clientTLSConfig() (*tls.Config, error) {
+	if c.TLSCertFile == "" && c.PeerTLSCAFile == "" {
+		return nil, nil
+	}
+	tlsConfig, err := api.NewClientTLSConfig(c.PeerTLSCAFile, c.PeerTLSServerName)
+	if err != nil {
+		return nil, err
+	}
+	if c.TLSCertFile == "" {
+		return tlsConfig, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return tlsConfig, nil
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_serverOptions_ok(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-tls")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	certFile, keyFile := writeTestKeyPair(t, dir)
+
+	// check plaintext has no options
+	opts, err := (&Config{}).serverOptions()
+	assert.Nil(t, err)
+	assert.Empty(t, opts)
+
+	// check TLS with and without client CA
+	for _, clientCAFile := range []string{"", certFile} {
+		c := (&Config{}).WithTLS(certFile, keyFile, clientCAFile)
+		opts, err = c.serverOptions()
+		assert.Nil(t, err)
+		assert.Len(t, opts, 1)
+	}
+
+	tlsConfig, err := (&Config{}).WithTLS(certFile, keyFile, "").tlsConfig()
+	assert.Nil(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.Nil(t, tlsConfig.ClientCAs)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+
+	tlsConfig, err = (&Config{}).WithTLS(certFile, keyFile, certFile).tlsConfig()
+	assert.Nil(t, err)
+	assert.NotNil(t, tlsConfig.ClientCAs)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+}
+
+func TestConfig_serverOptions_err(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-tls")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	certFile, keyFile := writeTestKeyPair(t, dir)
+	notPEMFile := filepath.Join(dir, "not-pem.txt")
+	assert.Nil(t, ioutil.WriteFile(notPEMFile, []byte("not PEM"), 0600))
+	missingFile := filepath.Join(dir, "missing.pem")
+
+	cases := map[string]struct {
+		c        *Config
+		expected error
+	}{
+		"missing key": {
+			c:        (&Config{}).WithTLS(certFile, "", ""),
+			expected: ErrMissingTLSKeyPair,
+		},
+		"missing cert": {
+			c:        (&Config{}).WithTLS("", keyFile, ""),
+			expected: ErrMissingTLSKeyPair,
+		},
+		"client CA without TLS": {
+			c:        (&Config{}).WithTLS("", "", certFile),
+			expected: ErrClientCAWithoutTLS,
+		},
+		"invalid client CA": {
+			c:        (&Config{}).WithTLS(certFile, keyFile, notPEMFile),
+			expected: ErrInvalidClientCA,
+		},
+	}
+	for info, c := range cases {
+		opts, err := c.c.serverOptions()
+		assert.Equal(t, c.expected, err, info)
+		assert.Nil(t, opts, info)
+	}
+
+	// check file errors bubble up
+	for _, c := range []*Config{
+		(&Config{}).WithTLS(missingFile, keyFile, ""),
+		(&Config{}).WithTLS(certFile, notPEMFile, ""),
+		(&Config{}).WithTLS(certFile, keyFile, missingFile),
+	} {
+		opts, err := c.serverOptions()
+		assert.NotNil(t, err)
+		assert.Nil(t, opts)
+	}
+}
+
+func TestConfig_clientTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-tls")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+	certFile, keyFile := writeTestKeyPair(t, dir)
+
+	// check plaintext dials insecurely
+	tlsConfig, err := (&Config{}).clientTLSConfig()
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+
+	// check peer CA without server TLS dials without a client certificate
+	tlsConfig, err = (&Config{}).WithPeerTLS(certFile, "localhost").clientTLSConfig()
+	assert.Nil(t, err)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Equal(t, "localhost", tlsConfig.ServerName)
+	assert.Empty(t, tlsConfig.Certificates)
+
+	// check server TLS dials with its certificate as the client certificate
+	tlsConfig, err = (&Config{}).WithTLS(certFile, keyFile, "").clientTLSConfig()
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	// check file errors bubble up
+	missingFile := filepath.Join(dir, "missing.pem")
+	for _, c := range []*Config{
+		(&Config{}).WithPeerTLS(missingFile, ""),
+		(&Config{}).WithTLS(certFile, missingFile, ""),
+	} {
+		tlsConfig, err = c.clientTLSConfig()
+		assert.NotNil(t, err)
+		assert.Nil(t, tlsConfig)
+	}
+}
+
+// writeTestKeyPair writes a self-signed certificate and its key to PEM files in the directory
+// and returns their paths.
+func writeTestKeyPair(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	assert.Nil(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+	return certFile, keyFile
+}